@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/audit"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/backup"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/logging"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/repo"
+	"github.com/wuyiadepoju/subscription-management/internal/bootstrap"
+	"github.com/wuyiadepoju/subscription-management/internal/config"
+	"github.com/wuyiadepoju/subscription-management/internal/lifecycle"
+	"github.com/wuyiadepoju/subscription-management/internal/telemetry"
+)
+
+func main() {
+	loader := config.NewLoader(flag.CommandLine, config.SectionSpanner|config.SectionTelemetry, config.Default())
+	var (
+		output      = flag.String("output", "", "Append JSON lines to this file instead of writing to stdout")
+		cursorFile  = flag.String("cursor-file", "", "File holding the last exported entry; read to resume and updated after the export")
+		since       = flag.Duration("since", 24*time.Hour, "How far back the first export reaches when there is no cursor")
+		batch       = flag.Int("batch", 500, "Entries read per query")
+		location    = flag.String("location", "", "Write a partitioned export for BigQuery here instead: gs://bucket/prefix or a local directory")
+		parallelism = flag.Int("parallelism", 8, "Partitions exported at once with -location")
+	)
+	flag.Parse()
+
+	cfg, err := loader.Load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if *output != "" && *location != "" {
+		fmt.Fprintln(os.Stderr, "-output and -location can't be used together")
+		os.Exit(2)
+	}
+	logger, err := logging.New(os.Stderr, cfg.Log.Level, cfg.Log.Format)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	app := lifecycle.New(logger, cfg.ShutdownTimeout)
+
+	tracer, err := telemetry.NewTracer(app, cfg, "audit-export", logger)
+	if err != nil {
+		app.Fatal("failed to configure tracing", err)
+	}
+
+	client, err := bootstrap.Spanner(app, cfg, logger)
+	if err != nil {
+		app.Fatal("failed to open Spanner", err)
+	}
+
+	hints, err := repo.ParseQueryHints(cfg.Spanner.QueryHints)
+	if err != nil {
+		app.Fatal("invalid query hints", err)
+	}
+
+	priority, err := repo.ParsePriority(cfg.Spanner.Priority, repo.PriorityBulk)
+	if err != nil {
+		app.Fatal("invalid Spanner priority", err)
+	}
+
+	from, err := readCursor(*cursorFile)
+	if err != nil {
+		app.Fatal("failed to read cursor", err)
+	}
+	if from.OccurredAt.IsZero() {
+		from.OccurredAt = time.Now().Add(-*since)
+	}
+
+	auditRepo := repo.NewAuditRepo(client, repo.WithTimeout(cfg.Spanner.Timeout), repo.WithTracer(tracer), repo.WithLogger(logger), repo.WithQueryHints(hints), repo.WithPriority(priority))
+
+	var store backup.Store
+	if *location != "" {
+		if store, err = backup.OpenStore(app.Context(), *location); err != nil {
+			app.Fatal("failed to open export location", err)
+		}
+	}
+
+	app.Go("audit export", func(ctx context.Context) error {
+		if store != nil {
+			// The cursor is saved only once every partition is written; a failed
+			// export writes no manifest and is simply run again
+			manifest, err := audit.ExportPartitioned(ctx, auditRepo, store, from, *parallelism)
+			if err != nil {
+				return err
+			}
+			if err := writeCursor(*cursorFile, manifest.Cursor); err != nil {
+				return err
+			}
+			logger.Info("partitioned audit export complete",
+				slog.String("location", *location),
+				slog.Time("read_timestamp", manifest.ReadTimestamp),
+				slog.Int("files", len(manifest.Files)),
+				slog.Int64("rows", manifest.Rows),
+				slog.Time("cursor_occurred_at", manifest.Cursor.OccurredAt),
+				slog.String("cursor_id", manifest.Cursor.ID),
+			)
+			return nil
+		}
+
+		var out io.Writer = os.Stdout
+		if *output != "" {
+			f, err := os.OpenFile(*output, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+			if err != nil {
+				return fmt.Errorf("failed to open output: %w", err)
+			}
+			defer f.Close()
+			out = f
+		}
+
+		cursor, exportErr := audit.Export(ctx, auditRepo, out, from, *batch)
+		// Save the cursor even after a failure, so what was written isn't exported twice
+		if err := writeCursor(*cursorFile, cursor); err != nil {
+			return errors.Join(exportErr, err)
+		}
+		if exportErr != nil {
+			return exportErr
+		}
+
+		logger.Info("audit export complete",
+			slog.Time("cursor_occurred_at", cursor.OccurredAt),
+			slog.String("cursor_id", cursor.ID),
+		)
+		return nil
+	})
+
+	if err := app.Wait(); err != nil {
+		os.Exit(1)
+	}
+}
+
+// readCursor loads the cursor saved at path; a missing file or empty path is a fresh start
+func readCursor(path string) (audit.Cursor, error) {
+	var cursor audit.Cursor
+	if path == "" {
+		return cursor, nil
+	}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cursor, nil
+	}
+	if err != nil {
+		return cursor, err
+	}
+	if err := json.Unmarshal(b, &cursor); err != nil {
+		return cursor, fmt.Errorf("invalid cursor file %s: %w", path, err)
+	}
+	return cursor, nil
+}
+
+// writeCursor saves the cursor to path, replacing the file atomically
+func writeCursor(path string, cursor audit.Cursor) error {
+	if path == "" {
+		return nil
+	}
+	b, err := json.Marshal(cursor)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o600); err != nil {
+		return fmt.Errorf("failed to write cursor: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
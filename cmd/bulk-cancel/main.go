@@ -0,0 +1,221 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/user"
+	"strings"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/adapters"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/audit"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/bus"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/logging"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/repo"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/cancel_subscription"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/instrument"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/workpool"
+	"github.com/wuyiadepoju/subscription-management/internal/bootstrap"
+	"github.com/wuyiadepoju/subscription-management/internal/config"
+	"github.com/wuyiadepoju/subscription-management/internal/lifecycle"
+	"github.com/wuyiadepoju/subscription-management/internal/telemetry"
+)
+
+func main() {
+	loader := config.NewLoader(flag.CommandLine, config.SectionSpanner|config.SectionRenewal|config.SectionDiscounts|config.SectionTelemetry|config.SectionEvents, config.Default())
+	defaults := cancel_subscription.DefaultBulkConfig()
+	var (
+		customer    = flag.String("customer", "", "Cancel every subscription of this customer that isn't cancelled yet")
+		plan        = flag.String("plan", "", "Cancel every subscription of this plan that isn't cancelled yet, such as when retiring it")
+		idsFile     = flag.String("ids", "", "File listing subscription IDs to cancel, one per line; - reads standard input")
+		reason      = flag.String("reason", "", "Cancellation reason code recorded on every subscription, such as plan_retired")
+		details     = flag.String("reason-details", "", "Free text recorded with -reason")
+		batchSize   = flag.Int("batch-size", defaults.BatchSize, fmt.Sprintf("Subscriptions read and committed together (at most %d)", cancel_subscription.MaxBulkBatchSize))
+		concurrency = flag.Int("concurrency", defaults.Concurrency, "Batches in flight")
+	)
+	flag.Parse()
+
+	cfg, err := loader.Load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if *customer == "" && *plan == "" && *idsFile == "" {
+		fmt.Fprintln(os.Stderr, "one of -customer, -plan or -ids is required")
+		os.Exit(2)
+	}
+	if *batchSize < 1 || *batchSize > cancel_subscription.MaxBulkBatchSize {
+		fmt.Fprintf(os.Stderr, "-batch-size must be between 1 and %d\n", cancel_subscription.MaxBulkBatchSize)
+		os.Exit(2)
+	}
+	logger, err := logging.New(os.Stdout, cfg.Log.Level, cfg.Log.Format)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	req := cancel_subscription.BulkRequest{
+		CustomerID: *customer,
+		PlanID:     *plan,
+		Reason:     domain.CancellationReason{Code: domain.CancellationReasonCode(*reason), Details: *details},
+	}
+	if err := req.Reason.Validate(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if *idsFile != "" {
+		if req.SubscriptionIDs, err = readIDs(*idsFile); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+	}
+
+	app := lifecycle.New(logger, cfg.ShutdownTimeout)
+
+	tracer, err := telemetry.NewTracer(app, cfg, "bulk-cancel", logger)
+	if err != nil {
+		app.Fatal("failed to configure tracing", err)
+	}
+
+	client, err := bootstrap.Spanner(app, cfg, logger)
+	if err != nil {
+		app.Fatal("failed to open Spanner", err)
+	}
+
+	hints, err := repo.ParseQueryHints(cfg.Spanner.QueryHints)
+	if err != nil {
+		app.Fatal("invalid query hints", err)
+	}
+
+	priority, err := repo.ParsePriority(cfg.Spanner.Priority, repo.PriorityBulk)
+	if err != nil {
+		app.Fatal("invalid Spanner priority", err)
+	}
+
+	subscriptionRepo := repo.NewSubscriptionRepo(client, repo.WithTimeout(cfg.Spanner.Timeout), repo.WithTracer(tracer), repo.WithLogger(logger), repo.WithQueryHints(hints), repo.WithPriority(priority))
+	clock := domain.RealClock{}
+	pricing := adapters.BundlePricing{
+		Bundles: repo.NewBundleRepo(client, repo.WithTimeout(cfg.Spanner.Timeout), repo.WithTracer(tracer), repo.WithLogger(logger), repo.WithPriority(priority)),
+		Base:    adapters.StaticPricing{Pricing: domain.Pricing{Policy: domain.DiscountPolicy{MaxStacked: int(cfg.Discounts.MaxStacked), MaxPercentOff: cfg.Discounts.MaxPercentOff}}},
+	}
+	// Deployments register their lifecycle hooks here, e.g. a CRM sync
+	hooks := adapters.HookChain{}
+	events, err := adapters.NewEventPublisher(app.Context(), adapters.EventsConfig{Topic: cfg.Events.Topic, Timeout: cfg.Events.Timeout, Metrics: adapters.NoopMetrics{}, Logger: logger})
+	if err != nil {
+		app.Fatal("failed to create event publisher", err)
+	}
+	// Bulk cancellations queue their refunds for the refunds worker, so nothing here
+	// calls the billing provider
+	outbox := repo.NewRefundOutboxRepo(client, repo.WithTimeout(cfg.Spanner.Timeout), repo.WithTracer(tracer), repo.WithLogger(logger), repo.WithQueryHints(hints), repo.WithPriority(priority))
+	canceller := cancel_subscription.NewInteractor(
+		subscriptionRepo,
+		repo.NewRefundRepo(client, repo.WithTimeout(cfg.Spanner.Timeout), repo.WithTracer(tracer), repo.WithLogger(logger), repo.WithPriority(priority)),
+		outbox,
+		repo.NewCreditBalanceRepo(client, repo.WithTimeout(cfg.Spanner.Timeout), repo.WithTracer(tracer), repo.WithLogger(logger), repo.WithPriority(priority)),
+		nil,
+		pricing,
+		adapters.EnvFeatureFlags{Logger: logger},
+		hooks,
+		events,
+		clock,
+		adapters.PlanBillingCycles{
+			Plans:       repo.NewPlanRepo(client, repo.WithTimeout(cfg.Spanner.Timeout), repo.WithTracer(tracer), repo.WithLogger(logger), repo.WithQueryHints(hints), repo.WithPriority(priority)),
+			DefaultDays: cfg.BillingCycleDays,
+		},
+	)
+	// Bulk cancellations are privileged, so they go through the bus, which records each
+	// in the audit trail
+	recorder := audit.NewRecorder(
+		repo.NewAuditRepo(client, repo.WithTimeout(cfg.Spanner.Timeout), repo.WithTracer(tracer), repo.WithLogger(logger)),
+		logger,
+		func(err error) bool { return errors.Is(err, bus.ErrUnauthorized) },
+	)
+	commands := bus.New(bus.Recovery(logger, adapters.NoopMetrics{}), bus.Audit(recorder))
+	err = commands.Register(cancel_subscription.BulkCommandName, cancel_subscription.NewBulkInteractor(canceller, subscriptionRepo, outbox, cancel_subscription.BulkConfig{
+		BatchSize:   *batchSize,
+		Concurrency: *concurrency,
+		Progress: func(p workpool.Progress) {
+			logger.Info("bulk cancellation progress", slog.Int("batches_done", p.Done()), slog.Int("batches", p.Total))
+		},
+	}))
+	if err != nil {
+		app.Fatal("failed to register bulk cancellation", err)
+	}
+	bulk := cancel_subscription.NewBulkInstrumented(
+		cancel_subscription.NewBulkDispatched(commands),
+		instrument.Instrumentation{Logger: logger, Metrics: adapters.NoopMetrics{}, Tracer: tracer},
+	)
+
+	app.Go("bulk cancel", func(ctx context.Context) error {
+		// Batches are committed whole, so one interrupted by shutdown is simply not
+		// committed; running the command again picks up where it stopped
+		result, err := bulk.Execute(audit.WithPrincipal(ctx, operator()), req)
+		if result != nil {
+			for _, f := range result.Failed {
+				logger.Error("subscription not cancelled", slog.String("subscription_id", f.SubscriptionID), slog.Any("error", f.Err))
+			}
+			logger.Info("bulk cancellation complete",
+				slog.Int("requested", result.Requested),
+				slog.Int("cancelled", result.Cancelled),
+				slog.Int("already_cancelled", result.AlreadyCancelled),
+				slog.Int("not_found", result.NotFound),
+				slog.Int("failed", len(result.Failed)),
+				slog.Int("refunds_queued", result.RefundsQueued),
+				slog.Int64("refunded_cents", result.RefundedAmount),
+				slog.Int64("credited_cents", result.CreditedAmount),
+				slog.Int("batches", result.Batches),
+				slog.Duration("elapsed", result.Elapsed),
+				slog.Float64("per_second", result.Throughput()),
+			)
+		}
+		if err == nil && len(result.Failed) > 0 {
+			err = fmt.Errorf("%d subscriptions not cancelled", len(result.Failed))
+		}
+		return err
+	})
+
+	if err := app.Wait(); err != nil {
+		os.Exit(1)
+	}
+}
+
+// operator is the audit principal running the command: the OS user, at this host
+func operator() audit.Principal {
+	name := os.Getenv("USER")
+	if u, err := user.Current(); err == nil {
+		name = u.Username
+	}
+	if host, err := os.Hostname(); err == nil {
+		name += "@" + host
+	}
+	return audit.Principal{ID: name}
+}
+
+// readIDs reads one subscription ID per line from path, or standard input for -,
+// skipping blank lines
+func readIDs(path string) ([]string, error) {
+	var in io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	var ids []string
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		if id := strings.TrimSpace(scanner.Text()); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids, scanner.Err()
+}
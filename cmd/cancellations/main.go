@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/adapters"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/debug"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/faults"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/health"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/logging"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/metrics"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/migrations"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/repo"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/cancel_subscription"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/instrument"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/workers/cancellations"
+	"github.com/wuyiadepoju/subscription-management/internal/bootstrap"
+	"github.com/wuyiadepoju/subscription-management/internal/config"
+	"github.com/wuyiadepoju/subscription-management/internal/lifecycle"
+	"github.com/wuyiadepoju/subscription-management/internal/telemetry"
+)
+
+func main() {
+	loader := config.NewLoader(flag.CommandLine, config.SectionSpanner|config.SectionRenewal|config.SectionMetrics|config.SectionDebug|config.SectionFaults|config.SectionSecrets|config.SectionTelemetry|config.SectionHealth|config.SectionDiscounts|config.SectionEvents, config.Default())
+	var (
+		interval    = flag.Duration("interval", time.Minute, "Time between cancellation passes")
+		batchSize   = flag.Int("batch-size", 500, "Maximum subscriptions cancelled per pass")
+		concurrency = flag.Int("concurrency", 8, "Maximum cancellations in flight")
+		once        = flag.Bool("once", false, "Run a single pass and exit")
+	)
+	flag.Parse()
+
+	cfg, err := loader.Load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	logger, err := logging.New(os.Stdout, cfg.Log.Level, cfg.Log.Format)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	app := lifecycle.New(logger, cfg.ShutdownTimeout)
+	ctx := app.Context()
+
+	secrets, err := adapters.NewSecretProvider(ctx, adapters.SecretsConfig{
+		Backend:  adapters.SecretsBackend(cfg.Secrets.Backend),
+		Project:  cfg.Secrets.Project,
+		CacheTTL: cfg.Secrets.CacheTTL,
+		Logger:   logger,
+	})
+	if err != nil {
+		app.Fatal("failed to create secret provider", err)
+	}
+
+	tracer, err := telemetry.NewTracer(app, cfg, "cancellations", logger)
+	if err != nil {
+		app.Fatal("failed to configure tracing", err)
+	}
+
+	client, err := bootstrap.Spanner(app, cfg, logger)
+	if err != nil {
+		app.Fatal("failed to open Spanner", err)
+	}
+
+	clock := domain.RealClock{}
+	metricsRegistry := metrics.NewRegistry()
+	if err := telemetry.StartMetrics(app, cfg, metricsRegistry, "cancellations", logger); err != nil {
+		app.Fatal("failed to configure metrics", err)
+	}
+	if cfg.Debug.Addr != "" {
+		debugServer, err := debug.NewServer(ctx, cfg.Debug.Addr, secrets, logger)
+		if err != nil {
+			app.Fatal("failed to start debug endpoints", err)
+		}
+		app.Serve("debug", debugServer)
+	}
+	injector, err := faults.New(cfg.Faults.Rules, cfg.Faults.Header, logger)
+	if err != nil {
+		app.Fatal("invalid fault rules", err)
+	}
+	hints, err := repo.ParseQueryHints(cfg.Spanner.QueryHints)
+	if err != nil {
+		app.Fatal("invalid query hints", err)
+	}
+
+	priority, err := repo.ParsePriority(cfg.Spanner.Priority, repo.PriorityWorker)
+	if err != nil {
+		app.Fatal("invalid Spanner priority", err)
+	}
+	repoOpts := []repo.Option{repo.WithTimeout(cfg.Spanner.Timeout), repo.WithTracer(tracer), repo.WithMetrics(metricsRegistry), repo.WithLogger(logger), repo.WithFaults(injector), repo.WithQueryHints(hints), repo.WithPriority(priority)}
+	subscriptionRepo := repo.NewSubscriptionRepo(client, repoOpts...)
+	pricing := adapters.BundlePricing{
+		Bundles: repo.NewBundleRepo(client, repoOpts...),
+		Base:    adapters.StaticPricing{Pricing: domain.Pricing{Policy: domain.DiscountPolicy{MaxStacked: int(cfg.Discounts.MaxStacked), MaxPercentOff: cfg.Discounts.MaxPercentOff}}},
+	}
+	// Deployments register their lifecycle hooks here, e.g. a CRM sync
+	hooks := adapters.HookChain{}
+	events, err := adapters.NewEventPublisher(ctx, adapters.EventsConfig{Topic: cfg.Events.Topic, Timeout: cfg.Events.Timeout, Metrics: metricsRegistry, Logger: logger})
+	if err != nil {
+		app.Fatal("failed to create event publisher", err)
+	}
+
+	// A scheduled cancellation is carried out once the period it paid for is over, so
+	// it refunds nothing and nothing here calls the billing provider
+	canceller := cancel_subscription.NewInteractor(
+		subscriptionRepo,
+		repo.NewRefundRepo(client, repoOpts...),
+		repo.NewRefundOutboxRepo(client, repoOpts...),
+		repo.NewCreditBalanceRepo(client, repoOpts...),
+		nil,
+		pricing,
+		adapters.EnvFeatureFlags{Logger: logger},
+		hooks,
+		events,
+		clock,
+		adapters.PlanBillingCycles{Plans: repo.NewPlanRepo(client, repoOpts...), DefaultDays: cfg.BillingCycleDays},
+	)
+	finalizer := cancel_subscription.NewFinalizeInstrumented(canceller, instrument.Instrumentation{Logger: logger, Metrics: metricsRegistry, Tracer: tracer})
+
+	scheduler := cancellations.NewScheduler(subscriptionRepo, finalizer, clock, metricsRegistry, logger, cancellations.Config{
+		BatchSize:   *batchSize,
+		Concurrency: *concurrency,
+	})
+
+	if cfg.Health.Addr != "" {
+		readiness := health.NewChecker(cfg.Health.Timeout, logger)
+		readiness.Add("spanner", func(ctx context.Context) error { return repo.Ping(ctx, client) })
+		readiness.Add("schema", func(ctx context.Context) error { return migrations.CheckSchema(ctx, client) })
+		app.Serve("health", health.NewServer(cfg.Health.Addr, readiness))
+	}
+
+	if *once {
+		app.Go("cancellation pass", func(ctx context.Context) error {
+			_, err := scheduler.RunOnce(ctx)
+			return err
+		})
+	} else {
+		logger.Info("cancellation scheduler started", slog.Duration("interval", *interval))
+		app.Go("cancellation scheduler", func(ctx context.Context) error {
+			return scheduler.Run(ctx, *interval)
+		})
+	}
+
+	if err := app.Wait(); err != nil {
+		os.Exit(1)
+	}
+}
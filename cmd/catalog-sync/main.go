@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/adapters"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/logging"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/repo"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/tracing"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/instrument"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/sync_plan_catalog"
+	"github.com/wuyiadepoju/subscription-management/internal/bootstrap"
+	"github.com/wuyiadepoju/subscription-management/internal/config"
+	"github.com/wuyiadepoju/subscription-management/internal/lifecycle"
+	"github.com/wuyiadepoju/subscription-management/internal/telemetry"
+)
+
+func main() {
+	loader := config.NewLoader(flag.CommandLine, config.SectionSpanner|config.SectionBilling|config.SectionSecrets|config.SectionTelemetry, config.Default())
+	var (
+		dryRun  = flag.Bool("dry-run", false, "Report drift without changing any plan")
+		output  = flag.String("output", "", "Write the JSON report to this file instead of stdout")
+		timeout = flag.Duration("timeout", 5*time.Minute, "Timeout for the sync run")
+	)
+	flag.Parse()
+
+	cfg, err := loader.Load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	logger, err := logging.New(os.Stderr, cfg.Log.Level, cfg.Log.Format)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	app := lifecycle.New(logger, cfg.ShutdownTimeout)
+	ctx := app.Context()
+
+	secrets, err := adapters.NewSecretProvider(ctx, adapters.SecretsConfig{
+		Backend:  adapters.SecretsBackend(cfg.Secrets.Backend),
+		Project:  cfg.Secrets.Project,
+		CacheTTL: cfg.Secrets.CacheTTL,
+		Logger:   logger,
+	})
+	if err != nil {
+		app.Fatal("failed to create secret provider", err)
+	}
+
+	tracer, err := telemetry.NewTracer(app, cfg, "catalog-sync", logger)
+	if err != nil {
+		app.Fatal("failed to configure tracing", err)
+	}
+
+	client, err := bootstrap.Spanner(app, cfg, logger)
+	if err != nil {
+		app.Fatal("failed to open Spanner", err)
+	}
+
+	priority, err := repo.ParsePriority(cfg.Spanner.Priority, repo.PriorityWorker)
+	if err != nil {
+		app.Fatal("invalid Spanner priority", err)
+	}
+
+	httpClient, err := adapters.NewBillingHTTPClient(ctx, 30*time.Second, adapters.BillingAuthConfig{
+		Method:       adapters.AuthMethod(cfg.Billing.Auth),
+		Secrets:      secrets,
+		APIKeyHeader: cfg.Billing.APIKeyHeader,
+		TokenURL:     cfg.Billing.TokenURL,
+		ClientID:     cfg.Billing.ClientID,
+		Scopes:       cfg.Billing.Scopes,
+	})
+	if err != nil {
+		app.Fatal("failed to create billing client", err)
+	}
+	httpClient.Transport = tracing.NewTransport(httpClient.Transport, tracer)
+	billingClient := adapters.NewHTTPBillingClient(httpClient, cfg.Billing.URL)
+
+	syncer := sync_plan_catalog.NewInstrumented(
+		sync_plan_catalog.NewInteractor(repo.NewPlanRepo(client, repo.WithTimeout(cfg.Spanner.Timeout), repo.WithTracer(tracer), repo.WithLogger(logger), repo.WithPriority(priority)), billingClient, domain.RealClock{}),
+		instrument.Instrumentation{Logger: logger, Metrics: adapters.NoopMetrics{}, Tracer: tracer},
+	)
+
+	app.Go("catalog-sync", func(ctx context.Context) error {
+		ctx, cancel := context.WithTimeout(ctx, *timeout)
+		defer cancel()
+
+		report, err := syncer.Execute(ctx, sync_plan_catalog.Request{DryRun: *dryRun})
+		if err != nil {
+			return err
+		}
+		// The service has no event publisher yet; consumers read the events from the logs
+		for _, event := range report.Events {
+			logger.InfoContext(ctx, "plan catalog updated",
+				slog.String("plan_id", event.PlanID),
+				slog.String("external_product_id", event.ExternalProductID),
+				slog.String("external_price_id", event.ExternalPriceID),
+				slog.Bool("created", event.Created),
+				slog.Int("changes", len(event.Changes)),
+			)
+		}
+		if err := writeReport(*output, report); err != nil {
+			return err
+		}
+
+		logger.Info("catalog sync complete",
+			slog.Bool("dry_run", report.DryRun),
+			slog.Int("checked", report.Checked),
+			slog.Int("created", report.Created),
+			slog.Int("updated", report.Updated),
+			slog.Int("drift", len(report.Drift)),
+		)
+		return nil
+	})
+
+	if err := app.Wait(); err != nil {
+		os.Exit(1)
+	}
+}
+
+// writeReport writes the report as indented JSON to path, or to stdout when path is empty
+func writeReport(path string, report *sync_plan_catalog.Report) error {
+	out := os.Stdout
+	if path != "" {
+		f, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("failed to create report file: %w", err)
+		}
+		defer f.Close()
+		out = f
+	}
+
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	return nil
+}
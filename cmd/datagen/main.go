@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/logging"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/repo"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/testkit"
+	"github.com/wuyiadepoju/subscription-management/internal/bootstrap"
+	"github.com/wuyiadepoju/subscription-management/internal/config"
+	"github.com/wuyiadepoju/subscription-management/internal/datagen"
+	"github.com/wuyiadepoju/subscription-management/internal/lifecycle"
+)
+
+func main() {
+	loader := config.NewLoader(flag.CommandLine, config.SectionSpanner|config.SectionRenewal, config.Default())
+	defaults := datagen.DefaultProfile()
+	var (
+		subscriptions = flag.Int("subscriptions", defaults.Subscriptions, "Subscriptions to generate, cancelled ones included")
+		plans         = flag.String("plans", "plan-basic:999=60,plan-pro:2999:10000=30,plan-team:9999:100000=10", "Weighted plans as id:price[:included usage]=weight")
+		span          = flag.Duration("span", defaults.Span, "How far back the first subscriptions started")
+		growth        = flag.Float64("growth", defaults.MonthlyGrowth, "Month-over-month growth in new subscriptions")
+		churn         = flag.Float64("churn", defaults.MonthlyChurn, "Share of subscriptions cancelling each month")
+		trialShare    = flag.Float64("trial-share", defaults.TrialShare, "Share of subscriptions that start with a trial")
+		trialDays     = flag.Int64("trial-days", defaults.TrialDays, "Length of a trial")
+		pastDueShare  = flag.Float64("past-due-share", defaults.PastDueShare, "Share of renewed active subscriptions whose last charge failed")
+		metric        = flag.String("metric", defaults.Metric, "Usage metric of the metered plans")
+		seed          = flag.Int64("seed", defaults.Seed, "Random seed; the same seed and flags generate the same population")
+		batch         = flag.Int("batch", datagen.DefaultBatchSize, "Subscriptions per commit, with their usage")
+		dryRun        = flag.Bool("dry-run", false, "Generate in memory and print the summary without writing to Spanner")
+	)
+	flag.Parse()
+
+	cfg, err := loader.Load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	logger, err := logging.New(os.Stderr, cfg.Log.Level, cfg.Log.Format)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	profile := datagen.Profile{
+		Subscriptions:    *subscriptions,
+		Span:             *span,
+		MonthlyGrowth:    *growth,
+		MonthlyChurn:     *churn,
+		TrialShare:       *trialShare,
+		TrialDays:        *trialDays,
+		PastDueShare:     *pastDueShare,
+		Metric:           *metric,
+		BillingCycleDays: cfg.BillingCycleDays,
+		Seed:             *seed,
+		Now:              time.Now().UTC(),
+	}
+	if profile.Plans, err = datagen.ParsePlans(*plans); err == nil {
+		err = profile.Validate()
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	app := lifecycle.New(logger, cfg.ShutdownTimeout)
+
+	target := datagen.Target{BatchSize: *batch}
+	if *dryRun {
+		target.Subscriptions, target.Usage = testkit.NewFakeSubscriptions(), testkit.NewFakeUsage()
+	} else {
+		client, err := bootstrap.Spanner(app, cfg, logger)
+		if err != nil {
+			app.Fatal("failed to open Spanner", err)
+		}
+
+		priority, err := repo.ParsePriority(cfg.Spanner.Priority, repo.PriorityBulk)
+		if err != nil {
+			app.Fatal("invalid Spanner priority", err)
+		}
+		target.Subscriptions = repo.NewSubscriptionRepo(client, repo.WithTimeout(cfg.Spanner.Timeout), repo.WithPriority(priority))
+		target.Usage = repo.NewUsageRepo(client, repo.WithTimeout(cfg.Spanner.Timeout), repo.WithPriority(priority))
+	}
+
+	app.Go("generate", func(ctx context.Context) error {
+		logger.Info("generating subscriptions", slog.Int("count", profile.Subscriptions), slog.Int64("seed", profile.Seed), slog.Bool("dry_run", *dryRun))
+		summary, err := datagen.Generate(ctx, profile, target)
+		if err != nil {
+			if summary != nil {
+				logger.Error("generation stopped", slog.Int("generated", summary.Subscriptions))
+			}
+			return err
+		}
+		return summary.WriteText(os.Stdout)
+	})
+
+	if err := app.Wait(); err != nil {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/adapters"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/debug"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/faults"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/health"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/logging"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/metrics"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/migrations"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/recovery"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/repo"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/tracing"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/transport/webhook"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/complete_charge_authentication"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/instrument"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/record_payment_failure"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/retry_payment"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/workers/dunning"
+	"github.com/wuyiadepoju/subscription-management/internal/bootstrap"
+	"github.com/wuyiadepoju/subscription-management/internal/config"
+	"github.com/wuyiadepoju/subscription-management/internal/lifecycle"
+	"github.com/wuyiadepoju/subscription-management/internal/telemetry"
+)
+
+func main() {
+	schedule := domain.DefaultDunningSchedule
+
+	loader := config.NewLoader(flag.CommandLine, config.SectionSpanner|config.SectionBilling|config.SectionBillingProviders|config.SectionMetrics|config.SectionDebug|config.SectionFaults|config.SectionSecrets|config.SectionTelemetry|config.SectionHealth|config.SectionDiscounts, config.Default())
+	var (
+		webhookAddr = flag.String("webhook-addr", "", "Listen address for payment failure and charge authentication webhooks (e.g. :8083); empty disables them. Requires PAYMENT_WEBHOOK_SECRET")
+		interval    = flag.Duration("interval", time.Minute, "Time between dunning passes")
+		batchSize   = flag.Int("batch-size", 500, "Maximum payment retries per pass")
+		concurrency = flag.Int("concurrency", 8, "Maximum payment retries in flight")
+		once        = flag.Bool("once", false, "Run a single pass and exit")
+	)
+	flag.Func("schedule", "Comma-separated delays before each payment retry (default 24h,72h,72h)", func(s string) error {
+		parsed, err := domain.ParseDunningSchedule(s)
+		if err != nil {
+			return err
+		}
+		schedule = parsed
+		return nil
+	})
+	flag.Parse()
+
+	cfg, err := loader.Load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	logger, err := logging.New(os.Stdout, cfg.Log.Level, cfg.Log.Format)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	app := lifecycle.New(logger, cfg.ShutdownTimeout)
+	ctx := app.Context()
+
+	secrets, err := adapters.NewSecretProvider(ctx, adapters.SecretsConfig{
+		Backend:  adapters.SecretsBackend(cfg.Secrets.Backend),
+		Project:  cfg.Secrets.Project,
+		CacheTTL: cfg.Secrets.CacheTTL,
+		Logger:   logger,
+	})
+	if err != nil {
+		app.Fatal("failed to create secret provider", err)
+	}
+
+	tracer, err := telemetry.NewTracer(app, cfg, "dunning", logger)
+	if err != nil {
+		app.Fatal("failed to configure tracing", err)
+	}
+
+	client, err := bootstrap.Spanner(app, cfg, logger)
+	if err != nil {
+		app.Fatal("failed to open Spanner", err)
+	}
+
+	clock := domain.RealClock{}
+	metricsRegistry := metrics.NewRegistry()
+	if err := telemetry.StartMetrics(app, cfg, metricsRegistry, "dunning", logger); err != nil {
+		app.Fatal("failed to configure metrics", err)
+	}
+	if cfg.Debug.Addr != "" {
+		debugServer, err := debug.NewServer(ctx, cfg.Debug.Addr, secrets, logger)
+		if err != nil {
+			app.Fatal("failed to start debug endpoints", err)
+		}
+		app.Serve("debug", debugServer)
+	}
+	injector, err := faults.New(cfg.Faults.Rules, cfg.Faults.Header, logger)
+	if err != nil {
+		app.Fatal("invalid fault rules", err)
+	}
+	hints, err := repo.ParseQueryHints(cfg.Spanner.QueryHints)
+	if err != nil {
+		app.Fatal("invalid query hints", err)
+	}
+
+	priority, err := repo.ParsePriority(cfg.Spanner.Priority, repo.PriorityWorker)
+	if err != nil {
+		app.Fatal("invalid Spanner priority", err)
+	}
+	subscriptionRepo := repo.NewSubscriptionRepo(client, repo.WithTimeout(cfg.Spanner.Timeout), repo.WithTracer(tracer), repo.WithMetrics(metricsRegistry), repo.WithLogger(logger), repo.WithFaults(injector), repo.WithQueryHints(hints), repo.WithPriority(priority))
+	creditRepo := repo.NewCreditBalanceRepo(client, repo.WithTimeout(cfg.Spanner.Timeout), repo.WithTracer(tracer), repo.WithMetrics(metricsRegistry), repo.WithLogger(logger), repo.WithFaults(injector), repo.WithPriority(priority))
+	pricing := adapters.BundlePricing{
+		Bundles: repo.NewBundleRepo(client, repo.WithTimeout(cfg.Spanner.Timeout), repo.WithTracer(tracer), repo.WithMetrics(metricsRegistry), repo.WithLogger(logger), repo.WithFaults(injector), repo.WithPriority(priority)),
+		Base:    adapters.StaticPricing{Pricing: domain.Pricing{Policy: domain.DiscountPolicy{MaxStacked: int(cfg.Discounts.MaxStacked), MaxPercentOff: cfg.Discounts.MaxPercentOff}}},
+	}
+	httpBilling := adapters.BillingConfig{
+		Provider:    adapters.ProviderHTTP,
+		BaseURL:     cfg.Billing.URL,
+		CallTimeout: cfg.Billing.Timeout,
+		Metrics:     metricsRegistry,
+		Tracer:      tracer,
+		Logger:      logger,
+		Faults:      injector,
+		Auth: adapters.BillingAuthConfig{
+			Method:       adapters.AuthMethod(cfg.Billing.Auth),
+			Secrets:      secrets,
+			APIKeyHeader: cfg.Billing.APIKeyHeader,
+			TokenURL:     cfg.Billing.TokenURL,
+			ClientID:     cfg.Billing.ClientID,
+			Scopes:       cfg.Billing.Scopes,
+		},
+	}
+	registry, err := newBillingRegistry(ctx, adapters.BillingProvider(cfg.Billing.Provider), httpBilling, secrets, cfg.Billing.PaddleSandbox, cfg.Billing.PaddlePlans, cfg.Billing.PaddleCustomerPrefix)
+	if err != nil {
+		app.Fatal("failed to create billing clients", err)
+	}
+
+	if cfg.Health.Addr != "" {
+		readiness := health.NewChecker(cfg.Health.Timeout, logger)
+		readiness.Add("spanner", func(ctx context.Context) error { return repo.Ping(ctx, client) })
+		readiness.Add("schema", func(ctx context.Context) error { return migrations.CheckSchema(ctx, client) })
+		pinger, err := adapters.NewBillingPinger(ctx, httpBilling)
+		if err != nil {
+			app.Fatal("failed to create billing health check", err)
+		}
+		readiness.Add("billing", pinger.Ping)
+		app.Serve("health", health.NewServer(cfg.Health.Addr, readiness))
+	}
+
+	in := instrument.Instrumentation{Logger: logger, Metrics: metricsRegistry, Tracer: tracer}
+	retrier := retry_payment.NewInstrumented(
+		retry_payment.NewInteractor(subscriptionRepo, creditRepo, registry, pricing, clock, schedule),
+		in,
+	)
+
+	worker := dunning.NewWorker(subscriptionRepo, retrier, clock, metricsRegistry, logger, dunning.Config{
+		BatchSize:   *batchSize,
+		Concurrency: *concurrency,
+	})
+
+	if *webhookAddr != "" && !*once {
+		// Fail fast without a key; the verifier resolves keys per request so rotations apply
+		if _, err := secrets.Secret(ctx, "payment-webhook-secret"); err != nil {
+			app.Fatal("failed to load webhook secret", err)
+		}
+		verifier := adapters.SecretHMACVerifier{
+			Secrets: secrets,
+			Names:   []string{"payment-webhook-secret", "payment-webhook-secret-previous"},
+			Logger:  logger,
+		}
+
+		mux := http.NewServeMux()
+		mux.Handle("/webhooks/payments", tracing.Middleware(tracer, "POST /webhooks/payments", recovery.Middleware(logger, metricsRegistry, "payment_webhook", webhook.NewPaymentFailureHandler(
+			record_payment_failure.NewInstrumented(record_payment_failure.NewInteractor(subscriptionRepo, pricing, clock, schedule), in),
+			verifier,
+			logger,
+		))))
+		authenticationRepo := repo.NewChargeAuthenticationRepo(client, repo.WithTimeout(cfg.Spanner.Timeout), repo.WithTracer(tracer), repo.WithMetrics(metricsRegistry), repo.WithLogger(logger), repo.WithFaults(injector), repo.WithPriority(priority))
+		mux.Handle("/webhooks/authentications", tracing.Middleware(tracer, "POST /webhooks/authentications", recovery.Middleware(logger, metricsRegistry, "authentication_webhook", webhook.NewAuthenticationHandler(
+			complete_charge_authentication.NewInstrumented(complete_charge_authentication.NewInteractor(authenticationRepo, subscriptionRepo, creditRepo, pricing, clock), in),
+			verifier,
+			logger,
+		))))
+		server := &http.Server{Addr: *webhookAddr, Handler: injector.Middleware(mux), ReadHeaderTimeout: 10 * time.Second}
+
+		app.Serve("payment webhook", server)
+	}
+
+	if *once {
+		app.Go("dunning pass", func(ctx context.Context) error {
+			_, err := worker.RunOnce(ctx)
+			return err
+		})
+	} else {
+		logger.Info("dunning worker started", slog.Duration("interval", *interval), slog.Int("retries", len(schedule)))
+		app.Go("dunning", func(ctx context.Context) error {
+			return worker.Run(ctx, *interval)
+		})
+	}
+
+	if err := app.Wait(); err != nil {
+		os.Exit(1)
+	}
+}
+
+// newBillingRegistry registers the HTTP provider, plus Paddle when PADDLE_API_KEY is set,
+// and routes the given plans and customer prefix to Paddle
+func newBillingRegistry(ctx context.Context, defaultProvider adapters.BillingProvider, httpBilling adapters.BillingConfig, secrets contracts.SecretProvider, sandbox bool, paddlePlans []string, paddlePrefix string) (*adapters.BillingRegistry, error) {
+	registry := adapters.NewBillingRegistry(defaultProvider)
+	resilience := adapters.DefaultResilienceConfig()
+
+	httpBilling.Timeout = 30 * time.Second
+	httpBilling.Resilience = &resilience
+	configs := []adapters.BillingConfig{httpBilling}
+	if apiKey, err := secrets.Secret(ctx, "paddle-api-key"); err == nil {
+		configs = append(configs, adapters.BillingConfig{Provider: adapters.ProviderPaddle, APIKey: apiKey, Sandbox: sandbox, Timeout: 30 * time.Second, CallTimeout: httpBilling.CallTimeout, Resilience: &resilience, Metrics: httpBilling.Metrics, Tracer: httpBilling.Tracer, Logger: httpBilling.Logger, Faults: httpBilling.Faults})
+	}
+	for _, cfg := range configs {
+		client, err := adapters.NewBillingClient(ctx, cfg)
+		if err != nil {
+			return nil, err
+		}
+		registry.Register(cfg.Provider, client)
+	}
+
+	// Fail at startup rather than on the first retry routed to a missing provider
+	if _, err := registry.Provider(defaultProvider); err != nil {
+		return nil, err
+	}
+	if len(paddlePlans) > 0 || paddlePrefix != "" {
+		if _, err := registry.Provider(adapters.ProviderPaddle); err != nil {
+			return nil, fmt.Errorf("paddle routes configured without PADDLE_API_KEY: %w", err)
+		}
+	}
+
+	for _, planID := range paddlePlans {
+		registry.RouteByPlan(planID, adapters.ProviderPaddle)
+	}
+	if paddlePrefix != "" {
+		registry.RouteByCustomerPrefix(paddlePrefix, adapters.ProviderPaddle)
+	}
+	return registry, nil
+}
@@ -0,0 +1,285 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/adapters"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/faults"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/logging"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/repo"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/testkit"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/cancel_subscription"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/create_subscription"
+	"github.com/wuyiadepoju/subscription-management/internal/bootstrap"
+	"github.com/wuyiadepoju/subscription-management/internal/config"
+	"github.com/wuyiadepoju/subscription-management/internal/lifecycle"
+	"github.com/wuyiadepoju/subscription-management/internal/loadgen"
+)
+
+func main() {
+	loader := config.NewLoader(flag.CommandLine, config.SectionSpanner|config.SectionBilling|config.SectionRenewal|config.SectionFaults|config.SectionSecrets|config.SectionDiscounts, config.Default())
+	var (
+		mixSpec      = flag.String("mix", "create=50,cancel=20,get=30", "Weighted operations: create, cancel and get")
+		concurrency  = flag.Int("concurrency", 16, "Operations in flight")
+		duration     = flag.Duration("duration", time.Minute, "How long to generate load")
+		rate         = flag.Float64("rate", 0, "Operations per second across all workers; 0 runs as fast as the concurrency allows")
+		seed         = flag.Int("seed", 100, "Subscriptions created before measuring, so cancel and get have something to act on")
+		billing      = flag.String("billing", "http", "Billing backend: http (the billing API, e.g. cmd/mock-billing) or fake (in process)")
+		planID       = flag.String("plan", "plan-loadgen", "Plan of the subscriptions created")
+		priceCents   = flag.Int64("price", 2999, "Price of -plan, in cents; the plan is saved at this price before the run")
+		output       = flag.String("output", "", "Also write the JSON report to this file")
+		maxP99       = flag.Duration("max-p99", 0, "Fail when any operation's p99 latency exceeds this; 0 disables the check")
+		maxErrorRate = flag.Float64("max-error-rate", 0, "Fail when any operation's error rate exceeds this fraction; 0 disables the check")
+		cacheTTL     = flag.Duration("cache-ttl", 0, "Cache subscriptions read by ID in process for this long; 0 reads every one from Spanner")
+		cacheSize    = flag.Int("cache-size", 10000, "Subscriptions the cache holds, with -cache-ttl")
+	)
+	flag.Parse()
+
+	cfg, err := loader.Load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	logger, err := logging.New(os.Stderr, cfg.Log.Level, cfg.Log.Format)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	mix, err := loadgen.ParseMix(*mixSpec)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	app := lifecycle.New(logger, cfg.ShutdownTimeout)
+	ctx := app.Context()
+
+	secrets, err := adapters.NewSecretProvider(ctx, adapters.SecretsConfig{
+		Backend:  adapters.SecretsBackend(cfg.Secrets.Backend),
+		Project:  cfg.Secrets.Project,
+		CacheTTL: cfg.Secrets.CacheTTL,
+		Logger:   logger,
+	})
+	if err != nil {
+		app.Fatal("failed to create secret provider", err)
+	}
+
+	client, err := bootstrap.Spanner(app, cfg, logger)
+	if err != nil {
+		app.Fatal("failed to open Spanner", err)
+	}
+
+	priority, err := repo.ParsePriority(cfg.Spanner.Priority, repo.PriorityInteractive)
+	if err != nil {
+		app.Fatal("invalid Spanner priority", err)
+	}
+
+	injector, err := faults.New(cfg.Faults.Rules, cfg.Faults.Header, logger)
+	if err != nil {
+		app.Fatal("invalid fault rules", err)
+	}
+	repoOpts := []repo.Option{repo.WithTimeout(cfg.Spanner.Timeout), repo.WithFaults(injector), repo.WithPriority(priority)}
+	var subscriptionRepo contracts.TransactionalSubscriptionRepository = repo.NewSubscriptionRepo(client, repoOpts...)
+	if *cacheTTL > 0 {
+		cache := adapters.NewCachedSubscriptions(subscriptionRepo, adapters.SubscriptionCacheConfig{TTL: *cacheTTL, MaxEntries: *cacheSize}, domain.RealClock{}, adapters.NoopMetrics{})
+		subscriptionRepo = cache
+		repoOpts = append(repoOpts, repo.WithCommitted(cache.Committed))
+	}
+	refundRepo := repo.NewRefundRepo(client, repoOpts...)
+	outboxRepo := repo.NewRefundOutboxRepo(client, repoOpts...)
+	creditRepo := repo.NewCreditBalanceRepo(client, repoOpts...)
+	referralRepo := repo.NewReferralRepo(client, repoOpts...)
+	bundleRepo := repo.NewBundleRepo(client, repoOpts...)
+	keyRepo := repo.NewIdempotencyKeyRepo(client, repoOpts...)
+	couponRepo := repo.NewCouponRepo(client, repoOpts...)
+	planRepo := repo.NewPlanRepo(client, repoOpts...)
+	// Subscriptions are created at their plan's price, so the plan goes in the catalog first
+	if err := savePlan(ctx, planRepo, *planID, *priceCents); err != nil {
+		app.Fatal("failed to save the plan", err)
+	}
+
+	var billingClient contracts.BillingClient
+	switch *billing {
+	case "http":
+		resilience := adapters.DefaultResilienceConfig()
+		billingClient, err = adapters.NewBillingClient(ctx, adapters.BillingConfig{
+			Provider: adapters.ProviderHTTP,
+			BaseURL:  cfg.Billing.URL,
+			Timeout:  30 * time.Second,
+			Auth: adapters.BillingAuthConfig{
+				Method:       adapters.AuthMethod(cfg.Billing.Auth),
+				Secrets:      secrets,
+				APIKeyHeader: cfg.Billing.APIKeyHeader,
+				TokenURL:     cfg.Billing.TokenURL,
+				ClientID:     cfg.Billing.ClientID,
+				Scopes:       cfg.Billing.Scopes,
+			},
+			CallTimeout: cfg.Billing.Timeout,
+			Resilience:  &resilience,
+			Logger:      logger,
+			Faults:      injector,
+		})
+		if err != nil {
+			app.Fatal("failed to create billing client", err)
+		}
+	case "fake":
+		// The fake keeps every call in memory, which suits runs of minutes, not hours
+		billingClient = adapters.NewFaultBillingClient(testkit.NewFakeBillingClient(), injector)
+	default:
+		fmt.Fprintf(os.Stderr, "invalid -billing %q: must be http or fake\n", *billing)
+		os.Exit(2)
+	}
+	resolver := adapters.StaticBillingResolver{Client: billingClient}
+	pricing := adapters.BundlePricing{
+		Bundles: bundleRepo,
+		Base:    adapters.StaticPricing{Pricing: domain.Pricing{Policy: domain.DiscountPolicy{MaxStacked: int(cfg.Discounts.MaxStacked), MaxPercentOff: cfg.Discounts.MaxPercentOff}}},
+	}
+	// Deployments register their lifecycle hooks here, e.g. a CRM sync
+	hooks := adapters.HookChain{}
+
+	clock := domain.RealClock{}
+	flags := adapters.EnvFeatureFlags{Logger: logger}
+	// Generated subscriptions aren't announced to the services that follow real ones
+	events := adapters.NoopEventPublisher{}
+	creator := create_subscription.NewInteractor(subscriptionRepo, planRepo, referralRepo, bundleRepo, keyRepo, couponRepo, resolver, flags, hooks, events, clock, cfg.BillingCycleDays)
+	canceller := cancel_subscription.NewInteractor(subscriptionRepo, refundRepo, outboxRepo, creditRepo, resolver, pricing, flags, hooks, events, clock, adapters.PlanBillingCycles{Plans: planRepo, DefaultDays: cfg.BillingCycleDays})
+
+	active := &pool{}
+	ops := map[string]loadgen.Op{
+		"create": func(ctx context.Context) error {
+			sub, _, err := creator.Execute(ctx, create_subscription.Request{
+				CustomerID: "loadgen-" + uuid.New().String(),
+				PlanID:     *planID,
+				PriceCents: *priceCents,
+			})
+			if err != nil {
+				return err
+			}
+			active.put(sub.ID())
+			return nil
+		},
+		"cancel": func(ctx context.Context) error {
+			id, ok := active.take()
+			if !ok {
+				return loadgen.ErrSkipped
+			}
+			_, err := canceller.Execute(ctx, id, domain.CancellationReason{})
+			return err
+		},
+		"get": func(ctx context.Context) error {
+			id, ok := active.any()
+			if !ok {
+				return loadgen.ErrSkipped
+			}
+			_, err := subscriptionRepo.FindByID(ctx, id)
+			return err
+		},
+	}
+
+	app.Go("load", func(ctx context.Context) error {
+		logger.Info("seeding subscriptions", slog.Int("count", *seed))
+		for i := 0; i < *seed; i++ {
+			if err := ops["create"](ctx); err != nil {
+				return fmt.Errorf("seeding failed: %w", err)
+			}
+		}
+
+		logger.Info("generating load", slog.String("mix", *mixSpec), slog.Int("concurrency", *concurrency), slog.Duration("duration", *duration), slog.Float64("rate", *rate))
+		report, err := loadgen.Run(ctx, loadgen.Config{Mix: mix, Concurrency: *concurrency, Duration: *duration, Rate: *rate}, ops)
+		if err != nil {
+			return err
+		}
+
+		if err := report.WriteText(os.Stdout); err != nil {
+			return err
+		}
+		for msg, n := range report.Errors {
+			logger.Warn("operation errors", slog.String("error", msg), slog.Int("count", n))
+		}
+		if *output != "" {
+			if err := writeReport(*output, report); err != nil {
+				return err
+			}
+		}
+		return loadgen.Thresholds{MaxP99: *maxP99, MaxErrorRate: *maxErrorRate}.Check(report)
+	})
+
+	if err := app.Wait(); err != nil {
+		os.Exit(1)
+	}
+}
+
+// pool holds the IDs of subscriptions created and not yet cancelled
+type pool struct {
+	mu  sync.Mutex
+	ids []string
+}
+
+func (p *pool) put(id string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.ids = append(p.ids, id)
+}
+
+// take removes and returns a random ID
+func (p *pool) take() (string, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.ids) == 0 {
+		return "", false
+	}
+	i := rand.Intn(len(p.ids))
+	id := p.ids[i]
+	p.ids[i] = p.ids[len(p.ids)-1]
+	p.ids = p.ids[:len(p.ids)-1]
+	return id, true
+}
+
+// any returns a random ID without removing it
+func (p *pool) any() (string, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.ids) == 0 {
+		return "", false
+	}
+	return p.ids[rand.Intn(len(p.ids))], true
+}
+
+// writeReport writes the report as indented JSON to path
+// savePlan creates or replaces the plan, active at the price
+func savePlan(ctx context.Context, plans contracts.PlanRepository, planID string, priceCents int64) error {
+	plan, _, err := domain.NewPlan(planID, domain.PlanDetails{Name: planID, PriceCents: priceCents, Currency: domain.DefaultCurrency}, domain.RealClock{})
+	if err != nil {
+		return err
+	}
+	var uow contracts.UnitOfWork
+	uow.Save(plans.Save(ctx, plan))
+	return uow.Commit(ctx, plans)
+}
+
+func writeReport(path string, report *loadgen.Report) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create report file: %w", err)
+	}
+	defer f.Close()
+
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	return nil
+}
@@ -7,25 +7,38 @@ import (
 	"os"
 	"time"
 
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/logging"
 	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/migrations"
+	"github.com/wuyiadepoju/subscription-management/internal/config"
+	"github.com/wuyiadepoju/subscription-management/internal/lifecycle"
 )
 
 func main() {
-	var (
-		projectID  = flag.String("project", "test-project", "Spanner project ID")
-		instanceID = flag.String("instance", "test-instance", "Spanner instance ID")
-		databaseID = flag.String("database", "subscription-db", "Spanner database ID")
-		timeout    = flag.Duration("timeout", 5*time.Minute, "Timeout for migration operations")
-	)
+	defaults := config.Default()
+	defaults.Log.Format = logging.FormatText
+	loader := config.NewLoader(flag.CommandLine, config.SectionSpanner, defaults)
+	timeout := flag.Duration("timeout", 5*time.Minute, "Timeout for migration operations")
 	flag.Parse()
 
-	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
-	defer cancel()
+	cfg, err := loader.Load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	logger, err := logging.New(os.Stdout, cfg.Log.Level, cfg.Log.Format)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
 
-	if err := migrations.RunMigrations(ctx, *projectID, *instanceID, *databaseID); err != nil {
-		fmt.Fprintf(os.Stderr, "Migration failed: %v\n", err)
+	app := lifecycle.New(logger, cfg.ShutdownTimeout)
+	app.Go("migrations", func(ctx context.Context) error {
+		ctx, cancel := context.WithTimeout(ctx, *timeout)
+		defer cancel()
+		return migrations.RunMigrations(ctx, logger, cfg.Spanner.Project, cfg.Spanner.Instance, cfg.Spanner.Database)
+	})
+
+	if err := app.Wait(); err != nil {
 		os.Exit(1)
 	}
-
-	fmt.Println("All migrations applied successfully!")
 }
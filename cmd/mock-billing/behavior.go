@@ -0,0 +1,152 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Behavior scripts how the mock billing server answers. It is loaded from a
+// -scenario file and can be replaced at runtime with PUT /_admin/behavior.
+type Behavior struct {
+	// Customers that /validate reports as invalid, by exact ID or prefix
+	InvalidCustomers []string `json:"invalid_customers"`
+	InvalidPrefix    string   `json:"invalid_prefix"`
+
+	// Customers whose charges are declined with 402
+	DeclinedCustomers []string `json:"declined_customers"`
+
+	// Customers whose charges are held for strong customer authentication: 402 with
+	// status requires_action, a payment_id and an action_url
+	AuthenticationCustomers []string `json:"authentication_customers"`
+
+	// Payment methods reported by /customers/{id}/payment-method. Customers not listed
+	// have a Visa ending 4242 that expires in three years; NoPaymentMethod answers 404.
+	PaymentMethods  map[string]PaymentMethod `json:"payment_methods"`
+	NoPaymentMethod []string                 `json:"no_payment_method"`
+
+	// Induced failures: the first FailFirst requests fail, then each request
+	// fails with probability FailureRate. Failures answer with FailureStatus.
+	FailFirst     int     `json:"fail_first"`
+	FailureRate   float64 `json:"failure_rate"`
+	FailureStatus int     `json:"failure_status"`
+
+	// Latency added to every request, plus up to LatencyJitter more
+	Latency       Duration `json:"latency"`
+	LatencyJitter Duration `json:"latency_jitter"`
+
+	// Refunds report PENDING until RefundSettleAfter has passed, then RefundOutcome
+	RefundSettleAfter Duration `json:"refund_settle_after"`
+	RefundOutcome     string   `json:"refund_outcome"` // succeeded or failed
+
+	// Product catalog served by /products, shaped like a Stripe product and its price
+	Catalog []Product `json:"catalog"`
+}
+
+// Product is a scripted catalog product with its recurring price
+type Product struct {
+	ProductID  string            `json:"product_id"`
+	PriceID    string            `json:"price_id"`
+	Name       string            `json:"name"`
+	UnitAmount int64             `json:"unit_amount"`
+	Currency   string            `json:"currency"`
+	Interval   string            `json:"interval,omitempty"`
+	Active     bool              `json:"active"`
+	Metadata   map[string]string `json:"metadata,omitempty"`
+}
+
+// PaymentMethod is a scripted payment method
+type PaymentMethod struct {
+	Valid    bool   `json:"valid"`
+	Brand    string `json:"brand"`
+	Last4    string `json:"last4"`
+	ExpMonth int    `json:"exp_month"`
+	ExpYear  int    `json:"exp_year"`
+}
+
+// defaultBehavior accepts every customer and settles refunds successfully
+func defaultBehavior() Behavior {
+	return Behavior{
+		InvalidPrefix: "invalid-",
+		FailureStatus: 503,
+		RefundOutcome: "succeeded",
+	}
+}
+
+// loadBehavior reads a scenario file over the defaults
+func loadBehavior(path string) (Behavior, error) {
+	b := defaultBehavior()
+	if path == "" {
+		return b, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return b, fmt.Errorf("failed to read scenario: %w", err)
+	}
+	if err := json.Unmarshal(data, &b); err != nil {
+		return b, fmt.Errorf("failed to parse scenario: %w", err)
+	}
+	return b, nil
+}
+
+// customerInvalid reports whether /validate should reject the customer
+func (b Behavior) customerInvalid(customerID string) bool {
+	if b.InvalidPrefix != "" && strings.HasPrefix(customerID, b.InvalidPrefix) {
+		return true
+	}
+	return contains(b.InvalidCustomers, customerID)
+}
+
+// chargeDeclined reports whether charges for the customer should be declined
+func (b Behavior) chargeDeclined(customerID string) bool {
+	return contains(b.DeclinedCustomers, customerID)
+}
+
+// chargeRequiresAuthentication reports whether charges for the customer should be
+// held for authentication
+func (b Behavior) chargeRequiresAuthentication(customerID string) bool {
+	return contains(b.AuthenticationCustomers, customerID)
+}
+
+// paymentMethod returns the customer's payment method, or false when they have none
+func (b Behavior) paymentMethod(customerID string, now time.Time) (PaymentMethod, bool) {
+	if contains(b.NoPaymentMethod, customerID) {
+		return PaymentMethod{}, false
+	}
+	if pm, ok := b.PaymentMethods[customerID]; ok {
+		return pm, true
+	}
+	return PaymentMethod{Valid: true, Brand: "visa", Last4: "4242", ExpMonth: int(now.Month()), ExpYear: now.Year() + 3}, true
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Duration is a time.Duration that reads and writes JSON as "250ms"
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
@@ -0,0 +1,66 @@
+// Command mock-billing serves the internal billing API with scriptable behavior so
+// the stack can run locally and in integration tests without the real billing API.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/logging"
+	"github.com/wuyiadepoju/subscription-management/internal/config"
+	"github.com/wuyiadepoju/subscription-management/internal/lifecycle"
+)
+
+func main() {
+	loader := config.NewLoader(flag.CommandLine, 0, config.Default())
+	var (
+		addr          = flag.String("addr", ":8081", "Listen address")
+		scenario      = flag.String("scenario", "", "JSON file scripting the server's behavior (see Behavior)")
+		webhookURL    = flag.String("webhook-url", "", "Send signed refund webhooks here once refunds settle (e.g. http://localhost:8082/webhooks/refunds)")
+		webhookSecret = flag.String("webhook-secret", "dev", "HMAC key for refund webhook signatures")
+	)
+	flag.Parse()
+
+	cfg, err := loader.Load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	logger, err := logging.New(os.Stdout, cfg.Log.Level, cfg.Log.Format)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	behavior, err := loadBehavior(*scenario)
+	if err != nil {
+		logger.Error("failed to load scenario", slog.Any("error", err))
+		os.Exit(1)
+	}
+
+	app := lifecycle.New(logger, cfg.ShutdownTimeout)
+
+	srv := newServer(behavior, logger, *webhookURL, []byte(*webhookSecret))
+	app.Go("refund notifier", func(ctx context.Context) error {
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+				srv.notifySettledRefunds()
+			}
+		}
+	})
+	app.Serve("mock billing", &http.Server{Addr: *addr, Handler: srv.routes(), ReadHeaderTimeout: 10 * time.Second})
+
+	if err := app.Wait(); err != nil {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,432 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// refund is a refund accepted by the mock
+type refund struct {
+	ID             string    `json:"refund_id"`
+	SubscriptionID string    `json:"subscription_id"`
+	CustomerID     string    `json:"customer_id"`
+	Amount         int64     `json:"amount"`
+	Currency       string    `json:"currency"`
+	RequestedAt    time.Time `json:"requested_at"`
+	notified       bool
+}
+
+// subscription is a subscription the mock has charged
+type subscription struct {
+	ID         string `json:"subscription_id"`
+	CustomerID string `json:"customer_id"`
+	Status     string `json:"status"`
+	Currency   string `json:"currency"`
+}
+
+// server implements the internal billing API with scripted behavior
+type server struct {
+	logger        *slog.Logger
+	webhookURL    string
+	webhookSecret []byte
+	client        *http.Client
+
+	mu            sync.Mutex
+	behavior      Behavior
+	requests      int
+	refunds       map[string]*refund
+	refundsByKey  map[string]string // idempotency key to refund ID
+	chargesByKey  map[string]bool
+	subscriptions map[string]*subscription
+	customers     map[string]bool
+	nextRefund    int
+	nextPayment   int
+}
+
+func newServer(behavior Behavior, logger *slog.Logger, webhookURL string, webhookSecret []byte) *server {
+	return &server{
+		logger:        logger,
+		webhookURL:    webhookURL,
+		webhookSecret: webhookSecret,
+		client:        &http.Client{Timeout: 10 * time.Second},
+		behavior:      behavior,
+		refunds:       make(map[string]*refund),
+		refundsByKey:  make(map[string]string),
+		chargesByKey:  make(map[string]bool),
+		subscriptions: make(map[string]*subscription),
+		customers:     make(map[string]bool),
+	}
+}
+
+func (s *server) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health", s.handleHealth)
+	mux.HandleFunc("/validate/", s.scripted(s.handleValidate))
+	mux.HandleFunc("/customers", s.scripted(s.handleCreateCustomer))
+	mux.HandleFunc("/customers/", s.scripted(s.handlePaymentMethod))
+	mux.HandleFunc("/refund", s.scripted(s.handleRefund))
+	mux.HandleFunc("/refunds/", s.scripted(s.handleRefundStatus))
+	mux.HandleFunc("/charge", s.scripted(s.handleCharge))
+	mux.HandleFunc("/subscriptions", s.scripted(s.handleListSubscriptions))
+	mux.HandleFunc("/subscriptions/", s.scripted(s.handleCancelSubscription))
+	mux.HandleFunc("/products", s.scripted(s.handleListProducts))
+	mux.HandleFunc("/_admin/behavior", s.handleBehavior)
+	return mux
+}
+
+// handleHealth answers the services' readiness ping. It isn't scripted, so probes
+// don't count towards fail_first.
+func (s *server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	if !allow(w, r, http.MethodGet) {
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// scripted applies the configured latency and induced failures before h runs
+func (s *server) scripted(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		b := s.behavior
+		s.requests++
+		fail := s.requests <= b.FailFirst || (b.FailureRate > 0 && rand.Float64() < b.FailureRate)
+		s.mu.Unlock()
+
+		delay := time.Duration(b.Latency)
+		if b.LatencyJitter > 0 {
+			delay += time.Duration(rand.Int63n(int64(b.LatencyJitter)))
+		}
+		if delay > 0 {
+			select {
+			case <-time.After(delay):
+			case <-r.Context().Done():
+				return
+			}
+		}
+
+		s.logger.Info("request", slog.String("method", r.Method), slog.String("path", r.URL.Path), slog.Bool("induced_failure", fail))
+		if fail {
+			http.Error(w, "induced failure", b.FailureStatus)
+			return
+		}
+		h(w, r)
+	}
+}
+
+func (s *server) handleValidate(w http.ResponseWriter, r *http.Request) {
+	if !allow(w, r, http.MethodGet) {
+		return
+	}
+	customerID := strings.TrimPrefix(r.URL.Path, "/validate/")
+
+	s.mu.Lock()
+	invalid := s.behavior.customerInvalid(customerID)
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]any{"valid": !invalid})
+}
+
+// handleCreateCustomer answers 201 for a new customer and 409 for one it already knows
+func (s *server) handleCreateCustomer(w http.ResponseWriter, r *http.Request) {
+	if !allow(w, r, http.MethodPost) {
+		return
+	}
+
+	var req struct {
+		CustomerID string `json:"customer_id"`
+		Email      string `json:"email"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.CustomerID == "" || req.Email == "" {
+		http.Error(w, "invalid customer", http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.customers[req.CustomerID] {
+		http.Error(w, "customer already exists", http.StatusConflict)
+		return
+	}
+	s.customers[req.CustomerID] = true
+	writeJSON(w, http.StatusCreated, map[string]any{"customer_id": req.CustomerID})
+}
+
+func (s *server) handlePaymentMethod(w http.ResponseWriter, r *http.Request) {
+	customerID, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/customers/"), "/payment-method")
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	if !allow(w, r, http.MethodGet) {
+		return
+	}
+
+	s.mu.Lock()
+	pm, found := s.behavior.paymentMethod(customerID, time.Now())
+	s.mu.Unlock()
+
+	if !found {
+		http.Error(w, "no payment method on file", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, http.StatusOK, pm)
+}
+
+func (s *server) handleRefund(w http.ResponseWriter, r *http.Request) {
+	if !allow(w, r, http.MethodPost) {
+		return
+	}
+
+	var req refund
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Amount <= 0 || req.Currency == "" {
+		http.Error(w, "invalid refund request", http.StatusBadRequest)
+		return
+	}
+	key := r.Header.Get("Idempotency-Key")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// A refund must be in the currency the subscription was charged in
+	if sub, ok := s.subscriptions[req.SubscriptionID]; ok && sub.Currency != req.Currency {
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]any{"error": "currency_mismatch", "charge_currency": sub.Currency})
+		return
+	}
+
+	if id, ok := s.refundsByKey[key]; ok && key != "" {
+		writeJSON(w, http.StatusOK, map[string]any{"refund_id": id, "status": "pending"})
+		return
+	}
+
+	s.nextRefund++
+	req.ID = fmt.Sprintf("mock-refund-%d", s.nextRefund)
+	req.RequestedAt = time.Now()
+	s.refunds[req.ID] = &req
+	if key != "" {
+		s.refundsByKey[key] = req.ID
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"refund_id": req.ID, "status": "pending"})
+}
+
+func (s *server) handleRefundStatus(w http.ResponseWriter, r *http.Request) {
+	if !allow(w, r, http.MethodGet) {
+		return
+	}
+	id := strings.TrimPrefix(r.URL.Path, "/refunds/")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ref, ok := s.refunds[id]
+	if !ok {
+		http.Error(w, "refund not found", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, http.StatusOK, s.refundStatus(ref))
+}
+
+// refundStatus reports a refund as pending until the settle delay has passed
+func (s *server) refundStatus(ref *refund) map[string]any {
+	if time.Since(ref.RequestedAt) < time.Duration(s.behavior.RefundSettleAfter) {
+		return map[string]any{"refund_id": ref.ID, "status": "pending"}
+	}
+	status := map[string]any{"refund_id": ref.ID, "status": s.behavior.RefundOutcome}
+	if s.behavior.RefundOutcome == "failed" {
+		status["failure_reason"] = "declined by mock billing"
+	}
+	return status
+}
+
+func (s *server) handleCharge(w http.ResponseWriter, r *http.Request) {
+	if !allow(w, r, http.MethodPost) {
+		return
+	}
+
+	var req struct {
+		CustomerID     string `json:"customer_id"`
+		SubscriptionID string `json:"subscription_id"`
+		Amount         int64  `json:"amount"`
+		Currency       string `json:"currency"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Amount <= 0 || req.Currency == "" {
+		http.Error(w, "invalid charge request", http.StatusBadRequest)
+		return
+	}
+	key := r.Header.Get("Idempotency-Key")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.behavior.chargeDeclined(req.CustomerID) {
+		http.Error(w, "card declined", http.StatusPaymentRequired)
+		return
+	}
+	if s.behavior.chargeRequiresAuthentication(req.CustomerID) {
+		s.nextPayment++
+		paymentID := fmt.Sprintf("mock-payment-%d", s.nextPayment)
+		writeJSON(w, http.StatusPaymentRequired, map[string]any{
+			"status":     "requires_action",
+			"payment_id": paymentID,
+			"action_url": "https://billing.example/authenticate/" + paymentID,
+		})
+		return
+	}
+	if key != "" && s.chargesByKey[key] {
+		writeJSON(w, http.StatusOK, map[string]any{"status": "succeeded", "replayed": true})
+		return
+	}
+	if key != "" {
+		s.chargesByKey[key] = true
+	}
+	s.subscriptions[req.SubscriptionID] = &subscription{ID: req.SubscriptionID, CustomerID: req.CustomerID, Status: "active", Currency: req.Currency}
+
+	writeJSON(w, http.StatusOK, map[string]any{"status": "succeeded"})
+}
+
+func (s *server) handleListSubscriptions(w http.ResponseWriter, r *http.Request) {
+	if !allow(w, r, http.MethodGet) {
+		return
+	}
+
+	s.mu.Lock()
+	subs := make([]*subscription, 0, len(s.subscriptions))
+	for _, sub := range s.subscriptions {
+		subs = append(subs, sub)
+	}
+	s.mu.Unlock()
+
+	sort.Slice(subs, func(i, j int) bool { return subs[i].ID < subs[j].ID })
+	writeJSON(w, http.StatusOK, map[string]any{"subscriptions": subs, "next_page_token": ""})
+}
+
+func (s *server) handleCancelSubscription(w http.ResponseWriter, r *http.Request) {
+	id, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/subscriptions/"), "/cancel")
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	if !allow(w, r, http.MethodPost) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sub, found := s.subscriptions[id]
+	if !found {
+		http.Error(w, "subscription not found", http.StatusNotFound)
+		return
+	}
+	sub.Status = "cancelled"
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *server) handleListProducts(w http.ResponseWriter, r *http.Request) {
+	if !allow(w, r, http.MethodGet) {
+		return
+	}
+
+	s.mu.Lock()
+	products := append([]Product{}, s.behavior.Catalog...)
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]any{"products": products, "next_page_token": ""})
+}
+
+// handleBehavior returns the current behavior on GET and replaces it on PUT
+func (s *server) handleBehavior(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.mu.Lock()
+		b := s.behavior
+		s.mu.Unlock()
+		writeJSON(w, http.StatusOK, b)
+	case http.MethodPut:
+		b := defaultBehavior()
+		if err := json.NewDecoder(r.Body).Decode(&b); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.mu.Lock()
+		s.behavior = b
+		s.requests = 0
+		s.mu.Unlock()
+		writeJSON(w, http.StatusOK, b)
+	default:
+		w.Header().Set("Allow", "GET, PUT")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// notifySettledRefunds sends a signed webhook for each refund that settled since the last call
+func (s *server) notifySettledRefunds() {
+	if s.webhookURL == "" {
+		return
+	}
+
+	s.mu.Lock()
+	var pending []map[string]any
+	for _, ref := range s.refunds {
+		if ref.notified {
+			continue
+		}
+		status := s.refundStatus(ref)
+		if status["status"] == "pending" {
+			continue
+		}
+		ref.notified = true
+		pending = append(pending, status)
+	}
+	s.mu.Unlock()
+
+	for _, status := range pending {
+		body, _ := json.Marshal(status)
+		mac := hmac.New(sha256.New, s.webhookSecret)
+		mac.Write(body)
+
+		req, err := http.NewRequest(http.MethodPost, s.webhookURL, bytes.NewReader(body))
+		if err != nil {
+			s.logger.Error("failed to build webhook", slog.Any("error", err))
+			continue
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Billing-Signature", hex.EncodeToString(mac.Sum(nil)))
+
+		resp, err := s.client.Do(req)
+		if err != nil {
+			s.logger.Error("refund webhook failed", slog.Any("refund_id", status["refund_id"]), slog.Any("error", err))
+			continue
+		}
+		resp.Body.Close()
+		s.logger.Info("refund webhook sent", slog.Any("refund_id", status["refund_id"]), slog.Int("status", resp.StatusCode))
+	}
+}
+
+// allow rejects requests whose method isn't method
+func allow(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method == method {
+		return true
+	}
+	w.Header().Set("Allow", method)
+	http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	return false
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
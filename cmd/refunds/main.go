@@ -0,0 +1,220 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/adapters"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/debug"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/faults"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/health"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/logging"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/metrics"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/migrations"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/recovery"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/repo"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/tracing"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/transport/webhook"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/instrument"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/poll_refund_status"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/record_refund_outcome"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/send_queued_refund"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/workers/refunds"
+	"github.com/wuyiadepoju/subscription-management/internal/bootstrap"
+	"github.com/wuyiadepoju/subscription-management/internal/config"
+	"github.com/wuyiadepoju/subscription-management/internal/lifecycle"
+	"github.com/wuyiadepoju/subscription-management/internal/telemetry"
+)
+
+func main() {
+	loader := config.NewLoader(flag.CommandLine, config.SectionSpanner|config.SectionBilling|config.SectionBillingProviders|config.SectionMetrics|config.SectionDebug|config.SectionFaults|config.SectionSecrets|config.SectionTelemetry|config.SectionHealth, config.Default())
+	var (
+		webhookAddr = flag.String("webhook-addr", "", "Listen address for refund webhooks (e.g. :8082); empty disables them. Requires REFUND_WEBHOOK_SECRET")
+		interval    = flag.Duration("interval", 5*time.Minute, "Time between poll passes")
+		sendEvery   = flag.Duration("send-interval", 30*time.Second, "Time between passes sending refunds queued by bulk cancellations")
+		minAge      = flag.Duration("min-age", 10*time.Minute, "Only poll refunds pending for at least this long")
+		batchSize   = flag.Int("batch-size", 500, "Maximum refunds polled or sent per pass")
+		concurrency = flag.Int("concurrency", 8, "Maximum status lookups or refund requests in flight")
+		once        = flag.Bool("once", false, "Run a single send pass and poll pass and exit")
+	)
+	flag.Parse()
+
+	cfg, err := loader.Load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	logger, err := logging.New(os.Stdout, cfg.Log.Level, cfg.Log.Format)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	app := lifecycle.New(logger, cfg.ShutdownTimeout)
+	ctx := app.Context()
+
+	secrets, err := adapters.NewSecretProvider(ctx, adapters.SecretsConfig{
+		Backend:  adapters.SecretsBackend(cfg.Secrets.Backend),
+		Project:  cfg.Secrets.Project,
+		CacheTTL: cfg.Secrets.CacheTTL,
+		Logger:   logger,
+	})
+	if err != nil {
+		app.Fatal("failed to create secret provider", err)
+	}
+
+	tracer, err := telemetry.NewTracer(app, cfg, "refunds", logger)
+	if err != nil {
+		app.Fatal("failed to configure tracing", err)
+	}
+
+	client, err := bootstrap.Spanner(app, cfg, logger)
+	if err != nil {
+		app.Fatal("failed to open Spanner", err)
+	}
+
+	metricsRegistry := metrics.NewRegistry()
+	if err := telemetry.StartMetrics(app, cfg, metricsRegistry, "refunds", logger); err != nil {
+		app.Fatal("failed to configure metrics", err)
+	}
+	if cfg.Debug.Addr != "" {
+		debugServer, err := debug.NewServer(ctx, cfg.Debug.Addr, secrets, logger)
+		if err != nil {
+			app.Fatal("failed to start debug endpoints", err)
+		}
+		app.Serve("debug", debugServer)
+	}
+	resilience := adapters.DefaultResilienceConfig()
+	injector, err := faults.New(cfg.Faults.Rules, cfg.Faults.Header, logger)
+	if err != nil {
+		app.Fatal("invalid fault rules", err)
+	}
+	hints, err := repo.ParseQueryHints(cfg.Spanner.QueryHints)
+	if err != nil {
+		app.Fatal("invalid query hints", err)
+	}
+
+	priority, err := repo.ParsePriority(cfg.Spanner.Priority, repo.PriorityWorker)
+	if err != nil {
+		app.Fatal("invalid Spanner priority", err)
+	}
+	billingCfg := adapters.BillingConfig{
+		Provider:   adapters.BillingProvider(cfg.Billing.Provider),
+		BaseURL:    cfg.Billing.URL,
+		Sandbox:    cfg.Billing.PaddleSandbox,
+		Timeout:    30 * time.Second,
+		Resilience: &resilience,
+		Auth: adapters.BillingAuthConfig{
+			Method:       adapters.AuthMethod(cfg.Billing.Auth),
+			Secrets:      secrets,
+			APIKeyHeader: cfg.Billing.APIKeyHeader,
+			TokenURL:     cfg.Billing.TokenURL,
+			ClientID:     cfg.Billing.ClientID,
+			Scopes:       cfg.Billing.Scopes,
+		},
+
+		CallTimeout: cfg.Billing.Timeout,
+		Metrics:     metricsRegistry,
+		Tracer:      tracer,
+		Logger:      logger,
+		Faults:      injector,
+	}
+	if billingCfg.Provider == adapters.ProviderPaddle {
+		if billingCfg.APIKey, err = secrets.Secret(ctx, "paddle-api-key"); err != nil {
+			logger.Error("failed to load Paddle API key", slog.Any("error", err))
+			os.Exit(1)
+		}
+	}
+	billingClient, err := adapters.NewBillingClient(ctx, billingCfg)
+	if err != nil {
+		app.Fatal("failed to create billing client", err)
+	}
+
+	if cfg.Health.Addr != "" {
+		readiness := health.NewChecker(cfg.Health.Timeout, logger)
+		readiness.Add("spanner", func(ctx context.Context) error { return repo.Ping(ctx, client) })
+		readiness.Add("schema", func(ctx context.Context) error { return migrations.CheckSchema(ctx, client) })
+		pinger, err := adapters.NewBillingPinger(ctx, billingCfg)
+		if err != nil {
+			app.Fatal("failed to create billing health check", err)
+		}
+		readiness.Add("billing", pinger.Ping)
+		app.Serve("health", health.NewServer(cfg.Health.Addr, readiness))
+	}
+
+	clock := domain.RealClock{}
+	in := instrument.Instrumentation{Logger: logger, Metrics: metricsRegistry, Tracer: tracer}
+	refundRepo := repo.NewRefundRepo(client, repo.WithTimeout(cfg.Spanner.Timeout), repo.WithTracer(tracer), repo.WithMetrics(metricsRegistry), repo.WithLogger(logger), repo.WithFaults(injector), repo.WithQueryHints(hints), repo.WithPriority(priority))
+
+	poller := refunds.NewPoller(refundRepo, poll_refund_status.NewInstrumented(
+		poll_refund_status.NewInteractor(refundRepo, repo.NewSubscriptionRepo(client, repo.WithTimeout(cfg.Spanner.Timeout), repo.WithTracer(tracer), repo.WithMetrics(metricsRegistry), repo.WithLogger(logger), repo.WithFaults(injector), repo.WithPriority(priority)), adapters.StaticBillingResolver{Client: billingClient}, clock),
+		in,
+	), clock, metricsRegistry, logger, refunds.Config{
+		BatchSize:   *batchSize,
+		Concurrency: *concurrency,
+		MinAge:      *minAge,
+	})
+
+	outboxRepo := repo.NewRefundOutboxRepo(client, repo.WithTimeout(cfg.Spanner.Timeout), repo.WithTracer(tracer), repo.WithMetrics(metricsRegistry), repo.WithLogger(logger), repo.WithFaults(injector), repo.WithQueryHints(hints), repo.WithPriority(priority))
+	sender := refunds.NewSender(outboxRepo, send_queued_refund.NewInstrumented(
+		send_queued_refund.NewInteractor(outboxRepo, refundRepo, adapters.StaticBillingResolver{Client: billingClient}, clock),
+		in,
+	), clock, metricsRegistry, logger, refunds.SenderConfig{
+		BatchSize:   *batchSize,
+		Concurrency: *concurrency,
+	})
+
+	if *once {
+		app.Go("refund passes", func(ctx context.Context) error {
+			if _, err := sender.RunOnce(ctx); err != nil {
+				return err
+			}
+			_, err := poller.RunOnce(ctx)
+			return err
+		})
+		if err := app.Wait(); err != nil {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *webhookAddr != "" {
+		// Fail fast without a key; the verifier resolves keys per request so rotations apply
+		if _, err := secrets.Secret(ctx, "refund-webhook-secret"); err != nil {
+			app.Fatal("failed to load webhook secret", err)
+		}
+		verifier := adapters.SecretHMACVerifier{
+			Secrets: secrets,
+			Names:   []string{"refund-webhook-secret", "refund-webhook-secret-previous"},
+			Logger:  logger,
+		}
+
+		mux := http.NewServeMux()
+		mux.Handle("/webhooks/refunds", tracing.Middleware(tracer, "POST /webhooks/refunds", recovery.Middleware(logger, metricsRegistry, "refund_webhook", webhook.NewRefundHandler(
+			record_refund_outcome.NewInstrumented(record_refund_outcome.NewInteractor(refundRepo, clock), in),
+			verifier,
+			logger,
+		))))
+		server := &http.Server{Addr: *webhookAddr, Handler: injector.Middleware(mux), ReadHeaderTimeout: 10 * time.Second}
+
+		app.Serve("refund webhook", server)
+	}
+
+	logger.Info("refund poller started", slog.Duration("interval", *interval), slog.Duration("min_age", *minAge), slog.Duration("send_interval", *sendEvery))
+	app.Go("refund poller", func(ctx context.Context) error {
+		return poller.Run(ctx, *interval)
+	})
+	app.Go("refund sender", func(ctx context.Context) error {
+		return sender.Run(ctx, *sendEvery)
+	})
+
+	if err := app.Wait(); err != nil {
+		os.Exit(1)
+	}
+}
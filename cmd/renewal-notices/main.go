@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/adapters"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/debug"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/faults"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/health"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/logging"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/metrics"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/migrations"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/repo"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/instrument"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/notify_renewal"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/workers/renewalnotices"
+	"github.com/wuyiadepoju/subscription-management/internal/bootstrap"
+	"github.com/wuyiadepoju/subscription-management/internal/config"
+	"github.com/wuyiadepoju/subscription-management/internal/lifecycle"
+	"github.com/wuyiadepoju/subscription-management/internal/telemetry"
+)
+
+func main() {
+	policy := domain.RenewalNoticePolicy{DefaultDays: 7}
+
+	loader := config.NewLoader(flag.CommandLine, config.SectionSpanner|config.SectionRenewal|config.SectionMetrics|config.SectionDebug|config.SectionFaults|config.SectionSecrets|config.SectionTelemetry|config.SectionHealth|config.SectionDiscounts, config.Default())
+	var (
+		interval      = flag.Duration("interval", time.Hour, "Time between notice passes")
+		defaultRegion = flag.String("default-region", "", "Region every customer is treated as being in, for the regional minimum notice")
+		batchSize     = flag.Int("batch-size", 500, "Maximum subscriptions looked at per pass")
+		concurrency   = flag.Int("concurrency", 8, "Maximum notices in flight")
+		once          = flag.Bool("once", false, "Run a single pass and exit")
+	)
+	flag.Int64Var(&policy.DefaultDays, "notice-days", policy.DefaultDays, "Days before a renewal the customer is told about it; 0 sends no notice unless a plan or region asks for one")
+	flag.Func("plan-notice-days", "Comma-separated plan=days notice overrides (e.g. annual=30,free=0)", func(s string) (err error) {
+		policy.PlanDays, err = domain.ParseNoticeDays(s)
+		return err
+	})
+	flag.Func("region-notice-days", "Comma-separated region=days legal minimum notice (e.g. US-CA=15)", func(s string) (err error) {
+		policy.RegionMinimumDays, err = domain.ParseNoticeDays(s)
+		return err
+	})
+	flag.Parse()
+
+	cfg, err := loader.Load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if err := policy.Validate(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	logger, err := logging.New(os.Stdout, cfg.Log.Level, cfg.Log.Format)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	app := lifecycle.New(logger, cfg.ShutdownTimeout)
+	ctx := app.Context()
+
+	secrets, err := adapters.NewSecretProvider(ctx, adapters.SecretsConfig{
+		Backend:  adapters.SecretsBackend(cfg.Secrets.Backend),
+		Project:  cfg.Secrets.Project,
+		CacheTTL: cfg.Secrets.CacheTTL,
+		Logger:   logger,
+	})
+	if err != nil {
+		app.Fatal("failed to create secret provider", err)
+	}
+
+	tracer, err := telemetry.NewTracer(app, cfg, "renewal-notices", logger)
+	if err != nil {
+		app.Fatal("failed to configure tracing", err)
+	}
+
+	client, err := bootstrap.Spanner(app, cfg, logger)
+	if err != nil {
+		app.Fatal("failed to open Spanner", err)
+	}
+
+	clock := domain.RealClock{}
+	metricsRegistry := metrics.NewRegistry()
+	if err := telemetry.StartMetrics(app, cfg, metricsRegistry, "renewal-notices", logger); err != nil {
+		app.Fatal("failed to configure metrics", err)
+	}
+	if cfg.Debug.Addr != "" {
+		debugServer, err := debug.NewServer(ctx, cfg.Debug.Addr, secrets, logger)
+		if err != nil {
+			app.Fatal("failed to start debug endpoints", err)
+		}
+		app.Serve("debug", debugServer)
+	}
+	injector, err := faults.New(cfg.Faults.Rules, cfg.Faults.Header, logger)
+	if err != nil {
+		app.Fatal("invalid fault rules", err)
+	}
+	hints, err := repo.ParseQueryHints(cfg.Spanner.QueryHints)
+	if err != nil {
+		app.Fatal("invalid query hints", err)
+	}
+
+	priority, err := repo.ParsePriority(cfg.Spanner.Priority, repo.PriorityWorker)
+	if err != nil {
+		app.Fatal("invalid Spanner priority", err)
+	}
+	subscriptionRepo := repo.NewSubscriptionRepo(client, repo.WithTimeout(cfg.Spanner.Timeout), repo.WithTracer(tracer), repo.WithMetrics(metricsRegistry), repo.WithLogger(logger), repo.WithFaults(injector), repo.WithQueryHints(hints), repo.WithPriority(priority))
+	// Periods last one billing cycle of the subscription's plan
+	cycles := adapters.PlanBillingCycles{Plans: repo.NewPlanRepo(client, repo.WithTimeout(cfg.Spanner.Timeout), repo.WithTracer(tracer), repo.WithMetrics(metricsRegistry), repo.WithLogger(logger), repo.WithFaults(injector), repo.WithPriority(priority)), DefaultDays: cfg.BillingCycleDays}
+	pricing := adapters.BundlePricing{
+		Bundles: repo.NewBundleRepo(client, repo.WithTimeout(cfg.Spanner.Timeout), repo.WithTracer(tracer), repo.WithMetrics(metricsRegistry), repo.WithLogger(logger), repo.WithFaults(injector), repo.WithPriority(priority)),
+		Base:    adapters.StaticPricing{Pricing: domain.Pricing{Policy: domain.DiscountPolicy{MaxStacked: int(cfg.Discounts.MaxStacked), MaxPercentOff: cfg.Discounts.MaxPercentOff}}},
+	}
+
+	noticeUseCase := notify_renewal.NewInstrumented(
+		notify_renewal.NewInteractor(subscriptionRepo, adapters.StaticRegions{Default: *defaultRegion}, pricing, adapters.LogRenewalNotices{Logger: logger}, clock, cycles, policy),
+		instrument.Instrumentation{Logger: logger, Metrics: metricsRegistry, Tracer: tracer},
+	)
+
+	scheduler := renewalnotices.NewScheduler(subscriptionRepo, noticeUseCase, clock, metricsRegistry, logger, renewalnotices.Config{
+		MaxNoticeDays:    policy.MaxDays(),
+		BillingCycleDays: cfg.BillingCycleDays,
+		BatchSize:        *batchSize,
+		Concurrency:      *concurrency,
+	})
+
+	if cfg.Health.Addr != "" {
+		readiness := health.NewChecker(cfg.Health.Timeout, logger)
+		readiness.Add("spanner", func(ctx context.Context) error { return repo.Ping(ctx, client) })
+		readiness.Add("schema", func(ctx context.Context) error { return migrations.CheckSchema(ctx, client) })
+		app.Serve("health", health.NewServer(cfg.Health.Addr, readiness))
+	}
+
+	if *once {
+		app.Go("renewal notice pass", func(ctx context.Context) error {
+			_, err := scheduler.RunOnce(ctx)
+			return err
+		})
+	} else {
+		logger.Info("renewal notice scheduler started", slog.Duration("interval", *interval), slog.Int64("max_notice_days", policy.MaxDays()))
+		app.Go("renewal notice scheduler", func(ctx context.Context) error {
+			return scheduler.Run(ctx, *interval)
+		})
+	}
+
+	if err := app.Wait(); err != nil {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/adapters"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/debug"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/faults"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/health"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/logging"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/metrics"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/migrations"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/repo"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/instrument"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/renew_subscription"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/workers/renewal"
+	"github.com/wuyiadepoju/subscription-management/internal/bootstrap"
+	"github.com/wuyiadepoju/subscription-management/internal/config"
+	"github.com/wuyiadepoju/subscription-management/internal/lifecycle"
+	"github.com/wuyiadepoju/subscription-management/internal/telemetry"
+)
+
+func main() {
+	schedule := domain.DefaultDunningSchedule
+
+	loader := config.NewLoader(flag.CommandLine, config.SectionSpanner|config.SectionBilling|config.SectionRenewal|config.SectionMetrics|config.SectionDebug|config.SectionFaults|config.SectionSecrets|config.SectionTelemetry|config.SectionHealth|config.SectionDiscounts, config.Default())
+	var (
+		interval    = flag.Duration("interval", time.Minute, "Time between renewal passes")
+		window      = flag.Duration("window", time.Hour, "Renew subscriptions whose period ends within this window")
+		batchSize   = flag.Int("batch-size", 500, "Maximum subscriptions renewed per pass")
+		concurrency = flag.Int("concurrency", 8, "Maximum renewals in flight")
+		once        = flag.Bool("once", false, "Run a single pass and exit")
+	)
+	flag.Func("schedule", "Comma-separated delays before each payment retry once a renewal charge is declined (default 24h,72h,72h)", func(s string) error {
+		parsed, err := domain.ParseDunningSchedule(s)
+		if err != nil {
+			return err
+		}
+		schedule = parsed
+		return nil
+	})
+	flag.Parse()
+
+	cfg, err := loader.Load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	logger, err := logging.New(os.Stdout, cfg.Log.Level, cfg.Log.Format)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	app := lifecycle.New(logger, cfg.ShutdownTimeout)
+	ctx := app.Context()
+
+	secrets, err := adapters.NewSecretProvider(ctx, adapters.SecretsConfig{
+		Backend:  adapters.SecretsBackend(cfg.Secrets.Backend),
+		Project:  cfg.Secrets.Project,
+		CacheTTL: cfg.Secrets.CacheTTL,
+		Logger:   logger,
+	})
+	if err != nil {
+		app.Fatal("failed to create secret provider", err)
+	}
+
+	tracer, err := telemetry.NewTracer(app, cfg, "renewer", logger)
+	if err != nil {
+		app.Fatal("failed to configure tracing", err)
+	}
+
+	client, err := bootstrap.Spanner(app, cfg, logger)
+	if err != nil {
+		app.Fatal("failed to open Spanner", err)
+	}
+
+	clock := domain.RealClock{}
+	metricsRegistry := metrics.NewRegistry()
+	if err := telemetry.StartMetrics(app, cfg, metricsRegistry, "renewer", logger); err != nil {
+		app.Fatal("failed to configure metrics", err)
+	}
+	if cfg.Debug.Addr != "" {
+		debugServer, err := debug.NewServer(ctx, cfg.Debug.Addr, secrets, logger)
+		if err != nil {
+			app.Fatal("failed to start debug endpoints", err)
+		}
+		app.Serve("debug", debugServer)
+	}
+	injector, err := faults.New(cfg.Faults.Rules, cfg.Faults.Header, logger)
+	if err != nil {
+		app.Fatal("invalid fault rules", err)
+	}
+	hints, err := repo.ParseQueryHints(cfg.Spanner.QueryHints)
+	if err != nil {
+		app.Fatal("invalid query hints", err)
+	}
+
+	priority, err := repo.ParsePriority(cfg.Spanner.Priority, repo.PriorityWorker)
+	if err != nil {
+		app.Fatal("invalid Spanner priority", err)
+	}
+	subscriptionRepo := repo.NewSubscriptionRepo(client, repo.WithTimeout(cfg.Spanner.Timeout), repo.WithTracer(tracer), repo.WithMetrics(metricsRegistry), repo.WithLogger(logger), repo.WithFaults(injector), repo.WithQueryHints(hints), repo.WithPriority(priority))
+	// Periods last one billing cycle of the subscription's plan
+	cycles := adapters.PlanBillingCycles{Plans: repo.NewPlanRepo(client, repo.WithTimeout(cfg.Spanner.Timeout), repo.WithTracer(tracer), repo.WithMetrics(metricsRegistry), repo.WithLogger(logger), repo.WithFaults(injector), repo.WithPriority(priority)), DefaultDays: cfg.BillingCycleDays}
+	creditRepo := repo.NewCreditBalanceRepo(client, repo.WithTimeout(cfg.Spanner.Timeout), repo.WithTracer(tracer), repo.WithMetrics(metricsRegistry), repo.WithLogger(logger), repo.WithFaults(injector), repo.WithPriority(priority))
+	referralRepo := repo.NewReferralRepo(client, repo.WithTimeout(cfg.Spanner.Timeout), repo.WithTracer(tracer), repo.WithMetrics(metricsRegistry), repo.WithLogger(logger), repo.WithFaults(injector), repo.WithPriority(priority))
+	authenticationRepo := repo.NewChargeAuthenticationRepo(client, repo.WithTimeout(cfg.Spanner.Timeout), repo.WithTracer(tracer), repo.WithMetrics(metricsRegistry), repo.WithLogger(logger), repo.WithFaults(injector), repo.WithPriority(priority))
+	referralReward := domain.ReferralReward{ReferrerCredit: cfg.Referrals.ReferrerCredit, RefereeCredit: cfg.Referrals.RefereeCredit}
+	pricing := adapters.BundlePricing{
+		Bundles: repo.NewBundleRepo(client, repo.WithTimeout(cfg.Spanner.Timeout), repo.WithTracer(tracer), repo.WithMetrics(metricsRegistry), repo.WithLogger(logger), repo.WithFaults(injector), repo.WithPriority(priority)),
+		Base:    adapters.StaticPricing{Pricing: domain.Pricing{Policy: domain.DiscountPolicy{MaxStacked: int(cfg.Discounts.MaxStacked), MaxPercentOff: cfg.Discounts.MaxPercentOff}}},
+	}
+	// Deployments register their lifecycle hooks here, e.g. a CRM sync
+	hooks := adapters.HookChain{}
+
+	resilience := adapters.DefaultResilienceConfig()
+	billingCfg := adapters.BillingConfig{
+		Provider: adapters.ProviderHTTP,
+		BaseURL:  cfg.Billing.URL,
+		Timeout:  30 * time.Second,
+		Auth: adapters.BillingAuthConfig{
+			Method:       adapters.AuthMethod(cfg.Billing.Auth),
+			Secrets:      secrets,
+			APIKeyHeader: cfg.Billing.APIKeyHeader,
+			TokenURL:     cfg.Billing.TokenURL,
+			ClientID:     cfg.Billing.ClientID,
+			Scopes:       cfg.Billing.Scopes,
+		},
+		CallTimeout: cfg.Billing.Timeout,
+		Resilience:  &resilience,
+		Metrics:     metricsRegistry,
+		Tracer:      tracer,
+		Logger:      logger,
+		Faults:      injector,
+	}
+	billingClient, err := adapters.NewBillingClient(ctx, billingCfg)
+	if err != nil {
+		app.Fatal("failed to create billing client", err)
+	}
+
+	renewer := renew_subscription.NewInstrumented(
+		renew_subscription.NewInteractor(subscriptionRepo, creditRepo, referralRepo, authenticationRepo, adapters.StaticBillingResolver{Client: billingClient}, pricing, hooks, adapters.LogAuthenticationRequests{Logger: logger}, clock, cycles, *window, schedule, referralReward),
+		instrument.Instrumentation{Logger: logger, Metrics: metricsRegistry, Tracer: tracer},
+	)
+
+	scheduler := renewal.NewScheduler(subscriptionRepo, cycles, renewer, clock, metricsRegistry, logger, renewal.Config{
+		Window:      *window,
+		BatchSize:   *batchSize,
+		Concurrency: *concurrency,
+	})
+
+	if cfg.Health.Addr != "" {
+		readiness := health.NewChecker(cfg.Health.Timeout, logger)
+		readiness.Add("spanner", func(ctx context.Context) error { return repo.Ping(ctx, client) })
+		readiness.Add("schema", func(ctx context.Context) error { return migrations.CheckSchema(ctx, client) })
+		pinger, err := adapters.NewBillingPinger(ctx, billingCfg)
+		if err != nil {
+			app.Fatal("failed to create billing health check", err)
+		}
+		readiness.Add("billing", pinger.Ping)
+		app.Serve("health", health.NewServer(cfg.Health.Addr, readiness))
+	}
+
+	if *once {
+		app.Go("renewal pass", func(ctx context.Context) error {
+			_, err := scheduler.RunOnce(ctx)
+			return err
+		})
+	} else {
+		logger.Info("renewer started", slog.Duration("interval", *interval), slog.Duration("window", *window))
+		app.Go("renewer", func(ctx context.Context) error {
+			return scheduler.Run(ctx, *interval)
+		})
+	}
+
+	if err := app.Wait(); err != nil {
+		os.Exit(1)
+	}
+}
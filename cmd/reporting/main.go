@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/adapters"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/audit"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/debug"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/health"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/logging"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/metrics"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/migrations"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/recovery"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/repo"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/tracing"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/transport/admin"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/transport/portal"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/erase_customer"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/export_cancellation_reasons"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/export_cancellation_surveys"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/export_cohort_retention"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/instrument"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/issue_portal_session"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/list_subscriptions"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/refresh_reporting"
+	"github.com/wuyiadepoju/subscription-management/internal/bootstrap"
+	"github.com/wuyiadepoju/subscription-management/internal/config"
+	"github.com/wuyiadepoju/subscription-management/internal/lifecycle"
+	"github.com/wuyiadepoju/subscription-management/internal/telemetry"
+)
+
+func main() {
+	loader := config.NewLoader(flag.CommandLine, config.SectionSpanner|config.SectionMetrics|config.SectionDebug|config.SectionSecrets|config.SectionTelemetry|config.SectionHealth, config.Default())
+	var (
+		adminAddr  = flag.String("admin-addr", ":8083", "Listen address for the admin API; empty only refreshes the projection. Requires ADMIN_TOKEN")
+		interval   = flag.Duration("interval", 15*time.Minute, "Time between projection refreshes")
+		windowDays = flag.Int("window-days", refresh_reporting.DefaultWindowDays, "Days of new and cancelled counts kept")
+		staleness  = flag.Duration("staleness", 10*time.Second, "Read the operational tables this far in the past, at one timestamp, so a refresh never waits on writes; 0 reads the latest")
+		once       = flag.Bool("once", false, "Refresh the projection once and exit")
+	)
+	flag.Parse()
+
+	cfg, err := loader.Load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	logger, err := logging.New(os.Stdout, cfg.Log.Level, cfg.Log.Format)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	app := lifecycle.New(logger, cfg.ShutdownTimeout)
+	ctx := app.Context()
+
+	secrets, err := adapters.NewSecretProvider(ctx, adapters.SecretsConfig{
+		Backend:  adapters.SecretsBackend(cfg.Secrets.Backend),
+		Project:  cfg.Secrets.Project,
+		CacheTTL: cfg.Secrets.CacheTTL,
+		Logger:   logger,
+	})
+	if err != nil {
+		app.Fatal("failed to create secret provider", err)
+	}
+
+	tracer, err := telemetry.NewTracer(app, cfg, "reporting", logger)
+	if err != nil {
+		app.Fatal("failed to configure tracing", err)
+	}
+
+	client, err := bootstrap.Spanner(app, cfg, logger)
+	if err != nil {
+		app.Fatal("failed to open Spanner", err)
+	}
+
+	hints, err := repo.ParseQueryHints(cfg.Spanner.QueryHints)
+	if err != nil {
+		app.Fatal("invalid query hints", err)
+	}
+
+	priority, err := repo.ParsePriority(cfg.Spanner.Priority, repo.PriorityBulk)
+	if err != nil {
+		app.Fatal("invalid Spanner priority", err)
+	}
+
+	metricsRegistry := metrics.NewRegistry()
+	if err := telemetry.StartMetrics(app, cfg, metricsRegistry, "reporting", logger); err != nil {
+		app.Fatal("failed to configure metrics", err)
+	}
+	if cfg.Debug.Addr != "" {
+		debugServer, err := debug.NewServer(ctx, cfg.Debug.Addr, secrets, logger)
+		if err != nil {
+			app.Fatal("failed to start debug endpoints", err)
+		}
+		app.Serve("debug", debugServer)
+	}
+
+	if cfg.Health.Addr != "" {
+		readiness := health.NewChecker(cfg.Health.Timeout, logger)
+		readiness.Add("spanner", func(ctx context.Context) error { return repo.Ping(ctx, client) })
+		readiness.Add("schema", func(ctx context.Context) error { return migrations.CheckSchema(ctx, client) })
+		app.Serve("health", health.NewServer(cfg.Health.Addr, readiness))
+	}
+
+	reportingRepo := repo.NewReportingRepo(client, repo.WithTimeout(cfg.Spanner.Timeout), repo.WithTracer(tracer), repo.WithMetrics(metricsRegistry), repo.WithLogger(logger), repo.WithPriority(priority))
+	refresher := refresh_reporting.NewInstrumented(
+		refresh_reporting.NewInteractor(reportingRepo, domain.RealClock{}, *windowDays),
+		instrument.Instrumentation{Logger: logger, Metrics: metricsRegistry, Tracer: tracer},
+	)
+
+	refresh := func(ctx context.Context) error {
+		aggregates, err := refresher.Execute(ctx, refresh_reporting.Request{Staleness: *staleness})
+		if err != nil {
+			return err
+		}
+		logger.Info("reporting projection refreshed",
+			slog.Time("read_at", aggregates.ReadAt),
+			slog.Int("plans", len(aggregates.ActiveByPlan)),
+			slog.Int("days", len(aggregates.Daily)),
+			slog.Int("refund_totals", len(aggregates.Refunds)),
+		)
+		return nil
+	}
+
+	if *once {
+		app.Go("reporting refresh", refresh)
+		if err := app.Wait(); err != nil {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *adminAddr != "" {
+		if _, err := secrets.Secret(ctx, admin.TokenSecret); err != nil {
+			app.Fatal("admin API requires a token", err)
+		}
+		in := instrument.Instrumentation{Logger: logger, Metrics: metricsRegistry, Tracer: tracer}
+		cohorts := export_cohort_retention.NewInstrumented(
+			export_cohort_retention.NewInteractor(reportingRepo, domain.RealClock{}),
+			in,
+		)
+		surveys := export_cancellation_surveys.NewInstrumented(
+			export_cancellation_surveys.NewInteractor(repo.NewSurveyRepo(client, repo.WithTimeout(cfg.Spanner.Timeout), repo.WithTracer(tracer), repo.WithMetrics(metricsRegistry), repo.WithLogger(logger), repo.WithPriority(priority)), domain.RealClock{}),
+			in,
+		)
+		subscriptionRepo := repo.NewSubscriptionRepo(client, repo.WithTimeout(cfg.Spanner.Timeout), repo.WithTracer(tracer), repo.WithMetrics(metricsRegistry), repo.WithLogger(logger), repo.WithQueryHints(hints), repo.WithPriority(priority))
+		reasons := export_cancellation_reasons.NewInstrumented(export_cancellation_reasons.NewInteractor(subscriptionRepo, domain.RealClock{}), in)
+		subscriptions := list_subscriptions.NewInstrumented(list_subscriptions.NewInteractor(subscriptionRepo), in)
+		// Portal sessions are served once a signing key exists
+		var portalSessions issue_portal_session.UseCase
+		if _, err := secrets.Secret(ctx, portal.TokenSecret); err == nil {
+			tokens := adapters.HMACPortalTokens{Secrets: secrets, Names: []string{portal.TokenSecret, portal.TokenSecretPrevious}, Logger: logger}
+			portalSessions = issue_portal_session.NewInstrumented(issue_portal_session.NewInteractor(tokens, domain.RealClock{}), in)
+		} else {
+			logger.Info("portal sessions disabled: no signing key", slog.String("secret", portal.TokenSecret))
+		}
+		// Erasures are served once a key to sign their reports exists
+		var erasures erase_customer.UseCase
+		if key, err := secrets.Secret(ctx, admin.ErasureKeySecret); err == nil {
+			signer, err := adapters.NewHMACSigner([]byte(key))
+			if err != nil {
+				app.Fatal("invalid erasure signing key", err)
+			}
+			erasureRepo := repo.NewErasureRepo(client, repo.WithTimeout(cfg.Spanner.Timeout), repo.WithTracer(tracer), repo.WithMetrics(metricsRegistry), repo.WithLogger(logger))
+			erasures = erase_customer.NewInstrumented(erase_customer.NewInteractor(erasureRepo, signer, domain.RealClock{}), in)
+		} else {
+			logger.Info("erasures disabled: no signing key", slog.String("secret", admin.ErasureKeySecret))
+		}
+		recorder := audit.NewRecorder(
+			repo.NewAuditRepo(client, repo.WithTimeout(cfg.Spanner.Timeout), repo.WithTracer(tracer), repo.WithMetrics(metricsRegistry), repo.WithLogger(logger)),
+			logger,
+			nil,
+		)
+		handler := tracing.Middleware(tracer, "GET /admin", recovery.Middleware(logger, metricsRegistry, "admin_api",
+			admin.NewHandler(reportingRepo, cohorts, surveys, reasons, portalSessions, subscriptions, erasures, recorder, secrets, logger),
+		))
+		app.Serve("admin API", &http.Server{Addr: *adminAddr, Handler: handler, ReadHeaderTimeout: 10 * time.Second})
+	}
+
+	app.Go("reporting", func(ctx context.Context) error {
+		ticker := time.NewTicker(*interval)
+		defer ticker.Stop()
+
+		for {
+			if err := refresh(ctx); err != nil && ctx.Err() == nil {
+				logger.Error("reporting refresh failed", slog.Any("error", err))
+			}
+
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-ticker.C:
+			}
+		}
+	})
+
+	if err := app.Wait(); err != nil {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/adapters"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/logging"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/repo"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/enforce_retention"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/instrument"
+	"github.com/wuyiadepoju/subscription-management/internal/bootstrap"
+	"github.com/wuyiadepoju/subscription-management/internal/config"
+	"github.com/wuyiadepoju/subscription-management/internal/lifecycle"
+	"github.com/wuyiadepoju/subscription-management/internal/telemetry"
+)
+
+func main() {
+	loader := config.NewLoader(flag.CommandLine, config.SectionSpanner|config.SectionTelemetry, config.Default())
+	var (
+		retention = flag.Duration("retention", 2*365*24*time.Hour, "How long cancelled subscriptions are kept")
+		action    = flag.String("action", "anonymize", "What to do with expired rows: anonymize or delete")
+		dryRun    = flag.Bool("dry-run", false, "Count affected rows without changing them")
+		interval  = flag.Duration("interval", 24*time.Hour, "Time between retention passes")
+		once      = flag.Bool("once", false, "Run a single pass and exit")
+	)
+	flag.Parse()
+
+	cfg, err := loader.Load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	logger, err := logging.New(os.Stdout, cfg.Log.Level, cfg.Log.Format)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	policy := enforce_retention.Policy{
+		Retention: *retention,
+		Action:    contracts.RetentionAction(strings.ToUpper(*action)),
+	}
+	if policy.Action != contracts.RetentionAnonymize && policy.Action != contracts.RetentionDelete {
+		logger.Error("invalid -action: must be anonymize or delete", slog.String("action", *action))
+		os.Exit(2)
+	}
+
+	app := lifecycle.New(logger, cfg.ShutdownTimeout)
+
+	tracer, err := telemetry.NewTracer(app, cfg, "retention", logger)
+	if err != nil {
+		app.Fatal("failed to configure tracing", err)
+	}
+
+	client, err := bootstrap.Spanner(app, cfg, logger)
+	if err != nil {
+		app.Fatal("failed to open Spanner", err)
+	}
+
+	priority, err := repo.ParsePriority(cfg.Spanner.Priority, repo.PriorityBulk)
+	if err != nil {
+		app.Fatal("invalid Spanner priority", err)
+	}
+
+	enforcer := enforce_retention.NewInstrumented(
+		enforce_retention.NewInteractor(repo.NewRetentionRepo(client, repo.WithPriority(priority)), domain.RealClock{}, policy),
+		instrument.Instrumentation{Logger: logger, Metrics: adapters.NoopMetrics{}, Tracer: tracer},
+	)
+
+	run := func(ctx context.Context) error {
+		// A purge in flight at shutdown completes rather than being cut off
+		ctx, cancel := lifecycle.Detach(ctx)
+		defer cancel()
+
+		report, err := enforcer.Execute(ctx, enforce_retention.Request{DryRun: *dryRun})
+		if err != nil {
+			return err
+		}
+		logger.Info("retention pass complete",
+			slog.String("target", report.Target),
+			slog.String("action", string(report.Action)),
+			slog.Time("cutoff", report.Cutoff),
+			slog.Int64("rows_affected", report.RowsAffected),
+			slog.Bool("dry_run", report.DryRun),
+		)
+		return nil
+	}
+
+	if *once {
+		app.Go("retention pass", run)
+	} else {
+		app.Go("retention", func(ctx context.Context) error {
+			ticker := time.NewTicker(*interval)
+			defer ticker.Stop()
+
+			for {
+				if err := run(ctx); err != nil && ctx.Err() == nil {
+					logger.Error("retention pass failed", slog.Any("error", err))
+				}
+
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case <-ticker.C:
+				}
+			}
+		})
+	}
+
+	if err := app.Wait(); err != nil {
+		os.Exit(1)
+	}
+}
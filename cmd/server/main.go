@@ -0,0 +1,257 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"google.golang.org/grpc"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/adapters"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/audit"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/bus"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/debug"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/faults"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/health"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/logging"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/metrics"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/migrations"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/recovery"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/repo"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/tracing"
+	grpcapi "github.com/wuyiadepoju/subscription-management/internal/app/subscription/transport/grpc"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/transport/grpc/subscriptionv1"
+	httpapi "github.com/wuyiadepoju/subscription-management/internal/app/subscription/transport/http"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/attach_add_on"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/cancel_subscription"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/create_subscription"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/detach_add_on"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/get_subscription"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/instrument"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/list_subscriptions"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/preview_cancel"
+	"github.com/wuyiadepoju/subscription-management/internal/bootstrap"
+	"github.com/wuyiadepoju/subscription-management/internal/config"
+	"github.com/wuyiadepoju/subscription-management/internal/lifecycle"
+	"github.com/wuyiadepoju/subscription-management/internal/telemetry"
+)
+
+func main() {
+	loader := config.NewLoader(flag.CommandLine, config.SectionSpanner|config.SectionBilling|config.SectionRenewal|config.SectionMetrics|config.SectionDebug|config.SectionFaults|config.SectionSecrets|config.SectionTelemetry|config.SectionHealth|config.SectionDiscounts|config.SectionEvents, config.Default())
+	var (
+		addr      = flag.String("addr", ":8080", "Listen address for the subscriptions REST API; empty disables it. Requires API_TOKEN")
+		grpcAddr  = flag.String("grpc-addr", ":9090", "Listen address for the SubscriptionService gRPC API; empty disables it. Requires API_TOKEN")
+		cacheTTL  = flag.Duration("cache-ttl", 0, "Cache subscriptions read by ID in process for this long; 0 reads every one from Spanner")
+		cacheSize = flag.Int("cache-size", 10000, "Subscriptions the cache holds, with -cache-ttl")
+	)
+	flag.Parse()
+
+	cfg, err := loader.Load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if *addr == "" && *grpcAddr == "" {
+		fmt.Fprintln(os.Stderr, "one of -addr or -grpc-addr is required")
+		os.Exit(2)
+	}
+	logger, err := logging.New(os.Stdout, cfg.Log.Level, cfg.Log.Format)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	app := lifecycle.New(logger, cfg.ShutdownTimeout)
+	ctx := app.Context()
+
+	secrets, err := adapters.NewSecretProvider(ctx, adapters.SecretsConfig{
+		Backend:  adapters.SecretsBackend(cfg.Secrets.Backend),
+		Project:  cfg.Secrets.Project,
+		CacheTTL: cfg.Secrets.CacheTTL,
+		Logger:   logger,
+	})
+	if err != nil {
+		app.Fatal("failed to create secret provider", err)
+	}
+	if _, err := secrets.Secret(ctx, httpapi.TokenSecret); err != nil {
+		app.Fatal("subscriptions API requires a token", err)
+	}
+
+	tracer, err := telemetry.NewTracer(app, cfg, "server", logger)
+	if err != nil {
+		app.Fatal("failed to configure tracing", err)
+	}
+
+	client, err := bootstrap.Spanner(app, cfg, logger)
+	if err != nil {
+		app.Fatal("failed to open Spanner", err)
+	}
+
+	clock := domain.RealClock{}
+	metricsRegistry := metrics.NewRegistry()
+	if err := telemetry.StartMetrics(app, cfg, metricsRegistry, "server", logger); err != nil {
+		app.Fatal("failed to configure metrics", err)
+	}
+	if cfg.Debug.Addr != "" {
+		debugServer, err := debug.NewServer(ctx, cfg.Debug.Addr, secrets, logger)
+		if err != nil {
+			app.Fatal("failed to start debug endpoints", err)
+		}
+		app.Serve("debug", debugServer)
+	}
+	injector, err := faults.New(cfg.Faults.Rules, cfg.Faults.Header, logger)
+	if err != nil {
+		app.Fatal("invalid fault rules", err)
+	}
+
+	priority, err := repo.ParsePriority(cfg.Spanner.Priority, repo.PriorityInteractive)
+	if err != nil {
+		app.Fatal("invalid Spanner priority", err)
+	}
+	repoOpts := []repo.Option{repo.WithTimeout(cfg.Spanner.Timeout), repo.WithTracer(tracer), repo.WithMetrics(metricsRegistry), repo.WithLogger(logger), repo.WithFaults(injector), repo.WithPriority(priority)}
+	subscriptionStore := repo.NewSubscriptionRepo(client, repoOpts...)
+	var subscriptionRepo contracts.TransactionalSubscriptionRepository = subscriptionStore
+	if *cacheTTL > 0 {
+		cache := adapters.NewCachedSubscriptions(subscriptionStore, adapters.SubscriptionCacheConfig{TTL: *cacheTTL, MaxEntries: *cacheSize}, clock, metricsRegistry)
+		subscriptionRepo = cache
+		// A unit of work may commit a subscription saved through the cache with another
+		// repository's Apply, so every repository reports its commits to the cache
+		repoOpts = append(repoOpts, repo.WithCommitted(cache.Committed))
+	}
+	planRepo := repo.NewPlanRepo(client, repoOpts...)
+	pricing := adapters.BundlePricing{
+		Bundles: repo.NewBundleRepo(client, repoOpts...),
+		Base:    adapters.StaticPricing{Pricing: domain.Pricing{Policy: domain.DiscountPolicy{MaxStacked: int(cfg.Discounts.MaxStacked), MaxPercentOff: cfg.Discounts.MaxPercentOff}}},
+	}
+	flags := adapters.EnvFeatureFlags{Logger: logger}
+	// Deployments register their lifecycle hooks here, e.g. a CRM sync
+	hooks := adapters.HookChain{}
+	events, err := adapters.NewEventPublisher(ctx, adapters.EventsConfig{Topic: cfg.Events.Topic, Timeout: cfg.Events.Timeout, Metrics: metricsRegistry, Logger: logger})
+	if err != nil {
+		app.Fatal("failed to create event publisher", err)
+	}
+
+	resilience := adapters.DefaultResilienceConfig()
+	billingCfg := adapters.BillingConfig{
+		Provider: adapters.ProviderHTTP,
+		BaseURL:  cfg.Billing.URL,
+		Timeout:  30 * time.Second,
+		Auth: adapters.BillingAuthConfig{
+			Method:       adapters.AuthMethod(cfg.Billing.Auth),
+			Secrets:      secrets,
+			APIKeyHeader: cfg.Billing.APIKeyHeader,
+			TokenURL:     cfg.Billing.TokenURL,
+			ClientID:     cfg.Billing.ClientID,
+			Scopes:       cfg.Billing.Scopes,
+		},
+		CallTimeout: cfg.Billing.Timeout,
+		Resilience:  &resilience,
+		Metrics:     metricsRegistry,
+		Tracer:      tracer,
+		Logger:      logger,
+		Faults:      injector,
+	}
+	billingClient, err := adapters.NewBillingClient(ctx, billingCfg)
+	if err != nil {
+		app.Fatal("failed to create billing client", err)
+	}
+	resolver := adapters.StaticBillingResolver{Client: billingClient}
+
+	in := instrument.Instrumentation{Logger: logger, Metrics: metricsRegistry, Tracer: tracer}
+	cycles := adapters.PlanBillingCycles{Plans: planRepo, DefaultDays: cfg.BillingCycleDays}
+	// Writes go through the command bus, so its middleware is registered once for all
+	// of them: panics are contained, privileged commands audited and requests
+	// validated before an interactor runs
+	recorder := audit.NewRecorder(repo.NewAuditRepo(client, repoOpts...), logger, func(err error) bool { return errors.Is(err, bus.ErrUnauthorized) })
+	commands := bus.New(bus.Recovery(logger, metricsRegistry), bus.Audit(recorder), bus.Validation())
+	for name, h := range map[string]bus.Handler{
+		create_subscription.CommandName: create_subscription.NewInteractor(subscriptionRepo, planRepo, repo.NewReferralRepo(client, repoOpts...), repo.NewBundleRepo(client, repoOpts...), repo.NewIdempotencyKeyRepo(client, repoOpts...), repo.NewCouponRepo(client, repoOpts...), resolver, flags, hooks, events, clock, cfg.BillingCycleDays),
+		cancel_subscription.CommandName: cancel_subscription.NewInteractor(subscriptionRepo, repo.NewRefundRepo(client, repoOpts...), repo.NewRefundOutboxRepo(client, repoOpts...), repo.NewCreditBalanceRepo(client, repoOpts...), resolver, pricing, flags, hooks, events, clock, cycles),
+		attach_add_on.CommandName:       attach_add_on.NewInteractor(subscriptionRepo, repo.NewBundleRepo(client, repoOpts...), repo.NewRefundRepo(client, repoOpts...), repo.NewRefundOutboxRepo(client, repoOpts...), resolver, pricing, cycles, clock),
+		detach_add_on.CommandName:       detach_add_on.NewInteractor(subscriptionRepo, repo.NewBundleRepo(client, repoOpts...), pricing, cycles, clock),
+	} {
+		if err := commands.Register(name, h); err != nil {
+			app.Fatal("failed to register command", err)
+		}
+	}
+	creator := create_subscription.NewInstrumented(create_subscription.NewDispatched(commands), in)
+	canceller := cancel_subscription.NewInstrumented(cancel_subscription.NewDispatched(commands), in)
+	attacher := attach_add_on.NewInstrumented(attach_add_on.NewDispatched(commands), in)
+	detacher := detach_add_on.NewInstrumented(detach_add_on.NewDispatched(commands), in)
+	getter := get_subscription.NewInstrumented(get_subscription.NewInteractor(subscriptionRepo, pricing, cycles, flags, clock), in)
+	previewer := preview_cancel.NewInstrumented(preview_cancel.NewInteractor(subscriptionRepo, pricing, cycles, flags, clock), in)
+	lister := list_subscriptions.NewInstrumented(list_subscriptions.NewInteractor(subscriptionStore), in)
+
+	if cfg.Health.Addr != "" {
+		readiness := health.NewChecker(cfg.Health.Timeout, logger)
+		readiness.Add("spanner", func(ctx context.Context) error { return repo.Ping(ctx, client) })
+		readiness.Add("schema", func(ctx context.Context) error { return migrations.CheckSchema(ctx, client) })
+		pinger, err := adapters.NewBillingPinger(ctx, billingCfg)
+		if err != nil {
+			app.Fatal("failed to create billing health check", err)
+		}
+		readiness.Add("billing", pinger.Ping)
+		app.Serve("health", health.NewServer(cfg.Health.Addr, readiness))
+	}
+
+	if *addr != "" {
+		handler := tracing.Middleware(tracer, "/subscriptions", recovery.Middleware(logger, metricsRegistry, "subscriptions_api",
+			httpapi.NewHandler(creator, canceller, getter, previewer, attacher, detacher, secrets, logger),
+		))
+		app.Serve("subscriptions API", &http.Server{Addr: *addr, Handler: handler, ReadHeaderTimeout: 10 * time.Second})
+	}
+
+	if *grpcAddr != "" {
+		server := grpc.NewServer(grpc.ChainUnaryInterceptor(
+			grpcapi.Recover(logger, metricsRegistry, "subscriptions_grpc"),
+			grpcapi.RequireToken(secrets, logger),
+		))
+		subscriptionv1.RegisterSubscriptionServiceServer(server, grpcapi.NewServer(creator, canceller, getter, previewer, lister, attacher, detacher, logger))
+		app.Go("subscriptions gRPC API", func(ctx context.Context) error {
+			return serveGRPC(ctx, server, *grpcAddr, cfg.ShutdownTimeout, logger)
+		})
+	}
+
+	if err := app.Wait(); err != nil {
+		os.Exit(1)
+	}
+}
+
+// serveGRPC serves until ctx ends, then stops taking calls and waits for those in
+// flight for up to drain before cutting them off
+func serveGRPC(ctx context.Context, server *grpc.Server, addr string, drain time.Duration, logger *slog.Logger) error {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	logger.Info("listening", slog.String("component", "subscriptions gRPC API"), slog.String("addr", addr))
+
+	errc := make(chan error, 1)
+	go func() { errc <- server.Serve(lis) }()
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(drain):
+		logger.Warn("gRPC drain deadline passed, cancelling calls in flight")
+		server.Stop()
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package adapters
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+var _ contracts.AuthenticationNotifier = LogAuthenticationRequests{}
+
+// LogAuthenticationRequests logs requests to authenticate a charge, for deployments
+// without a channel that notifies customers directly
+type LogAuthenticationRequests struct {
+	Logger *slog.Logger
+}
+
+// NotifyAuthenticationRequired logs the request; it never fails
+func (n LogAuthenticationRequests) NotifyAuthenticationRequired(ctx context.Context, event *domain.AuthenticationRequiredEvent) error {
+	n.Logger.WarnContext(ctx, "charge requires authentication",
+		slog.String("authentication_id", event.AuthenticationID),
+		slog.String("subscription_id", event.SubscriptionID),
+		slog.String("customer_id", event.CustomerID),
+		slog.Int64("amount", event.Amount),
+		slog.String("currency", event.Currency),
+		slog.String("action_url", event.ActionURL),
+	)
+	return nil
+}
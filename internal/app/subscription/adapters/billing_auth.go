@@ -0,0 +1,135 @@
+package adapters
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+)
+
+// AuthMethod selects how billing API requests are authenticated
+type AuthMethod string
+
+const (
+	AuthNone   AuthMethod = "none"
+	AuthBearer AuthMethod = "bearer"
+	AuthAPIKey AuthMethod = "api_key"
+	AuthOAuth2 AuthMethod = "oauth2"
+)
+
+// Secret names read from the configured SecretProvider
+const (
+	SecretBillingToken        = "billing-token"
+	SecretBillingAPIKey       = "billing-api-key"
+	SecretBillingClientSecret = "billing-client-secret"
+)
+
+// BillingAuthConfig configures billing API authentication. Credentials are never
+// configured directly; they are read from Secrets so they can come from the
+// environment or a secret store.
+type BillingAuthConfig struct {
+	Method  AuthMethod
+	Secrets contracts.SecretProvider
+
+	APIKeyHeader string // api_key only; defaults to X-API-Key
+
+	TokenURL string // oauth2 client credentials only
+	ClientID string
+	Scopes   []string
+}
+
+// NewBillingHTTPClient builds an HTTP client that authenticates every billing request.
+// Bearer tokens and API keys are read per request, so a rotated secret takes effect
+// without a restart; OAuth2 access tokens are cached and refreshed before they expire.
+func NewBillingHTTPClient(ctx context.Context, timeout time.Duration, auth BillingAuthConfig) (*http.Client, error) {
+	base := http.DefaultTransport
+
+	switch auth.Method {
+	case AuthNone, "":
+		return &http.Client{Timeout: timeout, Transport: base}, nil
+
+	case AuthBearer:
+		if auth.Secrets == nil {
+			return nil, fmt.Errorf("bearer auth requires a secret provider")
+		}
+		return &http.Client{Timeout: timeout, Transport: &secretHeaderTransport{
+			base:    base,
+			secrets: auth.Secrets,
+			secret:  SecretBillingToken,
+			header:  "Authorization",
+			prefix:  "Bearer ",
+		}}, nil
+
+	case AuthAPIKey:
+		if auth.Secrets == nil {
+			return nil, fmt.Errorf("api key auth requires a secret provider")
+		}
+		header := auth.APIKeyHeader
+		if header == "" {
+			header = "X-API-Key"
+		}
+		return &http.Client{Timeout: timeout, Transport: &secretHeaderTransport{
+			base:    base,
+			secrets: auth.Secrets,
+			secret:  SecretBillingAPIKey,
+			header:  header,
+		}}, nil
+
+	case AuthOAuth2:
+		if auth.Secrets == nil || auth.TokenURL == "" || auth.ClientID == "" {
+			return nil, fmt.Errorf("oauth2 auth requires a secret provider, token URL and client ID")
+		}
+		clientSecret, err := auth.Secrets.Secret(ctx, SecretBillingClientSecret)
+		if err != nil {
+			return nil, err
+		}
+		cfg := clientcredentials.Config{
+			ClientID:     auth.ClientID,
+			ClientSecret: clientSecret,
+			TokenURL:     auth.TokenURL,
+			Scopes:       auth.Scopes,
+		}
+		// Token requests use their own client so a slow token endpoint is bounded too
+		tokenCtx := context.WithValue(ctx, oauth2.HTTPClient, &http.Client{Timeout: timeout, Transport: base})
+		return &http.Client{Timeout: timeout, Transport: &oauth2.Transport{
+			Source: cfg.TokenSource(tokenCtx),
+			Base:   base,
+		}}, nil
+
+	default:
+		return nil, fmt.Errorf("unknown billing auth method %q", auth.Method)
+	}
+}
+
+// secretHeaderTransport sets a header from a secret on every request
+type secretHeaderTransport struct {
+	base    http.RoundTripper
+	secrets contracts.SecretProvider
+	secret  string
+	header  string
+	prefix  string
+}
+
+func (t *secretHeaderTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	value, err := t.secrets.Secret(req.Context(), t.secret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load billing credentials: %w", err)
+	}
+
+	// RoundTrippers must not modify the caller's request
+	authed := req.Clone(req.Context())
+	authed.Header.Set(t.header, t.prefix+value)
+	resp, err := t.base.RoundTrip(authed)
+	// A rejected credential may have been rotated; fetch it afresh next time
+	if err == nil && resp.StatusCode == http.StatusUnauthorized {
+		if c, ok := t.secrets.(interface{ Invalidate(name string) }); ok {
+			c.Invalidate(t.secret)
+		}
+	}
+	return resp, err
+}
@@ -0,0 +1,92 @@
+package adapters
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mapSecrets is an in-memory secret provider
+type mapSecrets map[string]string
+
+func (m mapSecrets) Secret(ctx context.Context, name string) (string, error) {
+	value, ok := m[name]
+	if !ok {
+		return "", ErrSecretNotFound
+	}
+	return value, nil
+}
+
+func TestNewBillingHTTPClient_SetsCredentialHeaders(t *testing.T) {
+	var gotAuth, gotKey string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		gotKey = r.Header.Get("X-Billing-Key")
+	}))
+	defer server.Close()
+
+	secrets := mapSecrets{SecretBillingToken: "tok-1", SecretBillingAPIKey: "key-1"}
+	ctx := context.Background()
+
+	bearer, err := NewBillingHTTPClient(ctx, time.Second, BillingAuthConfig{Method: AuthBearer, Secrets: secrets})
+	require.NoError(t, err)
+	_, err = bearer.Get(server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer tok-1", gotAuth)
+
+	// Rotated secrets are picked up on the next request
+	secrets[SecretBillingToken] = "tok-2"
+	_, err = bearer.Get(server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer tok-2", gotAuth)
+
+	apiKey, err := NewBillingHTTPClient(ctx, time.Second, BillingAuthConfig{Method: AuthAPIKey, Secrets: secrets, APIKeyHeader: "X-Billing-Key"})
+	require.NoError(t, err)
+	_, err = apiKey.Get(server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "key-1", gotKey)
+}
+
+func TestNewBillingHTTPClient_OAuth2CachesToken(t *testing.T) {
+	var tokenRequests int32
+	tokens := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&tokenRequests, 1)
+		user, pass, _ := r.BasicAuth()
+		if user != "client-1" || pass != "s3cret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{"access_token": "access-1", "token_type": "Bearer", "expires_in": 3600})
+	}))
+	defer tokens.Close()
+
+	var gotAuth string
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+	}))
+	defer api.Close()
+
+	client, err := NewBillingHTTPClient(context.Background(), time.Second, BillingAuthConfig{
+		Method:   AuthOAuth2,
+		Secrets:  mapSecrets{SecretBillingClientSecret: "s3cret"},
+		TokenURL: tokens.URL,
+		ClientID: "client-1",
+	})
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		_, err = client.Get(api.URL)
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, "Bearer access-1", gotAuth)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&tokenRequests))
+}
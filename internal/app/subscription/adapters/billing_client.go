@@ -7,12 +7,16 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 
 	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
 	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
 )
 
-var _ contracts.BillingClient = (*HTTPBillingClient)(nil)
+var (
+	_ contracts.BillingClient = (*HTTPBillingClient)(nil)
+	_ contracts.BillingPinger = (*HTTPBillingClient)(nil)
+)
 
 // HTTPBillingClient implements the billing client interface using HTTP
 type HTTPBillingClient struct {
@@ -28,6 +32,26 @@ func NewHTTPBillingClient(client *http.Client, baseURL string) *HTTPBillingClien
 	}
 }
 
+// Ping checks that the billing API answers GET /health with a 2xx status
+func (c *HTTPBillingClient) Ping(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/health", nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach billing API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		bodyBytes, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return &StatusError{Op: "ping", StatusCode: resp.StatusCode, Body: string(bodyBytes)}
+	}
+	return nil
+}
+
 // ValidateCustomer validates a customer with the external billing API
 func (c *HTTPBillingClient) ValidateCustomer(ctx context.Context, customerID string) error {
 	url := fmt.Sprintf("%s/validate/%s", c.baseURL, customerID)
@@ -43,6 +67,10 @@ func (c *HTTPBillingClient) ValidateCustomer(ctx context.Context, customerID str
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return &StatusError{Op: "customer validation", StatusCode: resp.StatusCode, Body: string(bodyBytes)}
+	}
 	if resp.StatusCode != http.StatusOK {
 		return domain.ErrInvalidCustomer
 	}
@@ -62,12 +90,167 @@ func (c *HTTPBillingClient) ValidateCustomer(ctx context.Context, customerID str
 	return nil
 }
 
-// ProcessRefund processes a refund through the external billing API
-func (c *HTTPBillingClient) ProcessRefund(ctx context.Context, amount int64) error {
+// CreateCustomer provisions a customer through the external billing API. The API answers
+// 409 Conflict for a customer ID it already knows, which counts as success.
+func (c *HTTPBillingClient) CreateCustomer(ctx context.Context, customerReq contracts.CreateCustomerRequest) error {
+	url := fmt.Sprintf("%s/customers", c.baseURL)
+
+	payload := map[string]any{
+		"customer_id": customerReq.CustomerID,
+		"email":       customerReq.Email,
+		"name":        customerReq.Name,
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to create customer: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated, http.StatusConflict:
+		return nil
+	}
+	bodyBytes, _ := io.ReadAll(resp.Body)
+	return &StatusError{Op: "create customer", StatusCode: resp.StatusCode, Body: string(bodyBytes)}
+}
+
+// ProcessRefund submits a refund to the external billing API and returns its refund ID.
+// The idempotency key lets the billing API deduplicate retried refunds. The API answers
+// 422 with the charge's currency when the refund is in a different one.
+func (c *HTTPBillingClient) ProcessRefund(ctx context.Context, refundReq contracts.RefundRequest) (string, error) {
+	if err := domain.ValidateCurrency(refundReq.Currency); err != nil {
+		return "", err
+	}
 	url := fmt.Sprintf("%s/refund", c.baseURL)
 
 	payload := map[string]any{
-		"amount": amount,
+		"subscription_id": refundReq.SubscriptionID,
+		"customer_id":     refundReq.CustomerID,
+		"amount":          refundReq.Amount,
+		"currency":        refundReq.Currency,
+		"reason":          refundReq.Reason,
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if refundReq.IdempotencyKey != "" {
+		req.Header.Set("Idempotency-Key", refundReq.IdempotencyKey)
+	}
+	if refundReq.CorrelationID != "" {
+		req.Header.Set("X-Correlation-ID", refundReq.CorrelationID)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to process refund: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnprocessableEntity {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		var rejection struct {
+			Error          string `json:"error"`
+			ChargeCurrency string `json:"charge_currency"`
+		}
+		if json.Unmarshal(bodyBytes, &rejection) == nil && rejection.Error == "currency_mismatch" {
+			return "", &domain.CurrencyMismatchError{ChargeCurrency: rejection.ChargeCurrency, RefundCurrency: refundReq.Currency}
+		}
+		return "", &StatusError{Op: "refund", StatusCode: resp.StatusCode, Body: string(bodyBytes)}
+	}
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return "", &StatusError{Op: "refund", StatusCode: resp.StatusCode, Body: string(bodyBytes)}
+	}
+
+	var result struct {
+		RefundID string `json:"refund_id"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	if result.RefundID == "" {
+		return "", fmt.Errorf("billing API accepted the refund without returning a refund_id")
+	}
+
+	return result.RefundID, nil
+}
+
+// GetRefundStatus looks up the settlement status of a refund
+func (c *HTTPBillingClient) GetRefundStatus(ctx context.Context, providerRefundID string) (contracts.RefundOutcome, error) {
+	url := fmt.Sprintf("%s/refunds/%s", c.baseURL, providerRefundID)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return contracts.RefundOutcome{}, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return contracts.RefundOutcome{}, fmt.Errorf("failed to get refund status: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return contracts.RefundOutcome{}, &StatusError{Op: "refund status", StatusCode: resp.StatusCode, Body: string(bodyBytes)}
+	}
+
+	var result struct {
+		Status        string `json:"status"`
+		FailureReason string `json:"failure_reason"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return contracts.RefundOutcome{}, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	status := domain.RefundStatus(strings.ToUpper(result.Status))
+	switch status {
+	case domain.RefundPending, domain.RefundSucceeded, domain.RefundFailed:
+	default:
+		return contracts.RefundOutcome{}, fmt.Errorf("%w: %q", domain.ErrInvalidRefundStatus, result.Status)
+	}
+
+	return contracts.RefundOutcome{Status: status, FailureReason: result.FailureReason}, nil
+}
+
+// ChargeCustomer charges a customer through the external billing API.
+// The idempotency key lets the billing API deduplicate retried charges; 402 means declined,
+// unless its body has status requires_action, which holds the charge for authentication.
+func (c *HTTPBillingClient) ChargeCustomer(ctx context.Context, chargeReq contracts.ChargeRequest) error {
+	if err := domain.ValidateCurrency(chargeReq.Currency); err != nil {
+		return err
+	}
+	url := fmt.Sprintf("%s/charge", c.baseURL)
+
+	payload := map[string]any{
+		"customer_id":     chargeReq.CustomerID,
+		"subscription_id": chargeReq.SubscriptionID,
+		"amount":          chargeReq.Amount,
+		"currency":        chargeReq.Currency,
 	}
 
 	body, err := json.Marshal(payload)
@@ -81,17 +264,75 @@ func (c *HTTPBillingClient) ProcessRefund(ctx context.Context, amount int64) err
 	}
 
 	req.Header.Set("Content-Type", "application/json")
+	if chargeReq.IdempotencyKey != "" {
+		req.Header.Set("Idempotency-Key", chargeReq.IdempotencyKey)
+	}
 
 	resp, err := c.client.Do(req)
 	if err != nil {
-		return fmt.Errorf("failed to process refund: %w", err)
+		return fmt.Errorf("failed to charge customer: %w", err)
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusPaymentRequired {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		var result struct {
+			Status    string `json:"status"`
+			PaymentID string `json:"payment_id"`
+			ActionURL string `json:"action_url"`
+		}
+		if json.Unmarshal(bodyBytes, &result) == nil && result.Status == "requires_action" {
+			return &domain.AuthenticationRequiredError{PaymentID: result.PaymentID, ActionURL: result.ActionURL}
+		}
+		return fmt.Errorf("%w: %s", domain.ErrPaymentDeclined, bodyBytes)
+	}
 	if resp.StatusCode != http.StatusOK {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("refund failed with status %d: %s", resp.StatusCode, string(bodyBytes))
+		return &StatusError{Op: "charge", StatusCode: resp.StatusCode, Body: string(bodyBytes)}
 	}
 
 	return nil
 }
+
+// GetPaymentMethodStatus looks up the customer's default payment method.
+// 404 means the customer has no payment method on file.
+func (c *HTTPBillingClient) GetPaymentMethodStatus(ctx context.Context, customerID string) (domain.PaymentMethod, error) {
+	url := fmt.Sprintf("%s/customers/%s/payment-method", c.baseURL, customerID)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return domain.PaymentMethod{}, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return domain.PaymentMethod{}, fmt.Errorf("failed to get payment method: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return domain.PaymentMethod{}, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return domain.PaymentMethod{}, &StatusError{Op: "payment method", StatusCode: resp.StatusCode, Body: string(bodyBytes)}
+	}
+
+	var result struct {
+		Valid    bool   `json:"valid"`
+		Brand    string `json:"brand"`
+		Last4    string `json:"last4"`
+		ExpMonth int    `json:"exp_month"`
+		ExpYear  int    `json:"exp_year"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return domain.PaymentMethod{}, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	pm := domain.PaymentMethod{Valid: result.Valid, Brand: result.Brand, Last4: result.Last4}
+	if result.ExpYear > 0 {
+		pm.ExpiresAt = domain.CardExpiry(result.ExpMonth, result.ExpYear)
+	}
+	return pm, nil
+}
@@ -0,0 +1,120 @@
+package adapters
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+func TestHTTPBillingClient_ProcessRefundCurrencyMismatch(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Currency string `json:"currency"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "USD", body.Currency)
+
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = w.Write([]byte(`{"error":"currency_mismatch","charge_currency":"EUR"}`))
+	}))
+	defer srv.Close()
+	client := NewHTTPBillingClient(srv.Client(), srv.URL)
+
+	_, err := client.ProcessRefund(context.Background(), contracts.RefundRequest{SubscriptionID: "sub-1", Amount: 1000, Currency: "USD"})
+
+	var mismatch *domain.CurrencyMismatchError
+	require.ErrorAs(t, err, &mismatch)
+	assert.Equal(t, &domain.CurrencyMismatchError{ChargeCurrency: "EUR", RefundCurrency: "USD"}, mismatch)
+	assert.ErrorIs(t, err, domain.ErrCurrencyMismatch)
+	assert.False(t, IsTransient(err))
+}
+
+func TestHTTPBillingClient_RejectsInvalidCurrencyBeforeSending(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request to %s", r.URL.Path)
+	}))
+	defer srv.Close()
+	client := NewHTTPBillingClient(srv.Client(), srv.URL)
+	ctx := context.Background()
+
+	_, err := client.ProcessRefund(ctx, contracts.RefundRequest{SubscriptionID: "sub-1", Amount: 1000})
+	assert.ErrorIs(t, err, domain.ErrInvalidCurrency)
+
+	err = client.ChargeCustomer(ctx, contracts.ChargeRequest{SubscriptionID: "sub-1", Amount: 1000, Currency: "usd"})
+	assert.ErrorIs(t, err, domain.ErrInvalidCurrency)
+}
+
+func TestHTTPBillingClient_ChargeRequiringAuthentication(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Idempotency-Key") == "sub-1:declined" {
+			http.Error(w, "card declined", http.StatusPaymentRequired)
+			return
+		}
+		w.WriteHeader(http.StatusPaymentRequired)
+		_, _ = w.Write([]byte(`{"status":"requires_action","payment_id":"pay-1","action_url":"https://billing.example/authenticate/pay-1"}`))
+	}))
+	defer srv.Close()
+	client := NewHTTPBillingClient(srv.Client(), srv.URL)
+	ctx := context.Background()
+
+	err := client.ChargeCustomer(ctx, contracts.ChargeRequest{SubscriptionID: "sub-1", Amount: 1000, Currency: "USD", IdempotencyKey: "sub-1:held"})
+
+	var challenge *domain.AuthenticationRequiredError
+	require.ErrorAs(t, err, &challenge)
+	assert.Equal(t, &domain.AuthenticationRequiredError{PaymentID: "pay-1", ActionURL: "https://billing.example/authenticate/pay-1"}, challenge)
+	assert.ErrorIs(t, err, domain.ErrPaymentDeclined)
+	assert.False(t, IsTransient(err))
+
+	err = client.ChargeCustomer(ctx, contracts.ChargeRequest{SubscriptionID: "sub-1", Amount: 1000, Currency: "USD", IdempotencyKey: "sub-1:declined"})
+	assert.ErrorIs(t, err, domain.ErrPaymentDeclined)
+	assert.NotErrorIs(t, err, domain.ErrAuthenticationRequired)
+}
+
+func TestHTTPBillingClient_Ping(t *testing.T) {
+	healthy := true
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/health", r.URL.Path)
+		if !healthy {
+			http.Error(w, "draining", http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+	pinger, err := NewBillingPinger(context.Background(), BillingConfig{Provider: ProviderHTTP, BaseURL: srv.URL})
+	require.NoError(t, err)
+
+	assert.NoError(t, pinger.Ping(context.Background()))
+
+	healthy = false
+	var status *StatusError
+	require.ErrorAs(t, pinger.Ping(context.Background()), &status)
+	assert.Equal(t, http.StatusServiceUnavailable, status.StatusCode)
+}
+
+func TestHTTPBillingClient_ListPlans(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/products", r.URL.Path)
+		assert.Equal(t, "page-2", r.URL.Query().Get("page_token"))
+		_, _ = w.Write([]byte(`{"products":[
+			{"product_id":"prod_pro","price_id":"price_pro","name":"Pro","unit_amount":3000,"currency":"usd","interval":"month","active":true,"metadata":{"plan_id":"plan-pro"}},
+			{"product_id":"prod_team","price_id":"price_team","name":"Team","unit_amount":5000,"currency":"eur","active":false}
+		],"next_page_token":"page-3"}`))
+	}))
+	defer srv.Close()
+	client := NewHTTPBillingClient(srv.Client(), srv.URL)
+
+	plans, next, err := client.ListPlans(context.Background(), "page-2")
+
+	require.NoError(t, err)
+	assert.Equal(t, "page-3", next)
+	assert.Equal(t, []domain.CatalogPlan{
+		{PlanID: "plan-pro", ExternalProductID: "prod_pro", ExternalPriceID: "price_pro", Name: "Pro", PriceCents: 3000, Currency: "USD", Interval: domain.IntervalMonth, Active: true},
+		{PlanID: "prod_team", ExternalProductID: "prod_team", ExternalPriceID: "price_team", Name: "Team", PriceCents: 5000, Currency: "EUR"},
+	}, plans)
+}
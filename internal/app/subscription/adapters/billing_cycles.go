@@ -0,0 +1,43 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+var (
+	_ contracts.BillingCycleSource = StaticBillingCycle{}
+	_ contracts.BillingCycleSource = PlanBillingCycles{}
+)
+
+// StaticBillingCycle bills every subscription in the same cycle
+type StaticBillingCycle struct {
+	Cycle domain.BillingCycle
+}
+
+// CycleFor returns the static cycle
+func (s StaticBillingCycle) CycleFor(ctx context.Context, sub *domain.Subscription) (domain.BillingCycle, error) {
+	return s.Cycle, nil
+}
+
+// PlanBillingCycles bills a subscription in its plan's interval. Plans without one, and
+// plans missing from the catalog, bill every DefaultDays days.
+type PlanBillingCycles struct {
+	Plans       contracts.PlanRepository
+	DefaultDays int64
+}
+
+// CycleFor returns the cycle of the subscription's plan
+func (p PlanBillingCycles) CycleFor(ctx context.Context, sub *domain.Subscription) (domain.BillingCycle, error) {
+	plan, err := p.Plans.FindByID(ctx, sub.PlanID())
+	if errors.Is(err, domain.ErrPlanNotFound) {
+		return domain.CycleOfDays(p.DefaultDays), nil
+	}
+	if err != nil {
+		return domain.BillingCycle{}, err
+	}
+	return plan.BillingCycle(p.DefaultDays), nil
+}
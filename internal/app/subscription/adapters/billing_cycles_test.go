@@ -0,0 +1,35 @@
+package adapters
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/testkit"
+)
+
+func TestPlanBillingCycles_UsesThePlansInterval(t *testing.T) {
+	at := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cycles := PlanBillingCycles{
+		Plans: testkit.NewFakePlans(
+			domain.ReconstructPlan("plan-annual", "Annual", 30000, "USD", domain.IntervalYear, 0, true, "", "", at, at),
+			domain.ReconstructPlan("plan-legacy", "Legacy", 900, "USD", "", 0, true, "", "", at, at),
+		),
+		DefaultDays: 30,
+	}
+
+	for planID, want := range map[string]domain.BillingCycle{
+		"plan-annual":  {Interval: domain.IntervalYear, Days: 30},
+		"plan-legacy":  domain.CycleOfDays(30),
+		"plan-missing": domain.CycleOfDays(30),
+	} {
+		sub := domain.ReconstructFromPersistence("sub-1", "cust-1", planID, 3000, domain.StatusActive, at)
+		cycle, err := cycles.CycleFor(context.Background(), sub)
+		require.NoError(t, err)
+		assert.Equal(t, want, cycle, planID)
+	}
+}
@@ -0,0 +1,137 @@
+package adapters
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/faults"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/tracing"
+)
+
+// BillingProvider names a billing backend implementation
+type BillingProvider string
+
+const (
+	ProviderHTTP   BillingProvider = "http"
+	ProviderPaddle BillingProvider = "paddle"
+)
+
+// BillingConfig selects and configures a billing backend
+type BillingConfig struct {
+	Provider BillingProvider
+	BaseURL  string            // internal HTTP billing API only
+	APIKey   string            // Paddle only
+	Sandbox  bool              // Paddle only
+	Timeout  time.Duration     // per HTTP request
+	Auth     BillingAuthConfig // internal HTTP billing API only
+
+	// CallTimeout bounds each billing call, including every request it makes, independently
+	// of the caller's deadline. With Resilience it applies to each attempt. Zero disables it.
+	CallTimeout time.Duration
+
+	// Hedge sends a second attempt of slow idempotent reads when set. With Resilience
+	// the pair of attempts counts as one call to the retry loop and circuit breaker.
+	Hedge *HedgeConfig
+
+	// Resilience wraps the client with retries and a circuit breaker when set
+	Resilience *ResilienceConfig
+
+	// Metrics and Tracer instrument every call when either is set; a nil one discards its telemetry
+	Metrics contracts.Metrics
+	Tracer  contracts.Tracer
+
+	// Logger receives retries and circuit breaker trips when Resilience doesn't set its own
+	Logger *slog.Logger
+
+	// Faults injects latency and errors under every other decorator when set. Never
+	// set it in production.
+	Faults *faults.Injector
+}
+
+// NewBillingClient builds the billing client selected by cfg
+func NewBillingClient(ctx context.Context, cfg BillingConfig) (contracts.BillingClient, error) {
+	client, err := newProviderClient(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	instrumented := cfg.Metrics != nil || cfg.Tracer != nil
+	if cfg.Metrics == nil {
+		cfg.Metrics = NoopMetrics{}
+	}
+	if cfg.Tracer == nil {
+		cfg.Tracer = NoopTracer{}
+	}
+
+	if cfg.Faults != nil {
+		client = NewFaultBillingClient(client, cfg.Faults)
+	}
+	if cfg.CallTimeout > 0 {
+		client = NewTimeoutBillingClient(client, cfg.CallTimeout)
+	}
+	if cfg.Hedge != nil {
+		client = NewHedgedBillingClient(client, *cfg.Hedge, cfg.Metrics)
+	}
+	if cfg.Resilience != nil {
+		resilience := *cfg.Resilience
+		if resilience.Logger == nil {
+			resilience.Logger = cfg.Logger
+		}
+		client = NewResilientBillingClient(client, resilience, domain.RealClock{})
+	}
+	if instrumented {
+		provider := cfg.Provider
+		if provider == "" {
+			provider = ProviderHTTP
+		}
+		client = NewInstrumentedBillingClient(client, provider, cfg.Metrics, cfg.Tracer)
+	}
+	return client, nil
+}
+
+// NewBillingPinger builds an undecorated client for cfg's provider to ping in health
+// checks, so probes bypass retries and the circuit breaker and record no spans
+func NewBillingPinger(ctx context.Context, cfg BillingConfig) (contracts.BillingPinger, error) {
+	cfg.Tracer = nil
+	client, err := newProviderClient(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	pinger, ok := client.(contracts.BillingPinger)
+	if !ok {
+		return nil, fmt.Errorf("%w: ping", ErrUnsupportedByProvider)
+	}
+	return pinger, nil
+}
+
+// newProviderClient builds the undecorated client for the configured provider
+func newProviderClient(ctx context.Context, cfg BillingConfig) (contracts.BillingClient, error) {
+	switch cfg.Provider {
+	case ProviderHTTP, "":
+		httpClient, err := NewBillingHTTPClient(ctx, cfg.Timeout, cfg.Auth)
+		if err != nil {
+			return nil, err
+		}
+		return NewHTTPBillingClient(traced(httpClient, cfg.Tracer), cfg.BaseURL), nil
+	case ProviderPaddle:
+		if cfg.APIKey == "" {
+			return nil, fmt.Errorf("paddle billing requires an API key")
+		}
+		return NewPaddleBillingClient(traced(&http.Client{Timeout: cfg.Timeout}, cfg.Tracer), cfg.APIKey, cfg.Sandbox), nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownBillingProvider, cfg.Provider)
+	}
+}
+
+// traced makes client record a span per request and propagate trace context to the
+// provider when a tracer is configured
+func traced(client *http.Client, tracer contracts.Tracer) *http.Client {
+	if tracer != nil {
+		client.Transport = tracing.NewTransport(client.Transport, tracer)
+	}
+	return client
+}
@@ -0,0 +1,154 @@
+package adapters
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+var (
+	_ contracts.BillingRecords = (*HTTPBillingClient)(nil)
+	_ contracts.BillingCatalog = (*HTTPBillingClient)(nil)
+)
+
+// ListSubscriptions lists the billing API's subscriptions one page at a time
+func (c *HTTPBillingClient) ListSubscriptions(ctx context.Context, pageToken string) ([]contracts.ProviderSubscription, string, error) {
+	endpoint := fmt.Sprintf("%s/subscriptions", c.baseURL)
+	if pageToken != "" {
+		endpoint += "?page_token=" + url.QueryEscape(pageToken)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list subscriptions: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, "", &StatusError{Op: "list subscriptions", StatusCode: resp.StatusCode, Body: string(bodyBytes)}
+	}
+
+	var result struct {
+		Subscriptions []struct {
+			SubscriptionID string `json:"subscription_id"`
+			CustomerID     string `json:"customer_id"`
+			Status         string `json:"status"`
+		} `json:"subscriptions"`
+		NextPageToken string `json:"next_page_token"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, "", fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	subs := make([]contracts.ProviderSubscription, 0, len(result.Subscriptions))
+	for _, s := range result.Subscriptions {
+		subs = append(subs, contracts.ProviderSubscription{
+			SubscriptionID: s.SubscriptionID,
+			CustomerID:     s.CustomerID,
+			Active:         s.Status == "active",
+		})
+	}
+
+	return subs, result.NextPageToken, nil
+}
+
+// CancelProviderSubscription cancels a subscription on the billing API side only
+func (c *HTTPBillingClient) CancelProviderSubscription(ctx context.Context, subscriptionID string) error {
+	endpoint := fmt.Sprintf("%s/subscriptions/%s/cancel", c.baseURL, url.PathEscape(subscriptionID))
+
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to cancel provider subscription: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return &StatusError{Op: "cancel provider subscription", StatusCode: resp.StatusCode, Body: string(bodyBytes)}
+	}
+
+	return nil
+}
+
+// ListPlans lists the billing API's product catalog one page at a time. A product's
+// plan_id metadata names the plan it maps to; products without it map to a plan with
+// the product's ID. A product's interval is the plan's billing interval.
+func (c *HTTPBillingClient) ListPlans(ctx context.Context, pageToken string) ([]domain.CatalogPlan, string, error) {
+	endpoint := fmt.Sprintf("%s/products", c.baseURL)
+	if pageToken != "" {
+		endpoint += "?page_token=" + url.QueryEscape(pageToken)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list products: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, "", &StatusError{Op: "list products", StatusCode: resp.StatusCode, Body: string(bodyBytes)}
+	}
+
+	var result struct {
+		Products []struct {
+			ProductID  string            `json:"product_id"`
+			PriceID    string            `json:"price_id"`
+			Name       string            `json:"name"`
+			UnitAmount int64             `json:"unit_amount"`
+			Currency   string            `json:"currency"`
+			Interval   string            `json:"interval"`
+			Active     bool              `json:"active"`
+			Metadata   map[string]string `json:"metadata"`
+		} `json:"products"`
+		NextPageToken string `json:"next_page_token"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, "", fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	plans := make([]domain.CatalogPlan, 0, len(result.Products))
+	for _, p := range result.Products {
+		planID := p.Metadata["plan_id"]
+		if planID == "" {
+			planID = p.ProductID
+		}
+		plans = append(plans, domain.CatalogPlan{
+			PlanID:            planID,
+			ExternalProductID: p.ProductID,
+			ExternalPriceID:   p.PriceID,
+			Name:              p.Name,
+			PriceCents:        p.UnitAmount,
+			Currency:          strings.ToUpper(p.Currency), // providers such as Stripe use lowercase codes
+			Interval:          domain.BillingInterval(p.Interval),
+			Active:            p.Active,
+		})
+	}
+
+	return plans, result.NextPageToken, nil
+}
@@ -0,0 +1,107 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+)
+
+// ErrUnknownBillingProvider is returned when a route points at an unregistered provider
+var ErrUnknownBillingProvider = errors.New("unknown billing provider")
+
+var (
+	_ contracts.BillingResolver = (*BillingRegistry)(nil)
+	_ contracts.BillingResolver = StaticBillingResolver{}
+)
+
+// BillingRegistry routes subscriptions to billing clients by plan or customer attributes.
+// A plan route wins over a customer prefix route, which wins over the default provider.
+type BillingRegistry struct {
+	mu              sync.RWMutex
+	providers       map[BillingProvider]contracts.BillingClient
+	planRoutes      map[string]BillingProvider
+	prefixRoutes    []prefixRoute
+	defaultProvider BillingProvider
+}
+
+type prefixRoute struct {
+	prefix   string
+	provider BillingProvider
+}
+
+// NewBillingRegistry creates a registry that falls back to defaultProvider
+func NewBillingRegistry(defaultProvider BillingProvider) *BillingRegistry {
+	return &BillingRegistry{
+		providers:       make(map[BillingProvider]contracts.BillingClient),
+		planRoutes:      make(map[string]BillingProvider),
+		defaultProvider: defaultProvider,
+	}
+}
+
+// Register makes a billing client available under a provider name
+func (r *BillingRegistry) Register(provider BillingProvider, client contracts.BillingClient) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.providers[provider] = client
+}
+
+// RouteByPlan bills every subscription on planID through provider
+func (r *BillingRegistry) RouteByPlan(planID string, provider BillingProvider) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.planRoutes[planID] = provider
+}
+
+// RouteByCustomerPrefix bills customers whose ID starts with prefix through provider.
+// Prefixes are checked in registration order.
+func (r *BillingRegistry) RouteByCustomerPrefix(prefix string, provider BillingProvider) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.prefixRoutes = append(r.prefixRoutes, prefixRoute{prefix: prefix, provider: provider})
+}
+
+// Provider returns the client registered under a provider name
+func (r *BillingRegistry) Provider(provider BillingProvider) (contracts.BillingClient, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	client, ok := r.providers[provider]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownBillingProvider, provider)
+	}
+	return client, nil
+}
+
+// Resolve returns the billing client responsible for the plan and customer
+func (r *BillingRegistry) Resolve(ctx context.Context, planID, customerID string) (contracts.BillingClient, error) {
+	return r.Provider(r.route(planID, customerID))
+}
+
+func (r *BillingRegistry) route(planID, customerID string) BillingProvider {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if provider, ok := r.planRoutes[planID]; ok {
+		return provider
+	}
+	for _, route := range r.prefixRoutes {
+		if strings.HasPrefix(customerID, route.prefix) {
+			return route.provider
+		}
+	}
+	return r.defaultProvider
+}
+
+// StaticBillingResolver resolves every subscription to the same client
+type StaticBillingResolver struct {
+	Client contracts.BillingClient
+}
+
+// Resolve returns the static client
+func (s StaticBillingResolver) Resolve(ctx context.Context, planID, customerID string) (contracts.BillingClient, error) {
+	return s.Client, nil
+}
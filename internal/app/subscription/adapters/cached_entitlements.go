@@ -0,0 +1,117 @@
+package adapters
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+var _ contracts.EntitlementRepository = (*CachedEntitlements)(nil)
+
+// maxCachedCustomers bounds the customers CachedEntitlements holds; once reached, the
+// cache starts over rather than growing with every customer ever checked
+const maxCachedCustomers = 100000
+
+// CachedEntitlements keeps each customer's entitled subscriptions and each plan's
+// entitlements for a TTL, so entitlement checks made on every request rarely reach
+// the database. Concurrent misses for the same key share one lookup. A change is
+// picked up within one TTL, or at once after Invalidate; failed lookups aren't cached.
+type CachedEntitlements struct {
+	next  contracts.EntitlementRepository
+	ttl   time.Duration
+	clock domain.Clock
+
+	mu        sync.Mutex
+	customers map[string]*cacheEntry[[]*domain.Subscription]
+	plans     map[string]*cacheEntry[[]domain.Entitlement]
+}
+
+type cacheEntry[T any] struct {
+	mu        sync.Mutex // held while refreshing, so one caller looks up and the rest wait
+	value     T
+	fetchedAt time.Time
+	ok        bool
+}
+
+// NewCachedEntitlements caches next's lookups for ttl
+func NewCachedEntitlements(next contracts.EntitlementRepository, ttl time.Duration, clock domain.Clock) *CachedEntitlements {
+	return &CachedEntitlements{
+		next:      next,
+		ttl:       ttl,
+		clock:     clock,
+		customers: make(map[string]*cacheEntry[[]*domain.Subscription]),
+		plans:     make(map[string]*cacheEntry[[]domain.Entitlement]),
+	}
+}
+
+// FindEntitledSubscriptions returns the customer's cached subscriptions, looking them
+// up when missing or expired
+func (c *CachedEntitlements) FindEntitledSubscriptions(ctx context.Context, customerID string) ([]*domain.Subscription, error) {
+	c.mu.Lock()
+	if _, ok := c.customers[customerID]; !ok && len(c.customers) >= maxCachedCustomers {
+		c.customers = make(map[string]*cacheEntry[[]*domain.Subscription])
+	}
+	e := entryFor(c.customers, customerID)
+	c.mu.Unlock()
+
+	return cached(c, e, func() ([]*domain.Subscription, error) {
+		return c.next.FindEntitledSubscriptions(ctx, customerID)
+	})
+}
+
+// FindPlanEntitlements returns the plan's cached entitlements, looking them up when
+// missing or expired
+func (c *CachedEntitlements) FindPlanEntitlements(ctx context.Context, planID string) ([]domain.Entitlement, error) {
+	c.mu.Lock()
+	e := entryFor(c.plans, planID)
+	c.mu.Unlock()
+
+	return cached(c, e, func() ([]domain.Entitlement, error) {
+		return c.next.FindPlanEntitlements(ctx, planID)
+	})
+}
+
+// Invalidate drops the customer's cached subscriptions, so the next check looks them
+// up. Call it when a subscription of theirs is created, cancelled or changes plan.
+func (c *CachedEntitlements) Invalidate(customerID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.customers, customerID)
+}
+
+// InvalidatePlan drops the plan's cached entitlements
+func (c *CachedEntitlements) InvalidatePlan(planID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.plans, planID)
+}
+
+// entryFor returns the entry for key, adding an empty one if there is none. The
+// caller holds the cache's lock.
+func entryFor[T any](entries map[string]*cacheEntry[T], key string) *cacheEntry[T] {
+	e, ok := entries[key]
+	if !ok {
+		e = &cacheEntry[T]{}
+		entries[key] = e
+	}
+	return e
+}
+
+// cached returns the entry's value while it is fresh, and refreshes it with lookup otherwise
+func cached[T any](c *CachedEntitlements, e *cacheEntry[T], lookup func() (T, error)) (T, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.ok && c.clock.Now().Sub(e.fetchedAt) < c.ttl {
+		return e.value, nil
+	}
+
+	value, err := lookup()
+	if err != nil {
+		return value, err
+	}
+	e.value, e.fetchedAt, e.ok = value, c.clock.Now(), true
+	return value, nil
+}
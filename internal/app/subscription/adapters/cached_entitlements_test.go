@@ -0,0 +1,64 @@
+package adapters
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/testkit"
+)
+
+func TestCachedEntitlements_RefreshesAfterTTL(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	next := testkit.NewFakeEntitlements().
+		WithSubscription(domain.ReconstructFromPersistence("sub-1", "cust-1", "plan-pro", 3000, domain.StatusActive, start)).
+		WithPlan("plan-pro", domain.Entitlement{Feature: "sso", Unlimited: true})
+	clock := &domain.FixedClock{FixedTime: start}
+	cache := NewCachedEntitlements(next, time.Minute, clock)
+
+	for i := 0; i < 3; i++ {
+		subs, err := cache.FindEntitledSubscriptions(ctx, "cust-1")
+		require.NoError(t, err)
+		assert.Len(t, subs, 1)
+		entitlements, err := cache.FindPlanEntitlements(ctx, "plan-pro")
+		require.NoError(t, err)
+		assert.Len(t, entitlements, 1)
+	}
+	assert.Equal(t, 2, next.Lookups())
+
+	clock.FixedTime = start.Add(time.Minute)
+	_, err := cache.FindEntitledSubscriptions(ctx, "cust-1")
+	require.NoError(t, err)
+	assert.Equal(t, 3, next.Lookups())
+
+	cache.Invalidate("cust-1")
+	cache.InvalidatePlan("plan-pro")
+	_, err = cache.FindEntitledSubscriptions(ctx, "cust-1")
+	require.NoError(t, err)
+	_, err = cache.FindPlanEntitlements(ctx, "plan-pro")
+	require.NoError(t, err)
+	assert.Equal(t, 5, next.Lookups())
+}
+
+func TestCachedEntitlements_ConcurrentMissesShareOneLookup(t *testing.T) {
+	next := testkit.NewFakeEntitlements()
+	cache := NewCachedEntitlements(next, time.Minute, domain.FixedClock{FixedTime: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = cache.FindEntitledSubscriptions(context.Background(), "cust-1")
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 1, next.Lookups())
+}
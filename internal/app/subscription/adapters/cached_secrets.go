@@ -0,0 +1,85 @@
+package adapters
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+var _ contracts.SecretProvider = (*CachedSecretProvider)(nil)
+
+// CachedSecretProvider keeps each secret for a TTL, so callers that resolve a secret
+// on every request don't call the secret store every time. A rotated secret is picked
+// up within one TTL, or at once after Invalidate. When a refresh fails, the last value
+// is served and the failure logged, so a secret store outage doesn't take the service
+// down with it.
+type CachedSecretProvider struct {
+	next   contracts.SecretProvider
+	ttl    time.Duration
+	clock  domain.Clock
+	logger *slog.Logger
+
+	mu      sync.Mutex
+	entries map[string]*secretEntry
+}
+
+type secretEntry struct {
+	mu        sync.Mutex // held while refreshing, so one caller fetches and the rest wait
+	value     string
+	fetchedAt time.Time
+	ok        bool
+}
+
+// NewCachedSecretProvider caches next's secrets for ttl
+func NewCachedSecretProvider(next contracts.SecretProvider, ttl time.Duration, clock domain.Clock, logger *slog.Logger) *CachedSecretProvider {
+	return &CachedSecretProvider{
+		next:    next,
+		ttl:     ttl,
+		clock:   clock,
+		logger:  logger,
+		entries: make(map[string]*secretEntry),
+	}
+}
+
+// Secret returns the cached value of name, fetching it when missing or expired
+func (p *CachedSecretProvider) Secret(ctx context.Context, name string) (string, error) {
+	p.mu.Lock()
+	e, ok := p.entries[name]
+	if !ok {
+		e = &secretEntry{}
+		p.entries[name] = e
+	}
+	p.mu.Unlock()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.ok && p.clock.Now().Sub(e.fetchedAt) < p.ttl {
+		return e.value, nil
+	}
+
+	value, err := p.next.Secret(ctx, name)
+	if err != nil {
+		if e.ok {
+			p.logger.WarnContext(ctx, "secret refresh failed, serving the cached value", slog.String("secret", name), slog.Any("error", err))
+			return e.value, nil
+		}
+		return "", err
+	}
+	if e.ok && value != e.value {
+		p.logger.InfoContext(ctx, "secret rotated", slog.String("secret", name))
+	}
+	e.value, e.fetchedAt, e.ok = value, p.clock.Now(), true
+	return value, nil
+}
+
+// Invalidate drops the cached value of name, so the next call fetches it. Call it
+// when a credential is rejected, in case it was rotated.
+func (p *CachedSecretProvider) Invalidate(name string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.entries, name)
+}
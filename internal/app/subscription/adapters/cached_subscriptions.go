@@ -0,0 +1,241 @@
+package adapters
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+var _ contracts.TransactionalSubscriptionRepository = (*CachedSubscriptions)(nil)
+
+// MetricSubscriptionCache counts FindByID lookups by result: hit or miss
+const MetricSubscriptionCache = "subscription_cache_lookups_total"
+
+// maxPendingWrites bounds the saved mutations CachedSubscriptions waits to see applied.
+// A caller that saves and never commits leaves one behind; past the bound the cache
+// forgets them all and starts empty.
+const maxPendingWrites = 10000
+
+// SubscriptionCacheConfig configures CachedSubscriptions
+type SubscriptionCacheConfig struct {
+	// TTL bounds how stale a subscription written by another process can be
+	TTL time.Duration
+	// MaxEntries is how many subscriptions are kept; the least recently used go first
+	MaxEntries int
+}
+
+// CachedSubscriptions is a read-through cache in front of a SubscriptionRepository,
+// for FindByID traffic that reads rows which rarely change. A subscription saved
+// through it is dropped from the cache and isn't cached again until its mutation has
+// been committed, so a process reads its own writes. A unit of work that commits the
+// mutation through another repository's Apply must report it to Committed, as the
+// Spanner repositories do with repo.WithCommitted. Writes by other processes show
+// within the TTL. Callers get copies, so changing one doesn't change the cache. It is
+// safe for concurrent use.
+type CachedSubscriptions struct {
+	next    contracts.TransactionalSubscriptionRepository
+	cfg     SubscriptionCacheConfig
+	clock   domain.Clock
+	metrics contracts.Metrics
+
+	mu      sync.Mutex
+	lru     *list.List // of *subscriptionEntry, most recently used first
+	entries map[string]*list.Element
+	pending map[*contracts.Mutation]string // saved but not yet applied, to the subscription ID
+	writing map[string]int                 // pending mutations per subscription
+	// version changes with every invalidation, so a lookup that raced a write doesn't
+	// cache what it read before the write
+	version uint64
+}
+
+type subscriptionEntry struct {
+	sub       *domain.Subscription
+	fetchedAt time.Time
+}
+
+// NewCachedSubscriptions caches next's FindByID, recording hits and misses to metrics
+func NewCachedSubscriptions(next contracts.TransactionalSubscriptionRepository, cfg SubscriptionCacheConfig, clock domain.Clock, metrics contracts.Metrics) *CachedSubscriptions {
+	return &CachedSubscriptions{
+		next:    next,
+		cfg:     cfg,
+		clock:   clock,
+		metrics: metrics,
+		lru:     list.New(),
+		entries: make(map[string]*list.Element),
+		pending: make(map[*contracts.Mutation]string),
+		writing: make(map[string]int),
+	}
+}
+
+// FindByID returns a copy of the cached subscription, looking it up when missing or
+// expired. Subscriptions that don't exist aren't cached.
+func (c *CachedSubscriptions) FindByID(ctx context.Context, id string) (*domain.Subscription, error) {
+	c.mu.Lock()
+	if el, ok := c.entries[id]; ok {
+		entry := el.Value.(*subscriptionEntry)
+		if c.clock.Now().Sub(entry.fetchedAt) < c.cfg.TTL {
+			c.lru.MoveToFront(el)
+			sub := entry.sub.Clone()
+			c.mu.Unlock()
+			c.metrics.IncCounter(MetricSubscriptionCache, map[string]string{"result": "hit"})
+			return sub, nil
+		}
+		c.remove(el)
+	}
+	version := c.version
+	c.mu.Unlock()
+	c.metrics.IncCounter(MetricSubscriptionCache, map[string]string{"result": "miss"})
+
+	sub, err := c.next.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.version == version && c.writing[id] == 0 {
+		c.add(id, sub.Clone())
+	}
+	return sub, nil
+}
+
+// Save drops the subscription from the cache until its mutation is applied
+func (c *CachedSubscriptions) Save(ctx context.Context, sub *domain.Subscription) (*contracts.Mutation, contracts.VersionCheck, error) {
+	mutation, check, err := c.next.Save(ctx, sub)
+	if err != nil {
+		return nil, contracts.VersionCheck{}, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.pending) >= maxPendingWrites {
+		c.reset()
+	}
+	c.pending[mutation] = sub.ID()
+	c.writing[sub.ID()]++
+	c.invalidate(sub.ID())
+	return mutation, check, nil
+}
+
+// Apply applies the mutations and drops the subscriptions they were saved for. It
+// drops them even when Apply fails, since a failed commit may still have been applied.
+func (c *CachedSubscriptions) Apply(ctx context.Context, mutations ...*contracts.Mutation) error {
+	err := c.next.Apply(ctx, mutations...)
+	c.Committed(mutations...)
+	return err
+}
+
+// ApplyChecked is Apply, committing only while the subscriptions checked are still at
+// their versions
+func (c *CachedSubscriptions) ApplyChecked(ctx context.Context, checks []contracts.VersionCheck, mutations ...*contracts.Mutation) error {
+	err := c.next.ApplyChecked(ctx, checks, mutations...)
+	c.Committed(mutations...)
+	return err
+}
+
+// RunInTransaction runs fn in next's transaction, whose reads bypass the cache. Once
+// it ends, the subscriptions fn applied are dropped as Apply drops them.
+func (c *CachedSubscriptions) RunInTransaction(ctx context.Context, fn func(ctx context.Context, tx contracts.SubscriptionTransaction) error) error {
+	var applied []*contracts.Mutation
+	err := c.next.RunInTransaction(ctx, func(ctx context.Context, tx contracts.SubscriptionTransaction) error {
+		return fn(ctx, &cachedTx{tx: tx, applied: &applied})
+	})
+	c.Committed(applied...)
+	return err
+}
+
+// cachedTx records the mutations applied in a transaction
+type cachedTx struct {
+	tx      contracts.SubscriptionTransaction
+	applied *[]*contracts.Mutation
+}
+
+func (t *cachedTx) FindByID(ctx context.Context, id string) (*domain.Subscription, error) {
+	return t.tx.FindByID(ctx, id)
+}
+
+func (t *cachedTx) Apply(ctx context.Context, mutations ...*contracts.Mutation) error {
+	*t.applied = append(*t.applied, mutations...)
+	return t.tx.Apply(ctx, mutations...)
+}
+
+func (t *cachedTx) ApplyChecked(ctx context.Context, checks []contracts.VersionCheck, mutations ...*contracts.Mutation) error {
+	*t.applied = append(*t.applied, mutations...)
+	return t.tx.ApplyChecked(ctx, checks, mutations...)
+}
+
+// Committed drops the subscriptions mutations were saved for and stops waiting for
+// them, whichever repository committed them and whether or not the commit succeeded,
+// since a failed commit may still have been applied. Mutations it wasn't waiting for
+// are ignored.
+func (c *CachedSubscriptions) Committed(mutations ...*contracts.Mutation) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, m := range mutations {
+		id, ok := c.pending[m]
+		if !ok {
+			continue
+		}
+		delete(c.pending, m)
+		if c.writing[id]--; c.writing[id] <= 0 {
+			delete(c.writing, id)
+		}
+		c.invalidate(id)
+	}
+}
+
+// Invalidate drops a subscription another process changed, so the next FindByID
+// reads it
+func (c *CachedSubscriptions) Invalidate(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.invalidate(id)
+}
+
+// Len returns how many subscriptions are cached, expired ones included
+func (c *CachedSubscriptions) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+// add caches sub, evicting the least recently used entry when full. The caller holds mu.
+func (c *CachedSubscriptions) add(id string, sub *domain.Subscription) {
+	if c.cfg.MaxEntries <= 0 {
+		return
+	}
+	if el, ok := c.entries[id]; ok {
+		c.remove(el)
+	}
+	for c.lru.Len() >= c.cfg.MaxEntries {
+		c.remove(c.lru.Back())
+	}
+	c.entries[id] = c.lru.PushFront(&subscriptionEntry{sub: sub, fetchedAt: c.clock.Now()})
+}
+
+// invalidate drops id and fails lookups in flight. The caller holds mu.
+func (c *CachedSubscriptions) invalidate(id string) {
+	c.version++
+	if el, ok := c.entries[id]; ok {
+		c.remove(el)
+	}
+}
+
+// remove drops an entry. The caller holds mu.
+func (c *CachedSubscriptions) remove(el *list.Element) {
+	c.lru.Remove(el)
+	delete(c.entries, el.Value.(*subscriptionEntry).sub.ID())
+}
+
+// reset forgets every entry and pending write. The caller holds mu.
+func (c *CachedSubscriptions) reset() {
+	c.version++
+	c.lru.Init()
+	c.entries = make(map[string]*list.Element)
+	c.pending = make(map[*contracts.Mutation]string)
+	c.writing = make(map[string]int)
+}
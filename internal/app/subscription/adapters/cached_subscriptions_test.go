@@ -0,0 +1,218 @@
+package adapters
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/testkit"
+)
+
+var cacheStart = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// countingSubscriptions counts the lookups that reach the repository
+type countingSubscriptions struct {
+	*testkit.FakeSubscriptions
+	mu    sync.Mutex
+	finds int
+}
+
+func (c *countingSubscriptions) FindByID(ctx context.Context, id string) (*domain.Subscription, error) {
+	c.mu.Lock()
+	c.finds++
+	c.mu.Unlock()
+	return c.FakeSubscriptions.FindByID(ctx, id)
+}
+
+func (c *countingSubscriptions) lookups() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.finds
+}
+
+func newCachedSubscriptions(maxEntries int, subs ...*domain.Subscription) (*CachedSubscriptions, *countingSubscriptions, *testkit.StepClock, *recordingMetrics) {
+	next := &countingSubscriptions{FakeSubscriptions: testkit.NewFakeSubscriptions().With(subs...)}
+	clock := testkit.NewStepClock(cacheStart)
+	metrics := newRecordingMetrics()
+	cache := NewCachedSubscriptions(next, SubscriptionCacheConfig{TTL: time.Minute, MaxEntries: maxEntries}, clock, metrics)
+	return cache, next, clock, metrics
+}
+
+func activeSubscription(id string) *domain.Subscription {
+	return domain.ReconstructFromPersistence(id, "cust-1", "plan-pro", 3000, domain.StatusActive, cacheStart)
+}
+
+func TestCachedSubscriptions_HitsUntilTTL(t *testing.T) {
+	ctx := context.Background()
+	cache, next, clock, metrics := newCachedSubscriptions(10, activeSubscription("sub-1"))
+
+	for i := 0; i < 3; i++ {
+		sub, err := cache.FindByID(ctx, "sub-1")
+		require.NoError(t, err)
+		assert.Equal(t, "sub-1", sub.ID())
+	}
+	assert.Equal(t, 1, next.lookups())
+	assert.Equal(t, []map[string]string{{"result": "miss"}, {"result": "hit"}, {"result": "hit"}}, metrics.counters[MetricSubscriptionCache])
+
+	clock.Advance(time.Minute)
+	_, err := cache.FindByID(ctx, "sub-1")
+	require.NoError(t, err)
+	assert.Equal(t, 2, next.lookups())
+}
+
+func TestCachedSubscriptions_ReturnsCopies(t *testing.T) {
+	ctx := context.Background()
+	cache, _, clock, _ := newCachedSubscriptions(10, activeSubscription("sub-1"))
+	_, err := cache.FindByID(ctx, "sub-1")
+	require.NoError(t, err)
+
+	sub, err := cache.FindByID(ctx, "sub-1")
+	require.NoError(t, err)
+	_, err = sub.Cancel(clock, 30)
+	require.NoError(t, err)
+
+	cached, err := cache.FindByID(ctx, "sub-1")
+	require.NoError(t, err)
+	assert.Equal(t, domain.StatusActive, cached.Status(), "an unsaved change doesn't reach the cache")
+}
+
+func TestCachedSubscriptions_ReadsThroughUntilSaveIsApplied(t *testing.T) {
+	ctx := context.Background()
+	cache, next, clock, _ := newCachedSubscriptions(10, activeSubscription("sub-1"))
+	sub, err := cache.FindByID(ctx, "sub-1")
+	require.NoError(t, err)
+
+	_, err = sub.Cancel(clock, 30)
+	require.NoError(t, err)
+	mutation, check, err := cache.Save(ctx, sub)
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		_, err = cache.FindByID(ctx, "sub-1")
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 3, next.lookups(), "a subscription with a pending write isn't cached")
+	assert.Zero(t, cache.Len())
+
+	require.NoError(t, cache.ApplyChecked(ctx, []contracts.VersionCheck{check}, mutation))
+	for i := 0; i < 2; i++ {
+		cached, err := cache.FindByID(ctx, "sub-1")
+		require.NoError(t, err)
+		assert.Equal(t, domain.StatusCancelled, cached.Status())
+	}
+	assert.Equal(t, 4, next.lookups())
+}
+
+func TestCachedSubscriptions_CachesAgainOnceTransactionEnds(t *testing.T) {
+	ctx := context.Background()
+	cache, next, clock, _ := newCachedSubscriptions(10, activeSubscription("sub-1"))
+	_, err := cache.FindByID(ctx, "sub-1")
+	require.NoError(t, err)
+
+	err = cache.RunInTransaction(ctx, func(ctx context.Context, tx contracts.SubscriptionTransaction) error {
+		sub, err := tx.FindByID(ctx, "sub-1")
+		if err != nil {
+			return err
+		}
+		if _, err := sub.Cancel(clock, 30); err != nil {
+			return err
+		}
+		var uow contracts.UnitOfWork
+		uow.SaveChecked(cache.Save(ctx, sub))
+		return uow.Commit(ctx, tx)
+	})
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		cached, err := cache.FindByID(ctx, "sub-1")
+		require.NoError(t, err)
+		assert.Equal(t, domain.StatusCancelled, cached.Status())
+	}
+	assert.Equal(t, 2, next.lookups(), "the save was dropped from the cache, then cached once committed")
+}
+
+// reportingCommitter is another repository's Apply, reporting its commits to the cache
+// as repo.WithCommitted does
+type reportingCommitter struct {
+	committed func(mutations ...*contracts.Mutation)
+}
+
+func (r reportingCommitter) Apply(ctx context.Context, mutations ...*contracts.Mutation) error {
+	r.committed(mutations...)
+	return nil
+}
+
+func TestCachedSubscriptions_CachesAgainOnceCommittedThroughAnotherRepository(t *testing.T) {
+	ctx := context.Background()
+	cache, next, clock, _ := newCachedSubscriptions(10, activeSubscription("sub-1"))
+	sub, err := cache.FindByID(ctx, "sub-1")
+	require.NoError(t, err)
+	_, err = sub.Cancel(clock, 30)
+	require.NoError(t, err)
+	mutation, _, err := cache.Save(ctx, sub)
+	require.NoError(t, err)
+	_, err = cache.FindByID(ctx, "sub-1")
+	require.NoError(t, err)
+	assert.Zero(t, cache.Len(), "a subscription with a pending write isn't cached")
+
+	var uow contracts.UnitOfWork
+	uow.Add(mutation)
+	require.NoError(t, uow.Commit(ctx, reportingCommitter{committed: cache.Committed}))
+
+	for i := 0; i < 2; i++ {
+		cached, err := cache.FindByID(ctx, "sub-1")
+		require.NoError(t, err)
+		assert.Equal(t, domain.StatusCancelled, cached.Status())
+	}
+	assert.Equal(t, 3, next.lookups(), "cached again once the other repository committed the save")
+	assert.Equal(t, 1, cache.Len())
+}
+
+func TestCachedSubscriptions_EvictsLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	cache, next, _, _ := newCachedSubscriptions(2, activeSubscription("sub-1"), activeSubscription("sub-2"), activeSubscription("sub-3"))
+
+	for _, id := range []string{"sub-1", "sub-2", "sub-1", "sub-3"} {
+		_, err := cache.FindByID(ctx, id)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 2, cache.Len())
+	assert.Equal(t, 3, next.lookups())
+
+	_, err := cache.FindByID(ctx, "sub-1")
+	require.NoError(t, err)
+	assert.Equal(t, 3, next.lookups(), "sub-1 was used more recently than sub-2")
+	_, err = cache.FindByID(ctx, "sub-2")
+	require.NoError(t, err)
+	assert.Equal(t, 4, next.lookups())
+}
+
+func TestCachedSubscriptions_DoesNotCacheMissingSubscriptions(t *testing.T) {
+	ctx := context.Background()
+	cache, next, _, _ := newCachedSubscriptions(10)
+
+	for i := 0; i < 2; i++ {
+		_, err := cache.FindByID(ctx, "sub-1")
+		assert.ErrorIs(t, err, domain.ErrSubscriptionNotFound)
+	}
+	assert.Equal(t, 2, next.lookups())
+}
+
+func TestCachedSubscriptions_Invalidate(t *testing.T) {
+	ctx := context.Background()
+	cache, next, _, _ := newCachedSubscriptions(10, activeSubscription("sub-1"))
+	_, err := cache.FindByID(ctx, "sub-1")
+	require.NoError(t, err)
+
+	cache.Invalidate("sub-1")
+	_, err = cache.FindByID(ctx, "sub-1")
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, next.lookups())
+}
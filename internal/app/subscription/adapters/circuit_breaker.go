@@ -0,0 +1,109 @@
+package adapters
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+// ErrCircuitOpen is returned without calling the dependency while its circuit breaker is open
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// BreakerState is the state of a circuit breaker
+type BreakerState int
+
+const (
+	BreakerClosed BreakerState = iota
+	BreakerOpen
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// BreakerConfig configures a circuit breaker
+type BreakerConfig struct {
+	FailureThreshold int           // consecutive failures that open the circuit
+	OpenTimeout      time.Duration // how long the circuit stays open before a probe is allowed
+}
+
+// CircuitBreaker fails fast after repeated failures, then lets a single probe
+// through once OpenTimeout has passed; a successful probe closes the circuit
+type CircuitBreaker struct {
+	mu       sync.Mutex
+	cfg      BreakerConfig
+	clock    domain.Clock
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// NewCircuitBreaker creates a closed circuit breaker
+func NewCircuitBreaker(cfg BreakerConfig, clock domain.Clock) *CircuitBreaker {
+	return &CircuitBreaker{cfg: cfg, clock: clock}
+}
+
+// State returns the current breaker state
+func (b *CircuitBreaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refresh()
+	return b.state
+}
+
+// Allow reports whether a call may proceed; every allowed call must be followed by Record
+func (b *CircuitBreaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refresh()
+
+	switch b.state {
+	case BreakerOpen:
+		return ErrCircuitOpen
+	case BreakerHalfOpen:
+		if b.probing {
+			return ErrCircuitOpen
+		}
+		b.probing = true
+	}
+	return nil
+}
+
+// Record reports the outcome of an allowed call
+func (b *CircuitBreaker) Record(success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == BreakerHalfOpen {
+		b.probing = false
+	}
+	if success {
+		b.state = BreakerClosed
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.state == BreakerHalfOpen || (b.cfg.FailureThreshold > 0 && b.failures >= b.cfg.FailureThreshold) {
+		b.state = BreakerOpen
+		b.openedAt = b.clock.Now()
+	}
+}
+
+// refresh moves an open breaker to half-open once its timeout has elapsed
+func (b *CircuitBreaker) refresh() {
+	if b.state == BreakerOpen && !b.clock.Now().Before(b.openedAt.Add(b.cfg.OpenTimeout)) {
+		b.state = BreakerHalfOpen
+	}
+}
@@ -0,0 +1,32 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+)
+
+// ErrSecretNotFound is returned when a secret has no value
+var ErrSecretNotFound = errors.New("secret not found")
+
+var _ contracts.SecretProvider = EnvSecretProvider{}
+
+// EnvSecretProvider reads secrets from environment variables. A secret named
+// "billing-api-key" is read from BILLING_API_KEY, after Prefix if one is set.
+type EnvSecretProvider struct {
+	Prefix string
+}
+
+// Secret returns the value of the environment variable for name
+func (p EnvSecretProvider) Secret(ctx context.Context, name string) (string, error) {
+	key := p.Prefix + strings.ToUpper(strings.NewReplacer("-", "_", ".", "_", "/", "_").Replace(name))
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return "", fmt.Errorf("%w: %s (env %s)", ErrSecretNotFound, name, key)
+	}
+	return value, nil
+}
@@ -0,0 +1,215 @@
+package adapters
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"time"
+
+	"google.golang.org/api/option"
+	pubsub "google.golang.org/api/pubsub/v1"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/correlation"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+var (
+	_ contracts.EventPublisher = NoopEventPublisher{}
+	_ contracts.EventPublisher = (*PubSubEventPublisher)(nil)
+)
+
+// MetricEventsPublished counts events sent to Pub/Sub by event_type and outcome:
+// published or failed
+const MetricEventsPublished = "events_published_total"
+
+// MetricEventPublishLag is metrics.SLIEventPublishLag: seconds from an event occurring
+// to its publication, by event_type
+const MetricEventPublishLag = "sli_event_publish_lag_seconds"
+
+// Event types, carried in the event_type attribute and field of each message
+const (
+	EventSubscriptionCreated   = "subscription.created"
+	EventSubscriptionCancelled = "subscription.cancelled"
+)
+
+var topicName = regexp.MustCompile(`^projects/[^/]+/topics/[^/]+$`)
+
+// NoopEventPublisher publishes nothing, for deployments without a topic
+type NoopEventPublisher struct{}
+
+func (NoopEventPublisher) PublishCreated(ctx context.Context, event *domain.SubscriptionCreatedEvent) {
+}
+
+func (NoopEventPublisher) PublishCancelled(ctx context.Context, event *domain.SubscriptionCancelledEvent) {
+}
+
+// EventsConfig configures the event publisher
+type EventsConfig struct {
+	Topic   string        // projects/<project>/topics/<topic>; empty publishes nothing
+	Timeout time.Duration // per publish call; zero disables it
+	Metrics contracts.Metrics
+	Logger  *slog.Logger
+}
+
+// NewEventPublisher builds the publisher selected by configuration: Pub/Sub when a
+// topic is set, otherwise NoopEventPublisher
+func NewEventPublisher(ctx context.Context, cfg EventsConfig) (contracts.EventPublisher, error) {
+	if cfg.Topic == "" {
+		return NoopEventPublisher{}, nil
+	}
+	return NewPubSubEventPublisher(ctx, cfg)
+}
+
+// PubSubEventPublisher publishes events to a Google Cloud Pub/Sub topic as JSON
+// messages. Each message's ordering key is the customer ID, so a subscription with
+// message ordering enabled receives a customer's events in the order they were
+// published. The event_type, subscription_id and correlation_id attributes let
+// subscribers filter without decoding the payload. Credentials come from Application
+// Default Credentials.
+//
+// A message is published once, without retries beyond the client's own; a failure
+// is logged with the event and counted in events_published_total, and the event is
+// not published.
+type PubSubEventPublisher struct {
+	topics  *pubsub.ProjectsTopicsService
+	topic   string
+	timeout time.Duration
+	metrics contracts.Metrics
+	logger  *slog.Logger
+}
+
+// NewPubSubEventPublisher creates a publisher for cfg.Topic
+func NewPubSubEventPublisher(ctx context.Context, cfg EventsConfig, opts ...option.ClientOption) (*PubSubEventPublisher, error) {
+	if !topicName.MatchString(cfg.Topic) {
+		return nil, fmt.Errorf("pub/sub topic %q must be projects/<project>/topics/<topic>", cfg.Topic)
+	}
+	if cfg.Metrics == nil {
+		return nil, errors.New("pub/sub event publisher requires metrics")
+	}
+	if cfg.Logger == nil {
+		return nil, errors.New("pub/sub event publisher requires a logger")
+	}
+	svc, err := pubsub.NewService(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Pub/Sub client: %w", err)
+	}
+	return &PubSubEventPublisher{
+		topics:  svc.Projects.Topics,
+		topic:   cfg.Topic,
+		timeout: cfg.Timeout,
+		metrics: cfg.Metrics,
+		logger:  cfg.Logger,
+	}, nil
+}
+
+// createdMessage is the payload of subscription.created
+type createdMessage struct {
+	EventType          string     `json:"event_type"`
+	SubscriptionID     string     `json:"subscription_id"`
+	CustomerID         string     `json:"customer_id"`
+	PlanID             string     `json:"plan_id"`
+	PriceCents         int64      `json:"price_cents"`
+	ReferralID         string     `json:"referral_id,omitempty"`
+	ReferrerCustomerID string     `json:"referrer_customer_id,omitempty"`
+	CouponCode         string     `json:"coupon_code,omitempty"`
+	TrialEndDate       *time.Time `json:"trial_end_date,omitempty"`
+	CreatedAt          time.Time  `json:"created_at"`
+}
+
+// cancelledMessage is the payload of subscription.cancelled. The reason's free text is
+// left out, as erasing the customer couldn't reach it once published.
+type cancelledMessage struct {
+	EventType         string    `json:"event_type"`
+	SubscriptionID    string    `json:"subscription_id"`
+	CustomerID        string    `json:"customer_id"`
+	RefundAmountCents int64     `json:"refund_amount_cents"`
+	CreditAmountCents int64     `json:"credit_amount_cents"`
+	Reason            string    `json:"reason,omitempty"`
+	CancelledAt       time.Time `json:"cancelled_at"`
+}
+
+// PublishCreated publishes subscription.created
+func (p *PubSubEventPublisher) PublishCreated(ctx context.Context, event *domain.SubscriptionCreatedEvent) {
+	msg := createdMessage{
+		EventType:          EventSubscriptionCreated,
+		SubscriptionID:     event.SubscriptionID,
+		CustomerID:         event.CustomerID,
+		PlanID:             event.PlanID,
+		PriceCents:         event.Price,
+		ReferralID:         event.ReferralID,
+		ReferrerCustomerID: event.ReferrerCustomerID,
+		CouponCode:         event.CouponCode,
+		CreatedAt:          event.CreatedAt,
+	}
+	if !event.TrialEndDate.IsZero() {
+		msg.TrialEndDate = &event.TrialEndDate
+	}
+	p.publish(ctx, EventSubscriptionCreated, event.SubscriptionID, event.CustomerID, event.CreatedAt, msg)
+}
+
+// PublishCancelled publishes subscription.cancelled
+func (p *PubSubEventPublisher) PublishCancelled(ctx context.Context, event *domain.SubscriptionCancelledEvent) {
+	p.publish(ctx, EventSubscriptionCancelled, event.SubscriptionID, event.CustomerID, event.CancelledAt, cancelledMessage{
+		EventType:         EventSubscriptionCancelled,
+		SubscriptionID:    event.SubscriptionID,
+		CustomerID:        event.CustomerID,
+		RefundAmountCents: event.RefundAmount,
+		CreditAmountCents: event.CreditAmount,
+		Reason:            string(event.Reason.Code),
+		CancelledAt:       event.CancelledAt,
+	})
+}
+
+// publish sends one message ordered by customerID, recording the time since the event
+// occurred once it is published
+func (p *PubSubEventPublisher) publish(ctx context.Context, eventType, subscriptionID, customerID string, occurredAt time.Time, payload any) {
+	err := p.send(ctx, eventType, subscriptionID, customerID, payload)
+	if err != nil {
+		p.metrics.IncCounter(MetricEventsPublished, map[string]string{"event_type": eventType, "outcome": "failed"})
+		p.logger.ErrorContext(ctx, "failed to publish event",
+			slog.String("event_type", eventType),
+			slog.String("subscription_id", subscriptionID),
+			slog.String("topic", p.topic),
+			slog.Any("error", err),
+		)
+		return
+	}
+	p.metrics.IncCounter(MetricEventsPublished, map[string]string{"event_type": eventType, "outcome": "published"})
+	p.metrics.ObserveHistogram(MetricEventPublishLag, time.Since(occurredAt).Seconds(), map[string]string{"event_type": eventType})
+}
+
+func (p *PubSubEventPublisher) send(ctx context.Context, eventType, subscriptionID, customerID string, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	attributes := map[string]string{
+		"event_type":      eventType,
+		"subscription_id": subscriptionID,
+	}
+	if id := correlation.ID(ctx); id != "" {
+		attributes["correlation_id"] = id
+	}
+
+	// The change is already saved, so the event is published even if the caller has
+	// given up on the request
+	ctx = context.WithoutCancel(ctx)
+	if p.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.timeout)
+		defer cancel()
+	}
+	_, err = p.topics.Publish(p.topic, &pubsub.PublishRequest{
+		Messages: []*pubsub.PubsubMessage{{
+			Data:        base64.StdEncoding.EncodeToString(data),
+			Attributes:  attributes,
+			OrderingKey: customerID,
+		}},
+	}).Context(ctx).Do()
+	return err
+}
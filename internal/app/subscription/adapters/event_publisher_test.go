@@ -0,0 +1,115 @@
+package adapters
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/option"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/correlation"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/logging"
+)
+
+type publishedMessage struct {
+	Data        string            `json:"data"`
+	Attributes  map[string]string `json:"attributes"`
+	OrderingKey string            `json:"orderingKey"`
+}
+
+// pubSubServer answers publish calls to projects/prod/topics/events, keeping the
+// messages, and fails the rest
+func pubSubServer(t *testing.T) (*httptest.Server, *[]publishedMessage) {
+	var published []publishedMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/projects/prod/topics/events:publish" {
+			http.Error(w, `{"error":{"code":404,"message":"topic not found"}}`, http.StatusNotFound)
+			return
+		}
+		var req struct {
+			Messages []publishedMessage `json:"messages"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		published = append(published, req.Messages...)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"messageIds":["1"]}`))
+	}))
+	t.Cleanup(server.Close)
+	return server, &published
+}
+
+func newTestPublisher(t *testing.T, server *httptest.Server, topic string, m *recordingMetrics) *PubSubEventPublisher {
+	p, err := NewPubSubEventPublisher(context.Background(), EventsConfig{Topic: topic, Timeout: time.Second, Metrics: m, Logger: logging.Discard()},
+		option.WithEndpoint(server.URL), option.WithoutAuthentication())
+	require.NoError(t, err)
+	return p
+}
+
+func TestPubSubEventPublisher_PublishesJSONOrderedByCustomer(t *testing.T) {
+	server, published := pubSubServer(t)
+	m := newRecordingMetrics()
+	p := newTestPublisher(t, server, "projects/prod/topics/events", m)
+	ctx := correlation.WithID(context.Background(), "corr-1")
+	at := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+
+	p.PublishCreated(ctx, &domain.SubscriptionCreatedEvent{SubscriptionID: "sub-1", CustomerID: "cust-1", PlanID: "plan-pro", Price: 2900, CreatedAt: at})
+	p.PublishCancelled(ctx, &domain.SubscriptionCancelledEvent{SubscriptionID: "sub-1", CustomerID: "cust-1", RefundAmount: 1500, Reason: domain.CancellationReason{Code: domain.CancellationReasonTooExpensive, Details: "Found a cheaper plan"}, CancelledAt: at})
+
+	require.Len(t, *published, 2)
+	created, cancelled := (*published)[0], (*published)[1]
+	assert.Equal(t, "cust-1", created.OrderingKey)
+	assert.Equal(t, map[string]string{"event_type": "subscription.created", "subscription_id": "sub-1", "correlation_id": "corr-1"}, created.Attributes)
+	data, err := base64.StdEncoding.DecodeString(created.Data)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"event_type": "subscription.created", "subscription_id": "sub-1", "customer_id": "cust-1", "plan_id": "plan-pro", "price_cents": 2900, "created_at": "2024-01-15T00:00:00Z"}`, string(data))
+
+	assert.Equal(t, "cust-1", cancelled.OrderingKey)
+	data, err = base64.StdEncoding.DecodeString(cancelled.Data)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"event_type": "subscription.cancelled", "subscription_id": "sub-1", "customer_id": "cust-1", "refund_amount_cents": 1500, "credit_amount_cents": 0, "reason": "too_expensive", "cancelled_at": "2024-01-15T00:00:00Z"}`, string(data))
+
+	assert.Equal(t, []map[string]string{
+		{"event_type": "subscription.created", "outcome": "published"},
+		{"event_type": "subscription.cancelled", "outcome": "published"},
+	}, m.counters[MetricEventsPublished])
+	assert.Len(t, m.histograms[MetricEventPublishLag], 2)
+}
+
+func TestPubSubEventPublisher_CountsFailures(t *testing.T) {
+	server, published := pubSubServer(t)
+	m := newRecordingMetrics()
+	p := newTestPublisher(t, server, "projects/prod/topics/missing", m)
+
+	p.PublishCancelled(context.Background(), &domain.SubscriptionCancelledEvent{SubscriptionID: "sub-1", CustomerID: "cust-1"})
+
+	assert.Empty(t, *published)
+	assert.Equal(t, []map[string]string{{"event_type": "subscription.cancelled", "outcome": "failed"}}, m.counters[MetricEventsPublished])
+	assert.Empty(t, m.histograms[MetricEventPublishLag])
+}
+
+func TestPubSubEventPublisher_PublishesOnceTheCallerHasGivenUp(t *testing.T) {
+	server, published := pubSubServer(t)
+	p := newTestPublisher(t, server, "projects/prod/topics/events", newRecordingMetrics())
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	p.PublishCreated(ctx, &domain.SubscriptionCreatedEvent{SubscriptionID: "sub-1", CustomerID: "cust-1"})
+
+	assert.Len(t, *published, 1, "the subscription is saved, so its event still goes out")
+}
+
+func TestNewEventPublisher(t *testing.T) {
+	p, err := NewEventPublisher(context.Background(), EventsConfig{})
+	require.NoError(t, err)
+	assert.Equal(t, NoopEventPublisher{}, p)
+
+	_, err = NewEventPublisher(context.Background(), EventsConfig{Topic: "events", Metrics: NoopMetrics{}, Logger: logging.Discard()})
+	assert.ErrorContains(t, err, "must be projects/<project>/topics/<topic>")
+}
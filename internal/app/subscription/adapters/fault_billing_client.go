@@ -0,0 +1,87 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/faults"
+)
+
+var _ contracts.BillingClient = (*FaultBillingClient)(nil)
+
+// FaultBillingClient injects faults into billing calls, named "billing.<Method>", before
+// they reach the provider. Injected errors take the form the provider's own would, so
+// the decorators above it retry, trip and time out as they would in a real outage.
+type FaultBillingClient struct {
+	next     contracts.BillingClient
+	injector *faults.Injector
+}
+
+// NewFaultBillingClient wraps next with the injector's faults
+func NewFaultBillingClient(next contracts.BillingClient, injector *faults.Injector) *FaultBillingClient {
+	return &FaultBillingClient{next: next, injector: injector}
+}
+
+func (c *FaultBillingClient) ValidateCustomer(ctx context.Context, customerID string) error {
+	if err := c.inject(ctx, "ValidateCustomer"); err != nil {
+		return err
+	}
+	return c.next.ValidateCustomer(ctx, customerID)
+}
+
+func (c *FaultBillingClient) CreateCustomer(ctx context.Context, req contracts.CreateCustomerRequest) error {
+	if err := c.inject(ctx, "CreateCustomer"); err != nil {
+		return err
+	}
+	return c.next.CreateCustomer(ctx, req)
+}
+
+func (c *FaultBillingClient) ProcessRefund(ctx context.Context, req contracts.RefundRequest) (string, error) {
+	if err := c.inject(ctx, "ProcessRefund"); err != nil {
+		return "", err
+	}
+	return c.next.ProcessRefund(ctx, req)
+}
+
+func (c *FaultBillingClient) GetRefundStatus(ctx context.Context, providerRefundID string) (contracts.RefundOutcome, error) {
+	if err := c.inject(ctx, "GetRefundStatus"); err != nil {
+		return contracts.RefundOutcome{}, err
+	}
+	return c.next.GetRefundStatus(ctx, providerRefundID)
+}
+
+func (c *FaultBillingClient) ChargeCustomer(ctx context.Context, req contracts.ChargeRequest) error {
+	if err := c.inject(ctx, "ChargeCustomer"); err != nil {
+		return err
+	}
+	return c.next.ChargeCustomer(ctx, req)
+}
+
+func (c *FaultBillingClient) GetPaymentMethodStatus(ctx context.Context, customerID string) (domain.PaymentMethod, error) {
+	if err := c.inject(ctx, "GetPaymentMethodStatus"); err != nil {
+		return domain.PaymentMethod{}, err
+	}
+	return c.next.GetPaymentMethodStatus(ctx, customerID)
+}
+
+// inject applies the faults for method and translates an injected error into the
+// billing error it stands for
+func (c *FaultBillingClient) inject(ctx context.Context, method string) error {
+	err := c.injector.Inject(ctx, "billing."+method)
+	var fault *faults.Error
+	if !errors.As(err, &fault) {
+		return err
+	}
+	switch fault.Kind {
+	case faults.KindTimeout:
+		return fmt.Errorf("%s: %w", fault, ErrBillingTimeout)
+	case faults.KindDeclined:
+		return fmt.Errorf("%s: %w", fault, domain.ErrPaymentDeclined)
+	default:
+		return &StatusError{Op: method, StatusCode: http.StatusServiceUnavailable, Body: fault.Error()}
+	}
+}
@@ -0,0 +1,41 @@
+package adapters
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/faults"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/logging"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/testkit"
+)
+
+func TestFaultBillingClient_InjectsProviderShapedErrors(t *testing.T) {
+	injector, err := faults.New([]string{
+		"billing.ProcessRefund error=100%",
+		"billing.ChargeCustomer error=100% kind=declined",
+		"billing.GetRefundStatus error=100% kind=timeout",
+	}, false, logging.Discard())
+	require.NoError(t, err)
+	fake := testkit.NewFakeBillingClient()
+	client := NewFaultBillingClient(fake, injector)
+	ctx := context.Background()
+
+	_, err = client.ProcessRefund(ctx, contracts.RefundRequest{SubscriptionID: "sub-1"})
+	var statusErr *StatusError
+	require.ErrorAs(t, err, &statusErr)
+	assert.True(t, IsTransient(err), "an injected outage is retried like a real one")
+
+	err = client.ChargeCustomer(ctx, contracts.ChargeRequest{CustomerID: "cust-1"})
+	assert.ErrorIs(t, err, domain.ErrPaymentDeclined)
+
+	_, err = client.GetRefundStatus(ctx, "refund-1")
+	assert.ErrorIs(t, err, ErrBillingTimeout)
+
+	assert.NoError(t, client.ValidateCustomer(ctx, "cust-1"))
+	assert.Empty(t, fake.CallsTo(testkit.OpProcessRefund), "failed calls never reach the provider")
+}
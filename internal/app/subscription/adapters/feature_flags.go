@@ -0,0 +1,154 @@
+package adapters
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+)
+
+// FlagRule decides a flag for each target. Listed customers and plans are always on.
+// Other targets are on when Enabled is set, or when their customer falls within the
+// first Percentage of 100 buckets; a customer stays in its bucket as the rollout grows.
+type FlagRule struct {
+	Enabled    bool
+	Percentage int
+	Customers  []string
+	Plans      []string
+}
+
+// Evaluate reports whether flag is on for target under this rule
+func (r FlagRule) Evaluate(flag string, target contracts.FlagTarget) bool {
+	for _, id := range r.Customers {
+		if id == target.CustomerID {
+			return true
+		}
+	}
+	for _, id := range r.Plans {
+		if id == target.PlanID {
+			return true
+		}
+	}
+	if r.Enabled || r.Percentage >= 100 {
+		return true
+	}
+	if r.Percentage <= 0 {
+		return false
+	}
+
+	key := target.CustomerID
+	if key == "" {
+		key = target.SubscriptionID
+	}
+	// Hashing the flag name too gives each flag its own cohort
+	h := fnv.New32a()
+	h.Write([]byte(flag + "/" + key))
+	return int(h.Sum32()%100) < r.Percentage
+}
+
+// ParseFlagRule parses a comma-separated rule: "on", "off", a percentage such as
+// "25%", "customer:<id>" and "plan:<id>", e.g. "10%,customer:cust-1,plan:pro"
+func ParseFlagRule(s string) (FlagRule, error) {
+	var rule FlagRule
+	for _, term := range strings.Split(s, ",") {
+		term = strings.TrimSpace(term)
+		switch {
+		case term == "":
+		case strings.EqualFold(term, "on"), strings.EqualFold(term, "true"):
+			rule.Enabled = true
+		case strings.EqualFold(term, "off"), strings.EqualFold(term, "false"):
+			rule.Enabled = false
+		case strings.HasSuffix(term, "%"):
+			pct, err := strconv.Atoi(strings.TrimSuffix(term, "%"))
+			if err != nil || pct < 0 || pct > 100 {
+				return FlagRule{}, fmt.Errorf("invalid rollout percentage %q", term)
+			}
+			rule.Percentage = pct
+		case strings.HasPrefix(term, "customer:"):
+			rule.Customers = append(rule.Customers, strings.TrimPrefix(term, "customer:"))
+		case strings.HasPrefix(term, "plan:"):
+			rule.Plans = append(rule.Plans, strings.TrimPrefix(term, "plan:"))
+		default:
+			return FlagRule{}, fmt.Errorf("invalid flag rule term %q", term)
+		}
+	}
+	return rule, nil
+}
+
+// StaticFeatureFlags evaluates a fixed rule per flag; flags without a rule are off
+type StaticFeatureFlags map[string]FlagRule
+
+// NewStaticFeatureFlags turns on/off toggles, such as the configuration's features,
+// into rules
+func NewStaticFeatureFlags(toggles map[string]bool) StaticFeatureFlags {
+	flags := make(StaticFeatureFlags, len(toggles))
+	for name, on := range toggles {
+		flags[name] = FlagRule{Enabled: on}
+	}
+	return flags
+}
+
+func (f StaticFeatureFlags) Enabled(ctx context.Context, flag string, target contracts.FlagTarget) bool {
+	rule, ok := f[flag]
+	return ok && rule.Evaluate(flag, target)
+}
+
+// EnvFeatureFlags reads each flag's rule from the environment on every evaluation:
+// cancel.hourly_refunds is read from FEATURE_CANCEL_HOURLY_REFUNDS. Flags without a
+// variable are left to Fallback, and are off without one. A malformed rule is logged
+// and treated as off.
+type EnvFeatureFlags struct {
+	Fallback contracts.FeatureFlags
+	Logger   *slog.Logger
+}
+
+func (f EnvFeatureFlags) Enabled(ctx context.Context, flag string, target contracts.FlagTarget) bool {
+	key := "FEATURE_" + strings.ToUpper(strings.NewReplacer("-", "_", ".", "_", "/", "_").Replace(flag))
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return f.Fallback != nil && f.Fallback.Enabled(ctx, flag, target)
+	}
+	rule, err := ParseFlagRule(value)
+	if err != nil {
+		if f.Logger != nil {
+			f.Logger.WarnContext(ctx, "invalid feature flag rule", slog.String("flag", flag), slog.String("env", key), slog.Any("error", err))
+		}
+		return false
+	}
+	return rule.Evaluate(flag, target)
+}
+
+// BooleanFlagEvaluator is the boolean evaluation shared by the OpenFeature client and
+// vendor SDKs such as LaunchDarkly's: a flag evaluated for a targeting key and
+// attributes, with a default returned alongside any error. Either SDK's client is
+// adapted to it with a small shim in the composition root.
+type BooleanFlagEvaluator interface {
+	BooleanValue(ctx context.Context, flag string, defaultValue bool, targetingKey string, attributes map[string]any) (bool, error)
+}
+
+// OpenFeatureFlags evaluates flags through a remote flag service, so rollouts are
+// managed in that service. The customer ID is the targeting key.
+type OpenFeatureFlags struct {
+	Client BooleanFlagEvaluator
+	Logger *slog.Logger
+}
+
+func (f OpenFeatureFlags) Enabled(ctx context.Context, flag string, target contracts.FlagTarget) bool {
+	attributes := map[string]any{
+		"subscription_id": target.SubscriptionID,
+		"plan_id":         target.PlanID,
+	}
+	on, err := f.Client.BooleanValue(ctx, flag, false, target.CustomerID, attributes)
+	if err != nil {
+		if f.Logger != nil {
+			f.Logger.WarnContext(ctx, "feature flag evaluation failed", slog.String("flag", flag), slog.Any("error", err))
+		}
+		return false
+	}
+	return on
+}
@@ -0,0 +1,100 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+)
+
+func TestParseFlagRule(t *testing.T) {
+	rule, err := ParseFlagRule("10%, customer:cust-1,plan:pro")
+	require.NoError(t, err)
+	assert.Equal(t, FlagRule{Percentage: 10, Customers: []string{"cust-1"}, Plans: []string{"pro"}}, rule)
+
+	rule, err = ParseFlagRule("ON")
+	require.NoError(t, err)
+	assert.True(t, rule.Enabled)
+
+	for _, bad := range []string{"101%", "-5%", "half", "tenant:cust-1"} {
+		_, err := ParseFlagRule(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestFlagRule_PercentageRollout(t *testing.T) {
+	target := func(i int) contracts.FlagTarget {
+		return contracts.FlagTarget{CustomerID: fmt.Sprintf("cust-%d", i)}
+	}
+
+	enabled := 0
+	for i := 0; i < 1000; i++ {
+		on := FlagRule{Percentage: 20}.Evaluate("f", target(i))
+		if on {
+			enabled++
+			// Growing the rollout keeps every customer that already had the flag
+			assert.True(t, FlagRule{Percentage: 50}.Evaluate("f", target(i)))
+		}
+		assert.Equal(t, on, FlagRule{Percentage: 20}.Evaluate("f", target(i)), "evaluation must be stable")
+		assert.False(t, FlagRule{Percentage: 0}.Evaluate("f", target(i)))
+		assert.True(t, FlagRule{Percentage: 100}.Evaluate("f", target(i)))
+	}
+	assert.InDelta(t, 200, enabled, 50)
+}
+
+func TestFlagRule_Allowlists(t *testing.T) {
+	rule := FlagRule{Customers: []string{"cust-1"}, Plans: []string{"pro"}}
+
+	assert.True(t, rule.Evaluate("f", contracts.FlagTarget{CustomerID: "cust-1", PlanID: "basic"}))
+	assert.True(t, rule.Evaluate("f", contracts.FlagTarget{CustomerID: "cust-2", PlanID: "pro"}))
+	assert.False(t, rule.Evaluate("f", contracts.FlagTarget{CustomerID: "cust-2", PlanID: "basic"}))
+}
+
+func TestEnvFeatureFlags(t *testing.T) {
+	target := contracts.FlagTarget{CustomerID: "cust-1"}
+	flags := EnvFeatureFlags{Fallback: StaticFeatureFlags{"cancel.hourly_refunds": {Enabled: true}}}
+
+	assert.True(t, flags.Enabled(context.Background(), "cancel.hourly_refunds", target), "fallback applies without a variable")
+	assert.False(t, flags.Enabled(context.Background(), "cancel.other", target))
+
+	t.Setenv("FEATURE_CANCEL_HOURLY_REFUNDS", "off")
+	assert.False(t, flags.Enabled(context.Background(), "cancel.hourly_refunds", target), "variable overrides fallback")
+
+	t.Setenv("FEATURE_CANCEL_HOURLY_REFUNDS", "customer:cust-1")
+	assert.True(t, flags.Enabled(context.Background(), "cancel.hourly_refunds", target))
+
+	t.Setenv("FEATURE_CANCEL_HOURLY_REFUNDS", "sometimes")
+	assert.False(t, flags.Enabled(context.Background(), "cancel.hourly_refunds", target), "malformed rule is off")
+}
+
+type fakeEvaluator struct {
+	value        bool
+	err          error
+	targetingKey string
+	attributes   map[string]any
+}
+
+func (f *fakeEvaluator) BooleanValue(ctx context.Context, flag string, defaultValue bool, targetingKey string, attributes map[string]any) (bool, error) {
+	f.targetingKey = targetingKey
+	f.attributes = attributes
+	if f.err != nil {
+		return defaultValue, f.err
+	}
+	return f.value, nil
+}
+
+func TestOpenFeatureFlags(t *testing.T) {
+	target := contracts.FlagTarget{CustomerID: "cust-1", SubscriptionID: "sub-1", PlanID: "pro"}
+
+	client := &fakeEvaluator{value: true}
+	assert.True(t, OpenFeatureFlags{Client: client}.Enabled(context.Background(), "f", target))
+	assert.Equal(t, "cust-1", client.targetingKey)
+	assert.Equal(t, "pro", client.attributes["plan_id"])
+
+	client = &fakeEvaluator{value: true, err: errors.New("provider not ready")}
+	assert.False(t, OpenFeatureFlags{Client: client}.Enabled(context.Background(), "f", target))
+}
@@ -0,0 +1,134 @@
+package adapters
+
+import (
+	"context"
+	"time"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+var _ contracts.BillingClient = (*HedgedBillingClient)(nil)
+
+// MetricBillingHedges counts hedged billing calls by operation and by which attempt answered
+const MetricBillingHedges = "billing_hedges_total"
+
+// Hedge outcomes recorded in MetricBillingHedges
+const (
+	HedgeNotNeeded  = "not_needed"  // the first attempt answered before the hedge delay
+	HedgePrimaryWon = "primary_won" // a hedge was sent but the first attempt still answered first
+	HedgeWon        = "hedge_won"   // the hedge answered first
+)
+
+// HedgeConfig configures request hedging
+type HedgeConfig struct {
+	// Delay is how long to wait for the first attempt before sending a second one.
+	// Set it near the call's p95 latency so only slow calls are hedged.
+	Delay time.Duration
+}
+
+// HedgedBillingClient cuts tail latency of idempotent billing reads: when the first attempt
+// hasn't answered within the hedge delay, it sends a second one and takes whichever answers
+// first. Only ValidateCustomer and GetRefundStatus are hedged; writes pass through.
+type HedgedBillingClient struct {
+	next    contracts.BillingClient
+	cfg     HedgeConfig
+	metrics contracts.Metrics
+}
+
+// NewHedgedBillingClient wraps next with hedged reads, recording hedge outcomes to metrics
+func NewHedgedBillingClient(next contracts.BillingClient, cfg HedgeConfig, metrics contracts.Metrics) *HedgedBillingClient {
+	return &HedgedBillingClient{next: next, cfg: cfg, metrics: metrics}
+}
+
+// ValidateCustomer validates a customer, hedging slow attempts
+func (c *HedgedBillingClient) ValidateCustomer(ctx context.Context, customerID string) error {
+	_, err := hedge(ctx, c, opValidateCustomer, func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, c.next.ValidateCustomer(ctx, customerID)
+	})
+	return err
+}
+
+// CreateCustomer is not hedged
+func (c *HedgedBillingClient) CreateCustomer(ctx context.Context, req contracts.CreateCustomerRequest) error {
+	return c.next.CreateCustomer(ctx, req)
+}
+
+// ProcessRefund is not hedged
+func (c *HedgedBillingClient) ProcessRefund(ctx context.Context, req contracts.RefundRequest) (string, error) {
+	return c.next.ProcessRefund(ctx, req)
+}
+
+// GetRefundStatus looks up a refund, hedging slow attempts
+func (c *HedgedBillingClient) GetRefundStatus(ctx context.Context, providerRefundID string) (contracts.RefundOutcome, error) {
+	return hedge(ctx, c, opGetRefundStatus, func(ctx context.Context) (contracts.RefundOutcome, error) {
+		return c.next.GetRefundStatus(ctx, providerRefundID)
+	})
+}
+
+// ChargeCustomer is not hedged
+func (c *HedgedBillingClient) ChargeCustomer(ctx context.Context, req contracts.ChargeRequest) error {
+	return c.next.ChargeCustomer(ctx, req)
+}
+
+// GetPaymentMethodStatus looks up a payment method, hedging slow attempts
+func (c *HedgedBillingClient) GetPaymentMethodStatus(ctx context.Context, customerID string) (domain.PaymentMethod, error) {
+	return hedge(ctx, c, opGetPaymentMethodStatus, func(ctx context.Context) (domain.PaymentMethod, error) {
+		return c.next.GetPaymentMethodStatus(ctx, customerID)
+	})
+}
+
+type hedgeResult[T any] struct {
+	value T
+	err   error
+	hedge bool
+}
+
+// hedge runs call, and a second copy of it if the first hasn't answered within the delay.
+// The first answer wins, except that a transient failure waits for the other attempt
+// while it is still running. The losing attempt is cancelled.
+func hedge[T any](ctx context.Context, c *HedgedBillingClient, op string, call func(ctx context.Context) (T, error)) (T, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Buffered so the losing attempt never blocks after we return
+	results := make(chan hedgeResult[T], 2)
+	launch := func(isHedge bool) {
+		go func() {
+			value, err := call(ctx)
+			results <- hedgeResult[T]{value: value, err: err, hedge: isHedge}
+		}()
+	}
+
+	launch(false)
+	timer := time.NewTimer(c.cfg.Delay)
+	defer timer.Stop()
+
+	hedged, inFlight := false, 1
+	for {
+		select {
+		case <-timer.C:
+			hedged = true
+			inFlight++
+			launch(true)
+		case res := <-results:
+			inFlight--
+			if IsTransient(res.err) && inFlight > 0 {
+				continue
+			}
+			c.record(op, hedged, res.hedge)
+			return res.value, res.err
+		}
+	}
+}
+
+func (c *HedgedBillingClient) record(op string, hedged, hedgeAnswered bool) {
+	outcome := HedgeNotNeeded
+	switch {
+	case hedgeAnswered:
+		outcome = HedgeWon
+	case hedged:
+		outcome = HedgePrimaryWon
+	}
+	c.metrics.IncCounter(MetricBillingHedges, map[string]string{"op": op, "outcome": outcome})
+}
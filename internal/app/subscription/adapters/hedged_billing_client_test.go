@@ -0,0 +1,96 @@
+package adapters
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+// hedgeOutcomes lists the outcome label of every MetricBillingHedges increment
+func hedgeOutcomes(m *recordingMetrics) []string {
+	var outcomes []string
+	for _, labels := range m.counters[MetricBillingHedges] {
+		outcomes = append(outcomes, labels["outcome"])
+	}
+	return outcomes
+}
+
+// sleepFor makes a mocked call take d, like a slow billing API
+func sleepFor(d time.Duration) func(mock.Arguments) {
+	return func(mock.Arguments) { time.Sleep(d) }
+}
+
+func TestHedgedBillingClient_FastCallIsNotHedged(t *testing.T) {
+	next := new(MockBillingClient)
+	metrics := newRecordingMetrics()
+	client := NewHedgedBillingClient(next, HedgeConfig{Delay: time.Second}, metrics)
+
+	next.On("ValidateCustomer", mock.Anything, "cust-1").Return(nil).Once()
+
+	err := client.ValidateCustomer(context.Background(), "cust-1")
+
+	assert.NoError(t, err)
+	next.AssertNumberOfCalls(t, "ValidateCustomer", 1)
+	assert.Equal(t, []string{HedgeNotNeeded}, hedgeOutcomes(metrics))
+}
+
+func TestHedgedBillingClient_HedgeAnswersFirst(t *testing.T) {
+	next := new(MockBillingClient)
+	metrics := newRecordingMetrics()
+	client := NewHedgedBillingClient(next, HedgeConfig{Delay: 10 * time.Millisecond}, metrics)
+
+	next.On("ValidateCustomer", mock.Anything, "cust-1").Run(blockUntilDone).Return(context.Canceled).Once()
+	next.On("ValidateCustomer", mock.Anything, "cust-1").Return(domain.ErrInvalidCustomer).Once()
+
+	err := client.ValidateCustomer(context.Background(), "cust-1")
+
+	assert.ErrorIs(t, err, domain.ErrInvalidCustomer)
+	assert.Equal(t, []string{HedgeWon}, hedgeOutcomes(metrics))
+}
+
+func TestHedgedBillingClient_PrimaryCanStillWin(t *testing.T) {
+	next := new(MockBillingClient)
+	metrics := newRecordingMetrics()
+	client := NewHedgedBillingClient(next, HedgeConfig{Delay: 10 * time.Millisecond}, metrics)
+	outcome := contracts.RefundOutcome{Status: domain.RefundSucceeded}
+
+	next.On("GetRefundStatus", mock.Anything, "refund-1").Run(sleepFor(30*time.Millisecond)).Return(outcome, nil).Once()
+	next.On("GetRefundStatus", mock.Anything, "refund-1").Run(blockUntilDone).Return(contracts.RefundOutcome{}, context.Canceled).Once()
+
+	got, err := client.GetRefundStatus(context.Background(), "refund-1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, outcome, got)
+	assert.Equal(t, []string{HedgePrimaryWon}, hedgeOutcomes(metrics))
+}
+
+func TestHedgedBillingClient_TransientFailureWaitsForOtherAttempt(t *testing.T) {
+	next := new(MockBillingClient)
+	metrics := newRecordingMetrics()
+	client := NewHedgedBillingClient(next, HedgeConfig{Delay: 10 * time.Millisecond}, metrics)
+
+	next.On("ValidateCustomer", mock.Anything, "cust-1").Run(sleepFor(20 * time.Millisecond)).Return(unavailable).Once()
+	next.On("ValidateCustomer", mock.Anything, "cust-1").Run(sleepFor(30 * time.Millisecond)).Return(nil).Once()
+
+	err := client.ValidateCustomer(context.Background(), "cust-1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{HedgeWon}, hedgeOutcomes(metrics))
+}
+
+func TestHedgedBillingClient_DoesNotHedgeWrites(t *testing.T) {
+	next := new(MockBillingClient)
+	client := NewHedgedBillingClient(next, HedgeConfig{Delay: time.Nanosecond}, newRecordingMetrics())
+
+	next.On("ChargeCustomer", mock.Anything, mock.Anything).Run(sleepFor(10 * time.Millisecond)).Return(nil).Once()
+
+	err := client.ChargeCustomer(context.Background(), contracts.ChargeRequest{CustomerID: "cust-1", Amount: 1000})
+
+	assert.NoError(t, err)
+	next.AssertNumberOfCalls(t, "ChargeCustomer", 1)
+}
@@ -0,0 +1,69 @@
+package adapters
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+)
+
+var _ contracts.ReportSigner = (*HMACSigner)(nil)
+
+// HMACSigner signs payloads with HMAC-SHA256
+type HMACSigner struct {
+	key []byte
+}
+
+// NewHMACSigner creates a signer using the given secret key
+func NewHMACSigner(key []byte) (*HMACSigner, error) {
+	if len(key) == 0 {
+		return nil, errors.New("signing key cannot be empty")
+	}
+	return &HMACSigner{key: key}, nil
+}
+
+// Sign returns the hex-encoded HMAC of payload
+func (s *HMACSigner) Sign(payload []byte) (string, error) {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// Verify reports whether signature is the hex-encoded HMAC of payload, in constant time
+func (s *HMACSigner) Verify(payload []byte, signature string) bool {
+	expected, _ := s.Sign(payload)
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
+
+// SecretHMACVerifier verifies HMAC-SHA256 signatures with keys resolved from a secret
+// provider on every call, so a rotated key takes effect without a restart. A signature
+// made with any of the named keys is accepted; during a rotation, name the new and the
+// previous key, and keys that don't exist are skipped.
+type SecretHMACVerifier struct {
+	Secrets contracts.SecretProvider
+	Names   []string
+	Logger  *slog.Logger
+}
+
+// Verify reports whether signature is the hex-encoded HMAC of payload under one of the keys
+func (v SecretHMACVerifier) Verify(payload []byte, signature string) bool {
+	ctx := context.Background()
+	for _, name := range v.Names {
+		key, err := v.Secrets.Secret(ctx, name)
+		if err != nil {
+			if !errors.Is(err, ErrSecretNotFound) && v.Logger != nil {
+				v.Logger.Error("failed to resolve signing key", slog.String("secret", name), slog.Any("error", err))
+			}
+			continue
+		}
+		signer, err := NewHMACSigner([]byte(key))
+		if err == nil && signer.Verify(payload, signature) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,109 @@
+package adapters
+
+import (
+	"context"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+var (
+	_ contracts.SubscriptionHooks = NoopHooks{}
+	_ contracts.SubscriptionHooks = HookChain{}
+)
+
+// NoopHooks allows every change and ignores every outcome. Embed it to implement only
+// the hooks a deployment needs.
+type NoopHooks struct{}
+
+func (NoopHooks) BeforeCreate(ctx context.Context, sub *domain.Subscription, event *domain.SubscriptionCreatedEvent) error {
+	return nil
+}
+
+func (NoopHooks) AfterCreate(ctx context.Context, sub *domain.Subscription, event *domain.SubscriptionCreatedEvent) {
+}
+
+func (NoopHooks) BeforeCancel(ctx context.Context, sub *domain.Subscription, event *domain.SubscriptionCancelledEvent) error {
+	return nil
+}
+
+func (NoopHooks) AfterCancel(ctx context.Context, sub *domain.Subscription, event *domain.SubscriptionCancelledEvent) {
+}
+
+func (NoopHooks) BeforeRenew(ctx context.Context, sub *domain.Subscription, event *domain.SubscriptionRenewedEvent) error {
+	return nil
+}
+
+func (NoopHooks) AfterRenew(ctx context.Context, sub *domain.Subscription, event *domain.SubscriptionRenewedEvent) {
+}
+
+func (NoopHooks) BeforePlanChange(ctx context.Context, sub *domain.Subscription, event *domain.SubscriptionPlanChangedEvent) error {
+	return nil
+}
+
+func (NoopHooks) AfterPlanChange(ctx context.Context, sub *domain.Subscription, event *domain.SubscriptionPlanChangedEvent) {
+}
+
+// HookChain runs several hooks in the order they were registered. A Before hook's
+// veto stops the chain; every After hook runs. The empty chain is a no-op.
+type HookChain []contracts.SubscriptionHooks
+
+func (c HookChain) BeforeCreate(ctx context.Context, sub *domain.Subscription, event *domain.SubscriptionCreatedEvent) error {
+	for _, h := range c {
+		if err := h.BeforeCreate(ctx, sub, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c HookChain) AfterCreate(ctx context.Context, sub *domain.Subscription, event *domain.SubscriptionCreatedEvent) {
+	for _, h := range c {
+		h.AfterCreate(ctx, sub, event)
+	}
+}
+
+func (c HookChain) BeforeCancel(ctx context.Context, sub *domain.Subscription, event *domain.SubscriptionCancelledEvent) error {
+	for _, h := range c {
+		if err := h.BeforeCancel(ctx, sub, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c HookChain) AfterCancel(ctx context.Context, sub *domain.Subscription, event *domain.SubscriptionCancelledEvent) {
+	for _, h := range c {
+		h.AfterCancel(ctx, sub, event)
+	}
+}
+
+func (c HookChain) BeforeRenew(ctx context.Context, sub *domain.Subscription, event *domain.SubscriptionRenewedEvent) error {
+	for _, h := range c {
+		if err := h.BeforeRenew(ctx, sub, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c HookChain) AfterRenew(ctx context.Context, sub *domain.Subscription, event *domain.SubscriptionRenewedEvent) {
+	for _, h := range c {
+		h.AfterRenew(ctx, sub, event)
+	}
+}
+
+func (c HookChain) BeforePlanChange(ctx context.Context, sub *domain.Subscription, event *domain.SubscriptionPlanChangedEvent) error {
+	for _, h := range c {
+		if err := h.BeforePlanChange(ctx, sub, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c HookChain) AfterPlanChange(ctx context.Context, sub *domain.Subscription, event *domain.SubscriptionPlanChangedEvent) {
+	for _, h := range c {
+		h.AfterPlanChange(ctx, sub, event)
+	}
+}
@@ -0,0 +1,48 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+// cancelHook records its name into calls and vetoes cancellations with veto
+type cancelHook struct {
+	NoopHooks
+	name  string
+	veto  error
+	calls *[]string
+}
+
+func (h cancelHook) BeforeCancel(ctx context.Context, sub *domain.Subscription, event *domain.SubscriptionCancelledEvent) error {
+	*h.calls = append(*h.calls, "before:"+h.name)
+	return h.veto
+}
+
+func (h cancelHook) AfterCancel(ctx context.Context, sub *domain.Subscription, event *domain.SubscriptionCancelledEvent) {
+	*h.calls = append(*h.calls, "after:"+h.name)
+}
+
+func TestHookChain_RunsHooksInOrder(t *testing.T) {
+	var calls []string
+	chain := HookChain{cancelHook{name: "crm", calls: &calls}, cancelHook{name: "audit", calls: &calls}}
+
+	assert.NoError(t, chain.BeforeCancel(context.Background(), nil, nil))
+	chain.AfterCancel(context.Background(), nil, nil)
+
+	assert.Equal(t, []string{"before:crm", "before:audit", "after:crm", "after:audit"}, calls)
+}
+
+func TestHookChain_VetoStopsBeforeHooks(t *testing.T) {
+	var calls []string
+	veto := errors.New("retention offer pending")
+	chain := HookChain{cancelHook{name: "crm", veto: veto, calls: &calls}, cancelHook{name: "audit", calls: &calls}}
+
+	assert.ErrorIs(t, chain.BeforeCancel(context.Background(), nil, nil), veto)
+	assert.Equal(t, []string{"before:crm"}, calls)
+	// Hooks that don't override a method fall back to NoopHooks
+	assert.NoError(t, chain.BeforeRenew(context.Background(), nil, nil))
+}
@@ -0,0 +1,168 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+var _ contracts.BillingClient = (*InstrumentedBillingClient)(nil)
+
+const (
+	MetricBillingCalls    = "billing_calls_total"
+	MetricBillingDuration = "billing_call_duration_seconds"
+	MetricBillingRetries  = "billing_retries_total"
+)
+
+// Billing operation names used in metric labels and span names
+const (
+	opValidateCustomer       = "validate_customer"
+	opCreateCustomer         = "create_customer"
+	opProcessRefund          = "process_refund"
+	opGetRefundStatus        = "get_refund_status"
+	opChargeCustomer         = "charge_customer"
+	opGetPaymentMethodStatus = "get_payment_method_status"
+)
+
+// InstrumentedBillingClient records a span, a latency histogram, a call counter labelled with
+// the response status, and the number of retries for every billing call. As the outermost
+// decorator its latency includes retries and backoff, which is what callers wait for.
+type InstrumentedBillingClient struct {
+	next     contracts.BillingClient
+	provider BillingProvider
+	metrics  contracts.Metrics
+	tracer   contracts.Tracer
+}
+
+// NewInstrumentedBillingClient wraps next, labelling its telemetry with provider
+func NewInstrumentedBillingClient(next contracts.BillingClient, provider BillingProvider, metrics contracts.Metrics, tracer contracts.Tracer) *InstrumentedBillingClient {
+	return &InstrumentedBillingClient{next: next, provider: provider, metrics: metrics, tracer: tracer}
+}
+
+func (c *InstrumentedBillingClient) ValidateCustomer(ctx context.Context, customerID string) error {
+	return c.do(ctx, opValidateCustomer, func(ctx context.Context) error {
+		return c.next.ValidateCustomer(ctx, customerID)
+	})
+}
+
+func (c *InstrumentedBillingClient) CreateCustomer(ctx context.Context, req contracts.CreateCustomerRequest) error {
+	return c.do(ctx, opCreateCustomer, func(ctx context.Context) error {
+		return c.next.CreateCustomer(ctx, req)
+	})
+}
+
+func (c *InstrumentedBillingClient) ProcessRefund(ctx context.Context, req contracts.RefundRequest) (string, error) {
+	var refundID string
+	err := c.do(ctx, opProcessRefund, func(ctx context.Context) error {
+		var err error
+		refundID, err = c.next.ProcessRefund(ctx, req)
+		return err
+	})
+	return refundID, err
+}
+
+func (c *InstrumentedBillingClient) GetRefundStatus(ctx context.Context, providerRefundID string) (contracts.RefundOutcome, error) {
+	var outcome contracts.RefundOutcome
+	err := c.do(ctx, opGetRefundStatus, func(ctx context.Context) error {
+		var err error
+		outcome, err = c.next.GetRefundStatus(ctx, providerRefundID)
+		return err
+	})
+	return outcome, err
+}
+
+func (c *InstrumentedBillingClient) ChargeCustomer(ctx context.Context, req contracts.ChargeRequest) error {
+	return c.do(ctx, opChargeCustomer, func(ctx context.Context) error {
+		return c.next.ChargeCustomer(ctx, req)
+	})
+}
+
+func (c *InstrumentedBillingClient) GetPaymentMethodStatus(ctx context.Context, customerID string) (domain.PaymentMethod, error) {
+	var pm domain.PaymentMethod
+	err := c.do(ctx, opGetPaymentMethodStatus, func(ctx context.Context) error {
+		var err error
+		pm, err = c.next.GetPaymentMethodStatus(ctx, customerID)
+		return err
+	})
+	return pm, err
+}
+
+// do runs call inside a span named after the operation and records its metrics
+func (c *InstrumentedBillingClient) do(ctx context.Context, op string, call func(ctx context.Context) error) error {
+	ctx, span := c.tracer.Start(ctx, "billing."+op)
+	defer span.End()
+	span.SetAttribute("billing.provider", string(c.provider))
+
+	attempts := new(atomic.Int64)
+	ctx = context.WithValue(ctx, attemptsKey{}, attempts)
+
+	start := time.Now()
+	err := call(ctx)
+	elapsed := time.Since(start)
+
+	status := statusLabel(err)
+	span.SetAttribute("billing.status", status)
+	if err != nil {
+		span.RecordError(err)
+	}
+
+	labels := map[string]string{"provider": string(c.provider), "op": op}
+	// Decorators that retry count their attempts; without one there is exactly one
+	if n := attempts.Load(); n > 1 {
+		span.SetAttribute("billing.attempts", strconv.FormatInt(n, 10))
+		for i := int64(1); i < n; i++ {
+			c.metrics.IncCounter(MetricBillingRetries, labels)
+		}
+	}
+	c.metrics.ObserveHistogram(MetricBillingDuration, elapsed.Seconds(), labels)
+	c.metrics.IncCounter(MetricBillingCalls, map[string]string{"provider": string(c.provider), "op": op, "status": status})
+
+	return err
+}
+
+// attemptsKey carries the attempt counter from InstrumentedBillingClient to the retry loop
+type attemptsKey struct{}
+
+// countAttempt records one attempt against the call's counter, if it is being instrumented
+func countAttempt(ctx context.Context) {
+	if attempts, ok := ctx.Value(attemptsKey{}).(*atomic.Int64); ok {
+		attempts.Add(1)
+	}
+}
+
+// statusLabel reduces a billing error to a low-cardinality label: the HTTP status code
+// where the API answered, otherwise the kind of failure
+func statusLabel(err error) string {
+	var statusErr *StatusError
+	var netErr net.Error
+	switch {
+	case err == nil:
+		return "ok"
+	case errors.As(err, &statusErr):
+		return strconv.Itoa(statusErr.StatusCode)
+	case errors.Is(err, domain.ErrInvalidCustomer):
+		return "invalid_customer"
+	case errors.Is(err, domain.ErrAuthenticationRequired):
+		return "authentication_required"
+	case errors.Is(err, domain.ErrPaymentDeclined):
+		return "declined"
+	case errors.Is(err, domain.ErrCurrencyMismatch):
+		return "currency_mismatch"
+	case errors.Is(err, ErrCircuitOpen):
+		return "circuit_open"
+	case errors.Is(err, ErrBillingTimeout), errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.As(err, &netErr):
+		return "network"
+	default:
+		return "error"
+	}
+}
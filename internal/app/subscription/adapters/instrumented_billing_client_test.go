@@ -0,0 +1,129 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+// recordingMetrics keeps every counter increment and histogram observation
+type recordingMetrics struct {
+	mu         sync.Mutex
+	counters   map[string][]map[string]string
+	histograms map[string][]map[string]string
+}
+
+func newRecordingMetrics() *recordingMetrics {
+	return &recordingMetrics{counters: map[string][]map[string]string{}, histograms: map[string][]map[string]string{}}
+}
+
+func (m *recordingMetrics) IncCounter(name string, labels map[string]string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.counters[name] = append(m.counters[name], labels)
+}
+
+func (m *recordingMetrics) ObserveHistogram(name string, value float64, labels map[string]string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.histograms[name] = append(m.histograms[name], labels)
+}
+
+// recordingTracer keeps the attributes and errors of every span it starts
+type recordingTracer struct {
+	spans []*recordingSpan
+}
+
+func (t *recordingTracer) Start(ctx context.Context, name string) (context.Context, contracts.Span) {
+	span := &recordingSpan{name: name, attrs: map[string]string{}}
+	t.spans = append(t.spans, span)
+	return ctx, span
+}
+
+type recordingSpan struct {
+	name  string
+	attrs map[string]string
+	err   error
+	ended bool
+}
+
+func (s *recordingSpan) SetAttribute(key, value string) { s.attrs[key] = value }
+
+func (s *recordingSpan) RecordError(err error) { s.err = err }
+
+func (s *recordingSpan) End() { s.ended = true }
+
+func TestInstrumentedBillingClient_RecordsSuccessfulCall(t *testing.T) {
+	next := new(MockBillingClient)
+	metrics := newRecordingMetrics()
+	tracer := &recordingTracer{}
+	client := NewInstrumentedBillingClient(next, ProviderHTTP, metrics, tracer)
+
+	next.On("ValidateCustomer", mock.Anything, "cust-1").Return(nil)
+
+	err := client.ValidateCustomer(context.Background(), "cust-1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []map[string]string{{"provider": "http", "op": "validate_customer", "status": "ok"}}, metrics.counters[MetricBillingCalls])
+	assert.Equal(t, []map[string]string{{"provider": "http", "op": "validate_customer"}}, metrics.histograms[MetricBillingDuration])
+	assert.Empty(t, metrics.counters[MetricBillingRetries])
+
+	assert.Len(t, tracer.spans, 1)
+	assert.Equal(t, "billing.validate_customer", tracer.spans[0].name)
+	assert.Equal(t, "ok", tracer.spans[0].attrs["billing.status"])
+	assert.True(t, tracer.spans[0].ended)
+}
+
+func TestInstrumentedBillingClient_LabelsFailuresByStatus(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status string
+	}{
+		{"http status", unavailable, "503"},
+		{"domain rejection", domain.ErrInvalidCustomer, "invalid_customer"},
+		{"per-call timeout", ErrBillingTimeout, "timeout"},
+		{"open circuit", ErrCircuitOpen, "circuit_open"},
+		{"other", errors.New("boom"), "error"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			next := new(MockBillingClient)
+			metrics := newRecordingMetrics()
+			tracer := &recordingTracer{}
+			client := NewInstrumentedBillingClient(next, ProviderPaddle, metrics, tracer)
+
+			next.On("ChargeCustomer", mock.Anything, mock.Anything).Return(tc.err)
+
+			err := client.ChargeCustomer(context.Background(), contracts.ChargeRequest{CustomerID: "cust-1", Amount: 1000})
+
+			assert.ErrorIs(t, err, tc.err)
+			assert.Equal(t, tc.status, metrics.counters[MetricBillingCalls][0]["status"])
+			assert.Equal(t, tc.err, tracer.spans[0].err)
+		})
+	}
+}
+
+func TestInstrumentedBillingClient_CountsRetries(t *testing.T) {
+	next := new(MockBillingClient)
+	metrics := newRecordingMetrics()
+	tracer := &recordingTracer{}
+	client := NewInstrumentedBillingClient(newTestResilientClient(next, domain.RealClock{}), ProviderHTTP, metrics, tracer)
+
+	next.On("ProcessRefund", mock.Anything, keyedRefund).Return("", unavailable).Twice()
+	next.On("ProcessRefund", mock.Anything, keyedRefund).Return("refund-1", nil).Once()
+
+	refundID, err := client.ProcessRefund(context.Background(), keyedRefund)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "refund-1", refundID)
+	assert.Len(t, metrics.counters[MetricBillingRetries], 2)
+	assert.Equal(t, "3", tracer.spans[0].attrs["billing.attempts"])
+}
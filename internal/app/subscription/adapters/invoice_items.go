@@ -0,0 +1,47 @@
+package adapters
+
+import (
+	"context"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+var (
+	_ contracts.InvoiceItemsSource = StaticInvoiceItems{}
+	_ contracts.InvoiceItemsSource = BundleInvoiceItems{}
+)
+
+// StaticInvoiceItems gives every subscription the same invoice items. The zero value
+// bills the plan's base price alone.
+type StaticInvoiceItems struct {
+	Items domain.InvoiceItems
+}
+
+// UpcomingItems returns the static items
+func (s StaticInvoiceItems) UpcomingItems(ctx context.Context, sub *domain.Subscription) (domain.InvoiceItems, error) {
+	return s.Items, nil
+}
+
+// BundleInvoiceItems bills the add-ons a subscription was set up with, on top of the
+// items Base gives it
+type BundleInvoiceItems struct {
+	Bundles contracts.SubscriptionBundleRepository
+	Base    contracts.InvoiceItemsSource
+}
+
+// UpcomingItems returns Base's items with the subscription's add-ons appended
+func (b BundleInvoiceItems) UpcomingItems(ctx context.Context, sub *domain.Subscription) (domain.InvoiceItems, error) {
+	items, err := b.Base.UpcomingItems(ctx, sub)
+	if err != nil {
+		return domain.InvoiceItems{}, err
+	}
+	bundle, err := b.Bundles.FindBySubscriptionID(ctx, sub.ID())
+	if err != nil {
+		return domain.InvoiceItems{}, err
+	}
+	if len(bundle.AddOns) > 0 {
+		items.AddOns = append(append([]domain.AddOnCharge(nil), items.AddOns...), bundle.AddOns...)
+	}
+	return items, nil
+}
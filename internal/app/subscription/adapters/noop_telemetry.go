@@ -0,0 +1,34 @@
+package adapters
+
+import (
+	"context"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+)
+
+var (
+	_ contracts.Metrics = NoopMetrics{}
+	_ contracts.Tracer  = NoopTracer{}
+)
+
+// NoopMetrics discards all metrics
+type NoopMetrics struct{}
+
+func (NoopMetrics) IncCounter(name string, labels map[string]string) {}
+
+func (NoopMetrics) ObserveHistogram(name string, value float64, labels map[string]string) {}
+
+// NoopTracer starts spans that record nothing
+type NoopTracer struct{}
+
+func (NoopTracer) Start(ctx context.Context, name string) (context.Context, contracts.Span) {
+	return ctx, noopSpan{}
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttribute(key, value string) {}
+
+func (noopSpan) RecordError(err error) {}
+
+func (noopSpan) End() {}
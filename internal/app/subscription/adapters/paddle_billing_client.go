@@ -0,0 +1,356 @@
+package adapters
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+const (
+	PaddleProductionURL = "https://api.paddle.com"
+	PaddleSandboxURL    = "https://sandbox-api.paddle.com"
+)
+
+// ErrUnsupportedByProvider is returned for operations a billing provider cannot perform
+var ErrUnsupportedByProvider = errors.New("operation not supported by billing provider")
+
+var (
+	_ contracts.BillingClient = (*PaddleBillingClient)(nil)
+	_ contracts.BillingPinger = (*PaddleBillingClient)(nil)
+)
+
+// PaddleBillingClient implements the billing client interface against the Paddle Billing API.
+// Customer IDs are Paddle customer IDs (ctm_...) and subscription IDs are Paddle
+// subscription IDs (sub_...). Paddle collects renewals itself, so charges are not
+// initiated from here.
+type PaddleBillingClient struct {
+	client  *http.Client
+	baseURL string
+	apiKey  string
+}
+
+// NewPaddleBillingClient creates a Paddle billing client; sandbox selects Paddle's sandbox environment
+func NewPaddleBillingClient(client *http.Client, apiKey string, sandbox bool) *PaddleBillingClient {
+	baseURL := PaddleProductionURL
+	if sandbox {
+		baseURL = PaddleSandboxURL
+	}
+	return &PaddleBillingClient{
+		client:  client,
+		baseURL: baseURL,
+		apiKey:  apiKey,
+	}
+}
+
+// Ping lists Paddle's event types, the cheapest authenticated read, so a revoked API
+// key fails the check as well as an outage
+func (c *PaddleBillingClient) Ping(ctx context.Context) error {
+	req, err := c.newRequest(ctx, "GET", c.baseURL+"/event-types", nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach Paddle: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		bodyBytes, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return &StatusError{Op: "ping", StatusCode: resp.StatusCode, Body: string(bodyBytes)}
+	}
+	return nil
+}
+
+// ValidateCustomer checks that the customer exists in Paddle and is active
+func (c *PaddleBillingClient) ValidateCustomer(ctx context.Context, customerID string) error {
+	endpoint := fmt.Sprintf("%s/customers/%s", c.baseURL, url.PathEscape(customerID))
+
+	req, err := c.newRequest(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to validate customer: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return domain.ErrInvalidCustomer
+	}
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return &StatusError{Op: "paddle customer lookup", StatusCode: resp.StatusCode, Body: string(bodyBytes)}
+	}
+
+	var result struct {
+		Data struct {
+			Status string `json:"status"`
+		} `json:"data"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	if result.Data.Status != "active" {
+		return domain.ErrInvalidCustomer
+	}
+
+	return nil
+}
+
+// ProcessRefund refunds part of the subscription's most recent completed transaction.
+// Paddle refunds are adjustments against a transaction line item, so the subscription
+// ID must be the Paddle subscription ID (sub_...). Paddle adjustments are reviewed
+// asynchronously; the returned ID is the adjustment ID (adj_...). Paddle has no
+// idempotency keys, so a repeated call creates a second adjustment. A refund in another
+// currency than the transaction is rejected before anything is sent.
+func (c *PaddleBillingClient) ProcessRefund(ctx context.Context, refundReq contracts.RefundRequest) (string, error) {
+	if err := domain.ValidateCurrency(refundReq.Currency); err != nil {
+		return "", err
+	}
+	txn, err := c.latestTransactionItem(ctx, refundReq.SubscriptionID)
+	if err != nil {
+		return "", err
+	}
+	if err := domain.CheckRefundCurrency(txn.currency, refundReq.Currency); err != nil {
+		return "", err
+	}
+
+	payload := map[string]any{
+		"action":         "refund",
+		"transaction_id": txn.id,
+		"reason":         refundReq.Reason,
+		"items": []map[string]any{{
+			"item_id": txn.itemID,
+			"type":    "partial",
+			"amount":  strconv.FormatInt(refundReq.Amount, 10), // Paddle amounts are strings in the lowest denomination
+		}},
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal payload: %w", err)
+	}
+
+	req, err := c.newRequest(ctx, "POST", c.baseURL+"/adjustments", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if refundReq.CorrelationID != "" {
+		req.Header.Set("X-Correlation-ID", refundReq.CorrelationID)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to process refund: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return "", &StatusError{Op: "paddle refund", StatusCode: resp.StatusCode, Body: string(bodyBytes)}
+	}
+
+	var result struct {
+		Data struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return result.Data.ID, nil
+}
+
+// GetRefundStatus maps the refund adjustment's review status to a refund outcome:
+// approved adjustments have been paid out, rejected or reversed ones have not
+func (c *PaddleBillingClient) GetRefundStatus(ctx context.Context, providerRefundID string) (contracts.RefundOutcome, error) {
+	endpoint := c.baseURL + "/adjustments?" + url.Values{"id": {providerRefundID}}.Encode()
+
+	req, err := c.newRequest(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return contracts.RefundOutcome{}, err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return contracts.RefundOutcome{}, fmt.Errorf("failed to get refund status: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return contracts.RefundOutcome{}, &StatusError{Op: "paddle adjustment lookup", StatusCode: resp.StatusCode, Body: string(bodyBytes)}
+	}
+
+	var result struct {
+		Data []struct {
+			Status string `json:"status"`
+		} `json:"data"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return contracts.RefundOutcome{}, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if len(result.Data) == 0 {
+		return contracts.RefundOutcome{}, fmt.Errorf("paddle adjustment %s not found", providerRefundID)
+	}
+
+	switch status := result.Data[0].Status; status {
+	case "approved":
+		return contracts.RefundOutcome{Status: domain.RefundSucceeded}, nil
+	case "rejected", "reversed":
+		return contracts.RefundOutcome{Status: domain.RefundFailed, FailureReason: "paddle adjustment " + status}, nil
+	default:
+		return contracts.RefundOutcome{Status: domain.RefundPending}, nil
+	}
+}
+
+// paddleTransaction identifies the transaction line item a refund adjustment references
+type paddleTransaction struct {
+	id       string
+	itemID   string
+	currency string
+}
+
+// latestTransactionItem finds the subscription's most recent completed transaction
+// and the line item a refund adjustment should reference
+func (c *PaddleBillingClient) latestTransactionItem(ctx context.Context, subscriptionID string) (paddleTransaction, error) {
+	query := url.Values{
+		"subscription_id": {subscriptionID},
+		"status":          {"completed"},
+		"order_by":        {"billed_at[DESC]"},
+		"per_page":        {"1"},
+	}
+
+	req, err := c.newRequest(ctx, "GET", c.baseURL+"/transactions?"+query.Encode(), nil)
+	if err != nil {
+		return paddleTransaction{}, err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return paddleTransaction{}, fmt.Errorf("failed to list transactions: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return paddleTransaction{}, &StatusError{Op: "paddle transaction lookup", StatusCode: resp.StatusCode, Body: string(bodyBytes)}
+	}
+
+	var result struct {
+		Data []struct {
+			ID           string `json:"id"`
+			CurrencyCode string `json:"currency_code"`
+			Details      struct {
+				LineItems []struct {
+					ID string `json:"id"`
+				} `json:"line_items"`
+			} `json:"details"`
+		} `json:"data"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return paddleTransaction{}, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	if len(result.Data) == 0 || len(result.Data[0].Details.LineItems) == 0 {
+		return paddleTransaction{}, fmt.Errorf("no completed paddle transaction to refund for subscription %s", subscriptionID)
+	}
+
+	txn := result.Data[0]
+	return paddleTransaction{id: txn.ID, itemID: txn.Details.LineItems[0].ID, currency: txn.CurrencyCode}, nil
+}
+
+// CreateCustomer is not supported: Paddle assigns its own customer IDs (ctm_...) at checkout,
+// so a customer can't be provisioned under one of our IDs
+func (c *PaddleBillingClient) CreateCustomer(ctx context.Context, req contracts.CreateCustomerRequest) error {
+	return fmt.Errorf("%w: paddle creates customers at checkout", ErrUnsupportedByProvider)
+}
+
+// ChargeCustomer is not supported: Paddle charges renewals and retries failed payments itself
+func (c *PaddleBillingClient) ChargeCustomer(ctx context.Context, req contracts.ChargeRequest) error {
+	return fmt.Errorf("%w: paddle collects subscription payments itself", ErrUnsupportedByProvider)
+}
+
+// GetPaymentMethodStatus returns the customer's most recently saved payment method.
+// Only cards expire; other saved methods, such as PayPal, have no expiry.
+func (c *PaddleBillingClient) GetPaymentMethodStatus(ctx context.Context, customerID string) (domain.PaymentMethod, error) {
+	endpoint := fmt.Sprintf("%s/customers/%s/payment-methods?order_by=saved_at[DESC]&per_page=1", c.baseURL, url.PathEscape(customerID))
+
+	req, err := c.newRequest(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return domain.PaymentMethod{}, err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return domain.PaymentMethod{}, fmt.Errorf("failed to get payment method: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return domain.PaymentMethod{}, domain.ErrInvalidCustomer
+	}
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return domain.PaymentMethod{}, &StatusError{Op: "paddle payment method lookup", StatusCode: resp.StatusCode, Body: string(bodyBytes)}
+	}
+
+	var result struct {
+		Data []struct {
+			Card *struct {
+				Type        string `json:"type"`
+				Last4       string `json:"last4"`
+				ExpiryMonth int    `json:"expiry_month"`
+				ExpiryYear  int    `json:"expiry_year"`
+			} `json:"card"`
+		} `json:"data"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return domain.PaymentMethod{}, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	if len(result.Data) == 0 {
+		return domain.PaymentMethod{}, nil
+	}
+	pm := domain.PaymentMethod{Valid: true}
+	if card := result.Data[0].Card; card != nil {
+		pm.Brand = card.Type
+		pm.Last4 = card.Last4
+		pm.ExpiresAt = domain.CardExpiry(card.ExpiryMonth, card.ExpiryYear)
+	}
+	return pm, nil
+}
+
+// newRequest builds an authenticated Paddle API request
+func (c *PaddleBillingClient) newRequest(ctx context.Context, method, endpoint string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	req.Header.Set("Paddle-Version", "1")
+
+	return req, nil
+}
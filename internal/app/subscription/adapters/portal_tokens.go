@@ -0,0 +1,89 @@
+package adapters
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+var _ contracts.PortalTokens = HMACPortalTokens{}
+
+// HMACPortalTokens encodes portal sessions as "<base64url claims>.<hex HMAC-SHA256>".
+// Tokens are signed with the first named key and accepted under any of them, so
+// during a rotation name the new key first and the previous one after it.
+type HMACPortalTokens struct {
+	Secrets contracts.SecretProvider
+	Names   []string
+	Logger  *slog.Logger
+}
+
+// portalClaims is the signed content of a portal token
+type portalClaims struct {
+	SessionID  string               `json:"session_id"`
+	CustomerID string               `json:"customer_id"`
+	Scopes     []domain.PortalScope `json:"scopes"`
+	IssuedAt   time.Time            `json:"issued_at"`
+	ExpiresAt  time.Time            `json:"expires_at"`
+}
+
+// Encode signs the session into a token
+func (t HMACPortalTokens) Encode(ctx context.Context, session *domain.PortalSession) (string, error) {
+	if len(t.Names) == 0 {
+		return "", errors.New("no portal signing key configured")
+	}
+	key, err := t.Secrets.Secret(ctx, t.Names[0])
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve portal signing key: %w", err)
+	}
+	signer, err := NewHMACSigner([]byte(key))
+	if err != nil {
+		return "", err
+	}
+
+	claims, err := json.Marshal(portalClaims{
+		SessionID:  session.ID(),
+		CustomerID: session.CustomerID(),
+		Scopes:     session.Scopes(),
+		IssuedAt:   session.IssuedAt(),
+		ExpiresAt:  session.ExpiresAt(),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal portal claims: %w", err)
+	}
+	payload := base64.RawURLEncoding.EncodeToString(claims)
+	signature, err := signer.Sign([]byte(payload))
+	if err != nil {
+		return "", err
+	}
+	return payload + "." + signature, nil
+}
+
+// Decode verifies the token's signature and returns its session
+func (t HMACPortalTokens) Decode(ctx context.Context, token string) (*domain.PortalSession, error) {
+	payload, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, domain.ErrInvalidPortalToken
+	}
+	verifier := SecretHMACVerifier{Secrets: t.Secrets, Names: t.Names, Logger: t.Logger}
+	if !verifier.Verify([]byte(payload), signature) {
+		return nil, domain.ErrInvalidPortalToken
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, domain.ErrInvalidPortalToken
+	}
+	var claims portalClaims
+	if err := json.Unmarshal(raw, &claims); err != nil || claims.CustomerID == "" {
+		return nil, domain.ErrInvalidPortalToken
+	}
+	return domain.ReconstructPortalSession(claims.SessionID, claims.CustomerID, claims.Scopes, claims.IssuedAt, claims.ExpiresAt), nil
+}
@@ -0,0 +1,48 @@
+package adapters
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+func TestHMACPortalTokens_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	clock := domain.FixedClock{FixedTime: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+	secrets := mapSecrets{"portal-token-secret": "new-key", "portal-token-secret-previous": "old-key"}
+	tokens := HMACPortalTokens{Secrets: secrets, Names: []string{"portal-token-secret", "portal-token-secret-previous"}}
+	session, err := domain.NewPortalSession("ps-1", "cust-1", []domain.PortalScope{domain.PortalScopeCancel}, 0, clock)
+	require.NoError(t, err)
+
+	token, err := tokens.Encode(ctx, session)
+	require.NoError(t, err)
+	decoded, err := tokens.Decode(ctx, token)
+
+	require.NoError(t, err)
+	assert.Equal(t, session, decoded)
+
+	t.Run("accepts tokens signed with the previous key", func(t *testing.T) {
+		previous := HMACPortalTokens{Secrets: secrets, Names: []string{"portal-token-secret-previous"}}
+		token, err := previous.Encode(ctx, session)
+		require.NoError(t, err)
+
+		_, err = tokens.Decode(ctx, token)
+		assert.NoError(t, err)
+	})
+
+	t.Run("rejects tampered and foreign tokens", func(t *testing.T) {
+		other := HMACPortalTokens{Secrets: mapSecrets{"portal-token-secret": "someone-else"}, Names: []string{"portal-token-secret"}}
+		foreign, err := other.Encode(ctx, session)
+		require.NoError(t, err)
+
+		for _, bad := range []string{"", "no-signature", "e30." + token[len(token)-64:], foreign} {
+			_, err := tokens.Decode(ctx, bad)
+			assert.ErrorIs(t, err, domain.ErrInvalidPortalToken, bad)
+		}
+	})
+}
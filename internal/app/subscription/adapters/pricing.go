@@ -0,0 +1,69 @@
+package adapters
+
+import (
+	"context"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+var (
+	_ contracts.PricingSource = StaticPricing{}
+	_ contracts.PricingSource = ItemsPricing{}
+	_ contracts.PricingSource = BundlePricing{}
+)
+
+// StaticPricing gives every subscription the same pricing. The zero value applies no
+// discounts, which is all a subscription carries today.
+type StaticPricing struct {
+	Pricing domain.Pricing
+}
+
+// PricingFor returns the static pricing
+func (s StaticPricing) PricingFor(ctx context.Context, sub *domain.Subscription) (domain.Pricing, error) {
+	return s.Pricing, nil
+}
+
+// ItemsPricing takes a subscription's add-ons and discounts from its upcoming invoice
+// items, so charges bill what the invoice preview shows
+type ItemsPricing struct {
+	Items  contracts.InvoiceItemsSource
+	Policy domain.DiscountPolicy
+}
+
+// PricingFor returns the add-ons and discounts of the subscription's upcoming invoice
+// under the policy
+func (p ItemsPricing) PricingFor(ctx context.Context, sub *domain.Subscription) (domain.Pricing, error) {
+	items, err := p.Items.UpcomingItems(ctx, sub)
+	if err != nil {
+		return domain.Pricing{}, err
+	}
+	pricing := domain.Pricing{AddOns: items.AddOns, Discounts: items.Discounts, Policy: p.Policy}
+	if err := pricing.Validate(); err != nil {
+		return domain.Pricing{}, err
+	}
+	return pricing, nil
+}
+
+// BundlePricing bills the add-ons a subscription is set up with alongside its plan, on
+// top of the pricing Base gives it, so renewals charge them and cancellations refund them
+type BundlePricing struct {
+	Bundles contracts.SubscriptionBundleRepository
+	Base    contracts.PricingSource
+}
+
+// PricingFor returns Base's pricing with the subscription's add-ons appended
+func (b BundlePricing) PricingFor(ctx context.Context, sub *domain.Subscription) (domain.Pricing, error) {
+	pricing, err := b.Base.PricingFor(ctx, sub)
+	if err != nil {
+		return domain.Pricing{}, err
+	}
+	bundle, err := b.Bundles.FindBySubscriptionID(ctx, sub.ID())
+	if err != nil {
+		return domain.Pricing{}, err
+	}
+	if len(bundle.AddOns) > 0 {
+		pricing.AddOns = append(append([]domain.AddOnCharge(nil), pricing.AddOns...), bundle.AddOns...)
+	}
+	return pricing, nil
+}
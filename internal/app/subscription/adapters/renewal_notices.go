@@ -0,0 +1,49 @@
+package adapters
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+var (
+	_ contracts.RenewalNoticeNotifier = LogRenewalNotices{}
+	_ contracts.CustomerRegionSource  = StaticRegions{}
+)
+
+// LogRenewalNotices logs renewal notices, for deployments without a channel that
+// notifies customers directly
+type LogRenewalNotices struct {
+	Logger *slog.Logger
+}
+
+// NotifyRenewalUpcoming logs the notice; it never fails
+func (n LogRenewalNotices) NotifyRenewalUpcoming(ctx context.Context, event *domain.RenewalUpcomingEvent) error {
+	n.Logger.InfoContext(ctx, "renewal upcoming",
+		slog.String("subscription_id", event.SubscriptionID),
+		slog.String("customer_id", event.CustomerID),
+		slog.String("plan_id", event.PlanID),
+		slog.Int64("amount", event.Amount),
+		slog.String("currency", event.Currency),
+		slog.Time("renews_at", event.RenewsAt),
+		slog.Int64("notice_days", event.NoticeDays),
+	)
+	return nil
+}
+
+// StaticRegions places customers in regions from a fixed map, and every other customer
+// in Default, for deployments that don't hold customer addresses
+type StaticRegions struct {
+	Default   string
+	Customers map[string]string
+}
+
+// RegionOf returns the customer's region
+func (r StaticRegions) RegionOf(ctx context.Context, customerID string) (string, error) {
+	if region, ok := r.Customers[customerID]; ok {
+		return region, nil
+	}
+	return r.Default, nil
+}
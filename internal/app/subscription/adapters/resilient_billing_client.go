@@ -0,0 +1,240 @@
+package adapters
+
+import (
+	"context"
+	"log/slog"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/logging"
+)
+
+var _ contracts.BillingClient = (*ResilientBillingClient)(nil)
+
+// RetryPolicy configures exponential backoff between attempts
+type RetryPolicy struct {
+	MaxAttempts    int // total attempts including the first; 1 disables retries
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+}
+
+// RetryBudget caps retries across all calls so a struggling billing API isn't
+// hammered: each failure spends a token, each success earns SuccessRefill tokens,
+// and retries stop while fewer than half of MaxTokens remain
+type RetryBudget struct {
+	MaxTokens     float64
+	SuccessRefill float64
+}
+
+// ResilienceConfig configures the resilient billing client
+type ResilienceConfig struct {
+	Retry   RetryPolicy
+	Budget  RetryBudget
+	Breaker BreakerConfig
+
+	Logger *slog.Logger // retries and circuit breaker trips; nil discards them
+}
+
+// DefaultResilienceConfig returns settings suited to the internal billing API
+func DefaultResilienceConfig() ResilienceConfig {
+	return ResilienceConfig{
+		Retry: RetryPolicy{
+			MaxAttempts:    4,
+			InitialBackoff: 100 * time.Millisecond,
+			MaxBackoff:     2 * time.Second,
+			Multiplier:     2,
+		},
+		Budget: RetryBudget{
+			MaxTokens:     10,
+			SuccessRefill: 0.1,
+		},
+		Breaker: BreakerConfig{
+			FailureThreshold: 5,
+			OpenTimeout:      30 * time.Second,
+		},
+	}
+}
+
+// ResilientBillingClient decorates a billing client with retries, a retry budget and
+// a circuit breaker. Only idempotent calls are retried: lookups, and charges and
+// refunds that carry an idempotency key.
+type ResilientBillingClient struct {
+	next    contracts.BillingClient
+	cfg     ResilienceConfig
+	breaker *CircuitBreaker
+	sleep   func(ctx context.Context, d time.Duration) error
+	logger  *slog.Logger
+
+	mu     sync.Mutex
+	tokens float64
+}
+
+// NewResilientBillingClient wraps next with the given resilience settings
+func NewResilientBillingClient(next contracts.BillingClient, cfg ResilienceConfig, clock domain.Clock) *ResilientBillingClient {
+	logger := cfg.Logger
+	if logger == nil {
+		logger = logging.Discard()
+	}
+	return &ResilientBillingClient{
+		next:    next,
+		cfg:     cfg,
+		breaker: NewCircuitBreaker(cfg.Breaker, clock),
+		sleep:   sleepContext,
+		logger:  logger,
+		tokens:  cfg.Budget.MaxTokens,
+	}
+}
+
+// BreakerState exposes the circuit breaker state for health checks
+func (c *ResilientBillingClient) BreakerState() BreakerState {
+	return c.breaker.State()
+}
+
+// ValidateCustomer validates a customer, retrying transient failures
+func (c *ResilientBillingClient) ValidateCustomer(ctx context.Context, customerID string) error {
+	return c.do(ctx, true, func(ctx context.Context) error {
+		return c.next.ValidateCustomer(ctx, customerID)
+	})
+}
+
+// CreateCustomer provisions a customer, retrying transient failures; creation is keyed by
+// customer ID, so a retry can't create a duplicate
+func (c *ResilientBillingClient) CreateCustomer(ctx context.Context, req contracts.CreateCustomerRequest) error {
+	return c.do(ctx, true, func(ctx context.Context) error {
+		return c.next.CreateCustomer(ctx, req)
+	})
+}
+
+// ProcessRefund processes a refund, retrying transient failures only when the
+// request carries an idempotency key; without one a retry could refund twice
+func (c *ResilientBillingClient) ProcessRefund(ctx context.Context, req contracts.RefundRequest) (string, error) {
+	var refundID string
+	err := c.do(ctx, req.IdempotencyKey != "", func(ctx context.Context) error {
+		var err error
+		refundID, err = c.next.ProcessRefund(ctx, req)
+		return err
+	})
+	return refundID, err
+}
+
+// GetRefundStatus looks up a refund, retrying transient failures
+func (c *ResilientBillingClient) GetRefundStatus(ctx context.Context, providerRefundID string) (contracts.RefundOutcome, error) {
+	var outcome contracts.RefundOutcome
+	err := c.do(ctx, true, func(ctx context.Context) error {
+		var err error
+		outcome, err = c.next.GetRefundStatus(ctx, providerRefundID)
+		return err
+	})
+	return outcome, err
+}
+
+// ChargeCustomer charges a customer, retrying transient failures only when the
+// request carries an idempotency key
+func (c *ResilientBillingClient) ChargeCustomer(ctx context.Context, req contracts.ChargeRequest) error {
+	return c.do(ctx, req.IdempotencyKey != "", func(ctx context.Context) error {
+		return c.next.ChargeCustomer(ctx, req)
+	})
+}
+
+// GetPaymentMethodStatus looks up a payment method, retrying transient failures
+func (c *ResilientBillingClient) GetPaymentMethodStatus(ctx context.Context, customerID string) (domain.PaymentMethod, error) {
+	var pm domain.PaymentMethod
+	err := c.do(ctx, true, func(ctx context.Context) error {
+		var err error
+		pm, err = c.next.GetPaymentMethodStatus(ctx, customerID)
+		return err
+	})
+	return pm, err
+}
+
+// do runs call through the circuit breaker, retrying transient failures when retryable
+func (c *ResilientBillingClient) do(ctx context.Context, retryable bool, call func(ctx context.Context) error) error {
+	backoff := c.cfg.Retry.InitialBackoff
+
+	for attempt := 1; ; attempt++ {
+		if err := c.breaker.Allow(); err != nil {
+			return err
+		}
+
+		countAttempt(ctx)
+		err := call(ctx)
+		transient := IsTransient(err)
+		// Domain rejections mean the billing API is healthy, so only transient errors trip the breaker
+		c.breaker.Record(!transient)
+		c.recordOutcome(!transient)
+		if transient && c.breaker.State() == BreakerOpen {
+			c.logger.WarnContext(ctx, "billing circuit breaker opened", slog.Duration("open_timeout", c.cfg.Breaker.OpenTimeout), slog.Any("error", err))
+		}
+
+		if !transient || !retryable || attempt >= c.cfg.Retry.MaxAttempts || !c.retryAllowed() {
+			return err
+		}
+
+		wait := jitter(backoff)
+		c.logger.WarnContext(ctx, "retrying billing call", slog.Int("attempt", attempt), slog.Duration("backoff", wait), slog.Any("error", err))
+		if sleepErr := c.sleep(ctx, wait); sleepErr != nil {
+			return err
+		}
+		backoff = nextBackoff(backoff, c.cfg.Retry)
+	}
+}
+
+// recordOutcome updates the retry budget
+func (c *ResilientBillingClient) recordOutcome(success bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if success {
+		c.tokens += c.cfg.Budget.SuccessRefill
+		if c.tokens > c.cfg.Budget.MaxTokens {
+			c.tokens = c.cfg.Budget.MaxTokens
+		}
+		return
+	}
+	c.tokens--
+	if c.tokens < 0 {
+		c.tokens = 0
+	}
+}
+
+// retryAllowed reports whether the retry budget has room for another retry
+func (c *ResilientBillingClient) retryAllowed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.tokens > c.cfg.Budget.MaxTokens/2
+}
+
+// nextBackoff grows the backoff by the policy multiplier, capped at MaxBackoff
+func nextBackoff(current time.Duration, policy RetryPolicy) time.Duration {
+	next := time.Duration(float64(current) * policy.Multiplier)
+	if policy.MaxBackoff > 0 && next > policy.MaxBackoff {
+		return policy.MaxBackoff
+	}
+	return next
+}
+
+// jitter spreads retries over [d/2, d) so clients that failed together don't retry together
+func jitter(d time.Duration) time.Duration {
+	if d <= 0 {
+		return 0
+	}
+	half := d / 2
+	return half + time.Duration(rand.Int63n(int64(d-half)))
+}
+
+// sleepContext waits for d or until ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
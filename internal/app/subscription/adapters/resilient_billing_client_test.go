@@ -0,0 +1,173 @@
+package adapters
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/testkit"
+)
+
+// MockBillingClient is a mock implementation of BillingClient
+type MockBillingClient struct {
+	mock.Mock
+}
+
+func (m *MockBillingClient) ValidateCustomer(ctx context.Context, customerID string) error {
+	args := m.Called(ctx, customerID)
+	return args.Error(0)
+}
+
+func (m *MockBillingClient) CreateCustomer(ctx context.Context, req contracts.CreateCustomerRequest) error {
+	args := m.Called(ctx, req)
+	return args.Error(0)
+}
+
+func (m *MockBillingClient) ProcessRefund(ctx context.Context, req contracts.RefundRequest) (string, error) {
+	args := m.Called(ctx, req)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockBillingClient) GetRefundStatus(ctx context.Context, providerRefundID string) (contracts.RefundOutcome, error) {
+	args := m.Called(ctx, providerRefundID)
+	return args.Get(0).(contracts.RefundOutcome), args.Error(1)
+}
+
+func (m *MockBillingClient) ChargeCustomer(ctx context.Context, req contracts.ChargeRequest) error {
+	args := m.Called(ctx, req)
+	return args.Error(0)
+}
+
+func (m *MockBillingClient) GetPaymentMethodStatus(ctx context.Context, customerID string) (domain.PaymentMethod, error) {
+	args := m.Called(ctx, customerID)
+	return args.Get(0).(domain.PaymentMethod), args.Error(1)
+}
+
+// steppingClock is a clock tests can move forward
+type steppingClock struct {
+	now time.Time
+}
+
+func (c *steppingClock) Now() time.Time {
+	return c.now
+}
+
+var (
+	unavailable   = &StatusError{Op: "test", StatusCode: http.StatusServiceUnavailable}
+	unkeyedRefund = contracts.RefundRequest{SubscriptionID: "sub-1", Amount: 1000}
+	keyedRefund   = contracts.RefundRequest{SubscriptionID: "sub-1", Amount: 1000, IdempotencyKey: "sub-1:0:refund"}
+)
+
+func newTestResilientClient(next contracts.BillingClient, clock domain.Clock) *ResilientBillingClient {
+	client := NewResilientBillingClient(next, DefaultResilienceConfig(), clock)
+	client.sleep = func(ctx context.Context, d time.Duration) error { return nil }
+	return client
+}
+
+func TestResilientBillingClient_RetriesTransientFailures(t *testing.T) {
+	ctx := context.Background()
+	next := new(MockBillingClient)
+	client := newTestResilientClient(next, domain.RealClock{})
+
+	next.On("ValidateCustomer", ctx, "cust-1").Return(unavailable).Twice()
+	next.On("ValidateCustomer", ctx, "cust-1").Return(nil).Once()
+
+	err := client.ValidateCustomer(ctx, "cust-1")
+
+	assert.NoError(t, err)
+	next.AssertNumberOfCalls(t, "ValidateCustomer", 3)
+}
+
+func TestResilientBillingClient_RetriesKeyedRefunds(t *testing.T) {
+	ctx := context.Background()
+	next := new(MockBillingClient)
+	client := newTestResilientClient(next, domain.RealClock{})
+
+	next.On("ProcessRefund", ctx, keyedRefund).Return("", unavailable).Once()
+	next.On("ProcessRefund", ctx, keyedRefund).Return("refund-1", nil).Once()
+
+	refundID, err := client.ProcessRefund(ctx, keyedRefund)
+	assert.NoError(t, err)
+	assert.Equal(t, "refund-1", refundID)
+	next.AssertNumberOfCalls(t, "ProcessRefund", 2)
+}
+
+func TestResilientBillingClient_DoesNotRetryNonIdempotentCalls(t *testing.T) {
+	ctx := context.Background()
+	next := new(MockBillingClient)
+	client := newTestResilientClient(next, domain.RealClock{})
+
+	next.On("ProcessRefund", ctx, unkeyedRefund).Return("", unavailable)
+	next.On("ChargeCustomer", ctx, contracts.ChargeRequest{Amount: 1000}).Return(unavailable)
+
+	_, err := client.ProcessRefund(ctx, unkeyedRefund)
+	assert.Equal(t, unavailable, err)
+	assert.Equal(t, unavailable, client.ChargeCustomer(ctx, contracts.ChargeRequest{Amount: 1000}))
+	next.AssertNumberOfCalls(t, "ProcessRefund", 1)
+	next.AssertNumberOfCalls(t, "ChargeCustomer", 1)
+}
+
+func TestResilientBillingClient_DoesNotRetryDomainErrors(t *testing.T) {
+	ctx := context.Background()
+	next := new(MockBillingClient)
+	client := newTestResilientClient(next, domain.RealClock{})
+
+	next.On("ValidateCustomer", ctx, "cust-1").Return(domain.ErrInvalidCustomer)
+
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, domain.ErrInvalidCustomer, client.ValidateCustomer(ctx, "cust-1"))
+	}
+	next.AssertNumberOfCalls(t, "ValidateCustomer", 10)
+	assert.Equal(t, BreakerClosed, client.BreakerState())
+}
+
+func TestResilientBillingClient_CircuitBreakerFailsFastAndRecovers(t *testing.T) {
+	ctx := context.Background()
+	clock := &steppingClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	next := new(MockBillingClient)
+	client := newTestResilientClient(next, clock)
+
+	refund := next.On("ProcessRefund", ctx, unkeyedRefund).Return("", unavailable)
+
+	for i := 0; i < 5; i++ {
+		_, err := client.ProcessRefund(ctx, unkeyedRefund)
+		assert.Equal(t, unavailable, err)
+	}
+	assert.Equal(t, BreakerOpen, client.BreakerState())
+
+	// Open: calls fail without reaching the billing API
+	_, err := client.ProcessRefund(ctx, unkeyedRefund)
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	next.AssertNumberOfCalls(t, "ProcessRefund", 5)
+
+	// After the timeout a successful probe closes the circuit
+	clock.now = clock.now.Add(30 * time.Second)
+	assert.Equal(t, BreakerHalfOpen, client.BreakerState())
+	refund.Unset()
+	next.On("ProcessRefund", ctx, unkeyedRefund).Return("refund-1", nil)
+
+	_, err = client.ProcessRefund(ctx, unkeyedRefund)
+	assert.NoError(t, err)
+	assert.Equal(t, BreakerClosed, client.BreakerState())
+}
+
+func TestResilientBillingClient_RetriedRefundKeepsItsKey(t *testing.T) {
+	ctx := context.Background()
+	fake := testkit.NewFakeBillingClient().FailFirst(testkit.OpProcessRefund, 2, unavailable)
+	client := newTestResilientClient(fake, domain.RealClock{})
+
+	refundID, err := client.ProcessRefund(ctx, keyedRefund)
+
+	assert.NoError(t, err)
+	assert.NotEmpty(t, refundID)
+	calls := fake.CallsTo(testkit.OpProcessRefund)
+	assert.Len(t, calls, 3)
+	for _, call := range calls {
+		assert.Equal(t, keyedRefund.IdempotencyKey, call.Refund.IdempotencyKey)
+	}
+}
@@ -0,0 +1,83 @@
+package adapters
+
+import (
+	"context"
+	"time"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+var (
+	_ contracts.RetentionOfferEngine = RetentionOfferChain{}
+	_ contracts.RetentionOfferEngine = DiscountOfferRule{}
+	_ contracts.RetentionOfferEngine = DowngradeOfferRule{}
+)
+
+// RetentionOfferChain asks each rule in turn and makes the first offer one proposes.
+// The empty chain never offers anything.
+type RetentionOfferChain []contracts.RetentionOfferEngine
+
+// OfferFor returns the first rule's offer, or nil if no rule has one
+func (c RetentionOfferChain) OfferFor(ctx context.Context, sub *domain.Subscription) (*domain.RetentionOfferTerms, error) {
+	for _, rule := range c {
+		terms, err := rule.OfferFor(ctx, sub)
+		if err != nil || terms != nil {
+			return terms, err
+		}
+	}
+	return nil, nil
+}
+
+// DiscountOfferRule offers PercentOff basis points off the next renewal to
+// subscriptions on one of Plans, or on any plan when Plans is empty, that have been
+// subscribed for at least MinTenure
+type DiscountOfferRule struct {
+	Name       string
+	PercentOff int64
+	Plans      []string
+	MinTenure  time.Duration
+	Clock      domain.Clock
+}
+
+// OfferFor returns the discount if the subscription qualifies
+func (r DiscountOfferRule) OfferFor(ctx context.Context, sub *domain.Subscription) (*domain.RetentionOfferTerms, error) {
+	if !onPlan(sub, r.Plans) || r.Clock.Now().Sub(sub.StartDate()) < r.MinTenure {
+		return nil, nil
+	}
+	return &domain.RetentionOfferTerms{Rule: r.Name, Kind: domain.RetentionOfferDiscount, PercentOff: r.PercentOff}, nil
+}
+
+// DowngradeTarget is a plan a downgrade offer moves to
+type DowngradeTarget struct {
+	PlanID     string
+	PriceCents int64
+}
+
+// DowngradeOfferRule offers a move to a cheaper plan, keyed by the plan being cancelled
+type DowngradeOfferRule struct {
+	Name       string
+	Downgrades map[string]DowngradeTarget
+}
+
+// OfferFor returns the downgrade for the subscription's plan, if there is one
+func (r DowngradeOfferRule) OfferFor(ctx context.Context, sub *domain.Subscription) (*domain.RetentionOfferTerms, error) {
+	target, ok := r.Downgrades[sub.PlanID()]
+	if !ok {
+		return nil, nil
+	}
+	return &domain.RetentionOfferTerms{Rule: r.Name, Kind: domain.RetentionOfferDowngrade, PlanID: target.PlanID, PriceCents: target.PriceCents}, nil
+}
+
+// onPlan reports whether sub is on one of plans; every plan matches an empty list
+func onPlan(sub *domain.Subscription, plans []string) bool {
+	if len(plans) == 0 {
+		return true
+	}
+	for _, id := range plans {
+		if id == sub.PlanID() {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,35 @@
+package adapters
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+func TestRetentionOfferChain_FirstMatchingRuleWins(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := domain.FixedClock{FixedTime: start.AddDate(0, 3, 0)}
+	chain := RetentionOfferChain{
+		DowngradeOfferRule{Name: "pro-to-basic", Downgrades: map[string]DowngradeTarget{"plan-pro": {PlanID: "plan-basic", PriceCents: 900}}},
+		DiscountOfferRule{Name: "loyal-half-off", PercentOff: 5000, MinTenure: 60 * 24 * time.Hour, Clock: clock},
+		DiscountOfferRule{Name: "team-quarter-off", PercentOff: 2500, Plans: []string{"plan-team"}, Clock: clock},
+	}
+	offerFor := func(planID string, started time.Time) *domain.RetentionOfferTerms {
+		terms, err := chain.OfferFor(context.Background(), domain.ReconstructFromPersistence("sub-1", "cust-1", planID, 2900, domain.StatusActive, started))
+		require.NoError(t, err)
+		return terms
+	}
+
+	assert.Equal(t, &domain.RetentionOfferTerms{Rule: "pro-to-basic", Kind: domain.RetentionOfferDowngrade, PlanID: "plan-basic", PriceCents: 900}, offerFor("plan-pro", start))
+	assert.Equal(t, &domain.RetentionOfferTerms{Rule: "loyal-half-off", Kind: domain.RetentionOfferDiscount, PercentOff: 5000}, offerFor("plan-team", start))
+	assert.Equal(t, &domain.RetentionOfferTerms{Rule: "team-quarter-off", Kind: domain.RetentionOfferDiscount, PercentOff: 2500}, offerFor("plan-team", clock.FixedTime))
+	assert.Nil(t, offerFor("plan-basic", clock.FixedTime))
+
+	terms, err := RetentionOfferChain{}.OfferFor(context.Background(), nil)
+	assert.NoError(t, err)
+	assert.Nil(t, terms)
+}
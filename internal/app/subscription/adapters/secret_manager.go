@@ -0,0 +1,68 @@
+package adapters
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"net/http"
+
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
+	secretmanager "google.golang.org/api/secretmanager/v1"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+)
+
+var _ contracts.SecretProvider = (*SecretManagerProvider)(nil)
+
+// SecretManagerProvider reads secrets from Google Secret Manager. A secret named
+// "billing-api-key" is the latest enabled version of
+// projects/<project>/secrets/billing-api-key. Credentials come from Application
+// Default Credentials. Every call is a request to Secret Manager, so wrap it in a
+// CachedSecretProvider.
+type SecretManagerProvider struct {
+	versions *secretmanager.ProjectsSecretsVersionsService
+	project  string
+}
+
+// NewSecretManagerProvider creates a provider for the secrets of project
+func NewSecretManagerProvider(ctx context.Context, project string, opts ...option.ClientOption) (*SecretManagerProvider, error) {
+	if project == "" {
+		return nil, errors.New("secret manager requires a project")
+	}
+	svc, err := secretmanager.NewService(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Secret Manager client: %w", err)
+	}
+	return &SecretManagerProvider{versions: svc.Projects.Secrets.Versions, project: project}, nil
+}
+
+// Secret returns the payload of the secret's latest version
+func (p *SecretManagerProvider) Secret(ctx context.Context, name string) (string, error) {
+	resource := fmt.Sprintf("projects/%s/secrets/%s/versions/latest", p.project, name)
+	resp, err := p.versions.Access(resource).Context(ctx).Do()
+	if err != nil {
+		var apiErr *googleapi.Error
+		if errors.As(err, &apiErr) && apiErr.Code == http.StatusNotFound {
+			return "", fmt.Errorf("%w: %s (%s)", ErrSecretNotFound, name, resource)
+		}
+		return "", fmt.Errorf("failed to access %s: %w", resource, err)
+	}
+	if resp.Payload == nil {
+		return "", fmt.Errorf("%w: %s has no payload", ErrSecretNotFound, resource)
+	}
+
+	data, err := base64.StdEncoding.DecodeString(resp.Payload.Data)
+	if err != nil {
+		return "", fmt.Errorf("invalid payload for %s: %w", resource, err)
+	}
+	if c := resp.Payload.DataCrc32c; c != 0 && int64(crc32.Checksum(data, crc32.MakeTable(crc32.Castagnoli))) != c {
+		return "", fmt.Errorf("payload for %s failed its checksum", resource)
+	}
+	if len(data) == 0 {
+		return "", fmt.Errorf("%w: %s is empty", ErrSecretNotFound, resource)
+	}
+	return string(data), nil
+}
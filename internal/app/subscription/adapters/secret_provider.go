@@ -0,0 +1,44 @@
+package adapters
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+// SecretsBackend selects where runtime secrets are read from
+type SecretsBackend string
+
+const (
+	SecretsEnv           SecretsBackend = "env"
+	SecretsSecretManager SecretsBackend = "secret-manager"
+)
+
+// SecretsConfig configures the secret provider
+type SecretsConfig struct {
+	Backend  SecretsBackend
+	Project  string        // Secret Manager project
+	CacheTTL time.Duration // how long Secret Manager values are kept
+	Logger   *slog.Logger
+}
+
+// NewSecretProvider builds the secret provider selected by configuration. Secret
+// Manager values are cached for CacheTTL; environment variables are read directly.
+func NewSecretProvider(ctx context.Context, cfg SecretsConfig) (contracts.SecretProvider, error) {
+	switch cfg.Backend {
+	case SecretsEnv, "":
+		return EnvSecretProvider{}, nil
+	case SecretsSecretManager:
+		sm, err := NewSecretManagerProvider(ctx, cfg.Project)
+		if err != nil {
+			return nil, err
+		}
+		return NewCachedSecretProvider(sm, cfg.CacheTTL, domain.RealClock{}, cfg.Logger), nil
+	default:
+		return nil, fmt.Errorf("unknown secrets backend %q", cfg.Backend)
+	}
+}
@@ -0,0 +1,128 @@
+package adapters
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"hash/crc32"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/option"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/logging"
+)
+
+// countingSecrets serves mapSecrets and counts lookups
+type countingSecrets struct {
+	values mapSecrets
+	calls  int
+	err    error
+}
+
+func (c *countingSecrets) Secret(ctx context.Context, name string) (string, error) {
+	c.calls++
+	if c.err != nil {
+		return "", c.err
+	}
+	return c.values.Secret(ctx, name)
+}
+
+func TestCachedSecretProvider_RefreshesAfterTTL(t *testing.T) {
+	ctx := context.Background()
+	next := &countingSecrets{values: mapSecrets{"billing-token": "tok-1"}}
+	clock := &domain.FixedClock{FixedTime: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	cached := NewCachedSecretProvider(next, time.Minute, clock, logging.Discard())
+
+	for i := 0; i < 3; i++ {
+		v, err := cached.Secret(ctx, "billing-token")
+		require.NoError(t, err)
+		assert.Equal(t, "tok-1", v)
+	}
+	assert.Equal(t, 1, next.calls)
+
+	next.values["billing-token"] = "tok-2"
+	clock.FixedTime = clock.FixedTime.Add(time.Minute)
+	v, err := cached.Secret(ctx, "billing-token")
+	require.NoError(t, err)
+	assert.Equal(t, "tok-2", v)
+
+	next.values["billing-token"] = "tok-3"
+	cached.Invalidate("billing-token")
+	v, err = cached.Secret(ctx, "billing-token")
+	require.NoError(t, err)
+	assert.Equal(t, "tok-3", v)
+}
+
+func TestCachedSecretProvider_ServesStaleValueWhenStoreFails(t *testing.T) {
+	ctx := context.Background()
+	next := &countingSecrets{values: mapSecrets{"billing-token": "tok-1"}}
+	clock := &domain.FixedClock{FixedTime: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	cached := NewCachedSecretProvider(next, time.Minute, clock, logging.Discard())
+
+	_, err := cached.Secret(ctx, "billing-token")
+	require.NoError(t, err)
+
+	next.err = errors.New("secret manager unavailable")
+	clock.FixedTime = clock.FixedTime.Add(time.Hour)
+	v, err := cached.Secret(ctx, "billing-token")
+	require.NoError(t, err)
+	assert.Equal(t, "tok-1", v)
+
+	_, err = cached.Secret(ctx, "never-fetched")
+	assert.EqualError(t, err, "secret manager unavailable")
+}
+
+func TestSecretManagerProvider_AccessesLatestVersion(t *testing.T) {
+	payload := []byte("whsec-1")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/projects/prod/secrets/refund-webhook-secret/versions/latest:access":
+			w.Header().Set("Content-Type", "application/json")
+			checksum := crc32.Checksum(payload, crc32.MakeTable(crc32.Castagnoli))
+			w.Write([]byte(`{"name":"projects/prod/secrets/refund-webhook-secret/versions/3","payload":{"data":"` +
+				base64.StdEncoding.EncodeToString(payload) + `","dataCrc32c":"` + strconv.FormatUint(uint64(checksum), 10) + `"}}`))
+		default:
+			http.Error(w, `{"error":{"code":404,"message":"not found"}}`, http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	p, err := NewSecretManagerProvider(ctx, "prod", option.WithEndpoint(server.URL), option.WithoutAuthentication())
+	require.NoError(t, err)
+
+	v, err := p.Secret(ctx, "refund-webhook-secret")
+	require.NoError(t, err)
+	assert.Equal(t, "whsec-1", v)
+
+	_, err = p.Secret(ctx, "missing")
+	assert.ErrorIs(t, err, ErrSecretNotFound)
+}
+
+func TestSecretHMACVerifier_AcceptsCurrentAndPreviousKeys(t *testing.T) {
+	secrets := mapSecrets{"webhook-secret": "new-key", "webhook-secret-previous": "old-key"}
+	verifier := SecretHMACVerifier{Secrets: secrets, Names: []string{"webhook-secret", "webhook-secret-previous"}}
+	payload := []byte(`{"refund_id":"re_1"}`)
+
+	sign := func(key string) string {
+		s, err := NewHMACSigner([]byte(key))
+		require.NoError(t, err)
+		sig, _ := s.Sign(payload)
+		return sig
+	}
+
+	assert.True(t, verifier.Verify(payload, sign("new-key")))
+	assert.True(t, verifier.Verify(payload, sign("old-key")))
+	assert.False(t, verifier.Verify(payload, sign("other-key")))
+
+	// Once the previous key is retired, its signatures stop verifying
+	delete(secrets, "webhook-secret-previous")
+	assert.False(t, verifier.Verify(payload, sign("old-key")))
+}
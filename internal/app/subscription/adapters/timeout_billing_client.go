@@ -0,0 +1,91 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+var _ contracts.BillingClient = (*TimeoutBillingClient)(nil)
+
+// ErrBillingTimeout is returned when a billing call exceeds its own timeout while the
+// caller's deadline had not yet expired
+var ErrBillingTimeout = errors.New("billing call timed out")
+
+// TimeoutBillingClient bounds every billing call with a timeout of its own, separate from
+// the caller's deadline, so a slow billing API can't consume the whole request budget.
+// Placed under ResilientBillingClient, the timeout applies to each attempt.
+type TimeoutBillingClient struct {
+	next    contracts.BillingClient
+	timeout time.Duration
+}
+
+// NewTimeoutBillingClient wraps next so each call is abandoned after timeout
+func NewTimeoutBillingClient(next contracts.BillingClient, timeout time.Duration) *TimeoutBillingClient {
+	return &TimeoutBillingClient{next: next, timeout: timeout}
+}
+
+func (c *TimeoutBillingClient) ValidateCustomer(ctx context.Context, customerID string) error {
+	return c.do(ctx, "validate customer", func(ctx context.Context) error {
+		return c.next.ValidateCustomer(ctx, customerID)
+	})
+}
+
+func (c *TimeoutBillingClient) CreateCustomer(ctx context.Context, req contracts.CreateCustomerRequest) error {
+	return c.do(ctx, "create customer", func(ctx context.Context) error {
+		return c.next.CreateCustomer(ctx, req)
+	})
+}
+
+func (c *TimeoutBillingClient) ProcessRefund(ctx context.Context, req contracts.RefundRequest) (string, error) {
+	var refundID string
+	err := c.do(ctx, "process refund", func(ctx context.Context) error {
+		var err error
+		refundID, err = c.next.ProcessRefund(ctx, req)
+		return err
+	})
+	return refundID, err
+}
+
+func (c *TimeoutBillingClient) GetRefundStatus(ctx context.Context, providerRefundID string) (contracts.RefundOutcome, error) {
+	var outcome contracts.RefundOutcome
+	err := c.do(ctx, "get refund status", func(ctx context.Context) error {
+		var err error
+		outcome, err = c.next.GetRefundStatus(ctx, providerRefundID)
+		return err
+	})
+	return outcome, err
+}
+
+func (c *TimeoutBillingClient) ChargeCustomer(ctx context.Context, req contracts.ChargeRequest) error {
+	return c.do(ctx, "charge customer", func(ctx context.Context) error {
+		return c.next.ChargeCustomer(ctx, req)
+	})
+}
+
+func (c *TimeoutBillingClient) GetPaymentMethodStatus(ctx context.Context, customerID string) (domain.PaymentMethod, error) {
+	var pm domain.PaymentMethod
+	err := c.do(ctx, "get payment method status", func(ctx context.Context) error {
+		var err error
+		pm, err = c.next.GetPaymentMethodStatus(ctx, customerID)
+		return err
+	})
+	return pm, err
+}
+
+// do runs call under the per-call timeout. When that timeout, not the caller's deadline,
+// cut the call short, the error wraps ErrBillingTimeout so it is treated as transient.
+func (c *TimeoutBillingClient) do(ctx context.Context, op string, call func(ctx context.Context) error) error {
+	callCtx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	err := call(callCtx)
+	if err != nil && ctx.Err() == nil && errors.Is(callCtx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("%s: %w after %s: %v", op, ErrBillingTimeout, c.timeout, err)
+	}
+	return err
+}
@@ -0,0 +1,54 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// blockUntilDone makes a mocked call wait for its context, like a hung billing API
+func blockUntilDone(args mock.Arguments) {
+	<-args.Get(0).(context.Context).Done()
+}
+
+func TestTimeoutBillingClient_CutsSlowCallsShort(t *testing.T) {
+	next := new(MockBillingClient)
+	client := NewTimeoutBillingClient(next, 10*time.Millisecond)
+
+	next.On("ValidateCustomer", mock.Anything, "cust-1").Run(blockUntilDone).Return(context.DeadlineExceeded)
+
+	err := client.ValidateCustomer(context.Background(), "cust-1")
+
+	assert.ErrorIs(t, err, ErrBillingTimeout)
+	assert.True(t, IsTransient(err))
+}
+
+func TestTimeoutBillingClient_CallerDeadlineIsNotWrapped(t *testing.T) {
+	next := new(MockBillingClient)
+	client := NewTimeoutBillingClient(next, time.Hour)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	next.On("ValidateCustomer", mock.Anything, "cust-1").Run(blockUntilDone).Return(context.DeadlineExceeded)
+
+	err := client.ValidateCustomer(ctx, "cust-1")
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.False(t, errors.Is(err, ErrBillingTimeout))
+}
+
+func TestTimeoutBillingClient_PassesThroughResults(t *testing.T) {
+	next := new(MockBillingClient)
+	client := NewTimeoutBillingClient(next, time.Second)
+
+	next.On("ProcessRefund", mock.Anything, keyedRefund).Return("refund-1", nil)
+
+	refundID, err := client.ProcessRefund(context.Background(), keyedRefund)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "refund-1", refundID)
+}
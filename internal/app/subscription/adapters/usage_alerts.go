@@ -0,0 +1,31 @@
+package adapters
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+var _ contracts.UsageAlertNotifier = LogUsageAlerts{}
+
+// LogUsageAlerts logs usage alerts, for deployments without a channel that notifies
+// customers directly
+type LogUsageAlerts struct {
+	Logger *slog.Logger
+}
+
+// NotifyUsageThreshold logs the alert; it never fails
+func (n LogUsageAlerts) NotifyUsageThreshold(ctx context.Context, event *domain.UsageThresholdReachedEvent) error {
+	n.Logger.WarnContext(ctx, "usage threshold reached",
+		slog.String("subscription_id", event.SubscriptionID),
+		slog.String("customer_id", event.CustomerID),
+		slog.String("metric", event.Metric),
+		slog.Int64("threshold_bp", event.ThresholdBP),
+		slog.Int64("quantity", event.Quantity),
+		slog.Int64("included", event.Included),
+		slog.Time("period_start", event.PeriodStart),
+	)
+	return nil
+}
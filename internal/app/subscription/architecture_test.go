@@ -0,0 +1,174 @@
+// Package subscription_test checks the layer boundaries of the subscription service.
+// It has no code of its own.
+package subscription_test
+
+import (
+	"go/build"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+const module = "github.com/wuyiadepoju/subscription-management/internal/app/subscription/"
+
+// layerRule forbids the packages under layer from depending on the packages matching
+// any of forbidden. A prefix ending in "/" matches the packages under it.
+type layerRule struct {
+	layer      string
+	forbidden  []string
+	transitive bool // also forbid the dependency through other packages of this module
+	why        string
+}
+
+var layerRules = []layerRule{
+	{
+		layer:      module + "domain",
+		forbidden:  []string{"github.com/", "cloud.google.com/", "google.golang.org/", "golang.org/"},
+		transitive: true,
+		why:        "the domain is pure business logic on the standard library",
+	},
+	{
+		layer:      module + "contracts",
+		forbidden:  []string{module + "adapters", module + "repo", module + "usecases/", module + "transport/", module + "workers/"},
+		transitive: true,
+		why:        "contracts only describe the boundaries between layers",
+	},
+	{
+		layer:      module + "usecases/",
+		forbidden:  []string{module + "adapters", module + "repo", module + "transport/", module + "workers/", module + "migrations", module + "backup"},
+		transitive: true,
+		why:        "use cases reach infrastructure only through contracts",
+	},
+	{
+		layer:     module + "usecases/",
+		forbidden: []string{"cloud.google.com/go/spanner", "net/http"},
+		why:       "use cases write through repositories and call out through adapters; Spanner mutations are contracts.Mutation",
+	},
+	{
+		layer:      module + "adapters",
+		forbidden:  []string{module + "repo", module + "usecases/", module + "transport/", module + "workers/"},
+		transitive: true,
+		why:        "adapters implement contracts and don't drive use cases",
+	},
+	{
+		layer:      module + "repo",
+		forbidden:  []string{module + "adapters", module + "usecases/", module + "transport/", module + "workers/"},
+		transitive: true,
+		why:        "repositories implement contracts and don't drive use cases",
+	},
+}
+
+func TestArchitecture_LayerDependencies(t *testing.T) {
+	imports := loadImports(t)
+
+	for _, rule := range layerRules {
+		for _, pkg := range sortedPackages(imports) {
+			if !matches(pkg, rule.layer) {
+				continue
+			}
+			if chain := forbiddenDependency(imports, pkg, rule); chain != nil {
+				t.Errorf("%s depends on %s (%s)", short(pkg), strings.Join(shortAll(chain[1:]), " -> "), rule.why)
+			}
+		}
+	}
+}
+
+// The rules are only as good as the packages they see
+func TestArchitecture_RulesMatchPackages(t *testing.T) {
+	imports := loadImports(t)
+
+	for _, rule := range layerRules {
+		found := false
+		for pkg := range imports {
+			found = found || matches(pkg, rule.layer)
+		}
+		if !found {
+			t.Errorf("no package matches the layer %s; was it moved?", short(rule.layer))
+		}
+	}
+}
+
+// loadImports returns the imports of the non-test files of every package under this
+// directory, by import path
+func loadImports(t *testing.T) map[string][]string {
+	t.Helper()
+	imports := make(map[string][]string)
+	err := filepath.WalkDir(".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return err
+		}
+		if name := d.Name(); path != "." && (name == "testdata" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+			return filepath.SkipDir
+		}
+		pkg, err := build.ImportDir(path, 0)
+		if _, ok := err.(*build.NoGoError); ok {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		imports[strings.TrimSuffix(module+filepath.ToSlash(path), "/.")] = pkg.Imports
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return imports
+}
+
+// forbiddenDependency returns the import chain from pkg to a package the rule forbids,
+// or nil. Only the packages of this module are followed, since they are the ones
+// loaded.
+func forbiddenDependency(imports map[string][]string, pkg string, rule layerRule) []string {
+	visited := map[string]bool{pkg: true}
+	var walk func(chain []string) []string
+	walk = func(chain []string) []string {
+		for _, imp := range imports[chain[len(chain)-1]] {
+			next := append(chain[:len(chain):len(chain)], imp)
+			for _, f := range rule.forbidden {
+				if matches(imp, f) {
+					return next
+				}
+			}
+			if rule.transitive && !visited[imp] {
+				visited[imp] = true
+				if found := walk(next); found != nil {
+					return found
+				}
+			}
+		}
+		return nil
+	}
+	return walk([]string{pkg})
+}
+
+// matches reports whether pkg is prefix, or under it when prefix ends in "/"
+func matches(pkg, prefix string) bool {
+	if strings.HasSuffix(prefix, "/") {
+		return strings.HasPrefix(pkg, prefix)
+	}
+	return pkg == prefix || strings.HasPrefix(pkg, prefix+"/")
+}
+
+func sortedPackages(imports map[string][]string) []string {
+	pkgs := make([]string, 0, len(imports))
+	for pkg := range imports {
+		pkgs = append(pkgs, pkg)
+	}
+	sort.Strings(pkgs)
+	return pkgs
+}
+
+func short(pkg string) string {
+	return strings.TrimPrefix(pkg, module)
+}
+
+func shortAll(pkgs []string) []string {
+	out := make([]string, len(pkgs))
+	for i, pkg := range pkgs {
+		out[i] = short(pkg)
+	}
+	return out
+}
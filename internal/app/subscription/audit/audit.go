@@ -0,0 +1,110 @@
+// Package audit records privileged operations in the security audit trail: who
+// performed them, from where, what they asked for and how it ended.
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/correlation"
+)
+
+// Anonymous is recorded for operations whose context carries no principal
+const Anonymous = "anonymous"
+
+// Principal identifies the caller of an operation
+type Principal struct {
+	ID       string
+	SourceIP string
+}
+
+type ctxKey struct{}
+
+// WithPrincipal returns a context carrying the caller. The transport sets it once
+// the caller is authenticated.
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, ctxKey{}, p)
+}
+
+// PrincipalFrom returns the caller carried by ctx, if any
+func PrincipalFrom(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(ctxKey{}).(Principal)
+	return p, ok
+}
+
+// HashPayload returns the hex SHA-256 of the payload's JSON encoding
+func HashPayload(payload any) (string, error) {
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode audit payload: %w", err)
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Recorder writes audit entries for privileged operations
+type Recorder struct {
+	log    contracts.AuditLog
+	logger *slog.Logger
+	now    func() time.Time
+	denied func(error) bool
+}
+
+// NewRecorder creates a recorder writing to log. denied reports whether an operation's
+// error means the caller was refused; it may be nil.
+func NewRecorder(log contracts.AuditLog, logger *slog.Logger, denied func(error) bool) *Recorder {
+	return &Recorder{log: log, logger: logger, now: time.Now, denied: denied}
+}
+
+// Record appends the outcome of action, performed with payload by the caller in ctx,
+// to the audit trail. The operation has already happened by then, so a failure to
+// record is logged with the whole entry, leaving the log pipeline as the fallback
+// trail, and returned for the caller to surface.
+func (r *Recorder) Record(ctx context.Context, action string, payload any, opErr error) error {
+	entry := contracts.AuditEntry{
+		ID:            uuid.New().String(),
+		Action:        action,
+		Principal:     Anonymous,
+		Outcome:       contracts.AuditSucceeded,
+		CorrelationID: correlation.ID(ctx),
+		OccurredAt:    r.now().UTC(),
+	}
+	if p, ok := PrincipalFrom(ctx); ok {
+		entry.Principal = p.ID
+		entry.SourceIP = p.SourceIP
+	}
+	if opErr != nil {
+		entry.Outcome = contracts.AuditFailed
+		if r.denied != nil && r.denied(opErr) {
+			entry.Outcome = contracts.AuditDenied
+		}
+		entry.Error = opErr.Error()
+	}
+
+	hash, hashErr := HashPayload(payload)
+	entry.PayloadHash = hash
+
+	// The audit write must not be cut short by the request that is finishing
+	err := errors.Join(hashErr, r.log.Record(context.WithoutCancel(ctx), entry))
+	if err != nil {
+		r.logger.ErrorContext(ctx, "failed to record audit entry",
+			slog.String("audit_id", entry.ID),
+			slog.String("action", entry.Action),
+			slog.String("principal", entry.Principal),
+			slog.String("source_ip", entry.SourceIP),
+			slog.String("payload_hash", entry.PayloadHash),
+			slog.String("outcome", string(entry.Outcome)),
+			slog.Any("error", err),
+		)
+	}
+	return err
+}
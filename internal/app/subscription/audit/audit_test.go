@@ -0,0 +1,235 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/correlation"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/logging"
+)
+
+var errDenied = errors.New("denied")
+
+type memoryLog struct {
+	entries    []contracts.AuditEntry
+	err        error
+	partitions int
+}
+
+func (m *memoryLog) Record(ctx context.Context, entry contracts.AuditEntry) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.entries = append(m.entries, entry)
+	return nil
+}
+
+func (m *memoryLog) ListAuditEntries(ctx context.Context, afterTime time.Time, afterID string, limit int) ([]contracts.AuditEntry, error) {
+	var page []contracts.AuditEntry
+	for _, e := range m.entries {
+		if e.OccurredAt.After(afterTime) || (e.OccurredAt.Equal(afterTime) && e.ID > afterID) {
+			page = append(page, e)
+		}
+		if len(page) == limit {
+			break
+		}
+	}
+	return page, nil
+}
+
+// PartitionAuditEntries deals the entries after the cursor into partitions in turn,
+// newest first, so no partition is in order
+func (m *memoryLog) PartitionAuditEntries(ctx context.Context, afterTime time.Time, afterID string) (contracts.AuditLogSnapshot, error) {
+	s := &memorySnapshot{parts: make([][]contracts.AuditEntry, m.partitions), failPart: -1}
+	if m.err != nil {
+		s.failPart, s.err = 0, m.err
+	}
+	for i := len(m.entries) - 1; i >= 0; i-- {
+		e := m.entries[i]
+		if e.OccurredAt.After(afterTime) || (e.OccurredAt.Equal(afterTime) && e.ID > afterID) {
+			n := (len(m.entries) - 1 - i) % m.partitions
+			s.parts[n] = append(s.parts[n], e)
+		}
+	}
+	return s, nil
+}
+
+type memorySnapshot struct {
+	parts    [][]contracts.AuditEntry
+	failPart int
+	err      error
+	closed   bool
+}
+
+func (s *memorySnapshot) Timestamp() time.Time { return time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC) }
+func (s *memorySnapshot) Partitions() int      { return len(s.parts) }
+func (s *memorySnapshot) Close()               { s.closed = true }
+
+func (s *memorySnapshot) ReadPartition(ctx context.Context, partition int, fn func(contracts.AuditEntry) error) error {
+	if partition == s.failPart {
+		return s.err
+	}
+	for _, e := range s.parts[partition] {
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// memoryStore keeps the files written to it
+type memoryStore struct {
+	mu    sync.Mutex
+	files map[string]*bytes.Buffer
+}
+
+func (m *memoryStore) Create(ctx context.Context, name string) (io.WriteCloser, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.files == nil {
+		m.files = make(map[string]*bytes.Buffer)
+	}
+	buf := &bytes.Buffer{}
+	m.files[name] = buf
+	return nopCloser{buf}, nil
+}
+
+type nopCloser struct{ io.Writer }
+
+func (nopCloser) Close() error { return nil }
+
+func isDenied(err error) bool { return errors.Is(err, errDenied) }
+
+func TestRecorder_RecordsPrincipalAndOutcome(t *testing.T) {
+	log := &memoryLog{}
+	r := NewRecorder(log, logging.Discard(), isDenied)
+
+	ctx := WithPrincipal(context.Background(), Principal{ID: "ops@example.com", SourceIP: "10.0.0.7"})
+	ctx = correlation.WithID(ctx, "corr-1")
+	payload := map[string]string{"customer_id": "cust-1"}
+
+	require.NoError(t, r.Record(ctx, "customer.erase", payload, nil))
+	require.NoError(t, r.Record(ctx, "customer.erase", payload, errDenied))
+	require.NoError(t, r.Record(context.Background(), "customer.erase", payload, errors.New("spanner unavailable")))
+
+	require.Len(t, log.entries, 3)
+	hash, err := HashPayload(payload)
+	require.NoError(t, err)
+
+	first := log.entries[0]
+	assert.Equal(t, "ops@example.com", first.Principal)
+	assert.Equal(t, "10.0.0.7", first.SourceIP)
+	assert.Equal(t, "corr-1", first.CorrelationID)
+	assert.Equal(t, hash, first.PayloadHash)
+	assert.NotContains(t, first.PayloadHash, "cust-1")
+	assert.Equal(t, contracts.AuditSucceeded, first.Outcome)
+
+	assert.Equal(t, contracts.AuditDenied, log.entries[1].Outcome)
+
+	assert.Equal(t, contracts.AuditFailed, log.entries[2].Outcome)
+	assert.Equal(t, Anonymous, log.entries[2].Principal)
+	assert.Equal(t, "spanner unavailable", log.entries[2].Error)
+}
+
+func TestRecorder_LogsEntryItCannotStore(t *testing.T) {
+	var logs bytes.Buffer
+	logger, err := logging.New(&logs, "info", "json")
+	require.NoError(t, err)
+	r := NewRecorder(&memoryLog{err: errors.New("spanner unavailable")}, logger, nil)
+
+	ctx := WithPrincipal(context.Background(), Principal{ID: "ops@example.com"})
+	err = r.Record(ctx, "customer.erase", "payload", nil)
+	require.Error(t, err)
+
+	var line map[string]any
+	require.NoError(t, json.Unmarshal(logs.Bytes(), &line))
+	assert.Equal(t, "failed to record audit entry", line["msg"])
+	assert.Equal(t, "ops@example.com", line["principal"])
+	assert.Equal(t, "customer.erase", line["action"])
+	assert.NotEmpty(t, line["payload_hash"])
+}
+
+func TestExport_ResumesFromCursor(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	log := &memoryLog{}
+	for i, id := range []string{"a", "b", "c", "d", "e"} {
+		log.entries = append(log.entries, contracts.AuditEntry{ID: id, Action: "customer.erase", Principal: "ops", Outcome: contracts.AuditSucceeded, OccurredAt: base.Add(time.Duration(i/2) * time.Minute)})
+	}
+
+	var out bytes.Buffer
+	cursor, err := Export(context.Background(), log, &out, Cursor{}, 2)
+	require.NoError(t, err)
+	assert.Equal(t, Cursor{OccurredAt: base.Add(2 * time.Minute), ID: "e"}, cursor)
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 5)
+	var event Event
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &event))
+	assert.Equal(t, EventType, event.Type)
+	assert.Equal(t, "a", event.ID)
+	assert.Equal(t, "SUCCEEDED", event.Outcome)
+
+	out.Reset()
+	cursor, err = Export(context.Background(), log, &out, Cursor{OccurredAt: base.Add(time.Minute), ID: "c"}, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 2, strings.Count(out.String(), "\n"), "only entries after the cursor are exported")
+	assert.Equal(t, "e", cursor.ID)
+}
+
+func TestExportPartitioned_WritesEveryPartitionThenTheManifest(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	log := &memoryLog{partitions: 3}
+	for i, id := range []string{"a", "b", "c", "d", "e", "f", "g"} {
+		log.entries = append(log.entries, contracts.AuditEntry{ID: id, Action: "customer.erase", Principal: "ops", Outcome: contracts.AuditSucceeded, OccurredAt: base.Add(time.Duration(i/2) * time.Minute)})
+	}
+	store := &memoryStore{}
+
+	manifest, err := ExportPartitioned(context.Background(), log, store, Cursor{OccurredAt: base, ID: "a"}, 2)
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(6), manifest.Rows, "only entries after the cursor are exported")
+	assert.Equal(t, Cursor{OccurredAt: base.Add(3 * time.Minute), ID: "g"}, manifest.Cursor, "the latest entry, whichever partition held it")
+	assert.Equal(t, Cursor{OccurredAt: base, ID: "a"}, manifest.After)
+	require.Len(t, manifest.Files, 3)
+	assert.Equal(t, "20240115T000000.000000Z/part-00001.json", manifest.Files[1].Name)
+
+	var ids []string
+	for _, f := range manifest.Files {
+		lines := strings.Split(strings.TrimSpace(store.files[f.Name].String()), "\n")
+		assert.Len(t, lines, int(f.Rows), f.Name)
+		for _, line := range lines {
+			var event Event
+			require.NoError(t, json.Unmarshal([]byte(line), &event))
+			assert.Equal(t, EventType, event.Type)
+			ids = append(ids, event.ID)
+		}
+	}
+	assert.ElementsMatch(t, []string{"b", "c", "d", "e", "f", "g"}, ids)
+
+	var written Manifest
+	require.NoError(t, json.Unmarshal(store.files["20240115T000000.000000Z/"+ManifestName].Bytes(), &written))
+	assert.Equal(t, manifest.Cursor.ID, written.Cursor.ID)
+	assert.Equal(t, int64(6), written.Rows)
+}
+
+func TestExportPartitioned_FailedPartitionWritesNoManifest(t *testing.T) {
+	log := &memoryLog{partitions: 2, err: errors.New("spanner unavailable")}
+	log.entries = []contracts.AuditEntry{{ID: "a", OccurredAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}}
+	store := &memoryStore{}
+
+	_, err := ExportPartitioned(context.Background(), log, store, Cursor{}, 2)
+
+	assert.ErrorIs(t, err, log.err)
+	assert.NotContains(t, store.files, "20240115T000000.000000Z/"+ManifestName)
+}
@@ -0,0 +1,72 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+)
+
+// Cursor marks the last entry exported, so the next export resumes after it
+type Cursor struct {
+	OccurredAt time.Time `json:"occurred_at"`
+	ID         string    `json:"id"`
+}
+
+// Event is an audit entry as exported to the SIEM, one JSON object per line
+type Event struct {
+	Type          string    `json:"event_type"`
+	ID            string    `json:"id"`
+	Timestamp     time.Time `json:"timestamp"`
+	Action        string    `json:"action"`
+	Principal     string    `json:"principal"`
+	SourceIP      string    `json:"source_ip,omitempty"`
+	PayloadHash   string    `json:"payload_hash"`
+	Outcome       string    `json:"outcome"`
+	Error         string    `json:"error,omitempty"`
+	CorrelationID string    `json:"correlation_id,omitempty"`
+}
+
+// EventType tags exported events so the SIEM can route them
+const EventType = "subscription.admin_audit"
+
+// Export writes every entry after from to w as JSON lines, reading batchSize entries
+// at a time, and returns the cursor of the last entry written. On error the returned
+// cursor still marks what was written, so a retry doesn't duplicate it.
+func Export(ctx context.Context, reader contracts.AuditLogReader, w io.Writer, from Cursor, batchSize int) (Cursor, error) {
+	enc := json.NewEncoder(w)
+	cursor := from
+	for {
+		entries, err := reader.ListAuditEntries(ctx, cursor.OccurredAt, cursor.ID, batchSize)
+		if err != nil {
+			return cursor, fmt.Errorf("failed to read audit entries: %w", err)
+		}
+		for _, e := range entries {
+			if err := enc.Encode(newEvent(e)); err != nil {
+				return cursor, fmt.Errorf("failed to write audit event: %w", err)
+			}
+			cursor = Cursor{OccurredAt: e.OccurredAt, ID: e.ID}
+		}
+		if len(entries) < batchSize {
+			return cursor, nil
+		}
+	}
+}
+
+func newEvent(e contracts.AuditEntry) Event {
+	return Event{
+		Type:          EventType,
+		ID:            e.ID,
+		Timestamp:     e.OccurredAt,
+		Action:        e.Action,
+		Principal:     e.Principal,
+		SourceIP:      e.SourceIP,
+		PayloadHash:   e.PayloadHash,
+		Outcome:       string(e.Outcome),
+		Error:         e.Error,
+		CorrelationID: e.CorrelationID,
+	}
+}
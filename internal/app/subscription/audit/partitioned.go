@@ -0,0 +1,150 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/workpool"
+)
+
+// ManifestName is written last in a partitioned export's directory, so an export
+// without it is incomplete
+const ManifestName = "manifest.json"
+
+// Store holds the files of a partitioned export; backup.DirStore and backup.GCSStore
+// are stores
+type Store interface {
+	// Create opens a file for writing; it is complete once Close returns nil
+	Create(ctx context.Context, name string) (io.WriteCloser, error)
+}
+
+// Manifest describes a partitioned export
+type Manifest struct {
+	ReadTimestamp time.Time      `json:"read_timestamp"` // the trail was read as of this time
+	After         Cursor         `json:"after"`          // entries after this one were exported
+	Cursor        Cursor         `json:"cursor"`         // the last entry exported, where the next export resumes
+	Files         []FileManifest `json:"files"`
+	Rows          int64          `json:"rows"`
+}
+
+// FileManifest is one JSON lines file, holding one partition
+type FileManifest struct {
+	Name string `json:"name"`
+	Rows int64  `json:"rows"`
+}
+
+// ExportPartitioned writes every entry after from as JSON lines, like Export, but
+// reads the trail from one snapshot split into partitions, parallelism at a time.
+// Each partition becomes its own file, <dir>/part-NNNNN.json, so a BigQuery load job
+// can read them in parallel too; dir is named after the read timestamp, such as
+// 20240115T000000.000000Z, and the manifest is written to it last. Files are in no
+// particular order, so the cursor is only advanced, to the latest entry exported,
+// once every partition is written; a failed export is simply run again.
+func ExportPartitioned(ctx context.Context, partitioner contracts.AuditLogPartitioner, store Store, from Cursor, parallelism int) (*Manifest, error) {
+	if parallelism < 1 {
+		parallelism = 1
+	}
+
+	snapshot, err := partitioner.PartitionAuditEntries(ctx, from.OccurredAt, from.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to partition audit entries: %w", err)
+	}
+	defer snapshot.Close()
+
+	manifest := &Manifest{
+		ReadTimestamp: snapshot.Timestamp(),
+		After:         from,
+		Cursor:        from,
+		Files:         make([]FileManifest, snapshot.Partitions()),
+	}
+	dir := manifest.ReadTimestamp.UTC().Format("20060102T150405.000000Z")
+	for i := range manifest.Files {
+		manifest.Files[i].Name = fmt.Sprintf("%s/part-%05d.json", dir, i)
+	}
+
+	partitions := make([]int, len(manifest.Files))
+	for i := range partitions {
+		partitions[i] = i
+	}
+	var mu sync.Mutex
+	result, err := workpool.Run(ctx, partitions, workpool.Config{Concurrency: parallelism, StopOnError: true}, func(ctx context.Context, i int) error {
+		file := &manifest.Files[i]
+		rows, last, err := exportPartition(ctx, snapshot, i, store, file.Name)
+		if err != nil {
+			return fmt.Errorf("failed to export %s: %w", file.Name, err)
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		file.Rows = rows
+		manifest.Rows += rows
+		if last.after(manifest.Cursor) {
+			manifest.Cursor = last
+		}
+		return nil
+	})
+	if err := result.FirstErr(); err != nil {
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := writeManifest(ctx, store, dir+"/"+ManifestName, manifest); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// exportPartition writes one partition's entries and returns how many there were and
+// the latest of them
+func exportPartition(ctx context.Context, snapshot contracts.AuditLogSnapshot, partition int, store Store, name string) (int64, Cursor, error) {
+	w, err := store.Create(ctx, name)
+	if err != nil {
+		return 0, Cursor{}, err
+	}
+	enc := json.NewEncoder(w)
+	var (
+		rows int64
+		last Cursor
+	)
+	err = snapshot.ReadPartition(ctx, partition, func(e contracts.AuditEntry) error {
+		if err := enc.Encode(newEvent(e)); err != nil {
+			return err
+		}
+		rows++
+		if c := (Cursor{OccurredAt: e.OccurredAt, ID: e.ID}); c.after(last) {
+			last = c
+		}
+		return nil
+	})
+	if err != nil {
+		w.Close()
+		return 0, Cursor{}, err
+	}
+	return rows, last, w.Close()
+}
+
+func writeManifest(ctx context.Context, store Store, name string, manifest *Manifest) error {
+	w, err := store.Create(ctx, name)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(manifest); err != nil {
+		w.Close()
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	return w.Close()
+}
+
+// after reports whether c comes after other in the trail's order
+func (c Cursor) after(other Cursor) bool {
+	return c.OccurredAt.After(other.OccurredAt) || (c.OccurredAt.Equal(other.OccurredAt) && c.ID > other.ID)
+}
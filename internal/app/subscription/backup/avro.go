@@ -0,0 +1,401 @@
+package backup
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// This file implements the subset of Avro object container files a snapshot needs:
+// a record of primitive fields, each optionally nullable, with the null codec.
+// Values are nil, bool, int32, int64, float64, string or []byte.
+
+var avroMagic = []byte{'O', 'b', 'j', 1}
+
+// rowsPerBlock bounds how many rows are buffered before a block is written
+const rowsPerBlock = 1000
+
+// Field is one column of a snapshot's Avro record
+type Field struct {
+	Name        string `json:"name"`
+	Type        string `json:"-"` // boolean, int, long, double, string or bytes
+	LogicalType string `json:"-"` // timestamp-micros or date, if any
+	Nullable    bool   `json:"-"`
+	SpannerType string `json:"-"` // the column's Spanner type, kept so a restore can rebuild it
+}
+
+// MarshalJSON writes the field in Avro schema form
+func (f Field) MarshalJSON() ([]byte, error) {
+	var typ any = f.Type
+	if f.LogicalType != "" {
+		typ = map[string]string{"type": f.Type, "logicalType": f.LogicalType}
+	}
+	field := map[string]any{"name": f.Name, "type": typ, "spanner_type": f.SpannerType}
+	if f.Nullable {
+		field["type"] = []any{"null", typ}
+		field["default"] = nil
+	}
+	return json.Marshal(field)
+}
+
+// UnmarshalJSON reads a field written by MarshalJSON
+func (f *Field) UnmarshalJSON(b []byte) error {
+	var raw struct {
+		Name        string          `json:"name"`
+		Type        json.RawMessage `json:"type"`
+		SpannerType string          `json:"spanner_type"`
+	}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	f.Name, f.SpannerType = raw.Name, raw.SpannerType
+
+	typ := raw.Type
+	var union []json.RawMessage
+	if json.Unmarshal(typ, &union) == nil {
+		if len(union) != 2 || string(union[0]) != `"null"` {
+			return fmt.Errorf("field %s: only [null, T] unions are supported", f.Name)
+		}
+		f.Nullable = true
+		typ = union[1]
+	}
+	if json.Unmarshal(typ, &f.Type) == nil {
+		return nil
+	}
+	var logical struct {
+		Type        string `json:"type"`
+		LogicalType string `json:"logicalType"`
+	}
+	if err := json.Unmarshal(typ, &logical); err != nil {
+		return fmt.Errorf("field %s: unsupported type %s", f.Name, typ)
+	}
+	f.Type, f.LogicalType = logical.Type, logical.LogicalType
+	return nil
+}
+
+type schema struct {
+	Type   string  `json:"type"`
+	Name   string  `json:"name"`
+	Fields []Field `json:"fields"`
+}
+
+// avroWriter writes rows to an Avro object container file
+type avroWriter struct {
+	w      io.Writer
+	fields []Field
+	sync   [16]byte
+	block  bytes.Buffer
+	rows   int64
+	err    error
+}
+
+func newAvroWriter(w io.Writer, name string, fields []Field) (*avroWriter, error) {
+	aw := &avroWriter{w: w, fields: fields}
+	if _, err := rand.Read(aw.sync[:]); err != nil {
+		return nil, err
+	}
+	s, err := json.Marshal(schema{Type: "record", Name: name, Fields: fields})
+	if err != nil {
+		return nil, err
+	}
+
+	var header bytes.Buffer
+	header.Write(avroMagic)
+	writeLong(&header, 2)
+	writeBytes(&header, []byte("avro.schema"))
+	writeBytes(&header, s)
+	writeBytes(&header, []byte("avro.codec"))
+	writeBytes(&header, []byte("null"))
+	writeLong(&header, 0)
+	header.Write(aw.sync[:])
+	if _, err := w.Write(header.Bytes()); err != nil {
+		return nil, err
+	}
+	return aw, nil
+}
+
+// Write appends one row, with a value per field
+func (aw *avroWriter) Write(row []any) error {
+	if len(row) != len(aw.fields) {
+		return fmt.Errorf("row has %d values for %d fields", len(row), len(aw.fields))
+	}
+	for i, f := range aw.fields {
+		if err := encodeValue(&aw.block, f, row[i]); err != nil {
+			return err
+		}
+	}
+	aw.rows++
+	if aw.rows == rowsPerBlock {
+		return aw.flush()
+	}
+	return nil
+}
+
+// Close writes any buffered rows; it doesn't close the underlying writer
+func (aw *avroWriter) Close() error {
+	return aw.flush()
+}
+
+func (aw *avroWriter) flush() error {
+	if aw.rows == 0 {
+		return nil
+	}
+	var head bytes.Buffer
+	writeLong(&head, aw.rows)
+	writeLong(&head, int64(aw.block.Len()))
+	for _, b := range [][]byte{head.Bytes(), aw.block.Bytes(), aw.sync[:]} {
+		if _, err := aw.w.Write(b); err != nil {
+			return err
+		}
+	}
+	aw.block.Reset()
+	aw.rows = 0
+	return nil
+}
+
+func encodeValue(buf *bytes.Buffer, f Field, v any) error {
+	if f.Nullable {
+		if v == nil {
+			writeLong(buf, 0)
+			return nil
+		}
+		writeLong(buf, 1)
+	} else if v == nil {
+		return fmt.Errorf("field %s is not nullable", f.Name)
+	}
+
+	ok := true
+	switch f.Type {
+	case "boolean":
+		var b bool
+		if b, ok = v.(bool); ok {
+			if b {
+				buf.WriteByte(1)
+			} else {
+				buf.WriteByte(0)
+			}
+		}
+	case "int":
+		var n int32
+		if n, ok = v.(int32); ok {
+			writeLong(buf, int64(n))
+		}
+	case "long":
+		var n int64
+		if n, ok = v.(int64); ok {
+			writeLong(buf, n)
+		}
+	case "double":
+		var d float64
+		if d, ok = v.(float64); ok {
+			var b [8]byte
+			binary.LittleEndian.PutUint64(b[:], math.Float64bits(d))
+			buf.Write(b[:])
+		}
+	case "string":
+		var s string
+		if s, ok = v.(string); ok {
+			writeBytes(buf, []byte(s))
+		}
+	case "bytes":
+		var b []byte
+		if b, ok = v.([]byte); ok {
+			writeBytes(buf, b)
+		}
+	default:
+		return fmt.Errorf("field %s: unsupported type %s", f.Name, f.Type)
+	}
+	if !ok {
+		return fmt.Errorf("field %s: %T is not a valid %s", f.Name, v, f.Type)
+	}
+	return nil
+}
+
+func writeLong(buf *bytes.Buffer, n int64) {
+	var b [binary.MaxVarintLen64]byte
+	buf.Write(b[:binary.PutVarint(b[:], n)])
+}
+
+func writeBytes(buf *bytes.Buffer, b []byte) {
+	writeLong(buf, int64(len(b)))
+	buf.Write(b)
+}
+
+// avroReader reads rows from an Avro object container file written by avroWriter
+type avroReader struct {
+	r         *bufio.Reader
+	fields    []Field
+	sync      [16]byte
+	remaining int64 // rows left in the current block
+}
+
+func newAvroReader(r io.Reader) (*avroReader, error) {
+	ar := &avroReader{r: bufio.NewReader(r)}
+	magic := make([]byte, 4)
+	if _, err := io.ReadFull(ar.r, magic); err != nil {
+		return nil, fmt.Errorf("failed to read avro header: %w", err)
+	}
+	if !bytes.Equal(magic, avroMagic) {
+		return nil, errors.New("not an avro object container file")
+	}
+
+	meta := make(map[string][]byte)
+	for {
+		n, err := ar.readLong()
+		if err != nil {
+			return nil, err
+		}
+		if n == 0 {
+			break
+		}
+		if n < 0 {
+			// A negative count is followed by the block's size in bytes
+			if _, err := ar.readLong(); err != nil {
+				return nil, err
+			}
+			n = -n
+		}
+		for ; n > 0; n-- {
+			key, err := ar.readBytes()
+			if err != nil {
+				return nil, err
+			}
+			value, err := ar.readBytes()
+			if err != nil {
+				return nil, err
+			}
+			meta[string(key)] = value
+		}
+	}
+	if codec := string(meta["avro.codec"]); codec != "" && codec != "null" {
+		return nil, fmt.Errorf("unsupported avro codec %q", codec)
+	}
+	var s schema
+	if err := json.Unmarshal(meta["avro.schema"], &s); err != nil {
+		return nil, fmt.Errorf("invalid avro schema: %w", err)
+	}
+	ar.fields = s.Fields
+	if _, err := io.ReadFull(ar.r, ar.sync[:]); err != nil {
+		return nil, err
+	}
+	return ar, nil
+}
+
+// Fields returns the schema's fields
+func (ar *avroReader) Fields() []Field {
+	return ar.fields
+}
+
+// Read returns the next row, or io.EOF after the last one
+func (ar *avroReader) Read() ([]any, error) {
+	for ar.remaining == 0 {
+		n, err := ar.readLong()
+		if err != nil {
+			return nil, err // io.EOF between blocks ends the file
+		}
+		if _, err := ar.readLong(); err != nil {
+			return nil, unexpected(err)
+		}
+		ar.remaining = n
+		if n == 0 {
+			if err := ar.readSync(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	row := make([]any, len(ar.fields))
+	for i, f := range ar.fields {
+		v, err := ar.readValue(f)
+		if err != nil {
+			return nil, unexpected(err)
+		}
+		row[i] = v
+	}
+	ar.remaining--
+	if ar.remaining == 0 {
+		if err := ar.readSync(); err != nil {
+			return nil, err
+		}
+	}
+	return row, nil
+}
+
+func (ar *avroReader) readSync() error {
+	var sync [16]byte
+	if _, err := io.ReadFull(ar.r, sync[:]); err != nil {
+		return unexpected(err)
+	}
+	if sync != ar.sync {
+		return errors.New("avro sync marker mismatch: file is corrupt")
+	}
+	return nil
+}
+
+func (ar *avroReader) readValue(f Field) (any, error) {
+	if f.Nullable {
+		branch, err := ar.readLong()
+		if err != nil {
+			return nil, err
+		}
+		if branch == 0 {
+			return nil, nil
+		}
+	}
+	switch f.Type {
+	case "boolean":
+		b, err := ar.r.ReadByte()
+		return b != 0, err
+	case "int":
+		n, err := ar.readLong()
+		return int32(n), err
+	case "long":
+		return ar.readLong()
+	case "double":
+		var b [8]byte
+		if _, err := io.ReadFull(ar.r, b[:]); err != nil {
+			return nil, err
+		}
+		return math.Float64frombits(binary.LittleEndian.Uint64(b[:])), nil
+	case "string":
+		b, err := ar.readBytes()
+		return string(b), err
+	case "bytes":
+		return ar.readBytes()
+	default:
+		return nil, fmt.Errorf("field %s: unsupported type %s", f.Name, f.Type)
+	}
+}
+
+func (ar *avroReader) readLong() (int64, error) {
+	return binary.ReadVarint(ar.r)
+}
+
+func (ar *avroReader) readBytes() ([]byte, error) {
+	n, err := ar.readLong()
+	if err != nil {
+		return nil, unexpected(err)
+	}
+	if n < 0 {
+		return nil, errors.New("negative avro length")
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(ar.r, b); err != nil {
+		return nil, unexpected(err)
+	}
+	return b, nil
+}
+
+// unexpected turns an end of file in the middle of a structure into an error
+func unexpected(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
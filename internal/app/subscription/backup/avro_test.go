@@ -0,0 +1,76 @@
+package backup
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testFields = []Field{
+	{Name: "id", Type: "string", SpannerType: "STRING"},
+	{Name: "price", Type: "long", SpannerType: "INT64"},
+	{Name: "active", Type: "boolean", SpannerType: "BOOL"},
+	{Name: "ratio", Type: "double", Nullable: true, SpannerType: "FLOAT64"},
+	{Name: "blob", Type: "bytes", Nullable: true, SpannerType: "BYTES"},
+	{Name: "cancelled_at", Type: "long", LogicalType: "timestamp-micros", Nullable: true, SpannerType: "TIMESTAMP"},
+	{Name: "start_date", Type: "int", LogicalType: "date", SpannerType: "DATE"},
+}
+
+func TestAvro_RoundTripsRowsAcrossBlocks(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := newAvroWriter(&buf, "subscriptions", testFields)
+	require.NoError(t, err)
+
+	var want [][]any
+	for i := 0; i < rowsPerBlock+5; i++ {
+		row := []any{"sub-" + string(rune('a'+i%26)), int64(-i), i%2 == 0, nil, nil, nil, int32(i)}
+		if i%3 == 0 {
+			row[3], row[4], row[5] = 0.5, []byte{byte(i)}, int64(1704067200000000)
+		}
+		require.NoError(t, w.Write(row))
+		want = append(want, row)
+	}
+	require.NoError(t, w.Close())
+
+	r, err := newAvroReader(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, testFields, r.Fields())
+
+	var got [][]any
+	for {
+		row, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		got = append(got, row)
+	}
+	assert.Equal(t, want, got)
+}
+
+func TestAvro_RejectsMismatchedValues(t *testing.T) {
+	w, err := newAvroWriter(io.Discard, "subscriptions", testFields)
+	require.NoError(t, err)
+
+	assert.ErrorContains(t, w.Write([]any{nil, int64(1), true, nil, nil, nil, int32(0)}), "id is not nullable")
+	assert.ErrorContains(t, w.Write([]any{"sub-1", 1, true, nil, nil, nil, int32(0)}), "int is not a valid long")
+}
+
+func TestField_SchemaForm(t *testing.T) {
+	b, err := json.Marshal(testFields[5])
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"name": "cancelled_at",
+		"type": ["null", {"type": "long", "logicalType": "timestamp-micros"}],
+		"default": null,
+		"spanner_type": "TIMESTAMP"
+	}`, string(b))
+
+	var f Field
+	require.NoError(t, json.Unmarshal(b, &f))
+	assert.Equal(t, testFields[5], f)
+}
@@ -0,0 +1,501 @@
+// Package backup exports a consistent snapshot of the database to Avro files, in a
+// local directory or Cloud Storage, and restores one. It is for disaster-recovery
+// drills, independent of Spanner's managed backups: a snapshot can be restored into
+// any database with the same schema.
+package backup
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"cloud.google.com/go/spanner"
+	"cloud.google.com/go/spanner/apiv1/spannerpb"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/workpool"
+	"google.golang.org/api/iterator"
+)
+
+// ManifestName is written last, so a snapshot without it is incomplete
+const ManifestName = "manifest.json"
+
+// Manifest describes a snapshot
+type Manifest struct {
+	ReadTimestamp time.Time       `json:"read_timestamp"` // every table was read as of this time
+	Tables        []TableManifest `json:"tables"`
+}
+
+// TableManifest describes one table's files
+type TableManifest struct {
+	Name   string         `json:"name"`
+	Fields []Field        `json:"fields"`
+	Files  []FileManifest `json:"files"`
+	Rows   int64          `json:"rows"`
+}
+
+// FileManifest is one Avro file, holding one read partition
+type FileManifest struct {
+	Name string `json:"name"`
+	Rows int64  `json:"rows"`
+}
+
+// ExportOptions shapes an export
+type ExportOptions struct {
+	Tables      []string // empty exports every table
+	Parallelism int      // partitions read at once
+	// Priority is the request priority of the partitioned reads; an export runs
+	// alongside customer traffic, so it is usually low
+	Priority spannerpb.RequestOptions_Priority
+}
+
+// Export reads every table at a single timestamp with partitioned reads, writing
+// each partition to its own Avro file, then the manifest
+func Export(ctx context.Context, client *spanner.Client, store Store, opts ExportOptions, logger *slog.Logger) (*Manifest, error) {
+	tables := opts.Tables
+	if len(tables) == 0 {
+		var err error
+		if tables, err = listTables(ctx, client); err != nil {
+			return nil, err
+		}
+	}
+	if opts.Parallelism < 1 {
+		opts.Parallelism = 1
+	}
+
+	manifest := &Manifest{Tables: make([]TableManifest, len(tables))}
+	for i, table := range tables {
+		fields, err := describeTable(ctx, client, table)
+		if err != nil {
+			return nil, err
+		}
+		manifest.Tables[i] = TableManifest{Name: table, Fields: fields}
+	}
+
+	txn, err := client.BatchReadOnlyTransaction(ctx, spanner.StrongRead())
+	if err != nil {
+		return nil, fmt.Errorf("failed to start snapshot: %w", err)
+	}
+	defer txn.Cleanup(context.WithoutCancel(ctx))
+	if manifest.ReadTimestamp, err = txn.Timestamp(); err != nil {
+		return nil, err
+	}
+
+	type job struct {
+		table     *TableManifest
+		index     int
+		partition *spanner.Partition
+	}
+	var jobs []job
+	for i := range manifest.Tables {
+		t := &manifest.Tables[i]
+		columns := make([]string, len(t.Fields))
+		for j, f := range t.Fields {
+			columns[j] = f.Name
+		}
+		partitions, err := txn.PartitionReadWithOptions(ctx, t.Name, spanner.AllKeys(), columns, spanner.PartitionOptions{}, spanner.ReadOptions{Priority: opts.Priority})
+		if err != nil {
+			return nil, fmt.Errorf("failed to partition %s: %w", t.Name, err)
+		}
+		t.Files = make([]FileManifest, len(partitions))
+		for j, p := range partitions {
+			t.Files[j].Name = fmt.Sprintf("%s/part-%05d.avro", t.Name, j)
+			jobs = append(jobs, job{table: t, index: j, partition: p})
+		}
+	}
+	logger.InfoContext(ctx, "exporting snapshot", slog.Time("read_timestamp", manifest.ReadTimestamp), slog.Int("tables", len(tables)), slog.Int("partitions", len(jobs)))
+
+	result, err := workpool.Run(ctx, jobs, workpool.Config{Concurrency: opts.Parallelism, StopOnError: true}, func(ctx context.Context, j job) error {
+		file := &j.table.Files[j.index]
+		rows, err := exportPartition(ctx, txn, j.partition, store, file.Name, j.table.Name, j.table.Fields)
+		if err != nil {
+			return fmt.Errorf("failed to export %s: %w", file.Name, err)
+		}
+		file.Rows = rows
+		return nil
+	})
+	if err := result.FirstErr(); err != nil {
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range manifest.Tables {
+		t := &manifest.Tables[i]
+		for _, f := range t.Files {
+			t.Rows += f.Rows
+		}
+		logger.InfoContext(ctx, "table exported", slog.String("table", t.Name), slog.Int64("rows", t.Rows), slog.Int("files", len(t.Files)))
+	}
+	if err := writeManifest(ctx, store, manifest); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+func exportPartition(ctx context.Context, txn *spanner.BatchReadOnlyTransaction, p *spanner.Partition, store Store, name, table string, fields []Field) (int64, error) {
+	w, err := store.Create(ctx, name)
+	if err != nil {
+		return 0, err
+	}
+	aw, err := newAvroWriter(w, table, fields)
+	if err != nil {
+		w.Close()
+		return 0, err
+	}
+
+	iter := txn.Execute(ctx, p)
+	defer iter.Stop()
+	var rows int64
+	for {
+		row, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			w.Close()
+			return 0, err
+		}
+		values, err := avroValues(row, fields)
+		if err != nil {
+			w.Close()
+			return 0, err
+		}
+		if err := aw.Write(values); err != nil {
+			w.Close()
+			return 0, err
+		}
+		rows++
+	}
+	if err := aw.Close(); err != nil {
+		w.Close()
+		return 0, err
+	}
+	return rows, w.Close()
+}
+
+// RestoreOptions shapes a restore
+type RestoreOptions struct {
+	Tables    []string // empty restores every table in the snapshot
+	BatchSize int      // rows written per commit
+}
+
+// Restore writes a snapshot's rows into the database with insert-or-update, so
+// existing rows with the same keys are overwritten and others are left alone.
+// Restore into an empty database migrated to the snapshot's schema for a drill.
+func Restore(ctx context.Context, client *spanner.Client, store Store, opts RestoreOptions, logger *slog.Logger) (*Manifest, error) {
+	manifest, err := readManifest(ctx, store)
+	if err != nil {
+		return nil, err
+	}
+	if opts.BatchSize < 1 {
+		opts.BatchSize = 500
+	}
+	wanted := make(map[string]bool)
+	for _, t := range opts.Tables {
+		wanted[t] = true
+	}
+
+	for _, t := range manifest.Tables {
+		if len(wanted) > 0 && !wanted[t.Name] {
+			continue
+		}
+		var restored int64
+		for _, f := range t.Files {
+			n, err := restoreFile(ctx, client, store, t.Name, f.Name, opts.BatchSize)
+			if err != nil {
+				return nil, fmt.Errorf("failed to restore %s: %w", f.Name, err)
+			}
+			if n != f.Rows {
+				return nil, fmt.Errorf("%s holds %d rows, the manifest says %d", f.Name, n, f.Rows)
+			}
+			restored += n
+		}
+		logger.InfoContext(ctx, "table restored", slog.String("table", t.Name), slog.Int64("rows", restored))
+	}
+	return manifest, nil
+}
+
+func restoreFile(ctx context.Context, client *spanner.Client, store Store, table, name string, batchSize int) (int64, error) {
+	r, err := store.Open(ctx, name)
+	if err != nil {
+		return 0, err
+	}
+	defer r.Close()
+	ar, err := newAvroReader(r)
+	if err != nil {
+		return 0, err
+	}
+	fields := ar.Fields()
+	columns := make([]string, len(fields))
+	for i, f := range fields {
+		columns[i] = f.Name
+	}
+
+	var (
+		rows  int64
+		batch []*spanner.Mutation
+	)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		_, err := client.Apply(ctx, batch)
+		batch = batch[:0]
+		return err
+	}
+	for {
+		row, err := ar.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return rows, err
+		}
+		values, err := spannerValues(row, fields)
+		if err != nil {
+			return rows, err
+		}
+		batch = append(batch, spanner.InsertOrUpdate(table, columns, values))
+		rows++
+		if len(batch) == batchSize {
+			if err := flush(); err != nil {
+				return rows, err
+			}
+		}
+	}
+	return rows, flush()
+}
+
+func writeManifest(ctx context.Context, store Store, m *Manifest) error {
+	w, err := store.Create(ctx, ManifestName)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(m); err != nil {
+		w.Close()
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	return w.Close()
+}
+
+func readManifest(ctx context.Context, store Store) (*Manifest, error) {
+	r, err := store.Open(ctx, ManifestName)
+	if err != nil {
+		return nil, fmt.Errorf("snapshot has no manifest, so it is missing or incomplete: %w", err)
+	}
+	defer r.Close()
+	var m Manifest
+	if err := json.NewDecoder(r).Decode(&m); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+	return &m, nil
+}
+
+func listTables(ctx context.Context, client *spanner.Client) ([]string, error) {
+	iter := client.Single().Query(ctx, spanner.Statement{
+		SQL: `SELECT TABLE_NAME FROM INFORMATION_SCHEMA.TABLES WHERE TABLE_SCHEMA = '' ORDER BY TABLE_NAME`,
+	})
+	defer iter.Stop()
+
+	var tables []string
+	for {
+		row, err := iter.Next()
+		if err == iterator.Done {
+			return tables, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list tables: %w", err)
+		}
+		var name string
+		if err := row.Columns(&name); err != nil {
+			return nil, err
+		}
+		tables = append(tables, name)
+	}
+}
+
+func describeTable(ctx context.Context, client *spanner.Client, table string) ([]Field, error) {
+	iter := client.Single().Query(ctx, spanner.Statement{
+		SQL: `SELECT COLUMN_NAME, SPANNER_TYPE, IS_NULLABLE
+			FROM INFORMATION_SCHEMA.COLUMNS
+			WHERE TABLE_SCHEMA = '' AND TABLE_NAME = @table
+			ORDER BY ORDINAL_POSITION`,
+		Params: map[string]any{"table": table},
+	})
+	defer iter.Stop()
+
+	var fields []Field
+	for {
+		row, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe %s: %w", table, err)
+		}
+		var name, spannerType, nullable string
+		if err := row.Columns(&name, &spannerType, &nullable); err != nil {
+			return nil, err
+		}
+		f, err := fieldFor(name, spannerType, nullable == "YES")
+		if err != nil {
+			return nil, fmt.Errorf("table %s: %w", table, err)
+		}
+		fields = append(fields, f)
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("table %s not found", table)
+	}
+	return fields, nil
+}
+
+// fieldFor maps a Spanner column to its Avro field
+func fieldFor(name, spannerType string, nullable bool) (Field, error) {
+	base, _, _ := strings.Cut(spannerType, "(")
+	f := Field{Name: name, Nullable: nullable, SpannerType: base}
+	switch base {
+	case "STRING", "NUMERIC", "JSON":
+		f.Type = "string"
+	case "INT64":
+		f.Type = "long"
+	case "BOOL":
+		f.Type = "boolean"
+	case "FLOAT64":
+		f.Type = "double"
+	case "BYTES":
+		f.Type = "bytes"
+	case "TIMESTAMP":
+		f.Type, f.LogicalType = "long", "timestamp-micros"
+	case "DATE":
+		f.Type, f.LogicalType = "int", "date"
+	default:
+		return Field{}, fmt.Errorf("column %s: unsupported type %s", name, spannerType)
+	}
+	return f, nil
+}
+
+var epoch = civil.Date{Year: 1970, Month: time.January, Day: 1}
+
+// avroValues converts a row read with fields' columns to Avro values
+func avroValues(row *spanner.Row, fields []Field) ([]any, error) {
+	values := make([]any, len(fields))
+	for i, f := range fields {
+		var err error
+		switch f.SpannerType {
+		case "STRING":
+			var v spanner.NullString
+			if err = row.Column(i, &v); err == nil && v.Valid {
+				values[i] = v.StringVal
+			}
+		case "NUMERIC":
+			var v spanner.NullNumeric
+			if err = row.Column(i, &v); err == nil && v.Valid {
+				values[i] = spanner.NumericString(&v.Numeric)
+			}
+		case "JSON":
+			var v spanner.NullJSON
+			if err = row.Column(i, &v); err == nil && v.Valid {
+				values[i] = v.String()
+			}
+		case "INT64":
+			var v spanner.NullInt64
+			if err = row.Column(i, &v); err == nil && v.Valid {
+				values[i] = v.Int64
+			}
+		case "BOOL":
+			var v spanner.NullBool
+			if err = row.Column(i, &v); err == nil && v.Valid {
+				values[i] = v.Bool
+			}
+		case "FLOAT64":
+			var v spanner.NullFloat64
+			if err = row.Column(i, &v); err == nil && v.Valid {
+				values[i] = v.Float64
+			}
+		case "BYTES":
+			var v []byte
+			if err = row.Column(i, &v); err == nil && v != nil {
+				values[i] = v
+			}
+		case "TIMESTAMP":
+			var v spanner.NullTime
+			if err = row.Column(i, &v); err == nil && v.Valid {
+				values[i] = v.Time.UnixMicro()
+			}
+		case "DATE":
+			var v spanner.NullDate
+			if err = row.Column(i, &v); err == nil && v.Valid {
+				values[i] = int32(v.Date.DaysSince(epoch))
+			}
+		default:
+			err = fmt.Errorf("unsupported type %s", f.SpannerType)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("column %s: %w", f.Name, err)
+		}
+	}
+	return values, nil
+}
+
+// spannerValues converts Avro values back to values for a mutation
+func spannerValues(row []any, fields []Field) ([]any, error) {
+	values := make([]any, len(fields))
+	for i, f := range fields {
+		v := row[i]
+		switch f.SpannerType {
+		case "STRING":
+			s, _ := v.(string)
+			values[i] = spanner.NullString{StringVal: s, Valid: v != nil}
+		case "NUMERIC":
+			n := spanner.NullNumeric{Valid: v != nil}
+			if s, ok := v.(string); ok {
+				if _, ok := n.Numeric.SetString(s); !ok {
+					return nil, fmt.Errorf("column %s: invalid numeric %q", f.Name, s)
+				}
+			}
+			values[i] = n
+		case "JSON":
+			j := spanner.NullJSON{Valid: v != nil}
+			if s, ok := v.(string); ok {
+				if err := json.Unmarshal([]byte(s), &j.Value); err != nil {
+					return nil, fmt.Errorf("column %s: %w", f.Name, err)
+				}
+			}
+			values[i] = j
+		case "INT64":
+			n, _ := v.(int64)
+			values[i] = spanner.NullInt64{Int64: n, Valid: v != nil}
+		case "BOOL":
+			b, _ := v.(bool)
+			values[i] = spanner.NullBool{Bool: b, Valid: v != nil}
+		case "FLOAT64":
+			d, _ := v.(float64)
+			values[i] = spanner.NullFloat64{Float64: d, Valid: v != nil}
+		case "BYTES":
+			b, _ := v.([]byte)
+			values[i] = b
+		case "TIMESTAMP":
+			var t spanner.NullTime
+			if us, ok := v.(int64); ok {
+				t = spanner.NullTime{Time: time.UnixMicro(us).UTC(), Valid: true}
+			}
+			values[i] = t
+		case "DATE":
+			var d spanner.NullDate
+			if days, ok := v.(int32); ok {
+				d = spanner.NullDate{Date: epoch.AddDays(int(days)), Valid: true}
+			}
+			values[i] = d
+		default:
+			return nil, fmt.Errorf("column %s: unsupported type %s", f.Name, f.SpannerType)
+		}
+	}
+	return values, nil
+}
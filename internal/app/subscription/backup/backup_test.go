@@ -0,0 +1,80 @@
+package backup
+
+import (
+	"context"
+	"io"
+	"math/big"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"cloud.google.com/go/spanner"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFieldFor_MapsSpannerTypes(t *testing.T) {
+	f, err := fieldFor("customer_id", "STRING(MAX)", false)
+	require.NoError(t, err)
+	assert.Equal(t, Field{Name: "customer_id", Type: "string", SpannerType: "STRING"}, f)
+
+	f, err = fieldFor("cancelled_at", "TIMESTAMP", true)
+	require.NoError(t, err)
+	assert.Equal(t, Field{Name: "cancelled_at", Type: "long", LogicalType: "timestamp-micros", Nullable: true, SpannerType: "TIMESTAMP"}, f)
+
+	_, err = fieldFor("tags", "ARRAY<STRING(MAX)>", false)
+	assert.ErrorContains(t, err, "unsupported type")
+}
+
+func TestSpannerValues_RebuildsColumns(t *testing.T) {
+	fields := []Field{
+		{Name: "cancelled_at", Type: "long", LogicalType: "timestamp-micros", Nullable: true, SpannerType: "TIMESTAMP"},
+		{Name: "refunded_at", Type: "long", LogicalType: "timestamp-micros", Nullable: true, SpannerType: "TIMESTAMP"},
+		{Name: "start_date", Type: "int", LogicalType: "date", SpannerType: "DATE"},
+		{Name: "amount", Type: "string", SpannerType: "NUMERIC"},
+		{Name: "metadata", Type: "string", SpannerType: "JSON"},
+	}
+	at := time.Date(2024, 1, 15, 9, 30, 0, 123000, time.UTC)
+
+	values, err := spannerValues([]any{at.UnixMicro(), nil, int32(19737), "12.50", `{"plan":"pro"}`}, fields)
+	require.NoError(t, err)
+
+	assert.Equal(t, spanner.NullTime{Time: at, Valid: true}, values[0])
+	assert.Equal(t, spanner.NullTime{}, values[1])
+	assert.Equal(t, spanner.NullDate{Date: civil.Date{Year: 2024, Month: time.January, Day: 15}, Valid: true}, values[2])
+	amount := values[3].(spanner.NullNumeric)
+	assert.Equal(t, 0, amount.Numeric.Cmp(big.NewRat(25, 2)))
+	assert.Equal(t, map[string]any{"plan": "pro"}, values[4].(spanner.NullJSON).Value)
+
+	_, err = spannerValues([]any{nil, nil, int32(0), "abc", "{}"}, fields)
+	assert.ErrorContains(t, err, "invalid numeric")
+}
+
+func TestDirStore_WritesNestedFiles(t *testing.T) {
+	ctx := context.Background()
+	store, err := OpenStore(ctx, t.TempDir())
+	require.NoError(t, err)
+
+	w, err := store.Create(ctx, "subscriptions/part-00000.avro")
+	require.NoError(t, err)
+	_, err = io.WriteString(w, "rows")
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	r, err := store.Open(ctx, "subscriptions/part-00000.avro")
+	require.NoError(t, err)
+	defer r.Close()
+	b, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, "rows", string(b))
+}
+
+func TestRestore_RequiresManifest(t *testing.T) {
+	_, err := Restore(context.Background(), nil, DirStore(t.TempDir()), RestoreOptions{}, nil)
+	assert.ErrorContains(t, err, "missing or incomplete")
+}
+
+func TestOpenStore_RejectsBucketlessLocation(t *testing.T) {
+	_, err := OpenStore(context.Background(), "gs:///snapshots")
+	assert.Error(t, err)
+}
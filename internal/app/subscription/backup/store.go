@@ -0,0 +1,106 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"google.golang.org/api/option"
+	storage "google.golang.org/api/storage/v1"
+)
+
+// Store holds the files of a snapshot
+type Store interface {
+	// Create opens a file for writing; it is complete once Close returns nil
+	Create(ctx context.Context, name string) (io.WriteCloser, error)
+	Open(ctx context.Context, name string) (io.ReadCloser, error)
+}
+
+// OpenStore returns the store at location: "gs://bucket/prefix" for Cloud Storage,
+// anything else for a local directory
+func OpenStore(ctx context.Context, location string, opts ...option.ClientOption) (Store, error) {
+	rest, ok := strings.CutPrefix(location, "gs://")
+	if !ok {
+		return DirStore(location), nil
+	}
+	bucket, prefix, _ := strings.Cut(rest, "/")
+	if bucket == "" {
+		return nil, fmt.Errorf("invalid Cloud Storage location %q", location)
+	}
+	svc, err := storage.NewService(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Cloud Storage client: %w", err)
+	}
+	return &GCSStore{objects: svc.Objects, bucket: bucket, prefix: strings.TrimSuffix(prefix, "/")}, nil
+}
+
+// DirStore keeps a snapshot in a local directory
+type DirStore string
+
+func (d DirStore) Create(ctx context.Context, name string) (io.WriteCloser, error) {
+	p := filepath.Join(string(d), filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(p), 0o700); err != nil {
+		return nil, err
+	}
+	return os.OpenFile(p, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+}
+
+func (d DirStore) Open(ctx context.Context, name string) (io.ReadCloser, error) {
+	return os.Open(filepath.Join(string(d), filepath.FromSlash(name)))
+}
+
+// GCSStore keeps a snapshot under a prefix of a Cloud Storage bucket. Credentials
+// come from Application Default Credentials.
+type GCSStore struct {
+	objects *storage.ObjectsService
+	bucket  string
+	prefix  string
+}
+
+func (g *GCSStore) object(name string) string {
+	if g.prefix == "" {
+		return name
+	}
+	return path.Join(g.prefix, name)
+}
+
+// Create streams the file to Cloud Storage as it is written
+func (g *GCSStore) Create(ctx context.Context, name string) (io.WriteCloser, error) {
+	pr, pw := io.Pipe()
+	w := &gcsWriter{pw: pw, done: make(chan error, 1)}
+	call := g.objects.Insert(g.bucket, &storage.Object{Name: g.object(name)}).Media(pr).Context(ctx)
+	go func() {
+		_, err := call.Do()
+		// Unblock the writer if the upload failed before reading everything
+		pr.CloseWithError(err)
+		w.done <- err
+	}()
+	return w, nil
+}
+
+func (g *GCSStore) Open(ctx context.Context, name string) (io.ReadCloser, error) {
+	resp, err := g.objects.Get(g.bucket, g.object(name)).Context(ctx).Download()
+	if err != nil {
+		return nil, fmt.Errorf("failed to download gs://%s/%s: %w", g.bucket, g.object(name), err)
+	}
+	return resp.Body, nil
+}
+
+type gcsWriter struct {
+	pw   *io.PipeWriter
+	done chan error
+}
+
+func (w *gcsWriter) Write(p []byte) (int, error) {
+	return w.pw.Write(p)
+}
+
+// Close finishes the upload and reports whether it succeeded
+func (w *gcsWriter) Close() error {
+	w.pw.Close()
+	return <-w.done
+}
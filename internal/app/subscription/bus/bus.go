@@ -0,0 +1,88 @@
+package bus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var (
+	ErrHandlerNotFound          = errors.New("no handler registered for command")
+	ErrHandlerAlreadyRegistered = errors.New("handler already registered for command")
+)
+
+// Command is a request struct that can be dispatched through the bus
+type Command interface {
+	CommandName() string
+}
+
+// HandlerFunc executes a single command and returns its result
+type HandlerFunc func(ctx context.Context, cmd Command) (any, error)
+
+// Handler is implemented by interactors that can be registered on the bus
+type Handler interface {
+	Handle(ctx context.Context, cmd Command) (any, error)
+}
+
+// Dispatcher sends commands to their handlers; *Bus implements it
+type Dispatcher interface {
+	Dispatch(ctx context.Context, cmd Command) (any, error)
+}
+
+var _ Dispatcher = (*Bus)(nil)
+
+// Middleware wraps a handler with cross-cutting behavior
+type Middleware func(next HandlerFunc) HandlerFunc
+
+// Bus dispatches commands to their registered handlers through a middleware chain
+type Bus struct {
+	mu         sync.RWMutex
+	handlers   map[string]HandlerFunc
+	middleware []Middleware
+}
+
+// New creates a command bus; middleware runs in the order given (first is outermost)
+func New(middleware ...Middleware) *Bus {
+	return &Bus{
+		handlers:   make(map[string]HandlerFunc),
+		middleware: middleware,
+	}
+}
+
+// Register binds a handler to a command name
+func (b *Bus) Register(name string, h Handler) error {
+	return b.RegisterFunc(name, h.Handle)
+}
+
+// RegisterFunc binds a handler function to a command name
+func (b *Bus) RegisterFunc(name string, h HandlerFunc) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.handlers[name]; ok {
+		return fmt.Errorf("%w: %s", ErrHandlerAlreadyRegistered, name)
+	}
+
+	// Wrap once at registration so dispatch doesn't rebuild the chain
+	wrapped := h
+	for i := len(b.middleware) - 1; i >= 0; i-- {
+		wrapped = b.middleware[i](wrapped)
+	}
+	b.handlers[name] = wrapped
+
+	return nil
+}
+
+// Dispatch routes a command to its handler
+func (b *Bus) Dispatch(ctx context.Context, cmd Command) (any, error) {
+	b.mu.RLock()
+	h, ok := b.handlers[cmd.CommandName()]
+	b.mu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrHandlerNotFound, cmd.CommandName())
+	}
+
+	return h(ctx, cmd)
+}
@@ -0,0 +1,213 @@
+package bus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/audit"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/logging"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/recovery"
+)
+
+type testCommand struct {
+	key     string
+	invalid bool
+}
+
+func (c testCommand) CommandName() string    { return "test.command" }
+func (c testCommand) IdempotencyKey() string { return c.key }
+
+func (c testCommand) Validate() error {
+	if c.invalid {
+		return errors.New("invalid command")
+	}
+	return nil
+}
+
+type recordingMetrics struct {
+	names []string
+	errs  []error
+}
+
+func (r *recordingMetrics) ObserveCommand(name string, duration time.Duration, err error) {
+	r.names = append(r.names, name)
+	r.errs = append(r.errs, err)
+}
+
+func TestBus_DispatchRoutesToHandler(t *testing.T) {
+	b := New()
+	require.NoError(t, b.RegisterFunc("test.command", func(ctx context.Context, cmd Command) (any, error) {
+		return "handled", nil
+	}))
+
+	result, err := b.Dispatch(context.Background(), testCommand{})
+
+	require.NoError(t, err)
+	assert.Equal(t, "handled", result)
+}
+
+func TestBus_UnknownCommand(t *testing.T) {
+	b := New()
+
+	result, err := b.Dispatch(context.Background(), testCommand{})
+
+	assert.ErrorIs(t, err, ErrHandlerNotFound)
+	assert.Nil(t, result)
+}
+
+func TestBus_DuplicateRegistration(t *testing.T) {
+	b := New()
+	h := func(ctx context.Context, cmd Command) (any, error) { return nil, nil }
+
+	require.NoError(t, b.RegisterFunc("test.command", h))
+	assert.ErrorIs(t, b.RegisterFunc("test.command", h), ErrHandlerAlreadyRegistered)
+}
+
+func TestBus_MiddlewareOrder(t *testing.T) {
+	var order []string
+	trace := func(name string) Middleware {
+		return func(next HandlerFunc) HandlerFunc {
+			return func(ctx context.Context, cmd Command) (any, error) {
+				order = append(order, name)
+				return next(ctx, cmd)
+			}
+		}
+	}
+
+	b := New(trace("outer"), trace("inner"))
+	require.NoError(t, b.RegisterFunc("test.command", func(ctx context.Context, cmd Command) (any, error) {
+		order = append(order, "handler")
+		return nil, nil
+	}))
+
+	_, err := b.Dispatch(context.Background(), testCommand{})
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"outer", "inner", "handler"}, order)
+}
+
+func TestBus_ValidationStopsInvalidCommands(t *testing.T) {
+	called := false
+	b := New(Validation())
+	require.NoError(t, b.RegisterFunc("test.command", func(ctx context.Context, cmd Command) (any, error) {
+		called = true
+		return nil, nil
+	}))
+
+	_, err := b.Dispatch(context.Background(), testCommand{invalid: true})
+
+	assert.EqualError(t, err, "invalid command")
+	assert.False(t, called)
+}
+
+func TestBus_MetricsRecordsOutcome(t *testing.T) {
+	recorder := &recordingMetrics{}
+	handlerErr := errors.New("boom")
+	b := New(Metrics(recorder))
+	require.NoError(t, b.RegisterFunc("test.command", func(ctx context.Context, cmd Command) (any, error) {
+		return nil, handlerErr
+	}))
+
+	_, err := b.Dispatch(context.Background(), testCommand{})
+
+	assert.ErrorIs(t, err, handlerErr)
+	assert.Equal(t, []string{"test.command"}, recorder.names)
+	assert.Equal(t, []error{handlerErr}, recorder.errs)
+}
+
+func TestBus_IdempotencyReturnsStoredResult(t *testing.T) {
+	calls := 0
+	b := New(Idempotency(NewMemoryIdempotencyStore()))
+	require.NoError(t, b.RegisterFunc("test.command", func(ctx context.Context, cmd Command) (any, error) {
+		calls++
+		return calls, nil
+	}))
+
+	first, err := b.Dispatch(context.Background(), testCommand{key: "k-1"})
+	require.NoError(t, err)
+	second, err := b.Dispatch(context.Background(), testCommand{key: "k-1"})
+	require.NoError(t, err)
+	third, err := b.Dispatch(context.Background(), testCommand{key: "k-2"})
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, first)
+	assert.Equal(t, 1, second)
+	assert.Equal(t, 2, third)
+	assert.Equal(t, 2, calls)
+}
+
+type nopMetrics struct{}
+
+func (nopMetrics) IncCounter(string, map[string]string)                {}
+func (nopMetrics) ObserveHistogram(string, float64, map[string]string) {}
+
+func TestBus_RecoveryTurnsPanicIntoError(t *testing.T) {
+	b := New(Recovery(logging.Discard(), nopMetrics{}))
+	require.NoError(t, b.RegisterFunc("test.command", func(ctx context.Context, cmd Command) (any, error) {
+		panic("handler bug")
+	}))
+
+	result, err := b.Dispatch(context.Background(), testCommand{})
+	assert.Nil(t, result)
+	assert.ErrorIs(t, err, recovery.ErrPanic)
+}
+
+type privilegedCommand struct{ CustomerID string }
+
+func (privilegedCommand) CommandName() string { return "test.privileged" }
+func (privilegedCommand) Privileged()         {}
+
+type memoryAuditLog struct{ entries []contracts.AuditEntry }
+
+func (m *memoryAuditLog) Record(ctx context.Context, entry contracts.AuditEntry) error {
+	m.entries = append(m.entries, entry)
+	return nil
+}
+
+func TestBus_AuditRecordsPrivilegedCommands(t *testing.T) {
+	log := &memoryAuditLog{}
+	recorder := audit.NewRecorder(log, logging.Discard(), func(err error) bool { return errors.Is(err, ErrUnauthorized) })
+	authorizer := authorizerFunc(func(ctx context.Context, cmd Command) error {
+		if c, ok := cmd.(privilegedCommand); ok && c.CustomerID == "forbidden" {
+			return fmt.Errorf("%w: %s", ErrUnauthorized, cmd.CommandName())
+		}
+		return nil
+	})
+	b := New(Recovery(logging.Discard(), nopMetrics{}), Audit(recorder), Authorization(authorizer))
+	require.NoError(t, b.RegisterFunc("test.privileged", func(ctx context.Context, cmd Command) (any, error) {
+		if cmd.(privilegedCommand).CustomerID == "panics" {
+			panic("handler bug")
+		}
+		return "done", nil
+	}))
+	require.NoError(t, b.RegisterFunc("test.command", func(ctx context.Context, cmd Command) (any, error) { return nil, nil }))
+
+	ctx := audit.WithPrincipal(context.Background(), audit.Principal{ID: "ops@example.com", SourceIP: "10.0.0.7"})
+	_, err := b.Dispatch(ctx, privilegedCommand{CustomerID: "cust-1"})
+	require.NoError(t, err)
+	_, err = b.Dispatch(ctx, privilegedCommand{CustomerID: "forbidden"})
+	assert.ErrorIs(t, err, ErrUnauthorized)
+	_, err = b.Dispatch(ctx, privilegedCommand{CustomerID: "panics"})
+	assert.ErrorIs(t, err, recovery.ErrPanic)
+	_, err = b.Dispatch(ctx, testCommand{})
+	require.NoError(t, err)
+
+	require.Len(t, log.entries, 3, "unprivileged commands are not audited")
+	assert.Equal(t, contracts.AuditSucceeded, log.entries[0].Outcome)
+	assert.Equal(t, "ops@example.com", log.entries[0].Principal)
+	assert.Equal(t, "test.privileged", log.entries[0].Action)
+	assert.Equal(t, contracts.AuditDenied, log.entries[1].Outcome)
+	assert.Equal(t, contracts.AuditFailed, log.entries[2].Outcome)
+	assert.Contains(t, log.entries[2].Error, "handler bug")
+}
+
+type authorizerFunc func(ctx context.Context, cmd Command) error
+
+func (f authorizerFunc) Authorize(ctx context.Context, cmd Command) error { return f(ctx, cmd) }
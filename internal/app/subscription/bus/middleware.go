@@ -0,0 +1,207 @@
+package bus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/audit"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/recovery"
+)
+
+// Validatable is implemented by commands that can check their own input
+type Validatable interface {
+	Validate() error
+}
+
+// Idempotent is implemented by commands carrying a caller-supplied idempotency key
+type Idempotent interface {
+	IdempotencyKey() string
+}
+
+// ErrUnauthorized is wrapped by authorizers refusing a command
+var ErrUnauthorized = errors.New("caller is not authorized for command")
+
+// Privileged is implemented by admin commands, which are recorded in the security
+// audit trail
+type Privileged interface {
+	Privileged()
+}
+
+// Authorizer decides whether the caller in ctx may execute a command. A refusal
+// should wrap ErrUnauthorized so it is audited as denied.
+type Authorizer interface {
+	Authorize(ctx context.Context, cmd Command) error
+}
+
+// MetricsRecorder receives the outcome and duration of every dispatched command
+type MetricsRecorder interface {
+	ObserveCommand(name string, duration time.Duration, err error)
+}
+
+// IdempotencyStore remembers results of idempotent commands
+type IdempotencyStore interface {
+	Get(ctx context.Context, key string) (any, bool, error)
+	Put(ctx context.Context, key string, result any) error
+}
+
+// Validation rejects commands whose Validate method returns an error
+func Validation() Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, cmd Command) (any, error) {
+			if v, ok := cmd.(Validatable); ok {
+				if err := v.Validate(); err != nil {
+					return nil, err
+				}
+			}
+			return next(ctx, cmd)
+		}
+	}
+}
+
+// Logging emits one structured log line per dispatched command
+func Logging(logger *slog.Logger) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, cmd Command) (any, error) {
+			start := time.Now()
+			result, err := next(ctx, cmd)
+
+			attrs := []any{
+				slog.String("command", cmd.CommandName()),
+				slog.Duration("duration", time.Since(start)),
+			}
+			if err != nil {
+				logger.ErrorContext(ctx, "command failed", append(attrs, slog.Any("error", err))...)
+			} else {
+				logger.InfoContext(ctx, "command handled", attrs...)
+			}
+
+			return result, err
+		}
+	}
+}
+
+// Metrics reports the duration and outcome of every dispatched command
+func Metrics(recorder MetricsRecorder) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, cmd Command) (any, error) {
+			start := time.Now()
+			result, err := next(ctx, cmd)
+			recorder.ObserveCommand(cmd.CommandName(), time.Since(start), err)
+			return result, err
+		}
+	}
+}
+
+// Recovery turns a panicking handler into a recovery.PanicError, so one bad command
+// fails on its own instead of crashing the process. Register it outermost.
+func Recovery(logger *slog.Logger, metrics contracts.Metrics) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, cmd Command) (any, error) {
+			var result any
+			err := recovery.Do(ctx, logger, metrics, cmd.CommandName(), func() error {
+				var err error
+				result, err = next(ctx, cmd)
+				return err
+			})
+			return result, err
+		}
+	}
+}
+
+// Audit records every Privileged command in the audit trail with its caller, a hash
+// of the command and its outcome, including refusals and panics. Register it just
+// inside Recovery so nothing it wraps escapes the trail. A failure to record is
+// logged by the recorder and does not change the command's result, which has
+// already taken effect.
+func Audit(recorder *audit.Recorder) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, cmd Command) (result any, err error) {
+			if _, ok := cmd.(Privileged); !ok {
+				return next(ctx, cmd)
+			}
+
+			defer func() {
+				if p := recover(); p != nil {
+					recorder.Record(ctx, cmd.CommandName(), cmd, fmt.Errorf("panic: %v", p))
+					panic(p)
+				}
+			}()
+
+			result, err = next(ctx, cmd)
+			recorder.Record(ctx, cmd.CommandName(), cmd, err)
+			return result, err
+		}
+	}
+}
+
+// Authorization asks the authorizer before letting a command through
+func Authorization(authorizer Authorizer) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, cmd Command) (any, error) {
+			if err := authorizer.Authorize(ctx, cmd); err != nil {
+				return nil, err
+			}
+			return next(ctx, cmd)
+		}
+	}
+}
+
+// Idempotency returns the stored result for a repeated idempotency key instead of
+// executing the command again. Only successful results are stored.
+func Idempotency(store IdempotencyStore) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, cmd Command) (any, error) {
+			ic, ok := cmd.(Idempotent)
+			if !ok || ic.IdempotencyKey() == "" {
+				return next(ctx, cmd)
+			}
+
+			key := cmd.CommandName() + ":" + ic.IdempotencyKey()
+			if result, found, err := store.Get(ctx, key); err != nil {
+				return nil, err
+			} else if found {
+				return result, nil
+			}
+
+			result, err := next(ctx, cmd)
+			if err != nil {
+				return nil, err
+			}
+
+			if err := store.Put(ctx, key, result); err != nil {
+				return nil, err
+			}
+			return result, nil
+		}
+	}
+}
+
+// MemoryIdempotencyStore is an in-process IdempotencyStore for single-instance deployments and tests
+type MemoryIdempotencyStore struct {
+	mu      sync.Mutex
+	results map[string]any
+}
+
+// NewMemoryIdempotencyStore creates an empty in-memory idempotency store
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{results: make(map[string]any)}
+}
+
+func (s *MemoryIdempotencyStore) Get(ctx context.Context, key string) (any, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result, ok := s.results[key]
+	return result, ok, nil
+}
+
+func (s *MemoryIdempotencyStore) Put(ctx context.Context, key string, result any) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.results[key] = result
+	return nil
+}
@@ -0,0 +1,60 @@
+package contracts
+
+import (
+	"context"
+	"time"
+)
+
+// AuditOutcome is how a privileged operation ended
+type AuditOutcome string
+
+const (
+	AuditSucceeded AuditOutcome = "SUCCEEDED"
+	AuditDenied    AuditOutcome = "DENIED"
+	AuditFailed    AuditOutcome = "FAILED"
+)
+
+// AuditEntry records who performed a privileged operation and how it ended. The
+// request is kept only as a hash, so the log proves what was asked without holding
+// customer data.
+type AuditEntry struct {
+	ID            string
+	Action        string
+	Principal     string
+	SourceIP      string
+	PayloadHash   string
+	Outcome       AuditOutcome
+	Error         string
+	CorrelationID string
+	OccurredAt    time.Time
+}
+
+// AuditLog persists the security audit trail of privileged operations. It is kept
+// apart from the domain's own audit records, such as the purge audit.
+type AuditLog interface {
+	Record(ctx context.Context, entry AuditEntry) error
+}
+
+// AuditLogReader pages through the audit trail for export, oldest first. Entries
+// after the given time and ID are returned, so an export resumes where the last one
+// stopped.
+type AuditLogReader interface {
+	ListAuditEntries(ctx context.Context, afterTime time.Time, afterID string, limit int) ([]AuditEntry, error)
+}
+
+// AuditLogPartitioner opens a snapshot of the audit trail for bulk export. The
+// snapshot holds the entries after the given time and ID, split into partitions that
+// are read independently, so a large export reads them in parallel.
+type AuditLogPartitioner interface {
+	PartitionAuditEntries(ctx context.Context, afterTime time.Time, afterID string) (AuditLogSnapshot, error)
+}
+
+// AuditLogSnapshot is the audit trail as of one timestamp. Its partitions may be read
+// concurrently, each in no particular order; Close releases the snapshot once every
+// partition has been read.
+type AuditLogSnapshot interface {
+	Timestamp() time.Time
+	Partitions() int
+	ReadPartition(ctx context.Context, partition int, fn func(AuditEntry) error) error
+	Close()
+}
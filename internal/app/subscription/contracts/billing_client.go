@@ -1,9 +1,86 @@
 package contracts
 
-import "context"
+import (
+	"context"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+// CreateCustomerRequest describes a customer to provision in the billing provider
+type CreateCustomerRequest struct {
+	CustomerID string
+	Email      string
+	Name       string
+}
+
+// ChargeRequest describes a charge against a customer's payment method
+type ChargeRequest struct {
+	CustomerID     string
+	SubscriptionID string
+	Amount         int64  // cents
+	Currency       string // ISO 4217
+	IdempotencyKey string
+}
+
+// RefundReasonCancellation marks refunds issued for the unused part of a cancelled period
+const RefundReasonCancellation = "cancellation"
+
+// RefundReasonCreditNote marks refunds that settle a credit note
+const RefundReasonCreditNote = "credit_note"
+
+// RefundReasonAddOnReversal marks refunds of an add-on charge whose attach failed to commit
+const RefundReasonAddOnReversal = "add_on_reversal"
+
+// RefundRequest describes a refund with enough context for the billing side to
+// reconcile it against the subscription and trace it back to the request
+type RefundRequest struct {
+	SubscriptionID string
+	CustomerID     string
+	Amount         int64  // cents
+	Currency       string // ISO 4217; must match the currency of the charge being refunded
+	Reason         string
+	CorrelationID  string
+	IdempotencyKey string
+}
+
+// RefundOutcome is the billing provider's view of a refund
+type RefundOutcome struct {
+	Status        domain.RefundStatus
+	FailureReason string
+}
 
 // BillingClient defines the interface for external billing service interactions
 type BillingClient interface {
 	ValidateCustomer(ctx context.Context, customerID string) error
-	ProcessRefund(ctx context.Context, amount int64) error
+	// CreateCustomer provisions a customer in the billing provider. A customer that already
+	// exists is not an error, so callers can use it to ensure the customer exists.
+	CreateCustomer(ctx context.Context, req CreateCustomerRequest) error
+	// ProcessRefund submits a refund and returns the provider's refund ID. Refunds settle
+	// asynchronously: success means the provider accepted the refund, not that it paid out.
+	// The billing API deduplicates requests with the same idempotency key. A refund in a
+	// different currency than the original charge fails with a *domain.CurrencyMismatchError.
+	ProcessRefund(ctx context.Context, req RefundRequest) (string, error)
+	// GetRefundStatus returns the current outcome of a refund submitted earlier
+	GetRefundStatus(ctx context.Context, providerRefundID string) (RefundOutcome, error)
+	// ChargeCustomer charges the customer's payment method. A charge the provider refused
+	// fails with domain.ErrPaymentDeclined; any other error leaves the outcome unknown, so
+	// retry it with the same idempotency key rather than treating it as declined. A charge
+	// held for strong customer authentication fails with a *domain.AuthenticationRequiredError,
+	// which is also ErrPaymentDeclined.
+	ChargeCustomer(ctx context.Context, req ChargeRequest) error
+	// GetPaymentMethodStatus returns the customer's default payment method. A customer
+	// without one gets a PaymentMethod that isn't Valid rather than an error.
+	GetPaymentMethodStatus(ctx context.Context, customerID string) (domain.PaymentMethod, error)
+}
+
+// BillingPinger checks that a billing provider responds, without side effects. The
+// provider clients implement it; the decorators don't, so a health check never
+// counts against a circuit breaker or retry budget.
+type BillingPinger interface {
+	Ping(ctx context.Context) error
+}
+
+// BillingResolver picks the billing backend responsible for a subscription
+type BillingResolver interface {
+	Resolve(ctx context.Context, planID, customerID string) (BillingClient, error)
 }
@@ -0,0 +1,28 @@
+package contracts
+
+import (
+	"context"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+// ProviderSubscription is the billing provider's view of one of our subscriptions
+type ProviderSubscription struct {
+	SubscriptionID string
+	CustomerID     string
+	Active         bool
+}
+
+// BillingRecords defines read access to the billing provider's own records, used for reconciliation
+type BillingRecords interface {
+	// ListSubscriptions returns one page of provider subscriptions and the token for the next page ("" when done)
+	ListSubscriptions(ctx context.Context, pageToken string) ([]ProviderSubscription, string, error)
+	CancelProviderSubscription(ctx context.Context, subscriptionID string) error
+}
+
+// BillingCatalog defines read access to the billing provider's product catalog, used to
+// import our plans from it
+type BillingCatalog interface {
+	// ListPlans returns one page of catalog plans and the token for the next page ("" when done)
+	ListPlans(ctx context.Context, pageToken string) ([]domain.CatalogPlan, string, error)
+}
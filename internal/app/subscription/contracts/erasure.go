@@ -0,0 +1,23 @@
+package contracts
+
+import "context"
+
+// ErasureRepository defines the persistence operations behind right-to-erasure requests
+type ErasureRepository interface {
+	// CountLiveByCustomer counts subscriptions that are not yet cancelled
+	CountLiveByCustomer(ctx context.Context, customerID string) (int64, error)
+	// TombstoneCustomer replaces the customer ID on every row that holds it, keeping the rows,
+	// and reports the rows changed per table
+	TombstoneCustomer(ctx context.Context, customerID, tombstone string) ([]TombstonedRows, error)
+}
+
+// TombstonedRows is the number of rows tombstoned in one table
+type TombstonedRows struct {
+	Table        string
+	RowsAffected int64
+}
+
+// ReportSigner signs compliance reports so their integrity can be verified later
+type ReportSigner interface {
+	Sign(payload []byte) (string, error)
+}
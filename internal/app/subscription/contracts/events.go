@@ -0,0 +1,15 @@
+package contracts
+
+import (
+	"context"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+// EventPublisher announces subscription changes to the services that follow them. The
+// use cases publish once the change is saved, so a publisher can't undo it; it
+// handles its own failures, as an After hook does.
+type EventPublisher interface {
+	PublishCreated(ctx context.Context, event *domain.SubscriptionCreatedEvent)
+	PublishCancelled(ctx context.Context, event *domain.SubscriptionCancelledEvent)
+}
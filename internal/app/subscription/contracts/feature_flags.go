@@ -0,0 +1,18 @@
+package contracts
+
+import "context"
+
+// FeatureFlags decides whether a gated behavior is on for one evaluation, so new
+// billing behavior can be rolled out per customer or by percentage and rolled back
+// without a deploy. Implementations fail closed: a flag that can't be evaluated is off.
+type FeatureFlags interface {
+	Enabled(ctx context.Context, flag string, target FlagTarget) bool
+}
+
+// FlagTarget is what a flag is evaluated for. CustomerID is the tenant: percentage
+// rollouts bucket by it, so every subscription of a customer gets the same answer.
+type FlagTarget struct {
+	CustomerID     string
+	SubscriptionID string
+	PlanID         string
+}
@@ -0,0 +1,26 @@
+package contracts
+
+import (
+	"context"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+// SubscriptionHooks lets a deployment add its own business rules to the subscription
+// lifecycle, such as syncing to a CRM, without forking the interactors. Hooks are
+// registered in the composition root.
+//
+// A Before hook runs once the domain has computed the change, before anything is
+// charged or saved; returning an error vetoes the change, and the use case fails with
+// domain.ErrRejectedByHook wrapping it. An After hook runs once the change is saved,
+// so it can't undo it; it handles its own failures.
+type SubscriptionHooks interface {
+	BeforeCreate(ctx context.Context, sub *domain.Subscription, event *domain.SubscriptionCreatedEvent) error
+	AfterCreate(ctx context.Context, sub *domain.Subscription, event *domain.SubscriptionCreatedEvent)
+	BeforeCancel(ctx context.Context, sub *domain.Subscription, event *domain.SubscriptionCancelledEvent) error
+	AfterCancel(ctx context.Context, sub *domain.Subscription, event *domain.SubscriptionCancelledEvent)
+	BeforeRenew(ctx context.Context, sub *domain.Subscription, event *domain.SubscriptionRenewedEvent) error
+	AfterRenew(ctx context.Context, sub *domain.Subscription, event *domain.SubscriptionRenewedEvent)
+	BeforePlanChange(ctx context.Context, sub *domain.Subscription, event *domain.SubscriptionPlanChangedEvent) error
+	AfterPlanChange(ctx context.Context, sub *domain.Subscription, event *domain.SubscriptionPlanChangedEvent)
+}
@@ -0,0 +1,13 @@
+package contracts
+
+import (
+	"context"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+// InvoiceItemsSource provides what a subscription's next invoice bills besides the
+// plan's base price: seats, add-ons, metered usage so far, discounts and the tax rate
+type InvoiceItemsSource interface {
+	UpcomingItems(ctx context.Context, sub *domain.Subscription) (domain.InvoiceItems, error)
+}
@@ -0,0 +1,22 @@
+package contracts
+
+import (
+	"context"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+// UsageAlertNotifier tells a customer their usage has reached an alert threshold
+type UsageAlertNotifier interface {
+	NotifyUsageThreshold(ctx context.Context, event *domain.UsageThresholdReachedEvent) error
+}
+
+// AuthenticationNotifier asks a customer to authenticate a charge the billing provider held
+type AuthenticationNotifier interface {
+	NotifyAuthenticationRequired(ctx context.Context, event *domain.AuthenticationRequiredEvent) error
+}
+
+// RenewalNoticeNotifier tells a customer their subscription is about to renew
+type RenewalNoticeNotifier interface {
+	NotifyRenewalUpcoming(ctx context.Context, event *domain.RenewalUpcomingEvent) error
+}
@@ -0,0 +1,14 @@
+package contracts
+
+import (
+	"context"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+// PortalTokens turns portal sessions into signed bearer tokens and back. Decode fails
+// with domain.ErrInvalidPortalToken for a token it didn't sign; it doesn't check expiry.
+type PortalTokens interface {
+	Encode(ctx context.Context, session *domain.PortalSession) (string, error)
+	Decode(ctx context.Context, token string) (*domain.PortalSession, error)
+}
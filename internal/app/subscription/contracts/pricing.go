@@ -0,0 +1,19 @@
+package contracts
+
+import (
+	"context"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+// PricingSource provides the discounts a subscription's charges get and the policy they
+// stack under. Every charge, proration and refund of a subscription resolves it.
+type PricingSource interface {
+	PricingFor(ctx context.Context, sub *domain.Subscription) (domain.Pricing, error)
+}
+
+// BillingCycleSource provides how long a subscription's billing periods last, which
+// its refunds are prorated over
+type BillingCycleSource interface {
+	CycleFor(ctx context.Context, sub *domain.Subscription) (domain.BillingCycle, error)
+}
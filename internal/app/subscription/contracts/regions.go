@@ -0,0 +1,9 @@
+package contracts
+
+import "context"
+
+// CustomerRegionSource returns the region a customer is billed in, such as an ISO 3166
+// country or subdivision code. Regions decide which legal requirements apply.
+type CustomerRegionSource interface {
+	RegionOf(ctx context.Context, customerID string) (string, error)
+}
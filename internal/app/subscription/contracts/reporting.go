@@ -0,0 +1,74 @@
+package contracts
+
+import (
+	"context"
+	"time"
+)
+
+// PlanCount is the number of active subscriptions on a plan
+type PlanCount struct {
+	PlanID string
+	Active int64
+}
+
+// DailyCount is the number of subscriptions started and cancelled on a UTC day
+type DailyCount struct {
+	Day       time.Time // midnight UTC
+	New       int64
+	Cancelled int64
+}
+
+// RefundTotal sums the refunds in one currency and status
+type RefundTotal struct {
+	Currency    string
+	Status      string
+	Count       int64
+	AmountCents int64
+}
+
+// Aggregates are the dashboard figures kept in the reporting projection
+type Aggregates struct {
+	ActiveByPlan []PlanCount
+	Daily        []DailyCount // oldest first
+	Refunds      []RefundTotal
+	RefreshedAt  time.Time
+	ReadAt       time.Time // the commit timestamp every figure was read at
+}
+
+// Snapshot chooses the commit timestamp a report reads at. All of a report's queries
+// run in one read-only transaction at that timestamp, so its figures agree with each
+// other, and reports read at the same timestamp agree across exports. The zero
+// Snapshot reads the latest committed data.
+type Snapshot struct {
+	At        time.Time     // read as of exactly this time
+	Staleness time.Duration // read this far in the past; used when At is zero
+}
+
+// ReportingRepository computes aggregates from the operational tables and keeps the
+// latest in the reporting projection, so readers never scan the operational tables
+type ReportingRepository interface {
+	// ComputeAggregates scans the operational tables at snapshot; Daily covers days
+	// from since
+	ComputeAggregates(ctx context.Context, since time.Time, snapshot Snapshot) (*Aggregates, error)
+	// SaveAggregates replaces the projection
+	SaveAggregates(ctx context.Context, aggregates *Aggregates) error
+	// LoadAggregates reads the projection, or returns domain.ErrAggregatesNotReady
+	// before the first refresh
+	LoadAggregates(ctx context.Context) (*Aggregates, error)
+}
+
+// CohortCount is the number of subscriptions that started in one UTC month and were
+// cancelled in another
+type CohortCount struct {
+	SignupMonth   time.Time // first of the month, UTC
+	CancelMonth   time.Time // first of the month, UTC; zero while not cancelled
+	Subscriptions int64
+}
+
+// CohortRepository counts subscriptions by signup and cancellation month for
+// cohort retention
+type CohortRepository interface {
+	// CountCohorts scans subscriptions started from since at snapshot, and returns the
+	// commit timestamp it read at
+	CountCohorts(ctx context.Context, since time.Time, snapshot Snapshot) ([]CohortCount, time.Time, error)
+}
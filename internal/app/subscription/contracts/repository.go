@@ -2,14 +2,294 @@ package contracts
 
 import (
 	"context"
+	"time"
 
 	"cloud.google.com/go/spanner"
 	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
 )
 
+// Mutation is a write returned by a repository's Save. Use cases collect them in a
+// UnitOfWork, committed once through a repository's Apply, and refer to them through
+// this alias, so they don't import Spanner.
+type Mutation = spanner.Mutation
+
 // SubscriptionRepository defines the interface for subscription persistence
 type SubscriptionRepository interface {
-	Save(ctx context.Context, sub *domain.Subscription) (*spanner.Mutation, error)
+	// Save returns the mutation writing the subscription at its next version, and the
+	// version it was read at, which the commit checks; add both with SaveChecked
+	Save(ctx context.Context, sub *domain.Subscription) (*spanner.Mutation, VersionCheck, error)
+	FindByID(ctx context.Context, id string) (*domain.Subscription, error)
+	Apply(ctx context.Context, mutations ...*spanner.Mutation) error
+	// ApplyChecked applies the mutations once the subscriptions checked are still at
+	// their versions, and otherwise fails with domain.ErrConcurrentModification
+	ApplyChecked(ctx context.Context, checks []VersionCheck, mutations ...*spanner.Mutation) error
+}
+
+// SubscriptionTransaction reads and writes subscriptions within one read-write
+// transaction. It is a CheckingCommitter, so a use case commits its unit of work
+// through it.
+type SubscriptionTransaction interface {
+	// FindByID reads a subscription, which no other transaction can change until this
+	// one ends
 	FindByID(ctx context.Context, id string) (*domain.Subscription, error)
+	// Apply buffers the mutations, which are written when the transaction commits
+	Apply(ctx context.Context, mutations ...*spanner.Mutation) error
+	// ApplyChecked buffers the mutations once the subscriptions checked are still at
+	// their versions, and otherwise fails with domain.ErrConcurrentModification
+	ApplyChecked(ctx context.Context, checks []VersionCheck, mutations ...*spanner.Mutation) error
+}
+
+// TransactionalSubscriptionRepository is a SubscriptionRepository that can also load,
+// check and write subscriptions atomically, for changes that must not act on a status
+// another request is changing at the same time
+type TransactionalSubscriptionRepository interface {
+	SubscriptionRepository
+	// RunInTransaction runs fn in a read-write transaction, which commits what fn
+	// applied when it returns nil and nothing when it returns an error, returned as is.
+	// fn runs again when the transaction is aborted, so it must not act outside it.
+	RunInTransaction(ctx context.Context, fn func(ctx context.Context, tx SubscriptionTransaction) error) error
+}
+
+// BulkCancellationRepository defines the reads behind bulk cancellation, which loads
+// subscriptions a batch at a time rather than one by one
+type BulkCancellationRepository interface {
+	// FindByIDs returns those of the subscriptions that exist, in no particular order
+	FindByIDs(ctx context.Context, ids []string) ([]*domain.Subscription, error)
+	// FindIDsByCustomer returns the IDs of the customer's subscriptions that aren't
+	// cancelled, ordered by ID
+	FindIDsByCustomer(ctx context.Context, customerID string) ([]string, error)
+	// FindIDsByPlan returns the IDs of the plan's subscriptions that aren't cancelled,
+	// ordered by ID
+	FindIDsByPlan(ctx context.Context, planID string) ([]string, error)
+}
+
+// ChurnRepository defines the reads behind churn analysis
+type ChurnRepository interface {
+	// CountCancellationReasons groups the subscriptions cancelled from since by UTC
+	// month of cancellation and reason code; those cancelled without a reason are
+	// counted under the empty code
+	CountCancellationReasons(ctx context.Context, since time.Time) ([]CancellationReasonCount, error)
+}
+
+// CancellationReasonCount is how many subscriptions cancelled in one UTC month gave a
+// reason
+type CancellationReasonCount struct {
+	Month         time.Time // first of the month, UTC
+	Reason        domain.CancellationReasonCode
+	Cancellations int64
+}
+
+// SubscriptionScanRepository streams subscriptions to batch jobs that visit more of
+// them than they could hold in memory at once
+type SubscriptionScanRepository interface {
+	// ForEachByStatus calls fn with each subscription of the status, in no particular
+	// order, stopping at fn's first error, which it returns
+	ForEachByStatus(ctx context.Context, status domain.SubscriptionStatus, fn func(*domain.Subscription) error) error
+}
+
+// SubscriptionSummary is a subscription as listed, with the columns the listing index stores
+type SubscriptionSummary struct {
+	ID         string
+	CustomerID string
+	PlanID     string
+	Price      int64 // cents
+	Status     domain.SubscriptionStatus
+	StartDate  time.Time
+}
+
+// ListingCursor is the position after the last subscription of a page. Listings are
+// ordered by start date then ID, neither of which changes, so rows created or updated
+// while a customer pages through are neither skipped nor repeated.
+type ListingCursor struct {
+	StartDate time.Time
+	ID        string
+}
+
+// SubscriptionListingRepository defines the paginated listing of a customer's subscriptions
+type SubscriptionListingRepository interface {
+	// ListByCustomer returns up to limit of the customer's subscriptions after the
+	// cursor, oldest first; an empty status lists them all
+	ListByCustomer(ctx context.Context, customerID string, status domain.SubscriptionStatus, after ListingCursor, limit int) ([]SubscriptionSummary, error)
+}
+
+// ScheduledCancellationRepository defines the queries used by the cancellation scheduler
+type ScheduledCancellationRepository interface {
+	FindDueForCancellation(ctx context.Context, now time.Time, limit int) ([]*domain.Subscription, error)
+}
+
+// PaymentMethodCheckRepository defines the queries used by the payment method checker
+type PaymentMethodCheckRepository interface {
+	FindRenewingUnflagged(ctx context.Context, renewsBefore time.Time, billingCycleDays int64, limit int) ([]*domain.Subscription, error)
+}
+
+// RenewalNoticeRepository defines the queries used by the renewal notice scheduler
+type RenewalNoticeRepository interface {
+	FindRenewingUnnoticed(ctx context.Context, renewsBefore time.Time, billingCycleDays int64, limit int) ([]*domain.Subscription, error)
+}
+
+// RefundRepository defines the interface for refund persistence
+type RefundRepository interface {
+	Save(ctx context.Context, refund *domain.Refund) (*spanner.Mutation, error)
+	FindByID(ctx context.Context, id string) (*domain.Refund, error)
+	FindByProviderRefundID(ctx context.Context, providerRefundID string) (*domain.Refund, error)
+	FindPending(ctx context.Context, requestedBefore time.Time, limit int) ([]*domain.Refund, error)
 	Apply(ctx context.Context, mutations ...*spanner.Mutation) error
 }
+
+// RefundOutboxRepository defines the interface for refunds queued to be sent to the
+// billing provider after the cancellation that owes them is committed
+type RefundOutboxRepository interface {
+	Save(ctx context.Context, refund *domain.QueuedRefund) (*spanner.Mutation, error)
+	// Delete returns a mutation removing a queued refund, applied once it has been sent
+	Delete(ctx context.Context, id string) (*spanner.Mutation, error)
+	FindByID(ctx context.Context, id string) (*domain.QueuedRefund, error)
+	// FindDue returns queued refunds whose next attempt is at or before now, oldest first
+	FindDue(ctx context.Context, now time.Time, limit int) ([]*domain.QueuedRefund, error)
+	// FindByCustomer returns the refunds still owed to the customer, with their failed
+	// attempts, oldest first
+	FindByCustomer(ctx context.Context, customerID string) ([]*domain.QueuedRefund, error)
+	Apply(ctx context.Context, mutations ...*spanner.Mutation) error
+}
+
+// ChargeAuthenticationRepository defines the interface for persisting charges held for
+// strong customer authentication
+type ChargeAuthenticationRepository interface {
+	Save(ctx context.Context, authentication *domain.ChargeAuthentication) (*spanner.Mutation, error)
+	FindByProviderPaymentID(ctx context.Context, providerPaymentID string) (*domain.ChargeAuthentication, error)
+	Apply(ctx context.Context, mutations ...*spanner.Mutation) error
+}
+
+// CreditNoteRepository defines the interface for credit note persistence
+type CreditNoteRepository interface {
+	Save(ctx context.Context, note *domain.CreditNote) (*spanner.Mutation, error)
+	FindByID(ctx context.Context, id string) (*domain.CreditNote, error)
+	// CreditedForInvoice sums the credit notes issued against an invoice so far
+	CreditedForInvoice(ctx context.Context, invoiceID string) (int64, error)
+	Apply(ctx context.Context, mutations ...*spanner.Mutation) error
+}
+
+// CreditBalanceRepository defines the interface for customer credit balances. Its
+// mutations are applied with those of the record that moved the balance.
+type CreditBalanceRepository interface {
+	Save(ctx context.Context, entry *domain.CreditEntry) (*spanner.Mutation, error)
+	Balance(ctx context.Context, customerID string) (int64, error)
+}
+
+// ReferralRepository defines the interface for referral codes and the referrals made
+// with them
+type ReferralRepository interface {
+	SaveCode(ctx context.Context, customerID, code string, createdAt time.Time) (*spanner.Mutation, error)
+	// FindCode returns the customer's referral code, ErrReferralCodeNotFound if they have none
+	FindCode(ctx context.Context, customerID string) (string, error)
+	// FindCodeOwner returns the customer a referral code belongs to
+	FindCodeOwner(ctx context.Context, code string) (string, error)
+	Save(ctx context.Context, referral *domain.Referral) (*spanner.Mutation, error)
+	// FindBySubscription returns the referral the subscription was created with
+	FindBySubscription(ctx context.Context, subscriptionID string) (*domain.Referral, error)
+	Apply(ctx context.Context, mutations ...*spanner.Mutation) error
+}
+
+// EntitlementRepository defines the reads behind entitlement checks. They are made on
+// every request of the services gating features, so implementations may serve them
+// from a cache or a stale read, as long as the staleness is bounded.
+type EntitlementRepository interface {
+	// FindEntitledSubscriptions returns the customer's subscriptions in one of
+	// domain.EntitledStatuses
+	FindEntitledSubscriptions(ctx context.Context, customerID string) ([]*domain.Subscription, error)
+	// FindPlanEntitlements returns the features the plan grants; a plan without any
+	// grants none
+	FindPlanEntitlements(ctx context.Context, planID string) ([]domain.Entitlement, error)
+}
+
+// PlanRepository defines the interface for plan persistence
+type PlanRepository interface {
+	Save(ctx context.Context, plan *domain.Plan) (*spanner.Mutation, error)
+	// Insert saves a new plan, failing with ErrPlanAlreadyExists when its ID is taken
+	Insert(ctx context.Context, plan *domain.Plan) error
+	// FindAll returns every plan; the catalog is small enough to hold in memory
+	FindAll(ctx context.Context) ([]*domain.Plan, error)
+	FindByID(ctx context.Context, id string) (*domain.Plan, error)
+	Apply(ctx context.Context, mutations ...*spanner.Mutation) error
+}
+
+// UsageRepository defines the interface for metered usage. Records are only inserted
+// and summed, like credit balance entries.
+type UsageRepository interface {
+	Save(ctx context.Context, record *domain.UsageRecord) (*spanner.Mutation, error)
+	// FindPeriodUsage sums the usage of a metric over one billing period and lists the
+	// thresholds already alerted at; Included is left to the caller
+	FindPeriodUsage(ctx context.Context, subscriptionID, metric string, periodStart time.Time) (domain.PeriodUsage, error)
+	// ClaimAlert records that the event's alert is being sent, or returns
+	// domain.ErrUsageAlertAlreadySent if it was claimed before, so concurrent
+	// recordings that reach the same threshold alert once
+	ClaimAlert(ctx context.Context, event *domain.UsageThresholdReachedEvent) error
+	Apply(ctx context.Context, mutations ...*spanner.Mutation) error
+}
+
+// CancellationSurveyRepository defines the interface for cancellation survey responses
+type CancellationSurveyRepository interface {
+	// Insert saves the response and its answers, or returns
+	// domain.ErrSurveyAlreadySubmitted if the cancellation already has a response
+	Insert(ctx context.Context, response *domain.SurveyResponse) error
+	// CountAnswers groups the choice and rating answers of responses submitted from
+	// since by UTC month, question and answer; free text answers aren't counted
+	CountAnswers(ctx context.Context, since time.Time) ([]SurveyAnswerCount, error)
+}
+
+// SurveyAnswerCount is how many responses submitted in one UTC month gave an answer to
+// a question
+type SurveyAnswerCount struct {
+	Month      time.Time // first of the month, UTC
+	QuestionID string
+	Value      string
+	Responses  int64
+}
+
+// RetentionOfferRepository defines the interface for retention offers and their outcomes
+type RetentionOfferRepository interface {
+	Save(ctx context.Context, offer *domain.RetentionOffer) (*spanner.Mutation, error)
+	FindByID(ctx context.Context, id string) (*domain.RetentionOffer, error)
+	// LastAcceptedAt returns when the subscription last accepted an offer, or the zero
+	// time if it never has
+	LastAcceptedAt(ctx context.Context, subscriptionID string) (time.Time, error)
+	Apply(ctx context.Context, mutations ...*spanner.Mutation) error
+}
+
+// SubscriptionTemplateRepository defines the interface for subscription template persistence
+type SubscriptionTemplateRepository interface {
+	// Insert saves a new template with its bundle, failing with ErrTemplateNameTaken
+	// when another template has its name
+	Insert(ctx context.Context, template *domain.SubscriptionTemplate) error
+	FindByID(ctx context.Context, id string) (*domain.SubscriptionTemplate, error)
+}
+
+// IdempotencyKeyRepository remembers the subscription each of a customer's idempotency
+// keys created, so a retried create returns it rather than creating another
+type IdempotencyKeyRepository interface {
+	// Save returns the mutation recording that the key created the subscription. It
+	// inserts, so committing it fails when a concurrent request recorded the key first.
+	Save(ctx context.Context, customerID, key, subscriptionID string, createdAt time.Time) (*spanner.Mutation, error)
+	// FindSubscriptionID returns the subscription the key created, or
+	// domain.ErrIdempotencyKeyNotFound
+	FindSubscriptionID(ctx context.Context, customerID, key string) (string, error)
+}
+
+// CouponRepository defines the interface for coupons and their redemptions
+type CouponRepository interface {
+	// Insert saves a new coupon, failing with ErrCouponAlreadyExists when its code is taken
+	Insert(ctx context.Context, coupon *domain.Coupon) error
+	FindByCode(ctx context.Context, code string) (*domain.Coupon, error)
+	// SaveRedemption returns the mutations recording the coupon's latest redemption by
+	// the subscription. Each redemption inserts the coupon's next number, so committing
+	// fails when a concurrent redemption took it first.
+	SaveRedemption(ctx context.Context, coupon *domain.Coupon, subscriptionID string, redeemedAt time.Time) ([]*spanner.Mutation, error)
+}
+
+// SubscriptionBundleRepository defines the interface for persisting the add-ons and
+// metadata subscriptions are set up with
+type SubscriptionBundleRepository interface {
+	// Save returns the mutations replacing the subscription's bundle
+	Save(ctx context.Context, subscriptionID string, bundle domain.SubscriptionBundle) ([]*spanner.Mutation, error)
+	// FindBySubscriptionID returns the subscription's bundle, empty if it has none
+	FindBySubscriptionID(ctx context.Context, subscriptionID string) (domain.SubscriptionBundle, error)
+}
@@ -0,0 +1,34 @@
+package contracts
+
+import (
+	"context"
+	"time"
+)
+
+// RetentionAction is what happens to records past their retention period
+type RetentionAction string
+
+const (
+	RetentionAnonymize RetentionAction = "ANONYMIZE"
+	RetentionDelete    RetentionAction = "DELETE"
+)
+
+// PurgeRecord is one entry in the purge audit trail
+type PurgeRecord struct {
+	ID           string
+	Target       string
+	Action       RetentionAction
+	Cutoff       time.Time
+	RowsAffected int64
+	DryRun       bool
+	ExecutedAt   time.Time
+}
+
+// RetentionRepository defines the persistence operations behind the retention policy.
+// Only cancelled subscriptions are eligible; the cutoff applies to their cancellation time.
+type RetentionRepository interface {
+	CountExpired(ctx context.Context, action RetentionAction, cutoff time.Time) (int64, error)
+	AnonymizeExpired(ctx context.Context, cutoff time.Time) (int64, error)
+	DeleteExpired(ctx context.Context, cutoff time.Time) (int64, error)
+	RecordPurge(ctx context.Context, record PurgeRecord) error
+}
@@ -0,0 +1,13 @@
+package contracts
+
+import (
+	"context"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+// RetentionOfferEngine decides what, if anything, to offer a customer asking to
+// cancel instead of cancelling. It returns nil when there is nothing to offer.
+type RetentionOfferEngine interface {
+	OfferFor(ctx context.Context, sub *domain.Subscription) (*domain.RetentionOfferTerms, error)
+}
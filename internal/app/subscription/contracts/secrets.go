@@ -0,0 +1,8 @@
+package contracts
+
+import "context"
+
+// SecretProvider resolves named secrets such as billing API credentials
+type SecretProvider interface {
+	Secret(ctx context.Context, name string) (string, error)
+}
@@ -0,0 +1,21 @@
+package contracts
+
+import "context"
+
+// Metrics defines the interface for recording application metrics
+type Metrics interface {
+	IncCounter(name string, labels map[string]string)
+	ObserveHistogram(name string, value float64, labels map[string]string)
+}
+
+// Tracer defines the interface for starting trace spans
+type Tracer interface {
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is a single unit of traced work
+type Span interface {
+	SetAttribute(key, value string)
+	RecordError(err error)
+	End()
+}
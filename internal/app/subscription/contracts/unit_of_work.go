@@ -0,0 +1,145 @@
+package contracts
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrUncheckedCommit is returned by Commit when the unit of work saved subscriptions
+// and the committer cannot check their versions
+var ErrUncheckedCommit = errors.New("committer cannot check subscription versions")
+
+// Committer applies mutations in one transaction. Every repository with an Apply is
+// one, and any of them can commit the mutations of the others.
+type Committer interface {
+	Apply(ctx context.Context, mutations ...*Mutation) error
+}
+
+// VersionCheck is the version a subscription was read at. A subscription's mutation
+// commits only while the stored subscription is still at that version, so a change
+// made since it was read is not overwritten.
+type VersionCheck struct {
+	SubscriptionID string
+	Version        int64
+}
+
+// CheckingCommitter is a Committer that can make the commit conditional on version
+// checks. A unit of work that saved subscriptions commits only through one.
+type CheckingCommitter interface {
+	Committer
+	// ApplyChecked applies the mutations in one transaction once every subscription
+	// checked is still at its version, and otherwise commits nothing and returns
+	// domain.ErrConcurrentModification
+	ApplyChecked(ctx context.Context, checks []VersionCheck, mutations ...*Mutation) error
+}
+
+// UnitOfWork collects the writes of one use case, so they are committed once, at the
+// end, all or none. Repositories' Save methods only build mutations; a use case adds
+// each to its unit of work and commits it after every check has passed:
+//
+//	var uow contracts.UnitOfWork
+//	uow.SaveChecked(i.repo.Save(ctx, sub))
+//	uow.Save(i.credits.Save(ctx, entry))
+//	if err := uow.Commit(ctx, i.repo); err != nil {
+//		return nil, err
+//	}
+//
+// A subscription's Save also returns the version it was read at, which SaveChecked
+// keeps with the mutation, so the commit checks it in the same transaction.
+//
+// The zero value is empty and ready to use. It is not safe for concurrent use.
+type UnitOfWork struct {
+	mutations []*Mutation
+	checks    []VersionCheck
+	err       error
+}
+
+// Save adds the mutation a repository's Save returned. The first error is kept and
+// returned by Err and Commit, so a use case can add several writes and check once.
+func (u *UnitOfWork) Save(mutation *Mutation, err error) {
+	u.SaveAll([]*Mutation{mutation}, err)
+}
+
+// SaveAll is Save for repositories that return several mutations, such as a bundle's
+// items
+func (u *UnitOfWork) SaveAll(mutations []*Mutation, err error) {
+	if err != nil {
+		if u.err == nil {
+			u.err = err
+		}
+		return
+	}
+	u.Add(mutations...)
+}
+
+// SaveChecked is Save for a subscription's mutation, which commits only while the
+// subscription is still at the version checked
+func (u *UnitOfWork) SaveChecked(mutation *Mutation, check VersionCheck, err error) {
+	u.Save(mutation, err)
+	if err == nil {
+		u.checks = append(u.checks, check)
+	}
+}
+
+// Add adds mutations collected elsewhere that need no version check
+func (u *UnitOfWork) Add(mutations ...*Mutation) {
+	u.mutations = append(u.mutations, mutations...)
+}
+
+// Merge adds the mutations, version checks and first error of another unit of work,
+// so both commit together
+func (u *UnitOfWork) Merge(other *UnitOfWork) {
+	if other.err != nil && u.err == nil {
+		u.err = other.err
+	}
+	u.mutations = append(u.mutations, other.mutations...)
+	u.checks = append(u.checks, other.checks...)
+}
+
+// Err is the first error passed to Save
+func (u *UnitOfWork) Err() error {
+	return u.err
+}
+
+// Len is the number of mutations collected
+func (u *UnitOfWork) Len() int {
+	return len(u.mutations)
+}
+
+// Mutations are the mutations collected, in the order they were added
+func (u *UnitOfWork) Mutations() []*Mutation {
+	return u.mutations
+}
+
+// Checks are the version checks of the subscriptions saved, in the order they were
+// saved
+func (u *UnitOfWork) Checks() []VersionCheck {
+	return u.checks
+}
+
+// Commit applies the mutations collected in one transaction through committer, then
+// empties the unit of work; after a failed commit they are kept, to retry. It commits
+// nothing when Save was given an error, and nothing when there is nothing to write.
+// When subscriptions were saved, committer must be a CheckingCommitter; any other
+// commits nothing and returns ErrUncheckedCommit.
+func (u *UnitOfWork) Commit(ctx context.Context, committer Committer) error {
+	if u.err != nil {
+		return u.err
+	}
+	if len(u.mutations) == 0 {
+		return nil
+	}
+	var err error
+	if len(u.checks) == 0 {
+		err = committer.Apply(ctx, u.mutations...)
+	} else if checking, ok := committer.(CheckingCommitter); ok {
+		err = checking.ApplyChecked(ctx, u.checks, u.mutations...)
+	} else {
+		err = ErrUncheckedCommit
+	}
+	if err != nil {
+		return err
+	}
+	u.mutations, u.checks = nil, nil
+	return nil
+}
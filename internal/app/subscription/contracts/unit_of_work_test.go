@@ -0,0 +1,102 @@
+package contracts
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"cloud.google.com/go/spanner"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingCommitter struct {
+	commits [][]*Mutation
+	err     error
+}
+
+func (c *recordingCommitter) Apply(_ context.Context, mutations ...*Mutation) error {
+	c.commits = append(c.commits, mutations)
+	return c.err
+}
+
+type checkingCommitter struct {
+	recordingCommitter
+	checks [][]VersionCheck
+}
+
+func (c *checkingCommitter) ApplyChecked(ctx context.Context, checks []VersionCheck, mutations ...*Mutation) error {
+	c.checks = append(c.checks, checks)
+	return c.Apply(ctx, mutations...)
+}
+
+func TestUnitOfWork_CommitsEverythingOnce(t *testing.T) {
+	sub := spanner.Insert("subscriptions", []string{"id"}, []any{"sub-1"})
+	credit := spanner.Insert("credit_entries", []string{"id"}, []any{"credit-1"})
+	addOns := []*Mutation{spanner.Insert("subscription_add_ons", []string{"id"}, []any{"addon-1"})}
+	committer := &recordingCommitter{}
+
+	var uow UnitOfWork
+	uow.Save(sub, nil)
+	uow.SaveAll(addOns, nil)
+	uow.Save(credit, nil)
+	require.NoError(t, uow.Commit(context.Background(), committer))
+
+	assert.Equal(t, [][]*Mutation{{sub, addOns[0], credit}}, committer.commits)
+	assert.Zero(t, uow.Len(), "committed writes aren't committed again")
+	require.NoError(t, uow.Commit(context.Background(), committer))
+	assert.Len(t, committer.commits, 1, "nothing to write commits nothing")
+}
+
+func TestUnitOfWork_CommitsNothingAfterAFailedSave(t *testing.T) {
+	broken := errors.New("invalid column")
+	committer := &recordingCommitter{}
+
+	var uow UnitOfWork
+	uow.Save(spanner.Insert("subscriptions", []string{"id"}, []any{"sub-1"}), nil)
+	uow.Save(nil, broken)
+	uow.Save(nil, errors.New("later"))
+
+	assert.ErrorIs(t, uow.Err(), broken, "the first error is kept")
+	assert.ErrorIs(t, uow.Commit(context.Background(), committer), broken)
+	assert.Empty(t, committer.commits)
+}
+
+func TestUnitOfWork_KeepsWritesWhenTheCommitFails(t *testing.T) {
+	committer := &recordingCommitter{err: errors.New("aborted")}
+
+	var uow UnitOfWork
+	uow.Save(spanner.Insert("subscriptions", []string{"id"}, []any{"sub-1"}), nil)
+
+	assert.Error(t, uow.Commit(context.Background(), committer))
+	assert.Equal(t, 1, uow.Len(), "kept to retry")
+}
+
+func TestUnitOfWork_CommitsTheVersionChecksWithTheWrites(t *testing.T) {
+	sub := spanner.Insert("subscriptions", []string{"id"}, []any{"sub-1"})
+	other := spanner.Insert("subscriptions", []string{"id"}, []any{"sub-2"})
+	credit := spanner.Insert("credit_entries", []string{"id"}, []any{"credit-1"})
+	committer := &checkingCommitter{}
+
+	var uow, batch UnitOfWork
+	uow.SaveChecked(sub, VersionCheck{SubscriptionID: "sub-1", Version: 3}, nil)
+	uow.Save(credit, nil)
+	batch.SaveChecked(other, VersionCheck{SubscriptionID: "sub-2", Version: 0}, nil)
+	batch.Merge(&uow)
+	require.NoError(t, batch.Commit(context.Background(), committer))
+
+	assert.Equal(t, [][]*Mutation{{other, sub, credit}}, committer.commits)
+	assert.Equal(t, [][]VersionCheck{{{SubscriptionID: "sub-2", Version: 0}, {SubscriptionID: "sub-1", Version: 3}}}, committer.checks)
+	assert.Empty(t, batch.Checks(), "committed checks aren't checked again")
+}
+
+func TestUnitOfWork_RefusesToCommitSavedSubscriptionsUnchecked(t *testing.T) {
+	committer := &recordingCommitter{}
+
+	var uow UnitOfWork
+	uow.SaveChecked(spanner.Insert("subscriptions", []string{"id"}, []any{"sub-1"}), VersionCheck{SubscriptionID: "sub-1", Version: 1}, nil)
+
+	assert.ErrorIs(t, uow.Commit(context.Background(), committer), ErrUncheckedCommit)
+	assert.Empty(t, committer.commits)
+	assert.Equal(t, 1, uow.Len(), "kept to commit through a checking committer")
+}
@@ -0,0 +1,32 @@
+// Package correlation carries a correlation ID through a request so logs, traces
+// and calls to external services can be tied back to the operation that caused them.
+package correlation
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+type ctxKey struct{}
+
+// WithID returns a context carrying the correlation ID
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// ID returns the correlation ID carried by ctx, or "" if there is none
+func ID(ctx context.Context) string {
+	id, _ := ctx.Value(ctxKey{}).(string)
+	return id
+}
+
+// Ensure returns ctx unchanged if it already carries a correlation ID, otherwise a
+// context carrying a new one
+func Ensure(ctx context.Context) (context.Context, string) {
+	if id := ID(ctx); id != "" {
+		return ctx, id
+	}
+	id := uuid.New().String()
+	return WithID(ctx, id), id
+}
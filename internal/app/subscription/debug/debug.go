@@ -0,0 +1,91 @@
+// Package debug serves the runtime debug endpoints, net/http/pprof profiles and
+// expvar variables, on a separate ops port. They let an operator profile CPU and
+// memory during an incident without rebuilding the binary, and are never exposed
+// alongside the service's own endpoints.
+package debug
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"expvar"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+)
+
+// TokenSecret names the bearer token callers must present, resolved through the
+// SecretProvider on every request so it can be rotated without a restart
+const TokenSecret = "debug-token"
+
+// ErrNoToken is returned when the debug endpoints would be served without a token
+var ErrNoToken = errors.New("debug endpoints require a token")
+
+var publishOnce sync.Once
+
+// NewServer returns a server for the debug endpoints on addr. It fails when the token
+// can't be resolved, so a misconfigured binary refuses to start rather than serving
+// profiles to anyone or to no one.
+func NewServer(ctx context.Context, addr string, secrets contracts.SecretProvider, logger *slog.Logger) (*http.Server, error) {
+	if _, err := token(ctx, secrets); err != nil {
+		return nil, err
+	}
+	return &http.Server{
+		Addr:              addr,
+		Handler:           NewHandler(secrets, logger),
+		ReadHeaderTimeout: 10 * time.Second,
+		// No write timeout: a CPU profile or execution trace streams for as long as requested
+	}, nil
+}
+
+// NewHandler serves /debug/pprof/ and /debug/vars to requests carrying the token as
+// "Authorization: Bearer <token>"
+func NewHandler(secrets contracts.SecretProvider, logger *slog.Logger) http.Handler {
+	publishOnce.Do(func() {
+		expvar.Publish("goroutines", expvar.Func(func() any { return runtime.NumGoroutine() }))
+	})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.Handle("/debug/vars", expvar.Handler())
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		want, err := token(r.Context(), secrets)
+		if err != nil {
+			logger.ErrorContext(r.Context(), "debug token unavailable", slog.Any("error", err))
+			http.Error(w, "debug endpoints unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(want)) != 1 {
+			logger.WarnContext(r.Context(), "debug request rejected", slog.String("path", r.URL.Path), slog.String("remote_addr", r.RemoteAddr))
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		logger.InfoContext(r.Context(), "debug request", slog.String("path", r.URL.Path), slog.String("remote_addr", r.RemoteAddr))
+		mux.ServeHTTP(w, r)
+	})
+}
+
+func token(ctx context.Context, secrets contracts.SecretProvider) (string, error) {
+	t, err := secrets.Secret(ctx, TokenSecret)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrNoToken, err)
+	}
+	if t == "" {
+		return "", ErrNoToken
+	}
+	return t, nil
+}
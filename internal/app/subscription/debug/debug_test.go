@@ -0,0 +1,81 @@
+package debug
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/logging"
+)
+
+type staticSecrets map[string]string
+
+func (s staticSecrets) Secret(_ context.Context, name string) (string, error) {
+	v, ok := s[name]
+	if !ok {
+		return "", errors.New("not found")
+	}
+	return v, nil
+}
+
+func get(t *testing.T, h http.Handler, path, token string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestHandler_RequiresToken(t *testing.T) {
+	h := NewHandler(staticSecrets{TokenSecret: "s3cret"}, logging.Discard())
+
+	rec := get(t, h, "/debug/vars", "")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, "Bearer", rec.Header().Get("WWW-Authenticate"))
+
+	assert.Equal(t, http.StatusUnauthorized, get(t, h, "/debug/pprof/", "wrong").Code)
+}
+
+func TestHandler_ServesExpvarAndPprof(t *testing.T) {
+	h := NewHandler(staticSecrets{TokenSecret: "s3cret"}, logging.Discard())
+
+	rec := get(t, h, "/debug/vars", "s3cret")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var vars map[string]any
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &vars))
+	assert.Contains(t, vars, "memstats")
+	assert.Contains(t, vars, "goroutines")
+
+	rec = get(t, h, "/debug/pprof/", "s3cret")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "goroutine")
+
+	rec = get(t, h, "/debug/pprof/heap?debug=1", "s3cret")
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestHandler_RotatedTokenTakesEffect(t *testing.T) {
+	secrets := staticSecrets{TokenSecret: "old"}
+	h := NewHandler(secrets, logging.Discard())
+
+	secrets[TokenSecret] = "new"
+	assert.Equal(t, http.StatusUnauthorized, get(t, h, "/debug/vars", "old").Code)
+	assert.Equal(t, http.StatusOK, get(t, h, "/debug/vars", "new").Code)
+}
+
+func TestNewServer_RefusesToStartWithoutToken(t *testing.T) {
+	_, err := NewServer(context.Background(), ":0", staticSecrets{}, logging.Discard())
+	assert.ErrorIs(t, err, ErrNoToken)
+
+	_, err = NewServer(context.Background(), ":0", staticSecrets{TokenSecret: ""}, logging.Discard())
+	assert.ErrorIs(t, err, ErrNoToken)
+}
@@ -0,0 +1,103 @@
+package domain
+
+import "sort"
+
+// Validate rejects an add-on that couldn't be billed or told apart from the others
+func (a AddOnCharge) Validate() error {
+	if a.ID == "" || a.Name == "" || a.Quantity <= 0 || a.UnitPrice < 0 {
+		return ErrInvalidAddOn
+	}
+	return nil
+}
+
+// WithAddOn returns a copy of b with the add-on attached, replacing the quantity and
+// price of one attached under the same ID. Add-ons stay ordered by ID, as they are read.
+func (b SubscriptionBundle) WithAddOn(addOn AddOnCharge) SubscriptionBundle {
+	c := b.Clone()
+	for n, a := range c.AddOns {
+		if a.ID == addOn.ID {
+			c.AddOns[n] = addOn
+			return c
+		}
+	}
+	c.AddOns = append(c.AddOns, addOn)
+	sort.SliceStable(c.AddOns, func(x, y int) bool { return c.AddOns[x].ID < c.AddOns[y].ID })
+	return c
+}
+
+// WithoutAddOn returns a copy of b with the add-on detached, or ErrAddOnNotFound if it
+// isn't attached
+func (b SubscriptionBundle) WithoutAddOn(addOnID string) (SubscriptionBundle, error) {
+	c := b.Clone()
+	for n, a := range c.AddOns {
+		if a.ID == addOnID {
+			c.AddOns = append(c.AddOns[:n], c.AddOns[n+1:]...)
+			if len(c.AddOns) == 0 {
+				c.AddOns = nil
+			}
+			return c, nil
+		}
+	}
+	return SubscriptionBundle{}, ErrAddOnNotFound
+}
+
+// AttachAddOn attaches an add-on to an active subscription set up with bundle, and
+// returns the bundle to save. The add-on's discounted price is prorated by the days left
+// in the current period, the way ChangePlan prorates a new price; the next renewal
+// charges it in full. Re-attaching an add-on changes its quantity and price.
+func (s *Subscription) AttachAddOn(clock Clock, cycle BillingCycle, pricing Pricing, bundle SubscriptionBundle, addOn AddOnCharge) (SubscriptionBundle, *SubscriptionAddOnsChangedEvent, error) {
+	if err := addOn.Validate(); err != nil {
+		return SubscriptionBundle{}, nil, err
+	}
+	changed := bundle.WithAddOn(addOn)
+	event, err := s.changeAddOns(clock, cycle, pricing, bundle.AddOns, changed.AddOns)
+	if err != nil {
+		return SubscriptionBundle{}, nil, err
+	}
+	event.AddOnID = addOn.ID
+	return changed, event, nil
+}
+
+// DetachAddOn detaches an add-on from an active subscription set up with bundle, and
+// returns the bundle to save. The event's negative ProratedAmount is the unused part of
+// the add-on for the period.
+func (s *Subscription) DetachAddOn(clock Clock, cycle BillingCycle, pricing Pricing, bundle SubscriptionBundle, addOnID string) (SubscriptionBundle, *SubscriptionAddOnsChangedEvent, error) {
+	changed, err := bundle.WithoutAddOn(addOnID)
+	if err != nil {
+		return SubscriptionBundle{}, nil, err
+	}
+	event, err := s.changeAddOns(clock, cycle, pricing, bundle.AddOns, changed.AddOns)
+	if err != nil {
+		return SubscriptionBundle{}, nil, err
+	}
+	event.AddOnID = addOnID
+	return changed, event, nil
+}
+
+// changeAddOns prorates moving from the add-ons before to those after for the days left
+// in the current period, both under pricing's discounts
+func (s *Subscription) changeAddOns(clock Clock, cycle BillingCycle, pricing Pricing, before, after []AddOnCharge) (*SubscriptionAddOnsChangedEvent, error) {
+	if s.status != StatusActive {
+		return nil, ErrNotActive
+	}
+
+	now := clock.Now()
+	periodDays := int64(cycle.PeriodEnd(s.currentPeriodStart).Sub(s.currentPeriodStart).Hours() / 24)
+	daysElapsed := int64(s.elapsed(now, cycle).Hours() / 24)
+	pricing.AddOns = before
+	oldPrice := s.PeriodPrice(pricing)
+	pricing.AddOns = after
+	newPrice := s.PeriodPrice(pricing)
+	var prorated int64
+	if periodDays > 0 {
+		prorated = ((newPrice.Net - oldPrice.Net) * (periodDays - daysElapsed)) / periodDays
+	}
+
+	return &SubscriptionAddOnsChangedEvent{
+		SubscriptionID: s.id,
+		CustomerID:     s.customerID,
+		AddOns:         append([]AddOnCharge(nil), after...),
+		ProratedAmount: prorated,
+		ChangedAt:      now,
+	}, nil
+}
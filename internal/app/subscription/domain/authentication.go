@@ -0,0 +1,188 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// AuthenticationFailureReason is the past-due failure reason of a renewal held for
+// strong customer authentication
+const AuthenticationFailureReason = "authentication_required"
+
+// AuthenticationRequiredError reports a charge the billing provider held for strong
+// customer authentication (3-D Secure) instead of taking it. The customer can still
+// complete it at ActionURL, but until then it is a declined charge.
+type AuthenticationRequiredError struct {
+	PaymentID string // the provider's ID of the held charge
+	ActionURL string // where the customer authenticates it
+}
+
+func (e *AuthenticationRequiredError) Error() string {
+	return fmt.Sprintf("%s: payment %s", ErrAuthenticationRequired, e.PaymentID)
+}
+
+// Is makes errors.Is hold for both ErrAuthenticationRequired and ErrPaymentDeclined, so
+// charge flows that don't handle authentication treat it as a decline
+func (e *AuthenticationRequiredError) Is(target error) bool {
+	return target == ErrAuthenticationRequired || target == ErrPaymentDeclined
+}
+
+// AuthenticationStatus is where a charge held for authentication is
+type AuthenticationStatus string
+
+const (
+	AuthenticationPending   AuthenticationStatus = "PENDING"
+	AuthenticationSucceeded AuthenticationStatus = "SUCCEEDED"
+	AuthenticationFailed    AuthenticationStatus = "FAILED"
+)
+
+// ChargeAuthentication tracks a renewal charge held for strong customer authentication
+// until the billing provider reports whether the customer completed it
+type ChargeAuthentication struct {
+	id                string
+	subscriptionID    string
+	customerID        string
+	providerPaymentID string
+	amount            int64 // cents held
+	creditApplied     int64 // cents of the period to take from the credit balance once paid
+	currency          string
+	actionURL         string
+	periodStart       time.Time
+	status            AuthenticationStatus
+	failureReason     string
+	requestedAt       time.Time
+	resolvedAt        time.Time
+}
+
+// NewChargeAuthentication records a charge of amount for the subscription's current
+// period that the provider held for authentication. creditApplied is the part of the
+// period the credit balance covers, spent only once the charge completes.
+func NewChargeAuthentication(id string, sub *Subscription, amount, creditApplied int64, currency string, challenge *AuthenticationRequiredError, clock Clock) (*ChargeAuthentication, *AuthenticationRequiredEvent) {
+	a := &ChargeAuthentication{
+		id:                id,
+		subscriptionID:    sub.id,
+		customerID:        sub.customerID,
+		providerPaymentID: challenge.PaymentID,
+		amount:            amount,
+		creditApplied:     creditApplied,
+		currency:          currency,
+		actionURL:         challenge.ActionURL,
+		periodStart:       sub.currentPeriodStart,
+		status:            AuthenticationPending,
+		requestedAt:       clock.Now(),
+	}
+
+	event := &AuthenticationRequiredEvent{
+		AuthenticationID: a.id,
+		SubscriptionID:   a.subscriptionID,
+		CustomerID:       a.customerID,
+		Amount:           a.amount,
+		Currency:         a.currency,
+		ActionURL:        a.actionURL,
+		RequestedAt:      a.requestedAt,
+	}
+
+	return a, event
+}
+
+// ReconstructChargeAuthentication rebuilds a charge authentication from persistence
+func ReconstructChargeAuthentication(id, subscriptionID, customerID, providerPaymentID string, amount, creditApplied int64, currency, actionURL string, periodStart time.Time, status AuthenticationStatus, failureReason string, requestedAt, resolvedAt time.Time) *ChargeAuthentication {
+	return &ChargeAuthentication{
+		id:                id,
+		subscriptionID:    subscriptionID,
+		customerID:        customerID,
+		providerPaymentID: providerPaymentID,
+		amount:            amount,
+		creditApplied:     creditApplied,
+		currency:          currency,
+		actionURL:         actionURL,
+		periodStart:       periodStart,
+		status:            status,
+		failureReason:     failureReason,
+		requestedAt:       requestedAt,
+		resolvedAt:        resolvedAt,
+	}
+}
+
+// ApplyOutcome moves the authentication to the status reported by the billing provider,
+// reporting whether it changed. A still-pending status changes nothing.
+func (a *ChargeAuthentication) ApplyOutcome(clock Clock, status AuthenticationStatus, reason string) (bool, error) {
+	switch status {
+	case AuthenticationPending:
+		return false, nil
+	case AuthenticationSucceeded, AuthenticationFailed:
+	default:
+		return false, ErrInvalidAuthenticationStatus
+	}
+	if a.status != AuthenticationPending {
+		return false, ErrAuthenticationResolved
+	}
+
+	a.status = status
+	if status == AuthenticationFailed {
+		a.failureReason = reason
+	}
+	a.resolvedAt = clock.Now()
+	return true, nil
+}
+
+// CompletesPeriodOf reports whether the authenticated charge paid for the period the
+// subscription is past due for; a subscription recovered, renewed or expired since
+// was paid, or given up on, some other way
+func (a *ChargeAuthentication) CompletesPeriodOf(sub *Subscription) bool {
+	return a.status == AuthenticationSucceeded && sub.id == a.subscriptionID &&
+		sub.status == StatusPastDue && sub.currentPeriodStart.Equal(a.periodStart)
+}
+
+// Getters
+func (a *ChargeAuthentication) ID() string {
+	return a.id
+}
+
+func (a *ChargeAuthentication) SubscriptionID() string {
+	return a.subscriptionID
+}
+
+func (a *ChargeAuthentication) CustomerID() string {
+	return a.customerID
+}
+
+func (a *ChargeAuthentication) ProviderPaymentID() string {
+	return a.providerPaymentID
+}
+
+func (a *ChargeAuthentication) Amount() int64 {
+	return a.amount
+}
+
+func (a *ChargeAuthentication) CreditApplied() int64 {
+	return a.creditApplied
+}
+
+func (a *ChargeAuthentication) Currency() string {
+	return a.currency
+}
+
+func (a *ChargeAuthentication) ActionURL() string {
+	return a.actionURL
+}
+
+func (a *ChargeAuthentication) PeriodStart() time.Time {
+	return a.periodStart
+}
+
+func (a *ChargeAuthentication) Status() AuthenticationStatus {
+	return a.status
+}
+
+func (a *ChargeAuthentication) FailureReason() string {
+	return a.failureReason
+}
+
+func (a *ChargeAuthentication) RequestedAt() time.Time {
+	return a.requestedAt
+}
+
+func (a *ChargeAuthentication) ResolvedAt() time.Time {
+	return a.resolvedAt
+}
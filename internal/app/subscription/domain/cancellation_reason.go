@@ -0,0 +1,49 @@
+package domain
+
+import "unicode/utf8"
+
+// CancellationReasonCode is why a customer cancelled, as churn analysis groups it
+type CancellationReasonCode string
+
+const (
+	CancellationReasonTooExpensive    CancellationReasonCode = "too_expensive"
+	CancellationReasonMissingFeatures CancellationReasonCode = "missing_features"
+	CancellationReasonSwitchedService CancellationReasonCode = "switched_service"
+	CancellationReasonUnused          CancellationReasonCode = "unused"
+	CancellationReasonServiceIssues   CancellationReasonCode = "service_issues"
+	CancellationReasonPlanRetired     CancellationReasonCode = "plan_retired" // cancelled by us, such as a plan sunset
+	CancellationReasonOther           CancellationReasonCode = "other"
+)
+
+// MaxCancellationReasonDetailsLength is the most characters a reason's free text can have
+const MaxCancellationReasonDetailsLength = 1000
+
+// CancellationReason is why a subscription was cancelled: a code to group by and,
+// optionally, the customer's own words. The zero value is a cancellation whose
+// reason wasn't given.
+type CancellationReason struct {
+	Code    CancellationReasonCode
+	Details string
+}
+
+// IsZero reports whether no reason was given
+func (r CancellationReason) IsZero() bool {
+	return r == CancellationReason{}
+}
+
+// Validate rejects an unknown code, details without a code and details that are too long
+func (r CancellationReason) Validate() error {
+	if r.IsZero() {
+		return nil
+	}
+	switch r.Code {
+	case CancellationReasonTooExpensive, CancellationReasonMissingFeatures, CancellationReasonSwitchedService,
+		CancellationReasonUnused, CancellationReasonServiceIssues, CancellationReasonPlanRetired, CancellationReasonOther:
+	default:
+		return ErrInvalidCancellationReason
+	}
+	if utf8.RuneCountInString(r.Details) > MaxCancellationReasonDetailsLength {
+		return ErrInvalidCancellationReason
+	}
+	return nil
+}
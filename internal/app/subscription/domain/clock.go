@@ -7,6 +7,13 @@ type Clock interface {
 	Now() time.Time
 }
 
+// TimerClock is a Clock that can also wait, for workers that schedule on it, so tests
+// can drive them with testkit.StepClock
+type TimerClock interface {
+	Clock
+	After(d time.Duration) <-chan time.Time
+}
+
 // RealClock is the production implementation of Clock
 type RealClock struct{}
 
@@ -14,6 +21,10 @@ func (r RealClock) Now() time.Time {
 	return time.Now()
 }
 
+func (r RealClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
 // FixedClock is used for testing with deterministic time
 type FixedClock struct {
 	FixedTime time.Time
@@ -0,0 +1,192 @@
+package domain
+
+import (
+	"strings"
+	"time"
+)
+
+// MaxCouponCodeLength is the longest coupon code
+const MaxCouponCodeLength = 64
+
+// CouponDuration is how many billing periods of a subscription a coupon discounts
+type CouponDuration string
+
+const (
+	// CouponOnce discounts the first paid period only
+	CouponOnce CouponDuration = "once"
+	// CouponRepeating discounts the first Periods paid periods
+	CouponRepeating CouponDuration = "repeating"
+	// CouponForever discounts every period for the life of the subscription
+	CouponForever CouponDuration = "forever"
+)
+
+// NormalizeCouponCode upper-cases a coupon code and checks it is letters, digits,
+// dashes and underscores
+func NormalizeCouponCode(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code == "" || len(code) > MaxCouponCodeLength {
+		return "", ErrInvalidCouponCode
+	}
+	for _, r := range code {
+		if (r < 'A' || r > 'Z') && (r < '0' || r > '9') && r != '-' && r != '_' {
+			return "", ErrInvalidCouponCode
+		}
+	}
+	return code, nil
+}
+
+// CouponTerms are what a coupon takes off, for how long, and how it may be redeemed.
+// A coupon takes off PercentOff basis points or AmountOff cents, not both.
+type CouponTerms struct {
+	PercentOff     int64
+	AmountOff      int64
+	Duration       CouponDuration
+	Periods        int64     // paid periods a repeating coupon discounts
+	MaxRedemptions int64     // zero allows any number
+	ExpiresAt      time.Time // last moment it can be redeemed; zero never expires
+}
+
+// Validate rejects terms a coupon can't have
+func (t CouponTerms) Validate() error {
+	if (t.PercentOff == 0) == (t.AmountOff == 0) {
+		return ErrInvalidCoupon
+	}
+	if t.PercentOff < 0 || t.PercentOff > basisPoints || t.AmountOff < 0 || t.MaxRedemptions < 0 {
+		return ErrInvalidCoupon
+	}
+	switch t.Duration {
+	case CouponOnce, CouponForever:
+		if t.Periods != 0 {
+			return ErrInvalidCoupon
+		}
+	case CouponRepeating:
+		if t.Periods <= 0 {
+			return ErrInvalidCoupon
+		}
+	default:
+		return ErrInvalidCoupon
+	}
+	return nil
+}
+
+// periods returns how many paid periods the terms discount; zero is every one
+func (t CouponTerms) periods() int64 {
+	switch t.Duration {
+	case CouponOnce:
+		return 1
+	case CouponRepeating:
+		return t.Periods
+	default:
+		return 0
+	}
+}
+
+// Coupon is a promotional code customers redeem when they subscribe
+type Coupon struct {
+	code          string
+	terms         CouponTerms
+	timesRedeemed int64
+	createdAt     time.Time
+}
+
+// NewCoupon creates a coupon that has not been redeemed yet
+func NewCoupon(code string, terms CouponTerms, clock Clock) (*Coupon, error) {
+	code, err := NormalizeCouponCode(code)
+	if err != nil {
+		return nil, err
+	}
+	if err := terms.Validate(); err != nil {
+		return nil, err
+	}
+	return &Coupon{code: code, terms: terms, createdAt: clock.Now()}, nil
+}
+
+// ReconstructCoupon recreates a coupon from database
+func ReconstructCoupon(code string, terms CouponTerms, timesRedeemed int64, createdAt time.Time) *Coupon {
+	return &Coupon{code: code, terms: terms, timesRedeemed: timesRedeemed, createdAt: createdAt}
+}
+
+// Redeem counts one more redemption of the coupon and returns its discount as the
+// redeeming subscription carries it. Expired coupons and those redeemed as many times
+// as they allow are rejected.
+func (c *Coupon) Redeem(clock Clock) (SubscriptionCoupon, error) {
+	if !c.terms.ExpiresAt.IsZero() && clock.Now().After(c.terms.ExpiresAt) {
+		return SubscriptionCoupon{}, ErrCouponExpired
+	}
+	if c.terms.MaxRedemptions > 0 && c.timesRedeemed >= c.terms.MaxRedemptions {
+		return SubscriptionCoupon{}, ErrCouponRedemptionLimitReached
+	}
+
+	c.timesRedeemed++
+	return SubscriptionCoupon{
+		Code:        c.code,
+		PercentOff:  c.terms.PercentOff,
+		AmountOff:   c.terms.AmountOff,
+		PeriodsLeft: c.terms.periods(),
+	}, nil
+}
+
+func (c *Coupon) Code() string {
+	return c.code
+}
+
+func (c *Coupon) Terms() CouponTerms {
+	return c.terms
+}
+
+func (c *Coupon) TimesRedeemed() int64 {
+	return c.timesRedeemed
+}
+
+func (c *Coupon) CreatedAt() time.Time {
+	return c.createdAt
+}
+
+// SubscriptionCoupon is the coupon a subscription was created with, copied from the
+// coupon when it was redeemed so later changes to the coupon don't reprice it. The
+// zero value is no coupon.
+type SubscriptionCoupon struct {
+	Code        string
+	PercentOff  int64
+	AmountOff   int64
+	PeriodsLeft int64 // paid periods still discounted, the current one included; zero is every one
+}
+
+// IsZero reports whether the subscription has no coupon
+func (c SubscriptionCoupon) IsZero() bool {
+	return c.Code == ""
+}
+
+// Discount is the discount the coupon gives a charge
+func (c SubscriptionCoupon) Discount() Discount {
+	return Discount{Code: c.Code, Kind: DiscountCoupon, PercentOff: c.PercentOff, AmountOff: c.AmountOff}
+}
+
+// next returns the coupon as it applies to the period after the current one; a coupon
+// whose last period is the current one is used up
+func (c SubscriptionCoupon) next() SubscriptionCoupon {
+	switch c.PeriodsLeft {
+	case 0:
+		return c
+	case 1:
+		return SubscriptionCoupon{}
+	default:
+		c.PeriodsLeft--
+		return c
+	}
+}
+
+// ApplyCoupon gives a subscription that is being created the coupon it redeemed, so
+// its discount applies from the first paid period. A trial's free days don't use it up.
+func (s *Subscription) ApplyCoupon(coupon SubscriptionCoupon) error {
+	if !s.coupon.IsZero() {
+		return ErrCouponAlreadyApplied
+	}
+	s.coupon = coupon
+	return nil
+}
+
+// Coupon is the coupon the subscription's charges are discounted by, zero if none
+func (s *Subscription) Coupon() SubscriptionCoupon {
+	return s.coupon
+}
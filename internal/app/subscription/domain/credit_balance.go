@@ -0,0 +1,98 @@
+package domain
+
+import "time"
+
+// CreditSource records what put value into, or took it out of, a credit balance
+type CreditSource string
+
+const (
+	CreditSourceCreditNote   CreditSource = "credit_note"
+	CreditSourceCancellation CreditSource = "cancellation"
+	CreditSourceDowngrade    CreditSource = "downgrade"
+	CreditSourceRenewal      CreditSource = "renewal"
+	CreditSourcePaymentRetry CreditSource = "payment_retry"
+	CreditSourceReferral     CreditSource = "referral"
+	CreditSourceRetention    CreditSource = "retention_offer"
+)
+
+// CreditEntry is one movement of a customer's credit balance: positive when credit is
+// granted, negative when it is spent. The balance is the sum of the entries, so
+// granting credit never has to read and rewrite a running total.
+type CreditEntry struct {
+	id         string
+	customerID string
+	amount     int64 // cents
+	currency   string
+	source     CreditSource
+	sourceID   string
+	createdAt  time.Time
+}
+
+// NewCreditEntry records amount cents moving into the customer's credit balance, or
+// out of it when negative, because of sourceID
+func NewCreditEntry(id, customerID string, amount int64, currency string, source CreditSource, sourceID string, clock Clock) *CreditEntry {
+	return &CreditEntry{
+		id:         id,
+		customerID: customerID,
+		amount:     amount,
+		currency:   currency,
+		source:     source,
+		sourceID:   sourceID,
+		createdAt:  clock.Now(),
+	}
+}
+
+// CoverWithCredit splits a charge of amount into the part a credit balance covers and
+// the part still to be charged to the payment method
+func CoverWithCredit(balance, amount int64) (covered, due int64) {
+	covered = amount
+	if balance < covered {
+		covered = balance
+	}
+	if covered < 0 {
+		covered = 0
+	}
+	return covered, amount - covered
+}
+
+// ReconstructCreditEntry rebuilds a credit balance entry from persistence
+func ReconstructCreditEntry(id, customerID string, amount int64, currency string, source CreditSource, sourceID string, createdAt time.Time) *CreditEntry {
+	return &CreditEntry{
+		id:         id,
+		customerID: customerID,
+		amount:     amount,
+		currency:   currency,
+		source:     source,
+		sourceID:   sourceID,
+		createdAt:  createdAt,
+	}
+}
+
+// Getters
+func (e *CreditEntry) ID() string {
+	return e.id
+}
+
+func (e *CreditEntry) CustomerID() string {
+	return e.customerID
+}
+
+func (e *CreditEntry) Amount() int64 {
+	return e.amount
+}
+
+func (e *CreditEntry) Currency() string {
+	return e.currency
+}
+
+func (e *CreditEntry) Source() CreditSource {
+	return e.source
+}
+
+func (e *CreditEntry) SourceID() string {
+	return e.sourceID
+}
+
+func (e *CreditEntry) CreatedAt() time.Time {
+	return e.createdAt
+}
@@ -0,0 +1,182 @@
+package domain
+
+import "time"
+
+// CreditReason records why a credit note was issued, for finance reporting
+type CreditReason string
+
+const (
+	CreditReasonOutage       CreditReason = "outage"
+	CreditReasonGoodwill     CreditReason = "goodwill"
+	CreditReasonBillingError CreditReason = "billing_error"
+)
+
+// CreditSettlement decides where the value of a credit note goes
+type CreditSettlement string
+
+const (
+	// SettleToBalance adds the credit to the customer's credit balance
+	SettleToBalance CreditSettlement = "balance"
+	// SettleAsRefund pays the credit back to the customer's payment method
+	SettleAsRefund CreditSettlement = "refund"
+)
+
+// InvoiceRef identifies a past invoice being credited, with what it charged and what
+// earlier credit notes already gave back against it
+type InvoiceRef struct {
+	ID       string
+	Amount   int64 // cents
+	Credited int64 // cents
+}
+
+// CreditNote is a partial credit against a past invoice. It is immutable once issued;
+// a refund settlement only records the provider's refund ID.
+type CreditNote struct {
+	id               string
+	customerID       string
+	subscriptionID   string
+	invoiceID        string
+	amount           int64 // cents
+	currency         string
+	reason           CreditReason
+	memo             string
+	settlement       CreditSettlement
+	providerRefundID string
+	issuedAt         time.Time
+}
+
+// IssueCreditNote credits amount against a subscription's past invoice. The credits
+// against one invoice never add up to more than it charged.
+func IssueCreditNote(id string, sub *Subscription, invoice InvoiceRef, amount int64, reason CreditReason, memo string, settlement CreditSettlement, clock Clock) (*CreditNote, error) {
+	if invoice.ID == "" {
+		return nil, ErrInvalidInvoiceID
+	}
+	if amount <= 0 {
+		return nil, ErrInvalidCreditAmount
+	}
+	if invoice.Credited+amount > invoice.Amount {
+		return nil, ErrCreditExceedsInvoice
+	}
+	switch reason {
+	case CreditReasonOutage, CreditReasonGoodwill, CreditReasonBillingError:
+	default:
+		return nil, ErrInvalidCreditReason
+	}
+	switch settlement {
+	case SettleToBalance, SettleAsRefund:
+	default:
+		return nil, ErrInvalidCreditSettlement
+	}
+
+	return &CreditNote{
+		id:             id,
+		customerID:     sub.customerID,
+		subscriptionID: sub.id,
+		invoiceID:      invoice.ID,
+		amount:         amount,
+		currency:       DefaultCurrency,
+		reason:         reason,
+		memo:           memo,
+		settlement:     settlement,
+		issuedAt:       clock.Now(),
+	}, nil
+}
+
+// ReconstructCreditNote rebuilds a credit note from persistence
+func ReconstructCreditNote(id, customerID, subscriptionID, invoiceID string, amount int64, currency string, reason CreditReason, memo string, settlement CreditSettlement, providerRefundID string, issuedAt time.Time) *CreditNote {
+	return &CreditNote{
+		id:               id,
+		customerID:       customerID,
+		subscriptionID:   subscriptionID,
+		invoiceID:        invoiceID,
+		amount:           amount,
+		currency:         currency,
+		reason:           reason,
+		memo:             memo,
+		settlement:       settlement,
+		providerRefundID: providerRefundID,
+		issuedAt:         issuedAt,
+	}
+}
+
+// RecordRefund records the refund the billing provider accepted for a refund settlement
+func (n *CreditNote) RecordRefund(providerRefundID string) {
+	n.providerRefundID = providerRefundID
+}
+
+// BalanceEntry returns the credit balance entry for a balance settlement. It shares the
+// note's ID, so saving it again doesn't credit the customer twice.
+func (n *CreditNote) BalanceEntry() *CreditEntry {
+	return &CreditEntry{
+		id:         n.id,
+		customerID: n.customerID,
+		amount:     n.amount,
+		currency:   n.currency,
+		source:     CreditSourceCreditNote,
+		sourceID:   n.id,
+		createdAt:  n.issuedAt,
+	}
+}
+
+// IssuedEvent describes the note for finance; balance is the customer's credit balance
+// after a balance settlement
+func (n *CreditNote) IssuedEvent(balance int64) *CreditNoteIssuedEvent {
+	return &CreditNoteIssuedEvent{
+		CreditNoteID:     n.id,
+		SubscriptionID:   n.subscriptionID,
+		CustomerID:       n.customerID,
+		InvoiceID:        n.invoiceID,
+		Amount:           n.amount,
+		Currency:         n.currency,
+		Reason:           n.reason,
+		Settlement:       n.settlement,
+		ProviderRefundID: n.providerRefundID,
+		Balance:          balance,
+		IssuedAt:         n.issuedAt,
+	}
+}
+
+// Getters
+func (n *CreditNote) ID() string {
+	return n.id
+}
+
+func (n *CreditNote) CustomerID() string {
+	return n.customerID
+}
+
+func (n *CreditNote) SubscriptionID() string {
+	return n.subscriptionID
+}
+
+func (n *CreditNote) InvoiceID() string {
+	return n.invoiceID
+}
+
+func (n *CreditNote) Amount() int64 {
+	return n.amount
+}
+
+func (n *CreditNote) Currency() string {
+	return n.currency
+}
+
+func (n *CreditNote) Reason() CreditReason {
+	return n.reason
+}
+
+func (n *CreditNote) Memo() string {
+	return n.memo
+}
+
+func (n *CreditNote) Settlement() CreditSettlement {
+	return n.settlement
+}
+
+func (n *CreditNote) ProviderRefundID() string {
+	return n.providerRefundID
+}
+
+func (n *CreditNote) IssuedAt() time.Time {
+	return n.issuedAt
+}
@@ -0,0 +1,53 @@
+package domain
+
+import "fmt"
+
+// CurrencyMismatchError reports a refund in a different currency than the charge it refunds.
+// It matches ErrCurrencyMismatch with errors.Is.
+type CurrencyMismatchError struct {
+	ChargeCurrency string
+	RefundCurrency string
+}
+
+func (e *CurrencyMismatchError) Error() string {
+	return fmt.Sprintf("%s: refund in %s, charge in %s", ErrCurrencyMismatch, e.RefundCurrency, e.ChargeCurrency)
+}
+
+// Is makes errors.Is(err, ErrCurrencyMismatch) hold for every CurrencyMismatchError
+func (e *CurrencyMismatchError) Is(target error) bool {
+	return target == ErrCurrencyMismatch
+}
+
+// ValidateCurrency checks that code has the shape of an ISO 4217 code: three uppercase letters
+func ValidateCurrency(code string) error {
+	if len(code) != 3 {
+		return fmt.Errorf("%w: %q", ErrInvalidCurrency, code)
+	}
+	for _, r := range code {
+		if r < 'A' || r > 'Z' {
+			return fmt.Errorf("%w: %q", ErrInvalidCurrency, code)
+		}
+	}
+	return nil
+}
+
+// ValidatePlanCurrency checks that a plan is priced in DefaultCurrency. Charges, refunds
+// and credit balances are all kept in it, so a plan in any other would be billed in the
+// wrong currency.
+func ValidatePlanCurrency(code string) error {
+	if err := ValidateCurrency(code); err != nil {
+		return err
+	}
+	if code != DefaultCurrency {
+		return fmt.Errorf("%w: %q", ErrUnsupportedCurrency, code)
+	}
+	return nil
+}
+
+// CheckRefundCurrency rejects refunding a charge made in chargeCurrency in refundCurrency
+func CheckRefundCurrency(chargeCurrency, refundCurrency string) error {
+	if chargeCurrency != refundCurrency {
+		return &CurrencyMismatchError{ChargeCurrency: chargeCurrency, RefundCurrency: refundCurrency}
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package domain
+
+import "sort"
+
+// DiscountKind is what granted a discount, which decides the order discounts apply in
+type DiscountKind string
+
+const (
+	// DiscountRegional adjusts the list price for the customer's region, so it applies first
+	DiscountRegional DiscountKind = "regional"
+	// DiscountCoupon is a promotional code; a discount without a kind counts as a coupon
+	DiscountCoupon DiscountKind = "coupon"
+)
+
+// discountPrecedence orders the kinds; lower applies first
+var discountPrecedence = map[DiscountKind]int{
+	DiscountRegional: 0,
+	DiscountCoupon:   1,
+	"":               1,
+}
+
+// Discount reduces a charge, by PercentOff basis points or AmountOff cents
+type Discount struct {
+	Code       string
+	Kind       DiscountKind
+	PercentOff int64
+	AmountOff  int64
+}
+
+// DiscountPolicy limits how far discounts stack. The zero value allows any number of
+// discounts, up to the whole charge.
+type DiscountPolicy struct {
+	MaxStacked    int   // most discounts applied to one charge; zero means no limit
+	MaxPercentOff int64 // basis points of a charge the discounts together may take off; zero means 100%
+}
+
+// Validate rejects limits that can't be applied
+func (p DiscountPolicy) Validate() error {
+	if p.MaxStacked < 0 || p.MaxPercentOff < 0 || p.MaxPercentOff > basisPoints {
+		return ErrInvalidDiscountPolicy
+	}
+	return nil
+}
+
+// AppliedDiscount is one discount as it applied to a charge
+type AppliedDiscount struct {
+	Code   string
+	Kind   DiscountKind
+	Amount int64 // cents taken off
+	Capped bool  // cut short by the policy's MaxPercentOff
+}
+
+// DiscountResolution itemizes the discounts that applied to a charge
+type DiscountResolution struct {
+	Applied []AppliedDiscount
+	Total   int64 // cents taken off
+	Net     int64 // cents left to charge
+}
+
+// Pricing is the add-ons billed alongside a subscription's plan, the discounts its
+// charges get and the policy they stack under. Renewals, dunning retries, plan-change
+// proration and cancellation refunds all resolve the same Pricing, so a customer is
+// refunded what they were charged. The zero value bills the plan alone, undiscounted.
+type Pricing struct {
+	AddOns    []AddOnCharge
+	Discounts []Discount
+	Policy    DiscountPolicy
+}
+
+// Validate rejects add-ons that can't be billed and discounts that can't be applied
+func (p Pricing) Validate() error {
+	for _, a := range p.AddOns {
+		if a.Quantity < 0 || a.UnitPrice < 0 {
+			return ErrInvalidAddOn
+		}
+	}
+	for _, d := range p.Discounts {
+		if _, ok := discountPrecedence[d.Kind]; !ok {
+			return ErrInvalidDiscount
+		}
+		if d.PercentOff < 0 || d.PercentOff > basisPoints || d.AmountOff < 0 {
+			return ErrInvalidDiscount
+		}
+	}
+	return p.Policy.Validate()
+}
+
+// Resolve applies the discounts to a charge of amount cents. Regional discounts apply
+// before coupons; within a kind, percentages apply before fixed amounts, and otherwise
+// in the order given. Each percentage is of what is left after the discounts before it.
+// Once MaxStacked discounts have applied the rest are dropped, and the last one to
+// apply is capped so the discounts never take off more than MaxPercentOff.
+func (p Pricing) Resolve(amount int64) DiscountResolution {
+	ordered := append([]Discount(nil), p.Discounts...)
+	sort.SliceStable(ordered, func(a, b int) bool {
+		pa, pb := discountPrecedence[ordered[a].Kind], discountPrecedence[ordered[b].Kind]
+		if pa != pb {
+			return pa < pb
+		}
+		return ordered[a].PercentOff > 0 && ordered[b].PercentOff == 0
+	})
+
+	limit := amount
+	if p.Policy.MaxPercentOff > 0 {
+		limit = percentOf(amount, p.Policy.MaxPercentOff)
+	}
+
+	res := DiscountResolution{Net: amount}
+	for _, d := range ordered {
+		if p.Policy.MaxStacked > 0 && len(res.Applied) == p.Policy.MaxStacked {
+			break
+		}
+		off := percentOf(res.Net, d.PercentOff) + d.AmountOff
+		capped := false
+		if off > limit-res.Total {
+			off, capped = limit-res.Total, true
+		}
+		if off <= 0 {
+			continue
+		}
+		res.Total += off
+		res.Net -= off
+		res.Applied = append(res.Applied, AppliedDiscount{Code: d.Code, Kind: kindOrCoupon(d.Kind), Amount: off, Capped: capped})
+	}
+	return res
+}
+
+// kindOrCoupon reports a discount without a kind as the coupon it counts as
+func kindOrCoupon(kind DiscountKind) DiscountKind {
+	if kind == "" {
+		return DiscountCoupon
+	}
+	return kind
+}
+
+// PeriodPrice resolves pricing, and the subscription's coupon while it applies, against
+// the subscription's price for one billing period and its add-ons
+func (s *Subscription) PeriodPrice(pricing Pricing) DiscountResolution {
+	return s.priceAt(s.price, pricing)
+}
+
+// priceAt resolves the subscription's discounts against a period of a plan priced at
+// priceCents, with the add-ons billed alongside it
+func (s *Subscription) priceAt(priceCents int64, pricing Pricing) DiscountResolution {
+	return s.pricing(pricing).Resolve(priceCents + pricing.addOnsTotal())
+}
+
+// addOnsTotal is what the add-ons add to one billing period, in cents
+func (p Pricing) addOnsTotal() int64 {
+	var total int64
+	for _, a := range p.AddOns {
+		total += a.Quantity * a.UnitPrice
+	}
+	return total
+}
+
+// pricing adds the subscription's coupon to pricing's discounts, after them
+func (s *Subscription) pricing(pricing Pricing) Pricing {
+	if s.coupon.IsZero() {
+		return pricing
+	}
+	discounts := make([]Discount, 0, len(pricing.Discounts)+1)
+	discounts = append(discounts, pricing.Discounts...)
+	pricing.Discounts = append(discounts, s.coupon.Discount())
+	return pricing
+}
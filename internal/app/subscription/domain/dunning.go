@@ -0,0 +1,141 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DunningSchedule is the delay before each payment retry of a past-due subscription.
+// Its length is the number of retries made before the subscription expires.
+type DunningSchedule []time.Duration
+
+// DefaultDunningSchedule retries a failed charge after one day, then twice more three days apart
+var DefaultDunningSchedule = DunningSchedule{24 * time.Hour, 72 * time.Hour, 72 * time.Hour}
+
+// ParseDunningSchedule parses a comma-separated list of delays such as "24h,72h,72h"
+func ParseDunningSchedule(s string) (DunningSchedule, error) {
+	var schedule DunningSchedule
+	for _, part := range strings.Split(s, ",") {
+		d, err := time.ParseDuration(strings.TrimSpace(part))
+		if err != nil {
+			return nil, fmt.Errorf("invalid retry delay %q: %w", part, err)
+		}
+		schedule = append(schedule, d)
+	}
+	if len(schedule) == 0 {
+		return nil, ErrEmptyDunningSchedule
+	}
+	return schedule, nil
+}
+
+// MarkPastDue moves an active subscription into dunning after a failed charge of the
+// price less pricing's discounts
+func (s *Subscription) MarkPastDue(clock Clock, schedule DunningSchedule, pricing Pricing) (*SubscriptionPastDueEvent, error) {
+	if s.status != StatusActive {
+		return nil, ErrNotActive
+	}
+	if len(schedule) == 0 {
+		return nil, ErrEmptyDunningSchedule
+	}
+
+	now := clock.Now()
+	s.status = StatusPastDue
+	s.dunningAttempts = 0
+	s.nextPaymentRetryAt = now.Add(schedule[0])
+
+	event := &SubscriptionPastDueEvent{
+		SubscriptionID:     s.id,
+		CustomerID:         s.customerID,
+		AmountDue:          s.PeriodPrice(pricing).Net,
+		NextPaymentRetryAt: s.nextPaymentRetryAt,
+		OccurredAt:         now,
+	}
+
+	return event, nil
+}
+
+// PaymentRetryDue reports whether a past-due subscription should be charged again
+func (s *Subscription) PaymentRetryDue(clock Clock) bool {
+	return s.status == StatusPastDue && !clock.Now().Before(s.nextPaymentRetryAt)
+}
+
+// RecoverPayment returns a past-due subscription to ACTIVE after a successful retry
+// charged the price less pricing's discounts
+func (s *Subscription) RecoverPayment(clock Clock, pricing Pricing) (*SubscriptionRecoveredEvent, error) {
+	if s.status != StatusPastDue {
+		return nil, ErrNotPastDue
+	}
+
+	now := clock.Now()
+	event := &SubscriptionRecoveredEvent{
+		SubscriptionID: s.id,
+		CustomerID:     s.customerID,
+		AmountPaid:     s.PeriodPrice(pricing).Net,
+		Attempts:       s.dunningAttempts + 1,
+		RecoveredAt:    now,
+	}
+
+	s.status = StatusActive
+	s.clearDunning()
+
+	return event, nil
+}
+
+// RecordFailedPaymentRetry counts a failed retry and schedules the next one.
+// Once the schedule is exhausted no further retry is scheduled; see DunningExhausted.
+func (s *Subscription) RecordFailedPaymentRetry(clock Clock, schedule DunningSchedule) (*PaymentRetryFailedEvent, error) {
+	if s.status != StatusPastDue {
+		return nil, ErrNotPastDue
+	}
+
+	now := clock.Now()
+	s.dunningAttempts++
+	if s.dunningAttempts < int64(len(schedule)) {
+		s.nextPaymentRetryAt = now.Add(schedule[s.dunningAttempts])
+	} else {
+		s.nextPaymentRetryAt = time.Time{}
+	}
+
+	event := &PaymentRetryFailedEvent{
+		SubscriptionID:     s.id,
+		CustomerID:         s.customerID,
+		Attempt:            s.dunningAttempts,
+		NextPaymentRetryAt: s.nextPaymentRetryAt,
+		FailedAt:           now,
+	}
+
+	return event, nil
+}
+
+// DunningExhausted reports whether every retry in the schedule has failed
+func (s *Subscription) DunningExhausted(schedule DunningSchedule) bool {
+	return s.status == StatusPastDue && s.dunningAttempts >= int64(len(schedule))
+}
+
+// Expire cancels a past-due subscription whose payment could not be recovered.
+// No refund is due because the current period was never paid for.
+func (s *Subscription) Expire(clock Clock) (*SubscriptionExpiredEvent, error) {
+	if s.status != StatusPastDue {
+		return nil, ErrNotPastDue
+	}
+
+	now := clock.Now()
+	event := &SubscriptionExpiredEvent{
+		SubscriptionID: s.id,
+		CustomerID:     s.customerID,
+		Attempts:       s.dunningAttempts,
+		ExpiredAt:      now,
+	}
+
+	s.status = StatusCancelled
+	s.cancelledAt = now
+	s.clearDunning()
+
+	return event, nil
+}
+
+func (s *Subscription) clearDunning() {
+	s.dunningAttempts = 0
+	s.nextPaymentRetryAt = time.Time{}
+}
@@ -0,0 +1,130 @@
+package domain
+
+import "time"
+
+// EntitledStatuses are the subscription statuses that keep a plan's entitlements.
+// A trial has them in full until its end date, and so does a subscription pending cancellation, until
+// its period ends. A past-due subscription keeps them, at its EntitlementGrace, while
+// dunning retries the charge; they end when it is cancelled.
+var EntitledStatuses = []SubscriptionStatus{StatusActive, StatusPastDue, StatusTrialing, StatusPendingCancellation}
+
+// EntitlementGrace is how much of its plan's entitlements a PAST_DUE subscription keeps
+// while dunning retries the charge, in basis points of each limit. Unlimited features
+// stay unlimited under any grace above zero.
+type EntitlementGrace int64
+
+const (
+	// GraceFull keeps every entitlement until dunning gives up
+	GraceFull EntitlementGrace = basisPoints
+	// GraceNone suspends every entitlement as soon as a payment fails
+	GraceNone EntitlementGrace = 0
+)
+
+// Validate rejects a grace outside 0-100%
+func (g EntitlementGrace) Validate() error {
+	if g < GraceNone || g > GraceFull {
+		return ErrInvalidEntitlementGrace
+	}
+	return nil
+}
+
+// degrade applies the grace to an entitlement of a past-due subscription, reporting
+// false when nothing is left of it
+func (g EntitlementGrace) degrade(e Entitlement) (Entitlement, bool) {
+	if g <= GraceNone {
+		return Entitlement{}, false
+	}
+	if !e.Unlimited {
+		e.Limit = e.Limit * int64(g) / basisPoints
+	}
+	return e, true
+}
+
+// Entitlement is a feature a plan grants, up to Limit units of it unless Unlimited
+type Entitlement struct {
+	Feature   string
+	Limit     int64
+	Unlimited bool
+}
+
+// EntitlementDecision answers whether a customer may use a feature right now, and how
+// much of it. SubscriptionID and PlanID are the subscription that grants it.
+type EntitlementDecision struct {
+	CustomerID     string
+	Feature        string
+	Entitled       bool
+	Limit          int64
+	Unlimited      bool
+	Degraded       bool // granted by a PAST_DUE subscription under a grace below GraceFull
+	SubscriptionID string
+	PlanID         string
+}
+
+// GrantsEntitlements reports whether the subscription's plan entitlements apply
+func (s *Subscription) GrantsEntitlements() bool {
+	for _, status := range EntitledStatuses {
+		if s.status == status {
+			return true
+		}
+	}
+	return false
+}
+
+// EntitledAt reports whether the subscription's plan entitlements apply at now. A trial
+// loses them at its end date, whether or not it has been converted or ended yet.
+func (s *Subscription) EntitledAt(now time.Time) bool {
+	if s.status == StatusTrialing && !s.trialEndDate.IsZero() && !now.Before(s.trialEndDate) {
+		return false
+	}
+	return s.GrantsEntitlements()
+}
+
+// DecideEntitlement decides whether the customer's subscriptions entitle them to
+// feature at now, given the entitlements of each subscription's plan. Past-due subscriptions
+// grant them degraded to grace. When several subscriptions grant the feature, the most
+// generous limit wins, so a customer with a second subscription never gets less than
+// with one.
+func DecideEntitlement(customerID, feature string, subs []*Subscription, plans map[string][]Entitlement, grace EntitlementGrace, now time.Time) EntitlementDecision {
+	decision := EntitlementDecision{CustomerID: customerID, Feature: feature}
+	for _, sub := range subs {
+		if sub.customerID != customerID || !sub.EntitledAt(now) {
+			continue
+		}
+		degraded := sub.status == StatusPastDue && grace < GraceFull
+		for _, e := range plans[sub.planID] {
+			if e.Feature != feature {
+				continue
+			}
+			if degraded {
+				var ok bool
+				if e, ok = grace.degrade(e); !ok {
+					continue
+				}
+			}
+			if !moreGenerous(e, decision) {
+				continue
+			}
+			decision.Entitled = true
+			decision.Limit = e.Limit
+			decision.Unlimited = e.Unlimited
+			decision.Degraded = degraded
+			decision.SubscriptionID = sub.id
+			decision.PlanID = sub.planID
+		}
+	}
+	return decision
+}
+
+// moreGenerous reports whether e grants more than the decision so far
+func moreGenerous(e Entitlement, d EntitlementDecision) bool {
+	switch {
+	case !d.Entitled:
+		return true
+	case d.Unlimited:
+		return false
+	case e.Unlimited:
+		return true
+	default:
+		return e.Limit > d.Limit
+	}
+}
@@ -3,10 +3,115 @@ package domain
 import "errors"
 
 var (
-	ErrInvalidCustomer      = errors.New("invalid customer")
-	ErrAlreadyCancelled     = errors.New("subscription already cancelled")
-	ErrSubscriptionNotFound = errors.New("subscription not found")
-	ErrInvalidPrice         = errors.New("price must be positive")
-	ErrInvalidPlanID        = errors.New("plan ID cannot be empty")
-	ErrInvalidCustomerID    = errors.New("customer ID cannot be empty")
+	ErrInvalidCustomer              = errors.New("invalid customer")
+	ErrAlreadyCancelled             = errors.New("subscription already cancelled")
+	ErrSubscriptionNotFound         = errors.New("subscription not found")
+	ErrInvalidPrice                 = errors.New("price must be positive")
+	ErrInvalidPlanID                = errors.New("plan ID cannot be empty")
+	ErrInvalidCustomerID            = errors.New("customer ID cannot be empty")
+	ErrInvalidCustomerEmail         = errors.New("customer email is required to create the customer")
+	ErrNotRenewable                 = errors.New("only active subscriptions can be renewed")
+	ErrRenewalNotDue                = errors.New("subscription is not due for renewal")
+	ErrNotActive                    = errors.New("subscription is not active")
+	ErrNotPastDue                   = errors.New("subscription is not past due")
+	ErrPaymentRetryNotDue           = errors.New("payment retry is not due yet")
+	ErrEmptyDunningSchedule         = errors.New("dunning schedule must have at least one retry")
+	ErrCustomerHasLiveSubscriptions = errors.New("customer still has subscriptions that are not cancelled")
+	ErrRefundNotFound               = errors.New("refund not found")
+	ErrRefundAlreadySettled         = errors.New("refund has already settled or failed")
+	ErrInvalidRefundStatus          = errors.New("refund status must be PENDING, SUCCEEDED or FAILED")
+	ErrQueuedRefundNotFound         = errors.New("queued refund not found")
+	ErrQueuedRefundFailed           = errors.New("queued refund failed every attempt and is no longer sent")
+	ErrPaymentDeclined              = errors.New("payment declined")
+	ErrSamePlan                     = errors.New("subscription is already on this plan")
+	ErrPaymentMethodUsable          = errors.New("payment method can be charged at the next renewal")
+	ErrPaymentMethodAlreadyFlagged  = errors.New("payment method already flagged for this renewal")
+	ErrInvalidCurrency              = errors.New("currency must be an ISO 4217 code")
+	ErrUnsupportedCurrency          = errors.New("plans must be priced in " + DefaultCurrency)
+	ErrCurrencyMismatch             = errors.New("refund currency does not match the charge")
+	ErrAggregatesNotReady           = errors.New("reporting aggregates have not been computed yet")
+	ErrInvalidInvoiceItem           = errors.New("invoice items cannot have negative quantities, prices or rates, or discounts over 100%")
+	ErrInvalidDiscount              = errors.New("discounts must be regional or coupon, with no negative amounts or percentages over 100%")
+	ErrInvalidDiscountPolicy        = errors.New("discount policy cannot stack a negative number of discounts or cap them outside 0-100%")
+	ErrInvalidInvoiceID             = errors.New("invoice ID cannot be empty")
+	ErrInvalidCreditAmount          = errors.New("credit amount must be positive")
+	ErrCreditExceedsInvoice         = errors.New("credits would exceed the amount the invoice charged")
+	ErrInvalidCreditReason          = errors.New("credit reason must be outage, goodwill or billing_error")
+	ErrInvalidCreditSettlement      = errors.New("credit settlement must be balance or refund")
+	ErrCreditNoteNotFound           = errors.New("credit note not found")
+	ErrInvalidReferralCode          = errors.New("referral code must be 8 letters and digits")
+	ErrReferralCodeNotFound         = errors.New("referral code not found")
+	ErrReferralNotFound             = errors.New("referral not found")
+	ErrSelfReferral                 = errors.New("customers can't refer themselves")
+	ErrReferralAlreadyRewarded      = errors.New("referral has already been rewarded")
+	ErrInvalidFeature               = errors.New("feature cannot be empty")
+	ErrInvalidExternalProductID     = errors.New("external product ID cannot be empty")
+	ErrPlanMappedElsewhere          = errors.New("plan is already mapped to another billing product")
+	ErrRejectedByHook               = errors.New("change rejected by a lifecycle hook")
+	ErrInvalidCohortWindow          = errors.New("cohort window must be between 1 and 60 months")
+	ErrUnsupportedExportFormat      = errors.New("export format must be csv or json")
+	ErrInvalidMetric                = errors.New("metric cannot be empty")
+	ErrInvalidUsageQuantity         = errors.New("usage quantity must be positive")
+	ErrInvalidUsageThreshold        = errors.New("usage alert thresholds must be positive")
+	ErrMetricNotMetered             = errors.New("subscription's plan does not meter this metric")
+	ErrUsageAlertAlreadySent        = errors.New("usage alert already sent for this threshold and period")
+	ErrInvalidEntitlementGrace      = errors.New("entitlement grace must be between 0 and 10000 basis points")
+	ErrAlreadyPastDue               = errors.New("subscription is already past due")
+	ErrInvalidTrialDays             = errors.New("trial days must be positive")
+	ErrNotTrialing                  = errors.New("subscription is not in a trial")
+	ErrNoPaymentMethod              = errors.New("customer has no payment method that can be charged")
+	ErrAuthenticationRequired       = errors.New("charge requires customer authentication")
+	ErrAuthenticationNotFound       = errors.New("charge authentication not found")
+	ErrAuthenticationResolved       = errors.New("charge authentication has already succeeded or failed")
+	ErrInvalidAuthenticationStatus  = errors.New("authentication status must be PENDING, SUCCEEDED or FAILED")
+	ErrInvalidPortalScope           = errors.New("portal scopes must be cancel or change_plan, with at least one")
+	ErrInvalidPortalTTL             = errors.New("portal session lifetime must be between 1 second and 1 hour")
+	ErrInvalidPortalToken           = errors.New("invalid portal token")
+	ErrPortalSessionExpired         = errors.New("portal session has expired")
+	ErrPortalForbidden              = errors.New("portal session does not allow this")
+	ErrInvalidRenewalNotice         = errors.New("renewal notice days must be written plan=days and not be negative")
+	ErrRenewalNoticeNotRequired     = errors.New("no renewal notice is required for this subscription")
+	ErrRenewalNoticeNotDue          = errors.New("renewal notice is not due yet")
+	ErrRenewalNoticeAlreadySent     = errors.New("renewal notice already sent for this renewal")
+	ErrNotCancelled                 = errors.New("subscription is not cancelled")
+	ErrInvalidSurvey                = errors.New("cancellation survey needs a version and questions with unique IDs, prompts and a choice, rating or text kind")
+	ErrInvalidSurveyAnswer          = errors.New("survey answer must be to a question of the survey, once, with a value it accepts")
+	ErrSurveyAnswerRequired         = errors.New("survey question requires an answer")
+	ErrSurveyAlreadySubmitted       = errors.New("cancellation survey already submitted for this cancellation")
+	ErrInvalidSurveyWindow          = errors.New("survey report window must be between 1 and 60 months")
+	ErrInvalidReasonWindow          = errors.New("cancellation reason report window must be between 1 and 60 months")
+	ErrInvalidRetentionOffer        = errors.New("retention offer needs a rule and either a discount of 1-10000 basis points or a plan and price to move to")
+	ErrRetentionOfferNotFound       = errors.New("retention offer not found")
+	ErrRetentionOfferResolved       = errors.New("retention offer has already been accepted or declined")
+	ErrRetentionOfferExpired        = errors.New("retention offer has expired")
+	ErrInvalidSubscriptionBundle    = errors.New("add-ons need unique IDs, names, a positive quantity and a price, and metadata must be within limits")
+	ErrInvalidTemplateName          = errors.New("template name must be 1 to 100 characters")
+	ErrTemplateNotFound             = errors.New("subscription template not found")
+	ErrTemplateNameTaken            = errors.New("a subscription template with this name already exists")
+	ErrInvalidSubscriptionStatus    = errors.New("subscription status must be ACTIVE, CANCELLED, PAST_DUE, TRIALING, PAUSED or PENDING_CANCELLATION")
+	ErrInvalidPageToken             = errors.New("page token is malformed")
+	ErrInvalidPageSize              = errors.New("page size must be between 1 and 200")
+	ErrInvalidReadTimestamp         = errors.New("read timestamp cannot be in the future")
+	ErrNotPaused                    = errors.New("subscription is not paused")
+	ErrConcurrentModification       = errors.New("subscription was changed by another request; read it again and retry")
+	ErrInvalidIdempotencyKey        = errors.New("idempotency key must be at most 255 characters")
+	ErrIdempotencyKeyNotFound       = errors.New("idempotency key not found")
+	ErrInvalidBillingInterval       = errors.New("billing interval must be day, week, month or year")
+	ErrPlanNotFound                 = errors.New("plan not found")
+	ErrInvalidPlanName              = errors.New("plan name cannot be empty")
+	ErrPlanAlreadyExists            = errors.New("plan already exists")
+	ErrPlanInactive                 = errors.New("plan is not active")
+	ErrPlanPriceMismatch            = errors.New("price does not match the plan's")
+	ErrCancellationAlreadyScheduled = errors.New("subscription is already scheduled to cancel")
+	ErrCancellationNotDue           = errors.New("subscription is not scheduled to cancel yet")
+	ErrInvalidCouponCode            = errors.New("coupon code must be 1 to 64 letters, digits, dashes or underscores")
+	ErrInvalidCoupon                = errors.New("coupon needs either a percentage of 1-10000 basis points or an amount off, and a once, repeating or forever duration with periods only when repeating")
+	ErrCouponNotFound               = errors.New("coupon not found")
+	ErrCouponAlreadyExists          = errors.New("coupon already exists")
+	ErrCouponExpired                = errors.New("coupon has expired")
+	ErrCouponRedemptionLimitReached = errors.New("coupon has been redeemed as many times as it allows")
+	ErrCouponAlreadyApplied         = errors.New("subscription already has a coupon")
+	ErrInvalidAddOn                 = errors.New("add-on needs an ID, a name, a positive quantity and a price")
+	ErrAddOnNotFound                = errors.New("add-on is not attached to the subscription")
+	ErrInvalidCancellationReason    = errors.New("cancellation reason must be too_expensive, missing_features, switched_service, unused, service_issues, plan_retired or other, with details of at most 1000 characters")
 )
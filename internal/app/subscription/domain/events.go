@@ -2,19 +2,280 @@ package domain
 
 import "time"
 
-// SubscriptionCreatedEvent is emitted when a subscription is created
+// SubscriptionCreatedEvent is emitted when a subscription is created. ReferralID and
+// ReferrerCustomerID are set when it was created with a referral code.
 type SubscriptionCreatedEvent struct {
-	SubscriptionID string
-	CustomerID     string
-	PlanID         string
-	Price          int64 // cents
-	CreatedAt      time.Time
+	SubscriptionID     string
+	CustomerID         string
+	PlanID             string
+	Price              int64 // cents
+	ReferralID         string
+	ReferrerCustomerID string
+	CouponCode         string    // empty unless the subscription was created with a coupon
+	TrialEndDate       time.Time // zero unless the subscription starts with a trial
+	CreatedAt          time.Time
 }
 
 // SubscriptionCancelledEvent is emitted when a subscription is cancelled
 type SubscriptionCancelledEvent struct {
 	SubscriptionID string
 	CustomerID     string
-	RefundAmount   int64 // cents
+	RefundAmount   int64              // cents
+	CreditAmount   int64              // cents granted to the credit balance in place of a refund
+	Discounts      []AppliedDiscount  // on the period price the refund was prorated from
+	Reason         CancellationReason // zero if none was given
 	CancelledAt    time.Time
 }
+
+// SubscriptionCancellationScheduledEvent is emitted when a subscription is set to
+// cancel at the end of its current period, at CancelAt
+type SubscriptionCancellationScheduledEvent struct {
+	SubscriptionID string
+	CustomerID     string
+	PlanID         string
+	CancelAt       time.Time
+	ScheduledAt    time.Time
+}
+
+// SubscriptionRenewedEvent is emitted when a subscription enters a new billing period
+type SubscriptionRenewedEvent struct {
+	SubscriptionID string
+	CustomerID     string
+	PlanID         string
+	Amount         int64 // cents, after discounts
+	Discount       int64 // cents taken off the price
+	Discounts      []AppliedDiscount
+	CreditApplied  int64 // cents of Amount paid from the credit balance rather than charged
+	PeriodStart    time.Time
+	PeriodEnd      time.Time
+	RenewedAt      time.Time
+}
+
+// SubscriptionPlanChangedEvent is emitted when a subscription moves to another plan mid-period
+type SubscriptionPlanChangedEvent struct {
+	SubscriptionID string
+	CustomerID     string
+	OldPlanID      string
+	NewPlanID      string
+	OldPrice       int64             // cents
+	NewPrice       int64             // cents
+	ProratedAmount int64             // cents owed for the rest of the period; negative for a downgrade
+	CreditAmount   int64             // cents of a downgrade granted to the credit balance
+	Discounts      []AppliedDiscount // on the new price
+	ChangedAt      time.Time
+}
+
+// SubscriptionAddOnsChangedEvent is emitted when an add-on is attached to or detached
+// from a subscription mid-period
+type SubscriptionAddOnsChangedEvent struct {
+	SubscriptionID string
+	CustomerID     string
+	AddOnID        string
+	AddOns         []AddOnCharge // attached once the change is made
+	ProratedAmount int64         // cents owed for the rest of the period; negative for a detach
+	ChangedAt      time.Time
+}
+
+// TrialConvertedEvent is emitted when a trial converts to a paid subscription and its
+// first period is charged
+type TrialConvertedEvent struct {
+	SubscriptionID string
+	CustomerID     string
+	PlanID         string
+	Amount         int64 // cents, after discounts
+	Discount       int64 // cents taken off the price
+	Discounts      []AppliedDiscount
+	TrialEndDate   time.Time
+	PeriodStart    time.Time
+	PeriodEnd      time.Time
+	ConvertedAt    time.Time
+}
+
+// SubscriptionPausedEvent is emitted when a subscription is paused; it isn't billed
+// or renewed until it is resumed
+type SubscriptionPausedEvent struct {
+	SubscriptionID string
+	CustomerID     string
+	PlanID         string
+	PausedAt       time.Time
+}
+
+// SubscriptionResumedEvent is emitted when a paused subscription is resumed. The
+// period it was paused in is extended by the time paused, so PeriodEnd is when it
+// now renews.
+type SubscriptionResumedEvent struct {
+	SubscriptionID string
+	CustomerID     string
+	PlanID         string
+	PausedAt       time.Time
+	PeriodStart    time.Time
+	PeriodEnd      time.Time
+	ResumedAt      time.Time
+}
+
+// SubscriptionPastDueEvent is emitted when a charge fails and dunning starts
+type SubscriptionPastDueEvent struct {
+	SubscriptionID     string
+	CustomerID         string
+	AmountDue          int64  // cents
+	FailureReason      string // as the billing provider reported it, or AuthenticationFailureReason; empty for a declined renewal
+	NextPaymentRetryAt time.Time
+	OccurredAt         time.Time
+}
+
+// AuthenticationRequiredEvent is emitted when a renewal charge is held for strong
+// customer authentication, so the customer can be sent to ActionURL to complete it
+type AuthenticationRequiredEvent struct {
+	AuthenticationID string
+	SubscriptionID   string
+	CustomerID       string
+	Amount           int64 // cents
+	Currency         string
+	ActionURL        string
+	RequestedAt      time.Time
+}
+
+// PaymentRetryFailedEvent is emitted when a dunning retry charge fails
+type PaymentRetryFailedEvent struct {
+	SubscriptionID     string
+	CustomerID         string
+	Attempt            int64
+	NextPaymentRetryAt time.Time // zero when no retries remain
+	FailedAt           time.Time
+}
+
+// SubscriptionRecoveredEvent is emitted when a dunning retry succeeds
+type SubscriptionRecoveredEvent struct {
+	SubscriptionID string
+	CustomerID     string
+	AmountPaid     int64 // cents
+	CreditApplied  int64 // cents of AmountPaid paid from the credit balance rather than charged
+	Attempts       int64
+	RecoveredAt    time.Time
+}
+
+// SubscriptionExpiredEvent is emitted when dunning is exhausted and the subscription is cancelled
+type SubscriptionExpiredEvent struct {
+	SubscriptionID string
+	CustomerID     string
+	Attempts       int64
+	ExpiredAt      time.Time
+}
+
+// PaymentMethodExpiringEvent is emitted when a subscription's payment method can't be
+// charged at its next renewal, so the customer can be asked to update it in time
+type PaymentMethodExpiringEvent struct {
+	SubscriptionID string
+	CustomerID     string
+	Brand          string
+	Last4          string
+	ExpiresAt      time.Time // zero when the payment method is missing or invalid rather than expiring
+	RenewsAt       time.Time
+	DetectedAt     time.Time
+}
+
+// RenewalUpcomingEvent is emitted NoticeDays before a subscription renews, so the
+// customer is told what they will be charged, and when, while they can still cancel
+type RenewalUpcomingEvent struct {
+	SubscriptionID string
+	CustomerID     string
+	PlanID         string
+	Amount         int64 // cents, after discounts, before any credit balance
+	Currency       string
+	RenewsAt       time.Time
+	NoticeDays     int64
+	NotifiedAt     time.Time
+}
+
+// RefundSettledEvent is emitted when the billing provider confirms a refund was paid out
+type RefundSettledEvent struct {
+	RefundID       string
+	SubscriptionID string
+	CustomerID     string
+	Amount         int64 // cents
+	Currency       string
+	RequestedAt    time.Time
+	SettledAt      time.Time
+}
+
+// RefundFailedEvent is emitted when the billing provider reports a refund failed
+type RefundFailedEvent struct {
+	RefundID       string
+	SubscriptionID string
+	CustomerID     string
+	Amount         int64 // cents
+	Currency       string
+	Reason         string
+	RequestedAt    time.Time
+	FailedAt       time.Time
+}
+
+// CreditNoteIssuedEvent is emitted when a credit note is issued, for finance. Balance is
+// the customer's credit balance after a balance settlement; ProviderRefundID is set
+// for a refund settlement.
+type CreditNoteIssuedEvent struct {
+	CreditNoteID     string
+	SubscriptionID   string
+	CustomerID       string
+	InvoiceID        string
+	Amount           int64 // cents
+	Currency         string
+	Reason           CreditReason
+	Settlement       CreditSettlement
+	ProviderRefundID string
+	Balance          int64 // cents
+	IssuedAt         time.Time
+}
+
+// ReferralRewardedEvent is emitted when a referee's first paid renewal credits both
+// parties of a referral
+type ReferralRewardedEvent struct {
+	ReferralID            string
+	Code                  string
+	ReferrerCustomerID    string
+	RefereeCustomerID     string
+	RefereeSubscriptionID string
+	ReferrerCredit        int64 // cents
+	RefereeCredit         int64 // cents
+	Currency              string
+	RewardedAt            time.Time
+}
+
+// PlanCatalogUpdatedEvent is emitted when a plan is created, changed or deactivated,
+// by hand or by a catalog sync with the billing provider. Changes lists every field
+// that differed; for a new plan, every field that was set.
+type PlanCatalogUpdatedEvent struct {
+	PlanID            string
+	ExternalProductID string
+	ExternalPriceID   string
+	Created           bool
+	Changes           []PlanFieldChange
+	UpdatedAt         time.Time
+}
+
+// UsageThresholdReachedEvent is emitted the first time in a billing period that a
+// subscription's usage of a metric reaches an alert threshold, so the customer can be
+// told before overage is charged
+type UsageThresholdReachedEvent struct {
+	SubscriptionID string
+	CustomerID     string
+	Metric         string
+	ThresholdBP    int64 // basis points of Included
+	Quantity       int64 // used so far this period
+	Included       int64
+	PeriodStart    time.Time
+	ReachedAt      time.Time
+}
+
+// RetentionOfferAcceptedEvent is emitted when a customer asking to cancel takes a
+// retention offer instead
+type RetentionOfferAcceptedEvent struct {
+	OfferID        string
+	SubscriptionID string
+	CustomerID     string
+	Rule           string
+	Kind           RetentionOfferKind
+	CreditAmount   int64                         // cents granted by a discount
+	PlanChange     *SubscriptionPlanChangedEvent // the move made by a downgrade
+	AcceptedAt     time.Time
+}
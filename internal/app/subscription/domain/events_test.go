@@ -0,0 +1,301 @@
+package domain_test
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/testkit/golden"
+)
+
+var (
+	at        = time.Date(2024, 3, 10, 15, 4, 5, 0, time.UTC)
+	periodEnd = at.AddDate(0, 0, 30)
+	discounts = []domain.AppliedDiscount{{Code: "SPRING", Kind: domain.DiscountCoupon, Amount: 300, Capped: true}}
+)
+
+var planChanged = &domain.SubscriptionPlanChangedEvent{
+	SubscriptionID: "sub-1",
+	CustomerID:     "cust-1",
+	OldPlanID:      "plan-pro",
+	NewPlanID:      "plan-basic",
+	OldPrice:       3000,
+	NewPrice:       1000,
+	ProratedAmount: -1000,
+	CreditAmount:   1000,
+	Discounts:      discounts,
+	ChangedAt:      at,
+}
+
+// events has one value of every event type, with every field set, so that the
+// snapshot of each shows every field consumers can see
+var events = map[string]any{
+	"SubscriptionCreatedEvent": domain.SubscriptionCreatedEvent{
+		SubscriptionID:     "sub-1",
+		CustomerID:         "cust-1",
+		PlanID:             "plan-pro",
+		Price:              3000,
+		ReferralID:         "ref-1",
+		ReferrerCustomerID: "cust-2",
+		CouponCode:         "LAUNCH20",
+		TrialEndDate:       at.AddDate(0, 0, 14),
+		CreatedAt:          at,
+	},
+	"SubscriptionCancelledEvent": domain.SubscriptionCancelledEvent{
+		SubscriptionID: "sub-1",
+		CustomerID:     "cust-1",
+		RefundAmount:   1500,
+		CreditAmount:   500,
+		Discounts:      discounts,
+		Reason:         domain.CancellationReason{Code: domain.CancellationReasonTooExpensive, Details: "Found a cheaper plan"},
+		CancelledAt:    at,
+	},
+	"SubscriptionRenewedEvent": domain.SubscriptionRenewedEvent{
+		SubscriptionID: "sub-1",
+		CustomerID:     "cust-1",
+		PlanID:         "plan-pro",
+		Amount:         2700,
+		Discount:       300,
+		Discounts:      discounts,
+		CreditApplied:  700,
+		PeriodStart:    at,
+		PeriodEnd:      periodEnd,
+		RenewedAt:      at,
+	},
+	"SubscriptionPlanChangedEvent": *planChanged,
+	"SubscriptionAddOnsChangedEvent": domain.SubscriptionAddOnsChangedEvent{
+		SubscriptionID: "sub-1",
+		CustomerID:     "cust-1",
+		AddOnID:        "extra-storage",
+		AddOns:         []domain.AddOnCharge{{ID: "extra-storage", Name: "Extra storage", Quantity: 2, UnitPrice: 500}},
+		ProratedAmount: 667,
+		ChangedAt:      at,
+	},
+	"TrialConvertedEvent": domain.TrialConvertedEvent{
+		SubscriptionID: "sub-1",
+		CustomerID:     "cust-1",
+		PlanID:         "plan-pro",
+		Amount:         2700,
+		Discount:       300,
+		Discounts:      discounts,
+		TrialEndDate:   at,
+		PeriodStart:    at,
+		PeriodEnd:      periodEnd,
+		ConvertedAt:    at,
+	},
+	"SubscriptionCancellationScheduledEvent": domain.SubscriptionCancellationScheduledEvent{
+		SubscriptionID: "sub-1",
+		CustomerID:     "cust-1",
+		PlanID:         "plan-pro",
+		CancelAt:       periodEnd,
+		ScheduledAt:    at,
+	},
+	"SubscriptionPausedEvent": domain.SubscriptionPausedEvent{
+		SubscriptionID: "sub-1",
+		CustomerID:     "cust-1",
+		PlanID:         "plan-pro",
+		PausedAt:       at,
+	},
+	"SubscriptionResumedEvent": domain.SubscriptionResumedEvent{
+		SubscriptionID: "sub-1",
+		CustomerID:     "cust-1",
+		PlanID:         "plan-pro",
+		PausedAt:       at.AddDate(0, 0, -10),
+		PeriodStart:    at,
+		PeriodEnd:      periodEnd,
+		ResumedAt:      at,
+	},
+	"SubscriptionPastDueEvent": domain.SubscriptionPastDueEvent{
+		SubscriptionID:     "sub-1",
+		CustomerID:         "cust-1",
+		AmountDue:          3000,
+		FailureReason:      "card_declined",
+		NextPaymentRetryAt: at.Add(24 * time.Hour),
+		OccurredAt:         at,
+	},
+	"AuthenticationRequiredEvent": domain.AuthenticationRequiredEvent{
+		AuthenticationID: "auth-1",
+		SubscriptionID:   "sub-1",
+		CustomerID:       "cust-1",
+		Amount:           3000,
+		Currency:         "USD",
+		ActionURL:        "https://billing.example.com/authenticate/pay-1",
+		RequestedAt:      at,
+	},
+	"PaymentRetryFailedEvent": domain.PaymentRetryFailedEvent{
+		SubscriptionID:     "sub-1",
+		CustomerID:         "cust-1",
+		Attempt:            2,
+		NextPaymentRetryAt: at.Add(72 * time.Hour),
+		FailedAt:           at,
+	},
+	"SubscriptionRecoveredEvent": domain.SubscriptionRecoveredEvent{
+		SubscriptionID: "sub-1",
+		CustomerID:     "cust-1",
+		AmountPaid:     3000,
+		CreditApplied:  500,
+		Attempts:       2,
+		RecoveredAt:    at,
+	},
+	"SubscriptionExpiredEvent": domain.SubscriptionExpiredEvent{
+		SubscriptionID: "sub-1",
+		CustomerID:     "cust-1",
+		Attempts:       4,
+		ExpiredAt:      at,
+	},
+	"PaymentMethodExpiringEvent": domain.PaymentMethodExpiringEvent{
+		SubscriptionID: "sub-1",
+		CustomerID:     "cust-1",
+		Brand:          "visa",
+		Last4:          "4242",
+		ExpiresAt:      at.AddDate(0, 0, 10),
+		RenewsAt:       periodEnd,
+		DetectedAt:     at,
+	},
+	"RenewalUpcomingEvent": domain.RenewalUpcomingEvent{
+		SubscriptionID: "sub-1",
+		CustomerID:     "cust-1",
+		PlanID:         "plan-pro",
+		Amount:         2700,
+		Currency:       "USD",
+		RenewsAt:       periodEnd,
+		NoticeDays:     7,
+		NotifiedAt:     at,
+	},
+	"RefundSettledEvent": domain.RefundSettledEvent{
+		RefundID:       "refund-1",
+		SubscriptionID: "sub-1",
+		CustomerID:     "cust-1",
+		Amount:         1500,
+		Currency:       "USD",
+		RequestedAt:    at,
+		SettledAt:      at.Add(time.Hour),
+	},
+	"RefundFailedEvent": domain.RefundFailedEvent{
+		RefundID:       "refund-1",
+		SubscriptionID: "sub-1",
+		CustomerID:     "cust-1",
+		Amount:         1500,
+		Currency:       "USD",
+		Reason:         "insufficient_funds",
+		RequestedAt:    at,
+		FailedAt:       at.Add(time.Hour),
+	},
+	"CreditNoteIssuedEvent": domain.CreditNoteIssuedEvent{
+		CreditNoteID:     "cn-1",
+		SubscriptionID:   "sub-1",
+		CustomerID:       "cust-1",
+		InvoiceID:        "inv-1",
+		Amount:           500,
+		Currency:         "USD",
+		Reason:           domain.CreditReasonGoodwill,
+		Settlement:       domain.SettleAsRefund,
+		ProviderRefundID: "re_123",
+		Balance:          1200,
+		IssuedAt:         at,
+	},
+	"ReferralRewardedEvent": domain.ReferralRewardedEvent{
+		ReferralID:            "ref-1",
+		Code:                  "FRIEND10",
+		ReferrerCustomerID:    "cust-2",
+		RefereeCustomerID:     "cust-1",
+		RefereeSubscriptionID: "sub-1",
+		ReferrerCredit:        1000,
+		RefereeCredit:         500,
+		Currency:              "USD",
+		RewardedAt:            at,
+	},
+	"PlanCatalogUpdatedEvent": domain.PlanCatalogUpdatedEvent{
+		PlanID:            "plan-pro",
+		ExternalProductID: "prod_123",
+		ExternalPriceID:   "price_123",
+		Created:           true,
+		Changes:           []domain.PlanFieldChange{{Field: "price_cents", Old: "2500", New: "3000"}},
+		UpdatedAt:         at,
+	},
+	"UsageThresholdReachedEvent": domain.UsageThresholdReachedEvent{
+		SubscriptionID: "sub-1",
+		CustomerID:     "cust-1",
+		Metric:         "api_calls",
+		ThresholdBP:    8000,
+		Quantity:       8000,
+		Included:       10000,
+		PeriodStart:    at,
+		ReachedAt:      at,
+	},
+	"RetentionOfferAcceptedEvent": domain.RetentionOfferAcceptedEvent{
+		OfferID:        "offer-1",
+		SubscriptionID: "sub-1",
+		CustomerID:     "cust-1",
+		Rule:           "downgrade-to-basic",
+		Kind:           domain.RetentionOfferDowngrade,
+		CreditAmount:   1500,
+		PlanChange:     planChanged,
+		AcceptedAt:     at,
+	},
+}
+
+// Hooks and notifiers hand these events to consumers outside this service, which
+// serialize them with encoding/json, so a renamed field is a breaking change
+func TestEvents_JSONSnapshots(t *testing.T) {
+	for name, event := range events {
+		t.Run(name, func(t *testing.T) {
+			golden.AssertJSON(t, "events/"+name, event)
+		})
+	}
+}
+
+func TestEvents_SnapshotsCoverEveryEvent(t *testing.T) {
+	declared := declaredEvents(t)
+	var covered []string
+	for name := range events {
+		covered = append(covered, name)
+	}
+	sort.Strings(covered)
+
+	if !reflect.DeepEqual(declared, covered) {
+		t.Errorf("every event in events.go needs a snapshot\ndeclared: %v\ncovered:  %v", declared, covered)
+	}
+}
+
+func TestEvents_SnapshotsSetEveryField(t *testing.T) {
+	for name, event := range events {
+		v := reflect.ValueOf(event)
+		for i := 0; i < v.NumField(); i++ {
+			if v.Field(i).IsZero() {
+				t.Errorf("%s.%s is unset, so its snapshot doesn't cover it", name, v.Type().Field(i).Name)
+			}
+		}
+	}
+}
+
+// declaredEvents lists the event types declared in events.go
+func declaredEvents(t *testing.T) []string {
+	t.Helper()
+	file, err := parser.ParseFile(token.NewFileSet(), "events.go", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			name := spec.(*ast.TypeSpec).Name.Name
+			if strings.HasSuffix(name, "Event") {
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,161 @@
+package domain
+
+import "time"
+
+// InvoiceLineKind identifies what a line of an invoice charges or credits
+type InvoiceLineKind string
+
+const (
+	LineBase     InvoiceLineKind = "base"
+	LineSeats    InvoiceLineKind = "seats"
+	LineAddOn    InvoiceLineKind = "add_on"
+	LineUsage    InvoiceLineKind = "usage"
+	LineDiscount InvoiceLineKind = "discount"
+	LineTax      InvoiceLineKind = "tax"
+)
+
+// Rates are in basis points, so 2500 is 25% and 887 is 8.87%
+const basisPoints = 10000
+
+// SeatCharge prices seats beyond those the plan's base price includes
+type SeatCharge struct {
+	Quantity  int64
+	Included  int64
+	UnitPrice int64 // cents per extra seat
+}
+
+// AddOnCharge is a recurring add-on billed alongside the plan
+type AddOnCharge struct {
+	ID        string
+	Name      string
+	Quantity  int64
+	UnitPrice int64 // cents
+}
+
+// UsageCharge prices metered usage beyond what the plan includes. Usage is billed in
+// arrears, so the next invoice carries the usage of the current period.
+type UsageCharge struct {
+	Metric    string
+	Quantity  int64
+	Included  int64
+	UnitPrice int64 // cents per unit over Included
+}
+
+// InvoiceItems is everything billed at renewal besides the plan's base price
+type InvoiceItems struct {
+	Seats     SeatCharge
+	AddOns    []AddOnCharge
+	Usage     []UsageCharge
+	Discounts []Discount // resolved against the subtotal; see Pricing.Resolve
+	TaxRate   int64      // basis points, applied after discounts
+}
+
+// InvoiceLine is one line of an invoice. Discounts have a negative Amount.
+type InvoiceLine struct {
+	Kind        InvoiceLineKind
+	Description string
+	Quantity    int64
+	UnitAmount  int64 // cents
+	Amount      int64 // cents
+}
+
+// InvoicePreview is the invoice a subscription's next renewal will charge. It is
+// computed, never stored or charged.
+type InvoicePreview struct {
+	SubscriptionID string
+	CustomerID     string
+	PlanID         string
+	Currency       string
+	PeriodStart    time.Time
+	PeriodEnd      time.Time
+	Lines          []InvoiceLine
+	Subtotal       int64 // cents, before discounts and tax
+	Discount       int64 // cents
+	Tax            int64 // cents
+	Total          int64 // cents
+}
+
+// PreviewInvoice computes the invoice for the period after the current one: the base
+// price, extra seats, add-ons and usage overages, less discounts stacked under policy,
+// plus tax on the discounted amount. Discounts never take the total below zero, and
+// percentages round half up to the cent.
+func (s *Subscription) PreviewInvoice(items InvoiceItems, cycle BillingCycle, policy DiscountPolicy) (*InvoicePreview, error) {
+	if s.status != StatusActive {
+		return nil, ErrNotRenewable
+	}
+	if err := items.validate(); err != nil {
+		return nil, err
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+
+	periodStart := s.CurrentPeriodEnd(cycle)
+	preview := &InvoicePreview{
+		SubscriptionID: s.id,
+		CustomerID:     s.customerID,
+		PlanID:         s.planID,
+		Currency:       DefaultCurrency,
+		PeriodStart:    periodStart,
+		PeriodEnd:      cycle.PeriodEnd(periodStart),
+	}
+
+	preview.addCharge(InvoiceLine{Kind: LineBase, Description: s.planID, Quantity: 1, UnitAmount: s.price})
+	if extra := items.Seats.Quantity - items.Seats.Included; extra > 0 {
+		preview.addCharge(InvoiceLine{Kind: LineSeats, Description: "additional seats", Quantity: extra, UnitAmount: items.Seats.UnitPrice})
+	}
+	for _, a := range items.AddOns {
+		preview.addCharge(InvoiceLine{Kind: LineAddOn, Description: a.Name, Quantity: a.Quantity, UnitAmount: a.UnitPrice})
+	}
+	for _, u := range items.Usage {
+		if over := u.Quantity - u.Included; over > 0 {
+			preview.addCharge(InvoiceLine{Kind: LineUsage, Description: u.Metric + " overage", Quantity: over, UnitAmount: u.UnitPrice})
+		}
+	}
+
+	discounts := Pricing{Discounts: items.Discounts, Policy: policy}.Resolve(preview.Subtotal)
+	for _, d := range discounts.Applied {
+		preview.Lines = append(preview.Lines, InvoiceLine{Kind: LineDiscount, Description: d.Code, Quantity: 1, UnitAmount: -d.Amount, Amount: -d.Amount})
+	}
+	preview.Discount = discounts.Total
+	remaining := discounts.Net
+
+	preview.Tax = percentOf(remaining, items.TaxRate)
+	if preview.Tax > 0 {
+		preview.Lines = append(preview.Lines, InvoiceLine{Kind: LineTax, Description: "tax", Quantity: 1, UnitAmount: preview.Tax, Amount: preview.Tax})
+	}
+	preview.Total = remaining + preview.Tax
+
+	return preview, nil
+}
+
+func (p *InvoicePreview) addCharge(line InvoiceLine) {
+	line.Amount = line.Quantity * line.UnitAmount
+	p.Lines = append(p.Lines, line)
+	p.Subtotal += line.Amount
+}
+
+func (items InvoiceItems) validate() error {
+	if items.Seats.Quantity < 0 || items.Seats.Included < 0 || items.Seats.UnitPrice < 0 || items.TaxRate < 0 {
+		return ErrInvalidInvoiceItem
+	}
+	for _, a := range items.AddOns {
+		if a.Quantity < 0 || a.UnitPrice < 0 {
+			return ErrInvalidInvoiceItem
+		}
+	}
+	for _, u := range items.Usage {
+		if u.Quantity < 0 || u.Included < 0 || u.UnitPrice < 0 {
+			return ErrInvalidInvoiceItem
+		}
+	}
+	if (Pricing{Discounts: items.Discounts}).Validate() != nil {
+		return ErrInvalidInvoiceItem
+	}
+	return nil
+}
+
+// percentOf returns rate basis points of cents, rounded half up
+func percentOf(cents, rate int64) int64 {
+	return (cents*rate + basisPoints/2) / basisPoints
+}
@@ -0,0 +1,56 @@
+package domain
+
+import "time"
+
+// Pause stops an active subscription's billing until it is resumed. The time paused
+// doesn't count towards the current period: a cancellation while paused is prorated
+// as of the pause, and Resume pushes the period's end out by the time paused, so the
+// customer keeps the part of it they paid for.
+func (s *Subscription) Pause(clock Clock) (*SubscriptionPausedEvent, error) {
+	if s.status != StatusActive {
+		return nil, ErrNotActive
+	}
+
+	now := clock.Now()
+	s.status = StatusPaused
+	s.pausedAt = now
+
+	event := &SubscriptionPausedEvent{
+		SubscriptionID: s.id,
+		CustomerID:     s.customerID,
+		PlanID:         s.planID,
+		PausedAt:       now,
+	}
+
+	return event, nil
+}
+
+// Resume makes a paused subscription ACTIVE again. Its current period ends later by
+// the time paused, so it renews that much later; the period keeps its start, and the
+// days the customer had left of it.
+func (s *Subscription) Resume(clock Clock, cycle BillingCycle) (*SubscriptionResumedEvent, error) {
+	if s.status != StatusPaused {
+		return nil, ErrNotPaused
+	}
+
+	now := clock.Now()
+	// A clock behind the one that paused it counts as no time paused
+	if paused := now.Sub(s.pausedAt); paused > 0 {
+		s.currentPeriodEnd = s.CurrentPeriodEnd(cycle).Add(paused)
+	}
+	pausedAt := s.pausedAt
+	s.status = StatusActive
+	s.pausedAt = time.Time{}
+
+	event := &SubscriptionResumedEvent{
+		SubscriptionID: s.id,
+		CustomerID:     s.customerID,
+		PlanID:         s.planID,
+		PausedAt:       pausedAt,
+		PeriodStart:    s.currentPeriodStart,
+		PeriodEnd:      s.CurrentPeriodEnd(cycle),
+		ResumedAt:      now,
+	}
+
+	return event, nil
+}
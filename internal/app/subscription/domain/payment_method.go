@@ -0,0 +1,22 @@
+package domain
+
+import "time"
+
+// PaymentMethod is the billing provider's view of a customer's default payment method
+type PaymentMethod struct {
+	Valid     bool // the provider holds a payment method it can charge
+	Brand     string
+	Last4     string
+	ExpiresAt time.Time // first instant it can no longer be charged; zero if it doesn't expire
+}
+
+// CardExpiry returns when a card printed with the given expiry month and year stops
+// working: cards are valid through the last day of their expiry month.
+func CardExpiry(month, year int) time.Time {
+	return time.Date(year, time.Month(month)+1, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// UsableAt reports whether the payment method can still be charged at t
+func (p PaymentMethod) UsableAt(t time.Time) bool {
+	return p.Valid && (p.ExpiresAt.IsZero() || t.Before(p.ExpiresAt))
+}
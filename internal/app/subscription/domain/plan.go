@@ -0,0 +1,370 @@
+package domain
+
+import (
+	"strconv"
+	"time"
+)
+
+// BillingInterval is how often a plan bills; each period lasts one interval
+type BillingInterval string
+
+const (
+	IntervalDay   BillingInterval = "day"
+	IntervalWeek  BillingInterval = "week"
+	IntervalMonth BillingInterval = "month"
+	IntervalYear  BillingInterval = "year"
+)
+
+// Validate rejects unknown intervals; empty is a plan without one
+func (i BillingInterval) Validate() error {
+	switch i {
+	case "", IntervalDay, IntervalWeek, IntervalMonth, IntervalYear:
+		return nil
+	default:
+		return ErrInvalidBillingInterval
+	}
+}
+
+// BillingCycle is how long a subscription's billing periods last: one interval of its
+// plan, or Days days for plans without one
+type BillingCycle struct {
+	Interval BillingInterval
+	Days     int64
+}
+
+// CycleOfDays is a cycle of a fixed number of days, as plans without an interval bill
+func CycleOfDays(days int64) BillingCycle {
+	return BillingCycle{Days: days}
+}
+
+// PeriodEnd returns when the period started at start ends. Monthly and yearly periods
+// end on the same day of the month, or on the last day of a shorter month, so a period
+// started on 31 January ends on the last day of February.
+func (c BillingCycle) PeriodEnd(start time.Time) time.Time {
+	switch c.Interval {
+	case IntervalDay:
+		return start.AddDate(0, 0, 1)
+	case IntervalWeek:
+		return start.AddDate(0, 0, 7)
+	case IntervalMonth:
+		return addMonths(start, 1)
+	case IntervalYear:
+		return addMonths(start, 12)
+	default:
+		return start.AddDate(0, 0, int(c.Days))
+	}
+}
+
+// addMonths adds n months to t, clamping the day to the last of the target month
+// rather than overflowing into the next as time.AddDate does
+func addMonths(t time.Time, n int) time.Time {
+	year, month, day := t.Date()
+	first := time.Date(year, month+time.Month(n), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	if last := first.AddDate(0, 1, -1).Day(); day > last {
+		day = last
+	}
+	return first.AddDate(0, 0, day-1)
+}
+
+// CatalogPlan is one plan as the billing provider's product catalog lists it: a
+// product and its recurring price
+type CatalogPlan struct {
+	PlanID            string // our plan ID; providers without a mapping use the product ID
+	ExternalProductID string
+	ExternalPriceID   string
+	Name              string
+	PriceCents        int64
+	Currency          string
+	Interval          BillingInterval // empty when the catalog doesn't say
+	Active            bool
+}
+
+// Validate rejects catalog entries that can't become a plan
+func (c CatalogPlan) Validate() error {
+	if c.PlanID == "" {
+		return ErrInvalidPlanID
+	}
+	if c.ExternalProductID == "" {
+		return ErrInvalidExternalProductID
+	}
+	if c.PriceCents <= 0 {
+		return ErrInvalidPrice
+	}
+	if err := c.Interval.Validate(); err != nil {
+		return err
+	}
+	return ValidatePlanCurrency(c.Currency)
+}
+
+// PlanFieldChange is one field of a plan that differed from the catalog
+type PlanFieldChange struct {
+	Field string
+	Old   string
+	New   string
+}
+
+// Plan is a plan customers subscribe to. Plans imported from the billing provider
+// keep the external IDs they were imported from, so later syncs update them in place.
+type Plan struct {
+	id                string
+	name              string
+	priceCents        int64
+	currency          string
+	interval          BillingInterval // empty bills every configured number of days
+	trialDays         int64           // zero starts subscriptions without a trial
+	active            bool
+	externalProductID string
+	externalPriceID   string
+	createdAt         time.Time
+	updatedAt         time.Time
+}
+
+// PlanDetails are the fields of a plan maintained by hand; the catalog sync leaves
+// TrialDays alone
+type PlanDetails struct {
+	Name       string
+	PriceCents int64
+	Currency   string
+	Interval   BillingInterval
+	TrialDays  int64
+}
+
+// Validate rejects details a plan can't have
+func (d PlanDetails) Validate() error {
+	if d.Name == "" {
+		return ErrInvalidPlanName
+	}
+	if d.PriceCents <= 0 {
+		return ErrInvalidPrice
+	}
+	if d.TrialDays < 0 {
+		return ErrInvalidTrialDays
+	}
+	if err := d.Interval.Validate(); err != nil {
+		return err
+	}
+	return ValidatePlanCurrency(d.Currency)
+}
+
+// NewPlan creates an active plan maintained by hand
+func NewPlan(id string, details PlanDetails, clock Clock) (*Plan, *PlanCatalogUpdatedEvent, error) {
+	if id == "" {
+		return nil, nil, ErrInvalidPlanID
+	}
+	if err := details.Validate(); err != nil {
+		return nil, nil, err
+	}
+
+	now := clock.Now()
+	plan := &Plan{id: id, createdAt: now, updatedAt: now}
+	changes := plan.set(details)
+	changes = append(changes, PlanFieldChange{Field: "active", Old: "false", New: "true"})
+	plan.active = true
+
+	return plan, &PlanCatalogUpdatedEvent{PlanID: id, Created: true, Changes: changes, UpdatedAt: now}, nil
+}
+
+// Update changes the plan's details and returns the event listing what changed. A plan
+// that already has them returns no event and is left unchanged. An imported plan's
+// name, price, currency and interval go back to the catalog's on its next sync.
+func (p *Plan) Update(details PlanDetails, clock Clock) (*PlanCatalogUpdatedEvent, error) {
+	if err := details.Validate(); err != nil {
+		return nil, err
+	}
+
+	changes := p.set(details)
+	if len(changes) == 0 {
+		return nil, nil
+	}
+	return p.updated(changes, clock), nil
+}
+
+// Deactivate stops new subscriptions to the plan. Existing ones keep renewing on it.
+func (p *Plan) Deactivate(clock Clock) (*PlanCatalogUpdatedEvent, error) {
+	if !p.active {
+		return nil, ErrPlanInactive
+	}
+	p.active = false
+	return p.updated([]PlanFieldChange{{Field: "active", Old: "true", New: "false"}}, clock), nil
+}
+
+// set copies the details onto the plan and returns those that changed
+func (p *Plan) set(details PlanDetails) []PlanFieldChange {
+	var changes []PlanFieldChange
+	set := func(field, from, to string) {
+		if from != to {
+			changes = append(changes, PlanFieldChange{Field: field, Old: from, New: to})
+		}
+	}
+
+	set("name", p.name, details.Name)
+	set("price_cents", strconv.FormatInt(p.priceCents, 10), strconv.FormatInt(details.PriceCents, 10))
+	set("currency", p.currency, details.Currency)
+	set("billing_interval", string(p.interval), string(details.Interval))
+	set("trial_days", strconv.FormatInt(p.trialDays, 10), strconv.FormatInt(details.TrialDays, 10))
+
+	p.name = details.Name
+	p.priceCents = details.PriceCents
+	p.currency = details.Currency
+	p.interval = details.Interval
+	p.trialDays = details.TrialDays
+	return changes
+}
+
+// updated stamps the plan as updated now and returns the event for the changes
+func (p *Plan) updated(changes []PlanFieldChange, clock Clock) *PlanCatalogUpdatedEvent {
+	now := clock.Now()
+	p.updatedAt = now
+	return &PlanCatalogUpdatedEvent{
+		PlanID:            p.id,
+		ExternalProductID: p.externalProductID,
+		ExternalPriceID:   p.externalPriceID,
+		Changes:           changes,
+		UpdatedAt:         now,
+	}
+}
+
+// NewPlanFromCatalog imports a plan from the billing provider's catalog
+func NewPlanFromCatalog(entry CatalogPlan, clock Clock) (*Plan, *PlanCatalogUpdatedEvent, error) {
+	if err := entry.Validate(); err != nil {
+		return nil, nil, err
+	}
+
+	now := clock.Now()
+	plan := &Plan{id: entry.PlanID, createdAt: now}
+	changes := plan.apply(entry)
+	plan.updatedAt = now
+
+	event := &PlanCatalogUpdatedEvent{
+		PlanID:            plan.id,
+		ExternalProductID: plan.externalProductID,
+		ExternalPriceID:   plan.externalPriceID,
+		Created:           true,
+		Changes:           changes,
+		UpdatedAt:         now,
+	}
+	return plan, event, nil
+}
+
+// SyncFromCatalog updates the plan to match the catalog and returns the event listing
+// what drifted. A plan that already matches returns no event and is left unchanged.
+func (p *Plan) SyncFromCatalog(entry CatalogPlan, clock Clock) (*PlanCatalogUpdatedEvent, error) {
+	if err := entry.Validate(); err != nil {
+		return nil, err
+	}
+	if p.externalProductID != "" && p.externalProductID != entry.ExternalProductID {
+		return nil, ErrPlanMappedElsewhere
+	}
+
+	changes := p.apply(entry)
+	if len(changes) == 0 {
+		return nil, nil
+	}
+	return p.updated(changes, clock), nil
+}
+
+// apply copies the catalog's fields onto the plan and returns those that changed
+func (p *Plan) apply(entry CatalogPlan) []PlanFieldChange {
+	var changes []PlanFieldChange
+	set := func(field, from, to string) {
+		if from != to {
+			changes = append(changes, PlanFieldChange{Field: field, Old: from, New: to})
+		}
+	}
+
+	set("name", p.name, entry.Name)
+	set("price_cents", strconv.FormatInt(p.priceCents, 10), strconv.FormatInt(entry.PriceCents, 10))
+	set("currency", p.currency, entry.Currency)
+	// A catalog that doesn't give the interval leaves the plan's own
+	if entry.Interval != "" {
+		set("billing_interval", string(p.interval), string(entry.Interval))
+		p.interval = entry.Interval
+	}
+	set("active", strconv.FormatBool(p.active), strconv.FormatBool(entry.Active))
+	set("external_product_id", p.externalProductID, entry.ExternalProductID)
+	set("external_price_id", p.externalPriceID, entry.ExternalPriceID)
+
+	p.name = entry.Name
+	p.priceCents = entry.PriceCents
+	p.currency = entry.Currency
+	p.active = entry.Active
+	p.externalProductID = entry.ExternalProductID
+	p.externalPriceID = entry.ExternalPriceID
+	return changes
+}
+
+// ReconstructPlan rebuilds a plan from persistence
+func ReconstructPlan(id, name string, priceCents int64, currency string, interval BillingInterval, trialDays int64, active bool, externalProductID, externalPriceID string, createdAt, updatedAt time.Time) *Plan {
+	return &Plan{
+		id:                id,
+		name:              name,
+		priceCents:        priceCents,
+		currency:          currency,
+		interval:          interval,
+		trialDays:         trialDays,
+		active:            active,
+		externalProductID: externalProductID,
+		externalPriceID:   externalPriceID,
+		createdAt:         createdAt,
+		updatedAt:         updatedAt,
+	}
+}
+
+// Getters
+func (p *Plan) ID() string {
+	return p.id
+}
+
+func (p *Plan) Name() string {
+	return p.name
+}
+
+func (p *Plan) PriceCents() int64 {
+	return p.priceCents
+}
+
+func (p *Plan) Currency() string {
+	return p.currency
+}
+
+// BillingInterval is how often the plan bills, or empty when it bills every configured
+// number of days
+func (p *Plan) BillingInterval() BillingInterval {
+	return p.interval
+}
+
+// BillingCycle is the length of the plan's periods; defaultDays is the length of those
+// of a plan without an interval
+func (p *Plan) BillingCycle(defaultDays int64) BillingCycle {
+	return BillingCycle{Interval: p.interval, Days: defaultDays}
+}
+
+// TrialDays is the free trial subscriptions to the plan start with
+func (p *Plan) TrialDays() int64 {
+	return p.trialDays
+}
+
+// Details returns the plan's fields maintained by hand
+func (p *Plan) Details() PlanDetails {
+	return PlanDetails{Name: p.name, PriceCents: p.priceCents, Currency: p.currency, Interval: p.interval, TrialDays: p.trialDays}
+}
+
+func (p *Plan) Active() bool {
+	return p.active
+}
+
+func (p *Plan) ExternalProductID() string {
+	return p.externalProductID
+}
+
+func (p *Plan) ExternalPriceID() string {
+	return p.externalPriceID
+}
+
+func (p *Plan) CreatedAt() time.Time {
+	return p.createdAt
+}
+
+func (p *Plan) UpdatedAt() time.Time {
+	return p.updatedAt
+}
@@ -0,0 +1,125 @@
+package domain
+
+import "time"
+
+// PortalScope is an action the customer portal may take on a customer's behalf
+type PortalScope string
+
+const (
+	PortalScopeCancel     PortalScope = "cancel"
+	PortalScopeChangePlan PortalScope = "change_plan"
+)
+
+const (
+	// DefaultPortalSessionTTL is how long a portal session lasts when no lifetime is asked for
+	DefaultPortalSessionTTL = 15 * time.Minute
+	// MaxPortalSessionTTL bounds how long a portal session can last
+	MaxPortalSessionTTL = time.Hour
+)
+
+// PortalSession lets the self-service portal act for one customer, within its scopes,
+// until it expires. It travels as a signed token, so it can't be revoked; keep it short.
+type PortalSession struct {
+	id         string
+	customerID string
+	scopes     []PortalScope
+	issuedAt   time.Time
+	expiresAt  time.Time
+}
+
+// NewPortalSession starts a session for the customer lasting ttl, or
+// DefaultPortalSessionTTL when ttl is zero
+func NewPortalSession(id, customerID string, scopes []PortalScope, ttl time.Duration, clock Clock) (*PortalSession, error) {
+	if customerID == "" {
+		return nil, ErrInvalidCustomerID
+	}
+	if ttl == 0 {
+		ttl = DefaultPortalSessionTTL
+	}
+	if ttl < time.Second || ttl > MaxPortalSessionTTL {
+		return nil, ErrInvalidPortalTTL
+	}
+	if err := validatePortalScopes(scopes); err != nil {
+		return nil, err
+	}
+
+	now := clock.Now()
+	return &PortalSession{
+		id:         id,
+		customerID: customerID,
+		scopes:     append([]PortalScope(nil), scopes...),
+		issuedAt:   now,
+		expiresAt:  now.Add(ttl),
+	}, nil
+}
+
+// ReconstructPortalSession rebuilds a session from a verified token
+func ReconstructPortalSession(id, customerID string, scopes []PortalScope, issuedAt, expiresAt time.Time) *PortalSession {
+	return &PortalSession{
+		id:         id,
+		customerID: customerID,
+		scopes:     scopes,
+		issuedAt:   issuedAt,
+		expiresAt:  expiresAt,
+	}
+}
+
+func validatePortalScopes(scopes []PortalScope) error {
+	if len(scopes) == 0 {
+		return ErrInvalidPortalScope
+	}
+	for _, scope := range scopes {
+		if scope != PortalScopeCancel && scope != PortalScopeChangePlan {
+			return ErrInvalidPortalScope
+		}
+	}
+	return nil
+}
+
+// ValidAt fails with ErrPortalSessionExpired once the session has expired
+func (p *PortalSession) ValidAt(clock Clock) error {
+	if !clock.Now().Before(p.expiresAt) {
+		return ErrPortalSessionExpired
+	}
+	return nil
+}
+
+// Allows reports whether the session includes the scope
+func (p *PortalSession) Allows(scope PortalScope) bool {
+	for _, s := range p.scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Authorize fails with ErrPortalForbidden unless the subscription belongs to the
+// session's customer and the session includes the scope
+func (p *PortalSession) Authorize(sub *Subscription, scope PortalScope) error {
+	if sub.customerID != p.customerID || !p.Allows(scope) {
+		return ErrPortalForbidden
+	}
+	return nil
+}
+
+// Getters
+func (p *PortalSession) ID() string {
+	return p.id
+}
+
+func (p *PortalSession) CustomerID() string {
+	return p.customerID
+}
+
+func (p *PortalSession) Scopes() []PortalScope {
+	return append([]PortalScope(nil), p.scopes...)
+}
+
+func (p *PortalSession) IssuedAt() time.Time {
+	return p.issuedAt
+}
+
+func (p *PortalSession) ExpiresAt() time.Time {
+	return p.expiresAt
+}
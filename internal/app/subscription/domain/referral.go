@@ -0,0 +1,187 @@
+package domain
+
+import (
+	"crypto/rand"
+	"io"
+	"strings"
+	"time"
+)
+
+// ReferralStatus represents whether a referral has paid off yet
+type ReferralStatus string
+
+const (
+	ReferralPending  ReferralStatus = "PENDING"
+	ReferralRewarded ReferralStatus = "REWARDED"
+)
+
+// ReferralCodeAlphabet is the characters referral codes are made of, without the ones
+// that are easily misread (0/O, 1/I/L)
+const ReferralCodeAlphabet = "23456789ABCDEFGHJKMNPQRSTUVWXYZ"
+
+// ReferralCodeLength is the length of a referral code
+const ReferralCodeLength = 8
+
+// NormalizeReferralCode uppercases a code as typed by a customer and checks its shape
+func NormalizeReferralCode(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if len(code) != ReferralCodeLength {
+		return "", ErrInvalidReferralCode
+	}
+	for _, r := range code {
+		if !strings.ContainsRune(ReferralCodeAlphabet, r) {
+			return "", ErrInvalidReferralCode
+		}
+	}
+	return code, nil
+}
+
+// GenerateReferralCode returns a random referral code
+func GenerateReferralCode() (string, error) {
+	// Bytes at or above the largest multiple of the alphabet size are redrawn, so every
+	// character is equally likely
+	limit := 256 - 256%len(ReferralCodeAlphabet)
+	code := make([]byte, 0, ReferralCodeLength)
+	buf := make([]byte, ReferralCodeLength)
+	for len(code) < ReferralCodeLength {
+		if _, err := io.ReadFull(rand.Reader, buf); err != nil {
+			return "", err
+		}
+		for _, b := range buf {
+			if int(b) < limit && len(code) < ReferralCodeLength {
+				code = append(code, ReferralCodeAlphabet[int(b)%len(ReferralCodeAlphabet)])
+			}
+		}
+	}
+	return string(code), nil
+}
+
+// ReferralReward is the credit a referral grants each party once it pays off
+type ReferralReward struct {
+	ReferrerCredit int64 // cents
+	RefereeCredit  int64 // cents
+}
+
+// Referral tracks a subscription created with another customer's referral code until
+// the referee's first paid renewal, when both parties are credited
+type Referral struct {
+	id                    string
+	code                  string
+	referrerCustomerID    string
+	refereeCustomerID     string
+	refereeSubscriptionID string
+	status                ReferralStatus
+	referrerCredit        int64 // cents
+	refereeCredit         int64 // cents
+	createdAt             time.Time
+	rewardedAt            time.Time
+}
+
+// NewReferral records that sub was created with referrerCustomerID's code. Customers
+// can't refer themselves.
+func NewReferral(id, code, referrerCustomerID string, sub *Subscription, clock Clock) (*Referral, error) {
+	if referrerCustomerID == sub.customerID {
+		return nil, ErrSelfReferral
+	}
+	return &Referral{
+		id:                    id,
+		code:                  code,
+		referrerCustomerID:    referrerCustomerID,
+		refereeCustomerID:     sub.customerID,
+		refereeSubscriptionID: sub.id,
+		status:                ReferralPending,
+		createdAt:             clock.Now(),
+	}, nil
+}
+
+// ReconstructReferral rebuilds a referral from persistence
+func ReconstructReferral(id, code, referrerCustomerID, refereeCustomerID, refereeSubscriptionID string, status ReferralStatus, referrerCredit, refereeCredit int64, createdAt, rewardedAt time.Time) *Referral {
+	return &Referral{
+		id:                    id,
+		code:                  code,
+		referrerCustomerID:    referrerCustomerID,
+		refereeCustomerID:     refereeCustomerID,
+		refereeSubscriptionID: refereeSubscriptionID,
+		status:                status,
+		referrerCredit:        referrerCredit,
+		refereeCredit:         refereeCredit,
+		createdAt:             createdAt,
+		rewardedAt:            rewardedAt,
+	}
+}
+
+// Reward grants both parties their credit, as credit balance entries with the given
+// IDs. A party whose reward is zero gets no entry. A referral is rewarded once.
+func (r *Referral) Reward(clock Clock, reward ReferralReward, referrerEntryID, refereeEntryID string) ([]*CreditEntry, *ReferralRewardedEvent, error) {
+	if r.status != ReferralPending {
+		return nil, nil, ErrReferralAlreadyRewarded
+	}
+
+	now := clock.Now()
+	r.status = ReferralRewarded
+	r.referrerCredit = reward.ReferrerCredit
+	r.refereeCredit = reward.RefereeCredit
+	r.rewardedAt = now
+
+	var entries []*CreditEntry
+	if r.referrerCredit > 0 {
+		entries = append(entries, NewCreditEntry(referrerEntryID, r.referrerCustomerID, r.referrerCredit, DefaultCurrency, CreditSourceReferral, r.id, clock))
+	}
+	if r.refereeCredit > 0 {
+		entries = append(entries, NewCreditEntry(refereeEntryID, r.refereeCustomerID, r.refereeCredit, DefaultCurrency, CreditSourceReferral, r.id, clock))
+	}
+
+	event := &ReferralRewardedEvent{
+		ReferralID:            r.id,
+		Code:                  r.code,
+		ReferrerCustomerID:    r.referrerCustomerID,
+		RefereeCustomerID:     r.refereeCustomerID,
+		RefereeSubscriptionID: r.refereeSubscriptionID,
+		ReferrerCredit:        r.referrerCredit,
+		RefereeCredit:         r.refereeCredit,
+		Currency:              DefaultCurrency,
+		RewardedAt:            now,
+	}
+	return entries, event, nil
+}
+
+// Getters
+func (r *Referral) ID() string {
+	return r.id
+}
+
+func (r *Referral) Code() string {
+	return r.code
+}
+
+func (r *Referral) ReferrerCustomerID() string {
+	return r.referrerCustomerID
+}
+
+func (r *Referral) RefereeCustomerID() string {
+	return r.refereeCustomerID
+}
+
+func (r *Referral) RefereeSubscriptionID() string {
+	return r.refereeSubscriptionID
+}
+
+func (r *Referral) Status() ReferralStatus {
+	return r.status
+}
+
+func (r *Referral) ReferrerCredit() int64 {
+	return r.referrerCredit
+}
+
+func (r *Referral) RefereeCredit() int64 {
+	return r.refereeCredit
+}
+
+func (r *Referral) CreatedAt() time.Time {
+	return r.createdAt
+}
+
+func (r *Referral) RewardedAt() time.Time {
+	return r.rewardedAt
+}
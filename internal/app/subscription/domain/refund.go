@@ -0,0 +1,156 @@
+package domain
+
+import "time"
+
+// RefundStatus represents where a refund is in the billing provider's asynchronous flow
+type RefundStatus string
+
+const (
+	RefundPending   RefundStatus = "PENDING"
+	RefundSucceeded RefundStatus = "SUCCEEDED"
+	RefundFailed    RefundStatus = "FAILED"
+)
+
+// Refund tracks a refund the billing provider has accepted but may not have paid out yet
+type Refund struct {
+	id               string
+	subscriptionID   string
+	customerID       string
+	amount           int64 // cents
+	currency         string
+	providerRefundID string
+	status           RefundStatus
+	failureReason    string
+	requestedAt      time.Time
+	settledAt        time.Time
+}
+
+// NewPendingRefund records a refund the billing provider accepted under providerRefundID
+func NewPendingRefund(id, subscriptionID, customerID string, amount int64, currency, providerRefundID string, clock Clock) *Refund {
+	return &Refund{
+		id:               id,
+		subscriptionID:   subscriptionID,
+		customerID:       customerID,
+		amount:           amount,
+		currency:         currency,
+		providerRefundID: providerRefundID,
+		status:           RefundPending,
+		requestedAt:      clock.Now(),
+	}
+}
+
+// ReconstructRefund rebuilds a refund from persistence
+func ReconstructRefund(id, subscriptionID, customerID string, amount int64, currency, providerRefundID string, status RefundStatus, failureReason string, requestedAt, settledAt time.Time) *Refund {
+	return &Refund{
+		id:               id,
+		subscriptionID:   subscriptionID,
+		customerID:       customerID,
+		amount:           amount,
+		currency:         currency,
+		providerRefundID: providerRefundID,
+		status:           status,
+		failureReason:    failureReason,
+		requestedAt:      requestedAt,
+		settledAt:        settledAt,
+	}
+}
+
+// Settle marks a pending refund as paid out
+func (r *Refund) Settle(clock Clock) (*RefundSettledEvent, error) {
+	if r.status != RefundPending {
+		return nil, ErrRefundAlreadySettled
+	}
+
+	r.status = RefundSucceeded
+	r.settledAt = clock.Now()
+
+	return &RefundSettledEvent{
+		RefundID:       r.id,
+		SubscriptionID: r.subscriptionID,
+		CustomerID:     r.customerID,
+		Amount:         r.amount,
+		Currency:       r.currency,
+		RequestedAt:    r.requestedAt,
+		SettledAt:      r.settledAt,
+	}, nil
+}
+
+// Fail marks a pending refund as failed at the provider
+func (r *Refund) Fail(clock Clock, reason string) (*RefundFailedEvent, error) {
+	if r.status != RefundPending {
+		return nil, ErrRefundAlreadySettled
+	}
+
+	r.status = RefundFailed
+	r.failureReason = reason
+	r.settledAt = clock.Now()
+
+	return &RefundFailedEvent{
+		RefundID:       r.id,
+		SubscriptionID: r.subscriptionID,
+		CustomerID:     r.customerID,
+		Amount:         r.amount,
+		Currency:       r.currency,
+		Reason:         reason,
+		RequestedAt:    r.requestedAt,
+		FailedAt:       r.settledAt,
+	}, nil
+}
+
+// ApplyOutcome moves the refund to the status reported by the billing provider.
+// A still-pending status changes nothing and returns no event.
+func (r *Refund) ApplyOutcome(clock Clock, status RefundStatus, reason string) (*RefundSettledEvent, *RefundFailedEvent, error) {
+	switch status {
+	case RefundPending:
+		return nil, nil, nil
+	case RefundSucceeded:
+		event, err := r.Settle(clock)
+		return event, nil, err
+	case RefundFailed:
+		event, err := r.Fail(clock, reason)
+		return nil, event, err
+	default:
+		return nil, nil, ErrInvalidRefundStatus
+	}
+}
+
+// Getters
+func (r *Refund) ID() string {
+	return r.id
+}
+
+func (r *Refund) SubscriptionID() string {
+	return r.subscriptionID
+}
+
+func (r *Refund) CustomerID() string {
+	return r.customerID
+}
+
+func (r *Refund) Amount() int64 {
+	return r.amount
+}
+
+func (r *Refund) Currency() string {
+	return r.currency
+}
+
+func (r *Refund) ProviderRefundID() string {
+	return r.providerRefundID
+}
+
+func (r *Refund) Status() RefundStatus {
+	return r.status
+}
+
+func (r *Refund) FailureReason() string {
+	return r.failureReason
+}
+
+func (r *Refund) RequestedAt() time.Time {
+	return r.requestedAt
+}
+
+func (r *Refund) SettledAt() time.Time {
+	return r.settledAt
+}
@@ -0,0 +1,155 @@
+package domain
+
+import "time"
+
+// QueuedRefundStatus is whether the refunds worker still sends a queued refund
+type QueuedRefundStatus string
+
+const (
+	QueuedRefundQueued QueuedRefundStatus = "QUEUED"
+	// QueuedRefundFailed has failed every attempt it was allowed. It is no longer sent
+	// and stays in the outbox to be refunded by hand.
+	QueuedRefundFailed QueuedRefundStatus = "FAILED"
+)
+
+// QueuedRefund is a refund owed to a customer that hasn't been sent to the billing
+// provider yet. It is saved with the cancellation that owes it, so a cancellation is
+// never committed without its refund, and the refunds worker sends it later. Refunds
+// that failed to send straight away, such as that of an add-on charge whose attach
+// didn't commit, are queued the same way.
+type QueuedRefund struct {
+	id             string
+	subscriptionID string
+	customerID     string
+	planID         string // resolves the billing provider
+	amount         int64  // cents
+	currency       string
+	reason         string // why the refund is owed, as the billing provider is told
+	idempotencyKey string // the same key the cancellation would have sent directly
+	correlationID  string
+	status         QueuedRefundStatus
+	attempts       int64
+	lastError      string
+	queuedAt       time.Time
+	nextAttemptAt  time.Time
+}
+
+// NewQueuedRefund queues a refund owed to sub's customer for reason, due to be sent
+// straight away
+func NewQueuedRefund(id string, sub *Subscription, amount int64, currency, reason, idempotencyKey, correlationID string, clock Clock) *QueuedRefund {
+	now := clock.Now()
+	return &QueuedRefund{
+		id:             id,
+		subscriptionID: sub.ID(),
+		customerID:     sub.CustomerID(),
+		planID:         sub.PlanID(),
+		amount:         amount,
+		currency:       currency,
+		reason:         reason,
+		idempotencyKey: idempotencyKey,
+		correlationID:  correlationID,
+		status:         QueuedRefundQueued,
+		queuedAt:       now,
+		nextAttemptAt:  now,
+	}
+}
+
+// ReconstructQueuedRefund rebuilds a queued refund from persistence
+func ReconstructQueuedRefund(id, subscriptionID, customerID, planID string, amount int64, currency, reason, idempotencyKey, correlationID string, status QueuedRefundStatus, attempts int64, lastError string, queuedAt, nextAttemptAt time.Time) *QueuedRefund {
+	return &QueuedRefund{
+		id:             id,
+		subscriptionID: subscriptionID,
+		customerID:     customerID,
+		planID:         planID,
+		amount:         amount,
+		currency:       currency,
+		reason:         reason,
+		idempotencyKey: idempotencyKey,
+		correlationID:  correlationID,
+		status:         status,
+		attempts:       attempts,
+		lastError:      lastError,
+		queuedAt:       queuedAt,
+		nextAttemptAt:  nextAttemptAt,
+	}
+}
+
+// Sent records that the provider accepted the refund under providerRefundID, returning
+// the pending refund that tracks it from now on
+func (q *QueuedRefund) Sent(refundID, providerRefundID string, clock Clock) *Refund {
+	return NewPendingRefund(refundID, q.subscriptionID, q.customerID, q.amount, q.currency, providerRefundID, clock)
+}
+
+// Postpone records a failed attempt to send the refund and when to try again
+func (q *QueuedRefund) Postpone(clock Clock, reason string, delay time.Duration) {
+	q.attempts++
+	q.lastError = reason
+	q.nextAttemptAt = clock.Now().Add(delay)
+}
+
+// Fail records a last failed attempt, after which the refund is no longer sent
+func (q *QueuedRefund) Fail(reason string) {
+	q.attempts++
+	q.lastError = reason
+	q.status = QueuedRefundFailed
+}
+
+// Getters
+func (q *QueuedRefund) ID() string {
+	return q.id
+}
+
+func (q *QueuedRefund) SubscriptionID() string {
+	return q.subscriptionID
+}
+
+func (q *QueuedRefund) CustomerID() string {
+	return q.customerID
+}
+
+func (q *QueuedRefund) PlanID() string {
+	return q.planID
+}
+
+func (q *QueuedRefund) Amount() int64 {
+	return q.amount
+}
+
+func (q *QueuedRefund) Currency() string {
+	return q.currency
+}
+
+// Reason is why the refund is owed; empty for refunds queued before reasons were
+// recorded, all of them owed by cancellations
+func (q *QueuedRefund) Reason() string {
+	return q.reason
+}
+
+func (q *QueuedRefund) IdempotencyKey() string {
+	return q.idempotencyKey
+}
+
+func (q *QueuedRefund) CorrelationID() string {
+	return q.correlationID
+}
+
+func (q *QueuedRefund) Status() QueuedRefundStatus {
+	return q.status
+}
+
+// Attempts is how many times sending the refund has failed
+func (q *QueuedRefund) Attempts() int64 {
+	return q.attempts
+}
+
+func (q *QueuedRefund) LastError() string {
+	return q.lastError
+}
+
+func (q *QueuedRefund) QueuedAt() time.Time {
+	return q.queuedAt
+}
+
+func (q *QueuedRefund) NextAttemptAt() time.Time {
+	return q.nextAttemptAt
+}
@@ -0,0 +1,112 @@
+package domain
+
+import (
+	"strconv"
+	"strings"
+)
+
+// RenewalNoticePolicy decides how many days before a renewal the customer is told about
+// it. Plans pick their own notice, and the customer's region can require more: some
+// jurisdictions set a legal minimum notice for automatic renewals.
+type RenewalNoticePolicy struct {
+	DefaultDays       int64            // notice for plans without their own; 0 sends none
+	PlanDays          map[string]int64 // notice by plan ID
+	RegionMinimumDays map[string]int64 // least notice the law requires, by customer region
+}
+
+// ParseNoticeDays parses a comma-separated list of key=days pairs, such as
+// "pro=14,team=30"; an empty string is an empty map
+func ParseNoticeDays(s string) (map[string]int64, error) {
+	days := make(map[string]int64)
+	if strings.TrimSpace(s) == "" {
+		return days, nil
+	}
+	for _, part := range strings.Split(s, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok || key == "" {
+			return nil, ErrInvalidRenewalNotice
+		}
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || n < 0 {
+			return nil, ErrInvalidRenewalNotice
+		}
+		days[key] = n
+	}
+	return days, nil
+}
+
+// Validate rejects negative notice periods
+func (p RenewalNoticePolicy) Validate() error {
+	if p.DefaultDays < 0 {
+		return ErrInvalidRenewalNotice
+	}
+	for _, days := range []map[string]int64{p.PlanDays, p.RegionMinimumDays} {
+		for _, n := range days {
+			if n < 0 {
+				return ErrInvalidRenewalNotice
+			}
+		}
+	}
+	return nil
+}
+
+// DaysFor returns the notice for a subscription to the plan by a customer in the
+// region: the plan's notice, raised to the region's legal minimum. Zero means none.
+func (p RenewalNoticePolicy) DaysFor(planID, region string) int64 {
+	days, ok := p.PlanDays[planID]
+	if !ok {
+		days = p.DefaultDays
+	}
+	if minimum := p.RegionMinimumDays[region]; minimum > days {
+		days = minimum
+	}
+	return days
+}
+
+// MaxDays returns the longest notice the policy can ask for, so a scheduler knows how
+// far ahead to look for renewals
+func (p RenewalNoticePolicy) MaxDays() int64 {
+	longest := p.DefaultDays
+	for _, days := range []map[string]int64{p.PlanDays, p.RegionMinimumDays} {
+		for _, n := range days {
+			if n > longest {
+				longest = n
+			}
+		}
+	}
+	return longest
+}
+
+// NoticeRenewal tells the customer of an active subscription about its next renewal,
+// once it is noticeDays or less away. Each renewal is noticed at most once; a notice
+// due late, such as for a subscription created within the notice period, is sent at once.
+func (s *Subscription) NoticeRenewal(clock Clock, cycle BillingCycle, noticeDays int64, pricing Pricing) (*RenewalUpcomingEvent, error) {
+	if s.status != StatusActive {
+		return nil, ErrNotActive
+	}
+	if noticeDays <= 0 {
+		return nil, ErrRenewalNoticeNotRequired
+	}
+
+	renewsAt := s.CurrentPeriodEnd(cycle)
+	if s.renewalNoticeSentFor.Equal(renewsAt) {
+		return nil, ErrRenewalNoticeAlreadySent
+	}
+	now := clock.Now()
+	if now.Before(renewsAt.AddDate(0, 0, -int(noticeDays))) {
+		return nil, ErrRenewalNoticeNotDue
+	}
+
+	s.renewalNoticeSentFor = renewsAt
+
+	return &RenewalUpcomingEvent{
+		SubscriptionID: s.id,
+		CustomerID:     s.customerID,
+		PlanID:         s.planID,
+		Amount:         s.PeriodPrice(pricing).Net,
+		Currency:       DefaultCurrency,
+		RenewsAt:       renewsAt,
+		NoticeDays:     noticeDays,
+		NotifiedAt:     now,
+	}, nil
+}
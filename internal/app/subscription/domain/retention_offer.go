@@ -0,0 +1,239 @@
+package domain
+
+import "time"
+
+// RetentionOfferKind is what a retention offer gives a customer to stay
+type RetentionOfferKind string
+
+const (
+	// RetentionOfferDiscount takes PercentOff off the next renewal, granted as credit
+	RetentionOfferDiscount RetentionOfferKind = "discount"
+	// RetentionOfferDowngrade moves the subscription to a cheaper plan
+	RetentionOfferDowngrade RetentionOfferKind = "downgrade"
+)
+
+// RetentionOfferStatus is where a retention offer is
+type RetentionOfferStatus string
+
+const (
+	RetentionOfferPresented RetentionOfferStatus = "PRESENTED"
+	RetentionOfferAccepted  RetentionOfferStatus = "ACCEPTED"
+	RetentionOfferDeclined  RetentionOfferStatus = "DECLINED"
+)
+
+// RetentionOfferPolicy decides how long a customer has to take an offer, and how soon
+// after accepting one they can be offered another. The zero value uses the defaults.
+type RetentionOfferPolicy struct {
+	TTL      time.Duration
+	Cooldown time.Duration
+}
+
+const (
+	DefaultRetentionOfferTTL      = 24 * time.Hour
+	DefaultRetentionOfferCooldown = 180 * 24 * time.Hour
+)
+
+// WithDefaults fills in the defaults for unset fields
+func (p RetentionOfferPolicy) WithDefaults() RetentionOfferPolicy {
+	if p.TTL <= 0 {
+		p.TTL = DefaultRetentionOfferTTL
+	}
+	if p.Cooldown <= 0 {
+		p.Cooldown = DefaultRetentionOfferCooldown
+	}
+	return p
+}
+
+// RetentionOfferTerms is the alternative to cancelling that an offer rule proposes
+type RetentionOfferTerms struct {
+	Rule       string // name of the rule that made the offer, for analysis
+	Kind       RetentionOfferKind
+	PercentOff int64  // basis points off the next renewal, for a discount
+	PlanID     string // plan to move to, for a downgrade
+	PriceCents int64  // price of PlanID, for a downgrade
+}
+
+// Validate rejects terms that can't be applied
+func (t RetentionOfferTerms) Validate() error {
+	if t.Rule == "" {
+		return ErrInvalidRetentionOffer
+	}
+	switch t.Kind {
+	case RetentionOfferDiscount:
+		if t.PercentOff <= 0 || t.PercentOff > basisPoints || t.PlanID != "" {
+			return ErrInvalidRetentionOffer
+		}
+	case RetentionOfferDowngrade:
+		if t.PlanID == "" || t.PriceCents <= 0 || t.PercentOff != 0 {
+			return ErrInvalidRetentionOffer
+		}
+	default:
+		return ErrInvalidRetentionOffer
+	}
+	return nil
+}
+
+// RetentionOffer is an offer made to a customer asking to cancel, and what they did
+// with it. Offers left unanswered past their expiry were ignored.
+type RetentionOffer struct {
+	id             string
+	subscriptionID string
+	customerID     string
+	planID         string // the plan the customer asked to cancel
+	terms          RetentionOfferTerms
+	status         RetentionOfferStatus
+	creditAmount   int64 // cents granted by an accepted discount
+	presentedAt    time.Time
+	expiresAt      time.Time
+	respondedAt    time.Time
+}
+
+// NewRetentionOffer presents terms to the customer of an active subscription asking
+// to cancel it. A downgrade must be to a cheaper plan, so accepting it never charges.
+func NewRetentionOffer(id string, sub *Subscription, terms RetentionOfferTerms, ttl time.Duration, clock Clock) (*RetentionOffer, error) {
+	if sub.status != StatusActive {
+		return nil, ErrNotActive
+	}
+	if err := terms.Validate(); err != nil {
+		return nil, err
+	}
+	if terms.Kind == RetentionOfferDowngrade {
+		if terms.PlanID == sub.planID {
+			return nil, ErrSamePlan
+		}
+		if terms.PriceCents >= sub.price {
+			return nil, ErrInvalidRetentionOffer
+		}
+	}
+
+	now := clock.Now()
+	return &RetentionOffer{
+		id:             id,
+		subscriptionID: sub.id,
+		customerID:     sub.customerID,
+		planID:         sub.planID,
+		terms:          terms,
+		status:         RetentionOfferPresented,
+		presentedAt:    now,
+		expiresAt:      now.Add(ttl),
+	}, nil
+}
+
+// ReconstructRetentionOffer rebuilds a retention offer from persistence
+func ReconstructRetentionOffer(id, subscriptionID, customerID, planID string, terms RetentionOfferTerms, status RetentionOfferStatus, creditAmount int64, presentedAt, expiresAt, respondedAt time.Time) *RetentionOffer {
+	return &RetentionOffer{
+		id:             id,
+		subscriptionID: subscriptionID,
+		customerID:     customerID,
+		planID:         planID,
+		terms:          terms,
+		status:         status,
+		creditAmount:   creditAmount,
+		presentedAt:    presentedAt,
+		expiresAt:      expiresAt,
+		respondedAt:    respondedAt,
+	}
+}
+
+// respondable reports why the offer can no longer be answered at now, if it can't
+func (o *RetentionOffer) respondable(now time.Time) error {
+	if o.status != RetentionOfferPresented {
+		return ErrRetentionOfferResolved
+	}
+	if !now.Before(o.expiresAt) {
+		return ErrRetentionOfferExpired
+	}
+	return nil
+}
+
+// Accept takes the offer for sub. A discount grants PercentOff of the next renewal's
+// discounted price as credit, which the renewal spends; a downgrade moves sub to the
+// offered plan the way a plan change does, and the event carries the plan change.
+func (o *RetentionOffer) Accept(clock Clock, sub *Subscription, cycle BillingCycle, pricing Pricing) (*RetentionOfferAcceptedEvent, error) {
+	if sub.id != o.subscriptionID {
+		return nil, ErrRetentionOfferNotFound
+	}
+	now := clock.Now()
+	if err := o.respondable(now); err != nil {
+		return nil, err
+	}
+
+	event := &RetentionOfferAcceptedEvent{
+		OfferID:        o.id,
+		SubscriptionID: o.subscriptionID,
+		CustomerID:     o.customerID,
+		Rule:           o.terms.Rule,
+		Kind:           o.terms.Kind,
+		AcceptedAt:     now,
+	}
+	switch o.terms.Kind {
+	case RetentionOfferDiscount:
+		if sub.status != StatusActive {
+			return nil, ErrNotActive
+		}
+		o.creditAmount = percentOf(sub.PeriodPrice(pricing).Net, o.terms.PercentOff)
+		event.CreditAmount = o.creditAmount
+	case RetentionOfferDowngrade:
+		planChange, err := sub.ChangePlan(clock, o.terms.PlanID, o.terms.PriceCents, cycle, pricing)
+		if err != nil {
+			return nil, err
+		}
+		event.PlanChange = planChange
+	}
+
+	o.status = RetentionOfferAccepted
+	o.respondedAt = now
+	return event, nil
+}
+
+// Decline records that the customer went on to cancel instead
+func (o *RetentionOffer) Decline(clock Clock) error {
+	now := clock.Now()
+	if err := o.respondable(now); err != nil {
+		return err
+	}
+	o.status = RetentionOfferDeclined
+	o.respondedAt = now
+	return nil
+}
+
+// Getters
+func (o *RetentionOffer) ID() string {
+	return o.id
+}
+
+func (o *RetentionOffer) SubscriptionID() string {
+	return o.subscriptionID
+}
+
+func (o *RetentionOffer) CustomerID() string {
+	return o.customerID
+}
+
+func (o *RetentionOffer) PlanID() string {
+	return o.planID
+}
+
+func (o *RetentionOffer) Terms() RetentionOfferTerms {
+	return o.terms
+}
+
+func (o *RetentionOffer) Status() RetentionOfferStatus {
+	return o.status
+}
+
+func (o *RetentionOffer) CreditAmount() int64 {
+	return o.creditAmount
+}
+
+func (o *RetentionOffer) PresentedAt() time.Time {
+	return o.presentedAt
+}
+
+func (o *RetentionOffer) ExpiresAt() time.Time {
+	return o.expiresAt
+}
+
+func (o *RetentionOffer) RespondedAt() time.Time {
+	return o.respondedAt
+}
@@ -0,0 +1,48 @@
+package domain
+
+// ScheduleCancellation marks an active subscription PENDING_CANCELLATION, to be
+// cancelled when its current period ends, as long as cycle makes it. The customer
+// keeps the period they paid for, so nothing is refunded; until then the subscription
+// isn't renewed, paused or changed. The reason is kept for when it is cancelled.
+func (s *Subscription) ScheduleCancellation(clock Clock, cycle BillingCycle, reason CancellationReason) (*SubscriptionCancellationScheduledEvent, error) {
+	if err := reason.Validate(); err != nil {
+		return nil, err
+	}
+	switch s.status {
+	case StatusActive:
+	case StatusCancelled:
+		return nil, ErrAlreadyCancelled
+	case StatusPendingCancellation:
+		return nil, ErrCancellationAlreadyScheduled
+	default:
+		return nil, ErrNotActive
+	}
+
+	now := clock.Now()
+	s.status = StatusPendingCancellation
+	s.cancelAt = s.CurrentPeriodEnd(cycle)
+	s.cancellationReason = reason
+
+	event := &SubscriptionCancellationScheduledEvent{
+		SubscriptionID: s.id,
+		CustomerID:     s.customerID,
+		PlanID:         s.planID,
+		CancelAt:       s.cancelAt,
+		ScheduledAt:    now,
+	}
+
+	return event, nil
+}
+
+// CancellationDue reports whether a scheduled cancellation may be carried out now:
+// ErrCancellationNotDue until its cancel_at has passed, and for a subscription
+// without one
+func (s *Subscription) CancellationDue(clock Clock) error {
+	if s.status == StatusCancelled {
+		return ErrAlreadyCancelled
+	}
+	if s.status != StatusPendingCancellation || clock.Now().Before(s.cancelAt) {
+		return ErrCancellationNotDue
+	}
+	return nil
+}
@@ -10,8 +10,17 @@ type SubscriptionStatus string
 const (
 	StatusActive    SubscriptionStatus = "ACTIVE"
 	StatusCancelled SubscriptionStatus = "CANCELLED"
+	StatusPastDue   SubscriptionStatus = "PAST_DUE"
+	StatusTrialing  SubscriptionStatus = "TRIALING"
+	StatusPaused    SubscriptionStatus = "PAUSED"
+	// StatusPendingCancellation is an active subscription cancelled at the end of its
+	// current period
+	StatusPendingCancellation SubscriptionStatus = "PENDING_CANCELLATION"
 )
 
+// DefaultCurrency is the ISO 4217 currency all prices are denominated in
+const DefaultCurrency = "USD"
+
 // Subscription is the aggregate root for subscription management
 type Subscription struct {
 	id         string
@@ -20,10 +29,53 @@ type Subscription struct {
 	price      int64 // cents
 	status     SubscriptionStatus
 	startDate  time.Time
+
+	currentPeriodStart time.Time
+
+	// currentPeriodEnd is when the current period ends: a cycle after it started,
+	// pushed out by any time paused since. Zero for a subscription saved before it was
+	// kept, whose period ends a cycle after it started.
+	currentPeriodEnd time.Time
+
+	// trialEndDate is when a subscription created as a trial stops being free
+	trialEndDate time.Time
+
+	dunningAttempts    int64
+	nextPaymentRetryAt time.Time
+
+	// paymentMethodFlaggedFor is the renewal the payment method was last flagged for
+	paymentMethodFlaggedFor time.Time
+
+	// renewalNoticeSentFor is the renewal the customer was last told about
+	renewalNoticeSentFor time.Time
+
+	// pausedAt is when a paused subscription was paused
+	pausedAt time.Time
+
+	// cancelAt is when a PENDING_CANCELLATION subscription is to be cancelled
+	cancelAt time.Time
+
+	// cancellationReason is why the subscription was cancelled or scheduled to be
+	cancellationReason CancellationReason
+
+	// coupon is the coupon the subscription was created with, while it still applies
+	coupon SubscriptionCoupon
+
+	cancelledAt time.Time
+
+	// version is the stored version the subscription was read at; zero for one not
+	// saved yet
+	version int64
+}
+
+// Clone returns a copy of the subscription that changes independently of it
+func (s *Subscription) Clone() *Subscription {
+	c := *s
+	return &c
 }
 
-// NewSubscription creates a new subscription aggregate
-func NewSubscription(id, customerID, planID string, priceCents int64, clock Clock) (*Subscription, *SubscriptionCreatedEvent, error) {
+// NewSubscription creates a new subscription aggregate, its first period a cycle long
+func NewSubscription(id, customerID, planID string, priceCents int64, cycle BillingCycle, clock Clock) (*Subscription, *SubscriptionCreatedEvent, error) {
 	if customerID == "" {
 		return nil, nil, ErrInvalidCustomerID
 	}
@@ -42,6 +94,9 @@ func NewSubscription(id, customerID, planID string, priceCents int64, clock Cloc
 		price:      priceCents,
 		status:     StatusActive,
 		startDate:  now,
+
+		currentPeriodStart: now,
+		currentPeriodEnd:   cycle.PeriodEnd(now),
 	}
 
 	event := &SubscriptionCreatedEvent{
@@ -55,47 +110,386 @@ func NewSubscription(id, customerID, planID string, priceCents int64, clock Cloc
 	return sub, event, nil
 }
 
+// RefundPolicy decides how the unused part of the current period is measured when
+// a subscription is cancelled
+type RefundPolicy string
+
+const (
+	// RefundUnusedDays refunds whole days left in the period; the day in progress is kept
+	RefundUnusedDays RefundPolicy = "unused_days"
+	// RefundUnusedHours refunds whole hours left, so cancelling early in a day doesn't cost the full day
+	RefundUnusedHours RefundPolicy = "unused_hours"
+)
+
 // Cancel cancels the subscription and calculates refund
 func (s *Subscription) Cancel(clock Clock, billingCycleDays int64) (*SubscriptionCancelledEvent, error) {
+	return s.CancelWithPolicy(clock, CycleOfDays(billingCycleDays), RefundUnusedDays, Pricing{}, CancellationReason{})
+}
+
+// CancellationQuote is what cancelling a subscription would give back, worked out
+// without cancelling it
+type CancellationQuote struct {
+	RefundAmount int64             // cents
+	Discounts    []AppliedDiscount // on the price the refund is of
+	EffectiveAt  time.Time
+}
+
+// QuoteCancellation works out what cancelling the subscription now would refund under
+// policy, as CancelWithPolicy does, without changing the subscription
+func (s *Subscription) QuoteCancellation(clock Clock, cycle BillingCycle, policy RefundPolicy, pricing Pricing) (*CancellationQuote, error) {
 	if s.status == StatusCancelled {
 		return nil, ErrAlreadyCancelled
 	}
 
 	now := clock.Now()
-	daysElapsed := int64(now.Sub(s.startDate).Hours() / 24)
+	discounts := s.PeriodPrice(pricing)
+	elapsed := s.elapsed(now, cycle)
+	period := cycle.PeriodEnd(s.currentPeriodStart).Sub(s.currentPeriodStart)
+	var refundCents int64
+	switch policy {
+	case RefundUnusedHours:
+		periodHours := int64(period.Hours())
+		hoursElapsed := int64(elapsed.Hours())
+		if hoursElapsed > periodHours {
+			hoursElapsed = periodHours
+		}
+		refundCents = (discounts.Net * (periodHours - hoursElapsed)) / periodHours
+	default:
+		periodDays := int64(period.Hours() / 24)
+		daysElapsed := int64(elapsed.Hours() / 24)
 
-	if daysElapsed >= billingCycleDays {
-		// No refund if full cycle used
-		daysElapsed = billingCycleDays
-	}
+		if daysElapsed >= periodDays {
+			// No refund if full cycle used
+			daysElapsed = periodDays
+		}
 
-	refundCents := (s.price * (billingCycleDays - daysElapsed)) / billingCycleDays
+		refundCents = (discounts.Net * (periodDays - daysElapsed)) / periodDays
+	}
 	if refundCents < 0 {
 		refundCents = 0
 	}
+	if s.status == StatusPastDue || s.status == StatusTrialing {
+		// The current period was never paid for
+		refundCents = 0
+	}
+
+	return &CancellationQuote{
+		RefundAmount: refundCents,
+		Discounts:    discounts.Applied,
+		EffectiveAt:  now,
+	}, nil
+}
+
+// CancelWithPolicy cancels the subscription, refunding the unused part of the current
+// period as measured by policy. The period is as long as cycle makes the one starting
+// at the current period start, so a February is prorated over 28 or 29 days. The
+// refund is of the discounted price, which is what the period was charged. A zero
+// reason keeps the one given when the cancellation was scheduled.
+func (s *Subscription) CancelWithPolicy(clock Clock, cycle BillingCycle, policy RefundPolicy, pricing Pricing, reason CancellationReason) (*SubscriptionCancelledEvent, error) {
+	if err := reason.Validate(); err != nil {
+		return nil, err
+	}
+	quote, err := s.QuoteCancellation(clock, cycle, policy, pricing)
+	if err != nil {
+		return nil, err
+	}
 
+	if !reason.IsZero() {
+		s.cancellationReason = reason
+	}
 	s.status = StatusCancelled
+	s.cancelledAt = quote.EffectiveAt
+	s.pausedAt = time.Time{}
+	s.clearDunning()
 
 	event := &SubscriptionCancelledEvent{
 		SubscriptionID: s.id,
 		CustomerID:     s.customerID,
-		RefundAmount:   refundCents,
-		CancelledAt:    now,
+		RefundAmount:   quote.RefundAmount,
+		Discounts:      quote.Discounts,
+		Reason:         s.cancellationReason,
+		CancelledAt:    quote.EffectiveAt,
+	}
+
+	return event, nil
+}
+
+// elapsed is how much of the current period, as long as cycle makes it, has been used
+// by now: the period less what is left of it before it ends. Time paused doesn't use
+// any, as Resume pushed the end out by it, and a paused subscription has used nothing
+// since it was paused. A clock behind the one that started the period counts as
+// nothing used, not as more than a full period left.
+func (s *Subscription) elapsed(now time.Time, cycle BillingCycle) time.Duration {
+	usedUntil := now
+	if s.status == StatusPaused {
+		usedUntil = s.pausedAt
+	}
+	period := cycle.PeriodEnd(s.currentPeriodStart).Sub(s.currentPeriodStart)
+	elapsed := period - s.CurrentPeriodEnd(cycle).Sub(usedUntil)
+	if elapsed < 0 {
+		return 0
+	}
+	if elapsed > period {
+		return period
+	}
+	return elapsed
+}
+
+// DaysRemaining is how many whole days of the current period are left to use, counted
+// as cancellation refunds count them. A trial counts the days left before it ends, and
+// a cancelled subscription has none.
+func (s *Subscription) DaysRemaining(clock Clock, cycle BillingCycle) int64 {
+	now := clock.Now()
+	switch s.status {
+	case StatusCancelled:
+		return 0
+	case StatusTrialing:
+		if left := int64(s.trialEndDate.Sub(now).Hours() / 24); left > 0 {
+			return left
+		}
+		return 0
+	}
+	periodDays := int64(cycle.PeriodEnd(s.currentPeriodStart).Sub(s.currentPeriodStart).Hours() / 24)
+	daysElapsed := int64(s.elapsed(now, cycle).Hours() / 24)
+	if daysElapsed >= periodDays {
+		return 0
+	}
+	return periodDays - daysElapsed
+}
+
+// NextRenewalAt is when the subscription is next charged: the end of an active
+// subscription's period, or the end of a trial. Subscriptions that aren't going to
+// renew as things stand, such as paused, past due or cancelling ones, return zero.
+func (s *Subscription) NextRenewalAt(cycle BillingCycle) time.Time {
+	switch s.status {
+	case StatusActive:
+		return s.CurrentPeriodEnd(cycle)
+	case StatusTrialing:
+		return s.trialEndDate
+	default:
+		return time.Time{}
+	}
+}
+
+// Renew advances the subscription into its next billing period.
+// A subscription can be renewed once its current period ends within renewalWindow
+// of now; renewing moves the period forward so a repeated call is rejected. The new
+// period is charged the price less pricing's discounts.
+func (s *Subscription) Renew(clock Clock, cycle BillingCycle, renewalWindow time.Duration, pricing Pricing) (*SubscriptionRenewedEvent, error) {
+	if s.status != StatusActive {
+		return nil, ErrNotRenewable
+	}
+
+	now := clock.Now()
+	periodEnd := s.CurrentPeriodEnd(cycle)
+	if now.Add(renewalWindow).Before(periodEnd) {
+		return nil, ErrRenewalNotDue
+	}
+
+	s.currentPeriodStart = periodEnd
+	s.currentPeriodEnd = cycle.PeriodEnd(periodEnd)
+	s.coupon = s.coupon.next()
+	discounts := s.PeriodPrice(pricing)
+
+	event := &SubscriptionRenewedEvent{
+		SubscriptionID: s.id,
+		CustomerID:     s.customerID,
+		PlanID:         s.planID,
+		Amount:         discounts.Net,
+		Discount:       discounts.Total,
+		Discounts:      discounts.Applied,
+		PeriodStart:    s.currentPeriodStart,
+		PeriodEnd:      s.CurrentPeriodEnd(cycle),
+		RenewedAt:      now,
+	}
+
+	return event, nil
+}
+
+// ChangePlan moves an active subscription to another plan for the rest of the current
+// period. The difference between the discounted prices is prorated by the days
+// remaining of the period cycle makes, the same way cancellation refunds are; the next
+// renewal charges the new price in full, less the same discounts.
+func (s *Subscription) ChangePlan(clock Clock, planID string, priceCents int64, cycle BillingCycle, pricing Pricing) (*SubscriptionPlanChangedEvent, error) {
+	if s.status != StatusActive {
+		return nil, ErrNotActive
+	}
+	if planID == "" {
+		return nil, ErrInvalidPlanID
+	}
+	if priceCents <= 0 {
+		return nil, ErrInvalidPrice
+	}
+	if planID == s.planID {
+		return nil, ErrSamePlan
+	}
+
+	now := clock.Now()
+	periodDays := int64(cycle.PeriodEnd(s.currentPeriodStart).Sub(s.currentPeriodStart).Hours() / 24)
+	daysElapsed := int64(s.elapsed(now, cycle).Hours() / 24)
+	oldDiscounts, newDiscounts := s.PeriodPrice(pricing), s.priceAt(priceCents, pricing)
+	prorated := ((newDiscounts.Net - oldDiscounts.Net) * (periodDays - daysElapsed)) / periodDays
+
+	event := &SubscriptionPlanChangedEvent{
+		SubscriptionID: s.id,
+		CustomerID:     s.customerID,
+		OldPlanID:      s.planID,
+		NewPlanID:      planID,
+		OldPrice:       s.price,
+		NewPrice:       priceCents,
+		ProratedAmount: prorated,
+		Discounts:      newDiscounts.Applied,
+		ChangedAt:      now,
+	}
+
+	s.planID = planID
+	s.price = priceCents
+
+	return event, nil
+}
+
+// FlagExpiringPaymentMethod flags an active subscription whose payment method can't be
+// charged at the end of the current period. A subscription is flagged at most once per
+// renewal, so a periodic check doesn't notify the customer again on every pass.
+func (s *Subscription) FlagExpiringPaymentMethod(clock Clock, pm PaymentMethod, cycle BillingCycle) (*PaymentMethodExpiringEvent, error) {
+	if s.status != StatusActive {
+		return nil, ErrNotActive
+	}
+
+	renewsAt := s.CurrentPeriodEnd(cycle)
+	if pm.UsableAt(renewsAt) {
+		return nil, ErrPaymentMethodUsable
+	}
+	if s.paymentMethodFlaggedFor.Equal(renewsAt) {
+		return nil, ErrPaymentMethodAlreadyFlagged
+	}
+
+	s.paymentMethodFlaggedFor = renewsAt
+
+	event := &PaymentMethodExpiringEvent{
+		SubscriptionID: s.id,
+		CustomerID:     s.customerID,
+		Brand:          pm.Brand,
+		Last4:          pm.Last4,
+		RenewsAt:       renewsAt,
+		DetectedAt:     clock.Now(),
+	}
+	if pm.Valid {
+		event.ExpiresAt = pm.ExpiresAt
 	}
 
 	return event, nil
 }
 
+// ReconstructOption sets optional state when recreating a subscription from database
+type ReconstructOption func(*Subscription)
+
+// WithCurrentPeriodStart restores the start of the current billing period
+func WithCurrentPeriodStart(t time.Time) ReconstructOption {
+	return func(s *Subscription) {
+		if !t.IsZero() {
+			s.currentPeriodStart = t
+		}
+	}
+}
+
+// WithCurrentPeriodEnd sets when the current period ends
+func WithCurrentPeriodEnd(t time.Time) ReconstructOption {
+	return func(s *Subscription) {
+		s.currentPeriodEnd = t
+	}
+}
+
+// WithTrialEndDate restores when a subscription created as a trial stops being free
+func WithTrialEndDate(t time.Time) ReconstructOption {
+	return func(s *Subscription) {
+		s.trialEndDate = t
+	}
+}
+
+// WithDunning restores the dunning progress of a past-due subscription
+func WithDunning(attempts int64, nextPaymentRetryAt time.Time) ReconstructOption {
+	return func(s *Subscription) {
+		s.dunningAttempts = attempts
+		s.nextPaymentRetryAt = nextPaymentRetryAt
+	}
+}
+
+// WithPaymentMethodFlaggedFor restores the renewal the payment method was last flagged for
+func WithPaymentMethodFlaggedFor(t time.Time) ReconstructOption {
+	return func(s *Subscription) {
+		s.paymentMethodFlaggedFor = t
+	}
+}
+
+// WithRenewalNoticeSentFor restores the renewal the customer was last told about
+func WithRenewalNoticeSentFor(t time.Time) ReconstructOption {
+	return func(s *Subscription) {
+		s.renewalNoticeSentFor = t
+	}
+}
+
+// WithPausedAt restores when a paused subscription was paused
+func WithPausedAt(t time.Time) ReconstructOption {
+	return func(s *Subscription) {
+		s.pausedAt = t
+	}
+}
+
+// WithCancelAt restores when a scheduled cancellation is to be carried out
+func WithCancelAt(t time.Time) ReconstructOption {
+	return func(s *Subscription) {
+		s.cancelAt = t
+	}
+}
+
+// WithCancellationReason restores why the subscription was cancelled or scheduled to be
+func WithCancellationReason(r CancellationReason) ReconstructOption {
+	return func(s *Subscription) {
+		s.cancellationReason = r
+	}
+}
+
+// WithCoupon restores the coupon the subscription was created with
+func WithCoupon(c SubscriptionCoupon) ReconstructOption {
+	return func(s *Subscription) {
+		s.coupon = c
+	}
+}
+
+// WithCancelledAt restores when a cancelled subscription was cancelled
+func WithCancelledAt(t time.Time) ReconstructOption {
+	return func(s *Subscription) {
+		s.cancelledAt = t
+	}
+}
+
+// WithVersion restores the stored version the subscription was read at
+func WithVersion(v int64) ReconstructOption {
+	return func(s *Subscription) {
+		s.version = v
+	}
+}
+
 // ReconstructFromPersistence recreates a subscription from database
-func ReconstructFromPersistence(id, customerID, planID string, priceCents int64, status SubscriptionStatus, startDate time.Time) *Subscription {
-	return &Subscription{
+func ReconstructFromPersistence(id, customerID, planID string, priceCents int64, status SubscriptionStatus, startDate time.Time, opts ...ReconstructOption) *Subscription {
+	sub := &Subscription{
 		id:         id,
 		customerID: customerID,
 		planID:     planID,
 		price:      priceCents,
 		status:     status,
 		startDate:  startDate,
+
+		// Rows written before renewals existed are still in their first period
+		currentPeriodStart: startDate,
 	}
+
+	for _, opt := range opts {
+		opt(sub)
+	}
+
+	return sub
 }
 
 // Getters (no setters!)
@@ -122,3 +516,66 @@ func (s *Subscription) Status() SubscriptionStatus {
 func (s *Subscription) StartDate() time.Time {
 	return s.startDate
 }
+
+func (s *Subscription) CurrentPeriodStart() time.Time {
+	return s.currentPeriodStart
+}
+
+func (s *Subscription) TrialEndDate() time.Time {
+	return s.trialEndDate
+}
+
+func (s *Subscription) DunningAttempts() int64 {
+	return s.dunningAttempts
+}
+
+func (s *Subscription) NextPaymentRetryAt() time.Time {
+	return s.nextPaymentRetryAt
+}
+
+func (s *Subscription) PaymentMethodFlaggedFor() time.Time {
+	return s.paymentMethodFlaggedFor
+}
+
+func (s *Subscription) RenewalNoticeSentFor() time.Time {
+	return s.renewalNoticeSentFor
+}
+
+func (s *Subscription) PausedAt() time.Time {
+	return s.pausedAt
+}
+
+func (s *Subscription) CancelAt() time.Time {
+	return s.cancelAt
+}
+
+// CancellationReason is why the subscription was cancelled or scheduled to be; zero if
+// no reason was given
+func (s *Subscription) CancellationReason() CancellationReason {
+	return s.cancellationReason
+}
+
+func (s *Subscription) CancelledAt() time.Time {
+	return s.cancelledAt
+}
+
+// Version is the stored version the subscription was read at. Saving it writes the
+// next version, and fails with ErrConcurrentModification if the stored one has moved on.
+func (s *Subscription) Version() int64 {
+	return s.version
+}
+
+// RecordedPeriodEnd is when the current period ends as recorded; zero for a
+// subscription saved before it was, whose period ends as cycle makes it
+func (s *Subscription) RecordedPeriodEnd() time.Time {
+	return s.currentPeriodEnd
+}
+
+// CurrentPeriodEnd returns when the current billing period ends, one cycle after it
+// started and later by any time it was paused
+func (s *Subscription) CurrentPeriodEnd(cycle BillingCycle) time.Time {
+	if !s.currentPeriodEnd.IsZero() {
+		return s.currentPeriodEnd
+	}
+	return cycle.PeriodEnd(s.currentPeriodStart)
+}
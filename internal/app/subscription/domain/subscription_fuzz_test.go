@@ -0,0 +1,76 @@
+package domain_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+// maxFuzzPrice bounds fuzzed prices at $10M a period, well past any plan, so the
+// refund arithmetic is exercised without overflowing int64
+const maxFuzzPrice = 1_000_000_000
+
+// FuzzCancelRefund checks the refund of a cancelled period under both refund
+// policies: it is never negative, never more than the price, and never grows as
+// more of the period is used. Elapsed time may be negative, as when the canceller's
+// clock is behind the one that started the period.
+//
+//	go test ./internal/app/subscription/domain -run '^$' -fuzz FuzzCancelRefund
+func FuzzCancelRefund(f *testing.F) {
+	f.Add(int64(3000), int64(30), int64(0), int64(60), false)
+	f.Add(int64(3000), int64(30), int64(15*24*60), int64(1), true)
+	f.Add(int64(999), int64(7), int64(-90), int64(24*60), false)
+	f.Add(int64(1), int64(1), int64(23*60+59), int64(1), true)
+	f.Add(int64(maxFuzzPrice), int64(366), int64(400*24*60), int64(0), false)
+
+	f.Fuzz(func(t *testing.T, price, cycleDays, elapsedMinutes, laterMinutes int64, hourly bool) {
+		price = 1 + mod(price, maxFuzzPrice)
+		cycleDays = 1 + mod(cycleDays, 366)
+		cycleMinutes := cycleDays * 24 * 60
+		// up to a full period early, and up to a full period past the end
+		elapsedMinutes = mod(elapsedMinutes, 3*cycleMinutes) - cycleMinutes
+		laterMinutes = mod(laterMinutes, 3*cycleMinutes)
+
+		policy := domain.RefundUnusedDays
+		if hourly {
+			policy = domain.RefundUnusedHours
+		}
+
+		early := cancelAfter(t, price, cycleDays, elapsedMinutes, policy)
+		late := cancelAfter(t, price, cycleDays, elapsedMinutes+laterMinutes, policy)
+
+		for _, refund := range []int64{early, late} {
+			if refund < 0 || refund > price {
+				t.Fatalf("refund %d of a %d price is out of range (cycle %d days, %s)", refund, price, cycleDays, policy)
+			}
+		}
+		if late > early {
+			t.Fatalf("refund grew from %d to %d after %d more minutes (cycle %d days, %s)", early, late, laterMinutes, cycleDays, policy)
+		}
+	})
+}
+
+// cancelAfter cancels an active subscription elapsed minutes into its period and
+// returns the refund
+func cancelAfter(t *testing.T, price, cycleDays, elapsedMinutes int64, policy domain.RefundPolicy) int64 {
+	t.Helper()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	sub := domain.ReconstructFromPersistence("sub-1", "cust-1", "plan-1", price, domain.StatusActive, start)
+	clock := domain.FixedClock{FixedTime: start.Add(time.Duration(elapsedMinutes) * time.Minute)}
+
+	event, err := sub.CancelWithPolicy(clock, domain.CycleOfDays(cycleDays), policy, domain.Pricing{}, domain.CancellationReason{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return event.RefundAmount
+}
+
+// mod is v modulo n, in [0, n) even for negative v
+func mod(v, n int64) int64 {
+	r := v % n
+	if r < 0 {
+		r += n
+	}
+	return r
+}
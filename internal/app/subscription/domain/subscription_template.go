@@ -0,0 +1,174 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	MaxMetadataKeys        = 50
+	MaxMetadataKeyLength   = 40
+	MaxMetadataValueLength = 500
+	// MaxTemplateNameLength is the most characters a template name can have
+	MaxTemplateNameLength = 100
+)
+
+// SubscriptionBundle is what a subscription is set up with besides its plan: recurring
+// add-ons billed alongside the plan, and free-form metadata for the deployment's own use
+type SubscriptionBundle struct {
+	AddOns   []AddOnCharge
+	Metadata map[string]string
+}
+
+// Validate rejects add-ons that couldn't be billed or told apart, and metadata over
+// the limits
+func (b SubscriptionBundle) Validate() error {
+	seen := make(map[string]bool, len(b.AddOns))
+	for _, a := range b.AddOns {
+		if a.ID == "" || a.Name == "" || seen[a.ID] {
+			return fmt.Errorf("%w: add-on %q", ErrInvalidSubscriptionBundle, a.ID)
+		}
+		seen[a.ID] = true
+		if a.Quantity <= 0 || a.UnitPrice < 0 {
+			return fmt.Errorf("%w: add-on %q needs a positive quantity and a price", ErrInvalidSubscriptionBundle, a.ID)
+		}
+	}
+	if len(b.Metadata) > MaxMetadataKeys {
+		return fmt.Errorf("%w: at most %d metadata keys", ErrInvalidSubscriptionBundle, MaxMetadataKeys)
+	}
+	for k, v := range b.Metadata {
+		if strings.TrimSpace(k) == "" || utf8.RuneCountInString(k) > MaxMetadataKeyLength || utf8.RuneCountInString(v) > MaxMetadataValueLength {
+			return fmt.Errorf("%w: metadata key %q", ErrInvalidSubscriptionBundle, k)
+		}
+	}
+	return nil
+}
+
+// IsEmpty reports whether the bundle adds nothing to the plan
+func (b SubscriptionBundle) IsEmpty() bool {
+	return len(b.AddOns) == 0 && len(b.Metadata) == 0
+}
+
+// Clone returns a copy that shares nothing with b, so a subscription created from a
+// template can't change the template
+func (b SubscriptionBundle) Clone() SubscriptionBundle {
+	var c SubscriptionBundle
+	if len(b.AddOns) > 0 {
+		c.AddOns = append([]AddOnCharge(nil), b.AddOns...)
+	}
+	if len(b.Metadata) > 0 {
+		c.Metadata = make(map[string]string, len(b.Metadata))
+		for k, v := range b.Metadata {
+			c.Metadata[k] = v
+		}
+	}
+	return c
+}
+
+// WithMetadata returns a copy of b with metadata set over its own. An empty value
+// removes the key, so a copy can drop what only applied to the original.
+func (b SubscriptionBundle) WithMetadata(metadata map[string]string) SubscriptionBundle {
+	c := b.Clone()
+	for k, v := range metadata {
+		if v == "" {
+			delete(c.Metadata, k)
+			continue
+		}
+		if c.Metadata == nil {
+			c.Metadata = make(map[string]string, len(metadata))
+		}
+		c.Metadata[k] = v
+	}
+	if len(c.Metadata) == 0 {
+		c.Metadata = nil
+	}
+	return c
+}
+
+// SubscriptionTemplate is a named plan, price, trial and bundle that subscriptions are
+// created from, so the same setup isn't entered by hand for every customer
+type SubscriptionTemplate struct {
+	id        string
+	name      string
+	planID    string
+	price     int64 // cents
+	trialDays int64 // zero starts subscriptions ACTIVE
+	bundle    SubscriptionBundle
+	createdAt time.Time
+}
+
+// NewSubscriptionTemplate validates and creates a template. Names are unique, which
+// the repository enforces.
+func NewSubscriptionTemplate(id, name, planID string, priceCents, trialDays int64, bundle SubscriptionBundle, clock Clock) (*SubscriptionTemplate, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > MaxTemplateNameLength {
+		return nil, ErrInvalidTemplateName
+	}
+	if planID == "" {
+		return nil, ErrInvalidPlanID
+	}
+	if priceCents <= 0 {
+		return nil, ErrInvalidPrice
+	}
+	if trialDays < 0 {
+		return nil, ErrInvalidTrialDays
+	}
+	if err := bundle.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &SubscriptionTemplate{
+		id:        id,
+		name:      name,
+		planID:    planID,
+		price:     priceCents,
+		trialDays: trialDays,
+		bundle:    bundle.Clone(),
+		createdAt: clock.Now(),
+	}, nil
+}
+
+// ReconstructSubscriptionTemplate rebuilds a template from persistence
+func ReconstructSubscriptionTemplate(id, name, planID string, priceCents, trialDays int64, bundle SubscriptionBundle, createdAt time.Time) *SubscriptionTemplate {
+	return &SubscriptionTemplate{
+		id:        id,
+		name:      name,
+		planID:    planID,
+		price:     priceCents,
+		trialDays: trialDays,
+		bundle:    bundle,
+		createdAt: createdAt,
+	}
+}
+
+// Getters
+func (t *SubscriptionTemplate) ID() string {
+	return t.id
+}
+
+func (t *SubscriptionTemplate) Name() string {
+	return t.name
+}
+
+func (t *SubscriptionTemplate) PlanID() string {
+	return t.planID
+}
+
+func (t *SubscriptionTemplate) Price() int64 {
+	return t.price
+}
+
+func (t *SubscriptionTemplate) TrialDays() int64 {
+	return t.trialDays
+}
+
+// Bundle returns a copy of the template's bundle
+func (t *SubscriptionTemplate) Bundle() SubscriptionBundle {
+	return t.bundle.Clone()
+}
+
+func (t *SubscriptionTemplate) CreatedAt() time.Time {
+	return t.createdAt
+}
@@ -0,0 +1,227 @@
+package domain
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// SurveyQuestionKind is how a cancellation survey question is answered
+type SurveyQuestionKind string
+
+const (
+	SurveyChoice SurveyQuestionKind = "choice" // one of the question's options
+	SurveyRating SurveyQuestionKind = "rating" // MinSurveyRating to MaxSurveyRating
+	SurveyText   SurveyQuestionKind = "text"   // free text, not aggregated
+)
+
+const (
+	MinSurveyRating = 1
+	MaxSurveyRating = 5
+	// MaxSurveyTextLength is the most characters a free text answer can have
+	MaxSurveyTextLength = 2000
+)
+
+// SurveyQuestion is one question of a cancellation survey. Its ID is what answers are
+// stored and reported under, so it must stay the same when the prompt is reworded.
+type SurveyQuestion struct {
+	ID       string
+	Prompt   string
+	Kind     SurveyQuestionKind
+	Options  []string // the answers a choice question accepts
+	Required bool
+}
+
+// CancellationSurvey is the questions a cancelling customer is asked. Version is
+// stored with every response, so answers can be told apart after questions change.
+type CancellationSurvey struct {
+	Version   string
+	Questions []SurveyQuestion
+}
+
+// Validate rejects a survey customers couldn't answer, or whose answers couldn't be
+// told apart
+func (s CancellationSurvey) Validate() error {
+	if s.Version == "" || len(s.Questions) == 0 {
+		return ErrInvalidSurvey
+	}
+	seen := make(map[string]bool, len(s.Questions))
+	for _, q := range s.Questions {
+		if q.ID == "" || q.Prompt == "" || seen[q.ID] {
+			return fmt.Errorf("%w: question %q", ErrInvalidSurvey, q.ID)
+		}
+		seen[q.ID] = true
+		switch q.Kind {
+		case SurveyChoice:
+			if len(q.Options) == 0 {
+				return fmt.Errorf("%w: question %q has no options", ErrInvalidSurvey, q.ID)
+			}
+		case SurveyRating, SurveyText:
+			if len(q.Options) > 0 {
+				return fmt.Errorf("%w: only choice questions have options", ErrInvalidSurvey)
+			}
+		default:
+			return fmt.Errorf("%w: question %q kind must be choice, rating or text", ErrInvalidSurvey, q.ID)
+		}
+	}
+	return nil
+}
+
+// question returns the survey question with the given ID
+func (s CancellationSurvey) question(id string) (SurveyQuestion, bool) {
+	for _, q := range s.Questions {
+		if q.ID == id {
+			return q, true
+		}
+	}
+	return SurveyQuestion{}, false
+}
+
+// SurveyAnswer is a customer's answer to one question. Kind is filled in from the
+// survey when the response is recorded.
+type SurveyAnswer struct {
+	QuestionID string
+	Kind       SurveyQuestionKind
+	Value      string
+}
+
+// check normalizes the answer to q, or reports why it isn't one
+func (a SurveyAnswer) check(q SurveyQuestion) (SurveyAnswer, error) {
+	a.Kind = q.Kind
+	a.Value = strings.TrimSpace(a.Value)
+	invalid := fmt.Errorf("%w: question %q", ErrInvalidSurveyAnswer, q.ID)
+	switch q.Kind {
+	case SurveyChoice:
+		for _, option := range q.Options {
+			if a.Value == option {
+				return a, nil
+			}
+		}
+		return a, invalid
+	case SurveyRating:
+		rating, err := strconv.Atoi(a.Value)
+		if err != nil || rating < MinSurveyRating || rating > MaxSurveyRating {
+			return a, invalid
+		}
+		a.Value = strconv.Itoa(rating)
+		return a, nil
+	default:
+		if a.Value == "" || utf8.RuneCountInString(a.Value) > MaxSurveyTextLength {
+			return a, invalid
+		}
+		return a, nil
+	}
+}
+
+// SurveyResponse is a customer's answers to the cancellation survey for one
+// cancellation of a subscription
+type SurveyResponse struct {
+	id             string
+	subscriptionID string
+	customerID     string
+	planID         string
+	surveyVersion  string
+	answers        []SurveyAnswer // in the survey's question order
+	cancelledAt    time.Time
+	submittedAt    time.Time
+}
+
+// NewSurveyResponse records answers to survey for the cancellation of sub. Every
+// answer must be to a question of the survey, once, and every required question must
+// be answered; questions that aren't required can be skipped.
+func NewSurveyResponse(id string, sub *Subscription, survey CancellationSurvey, answers []SurveyAnswer, clock Clock) (*SurveyResponse, error) {
+	if sub.status != StatusCancelled {
+		return nil, ErrNotCancelled
+	}
+	if len(answers) == 0 {
+		return nil, ErrInvalidSurveyAnswer
+	}
+
+	byQuestion := make(map[string]SurveyAnswer, len(answers))
+	for _, a := range answers {
+		q, ok := survey.question(a.QuestionID)
+		if !ok {
+			return nil, fmt.Errorf("%w: no question %q", ErrInvalidSurveyAnswer, a.QuestionID)
+		}
+		if _, dup := byQuestion[q.ID]; dup {
+			return nil, fmt.Errorf("%w: question %q answered twice", ErrInvalidSurveyAnswer, q.ID)
+		}
+		checked, err := a.check(q)
+		if err != nil {
+			return nil, err
+		}
+		byQuestion[q.ID] = checked
+	}
+
+	ordered := make([]SurveyAnswer, 0, len(byQuestion))
+	for _, q := range survey.Questions {
+		a, ok := byQuestion[q.ID]
+		if !ok {
+			if q.Required {
+				return nil, fmt.Errorf("%w: question %q", ErrSurveyAnswerRequired, q.ID)
+			}
+			continue
+		}
+		ordered = append(ordered, a)
+	}
+
+	return &SurveyResponse{
+		id:             id,
+		subscriptionID: sub.id,
+		customerID:     sub.customerID,
+		planID:         sub.planID,
+		surveyVersion:  survey.Version,
+		answers:        ordered,
+		cancelledAt:    sub.cancelledAt,
+		submittedAt:    clock.Now(),
+	}, nil
+}
+
+// ReconstructSurveyResponse rebuilds a survey response from persistence
+func ReconstructSurveyResponse(id, subscriptionID, customerID, planID, surveyVersion string, answers []SurveyAnswer, cancelledAt, submittedAt time.Time) *SurveyResponse {
+	return &SurveyResponse{
+		id:             id,
+		subscriptionID: subscriptionID,
+		customerID:     customerID,
+		planID:         planID,
+		surveyVersion:  surveyVersion,
+		answers:        answers,
+		cancelledAt:    cancelledAt,
+		submittedAt:    submittedAt,
+	}
+}
+
+// Getters
+func (r *SurveyResponse) ID() string {
+	return r.id
+}
+
+func (r *SurveyResponse) SubscriptionID() string {
+	return r.subscriptionID
+}
+
+func (r *SurveyResponse) CustomerID() string {
+	return r.customerID
+}
+
+func (r *SurveyResponse) PlanID() string {
+	return r.planID
+}
+
+func (r *SurveyResponse) SurveyVersion() string {
+	return r.surveyVersion
+}
+
+func (r *SurveyResponse) Answers() []SurveyAnswer {
+	return r.answers
+}
+
+func (r *SurveyResponse) CancelledAt() time.Time {
+	return r.cancelledAt
+}
+
+func (r *SurveyResponse) SubmittedAt() time.Time {
+	return r.submittedAt
+}
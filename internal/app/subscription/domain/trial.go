@@ -0,0 +1,49 @@
+package domain
+
+// NewTrialSubscription creates a subscription that is free for trialDays before its
+// first period is charged. It stays TRIALING until it is converted or cancelled.
+func NewTrialSubscription(id, customerID, planID string, priceCents, trialDays int64, cycle BillingCycle, clock Clock) (*Subscription, *SubscriptionCreatedEvent, error) {
+	if trialDays <= 0 {
+		return nil, nil, ErrInvalidTrialDays
+	}
+	sub, event, err := NewSubscription(id, customerID, planID, priceCents, cycle, clock)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sub.status = StatusTrialing
+	sub.trialEndDate = sub.startDate.AddDate(0, 0, int(trialDays))
+	event.TrialEndDate = sub.trialEndDate
+
+	return sub, event, nil
+}
+
+// ConvertTrial makes a trialing subscription ACTIVE, starting its first paid billing
+// period now, at the price less pricing's discounts. A trial may convert before its
+// end date.
+func (s *Subscription) ConvertTrial(clock Clock, cycle BillingCycle, pricing Pricing) (*TrialConvertedEvent, error) {
+	if s.status != StatusTrialing {
+		return nil, ErrNotTrialing
+	}
+
+	now := clock.Now()
+	s.status = StatusActive
+	s.currentPeriodStart = now
+	s.currentPeriodEnd = cycle.PeriodEnd(now)
+	discounts := s.PeriodPrice(pricing)
+
+	event := &TrialConvertedEvent{
+		SubscriptionID: s.id,
+		CustomerID:     s.customerID,
+		PlanID:         s.planID,
+		Amount:         discounts.Net,
+		Discount:       discounts.Total,
+		Discounts:      discounts.Applied,
+		TrialEndDate:   s.trialEndDate,
+		PeriodStart:    s.currentPeriodStart,
+		PeriodEnd:      s.CurrentPeriodEnd(cycle),
+		ConvertedAt:    now,
+	}
+
+	return event, nil
+}
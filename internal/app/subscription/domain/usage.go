@@ -0,0 +1,146 @@
+package domain
+
+import (
+	"sort"
+	"time"
+)
+
+// DefaultUsageAlertThresholds alert customers at 80% and 100% of their included usage
+var DefaultUsageAlertThresholds = []int64{8000, 10000}
+
+// UsageAlertPolicy is the share of a metric's included quantity, in basis points, at
+// which the customer is alerted. Thresholds over 10000 alert on overage, e.g. 15000
+// at half as much again as the plan includes.
+type UsageAlertPolicy struct {
+	Thresholds []int64
+}
+
+// Validate rejects thresholds that can never be reached
+func (p UsageAlertPolicy) Validate() error {
+	for _, t := range p.Thresholds {
+		if t <= 0 {
+			return ErrInvalidUsageThreshold
+		}
+	}
+	return nil
+}
+
+// UsageRecord is one report of metered usage. Usage is the sum of the records of a
+// billing period, so recording usage never reads and rewrites a running total.
+type UsageRecord struct {
+	id             string
+	subscriptionID string
+	customerID     string
+	metric         string
+	quantity       int64
+	periodStart    time.Time
+	recordedAt     time.Time
+}
+
+// NewUsageRecord records quantity units of metric used by sub in its current period
+func NewUsageRecord(id string, sub *Subscription, metric string, quantity int64, clock Clock) (*UsageRecord, error) {
+	if metric == "" {
+		return nil, ErrInvalidMetric
+	}
+	if quantity <= 0 {
+		return nil, ErrInvalidUsageQuantity
+	}
+	now := clock.Now()
+	if !sub.EntitledAt(now) {
+		return nil, ErrNotActive
+	}
+	return &UsageRecord{
+		id:             id,
+		subscriptionID: sub.id,
+		customerID:     sub.customerID,
+		metric:         metric,
+		quantity:       quantity,
+		periodStart:    sub.currentPeriodStart,
+		recordedAt:     now,
+	}, nil
+}
+
+// ReconstructUsageRecord rebuilds a usage record from persistence
+func ReconstructUsageRecord(id, subscriptionID, customerID, metric string, quantity int64, periodStart, recordedAt time.Time) *UsageRecord {
+	return &UsageRecord{
+		id:             id,
+		subscriptionID: subscriptionID,
+		customerID:     customerID,
+		metric:         metric,
+		quantity:       quantity,
+		periodStart:    periodStart,
+		recordedAt:     recordedAt,
+	}
+}
+
+// PeriodUsage is a metric's usage over one billing period of a subscription, and the
+// thresholds the customer has already been alerted at
+type PeriodUsage struct {
+	Quantity int64
+	Included int64   // from the plan's entitlement; zero when unlimited or nothing is included
+	Alerted  []int64 // basis points
+}
+
+// ThresholdsReached returns an event for every threshold of policy the usage has
+// reached and not been alerted at yet, lowest first. Usage with nothing included
+// raises no alerts.
+func (r *UsageRecord) ThresholdsReached(usage PeriodUsage, policy UsageAlertPolicy, clock Clock) []*UsageThresholdReachedEvent {
+	if usage.Included <= 0 {
+		return nil
+	}
+	alerted := make(map[int64]bool, len(usage.Alerted))
+	for _, t := range usage.Alerted {
+		alerted[t] = true
+	}
+
+	thresholds := append([]int64(nil), policy.Thresholds...)
+	sort.Slice(thresholds, func(i, j int) bool { return thresholds[i] < thresholds[j] })
+
+	var events []*UsageThresholdReachedEvent
+	for _, t := range thresholds {
+		if alerted[t] || usage.Quantity*basisPoints < t*usage.Included {
+			continue
+		}
+		alerted[t] = true
+		events = append(events, &UsageThresholdReachedEvent{
+			SubscriptionID: r.subscriptionID,
+			CustomerID:     r.customerID,
+			Metric:         r.metric,
+			ThresholdBP:    t,
+			Quantity:       usage.Quantity,
+			Included:       usage.Included,
+			PeriodStart:    r.periodStart,
+			ReachedAt:      clock.Now(),
+		})
+	}
+	return events
+}
+
+// Getters
+func (r *UsageRecord) ID() string {
+	return r.id
+}
+
+func (r *UsageRecord) SubscriptionID() string {
+	return r.subscriptionID
+}
+
+func (r *UsageRecord) CustomerID() string {
+	return r.customerID
+}
+
+func (r *UsageRecord) Metric() string {
+	return r.metric
+}
+
+func (r *UsageRecord) Quantity() int64 {
+	return r.quantity
+}
+
+func (r *UsageRecord) PeriodStart() time.Time {
+	return r.periodStart
+}
+
+func (r *UsageRecord) RecordedAt() time.Time {
+	return r.recordedAt
+}
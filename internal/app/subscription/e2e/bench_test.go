@@ -0,0 +1,280 @@
+//go:build integration
+
+package e2e
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/adapters"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/testkit"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/cancel_subscription"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/create_subscription"
+)
+
+// Each benchmark runs against the in-memory testkit repositories, which times the
+// code in front of the database, and against the Spanner emulator the tests use. The
+// emulator isn't Spanner, so compare its numbers before and after a change rather
+// than reading them as production latencies.
+//
+//	go test -tags integration ./internal/app/subscription/e2e -run '^$' -bench . -benchmem
+
+const (
+	benchSeed        = 1000 // subscriptions in the database before a benchmark starts
+	benchCycleDays   = 30
+	benchApplyChunk  = 500 // subscriptions per commit when seeding the emulator
+	benchRenewalPage = 100
+)
+
+// benchStore is the set of repositories a benchmark runs against
+type benchStore struct {
+	ctx       context.Context
+	subs      contracts.TransactionalSubscriptionRepository
+	scans     contracts.SubscriptionScanRepository
+	bulk      contracts.BulkCancellationRepository
+	refunds   contracts.RefundRepository
+	outbox    contracts.RefundOutboxRepository
+	credits   contracts.CreditBalanceRepository
+	referrals contracts.ReferralRepository
+	bundles   contracts.SubscriptionBundleRepository
+	keys      contracts.IdempotencyKeyRepository
+	coupons   contracts.CouponRepository
+	plans     contracts.PlanRepository
+}
+
+// forEachStore runs bench against the in-memory repositories and then, if there is an
+// emulator, the package's test database, emptied after each run
+func forEachStore(b *testing.B, bench func(b *testing.B, store benchStore)) {
+	b.Run("memory", func(b *testing.B) {
+		subs := testkit.NewFakeSubscriptions()
+		bench(b, benchStore{
+			ctx:       context.Background(),
+			subs:      subs,
+			scans:     subs,
+			bulk:      subs,
+			refunds:   testkit.NewFakeRefunds(),
+			outbox:    testkit.NewFakeRefundOutbox(),
+			credits:   testkit.NewFakeCreditBalances(),
+			referrals: testkit.NewFakeReferrals(),
+			bundles:   testkit.NewFakeBundles(),
+			keys:      testkit.NewFakeIdempotencyKeys(),
+			coupons:   testkit.NewFakeCoupons(),
+			plans:     testkit.NewFakePlans(),
+		})
+	})
+
+	b.Run("emulator", func(b *testing.B) {
+		ts := setupTest(b)
+		bench(b, benchStore{
+			ctx:       ts.ctx,
+			subs:      ts.subscriptionRepo,
+			scans:     ts.subscriptionRepo,
+			bulk:      ts.subscriptionRepo,
+			refunds:   ts.refundRepo,
+			outbox:    ts.outboxRepo,
+			credits:   ts.creditRepo,
+			referrals: ts.referralRepo,
+			bundles:   ts.bundleRepo,
+			keys:      ts.keyRepo,
+			coupons:   ts.couponRepo,
+			plans:     ts.planRepo,
+		})
+	})
+}
+
+// seed stores n active subscriptions, the i-th started i minutes after start, and
+// returns their IDs
+func seed(b *testing.B, store benchStore, n int, start time.Time) []string {
+	b.Helper()
+	ids := make([]string, 0, n)
+	var uow contracts.UnitOfWork
+	flush := func() {
+		if err := uow.Commit(store.ctx, store.subs); err != nil {
+			b.Fatalf("seeding: %v", err)
+		}
+	}
+
+	for i := 0; i < n; i++ {
+		id := uuid.New().String()
+		sub := domain.ReconstructFromPersistence(id, "bench-"+id, "plan-pro", 3000, domain.StatusActive, start.Add(time.Duration(i)*time.Minute))
+		uow.SaveChecked(store.subs.Save(store.ctx, sub))
+		ids = append(ids, id)
+		if uow.Len() == benchApplyChunk {
+			flush()
+		}
+	}
+	flush()
+	return ids
+}
+
+func BenchmarkSubscriptionRepo_FindByID(b *testing.B) {
+	forEachStore(b, func(b *testing.B, store benchStore) {
+		ids := seed(b, store, benchSeed, time.Now().UTC())
+
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if _, err := store.subs.FindByID(store.ctx, ids[i%len(ids)]); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// The renewal scheduler's scan, over a table where every seeded subscription is due,
+// stopped once it has a batch of a tenth of them
+func BenchmarkSubscriptionRepo_ForEachByStatus(b *testing.B) {
+	forEachStore(b, func(b *testing.B, store benchStore) {
+		started := time.Now().UTC().AddDate(0, 0, -2*benchCycleDays)
+		seed(b, store, benchSeed, started)
+		full := errors.New("batch full")
+
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			var due []string
+			err := store.scans.ForEachByStatus(store.ctx, domain.StatusActive, func(sub *domain.Subscription) error {
+				due = append(due, sub.ID())
+				if len(due) == benchRenewalPage {
+					return full
+				}
+				return nil
+			})
+			if !errors.Is(err, full) {
+				b.Fatalf("got %d subscriptions and %v, want a batch of %d", len(due), err, benchRenewalPage)
+			}
+		}
+	})
+}
+
+// One Apply of a batch of new subscriptions, at the batch sizes of a single command,
+// a worker page and a bulk import
+func BenchmarkSubscriptionRepo_Apply(b *testing.B) {
+	forEachStore(b, func(b *testing.B, store benchStore) {
+		for _, size := range []int{1, 10, 100} {
+			b.Run(fmt.Sprintf("batch=%d", size), func(b *testing.B) {
+				now := time.Now().UTC()
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					var uow contracts.UnitOfWork
+					for j := 0; j < size; j++ {
+						id := uuid.New().String()
+						uow.SaveChecked(store.subs.Save(store.ctx, domain.ReconstructFromPersistence(id, "bench-"+id, "plan-pro", 3000, domain.StatusActive, now)))
+					}
+					if err := uow.Commit(store.ctx, store.subs); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	})
+}
+
+func BenchmarkCreateSubscription(b *testing.B) {
+	forEachStore(b, func(b *testing.B, store benchStore) {
+		plan, _, err := domain.NewPlan("plan-pro", domain.PlanDetails{Name: "Pro", PriceCents: 3000, Currency: domain.DefaultCurrency}, domain.RealClock{})
+		if err != nil {
+			b.Fatal(err)
+		}
+		// Upserted, as the benchmark runs again for each b.N
+		var uow contracts.UnitOfWork
+		uow.Save(store.plans.Save(store.ctx, plan))
+		if err := uow.Commit(store.ctx, store.plans); err != nil {
+			b.Fatal(err)
+		}
+		creator := create_subscription.NewInteractor(
+			store.subs,
+			store.plans,
+			store.referrals,
+			store.bundles,
+			store.keys,
+			store.coupons,
+			adapters.StaticBillingResolver{Client: testkit.NewFakeBillingClient()},
+			adapters.StaticFeatureFlags{},
+			adapters.HookChain{},
+			adapters.NoopEventPublisher{},
+			domain.RealClock{},
+			30,
+		)
+
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			_, _, err := creator.Execute(store.ctx, create_subscription.Request{
+				CustomerID: "bench-" + uuid.New().String(),
+				PlanID:     "plan-pro",
+				PriceCents: 3000,
+			})
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// Cancels halfway through the period, so every cancellation refunds
+func BenchmarkCancelSubscription(b *testing.B) {
+	forEachStore(b, func(b *testing.B, store benchStore) {
+		ids := seed(b, store, b.N, time.Now().UTC().AddDate(0, 0, -benchCycleDays/2))
+		canceller := cancel_subscription.NewInteractor(
+			store.subs,
+			store.refunds,
+			store.outbox,
+			store.credits,
+			adapters.StaticBillingResolver{Client: testkit.NewFakeBillingClient()},
+			adapters.StaticPricing{},
+			adapters.StaticFeatureFlags{},
+			adapters.HookChain{},
+			adapters.NoopEventPublisher{},
+			domain.RealClock{},
+			adapters.StaticBillingCycle{Cycle: domain.CycleOfDays(benchCycleDays)},
+		)
+
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if _, err := canceller.Execute(store.ctx, ids[i], domain.CancellationReason{}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// Cancels b.N subscriptions halfway through their period in one bulk cancellation,
+// reporting the throughput the use case measured
+func BenchmarkBulkCancel(b *testing.B) {
+	forEachStore(b, func(b *testing.B, store benchStore) {
+		ids := seed(b, store, b.N, time.Now().UTC().AddDate(0, 0, -benchCycleDays/2))
+		canceller := cancel_subscription.NewInteractor(
+			store.subs,
+			store.refunds,
+			store.outbox,
+			store.credits,
+			adapters.StaticBillingResolver{Client: testkit.NewFakeBillingClient()},
+			adapters.StaticPricing{},
+			adapters.StaticFeatureFlags{},
+			adapters.HookChain{},
+			adapters.NoopEventPublisher{},
+			domain.RealClock{},
+			adapters.StaticBillingCycle{Cycle: domain.CycleOfDays(benchCycleDays)},
+		)
+		bulk := cancel_subscription.NewBulkInteractor(canceller, store.bulk, store.outbox, cancel_subscription.DefaultBulkConfig())
+
+		b.ReportAllocs()
+		b.ResetTimer()
+		result, err := bulk.Execute(store.ctx, cancel_subscription.BulkRequest{SubscriptionIDs: ids})
+		if err != nil {
+			b.Fatal(err)
+		}
+		if result.Cancelled != b.N {
+			b.Fatalf("cancelled %d of %d: %v", result.Cancelled, b.N, result.Failed)
+		}
+		b.ReportMetric(result.Throughput(), "cancels/s")
+	})
+}
@@ -1,37 +1,29 @@
+//go:build integration
+
 package e2e
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"os"
-	"path/filepath"
-	"strings"
 	"testing"
 	"time"
 
 	"cloud.google.com/go/spanner"
-	admin "cloud.google.com/go/spanner/admin/database/apiv1"
-	"cloud.google.com/go/spanner/admin/database/apiv1/databasepb"
-	instanceadmin "cloud.google.com/go/spanner/admin/instance/apiv1"
-	"cloud.google.com/go/spanner/admin/instance/apiv1/instancepb"
-	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/adapters"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/audit"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/backup"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
 	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/logging"
 	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/repo"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/testkit/integration"
 	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/cancel_subscription"
 	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/create_subscription"
-	"google.golang.org/api/option"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
-)
-
-const (
-	testProject  = "test-project"
-	testInstance = "test-instance"
-	testDatabase = "test-db"
-	emulatorHost = "localhost:9010"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/send_queued_refund"
 )
 
 // MockBillingClient is a mock implementation of BillingClient for e2e tests
@@ -44,152 +36,126 @@ func (m *MockBillingClient) ValidateCustomer(ctx context.Context, customerID str
 	return args.Error(0)
 }
 
-func (m *MockBillingClient) ProcessRefund(ctx context.Context, amount int64) error {
-	args := m.Called(ctx, amount)
+func (m *MockBillingClient) CreateCustomer(ctx context.Context, req contracts.CreateCustomerRequest) error {
+	args := m.Called(ctx, req)
+	return args.Error(0)
+}
+
+func (m *MockBillingClient) ProcessRefund(ctx context.Context, req contracts.RefundRequest) (string, error) {
+	args := m.Called(ctx, req)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockBillingClient) GetRefundStatus(ctx context.Context, providerRefundID string) (contracts.RefundOutcome, error) {
+	args := m.Called(ctx, providerRefundID)
+	return args.Get(0).(contracts.RefundOutcome), args.Error(1)
+}
+
+func (m *MockBillingClient) ChargeCustomer(ctx context.Context, req contracts.ChargeRequest) error {
+	args := m.Called(ctx, req)
 	return args.Error(0)
 }
 
+func (m *MockBillingClient) GetPaymentMethodStatus(ctx context.Context, customerID string) (domain.PaymentMethod, error) {
+	args := m.Called(ctx, customerID)
+	return args.Get(0).(domain.PaymentMethod), args.Error(1)
+}
+
+// refundOf matches a cancellation refund for amount
+func refundOf(amount int64) any {
+	return mock.MatchedBy(func(req contracts.RefundRequest) bool {
+		return req.Amount == amount && req.Reason == contracts.RefundReasonCancellation
+	})
+}
+
+func TestMain(m *testing.M) {
+	integration.Main(m)
+}
+
 // testSetup holds test dependencies
 type testSetup struct {
 	ctx               context.Context
-	cancel            context.CancelFunc
-	database          string
+	db                *integration.DB
 	spannerClient     *spanner.Client
-	adminClient       *admin.DatabaseAdminClient
 	subscriptionRepo  *repo.SubscriptionRepo
+	refundRepo        *repo.RefundRepo
+	outboxRepo        *repo.RefundOutboxRepo
+	creditRepo        *repo.CreditBalanceRepo
+	referralRepo      *repo.ReferralRepo
+	bundleRepo        *repo.BundleRepo
+	keyRepo           *repo.IdempotencyKeyRepo
+	couponRepo        *repo.CouponRepo
+	planRepo          *repo.PlanRepo
 	mockBillingClient *MockBillingClient
 	createInteractor  *create_subscription.Interactor
 	cancelInteractor  *cancel_subscription.Interactor
 	clock             domain.Clock
 }
 
-// setupTest creates a test database and initializes all dependencies
-func setupTest(t *testing.T) *testSetup {
-	// Create context with timeout for setup operations to prevent hanging
-	setupCtx, setupCancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer setupCancel()
-
-	// Set emulator host
-	os.Setenv("SPANNER_EMULATOR_HOST", emulatorHost)
-	defer os.Unsetenv("SPANNER_EMULATOR_HOST")
-
-	// Create unique database name for this test
-	dbName := fmt.Sprintf("%s-%s", testDatabase, uuid.New().String()[:8])
-	database := fmt.Sprintf("projects/%s/instances/%s/databases/%s", testProject, testInstance, dbName)
-
-	// Handle endpoint format for emulator
-	endpoint := emulatorHost
-	if strings.Contains(emulatorHost, "://") {
-		endpoint = strings.TrimPrefix(strings.TrimPrefix(emulatorHost, "http://"), "https://")
-	}
-
-	// Create admin client with timeout context
-	adminClient, err := admin.NewDatabaseAdminClient(setupCtx, option.WithEndpoint(endpoint))
-	if err != nil {
-		t.Fatalf("Failed to create admin client: %v. Make sure Spanner emulator is running (docker compose up -d)", err)
-	}
+// setupTest wires the dependencies to the package's test database. The test is
+// skipped when there is no emulator, and its rows are deleted when it ends.
+func setupTest(t testing.TB) *testSetup {
+	db := integration.Setup(t)
 
-	// Create instance if it doesn't exist (for emulator)
-	instanceName := fmt.Sprintf("projects/%s/instances/%s", testProject, testInstance)
-	projectName := fmt.Sprintf("projects/%s", testProject)
-
-	instanceAdminClient, err := instanceadmin.NewInstanceAdminClient(setupCtx, option.WithEndpoint(endpoint))
-	if err != nil {
-		t.Fatalf("Failed to create instance admin client: %v", err)
-	}
-	defer instanceAdminClient.Close()
-
-	_, err = instanceAdminClient.GetInstance(setupCtx, &instancepb.GetInstanceRequest{
-		Name: instanceName,
-	})
-	if err != nil {
-		if st, ok := status.FromError(err); ok && st.Code() == codes.NotFound {
-			// Instance doesn't exist, create it
-			op, err := instanceAdminClient.CreateInstance(setupCtx, &instancepb.CreateInstanceRequest{
-				Parent:     projectName,
-				InstanceId: testInstance,
-				Instance: &instancepb.Instance{
-					DisplayName: testInstance,
-				},
-			})
-			if err != nil {
-				t.Fatalf("Failed to create instance: %v", err)
-			}
-			_, err = op.Wait(setupCtx)
-			if err != nil {
-				if setupCtx.Err() == context.DeadlineExceeded {
-					t.Fatalf("Timeout waiting for instance creation. Is Spanner emulator running? (docker compose up -d)")
-				}
-				t.Fatalf("Failed to wait for instance creation: %v", err)
-			}
-		} else {
-			t.Fatalf("Failed to check instance existence: %v", err)
-		}
-	}
-
-	// Create database
-	op, err := adminClient.CreateDatabase(setupCtx, &databasepb.CreateDatabaseRequest{
-		Parent:          instanceName,
-		CreateStatement: fmt.Sprintf("CREATE DATABASE `%s`", dbName),
-	})
-	if err != nil {
-		t.Fatalf("Failed to create database: %v", err)
-	}
-
-	// Wait for database creation with timeout
-	db, err := op.Wait(setupCtx)
-	if err != nil {
-		if setupCtx.Err() == context.DeadlineExceeded {
-			t.Fatalf("Timeout waiting for database creation. Is Spanner emulator running? (docker compose up -d)")
-		}
-		t.Fatalf("Failed to wait for database creation: %v", err)
-	}
-	database = db.Name
-
-	// Run migrations (apply schema)
-	if err := runMigrations(setupCtx, adminClient, database); err != nil {
-		t.Fatalf("Failed to run migrations: %v", err)
-	}
-
-	// Create a background context for test execution (not canceled when setup returns)
+	// Create a context for test execution, cancelled when the test ends
 	ctx, cancel := context.WithCancel(context.Background())
-
-	// Create Spanner client
-	spannerEndpoint := emulatorHost
-	if strings.Contains(emulatorHost, "://") {
-		spannerEndpoint = strings.TrimPrefix(strings.TrimPrefix(emulatorHost, "http://"), "https://")
-	}
-	spannerClient, err := spanner.NewClient(ctx, database, option.WithEndpoint(spannerEndpoint))
-	if err != nil {
-		cancel()
-		t.Fatalf("Failed to create Spanner client: %v", err)
-	}
+	t.Cleanup(cancel)
 
 	// Initialize dependencies
-	subscriptionRepo := repo.NewSubscriptionRepo(spannerClient)
+	subscriptionRepo := repo.NewSubscriptionRepo(db.Client)
+	refundRepo := repo.NewRefundRepo(db.Client)
+	outboxRepo := repo.NewRefundOutboxRepo(db.Client)
+	creditRepo := repo.NewCreditBalanceRepo(db.Client)
+	referralRepo := repo.NewReferralRepo(db.Client)
+	bundleRepo := repo.NewBundleRepo(db.Client)
+	keyRepo := repo.NewIdempotencyKeyRepo(db.Client)
+	couponRepo := repo.NewCouponRepo(db.Client)
+	planRepo := repo.NewPlanRepo(db.Client)
 	mockBillingClient := new(MockBillingClient)
 	clock := domain.RealClock{}
 
 	createInteractor := create_subscription.NewInteractor(
 		subscriptionRepo,
-		mockBillingClient,
+		planRepo,
+		referralRepo,
+		bundleRepo,
+		keyRepo,
+		couponRepo,
+		adapters.StaticBillingResolver{Client: mockBillingClient},
+		adapters.StaticFeatureFlags{},
+		adapters.HookChain{},
+		adapters.NoopEventPublisher{},
 		clock,
+		30,
 	)
 
 	cancelInteractor := cancel_subscription.NewInteractor(
 		subscriptionRepo,
-		mockBillingClient,
+		refundRepo,
+		outboxRepo,
+		creditRepo,
+		adapters.StaticBillingResolver{Client: mockBillingClient},
+		adapters.StaticPricing{},
+		adapters.StaticFeatureFlags{},
+		adapters.HookChain{},
+		adapters.NoopEventPublisher{},
 		clock,
-		30, // billing cycle days
+		adapters.StaticBillingCycle{Cycle: domain.CycleOfDays(30)}, // billing cycle days
 	)
 
 	return &testSetup{
 		ctx:               ctx,
-		cancel:            cancel,
-		database:          database,
-		spannerClient:     spannerClient,
-		adminClient:       adminClient,
+		db:                db,
+		spannerClient:     db.Client,
 		subscriptionRepo:  subscriptionRepo,
+		refundRepo:        refundRepo,
+		outboxRepo:        outboxRepo,
+		creditRepo:        creditRepo,
+		referralRepo:      referralRepo,
+		bundleRepo:        bundleRepo,
+		keyRepo:           keyRepo,
+		couponRepo:        couponRepo,
+		planRepo:          planRepo,
 		mockBillingClient: mockBillingClient,
 		createInteractor:  createInteractor,
 		cancelInteractor:  cancelInteractor,
@@ -197,146 +163,18 @@ func setupTest(t *testing.T) *testSetup {
 	}
 }
 
-// teardownTest cleans up test resources
-func (ts *testSetup) teardownTest(t *testing.T) {
-	// Cancel context first to stop any ongoing operations
-	if ts.cancel != nil {
-		ts.cancel()
-	}
-
-	if ts.spannerClient != nil {
-		ts.spannerClient.Close()
-	}
-
-	if ts.adminClient != nil {
-		// Use a fresh context for cleanup operations
-		cleanupCtx, cleanupCancel := context.WithTimeout(context.Background(), 10*time.Second)
-		defer cleanupCancel()
-
-		// Drop database
-		if err := ts.adminClient.DropDatabase(cleanupCtx, &databasepb.DropDatabaseRequest{
-			Database: ts.database,
-		}); err != nil {
-			t.Logf("Failed to drop database: %v", err)
-		}
-		ts.adminClient.Close()
-	}
-}
-
-// runMigrations runs database migrations by reading the migration file
-func runMigrations(ctx context.Context, adminClient *admin.DatabaseAdminClient, database string) error {
-	// Find migrations directory relative to project root
-	migrationsDir, err := findMigrationsDir()
-	if err != nil {
-		return fmt.Errorf("failed to find migrations directory: %w", err)
-	}
-
-	migrationFile := filepath.Join(migrationsDir, "001_initial_schema.sql")
-	migrationSQL, err := os.ReadFile(migrationFile)
-	if err != nil {
-		return fmt.Errorf("failed to read migration file: %w", err)
-	}
-
-	statements := parseDDLStatements(string(migrationSQL))
-
-	op, err := adminClient.UpdateDatabaseDdl(ctx, &databasepb.UpdateDatabaseDdlRequest{
-		Database:   database,
-		Statements: statements,
-	})
-	if err != nil {
-		return fmt.Errorf("failed to start DDL operation: %w", err)
-	}
-
-	err = op.Wait(ctx)
-	if err != nil {
-		if ctx.Err() == context.DeadlineExceeded {
-			return fmt.Errorf("timeout waiting for migrations. Is Spanner emulator running? (docker compose up -d)")
-		}
-		return fmt.Errorf("failed to wait for migrations: %w", err)
-	}
-	return nil
-}
-
-// findMigrationsDir finds the migrations directory relative to the project root
-func findMigrationsDir() (string, error) {
-	// Start from current working directory
-	wd, err := os.Getwd()
-	if err != nil {
-		return "", fmt.Errorf("failed to get working directory: %w", err)
-	}
-
-	// Walk up the directory tree to find go.mod (project root)
-	dir := wd
-	for {
-		goModPath := filepath.Join(dir, "go.mod")
-		if _, err := os.Stat(goModPath); err == nil {
-			// Found project root, migrations should be at migrations/
-			migrationsPath := filepath.Join(dir, "migrations")
-			if _, err := os.Stat(migrationsPath); err == nil {
-				return migrationsPath, nil
-			}
-			return "", fmt.Errorf("migrations directory not found at %s", migrationsPath)
-		}
-
-		// Move up one directory
-		parent := filepath.Dir(dir)
-		if parent == dir {
-			// Reached filesystem root
-			break
-		}
-		dir = parent
-	}
-
-	// Fallback: try relative path from current directory
-	migrationsPath := filepath.Join(wd, "migrations")
-	if _, err := os.Stat(migrationsPath); err == nil {
-		return migrationsPath, nil
-	}
-
-	return "", fmt.Errorf("could not find migrations directory (searched from %s)", wd)
-}
-
-// parseDDLStatements parses SQL into DDL statements
-func parseDDLStatements(sql string) []string {
-	var statements []string
-	current := ""
-
-	lines := strings.Split(sql, "\n")
-	for _, line := range lines {
-		trimmed := strings.TrimSpace(line)
-		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
-			continue
-		}
-		current += " " + trimmed
-		if strings.HasSuffix(trimmed, ";") {
-			stmt := strings.TrimSpace(strings.TrimSuffix(current, ";"))
-			if stmt != "" {
-				statements = append(statements, stmt)
-			}
-			current = ""
-		}
-	}
-
-	return statements
-}
-
-// cleanupDatabase deletes all test data
-func (ts *testSetup) cleanupDatabase(t *testing.T) {
-	// Delete all subscriptions
-	_, err := ts.spannerClient.Apply(ts.ctx, []*spanner.Mutation{
-		spanner.Delete("subscriptions", spanner.AllKeys()),
-	})
-	if err != nil {
-		t.Logf("Failed to cleanup subscriptions: %v", err)
-	}
+// withPlan puts an active plan in the catalog, so subscriptions can be created on it
+// at the price
+func (ts *testSetup) withPlan(t testing.TB, planID string, priceCents int64) {
+	plan, _, err := domain.NewPlan(planID, domain.PlanDetails{Name: planID, PriceCents: priceCents, Currency: domain.DefaultCurrency}, ts.clock)
+	require.NoError(t, err)
+	require.NoError(t, ts.planRepo.Insert(ts.ctx, plan))
 }
 
 // Test scenarios
 
 func TestE2E_CreateAndCancelSubscription(t *testing.T) {
 	ts := setupTest(t)
-	defer ts.teardownTest(t)
-	defer ts.cleanupDatabase(t)
 
 	// Use fixed clock for deterministic tests
 	startDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
@@ -345,14 +183,24 @@ func TestE2E_CreateAndCancelSubscription(t *testing.T) {
 	// Create use cases with fixed clock
 	createInteractor := create_subscription.NewInteractor(
 		ts.subscriptionRepo,
-		ts.mockBillingClient,
+		ts.planRepo,
+		ts.referralRepo,
+		ts.bundleRepo,
+		ts.keyRepo,
+		ts.couponRepo,
+		adapters.StaticBillingResolver{Client: ts.mockBillingClient},
+		adapters.StaticFeatureFlags{},
+		adapters.HookChain{},
+		adapters.NoopEventPublisher{},
 		fixedClock,
+		30,
 	)
 
 	// Test data
 	customerID := "cust-e2e-123"
 	planID := "plan-premium"
 	priceCents := int64(3000) // $30.00
+	ts.withPlan(t, planID, priceCents)
 
 	// Step 1: Create subscription
 	t.Run("Create subscription", func(t *testing.T) {
@@ -420,16 +268,23 @@ func TestE2E_CreateAndCancelSubscription(t *testing.T) {
 		// Create new cancel interactor with updated clock
 		cancelInteractorWithClock := cancel_subscription.NewInteractor(
 			ts.subscriptionRepo,
-			ts.mockBillingClient,
+			ts.refundRepo,
+			ts.outboxRepo,
+			ts.creditRepo,
+			adapters.StaticBillingResolver{Client: ts.mockBillingClient},
+			adapters.StaticPricing{},
+			adapters.StaticFeatureFlags{},
+			adapters.HookChain{},
+			adapters.NoopEventPublisher{},
 			cancelClock,
-			30,
+			adapters.StaticBillingCycle{Cycle: domain.CycleOfDays(30)},
 		)
 
 		// Expected refund: 3000 * (30 - 14) / 30 = 1600 cents
 		expectedRefund := int64(1600)
-		ts.mockBillingClient.On("ProcessRefund", ts.ctx, expectedRefund).Return(nil)
+		ts.mockBillingClient.On("ProcessRefund", ts.ctx, refundOf(expectedRefund)).Return("refund-e2e-cancel", nil)
 
-		event, err := cancelInteractorWithClock.Execute(ts.ctx, subscriptionID)
+		event, err := cancelInteractorWithClock.Execute(ts.ctx, subscriptionID, domain.CancellationReason{})
 
 		// Assertions
 		require.NoError(t, err)
@@ -445,6 +300,12 @@ func TestE2E_CreateAndCancelSubscription(t *testing.T) {
 		require.NoError(t, err)
 		assert.Equal(t, domain.StatusCancelled, persistedSub.Status())
 
+		// Verify the accepted refund is tracked until it settles
+		refund, err := ts.refundRepo.FindByProviderRefundID(ts.ctx, "refund-e2e-cancel")
+		require.NoError(t, err)
+		assert.Equal(t, domain.RefundPending, refund.Status())
+		assert.Equal(t, expectedRefund, refund.Amount())
+
 		ts.mockBillingClient.AssertExpectations(t)
 	})
 
@@ -455,12 +316,19 @@ func TestE2E_CreateAndCancelSubscription(t *testing.T) {
 
 		cancelInteractorWithClock := cancel_subscription.NewInteractor(
 			ts.subscriptionRepo,
-			ts.mockBillingClient,
+			ts.refundRepo,
+			ts.outboxRepo,
+			ts.creditRepo,
+			adapters.StaticBillingResolver{Client: ts.mockBillingClient},
+			adapters.StaticPricing{},
+			adapters.StaticFeatureFlags{},
+			adapters.HookChain{},
+			adapters.NoopEventPublisher{},
 			cancelClock,
-			30,
+			adapters.StaticBillingCycle{Cycle: domain.CycleOfDays(30)},
 		)
 
-		event, err := cancelInteractorWithClock.Execute(ts.ctx, subscriptionID)
+		event, err := cancelInteractorWithClock.Execute(ts.ctx, subscriptionID, domain.CancellationReason{})
 
 		// Should return error
 		assert.Error(t, err)
@@ -476,19 +344,27 @@ func TestE2E_CreateAndCancelSubscription(t *testing.T) {
 
 func TestE2E_CancelSubscription_NoRefund(t *testing.T) {
 	ts := setupTest(t)
-	defer ts.teardownTest(t)
-	defer ts.cleanupDatabase(t)
 
 	startDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
 	clock := domain.FixedClock{FixedTime: startDate}
 
 	createInteractor := create_subscription.NewInteractor(
 		ts.subscriptionRepo,
-		ts.mockBillingClient,
+		ts.planRepo,
+		ts.referralRepo,
+		ts.bundleRepo,
+		ts.keyRepo,
+		ts.couponRepo,
+		adapters.StaticBillingResolver{Client: ts.mockBillingClient},
+		adapters.StaticFeatureFlags{},
+		adapters.HookChain{},
+		adapters.NoopEventPublisher{},
 		clock,
+		30,
 	)
 
 	// Create subscription
+	ts.withPlan(t, "plan-basic", 2000)
 	ts.mockBillingClient.On("ValidateCustomer", ts.ctx, "cust-no-refund").Return(nil)
 	req := create_subscription.Request{
 		CustomerID: "cust-no-refund",
@@ -505,13 +381,20 @@ func TestE2E_CancelSubscription_NoRefund(t *testing.T) {
 
 	cancelInteractor := cancel_subscription.NewInteractor(
 		ts.subscriptionRepo,
-		ts.mockBillingClient,
+		ts.refundRepo,
+		ts.outboxRepo,
+		ts.creditRepo,
+		adapters.StaticBillingResolver{Client: ts.mockBillingClient},
+		adapters.StaticPricing{},
+		adapters.StaticFeatureFlags{},
+		adapters.HookChain{},
+		adapters.NoopEventPublisher{},
 		cancelClock,
-		30,
+		adapters.StaticBillingCycle{Cycle: domain.CycleOfDays(30)},
 	)
 
 	// No refund should be processed (amount is 0)
-	event, err := cancelInteractor.Execute(ts.ctx, sub.ID())
+	event, err := cancelInteractor.Execute(ts.ctx, sub.ID(), domain.CancellationReason{})
 
 	require.NoError(t, err)
 	assert.Equal(t, int64(0), event.RefundAmount)
@@ -556,19 +439,27 @@ func TestE2E_CancelSubscription_RefundCalculation(t *testing.T) {
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			ts := setupTest(t)
-			defer ts.teardownTest(t)
-			defer ts.cleanupDatabase(t)
 
 			startDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
 			createClock := domain.FixedClock{FixedTime: startDate}
 
 			createInteractor := create_subscription.NewInteractor(
 				ts.subscriptionRepo,
-				ts.mockBillingClient,
+				ts.planRepo,
+				ts.referralRepo,
+				ts.bundleRepo,
+				ts.keyRepo,
+				ts.couponRepo,
+				adapters.StaticBillingResolver{Client: ts.mockBillingClient},
+				adapters.StaticFeatureFlags{},
+				adapters.HookChain{},
+				adapters.NoopEventPublisher{},
 				createClock,
+				30,
 			)
 
 			// Create subscription
+			ts.withPlan(t, "plan-test", tc.priceCents)
 			customerID := "cust-" + tc.name
 			ts.mockBillingClient.On("ValidateCustomer", ts.ctx, customerID).Return(nil)
 
@@ -587,16 +478,23 @@ func TestE2E_CancelSubscription_RefundCalculation(t *testing.T) {
 
 			cancelInteractor := cancel_subscription.NewInteractor(
 				ts.subscriptionRepo,
-				ts.mockBillingClient,
+				ts.refundRepo,
+				ts.outboxRepo,
+				ts.creditRepo,
+				adapters.StaticBillingResolver{Client: ts.mockBillingClient},
+				adapters.StaticPricing{},
+				adapters.StaticFeatureFlags{},
+				adapters.HookChain{},
+				adapters.NoopEventPublisher{},
 				cancelClock,
-				30,
+				adapters.StaticBillingCycle{Cycle: domain.CycleOfDays(30)},
 			)
 
 			if tc.expectedRefund > 0 {
-				ts.mockBillingClient.On("ProcessRefund", ts.ctx, tc.expectedRefund).Return(nil)
+				ts.mockBillingClient.On("ProcessRefund", ts.ctx, refundOf(tc.expectedRefund)).Return("refund-"+tc.name, nil)
 			}
 
-			event, err := cancelInteractor.Execute(ts.ctx, sub.ID())
+			event, err := cancelInteractor.Execute(ts.ctx, sub.ID(), domain.CancellationReason{})
 
 			require.NoError(t, err)
 			assert.Equal(t, tc.expectedRefund, event.RefundAmount)
@@ -613,8 +511,7 @@ func TestE2E_CancelSubscription_RefundCalculation(t *testing.T) {
 
 func TestE2E_CreateSubscription_InvalidCustomer(t *testing.T) {
 	ts := setupTest(t)
-	defer ts.teardownTest(t)
-	defer ts.cleanupDatabase(t)
+	ts.withPlan(t, "plan-test", 1000)
 
 	// Mock billing client to return error
 	ts.mockBillingClient.On("ValidateCustomer", ts.ctx, "invalid-customer").Return(domain.ErrInvalidCustomer)
@@ -650,13 +547,55 @@ func TestE2E_CreateSubscription_InvalidCustomer(t *testing.T) {
 	ts.mockBillingClient.AssertExpectations(t)
 }
 
+func TestE2E_RetriedCreateReturnsTheSubscriptionItsKeyCreated(t *testing.T) {
+	ts := setupTest(t)
+	ts.withPlan(t, "plan-basic", 3000)
+	ts.mockBillingClient.On("ValidateCustomer", ts.ctx, "cust-retry").Return(nil).Once()
+	req := create_subscription.Request{CustomerID: "cust-retry", PlanID: "plan-basic", PriceCents: 3000, IdempotencyKey: "order-42"}
+
+	first, event, err := ts.createInteractor.Execute(ts.ctx, req)
+	require.NoError(t, err)
+	require.NotNil(t, event)
+
+	retried, event, err := ts.createInteractor.Execute(ts.ctx, req)
+	require.NoError(t, err)
+	assert.Nil(t, event)
+	assert.Equal(t, first.ID(), retried.ID())
+
+	ids, err := ts.subscriptionRepo.FindIDsByCustomer(ts.ctx, "cust-retry")
+	require.NoError(t, err)
+	assert.Equal(t, []string{first.ID()}, ids)
+	ts.mockBillingClient.AssertExpectations(t)
+}
+
+func TestE2E_CouponIsRedeemedUpToItsLimit(t *testing.T) {
+	ts := setupTest(t)
+	ts.withPlan(t, "plan-basic", 3000)
+	ts.mockBillingClient.On("ValidateCustomer", ts.ctx, mock.Anything).Return(nil)
+	coupon, err := domain.NewCoupon("LAUNCH20", domain.CouponTerms{PercentOff: 2000, Duration: domain.CouponOnce, MaxRedemptions: 1}, ts.clock)
+	require.NoError(t, err)
+	require.NoError(t, ts.couponRepo.Insert(ts.ctx, coupon))
+
+	sub, event, err := ts.createInteractor.Execute(ts.ctx, create_subscription.Request{CustomerID: "cust-1", PlanID: "plan-basic", CouponCode: "launch20"})
+	require.NoError(t, err)
+	assert.Equal(t, "LAUNCH20", event.CouponCode)
+
+	stored, err := ts.subscriptionRepo.FindByID(ts.ctx, sub.ID())
+	require.NoError(t, err)
+	assert.Equal(t, domain.SubscriptionCoupon{Code: "LAUNCH20", PercentOff: 2000, PeriodsLeft: 1}, stored.Coupon())
+	redeemed, err := ts.couponRepo.FindByCode(ts.ctx, "LAUNCH20")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), redeemed.TimesRedeemed())
+
+	_, _, err = ts.createInteractor.Execute(ts.ctx, create_subscription.Request{CustomerID: "cust-2", PlanID: "plan-basic", CouponCode: "LAUNCH20"})
+	assert.ErrorIs(t, err, domain.ErrCouponRedemptionLimitReached)
+}
+
 func TestE2E_CancelSubscription_NotFound(t *testing.T) {
 	ts := setupTest(t)
-	defer ts.teardownTest(t)
-	defer ts.cleanupDatabase(t)
 
 	// Try to cancel non-existent subscription
-	event, err := ts.cancelInteractor.Execute(ts.ctx, "non-existent-id")
+	event, err := ts.cancelInteractor.Execute(ts.ctx, "non-existent-id", domain.CancellationReason{})
 
 	// Should return error
 	assert.Error(t, err)
@@ -666,3 +605,322 @@ func TestE2E_CancelSubscription_NotFound(t *testing.T) {
 	// Verify ProcessRefund was NOT called
 	ts.mockBillingClient.AssertNotCalled(t, "ProcessRefund", ts.ctx, mock.Anything)
 }
+
+func TestE2E_BackupAndRestore(t *testing.T) {
+	ts := setupTest(t)
+	ts.withPlan(t, "plan-basic", 1500)
+
+	ts.mockBillingClient.On("ValidateCustomer", ts.ctx, "cust-backup").Return(nil)
+	sub, _, err := ts.createInteractor.Execute(ts.ctx, create_subscription.Request{
+		CustomerID: "cust-backup",
+		PlanID:     "plan-basic",
+		PriceCents: 1500,
+	})
+	require.NoError(t, err)
+	// Compare against the stored row, which holds microseconds
+	stored, err := ts.subscriptionRepo.FindByID(ts.ctx, sub.ID())
+	require.NoError(t, err)
+
+	store := backup.DirStore(t.TempDir())
+	manifest, err := backup.Export(ts.ctx, ts.spannerClient, store, backup.ExportOptions{Tables: []string{"subscriptions"}, Parallelism: 2}, logging.Discard())
+	require.NoError(t, err)
+	require.Len(t, manifest.Tables, 1)
+	assert.Equal(t, int64(1), manifest.Tables[0].Rows)
+
+	ts.db.Truncate(t, "subscriptions")
+	_, err = ts.subscriptionRepo.FindByID(ts.ctx, sub.ID())
+	require.ErrorIs(t, err, domain.ErrSubscriptionNotFound)
+
+	_, err = backup.Restore(ts.ctx, ts.spannerClient, store, backup.RestoreOptions{}, logging.Discard())
+	require.NoError(t, err)
+
+	restored, err := ts.subscriptionRepo.FindByID(ts.ctx, sub.ID())
+	require.NoError(t, err)
+	assert.Equal(t, stored.CustomerID(), restored.CustomerID())
+	assert.Equal(t, stored.Price(), restored.Price())
+	assert.True(t, stored.StartDate().Equal(restored.StartDate()))
+}
+
+func TestE2E_PartitionedAuditExport(t *testing.T) {
+	ts := setupTest(t)
+
+	auditRepo := repo.NewAuditRepo(ts.spannerClient)
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 20; i++ {
+		require.NoError(t, auditRepo.Record(ts.ctx, contracts.AuditEntry{
+			ID:          fmt.Sprintf("audit-%02d", i),
+			Action:      "customer.erase",
+			Principal:   "ops@example.com",
+			PayloadHash: "hash",
+			Outcome:     contracts.AuditSucceeded,
+			OccurredAt:  base.Add(time.Duration(i) * time.Minute),
+		}))
+	}
+
+	dir := t.TempDir()
+	from := audit.Cursor{OccurredAt: base.Add(4 * time.Minute), ID: "audit-04"}
+	manifest, err := audit.ExportPartitioned(ts.ctx, auditRepo, backup.DirStore(dir), from, 4)
+	require.NoError(t, err)
+	assert.Equal(t, int64(15), manifest.Rows)
+	assert.Equal(t, "audit-19", manifest.Cursor.ID)
+	assert.NotEmpty(t, manifest.Files)
+
+	again, err := audit.ExportPartitioned(ts.ctx, auditRepo, backup.DirStore(dir), manifest.Cursor, 4)
+	require.NoError(t, err)
+	assert.Zero(t, again.Rows)
+	assert.Equal(t, manifest.Cursor, again.Cursor, "an empty export keeps the cursor")
+}
+
+func TestE2E_BulkCancelQueuesRefundsThatAreSentLater(t *testing.T) {
+	ts := setupTest(t)
+
+	startDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var uow contracts.UnitOfWork
+	for i := 0; i < 5; i++ {
+		sub := domain.ReconstructFromPersistence(fmt.Sprintf("bulk-%d", i), "cust-closing", "plan-basic", 3000, domain.StatusActive, startDate)
+		uow.SaveChecked(ts.subscriptionRepo.Save(ts.ctx, sub))
+	}
+	require.NoError(t, uow.Commit(ts.ctx, ts.subscriptionRepo))
+
+	clock := domain.FixedClock{FixedTime: startDate.AddDate(0, 0, 15)}
+	cancel := cancel_subscription.NewInteractor(ts.subscriptionRepo, ts.refundRepo, ts.outboxRepo, ts.creditRepo, adapters.StaticBillingResolver{Client: ts.mockBillingClient}, adapters.StaticPricing{}, adapters.StaticFeatureFlags{}, adapters.HookChain{}, adapters.NoopEventPublisher{}, clock, adapters.StaticBillingCycle{Cycle: domain.CycleOfDays(30)})
+	bulk := cancel_subscription.NewBulkInteractor(cancel, ts.subscriptionRepo, ts.outboxRepo, cancel_subscription.BulkConfig{BatchSize: 2, Concurrency: 2})
+
+	result, err := bulk.Execute(ts.ctx, cancel_subscription.BulkRequest{CustomerID: "cust-closing"})
+	require.NoError(t, err)
+	assert.Equal(t, 5, result.Cancelled)
+	assert.Equal(t, 3, result.Batches)
+	assert.Equal(t, int64(5*1500), result.RefundedAmount)
+	ts.mockBillingClient.AssertNotCalled(t, "ProcessRefund", mock.Anything, mock.Anything)
+
+	remaining, err := ts.subscriptionRepo.FindIDsByCustomer(ts.ctx, "cust-closing")
+	require.NoError(t, err)
+	assert.Empty(t, remaining)
+
+	queued, err := ts.outboxRepo.FindDue(ts.ctx, clock.Now(), 10)
+	require.NoError(t, err)
+	require.Len(t, queued, 5)
+
+	for i := range queued {
+		ts.mockBillingClient.On("ProcessRefund", mock.Anything, refundOf(1500)).Return(fmt.Sprintf("provider-refund-%d", i), nil).Once()
+	}
+	sender := send_queued_refund.NewInteractor(ts.outboxRepo, ts.refundRepo, adapters.StaticBillingResolver{Client: ts.mockBillingClient}, clock)
+	for _, q := range queued {
+		_, err := sender.Execute(ts.ctx, q.ID())
+		require.NoError(t, err)
+	}
+
+	queued, err = ts.outboxRepo.FindDue(ts.ctx, clock.Now(), 10)
+	require.NoError(t, err)
+	assert.Empty(t, queued)
+	pending, err := ts.refundRepo.FindPending(ts.ctx, clock.Now(), 10)
+	require.NoError(t, err)
+	assert.Len(t, pending, 5)
+	ts.mockBillingClient.AssertExpectations(t)
+}
+
+func TestE2E_BulkCancelRetiresAPlan(t *testing.T) {
+	ts := setupTest(t)
+
+	startDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var uow contracts.UnitOfWork
+	for i, planID := range []string{"plan-legacy", "plan-legacy", "plan-legacy", "plan-basic"} {
+		sub := domain.ReconstructFromPersistence(fmt.Sprintf("sunset-%d", i), fmt.Sprintf("cust-sunset-%d", i), planID, 3000, domain.StatusActive, startDate)
+		uow.SaveChecked(ts.subscriptionRepo.Save(ts.ctx, sub))
+	}
+	require.NoError(t, uow.Commit(ts.ctx, ts.subscriptionRepo))
+
+	clock := domain.FixedClock{FixedTime: startDate.AddDate(0, 0, 15)}
+	cancel := cancel_subscription.NewInteractor(ts.subscriptionRepo, ts.refundRepo, ts.outboxRepo, ts.creditRepo, adapters.StaticBillingResolver{Client: ts.mockBillingClient}, adapters.StaticPricing{}, adapters.StaticFeatureFlags{}, adapters.HookChain{}, adapters.NoopEventPublisher{}, clock, adapters.StaticBillingCycle{Cycle: domain.CycleOfDays(30)})
+	bulk := cancel_subscription.NewBulkInteractor(cancel, ts.subscriptionRepo, ts.outboxRepo, cancel_subscription.BulkConfig{BatchSize: 2, Concurrency: 2})
+
+	retired := domain.CancellationReason{Code: domain.CancellationReasonPlanRetired, Details: "Legacy plan sunset"}
+	result, err := bulk.Execute(ts.ctx, cancel_subscription.BulkRequest{PlanID: "plan-legacy", Reason: retired})
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.Cancelled)
+	assert.Empty(t, result.Failed)
+	cancelled, err := ts.subscriptionRepo.FindByID(ts.ctx, "sunset-0")
+	require.NoError(t, err)
+	assert.Equal(t, retired, cancelled.CancellationReason())
+
+	remaining, err := ts.subscriptionRepo.FindIDsByPlan(ts.ctx, "plan-legacy")
+	require.NoError(t, err)
+	assert.Empty(t, remaining)
+	kept, err := ts.subscriptionRepo.FindByID(ts.ctx, "sunset-3")
+	require.NoError(t, err)
+	assert.Equal(t, domain.StatusActive, kept.Status())
+	assert.True(t, kept.CancellationReason().IsZero())
+
+	counts, err := ts.subscriptionRepo.CountCancellationReasons(ts.ctx, startDate)
+	require.NoError(t, err)
+	assert.Contains(t, counts, contracts.CancellationReasonCount{Month: startDate, Reason: domain.CancellationReasonPlanRetired, Cancellations: 3})
+}
+
+func TestE2E_FailedRefundIsQueuedAndRetried(t *testing.T) {
+	ts := setupTest(t)
+
+	startDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	sub := domain.ReconstructFromPersistence("retry-1", "cust-retry-refund", "plan-basic", 3000, domain.StatusActive, startDate)
+	mutation, check, err := ts.subscriptionRepo.Save(ts.ctx, sub)
+	require.NoError(t, err)
+	require.NoError(t, ts.subscriptionRepo.ApplyChecked(ts.ctx, []contracts.VersionCheck{check}, mutation))
+
+	clock := domain.FixedClock{FixedTime: startDate.AddDate(0, 0, 15)}
+	cancel := cancel_subscription.NewInteractor(ts.subscriptionRepo, ts.refundRepo, ts.outboxRepo, ts.creditRepo, adapters.StaticBillingResolver{Client: ts.mockBillingClient}, adapters.StaticPricing{}, adapters.StaticFeatureFlags{}, adapters.HookChain{}, adapters.NoopEventPublisher{}, clock, adapters.StaticBillingCycle{Cycle: domain.CycleOfDays(30)})
+	ts.mockBillingClient.On("ProcessRefund", mock.Anything, refundOf(1500)).Return("", errors.New("billing unavailable")).Once()
+
+	_, err = cancel.Execute(ts.ctx, "retry-1", domain.CancellationReason{})
+	require.NoError(t, err)
+
+	owed, err := ts.outboxRepo.FindByCustomer(ts.ctx, "cust-retry-refund")
+	require.NoError(t, err)
+	require.Len(t, owed, 1)
+	assert.Equal(t, int64(1), owed[0].Attempts())
+	assert.Equal(t, "billing unavailable", owed[0].LastError())
+	due, err := ts.outboxRepo.FindDue(ts.ctx, clock.Now(), 10)
+	require.NoError(t, err)
+	assert.Empty(t, due, "the retry backs off")
+
+	later := domain.FixedClock{FixedTime: clock.Now().Add(time.Minute)}
+	ts.mockBillingClient.On("ProcessRefund", mock.Anything, refundOf(1500)).Return("provider-refund-1", nil).Once()
+	_, err = send_queued_refund.NewInteractor(ts.outboxRepo, ts.refundRepo, adapters.StaticBillingResolver{Client: ts.mockBillingClient}, later).Execute(ts.ctx, owed[0].ID())
+	require.NoError(t, err)
+
+	owed, err = ts.outboxRepo.FindByCustomer(ts.ctx, "cust-retry-refund")
+	require.NoError(t, err)
+	assert.Empty(t, owed)
+	ts.mockBillingClient.AssertExpectations(t)
+}
+
+func TestE2E_CancellationAtPeriodEndIsCarriedOutWhenDue(t *testing.T) {
+	ts := setupTest(t)
+
+	startDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	sub := domain.ReconstructFromPersistence("period-end-1", "cust-period-end", "plan-basic", 3000, domain.StatusActive, startDate)
+	mutation, check, err := ts.subscriptionRepo.Save(ts.ctx, sub)
+	require.NoError(t, err)
+	require.NoError(t, ts.subscriptionRepo.ApplyChecked(ts.ctx, []contracts.VersionCheck{check}, mutation))
+
+	cancelAt := startDate.AddDate(0, 0, 30)
+	canceller := func(now time.Time) *cancel_subscription.Interactor {
+		return cancel_subscription.NewInteractor(ts.subscriptionRepo, ts.refundRepo, ts.outboxRepo, ts.creditRepo, adapters.StaticBillingResolver{Client: ts.mockBillingClient}, adapters.StaticPricing{}, adapters.StaticFeatureFlags{}, adapters.HookChain{}, adapters.NoopEventPublisher{}, domain.FixedClock{FixedTime: now}, adapters.StaticBillingCycle{Cycle: domain.CycleOfDays(30)})
+	}
+	event, err := canceller(startDate.AddDate(0, 0, 15)).CancelAtPeriodEnd(ts.ctx, "period-end-1", domain.CancellationReason{})
+	require.NoError(t, err)
+	assert.Equal(t, cancelAt, event.CancelAt)
+
+	stored, err := ts.subscriptionRepo.FindByID(ts.ctx, "period-end-1")
+	require.NoError(t, err)
+	assert.Equal(t, domain.StatusPendingCancellation, stored.Status())
+	assert.Equal(t, cancelAt, stored.CancelAt().UTC())
+	due, err := ts.subscriptionRepo.FindDueForCancellation(ts.ctx, cancelAt.Add(-time.Second), 10)
+	require.NoError(t, err)
+	assert.Empty(t, due)
+
+	due, err = ts.subscriptionRepo.FindDueForCancellation(ts.ctx, cancelAt, 10)
+	require.NoError(t, err)
+	require.Len(t, due, 1)
+	assert.Equal(t, "period-end-1", due[0].ID())
+
+	cancelled, err := canceller(cancelAt).FinalizeScheduled(ts.ctx, "period-end-1")
+	require.NoError(t, err)
+	assert.Zero(t, cancelled.RefundAmount)
+	stored, err = ts.subscriptionRepo.FindByID(ts.ctx, "period-end-1")
+	require.NoError(t, err)
+	assert.Equal(t, domain.StatusCancelled, stored.Status())
+	ts.mockBillingClient.AssertNotCalled(t, "ProcessRefund", mock.Anything, mock.Anything)
+}
+
+func TestE2E_SecondOfTwoConcurrentCancelsIsRejected(t *testing.T) {
+	ts := setupTest(t)
+
+	startDate := time.Now().AddDate(0, 0, -10)
+	sub := domain.ReconstructFromPersistence("occ-1", "cust-occ", "plan-basic", 3000, domain.StatusActive, startDate)
+	mutation, check, err := ts.subscriptionRepo.Save(ts.ctx, sub)
+	require.NoError(t, err)
+	require.NoError(t, ts.subscriptionRepo.ApplyChecked(ts.ctx, []contracts.VersionCheck{check}, mutation))
+
+	// Both requests read the subscription as active before either commits
+	first, err := ts.subscriptionRepo.FindByID(ts.ctx, "occ-1")
+	require.NoError(t, err)
+	second, err := ts.subscriptionRepo.FindByID(ts.ctx, "occ-1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), first.Version())
+
+	_, err = first.Cancel(ts.clock, 30)
+	require.NoError(t, err)
+	var firstUoW contracts.UnitOfWork
+	firstUoW.SaveChecked(ts.subscriptionRepo.Save(ts.ctx, first))
+	require.NoError(t, firstUoW.Commit(ts.ctx, ts.subscriptionRepo))
+
+	_, err = second.Cancel(ts.clock, 30)
+	require.NoError(t, err)
+	var secondUoW contracts.UnitOfWork
+	secondUoW.SaveChecked(ts.subscriptionRepo.Save(ts.ctx, second))
+	assert.ErrorIs(t, secondUoW.Commit(ts.ctx, ts.refundRepo), contracts.ErrUncheckedCommit, "a repository that can't check the version doesn't commit")
+	assert.ErrorIs(t, secondUoW.Commit(ts.ctx, ts.subscriptionRepo), domain.ErrConcurrentModification)
+	assert.ErrorIs(t, secondUoW.Commit(ts.ctx, ts.subscriptionRepo), domain.ErrConcurrentModification, "a retry checks again")
+
+	stored, err := ts.subscriptionRepo.FindByID(ts.ctx, "occ-1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), stored.Version())
+	assert.Equal(t, domain.StatusCancelled, stored.Status())
+}
+
+func TestE2E_ConcurrentCancelsRefundOnce(t *testing.T) {
+	ts := setupTest(t)
+
+	sub := domain.ReconstructFromPersistence("race-1", "cust-race", "plan-basic", 3000, domain.StatusActive, time.Now().AddDate(0, 0, -10))
+	mutation, check, err := ts.subscriptionRepo.Save(ts.ctx, sub)
+	require.NoError(t, err)
+	require.NoError(t, ts.subscriptionRepo.ApplyChecked(ts.ctx, []contracts.VersionCheck{check}, mutation))
+	ts.mockBillingClient.On("ProcessRefund", ts.ctx, mock.Anything).Return("refund-race", nil)
+
+	errs := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			_, err := ts.cancelInteractor.Execute(ts.ctx, "race-1", domain.CancellationReason{})
+			errs <- err
+		}()
+	}
+	results := []error{<-errs, <-errs}
+
+	assert.Contains(t, results, nil)
+	assert.Condition(t, func() bool {
+		return errors.Is(results[0], domain.ErrAlreadyCancelled) || errors.Is(results[1], domain.ErrAlreadyCancelled)
+	}, "the second cancellation reads the first one's commit: %v", results)
+	ts.mockBillingClient.AssertNumberOfCalls(t, "ProcessRefund", 1)
+}
+
+func TestE2E_ForEachByStatusStreamsEveryMatch(t *testing.T) {
+	ts := setupTest(t)
+
+	startDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var uow contracts.UnitOfWork
+	for i := 0; i < 7; i++ {
+		status := domain.StatusActive
+		if i%3 == 0 {
+			status = domain.StatusPastDue
+		}
+		sub := domain.ReconstructFromPersistence(fmt.Sprintf("scan-%d", i), "cust-scan", "plan-basic", 3000, status, startDate)
+		uow.SaveChecked(ts.subscriptionRepo.Save(ts.ctx, sub))
+	}
+	require.NoError(t, uow.Commit(ts.ctx, ts.subscriptionRepo))
+
+	var pastDue []string
+	err := ts.subscriptionRepo.ForEachByStatus(ts.ctx, domain.StatusPastDue, func(sub *domain.Subscription) error {
+		pastDue = append(pastDue, sub.ID())
+		return nil
+	})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"scan-0", "scan-3", "scan-6"}, pastDue)
+
+	stop := errors.New("stop")
+	seen := 0
+	err = ts.subscriptionRepo.ForEachByStatus(ts.ctx, domain.StatusActive, func(*domain.Subscription) error {
+		seen++
+		return stop
+	})
+	assert.ErrorIs(t, err, stop)
+	assert.Equal(t, 1, seen, "the scan stops at fn's first error")
+}
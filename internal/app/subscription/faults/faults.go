@@ -0,0 +1,220 @@
+// Package faults injects latency and errors into repository and billing calls so
+// billing outages and Spanner aborts can be rehearsed outside production. Rules come
+// from configuration or, when allowed, from a request header; with no rules every
+// call passes through untouched.
+package faults
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Header carries rules for the request it arrives on, e.g.
+// "X-Fault-Inject: billing.ChargeCustomer error=100% kind=unavailable"
+const Header = "X-Fault-Inject"
+
+// Kind is the failure an injected error stands for. Each layer turns it into the
+// error its callers would really see.
+type Kind string
+
+const (
+	KindUnavailable Kind = "unavailable" // the dependency is down
+	KindTimeout     Kind = "timeout"     // the call ran out of time
+	KindAborted     Kind = "aborted"     // a Spanner transaction was aborted
+	KindDeclined    Kind = "declined"    // the billing provider refused a charge
+)
+
+// Rule injects faults into the operations it matches. Op is an operation name such
+// as "billing.ProcessRefund" or "spanner.subscriptions.Apply", or a prefix ending in
+// "*" such as "billing.*". A matching call is delayed by Latency, then fails with
+// Kind on a Rate fraction of calls.
+type Rule struct {
+	Op      string
+	Latency time.Duration
+	Rate    float64
+	Kind    Kind
+}
+
+func (r Rule) matches(op string) bool {
+	if prefix, ok := strings.CutSuffix(r.Op, "*"); ok {
+		return strings.HasPrefix(op, prefix)
+	}
+	return r.Op == op
+}
+
+// ParseRule parses "<op> [latency=<duration>] [error=<percent>%] [kind=<kind>]".
+// The kind defaults to unavailable.
+func ParseRule(s string) (Rule, error) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return Rule{}, fmt.Errorf("empty fault rule")
+	}
+	rule := Rule{Op: fields[0], Kind: KindUnavailable}
+	for _, field := range fields[1:] {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok {
+			return Rule{}, fmt.Errorf("fault rule %q: %q is not key=value", s, field)
+		}
+		switch key {
+		case "latency":
+			d, err := time.ParseDuration(value)
+			if err != nil || d < 0 {
+				return Rule{}, fmt.Errorf("fault rule %q: invalid latency %q", s, value)
+			}
+			rule.Latency = d
+		case "error":
+			pct, err := strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+			if err != nil || pct < 0 || pct > 100 {
+				return Rule{}, fmt.Errorf("fault rule %q: invalid error rate %q", s, value)
+			}
+			rule.Rate = pct / 100
+		case "kind":
+			switch k := Kind(value); k {
+			case KindUnavailable, KindTimeout, KindAborted, KindDeclined:
+				rule.Kind = k
+			default:
+				return Rule{}, fmt.Errorf("fault rule %q: unknown kind %q", s, value)
+			}
+		default:
+			return Rule{}, fmt.Errorf("fault rule %q: unknown setting %q", s, key)
+		}
+	}
+	return rule, nil
+}
+
+// ParseRules parses each rule in turn
+func ParseRules(specs []string) ([]Rule, error) {
+	rules := make([]Rule, 0, len(specs))
+	for _, spec := range specs {
+		rule, err := ParseRule(spec)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// Error is an injected failure
+type Error struct {
+	Op   string
+	Kind Kind
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("injected %s fault in %s", e.Kind, e.Op)
+}
+
+// Injector applies fault rules to calls. A nil Injector injects nothing, so layers
+// can hold one unconditionally.
+type Injector struct {
+	rules       []Rule
+	allowHeader bool
+	logger      *slog.Logger
+	rand        func() float64
+}
+
+// New returns an injector applying the rules in specs to every call and, when
+// allowHeader is set, the rules a request brings in Header. It returns nil when
+// neither can inject anything, and warns when injection is enabled.
+func New(specs []string, allowHeader bool, logger *slog.Logger) (*Injector, error) {
+	rules, err := ParseRules(specs)
+	if err != nil {
+		return nil, err
+	}
+	if len(rules) == 0 && !allowHeader {
+		return nil, nil
+	}
+	logger.Warn("fault injection enabled", slog.Any("rules", specs), slog.Bool("header", allowHeader))
+	return &Injector{rules: rules, allowHeader: allowHeader, logger: logger, rand: rand.Float64}, nil
+}
+
+type ctxKey struct{}
+
+// WithRules returns a context whose calls are subject to rules as well as the
+// injector's own
+func WithRules(ctx context.Context, rules []Rule) context.Context {
+	return context.WithValue(ctx, ctxKey{}, append(rulesFrom(ctx), rules...))
+}
+
+func rulesFrom(ctx context.Context) []Rule {
+	rules, _ := ctx.Value(ctxKey{}).([]Rule)
+	return rules
+}
+
+// Inject applies the first rule matching op, from the request's rules then the
+// injector's: it waits out the latency, then fails at the rule's rate. It returns
+// the context's error if the context ends during the delay.
+func (i *Injector) Inject(ctx context.Context, op string) error {
+	if i == nil {
+		return nil
+	}
+	rule, ok := i.match(ctx, op)
+	if !ok {
+		return nil
+	}
+
+	if rule.Latency > 0 {
+		timer := time.NewTimer(rule.Latency)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+	if rule.Rate > 0 && i.rand() < rule.Rate {
+		i.logger.WarnContext(ctx, "injecting fault", slog.String("op", op), slog.String("kind", string(rule.Kind)))
+		return &Error{Op: op, Kind: rule.Kind}
+	}
+	return nil
+}
+
+func (i *Injector) match(ctx context.Context, op string) (Rule, bool) {
+	if i.allowHeader {
+		for _, rule := range rulesFrom(ctx) {
+			if rule.matches(op) {
+				return rule, true
+			}
+		}
+	}
+	for _, rule := range i.rules {
+		if rule.matches(op) {
+			return rule, true
+		}
+	}
+	return Rule{}, false
+}
+
+// Middleware applies the rules in a request's Header to the calls made while
+// handling it. Without an injector allowing headers, the header is ignored; a
+// malformed rule is rejected with 400.
+func (i *Injector) Middleware(next http.Handler) http.Handler {
+	if i == nil || !i.allowHeader {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		values := r.Header.Values(Header)
+		if len(values) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		var specs []string
+		for _, v := range values {
+			specs = append(specs, strings.Split(v, ",")...)
+		}
+		rules, err := ParseRules(specs)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(WithRules(r.Context(), rules)))
+	})
+}
@@ -0,0 +1,104 @@
+package faults
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/logging"
+)
+
+func TestParseRule(t *testing.T) {
+	rule, err := ParseRule("spanner.subscriptions.* latency=250ms error=12.5% kind=aborted")
+	require.NoError(t, err)
+	assert.Equal(t, Rule{Op: "spanner.subscriptions.*", Latency: 250 * time.Millisecond, Rate: 0.125, Kind: KindAborted}, rule)
+
+	rule, err = ParseRule("billing.ChargeCustomer error=100%")
+	require.NoError(t, err)
+	assert.Equal(t, KindUnavailable, rule.Kind)
+
+	for _, bad := range []string{"", "billing.* error=150%", "billing.* latency=soon", "billing.* kind=flaky", "billing.* sometimes", "billing.* jitter=1s"} {
+		_, err := ParseRule(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestNew_NothingToInject(t *testing.T) {
+	injector, err := New(nil, false, logging.Discard())
+	require.NoError(t, err)
+	assert.Nil(t, injector)
+	assert.NoError(t, injector.Inject(context.Background(), "billing.ChargeCustomer"))
+
+	_, err = New([]string{"billing.* error=lots"}, false, logging.Discard())
+	assert.Error(t, err)
+}
+
+func TestInjector_ErrorRate(t *testing.T) {
+	injector, err := New([]string{"billing.* error=30%", "spanner.refunds.Apply kind=aborted error=100%"}, false, logging.Discard())
+	require.NoError(t, err)
+
+	roll := 0.0
+	injector.rand = func() float64 { return roll }
+
+	roll = 0.29
+	var fault *Error
+	require.ErrorAs(t, injector.Inject(context.Background(), "billing.ProcessRefund"), &fault)
+	assert.Equal(t, Error{Op: "billing.ProcessRefund", Kind: KindUnavailable}, *fault)
+
+	roll = 0.3
+	assert.NoError(t, injector.Inject(context.Background(), "billing.ProcessRefund"))
+
+	require.ErrorAs(t, injector.Inject(context.Background(), "spanner.refunds.Apply"), &fault)
+	assert.Equal(t, KindAborted, fault.Kind)
+	assert.NoError(t, injector.Inject(context.Background(), "spanner.subscriptions.Apply"))
+}
+
+func TestInjector_LatencyHonoursContext(t *testing.T) {
+	injector, err := New([]string{"billing.* latency=20ms"}, false, logging.Discard())
+	require.NoError(t, err)
+
+	start := time.Now()
+	require.NoError(t, injector.Inject(context.Background(), "billing.ChargeCustomer"))
+	assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, injector.Inject(ctx, "billing.ChargeCustomer"), context.Canceled)
+}
+
+func TestMiddleware_AppliesHeaderRulesToTheRequest(t *testing.T) {
+	var injected error
+	handler := func(injector *Injector) http.Handler {
+		return injector.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			injected = injector.Inject(r.Context(), "billing.ChargeCustomer")
+		}))
+	}
+	request := func(value string) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req.Header.Set(Header, value)
+		return req
+	}
+
+	allowed, err := New(nil, true, logging.Discard())
+	require.NoError(t, err)
+	handler(allowed).ServeHTTP(httptest.NewRecorder(), request("billing.ChargeCustomer error=100% kind=declined"))
+	var fault *Error
+	require.ErrorAs(t, injected, &fault)
+	assert.Equal(t, KindDeclined, fault.Kind)
+
+	rec := httptest.NewRecorder()
+	handler(allowed).ServeHTTP(rec, request("billing.* error=often"))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	configOnly, err := New([]string{"spanner.* latency=1ms"}, false, logging.Discard())
+	require.NoError(t, err)
+	injected = errors.New("not called")
+	handler(configOnly).ServeHTTP(httptest.NewRecorder(), request("billing.ChargeCustomer error=100%"))
+	assert.NoError(t, injected, "header rules are ignored unless allowed")
+}
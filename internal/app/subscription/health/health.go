@@ -0,0 +1,147 @@
+// Package health serves the liveness and readiness probes on the ops port. /healthz
+// answers while the process is serving. /readyz runs every dependency check at once
+// and reports each one, so an instance that can't do useful work is taken out of
+// rotation and the response says which dependency is why.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/logging"
+)
+
+// Status is the outcome of one check or of the whole probe
+type Status string
+
+const (
+	StatusOK      Status = "ok"
+	StatusFailing Status = "failing"
+)
+
+// Check reports whether a dependency is usable; it should be cheap and free of side
+// effects, since probes run it every few seconds
+type Check func(ctx context.Context) error
+
+// Result is one check's outcome in a Report
+type Result struct {
+	Status     Status  `json:"status"`
+	Error      string  `json:"error,omitempty"`
+	DurationMS float64 `json:"duration_ms"`
+}
+
+// Report is the /readyz response body
+type Report struct {
+	Status Status            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+}
+
+// Checker runs the readiness checks. Add every check before serving.
+type Checker struct {
+	timeout time.Duration
+	logger  *slog.Logger
+	checks  []namedCheck
+
+	mu      sync.Mutex
+	failing map[string]bool // by check, so only changes are logged
+}
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// NewChecker creates a checker giving each check up to timeout. A nil logger discards
+// the log lines for checks that start or stop failing.
+func NewChecker(timeout time.Duration, logger *slog.Logger) *Checker {
+	if logger == nil {
+		logger = logging.Discard()
+	}
+	return &Checker{timeout: timeout, logger: logger, failing: make(map[string]bool)}
+}
+
+// Add registers a check reported under name
+func (c *Checker) Add(name string, check Check) {
+	c.checks = append(c.checks, namedCheck{name: name, check: check})
+}
+
+// Check runs every check concurrently. The report is ok only when all of them are.
+func (c *Checker) Check(ctx context.Context) Report {
+	results := make([]Result, len(c.checks))
+	var wg sync.WaitGroup
+	for i, nc := range c.checks {
+		wg.Add(1)
+		go func(i int, nc namedCheck) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, c.timeout)
+			defer cancel()
+
+			start := time.Now()
+			err := nc.check(ctx)
+			results[i] = Result{Status: StatusOK, DurationMS: float64(time.Since(start).Microseconds()) / 1000}
+			if err != nil {
+				results[i].Status, results[i].Error = StatusFailing, err.Error()
+			}
+		}(i, nc)
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusOK, Checks: make(map[string]Result, len(c.checks))}
+	for i, nc := range c.checks {
+		report.Checks[nc.name] = results[i]
+		if results[i].Status != StatusOK {
+			report.Status = StatusFailing
+		}
+		c.logChange(ctx, nc.name, results[i])
+	}
+	return report
+}
+
+// logChange logs a check that started or stopped failing
+func (c *Checker) logChange(ctx context.Context, name string, result Result) {
+	failing := result.Status != StatusOK
+	c.mu.Lock()
+	changed := c.failing[name] != failing
+	c.failing[name] = failing
+	c.mu.Unlock()
+
+	switch {
+	case changed && failing:
+		c.logger.WarnContext(ctx, "readiness check failing", slog.String("check", name), slog.String("error", result.Error))
+	case changed:
+		c.logger.InfoContext(ctx, "readiness check recovered", slog.String("check", name))
+	}
+}
+
+// NewHandler serves /healthz, which always answers 200, and /readyz, which answers 200
+// or 503 with the Report as JSON
+func NewHandler(checker *Checker) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write([]byte("ok\n"))
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		report := checker.Check(r.Context())
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		if report.Status != StatusOK {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		json.NewEncoder(w).Encode(report)
+	})
+	return mux
+}
+
+// NewServer returns a server for the probes on addr
+func NewServer(addr string, checker *Checker) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		Handler:           NewHandler(checker),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+}
@@ -0,0 +1,85 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/testkit/golden"
+)
+
+func probe(t *testing.T, checker *Checker, path string) (int, Report) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	NewHandler(checker).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	var report Report
+	if path == "/readyz" {
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+	}
+	return rec.Code, report
+}
+
+func TestReadyz_ReportsEveryDependency(t *testing.T) {
+	checker := NewChecker(time.Second, nil)
+	checker.Add("spanner", func(context.Context) error { return nil })
+	checker.Add("schema", func(context.Context) error { return errors.New("schema is at version 8, binary expects 9") })
+
+	code, report := probe(t, checker, "/readyz")
+
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, StatusFailing, report.Status)
+	assert.Equal(t, StatusOK, report.Checks["spanner"].Status)
+	assert.Equal(t, StatusFailing, report.Checks["schema"].Status)
+	assert.Equal(t, "schema is at version 8, binary expects 9", report.Checks["schema"].Error)
+}
+
+func TestReadyz_OKWhenAllChecksPass(t *testing.T) {
+	checker := NewChecker(time.Second, nil)
+	checker.Add("billing", func(context.Context) error { return nil })
+
+	code, report := probe(t, checker, "/readyz")
+
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, StatusOK, report.Status)
+}
+
+func TestReadyz_SlowCheckTimesOut(t *testing.T) {
+	checker := NewChecker(10*time.Millisecond, nil)
+	checker.Add("billing", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	start := time.Now()
+	code, report := probe(t, checker, "/readyz")
+
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, context.DeadlineExceeded.Error(), report.Checks["billing"].Error)
+}
+
+func TestHealthz_IgnoresDependencies(t *testing.T) {
+	checker := NewChecker(time.Second, nil)
+	checker.Add("spanner", func(context.Context) error { return errors.New("unavailable") })
+
+	code, _ := probe(t, checker, "/healthz")
+
+	assert.Equal(t, http.StatusOK, code)
+}
+
+func TestReport_JSONSnapshot(t *testing.T) {
+	golden.AssertJSON(t, "readyz", Report{
+		Status: StatusFailing,
+		Checks: map[string]Result{
+			"spanner": {Status: StatusOK, DurationMS: 1.5},
+			"schema":  {Status: StatusFailing, Error: "schema is at version 8, binary expects 9", DurationMS: 2.25},
+		},
+	})
+}
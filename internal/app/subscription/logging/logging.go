@@ -0,0 +1,119 @@
+// Package logging builds the service's structured loggers. Every line logged with a
+// request's context carries that request's fields (correlation_id, subscription_id,
+// customer_id), so one operation can be followed across components without each of
+// them passing the IDs along.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/correlation"
+)
+
+// Formats accepted by New
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// New creates a logger writing to w at the given level (debug, info, warn or error)
+// in the given format (json or text). Request fields are added from the context of
+// each *Context call.
+func New(w io.Writer, level, format string) (*slog.Logger, error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("invalid log level %q: %w", level, err)
+	}
+	opts := &slog.HandlerOptions{Level: lvl}
+
+	var handler slog.Handler
+	switch strings.ToLower(format) {
+	case FormatJSON:
+		handler = slog.NewJSONHandler(w, opts)
+	case FormatText:
+		handler = slog.NewTextHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("invalid log format %q: must be %s or %s", format, FormatJSON, FormatText)
+	}
+	return slog.New(NewContextHandler(handler)), nil
+}
+
+// Discard returns a logger that drops every line, for components whose logger is optional
+func Discard() *slog.Logger {
+	return slog.New(discardHandler{})
+}
+
+type fieldsKey struct{}
+
+// With returns a context whose log lines carry attrs in addition to any fields
+// already on ctx. A later field with the same key replaces the earlier one.
+func With(ctx context.Context, attrs ...slog.Attr) context.Context {
+	if len(attrs) == 0 {
+		return ctx
+	}
+	existing := Fields(ctx)
+	fields := make([]slog.Attr, 0, len(existing)+len(attrs))
+	for _, a := range existing {
+		if !hasKey(attrs, a.Key) {
+			fields = append(fields, a)
+		}
+	}
+	return context.WithValue(ctx, fieldsKey{}, append(fields, attrs...))
+}
+
+// Fields returns the log fields carried by ctx
+func Fields(ctx context.Context) []slog.Attr {
+	fields, _ := ctx.Value(fieldsKey{}).([]slog.Attr)
+	return fields
+}
+
+func hasKey(attrs []slog.Attr, key string) bool {
+	for _, a := range attrs {
+		if a.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// ContextHandler adds the correlation ID and fields of the record's context to each record
+type ContextHandler struct {
+	next slog.Handler
+}
+
+// NewContextHandler wraps next
+func NewContextHandler(next slog.Handler) *ContextHandler {
+	return &ContextHandler{next: next}
+}
+
+func (h *ContextHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *ContextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := correlation.ID(ctx); id != "" {
+		r.AddAttrs(slog.String("correlation_id", id))
+	}
+	r.AddAttrs(Fields(ctx)...)
+	return h.next.Handle(ctx, r)
+}
+
+func (h *ContextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &ContextHandler{next: h.next.WithAttrs(attrs)}
+}
+
+func (h *ContextHandler) WithGroup(name string) slog.Handler {
+	return &ContextHandler{next: h.next.WithGroup(name)}
+}
+
+// discardHandler is a slog.Handler that drops every record
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+func (d discardHandler) WithAttrs([]slog.Attr) slog.Handler      { return d }
+func (d discardHandler) WithGroup(string) slog.Handler           { return d }
@@ -0,0 +1,78 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/correlation"
+)
+
+func decode(t *testing.T, buf *bytes.Buffer) map[string]any {
+	var line map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+	return line
+}
+
+func TestNew_AddsRequestFieldsFromContext(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, "info", "json")
+	require.NoError(t, err)
+
+	ctx := correlation.WithID(context.Background(), "corr-1")
+	ctx = With(ctx, slog.String("subscription_id", "sub-1"), slog.String("customer_id", "cust-1"))
+	logger.InfoContext(ctx, "renewed", slog.Int64("amount", 999))
+
+	line := decode(t, &buf)
+	assert.Equal(t, "renewed", line["msg"])
+	assert.Equal(t, "INFO", line["level"])
+	assert.Equal(t, "corr-1", line["correlation_id"])
+	assert.Equal(t, "sub-1", line["subscription_id"])
+	assert.Equal(t, "cust-1", line["customer_id"])
+	assert.Equal(t, float64(999), line["amount"])
+}
+
+func TestNew_RespectsLevel(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, "warn", "text")
+	require.NoError(t, err)
+
+	logger.Info("hidden")
+	assert.Empty(t, buf.String())
+
+	logger.Warn("shown")
+	assert.Contains(t, buf.String(), "msg=shown")
+}
+
+func TestNew_RejectsUnknownSettings(t *testing.T) {
+	_, err := New(&bytes.Buffer{}, "loud", "json")
+	assert.ErrorContains(t, err, "invalid log level")
+
+	_, err = New(&bytes.Buffer{}, "info", "xml")
+	assert.ErrorContains(t, err, "invalid log format")
+}
+
+func TestWith_LaterFieldReplacesEarlier(t *testing.T) {
+	ctx := With(context.Background(), slog.String("subscription_id", "sub-1"), slog.String("customer_id", "cust-1"))
+	ctx = With(ctx, slog.String("subscription_id", "sub-2"))
+
+	assert.Equal(t, []slog.Attr{slog.String("customer_id", "cust-1"), slog.String("subscription_id", "sub-2")}, Fields(ctx))
+}
+
+func TestContextHandler_KeepsWrappingThroughWith(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, "info", "json")
+	require.NoError(t, err)
+
+	ctx := With(context.Background(), slog.String("subscription_id", "sub-1"))
+	logger.With(slog.String("worker", "renewer")).InfoContext(ctx, "pass complete")
+
+	line := decode(t, &buf)
+	assert.Equal(t, "renewer", line["worker"])
+	assert.Equal(t, "sub-1", line["subscription_id"])
+}
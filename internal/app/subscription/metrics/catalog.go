@@ -0,0 +1,62 @@
+package metrics
+
+// Core metrics recorded outside a single component. Component-specific metrics keep
+// their name constants next to the code that records them; all of them are described
+// in Core.
+const (
+	SubscriptionsCreated   = "subscriptions_created_total"
+	SubscriptionsCancelled = "subscriptions_cancelled_total"
+	RetentionOffers        = "retention_offers_total"
+	RefundAmount           = "refund_amount_cents"
+	SpannerErrors          = "spanner_errors_total"
+
+	// SLIEventPublishLag is for event publishers: seconds from an event occurring to
+	// its publication, by event_type
+	SLIEventPublishLag = "sli_event_publish_lag_seconds"
+)
+
+// DefaultBuckets suit latencies in seconds, from a few milliseconds to ten seconds
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// amountBuckets suit refund amounts in cents, from one dollar to a thousand
+var amountBuckets = []float64{100, 500, 1000, 2500, 5000, 10000, 25000, 50000, 100000}
+
+// settlementBuckets suit refund processing times in seconds, from a minute to a week
+var settlementBuckets = []float64{60, 300, 900, 3600, 4 * 3600, 12 * 3600, 24 * 3600, 3 * 24 * 3600, 7 * 24 * 3600}
+
+// Core describes every metric the service records, so each is exported with its type
+// and help text. NewRegistry registers all of them.
+func Core() []Definition {
+	return []Definition{
+		{Name: SubscriptionsCreated, Type: Counter, Help: "Subscriptions created, by plan."},
+		{Name: SubscriptionsCancelled, Type: Counter, Help: "Subscriptions cancelled."},
+		{Name: RetentionOffers, Type: Counter, Help: "Retention offers made to customers asking to cancel, by kind and outcome (presented, accepted or declined)."},
+		{Name: RefundAmount, Type: Histogram, Help: "Prorated refund issued on cancellation, in the currency's minor unit.", Buckets: amountBuckets},
+		{Name: SpannerErrors, Type: Counter, Help: "Failed Spanner operations, by repository operation and gRPC code."},
+		{Name: "subscription_cache_lookups_total", Type: Counter, Help: "Subscription lookups by ID through the in-process cache, by result (hit or miss)."},
+		{Name: "panics_total", Type: Counter, Help: "Panics recovered instead of crashing the process, by component."},
+
+		{Name: "usecase_executions_total", Type: Counter, Help: "Use case executions, by use case and outcome."},
+		{Name: "usecase_duration_seconds", Type: Histogram, Help: "Use case latency, by use case."},
+
+		{Name: "billing_calls_total", Type: Counter, Help: "Billing provider calls, by provider, operation and status."},
+		{Name: "billing_call_duration_seconds", Type: Histogram, Help: "Billing provider call latency including retries, by provider and operation."},
+		{Name: "billing_retries_total", Type: Counter, Help: "Billing call retries made by the resilient client, by provider and operation."},
+		{Name: "billing_hedges_total", Type: Counter, Help: "Hedged billing reads, by operation and which attempt answered."},
+		{Name: "events_published_total", Type: Counter, Help: "Subscription events sent to Pub/Sub, by event type and outcome (published or failed)."},
+
+		{Name: "sli_requests_total", Type: Counter, Help: "Requests counted against an SLO, by slo and outcome (good, bad, or rejected for caller errors that don't spend error budget)."},
+		{Name: "sli_refund_processing_seconds", Type: Histogram, Help: "Time from requesting a refund to the provider settling or failing it, by outcome and source.", Buckets: settlementBuckets},
+		{Name: "sli_refund_failures_total", Type: Counter, Help: "Refunds the billing provider reported as failed, by source."},
+		{Name: SLIEventPublishLag, Type: Histogram, Help: "Time from a domain event occurring to its publication, by event type."},
+
+		{Name: "renewals_total", Type: Counter, Help: "Renewal attempts by the renewer, by outcome."},
+		{Name: "scheduled_cancellations_total", Type: Counter, Help: "Scheduled cancellations carried out by the cancellation scheduler, by outcome."},
+		{Name: "payment_retries_total", Type: Counter, Help: "Payment retries by the dunning worker, by outcome."},
+		{Name: "refund_polls_total", Type: Counter, Help: "Refund status polls, by outcome."},
+		{Name: "refund_dispatches_total", Type: Counter, Help: "Attempts to send refunds queued in the refund outbox to the billing provider, by outcome."},
+		{Name: "bulk_cancellations_total", Type: Counter, Help: "Subscriptions bulk cancellations were asked to cancel, by outcome (cancelled, already_cancelled, not_found or failed)."},
+		{Name: "payment_method_checks_total", Type: Counter, Help: "Payment method expiry checks, by outcome."},
+		{Name: "renewal_notices_total", Type: Counter, Help: "Renewal notice attempts, by outcome."},
+	}
+}
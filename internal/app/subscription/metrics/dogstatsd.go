@@ -0,0 +1,141 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+)
+
+var _ Exporter = (*DogStatsDExporter)(nil)
+
+// maxPacket keeps each datagram within a typical MTU so none is fragmented
+const maxPacket = 1432
+
+// DogStatsDExporter sends metrics to a Datadog agent over DogStatsD. The agent wants
+// counts since the last flush, so the exporter sends the increase of each series since
+// its previous export. A histogram is sent as <name>.count, <name>.sum and
+// <name>.bucket{le}, the same series Prometheus exposes.
+type DogStatsDExporter struct {
+	addr string
+	tags []string // added to every metric, such as service:<name>
+
+	mu   sync.Mutex
+	conn net.Conn
+	last map[string]Series // previous export, by name and labelKey
+}
+
+// NewDogStatsDExporter creates an exporter sending to addr, the agent's DogStatsD
+// host:port (normally port 8125), tagging every metric with service:<serviceName>
+func NewDogStatsDExporter(addr, serviceName string) *DogStatsDExporter {
+	return &DogStatsDExporter{
+		addr: addr,
+		tags: []string{"service:" + sanitizeTag(serviceName)},
+		last: make(map[string]Series),
+	}
+}
+
+// Export sends the increase of every series since the previous export
+func (e *DogStatsDExporter) Export(ctx context.Context, families []Family) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var lines []string
+	next := make(map[string]Series, len(e.last))
+	for _, f := range families {
+		for _, s := range f.Series {
+			key := f.Name + "\x00" + labelKey(s.Labels)
+			prev, seen := e.last[key]
+			next[key] = s
+			if seen && s.Count < prev.Count {
+				prev = Series{} // the process restarted its counts; send them whole
+			}
+
+			tags := e.tagsFor(s.Labels, "")
+			if f.Type == Counter {
+				if d := s.Count - prev.Count; d > 0 {
+					lines = append(lines, fmt.Sprintf("%s:%d|c%s", f.Name, d, tags))
+				}
+				continue
+			}
+			if s.Count == prev.Count {
+				continue
+			}
+			lines = append(lines,
+				fmt.Sprintf("%s.count:%d|c%s", f.Name, s.Count-prev.Count, tags),
+				fmt.Sprintf("%s.sum:%s|c%s", f.Name, formatFloat(s.Sum-prev.Sum), tags))
+			for i, upper := range s.Bounds {
+				var before uint64
+				if len(prev.Buckets) == len(s.Buckets) {
+					before = prev.Buckets[i]
+				}
+				if d := s.Buckets[i] - before; d > 0 {
+					lines = append(lines, fmt.Sprintf("%s.bucket:%d|c%s", f.Name, d, e.tagsFor(s.Labels, formatFloat(upper))))
+				}
+			}
+		}
+	}
+	if err := e.send(lines); err != nil {
+		return err // the next export sends these increases again
+	}
+	e.last = next
+	return nil
+}
+
+// tagsFor renders |#k:v,... for labels, adding le for histogram buckets
+func (e *DogStatsDExporter) tagsFor(labels map[string]string, le string) string {
+	tags := append([]string(nil), e.tags...)
+	for _, k := range sortedKeys(labels) {
+		tags = append(tags, k+":"+sanitizeTag(labels[k]))
+	}
+	if le != "" {
+		tags = append(tags, "le:"+le)
+	}
+	return "|#" + strings.Join(tags, ",")
+}
+
+// send writes lines in as few datagrams as fit maxPacket
+func (e *DogStatsDExporter) send(lines []string) error {
+	if len(lines) == 0 {
+		return nil
+	}
+	if e.conn == nil {
+		conn, err := net.Dial("udp", e.addr)
+		if err != nil {
+			return fmt.Errorf("failed to reach DogStatsD at %s: %w", e.addr, err)
+		}
+		e.conn = conn
+	}
+
+	var packet bytes.Buffer
+	flush := func() error {
+		if packet.Len() == 0 {
+			return nil
+		}
+		_, err := e.conn.Write(packet.Bytes())
+		packet.Reset()
+		return err
+	}
+	for _, line := range lines {
+		if packet.Len() > 0 && packet.Len()+1+len(line) > maxPacket {
+			if err := flush(); err != nil {
+				return fmt.Errorf("failed to send metrics: %w", err)
+			}
+		}
+		if packet.Len() > 0 {
+			packet.WriteByte('\n')
+		}
+		packet.WriteString(line)
+	}
+	if err := flush(); err != nil {
+		return fmt.Errorf("failed to send metrics: %w", err)
+	}
+	return nil
+}
+
+var tagEscaper = strings.NewReplacer(",", "_", "|", "_", "\n", "_")
+
+// sanitizeTag replaces the characters that delimit DogStatsD tags
+func sanitizeTag(s string) string { return tagEscaper.Replace(s) }
@@ -0,0 +1,104 @@
+package metrics
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// contentType is the Prometheus text exposition format
+const contentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// ServeHTTP writes every metric with at least one sample in the Prometheus text format
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", contentType)
+	buf := bufio.NewWriter(w)
+	r.writeText(buf)
+	buf.Flush()
+}
+
+// writeText writes the exposition of reg to w, families and series in a stable order
+func (r *Registry) writeText(w io.Writer) {
+	writeFamilies(w, r.Snapshot())
+}
+
+func writeFamilies(w io.Writer, families []Family) {
+	for _, f := range families {
+		if f.Help != "" {
+			fmt.Fprintf(w, "# HELP %s %s\n", f.Name, escapeHelp(f.Help))
+		}
+		fmt.Fprintf(w, "# TYPE %s %s\n", f.Name, f.Type)
+
+		for _, s := range f.Series {
+			if f.Type == Counter {
+				fmt.Fprintf(w, "%s%s %d\n", f.Name, formatLabels(s.Labels, ""), s.Count)
+				continue
+			}
+			for i, upper := range s.Bounds {
+				fmt.Fprintf(w, "%s_bucket%s %d\n", f.Name, formatLabels(s.Labels, formatFloat(upper)), s.Buckets[i])
+			}
+			fmt.Fprintf(w, "%s_bucket%s %d\n", f.Name, formatLabels(s.Labels, "+Inf"), s.Count)
+			fmt.Fprintf(w, "%s_sum%s %s\n", f.Name, formatLabels(s.Labels, ""), formatFloat(s.Sum))
+			fmt.Fprintf(w, "%s_count%s %d\n", f.Name, formatLabels(s.Labels, ""), s.Count)
+		}
+	}
+}
+
+// Serve exposes reg at /metrics on addr until ctx is done
+func Serve(ctx context.Context, addr string, reg *Registry, logger *slog.Logger) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", reg)
+	server := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	logger.Info("metrics listening", slog.String("addr", addr))
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// formatLabels renders {k="v",...} in key order, adding le for histogram buckets
+func formatLabels(labels map[string]string, le string) string {
+	if len(labels) == 0 && le == "" {
+		return ""
+	}
+	pairs := make([]string, 0, len(labels)+1)
+	for _, k := range sortedKeys(labels) {
+		pairs = append(pairs, k+`="`+escapeLabel(labels[k])+`"`)
+	}
+	if le != "" {
+		pairs = append(pairs, `le="`+le+`"`)
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatFloat(v float64) string {
+	if math.IsInf(v, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
+
+func escapeHelp(s string) string { return helpEscaper.Replace(s) }
@@ -0,0 +1,205 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var _ Exporter = (*OTLPExporter)(nil)
+
+// scopeName identifies this service's instrumentation in exported metrics
+const scopeName = "github.com/wuyiadepoju/subscription-management"
+
+// OTLPExporter sends metrics to an OpenTelemetry collector with the OTLP/HTTP JSON
+// encoding. Values are cumulative from the exporter's creation, which stands in for
+// the process start.
+type OTLPExporter struct {
+	client      *http.Client
+	endpoint    string
+	serviceName string
+	headers     map[string]string
+	start       time.Time
+	now         func() time.Time
+}
+
+// NewOTLPExporter creates an exporter posting to endpoint, the collector's base URL
+// (for example http://localhost:4318); metrics go to <endpoint>/v1/metrics. headers
+// are added to every request, for collectors that require authentication.
+func NewOTLPExporter(client *http.Client, endpoint, serviceName string, headers map[string]string) *OTLPExporter {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &OTLPExporter{
+		client:      client,
+		endpoint:    strings.TrimSuffix(endpoint, "/") + "/v1/metrics",
+		serviceName: serviceName,
+		headers:     headers,
+		start:       time.Now(),
+		now:         time.Now,
+	}
+}
+
+// Export posts one snapshot
+func (e *OTLPExporter) Export(ctx context.Context, families []Family) error {
+	if len(families) == 0 {
+		return nil
+	}
+	body, err := json.Marshal(e.encode(families))
+	if err != nil {
+		return fmt.Errorf("failed to marshal metrics: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", e.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to export metrics: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		bodyBytes, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("collector responded %d: %s", resp.StatusCode, bodyBytes)
+	}
+	return nil
+}
+
+// OTLP/JSON message shapes; 64-bit integers are decimal strings, per the spec
+type (
+	otlpRequest struct {
+		ResourceMetrics []otlpResourceMetrics `json:"resourceMetrics"`
+	}
+	otlpResourceMetrics struct {
+		Resource     otlpResource       `json:"resource"`
+		ScopeMetrics []otlpScopeMetrics `json:"scopeMetrics"`
+	}
+	otlpResource struct {
+		Attributes []otlpKeyValue `json:"attributes"`
+	}
+	otlpScopeMetrics struct {
+		Scope   otlpScope    `json:"scope"`
+		Metrics []otlpMetric `json:"metrics"`
+	}
+	otlpScope struct {
+		Name string `json:"name"`
+	}
+	otlpMetric struct {
+		Name        string         `json:"name"`
+		Description string         `json:"description,omitempty"`
+		Sum         *otlpSum       `json:"sum,omitempty"`
+		Histogram   *otlpHistogram `json:"histogram,omitempty"`
+	}
+	otlpSum struct {
+		DataPoints             []otlpNumberPoint `json:"dataPoints"`
+		AggregationTemporality int               `json:"aggregationTemporality"`
+		IsMonotonic            bool              `json:"isMonotonic"`
+	}
+	otlpNumberPoint struct {
+		Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+		StartTimeUnixNano string         `json:"startTimeUnixNano"`
+		TimeUnixNano      string         `json:"timeUnixNano"`
+		AsInt             string         `json:"asInt"`
+	}
+	otlpHistogram struct {
+		DataPoints             []otlpHistogramPoint `json:"dataPoints"`
+		AggregationTemporality int                  `json:"aggregationTemporality"`
+	}
+	otlpHistogramPoint struct {
+		Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+		StartTimeUnixNano string         `json:"startTimeUnixNano"`
+		TimeUnixNano      string         `json:"timeUnixNano"`
+		Count             string         `json:"count"`
+		Sum               float64        `json:"sum"`
+		BucketCounts      []string       `json:"bucketCounts"`
+		ExplicitBounds    []float64      `json:"explicitBounds"`
+	}
+	otlpKeyValue struct {
+		Key   string    `json:"key"`
+		Value otlpValue `json:"value"`
+	}
+	otlpValue struct {
+		StringValue string `json:"stringValue"`
+	}
+)
+
+const otlpCumulative = 2
+
+// encode converts a snapshot to an OTLP export request
+func (e *OTLPExporter) encode(families []Family) otlpRequest {
+	start := strconv.FormatInt(e.start.UnixNano(), 10)
+	now := strconv.FormatInt(e.now().UnixNano(), 10)
+
+	out := make([]otlpMetric, 0, len(families))
+	for _, f := range families {
+		metric := otlpMetric{Name: f.Name, Description: f.Help}
+		if f.Type == Counter {
+			sum := &otlpSum{AggregationTemporality: otlpCumulative, IsMonotonic: true}
+			for _, s := range f.Series {
+				sum.DataPoints = append(sum.DataPoints, otlpNumberPoint{
+					Attributes:        keyValues(s.Labels),
+					StartTimeUnixNano: start,
+					TimeUnixNano:      now,
+					AsInt:             strconv.FormatUint(s.Count, 10),
+				})
+			}
+			metric.Sum = sum
+		} else {
+			hist := &otlpHistogram{AggregationTemporality: otlpCumulative}
+			for _, s := range f.Series {
+				hist.DataPoints = append(hist.DataPoints, otlpHistogramPoint{
+					Attributes:        keyValues(s.Labels),
+					StartTimeUnixNano: start,
+					TimeUnixNano:      now,
+					Count:             strconv.FormatUint(s.Count, 10),
+					Sum:               s.Sum,
+					BucketCounts:      bucketCounts(s),
+					ExplicitBounds:    s.Bounds,
+				})
+			}
+			metric.Histogram = hist
+		}
+		out = append(out, metric)
+	}
+
+	return otlpRequest{ResourceMetrics: []otlpResourceMetrics{{
+		Resource:     otlpResource{Attributes: keyValues(map[string]string{"service.name": e.serviceName})},
+		ScopeMetrics: []otlpScopeMetrics{{Scope: otlpScope{Name: scopeName}, Metrics: out}},
+	}}}
+}
+
+// bucketCounts converts cumulative buckets to OTLP's per-bucket counts, with the
+// overflow bucket last
+func bucketCounts(s Series) []string {
+	counts := make([]string, 0, len(s.Buckets)+1)
+	var below uint64
+	for _, cumulative := range s.Buckets {
+		counts = append(counts, strconv.FormatUint(cumulative-below, 10))
+		below = cumulative
+	}
+	return append(counts, strconv.FormatUint(s.Count-below, 10))
+}
+
+// keyValues converts labels to OTLP key-values in a stable order
+func keyValues(labels map[string]string) []otlpKeyValue {
+	kvs := make([]otlpKeyValue, 0, len(labels))
+	for k, v := range labels {
+		kvs = append(kvs, otlpKeyValue{Key: k, Value: otlpValue{StringValue: v}})
+	}
+	sort.Slice(kvs, func(i, j int) bool { return kvs[i].Key < kvs[j].Key })
+	return kvs
+}
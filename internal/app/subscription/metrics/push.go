@@ -0,0 +1,61 @@
+package metrics
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/logging"
+)
+
+// Exporter sends a snapshot of the registry to a metrics backend
+type Exporter interface {
+	Export(ctx context.Context, families []Family) error
+}
+
+// Pusher periodically exports a registry, for backends that don't scrape /metrics.
+// Values are cumulative since the process started; an exporter whose backend wants
+// deltas computes them.
+type Pusher struct {
+	reg      *Registry
+	exporter Exporter
+	interval time.Duration
+	logger   *slog.Logger
+
+	mu sync.Mutex // one export at a time, so delta exporters see snapshots in order
+}
+
+// NewPusher creates a pusher exporting reg every interval. A nil logger discards
+// export failures.
+func NewPusher(reg *Registry, exporter Exporter, interval time.Duration, logger *slog.Logger) *Pusher {
+	if logger == nil {
+		logger = logging.Discard()
+	}
+	return &Pusher{reg: reg, exporter: exporter, interval: interval, logger: logger}
+}
+
+// Run exports every interval until ctx is done. Failures are logged and the next
+// export carries the values again, so nothing is lost but the interval's resolution.
+func (p *Pusher) Run(ctx context.Context) error {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := p.Flush(ctx); err != nil {
+				p.logger.Warn("failed to export metrics", slog.Any("error", err))
+			}
+		}
+	}
+}
+
+// Flush exports the current values now. Register it with lifecycle.App.OnClose so
+// samples recorded while draining are not lost at exit.
+func (p *Pusher) Flush(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.exporter.Export(ctx, p.reg.Snapshot())
+}
@@ -0,0 +1,128 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry_SnapshotCopiesValues(t *testing.T) {
+	r := NewRegistry()
+	r.ObserveHistogram(RefundAmount, 700, map[string]string{"currency": "USD"})
+
+	snapshot := r.Snapshot()
+	r.ObserveHistogram(RefundAmount, 700, map[string]string{"currency": "USD"})
+
+	require.Len(t, snapshot, 1)
+	s := snapshot[0].Series[0]
+	assert.Equal(t, uint64(1), s.Count)
+	assert.Equal(t, []uint64{0, 0, 1, 1, 1, 1, 1, 1, 1}, s.Buckets)
+}
+
+func TestOTLPExporter_PostsCumulativeJSON(t *testing.T) {
+	var got otlpRequest
+	var path string
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &got)
+	}))
+	defer collector.Close()
+
+	r := NewRegistry()
+	r.IncCounter(SubscriptionsCreated, map[string]string{"plan_id": "pro"})
+	r.IncCounter(SubscriptionsCreated, map[string]string{"plan_id": "pro"})
+	r.ObserveHistogram(RefundAmount, 700, map[string]string{"currency": "USD"})
+	r.ObserveHistogram(RefundAmount, 999999, map[string]string{"currency": "USD"})
+
+	require.NoError(t, NewOTLPExporter(nil, collector.URL, "renewer", nil).Export(context.Background(), r.Snapshot()))
+
+	assert.Equal(t, "/v1/metrics", path)
+	require.Len(t, got.ResourceMetrics, 1)
+	metrics := got.ResourceMetrics[0].ScopeMetrics[0].Metrics
+	require.Len(t, metrics, 2)
+
+	hist := metrics[0]
+	assert.Equal(t, RefundAmount, hist.Name)
+	require.NotNil(t, hist.Histogram)
+	point := hist.Histogram.DataPoints[0]
+	assert.Equal(t, "2", point.Count)
+	assert.Equal(t, []string{"0", "0", "1", "0", "0", "0", "0", "0", "0", "1"}, point.BucketCounts, "per-bucket counts with the overflow last")
+	assert.Equal(t, amountBuckets, point.ExplicitBounds)
+
+	sum := metrics[1]
+	require.NotNil(t, sum.Sum)
+	assert.True(t, sum.Sum.IsMonotonic)
+	assert.Equal(t, otlpCumulative, sum.Sum.AggregationTemporality)
+	assert.Equal(t, "2", sum.Sum.DataPoints[0].AsInt)
+	assert.Equal(t, []otlpKeyValue{{Key: "plan_id", Value: otlpValue{StringValue: "pro"}}}, sum.Sum.DataPoints[0].Attributes)
+}
+
+func TestDogStatsDExporter_SendsIncreases(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+	read := func() []string {
+		buf := make([]byte, maxPacket)
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+		n, _, err := conn.ReadFrom(buf)
+		require.NoError(t, err)
+		lines := strings.Split(string(buf[:n]), "\n")
+		sort.Strings(lines)
+		return lines
+	}
+
+	r := NewRegistry()
+	exporter := NewDogStatsDExporter(conn.LocalAddr().String(), "renewer")
+	r.IncCounter(SubscriptionsCreated, map[string]string{"plan_id": "pro,annual"})
+	r.IncCounter(SubscriptionsCreated, map[string]string{"plan_id": "pro,annual"})
+	r.ObserveHistogram("usecase_duration_seconds", 0.02, map[string]string{"usecase": "renew"})
+
+	require.NoError(t, exporter.Export(context.Background(), r.Snapshot()))
+	assert.Equal(t, []string{
+		"subscriptions_created_total:2|c|#service:renewer,plan_id:pro_annual",
+		"usecase_duration_seconds.bucket:1|c|#service:renewer,usecase:renew,le:0.025",
+		"usecase_duration_seconds.bucket:1|c|#service:renewer,usecase:renew,le:0.05",
+		"usecase_duration_seconds.bucket:1|c|#service:renewer,usecase:renew,le:0.1",
+		"usecase_duration_seconds.bucket:1|c|#service:renewer,usecase:renew,le:0.25",
+		"usecase_duration_seconds.bucket:1|c|#service:renewer,usecase:renew,le:0.5",
+		"usecase_duration_seconds.bucket:1|c|#service:renewer,usecase:renew,le:1",
+		"usecase_duration_seconds.bucket:1|c|#service:renewer,usecase:renew,le:10",
+		"usecase_duration_seconds.bucket:1|c|#service:renewer,usecase:renew,le:2.5",
+		"usecase_duration_seconds.bucket:1|c|#service:renewer,usecase:renew,le:5",
+		"usecase_duration_seconds.count:1|c|#service:renewer,usecase:renew",
+		"usecase_duration_seconds.sum:0.02|c|#service:renewer,usecase:renew",
+	}, read())
+
+	// Only what changed since the last export is sent
+	r.IncCounter(SubscriptionsCreated, map[string]string{"plan_id": "pro,annual"})
+	require.NoError(t, exporter.Export(context.Background(), r.Snapshot()))
+	assert.Equal(t, []string{"subscriptions_created_total:1|c|#service:renewer,plan_id:pro_annual"}, read())
+}
+
+func TestPusher_FlushWritesSnapshot(t *testing.T) {
+	var buf bytes.Buffer
+	exporter := NewStdoutExporter(&buf)
+	exporter.now = func() time.Time { return time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC) }
+	r := NewRegistry()
+	r.IncCounter(SubscriptionsCancelled, nil)
+
+	require.NoError(t, NewPusher(r, exporter, time.Minute, nil).Flush(context.Background()))
+
+	assert.Equal(t, `# metrics at 2026-03-01T12:00:00Z
+# HELP subscriptions_cancelled_total Subscriptions cancelled.
+# TYPE subscriptions_cancelled_total counter
+subscriptions_cancelled_total 1
+`, buf.String())
+}
@@ -0,0 +1,230 @@
+// Package metrics collects the service's metrics in memory and exposes them in the
+// Prometheus text format, so a Prometheus server can scrape each binary's /metrics.
+// A Pusher sends them instead to a backend that doesn't scrape: an OTLP collector,
+// a Datadog agent or stdout.
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+)
+
+var _ contracts.Metrics = (*Registry)(nil)
+
+// ErrDuplicateMetric is returned when a metric name is registered twice
+var ErrDuplicateMetric = errors.New("metric already registered")
+
+// Type is the Prometheus metric type
+type Type string
+
+const (
+	Counter   Type = "counter"
+	Histogram Type = "histogram"
+)
+
+// Definition describes one metric
+type Definition struct {
+	Name    string
+	Type    Type
+	Help    string
+	Buckets []float64 // histogram upper bounds, ascending; defaults to DefaultBuckets
+}
+
+// Registry implements contracts.Metrics by aggregating samples in memory. It is safe
+// for concurrent use.
+type Registry struct {
+	mu       sync.Mutex
+	families map[string]*family
+}
+
+// NewRegistry creates a registry with every Core metric registered
+func NewRegistry() *Registry {
+	r := &Registry{families: make(map[string]*family)}
+	for _, def := range Core() {
+		if err := r.Register(def); err != nil {
+			panic(err) // Core is static, so this is a programming error
+		}
+	}
+	return r
+}
+
+// Register adds a metric definition. Names recorded without one are registered on
+// first use, with no help text and DefaultBuckets.
+func (r *Registry) Register(def Definition) error {
+	if def.Type == Histogram && len(def.Buckets) == 0 {
+		def.Buckets = DefaultBuckets
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.families[def.Name]; ok {
+		return fmt.Errorf("%w: %s", ErrDuplicateMetric, def.Name)
+	}
+	r.families[def.Name] = &family{def: def, series: make(map[string]*series)}
+	return nil
+}
+
+// IncCounter adds one to the counter with the given labels. Recording a counter
+// under a name registered as another type is ignored.
+func (r *Registry) IncCounter(name string, labels map[string]string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if s := r.series(name, Counter, labels); s != nil {
+		s.count++
+	}
+}
+
+// ObserveHistogram records value in the histogram with the given labels. Recording
+// a histogram under a name registered as another type is ignored.
+func (r *Registry) ObserveHistogram(name string, value float64, labels map[string]string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s := r.series(name, Histogram, labels)
+	if s == nil {
+		return
+	}
+	s.count++
+	s.sum += value
+	for i, upper := range s.bounds {
+		if value <= upper {
+			s.buckets[i]++
+		}
+	}
+}
+
+// Family is a point-in-time copy of one metric's series
+type Family struct {
+	Name   string
+	Type   Type
+	Help   string
+	Series []Series // in a stable order
+}
+
+// Series is a point-in-time copy of one label combination. Counters use Count only;
+// histogram Buckets are cumulative counts for each of Bounds.
+type Series struct {
+	Labels  map[string]string
+	Count   uint64
+	Sum     float64
+	Bounds  []float64
+	Buckets []uint64
+}
+
+// Snapshot copies every metric with at least one sample, families and series in a
+// stable order, for exporters that push rather than being scraped
+func (r *Registry) Snapshot() []Family {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	names := make([]string, 0, len(r.families))
+	for name, f := range r.families {
+		if len(f.series) > 0 {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	families := make([]Family, 0, len(names))
+	for _, name := range names {
+		f := r.families[name]
+		keys := make([]string, 0, len(f.series))
+		for key := range f.series {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		family := Family{Name: name, Type: f.def.Type, Help: f.def.Help, Series: make([]Series, 0, len(keys))}
+		for _, key := range keys {
+			s := f.series[key]
+			family.Series = append(family.Series, Series{
+				Labels:  copyLabels(s.labels),
+				Count:   s.count,
+				Sum:     s.sum,
+				Bounds:  s.bounds,
+				Buckets: append([]uint64(nil), s.buckets...),
+			})
+		}
+		families = append(families, family)
+	}
+	return families
+}
+
+// series returns the series for labels, creating the family and series as needed,
+// or nil when name is registered as another type. r.mu must be held.
+func (r *Registry) series(name string, typ Type, labels map[string]string) *series {
+	f, ok := r.families[name]
+	if !ok {
+		def := Definition{Name: name, Type: typ}
+		if typ == Histogram {
+			def.Buckets = DefaultBuckets
+		}
+		f = &family{def: def, series: make(map[string]*series)}
+		r.families[name] = f
+	}
+	if f.def.Type != typ {
+		return nil
+	}
+
+	key := labelKey(labels)
+	s, ok := f.series[key]
+	if !ok {
+		s = &series{labels: copyLabels(labels)}
+		if typ == Histogram {
+			s.bounds = f.def.Buckets
+			s.buckets = make([]uint64, len(f.def.Buckets))
+		}
+		f.series[key] = s
+	}
+	return s
+}
+
+// family is every series of one metric
+type family struct {
+	def    Definition
+	series map[string]*series // by labelKey
+}
+
+// series is one label combination. Counters use count only; histogram buckets
+// are cumulative, matching the exposition format.
+type series struct {
+	labels  map[string]string
+	count   uint64
+	sum     float64
+	bounds  []float64
+	buckets []uint64
+}
+
+// labelKey identifies a label set regardless of map order
+func labelKey(labels map[string]string) string {
+	keys := sortedKeys(labels)
+	var b strings.Builder
+	for _, k := range keys {
+		b.WriteString(k)
+		b.WriteByte(0)
+		b.WriteString(labels[k])
+		b.WriteByte(0)
+	}
+	return b.String()
+}
+
+func sortedKeys(labels map[string]string) []string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func copyLabels(labels map[string]string) map[string]string {
+	out := make(map[string]string, len(labels))
+	for k, v := range labels {
+		out[k] = v
+	}
+	return out
+}
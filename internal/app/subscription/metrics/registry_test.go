@@ -0,0 +1,120 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/adapters"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/instrument"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/workers/dunning"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/workers/paymentmethods"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/workers/refunds"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/workers/renewal"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/workers/renewalnotices"
+)
+
+func scrape(t *testing.T, r *Registry) string {
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, contentType, rec.Header().Get("Content-Type"))
+	return rec.Body.String()
+}
+
+func TestRegistry_CountersByLabelSet(t *testing.T) {
+	r := NewRegistry()
+
+	r.IncCounter(SubscriptionsCreated, map[string]string{"plan_id": "pro"})
+	r.IncCounter(SubscriptionsCreated, map[string]string{"plan_id": "pro"})
+	r.IncCounter(SubscriptionsCreated, map[string]string{"plan_id": "basic"})
+	r.IncCounter(SubscriptionsCancelled, nil)
+
+	assert.Equal(t, `# HELP subscriptions_cancelled_total Subscriptions cancelled.
+# TYPE subscriptions_cancelled_total counter
+subscriptions_cancelled_total 1
+# HELP subscriptions_created_total Subscriptions created, by plan.
+# TYPE subscriptions_created_total counter
+subscriptions_created_total{plan_id="basic"} 1
+subscriptions_created_total{plan_id="pro"} 2
+`, scrape(t, r))
+}
+
+func TestRegistry_HistogramBucketsAreCumulative(t *testing.T) {
+	r := NewRegistry()
+
+	labels := map[string]string{"currency": "USD"}
+	r.ObserveHistogram(RefundAmount, 250, labels)
+	r.ObserveHistogram(RefundAmount, 5000, labels)
+	r.ObserveHistogram(RefundAmount, 200000, labels)
+
+	body := scrape(t, r)
+	assert.Contains(t, body, "# TYPE refund_amount_cents histogram\n")
+	assert.Contains(t, body, `refund_amount_cents_bucket{currency="USD",le="100"} 0`+"\n")
+	assert.Contains(t, body, `refund_amount_cents_bucket{currency="USD",le="500"} 1`+"\n")
+	assert.Contains(t, body, `refund_amount_cents_bucket{currency="USD",le="5000"} 2`+"\n")
+	assert.Contains(t, body, `refund_amount_cents_bucket{currency="USD",le="100000"} 2`+"\n")
+	assert.Contains(t, body, `refund_amount_cents_bucket{currency="USD",le="+Inf"} 3`+"\n")
+	assert.Contains(t, body, `refund_amount_cents_sum{currency="USD"} 205250`+"\n")
+	assert.Contains(t, body, `refund_amount_cents_count{currency="USD"} 3`+"\n")
+}
+
+func TestRegistry_UnregisteredNamesAreStillExported(t *testing.T) {
+	r := NewRegistry()
+
+	r.IncCounter("jobs_total", map[string]string{"outcome": `say "hi"`})
+	r.ObserveHistogram("job_duration_seconds", 0.2, nil)
+
+	body := scrape(t, r)
+	assert.Contains(t, body, "# TYPE jobs_total counter\njobs_total{outcome=\"say \\\"hi\\\"\"} 1\n")
+	assert.Contains(t, body, `job_duration_seconds_bucket{le="0.25"} 1`)
+	assert.NotContains(t, body, "# HELP jobs_total")
+}
+
+func TestRegistry_TypeMismatchIsIgnored(t *testing.T) {
+	r := NewRegistry()
+
+	r.ObserveHistogram(SpannerErrors, 1, nil)
+
+	assert.Empty(t, scrape(t, r))
+}
+
+func TestRegistry_DuplicateRegistration(t *testing.T) {
+	r := NewRegistry()
+
+	err := r.Register(Definition{Name: SpannerErrors, Type: Counter})
+
+	assert.True(t, errors.Is(err, ErrDuplicateMetric))
+}
+
+// TestCore_DescribesEveryComponentMetric keeps Core in step with the names components record
+func TestCore_DescribesEveryComponentMetric(t *testing.T) {
+	described := make(map[string]bool)
+	for _, def := range Core() {
+		described[def.Name] = true
+	}
+
+	for _, name := range []string{
+		instrument.MetricExecutions,
+		instrument.MetricDuration,
+		instrument.MetricSLIRequests,
+		instrument.MetricSLIRefundProcessing,
+		instrument.MetricSLIRefundFailures,
+		adapters.MetricBillingCalls,
+		adapters.MetricBillingDuration,
+		adapters.MetricBillingRetries,
+		adapters.MetricBillingHedges,
+		renewal.MetricRenewals,
+		dunning.MetricPaymentRetries,
+		refunds.MetricRefundPolls,
+		refunds.MetricRefundDispatches,
+		paymentmethods.MetricPaymentMethodChecks,
+		renewalnotices.MetricRenewalNotices,
+	} {
+		assert.True(t, described[name], name)
+	}
+}
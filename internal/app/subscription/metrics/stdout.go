@@ -0,0 +1,36 @@
+package metrics
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+var _ Exporter = (*StdoutExporter)(nil)
+
+// StdoutExporter writes each snapshot in the Prometheus text format, headed by its
+// time, for local development without a metrics backend
+type StdoutExporter struct {
+	mu  sync.Mutex
+	w   io.Writer
+	now func() time.Time
+}
+
+// NewStdoutExporter creates an exporter writing to w, typically os.Stdout
+func NewStdoutExporter(w io.Writer) *StdoutExporter {
+	return &StdoutExporter{w: w, now: time.Now}
+}
+
+// Export writes one snapshot
+func (e *StdoutExporter) Export(ctx context.Context, families []Family) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	buf := bufio.NewWriter(e.w)
+	fmt.Fprintf(buf, "# metrics at %s\n", e.now().UTC().Format(time.RFC3339))
+	writeFamilies(buf, families)
+	return buf.Flush()
+}
@@ -3,6 +3,7 @@ package migrations
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"sort"
@@ -17,8 +18,11 @@ import (
 	"google.golang.org/grpc/status"
 )
 
-// RunMigrations executes all SQL migration files in the migrations directory
-func RunMigrations(ctx context.Context, projectID, instanceID, databaseID string) error {
+// RunMigrations applies the SQL migration files in the migrations directory that the
+// database hasn't recorded in schema_migrations, then records them, logging progress
+// to logger. A database without schema_migrations gets every file, as before the
+// table existed.
+func RunMigrations(ctx context.Context, logger *slog.Logger, projectID, instanceID, databaseID string) error {
 	emulatorHost := os.Getenv("SPANNER_EMULATOR_HOST")
 
 	projectName := fmt.Sprintf("projects/%s", projectID)
@@ -29,9 +33,8 @@ func RunMigrations(ctx context.Context, projectID, instanceID, databaseID string
 	var instanceAdminClient *instanceadmin.InstanceAdminClient
 	var err error
 
-	fmt.Printf("Connecting to Spanner...\n")
 	if emulatorHost != "" {
-		fmt.Printf("Using emulator at %s\n", emulatorHost)
+		logger.InfoContext(ctx, "connecting to Spanner emulator", slog.String("host", emulatorHost))
 		// For emulator, endpoint should be without http:// for gRPC
 		endpoint := emulatorHost
 		if strings.Contains(emulatorHost, "://") {
@@ -40,7 +43,7 @@ func RunMigrations(ctx context.Context, projectID, instanceID, databaseID string
 		}
 		instanceAdminClient, err = instanceadmin.NewInstanceAdminClient(ctx, option.WithEndpoint(endpoint))
 	} else {
-		fmt.Printf("Using production Spanner\n")
+		logger.InfoContext(ctx, "connecting to Spanner")
 		instanceAdminClient, err = instanceadmin.NewInstanceAdminClient(ctx)
 	}
 	if err != nil {
@@ -49,13 +52,12 @@ func RunMigrations(ctx context.Context, projectID, instanceID, databaseID string
 	defer instanceAdminClient.Close()
 
 	// Check if instance exists, create if it doesn't
-	fmt.Printf("Checking if instance exists: %s\n", instanceName)
 	_, err = instanceAdminClient.GetInstance(ctx, &instancepb.GetInstanceRequest{
 		Name: instanceName,
 	})
 	if err != nil {
 		if st, ok := status.FromError(err); ok && st.Code() == codes.NotFound {
-			fmt.Printf("Instance does not exist, creating: %s\n", instanceID)
+			logger.InfoContext(ctx, "creating instance", slog.String("instance", instanceName))
 			// For emulator, create instance with minimal config
 			op, err := instanceAdminClient.CreateInstance(ctx, &instancepb.CreateInstanceRequest{
 				Parent:     projectName,
@@ -69,17 +71,16 @@ func RunMigrations(ctx context.Context, projectID, instanceID, databaseID string
 			}
 
 			// Wait for instance creation
-			fmt.Printf("Waiting for instance creation...\n")
 			_, err = op.Wait(ctx)
 			if err != nil {
 				return fmt.Errorf("instance creation failed: %w", err)
 			}
-			fmt.Printf("✓ Instance created: %s\n", instanceName)
+			logger.InfoContext(ctx, "instance created", slog.String("instance", instanceName))
 		} else {
 			return fmt.Errorf("failed to check instance existence: %w", err)
 		}
 	} else {
-		fmt.Printf("✓ Instance exists: %s\n", instanceName)
+		logger.InfoContext(ctx, "instance exists", slog.String("instance", instanceName))
 	}
 
 	// Create database admin client for DDL operations
@@ -109,14 +110,17 @@ func RunMigrations(ctx context.Context, projectID, instanceID, databaseID string
 	}
 
 	if len(files) == 0 {
-		fmt.Printf("No migration files found in migrations/ directory\n")
+		logger.WarnContext(ctx, "no migration files found", slog.String("dir", migrationsDir))
 		return nil
 	}
 
-	// Read all migration files and combine statements
-	var allStatements []string
+	// Read the migration files, each numbered by its name's prefix
+	var all []migration
 	for _, file := range files {
-		fmt.Printf("Reading migration: %s\n", filepath.Base(file))
+		version, err := migrationVersion(file)
+		if err != nil {
+			return err
+		}
 		sql, err := os.ReadFile(file)
 		if err != nil {
 			return fmt.Errorf("failed to read migration file %s: %w", file, err)
@@ -125,20 +129,19 @@ func RunMigrations(ctx context.Context, projectID, instanceID, databaseID string
 		// Extract DDL statements
 		statements := parseDDLStatements(string(sql))
 		if len(statements) == 0 {
-			fmt.Printf("  Skipping (no DDL statements found)\n")
+			logger.WarnContext(ctx, "skipping migration without DDL statements", slog.String("file", filepath.Base(file)))
 			continue
 		}
-		allStatements = append(allStatements, statements...)
-		fmt.Printf("  Extracted %d DDL statement(s)\n", len(statements))
+		all = append(all, migration{version: version, name: filepath.Base(file), statements: statements})
+		logger.DebugContext(ctx, "read migration", slog.String("file", filepath.Base(file)), slog.Int("statements", len(statements)))
 	}
 
-	if len(allStatements) == 0 {
-		fmt.Printf("No DDL statements found in migration files\n")
+	if len(all) == 0 {
+		logger.WarnContext(ctx, "no DDL statements found in migration files")
 		return nil
 	}
 
 	// Check if database exists
-	fmt.Printf("Checking if database exists: %s\n", databasePath)
 	_, err = adminClient.GetDatabase(ctx, &databasepb.GetDatabaseRequest{
 		Name: databasePath,
 	})
@@ -146,7 +149,8 @@ func RunMigrations(ctx context.Context, projectID, instanceID, databaseID string
 	if err != nil {
 		// Database doesn't exist, create it with DDL statements
 		if st, ok := status.FromError(err); ok && st.Code() == codes.NotFound {
-			fmt.Printf("Database does not exist, creating with migrations: %s\n", databaseID)
+			allStatements := statementsOf(all)
+			logger.InfoContext(ctx, "creating database", slog.String("database", databasePath), slog.Int("statements", len(allStatements)))
 			op, err := adminClient.CreateDatabase(ctx, &databasepb.CreateDatabaseRequest{
 				Parent:          instanceName,
 				CreateStatement: fmt.Sprintf("CREATE DATABASE `%s`", databaseID),
@@ -157,37 +161,49 @@ func RunMigrations(ctx context.Context, projectID, instanceID, databaseID string
 			}
 
 			// Wait for database creation
-			fmt.Printf("Waiting for database creation and migrations...\n")
 			db, err := op.Wait(ctx)
 			if err != nil {
 				return fmt.Errorf("database creation failed: %w", err)
 			}
-			fmt.Printf("✓ Database created: %s\n", db.Name)
-			fmt.Printf("✓ Successfully applied %d migration statement(s)\n", len(allStatements))
-			return nil
+			logger.InfoContext(ctx, "database created", slog.String("database", db.Name), slog.Int("statements", len(allStatements)))
+			return recordMigrations(ctx, databasePath, all)
 		}
 		return fmt.Errorf("failed to check database existence: %w", err)
 	}
 
-	// Database exists - apply migrations using UpdateDatabaseDdl
-	fmt.Printf("✓ Database exists: %s\n", databaseID)
-	fmt.Printf("Applying %d DDL statement(s)...\n", len(allStatements))
+	// Database exists - apply the migrations it hasn't recorded using UpdateDatabaseDdl
+	current, err := appliedVersion(ctx, databasePath)
+	if err != nil {
+		return err
+	}
+	var pending []migration
+	for _, m := range all {
+		if m.version > current {
+			pending = append(pending, m)
+		}
+	}
+	if len(pending) == 0 {
+		logger.InfoContext(ctx, "schema up to date", slog.String("database", databasePath), slog.Int64("version", current))
+		return nil
+	}
+
+	statements := statementsOf(pending)
+	logger.InfoContext(ctx, "applying migrations", slog.String("database", databasePath), slog.Int64("from_version", current), slog.Int("statements", len(statements)))
 
 	op, err := adminClient.UpdateDatabaseDdl(ctx, &databasepb.UpdateDatabaseDdlRequest{
 		Database:   databasePath,
-		Statements: allStatements,
+		Statements: statements,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to start migrations: %w", err)
 	}
 
-	fmt.Printf("Waiting for DDL operations to complete...\n")
 	if err := op.Wait(ctx); err != nil {
 		return fmt.Errorf("failed to complete migrations: %w", err)
 	}
 
-	fmt.Printf("✓ Successfully applied %d migration statement(s)\n", len(allStatements))
-	return nil
+	logger.InfoContext(ctx, "migrations applied", slog.String("database", databasePath), slog.Int64("version", pending[len(pending)-1].version), slog.Int("statements", len(statements)))
+	return recordMigrations(ctx, databasePath, pending)
 }
 
 // findMigrationsDir finds the migrations directory relative to the project root
@@ -275,9 +291,7 @@ func parseDDLStatements(sql string) []string {
 
 		// If line ends with semicolon, finalize the statement
 		if strings.HasSuffix(trimmed, ";") {
-			stmt := strings.TrimSpace(currentStatement.String())
-			// Remove trailing semicolon
-			stmt = strings.TrimSuffix(stmt, ";")
+			stmt := trimStatement(currentStatement.String())
 			if stmt != "" {
 				statements = append(statements, stmt)
 			}
@@ -287,7 +301,7 @@ func parseDDLStatements(sql string) []string {
 
 	// Handle any remaining statement without trailing semicolon
 	if currentStatement.Len() > 0 {
-		stmt := strings.TrimSpace(currentStatement.String())
+		stmt := trimStatement(currentStatement.String())
 		if stmt != "" {
 			statements = append(statements, stmt)
 		}
@@ -295,3 +309,13 @@ func parseDDLStatements(sql string) []string {
 
 	return statements
 }
+
+// trimStatement strips the whitespace and terminating semicolons around a statement,
+// however many a file repeats
+func trimStatement(stmt string) string {
+	stmt = strings.TrimSpace(stmt)
+	for strings.HasSuffix(stmt, ";") {
+		stmt = strings.TrimSpace(strings.TrimSuffix(stmt, ";"))
+	}
+	return stmt
+}
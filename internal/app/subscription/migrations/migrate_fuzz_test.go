@@ -0,0 +1,58 @@
+package migrations
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// FuzzParseDDLStatements checks that whatever a migration file holds, every parsed
+// statement is one trimmed line with no comment and no terminating semicolon, and that
+// writing the statements back out one per line parses to the same statements
+//
+//	go test ./internal/app/subscription/migrations -run '^$' -fuzz FuzzParseDDLStatements
+func FuzzParseDDLStatements(f *testing.F) {
+	dir, err := findMigrationsDir()
+	if err != nil {
+		f.Fatal(err)
+	}
+	files, err := getMigrationFiles(dir)
+	if err != nil {
+		f.Fatal(err)
+	}
+	for _, file := range files {
+		sql, err := os.ReadFile(file)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(string(sql))
+	}
+	f.Add("CREATE TABLE t (id STRING(36)) PRIMARY KEY (id)")
+	f.Add("-- only a comment\n\n   \n")
+	f.Add("CREATE INDEX i ON t(a); -- trailing; comment\nDROP INDEX j;;\n ; \n")
+	f.Add("ALTER TABLE t ADD COLUMN c STRING(MAX) -- no terminator\n;")
+
+	f.Fuzz(func(t *testing.T, sql string) {
+		statements := parseDDLStatements(sql)
+		for _, stmt := range statements {
+			switch {
+			case stmt == "":
+				t.Fatalf("empty statement from %q", sql)
+			case stmt != strings.TrimSpace(stmt):
+				t.Fatalf("statement %q isn't trimmed", stmt)
+			case strings.HasSuffix(stmt, ";"):
+				t.Fatalf("statement %q keeps its terminator", stmt)
+			case strings.Contains(stmt, "\n"):
+				t.Fatalf("statement %q spans lines", stmt)
+			case strings.Contains(stmt, "--"):
+				t.Fatalf("statement %q keeps a comment", stmt)
+			}
+		}
+
+		rewritten := strings.Join(statements, ";\n")
+		if again := parseDDLStatements(rewritten); !reflect.DeepEqual(again, statements) {
+			t.Fatalf("reparsing %q gave %q, want %q", rewritten, again, statements)
+		}
+	})
+}
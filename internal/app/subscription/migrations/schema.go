@@ -0,0 +1,111 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"cloud.google.com/go/spanner"
+)
+
+// SchemaVersion is the migration this binary's code was written against: the highest
+// numbered file in migrations/. Bump it with every new migration file.
+const SchemaVersion int64 = 36
+
+// migration is one migration file's DDL
+type migration struct {
+	version    int64
+	name       string
+	statements []string
+}
+
+// migrationVersion parses the number a migration file's name starts with, such as 9
+// for 009_schema_migrations.sql
+func migrationVersion(path string) (int64, error) {
+	name := filepath.Base(path)
+	prefix, _, _ := strings.Cut(name, "_")
+	version, err := strconv.ParseInt(prefix, 10, 64)
+	if err != nil || version <= 0 {
+		return 0, fmt.Errorf("migration file %s must start with its version number", name)
+	}
+	return version, nil
+}
+
+func statementsOf(migrations []migration) []string {
+	var statements []string
+	for _, m := range migrations {
+		statements = append(statements, m.statements...)
+	}
+	return statements
+}
+
+// CurrentVersion returns the highest migration recorded in schema_migrations, or 0
+// when the table doesn't exist yet
+func CurrentVersion(ctx context.Context, client *spanner.Client) (int64, error) {
+	var exists bool
+	err := client.Single().Query(ctx, spanner.Statement{SQL: `
+		SELECT COUNT(*) > 0 FROM INFORMATION_SCHEMA.TABLES
+		WHERE TABLE_SCHEMA = '' AND TABLE_NAME = 'schema_migrations'
+	`}).Do(func(row *spanner.Row) error { return row.Columns(&exists) })
+	if err != nil {
+		return 0, fmt.Errorf("failed to look up schema_migrations: %w", err)
+	}
+	if !exists {
+		return 0, nil
+	}
+
+	var version spanner.NullInt64
+	err = client.Single().Query(ctx, spanner.Statement{SQL: `SELECT MAX(version) FROM schema_migrations`}).
+		Do(func(row *spanner.Row) error { return row.Columns(&version) })
+	if err != nil {
+		return 0, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	return version.Int64, nil
+}
+
+// CheckSchema fails when the database is behind SchemaVersion, so an instance isn't
+// ready until the migrations its code needs have run. A database ahead of it is fine:
+// migrations are additive, and during a rollout the previous binary still runs
+// against the new schema.
+func CheckSchema(ctx context.Context, client *spanner.Client) error {
+	version, err := CurrentVersion(ctx, client)
+	if err != nil {
+		return err
+	}
+	if version < SchemaVersion {
+		return fmt.Errorf("schema is at version %d, binary expects %d: run cmd/migrate", version, SchemaVersion)
+	}
+	return nil
+}
+
+// appliedVersion opens the database to read its current version
+func appliedVersion(ctx context.Context, databasePath string) (int64, error) {
+	client, err := spanner.NewClient(ctx, databasePath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create Spanner client: %w", err)
+	}
+	defer client.Close()
+	return CurrentVersion(ctx, client)
+}
+
+// recordMigrations adds the applied migrations to schema_migrations
+func recordMigrations(ctx context.Context, databasePath string, applied []migration) error {
+	client, err := spanner.NewClient(ctx, databasePath)
+	if err != nil {
+		return fmt.Errorf("failed to create Spanner client: %w", err)
+	}
+	defer client.Close()
+
+	mutations := make([]*spanner.Mutation, 0, len(applied))
+	for _, m := range applied {
+		mutations = append(mutations, spanner.InsertOrUpdate("schema_migrations",
+			[]string{"version", "name", "applied_at"},
+			[]any{m.version, m.name, spanner.CommitTimestamp}))
+	}
+	if _, err := client.Apply(ctx, mutations); err != nil {
+		return fmt.Errorf("failed to record migrations: %w", err)
+	}
+	return nil
+}
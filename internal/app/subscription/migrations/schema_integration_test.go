@@ -0,0 +1,496 @@
+//go:build integration
+
+package migrations_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/migrations"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/testkit/integration"
+)
+
+func TestMain(m *testing.M) {
+	integration.Main(m)
+}
+
+// schema is what the repositories read and write, as the migrations should leave it.
+// A migration that changes the schema changes this too.
+var schema = []integration.TableSpec{
+	{
+		Name:       "subscriptions",
+		PrimaryKey: []string{"id"},
+		Columns: map[string]string{
+			"id":                          "STRING(255) NOT NULL",
+			"customer_id":                 "STRING(255) NOT NULL",
+			"plan_id":                     "STRING(255) NOT NULL",
+			"price_cents":                 "INT64 NOT NULL",
+			"status":                      "STRING(50) NOT NULL",
+			"start_date":                  "TIMESTAMP NOT NULL",
+			"current_period_start":        "TIMESTAMP",
+			"current_period_end":          "TIMESTAMP",
+			"dunning_attempts":            "INT64",
+			"next_payment_retry_at":       "TIMESTAMP",
+			"cancelled_at":                "TIMESTAMP",
+			"payment_method_flagged_for":  "TIMESTAMP",
+			"trial_end_date":              "TIMESTAMP",
+			"renewal_notice_sent_for":     "TIMESTAMP",
+			"paused_at":                   "TIMESTAMP",
+			"cancel_at":                   "TIMESTAMP",
+			"coupon_code":                 "STRING(64)",
+			"coupon_percent_off":          "INT64",
+			"coupon_amount_off_cents":     "INT64",
+			"coupon_periods_left":         "INT64",
+			"cancellation_reason":         "STRING(64)",
+			"cancellation_reason_details": "STRING(MAX)",
+			"version":                     "INT64",
+		},
+		Indexes: []integration.IndexSpec{
+			{Name: "idx_customer_id", Columns: []string{"customer_id"}},
+			{Name: "idx_status_next_payment_retry_at", Columns: []string{"status", "next_payment_retry_at"}},
+			{Name: "idx_status_cancelled_at", Columns: []string{"status", "cancelled_at"}},
+			{Name: "idx_status_cancel_at", Columns: []string{"status", "cancel_at"}},
+			{Name: "idx_status_current_period_end", Columns: []string{"status", "current_period_end"}},
+			{Name: "idx_plan_id_status", Columns: []string{"plan_id", "status"}},
+			{Name: "idx_subscriptions_customer_status_start", Columns: []string{"customer_id", "status", "start_date"}, Storing: []string{"plan_id", "price_cents"}},
+		},
+	},
+	{
+		Name:       "purge_audit",
+		PrimaryKey: []string{"id"},
+		Columns: map[string]string{
+			"id":            "STRING(36) NOT NULL",
+			"target":        "STRING(100) NOT NULL",
+			"action":        "STRING(20) NOT NULL",
+			"cutoff":        "TIMESTAMP NOT NULL",
+			"rows_affected": "INT64 NOT NULL",
+			"dry_run":       "BOOL NOT NULL",
+			"executed_at":   "TIMESTAMP NOT NULL",
+		},
+	},
+	{
+		Name:       "refunds",
+		PrimaryKey: []string{"id"},
+		Columns: map[string]string{
+			"id":                 "STRING(36) NOT NULL",
+			"subscription_id":    "STRING(255) NOT NULL",
+			"customer_id":        "STRING(255) NOT NULL",
+			"amount_cents":       "INT64 NOT NULL",
+			"currency":           "STRING(3) NOT NULL",
+			"provider_refund_id": "STRING(255) NOT NULL",
+			"status":             "STRING(50) NOT NULL",
+			"failure_reason":     "STRING(MAX)",
+			"requested_at":       "TIMESTAMP NOT NULL",
+			"settled_at":         "TIMESTAMP",
+		},
+		Indexes: []integration.IndexSpec{
+			{Name: "idx_refunds_provider_refund_id", Columns: []string{"provider_refund_id"}, Unique: true},
+			{Name: "idx_refunds_status_requested_at", Columns: []string{"status", "requested_at"}},
+			{Name: "idx_refunds_customer_id", Columns: []string{"customer_id"}},
+		},
+	},
+	{
+		Name:       "admin_audit",
+		PrimaryKey: []string{"id"},
+		Columns: map[string]string{
+			"id":             "STRING(36) NOT NULL",
+			"action":         "STRING(100) NOT NULL",
+			"principal":      "STRING(255) NOT NULL",
+			"source_ip":      "STRING(45)",
+			"payload_hash":   "STRING(64) NOT NULL",
+			"outcome":        "STRING(20) NOT NULL",
+			"error":          "STRING(MAX)",
+			"correlation_id": "STRING(36)",
+			"occurred_at":    "TIMESTAMP NOT NULL",
+		},
+		Indexes: []integration.IndexSpec{
+			{Name: "idx_admin_audit_occurred_at", Columns: []string{"occurred_at", "id"}},
+		},
+	},
+	{
+		Name:       "report_active_by_plan",
+		PrimaryKey: []string{"plan_id"},
+		Columns: map[string]string{
+			"plan_id":      "STRING(255) NOT NULL",
+			"active_count": "INT64 NOT NULL",
+			"refreshed_at": "TIMESTAMP NOT NULL",
+		},
+	},
+	{
+		Name:       "report_daily_subscriptions",
+		PrimaryKey: []string{"day"},
+		Columns: map[string]string{
+			"day":             "DATE NOT NULL",
+			"new_count":       "INT64 NOT NULL",
+			"cancelled_count": "INT64 NOT NULL",
+			"refreshed_at":    "TIMESTAMP NOT NULL",
+			"read_at":         "TIMESTAMP",
+		},
+	},
+	{
+		Name:       "report_refund_totals",
+		PrimaryKey: []string{"currency", "status"},
+		Columns: map[string]string{
+			"currency":     "STRING(3) NOT NULL",
+			"status":       "STRING(50) NOT NULL",
+			"refund_count": "INT64 NOT NULL",
+			"amount_cents": "INT64 NOT NULL",
+			"refreshed_at": "TIMESTAMP NOT NULL",
+		},
+	},
+	{
+		Name:       "schema_migrations",
+		PrimaryKey: []string{"version"},
+		Columns: map[string]string{
+			"version":    "INT64 NOT NULL",
+			"name":       "STRING(255) NOT NULL",
+			"applied_at": "TIMESTAMP NOT NULL",
+		},
+	},
+	{
+		Name:       "credit_notes",
+		PrimaryKey: []string{"id"},
+		Columns: map[string]string{
+			"id":                 "STRING(36) NOT NULL",
+			"customer_id":        "STRING(255) NOT NULL",
+			"subscription_id":    "STRING(255) NOT NULL",
+			"invoice_id":         "STRING(255) NOT NULL",
+			"amount_cents":       "INT64 NOT NULL",
+			"currency":           "STRING(3) NOT NULL",
+			"reason":             "STRING(50) NOT NULL",
+			"memo":               "STRING(MAX)",
+			"settlement":         "STRING(50) NOT NULL",
+			"provider_refund_id": "STRING(255)",
+			"issued_at":          "TIMESTAMP NOT NULL",
+		},
+		Indexes: []integration.IndexSpec{
+			{Name: "idx_credit_notes_invoice_id", Columns: []string{"invoice_id"}},
+			{Name: "idx_credit_notes_customer_id", Columns: []string{"customer_id"}},
+		},
+	},
+	{
+		Name:       "customer_credits",
+		PrimaryKey: []string{"id"},
+		Columns: map[string]string{
+			"id":           "STRING(36) NOT NULL",
+			"customer_id":  "STRING(255) NOT NULL",
+			"amount_cents": "INT64 NOT NULL",
+			"currency":     "STRING(3) NOT NULL",
+			"source":       "STRING(50) NOT NULL",
+			"source_id":    "STRING(255) NOT NULL",
+			"created_at":   "TIMESTAMP NOT NULL",
+		},
+		Indexes: []integration.IndexSpec{
+			{Name: "idx_customer_credits_customer_id", Columns: []string{"customer_id"}},
+		},
+	},
+	{
+		Name:       "referral_codes",
+		PrimaryKey: []string{"code"},
+		Columns: map[string]string{
+			"code":        "STRING(16) NOT NULL",
+			"customer_id": "STRING(255) NOT NULL",
+			"created_at":  "TIMESTAMP NOT NULL",
+		},
+		Indexes: []integration.IndexSpec{
+			{Name: "idx_referral_codes_customer_id", Columns: []string{"customer_id"}, Unique: true},
+		},
+	},
+	{
+		Name:       "referral_credits",
+		PrimaryKey: []string{"id"},
+		Columns: map[string]string{
+			"id":                    "STRING(36) NOT NULL",
+			"code":                  "STRING(16) NOT NULL",
+			"referrer_customer_id":  "STRING(255) NOT NULL",
+			"customer_id":           "STRING(255) NOT NULL",
+			"subscription_id":       "STRING(255) NOT NULL",
+			"status":                "STRING(50) NOT NULL",
+			"referrer_credit_cents": "INT64 NOT NULL",
+			"referee_credit_cents":  "INT64 NOT NULL",
+			"created_at":            "TIMESTAMP NOT NULL",
+			"rewarded_at":           "TIMESTAMP",
+		},
+		Indexes: []integration.IndexSpec{
+			{Name: "idx_referral_credits_subscription_id", Columns: []string{"subscription_id"}, Unique: true},
+			{Name: "idx_referral_credits_referrer_customer_id", Columns: []string{"referrer_customer_id"}},
+		},
+	},
+	{
+		Name:       "plan_entitlements",
+		PrimaryKey: []string{"plan_id", "feature"},
+		Columns: map[string]string{
+			"plan_id":     "STRING(255) NOT NULL",
+			"feature":     "STRING(255) NOT NULL",
+			"limit_value": "INT64",
+		},
+	},
+	{
+		Name:       "plans",
+		PrimaryKey: []string{"id"},
+		Columns: map[string]string{
+			"id":                  "STRING(255) NOT NULL",
+			"name":                "STRING(255) NOT NULL",
+			"price_cents":         "INT64 NOT NULL",
+			"currency":            "STRING(3) NOT NULL",
+			"billing_interval":    "STRING(8)",
+			"trial_days":          "INT64",
+			"active":              "BOOL NOT NULL",
+			"external_product_id": "STRING(255)",
+			"external_price_id":   "STRING(255)",
+			"created_at":          "TIMESTAMP NOT NULL",
+			"updated_at":          "TIMESTAMP NOT NULL",
+		},
+		Indexes: []integration.IndexSpec{
+			{Name: "idx_plans_external_product_id", Columns: []string{"external_product_id"}, Unique: true, NullFiltered: true},
+		},
+	},
+	{
+		Name:       "usage_records",
+		PrimaryKey: []string{"id"},
+		Columns: map[string]string{
+			"id":              "STRING(36) NOT NULL",
+			"subscription_id": "STRING(255) NOT NULL",
+			"customer_id":     "STRING(255) NOT NULL",
+			"metric":          "STRING(255) NOT NULL",
+			"quantity":        "INT64 NOT NULL",
+			"period_start":    "TIMESTAMP NOT NULL",
+			"recorded_at":     "TIMESTAMP NOT NULL",
+		},
+		Indexes: []integration.IndexSpec{
+			{Name: "idx_usage_records_period", Columns: []string{"subscription_id", "metric", "period_start"}, Storing: []string{"quantity"}},
+		},
+	},
+	{
+		Name:       "usage_alerts",
+		PrimaryKey: []string{"subscription_id", "metric", "period_start", "threshold_bp"},
+		Columns: map[string]string{
+			"subscription_id": "STRING(255) NOT NULL",
+			"metric":          "STRING(255) NOT NULL",
+			"period_start":    "TIMESTAMP NOT NULL",
+			"threshold_bp":    "INT64 NOT NULL",
+			"quantity":        "INT64 NOT NULL",
+			"included":        "INT64 NOT NULL",
+			"reached_at":      "TIMESTAMP NOT NULL",
+		},
+	},
+	{
+		Name:       "charge_authentications",
+		PrimaryKey: []string{"id"},
+		Columns: map[string]string{
+			"id":                   "STRING(36) NOT NULL",
+			"subscription_id":      "STRING(255) NOT NULL",
+			"customer_id":          "STRING(255) NOT NULL",
+			"provider_payment_id":  "STRING(255) NOT NULL",
+			"amount_cents":         "INT64 NOT NULL",
+			"credit_applied_cents": "INT64 NOT NULL",
+			"currency":             "STRING(3) NOT NULL",
+			"action_url":           "STRING(MAX) NOT NULL",
+			"period_start":         "TIMESTAMP NOT NULL",
+			"status":               "STRING(50) NOT NULL",
+			"failure_reason":       "STRING(MAX)",
+			"requested_at":         "TIMESTAMP NOT NULL",
+			"resolved_at":          "TIMESTAMP",
+		},
+		Indexes: []integration.IndexSpec{
+			{Name: "idx_charge_authentications_provider_payment_id", Columns: []string{"provider_payment_id"}, Unique: true},
+			{Name: "idx_charge_authentications_customer_id", Columns: []string{"customer_id"}},
+		},
+	},
+	{
+		Name:       "cancellation_survey_responses",
+		PrimaryKey: []string{"id"},
+		Columns: map[string]string{
+			"id":              "STRING(36) NOT NULL",
+			"subscription_id": "STRING(255) NOT NULL",
+			"customer_id":     "STRING(255) NOT NULL",
+			"plan_id":         "STRING(255) NOT NULL",
+			"survey_version":  "STRING(255) NOT NULL",
+			"cancelled_at":    "TIMESTAMP NOT NULL",
+			"submitted_at":    "TIMESTAMP NOT NULL",
+		},
+		Indexes: []integration.IndexSpec{
+			{Name: "idx_cancellation_survey_responses_cancellation", Columns: []string{"subscription_id", "cancelled_at"}, Unique: true},
+			{Name: "idx_cancellation_survey_responses_customer_id", Columns: []string{"customer_id"}},
+			{Name: "idx_cancellation_survey_responses_submitted_at", Columns: []string{"submitted_at"}},
+		},
+	},
+	{
+		Name:       "cancellation_survey_answers",
+		PrimaryKey: []string{"response_id", "question_id"},
+		Columns: map[string]string{
+			"response_id": "STRING(36) NOT NULL",
+			"question_id": "STRING(255) NOT NULL",
+			"kind":        "STRING(50) NOT NULL",
+			"value":       "STRING(MAX) NOT NULL",
+		},
+	},
+	{
+		Name:       "retention_offers",
+		PrimaryKey: []string{"id"},
+		Columns: map[string]string{
+			"id":                  "STRING(36) NOT NULL",
+			"subscription_id":     "STRING(255) NOT NULL",
+			"customer_id":         "STRING(255) NOT NULL",
+			"plan_id":             "STRING(255) NOT NULL",
+			"rule":                "STRING(255) NOT NULL",
+			"kind":                "STRING(50) NOT NULL",
+			"percent_off_bp":      "INT64 NOT NULL",
+			"offer_plan_id":       "STRING(255)",
+			"offer_price_cents":   "INT64 NOT NULL",
+			"status":              "STRING(50) NOT NULL",
+			"credit_amount_cents": "INT64 NOT NULL",
+			"presented_at":        "TIMESTAMP NOT NULL",
+			"expires_at":          "TIMESTAMP NOT NULL",
+			"responded_at":        "TIMESTAMP",
+		},
+		Indexes: []integration.IndexSpec{
+			{Name: "idx_retention_offers_subscription_status", Columns: []string{"subscription_id", "status", "responded_at"}},
+			{Name: "idx_retention_offers_customer_id", Columns: []string{"customer_id"}},
+		},
+	},
+	{
+		Name:       "subscription_templates",
+		PrimaryKey: []string{"id"},
+		Columns: map[string]string{
+			"id":          "STRING(36) NOT NULL",
+			"name":        "STRING(100) NOT NULL",
+			"plan_id":     "STRING(255) NOT NULL",
+			"price_cents": "INT64 NOT NULL",
+			"trial_days":  "INT64 NOT NULL",
+			"created_at":  "TIMESTAMP NOT NULL",
+		},
+		Indexes: []integration.IndexSpec{
+			{Name: "idx_subscription_templates_name", Columns: []string{"name"}, Unique: true},
+		},
+	},
+	{
+		Name:       "subscription_template_add_ons",
+		PrimaryKey: []string{"template_id", "add_on_id"},
+		Columns: map[string]string{
+			"template_id":      "STRING(36) NOT NULL",
+			"add_on_id":        "STRING(255) NOT NULL",
+			"name":             "STRING(255) NOT NULL",
+			"quantity":         "INT64 NOT NULL",
+			"unit_price_cents": "INT64 NOT NULL",
+		},
+	},
+	{
+		Name:       "subscription_template_metadata",
+		PrimaryKey: []string{"template_id", "key"},
+		Columns: map[string]string{
+			"template_id": "STRING(36) NOT NULL",
+			"key":         "STRING(40) NOT NULL",
+			"value":       "STRING(MAX) NOT NULL",
+		},
+	},
+	{
+		Name:       "subscription_add_ons",
+		PrimaryKey: []string{"subscription_id", "add_on_id"},
+		Columns: map[string]string{
+			"subscription_id":  "STRING(255) NOT NULL",
+			"add_on_id":        "STRING(255) NOT NULL",
+			"name":             "STRING(255) NOT NULL",
+			"quantity":         "INT64 NOT NULL",
+			"unit_price_cents": "INT64 NOT NULL",
+		},
+	},
+	{
+		Name:       "subscription_metadata",
+		PrimaryKey: []string{"subscription_id", "key"},
+		Columns: map[string]string{
+			"subscription_id": "STRING(255) NOT NULL",
+			"key":             "STRING(40) NOT NULL",
+			"value":           "STRING(MAX) NOT NULL",
+		},
+	},
+	{
+		Name:       "refund_outbox",
+		PrimaryKey: []string{"id"},
+		Columns: map[string]string{
+			"id":              "STRING(36) NOT NULL",
+			"subscription_id": "STRING(255) NOT NULL",
+			"customer_id":     "STRING(255) NOT NULL",
+			"plan_id":         "STRING(255) NOT NULL",
+			"amount_cents":    "INT64 NOT NULL",
+			"currency":        "STRING(3) NOT NULL",
+			"reason":          "STRING(32)",
+			"idempotency_key": "STRING(255) NOT NULL",
+			"correlation_id":  "STRING(255)",
+			"attempts":        "INT64 NOT NULL",
+			"last_error":      "STRING(MAX)",
+			"queued_at":       "TIMESTAMP NOT NULL",
+			"next_attempt_at": "TIMESTAMP NOT NULL",
+			"status":          "STRING(16)",
+		},
+		Indexes: []integration.IndexSpec{
+			{Name: "idx_refund_outbox_next_attempt_at", Columns: []string{"next_attempt_at"}},
+			{Name: "idx_refund_outbox_customer_id", Columns: []string{"customer_id"}},
+		},
+	},
+	{
+		Name:       "idempotency_keys",
+		PrimaryKey: []string{"customer_id", "idempotency_key"},
+		Columns: map[string]string{
+			"customer_id":     "STRING(255) NOT NULL",
+			"idempotency_key": "STRING(255) NOT NULL",
+			"subscription_id": "STRING(255) NOT NULL",
+			"created_at":      "TIMESTAMP NOT NULL",
+		},
+	},
+	{
+		Name:       "coupons",
+		PrimaryKey: []string{"code"},
+		Columns: map[string]string{
+			"code":             "STRING(64) NOT NULL",
+			"percent_off":      "INT64",
+			"amount_off_cents": "INT64",
+			"duration":         "STRING(16) NOT NULL",
+			"duration_periods": "INT64",
+			"max_redemptions":  "INT64",
+			"expires_at":       "TIMESTAMP",
+			"times_redeemed":   "INT64 NOT NULL",
+			"created_at":       "TIMESTAMP NOT NULL",
+		},
+	},
+	{
+		Name:       "coupon_redemptions",
+		PrimaryKey: []string{"coupon_code", "redemption"},
+		Columns: map[string]string{
+			"coupon_code":     "STRING(64) NOT NULL",
+			"redemption":      "INT64 NOT NULL",
+			"subscription_id": "STRING(255) NOT NULL",
+			"redeemed_at":     "TIMESTAMP NOT NULL",
+		},
+	},
+}
+
+func TestMigrations_CreateTheSchemaTheCodeExpects(t *testing.T) {
+	db := integration.Setup(t)
+
+	db.Schema(t).Assert(t, schema...)
+}
+
+func TestMigrations_EveryTableIsExpected(t *testing.T) {
+	db := integration.Setup(t)
+
+	expected := make(map[string]bool, len(schema))
+	for _, spec := range schema {
+		expected[spec.Name] = true
+	}
+	for table := range db.Schema(t).Tables {
+		assert.True(t, expected[table], "table %s isn't in the expected schema", table)
+	}
+}
+
+func TestMigrations_RecordTheSchemaVersion(t *testing.T) {
+	db := integration.Setup(t)
+
+	version, err := migrations.CurrentVersion(context.Background(), db.Client)
+
+	require.NoError(t, err)
+	assert.Equal(t, migrations.SchemaVersion, version)
+}
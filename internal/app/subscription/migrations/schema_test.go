@@ -0,0 +1,38 @@
+package migrations
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchemaVersion_MatchesNewestMigration(t *testing.T) {
+	dir, err := findMigrationsDir()
+	require.NoError(t, err)
+	files, err := getMigrationFiles(dir)
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+
+	var newest int64
+	seen := make(map[int64]string)
+	for _, file := range files {
+		version, err := migrationVersion(file)
+		require.NoError(t, err)
+		require.NotContains(t, seen, version, "%s reuses the version of %s", file, seen[version])
+		seen[version] = file
+		if version > newest {
+			newest = version
+		}
+	}
+	assert.Equal(t, newest, SchemaVersion, "bump SchemaVersion with each new migration")
+}
+
+func TestMigrationVersion(t *testing.T) {
+	version, err := migrationVersion("/migrations/009_schema_migrations.sql")
+	require.NoError(t, err)
+	assert.Equal(t, int64(9), version)
+
+	_, err = migrationVersion("schema.sql")
+	assert.ErrorContains(t, err, "must start with its version number")
+}
@@ -0,0 +1,129 @@
+// Package recovery is the last line of error handling: it turns a panic in one
+// request or one unit of worker work into an ordinary failure, logged with its stack
+// trace and counted, instead of letting it crash the process.
+package recovery
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/correlation"
+)
+
+// MetricPanics counts recovered panics, labelled by component
+const MetricPanics = "panics_total"
+
+// CorrelationHeader carries the caller's correlation ID in and the request's out
+const CorrelationHeader = "X-Correlation-ID"
+
+// ErrPanic is matched by every error produced from a recovered panic
+var ErrPanic = errors.New("panic recovered")
+
+// PanicError is a recovered panic together with the stack of the goroutine that panicked
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+func (e *PanicError) Is(target error) bool {
+	return target == ErrPanic
+}
+
+// Unwrap exposes the panic value when it is itself an error
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// Do runs fn and returns its error, or a *PanicError if fn panics. The panic is
+// logged with its stack and counted under component.
+func Do(ctx context.Context, logger *slog.Logger, metrics contracts.Metrics, component string, fn func() error) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			p := &PanicError{Value: v, Stack: debug.Stack()}
+			report(ctx, logger, metrics, component, p)
+			err = p
+		}
+	}()
+	return fn()
+}
+
+// report logs a recovered panic and increments the panic counter
+func report(ctx context.Context, logger *slog.Logger, metrics contracts.Metrics, component string, p *PanicError) {
+	logger.ErrorContext(ctx, "panic recovered",
+		slog.String("component", component),
+		slog.Any("panic", p.Value),
+		slog.String("stack", string(p.Stack)),
+	)
+	metrics.IncCounter(MetricPanics, map[string]string{"component": component})
+}
+
+// Middleware recovers panics in next. The client gets a 500 naming the request's
+// correlation ID, taken from the X-Correlation-ID header or generated, so the
+// failure can be found in the logs. http.ErrAbortHandler is re-raised, as net/http
+// uses it to abort a response deliberately.
+func Middleware(logger *slog.Logger, metrics contracts.Metrics, component string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		if id := r.Header.Get(CorrelationHeader); id != "" && correlation.ID(ctx) == "" {
+			ctx = correlation.WithID(ctx, id)
+		}
+		ctx, id := correlation.Ensure(ctx)
+		w.Header().Set(CorrelationHeader, id)
+
+		rec := &responseRecorder{ResponseWriter: w}
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+			if v == http.ErrAbortHandler {
+				panic(v)
+			}
+			report(ctx, logger, metrics, component, &PanicError{Value: v, Stack: debug.Stack()})
+			if rec.wroteHeader {
+				// Too late for a 500; abort so the client sees a broken response, not a truncated success
+				panic(http.ErrAbortHandler)
+			}
+			writeInternalError(w, id)
+		}()
+
+		next.ServeHTTP(rec, r.WithContext(ctx))
+	})
+}
+
+// writeInternalError sends the JSON body clients get for an unexpected failure
+func writeInternalError(w http.ResponseWriter, correlationID string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusInternalServerError)
+	json.NewEncoder(w).Encode(map[string]string{
+		"error":          "internal_error",
+		"correlation_id": correlationID,
+	})
+}
+
+// responseRecorder notes whether the handler has started its response
+type responseRecorder struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	r.wroteHeader = true
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	return r.ResponseWriter.Write(b)
+}
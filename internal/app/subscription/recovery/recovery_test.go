@@ -0,0 +1,124 @@
+package recovery
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/correlation"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/logging"
+)
+
+type countingMetrics struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+func (m *countingMetrics) IncCounter(name string, labels map[string]string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.counts == nil {
+		m.counts = make(map[string]int)
+	}
+	m.counts[name+"/"+labels["component"]]++
+}
+
+func (m *countingMetrics) ObserveHistogram(string, float64, map[string]string) {}
+
+func TestDo_ConvertsPanicToError(t *testing.T) {
+	var logs bytes.Buffer
+	logger, err := logging.New(&logs, "info", "json")
+	require.NoError(t, err)
+	metrics := &countingMetrics{}
+
+	ctx := correlation.WithID(context.Background(), "corr-1")
+	err = Do(ctx, logger, metrics, "renewer", func() error {
+		var m map[string]int
+		m["boom"] = 1
+		return nil
+	})
+
+	var p *PanicError
+	require.ErrorAs(t, err, &p)
+	assert.ErrorIs(t, err, ErrPanic)
+	assert.Contains(t, string(p.Stack), "recovery_test.go")
+	assert.Equal(t, 1, metrics.counts["panics_total/renewer"])
+
+	var line map[string]any
+	require.NoError(t, json.Unmarshal(logs.Bytes(), &line))
+	assert.Equal(t, "panic recovered", line["msg"])
+	assert.Equal(t, "corr-1", line["correlation_id"])
+	assert.Contains(t, line["stack"], "recovery_test.go")
+}
+
+func TestDo_PassesThroughErrors(t *testing.T) {
+	metrics := &countingMetrics{}
+	failure := errors.New("declined")
+
+	err := Do(context.Background(), logging.Discard(), metrics, "dunning", func() error { return failure })
+
+	assert.Equal(t, failure, err)
+	assert.Empty(t, metrics.counts)
+}
+
+func TestDo_UnwrapsPanickedError(t *testing.T) {
+	failure := errors.New("bad state")
+
+	err := Do(context.Background(), logging.Discard(), &countingMetrics{}, "dunning", func() error { panic(failure) })
+
+	assert.ErrorIs(t, err, failure)
+	assert.ErrorIs(t, err, ErrPanic)
+}
+
+func TestMiddleware_Returns500WithCorrelationID(t *testing.T) {
+	metrics := &countingMetrics{}
+	handler := Middleware(logging.Discard(), metrics, "api", http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic("nil subscription")
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/subscriptions", nil)
+	req.Header.Set(CorrelationHeader, "corr-7")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Equal(t, "corr-7", rec.Header().Get(CorrelationHeader))
+	assert.JSONEq(t, `{"error":"internal_error","correlation_id":"corr-7"}`, rec.Body.String())
+	assert.Equal(t, 1, metrics.counts["panics_total/api"])
+}
+
+func TestMiddleware_GeneratesCorrelationID(t *testing.T) {
+	var seen string
+	handler := Middleware(logging.Discard(), &countingMetrics{}, "api", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = correlation.ID(r.Context())
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.NotEmpty(t, seen)
+	assert.Equal(t, seen, rec.Header().Get(CorrelationHeader))
+}
+
+func TestMiddleware_AbortsResponseAlreadyStarted(t *testing.T) {
+	metrics := &countingMetrics{}
+	handler := Middleware(logging.Discard(), metrics, "api", http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		panic("half written")
+	}))
+
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+	assert.Equal(t, 1, metrics.counts["panics_total/api"])
+}
@@ -0,0 +1,214 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"google.golang.org/api/iterator"
+)
+
+var (
+	_ contracts.AuditLog            = (*AuditRepo)(nil)
+	_ contracts.AuditLogReader      = (*AuditRepo)(nil)
+	_ contracts.AuditLogPartitioner = (*AuditRepo)(nil)
+)
+
+const auditColumns = "id, action, principal, source_ip, payload_hash, outcome, error, correlation_id, occurred_at"
+
+// AuditRepo stores the security audit trail in Cloud Spanner. Entries are only ever
+// inserted.
+type AuditRepo struct {
+	client *spanner.Client
+	opts   options
+}
+
+// NewAuditRepo creates a new audit repository
+func NewAuditRepo(client *spanner.Client, opts ...Option) *AuditRepo {
+	return &AuditRepo{client: client, opts: newOptions(opts)}
+}
+
+// Record appends an entry to the audit trail
+func (r *AuditRepo) Record(ctx context.Context, entry contracts.AuditEntry) (err error) {
+	ctx, end, err := r.opts.begin(ctx, "audit.Record")
+	defer end(&err)
+	if err != nil {
+		return err
+	}
+
+	mutation := spanner.Insert("admin_audit",
+		[]string{"id", "action", "principal", "source_ip", "payload_hash", "outcome", "error", "correlation_id", "occurred_at"},
+		[]any{
+			entry.ID,
+			entry.Action,
+			entry.Principal,
+			spanner.NullString{StringVal: entry.SourceIP, Valid: entry.SourceIP != ""},
+			entry.PayloadHash,
+			string(entry.Outcome),
+			spanner.NullString{StringVal: entry.Error, Valid: entry.Error != ""},
+			spanner.NullString{StringVal: entry.CorrelationID, Valid: entry.CorrelationID != ""},
+			entry.OccurredAt,
+		})
+
+	_, err = r.client.Apply(ctx, []*spanner.Mutation{mutation}, r.opts.applyOptions()...)
+	return err
+}
+
+// ListAuditEntries returns up to limit entries after (afterTime, afterID), oldest first
+func (r *AuditRepo) ListAuditEntries(ctx context.Context, afterTime time.Time, afterID string, limit int) (_ []contracts.AuditEntry, err error) {
+	const op = "audit.ListAuditEntries"
+	stmt := spanner.Statement{
+		SQL: `
+			SELECT ` + auditColumns + `
+			FROM ` + r.opts.from(op, "admin_audit") + `
+			WHERE occurred_at > @after_time
+			   OR (occurred_at = @after_time AND id > @after_id)
+			ORDER BY occurred_at, id
+			LIMIT @limit
+		`,
+		Params: map[string]any{
+			"after_time": afterTime,
+			"after_id":   afterID,
+			"limit":      int64(limit),
+		},
+	}
+
+	ctx, end, err := r.opts.begin(ctx, op)
+	defer end(&err)
+	if err != nil {
+		return nil, err
+	}
+
+	iter := r.opts.single(r.client).Query(ctx, r.opts.hinted(op, stmt))
+	defer iter.Stop()
+
+	var entries []contracts.AuditEntry
+	for {
+		row, err := iter.Next()
+		if err == iterator.Done {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		entry, err := scanAuditEntry(row)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+}
+
+// PartitionAuditEntries opens a batch read-only transaction at the current time and
+// partitions the query for the entries after (afterTime, afterID). The partitions
+// carry no order, so the query may use any plan Spanner can split.
+func (r *AuditRepo) PartitionAuditEntries(ctx context.Context, afterTime time.Time, afterID string) (_ contracts.AuditLogSnapshot, err error) {
+	const op = "audit.PartitionAuditEntries"
+	stmt := spanner.Statement{
+		SQL: `
+			SELECT ` + auditColumns + `
+			FROM ` + r.opts.from(op, "admin_audit") + `
+			WHERE occurred_at > @after_time
+			   OR (occurred_at = @after_time AND id > @after_id)
+		`,
+		Params: map[string]any{
+			"after_time": afterTime,
+			"after_id":   afterID,
+		},
+	}
+
+	ctx, end, err := r.opts.begin(ctx, op)
+	defer end(&err)
+	if err != nil {
+		return nil, err
+	}
+
+	txn, err := r.client.BatchReadOnlyTransaction(ctx, spanner.StrongRead())
+	if err != nil {
+		return nil, err
+	}
+	ts, err := txn.Timestamp()
+	if err != nil {
+		txn.Cleanup(context.WithoutCancel(ctx))
+		return nil, err
+	}
+	partitions, err := txn.PartitionQueryWithOptions(ctx, r.opts.hinted(op, stmt), spanner.PartitionOptions{}, r.opts.queryOptions())
+	if err != nil {
+		txn.Cleanup(context.WithoutCancel(ctx))
+		return nil, err
+	}
+
+	// A partition is read whole however long it takes, so the timeout bounding single
+	// operations doesn't apply to it
+	readOpts := r.opts
+	readOpts.timeout = 0
+	return &auditSnapshot{txn: txn, timestamp: ts, partitions: partitions, opts: readOpts}, nil
+}
+
+// auditSnapshot reads the partitions of one batch read-only transaction
+type auditSnapshot struct {
+	txn        *spanner.BatchReadOnlyTransaction
+	timestamp  time.Time
+	partitions []*spanner.Partition
+	opts       options
+}
+
+func (s *auditSnapshot) Timestamp() time.Time {
+	return s.timestamp
+}
+
+func (s *auditSnapshot) Partitions() int {
+	return len(s.partitions)
+}
+
+// ReadPartition calls fn with each entry of the partition, stopping at fn's first error
+func (s *auditSnapshot) ReadPartition(ctx context.Context, partition int, fn func(contracts.AuditEntry) error) (err error) {
+	ctx, end, err := s.opts.begin(ctx, "audit.ReadAuditPartition")
+	defer end(&err)
+	if err != nil {
+		return err
+	}
+
+	iter := s.txn.Execute(ctx, s.partitions[partition])
+	defer iter.Stop()
+	for {
+		row, err := iter.Next()
+		if err == iterator.Done {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		entry, err := scanAuditEntry(row)
+		if err != nil {
+			return err
+		}
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+}
+
+// Close ends the batch read-only transaction
+func (s *auditSnapshot) Close() {
+	s.txn.Cleanup(context.Background())
+}
+
+// scanAuditEntry reads a row of auditColumns
+func scanAuditEntry(row *spanner.Row) (contracts.AuditEntry, error) {
+	var (
+		entry                            contracts.AuditEntry
+		outcome                          string
+		sourceIP, errText, correlationID spanner.NullString
+	)
+	if err := row.Columns(&entry.ID, &entry.Action, &entry.Principal, &sourceIP, &entry.PayloadHash, &outcome, &errText, &correlationID, &entry.OccurredAt); err != nil {
+		return contracts.AuditEntry{}, err
+	}
+	entry.Outcome = contracts.AuditOutcome(outcome)
+	entry.SourceIP = sourceIP.StringVal
+	entry.Error = errText.StringVal
+	entry.CorrelationID = correlationID.StringVal
+	return entry, nil
+}
@@ -0,0 +1,129 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"google.golang.org/api/iterator"
+)
+
+var _ contracts.ChargeAuthenticationRepository = (*ChargeAuthenticationRepo)(nil)
+
+const authenticationColumns = "id, subscription_id, customer_id, provider_payment_id, amount_cents, credit_applied_cents, currency, action_url, period_start, status, failure_reason, requested_at, resolved_at"
+
+// ChargeAuthenticationRepo implements the charge authentication repository interface
+// using Cloud Spanner
+type ChargeAuthenticationRepo struct {
+	client *spanner.Client
+	opts   options
+}
+
+// NewChargeAuthenticationRepo creates a new charge authentication repository
+func NewChargeAuthenticationRepo(client *spanner.Client, opts ...Option) *ChargeAuthenticationRepo {
+	return &ChargeAuthenticationRepo{client: client, opts: newOptions(opts)}
+}
+
+// Save returns a mutation for persisting a charge authentication to the database
+// The mutation must be applied using Apply() method
+func (r *ChargeAuthenticationRepo) Save(ctx context.Context, a *domain.ChargeAuthentication) (*spanner.Mutation, error) {
+	mutation := spanner.InsertOrUpdate("charge_authentications",
+		[]string{"id", "subscription_id", "customer_id", "provider_payment_id", "amount_cents", "credit_applied_cents", "currency", "action_url", "period_start", "status", "failure_reason", "requested_at", "resolved_at"},
+		[]any{
+			a.ID(),
+			a.SubscriptionID(),
+			a.CustomerID(),
+			a.ProviderPaymentID(),
+			a.Amount(),
+			a.CreditApplied(),
+			a.Currency(),
+			a.ActionURL(),
+			a.PeriodStart(),
+			string(a.Status()),
+			spanner.NullString{StringVal: a.FailureReason(), Valid: a.FailureReason() != ""},
+			a.RequestedAt(),
+			nullTime(a.ResolvedAt()),
+		})
+
+	return mutation, nil
+}
+
+// Apply applies the given mutations to the database
+func (r *ChargeAuthenticationRepo) Apply(ctx context.Context, mutations ...*spanner.Mutation) (err error) {
+	ctx, end, err := r.opts.begin(ctx, "charge_authentications.Apply")
+	defer end(&err)
+	if err != nil {
+		return err
+	}
+
+	err = r.opts.apply(ctx, r.client, mutations)
+	return err
+}
+
+// FindByProviderPaymentID retrieves a charge authentication by the billing provider's payment ID
+func (r *ChargeAuthenticationRepo) FindByProviderPaymentID(ctx context.Context, providerPaymentID string) (_ *domain.ChargeAuthentication, err error) {
+	stmt := spanner.Statement{
+		SQL:    `SELECT ` + authenticationColumns + ` FROM charge_authentications WHERE provider_payment_id = @provider_payment_id`,
+		Params: map[string]any{"provider_payment_id": providerPaymentID},
+	}
+
+	ctx, end, err := r.opts.begin(ctx, "charge_authentications.FindByProviderPaymentID")
+	defer end(&err)
+	if err != nil {
+		return nil, err
+	}
+
+	iter := r.opts.single(r.client).Query(ctx, stmt)
+	defer iter.Stop()
+
+	row, err := iter.Next()
+	if err != nil {
+		if err == iterator.Done {
+			return nil, domain.ErrAuthenticationNotFound
+		}
+		return nil, err
+	}
+
+	return scanAuthentication(row)
+}
+
+// scanAuthentication maps a row selected with authenticationColumns to the entity
+func scanAuthentication(row *spanner.Row) (*domain.ChargeAuthentication, error) {
+	var (
+		id                string
+		subscriptionID    string
+		customerID        string
+		providerPaymentID string
+		amountCents       int64
+		creditCents       int64
+		currency          string
+		actionURL         string
+		periodStart       time.Time
+		status            string
+		failureReason     spanner.NullString
+		requestedAt       time.Time
+		resolvedAt        spanner.NullTime
+	)
+
+	if err := row.Columns(&id, &subscriptionID, &customerID, &providerPaymentID, &amountCents, &creditCents, &currency, &actionURL, &periodStart, &status, &failureReason, &requestedAt, &resolvedAt); err != nil {
+		return nil, err
+	}
+
+	return domain.ReconstructChargeAuthentication(
+		id,
+		subscriptionID,
+		customerID,
+		providerPaymentID,
+		amountCents,
+		creditCents,
+		currency,
+		actionURL,
+		periodStart,
+		domain.AuthenticationStatus(status),
+		failureReason.StringVal,
+		requestedAt,
+		resolvedAt.Time,
+	), nil
+}
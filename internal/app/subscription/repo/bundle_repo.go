@@ -0,0 +1,122 @@
+package repo
+
+import (
+	"context"
+
+	"cloud.google.com/go/spanner"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+var _ contracts.SubscriptionBundleRepository = (*BundleRepo)(nil)
+
+// bundleTables names the tables holding the add-ons and metadata of a bundle's owner,
+// a subscription or a template, keyed by the owner's ID
+type bundleTables struct {
+	addOns   string
+	metadata string
+	key      string
+}
+
+var (
+	subscriptionBundleTables = bundleTables{addOns: "subscription_add_ons", metadata: "subscription_metadata", key: "subscription_id"}
+	templateBundleTables     = bundleTables{addOns: "subscription_template_add_ons", metadata: "subscription_template_metadata", key: "template_id"}
+)
+
+// insert returns the mutations writing the bundle's rows for ownerID
+func (t bundleTables) insert(ownerID string, bundle domain.SubscriptionBundle) []*spanner.Mutation {
+	var mutations []*spanner.Mutation
+	for _, a := range bundle.AddOns {
+		mutations = append(mutations, spanner.InsertOrUpdate(t.addOns,
+			[]string{t.key, "add_on_id", "name", "quantity", "unit_price_cents"},
+			[]any{ownerID, a.ID, a.Name, a.Quantity, a.UnitPrice},
+		))
+	}
+	for k, v := range bundle.Metadata {
+		mutations = append(mutations, spanner.InsertOrUpdate(t.metadata,
+			[]string{t.key, "key", "value"},
+			[]any{ownerID, k, v},
+		))
+	}
+	return mutations
+}
+
+// replace returns the mutations deleting ownerID's rows, then writing the bundle's
+func (t bundleTables) replace(ownerID string, bundle domain.SubscriptionBundle) []*spanner.Mutation {
+	owned := spanner.Key{ownerID}.AsPrefix()
+	return append([]*spanner.Mutation{
+		spanner.Delete(t.addOns, owned),
+		spanner.Delete(t.metadata, owned),
+	}, t.insert(ownerID, bundle)...)
+}
+
+// read returns ownerID's bundle, with add-ons ordered by ID
+func (t bundleTables) read(ctx context.Context, txn readOnly, ownerID string) (domain.SubscriptionBundle, error) {
+	var bundle domain.SubscriptionBundle
+	params := map[string]any{"owner_id": ownerID}
+
+	err := query(ctx, txn, spanner.Statement{
+		SQL:    `SELECT add_on_id, name, quantity, unit_price_cents FROM ` + t.addOns + ` WHERE ` + t.key + ` = @owner_id ORDER BY add_on_id`,
+		Params: params,
+	}, func(row *spanner.Row) error {
+		var a domain.AddOnCharge
+		if err := row.Columns(&a.ID, &a.Name, &a.Quantity, &a.UnitPrice); err != nil {
+			return err
+		}
+		bundle.AddOns = append(bundle.AddOns, a)
+		return nil
+	})
+	if err != nil {
+		return domain.SubscriptionBundle{}, err
+	}
+
+	err = query(ctx, txn, spanner.Statement{
+		SQL:    `SELECT key, value FROM ` + t.metadata + ` WHERE ` + t.key + ` = @owner_id`,
+		Params: params,
+	}, func(row *spanner.Row) error {
+		var k, v string
+		if err := row.Columns(&k, &v); err != nil {
+			return err
+		}
+		if bundle.Metadata == nil {
+			bundle.Metadata = make(map[string]string)
+		}
+		bundle.Metadata[k] = v
+		return nil
+	})
+	if err != nil {
+		return domain.SubscriptionBundle{}, err
+	}
+	return bundle, nil
+}
+
+// BundleRepo implements the subscription bundle repository interface using Cloud Spanner
+type BundleRepo struct {
+	client *spanner.Client
+	opts   options
+}
+
+// NewBundleRepo creates a new subscription bundle repository
+func NewBundleRepo(client *spanner.Client, opts ...Option) *BundleRepo {
+	return &BundleRepo{client: client, opts: newOptions(opts)}
+}
+
+// Save returns the mutations replacing the subscription's add-ons and metadata
+// Commit them in the same unit of work as the subscription's own mutation, so an
+// add-on is never stored, or dropped, without the subscription it is billed on.
+func (r *BundleRepo) Save(ctx context.Context, subscriptionID string, bundle domain.SubscriptionBundle) ([]*spanner.Mutation, error) {
+	return subscriptionBundleTables.replace(subscriptionID, bundle), nil
+}
+
+// FindBySubscriptionID retrieves the subscription's add-ons and metadata
+func (r *BundleRepo) FindBySubscriptionID(ctx context.Context, subscriptionID string) (_ domain.SubscriptionBundle, err error) {
+	ctx, end, err := r.opts.begin(ctx, "subscription_bundles.FindBySubscriptionID")
+	defer end(&err)
+	if err != nil {
+		return domain.SubscriptionBundle{}, err
+	}
+
+	txn := r.opts.readOnlyTransaction(r.client)
+	defer txn.Close()
+	return subscriptionBundleTables.read(ctx, txn, subscriptionID)
+}
@@ -0,0 +1,125 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+)
+
+var _ contracts.CouponRepository = (*CouponRepo)(nil)
+
+// CouponRepo implements the coupon repository interface using Cloud Spanner
+type CouponRepo struct {
+	client *spanner.Client
+	opts   options
+}
+
+// NewCouponRepo creates a new coupon repository
+func NewCouponRepo(client *spanner.Client, opts ...Option) *CouponRepo {
+	return &CouponRepo{client: client, opts: newOptions(opts)}
+}
+
+// Insert saves a new coupon. It is inserted, not upserted, so a code that is taken
+// fails with domain.ErrCouponAlreadyExists.
+func (r *CouponRepo) Insert(ctx context.Context, coupon *domain.Coupon) (err error) {
+	ctx, end, err := r.opts.begin(ctx, "coupons.Insert")
+	defer end(&err)
+	if err != nil {
+		return err
+	}
+
+	terms := coupon.Terms()
+	mutation := spanner.Insert("coupons",
+		[]string{"code", "percent_off", "amount_off_cents", "duration", "duration_periods", "max_redemptions", "expires_at", "times_redeemed", "created_at"},
+		[]any{
+			coupon.Code(),
+			nullPositive(terms.PercentOff),
+			nullPositive(terms.AmountOff),
+			string(terms.Duration),
+			nullPositive(terms.Periods),
+			nullPositive(terms.MaxRedemptions),
+			nullTime(terms.ExpiresAt),
+			coupon.TimesRedeemed(),
+			coupon.CreatedAt(),
+		})
+	_, err = r.client.Apply(ctx, []*spanner.Mutation{mutation}, r.opts.applyOptions()...)
+	if spanner.ErrCode(err) == codes.AlreadyExists {
+		return domain.ErrCouponAlreadyExists
+	}
+	return err
+}
+
+// SaveRedemption returns the mutations counting the coupon's latest redemption and
+// inserting it under its number
+// Apply them with the subscription that redeems the coupon: the redemption number is
+// the row's key, so of two creates redeeming the same number, only one commits.
+func (r *CouponRepo) SaveRedemption(ctx context.Context, coupon *domain.Coupon, subscriptionID string, redeemedAt time.Time) ([]*spanner.Mutation, error) {
+	return []*spanner.Mutation{
+		spanner.Update("coupons", []string{"code", "times_redeemed"}, []any{coupon.Code(), coupon.TimesRedeemed()}),
+		spanner.Insert("coupon_redemptions",
+			[]string{"coupon_code", "redemption", "subscription_id", "redeemed_at"},
+			[]any{coupon.Code(), coupon.TimesRedeemed(), subscriptionID, redeemedAt},
+		),
+	}, nil
+}
+
+// FindByCode retrieves a coupon by its code
+func (r *CouponRepo) FindByCode(ctx context.Context, code string) (_ *domain.Coupon, err error) {
+	ctx, end, err := r.opts.begin(ctx, "coupons.FindByCode")
+	defer end(&err)
+	if err != nil {
+		return nil, err
+	}
+
+	iter := r.opts.single(r.client).Query(ctx, spanner.Statement{
+		SQL: `
+			SELECT code, percent_off, amount_off_cents, duration, duration_periods, max_redemptions, expires_at, times_redeemed, created_at
+			FROM coupons
+			WHERE code = @code
+		`,
+		Params: map[string]any{"code": code},
+	})
+	defer iter.Stop()
+
+	row, err := iter.Next()
+	if err == iterator.Done {
+		return nil, domain.ErrCouponNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		dbCode         string
+		percentOff     spanner.NullInt64
+		amountOff      spanner.NullInt64
+		duration       string
+		periods        spanner.NullInt64
+		maxRedemptions spanner.NullInt64
+		expiresAt      spanner.NullTime
+		timesRedeemed  int64
+		createdAt      time.Time
+	)
+	if err := row.Columns(&dbCode, &percentOff, &amountOff, &duration, &periods, &maxRedemptions, &expiresAt, &timesRedeemed, &createdAt); err != nil {
+		return nil, err
+	}
+
+	return domain.ReconstructCoupon(dbCode, domain.CouponTerms{
+		PercentOff:     percentOff.Int64,
+		AmountOff:      amountOff.Int64,
+		Duration:       domain.CouponDuration(duration),
+		Periods:        periods.Int64,
+		MaxRedemptions: maxRedemptions.Int64,
+		ExpiresAt:      expiresAt.Time,
+	}, timesRedeemed, createdAt), nil
+}
+
+// nullPositive stores zero as NULL, for the terms a coupon may not have
+func nullPositive(v int64) spanner.NullInt64 {
+	return spanner.NullInt64{Int64: v, Valid: v != 0}
+}
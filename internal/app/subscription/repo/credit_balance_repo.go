@@ -0,0 +1,57 @@
+package repo
+
+import (
+	"context"
+
+	"cloud.google.com/go/spanner"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+var _ contracts.CreditBalanceRepository = (*CreditBalanceRepo)(nil)
+
+// CreditBalanceRepo implements the credit balance repository interface using Cloud
+// Spanner, as a ledger of entries in customer_credits
+type CreditBalanceRepo struct {
+	client *spanner.Client
+	opts   options
+}
+
+// NewCreditBalanceRepo creates a new credit balance repository
+func NewCreditBalanceRepo(client *spanner.Client, opts ...Option) *CreditBalanceRepo {
+	return &CreditBalanceRepo{client: client, opts: newOptions(opts)}
+}
+
+// Save returns a mutation for persisting a balance entry. Apply it together with the
+// record that moved the balance, so neither is saved without the other.
+func (r *CreditBalanceRepo) Save(ctx context.Context, entry *domain.CreditEntry) (*spanner.Mutation, error) {
+	mutation := spanner.InsertOrUpdate("customer_credits",
+		[]string{"id", "customer_id", "amount_cents", "currency", "source", "source_id", "created_at"},
+		[]any{
+			entry.ID(),
+			entry.CustomerID(),
+			entry.Amount(),
+			entry.Currency(),
+			string(entry.Source()),
+			entry.SourceID(),
+			entry.CreatedAt(),
+		})
+
+	return mutation, nil
+}
+
+// Balance returns the customer's credit balance, zero if they never had credit
+func (r *CreditBalanceRepo) Balance(ctx context.Context, customerID string) (_ int64, err error) {
+	stmt := spanner.Statement{
+		SQL:    `SELECT COALESCE(SUM(amount_cents), 0) FROM customer_credits WHERE customer_id = @customer_id`,
+		Params: map[string]any{"customer_id": customerID},
+	}
+
+	ctx, end, err := r.opts.begin(ctx, "customer_credits.Balance")
+	defer end(&err)
+	if err != nil {
+		return 0, err
+	}
+
+	return querySum(ctx, r.opts.single(r.client), stmt)
+}
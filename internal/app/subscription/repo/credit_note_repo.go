@@ -0,0 +1,155 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"google.golang.org/api/iterator"
+)
+
+var _ contracts.CreditNoteRepository = (*CreditNoteRepo)(nil)
+
+const creditNoteColumns = "id, customer_id, subscription_id, invoice_id, amount_cents, currency, reason, memo, settlement, provider_refund_id, issued_at"
+
+// CreditNoteRepo implements the credit note repository interface using Cloud Spanner
+type CreditNoteRepo struct {
+	client *spanner.Client
+	opts   options
+}
+
+// NewCreditNoteRepo creates a new credit note repository
+func NewCreditNoteRepo(client *spanner.Client, opts ...Option) *CreditNoteRepo {
+	return &CreditNoteRepo{client: client, opts: newOptions(opts)}
+}
+
+// Save returns a mutation for persisting a credit note to the database
+// The mutation must be applied using Apply() method
+func (r *CreditNoteRepo) Save(ctx context.Context, note *domain.CreditNote) (*spanner.Mutation, error) {
+	mutation := spanner.InsertOrUpdate("credit_notes",
+		[]string{"id", "customer_id", "subscription_id", "invoice_id", "amount_cents", "currency", "reason", "memo", "settlement", "provider_refund_id", "issued_at"},
+		[]any{
+			note.ID(),
+			note.CustomerID(),
+			note.SubscriptionID(),
+			note.InvoiceID(),
+			note.Amount(),
+			note.Currency(),
+			string(note.Reason()),
+			spanner.NullString{StringVal: note.Memo(), Valid: note.Memo() != ""},
+			string(note.Settlement()),
+			spanner.NullString{StringVal: note.ProviderRefundID(), Valid: note.ProviderRefundID() != ""},
+			note.IssuedAt(),
+		})
+
+	return mutation, nil
+}
+
+// Apply applies the given mutations to the database in one transaction
+func (r *CreditNoteRepo) Apply(ctx context.Context, mutations ...*spanner.Mutation) (err error) {
+	ctx, end, err := r.opts.begin(ctx, "credit_notes.Apply")
+	defer end(&err)
+	if err != nil {
+		return err
+	}
+
+	err = r.opts.apply(ctx, r.client, mutations)
+	return err
+}
+
+// FindByID retrieves a credit note by ID
+func (r *CreditNoteRepo) FindByID(ctx context.Context, id string) (_ *domain.CreditNote, err error) {
+	stmt := spanner.Statement{
+		SQL:    `SELECT ` + creditNoteColumns + ` FROM credit_notes WHERE id = @id`,
+		Params: map[string]any{"id": id},
+	}
+
+	ctx, end, err := r.opts.begin(ctx, "credit_notes.FindByID")
+	defer end(&err)
+	if err != nil {
+		return nil, err
+	}
+
+	iter := r.opts.single(r.client).Query(ctx, stmt)
+	defer iter.Stop()
+
+	row, err := iter.Next()
+	if err != nil {
+		if err == iterator.Done {
+			return nil, domain.ErrCreditNoteNotFound
+		}
+		return nil, err
+	}
+
+	return scanCreditNote(row)
+}
+
+// CreditedForInvoice sums the credit notes issued against an invoice so far
+func (r *CreditNoteRepo) CreditedForInvoice(ctx context.Context, invoiceID string) (_ int64, err error) {
+	stmt := spanner.Statement{
+		SQL:    `SELECT COALESCE(SUM(amount_cents), 0) FROM credit_notes WHERE invoice_id = @invoice_id`,
+		Params: map[string]any{"invoice_id": invoiceID},
+	}
+
+	ctx, end, err := r.opts.begin(ctx, "credit_notes.CreditedForInvoice")
+	defer end(&err)
+	if err != nil {
+		return 0, err
+	}
+
+	return querySum(ctx, r.opts.single(r.client), stmt)
+}
+
+// querySum runs a statement selecting a single INT64 and returns it
+func querySum(ctx context.Context, txn readOnly, stmt spanner.Statement) (int64, error) {
+	iter := txn.Query(ctx, stmt)
+	defer iter.Stop()
+
+	row, err := iter.Next()
+	if err != nil {
+		return 0, err
+	}
+
+	var sum int64
+	if err := row.Columns(&sum); err != nil {
+		return 0, err
+	}
+	return sum, nil
+}
+
+// scanCreditNote maps a row selected with creditNoteColumns to the entity
+func scanCreditNote(row *spanner.Row) (*domain.CreditNote, error) {
+	var (
+		id               string
+		customerID       string
+		subscriptionID   string
+		invoiceID        string
+		amountCents      int64
+		currency         string
+		reason           string
+		memo             spanner.NullString
+		settlement       string
+		providerRefundID spanner.NullString
+		issuedAt         time.Time
+	)
+
+	if err := row.Columns(&id, &customerID, &subscriptionID, &invoiceID, &amountCents, &currency, &reason, &memo, &settlement, &providerRefundID, &issuedAt); err != nil {
+		return nil, err
+	}
+
+	return domain.ReconstructCreditNote(
+		id,
+		customerID,
+		subscriptionID,
+		invoiceID,
+		amountCents,
+		currency,
+		domain.CreditReason(reason),
+		memo.StringVal,
+		domain.CreditSettlement(settlement),
+		providerRefundID.StringVal,
+		issuedAt,
+	), nil
+}
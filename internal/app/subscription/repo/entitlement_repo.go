@@ -0,0 +1,114 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"google.golang.org/api/iterator"
+)
+
+var _ contracts.EntitlementRepository = (*EntitlementRepo)(nil)
+
+// EntitlementRepo implements the entitlement repository interface using Cloud Spanner.
+// Its reads are bounded-staleness reads, which any replica can serve without waiting
+// on the leader, so checks stay fast under load at the cost of lagging writes by up to
+// maxStaleness.
+type EntitlementRepo struct {
+	client       *spanner.Client
+	maxStaleness time.Duration
+	opts         options
+}
+
+// NewEntitlementRepo creates a new entitlement repository whose reads lag writes by
+// at most maxStaleness. Zero makes them strong reads.
+func NewEntitlementRepo(client *spanner.Client, maxStaleness time.Duration, opts ...Option) *EntitlementRepo {
+	return &EntitlementRepo{client: client, maxStaleness: maxStaleness, opts: newOptions(opts)}
+}
+
+// FindEntitledSubscriptions returns the customer's subscriptions that keep their plan's entitlements
+func (r *EntitlementRepo) FindEntitledSubscriptions(ctx context.Context, customerID string) (_ []*domain.Subscription, err error) {
+	statuses := make([]string, len(domain.EntitledStatuses))
+	for i, status := range domain.EntitledStatuses {
+		statuses[i] = string(status)
+	}
+	stmt := spanner.Statement{
+		SQL: `SELECT ` + subscriptionColumns + `
+			FROM subscriptions
+			WHERE customer_id = @customer_id AND status IN UNNEST(@statuses)`,
+		Params: map[string]any{"customer_id": customerID, "statuses": statuses},
+	}
+
+	ctx, end, err := r.opts.begin(ctx, "subscriptions.FindEntitledSubscriptions")
+	defer end(&err)
+	if err != nil {
+		return nil, err
+	}
+
+	iter := r.read().Query(ctx, stmt)
+	defer iter.Stop()
+
+	var subs []*domain.Subscription
+	for {
+		row, err := iter.Next()
+		if err == iterator.Done {
+			return subs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		sub, err := scanSubscription(row)
+		if err != nil {
+			return nil, err
+		}
+		subs = append(subs, sub)
+	}
+}
+
+// FindPlanEntitlements returns the features the plan grants
+func (r *EntitlementRepo) FindPlanEntitlements(ctx context.Context, planID string) (_ []domain.Entitlement, err error) {
+	stmt := spanner.Statement{
+		SQL:    `SELECT feature, limit_value FROM plan_entitlements WHERE plan_id = @plan_id`,
+		Params: map[string]any{"plan_id": planID},
+	}
+
+	ctx, end, err := r.opts.begin(ctx, "plan_entitlements.FindPlanEntitlements")
+	defer end(&err)
+	if err != nil {
+		return nil, err
+	}
+
+	iter := r.read().Query(ctx, stmt)
+	defer iter.Stop()
+
+	var entitlements []domain.Entitlement
+	for {
+		row, err := iter.Next()
+		if err == iterator.Done {
+			return entitlements, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var (
+			feature string
+			limit   spanner.NullInt64
+		)
+		if err := row.Columns(&feature, &limit); err != nil {
+			return nil, err
+		}
+		entitlements = append(entitlements, domain.Entitlement{Feature: feature, Limit: limit.Int64, Unlimited: !limit.Valid})
+	}
+}
+
+// read returns a single-use read-only transaction at the repository's staleness
+func (r *EntitlementRepo) read() readOnly {
+	if r.maxStaleness <= 0 {
+		return r.opts.single(r.client)
+	}
+	return r.opts.prioritize(r.client.Single().WithTimestampBound(spanner.MaxStaleness(r.maxStaleness)))
+}
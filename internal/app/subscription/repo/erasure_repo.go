@@ -0,0 +1,172 @@
+package repo
+
+import (
+	"context"
+
+	"cloud.google.com/go/spanner"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+var _ contracts.ErasureRepository = (*ErasureRepo)(nil)
+
+// ErasureRepo implements the erasure repository interface using Cloud Spanner
+type ErasureRepo struct {
+	client *spanner.Client
+	opts   options
+}
+
+// NewErasureRepo creates a new erasure repository
+func NewErasureRepo(client *spanner.Client, opts ...Option) *ErasureRepo {
+	return &ErasureRepo{client: client, opts: newOptions(opts)}
+}
+
+// CountLiveByCustomer counts the customer's subscriptions that are not cancelled
+func (r *ErasureRepo) CountLiveByCustomer(ctx context.Context, customerID string) (_ int64, err error) {
+	stmt := spanner.Statement{
+		SQL: `SELECT COUNT(*) FROM subscriptions WHERE customer_id = @customer_id AND status != @cancelled`,
+		Params: map[string]any{
+			"customer_id": customerID,
+			"cancelled":   string(domain.StatusCancelled),
+		},
+	}
+
+	ctx, end, err := r.opts.begin(ctx, "erasure.CountLiveByCustomer")
+	defer end(&err)
+	if err != nil {
+		return 0, err
+	}
+
+	iter := r.opts.single(r.client).Query(ctx, stmt)
+	defer iter.Stop()
+
+	row, err := iter.Next()
+	if err != nil {
+		return 0, err
+	}
+
+	var count int64
+	if err := row.Columns(&count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// customerColumn is a table column holding customer IDs
+type customerColumn struct {
+	table  string
+	column string
+}
+
+// customerColumns lists every column holding customer IDs. Referrals name two
+// customers, so erasing either one rewrites their side of the row.
+var customerColumns = []customerColumn{
+	{"subscriptions", "customer_id"},
+	{"refunds", "customer_id"},
+	{"credit_notes", "customer_id"},
+	{"customer_credits", "customer_id"},
+	{"referral_codes", "customer_id"},
+	{"referral_credits", "customer_id"},
+	{"referral_credits", "referrer_customer_id"},
+	{"usage_records", "customer_id"},
+	{"charge_authentications", "customer_id"},
+	{"cancellation_survey_responses", "customer_id"},
+	{"retention_offers", "customer_id"},
+	{"refund_outbox", "customer_id"},
+}
+
+// name is how the column is reported: the table alone for customer_id
+func (c customerColumn) name() string {
+	if c.column == "customer_id" {
+		return c.table
+	}
+	return c.table + "." + c.column
+}
+
+// freeTextRows selects the customer's rows of free text, which may name them, so they
+// are deleted rather than kept under the tombstone, and their idempotency keys, whose
+// primary key holds their ID. Each selects by @customer_id, so they are deleted before
+// the customer columns are rewritten.
+var freeTextRows = []struct {
+	table  string
+	where  string
+	params map[string]any
+}{
+	{
+		table: "cancellation_survey_answers",
+		where: `kind = @text
+			AND response_id IN (SELECT id FROM cancellation_survey_responses WHERE customer_id = @customer_id)`,
+		params: map[string]any{"text": string(domain.SurveyText)},
+	},
+	{
+		table: "subscription_metadata",
+		where: `subscription_id IN (SELECT id FROM subscriptions WHERE customer_id = @customer_id)`,
+	},
+	{
+		table: "idempotency_keys",
+		where: `customer_id = @customer_id`,
+	},
+}
+
+// freeTextColumns are columns of the customer's rows holding free text, which may name
+// them, so they are cleared; the rows themselves are kept under the tombstone. Each is
+// cleared by customer_id before the customer columns are rewritten.
+var freeTextColumns = []customerColumn{
+	{"subscriptions", "cancellation_reason_details"},
+}
+
+// TombstoneCustomer rewrites the customer ID in every customer column, deletes the
+// customer's free text survey answers and subscription metadata and clears their
+// cancellation reason details, in a single read-write transaction
+func (r *ErasureRepo) TombstoneCustomer(ctx context.Context, customerID, tombstone string) (_ []contracts.TombstonedRows, err error) {
+	var results []contracts.TombstonedRows
+	ctx, end, err := r.opts.begin(ctx, "erasure.TombstoneCustomer")
+	defer end(&err)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = r.client.ReadWriteTransactionWithOptions(ctx, func(ctx context.Context, txn *spanner.ReadWriteTransaction) error {
+		// The function may be retried on abort, so start from a clean slate
+		results = results[:0]
+		for _, t := range freeTextRows {
+			params := map[string]any{"customer_id": customerID}
+			for k, v := range t.params {
+				params[k] = v
+			}
+			rows, err := txn.UpdateWithOptions(ctx, spanner.Statement{
+				SQL:    `DELETE FROM ` + t.table + ` WHERE ` + t.where,
+				Params: params,
+			}, r.opts.queryOptions())
+			if err != nil {
+				return err
+			}
+			results = append(results, contracts.TombstonedRows{Table: t.table, RowsAffected: rows})
+		}
+		for _, c := range freeTextColumns {
+			rows, err := txn.UpdateWithOptions(ctx, spanner.Statement{
+				SQL:    `UPDATE ` + c.table + ` SET ` + c.column + ` = NULL WHERE customer_id = @customer_id AND ` + c.column + ` IS NOT NULL`,
+				Params: map[string]any{"customer_id": customerID},
+			}, r.opts.queryOptions())
+			if err != nil {
+				return err
+			}
+			results = append(results, contracts.TombstonedRows{Table: c.name(), RowsAffected: rows})
+		}
+		for _, c := range customerColumns {
+			rows, err := txn.UpdateWithOptions(ctx, spanner.Statement{
+				SQL: `UPDATE ` + c.table + ` SET ` + c.column + ` = @tombstone WHERE ` + c.column + ` = @customer_id`,
+				Params: map[string]any{
+					"customer_id": customerID,
+					"tombstone":   tombstone,
+				},
+			}, r.opts.queryOptions())
+			if err != nil {
+				return err
+			}
+			results = append(results, contracts.TombstonedRows{Table: c.name(), RowsAffected: rows})
+		}
+		return nil
+	}, r.opts.transactionOptions())
+	return results, err
+}
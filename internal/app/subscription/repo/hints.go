@@ -0,0 +1,115 @@
+package repo
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"cloud.google.com/go/spanner"
+)
+
+// QueryHints steer the plan Spanner picks for one query, for list queries that pick a
+// bad plan on large tables. The values are written into the SQL unquoted, so hints
+// from configuration go through ParseQueryHint.
+type QueryHints struct {
+	// ForceIndex is the index the query reads its table through, or "_BASE_TABLE"
+	// to read the table itself
+	ForceIndex string
+	// OptimizerVersion pins the query optimizer, such as "6" or "latest_version"
+	OptimizerVersion string
+	// OptimizerStatisticsPackage pins the statistics the optimizer plans with
+	OptimizerStatisticsPackage string
+}
+
+// WithQueryHints applies hints to the queries named by the map's keys, which are their
+// operation names such as "subscriptions.FindDueForCancellation". They replace the hints a
+// repository gives the query itself. Only list queries take hints; others ignore them.
+func WithQueryHints(hints map[string]QueryHints) Option {
+	return func(o *options) {
+		if o.hints == nil {
+			o.hints = make(map[string]QueryHints, len(hints))
+		}
+		for op, h := range hints {
+			o.hints[op] = h
+		}
+	}
+}
+
+var (
+	identifier     = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	optimizerValue = regexp.MustCompile(`^([0-9]+|latest_version)$`)
+)
+
+// ParseQueryHint parses a hint written as "<op> key=value...", such as
+// "subscriptions.FindDueForCancellation index=idx_status_cancel_at optimizer_version=6".
+// The keys are index, optimizer_version and statistics_package.
+func ParseQueryHint(s string) (string, QueryHints, error) {
+	fields := strings.Fields(s)
+	if len(fields) < 2 {
+		return "", QueryHints{}, fmt.Errorf("query hint %q: want an operation and at least one key=value", s)
+	}
+	var hints QueryHints
+	for _, field := range fields[1:] {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok {
+			return "", QueryHints{}, fmt.Errorf("query hint %q: %q is not key=value", s, field)
+		}
+		switch key {
+		case "index":
+			if !identifier.MatchString(value) {
+				return "", QueryHints{}, fmt.Errorf("query hint %q: invalid index %q", s, value)
+			}
+			hints.ForceIndex = value
+		case "optimizer_version":
+			if !optimizerValue.MatchString(value) {
+				return "", QueryHints{}, fmt.Errorf("query hint %q: invalid optimizer version %q", s, value)
+			}
+			hints.OptimizerVersion = value
+		case "statistics_package":
+			if !identifier.MatchString(value) {
+				return "", QueryHints{}, fmt.Errorf("query hint %q: invalid statistics package %q", s, value)
+			}
+			hints.OptimizerStatisticsPackage = value
+		default:
+			return "", QueryHints{}, fmt.Errorf("query hint %q: unknown setting %q", s, key)
+		}
+	}
+	return fields[0], hints, nil
+}
+
+// ParseQueryHints parses each hint in turn; a later hint for the same query wins
+func ParseQueryHints(specs []string) (map[string]QueryHints, error) {
+	hints := make(map[string]QueryHints, len(specs))
+	for _, spec := range specs {
+		op, h, err := ParseQueryHint(spec)
+		if err != nil {
+			return nil, err
+		}
+		hints[op] = h
+	}
+	return hints, nil
+}
+
+// from names table in the FROM clause of the query op, with its index hint
+func (o options) from(op, table string) string {
+	if index := o.hints[op].ForceIndex; index != "" {
+		return table + "@{FORCE_INDEX=" + index + "}"
+	}
+	return table
+}
+
+// hinted prefixes the statement of the query op with its statement hints
+func (o options) hinted(op string, stmt spanner.Statement) spanner.Statement {
+	h := o.hints[op]
+	var hints []string
+	if h.OptimizerVersion != "" {
+		hints = append(hints, "OPTIMIZER_VERSION="+h.OptimizerVersion)
+	}
+	if h.OptimizerStatisticsPackage != "" {
+		hints = append(hints, "OPTIMIZER_STATISTICS_PACKAGE="+h.OptimizerStatisticsPackage)
+	}
+	if len(hints) > 0 {
+		stmt.SQL = "@{" + strings.Join(hints, ", ") + "}" + stmt.SQL
+	}
+	return stmt
+}
@@ -0,0 +1,92 @@
+package repo
+
+import (
+	"context"
+	"testing"
+
+	"cloud.google.com/go/spanner"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+)
+
+// attributeTracer keeps the attributes of the spans it starts
+type attributeTracer struct {
+	attributes map[string]string
+}
+
+func (t *attributeTracer) Start(ctx context.Context, name string) (context.Context, contracts.Span) {
+	t.attributes = make(map[string]string)
+	return ctx, t
+}
+
+func (t *attributeTracer) SetAttribute(key, value string) { t.attributes[key] = value }
+func (t *attributeTracer) RecordError(error)              {}
+func (t *attributeTracer) End()                           {}
+
+func TestParseQueryHints(t *testing.T) {
+	hints, err := ParseQueryHints([]string{
+		"subscriptions.FindDueForRenewal index=idx_renewal optimizer_version=6",
+		"refunds.FindPending index=_BASE_TABLE statistics_package=auto_20240101_00_00_00UTC",
+		"subscriptions.FindDueForRenewal optimizer_version=latest_version",
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, map[string]QueryHints{
+		"subscriptions.FindDueForRenewal": {OptimizerVersion: "latest_version"},
+		"refunds.FindPending":             {ForceIndex: "_BASE_TABLE", OptimizerStatisticsPackage: "auto_20240101_00_00_00UTC"},
+	}, hints, "a later hint for the same query wins")
+}
+
+func TestParseQueryHint_RejectsUnsafeValues(t *testing.T) {
+	for _, spec := range []string{
+		"subscriptions.FindDueForRenewal",
+		"subscriptions.FindDueForRenewal index",
+		"subscriptions.FindDueForRenewal index=idx}; DROP TABLE subscriptions",
+		"subscriptions.FindDueForRenewal optimizer_version=six",
+		"subscriptions.FindDueForRenewal statistics_package=a-b",
+		"subscriptions.FindDueForRenewal join=hash",
+	} {
+		_, _, err := ParseQueryHint(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestOptions_ApplyHintsOfTheirQueryOnly(t *testing.T) {
+	o := newOptions([]Option{
+		WithQueryHints(map[string]QueryHints{"subscriptions.ListByCustomer": {ForceIndex: "idx_default"}}),
+		WithQueryHints(map[string]QueryHints{
+			"subscriptions.ListByCustomer":    {ForceIndex: "idx_override"},
+			"subscriptions.FindDueForRenewal": {OptimizerVersion: "6", OptimizerStatisticsPackage: "stats"},
+		}),
+	})
+
+	assert.Equal(t, "subscriptions@{FORCE_INDEX=idx_override}", o.from("subscriptions.ListByCustomer", "subscriptions"))
+	assert.Equal(t, "subscriptions", o.from("subscriptions.FindDueForRenewal", "subscriptions"))
+	assert.Equal(t, "@{OPTIMIZER_VERSION=6, OPTIMIZER_STATISTICS_PACKAGE=stats}SELECT 1",
+		o.hinted("subscriptions.FindDueForRenewal", spanner.Statement{SQL: "SELECT 1"}).SQL)
+	assert.Equal(t, "SELECT 1", o.hinted("subscriptions.ListByCustomer", spanner.Statement{SQL: "SELECT 1"}).SQL)
+}
+
+func TestOptions_RecordHintsInSpans(t *testing.T) {
+	tracer := &attributeTracer{}
+	o := newOptions([]Option{
+		WithTracer(tracer),
+		WithQueryHints(map[string]QueryHints{"refunds.FindPending": {ForceIndex: "idx_refunds_status_requested_at", OptimizerVersion: "6"}}),
+	})
+
+	_, end, err := o.begin(context.Background(), "refunds.FindPending")
+	require.NoError(t, err)
+	end(&err)
+
+	assert.Equal(t, map[string]string{
+		"db.system":                    "spanner",
+		"db.spanner.force_index":       "idx_refunds_status_requested_at",
+		"db.spanner.optimizer_version": "6",
+	}, tracer.attributes)
+
+	_, end, err = o.begin(context.Background(), "refunds.FindByID")
+	require.NoError(t, err)
+	end(&err)
+	assert.Equal(t, map[string]string{"db.system": "spanner"}, tracer.attributes, "queries without hints record none")
+}
@@ -0,0 +1,71 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"google.golang.org/api/iterator"
+)
+
+var _ contracts.IdempotencyKeyRepository = (*IdempotencyKeyRepo)(nil)
+
+// IdempotencyKeyRepo implements the idempotency key repository interface using Cloud Spanner
+type IdempotencyKeyRepo struct {
+	client *spanner.Client
+	opts   options
+}
+
+// NewIdempotencyKeyRepo creates a new idempotency key repository
+func NewIdempotencyKeyRepo(client *spanner.Client, opts ...Option) *IdempotencyKeyRepo {
+	return &IdempotencyKeyRepo{client: client, opts: newOptions(opts)}
+}
+
+// Save returns a mutation inserting the key
+// The mutation must be applied with the subscription's, so the key is stored only if
+// the subscription it points to is. Of two requests racing with the same key, the
+// second fails to insert it and creates nothing.
+func (r *IdempotencyKeyRepo) Save(ctx context.Context, customerID, key, subscriptionID string, createdAt time.Time) (*spanner.Mutation, error) {
+	return spanner.Insert("idempotency_keys",
+		[]string{"customer_id", "idempotency_key", "subscription_id", "created_at"},
+		[]any{customerID, key, subscriptionID, createdAt},
+	), nil
+}
+
+// FindSubscriptionID reads the subscription the customer's key created
+func (r *IdempotencyKeyRepo) FindSubscriptionID(ctx context.Context, customerID, key string) (_ string, err error) {
+	ctx, end, err := r.opts.begin(ctx, "idempotency_keys.FindSubscriptionID")
+	defer end(&err)
+	if err != nil {
+		return "", err
+	}
+
+	iter := r.opts.single(r.client).Query(ctx, spanner.Statement{
+		SQL: `
+			SELECT subscription_id
+			FROM idempotency_keys
+			WHERE customer_id = @customer_id AND idempotency_key = @key
+		`,
+		Params: map[string]any{
+			"customer_id": customerID,
+			"key":         key,
+		},
+	})
+	defer iter.Stop()
+
+	row, err := iter.Next()
+	if err != nil {
+		if err == iterator.Done {
+			return "", domain.ErrIdempotencyKeyNotFound
+		}
+		return "", err
+	}
+
+	var subscriptionID string
+	if err := row.Columns(&subscriptionID); err != nil {
+		return "", err
+	}
+	return subscriptionID, nil
+}
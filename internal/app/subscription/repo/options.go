@@ -0,0 +1,175 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"cloud.google.com/go/spanner/apiv1/spannerpb"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/faults"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/metrics"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Option configures a repository
+type Option func(*options)
+
+type options struct {
+	timeout  time.Duration
+	tracer   contracts.Tracer
+	metrics  contracts.Metrics
+	logger   *slog.Logger
+	faults   *faults.Injector
+	hints    map[string]QueryHints // by operation
+	priority spannerpb.RequestOptions_Priority
+	commit   func(mutations ...*spanner.Mutation)
+}
+
+// WithTimeout bounds every Spanner operation the repository performs, independently
+// of the caller's deadline, so a slow database can't consume the whole request budget.
+// Zero disables the bound.
+func WithTimeout(d time.Duration) Option {
+	return func(o *options) { o.timeout = d }
+}
+
+// WithTracer records a span for every Spanner operation the repository performs
+func WithTracer(t contracts.Tracer) Option {
+	return func(o *options) { o.tracer = t }
+}
+
+// WithMetrics counts failed Spanner operations in spanner_errors_total{op, code}.
+// Lookups that find nothing are not failures.
+func WithMetrics(m contracts.Metrics) Option {
+	return func(o *options) { o.metrics = m }
+}
+
+// WithLogger logs failed Spanner operations, with the request fields of their context
+func WithLogger(l *slog.Logger) Option {
+	return func(o *options) { o.logger = l }
+}
+
+// WithFaults injects latency and errors into Spanner operations, named
+// "spanner.<op>" such as "spanner.subscriptions.Apply", for resilience rehearsals.
+// Injected errors carry the Spanner code of the failure they stand for. Never set it
+// in production.
+func WithFaults(injector *faults.Injector) Option {
+	return func(o *options) { o.faults = injector }
+}
+
+// WithCommitted calls fn with the mutations of every Apply and ApplyChecked once it
+// returns, failed or not, such as adapters.CachedSubscriptions.Committed so a cache
+// learns of subscriptions saved through it but committed through this repository.
+// Mutations buffered in a transaction aren't reported.
+func WithCommitted(fn func(mutations ...*spanner.Mutation)) Option {
+	return func(o *options) { o.commit = fn }
+}
+
+func newOptions(opts []Option) options {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// begin derives the context for one Spanner operation, named op in its span, metrics
+// and logs, and returns the function that ends it. The caller defers end(&err) with its
+// named error result so a failure is recorded, then returns any error begin gave, which
+// is an injected fault. The caller's deadline still wins when it is sooner.
+func (o options) begin(ctx context.Context, op string) (context.Context, func(*error), error) {
+	var span contracts.Span
+	if o.tracer != nil {
+		ctx, span = o.tracer.Start(ctx, "spanner."+op)
+		span.SetAttribute("db.system", "spanner")
+		if h, ok := o.hints[op]; ok {
+			setHintAttributes(span, h)
+		}
+		if o.priority != spannerpb.RequestOptions_PRIORITY_UNSPECIFIED {
+			span.SetAttribute("db.spanner.priority", o.priority.String())
+		}
+	}
+	cancel := context.CancelFunc(func() {})
+	if o.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, o.timeout)
+	}
+
+	end := func(errp *error) {
+		cancel()
+		err := *errp
+		if isFailure(err) {
+			if span != nil {
+				span.RecordError(err)
+			}
+			code := spanner.ErrCode(err).String()
+			if o.metrics != nil {
+				o.metrics.IncCounter(metrics.SpannerErrors, map[string]string{"op": op, "code": code})
+			}
+			if o.logger != nil {
+				o.logger.WarnContext(ctx, "spanner operation failed", slog.String("op", op), slog.String("code", code), slog.Any("error", err))
+			}
+		}
+		if span != nil {
+			span.End()
+		}
+	}
+	return ctx, end, injectedError(o.faults.Inject(ctx, "spanner."+op))
+}
+
+// setHintAttributes records the hints a query ran with, so a plan regression can be
+// traced to them
+func setHintAttributes(span contracts.Span, h QueryHints) {
+	if h.ForceIndex != "" {
+		span.SetAttribute("db.spanner.force_index", h.ForceIndex)
+	}
+	if h.OptimizerVersion != "" {
+		span.SetAttribute("db.spanner.optimizer_version", h.OptimizerVersion)
+	}
+	if h.OptimizerStatisticsPackage != "" {
+		span.SetAttribute("db.spanner.optimizer_statistics_package", h.OptimizerStatisticsPackage)
+	}
+}
+
+// injectedError gives an injected fault the Spanner code of the failure it stands for
+func injectedError(err error) error {
+	var fault *faults.Error
+	if !errors.As(err, &fault) {
+		return err
+	}
+	code := codes.Unavailable
+	switch fault.Kind {
+	case faults.KindAborted:
+		code = codes.Aborted
+	case faults.KindTimeout:
+		code = codes.DeadlineExceeded
+	}
+	return spanner.ToSpannerError(status.Error(code, fault.Error()))
+}
+
+// isFailure reports whether err is a database failure rather than an empty lookup, or
+// a usage alert, survey response or template name recorded before, or a subscription
+// saved by another request since it was read
+func isFailure(err error) bool {
+	return err != nil &&
+		!errors.Is(err, domain.ErrSubscriptionNotFound) &&
+		!errors.Is(err, domain.ErrRefundNotFound) &&
+		!errors.Is(err, domain.ErrCreditNoteNotFound) &&
+		!errors.Is(err, domain.ErrReferralCodeNotFound) &&
+		!errors.Is(err, domain.ErrReferralNotFound) &&
+		!errors.Is(err, domain.ErrAuthenticationNotFound) &&
+		!errors.Is(err, domain.ErrRetentionOfferNotFound) &&
+		!errors.Is(err, domain.ErrTemplateNotFound) &&
+		!errors.Is(err, domain.ErrIdempotencyKeyNotFound) &&
+		!errors.Is(err, domain.ErrPlanNotFound) &&
+		!errors.Is(err, domain.ErrUsageAlertAlreadySent) &&
+		!errors.Is(err, domain.ErrSurveyAlreadySubmitted) &&
+		!errors.Is(err, domain.ErrTemplateNameTaken) &&
+		!errors.Is(err, domain.ErrPlanAlreadyExists) &&
+		!errors.Is(err, domain.ErrCouponNotFound) &&
+		!errors.Is(err, domain.ErrCouponAlreadyExists) &&
+		!errors.Is(err, domain.ErrConcurrentModification)
+}
@@ -0,0 +1,14 @@
+package repo
+
+import (
+	"context"
+
+	"cloud.google.com/go/spanner"
+)
+
+// Ping checks that the database answers a trivial query, for readiness probes
+func Ping(ctx context.Context, client *spanner.Client) error {
+	var one int64
+	return client.Single().Query(ctx, spanner.Statement{SQL: "SELECT 1"}).
+		Do(func(row *spanner.Row) error { return row.Columns(&one) })
+}
@@ -0,0 +1,166 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+)
+
+var _ contracts.PlanRepository = (*PlanRepo)(nil)
+
+const planColumns = "id, name, price_cents, currency, billing_interval, trial_days, active, external_product_id, external_price_id, created_at, updated_at"
+
+// PlanRepo implements the plan repository interface using Cloud Spanner
+type PlanRepo struct {
+	client *spanner.Client
+	opts   options
+}
+
+// NewPlanRepo creates a new plan repository
+func NewPlanRepo(client *spanner.Client, opts ...Option) *PlanRepo {
+	return &PlanRepo{client: client, opts: newOptions(opts)}
+}
+
+// Save returns a mutation for persisting a plan to the database
+// The mutation must be applied using Apply() method
+func (r *PlanRepo) Save(ctx context.Context, plan *domain.Plan) (*spanner.Mutation, error) {
+	return planMutation(spanner.InsertOrUpdate, plan), nil
+}
+
+// Insert saves a new plan. It is inserted, not upserted, so a plan ID that is taken
+// fails with domain.ErrPlanAlreadyExists.
+func (r *PlanRepo) Insert(ctx context.Context, plan *domain.Plan) (err error) {
+	ctx, end, err := r.opts.begin(ctx, "plans.Insert")
+	defer end(&err)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.client.Apply(ctx, []*spanner.Mutation{planMutation(spanner.Insert, plan)}, r.opts.applyOptions()...)
+	if spanner.ErrCode(err) == codes.AlreadyExists {
+		return domain.ErrPlanAlreadyExists
+	}
+	return err
+}
+
+// planMutation writes every column of the plan with op, an insert or upsert
+func planMutation(op func(table string, columns []string, values []any) *spanner.Mutation, plan *domain.Plan) *spanner.Mutation {
+	return op("plans",
+		[]string{"id", "name", "price_cents", "currency", "billing_interval", "trial_days", "active", "external_product_id", "external_price_id", "created_at", "updated_at"},
+		[]any{
+			plan.ID(),
+			plan.Name(),
+			plan.PriceCents(),
+			plan.Currency(),
+			nullString(string(plan.BillingInterval())),
+			plan.TrialDays(),
+			plan.Active(),
+			nullString(plan.ExternalProductID()),
+			nullString(plan.ExternalPriceID()),
+			plan.CreatedAt(),
+			plan.UpdatedAt(),
+		})
+}
+
+// FindAll returns every plan, ordered by ID
+func (r *PlanRepo) FindAll(ctx context.Context) (_ []*domain.Plan, err error) {
+	stmt := spanner.Statement{SQL: `SELECT ` + planColumns + ` FROM plans ORDER BY id`}
+
+	ctx, end, err := r.opts.begin(ctx, "plans.FindAll")
+	defer end(&err)
+	if err != nil {
+		return nil, err
+	}
+
+	iter := r.opts.single(r.client).Query(ctx, stmt)
+	defer iter.Stop()
+
+	var plans []*domain.Plan
+	for {
+		row, err := iter.Next()
+		if err == iterator.Done {
+			return plans, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		plan, err := scanPlan(row)
+		if err != nil {
+			return nil, err
+		}
+		plans = append(plans, plan)
+	}
+}
+
+// FindByID retrieves a plan by ID
+func (r *PlanRepo) FindByID(ctx context.Context, id string) (_ *domain.Plan, err error) {
+	stmt := spanner.Statement{
+		SQL:    `SELECT ` + planColumns + ` FROM plans WHERE id = @id`,
+		Params: map[string]any{"id": id},
+	}
+
+	ctx, end, err := r.opts.begin(ctx, "plans.FindByID")
+	defer end(&err)
+	if err != nil {
+		return nil, err
+	}
+
+	iter := r.opts.single(r.client).Query(ctx, stmt)
+	defer iter.Stop()
+
+	row, err := iter.Next()
+	if err == iterator.Done {
+		return nil, domain.ErrPlanNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return scanPlan(row)
+}
+
+// Apply applies the given mutations to the database in one transaction
+func (r *PlanRepo) Apply(ctx context.Context, mutations ...*spanner.Mutation) (err error) {
+	ctx, end, err := r.opts.begin(ctx, "plans.Apply")
+	defer end(&err)
+	if err != nil {
+		return err
+	}
+
+	err = r.opts.apply(ctx, r.client, mutations)
+	return err
+}
+
+// nullString stores empty strings as NULL, so the unique index on external IDs
+// ignores plans that weren't imported
+func nullString(s string) spanner.NullString {
+	return spanner.NullString{StringVal: s, Valid: s != ""}
+}
+
+// scanPlan maps a row selected with planColumns to the entity
+func scanPlan(row *spanner.Row) (*domain.Plan, error) {
+	var (
+		id                string
+		name              string
+		priceCents        int64
+		currency          string
+		interval          spanner.NullString
+		trialDays         spanner.NullInt64
+		active            bool
+		externalProductID spanner.NullString
+		externalPriceID   spanner.NullString
+		createdAt         time.Time
+		updatedAt         time.Time
+	)
+
+	if err := row.Columns(&id, &name, &priceCents, &currency, &interval, &trialDays, &active, &externalProductID, &externalPriceID, &createdAt, &updatedAt); err != nil {
+		return nil, err
+	}
+
+	return domain.ReconstructPlan(id, name, priceCents, currency, domain.BillingInterval(interval.StringVal), trialDays.Int64, active, externalProductID.StringVal, externalPriceID.StringVal, createdAt, updatedAt), nil
+}
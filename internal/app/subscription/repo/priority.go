@@ -0,0 +1,84 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"cloud.google.com/go/spanner"
+	"cloud.google.com/go/spanner/apiv1/spannerpb"
+)
+
+// Request priorities by operation class. Spanner serves high priority requests first
+// when the instance is busy, so background jobs run low to keep out of the way of
+// customer-facing requests.
+const (
+	PriorityInteractive = spannerpb.RequestOptions_PRIORITY_HIGH   // customer-facing requests, such as create and cancel
+	PriorityWorker      = spannerpb.RequestOptions_PRIORITY_MEDIUM // background workers with deadlines, such as renewals
+	PriorityBulk        = spannerpb.RequestOptions_PRIORITY_LOW    // exports, reports and backfills
+)
+
+// WithPriority sets the request priority of every read, query and commit the
+// repository performs. Unset, Spanner treats requests as high priority.
+func WithPriority(p spannerpb.RequestOptions_Priority) Option {
+	return func(o *options) { o.priority = p }
+}
+
+// ParsePriority parses "high", "medium" or "low"; empty gives def, the priority of
+// the binary's operation class
+func ParsePriority(s string, def spannerpb.RequestOptions_Priority) (spannerpb.RequestOptions_Priority, error) {
+	switch strings.ToLower(s) {
+	case "":
+		return def, nil
+	case "high":
+		return spannerpb.RequestOptions_PRIORITY_HIGH, nil
+	case "medium":
+		return spannerpb.RequestOptions_PRIORITY_MEDIUM, nil
+	case "low":
+		return spannerpb.RequestOptions_PRIORITY_LOW, nil
+	}
+	return spannerpb.RequestOptions_PRIORITY_UNSPECIFIED, fmt.Errorf("invalid Spanner priority %q: want high, medium or low", s)
+}
+
+// queryOptions carries the priority into a query or DML statement
+func (o options) queryOptions() spanner.QueryOptions {
+	return spanner.QueryOptions{Priority: o.priority}
+}
+
+// applyOptions carries the priority into a commit made with Apply
+func (o options) applyOptions() []spanner.ApplyOption {
+	if o.priority == spannerpb.RequestOptions_PRIORITY_UNSPECIFIED {
+		return nil
+	}
+	return []spanner.ApplyOption{spanner.Priority(o.priority)}
+}
+
+// transactionOptions carries the priority into a read-write transaction's commit
+func (o options) transactionOptions() spanner.TransactionOptions {
+	return spanner.TransactionOptions{CommitPriority: o.priority}
+}
+
+// readOnly is a read-only transaction whose queries run at the repository's priority
+type readOnly struct {
+	*spanner.ReadOnlyTransaction
+	opts spanner.QueryOptions
+}
+
+func (t readOnly) Query(ctx context.Context, stmt spanner.Statement) *spanner.RowIterator {
+	return t.QueryWithOptions(ctx, stmt, t.opts)
+}
+
+// prioritize runs txn's queries at the repository's priority
+func (o options) prioritize(txn *spanner.ReadOnlyTransaction) readOnly {
+	return readOnly{ReadOnlyTransaction: txn, opts: o.queryOptions()}
+}
+
+// single is client.Single at the repository's priority
+func (o options) single(client *spanner.Client) readOnly {
+	return o.prioritize(client.Single())
+}
+
+// readOnlyTransaction is client.ReadOnlyTransaction at the repository's priority
+func (o options) readOnlyTransaction(client *spanner.Client) readOnly {
+	return o.prioritize(client.ReadOnlyTransaction())
+}
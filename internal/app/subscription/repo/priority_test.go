@@ -0,0 +1,43 @@
+package repo
+
+import (
+	"context"
+	"testing"
+
+	"cloud.google.com/go/spanner/apiv1/spannerpb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePriority(t *testing.T) {
+	p, err := ParsePriority("", PriorityBulk)
+	require.NoError(t, err)
+	assert.Equal(t, spannerpb.RequestOptions_PRIORITY_LOW, p, "empty gives the class's priority")
+
+	p, err = ParsePriority("High", PriorityBulk)
+	require.NoError(t, err)
+	assert.Equal(t, spannerpb.RequestOptions_PRIORITY_HIGH, p)
+
+	_, err = ParsePriority("urgent", PriorityBulk)
+	assert.ErrorContains(t, err, `invalid Spanner priority "urgent"`)
+}
+
+func TestPriority_ReachesEveryRequestKind(t *testing.T) {
+	o := newOptions([]Option{WithPriority(PriorityBulk)})
+
+	assert.Equal(t, spannerpb.RequestOptions_PRIORITY_LOW, o.queryOptions().Priority)
+	assert.Equal(t, spannerpb.RequestOptions_PRIORITY_LOW, o.transactionOptions().CommitPriority)
+	assert.Len(t, o.applyOptions(), 1)
+	assert.Empty(t, newOptions(nil).applyOptions(), "Spanner's default when unset")
+}
+
+func TestPriority_IsRecordedOnSpans(t *testing.T) {
+	tracer := &attributeTracer{}
+	o := newOptions([]Option{WithTracer(tracer), WithPriority(PriorityWorker)})
+
+	_, end, err := o.begin(context.Background(), "subscriptions.FindDueForRenewal")
+	require.NoError(t, err)
+	end(&err)
+
+	assert.Equal(t, "PRIORITY_MEDIUM", tracer.attributes["db.spanner.priority"])
+}
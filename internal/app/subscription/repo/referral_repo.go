@@ -0,0 +1,183 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"google.golang.org/api/iterator"
+)
+
+var _ contracts.ReferralRepository = (*ReferralRepo)(nil)
+
+const referralColumns = "id, code, referrer_customer_id, customer_id, subscription_id, status, referrer_credit_cents, referee_credit_cents, created_at, rewarded_at"
+
+// ReferralRepo implements the referral repository interface using Cloud Spanner
+type ReferralRepo struct {
+	client *spanner.Client
+	opts   options
+}
+
+// NewReferralRepo creates a new referral repository
+func NewReferralRepo(client *spanner.Client, opts ...Option) *ReferralRepo {
+	return &ReferralRepo{client: client, opts: newOptions(opts)}
+}
+
+// SaveCode returns a mutation for giving a customer a referral code. Codes are
+// inserted, not upserted, so a code generated twice fails instead of changing hands.
+func (r *ReferralRepo) SaveCode(ctx context.Context, customerID, code string, createdAt time.Time) (*spanner.Mutation, error) {
+	mutation := spanner.Insert("referral_codes",
+		[]string{"code", "customer_id", "created_at"},
+		[]any{code, customerID, createdAt})
+
+	return mutation, nil
+}
+
+// FindCode returns the customer's referral code
+func (r *ReferralRepo) FindCode(ctx context.Context, customerID string) (_ string, err error) {
+	stmt := spanner.Statement{
+		SQL:    `SELECT code FROM referral_codes WHERE customer_id = @customer_id`,
+		Params: map[string]any{"customer_id": customerID},
+	}
+
+	ctx, end, err := r.opts.begin(ctx, "referral_codes.FindCode")
+	defer end(&err)
+	if err != nil {
+		return "", err
+	}
+
+	return r.queryString(ctx, stmt)
+}
+
+// FindCodeOwner returns the customer a referral code belongs to
+func (r *ReferralRepo) FindCodeOwner(ctx context.Context, code string) (_ string, err error) {
+	stmt := spanner.Statement{
+		SQL:    `SELECT customer_id FROM referral_codes WHERE code = @code`,
+		Params: map[string]any{"code": code},
+	}
+
+	ctx, end, err := r.opts.begin(ctx, "referral_codes.FindCodeOwner")
+	defer end(&err)
+	if err != nil {
+		return "", err
+	}
+
+	return r.queryString(ctx, stmt)
+}
+
+// queryString runs a referral_codes statement selecting a single STRING and returns it
+func (r *ReferralRepo) queryString(ctx context.Context, stmt spanner.Statement) (string, error) {
+	iter := r.opts.single(r.client).Query(ctx, stmt)
+	defer iter.Stop()
+
+	row, err := iter.Next()
+	if err != nil {
+		if err == iterator.Done {
+			return "", domain.ErrReferralCodeNotFound
+		}
+		return "", err
+	}
+
+	var value string
+	if err := row.Columns(&value); err != nil {
+		return "", err
+	}
+	return value, nil
+}
+
+// Save returns a mutation for persisting a referral to the database
+// The mutation must be applied using Apply() method
+func (r *ReferralRepo) Save(ctx context.Context, referral *domain.Referral) (*spanner.Mutation, error) {
+	rewardedAt := spanner.NullTime{Time: referral.RewardedAt(), Valid: !referral.RewardedAt().IsZero()}
+
+	mutation := spanner.InsertOrUpdate("referral_credits",
+		[]string{"id", "code", "referrer_customer_id", "customer_id", "subscription_id", "status", "referrer_credit_cents", "referee_credit_cents", "created_at", "rewarded_at"},
+		[]any{
+			referral.ID(),
+			referral.Code(),
+			referral.ReferrerCustomerID(),
+			referral.RefereeCustomerID(),
+			referral.RefereeSubscriptionID(),
+			string(referral.Status()),
+			referral.ReferrerCredit(),
+			referral.RefereeCredit(),
+			referral.CreatedAt(),
+			rewardedAt,
+		})
+
+	return mutation, nil
+}
+
+// FindBySubscription returns the referral the subscription was created with
+func (r *ReferralRepo) FindBySubscription(ctx context.Context, subscriptionID string) (_ *domain.Referral, err error) {
+	stmt := spanner.Statement{
+		SQL:    `SELECT ` + referralColumns + ` FROM referral_credits WHERE subscription_id = @subscription_id`,
+		Params: map[string]any{"subscription_id": subscriptionID},
+	}
+
+	ctx, end, err := r.opts.begin(ctx, "referral_credits.FindBySubscription")
+	defer end(&err)
+	if err != nil {
+		return nil, err
+	}
+
+	iter := r.opts.single(r.client).Query(ctx, stmt)
+	defer iter.Stop()
+
+	row, err := iter.Next()
+	if err != nil {
+		if err == iterator.Done {
+			return nil, domain.ErrReferralNotFound
+		}
+		return nil, err
+	}
+
+	return scanReferral(row)
+}
+
+// Apply applies the given mutations to the database in one transaction
+func (r *ReferralRepo) Apply(ctx context.Context, mutations ...*spanner.Mutation) (err error) {
+	ctx, end, err := r.opts.begin(ctx, "referral_credits.Apply")
+	defer end(&err)
+	if err != nil {
+		return err
+	}
+
+	err = r.opts.apply(ctx, r.client, mutations)
+	return err
+}
+
+// scanReferral maps a row selected with referralColumns to the entity
+func scanReferral(row *spanner.Row) (*domain.Referral, error) {
+	var (
+		id                  string
+		code                string
+		referrerCustomerID  string
+		customerID          string
+		subscriptionID      string
+		status              string
+		referrerCreditCents int64
+		refereeCreditCents  int64
+		createdAt           time.Time
+		rewardedAt          spanner.NullTime
+	)
+
+	if err := row.Columns(&id, &code, &referrerCustomerID, &customerID, &subscriptionID, &status, &referrerCreditCents, &refereeCreditCents, &createdAt, &rewardedAt); err != nil {
+		return nil, err
+	}
+
+	return domain.ReconstructReferral(
+		id,
+		code,
+		referrerCustomerID,
+		customerID,
+		subscriptionID,
+		domain.ReferralStatus(status),
+		referrerCreditCents,
+		refereeCreditCents,
+		createdAt,
+		rewardedAt.Time,
+	), nil
+}
@@ -0,0 +1,194 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"google.golang.org/api/iterator"
+)
+
+var _ contracts.RefundOutboxRepository = (*RefundOutboxRepo)(nil)
+
+const refundOutboxColumns = "id, subscription_id, customer_id, plan_id, amount_cents, currency, reason, idempotency_key, correlation_id, status, attempts, last_error, queued_at, next_attempt_at"
+
+// RefundOutboxRepo implements the refund outbox repository interface using Cloud Spanner
+type RefundOutboxRepo struct {
+	client *spanner.Client
+	opts   options
+}
+
+// NewRefundOutboxRepo creates a new refund outbox repository
+func NewRefundOutboxRepo(client *spanner.Client, opts ...Option) *RefundOutboxRepo {
+	return &RefundOutboxRepo{client: client, opts: newOptions(opts)}
+}
+
+// Save returns a mutation for persisting a queued refund to the database
+// The mutation must be applied using Apply() method
+func (r *RefundOutboxRepo) Save(ctx context.Context, refund *domain.QueuedRefund) (*spanner.Mutation, error) {
+	mutation := spanner.InsertOrUpdate("refund_outbox",
+		[]string{"id", "subscription_id", "customer_id", "plan_id", "amount_cents", "currency", "reason", "idempotency_key", "correlation_id", "status", "attempts", "last_error", "queued_at", "next_attempt_at"},
+		[]any{
+			refund.ID(),
+			refund.SubscriptionID(),
+			refund.CustomerID(),
+			refund.PlanID(),
+			refund.Amount(),
+			refund.Currency(),
+			nullString(refund.Reason()),
+			refund.IdempotencyKey(),
+			spanner.NullString{StringVal: refund.CorrelationID(), Valid: refund.CorrelationID() != ""},
+			string(refund.Status()),
+			refund.Attempts(),
+			spanner.NullString{StringVal: refund.LastError(), Valid: refund.LastError() != ""},
+			refund.QueuedAt(),
+			refund.NextAttemptAt(),
+		})
+
+	return mutation, nil
+}
+
+// Delete returns a mutation removing a queued refund from the database
+// The mutation must be applied using Apply() method
+func (r *RefundOutboxRepo) Delete(ctx context.Context, id string) (*spanner.Mutation, error) {
+	return spanner.Delete("refund_outbox", spanner.Key{id}), nil
+}
+
+// Apply applies the given mutations to the database
+func (r *RefundOutboxRepo) Apply(ctx context.Context, mutations ...*spanner.Mutation) (err error) {
+	ctx, end, err := r.opts.begin(ctx, "refund_outbox.Apply")
+	defer end(&err)
+	if err != nil {
+		return err
+	}
+
+	err = r.opts.apply(ctx, r.client, mutations)
+	return err
+}
+
+// FindByID retrieves a queued refund by ID
+func (r *RefundOutboxRepo) FindByID(ctx context.Context, id string) (_ *domain.QueuedRefund, err error) {
+	refunds, err := r.query(ctx, "refund_outbox.FindByID", spanner.Statement{
+		SQL:    `SELECT ` + refundOutboxColumns + ` FROM refund_outbox WHERE id = @id`,
+		Params: map[string]any{"id": id},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(refunds) == 0 {
+		return nil, domain.ErrQueuedRefundNotFound
+	}
+	return refunds[0], nil
+}
+
+// FindDue returns queued refunds whose next attempt is at or before now, oldest first.
+// Failed refunds are no longer due.
+func (r *RefundOutboxRepo) FindDue(ctx context.Context, now time.Time, limit int) ([]*domain.QueuedRefund, error) {
+	const op = "refund_outbox.FindDue"
+	return r.query(ctx, op, spanner.Statement{
+		SQL: `
+			SELECT ` + refundOutboxColumns + `
+			FROM ` + r.opts.from(op, "refund_outbox") + `
+			WHERE next_attempt_at <= @now AND IFNULL(status, 'QUEUED') = 'QUEUED'
+			ORDER BY next_attempt_at, id
+			LIMIT @limit
+		`,
+		Params: map[string]any{
+			"now":   now,
+			"limit": int64(limit),
+		},
+	})
+}
+
+// FindByCustomer returns the refunds still owed to the customer, with their failed
+// attempts, oldest first. Those that failed for good are included.
+func (r *RefundOutboxRepo) FindByCustomer(ctx context.Context, customerID string) ([]*domain.QueuedRefund, error) {
+	const op = "refund_outbox.FindByCustomer"
+	return r.query(ctx, op, spanner.Statement{
+		SQL: `
+			SELECT ` + refundOutboxColumns + `
+			FROM ` + r.opts.from(op, "refund_outbox") + `
+			WHERE customer_id = @customer_id
+			ORDER BY queued_at, id
+		`,
+		Params: map[string]any{"customer_id": customerID},
+	})
+}
+
+// query runs a statement selecting refundOutboxColumns, traced as op, and collects every row
+func (r *RefundOutboxRepo) query(ctx context.Context, op string, stmt spanner.Statement) (_ []*domain.QueuedRefund, err error) {
+	ctx, end, err := r.opts.begin(ctx, op)
+	defer end(&err)
+	if err != nil {
+		return nil, err
+	}
+
+	iter := r.opts.single(r.client).Query(ctx, r.opts.hinted(op, stmt))
+	defer iter.Stop()
+
+	var refunds []*domain.QueuedRefund
+	for {
+		row, err := iter.Next()
+		if err == iterator.Done {
+			return refunds, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		refund, err := scanQueuedRefund(row)
+		if err != nil {
+			return nil, err
+		}
+		refunds = append(refunds, refund)
+	}
+}
+
+// scanQueuedRefund maps a row selected with refundOutboxColumns to the entity
+func scanQueuedRefund(row *spanner.Row) (*domain.QueuedRefund, error) {
+	var (
+		id             string
+		subscriptionID string
+		customerID     string
+		planID         string
+		amountCents    int64
+		currency       string
+		reason         spanner.NullString
+		idempotencyKey string
+		correlationID  spanner.NullString
+		status         spanner.NullString
+		attempts       int64
+		lastError      spanner.NullString
+		queuedAt       time.Time
+		nextAttemptAt  time.Time
+	)
+
+	if err := row.Columns(&id, &subscriptionID, &customerID, &planID, &amountCents, &currency, &reason, &idempotencyKey, &correlationID, &status, &attempts, &lastError, &queuedAt, &nextAttemptAt); err != nil {
+		return nil, err
+	}
+
+	// Refunds queued before statuses were recorded are all still queued
+	queuedStatus := domain.QueuedRefundQueued
+	if status.Valid {
+		queuedStatus = domain.QueuedRefundStatus(status.StringVal)
+	}
+
+	return domain.ReconstructQueuedRefund(
+		id,
+		subscriptionID,
+		customerID,
+		planID,
+		amountCents,
+		currency,
+		reason.StringVal,
+		idempotencyKey,
+		correlationID.StringVal,
+		queuedStatus,
+		attempts,
+		lastError.StringVal,
+		queuedAt,
+		nextAttemptAt,
+	), nil
+}
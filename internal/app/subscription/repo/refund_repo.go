@@ -0,0 +1,176 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"google.golang.org/api/iterator"
+)
+
+var _ contracts.RefundRepository = (*RefundRepo)(nil)
+
+const refundColumns = "id, subscription_id, customer_id, amount_cents, currency, provider_refund_id, status, failure_reason, requested_at, settled_at"
+
+// RefundRepo implements the refund repository interface using Cloud Spanner
+type RefundRepo struct {
+	client *spanner.Client
+	opts   options
+}
+
+// NewRefundRepo creates a new refund repository
+func NewRefundRepo(client *spanner.Client, opts ...Option) *RefundRepo {
+	return &RefundRepo{client: client, opts: newOptions(opts)}
+}
+
+// Save returns a mutation for persisting a refund to the database
+// The mutation must be applied using Apply() method
+func (r *RefundRepo) Save(ctx context.Context, refund *domain.Refund) (*spanner.Mutation, error) {
+	mutation := spanner.InsertOrUpdate("refunds",
+		[]string{"id", "subscription_id", "customer_id", "amount_cents", "currency", "provider_refund_id", "status", "failure_reason", "requested_at", "settled_at"},
+		[]any{
+			refund.ID(),
+			refund.SubscriptionID(),
+			refund.CustomerID(),
+			refund.Amount(),
+			refund.Currency(),
+			refund.ProviderRefundID(),
+			string(refund.Status()),
+			spanner.NullString{StringVal: refund.FailureReason(), Valid: refund.FailureReason() != ""},
+			refund.RequestedAt(),
+			nullTime(refund.SettledAt()),
+		})
+
+	return mutation, nil
+}
+
+// Apply applies the given mutations to the database
+func (r *RefundRepo) Apply(ctx context.Context, mutations ...*spanner.Mutation) (err error) {
+	ctx, end, err := r.opts.begin(ctx, "refunds.Apply")
+	defer end(&err)
+	if err != nil {
+		return err
+	}
+
+	err = r.opts.apply(ctx, r.client, mutations)
+	return err
+}
+
+// FindByID retrieves a refund by ID
+func (r *RefundRepo) FindByID(ctx context.Context, id string) (*domain.Refund, error) {
+	return r.findOne(ctx, "refunds.FindByID", spanner.Statement{
+		SQL:    `SELECT ` + refundColumns + ` FROM refunds WHERE id = @id`,
+		Params: map[string]any{"id": id},
+	})
+}
+
+// FindByProviderRefundID retrieves a refund by the billing provider's refund ID
+func (r *RefundRepo) FindByProviderRefundID(ctx context.Context, providerRefundID string) (*domain.Refund, error) {
+	return r.findOne(ctx, "refunds.FindByProviderRefundID", spanner.Statement{
+		SQL:    `SELECT ` + refundColumns + ` FROM refunds WHERE provider_refund_id = @provider_refund_id`,
+		Params: map[string]any{"provider_refund_id": providerRefundID},
+	})
+}
+
+// FindPending returns pending refunds requested at or before requestedBefore, oldest first
+func (r *RefundRepo) FindPending(ctx context.Context, requestedBefore time.Time, limit int) (_ []*domain.Refund, err error) {
+	const op = "refunds.FindPending"
+	stmt := spanner.Statement{
+		SQL: `
+			SELECT ` + refundColumns + `
+			FROM ` + r.opts.from(op, "refunds") + `
+			WHERE status = @status
+			  AND requested_at <= @requested_before
+			ORDER BY requested_at, id
+			LIMIT @limit
+		`,
+		Params: map[string]any{
+			"status":           string(domain.RefundPending),
+			"requested_before": requestedBefore,
+			"limit":            int64(limit),
+		},
+	}
+
+	ctx, end, err := r.opts.begin(ctx, op)
+	defer end(&err)
+	if err != nil {
+		return nil, err
+	}
+
+	iter := r.opts.single(r.client).Query(ctx, r.opts.hinted(op, stmt))
+	defer iter.Stop()
+
+	var refunds []*domain.Refund
+	for {
+		row, err := iter.Next()
+		if err == iterator.Done {
+			return refunds, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		refund, err := scanRefund(row)
+		if err != nil {
+			return nil, err
+		}
+		refunds = append(refunds, refund)
+	}
+}
+
+// findOne runs a statement selecting refundColumns, traced as op, and returns the first row
+func (r *RefundRepo) findOne(ctx context.Context, op string, stmt spanner.Statement) (_ *domain.Refund, err error) {
+	ctx, end, err := r.opts.begin(ctx, op)
+	defer end(&err)
+	if err != nil {
+		return nil, err
+	}
+
+	iter := r.opts.single(r.client).Query(ctx, stmt)
+	defer iter.Stop()
+
+	row, err := iter.Next()
+	if err != nil {
+		if err == iterator.Done {
+			return nil, domain.ErrRefundNotFound
+		}
+		return nil, err
+	}
+
+	return scanRefund(row)
+}
+
+// scanRefund maps a row selected with refundColumns to the entity
+func scanRefund(row *spanner.Row) (*domain.Refund, error) {
+	var (
+		id               string
+		subscriptionID   string
+		customerID       string
+		amountCents      int64
+		currency         string
+		providerRefundID string
+		status           string
+		failureReason    spanner.NullString
+		requestedAt      time.Time
+		settledAt        spanner.NullTime
+	)
+
+	if err := row.Columns(&id, &subscriptionID, &customerID, &amountCents, &currency, &providerRefundID, &status, &failureReason, &requestedAt, &settledAt); err != nil {
+		return nil, err
+	}
+
+	return domain.ReconstructRefund(
+		id,
+		subscriptionID,
+		customerID,
+		amountCents,
+		currency,
+		providerRefundID,
+		domain.RefundStatus(status),
+		failureReason.StringVal,
+		requestedAt,
+		settledAt.Time,
+	), nil
+}
@@ -0,0 +1,311 @@
+package repo
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"cloud.google.com/go/spanner"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"google.golang.org/api/iterator"
+)
+
+var (
+	_ contracts.ReportingRepository = (*ReportingRepo)(nil)
+	_ contracts.CohortRepository    = (*ReportingRepo)(nil)
+)
+
+// ReportingRepo keeps the reporting projection in Cloud Spanner. The expensive scans
+// run only when the projection is refreshed; reads touch the small report tables.
+type ReportingRepo struct {
+	client *spanner.Client
+	opts   options
+}
+
+// NewReportingRepo creates a new reporting repository
+func NewReportingRepo(client *spanner.Client, opts ...Option) *ReportingRepo {
+	return &ReportingRepo{client: client, opts: newOptions(opts)}
+}
+
+// ComputeAggregates scans subscriptions and refunds in one read-only transaction at
+// snapshot, so the figures agree with each other
+func (r *ReportingRepo) ComputeAggregates(ctx context.Context, since time.Time, snapshot contracts.Snapshot) (_ *contracts.Aggregates, err error) {
+	ctx, end, err := r.opts.begin(ctx, "reporting.ComputeAggregates")
+	defer end(&err)
+	if err != nil {
+		return nil, err
+	}
+
+	txn := r.snapshot(snapshot)
+	defer txn.Close()
+
+	var a contracts.Aggregates
+	err = query(ctx, txn, spanner.Statement{
+		SQL: `
+			SELECT plan_id, COUNT(*)
+			FROM subscriptions
+			WHERE status = @status
+			GROUP BY plan_id
+			ORDER BY plan_id
+		`,
+		Params: map[string]any{"status": string(domain.StatusActive)},
+	}, func(row *spanner.Row) error {
+		var c contracts.PlanCount
+		if err := row.Columns(&c.PlanID, &c.Active); err != nil {
+			return err
+		}
+		a.ActiveByPlan = append(a.ActiveByPlan, c)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Cancellations are counted by cancelled_at, so a subscription started and
+	// cancelled in the window counts on both days
+	days := make(map[civil.Date]*contracts.DailyCount)
+	count := func(column string, add func(*contracts.DailyCount, int64)) error {
+		return query(ctx, txn, spanner.Statement{
+			SQL: `
+				SELECT DATE(` + column + `, "UTC") AS day, COUNT(*)
+				FROM subscriptions
+				WHERE ` + column + ` >= @since
+				GROUP BY day
+			`,
+			Params: map[string]any{"since": since},
+		}, func(row *spanner.Row) error {
+			var (
+				day civil.Date
+				n   int64
+			)
+			if err := row.Columns(&day, &n); err != nil {
+				return err
+			}
+			d, ok := days[day]
+			if !ok {
+				d = &contracts.DailyCount{Day: day.In(time.UTC)}
+				days[day] = d
+			}
+			add(d, n)
+			return nil
+		})
+	}
+	if err := count("start_date", func(d *contracts.DailyCount, n int64) { d.New = n }); err != nil {
+		return nil, err
+	}
+	if err := count("cancelled_at", func(d *contracts.DailyCount, n int64) { d.Cancelled = n }); err != nil {
+		return nil, err
+	}
+	for _, d := range days {
+		a.Daily = append(a.Daily, *d)
+	}
+	sort.Slice(a.Daily, func(i, j int) bool { return a.Daily[i].Day.Before(a.Daily[j].Day) })
+
+	err = query(ctx, txn, spanner.Statement{
+		SQL: `
+			SELECT currency, status, COUNT(*), SUM(amount_cents)
+			FROM refunds
+			GROUP BY currency, status
+			ORDER BY currency, status
+		`,
+	}, func(row *spanner.Row) error {
+		var t contracts.RefundTotal
+		if err := row.Columns(&t.Currency, &t.Status, &t.Count, &t.AmountCents); err != nil {
+			return err
+		}
+		a.Refunds = append(a.Refunds, t)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if a.ReadAt, err = txn.Timestamp(); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// SaveAggregates replaces the projection in one transaction, so readers see either
+// the previous refresh or this one
+func (r *ReportingRepo) SaveAggregates(ctx context.Context, a *contracts.Aggregates) (err error) {
+	ctx, end, err := r.opts.begin(ctx, "reporting.SaveAggregates")
+	defer end(&err)
+	if err != nil {
+		return err
+	}
+
+	mutations := []*spanner.Mutation{
+		spanner.Delete("report_active_by_plan", spanner.AllKeys()),
+		spanner.Delete("report_daily_subscriptions", spanner.AllKeys()),
+		spanner.Delete("report_refund_totals", spanner.AllKeys()),
+	}
+	for _, c := range a.ActiveByPlan {
+		mutations = append(mutations, spanner.Insert("report_active_by_plan",
+			[]string{"plan_id", "active_count", "refreshed_at"},
+			[]any{c.PlanID, c.Active, a.RefreshedAt}))
+	}
+	for _, d := range a.Daily {
+		mutations = append(mutations, spanner.Insert("report_daily_subscriptions",
+			[]string{"day", "new_count", "cancelled_count", "refreshed_at", "read_at"},
+			[]any{civil.DateOf(d.Day), d.New, d.Cancelled, a.RefreshedAt, a.ReadAt}))
+	}
+	for _, t := range a.Refunds {
+		mutations = append(mutations, spanner.Insert("report_refund_totals",
+			[]string{"currency", "status", "refund_count", "amount_cents", "refreshed_at"},
+			[]any{t.Currency, t.Status, t.Count, t.AmountCents, a.RefreshedAt}))
+	}
+
+	_, err = r.client.Apply(ctx, mutations, r.opts.applyOptions()...)
+	return err
+}
+
+// LoadAggregates reads the projection. Every refresh writes a row per day of its
+// window, so no daily rows means there has been no refresh.
+func (r *ReportingRepo) LoadAggregates(ctx context.Context) (_ *contracts.Aggregates, err error) {
+	ctx, end, err := r.opts.begin(ctx, "reporting.LoadAggregates")
+	defer end(&err)
+	if err != nil {
+		return nil, err
+	}
+
+	txn := r.opts.readOnlyTransaction(r.client)
+	defer txn.Close()
+
+	var a contracts.Aggregates
+	err = query(ctx, txn, spanner.Statement{
+		SQL: `SELECT day, new_count, cancelled_count, refreshed_at, read_at FROM report_daily_subscriptions ORDER BY day`,
+	}, func(row *spanner.Row) error {
+		var (
+			d      contracts.DailyCount
+			day    civil.Date
+			readAt spanner.NullTime // refreshes before migration 023 didn't record it
+		)
+		if err := row.Columns(&day, &d.New, &d.Cancelled, &a.RefreshedAt, &readAt); err != nil {
+			return err
+		}
+		d.Day = day.In(time.UTC)
+		a.ReadAt = readAt.Time
+		a.Daily = append(a.Daily, d)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(a.Daily) == 0 {
+		return nil, domain.ErrAggregatesNotReady
+	}
+
+	err = query(ctx, txn, spanner.Statement{
+		SQL: `SELECT plan_id, active_count FROM report_active_by_plan ORDER BY plan_id`,
+	}, func(row *spanner.Row) error {
+		var c contracts.PlanCount
+		if err := row.Columns(&c.PlanID, &c.Active); err != nil {
+			return err
+		}
+		a.ActiveByPlan = append(a.ActiveByPlan, c)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = query(ctx, txn, spanner.Statement{
+		SQL: `SELECT currency, status, refund_count, amount_cents FROM report_refund_totals ORDER BY currency, status`,
+	}, func(row *spanner.Row) error {
+		var t contracts.RefundTotal
+		if err := row.Columns(&t.Currency, &t.Status, &t.Count, &t.AmountCents); err != nil {
+			return err
+		}
+		a.Refunds = append(a.Refunds, t)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// CountCohorts groups the subscriptions started from since by UTC signup and
+// cancellation month, at snapshot. Subscriptions removed by the retention job no
+// longer count.
+func (r *ReportingRepo) CountCohorts(ctx context.Context, since time.Time, snapshot contracts.Snapshot) (_ []contracts.CohortCount, _ time.Time, err error) {
+	ctx, end, err := r.opts.begin(ctx, "reporting.CountCohorts")
+	defer end(&err)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	txn := r.snapshot(snapshot)
+	defer txn.Close()
+
+	var counts []contracts.CohortCount
+	err = query(ctx, txn, spanner.Statement{
+		SQL: `
+			SELECT
+				DATE_TRUNC(DATE(start_date, "UTC"), MONTH) AS signup_month,
+				DATE_TRUNC(DATE(cancelled_at, "UTC"), MONTH) AS cancel_month,
+				COUNT(*)
+			FROM subscriptions
+			WHERE start_date >= @since
+			GROUP BY signup_month, cancel_month
+			ORDER BY signup_month
+		`,
+		Params: map[string]any{"since": since},
+	}, func(row *spanner.Row) error {
+		var (
+			signup civil.Date
+			cancel spanner.NullDate
+			c      contracts.CohortCount
+		)
+		if err := row.Columns(&signup, &cancel, &c.Subscriptions); err != nil {
+			return err
+		}
+		c.SignupMonth = signup.In(time.UTC)
+		if cancel.Valid {
+			c.CancelMonth = cancel.Date.In(time.UTC)
+		}
+		counts = append(counts, c)
+		return nil
+	})
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	readAt, err := txn.Timestamp()
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	return counts, readAt, nil
+}
+
+// snapshot is a read-only transaction at the snapshot's timestamp; every query in it
+// reads the same committed state
+func (r *ReportingRepo) snapshot(s contracts.Snapshot) readOnly {
+	txn := r.client.ReadOnlyTransaction()
+	switch {
+	case !s.At.IsZero():
+		txn = txn.WithTimestampBound(spanner.ReadTimestamp(s.At))
+	case s.Staleness > 0:
+		txn = txn.WithTimestampBound(spanner.ExactStaleness(s.Staleness))
+	}
+	return r.opts.prioritize(txn)
+}
+
+// query runs stmt in txn and calls fn for each row
+func query(ctx context.Context, txn readOnly, stmt spanner.Statement, fn func(*spanner.Row) error) error {
+	iter := txn.Query(ctx, stmt)
+	defer iter.Stop()
+	for {
+		row, err := iter.Next()
+		if err == iterator.Done {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"google.golang.org/api/iterator"
+)
+
+var _ contracts.RetentionOfferRepository = (*RetentionOfferRepo)(nil)
+
+const retentionOfferColumns = "id, subscription_id, customer_id, plan_id, rule, kind, percent_off_bp, offer_plan_id, offer_price_cents, status, credit_amount_cents, presented_at, expires_at, responded_at"
+
+// RetentionOfferRepo implements the retention offer repository interface using Cloud Spanner
+type RetentionOfferRepo struct {
+	client *spanner.Client
+	opts   options
+}
+
+// NewRetentionOfferRepo creates a new retention offer repository
+func NewRetentionOfferRepo(client *spanner.Client, opts ...Option) *RetentionOfferRepo {
+	return &RetentionOfferRepo{client: client, opts: newOptions(opts)}
+}
+
+// Save returns a mutation for persisting a retention offer to the database
+// The mutation must be applied using Apply() method
+func (r *RetentionOfferRepo) Save(ctx context.Context, offer *domain.RetentionOffer) (*spanner.Mutation, error) {
+	terms := offer.Terms()
+	mutation := spanner.InsertOrUpdate("retention_offers",
+		[]string{"id", "subscription_id", "customer_id", "plan_id", "rule", "kind", "percent_off_bp", "offer_plan_id", "offer_price_cents", "status", "credit_amount_cents", "presented_at", "expires_at", "responded_at"},
+		[]any{
+			offer.ID(),
+			offer.SubscriptionID(),
+			offer.CustomerID(),
+			offer.PlanID(),
+			terms.Rule,
+			string(terms.Kind),
+			terms.PercentOff,
+			spanner.NullString{StringVal: terms.PlanID, Valid: terms.PlanID != ""},
+			terms.PriceCents,
+			string(offer.Status()),
+			offer.CreditAmount(),
+			offer.PresentedAt(),
+			offer.ExpiresAt(),
+			nullTime(offer.RespondedAt()),
+		})
+
+	return mutation, nil
+}
+
+// Apply applies the given mutations to the database in one transaction
+func (r *RetentionOfferRepo) Apply(ctx context.Context, mutations ...*spanner.Mutation) (err error) {
+	ctx, end, err := r.opts.begin(ctx, "retention_offers.Apply")
+	defer end(&err)
+	if err != nil {
+		return err
+	}
+
+	err = r.opts.apply(ctx, r.client, mutations)
+	return err
+}
+
+// FindByID retrieves a retention offer by ID
+func (r *RetentionOfferRepo) FindByID(ctx context.Context, id string) (_ *domain.RetentionOffer, err error) {
+	stmt := spanner.Statement{
+		SQL:    `SELECT ` + retentionOfferColumns + ` FROM retention_offers WHERE id = @id`,
+		Params: map[string]any{"id": id},
+	}
+
+	ctx, end, err := r.opts.begin(ctx, "retention_offers.FindByID")
+	defer end(&err)
+	if err != nil {
+		return nil, err
+	}
+
+	iter := r.opts.single(r.client).Query(ctx, stmt)
+	defer iter.Stop()
+
+	row, err := iter.Next()
+	if err != nil {
+		if err == iterator.Done {
+			return nil, domain.ErrRetentionOfferNotFound
+		}
+		return nil, err
+	}
+
+	return scanRetentionOffer(row)
+}
+
+// LastAcceptedAt returns when the subscription last accepted a retention offer
+func (r *RetentionOfferRepo) LastAcceptedAt(ctx context.Context, subscriptionID string) (_ time.Time, err error) {
+	stmt := spanner.Statement{
+		SQL: `SELECT MAX(responded_at) FROM retention_offers
+			WHERE subscription_id = @subscription_id AND status = @accepted`,
+		Params: map[string]any{
+			"subscription_id": subscriptionID,
+			"accepted":        string(domain.RetentionOfferAccepted),
+		},
+	}
+
+	ctx, end, err := r.opts.begin(ctx, "retention_offers.LastAcceptedAt")
+	defer end(&err)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	iter := r.opts.single(r.client).Query(ctx, stmt)
+	defer iter.Stop()
+
+	row, err := iter.Next()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var last spanner.NullTime
+	if err := row.Columns(&last); err != nil {
+		return time.Time{}, err
+	}
+	return last.Time, nil
+}
+
+// scanRetentionOffer maps a row selected with retentionOfferColumns to the entity
+func scanRetentionOffer(row *spanner.Row) (*domain.RetentionOffer, error) {
+	var (
+		id             string
+		subscriptionID string
+		customerID     string
+		planID         string
+		rule           string
+		kind           string
+		percentOff     int64
+		offerPlanID    spanner.NullString
+		offerPrice     int64
+		status         string
+		creditAmount   int64
+		presentedAt    time.Time
+		expiresAt      time.Time
+		respondedAt    spanner.NullTime
+	)
+
+	if err := row.Columns(&id, &subscriptionID, &customerID, &planID, &rule, &kind, &percentOff, &offerPlanID, &offerPrice, &status, &creditAmount, &presentedAt, &expiresAt, &respondedAt); err != nil {
+		return nil, err
+	}
+
+	return domain.ReconstructRetentionOffer(
+		id,
+		subscriptionID,
+		customerID,
+		planID,
+		domain.RetentionOfferTerms{
+			Rule:       rule,
+			Kind:       domain.RetentionOfferKind(kind),
+			PercentOff: percentOff,
+			PlanID:     offerPlanID.StringVal,
+			PriceCents: offerPrice,
+		},
+		domain.RetentionOfferStatus(status),
+		creditAmount,
+		presentedAt,
+		expiresAt,
+		respondedAt.Time,
+	), nil
+}
@@ -0,0 +1,108 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+var _ contracts.RetentionRepository = (*RetentionRepo)(nil)
+
+// anonymizedPrefix marks customer IDs that have already been replaced by a hash
+const anonymizedPrefix = "anonymized-"
+
+// RetentionRepo implements the retention repository interface using Cloud Spanner.
+// Bulk changes use partitioned DML, so each statement must stay idempotent.
+type RetentionRepo struct {
+	client *spanner.Client
+	opts   options
+}
+
+// NewRetentionRepo creates a new retention repository
+func NewRetentionRepo(client *spanner.Client, opts ...Option) *RetentionRepo {
+	return &RetentionRepo{client: client, opts: newOptions(opts)}
+}
+
+// CountExpired counts the rows the given action would change
+func (r *RetentionRepo) CountExpired(ctx context.Context, action contracts.RetentionAction, cutoff time.Time) (int64, error) {
+	sql := `SELECT COUNT(*) FROM subscriptions WHERE status = @status AND cancelled_at < @cutoff`
+	params := expiredParams(cutoff)
+	if action == contracts.RetentionAnonymize {
+		sql += ` AND NOT STARTS_WITH(customer_id, @prefix)`
+		params["prefix"] = anonymizedPrefix
+	}
+
+	stmt := spanner.Statement{
+		SQL:    sql,
+		Params: params,
+	}
+
+	iter := r.opts.single(r.client).Query(ctx, stmt)
+	defer iter.Stop()
+
+	row, err := iter.Next()
+	if err != nil {
+		return 0, err
+	}
+
+	var count int64
+	if err := row.Columns(&count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// AnonymizeExpired replaces the customer ID of expired rows with a one-way hash
+func (r *RetentionRepo) AnonymizeExpired(ctx context.Context, cutoff time.Time) (int64, error) {
+	stmt := spanner.Statement{
+		SQL: `
+			UPDATE subscriptions
+			SET customer_id = CONCAT(@prefix, TO_HEX(SHA256(customer_id)))
+			WHERE status = @status
+			  AND cancelled_at < @cutoff
+			  AND NOT STARTS_WITH(customer_id, @prefix)
+		`,
+		Params: expiredParams(cutoff),
+	}
+	stmt.Params["prefix"] = anonymizedPrefix
+
+	return r.client.PartitionedUpdateWithOptions(ctx, stmt, r.opts.queryOptions())
+}
+
+// DeleteExpired deletes expired rows
+func (r *RetentionRepo) DeleteExpired(ctx context.Context, cutoff time.Time) (int64, error) {
+	stmt := spanner.Statement{
+		SQL:    `DELETE FROM subscriptions WHERE status = @status AND cancelled_at < @cutoff`,
+		Params: expiredParams(cutoff),
+	}
+
+	return r.client.PartitionedUpdateWithOptions(ctx, stmt, r.opts.queryOptions())
+}
+
+// RecordPurge appends an entry to the purge audit trail
+func (r *RetentionRepo) RecordPurge(ctx context.Context, record contracts.PurgeRecord) error {
+	mutation := spanner.Insert("purge_audit",
+		[]string{"id", "target", "action", "cutoff", "rows_affected", "dry_run", "executed_at"},
+		[]any{
+			record.ID,
+			record.Target,
+			string(record.Action),
+			record.Cutoff,
+			record.RowsAffected,
+			record.DryRun,
+			record.ExecutedAt,
+		})
+
+	_, err := r.client.Apply(ctx, []*spanner.Mutation{mutation}, r.opts.applyOptions()...)
+	return err
+}
+
+func expiredParams(cutoff time.Time) map[string]any {
+	return map[string]any{
+		"status": string(domain.StatusCancelled),
+		"cutoff": cutoff,
+	}
+}
@@ -2,31 +2,87 @@ package repo
 
 import (
 	"context"
+	"errors"
 	"time"
 
+	"cloud.google.com/go/civil"
 	"cloud.google.com/go/spanner"
 	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
 	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
 	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
 )
 
-var _ contracts.SubscriptionRepository = (*SubscriptionRepo)(nil)
+var (
+	_ contracts.SubscriptionRepository              = (*SubscriptionRepo)(nil)
+	_ contracts.TransactionalSubscriptionRepository = (*SubscriptionRepo)(nil)
+	_ contracts.PaymentMethodCheckRepository        = (*SubscriptionRepo)(nil)
+	_ contracts.RenewalNoticeRepository             = (*SubscriptionRepo)(nil)
+	_ contracts.BulkCancellationRepository          = (*SubscriptionRepo)(nil)
+	_ contracts.SubscriptionListingRepository       = (*SubscriptionRepo)(nil)
+	_ contracts.SubscriptionScanRepository          = (*SubscriptionRepo)(nil)
+	_ contracts.ChurnRepository                     = (*SubscriptionRepo)(nil)
+)
+
+const subscriptionColumns = "id, customer_id, plan_id, price_cents, status, start_date, current_period_start, current_period_end, dunning_attempts, next_payment_retry_at, cancelled_at, payment_method_flagged_for, trial_end_date, renewal_notice_sent_for, paused_at, cancel_at, coupon_code, coupon_percent_off, coupon_amount_off_cents, coupon_periods_left, cancellation_reason, cancellation_reason_details, version"
+
+// currentPeriodEnd is the SQL for when a subscriptions row's current period ends: its
+// current_period_end, or derivedPeriodEnd for rows written before that was kept
+const currentPeriodEnd = `COALESCE(subscriptions.current_period_end, ` + derivedPeriodEnd + `)`
+
+// periodEndsBy is the SQL condition that a subscriptions row's current period ends at or
+// before the time param names. It compares current_period_end itself, so the range reads
+// idx_status_current_period_end, and works derivedPeriodEnd out only for the rows
+// written before it was kept, which sort first in the index as NULLs.
+func periodEndsBy(param string) string {
+	return `(current_period_end <= @` + param + `
+			       OR (current_period_end IS NULL AND ` + derivedPeriodEnd + ` <= @` + param + `))`
+}
+
+// derivedPeriodEnd is the SQL for when a subscriptions row's current period ends, as
+// domain.BillingCycle.PeriodEnd works it out in UTC: one interval of the row's plan,
+// with months and years clamped to the last day of a shorter month the way DATE_ADD
+// does, or @cycle_days days for plans without an interval and plans not in the catalog.
+// Rows written before current_period_start existed fall back to their start date.
+const derivedPeriodEnd = `(
+	SELECT CASE plan_interval
+		WHEN 'day' THEN TIMESTAMP_ADD(period_start, INTERVAL 1 DAY)
+		WHEN 'week' THEN TIMESTAMP_ADD(period_start, INTERVAL 7 DAY)
+		WHEN 'month' THEN TIMESTAMP_ADD(TIMESTAMP(DATE_ADD(DATE(period_start, "UTC"), INTERVAL 1 MONTH), "UTC"),
+			INTERVAL TIMESTAMP_DIFF(period_start, TIMESTAMP_TRUNC(period_start, DAY, "UTC"), MICROSECOND) MICROSECOND)
+		WHEN 'year' THEN TIMESTAMP_ADD(TIMESTAMP(DATE_ADD(DATE(period_start, "UTC"), INTERVAL 1 YEAR), "UTC"),
+			INTERVAL TIMESTAMP_DIFF(period_start, TIMESTAMP_TRUNC(period_start, DAY, "UTC"), MICROSECOND) MICROSECOND)
+		ELSE TIMESTAMP_ADD(period_start, INTERVAL @cycle_days DAY)
+	END
+	FROM (SELECT
+		COALESCE(subscriptions.current_period_start, subscriptions.start_date) AS period_start,
+		(SELECT plans.billing_interval FROM plans WHERE plans.id = subscriptions.plan_id) AS plan_interval)
+)`
 
 // SubscriptionRepo implements the subscription repository interface using Cloud Spanner
 type SubscriptionRepo struct {
 	client *spanner.Client
+	opts   options
 }
 
 // NewSubscriptionRepo creates a new subscription repository
-func NewSubscriptionRepo(client *spanner.Client) *SubscriptionRepo {
-	return &SubscriptionRepo{client: client}
+func NewSubscriptionRepo(client *spanner.Client, opts ...Option) *SubscriptionRepo {
+	defaults := WithQueryHints(map[string]QueryHints{
+		// Listings read their covering index whatever the optimizer's statistics say
+		"subscriptions.ListByCustomer": {ForceIndex: "idx_subscriptions_customer_status_start"},
+	})
+	return &SubscriptionRepo{client: client, opts: newOptions(append([]Option{defaults}, opts...))}
 }
 
 // Save returns a mutation for persisting a subscription to the database
-// The mutation must be applied using Apply() method
-func (r *SubscriptionRepo) Save(ctx context.Context, sub *domain.Subscription) (*spanner.Mutation, error) {
+// The mutation must be applied using ApplyChecked() with the version check returned.
+// It writes the version after the one the subscription was read at, and the commit
+// fails with domain.ErrConcurrentModification if the stored version has moved on.
+func (r *SubscriptionRepo) Save(ctx context.Context, sub *domain.Subscription) (*spanner.Mutation, contracts.VersionCheck, error) {
+	coupon := sub.Coupon()
+	reason := sub.CancellationReason()
 	mutation := spanner.InsertOrUpdate("subscriptions",
-		[]string{"id", "customer_id", "plan_id", "price_cents", "status", "start_date"},
+		[]string{"id", "customer_id", "plan_id", "price_cents", "status", "start_date", "current_period_start", "current_period_end", "dunning_attempts", "next_payment_retry_at", "cancelled_at", "payment_method_flagged_for", "trial_end_date", "renewal_notice_sent_for", "paused_at", "cancel_at", "coupon_code", "coupon_percent_off", "coupon_amount_off_cents", "coupon_periods_left", "cancellation_reason", "cancellation_reason_details", "version"},
 		[]any{
 			sub.ID(),
 			sub.CustomerID(),
@@ -34,22 +90,125 @@ func (r *SubscriptionRepo) Save(ctx context.Context, sub *domain.Subscription) (
 			sub.Price(),
 			string(sub.Status()),
 			sub.StartDate(),
+			sub.CurrentPeriodStart(),
+			nullTime(sub.RecordedPeriodEnd()),
+			sub.DunningAttempts(),
+			nullTime(sub.NextPaymentRetryAt()),
+			nullTime(sub.CancelledAt()),
+			nullTime(sub.PaymentMethodFlaggedFor()),
+			nullTime(sub.TrialEndDate()),
+			nullTime(sub.RenewalNoticeSentFor()),
+			nullTime(sub.PausedAt()),
+			nullTime(sub.CancelAt()),
+			nullString(coupon.Code),
+			couponInt(coupon, coupon.PercentOff),
+			couponInt(coupon, coupon.AmountOff),
+			couponInt(coupon, coupon.PeriodsLeft),
+			nullString(string(reason.Code)),
+			nullString(reason.Details),
+			sub.Version() + 1,
 		})
 
-	return mutation, nil
+	return mutation, contracts.VersionCheck{SubscriptionID: sub.ID(), Version: sub.Version()}, nil
 }
 
 // Apply applies the given mutations to the database
-func (r *SubscriptionRepo) Apply(ctx context.Context, mutations ...*spanner.Mutation) error {
-	_, err := r.client.Apply(ctx, mutations)
+func (r *SubscriptionRepo) Apply(ctx context.Context, mutations ...*spanner.Mutation) (err error) {
+	ctx, end, err := r.opts.begin(ctx, "subscriptions.Apply")
+	defer end(&err)
+	if err != nil {
+		return err
+	}
+
+	err = r.opts.apply(ctx, r.client, mutations)
+	return err
+}
+
+// ApplyChecked applies the given mutations to the database once the subscriptions
+// checked are still at the versions they were read at
+func (r *SubscriptionRepo) ApplyChecked(ctx context.Context, checks []contracts.VersionCheck, mutations ...*spanner.Mutation) (err error) {
+	ctx, end, err := r.opts.begin(ctx, "subscriptions.ApplyChecked")
+	defer end(&err)
+	if err != nil {
+		return err
+	}
+
+	err = r.opts.applyChecked(ctx, r.client, checks, mutations)
 	return err
 }
 
+// RunInTransaction runs fn in a Spanner read-write transaction. The subscriptions fn
+// reads through tx are locked until it commits, so a concurrent change waits for it or
+// aborts it, and an aborted transaction runs fn again from the start.
+func (r *SubscriptionRepo) RunInTransaction(ctx context.Context, fn func(ctx context.Context, tx contracts.SubscriptionTransaction) error) error {
+	ctx, end, err := r.opts.begin(ctx, "subscriptions.RunInTransaction")
+	if err != nil {
+		end(&err)
+		return err
+	}
+
+	var fnErr error
+	_, err = r.client.ReadWriteTransactionWithOptions(ctx, func(ctx context.Context, txn *spanner.ReadWriteTransaction) error {
+		fnErr = fn(ctx, &subscriptionTx{txn: txn, opts: r.opts})
+		return fnErr
+	}, r.opts.transactionOptions())
+
+	// fn's own errors, such as a subscription already cancelled, are not Spanner failures
+	failure := err
+	if errors.Is(err, fnErr) && spanner.ErrCode(fnErr) == codes.Unknown {
+		failure = nil
+	}
+	end(&failure)
+	return err
+}
+
+// subscriptionTx is a SubscriptionTransaction over a Spanner read-write transaction
+type subscriptionTx struct {
+	txn  *spanner.ReadWriteTransaction
+	opts options
+}
+
+// FindByID reads the subscription in the transaction
+func (t *subscriptionTx) FindByID(ctx context.Context, id string) (*domain.Subscription, error) {
+	iter := t.txn.QueryWithOptions(ctx, spanner.Statement{
+		SQL: `
+			SELECT ` + subscriptionColumns + `
+			FROM subscriptions
+			WHERE id = @id
+		`,
+		Params: map[string]any{
+			"id": id,
+		},
+	}, t.opts.queryOptions())
+	defer iter.Stop()
+
+	row, err := iter.Next()
+	if err != nil {
+		if err == iterator.Done {
+			return nil, domain.ErrSubscriptionNotFound
+		}
+		return nil, err
+	}
+
+	return scanSubscription(row)
+}
+
+// Apply buffers the mutations in the transaction
+func (t *subscriptionTx) Apply(ctx context.Context, mutations ...*spanner.Mutation) error {
+	return t.txn.BufferWrite(mutations)
+}
+
+// ApplyChecked buffers the mutations once the subscriptions checked are still at the
+// versions they were read at
+func (t *subscriptionTx) ApplyChecked(ctx context.Context, checks []contracts.VersionCheck, mutations ...*spanner.Mutation) error {
+	return bufferChecked(ctx, t.txn, checks, mutations)
+}
+
 // FindByID retrieves a subscription by ID
-func (r *SubscriptionRepo) FindByID(ctx context.Context, id string) (*domain.Subscription, error) {
+func (r *SubscriptionRepo) FindByID(ctx context.Context, id string) (_ *domain.Subscription, err error) {
 	stmt := spanner.Statement{
 		SQL: `
-			SELECT id, customer_id, plan_id, price_cents, status, start_date
+			SELECT ` + subscriptionColumns + `
 			FROM subscriptions
 			WHERE id = @id
 		`,
@@ -58,7 +217,13 @@ func (r *SubscriptionRepo) FindByID(ctx context.Context, id string) (*domain.Sub
 		},
 	}
 
-	iter := r.client.Single().Query(ctx, stmt)
+	ctx, end, err := r.opts.begin(ctx, "subscriptions.FindByID")
+	defer end(&err)
+	if err != nil {
+		return nil, err
+	}
+
+	iter := r.opts.single(r.client).Query(ctx, stmt)
 	defer iter.Stop()
 
 	row, err := iter.Next()
@@ -69,16 +234,393 @@ func (r *SubscriptionRepo) FindByID(ctx context.Context, id string) (*domain.Sub
 		return nil, err
 	}
 
+	return scanSubscription(row)
+}
+
+// FindDueForCancellation returns subscriptions pending cancellation whose cancel_at
+// is at or before now
+func (r *SubscriptionRepo) FindDueForCancellation(ctx context.Context, now time.Time, limit int) ([]*domain.Subscription, error) {
+	const op = "subscriptions.FindDueForCancellation"
+	stmt := spanner.Statement{
+		SQL: `
+			SELECT ` + subscriptionColumns + `
+			FROM ` + r.opts.from(op, "subscriptions") + `
+			WHERE status = @status
+			  AND cancel_at <= @now
+			ORDER BY cancel_at, id
+			LIMIT @limit
+		`,
+		Params: map[string]any{
+			"status": string(domain.StatusPendingCancellation),
+			"now":    now,
+			"limit":  int64(limit),
+		},
+	}
+
+	return r.query(ctx, op, stmt)
+}
+
+// FindRenewingUnflagged returns active subscriptions whose current period ends at or before
+// renewsBefore and whose payment method hasn't been flagged for that renewal yet. Periods
+// end as periodEndsBy works them out.
+func (r *SubscriptionRepo) FindRenewingUnflagged(ctx context.Context, renewsBefore time.Time, billingCycleDays int64, limit int) ([]*domain.Subscription, error) {
+	const op = "subscriptions.FindRenewingUnflagged"
+	stmt := spanner.Statement{
+		SQL: `
+			SELECT ` + subscriptionColumns + `
+			FROM ` + r.opts.from(op, "subscriptions") + `
+			WHERE status = @status
+			  AND ` + periodEndsBy("renews_before") + `
+			  AND (payment_method_flagged_for IS NULL
+			       OR payment_method_flagged_for != ` + currentPeriodEnd + `)
+			ORDER BY COALESCE(current_period_start, start_date), id
+			LIMIT @limit
+		`,
+		Params: map[string]any{
+			"status":        string(domain.StatusActive),
+			"cycle_days":    billingCycleDays,
+			"renews_before": renewsBefore,
+			"limit":         int64(limit),
+		},
+	}
+
+	return r.query(ctx, op, stmt)
+}
+
+// FindRenewingUnnoticed returns active subscriptions whose current period ends at or
+// before renewsBefore and whose customer hasn't been told about that renewal yet. Periods
+// end as periodEndsBy works them out.
+func (r *SubscriptionRepo) FindRenewingUnnoticed(ctx context.Context, renewsBefore time.Time, billingCycleDays int64, limit int) ([]*domain.Subscription, error) {
+	const op = "subscriptions.FindRenewingUnnoticed"
+	stmt := spanner.Statement{
+		SQL: `
+			SELECT ` + subscriptionColumns + `
+			FROM ` + r.opts.from(op, "subscriptions") + `
+			WHERE status = @status
+			  AND ` + periodEndsBy("renews_before") + `
+			  AND (renewal_notice_sent_for IS NULL
+			       OR renewal_notice_sent_for != ` + currentPeriodEnd + `)
+			ORDER BY COALESCE(current_period_start, start_date), id
+			LIMIT @limit
+		`,
+		Params: map[string]any{
+			"status":        string(domain.StatusActive),
+			"cycle_days":    billingCycleDays,
+			"renews_before": renewsBefore,
+			"limit":         int64(limit),
+		},
+	}
+
+	return r.query(ctx, op, stmt)
+}
+
+// FindByIDs returns those of the subscriptions that exist, read in one query
+func (r *SubscriptionRepo) FindByIDs(ctx context.Context, ids []string) ([]*domain.Subscription, error) {
+	stmt := spanner.Statement{
+		SQL: `
+			SELECT ` + subscriptionColumns + `
+			FROM subscriptions
+			WHERE id IN UNNEST(@ids)
+		`,
+		Params: map[string]any{
+			"ids": ids,
+		},
+	}
+
+	return r.query(ctx, "subscriptions.FindByIDs", stmt)
+}
+
+// FindIDsByCustomer returns the IDs of the customer's subscriptions that aren't cancelled
+func (r *SubscriptionRepo) FindIDsByCustomer(ctx context.Context, customerID string) ([]string, error) {
+	const op = "subscriptions.FindIDsByCustomer"
+	stmt := spanner.Statement{
+		SQL: `
+			SELECT id
+			FROM ` + r.opts.from(op, "subscriptions") + `
+			WHERE customer_id = @customer_id
+			  AND status != @cancelled
+			ORDER BY id
+		`,
+		Params: map[string]any{
+			"customer_id": customerID,
+			"cancelled":   string(domain.StatusCancelled),
+		},
+	}
+
+	return r.queryIDs(ctx, op, stmt)
+}
+
+// FindIDsByPlan returns the IDs of the plan's subscriptions that aren't cancelled
+func (r *SubscriptionRepo) FindIDsByPlan(ctx context.Context, planID string) ([]string, error) {
+	const op = "subscriptions.FindIDsByPlan"
+	stmt := spanner.Statement{
+		SQL: `
+			SELECT id
+			FROM ` + r.opts.from(op, "subscriptions") + `
+			WHERE plan_id = @plan_id
+			  AND status != @cancelled
+			ORDER BY id
+		`,
+		Params: map[string]any{
+			"plan_id":   planID,
+			"cancelled": string(domain.StatusCancelled),
+		},
+	}
+
+	return r.queryIDs(ctx, op, stmt)
+}
+
+// CountCancellationReasons groups the subscriptions cancelled from since by UTC month
+// of cancellation and reason code, read through idx_status_cancelled_at
+func (r *SubscriptionRepo) CountCancellationReasons(ctx context.Context, since time.Time) (_ []contracts.CancellationReasonCount, err error) {
+	ctx, end, err := r.opts.begin(ctx, "subscriptions.CountCancellationReasons")
+	defer end(&err)
+	if err != nil {
+		return nil, err
+	}
+
+	txn := r.opts.readOnlyTransaction(r.client)
+	defer txn.Close()
+
+	var counts []contracts.CancellationReasonCount
+	err = query(ctx, txn, spanner.Statement{
+		SQL: `
+			SELECT
+				DATE_TRUNC(DATE(cancelled_at, "UTC"), MONTH) AS month,
+				IFNULL(cancellation_reason, "") AS reason,
+				COUNT(*)
+			FROM subscriptions
+			WHERE status = @cancelled AND cancelled_at >= @since
+			GROUP BY month, reason
+			ORDER BY month, reason
+		`,
+		Params: map[string]any{
+			"cancelled": string(domain.StatusCancelled),
+			"since":     since,
+		},
+	}, func(row *spanner.Row) error {
+		var (
+			month  civil.Date
+			reason string
+			c      contracts.CancellationReasonCount
+		)
+		if err := row.Columns(&month, &reason, &c.Cancellations); err != nil {
+			return err
+		}
+		c.Month = month.In(time.UTC)
+		c.Reason = domain.CancellationReasonCode(reason)
+		counts = append(counts, c)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+// queryIDs runs the query op, whose rows are a single id column
+func (r *SubscriptionRepo) queryIDs(ctx context.Context, op string, stmt spanner.Statement) (_ []string, err error) {
+	ctx, end, err := r.opts.begin(ctx, op)
+	defer end(&err)
+	if err != nil {
+		return nil, err
+	}
+
+	iter := r.opts.single(r.client).Query(ctx, r.opts.hinted(op, stmt))
+	defer iter.Stop()
+
+	var ids []string
+	for {
+		row, err := iter.Next()
+		if err == iterator.Done {
+			return ids, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var id string
+		if err := row.Columns(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+}
+
+// ForEachByStatus streams the subscriptions of the status to fn as Spanner returns
+// them, so only the rows in flight are held in memory. The scan is read whole however
+// long it takes, so the timeout bounding single operations doesn't apply to it; fn
+// runs while the query is open and should hand slow work off rather than do it inline.
+func (r *SubscriptionRepo) ForEachByStatus(ctx context.Context, status domain.SubscriptionStatus, fn func(*domain.Subscription) error) (err error) {
+	const op = "subscriptions.ForEachByStatus"
+	stmt := spanner.Statement{
+		SQL: `
+			SELECT ` + subscriptionColumns + `
+			FROM ` + r.opts.from(op, "subscriptions") + `
+			WHERE status = @status
+		`,
+		Params: map[string]any{
+			"status": string(status),
+		},
+	}
+
+	scanOpts := r.opts
+	scanOpts.timeout = 0
+	ctx, end, err := scanOpts.begin(ctx, op)
+	defer end(&err)
+	if err != nil {
+		return err
+	}
+
+	iter := scanOpts.single(r.client).Query(ctx, scanOpts.hinted(op, stmt))
+	defer iter.Stop()
+	for {
+		row, err := iter.Next()
+		if err == iterator.Done {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		sub, err := scanSubscription(row)
+		if err != nil {
+			return err
+		}
+		if err := fn(sub); err != nil {
+			return err
+		}
+	}
+}
+
+// ListByCustomer returns up to limit of the customer's subscriptions after the cursor,
+// ordered by start date then ID. By default it reads only
+// idx_subscriptions_customer_status_start, which holds a customer's rows of each status
+// in that order.
+func (r *SubscriptionRepo) ListByCustomer(ctx context.Context, customerID string, status domain.SubscriptionStatus, after contracts.ListingCursor, limit int) (_ []contracts.SubscriptionSummary, err error) {
+	const op = "subscriptions.ListByCustomer"
+	filter := ""
+	if status != "" {
+		filter = "AND status = @status"
+	}
+	stmt := spanner.Statement{
+		SQL: `
+			SELECT id, customer_id, plan_id, price_cents, status, start_date
+			FROM ` + r.opts.from(op, "subscriptions") + `
+			WHERE customer_id = @customer_id
+			  ` + filter + `
+			  AND (start_date > @after_start OR (start_date = @after_start AND id > @after_id))
+			ORDER BY start_date, id
+			LIMIT @limit
+		`,
+		Params: map[string]any{
+			"customer_id": customerID,
+			"status":      string(status),
+			"after_start": after.StartDate,
+			"after_id":    after.ID,
+			"limit":       int64(limit),
+		},
+	}
+
+	ctx, end, err := r.opts.begin(ctx, op)
+	defer end(&err)
+	if err != nil {
+		return nil, err
+	}
+
+	iter := r.opts.single(r.client).Query(ctx, r.opts.hinted(op, stmt))
+	defer iter.Stop()
+
+	var page []contracts.SubscriptionSummary
+	for {
+		row, err := iter.Next()
+		if err == iterator.Done {
+			return page, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var (
+			sub    contracts.SubscriptionSummary
+			status string
+		)
+		if err := row.Columns(&sub.ID, &sub.CustomerID, &sub.PlanID, &sub.Price, &status, &sub.StartDate); err != nil {
+			return nil, err
+		}
+		sub.Status = domain.SubscriptionStatus(status)
+		page = append(page, sub)
+	}
+}
+
+// query runs a statement selecting subscriptionColumns, traced as op, and collects every row
+func (r *SubscriptionRepo) query(ctx context.Context, op string, stmt spanner.Statement) (_ []*domain.Subscription, err error) {
+	ctx, end, err := r.opts.begin(ctx, op)
+	defer end(&err)
+	if err != nil {
+		return nil, err
+	}
+
+	iter := r.opts.single(r.client).Query(ctx, r.opts.hinted(op, stmt))
+	defer iter.Stop()
+
+	var subs []*domain.Subscription
+	for {
+		row, err := iter.Next()
+		if err == iterator.Done {
+			return subs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		sub, err := scanSubscription(row)
+		if err != nil {
+			return nil, err
+		}
+		subs = append(subs, sub)
+	}
+}
+
+// nullTime stores zero times as NULL
+func nullTime(t time.Time) spanner.NullTime {
+	return spanner.NullTime{Time: t, Valid: !t.IsZero()}
+}
+
+// couponInt stores a field of the subscription's coupon, NULL when it has none
+func couponInt(coupon domain.SubscriptionCoupon, v int64) spanner.NullInt64 {
+	return spanner.NullInt64{Int64: v, Valid: !coupon.IsZero()}
+}
+
+// scanSubscription maps a row selected with subscriptionColumns to the aggregate
+func scanSubscription(row *spanner.Row) (*domain.Subscription, error) {
 	var (
-		dbID       string
-		customerID string
-		planID     string
-		priceCents int64
-		status     string
-		startDate  time.Time
+		dbID               string
+		customerID         string
+		planID             string
+		priceCents         int64
+		status             string
+		startDate          time.Time
+		currentPeriodStart spanner.NullTime
+		currentPeriodEnd   spanner.NullTime
+		dunningAttempts    spanner.NullInt64
+		nextPaymentRetryAt spanner.NullTime
+		cancelledAt        spanner.NullTime
+		pmFlaggedFor       spanner.NullTime
+		trialEndDate       spanner.NullTime
+		noticeSentFor      spanner.NullTime
+		pausedAt           spanner.NullTime
+		cancelAt           spanner.NullTime
+		couponCode         spanner.NullString
+		couponPercentOff   spanner.NullInt64
+		couponAmountOff    spanner.NullInt64
+		couponPeriodsLeft  spanner.NullInt64
+		reasonCode         spanner.NullString
+		reasonDetails      spanner.NullString
+		version            spanner.NullInt64
 	)
 
-	if err := row.Columns(&dbID, &customerID, &planID, &priceCents, &status, &startDate); err != nil {
+	if err := row.Columns(&dbID, &customerID, &planID, &priceCents, &status, &startDate, &currentPeriodStart, &currentPeriodEnd, &dunningAttempts, &nextPaymentRetryAt, &cancelledAt, &pmFlaggedFor, &trialEndDate, &noticeSentFor, &pausedAt, &cancelAt, &couponCode, &couponPercentOff, &couponAmountOff, &couponPeriodsLeft, &reasonCode, &reasonDetails, &version); err != nil {
 		return nil, err
 	}
 
@@ -89,6 +631,26 @@ func (r *SubscriptionRepo) FindByID(ctx context.Context, id string) (*domain.Sub
 		priceCents,
 		domain.SubscriptionStatus(status),
 		startDate,
+		domain.WithCurrentPeriodStart(currentPeriodStart.Time),
+		domain.WithCurrentPeriodEnd(currentPeriodEnd.Time),
+		domain.WithDunning(dunningAttempts.Int64, nextPaymentRetryAt.Time),
+		domain.WithCancelledAt(cancelledAt.Time),
+		domain.WithPaymentMethodFlaggedFor(pmFlaggedFor.Time),
+		domain.WithTrialEndDate(trialEndDate.Time),
+		domain.WithRenewalNoticeSentFor(noticeSentFor.Time),
+		domain.WithPausedAt(pausedAt.Time),
+		domain.WithCancelAt(cancelAt.Time),
+		domain.WithCoupon(domain.SubscriptionCoupon{
+			Code:        couponCode.StringVal,
+			PercentOff:  couponPercentOff.Int64,
+			AmountOff:   couponAmountOff.Int64,
+			PeriodsLeft: couponPeriodsLeft.Int64,
+		}),
+		domain.WithCancellationReason(domain.CancellationReason{
+			Code:    domain.CancellationReasonCode(reasonCode.StringVal),
+			Details: reasonDetails.StringVal,
+		}),
+		domain.WithVersion(version.Int64),
 	)
 
 	return sub, nil
@@ -0,0 +1,108 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"cloud.google.com/go/spanner"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"google.golang.org/grpc/codes"
+)
+
+var _ contracts.CancellationSurveyRepository = (*SurveyRepo)(nil)
+
+// SurveyRepo implements the cancellation survey repository interface using Cloud Spanner
+type SurveyRepo struct {
+	client *spanner.Client
+	opts   options
+}
+
+// NewSurveyRepo creates a new cancellation survey repository
+func NewSurveyRepo(client *spanner.Client, opts ...Option) *SurveyRepo {
+	return &SurveyRepo{client: client, opts: newOptions(opts)}
+}
+
+// Insert saves the response and its answers in one transaction. The response is
+// inserted, not upserted, and the unique index on the cancellation makes a second
+// response to the same cancellation fail.
+func (r *SurveyRepo) Insert(ctx context.Context, response *domain.SurveyResponse) (err error) {
+	ctx, end, err := r.opts.begin(ctx, "cancellation_surveys.Insert")
+	defer end(&err)
+	if err != nil {
+		return err
+	}
+
+	mutations := []*spanner.Mutation{spanner.Insert("cancellation_survey_responses",
+		[]string{"id", "subscription_id", "customer_id", "plan_id", "survey_version", "cancelled_at", "submitted_at"},
+		[]any{
+			response.ID(),
+			response.SubscriptionID(),
+			response.CustomerID(),
+			response.PlanID(),
+			response.SurveyVersion(),
+			response.CancelledAt(),
+			response.SubmittedAt(),
+		})}
+	for _, a := range response.Answers() {
+		mutations = append(mutations, spanner.Insert("cancellation_survey_answers",
+			[]string{"response_id", "question_id", "kind", "value"},
+			[]any{response.ID(), a.QuestionID, string(a.Kind), a.Value},
+		))
+	}
+
+	_, err = r.client.Apply(ctx, mutations, r.opts.applyOptions()...)
+	if spanner.ErrCode(err) == codes.AlreadyExists {
+		return domain.ErrSurveyAlreadySubmitted
+	}
+	return err
+}
+
+// CountAnswers groups the choice and rating answers of responses submitted from since
+// by UTC submission month, question and answer
+func (r *SurveyRepo) CountAnswers(ctx context.Context, since time.Time) (_ []contracts.SurveyAnswerCount, err error) {
+	ctx, end, err := r.opts.begin(ctx, "cancellation_surveys.CountAnswers")
+	defer end(&err)
+	if err != nil {
+		return nil, err
+	}
+
+	txn := r.opts.readOnlyTransaction(r.client)
+	defer txn.Close()
+
+	var counts []contracts.SurveyAnswerCount
+	err = query(ctx, txn, spanner.Statement{
+		SQL: `
+			SELECT
+				DATE_TRUNC(DATE(r.submitted_at, "UTC"), MONTH) AS month,
+				a.question_id,
+				a.value,
+				COUNT(*)
+			FROM cancellation_survey_answers a
+			JOIN cancellation_survey_responses r ON r.id = a.response_id
+			WHERE r.submitted_at >= @since AND a.kind != @text
+			GROUP BY month, a.question_id, a.value
+			ORDER BY month, a.question_id, a.value
+		`,
+		Params: map[string]any{
+			"since": since,
+			"text":  string(domain.SurveyText),
+		},
+	}, func(row *spanner.Row) error {
+		var (
+			month civil.Date
+			c     contracts.SurveyAnswerCount
+		)
+		if err := row.Columns(&month, &c.QuestionID, &c.Value, &c.Responses); err != nil {
+			return err
+		}
+		c.Month = month.In(time.UTC)
+		counts = append(counts, c)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
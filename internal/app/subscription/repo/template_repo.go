@@ -0,0 +1,97 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+)
+
+var _ contracts.SubscriptionTemplateRepository = (*TemplateRepo)(nil)
+
+// TemplateRepo implements the subscription template repository interface using Cloud Spanner
+type TemplateRepo struct {
+	client *spanner.Client
+	opts   options
+}
+
+// NewTemplateRepo creates a new subscription template repository
+func NewTemplateRepo(client *spanner.Client, opts ...Option) *TemplateRepo {
+	return &TemplateRepo{client: client, opts: newOptions(opts)}
+}
+
+// Insert saves the template and its bundle in one transaction. The template is
+// inserted, not upserted, and the unique index on its name makes a second template
+// with the same name fail.
+func (r *TemplateRepo) Insert(ctx context.Context, template *domain.SubscriptionTemplate) (err error) {
+	ctx, end, err := r.opts.begin(ctx, "subscription_templates.Insert")
+	defer end(&err)
+	if err != nil {
+		return err
+	}
+
+	mutations := append([]*spanner.Mutation{spanner.Insert("subscription_templates",
+		[]string{"id", "name", "plan_id", "price_cents", "trial_days", "created_at"},
+		[]any{
+			template.ID(),
+			template.Name(),
+			template.PlanID(),
+			template.Price(),
+			template.TrialDays(),
+			template.CreatedAt(),
+		})}, templateBundleTables.insert(template.ID(), template.Bundle())...)
+
+	_, err = r.client.Apply(ctx, mutations, r.opts.applyOptions()...)
+	if spanner.ErrCode(err) == codes.AlreadyExists {
+		return domain.ErrTemplateNameTaken
+	}
+	return err
+}
+
+// FindByID retrieves a template and its bundle by ID
+func (r *TemplateRepo) FindByID(ctx context.Context, id string) (_ *domain.SubscriptionTemplate, err error) {
+	ctx, end, err := r.opts.begin(ctx, "subscription_templates.FindByID")
+	defer end(&err)
+	if err != nil {
+		return nil, err
+	}
+
+	txn := r.opts.readOnlyTransaction(r.client)
+	defer txn.Close()
+
+	iter := txn.Query(ctx, spanner.Statement{
+		SQL:    `SELECT id, name, plan_id, price_cents, trial_days, created_at FROM subscription_templates WHERE id = @id`,
+		Params: map[string]any{"id": id},
+	})
+	defer iter.Stop()
+
+	row, err := iter.Next()
+	if err != nil {
+		if err == iterator.Done {
+			return nil, domain.ErrTemplateNotFound
+		}
+		return nil, err
+	}
+
+	var (
+		templateID string
+		name       string
+		planID     string
+		price      int64
+		trialDays  int64
+		createdAt  time.Time
+	)
+	if err := row.Columns(&templateID, &name, &planID, &price, &trialDays, &createdAt); err != nil {
+		return nil, err
+	}
+
+	bundle, err := templateBundleTables.read(ctx, txn, templateID)
+	if err != nil {
+		return nil, err
+	}
+	return domain.ReconstructSubscriptionTemplate(templateID, name, planID, price, trialDays, bundle, createdAt), nil
+}
@@ -0,0 +1,127 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"google.golang.org/grpc/codes"
+)
+
+var _ contracts.UsageRepository = (*UsageRepo)(nil)
+
+// UsageRepo implements the usage repository interface using Cloud Spanner
+type UsageRepo struct {
+	client *spanner.Client
+	opts   options
+}
+
+// NewUsageRepo creates a new usage repository
+func NewUsageRepo(client *spanner.Client, opts ...Option) *UsageRepo {
+	return &UsageRepo{client: client, opts: newOptions(opts)}
+}
+
+// Save returns a mutation for persisting a usage record to the database
+// The mutation must be applied using Apply() method
+func (r *UsageRepo) Save(ctx context.Context, record *domain.UsageRecord) (*spanner.Mutation, error) {
+	mutation := spanner.Insert("usage_records",
+		[]string{"id", "subscription_id", "customer_id", "metric", "quantity", "period_start", "recorded_at"},
+		[]any{
+			record.ID(),
+			record.SubscriptionID(),
+			record.CustomerID(),
+			record.Metric(),
+			record.Quantity(),
+			record.PeriodStart(),
+			record.RecordedAt(),
+		})
+
+	return mutation, nil
+}
+
+// FindPeriodUsage sums the period's records and reads its alerts in one read-only
+// transaction
+func (r *UsageRepo) FindPeriodUsage(ctx context.Context, subscriptionID, metric string, periodStart time.Time) (_ domain.PeriodUsage, err error) {
+	ctx, end, err := r.opts.begin(ctx, "usage_records.FindPeriodUsage")
+	defer end(&err)
+	if err != nil {
+		return domain.PeriodUsage{}, err
+	}
+
+	txn := r.opts.readOnlyTransaction(r.client)
+	defer txn.Close()
+
+	params := map[string]any{
+		"subscription_id": subscriptionID,
+		"metric":          metric,
+		"period_start":    periodStart,
+	}
+
+	var usage domain.PeriodUsage
+	err = query(ctx, txn, spanner.Statement{
+		SQL: `
+			SELECT IFNULL(SUM(quantity), 0)
+			FROM usage_records
+			WHERE subscription_id = @subscription_id AND metric = @metric AND period_start = @period_start
+		`,
+		Params: params,
+	}, func(row *spanner.Row) error {
+		return row.Columns(&usage.Quantity)
+	})
+	if err != nil {
+		return domain.PeriodUsage{}, err
+	}
+
+	err = query(ctx, txn, spanner.Statement{
+		SQL: `
+			SELECT threshold_bp
+			FROM usage_alerts
+			WHERE subscription_id = @subscription_id AND metric = @metric AND period_start = @period_start
+		`,
+		Params: params,
+	}, func(row *spanner.Row) error {
+		var threshold int64
+		if err := row.Columns(&threshold); err != nil {
+			return err
+		}
+		usage.Alerted = append(usage.Alerted, threshold)
+		return nil
+	})
+	if err != nil {
+		return domain.PeriodUsage{}, err
+	}
+	return usage, nil
+}
+
+// ClaimAlert inserts the alert's row. It is inserted, not upserted, so a second claim
+// of the same threshold and period fails instead of alerting twice.
+func (r *UsageRepo) ClaimAlert(ctx context.Context, event *domain.UsageThresholdReachedEvent) (err error) {
+	ctx, end, err := r.opts.begin(ctx, "usage_alerts.ClaimAlert")
+	defer end(&err)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.client.Apply(ctx, []*spanner.Mutation{spanner.Insert("usage_alerts",
+		[]string{"subscription_id", "metric", "period_start", "threshold_bp", "quantity", "included", "reached_at"},
+		[]any{event.SubscriptionID, event.Metric, event.PeriodStart, event.ThresholdBP, event.Quantity, event.Included, event.ReachedAt},
+	)}, r.opts.applyOptions()...)
+	if spanner.ErrCode(err) == codes.AlreadyExists {
+		return domain.ErrUsageAlertAlreadySent
+	}
+	return err
+}
+
+// Apply applies the given mutations to the database in one transaction
+func (r *UsageRepo) Apply(ctx context.Context, mutations ...*spanner.Mutation) (err error) {
+	ctx, end, err := r.opts.begin(ctx, "usage_records.Apply")
+	defer end(&err)
+	if err != nil {
+		return err
+	}
+
+	err = r.opts.apply(ctx, r.client, mutations)
+	return err
+}
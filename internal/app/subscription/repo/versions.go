@@ -0,0 +1,83 @@
+package repo
+
+import (
+	"context"
+	"errors"
+
+	"cloud.google.com/go/spanner"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+// apply commits mutations in one transaction
+func (o options) apply(ctx context.Context, client *spanner.Client, mutations []*spanner.Mutation) error {
+	_, err := client.Apply(ctx, mutations, o.applyOptions()...)
+	o.committed(mutations)
+	return err
+}
+
+// applyChecked commits mutations in one read-write transaction, which first reads the
+// stored versions of the subscriptions checked and commits nothing, with
+// domain.ErrConcurrentModification, if any has moved on since they were read
+func (o options) applyChecked(ctx context.Context, client *spanner.Client, checks []contracts.VersionCheck, mutations []*spanner.Mutation) error {
+	if len(checks) == 0 {
+		return o.apply(ctx, client, mutations)
+	}
+
+	_, err := client.ReadWriteTransactionWithOptions(ctx, func(ctx context.Context, txn *spanner.ReadWriteTransaction) error {
+		return bufferChecked(ctx, txn, checks, mutations)
+	}, o.transactionOptions())
+	o.committed(mutations)
+	if errors.Is(err, domain.ErrConcurrentModification) {
+		return domain.ErrConcurrentModification
+	}
+	return err
+}
+
+// committed reports mutations to WithCommitted's function, if any
+func (o options) committed(mutations []*spanner.Mutation) {
+	if o.commit != nil {
+		o.commit(mutations...)
+	}
+}
+
+// bufferChecked buffers mutations in txn once the subscriptions checked are still at
+// the versions they were read at
+func bufferChecked(ctx context.Context, txn *spanner.ReadWriteTransaction, checks []contracts.VersionCheck, mutations []*spanner.Mutation) error {
+	if len(checks) > 0 {
+		stored, err := storedVersions(ctx, txn, checks)
+		if err != nil {
+			return err
+		}
+		for _, check := range checks {
+			if stored[check.SubscriptionID] != check.Version {
+				return domain.ErrConcurrentModification
+			}
+		}
+	}
+	return txn.BufferWrite(mutations)
+}
+
+// storedVersions reads the versions of the checked subscriptions, locking their rows
+// until the transaction commits. Subscriptions not stored yet, and rows not saved
+// since versions were added, are at version 0.
+func storedVersions(ctx context.Context, txn *spanner.ReadWriteTransaction, checks []contracts.VersionCheck) (map[string]int64, error) {
+	keys := make([]spanner.KeySet, len(checks))
+	for i, check := range checks {
+		keys[i] = spanner.Key{check.SubscriptionID}
+	}
+
+	stored := make(map[string]int64, len(checks))
+	err := txn.Read(ctx, "subscriptions", spanner.KeySets(keys...), []string{"id", "version"}).Do(func(row *spanner.Row) error {
+		var (
+			id      string
+			version spanner.NullInt64
+		)
+		if err := row.Columns(&id, &version); err != nil {
+			return err
+		}
+		stored[id] = version.Int64
+		return nil
+	})
+	return stored, err
+}
@@ -0,0 +1,36 @@
+package testkit
+
+import (
+	"context"
+	"sync"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+)
+
+var _ contracts.AuditLog = (*RecordingAuditLog)(nil)
+
+// RecordingAuditLog is an AuditLog that keeps the entries it is given, and fails with
+// Err when set. It is safe for concurrent use.
+type RecordingAuditLog struct {
+	Err error
+
+	mu      sync.Mutex
+	entries []contracts.AuditEntry
+}
+
+// Entries returns the entries recorded so far
+func (l *RecordingAuditLog) Entries() []contracts.AuditEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]contracts.AuditEntry(nil), l.entries...)
+}
+
+func (l *RecordingAuditLog) Record(ctx context.Context, entry contracts.AuditEntry) error {
+	if l.Err != nil {
+		return l.Err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append(l.entries, entry)
+	return nil
+}
@@ -0,0 +1,101 @@
+package testkit
+
+import (
+	"context"
+	"sync"
+
+	"cloud.google.com/go/spanner"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+var (
+	_ contracts.ChargeAuthenticationRepository = (*FakeAuthentications)(nil)
+	_ contracts.AuthenticationNotifier         = (*RecordingAuthenticationRequests)(nil)
+)
+
+// FakeAuthentications is an in-memory ChargeAuthenticationRepository. Authentications
+// are stored as soon as they are saved; Apply only counts its calls. It is safe for
+// concurrent use. The zero value is not usable; call NewFakeAuthentications.
+type FakeAuthentications struct {
+	mu              sync.Mutex
+	authentications map[string]*domain.ChargeAuthentication // by provider payment ID
+	applied         int
+}
+
+// NewFakeAuthentications returns a fake with no authentications
+func NewFakeAuthentications() *FakeAuthentications {
+	return &FakeAuthentications{authentications: make(map[string]*domain.ChargeAuthentication)}
+}
+
+// WithAuthentication stores an authentication as if a renewal had saved it
+func (f *FakeAuthentications) WithAuthentication(a *domain.ChargeAuthentication) *FakeAuthentications {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.authentications[a.ProviderPaymentID()] = a
+	return f
+}
+
+// Authentication returns the authentication saved for the provider's payment, nil if there is none
+func (f *FakeAuthentications) Authentication(providerPaymentID string) *domain.ChargeAuthentication {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.authentications[providerPaymentID]
+}
+
+// Applied returns how many times Apply was called
+func (f *FakeAuthentications) Applied() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.applied
+}
+
+func (f *FakeAuthentications) Save(ctx context.Context, a *domain.ChargeAuthentication) (*spanner.Mutation, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.authentications[a.ProviderPaymentID()] = a
+	return &spanner.Mutation{}, nil
+}
+
+func (f *FakeAuthentications) FindByProviderPaymentID(ctx context.Context, providerPaymentID string) (*domain.ChargeAuthentication, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	a, ok := f.authentications[providerPaymentID]
+	if !ok {
+		return nil, domain.ErrAuthenticationNotFound
+	}
+	return a, nil
+}
+
+func (f *FakeAuthentications) Apply(ctx context.Context, mutations ...*spanner.Mutation) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.applied++
+	return nil
+}
+
+// RecordingAuthenticationRequests is an AuthenticationNotifier that keeps the requests
+// it is sent, and fails with Err when set. It is safe for concurrent use.
+type RecordingAuthenticationRequests struct {
+	Err error
+
+	mu       sync.Mutex
+	requests []*domain.AuthenticationRequiredEvent
+}
+
+// Requests returns the requests sent so far
+func (n *RecordingAuthenticationRequests) Requests() []*domain.AuthenticationRequiredEvent {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]*domain.AuthenticationRequiredEvent(nil), n.requests...)
+}
+
+func (n *RecordingAuthenticationRequests) NotifyAuthenticationRequired(ctx context.Context, event *domain.AuthenticationRequiredEvent) error {
+	if n.Err != nil {
+		return n.Err
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.requests = append(n.requests, event)
+	return nil
+}
@@ -0,0 +1,303 @@
+// Package testkit provides fakes for exercising use cases and adapters in tests without
+// network access or hand-written mock expectations.
+package testkit
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+var _ contracts.BillingClient = (*FakeBillingClient)(nil)
+
+// Op names a BillingClient method
+type Op string
+
+const (
+	OpValidateCustomer       Op = "ValidateCustomer"
+	OpCreateCustomer         Op = "CreateCustomer"
+	OpProcessRefund          Op = "ProcessRefund"
+	OpGetRefundStatus        Op = "GetRefundStatus"
+	OpChargeCustomer         Op = "ChargeCustomer"
+	OpGetPaymentMethodStatus Op = "GetPaymentMethodStatus"
+)
+
+// Call is one recorded call to the fake; only the fields of its Op are set
+type Call struct {
+	Op               Op
+	CustomerID       string
+	Customer         contracts.CreateCustomerRequest
+	Refund           contracts.RefundRequest
+	Charge           contracts.ChargeRequest
+	ProviderRefundID string
+	Err              error
+}
+
+// script holds the scripted behavior of one Op
+type script struct {
+	failFirst int
+	failErr   error
+	always    error
+	delay     time.Duration
+	calls     int
+}
+
+// FakeBillingClient is an in-memory BillingClient whose behavior is scripted per operation.
+// Every call is recorded, refunds are deduplicated by idempotency key like the real API,
+// and it is safe for concurrent use. The zero value is not usable; call NewFakeBillingClient.
+type FakeBillingClient struct {
+	mu             sync.Mutex
+	scripts        map[Op]*script
+	invalid        map[string]bool
+	calls          []Call
+	refundsByKey   map[string]string
+	refundOutcomes map[string]contracts.RefundOutcome
+	defaultOutcome contracts.RefundOutcome
+	nextRefund     int
+	paymentMethods map[string]domain.PaymentMethod
+	chargeCurrency map[string]string // subscription ID to the currency it was charged in
+}
+
+// NewFakeBillingClient returns a fake that accepts every customer, charge and refund,
+// reports refunds as pending, and gives every customer a valid card that doesn't expire
+func NewFakeBillingClient() *FakeBillingClient {
+	return &FakeBillingClient{
+		scripts:        make(map[Op]*script),
+		invalid:        make(map[string]bool),
+		refundsByKey:   make(map[string]string),
+		refundOutcomes: make(map[string]contracts.RefundOutcome),
+		defaultOutcome: contracts.RefundOutcome{Status: domain.RefundPending},
+		paymentMethods: make(map[string]domain.PaymentMethod),
+		chargeCurrency: make(map[string]string),
+	}
+}
+
+// RejectCustomers makes ValidateCustomer fail with ErrInvalidCustomer for the given customers
+func (f *FakeBillingClient) RejectCustomers(customerIDs ...string) *FakeBillingClient {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, id := range customerIDs {
+		f.invalid[id] = true
+	}
+	return f
+}
+
+// FailFirst makes the next n calls to op fail with err; later calls behave normally
+func (f *FakeBillingClient) FailFirst(op Op, n int, err error) *FakeBillingClient {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	s := f.script(op)
+	s.failFirst, s.failErr, s.calls = n, err, 0
+	return f
+}
+
+// FailAlways makes every call to op fail with err; nil restores normal behavior
+func (f *FakeBillingClient) FailAlways(op Op, err error) *FakeBillingClient {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.script(op).always = err
+	return f
+}
+
+// Delay makes every call to op take d. A call whose context ends first returns the context's error.
+func (f *FakeBillingClient) Delay(op Op, d time.Duration) *FakeBillingClient {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.script(op).delay = d
+	return f
+}
+
+// SettleRefunds sets the outcome GetRefundStatus reports for refunds without their own outcome
+func (f *FakeBillingClient) SettleRefunds(outcome contracts.RefundOutcome) *FakeBillingClient {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.defaultOutcome = outcome
+	return f
+}
+
+// SettleRefund sets the outcome GetRefundStatus reports for one provider refund ID
+func (f *FakeBillingClient) SettleRefund(providerRefundID string, outcome contracts.RefundOutcome) *FakeBillingClient {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.refundOutcomes[providerRefundID] = outcome
+	return f
+}
+
+// SetPaymentMethod sets the payment method GetPaymentMethodStatus reports for a customer
+func (f *FakeBillingClient) SetPaymentMethod(customerID string, pm domain.PaymentMethod) *FakeBillingClient {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.paymentMethods[customerID] = pm
+	return f
+}
+
+// ChargedIn records that a subscription was charged in currency, as if ChargeCustomer had
+// charged it. Refunds for it in any other currency fail with *domain.CurrencyMismatchError.
+func (f *FakeBillingClient) ChargedIn(subscriptionID, currency string) *FakeBillingClient {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.chargeCurrency[subscriptionID] = currency
+	return f
+}
+
+// Calls returns every call made so far, in order
+func (f *FakeBillingClient) Calls() []Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Call(nil), f.calls...)
+}
+
+// CallsTo returns the calls made to op, in order
+func (f *FakeBillingClient) CallsTo(op Op) []Call {
+	var calls []Call
+	for _, call := range f.Calls() {
+		if call.Op == op {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+func (f *FakeBillingClient) ValidateCustomer(ctx context.Context, customerID string) error {
+	call := Call{Op: OpValidateCustomer, CustomerID: customerID}
+	err := f.begin(ctx, OpValidateCustomer)
+	if err == nil {
+		f.mu.Lock()
+		if f.invalid[customerID] {
+			err = domain.ErrInvalidCustomer
+		}
+		f.mu.Unlock()
+	}
+	f.record(call, err)
+	return err
+}
+
+func (f *FakeBillingClient) CreateCustomer(ctx context.Context, req contracts.CreateCustomerRequest) error {
+	err := f.begin(ctx, OpCreateCustomer)
+	f.record(Call{Op: OpCreateCustomer, CustomerID: req.CustomerID, Customer: req}, err)
+	return err
+}
+
+func (f *FakeBillingClient) ProcessRefund(ctx context.Context, req contracts.RefundRequest) (string, error) {
+	call := Call{Op: OpProcessRefund, CustomerID: req.CustomerID, Refund: req}
+	if err := f.begin(ctx, OpProcessRefund); err != nil {
+		f.record(call, err)
+		return "", err
+	}
+
+	f.mu.Lock()
+	if charged, ok := f.chargeCurrency[req.SubscriptionID]; ok {
+		if err := domain.CheckRefundCurrency(charged, req.Currency); err != nil {
+			f.mu.Unlock()
+			f.record(call, err)
+			return "", err
+		}
+	}
+	refundID, replayed := f.refundsByKey[req.IdempotencyKey]
+	if !replayed || req.IdempotencyKey == "" {
+		f.nextRefund++
+		refundID = fmt.Sprintf("fake-refund-%d", f.nextRefund)
+		if req.IdempotencyKey != "" {
+			f.refundsByKey[req.IdempotencyKey] = refundID
+		}
+	}
+	f.mu.Unlock()
+
+	f.record(call, nil)
+	return refundID, nil
+}
+
+func (f *FakeBillingClient) GetRefundStatus(ctx context.Context, providerRefundID string) (contracts.RefundOutcome, error) {
+	call := Call{Op: OpGetRefundStatus, ProviderRefundID: providerRefundID}
+	if err := f.begin(ctx, OpGetRefundStatus); err != nil {
+		f.record(call, err)
+		return contracts.RefundOutcome{}, err
+	}
+
+	f.mu.Lock()
+	outcome, ok := f.refundOutcomes[providerRefundID]
+	if !ok {
+		outcome = f.defaultOutcome
+	}
+	f.mu.Unlock()
+
+	f.record(call, nil)
+	return outcome, nil
+}
+
+func (f *FakeBillingClient) ChargeCustomer(ctx context.Context, req contracts.ChargeRequest) error {
+	err := f.begin(ctx, OpChargeCustomer)
+	if err == nil && req.Currency != "" {
+		f.mu.Lock()
+		f.chargeCurrency[req.SubscriptionID] = req.Currency
+		f.mu.Unlock()
+	}
+	f.record(Call{Op: OpChargeCustomer, CustomerID: req.CustomerID, Charge: req}, err)
+	return err
+}
+
+func (f *FakeBillingClient) GetPaymentMethodStatus(ctx context.Context, customerID string) (domain.PaymentMethod, error) {
+	call := Call{Op: OpGetPaymentMethodStatus, CustomerID: customerID}
+	if err := f.begin(ctx, OpGetPaymentMethodStatus); err != nil {
+		f.record(call, err)
+		return domain.PaymentMethod{}, err
+	}
+
+	f.mu.Lock()
+	pm, ok := f.paymentMethods[customerID]
+	if !ok {
+		pm = domain.PaymentMethod{Valid: true}
+	}
+	f.mu.Unlock()
+
+	f.record(call, nil)
+	return pm, nil
+}
+
+// begin applies the scripted delay and failures for one call to op
+func (f *FakeBillingClient) begin(ctx context.Context, op Op) error {
+	f.mu.Lock()
+	s := f.script(op)
+	s.calls++
+	delay := s.delay
+	var err error
+	switch {
+	case s.always != nil:
+		err = s.always
+	case s.calls <= s.failFirst:
+		err = s.failErr
+	}
+	f.mu.Unlock()
+
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+	return err
+}
+
+func (f *FakeBillingClient) record(call Call, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	call.Err = err
+	f.calls = append(f.calls, call)
+}
+
+// script returns the script for op, creating it; callers hold f.mu
+func (f *FakeBillingClient) script(op Op) *script {
+	s, ok := f.scripts[op]
+	if !ok {
+		s = &script{}
+		f.scripts[op] = s
+	}
+	return s
+}
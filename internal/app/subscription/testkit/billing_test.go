@@ -0,0 +1,95 @@
+package testkit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+var errUnavailable = errors.New("billing unavailable")
+
+func TestFakeBillingClient_FailFirst(t *testing.T) {
+	ctx := context.Background()
+	fake := NewFakeBillingClient().FailFirst(OpProcessRefund, 2, errUnavailable)
+	req := contracts.RefundRequest{SubscriptionID: "sub-1", Amount: 1000}
+
+	_, err1 := fake.ProcessRefund(ctx, req)
+	_, err2 := fake.ProcessRefund(ctx, req)
+	refundID, err3 := fake.ProcessRefund(ctx, req)
+
+	assert.ErrorIs(t, err1, errUnavailable)
+	assert.ErrorIs(t, err2, errUnavailable)
+	assert.NoError(t, err3)
+	assert.Equal(t, "fake-refund-1", refundID)
+
+	calls := fake.CallsTo(OpProcessRefund)
+	assert.Len(t, calls, 3)
+	assert.ErrorIs(t, calls[0].Err, errUnavailable)
+	assert.NoError(t, calls[2].Err)
+}
+
+func TestFakeBillingClient_DeduplicatesRefundsByIdempotencyKey(t *testing.T) {
+	ctx := context.Background()
+	fake := NewFakeBillingClient()
+	req := contracts.RefundRequest{SubscriptionID: "sub-1", Amount: 1000, IdempotencyKey: "sub-1:0:refund"}
+
+	first, _ := fake.ProcessRefund(ctx, req)
+	second, _ := fake.ProcessRefund(ctx, req)
+	other, _ := fake.ProcessRefund(ctx, contracts.RefundRequest{SubscriptionID: "sub-2", Amount: 1000})
+
+	assert.Equal(t, first, second)
+	assert.NotEqual(t, first, other)
+}
+
+func TestFakeBillingClient_RejectsCustomers(t *testing.T) {
+	ctx := context.Background()
+	fake := NewFakeBillingClient().RejectCustomers("cust-bad")
+
+	assert.NoError(t, fake.ValidateCustomer(ctx, "cust-good"))
+	assert.ErrorIs(t, fake.ValidateCustomer(ctx, "cust-bad"), domain.ErrInvalidCustomer)
+	assert.Len(t, fake.Calls(), 2)
+}
+
+func TestFakeBillingClient_DelayHonoursContext(t *testing.T) {
+	fake := NewFakeBillingClient().Delay(OpChargeCustomer, time.Hour)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err := fake.ChargeCustomer(ctx, contracts.ChargeRequest{CustomerID: "cust-1", Amount: 1000})
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestFakeBillingClient_RefundOutcomes(t *testing.T) {
+	ctx := context.Background()
+	succeeded := contracts.RefundOutcome{Status: domain.RefundSucceeded}
+	failed := contracts.RefundOutcome{Status: domain.RefundFailed, FailureReason: "card expired"}
+	fake := NewFakeBillingClient().SettleRefunds(succeeded).SettleRefund("refund-2", failed)
+
+	outcome1, _ := fake.GetRefundStatus(ctx, "refund-1")
+	outcome2, _ := fake.GetRefundStatus(ctx, "refund-2")
+
+	assert.Equal(t, succeeded, outcome1)
+	assert.Equal(t, failed, outcome2)
+}
+
+func TestFakeBillingClient_RejectsRefundInAnotherCurrency(t *testing.T) {
+	ctx := context.Background()
+	fake := NewFakeBillingClient()
+	assert.NoError(t, fake.ChargeCustomer(ctx, contracts.ChargeRequest{SubscriptionID: "sub-1", Amount: 1000, Currency: "EUR"}))
+
+	_, err := fake.ProcessRefund(ctx, contracts.RefundRequest{SubscriptionID: "sub-1", Amount: 500, Currency: "USD"})
+
+	var mismatch *domain.CurrencyMismatchError
+	assert.ErrorAs(t, err, &mismatch)
+	assert.Equal(t, "EUR", mismatch.ChargeCurrency)
+	assert.ErrorIs(t, err, domain.ErrCurrencyMismatch)
+
+	_, err = fake.ProcessRefund(ctx, contracts.RefundRequest{SubscriptionID: "sub-1", Amount: 500, Currency: "EUR"})
+	assert.NoError(t, err)
+}
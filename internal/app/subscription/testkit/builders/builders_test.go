@@ -0,0 +1,103 @@
+package builders
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+func TestSubscriptionBuilder_Defaults(t *testing.T) {
+	sub := NewSubscriptionBuilder().Build()
+
+	assert.Equal(t, domain.ReconstructFromPersistence("sub-123", "cust-456", "plan-789", 3000, domain.StatusActive, DefaultStartDate), sub)
+}
+
+func TestSubscriptionBuilder_Cancelled(t *testing.T) {
+	cancelledAt := DefaultStartDate.AddDate(0, 0, 12)
+
+	sub := NewSubscriptionBuilder().CancelledAt(cancelledAt).WithPrice(4500).Build()
+
+	assert.Equal(t, domain.StatusCancelled, sub.Status())
+	assert.Equal(t, cancelledAt, sub.CancelledAt())
+	assert.Equal(t, int64(4500), sub.Price())
+	assert.Equal(t, DefaultStartDate, NewSubscriptionBuilder().Cancelled().Build().CancelledAt())
+}
+
+func TestSubscriptionBuilder_States(t *testing.T) {
+	retryAt := DefaultStartDate.AddDate(0, 1, 3)
+	pastDue := NewSubscriptionBuilder().InDunning(2, retryAt).Build()
+	assert.Equal(t, domain.StatusPastDue, pastDue.Status())
+	assert.Equal(t, int64(2), pastDue.DunningAttempts())
+	assert.Equal(t, retryAt, pastDue.NextPaymentRetryAt())
+
+	started := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	trial := NewSubscriptionBuilder().Trialing(14).StartedAt(started).Build()
+	assert.Equal(t, domain.StatusTrialing, trial.Status())
+	assert.Equal(t, started.AddDate(0, 0, 14), trial.TrialEndDate())
+
+	periodStart := DefaultStartDate.AddDate(0, 0, 60)
+	renewed := NewSubscriptionBuilder().InPeriodFrom(periodStart).Build()
+	assert.Equal(t, periodStart, renewed.CurrentPeriodStart())
+	assert.Equal(t, DefaultStartDate, renewed.StartDate())
+}
+
+func TestSubscriptionBuilder_BuildsNewSubscriptions(t *testing.T) {
+	b := NewSubscriptionBuilder()
+
+	assert.NotSame(t, b.Build(), b.Build())
+}
+
+func TestEventBuilders(t *testing.T) {
+	sub := NewSubscriptionBuilder().WithPlan("plan-pro").Build()
+
+	assert.Equal(t, &domain.SubscriptionCreatedEvent{
+		SubscriptionID:     "sub-123",
+		CustomerID:         "cust-456",
+		PlanID:             "plan-pro",
+		Price:              3000,
+		ReferralID:         "ref-1",
+		ReferrerCustomerID: "cust-1",
+		CreatedAt:          DefaultStartDate,
+	}, NewCreatedEventBuilder(sub).WithReferral("ref-1", "cust-1").Build())
+
+	renewed := NewRenewedEventBuilder(sub).WithDiscount(600).WithDiscount(500).Build()
+	assert.Equal(t, int64(2500), renewed.Amount)
+	assert.Equal(t, int64(500), renewed.Discount)
+	assert.Equal(t, DefaultStartDate.AddDate(0, 0, 30), renewed.PeriodStart)
+	assert.Equal(t, DefaultStartDate.AddDate(0, 0, 60), renewed.PeriodEnd)
+
+	changed := NewPlanChangedEventBuilder(sub, "plan-basic", 1000).WithProration(-2000).Build()
+	assert.Equal(t, "plan-pro", changed.OldPlanID)
+	assert.Equal(t, "plan-basic", changed.NewPlanID)
+	assert.Equal(t, int64(-2000), changed.ProratedAmount)
+
+	cancelledAt := DefaultStartDate.AddDate(0, 0, 14)
+	cancelled := NewCancelledEventBuilder(sub).At(cancelledAt).WithRefund(1600).Build()
+	assert.Equal(t, &domain.SubscriptionCancelledEvent{SubscriptionID: "sub-123", CustomerID: "cust-456", RefundAmount: 1600, CancelledAt: cancelledAt}, cancelled)
+}
+
+func TestRequestBuilders(t *testing.T) {
+	sub := NewSubscriptionBuilder().Build()
+
+	assert.Equal(t, contracts.ChargeRequest{
+		CustomerID:     "cust-456",
+		SubscriptionID: "sub-123",
+		Amount:         2500,
+		Currency:       "USD",
+		IdempotencyKey: "sub-123:renewal",
+	}, NewChargeRequestBuilder(sub).WithAmount(2500).WithIdempotencyKey("sub-123:renewal").Build())
+
+	assert.Equal(t, contracts.RefundRequest{
+		SubscriptionID: "sub-123",
+		CustomerID:     "cust-456",
+		Amount:         3000,
+		Currency:       "USD",
+		Reason:         contracts.RefundReasonCancellation,
+	}, NewRefundRequestBuilder(sub).Build())
+
+	assert.Equal(t, contracts.CreateCustomerRequest{CustomerID: "cust-1", Email: "cust-1@example.com", Name: "Ada"},
+		NewCreateCustomerRequestBuilder().WithCustomerID("cust-1").WithEmail("cust-1@example.com").WithName("Ada").Build())
+}
@@ -0,0 +1,160 @@
+package builders
+
+import (
+	"time"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+// DefaultBillingCycleDays is the period length renewal events are built with
+const DefaultBillingCycleDays = 30
+
+// CreatedEventBuilder builds the event of a subscription being created
+type CreatedEventBuilder struct {
+	event domain.SubscriptionCreatedEvent
+}
+
+// NewCreatedEventBuilder starts the event sub was created with, at its start date
+func NewCreatedEventBuilder(sub *domain.Subscription) *CreatedEventBuilder {
+	return &CreatedEventBuilder{event: domain.SubscriptionCreatedEvent{
+		SubscriptionID: sub.ID(),
+		CustomerID:     sub.CustomerID(),
+		PlanID:         sub.PlanID(),
+		Price:          sub.Price(),
+		TrialEndDate:   sub.TrialEndDate(),
+		CreatedAt:      sub.StartDate(),
+	}}
+}
+
+// WithReferral records the referral the subscription was created with
+func (b *CreatedEventBuilder) WithReferral(referralID, referrerCustomerID string) *CreatedEventBuilder {
+	b.event.ReferralID = referralID
+	b.event.ReferrerCustomerID = referrerCustomerID
+	return b
+}
+
+func (b *CreatedEventBuilder) Build() *domain.SubscriptionCreatedEvent {
+	event := b.event
+	return &event
+}
+
+// CancelledEventBuilder builds the event of a subscription being cancelled
+type CancelledEventBuilder struct {
+	event domain.SubscriptionCancelledEvent
+}
+
+// NewCancelledEventBuilder starts the cancellation of sub without a refund, at its
+// cancellation date if it has one
+func NewCancelledEventBuilder(sub *domain.Subscription) *CancelledEventBuilder {
+	return &CancelledEventBuilder{event: domain.SubscriptionCancelledEvent{
+		SubscriptionID: sub.ID(),
+		CustomerID:     sub.CustomerID(),
+		CancelledAt:    sub.CancelledAt(),
+	}}
+}
+
+func (b *CancelledEventBuilder) At(t time.Time) *CancelledEventBuilder {
+	b.event.CancelledAt = t
+	return b
+}
+
+// WithRefund sets the cents refunded for the unused part of the period
+func (b *CancelledEventBuilder) WithRefund(cents int64) *CancelledEventBuilder {
+	b.event.RefundAmount = cents
+	return b
+}
+
+// WithCredit sets the cents granted to the credit balance in place of a refund
+func (b *CancelledEventBuilder) WithCredit(cents int64) *CancelledEventBuilder {
+	b.event.CreditAmount = cents
+	return b
+}
+
+func (b *CancelledEventBuilder) Build() *domain.SubscriptionCancelledEvent {
+	event := b.event
+	return &event
+}
+
+// RenewedEventBuilder builds the event of a subscription entering a new period
+type RenewedEventBuilder struct {
+	event domain.SubscriptionRenewedEvent
+}
+
+// NewRenewedEventBuilder starts the renewal of sub at the end of its current period,
+// charged its full price
+func NewRenewedEventBuilder(sub *domain.Subscription) *RenewedEventBuilder {
+	periodStart := sub.CurrentPeriodStart().AddDate(0, 0, DefaultBillingCycleDays)
+	return &RenewedEventBuilder{event: domain.SubscriptionRenewedEvent{
+		SubscriptionID: sub.ID(),
+		CustomerID:     sub.CustomerID(),
+		PlanID:         sub.PlanID(),
+		Amount:         sub.Price(),
+		PeriodStart:    periodStart,
+		PeriodEnd:      periodStart.AddDate(0, 0, DefaultBillingCycleDays),
+		RenewedAt:      periodStart,
+	}}
+}
+
+// WithDiscount takes cents off the amount charged
+func (b *RenewedEventBuilder) WithDiscount(cents int64) *RenewedEventBuilder {
+	b.event.Amount += b.event.Discount - cents
+	b.event.Discount = cents
+	return b
+}
+
+// WithCreditApplied pays cents of the amount from the credit balance
+func (b *RenewedEventBuilder) WithCreditApplied(cents int64) *RenewedEventBuilder {
+	b.event.CreditApplied = cents
+	return b
+}
+
+func (b *RenewedEventBuilder) At(t time.Time) *RenewedEventBuilder {
+	b.event.RenewedAt = t
+	return b
+}
+
+func (b *RenewedEventBuilder) Build() *domain.SubscriptionRenewedEvent {
+	event := b.event
+	return &event
+}
+
+// PlanChangedEventBuilder builds the event of a subscription moving to another plan
+type PlanChangedEventBuilder struct {
+	event domain.SubscriptionPlanChangedEvent
+}
+
+// NewPlanChangedEventBuilder starts the move of sub to planID at priceCents, at the
+// start of its current period and so with nothing to prorate
+func NewPlanChangedEventBuilder(sub *domain.Subscription, planID string, priceCents int64) *PlanChangedEventBuilder {
+	return &PlanChangedEventBuilder{event: domain.SubscriptionPlanChangedEvent{
+		SubscriptionID: sub.ID(),
+		CustomerID:     sub.CustomerID(),
+		OldPlanID:      sub.PlanID(),
+		NewPlanID:      planID,
+		OldPrice:       sub.Price(),
+		NewPrice:       priceCents,
+		ChangedAt:      sub.CurrentPeriodStart(),
+	}}
+}
+
+// WithProration sets the cents owed for the rest of the period, negative for a downgrade
+func (b *PlanChangedEventBuilder) WithProration(cents int64) *PlanChangedEventBuilder {
+	b.event.ProratedAmount = cents
+	return b
+}
+
+// WithCredit sets the cents of a downgrade granted to the credit balance
+func (b *PlanChangedEventBuilder) WithCredit(cents int64) *PlanChangedEventBuilder {
+	b.event.CreditAmount = cents
+	return b
+}
+
+func (b *PlanChangedEventBuilder) At(t time.Time) *PlanChangedEventBuilder {
+	b.event.ChangedAt = t
+	return b
+}
+
+func (b *PlanChangedEventBuilder) Build() *domain.SubscriptionPlanChangedEvent {
+	event := b.event
+	return &event
+}
@@ -0,0 +1,121 @@
+package builders
+
+import (
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+// ChargeRequestBuilder builds a charge request to the billing provider
+type ChargeRequestBuilder struct {
+	req contracts.ChargeRequest
+}
+
+// NewChargeRequestBuilder starts a charge of sub's full price in the default currency
+func NewChargeRequestBuilder(sub *domain.Subscription) *ChargeRequestBuilder {
+	return &ChargeRequestBuilder{req: contracts.ChargeRequest{
+		CustomerID:     sub.CustomerID(),
+		SubscriptionID: sub.ID(),
+		Amount:         sub.Price(),
+		Currency:       domain.DefaultCurrency,
+	}}
+}
+
+// WithAmount sets the cents charged
+func (b *ChargeRequestBuilder) WithAmount(cents int64) *ChargeRequestBuilder {
+	b.req.Amount = cents
+	return b
+}
+
+func (b *ChargeRequestBuilder) WithCurrency(currency string) *ChargeRequestBuilder {
+	b.req.Currency = currency
+	return b
+}
+
+func (b *ChargeRequestBuilder) WithIdempotencyKey(key string) *ChargeRequestBuilder {
+	b.req.IdempotencyKey = key
+	return b
+}
+
+func (b *ChargeRequestBuilder) Build() contracts.ChargeRequest {
+	return b.req
+}
+
+// RefundRequestBuilder builds a refund request to the billing provider
+type RefundRequestBuilder struct {
+	req contracts.RefundRequest
+}
+
+// NewRefundRequestBuilder starts a cancellation refund of sub's full price in the
+// default currency
+func NewRefundRequestBuilder(sub *domain.Subscription) *RefundRequestBuilder {
+	return &RefundRequestBuilder{req: contracts.RefundRequest{
+		SubscriptionID: sub.ID(),
+		CustomerID:     sub.CustomerID(),
+		Amount:         sub.Price(),
+		Currency:       domain.DefaultCurrency,
+		Reason:         contracts.RefundReasonCancellation,
+	}}
+}
+
+// WithAmount sets the cents refunded
+func (b *RefundRequestBuilder) WithAmount(cents int64) *RefundRequestBuilder {
+	b.req.Amount = cents
+	return b
+}
+
+func (b *RefundRequestBuilder) WithCurrency(currency string) *RefundRequestBuilder {
+	b.req.Currency = currency
+	return b
+}
+
+func (b *RefundRequestBuilder) WithReason(reason string) *RefundRequestBuilder {
+	b.req.Reason = reason
+	return b
+}
+
+func (b *RefundRequestBuilder) WithCorrelationID(id string) *RefundRequestBuilder {
+	b.req.CorrelationID = id
+	return b
+}
+
+func (b *RefundRequestBuilder) WithIdempotencyKey(key string) *RefundRequestBuilder {
+	b.req.IdempotencyKey = key
+	return b
+}
+
+func (b *RefundRequestBuilder) Build() contracts.RefundRequest {
+	return b.req
+}
+
+// CreateCustomerRequestBuilder builds a request to provision a customer in billing
+type CreateCustomerRequestBuilder struct {
+	req contracts.CreateCustomerRequest
+}
+
+// NewCreateCustomerRequestBuilder starts a request for DefaultCustomerID with a
+// placeholder email
+func NewCreateCustomerRequestBuilder() *CreateCustomerRequestBuilder {
+	return &CreateCustomerRequestBuilder{req: contracts.CreateCustomerRequest{
+		CustomerID: DefaultCustomerID,
+		Email:      DefaultCustomerID + "@example.com",
+	}}
+}
+
+func (b *CreateCustomerRequestBuilder) WithCustomerID(customerID string) *CreateCustomerRequestBuilder {
+	b.req.CustomerID = customerID
+	return b
+}
+
+func (b *CreateCustomerRequestBuilder) WithEmail(email string) *CreateCustomerRequestBuilder {
+	b.req.Email = email
+	return b
+}
+
+func (b *CreateCustomerRequestBuilder) WithName(name string) *CreateCustomerRequestBuilder {
+	b.req.Name = name
+	return b
+}
+
+func (b *CreateCustomerRequestBuilder) Build() contracts.CreateCustomerRequest {
+	return b.req
+}
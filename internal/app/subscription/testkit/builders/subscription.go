@@ -0,0 +1,169 @@
+// Package builders builds domain fixtures for tests. Every builder starts from the
+// values most tests use, so a test only spells out what it is about:
+//
+//	sub := builders.NewSubscriptionBuilder().Cancelled().WithPrice(3000).Build()
+//
+// Use case requests aren't built here: each use case's tests are in its own package,
+// which builders would have to import, and that cycle isn't allowed.
+package builders
+
+import (
+	"time"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+// Defaults shared by the builders
+const (
+	DefaultSubscriptionID = "sub-123"
+	DefaultCustomerID     = "cust-456"
+	DefaultPlanID         = "plan-789"
+	DefaultPrice          = 3000 // cents
+)
+
+// DefaultStartDate is when built subscriptions start, unless StartedAt says otherwise
+var DefaultStartDate = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// SubscriptionBuilder builds a subscription as if loaded from the database. The zero
+// value is not usable; call NewSubscriptionBuilder.
+type SubscriptionBuilder struct {
+	id          string
+	customerID  string
+	planID      string
+	price       int64
+	status      domain.SubscriptionStatus
+	startDate   time.Time
+	cancelledAt time.Time
+	opts        []domain.ReconstructOption
+}
+
+// NewSubscriptionBuilder starts an ACTIVE subscription to DefaultPlanID at
+// DefaultPrice, started on DefaultStartDate and in its first period
+func NewSubscriptionBuilder() *SubscriptionBuilder {
+	return &SubscriptionBuilder{
+		id:         DefaultSubscriptionID,
+		customerID: DefaultCustomerID,
+		planID:     DefaultPlanID,
+		price:      DefaultPrice,
+		status:     domain.StatusActive,
+		startDate:  DefaultStartDate,
+	}
+}
+
+func (b *SubscriptionBuilder) WithID(id string) *SubscriptionBuilder {
+	b.id = id
+	return b
+}
+
+func (b *SubscriptionBuilder) WithCustomerID(customerID string) *SubscriptionBuilder {
+	b.customerID = customerID
+	return b
+}
+
+func (b *SubscriptionBuilder) WithPlan(planID string) *SubscriptionBuilder {
+	b.planID = planID
+	return b
+}
+
+// WithPrice sets the plan price in cents
+func (b *SubscriptionBuilder) WithPrice(cents int64) *SubscriptionBuilder {
+	b.price = cents
+	return b
+}
+
+// StartedAt sets when the subscription, and so its first period, started
+func (b *SubscriptionBuilder) StartedAt(t time.Time) *SubscriptionBuilder {
+	b.startDate = t
+	return b
+}
+
+// InPeriodFrom puts the subscription in a later period, starting at t
+func (b *SubscriptionBuilder) InPeriodFrom(t time.Time) *SubscriptionBuilder {
+	b.opts = append(b.opts, domain.WithCurrentPeriodStart(t))
+	return b
+}
+
+func (b *SubscriptionBuilder) Active() *SubscriptionBuilder {
+	b.status = domain.StatusActive
+	return b
+}
+
+// Cancelled cancels the subscription when it started; use CancelledAt to say when
+func (b *SubscriptionBuilder) Cancelled() *SubscriptionBuilder {
+	b.status = domain.StatusCancelled
+	return b
+}
+
+func (b *SubscriptionBuilder) CancelledAt(t time.Time) *SubscriptionBuilder {
+	b.status = domain.StatusCancelled
+	b.cancelledAt = t
+	return b
+}
+
+// PastDue puts the subscription in dunning with no retries made yet
+func (b *SubscriptionBuilder) PastDue() *SubscriptionBuilder {
+	b.status = domain.StatusPastDue
+	return b
+}
+
+// InDunning puts the subscription past due after attempts failed retries, with the
+// next one due at nextRetryAt
+func (b *SubscriptionBuilder) InDunning(attempts int64, nextRetryAt time.Time) *SubscriptionBuilder {
+	b.status = domain.StatusPastDue
+	b.opts = append(b.opts, domain.WithDunning(attempts, nextRetryAt))
+	return b
+}
+
+// Trialing starts the subscription as a trial of days days
+func (b *SubscriptionBuilder) Trialing(days int) *SubscriptionBuilder {
+	b.status = domain.StatusTrialing
+	b.opts = append(b.opts, func(s *domain.Subscription) {
+		domain.WithTrialEndDate(s.StartDate().AddDate(0, 0, days))(s)
+	})
+	return b
+}
+
+// PausedAt pauses the subscription at t
+func (b *SubscriptionBuilder) PausedAt(t time.Time) *SubscriptionBuilder {
+	b.status = domain.StatusPaused
+	b.opts = append(b.opts, domain.WithPausedAt(t))
+	return b
+}
+
+// PendingCancellationAt schedules the subscription to cancel at t
+func (b *SubscriptionBuilder) PendingCancellationAt(t time.Time) *SubscriptionBuilder {
+	b.status = domain.StatusPendingCancellation
+	b.opts = append(b.opts, domain.WithCancelAt(t))
+	return b
+}
+
+// WithCoupon gives the subscription the coupon it was created with
+func (b *SubscriptionBuilder) WithCoupon(c domain.SubscriptionCoupon) *SubscriptionBuilder {
+	b.opts = append(b.opts, domain.WithCoupon(c))
+	return b
+}
+
+// PaymentMethodFlaggedFor records the renewal the payment method was flagged for
+func (b *SubscriptionBuilder) PaymentMethodFlaggedFor(renewal time.Time) *SubscriptionBuilder {
+	b.opts = append(b.opts, domain.WithPaymentMethodFlaggedFor(renewal))
+	return b
+}
+
+// RenewalNoticeSentFor records the renewal the customer was told about
+func (b *SubscriptionBuilder) RenewalNoticeSentFor(renewal time.Time) *SubscriptionBuilder {
+	b.opts = append(b.opts, domain.WithRenewalNoticeSentFor(renewal))
+	return b
+}
+
+// Build returns a new subscription each time it is called
+func (b *SubscriptionBuilder) Build() *domain.Subscription {
+	opts := append([]domain.ReconstructOption(nil), b.opts...)
+	if b.status == domain.StatusCancelled {
+		cancelledAt := b.cancelledAt
+		if cancelledAt.IsZero() {
+			cancelledAt = b.startDate
+		}
+		opts = append(opts, domain.WithCancelledAt(cancelledAt))
+	}
+	return domain.ReconstructFromPersistence(b.id, b.customerID, b.planID, b.price, b.status, b.startDate, opts...)
+}
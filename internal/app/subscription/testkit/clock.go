@@ -0,0 +1,98 @@
+package testkit
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+var _ domain.TimerClock = (*StepClock)(nil)
+
+// StepClock is a clock that only moves when the test moves it, so one interactor or
+// worker can be taken through days of time. Timers from After fire when the clock
+// reaches their deadline. It is safe for concurrent use. The zero value is not usable;
+// call NewStepClock.
+type StepClock struct {
+	mu      sync.Mutex
+	added   *sync.Cond // broadcast when a timer is added
+	now     time.Time
+	waiters []stepWaiter
+}
+
+type stepWaiter struct {
+	at time.Time
+	ch chan time.Time
+}
+
+// NewStepClock returns a clock stopped at start
+func NewStepClock(start time.Time) *StepClock {
+	c := &StepClock{now: start}
+	c.added = sync.NewCond(&c.mu)
+	return c
+}
+
+func (c *StepClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Advance moves the clock forward by d and fires the timers now due
+func (c *StepClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	c.fire()
+}
+
+// Set moves the clock to t, which may be in the past, and fires the timers now due
+func (c *StepClock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = t
+	c.fire()
+}
+
+// After returns a channel that receives the deadline once the clock has advanced by
+// d. A d of zero or less fires straight away, like time.After.
+func (c *StepClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	w := stepWaiter{at: c.now.Add(d), ch: make(chan time.Time, 1)}
+	if d <= 0 {
+		w.ch <- w.at
+		return w.ch
+	}
+	c.waiters = append(c.waiters, w)
+	c.added.Broadcast()
+	return w.ch
+}
+
+// Waiters returns how many timers haven't fired yet
+func (c *StepClock) Waiters() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.waiters)
+}
+
+// BlockUntil waits until n timers are pending, so a test advances the clock only once
+// the code under test is waiting on it
+func (c *StepClock) BlockUntil(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for len(c.waiters) < n {
+		c.added.Wait()
+	}
+}
+
+// fire sends to the timers due by now, earliest first. The caller holds mu.
+func (c *StepClock) fire() {
+	sort.SliceStable(c.waiters, func(i, j int) bool { return c.waiters[i].at.Before(c.waiters[j].at) })
+	i := 0
+	for ; i < len(c.waiters) && !c.waiters[i].at.After(c.now); i++ {
+		c.waiters[i].ch <- c.waiters[i].at
+	}
+	c.waiters = append(c.waiters[:0], c.waiters[i:]...)
+}
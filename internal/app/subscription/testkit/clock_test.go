@@ -0,0 +1,70 @@
+package testkit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var clockStart = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestStepClock_AdvanceAndSet(t *testing.T) {
+	clock := NewStepClock(clockStart)
+
+	clock.Advance(14 * 24 * time.Hour)
+	assert.Equal(t, clockStart.AddDate(0, 0, 14), clock.Now())
+
+	clock.Set(clockStart)
+	assert.Equal(t, clockStart, clock.Now())
+}
+
+func TestStepClock_AfterFiresAtItsDeadline(t *testing.T) {
+	clock := NewStepClock(clockStart)
+	hour := clock.After(time.Hour)
+	day := clock.After(24 * time.Hour)
+
+	clock.Advance(59 * time.Minute)
+	assertPending(t, hour)
+	assert.Equal(t, 2, clock.Waiters())
+
+	clock.Advance(time.Minute)
+	assert.Equal(t, clockStart.Add(time.Hour), <-hour)
+	assertPending(t, day)
+
+	clock.Set(clockStart.AddDate(0, 0, 2))
+	assert.Equal(t, clockStart.Add(24*time.Hour), <-day)
+	assert.Zero(t, clock.Waiters())
+}
+
+func TestStepClock_AfterWithoutDelayFiresStraightAway(t *testing.T) {
+	clock := NewStepClock(clockStart)
+
+	assert.Equal(t, clockStart, <-clock.After(0))
+	assert.Zero(t, clock.Waiters())
+}
+
+func TestStepClock_BlockUntilWaitsForTheTimer(t *testing.T) {
+	clock := NewStepClock(clockStart)
+	ticks := make(chan time.Time)
+	go func() {
+		for i := 0; i < 3; i++ {
+			ticks <- <-clock.After(time.Minute)
+		}
+	}()
+
+	for i := 1; i <= 3; i++ {
+		clock.BlockUntil(1)
+		clock.Advance(time.Minute)
+		assert.Equal(t, clockStart.Add(time.Duration(i)*time.Minute), <-ticks)
+	}
+}
+
+func assertPending(t *testing.T, ch <-chan time.Time) {
+	t.Helper()
+	select {
+	case at := <-ch:
+		t.Fatalf("timer fired early, at %v", at)
+	default:
+	}
+}
@@ -0,0 +1,70 @@
+package testkit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+var _ contracts.CouponRepository = (*FakeCoupons)(nil)
+
+// FakeCoupons is an in-memory CouponRepository. A redemption is stored as soon as it is
+// saved, and coupons are returned as copies, so a redemption that isn't saved leaves
+// the stored coupon as it was. It is safe for concurrent use. The zero value is not
+// usable; call NewFakeCoupons.
+type FakeCoupons struct {
+	mu          sync.Mutex
+	coupons     map[string]*domain.Coupon
+	redemptions map[string][]string // coupon code to the subscriptions that redeemed it
+}
+
+// NewFakeCoupons returns a fake holding the given coupons
+func NewFakeCoupons(coupons ...*domain.Coupon) *FakeCoupons {
+	f := &FakeCoupons{
+		coupons:     make(map[string]*domain.Coupon, len(coupons)),
+		redemptions: make(map[string][]string),
+	}
+	for _, c := range coupons {
+		f.coupons[c.Code()] = c
+	}
+	return f
+}
+
+// Redemptions returns the subscriptions that redeemed the coupon, in order
+func (f *FakeCoupons) Redemptions(code string) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.redemptions[code]...)
+}
+
+func (f *FakeCoupons) Insert(ctx context.Context, coupon *domain.Coupon) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.coupons[coupon.Code()]; ok {
+		return domain.ErrCouponAlreadyExists
+	}
+	f.coupons[coupon.Code()] = coupon
+	return nil
+}
+
+func (f *FakeCoupons) FindByCode(ctx context.Context, code string) (*domain.Coupon, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	c, ok := f.coupons[code]
+	if !ok {
+		return nil, domain.ErrCouponNotFound
+	}
+	return domain.ReconstructCoupon(c.Code(), c.Terms(), c.TimesRedeemed(), c.CreatedAt()), nil
+}
+
+func (f *FakeCoupons) SaveRedemption(ctx context.Context, coupon *domain.Coupon, subscriptionID string, redeemedAt time.Time) ([]*spanner.Mutation, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.coupons[coupon.Code()] = coupon
+	f.redemptions[coupon.Code()] = append(f.redemptions[coupon.Code()], subscriptionID)
+	return []*spanner.Mutation{{}, {}}, nil
+}
@@ -0,0 +1,55 @@
+package testkit
+
+import (
+	"context"
+	"sync"
+
+	"cloud.google.com/go/spanner"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+var _ contracts.CreditBalanceRepository = (*FakeCreditBalances)(nil)
+
+// FakeCreditBalances is an in-memory CreditBalanceRepository. Balances are set up front
+// with Grant; saved entries are recorded but don't move the balance, since the real
+// repository only moves it once the caller applies the mutation. It is safe for
+// concurrent use. The zero value is not usable; call NewFakeCreditBalances.
+type FakeCreditBalances struct {
+	mu       sync.Mutex
+	balances map[string]int64
+	entries  []*domain.CreditEntry
+}
+
+// NewFakeCreditBalances returns a fake in which every customer has no credit
+func NewFakeCreditBalances() *FakeCreditBalances {
+	return &FakeCreditBalances{balances: make(map[string]int64)}
+}
+
+// Grant gives the customer amount cents of credit
+func (f *FakeCreditBalances) Grant(customerID string, amount int64) *FakeCreditBalances {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.balances[customerID] += amount
+	return f
+}
+
+// Entries returns the entries saved so far, in order
+func (f *FakeCreditBalances) Entries() []*domain.CreditEntry {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]*domain.CreditEntry(nil), f.entries...)
+}
+
+func (f *FakeCreditBalances) Save(ctx context.Context, entry *domain.CreditEntry) (*spanner.Mutation, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.entries = append(f.entries, entry)
+	return &spanner.Mutation{}, nil
+}
+
+func (f *FakeCreditBalances) Balance(ctx context.Context, customerID string) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.balances[customerID], nil
+}
@@ -0,0 +1,72 @@
+package testkit
+
+import (
+	"context"
+	"sync"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+var _ contracts.EntitlementRepository = (*FakeEntitlements)(nil)
+
+// FakeEntitlements is an in-memory EntitlementRepository that counts its lookups. It
+// is safe for concurrent use. The zero value is not usable; call NewFakeEntitlements.
+type FakeEntitlements struct {
+	mu      sync.Mutex
+	subs    map[string][]*domain.Subscription // by customer ID
+	plans   map[string][]domain.Entitlement
+	lookups int
+}
+
+// NewFakeEntitlements returns a fake with no subscriptions or plans
+func NewFakeEntitlements() *FakeEntitlements {
+	return &FakeEntitlements{
+		subs:  make(map[string][]*domain.Subscription),
+		plans: make(map[string][]domain.Entitlement),
+	}
+}
+
+// WithSubscription adds a subscription of its customer
+func (f *FakeEntitlements) WithSubscription(sub *domain.Subscription) *FakeEntitlements {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.subs[sub.CustomerID()] = append(f.subs[sub.CustomerID()], sub)
+	return f
+}
+
+// WithPlan sets the entitlements the plan grants
+func (f *FakeEntitlements) WithPlan(planID string, entitlements ...domain.Entitlement) *FakeEntitlements {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.plans[planID] = entitlements
+	return f
+}
+
+// Lookups returns how many lookups were made
+func (f *FakeEntitlements) Lookups() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.lookups
+}
+
+// FindEntitledSubscriptions filters by status like the Spanner repository does
+func (f *FakeEntitlements) FindEntitledSubscriptions(ctx context.Context, customerID string) ([]*domain.Subscription, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.lookups++
+	var subs []*domain.Subscription
+	for _, sub := range f.subs[customerID] {
+		if sub.GrantsEntitlements() {
+			subs = append(subs, sub)
+		}
+	}
+	return subs, nil
+}
+
+func (f *FakeEntitlements) FindPlanEntitlements(ctx context.Context, planID string) ([]domain.Entitlement, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.lookups++
+	return f.plans[planID], nil
+}
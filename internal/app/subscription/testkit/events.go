@@ -0,0 +1,45 @@
+package testkit
+
+import (
+	"context"
+	"sync"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+var _ contracts.EventPublisher = (*RecordingEvents)(nil)
+
+// RecordingEvents is an EventPublisher that records the events published. It is safe
+// for concurrent use.
+type RecordingEvents struct {
+	mu        sync.Mutex
+	created   []*domain.SubscriptionCreatedEvent
+	cancelled []*domain.SubscriptionCancelledEvent
+}
+
+// Created returns the creation events published, in order
+func (e *RecordingEvents) Created() []*domain.SubscriptionCreatedEvent {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]*domain.SubscriptionCreatedEvent(nil), e.created...)
+}
+
+// Cancelled returns the cancellation events published, in order
+func (e *RecordingEvents) Cancelled() []*domain.SubscriptionCancelledEvent {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]*domain.SubscriptionCancelledEvent(nil), e.cancelled...)
+}
+
+func (e *RecordingEvents) PublishCreated(ctx context.Context, event *domain.SubscriptionCreatedEvent) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.created = append(e.created, event)
+}
+
+func (e *RecordingEvents) PublishCancelled(ctx context.Context, event *domain.SubscriptionCancelledEvent) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.cancelled = append(e.cancelled, event)
+}
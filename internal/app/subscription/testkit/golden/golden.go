@@ -0,0 +1,110 @@
+// Package golden compares serialized output with snapshots checked in under a test
+// package's testdata directory, so a change to a wire format fails a test and shows
+// up as a diff in review instead of breaking a downstream consumer.
+//
+// Snapshots are rewritten, not compared, when UPDATE_GOLDEN is set:
+//
+//	UPDATE_GOLDEN=1 go test ./...
+package golden
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// UpdateEnv is the environment variable that makes Assert rewrite snapshots
+const UpdateEnv = "UPDATE_GOLDEN"
+
+// Path returns where the snapshot called name is kept
+func Path(name string) string {
+	return filepath.Join("testdata", name+".golden")
+}
+
+// AssertJSON marshals v with encoding/json, the way consumers see it, and compares the
+// indented result with the snapshot called name
+func AssertJSON(t testing.TB, name string, v any) {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("golden: marshal %s: %v", name, err)
+	}
+	AssertJSONBytes(t, name, data)
+}
+
+// AssertJSONBytes compares an already serialized JSON document, such as a response
+// body, with the snapshot called name. The document is indented first so snapshots
+// diff field by field.
+func AssertJSONBytes(t testing.TB, name string, data []byte) {
+	t.Helper()
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, bytes.TrimSpace(data), "", "  "); err != nil {
+		t.Fatalf("golden: %s is not JSON: %v", name, err)
+	}
+	indented.WriteByte('\n')
+	Assert(t, name, indented.Bytes())
+}
+
+// Assert compares got with the snapshot called name, or rewrites the snapshot when
+// UpdateEnv is set
+func Assert(t testing.TB, name string, got []byte) {
+	t.Helper()
+	path := Path(name)
+
+	if os.Getenv(UpdateEnv) != "" {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("golden: %v", err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatalf("golden: %v", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		t.Fatalf("golden: no snapshot at %s; run with %s=1 to create it", path, UpdateEnv)
+	}
+	if err != nil {
+		t.Fatalf("golden: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("golden: %s changed; if the wire format change is intended, run with %s=1 and review the diff\n%s",
+			path, UpdateEnv, diff(string(want), string(got)))
+	}
+}
+
+// diff lists the lines that differ between want and got, position by position. It is
+// meant for small snapshots, where this is enough to spot a renamed or dropped field.
+func diff(want, got string) string {
+	wantLines := strings.Split(want, "\n")
+	gotLines := strings.Split(got, "\n")
+	n := len(wantLines)
+	if len(gotLines) > n {
+		n = len(gotLines)
+	}
+
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		var w, g string
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if w == g {
+			continue
+		}
+		if i < len(wantLines) {
+			b.WriteString("- " + w + "\n")
+		}
+		if i < len(gotLines) {
+			b.WriteString("+ " + g + "\n")
+		}
+	}
+	return b.String()
+}
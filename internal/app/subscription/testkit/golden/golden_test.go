@@ -0,0 +1,22 @@
+package golden
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff_ListsChangedLines(t *testing.T) {
+	want := "{\n  \"PlanID\": \"plan-pro\",\n  \"Price\": 3000\n}\n"
+	got := "{\n  \"PlanId\": \"plan-pro\",\n  \"Price\": 3000\n}\n"
+
+	assert.Equal(t, "-   \"PlanID\": \"plan-pro\",\n+   \"PlanId\": \"plan-pro\",\n", diff(want, got))
+}
+
+func TestDiff_ListsAddedAndDroppedLines(t *testing.T) {
+	short := "{\n  \"Amount\": 3000"
+	long := short + "\n  \"Currency\": \"USD\""
+
+	assert.Equal(t, "+   \"Currency\": \"USD\"\n", diff(short, long))
+	assert.Equal(t, "-   \"Currency\": \"USD\"\n", diff(long, short))
+}
@@ -0,0 +1,66 @@
+package testkit
+
+import (
+	"context"
+	"sync"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+var _ contracts.SubscriptionHooks = (*RecordingHooks)(nil)
+
+// RecordingHooks is a SubscriptionHooks that records the hooks called, by method
+// name, and vetoes with Veto when it is set. It is safe for concurrent use.
+type RecordingHooks struct {
+	Veto error
+
+	mu    sync.Mutex
+	calls []string
+}
+
+// Calls returns the names of the hooks called, in order
+func (h *RecordingHooks) Calls() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]string(nil), h.calls...)
+}
+
+func (h *RecordingHooks) record(name string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.calls = append(h.calls, name)
+	return h.Veto
+}
+
+func (h *RecordingHooks) BeforeCreate(ctx context.Context, sub *domain.Subscription, event *domain.SubscriptionCreatedEvent) error {
+	return h.record("BeforeCreate")
+}
+
+func (h *RecordingHooks) AfterCreate(ctx context.Context, sub *domain.Subscription, event *domain.SubscriptionCreatedEvent) {
+	h.record("AfterCreate")
+}
+
+func (h *RecordingHooks) BeforeCancel(ctx context.Context, sub *domain.Subscription, event *domain.SubscriptionCancelledEvent) error {
+	return h.record("BeforeCancel")
+}
+
+func (h *RecordingHooks) AfterCancel(ctx context.Context, sub *domain.Subscription, event *domain.SubscriptionCancelledEvent) {
+	h.record("AfterCancel")
+}
+
+func (h *RecordingHooks) BeforeRenew(ctx context.Context, sub *domain.Subscription, event *domain.SubscriptionRenewedEvent) error {
+	return h.record("BeforeRenew")
+}
+
+func (h *RecordingHooks) AfterRenew(ctx context.Context, sub *domain.Subscription, event *domain.SubscriptionRenewedEvent) {
+	h.record("AfterRenew")
+}
+
+func (h *RecordingHooks) BeforePlanChange(ctx context.Context, sub *domain.Subscription, event *domain.SubscriptionPlanChangedEvent) error {
+	return h.record("BeforePlanChange")
+}
+
+func (h *RecordingHooks) AfterPlanChange(ctx context.Context, sub *domain.Subscription, event *domain.SubscriptionPlanChangedEvent) {
+	h.record("AfterPlanChange")
+}
@@ -0,0 +1,67 @@
+package testkit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+var _ contracts.IdempotencyKeyRepository = (*FakeIdempotencyKeys)(nil)
+
+// FakeIdempotencyKeys is an in-memory IdempotencyKeyRepository. A key is stored as soon
+// as it is saved, and saving it again keeps the first subscription, as only the first
+// insert of a key commits. It is safe for concurrent use. The zero value is not usable;
+// call NewFakeIdempotencyKeys.
+type FakeIdempotencyKeys struct {
+	mu   sync.Mutex
+	keys map[idempotencyKey]string // to the subscription ID
+}
+
+// idempotencyKey identifies a key, like the idempotency_keys primary key
+type idempotencyKey struct {
+	customerID string
+	key        string
+}
+
+// NewFakeIdempotencyKeys returns a fake holding no keys
+func NewFakeIdempotencyKeys() *FakeIdempotencyKeys {
+	return &FakeIdempotencyKeys{keys: make(map[idempotencyKey]string)}
+}
+
+// With records that the customer's key created the subscription
+func (f *FakeIdempotencyKeys) With(customerID, key, subscriptionID string) *FakeIdempotencyKeys {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.keys[idempotencyKey{customerID, key}] = subscriptionID
+	return f
+}
+
+// Len returns how many keys are stored
+func (f *FakeIdempotencyKeys) Len() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.keys)
+}
+
+func (f *FakeIdempotencyKeys) Save(ctx context.Context, customerID, key, subscriptionID string, createdAt time.Time) (*spanner.Mutation, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.keys[idempotencyKey{customerID, key}]; !ok {
+		f.keys[idempotencyKey{customerID, key}] = subscriptionID
+	}
+	return &spanner.Mutation{}, nil
+}
+
+func (f *FakeIdempotencyKeys) FindSubscriptionID(ctx context.Context, customerID, key string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	id, ok := f.keys[idempotencyKey{customerID, key}]
+	if !ok {
+		return "", domain.ErrIdempotencyKeyNotFound
+	}
+	return id, nil
+}
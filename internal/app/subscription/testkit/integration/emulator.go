@@ -0,0 +1,109 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// emulatorImage is the image docker-compose.yml runs
+const emulatorImage = "gcr.io/cloud-spanner-emulator/emulator"
+
+// emulatorStartTimeout covers pulling the image on a machine that doesn't have it
+const emulatorStartTimeout = 3 * time.Minute
+
+// findEmulator returns the emulator named by SPANNER_EMULATOR_HOST, or else starts one
+// in Docker on a free port and returns its container, for Main to remove
+func findEmulator() (addr, containerID string, err error) {
+	if host := os.Getenv("SPANNER_EMULATOR_HOST"); host != "" {
+		return strings.TrimPrefix(strings.TrimPrefix(host, "http://"), "https://"), "", nil
+	}
+	return startEmulator()
+}
+
+// startEmulator runs the emulator image with its gRPC port published on a free
+// loopback port, and waits until that port accepts connections
+func startEmulator() (addr, containerID string, err error) {
+	if _, err := exec.LookPath("docker"); err != nil {
+		return "", "", errors.New("docker is not installed")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), emulatorStartTimeout)
+	defer cancel()
+	if err := exec.CommandContext(ctx, "docker", "info").Run(); err != nil {
+		return "", "", errors.New("the Docker daemon is not reachable")
+	}
+
+	out, err := docker(ctx, "run", "--detach", "--rm", "--publish", "127.0.0.1::9010", emulatorImage)
+	if err != nil {
+		return "", "", fmt.Errorf("starting %s: %w", emulatorImage, err)
+	}
+	containerID = out
+	if addr, err = publishedAddr(ctx, containerID); err == nil {
+		err = waitForPort(ctx, addr)
+	}
+	if err != nil {
+		stopContainer(containerID)
+		return "", "", err
+	}
+	return addr, containerID, nil
+}
+
+// publishedAddr returns the loopback address Docker published the emulator's gRPC
+// port on
+func publishedAddr(ctx context.Context, containerID string) (string, error) {
+	out, err := docker(ctx, "port", containerID, "9010/tcp")
+	if err != nil {
+		return "", fmt.Errorf("finding the emulator's port: %w", err)
+	}
+	// One line per address family; the container only publishes on 127.0.0.1
+	addr, _, _ := strings.Cut(out, "\n")
+	return strings.TrimSpace(addr), nil
+}
+
+// waitForPort waits until addr accepts TCP connections
+func waitForPort(ctx context.Context, addr string) error {
+	for {
+		conn, err := net.DialTimeout("tcp", addr, time.Second)
+		if err == nil {
+			conn.Close()
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("emulator at %s didn't start: %w", addr, err)
+		case <-time.After(200 * time.Millisecond):
+		}
+	}
+}
+
+// stopContainer removes a container the harness started. It has its own deadline,
+// since it runs after the tests, whatever state they left.
+func stopContainer(id string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if _, err := docker(ctx, "rm", "--force", id); err != nil {
+		fmt.Fprintf(os.Stderr, "integration: removing emulator container %s: %v\n", id, err)
+	}
+}
+
+// docker runs the docker CLI and returns its trimmed output, or its stderr as the error
+func docker(ctx context.Context, args ...string) (string, error) {
+	var stderr strings.Builder
+	cmd := exec.CommandContext(ctx, "docker", args...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", errors.New(msg)
+		}
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
@@ -0,0 +1,224 @@
+//go:build integration
+
+// Package integration is the harness for tests against the Spanner emulator. A test
+// package gets a database of its own, created and migrated by the first test that asks
+// for it and dropped when the package's tests finish. Each test's rows are deleted
+// when it ends, so tests share the schema without seeing each other's data.
+//
+// The package and the tests using it build only with the integration tag:
+//
+//	//go:build integration
+//
+//	func TestMain(m *testing.M) { integration.Main(m) }
+//
+//	func TestSomething(t *testing.T) {
+//		db := integration.Setup(t)
+//		subs := repo.NewSubscriptionRepo(db.Client)
+//		...
+//	}
+//
+// The emulator is the one named by SPANNER_EMULATOR_HOST, or else one the harness
+// starts in Docker and removes afterwards. Tests are skipped when there is neither,
+// and with -short.
+package integration
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	admin "cloud.google.com/go/spanner/admin/database/apiv1"
+	"cloud.google.com/go/spanner/admin/database/apiv1/databasepb"
+	instanceadmin "cloud.google.com/go/spanner/admin/instance/apiv1"
+	"cloud.google.com/go/spanner/admin/instance/apiv1/instancepb"
+	"github.com/google/uuid"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/logging"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/migrations"
+)
+
+const (
+	Project  = "test-project"
+	Instance = "test-instance"
+)
+
+// setupTimeout bounds creating and migrating the database, once there is an emulator
+const setupTimeout = 2 * time.Minute
+
+// migrationsTable is kept by Truncate, so the database stays at its schema version
+const migrationsTable = "schema_migrations"
+
+// DB is the migrated database of a test package
+type DB struct {
+	Client *spanner.Client
+	ID     string // the database ID, unique to the package's run
+	Path   string // projects/<project>/instances/<instance>/databases/<ID>
+}
+
+// harness is the package's emulator and database, set up once
+var harness struct {
+	once        sync.Once
+	db          *DB
+	skip        string // why tests are skipped; there is no emulator
+	err         error  // why tests fail; there is an emulator, but no database
+	containerID string // set when the harness started the emulator
+}
+
+// Main runs a package's tests, then drops its database and removes an emulator the
+// harness started. Call it from TestMain.
+func Main(m *testing.M) {
+	code := m.Run()
+	if db := harness.db; db != nil {
+		db.Client.Close()
+		dropDatabase(db.Path)
+	}
+	if harness.containerID != "" {
+		stopContainer(harness.containerID)
+	}
+	os.Exit(code)
+}
+
+// Setup returns the package's database, creating and migrating it on the first call,
+// and deletes the rows tb writes when tb ends
+func Setup(tb testing.TB) *DB {
+	tb.Helper()
+	if testing.Short() {
+		tb.Skip("integration tests need a Spanner emulator and don't run with -short")
+	}
+
+	harness.once.Do(func() {
+		addr, containerID, err := findEmulator()
+		if err != nil {
+			harness.skip = err.Error()
+			return
+		}
+		harness.containerID = containerID
+		// The Spanner clients, including the migration runner's, find the emulator here
+		os.Setenv("SPANNER_EMULATOR_HOST", addr)
+		harness.db, harness.err = createDatabase()
+	})
+
+	switch {
+	case harness.skip != "":
+		tb.Skipf("no Spanner emulator (%s); set SPANNER_EMULATOR_HOST or make Docker available", harness.skip)
+	case harness.err != nil:
+		tb.Fatalf("setting up the test database: %v", harness.err)
+	}
+	tb.Cleanup(func() { harness.db.Truncate(tb) })
+	return harness.db
+}
+
+// Truncate deletes every row of tables, or of every table but schema_migrations when
+// none are named
+func (db *DB) Truncate(tb testing.TB, tables ...string) {
+	tb.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if len(tables) == 0 {
+		var err error
+		if tables, err = db.tables(ctx); err != nil {
+			tb.Fatalf("listing tables to truncate: %v", err)
+		}
+	}
+	mutations := make([]*spanner.Mutation, 0, len(tables))
+	for _, table := range tables {
+		mutations = append(mutations, spanner.Delete(table, spanner.AllKeys()))
+	}
+	if _, err := db.Client.Apply(ctx, mutations); err != nil {
+		tb.Fatalf("truncating %v: %v", tables, err)
+	}
+}
+
+// tables returns the database's tables, but for schema_migrations
+func (db *DB) tables(ctx context.Context) ([]string, error) {
+	stmt := spanner.Statement{
+		SQL:    `SELECT table_name FROM information_schema.tables WHERE table_schema = '' AND table_name != @migrations`,
+		Params: map[string]interface{}{"migrations": migrationsTable},
+	}
+	iter := db.Client.Single().Query(ctx, stmt)
+	defer iter.Stop()
+
+	var tables []string
+	for {
+		row, err := iter.Next()
+		if err == iterator.Done {
+			return tables, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		var table string
+		if err := row.Columns(&table); err != nil {
+			return nil, err
+		}
+		tables = append(tables, table)
+	}
+}
+
+// createDatabase creates a database named for this run and applies the migrations
+func createDatabase() (*DB, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), setupTimeout)
+	defer cancel()
+
+	if err := ensureInstance(ctx); err != nil {
+		return nil, err
+	}
+	id := "test-db-" + uuid.New().String()[:8]
+	if err := migrations.RunMigrations(ctx, logging.Discard(), Project, Instance, id); err != nil {
+		return nil, fmt.Errorf("migrating %s: %w", id, err)
+	}
+
+	path := fmt.Sprintf("projects/%s/instances/%s/databases/%s", Project, Instance, id)
+	client, err := spanner.NewClient(context.Background(), path)
+	if err != nil {
+		dropDatabase(path)
+		return nil, fmt.Errorf("connecting to %s: %w", id, err)
+	}
+	return &DB{Client: client, ID: id, Path: path}, nil
+}
+
+// ensureInstance creates the test instance unless it exists. Packages run in parallel,
+// so another package may create it first.
+func ensureInstance(ctx context.Context) error {
+	client, err := instanceadmin.NewInstanceAdminClient(ctx)
+	if err != nil {
+		return fmt.Errorf("creating instance admin client: %w", err)
+	}
+	defer client.Close()
+
+	op, err := client.CreateInstance(ctx, &instancepb.CreateInstanceRequest{
+		Parent:     "projects/" + Project,
+		InstanceId: Instance,
+		Instance:   &instancepb.Instance{DisplayName: Instance},
+	})
+	if err == nil {
+		_, err = op.Wait(ctx)
+	}
+	if err != nil && status.Code(err) != codes.AlreadyExists {
+		return fmt.Errorf("creating instance %s: %w", Instance, err)
+	}
+	return nil
+}
+
+// dropDatabase drops a database the harness created. A failure is only reported, since
+// the emulator forgets its databases when it stops.
+func dropDatabase(path string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	client, err := admin.NewDatabaseAdminClient(ctx)
+	if err == nil {
+		err = client.DropDatabase(ctx, &databasepb.DropDatabaseRequest{Database: path})
+		client.Close()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "integration: dropping %s: %v\n", path, err)
+	}
+}
@@ -0,0 +1,230 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/spanner"
+)
+
+// Schema is a database's tables, columns and indexes as its information schema
+// reports them, after the migrations rather than as the migration files say
+type Schema struct {
+	Tables map[string]*Table
+}
+
+// Table is a table of a Schema
+type Table struct {
+	Columns    map[string]Column
+	PrimaryKey []string
+	Indexes    map[string]Index
+}
+
+// Column is a column of a Table
+type Column struct {
+	Type     string // the Spanner type, e.g. STRING(36) or TIMESTAMP
+	Nullable bool
+}
+
+// String is the column as DDL declares it, e.g. STRING(36) NOT NULL
+func (c Column) String() string {
+	if c.Nullable {
+		return c.Type
+	}
+	return c.Type + " NOT NULL"
+}
+
+// Index is a secondary index of a Table
+type Index struct {
+	Columns      []string // key columns, in order
+	Storing      []string // sorted
+	Unique       bool
+	NullFiltered bool
+}
+
+// TableSpec is what a test expects of a table. The table may have more columns and
+// indexes than the spec names.
+type TableSpec struct {
+	Name       string
+	PrimaryKey []string
+	Columns    map[string]string // column to its DDL type, e.g. STRING(36) NOT NULL
+	Indexes    []IndexSpec
+}
+
+// IndexSpec is what a test expects of an index
+type IndexSpec struct {
+	Name         string
+	Columns      []string
+	Storing      []string
+	Unique       bool
+	NullFiltered bool
+}
+
+// Schema reads the database's schema
+func (db *DB) Schema(tb testing.TB) *Schema {
+	tb.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	schema, err := readSchema(ctx, db.Client)
+	if err != nil {
+		tb.Fatalf("reading the schema of %s: %v", db.ID, err)
+	}
+	return schema
+}
+
+// Assert reports, with tb.Errorf, every way the schema differs from specs
+func (s *Schema) Assert(tb testing.TB, specs ...TableSpec) {
+	tb.Helper()
+	for _, spec := range specs {
+		table, ok := s.Tables[spec.Name]
+		if !ok {
+			tb.Errorf("table %s doesn't exist", spec.Name)
+			continue
+		}
+		if spec.PrimaryKey != nil && !equal(table.PrimaryKey, spec.PrimaryKey) {
+			tb.Errorf("table %s has primary key (%s), want (%s)", spec.Name, strings.Join(table.PrimaryKey, ", "), strings.Join(spec.PrimaryKey, ", "))
+		}
+		for _, name := range sortedKeys(spec.Columns) {
+			column, ok := table.Columns[name]
+			switch {
+			case !ok:
+				tb.Errorf("column %s.%s doesn't exist", spec.Name, name)
+			case column.String() != spec.Columns[name]:
+				tb.Errorf("column %s.%s is %s, want %s", spec.Name, name, column, spec.Columns[name])
+			}
+		}
+		for _, want := range spec.Indexes {
+			index, ok := table.Indexes[want.Name]
+			if !ok {
+				tb.Errorf("index %s on %s doesn't exist", want.Name, spec.Name)
+				continue
+			}
+			got := IndexSpec{Name: want.Name, Columns: index.Columns, Storing: index.Storing, Unique: index.Unique, NullFiltered: index.NullFiltered}
+			if got.String() != want.String() {
+				tb.Errorf("index %s on %s is %s, want %s", want.Name, spec.Name, got, want)
+			}
+		}
+	}
+}
+
+// String is the index as DDL declares it, less its table
+func (i IndexSpec) String() string {
+	var b strings.Builder
+	if i.Unique {
+		b.WriteString("UNIQUE ")
+	}
+	if i.NullFiltered {
+		b.WriteString("NULL_FILTERED ")
+	}
+	fmt.Fprintf(&b, "INDEX %s(%s)", i.Name, strings.Join(i.Columns, ", "))
+	if len(i.Storing) > 0 {
+		storing := append([]string(nil), i.Storing...)
+		sort.Strings(storing)
+		fmt.Fprintf(&b, " STORING (%s)", strings.Join(storing, ", "))
+	}
+	return b.String()
+}
+
+// readSchema queries the information schema for the tables, columns and indexes of
+// the default schema
+func readSchema(ctx context.Context, client *spanner.Client) (*Schema, error) {
+	schema := &Schema{Tables: make(map[string]*Table)}
+	table := func(name string) *Table {
+		t, ok := schema.Tables[name]
+		if !ok {
+			t = &Table{Columns: make(map[string]Column), Indexes: make(map[string]Index)}
+			schema.Tables[name] = t
+		}
+		return t
+	}
+	tx := client.ReadOnlyTransaction()
+	defer tx.Close()
+
+	err := tx.Query(ctx, spanner.Statement{SQL: `
+		SELECT table_name, column_name, spanner_type, is_nullable FROM information_schema.columns
+		WHERE table_schema = ''
+	`}).Do(func(row *spanner.Row) error {
+		var tableName, name, typ, nullable string
+		if err := row.Columns(&tableName, &name, &typ, &nullable); err != nil {
+			return err
+		}
+		table(tableName).Columns[name] = Column{Type: typ, Nullable: nullable == "YES"}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reading columns: %w", err)
+	}
+
+	err = tx.Query(ctx, spanner.Statement{SQL: `
+		SELECT table_name, index_name, is_unique, is_null_filtered FROM information_schema.indexes
+		WHERE table_schema = '' AND index_type = 'INDEX'
+	`}).Do(func(row *spanner.Row) error {
+		var tableName, name string
+		var unique, nullFiltered bool
+		if err := row.Columns(&tableName, &name, &unique, &nullFiltered); err != nil {
+			return err
+		}
+		table(tableName).Indexes[name] = Index{Unique: unique, NullFiltered: nullFiltered}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reading indexes: %w", err)
+	}
+
+	// Key columns have a position; stored columns have none
+	err = tx.Query(ctx, spanner.Statement{SQL: `
+		SELECT table_name, index_name, index_type, column_name, ordinal_position FROM information_schema.index_columns
+		WHERE table_schema = '' AND index_type IN ('INDEX', 'PRIMARY_KEY')
+		ORDER BY table_name, index_name, ordinal_position, column_name
+	`}).Do(func(row *spanner.Row) error {
+		var tableName, indexName, indexType, column string
+		var position spanner.NullInt64
+		if err := row.Columns(&tableName, &indexName, &indexType, &column, &position); err != nil {
+			return err
+		}
+		t := table(tableName)
+		if indexType == "PRIMARY_KEY" {
+			t.PrimaryKey = append(t.PrimaryKey, column)
+			return nil
+		}
+		index := t.Indexes[indexName]
+		if position.Valid {
+			index.Columns = append(index.Columns, column)
+		} else {
+			index.Storing = append(index.Storing, column)
+		}
+		t.Indexes[indexName] = index
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reading index columns: %w", err)
+	}
+	return schema, nil
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
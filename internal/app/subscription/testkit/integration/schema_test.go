@@ -0,0 +1,80 @@
+//go:build integration
+
+package integration
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingTB collects the errors an assertion reports
+type recordingTB struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recordingTB) Helper() {}
+
+func (r *recordingTB) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+var refunds = &Table{
+	PrimaryKey: []string{"id"},
+	Columns: map[string]Column{
+		"id":             {Type: "STRING(36)"},
+		"status":         {Type: "STRING(50)"},
+		"failure_reason": {Type: "STRING(MAX)", Nullable: true},
+	},
+	Indexes: map[string]Index{
+		"idx_refunds_status": {Columns: []string{"status", "requested_at"}, Storing: []string{"amount_cents"}},
+	},
+}
+
+func TestSchema_AssertPassesWhatTheSpecNames(t *testing.T) {
+	rec := &recordingTB{}
+	schema := &Schema{Tables: map[string]*Table{"refunds": refunds}}
+
+	schema.Assert(rec, TableSpec{
+		Name:       "refunds",
+		PrimaryKey: []string{"id"},
+		Columns:    map[string]string{"id": "STRING(36) NOT NULL", "failure_reason": "STRING(MAX)"},
+		Indexes:    []IndexSpec{{Name: "idx_refunds_status", Columns: []string{"status", "requested_at"}, Storing: []string{"amount_cents"}}},
+	})
+
+	assert.Empty(t, rec.errors)
+}
+
+func TestSchema_AssertReportsEveryDifference(t *testing.T) {
+	rec := &recordingTB{}
+	schema := &Schema{Tables: map[string]*Table{"refunds": refunds}}
+
+	schema.Assert(rec,
+		TableSpec{
+			Name:       "refunds",
+			PrimaryKey: []string{"id", "status"},
+			Columns: map[string]string{
+				"status":         "STRING(100) NOT NULL",
+				"failure_reason": "STRING(MAX) NOT NULL",
+				"settled_at":     "TIMESTAMP",
+			},
+			Indexes: []IndexSpec{
+				{Name: "idx_refunds_status", Columns: []string{"status", "requested_at"}, Unique: true},
+				{Name: "idx_refunds_customer_id", Columns: []string{"customer_id"}},
+			},
+		},
+		TableSpec{Name: "credit_notes"},
+	)
+
+	assert.Equal(t, []string{
+		"table refunds has primary key (id), want (id, status)",
+		"column refunds.failure_reason is STRING(MAX), want STRING(MAX) NOT NULL",
+		"column refunds.settled_at doesn't exist",
+		"column refunds.status is STRING(50) NOT NULL, want STRING(100) NOT NULL",
+		"index idx_refunds_status on refunds is INDEX idx_refunds_status(status, requested_at) STORING (amount_cents), want UNIQUE INDEX idx_refunds_status(status, requested_at)",
+		"index idx_refunds_customer_id on refunds doesn't exist",
+		"table credit_notes doesn't exist",
+	}, rec.errors)
+}
@@ -0,0 +1,52 @@
+package testkit
+
+import (
+	"context"
+
+	"cloud.google.com/go/spanner"
+	"github.com/stretchr/testify/mock"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+var _ contracts.TransactionalSubscriptionRepository = (*MockSubscriptions)(nil)
+
+// MockSubscriptions is a testify mock of the subscription repository, for tests that
+// set expectations on the mutations written rather than read subscriptions back from
+// FakeSubscriptions. Save returns the version check of the subscription it is given.
+type MockSubscriptions struct {
+	mock.Mock
+}
+
+func (m *MockSubscriptions) Save(ctx context.Context, sub *domain.Subscription) (*spanner.Mutation, contracts.VersionCheck, error) {
+	args := m.Called(ctx, sub)
+	if args.Get(0) == nil {
+		return nil, contracts.VersionCheck{}, args.Error(1)
+	}
+	return args.Get(0).(*spanner.Mutation), contracts.VersionCheck{SubscriptionID: sub.ID(), Version: sub.Version()}, args.Error(1)
+}
+
+func (m *MockSubscriptions) Apply(ctx context.Context, mutations ...*spanner.Mutation) error {
+	// Convert variadic to slice for mock
+	args := m.Called(ctx, mutations)
+	return args.Error(0)
+}
+
+// ApplyChecked is recorded as Apply, so the expectations on Apply cover checked commits
+func (m *MockSubscriptions) ApplyChecked(ctx context.Context, checks []contracts.VersionCheck, mutations ...*spanner.Mutation) error {
+	return m.Apply(ctx, mutations...)
+}
+
+// RunInTransaction runs fn with the mock as its transaction, so the expectations on
+// FindByID and Apply cover the reads and writes made in it
+func (m *MockSubscriptions) RunInTransaction(ctx context.Context, fn func(ctx context.Context, tx contracts.SubscriptionTransaction) error) error {
+	return fn(ctx, m)
+}
+
+func (m *MockSubscriptions) FindByID(ctx context.Context, id string) (*domain.Subscription, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Subscription), args.Error(1)
+}
@@ -0,0 +1,72 @@
+package testkit
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"cloud.google.com/go/spanner"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+var _ contracts.PlanRepository = (*FakePlans)(nil)
+
+// FakePlans is an in-memory PlanRepository. Plans are stored as soon as they are saved;
+// Apply does nothing. It is safe for concurrent use. The zero value is not usable; call
+// NewFakePlans.
+type FakePlans struct {
+	mu    sync.Mutex
+	plans map[string]*domain.Plan
+}
+
+// NewFakePlans returns a fake holding the plans
+func NewFakePlans(plans ...*domain.Plan) *FakePlans {
+	f := &FakePlans{plans: make(map[string]*domain.Plan)}
+	for _, plan := range plans {
+		f.plans[plan.ID()] = plan
+	}
+	return f
+}
+
+func (f *FakePlans) Save(ctx context.Context, plan *domain.Plan) (*spanner.Mutation, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.plans[plan.ID()] = plan
+	return &spanner.Mutation{}, nil
+}
+
+func (f *FakePlans) Insert(ctx context.Context, plan *domain.Plan) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.plans[plan.ID()]; ok {
+		return domain.ErrPlanAlreadyExists
+	}
+	f.plans[plan.ID()] = plan
+	return nil
+}
+
+func (f *FakePlans) FindAll(ctx context.Context) ([]*domain.Plan, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	plans := make([]*domain.Plan, 0, len(f.plans))
+	for _, plan := range f.plans {
+		plans = append(plans, plan)
+	}
+	sort.Slice(plans, func(i, j int) bool { return plans[i].ID() < plans[j].ID() })
+	return plans, nil
+}
+
+func (f *FakePlans) FindByID(ctx context.Context, id string) (*domain.Plan, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	plan, ok := f.plans[id]
+	if !ok {
+		return nil, domain.ErrPlanNotFound
+	}
+	return plan, nil
+}
+
+func (f *FakePlans) Apply(ctx context.Context, mutations ...*spanner.Mutation) error {
+	return nil
+}
@@ -0,0 +1,111 @@
+package testkit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+var _ contracts.ReferralRepository = (*FakeReferrals)(nil)
+
+// FakeReferrals is an in-memory ReferralRepository. Codes and referrals are stored as
+// soon as they are saved; Apply only counts its calls. It is safe for concurrent use.
+// The zero value is not usable; call NewFakeReferrals.
+type FakeReferrals struct {
+	mu        sync.Mutex
+	codes     map[string]string // customer ID to code
+	owners    map[string]string // code to customer ID
+	referrals map[string]*domain.Referral
+	applied   int
+}
+
+// NewFakeReferrals returns a fake with no codes or referrals
+func NewFakeReferrals() *FakeReferrals {
+	return &FakeReferrals{
+		codes:     make(map[string]string),
+		owners:    make(map[string]string),
+		referrals: make(map[string]*domain.Referral),
+	}
+}
+
+// WithCode gives the customer a referral code
+func (f *FakeReferrals) WithCode(customerID, code string) *FakeReferrals {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.codes[customerID] = code
+	f.owners[code] = customerID
+	return f
+}
+
+// WithReferral stores a referral as if its subscription had been created with it
+func (f *FakeReferrals) WithReferral(referral *domain.Referral) *FakeReferrals {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.referrals[referral.RefereeSubscriptionID()] = referral
+	return f
+}
+
+// Referral returns the referral saved for the subscription, nil if there is none
+func (f *FakeReferrals) Referral(subscriptionID string) *domain.Referral {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.referrals[subscriptionID]
+}
+
+// Applied returns how many times Apply was called
+func (f *FakeReferrals) Applied() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.applied
+}
+
+func (f *FakeReferrals) SaveCode(ctx context.Context, customerID, code string, createdAt time.Time) (*spanner.Mutation, error) {
+	f.WithCode(customerID, code)
+	return &spanner.Mutation{}, nil
+}
+
+func (f *FakeReferrals) FindCode(ctx context.Context, customerID string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	code, ok := f.codes[customerID]
+	if !ok {
+		return "", domain.ErrReferralCodeNotFound
+	}
+	return code, nil
+}
+
+func (f *FakeReferrals) FindCodeOwner(ctx context.Context, code string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	customerID, ok := f.owners[code]
+	if !ok {
+		return "", domain.ErrReferralCodeNotFound
+	}
+	return customerID, nil
+}
+
+func (f *FakeReferrals) Save(ctx context.Context, referral *domain.Referral) (*spanner.Mutation, error) {
+	f.WithReferral(referral)
+	return &spanner.Mutation{}, nil
+}
+
+func (f *FakeReferrals) FindBySubscription(ctx context.Context, subscriptionID string) (*domain.Referral, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	referral, ok := f.referrals[subscriptionID]
+	if !ok {
+		return nil, domain.ErrReferralNotFound
+	}
+	return referral, nil
+}
+
+func (f *FakeReferrals) Apply(ctx context.Context, mutations ...*spanner.Mutation) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.applied++
+	return nil
+}
@@ -0,0 +1,109 @@
+package testkit
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+var _ contracts.RefundOutboxRepository = (*FakeRefundOutbox)(nil)
+
+// FakeRefundOutbox is an in-memory RefundOutboxRepository. Saving or deleting a queued
+// refund takes effect straight away. It is safe for concurrent use. The zero value is
+// not usable; call NewFakeRefundOutbox.
+type FakeRefundOutbox struct {
+	mu      sync.Mutex
+	refunds map[string]*domain.QueuedRefund
+}
+
+// NewFakeRefundOutbox returns a fake holding no queued refunds
+func NewFakeRefundOutbox() *FakeRefundOutbox {
+	return &FakeRefundOutbox{refunds: make(map[string]*domain.QueuedRefund)}
+}
+
+// All returns the queued refunds, ordered by subscription ID
+func (f *FakeRefundOutbox) All() []*domain.QueuedRefund {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	refunds := make([]*domain.QueuedRefund, 0, len(f.refunds))
+	for _, r := range f.refunds {
+		refunds = append(refunds, r)
+	}
+	sort.Slice(refunds, func(i, j int) bool { return refunds[i].SubscriptionID() < refunds[j].SubscriptionID() })
+	return refunds
+}
+
+func (f *FakeRefundOutbox) Save(ctx context.Context, refund *domain.QueuedRefund) (*spanner.Mutation, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.refunds[refund.ID()] = refund
+	return &spanner.Mutation{}, nil
+}
+
+func (f *FakeRefundOutbox) Delete(ctx context.Context, id string) (*spanner.Mutation, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.refunds, id)
+	return &spanner.Mutation{}, nil
+}
+
+func (f *FakeRefundOutbox) FindByID(ctx context.Context, id string) (*domain.QueuedRefund, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	refund, ok := f.refunds[id]
+	if !ok {
+		return nil, domain.ErrQueuedRefundNotFound
+	}
+	return refund, nil
+}
+
+// FindDue returns queued refunds whose next attempt is at or before now, oldest first.
+// Failed refunds are no longer due.
+func (f *FakeRefundOutbox) FindDue(ctx context.Context, now time.Time, limit int) ([]*domain.QueuedRefund, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var due []*domain.QueuedRefund
+	for _, r := range f.refunds {
+		if r.Status() == domain.QueuedRefundQueued && !r.NextAttemptAt().After(now) {
+			due = append(due, r)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		if !due[i].NextAttemptAt().Equal(due[j].NextAttemptAt()) {
+			return due[i].NextAttemptAt().Before(due[j].NextAttemptAt())
+		}
+		return due[i].ID() < due[j].ID()
+	})
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	return due, nil
+}
+
+// FindByCustomer returns the refunds still owed to the customer, oldest first
+func (f *FakeRefundOutbox) FindByCustomer(ctx context.Context, customerID string) ([]*domain.QueuedRefund, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var owed []*domain.QueuedRefund
+	for _, r := range f.refunds {
+		if r.CustomerID() == customerID {
+			owed = append(owed, r)
+		}
+	}
+	sort.Slice(owed, func(i, j int) bool {
+		if !owed[i].QueuedAt().Equal(owed[j].QueuedAt()) {
+			return owed[i].QueuedAt().Before(owed[j].QueuedAt())
+		}
+		return owed[i].ID() < owed[j].ID()
+	})
+	return owed, nil
+}
+
+func (f *FakeRefundOutbox) Apply(ctx context.Context, mutations ...*spanner.Mutation) error {
+	return nil
+}
@@ -0,0 +1,37 @@
+package testkit
+
+import (
+	"context"
+	"sync"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+var _ contracts.RenewalNoticeNotifier = (*RecordingRenewalNotices)(nil)
+
+// RecordingRenewalNotices is a RenewalNoticeNotifier that keeps the notices it is sent,
+// and fails with Err when set. It is safe for concurrent use.
+type RecordingRenewalNotices struct {
+	Err error
+
+	mu      sync.Mutex
+	notices []*domain.RenewalUpcomingEvent
+}
+
+// Notices returns the notices sent so far
+func (n *RecordingRenewalNotices) Notices() []*domain.RenewalUpcomingEvent {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]*domain.RenewalUpcomingEvent(nil), n.notices...)
+}
+
+func (n *RecordingRenewalNotices) NotifyRenewalUpcoming(ctx context.Context, event *domain.RenewalUpcomingEvent) error {
+	if n.Err != nil {
+		return n.Err
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.notices = append(n.notices, event)
+	return nil
+}
@@ -0,0 +1,78 @@
+package testkit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+var _ contracts.RetentionOfferRepository = (*FakeRetentionOffers)(nil)
+
+// FakeRetentionOffers is an in-memory RetentionOfferRepository. Saving an offer stores
+// it straight away, since tests read offers back rather than mutations. It is safe
+// for concurrent use. The zero value is not usable; call NewFakeRetentionOffers.
+type FakeRetentionOffers struct {
+	mu     sync.Mutex
+	offers map[string]*domain.RetentionOffer
+	saved  []string
+}
+
+// NewFakeRetentionOffers returns a fake holding no offers
+func NewFakeRetentionOffers() *FakeRetentionOffers {
+	return &FakeRetentionOffers{offers: make(map[string]*domain.RetentionOffer)}
+}
+
+// With stores offers as if they had been saved before
+func (f *FakeRetentionOffers) With(offers ...*domain.RetentionOffer) *FakeRetentionOffers {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, o := range offers {
+		f.offers[o.ID()] = o
+	}
+	return f
+}
+
+// Saved returns the IDs of the offers saved so far, in order
+func (f *FakeRetentionOffers) Saved() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.saved...)
+}
+
+func (f *FakeRetentionOffers) Save(ctx context.Context, offer *domain.RetentionOffer) (*spanner.Mutation, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.offers[offer.ID()] = offer
+	f.saved = append(f.saved, offer.ID())
+	return &spanner.Mutation{}, nil
+}
+
+func (f *FakeRetentionOffers) FindByID(ctx context.Context, id string) (*domain.RetentionOffer, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	offer, ok := f.offers[id]
+	if !ok {
+		return nil, domain.ErrRetentionOfferNotFound
+	}
+	return offer, nil
+}
+
+func (f *FakeRetentionOffers) LastAcceptedAt(ctx context.Context, subscriptionID string) (time.Time, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var last time.Time
+	for _, o := range f.offers {
+		if o.SubscriptionID() == subscriptionID && o.Status() == domain.RetentionOfferAccepted && o.RespondedAt().After(last) {
+			last = o.RespondedAt()
+		}
+	}
+	return last, nil
+}
+
+func (f *FakeRetentionOffers) Apply(ctx context.Context, mutations ...*spanner.Mutation) error {
+	return nil
+}
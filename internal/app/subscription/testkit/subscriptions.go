@@ -0,0 +1,293 @@
+package testkit
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+var (
+	_ contracts.SubscriptionRepository              = (*FakeSubscriptions)(nil)
+	_ contracts.TransactionalSubscriptionRepository = (*FakeSubscriptions)(nil)
+	_ contracts.BulkCancellationRepository          = (*FakeSubscriptions)(nil)
+	_ contracts.SubscriptionListingRepository       = (*FakeSubscriptions)(nil)
+	_ contracts.SubscriptionScanRepository          = (*FakeSubscriptions)(nil)
+	_ contracts.RefundRepository                    = (*FakeRefunds)(nil)
+)
+
+// FakeSubscriptions is an in-memory SubscriptionRepository that also answers the
+// renewal scheduler's query. Saving a subscription stores it straight away, since
+// tests read subscriptions back rather than mutations. As with Spanner, a save fails
+// with domain.ErrConcurrentModification unless the subscription is at the stored
+// version, and stores a copy at the next one. It is safe for concurrent use. The zero
+// value is not usable; call NewFakeSubscriptions.
+type FakeSubscriptions struct {
+	mu   sync.Mutex
+	subs map[string]*domain.Subscription
+	// tx serializes transactions, as Spanner's locks do for those on one subscription
+	tx sync.Mutex
+}
+
+// NewFakeSubscriptions returns a fake holding no subscriptions
+func NewFakeSubscriptions() *FakeSubscriptions {
+	return &FakeSubscriptions{subs: make(map[string]*domain.Subscription)}
+}
+
+// With stores subscriptions as if they had been saved before
+func (f *FakeSubscriptions) With(subs ...*domain.Subscription) *FakeSubscriptions {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, s := range subs {
+		f.subs[s.ID()] = s
+	}
+	return f
+}
+
+// Len returns how many subscriptions are stored
+func (f *FakeSubscriptions) Len() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.subs)
+}
+
+// All returns the stored subscriptions, ordered by ID
+func (f *FakeSubscriptions) All() []*domain.Subscription {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	subs := make([]*domain.Subscription, 0, len(f.subs))
+	for _, s := range f.subs {
+		subs = append(subs, s)
+	}
+	sort.Slice(subs, func(i, j int) bool { return subs[i].ID() < subs[j].ID() })
+	return subs
+}
+
+func (f *FakeSubscriptions) Save(ctx context.Context, sub *domain.Subscription) (*spanner.Mutation, contracts.VersionCheck, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var stored int64
+	if s, ok := f.subs[sub.ID()]; ok {
+		stored = s.Version()
+	}
+	if sub.Version() != stored {
+		return nil, contracts.VersionCheck{}, domain.ErrConcurrentModification
+	}
+	saved := sub.Clone()
+	domain.WithVersion(sub.Version() + 1)(saved)
+	f.subs[sub.ID()] = saved
+	return &spanner.Mutation{}, contracts.VersionCheck{SubscriptionID: sub.ID(), Version: sub.Version()}, nil
+}
+
+// RunInTransaction runs fn with the fake as its transaction, one transaction at a
+// time. What fn saves is stored straight away, even when it then fails.
+func (f *FakeSubscriptions) RunInTransaction(ctx context.Context, fn func(ctx context.Context, tx contracts.SubscriptionTransaction) error) error {
+	f.tx.Lock()
+	defer f.tx.Unlock()
+	return fn(ctx, f)
+}
+
+func (f *FakeSubscriptions) FindByID(ctx context.Context, id string) (*domain.Subscription, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	sub, ok := f.subs[id]
+	if !ok {
+		return nil, domain.ErrSubscriptionNotFound
+	}
+	return sub, nil
+}
+
+func (f *FakeSubscriptions) FindByIDs(ctx context.Context, ids []string) ([]*domain.Subscription, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var subs []*domain.Subscription
+	for _, id := range ids {
+		if sub, ok := f.subs[id]; ok {
+			subs = append(subs, sub)
+		}
+	}
+	return subs, nil
+}
+
+// FindIDsByCustomer returns the IDs of the customer's subscriptions that aren't
+// cancelled, ordered by ID
+func (f *FakeSubscriptions) FindIDsByCustomer(ctx context.Context, customerID string) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var ids []string
+	for _, s := range f.subs {
+		if s.CustomerID() == customerID && s.Status() != domain.StatusCancelled {
+			ids = append(ids, s.ID())
+		}
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// FindIDsByPlan returns the IDs of the plan's subscriptions that aren't cancelled,
+// ordered by ID
+func (f *FakeSubscriptions) FindIDsByPlan(ctx context.Context, planID string) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var ids []string
+	for _, s := range f.subs {
+		if s.PlanID() == planID && s.Status() != domain.StatusCancelled {
+			ids = append(ids, s.ID())
+		}
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// ListByCustomer returns a page of the customer's subscriptions in the order of the
+// Spanner query: by start date, then by ID
+func (f *FakeSubscriptions) ListByCustomer(ctx context.Context, customerID string, status domain.SubscriptionStatus, after contracts.ListingCursor, limit int) ([]contracts.SubscriptionSummary, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var page []contracts.SubscriptionSummary
+	for _, s := range f.subs {
+		if s.CustomerID() != customerID || (status != "" && s.Status() != status) {
+			continue
+		}
+		if s.StartDate().Before(after.StartDate) || (s.StartDate().Equal(after.StartDate) && s.ID() <= after.ID) {
+			continue
+		}
+		page = append(page, contracts.SubscriptionSummary{ID: s.ID(), CustomerID: s.CustomerID(), PlanID: s.PlanID(), Price: s.Price(), Status: s.Status(), StartDate: s.StartDate()})
+	}
+	sort.Slice(page, func(i, j int) bool {
+		if !page[i].StartDate.Equal(page[j].StartDate) {
+			return page[i].StartDate.Before(page[j].StartDate)
+		}
+		return page[i].ID < page[j].ID
+	})
+	if len(page) > limit {
+		page = page[:limit]
+	}
+	return page, nil
+}
+
+// ForEachByStatus calls fn with the subscriptions of the status, ordered by ID. fn
+// runs without the fake locked, so it may save what it is given.
+func (f *FakeSubscriptions) ForEachByStatus(ctx context.Context, status domain.SubscriptionStatus, fn func(*domain.Subscription) error) error {
+	for _, s := range f.All() {
+		if s.Status() != status {
+			continue
+		}
+		if err := fn(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FindDueForCancellation returns subscriptions pending cancellation whose cancel_at
+// is by now, in the order of the Spanner query: soonest first, then by ID
+func (f *FakeSubscriptions) FindDueForCancellation(ctx context.Context, now time.Time, limit int) ([]*domain.Subscription, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var due []*domain.Subscription
+	for _, s := range f.subs {
+		if s.Status() == domain.StatusPendingCancellation && !s.CancelAt().After(now) {
+			due = append(due, s)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		if !due[i].CancelAt().Equal(due[j].CancelAt()) {
+			return due[i].CancelAt().Before(due[j].CancelAt())
+		}
+		return due[i].ID() < due[j].ID()
+	})
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	return due, nil
+}
+
+func (f *FakeSubscriptions) Apply(ctx context.Context, mutations ...*spanner.Mutation) error {
+	return nil
+}
+
+// ApplyChecked commits nothing more, since Save already checked the version
+func (f *FakeSubscriptions) ApplyChecked(ctx context.Context, checks []contracts.VersionCheck, mutations ...*spanner.Mutation) error {
+	return nil
+}
+
+// FakeRefunds is an in-memory RefundRepository. Saving a refund stores it straight
+// away. It is safe for concurrent use. The zero value is not usable; call
+// NewFakeRefunds.
+type FakeRefunds struct {
+	mu      sync.Mutex
+	refunds map[string]*domain.Refund
+	saved   []string
+}
+
+// NewFakeRefunds returns a fake holding no refunds
+func NewFakeRefunds() *FakeRefunds {
+	return &FakeRefunds{refunds: make(map[string]*domain.Refund)}
+}
+
+// Saved returns the IDs of the refunds saved so far, in order
+func (f *FakeRefunds) Saved() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.saved...)
+}
+
+func (f *FakeRefunds) Save(ctx context.Context, refund *domain.Refund) (*spanner.Mutation, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.refunds[refund.ID()] = refund
+	f.saved = append(f.saved, refund.ID())
+	return &spanner.Mutation{}, nil
+}
+
+func (f *FakeRefunds) FindByID(ctx context.Context, id string) (*domain.Refund, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	refund, ok := f.refunds[id]
+	if !ok {
+		return nil, domain.ErrRefundNotFound
+	}
+	return refund, nil
+}
+
+func (f *FakeRefunds) FindByProviderRefundID(ctx context.Context, providerRefundID string) (*domain.Refund, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, r := range f.refunds {
+		if providerRefundID != "" && r.ProviderRefundID() == providerRefundID {
+			return r, nil
+		}
+	}
+	return nil, domain.ErrRefundNotFound
+}
+
+// FindPending returns pending refunds requested by requestedBefore, oldest first
+func (f *FakeRefunds) FindPending(ctx context.Context, requestedBefore time.Time, limit int) ([]*domain.Refund, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var pending []*domain.Refund
+	for _, r := range f.refunds {
+		if r.Status() == domain.RefundPending && !r.RequestedAt().After(requestedBefore) {
+			pending = append(pending, r)
+		}
+	}
+	sort.Slice(pending, func(i, j int) bool {
+		if !pending[i].RequestedAt().Equal(pending[j].RequestedAt()) {
+			return pending[i].RequestedAt().Before(pending[j].RequestedAt())
+		}
+		return pending[i].ID() < pending[j].ID()
+	})
+	if len(pending) > limit {
+		pending = pending[:limit]
+	}
+	return pending, nil
+}
+
+func (f *FakeRefunds) Apply(ctx context.Context, mutations ...*spanner.Mutation) error {
+	return nil
+}
@@ -0,0 +1,94 @@
+package testkit
+
+import (
+	"context"
+	"sync"
+
+	"cloud.google.com/go/spanner"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+var (
+	_ contracts.SubscriptionTemplateRepository = (*FakeTemplates)(nil)
+	_ contracts.SubscriptionBundleRepository   = (*FakeBundles)(nil)
+)
+
+// FakeTemplates is an in-memory SubscriptionTemplateRepository that, like the real
+// one, refuses a second template with the same name. It is safe for concurrent use.
+// The zero value is not usable; call NewFakeTemplates.
+type FakeTemplates struct {
+	mu        sync.Mutex
+	templates map[string]*domain.SubscriptionTemplate
+}
+
+// NewFakeTemplates returns a fake holding no templates
+func NewFakeTemplates() *FakeTemplates {
+	return &FakeTemplates{templates: make(map[string]*domain.SubscriptionTemplate)}
+}
+
+// With stores templates as if they had been inserted before
+func (f *FakeTemplates) With(templates ...*domain.SubscriptionTemplate) *FakeTemplates {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, t := range templates {
+		f.templates[t.ID()] = t
+	}
+	return f
+}
+
+func (f *FakeTemplates) Insert(ctx context.Context, template *domain.SubscriptionTemplate) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, t := range f.templates {
+		if t.Name() == template.Name() {
+			return domain.ErrTemplateNameTaken
+		}
+	}
+	f.templates[template.ID()] = template
+	return nil
+}
+
+func (f *FakeTemplates) FindByID(ctx context.Context, id string) (*domain.SubscriptionTemplate, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	template, ok := f.templates[id]
+	if !ok {
+		return nil, domain.ErrTemplateNotFound
+	}
+	return template, nil
+}
+
+// FakeBundles is an in-memory SubscriptionBundleRepository. Saving a bundle stores it
+// straight away, since tests read bundles back rather than mutations. It is safe for
+// concurrent use. The zero value is not usable; call NewFakeBundles.
+type FakeBundles struct {
+	mu      sync.Mutex
+	bundles map[string]domain.SubscriptionBundle
+}
+
+// NewFakeBundles returns a fake in which no subscription has a bundle
+func NewFakeBundles() *FakeBundles {
+	return &FakeBundles{bundles: make(map[string]domain.SubscriptionBundle)}
+}
+
+// With gives the subscription a bundle
+func (f *FakeBundles) With(subscriptionID string, bundle domain.SubscriptionBundle) *FakeBundles {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.bundles[subscriptionID] = bundle
+	return f
+}
+
+func (f *FakeBundles) Save(ctx context.Context, subscriptionID string, bundle domain.SubscriptionBundle) ([]*spanner.Mutation, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.bundles[subscriptionID] = bundle
+	return []*spanner.Mutation{{}}, nil
+}
+
+func (f *FakeBundles) FindBySubscriptionID(ctx context.Context, subscriptionID string) (domain.SubscriptionBundle, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.bundles[subscriptionID], nil
+}
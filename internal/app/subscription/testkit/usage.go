@@ -0,0 +1,122 @@
+package testkit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+var (
+	_ contracts.UsageRepository    = (*FakeUsage)(nil)
+	_ contracts.UsageAlertNotifier = (*RecordingUsageAlerts)(nil)
+)
+
+// FakeUsage is an in-memory UsageRepository. Records are stored as soon as they are
+// saved; Apply only counts its calls. Alerts are claimed once per threshold and
+// period, like the Spanner repository. It is safe for concurrent use. The zero value
+// is not usable; call NewFakeUsage.
+type FakeUsage struct {
+	mu      sync.Mutex
+	records []*domain.UsageRecord
+	alerts  map[alertKey]bool
+	applied int
+}
+
+// NewFakeUsage returns a fake with no usage
+func NewFakeUsage() *FakeUsage {
+	return &FakeUsage{alerts: make(map[alertKey]bool)}
+}
+
+// Records returns the usage records saved
+func (f *FakeUsage) Records() []*domain.UsageRecord {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]*domain.UsageRecord(nil), f.records...)
+}
+
+// Applied returns how many times Apply was called
+func (f *FakeUsage) Applied() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.applied
+}
+
+func (f *FakeUsage) Save(ctx context.Context, record *domain.UsageRecord) (*spanner.Mutation, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.records = append(f.records, record)
+	return &spanner.Mutation{}, nil
+}
+
+func (f *FakeUsage) FindPeriodUsage(ctx context.Context, subscriptionID, metric string, periodStart time.Time) (domain.PeriodUsage, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var usage domain.PeriodUsage
+	for _, r := range f.records {
+		if r.SubscriptionID() == subscriptionID && r.Metric() == metric && r.PeriodStart().Equal(periodStart) {
+			usage.Quantity += r.Quantity()
+		}
+	}
+	for key := range f.alerts {
+		if key.subscriptionID == subscriptionID && key.metric == metric && key.periodStart.Equal(periodStart) {
+			usage.Alerted = append(usage.Alerted, key.threshold)
+		}
+	}
+	return usage, nil
+}
+
+func (f *FakeUsage) ClaimAlert(ctx context.Context, event *domain.UsageThresholdReachedEvent) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key := alertKey{event.SubscriptionID, event.Metric, event.PeriodStart, event.ThresholdBP}
+	if f.alerts[key] {
+		return domain.ErrUsageAlertAlreadySent
+	}
+	f.alerts[key] = true
+	return nil
+}
+
+func (f *FakeUsage) Apply(ctx context.Context, mutations ...*spanner.Mutation) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.applied++
+	return nil
+}
+
+// alertKey identifies an alert claim, like the usage_alerts primary key
+type alertKey struct {
+	subscriptionID string
+	metric         string
+	periodStart    time.Time
+	threshold      int64
+}
+
+// RecordingUsageAlerts is a UsageAlertNotifier that keeps the alerts it is sent, and
+// fails with Err when set. It is safe for concurrent use.
+type RecordingUsageAlerts struct {
+	Err error
+
+	mu     sync.Mutex
+	alerts []*domain.UsageThresholdReachedEvent
+}
+
+// Alerts returns the alerts sent so far
+func (n *RecordingUsageAlerts) Alerts() []*domain.UsageThresholdReachedEvent {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]*domain.UsageThresholdReachedEvent(nil), n.alerts...)
+}
+
+func (n *RecordingUsageAlerts) NotifyUsageThreshold(ctx context.Context, event *domain.UsageThresholdReachedEvent) error {
+	if n.Err != nil {
+		return n.Err
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.alerts = append(n.alerts, event)
+	return nil
+}
@@ -0,0 +1,125 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var _ Exporter = (*DatadogExporter)(nil)
+
+// DatadogExporter sends spans to a Datadog agent's trace intake, for environments that
+// run the agent rather than an OpenTelemetry collector. Datadog IDs are 64 bits: the
+// span ID is used as is, the trace ID's low half becomes trace_id and its high half is
+// kept in the _dd.p.tid tag, so the trace joins up with W3C-propagated spans.
+type DatadogExporter struct {
+	client      *http.Client
+	endpoint    string
+	serviceName string
+}
+
+// NewDatadogExporter creates an exporter putting spans to agentURL, the agent's trace
+// endpoint (for example http://localhost:8126); spans go to <agentURL>/v0.3/traces
+func NewDatadogExporter(client *http.Client, agentURL, serviceName string) *DatadogExporter {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &DatadogExporter{
+		client:      client,
+		endpoint:    strings.TrimSuffix(agentURL, "/") + "/v0.3/traces",
+		serviceName: serviceName,
+	}
+}
+
+// Export sends one batch of spans, grouped by trace as the agent expects
+func (e *DatadogExporter) Export(ctx context.Context, spans []SpanData) error {
+	traces := e.encode(spans)
+	body, err := json.Marshal(traces)
+	if err != nil {
+		return fmt.Errorf("failed to marshal spans: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "PUT", e.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Datadog-Trace-Count", strconv.Itoa(len(traces)))
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to export spans: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		bodyBytes, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("datadog agent responded %d: %s", resp.StatusCode, bodyBytes)
+	}
+	return nil
+}
+
+// ddSpan is the agent's v0.3 span; times are nanoseconds
+type ddSpan struct {
+	TraceID  uint64            `json:"trace_id"`
+	SpanID   uint64            `json:"span_id"`
+	ParentID uint64            `json:"parent_id,omitempty"`
+	Name     string            `json:"name"`
+	Resource string            `json:"resource"`
+	Service  string            `json:"service"`
+	Type     string            `json:"type,omitempty"`
+	Start    int64             `json:"start"`
+	Duration int64             `json:"duration"`
+	Error    int32             `json:"error"`
+	Meta     map[string]string `json:"meta,omitempty"`
+}
+
+// encode converts spans to the agent's payload: one list of spans per trace, in the
+// order each trace first appears
+func (e *DatadogExporter) encode(spans []SpanData) [][]ddSpan {
+	var traces [][]ddSpan
+	index := make(map[TraceID]int)
+	for _, s := range spans {
+		span := ddSpan{
+			TraceID:  binary.BigEndian.Uint64(s.SpanContext.TraceID[8:]),
+			SpanID:   binary.BigEndian.Uint64(s.SpanContext.SpanID[:]),
+			ParentID: binary.BigEndian.Uint64(s.ParentSpanID[:]),
+			Name:     "subscription." + s.Kind.String(),
+			Resource: s.Name,
+			Service:  e.serviceName,
+			Start:    s.Start.UnixNano(),
+			Duration: s.End.Sub(s.Start).Nanoseconds(),
+			Meta:     map[string]string{"span.kind": s.Kind.String()},
+		}
+		if s.Kind == KindServer {
+			span.Type = "web"
+		}
+		for k, v := range s.Attributes {
+			span.Meta[k] = v
+		}
+		if high := s.SpanContext.TraceID[:8]; !bytes.Equal(high, make([]byte, 8)) {
+			span.Meta["_dd.p.tid"] = hex.EncodeToString(high)
+		}
+		if len(s.Errors) > 0 {
+			span.Error = 1
+			span.Meta["error.message"] = s.Errors[len(s.Errors)-1]
+		}
+
+		i, ok := index[s.SpanContext.TraceID]
+		if !ok {
+			i = len(traces)
+			index[s.SpanContext.TraceID] = i
+			traces = append(traces, nil)
+		}
+		traces[i] = append(traces[i], span)
+	}
+	return traces
+}
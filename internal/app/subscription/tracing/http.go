@@ -0,0 +1,87 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+)
+
+// startSpan starts a span of the given kind when tracer supports kinds, and an internal span otherwise
+func startSpan(ctx context.Context, tracer contracts.Tracer, name string, kind SpanKind) (context.Context, contracts.Span) {
+	if t, ok := tracer.(*Tracer); ok {
+		return t.StartWithKind(ctx, name, kind)
+	}
+	return tracer.Start(ctx, name)
+}
+
+// Transport is an http.RoundTripper that traces each outbound request as a client
+// span and propagates the trace to the server with a traceparent header
+type Transport struct {
+	Base   http.RoundTripper
+	Tracer contracts.Tracer
+}
+
+// NewTransport wraps base, which defaults to http.DefaultTransport
+func NewTransport(base http.RoundTripper, tracer contracts.Tracer) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &Transport{Base: base, Tracer: tracer}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, span := startSpan(req.Context(), t.Tracer, "HTTP "+req.Method, KindClient)
+	defer span.End()
+	span.SetAttribute("http.method", req.Method)
+	span.SetAttribute("http.url", req.URL.Redacted())
+
+	// RoundTrippers must not modify the caller's request
+	traced := req.Clone(ctx)
+	Inject(ctx, traced.Header)
+
+	resp, err := t.Base.RoundTrip(traced)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	span.SetAttribute("http.status_code", strconv.Itoa(resp.StatusCode))
+	return resp, nil
+}
+
+// Middleware traces each inbound request as a server span named name, continuing
+// the caller's trace when the request carries a traceparent header
+func Middleware(tracer contracts.Tracer, name string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := startSpan(Extract(r.Context(), r.Header), tracer, name, KindServer)
+		defer span.End()
+		span.SetAttribute("http.method", r.Method)
+		span.SetAttribute("http.target", r.URL.Path)
+
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r.WithContext(ctx))
+
+		span.SetAttribute("http.status_code", strconv.Itoa(rec.status))
+		if rec.status >= 500 {
+			span.RecordError(errServerStatus(rec.status))
+		}
+	})
+}
+
+// statusRecorder captures the status code written by a handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+type errServerStatus int
+
+func (e errServerStatus) Error() string {
+	return "server responded " + strconv.Itoa(int(e))
+}
@@ -0,0 +1,65 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+var _ Exporter = (*StdoutExporter)(nil)
+
+// StdoutExporter writes each span as one JSON line, for local development without a
+// tracing backend
+type StdoutExporter struct {
+	mu          sync.Mutex
+	enc         *json.Encoder
+	serviceName string
+}
+
+// NewStdoutExporter creates an exporter writing to w, typically os.Stdout
+func NewStdoutExporter(w io.Writer, serviceName string) *StdoutExporter {
+	return &StdoutExporter{enc: json.NewEncoder(w), serviceName: serviceName}
+}
+
+// stdoutSpan is a span as written by StdoutExporter
+type stdoutSpan struct {
+	Service    string            `json:"service"`
+	Name       string            `json:"name"`
+	Kind       string            `json:"kind"`
+	TraceID    string            `json:"trace_id"`
+	SpanID     string            `json:"span_id"`
+	ParentID   string            `json:"parent_id,omitempty"`
+	Start      time.Time         `json:"start"`
+	DurationMS float64           `json:"duration_ms"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+	Errors     []string          `json:"errors,omitempty"`
+}
+
+// Export writes one batch of spans
+func (e *StdoutExporter) Export(ctx context.Context, spans []SpanData) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, s := range spans {
+		line := stdoutSpan{
+			Service:    e.serviceName,
+			Name:       s.Name,
+			Kind:       s.Kind.String(),
+			TraceID:    s.SpanContext.TraceID.String(),
+			SpanID:     s.SpanContext.SpanID.String(),
+			Start:      s.Start,
+			DurationMS: float64(s.End.Sub(s.Start).Microseconds()) / 1000,
+			Attributes: s.Attributes,
+			Errors:     s.Errors,
+		}
+		if s.ParentSpanID != (SpanID{}) {
+			line.ParentID = s.ParentSpanID.String()
+		}
+		if err := e.enc.Encode(line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,345 @@
+// Package tracing implements distributed tracing compatible with OpenTelemetry: spans
+// carry W3C trace context across HTTP boundaries and are exported over OTLP/HTTP, to a
+// Datadog agent or to stdout, so a single trace follows an operation from the inbound
+// request through Spanner and billing.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/logging"
+)
+
+var _ contracts.Tracer = (*Tracer)(nil)
+
+// TraceID identifies a trace
+type TraceID [16]byte
+
+func (id TraceID) String() string { return hex.EncodeToString(id[:]) }
+
+// SpanID identifies a span within a trace
+type SpanID [8]byte
+
+func (id SpanID) String() string { return hex.EncodeToString(id[:]) }
+
+// SpanContext is the part of a span that crosses process boundaries
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Sampled bool
+	Remote  bool // extracted from an inbound request rather than started here
+}
+
+// IsValid reports whether both IDs are set
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != TraceID{} && sc.SpanID != SpanID{}
+}
+
+type spanContextKey struct{}
+
+// ContextWithSpanContext returns a context whose next span is a child of sc
+func ContextWithSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, spanContextKey{}, sc)
+}
+
+// SpanContextFromContext returns the span context of the current span, if any
+func SpanContextFromContext(ctx context.Context) (SpanContext, bool) {
+	sc, ok := ctx.Value(spanContextKey{}).(SpanContext)
+	return sc, ok && sc.IsValid()
+}
+
+// SpanKind matches the OTLP span kinds
+type SpanKind int
+
+const (
+	KindInternal SpanKind = 1
+	KindServer   SpanKind = 2
+	KindClient   SpanKind = 3
+)
+
+func (k SpanKind) String() string {
+	switch k {
+	case KindServer:
+		return "server"
+	case KindClient:
+		return "client"
+	default:
+		return "internal"
+	}
+}
+
+// SpanData is a finished span as handed to an Exporter
+type SpanData struct {
+	Name         string
+	Kind         SpanKind
+	SpanContext  SpanContext
+	ParentSpanID SpanID // zero for a root span
+	Start        time.Time
+	End          time.Time
+	Attributes   map[string]string
+	Errors       []string // messages passed to RecordError, in order
+}
+
+// Exporter sends finished spans to a tracing backend
+type Exporter interface {
+	Export(ctx context.Context, spans []SpanData) error
+}
+
+// Config configures a Tracer
+type Config struct {
+	// Exporter receives sampled spans in batches. Nil still propagates trace context
+	// to downstream services but exports nothing.
+	Exporter Exporter
+
+	// SampleRatio is the fraction of new traces that are recorded. A span with a parent
+	// follows its parent's decision, so a trace is never partly recorded.
+	SampleRatio float64
+
+	BatchSize     int           // spans per export; defaults to 512
+	BatchInterval time.Duration // longest a span waits for export; defaults to 5s
+	MaxQueue      int           // spans buffered before new ones are dropped; defaults to 2048
+
+	Logger *slog.Logger // export failures; nil discards them
+}
+
+// Tracer starts spans and exports them in the background. Call Shutdown before exit
+// so buffered spans are flushed.
+type Tracer struct {
+	cfg Config
+
+	mu      sync.Mutex
+	queue   []SpanData
+	dropped int
+
+	flush chan struct{}
+	stop  chan struct{}
+	done  chan struct{}
+}
+
+// NewTracer creates a tracer and starts its export loop
+func NewTracer(cfg Config) *Tracer {
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 512
+	}
+	if cfg.BatchInterval <= 0 {
+		cfg.BatchInterval = 5 * time.Second
+	}
+	if cfg.MaxQueue <= 0 {
+		cfg.MaxQueue = 2048
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = logging.Discard()
+	}
+
+	t := &Tracer{
+		cfg:   cfg,
+		flush: make(chan struct{}, 1),
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+	go t.loop()
+	return t
+}
+
+// Start starts an internal span as a child of the span in ctx
+func (t *Tracer) Start(ctx context.Context, name string) (context.Context, contracts.Span) {
+	return t.StartWithKind(ctx, name, KindInternal)
+}
+
+// StartWithKind starts a span of the given kind as a child of the span in ctx
+func (t *Tracer) StartWithKind(ctx context.Context, name string, kind SpanKind) (context.Context, *Span) {
+	parent, hasParent := SpanContextFromContext(ctx)
+
+	sc := SpanContext{SpanID: newSpanID()}
+	if hasParent {
+		sc.TraceID = parent.TraceID
+		sc.Sampled = parent.Sampled
+	} else {
+		sc.TraceID = newTraceID()
+		sc.Sampled = t.sample(sc.TraceID)
+	}
+
+	s := &Span{
+		tracer: t,
+		data: SpanData{
+			Name:        name,
+			Kind:        kind,
+			SpanContext: sc,
+			Start:       time.Now(),
+			Attributes:  make(map[string]string),
+		},
+	}
+	if hasParent {
+		s.data.ParentSpanID = parent.SpanID
+	}
+	return ContextWithSpanContext(ctx, sc), s
+}
+
+// Shutdown exports every buffered span and stops the export loop
+func (t *Tracer) Shutdown(ctx context.Context) error {
+	select {
+	case <-t.stop:
+	default:
+		close(t.stop)
+	}
+	select {
+	case <-t.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// sample decides whether a new trace is recorded, from the trace ID so every
+// service that sees the ID would make the same decision
+func (t *Tracer) sample(id TraceID) bool {
+	switch {
+	case t.cfg.SampleRatio >= 1:
+		return true
+	case t.cfg.SampleRatio <= 0:
+		return false
+	}
+	bound := uint64(t.cfg.SampleRatio * (1 << 63))
+	return binary.BigEndian.Uint64(id[8:])>>1 < bound
+}
+
+// enqueue buffers a finished span for export
+func (t *Tracer) enqueue(data SpanData) {
+	if t.cfg.Exporter == nil {
+		return
+	}
+
+	t.mu.Lock()
+	if len(t.queue) >= t.cfg.MaxQueue {
+		t.dropped++
+		t.mu.Unlock()
+		return
+	}
+	t.queue = append(t.queue, data)
+	full := len(t.queue) >= t.cfg.BatchSize
+	t.mu.Unlock()
+
+	if full {
+		select {
+		case t.flush <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// loop exports a batch when one fills up or every BatchInterval, and drains the queue on Shutdown
+func (t *Tracer) loop() {
+	defer close(t.done)
+	ticker := time.NewTicker(t.cfg.BatchInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-t.stop:
+			for t.exportBatch() {
+			}
+			return
+		case <-t.flush:
+			for t.exportBatch() {
+			}
+		case <-ticker.C:
+			t.exportBatch()
+		}
+	}
+}
+
+// exportBatch exports up to BatchSize queued spans and reports whether more remain
+func (t *Tracer) exportBatch() bool {
+	t.mu.Lock()
+	n := min(len(t.queue), t.cfg.BatchSize)
+	batch := t.queue[:n:n]
+	t.queue = t.queue[n:]
+	more := len(t.queue) > 0
+	dropped := t.dropped
+	t.dropped = 0
+	t.mu.Unlock()
+
+	if dropped > 0 {
+		t.cfg.Logger.Warn("trace queue full, spans dropped", slog.Int("dropped", dropped))
+	}
+	if n == 0 {
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := t.cfg.Exporter.Export(ctx, batch); err != nil {
+		t.cfg.Logger.Warn("failed to export spans", slog.Int("spans", n), slog.Any("error", err))
+	}
+	return more
+}
+
+// Span is a span started by Tracer. It is safe for concurrent use.
+type Span struct {
+	tracer *Tracer
+
+	mu    sync.Mutex
+	data  SpanData
+	ended bool
+}
+
+// SpanContext returns the span's identity, for propagation
+func (s *Span) SpanContext() SpanContext {
+	return s.data.SpanContext
+}
+
+func (s *Span) SetAttribute(key, value string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.ended {
+		s.data.Attributes[key] = value
+	}
+}
+
+// RecordError marks the span as failed
+func (s *Span) RecordError(err error) {
+	if err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.ended {
+		s.data.Errors = append(s.data.Errors, err.Error())
+	}
+}
+
+// End finishes the span and queues it for export if its trace is sampled; later calls do nothing
+func (s *Span) End() {
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.End = time.Now()
+	data := s.data
+	s.mu.Unlock()
+
+	if data.SpanContext.Sampled {
+		s.tracer.enqueue(data)
+	}
+}
+
+func newTraceID() TraceID {
+	var id TraceID
+	_, _ = rand.Read(id[:])
+	return id
+}
+
+func newSpanID() SpanID {
+	var id SpanID
+	_, _ = rand.Read(id[:])
+	return id
+}
@@ -0,0 +1,275 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingExporter keeps every exported span
+type recordingExporter struct {
+	mu    sync.Mutex
+	spans []SpanData
+}
+
+func (e *recordingExporter) Export(ctx context.Context, spans []SpanData) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = append(e.spans, spans...)
+	return nil
+}
+
+func (e *recordingExporter) byName() map[string]SpanData {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	out := make(map[string]SpanData, len(e.spans))
+	for _, s := range e.spans {
+		out[s.Name] = s
+	}
+	return out
+}
+
+func shutdown(t *testing.T, tracer *Tracer) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, tracer.Shutdown(ctx))
+}
+
+func TestTracer_ChildSpansShareTheTrace(t *testing.T) {
+	exporter := &recordingExporter{}
+	tracer := NewTracer(Config{Exporter: exporter, SampleRatio: 1})
+
+	ctx, parent := tracer.Start(context.Background(), "usecase.cancel_subscription")
+	_, child := tracer.Start(ctx, "spanner.subscriptions.Apply")
+	child.RecordError(errors.New("aborted"))
+	child.End()
+	parent.End()
+	shutdown(t, tracer)
+
+	spans := exporter.byName()
+	require.Len(t, spans, 2)
+	p, c := spans["usecase.cancel_subscription"], spans["spanner.subscriptions.Apply"]
+	assert.Equal(t, p.SpanContext.TraceID, c.SpanContext.TraceID)
+	assert.Equal(t, p.SpanContext.SpanID, c.ParentSpanID)
+	assert.Equal(t, SpanID{}, p.ParentSpanID)
+	assert.Equal(t, []string{"aborted"}, c.Errors)
+}
+
+func TestTracer_UnsampledTracesAreNotExported(t *testing.T) {
+	exporter := &recordingExporter{}
+	tracer := NewTracer(Config{Exporter: exporter, SampleRatio: 0})
+
+	ctx, span := tracer.Start(context.Background(), "root")
+	_, child := tracer.Start(ctx, "child")
+	child.End()
+	span.End()
+	shutdown(t, tracer)
+
+	assert.Empty(t, exporter.spans)
+}
+
+func TestTracer_RemoteParentDecidesSampling(t *testing.T) {
+	exporter := &recordingExporter{}
+	tracer := NewTracer(Config{Exporter: exporter, SampleRatio: 0})
+
+	h := http.Header{}
+	h.Set(TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	_, span := tracer.Start(Extract(context.Background(), h), "webhook")
+	span.End()
+	shutdown(t, tracer)
+
+	require.Len(t, exporter.spans, 1)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", exporter.spans[0].SpanContext.TraceID.String())
+	assert.Equal(t, "00f067aa0ba902b7", exporter.spans[0].ParentSpanID.String())
+}
+
+func TestPropagation_RoundTrip(t *testing.T) {
+	sc := SpanContext{TraceID: newTraceID(), SpanID: newSpanID(), Sampled: true}
+	h := http.Header{}
+
+	Inject(ContextWithSpanContext(context.Background(), sc), h)
+	got, ok := SpanContextFromContext(Extract(context.Background(), h))
+
+	require.True(t, ok)
+	assert.Equal(t, sc.TraceID, got.TraceID)
+	assert.Equal(t, sc.SpanID, got.SpanID)
+	assert.True(t, got.Sampled)
+	assert.True(t, got.Remote)
+}
+
+func TestPropagation_MalformedHeaderStartsNewTrace(t *testing.T) {
+	for _, value := range []string{
+		"",
+		"garbage",
+		"01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-zzf067aa0ba902b7-01",
+	} {
+		h := http.Header{}
+		h.Set(TraceparentHeader, value)
+		_, ok := SpanContextFromContext(Extract(context.Background(), h))
+		assert.False(t, ok, value)
+	}
+}
+
+func TestTransportAndMiddleware_JoinOneTrace(t *testing.T) {
+	exporter := &recordingExporter{}
+	tracer := NewTracer(Config{Exporter: exporter, SampleRatio: 1})
+
+	server := httptest.NewServer(Middleware(tracer, "POST /charges", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	})))
+	defer server.Close()
+
+	client := &http.Client{Transport: NewTransport(nil, tracer)}
+	ctx, root := tracer.Start(context.Background(), "usecase.renew_subscription")
+	req, err := http.NewRequestWithContext(ctx, "POST", server.URL+"/charges", nil)
+	require.NoError(t, err)
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	root.End()
+	shutdown(t, tracer)
+
+	spans := exporter.byName()
+	require.Len(t, spans, 3)
+	clientSpan, serverSpan := spans["HTTP POST"], spans["POST /charges"]
+	assert.Equal(t, KindClient, clientSpan.Kind)
+	assert.Equal(t, KindServer, serverSpan.Kind)
+	assert.Equal(t, spans["usecase.renew_subscription"].SpanContext.SpanID, clientSpan.ParentSpanID)
+	assert.Equal(t, clientSpan.SpanContext.SpanID, serverSpan.ParentSpanID)
+	assert.Equal(t, clientSpan.SpanContext.TraceID, serverSpan.SpanContext.TraceID)
+	assert.Equal(t, "502", clientSpan.Attributes["http.status_code"])
+	assert.Len(t, serverSpan.Errors, 1)
+	assert.Empty(t, req.Header.Get(TraceparentHeader), "caller's request must not be modified")
+}
+
+func TestOTLPExporter_PostsJSON(t *testing.T) {
+	var got struct {
+		ResourceSpans []struct {
+			Resource struct {
+				Attributes []otlpKeyValue `json:"attributes"`
+			} `json:"resource"`
+			ScopeSpans []struct {
+				Spans []otlpSpan `json:"spans"`
+			} `json:"scopeSpans"`
+		} `json:"resourceSpans"`
+	}
+	var path, auth string
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, auth = r.URL.Path, r.Header.Get("Authorization")
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &got)
+	}))
+	defer collector.Close()
+
+	exporter := NewOTLPExporter(nil, collector.URL+"/", "renewer", map[string]string{"Authorization": "Bearer token"})
+	start := time.Unix(1700000000, 0)
+	err := exporter.Export(context.Background(), []SpanData{{
+		Name:         "billing.charge_customer",
+		Kind:         KindInternal,
+		SpanContext:  SpanContext{TraceID: TraceID{1}, SpanID: SpanID{2}, Sampled: true},
+		ParentSpanID: SpanID{3},
+		Start:        start,
+		End:          start.Add(time.Second),
+		Attributes:   map[string]string{"subscription_id": "sub-1"},
+		Errors:       []string{"card declined"},
+	}})
+
+	require.NoError(t, err)
+	assert.Equal(t, "/v1/traces", path)
+	assert.Equal(t, "Bearer token", auth)
+	require.Len(t, got.ResourceSpans, 1)
+	assert.Equal(t, []otlpKeyValue{{Key: "service.name", Value: otlpValue{StringValue: "renewer"}}}, got.ResourceSpans[0].Resource.Attributes)
+	spans := got.ResourceSpans[0].ScopeSpans[0].Spans
+	require.Len(t, spans, 1)
+	assert.Equal(t, "01000000000000000000000000000000", spans[0].TraceID)
+	assert.Equal(t, "0300000000000000", spans[0].ParentSpanID)
+	assert.Equal(t, "1700000000000000000", spans[0].StartTimeUnixNano)
+	assert.Equal(t, otlpStatus{Code: otlpStatusError, Message: "card declined"}, spans[0].Status)
+	assert.Equal(t, "exception", spans[0].Events[0].Name)
+}
+
+func TestOTLPExporter_CollectorError(t *testing.T) {
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "overloaded", http.StatusServiceUnavailable)
+	}))
+	defer collector.Close()
+
+	err := NewOTLPExporter(nil, collector.URL, "renewer", nil).Export(context.Background(), []SpanData{{Name: "x"}})
+
+	assert.ErrorContains(t, err, "collector responded 503")
+}
+
+func TestDatadogExporter_GroupsSpansByTrace(t *testing.T) {
+	var got [][]ddSpan
+	var method, path, count string
+	agent := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, path, count = r.Method, r.URL.Path, r.Header.Get("X-Datadog-Trace-Count")
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &got)
+	}))
+	defer agent.Close()
+
+	traceID := TraceID{0, 0, 0, 0, 0, 0, 0, 0xab, 0, 0, 0, 0, 0, 0, 0, 1}
+	start := time.Unix(1700000000, 0)
+	err := NewDatadogExporter(nil, agent.URL, "refunds").Export(context.Background(), []SpanData{
+		{Name: "POST /webhooks/refunds", Kind: KindServer, SpanContext: SpanContext{TraceID: traceID, SpanID: SpanID{7: 2}}, Start: start, End: start.Add(time.Millisecond)},
+		{Name: "spanner.refunds.Save", Kind: KindInternal, SpanContext: SpanContext{TraceID: traceID, SpanID: SpanID{7: 3}}, ParentSpanID: SpanID{7: 2}, Start: start, End: start, Errors: []string{"aborted"}},
+		{Name: "refund poll", Kind: KindInternal, SpanContext: SpanContext{TraceID: TraceID{15: 9}, SpanID: SpanID{7: 4}}, Start: start, End: start},
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "PUT", method)
+	assert.Equal(t, "/v0.3/traces", path)
+	assert.Equal(t, "2", count)
+	require.Len(t, got, 2)
+	require.Len(t, got[0], 2)
+
+	server := got[0][0]
+	assert.Equal(t, uint64(1), server.TraceID)
+	assert.Equal(t, uint64(2), server.SpanID)
+	assert.Equal(t, "subscription.server", server.Name)
+	assert.Equal(t, "POST /webhooks/refunds", server.Resource)
+	assert.Equal(t, "refunds", server.Service)
+	assert.Equal(t, "web", server.Type)
+	assert.Equal(t, int64(time.Millisecond), server.Duration)
+	assert.Equal(t, "00000000000000ab", server.Meta["_dd.p.tid"])
+
+	child := got[0][1]
+	assert.Equal(t, uint64(2), child.ParentID)
+	assert.Equal(t, int32(1), child.Error)
+	assert.Equal(t, "aborted", child.Meta["error.message"])
+	assert.NotContains(t, got[1][0].Meta, "_dd.p.tid")
+}
+
+func TestStdoutExporter_WritesJSONLines(t *testing.T) {
+	var buf bytes.Buffer
+	start := time.Unix(1700000000, 0).UTC()
+	err := NewStdoutExporter(&buf, "renewer").Export(context.Background(), []SpanData{
+		{Name: "usecase.renew", Kind: KindInternal, SpanContext: SpanContext{TraceID: TraceID{1}, SpanID: SpanID{2}}, Start: start, End: start.Add(1500 * time.Microsecond)},
+		{Name: "HTTP POST", Kind: KindClient, SpanContext: SpanContext{TraceID: TraceID{1}, SpanID: SpanID{3}}, ParentSpanID: SpanID{2}, Start: start, End: start},
+	})
+
+	require.NoError(t, err)
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	require.Len(t, lines, 2)
+	var first, second stdoutSpan
+	require.NoError(t, json.Unmarshal(lines[0], &first))
+	require.NoError(t, json.Unmarshal(lines[1], &second))
+	assert.Equal(t, "renewer", first.Service)
+	assert.Equal(t, 1.5, first.DurationMS)
+	assert.Empty(t, first.ParentID)
+	assert.Equal(t, "client", second.Kind)
+	assert.Equal(t, "0200000000000000", second.ParentID)
+}
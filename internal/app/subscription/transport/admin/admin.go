@@ -0,0 +1,85 @@
+// Package admin serves the internal admin API used by operators and dashboards.
+package admin
+
+import (
+	"context"
+	"crypto/subtle"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/audit"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/erase_customer"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/export_cancellation_reasons"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/export_cancellation_surveys"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/export_cohort_retention"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/issue_portal_session"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/list_subscriptions"
+)
+
+// TokenSecret names the bearer token admin callers must present, resolved through
+// the SecretProvider on every request so it can be rotated without a restart
+const TokenSecret = "admin-token"
+
+// RequireToken serves next only to requests carrying the admin token as
+// "Authorization: Bearer <token>", and records the caller as the audit principal
+func RequireToken(secrets contracts.SecretProvider, logger *slog.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		want, err := secrets.Secret(r.Context(), TokenSecret)
+		if err != nil || want == "" {
+			logger.ErrorContext(r.Context(), "admin token unavailable", slog.Any("error", err))
+			http.Error(w, "admin API unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(want)) != 1 {
+			logger.WarnContext(r.Context(), "admin request rejected", slog.String("path", r.URL.Path), slog.String("remote_addr", r.RemoteAddr))
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		ctx := audit.WithPrincipal(r.Context(), audit.Principal{ID: "admin-token", SourceIP: sourceIP(r)})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func sourceIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// NewHandler routes the admin API; a nil surveys, reasons, portal, subscriptions or
+// erasures leaves out cancellation surveys, cancellation reasons, portal sessions, the
+// subscription listing or erasures. Every request is recorded in the audit trail
+// unless recorder is nil.
+func NewHandler(aggregates AggregatesSource, cohorts export_cohort_retention.UseCase, surveys export_cancellation_surveys.UseCase, reasons export_cancellation_reasons.UseCase, portal issue_portal_session.UseCase, subscriptions list_subscriptions.UseCase, erasures erase_customer.UseCase, recorder *audit.Recorder, secrets contracts.SecretProvider, logger *slog.Logger) http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/admin/aggregates", Audited(recorder, "admin.aggregates", NewAggregatesHandler(aggregates, logger)))
+	mux.Handle("/admin/cohorts", Audited(recorder, "admin.cohorts", NewCohortsHandler(cohorts, logger)))
+	if surveys != nil {
+		mux.Handle("/admin/cancellation-surveys", Audited(recorder, "admin.cancellation_surveys", NewCancellationSurveysHandler(surveys, logger)))
+	}
+	if reasons != nil {
+		mux.Handle("/admin/cancellation-reasons", Audited(recorder, "admin.cancellation_reasons", NewCancellationReasonsHandler(reasons, logger)))
+	}
+	if portal != nil {
+		mux.Handle("/admin/portal-sessions", Audited(recorder, issue_portal_session.CommandName, NewPortalSessionsHandler(portal, logger)))
+	}
+	if subscriptions != nil {
+		mux.Handle("/admin/subscriptions", Audited(recorder, "admin.subscriptions", NewSubscriptionsHandler(subscriptions, logger)))
+	}
+	if erasures != nil {
+		mux.Handle("/admin/erasures", Audited(recorder, erase_customer.CommandName, NewErasuresHandler(erasures, logger)))
+	}
+	return RequireToken(secrets, logger, mux)
+}
+
+// AggregatesSource reads the reporting projection
+type AggregatesSource interface {
+	LoadAggregates(ctx context.Context) (*contracts.Aggregates, error)
+}
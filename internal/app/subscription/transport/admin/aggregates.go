@@ -0,0 +1,93 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+// AggregatesHandler serves the reporting projection to the internal dashboard. It
+// never queries the operational tables; figures are as fresh as the last refresh.
+type AggregatesHandler struct {
+	source AggregatesSource
+	logger *slog.Logger
+}
+
+// NewAggregatesHandler creates the aggregates handler
+func NewAggregatesHandler(source AggregatesSource, logger *slog.Logger) *AggregatesHandler {
+	return &AggregatesHandler{source: source, logger: logger}
+}
+
+type aggregatesResponse struct {
+	RefreshedAt  time.Time     `json:"refreshed_at"`
+	ReadAt       time.Time     `json:"read_at"` // pass as as_of to exports that should agree
+	ActiveByPlan []planCount   `json:"active_by_plan"`
+	Daily        []dailyCount  `json:"daily"`
+	Refunds      []refundTotal `json:"refunds"`
+}
+
+type planCount struct {
+	PlanID string `json:"plan_id"`
+	Active int64  `json:"active"`
+}
+
+type dailyCount struct {
+	Day       string `json:"day"` // YYYY-MM-DD, UTC
+	New       int64  `json:"new"`
+	Cancelled int64  `json:"cancelled"`
+}
+
+type refundTotal struct {
+	Currency    string `json:"currency"`
+	Status      string `json:"status"`
+	Count       int64  `json:"count"`
+	AmountCents int64  `json:"amount_cents"`
+}
+
+// ServeHTTP answers GET with the latest aggregates, or 503 before the first refresh
+func (h *AggregatesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	a, err := h.source.LoadAggregates(r.Context())
+	switch {
+	case errors.Is(err, domain.ErrAggregatesNotReady):
+		w.Header().Set("Retry-After", "60")
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	case err != nil:
+		h.logger.ErrorContext(r.Context(), "failed to load aggregates", slog.Any("error", err))
+		http.Error(w, "failed to load aggregates", http.StatusInternalServerError)
+		return
+	}
+
+	resp := aggregatesResponse{
+		RefreshedAt:  a.RefreshedAt,
+		ReadAt:       a.ReadAt,
+		ActiveByPlan: make([]planCount, 0, len(a.ActiveByPlan)),
+		Daily:        make([]dailyCount, 0, len(a.Daily)),
+		Refunds:      make([]refundTotal, 0, len(a.Refunds)),
+	}
+	for _, c := range a.ActiveByPlan {
+		resp.ActiveByPlan = append(resp.ActiveByPlan, planCount{PlanID: c.PlanID, Active: c.Active})
+	}
+	for _, d := range a.Daily {
+		resp.Daily = append(resp.Daily, dailyCount{Day: d.Day.Format(time.DateOnly), New: d.New, Cancelled: d.Cancelled})
+	}
+	for _, t := range a.Refunds {
+		resp.Refunds = append(resp.Refunds, refundTotal{Currency: t.Currency, Status: t.Status, Count: t.Count, AmountCents: t.AmountCents})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Last-Modified", a.RefreshedAt.UTC().Format(http.TimeFormat))
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		h.logger.WarnContext(r.Context(), "failed to write aggregates", slog.Any("error", err))
+	}
+}
@@ -0,0 +1,89 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/logging"
+)
+
+type staticSecrets map[string]string
+
+func (s staticSecrets) Secret(_ context.Context, name string) (string, error) {
+	v, ok := s[name]
+	if !ok {
+		return "", errors.New("not found")
+	}
+	return v, nil
+}
+
+type stubSource struct {
+	aggregates *contracts.Aggregates
+	err        error
+}
+
+func (s stubSource) LoadAggregates(context.Context) (*contracts.Aggregates, error) {
+	return s.aggregates, s.err
+}
+
+func get(h http.Handler, token string) *httptest.ResponseRecorder {
+	return getPath(h, "/admin/aggregates", token)
+}
+
+func getPath(h http.Handler, path, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAggregates_ServesProjection(t *testing.T) {
+	refreshed := time.Date(2024, 3, 10, 15, 4, 5, 0, time.UTC)
+	h := NewHandler(stubSource{aggregates: &contracts.Aggregates{
+		ActiveByPlan: []contracts.PlanCount{{PlanID: "plan-pro", Active: 12}},
+		Daily:        []contracts.DailyCount{{Day: time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC), New: 3, Cancelled: 1}},
+		Refunds:      []contracts.RefundTotal{{Currency: "USD", Status: "SUCCEEDED", Count: 2, AmountCents: 1800}},
+		RefreshedAt:  refreshed,
+		ReadAt:       refreshed.Add(-15 * time.Second),
+	}}, nil, nil, nil, nil, nil, nil, nil, staticSecrets{TokenSecret: "s3cret"}, logging.Discard())
+
+	rec := get(h, "s3cret")
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "Sun, 10 Mar 2024 15:04:05 GMT", rec.Header().Get("Last-Modified"))
+	assert.JSONEq(t, `{
+		"refreshed_at": "2024-03-10T15:04:05Z",
+		"read_at": "2024-03-10T15:03:50Z",
+		"active_by_plan": [{"plan_id": "plan-pro", "active": 12}],
+		"daily": [{"day": "2024-03-10", "new": 3, "cancelled": 1}],
+		"refunds": [{"currency": "USD", "status": "SUCCEEDED", "count": 2, "amount_cents": 1800}]
+	}`, rec.Body.String())
+}
+
+func TestAggregates_NotReadyBeforeFirstRefresh(t *testing.T) {
+	h := NewHandler(stubSource{err: domain.ErrAggregatesNotReady}, nil, nil, nil, nil, nil, nil, nil, staticSecrets{TokenSecret: "s3cret"}, logging.Discard())
+
+	rec := get(h, "s3cret")
+
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, "60", rec.Header().Get("Retry-After"))
+}
+
+func TestAggregates_RequiresToken(t *testing.T) {
+	h := NewHandler(stubSource{}, nil, nil, nil, nil, nil, nil, nil, staticSecrets{TokenSecret: "s3cret"}, logging.Discard())
+
+	assert.Equal(t, http.StatusUnauthorized, get(h, "").Code)
+	assert.Equal(t, http.StatusUnauthorized, get(h, "wrong").Code)
+	assert.Equal(t, http.StatusServiceUnavailable, get(NewHandler(stubSource{}, nil, nil, nil, nil, nil, nil, nil, staticSecrets{}, logging.Discard()), "s3cret").Code)
+}
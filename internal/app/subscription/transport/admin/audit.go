@@ -0,0 +1,66 @@
+package admin
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/audit"
+)
+
+// auditPayload is the request an audit entry hashes
+type auditPayload struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	Query  string `json:"query"`
+	Body   string `json:"body"` // as much as the handler read
+}
+
+// Audited records every request served by next in the audit trail as action, with
+// the caller RequireToken put in the context; a nil recorder records nothing.
+// Responses with an error status, and panics, are recorded as failed.
+func Audited(recorder *audit.Recorder, action string, next http.Handler) http.Handler {
+	if recorder == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body bytes.Buffer
+		r.Body = readCloser{Reader: io.TeeReader(r.Body, &body), Closer: r.Body}
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		payload := func() auditPayload {
+			return auditPayload{Method: r.Method, Path: r.URL.Path, Query: r.URL.RawQuery, Body: body.String()}
+		}
+
+		defer func() {
+			if p := recover(); p != nil {
+				recorder.Record(r.Context(), action, payload(), fmt.Errorf("panic: %v", p))
+				panic(p)
+			}
+		}()
+
+		next.ServeHTTP(rec, r)
+
+		var err error
+		if rec.status >= http.StatusBadRequest {
+			err = fmt.Errorf("responded %d %s", rec.status, http.StatusText(rec.status))
+		}
+		recorder.Record(r.Context(), action, payload(), err)
+	})
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// statusRecorder captures the status code written by a handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
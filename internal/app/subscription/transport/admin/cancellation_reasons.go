@@ -0,0 +1,64 @@
+package admin
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/export_cancellation_reasons"
+)
+
+// CancellationReasonsHandler exports the reasons cancelled subscriptions gave, month by
+// month, for churn analysis
+type CancellationReasonsHandler struct {
+	exporter export_cancellation_reasons.UseCase
+	logger   *slog.Logger
+}
+
+// NewCancellationReasonsHandler creates the cancellation reasons handler
+func NewCancellationReasonsHandler(exporter export_cancellation_reasons.UseCase, logger *slog.Logger) *CancellationReasonsHandler {
+	return &CancellationReasonsHandler{exporter: exporter, logger: logger}
+}
+
+// ServeHTTP answers GET ?months=N&format=csv|json with the cancellation reasons
+func (h *CancellationReasonsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	format, err := export_cancellation_reasons.ParseFormat(r.URL.Query().Get("format"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var req export_cancellation_reasons.Request
+	if months := r.URL.Query().Get("months"); months != "" {
+		if req.Months, err = strconv.Atoi(months); err != nil {
+			http.Error(w, domain.ErrInvalidReasonWindow.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	report, err := h.exporter.Execute(r.Context(), req)
+	switch {
+	case errors.Is(err, domain.ErrInvalidReasonWindow):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case err != nil:
+		h.logger.ErrorContext(r.Context(), "failed to export cancellation reasons", slog.Any("error", err))
+		http.Error(w, "failed to export cancellation reasons", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", format.ContentType())
+	if format == export_cancellation_reasons.FormatCSV {
+		w.Header().Set("Content-Disposition", `attachment; filename="cancellation-reasons.csv"`)
+	}
+	if err := export_cancellation_reasons.Write(w, format, report); err != nil {
+		h.logger.WarnContext(r.Context(), "failed to write cancellation reasons", slog.Any("error", err))
+	}
+}
@@ -0,0 +1,59 @@
+package admin
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/logging"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/export_cancellation_reasons"
+)
+
+type stubReasonExporter struct {
+	requests []export_cancellation_reasons.Request
+}
+
+func (s *stubReasonExporter) Execute(_ context.Context, req export_cancellation_reasons.Request) (*export_cancellation_reasons.Report, error) {
+	s.requests = append(s.requests, req)
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	return &export_cancellation_reasons.Report{
+		GeneratedAt: time.Date(2024, 2, 10, 0, 0, 0, 0, time.UTC),
+		Months: []export_cancellation_reasons.Month{{Month: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), Cancellations: 2, Reasons: []export_cancellation_reasons.Reason{
+			{Code: domain.CancellationReasonTooExpensive, Cancellations: 2, ShareBP: 10000},
+		}}},
+	}, nil
+}
+
+func TestCancellationReasons_ExportsCSV(t *testing.T) {
+	exporter := &stubReasonExporter{}
+	h := NewHandler(stubSource{}, nil, nil, exporter, nil, nil, nil, nil, staticSecrets{TokenSecret: "s3cret"}, logging.Discard())
+
+	rec := getPath(h, "/admin/cancellation-reasons?months=6&format=csv", "s3cret")
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.Equal(t, "month,reason,cancellations,share_bp\n2024-02,too_expensive,2,10000\n", rec.Body.String())
+	assert.Equal(t, []export_cancellation_reasons.Request{{Months: 6}}, exporter.requests)
+}
+
+func TestCancellationReasons_RejectsBadParameters(t *testing.T) {
+	exporter := &stubReasonExporter{}
+	h := NewHandler(stubSource{}, nil, nil, exporter, nil, nil, nil, nil, staticSecrets{TokenSecret: "s3cret"}, logging.Discard())
+
+	assert.Equal(t, http.StatusBadRequest, getPath(h, "/admin/cancellation-reasons?format=xlsx", "s3cret").Code)
+	assert.Equal(t, http.StatusBadRequest, getPath(h, "/admin/cancellation-reasons?months=many", "s3cret").Code)
+	assert.Equal(t, http.StatusBadRequest, getPath(h, "/admin/cancellation-reasons?months=61", "s3cret").Code)
+	assert.Equal(t, []export_cancellation_reasons.Request{{Months: 61}}, exporter.requests)
+}
+
+func TestCancellationReasons_NotMountedWithoutExporter(t *testing.T) {
+	h := NewHandler(stubSource{}, nil, nil, nil, nil, nil, nil, nil, staticSecrets{TokenSecret: "s3cret"}, logging.Discard())
+
+	assert.Equal(t, http.StatusNotFound, getPath(h, "/admin/cancellation-reasons", "s3cret").Code)
+}
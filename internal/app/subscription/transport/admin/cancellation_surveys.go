@@ -0,0 +1,64 @@
+package admin
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/export_cancellation_surveys"
+)
+
+// CancellationSurveysHandler exports how cancelling customers answered the choice and
+// rating questions of the cancellation survey, month by month
+type CancellationSurveysHandler struct {
+	exporter export_cancellation_surveys.UseCase
+	logger   *slog.Logger
+}
+
+// NewCancellationSurveysHandler creates the cancellation surveys handler
+func NewCancellationSurveysHandler(exporter export_cancellation_surveys.UseCase, logger *slog.Logger) *CancellationSurveysHandler {
+	return &CancellationSurveysHandler{exporter: exporter, logger: logger}
+}
+
+// ServeHTTP answers GET ?months=N&format=csv|json with the cancellation survey answers
+func (h *CancellationSurveysHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	format, err := export_cancellation_surveys.ParseFormat(r.URL.Query().Get("format"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var req export_cancellation_surveys.Request
+	if months := r.URL.Query().Get("months"); months != "" {
+		if req.Months, err = strconv.Atoi(months); err != nil {
+			http.Error(w, domain.ErrInvalidSurveyWindow.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	report, err := h.exporter.Execute(r.Context(), req)
+	switch {
+	case errors.Is(err, domain.ErrInvalidSurveyWindow):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case err != nil:
+		h.logger.ErrorContext(r.Context(), "failed to export cancellation surveys", slog.Any("error", err))
+		http.Error(w, "failed to export cancellation surveys", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", format.ContentType())
+	if format == export_cancellation_surveys.FormatCSV {
+		w.Header().Set("Content-Disposition", `attachment; filename="cancellation-surveys.csv"`)
+	}
+	if err := export_cancellation_surveys.Write(w, format, report); err != nil {
+		h.logger.WarnContext(r.Context(), "failed to write cancellation surveys", slog.Any("error", err))
+	}
+}
@@ -0,0 +1,58 @@
+package admin
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/logging"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/export_cancellation_surveys"
+)
+
+type stubSurveyExporter struct {
+	requests []export_cancellation_surveys.Request
+}
+
+func (s *stubSurveyExporter) Execute(_ context.Context, req export_cancellation_surveys.Request) (*export_cancellation_surveys.Report, error) {
+	s.requests = append(s.requests, req)
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	return &export_cancellation_surveys.Report{
+		GeneratedAt: time.Date(2024, 2, 10, 0, 0, 0, 0, time.UTC),
+		Months: []export_cancellation_surveys.Month{{Month: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), Questions: []export_cancellation_surveys.Question{
+			{ID: "reason", Answered: 2, Answers: []export_cancellation_surveys.Answer{{Value: "too_expensive", Responses: 2, ShareBP: 10000}}},
+		}}},
+	}, nil
+}
+
+func TestCancellationSurveys_ExportsCSV(t *testing.T) {
+	exporter := &stubSurveyExporter{}
+	h := NewHandler(stubSource{}, nil, exporter, nil, nil, nil, nil, nil, staticSecrets{TokenSecret: "s3cret"}, logging.Discard())
+
+	rec := getPath(h, "/admin/cancellation-surveys?months=6&format=csv", "s3cret")
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.Equal(t, "month,question_id,answer,responses,share_bp\n2024-02,reason,too_expensive,2,10000\n", rec.Body.String())
+	assert.Equal(t, []export_cancellation_surveys.Request{{Months: 6}}, exporter.requests)
+}
+
+func TestCancellationSurveys_RejectsBadParameters(t *testing.T) {
+	exporter := &stubSurveyExporter{}
+	h := NewHandler(stubSource{}, nil, exporter, nil, nil, nil, nil, nil, staticSecrets{TokenSecret: "s3cret"}, logging.Discard())
+
+	assert.Equal(t, http.StatusBadRequest, getPath(h, "/admin/cancellation-surveys?format=xlsx", "s3cret").Code)
+	assert.Equal(t, http.StatusBadRequest, getPath(h, "/admin/cancellation-surveys?months=many", "s3cret").Code)
+	assert.Equal(t, http.StatusBadRequest, getPath(h, "/admin/cancellation-surveys?months=61", "s3cret").Code)
+	assert.Equal(t, []export_cancellation_surveys.Request{{Months: 61}}, exporter.requests)
+}
+
+func TestCancellationSurveys_NotMountedWithoutExporter(t *testing.T) {
+	h := NewHandler(stubSource{}, nil, nil, nil, nil, nil, nil, nil, staticSecrets{TokenSecret: "s3cret"}, logging.Discard())
+
+	assert.Equal(t, http.StatusNotFound, getPath(h, "/admin/cancellation-surveys", "s3cret").Code)
+}
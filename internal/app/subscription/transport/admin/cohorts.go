@@ -0,0 +1,73 @@
+package admin
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/export_cohort_retention"
+)
+
+// CohortsHandler exports cohort retention for the standard retention charts. Unlike
+// the aggregates it is computed on request, from one grouped scan of subscriptions.
+type CohortsHandler struct {
+	exporter export_cohort_retention.UseCase
+	logger   *slog.Logger
+}
+
+// NewCohortsHandler creates the cohorts handler
+func NewCohortsHandler(exporter export_cohort_retention.UseCase, logger *slog.Logger) *CohortsHandler {
+	return &CohortsHandler{exporter: exporter, logger: logger}
+}
+
+// ServeHTTP answers GET ?months=N&format=csv|json&as_of=RFC3339 with the cohort
+// retention report. as_of reads subscriptions at that commit timestamp, such as the
+// aggregates' read_at, so the report agrees with the dashboard.
+func (h *CohortsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	format, err := export_cohort_retention.ParseFormat(r.URL.Query().Get("format"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var req export_cohort_retention.Request
+	if months := r.URL.Query().Get("months"); months != "" {
+		if req.Months, err = strconv.Atoi(months); err != nil {
+			http.Error(w, domain.ErrInvalidCohortWindow.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if asOf := r.URL.Query().Get("as_of"); asOf != "" {
+		if req.AsOf, err = time.Parse(time.RFC3339Nano, asOf); err != nil {
+			http.Error(w, "as_of must be an RFC 3339 timestamp", http.StatusBadRequest)
+			return
+		}
+	}
+
+	report, err := h.exporter.Execute(r.Context(), req)
+	switch {
+	case errors.Is(err, domain.ErrInvalidCohortWindow), errors.Is(err, domain.ErrInvalidReadTimestamp):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case err != nil:
+		h.logger.ErrorContext(r.Context(), "failed to export cohort retention", slog.Any("error", err))
+		http.Error(w, "failed to export cohort retention", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", format.ContentType())
+	if format == export_cohort_retention.FormatCSV {
+		w.Header().Set("Content-Disposition", `attachment; filename="cohort-retention.csv"`)
+	}
+	if err := export_cohort_retention.Write(w, format, report); err != nil {
+		h.logger.WarnContext(r.Context(), "failed to write cohort retention", slog.Any("error", err))
+	}
+}
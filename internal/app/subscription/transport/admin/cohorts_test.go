@@ -0,0 +1,88 @@
+package admin
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/logging"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/export_cohort_retention"
+)
+
+type stubExporter struct {
+	requests []export_cohort_retention.Request
+}
+
+func (s *stubExporter) Execute(_ context.Context, req export_cohort_retention.Request) (*export_cohort_retention.Report, error) {
+	s.requests = append(s.requests, req)
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	generated := time.Date(2024, 2, 10, 0, 0, 0, 0, time.UTC)
+	if req.AsOf.After(generated) {
+		return nil, domain.ErrInvalidReadTimestamp
+	}
+	feb := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	return &export_cohort_retention.Report{
+		GeneratedAt: generated,
+		ReadAt:      generated.Add(-time.Second),
+		Cohorts: []export_cohort_retention.Cohort{{Month: feb, Size: 2, Periods: []export_cohort_retention.Period{
+			{Offset: 0, Month: feb, Active: 1, Cancelled: 1, RetentionBP: 5000},
+		}}},
+	}, nil
+}
+
+func TestCohorts_ExportsCSV(t *testing.T) {
+	exporter := &stubExporter{}
+	h := NewHandler(stubSource{}, exporter, nil, nil, nil, nil, nil, nil, staticSecrets{TokenSecret: "s3cret"}, logging.Discard())
+
+	rec := getPath(h, "/admin/cohorts?months=6&format=csv", "s3cret")
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.Equal(t, "cohort,size,offset,month,active,cancelled,retention_bp\n2024-02,2,0,2024-02,1,1,5000\n", rec.Body.String())
+	assert.Equal(t, []export_cohort_retention.Request{{Months: 6}}, exporter.requests)
+}
+
+func TestCohorts_ExportsJSONByDefault(t *testing.T) {
+	h := NewHandler(stubSource{}, &stubExporter{}, nil, nil, nil, nil, nil, nil, staticSecrets{TokenSecret: "s3cret"}, logging.Discard())
+
+	rec := getPath(h, "/admin/cohorts", "s3cret")
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{
+		"generated_at": "2024-02-10T00:00:00Z",
+		"read_at": "2024-02-09T23:59:59Z",
+		"cohorts": [{"month": "2024-02", "size": 2, "periods": [
+			{"offset": 0, "month": "2024-02", "active": 1, "cancelled": 1, "retention_bp": 5000}
+		]}]
+	}`, rec.Body.String())
+}
+
+func TestCohorts_ReadsAsOfTheAggregatesTimestamp(t *testing.T) {
+	exporter := &stubExporter{}
+	h := NewHandler(stubSource{}, exporter, nil, nil, nil, nil, nil, nil, staticSecrets{TokenSecret: "s3cret"}, logging.Discard())
+
+	rec := getPath(h, "/admin/cohorts?as_of=2024-02-09T12:00:00.123456Z", "s3cret")
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, []export_cohort_retention.Request{{AsOf: time.Date(2024, 2, 9, 12, 0, 0, 123456000, time.UTC)}}, exporter.requests)
+}
+
+func TestCohorts_RejectsBadParameters(t *testing.T) {
+	exporter := &stubExporter{}
+	h := NewHandler(stubSource{}, exporter, nil, nil, nil, nil, nil, nil, staticSecrets{TokenSecret: "s3cret"}, logging.Discard())
+
+	assert.Equal(t, http.StatusBadRequest, getPath(h, "/admin/cohorts?format=xlsx", "s3cret").Code)
+	assert.Equal(t, http.StatusBadRequest, getPath(h, "/admin/cohorts?months=many", "s3cret").Code)
+	assert.Equal(t, http.StatusBadRequest, getPath(h, "/admin/cohorts?months=61", "s3cret").Code)
+	assert.Equal(t, http.StatusBadRequest, getPath(h, "/admin/cohorts?as_of=yesterday", "s3cret").Code)
+	assert.Equal(t, http.StatusBadRequest, getPath(h, "/admin/cohorts?as_of=2030-01-01T00:00:00Z", "s3cret").Code)
+	assert.Equal(t, http.StatusUnauthorized, getPath(h, "/admin/cohorts", "").Code)
+	assert.Equal(t, []export_cohort_retention.Request{{Months: 61}, {AsOf: time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)}}, exporter.requests)
+}
@@ -0,0 +1,64 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/erase_customer"
+)
+
+// ErasureKeySecret names the key erasure reports are signed with
+const ErasureKeySecret = "erasure-signing-key"
+
+// ErasuresHandler carries out right-to-erasure requests
+type ErasuresHandler struct {
+	eraser erase_customer.UseCase
+	logger *slog.Logger
+}
+
+// NewErasuresHandler creates the erasures handler
+func NewErasuresHandler(eraser erase_customer.UseCase, logger *slog.Logger) *ErasuresHandler {
+	return &ErasuresHandler{eraser: eraser, logger: logger}
+}
+
+type erasureRequest struct {
+	CustomerID string `json:"customer_id"`
+}
+
+// ServeHTTP answers POST by erasing the customer and returning the signed report
+func (h *ErasuresHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var body erasureRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&body); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.eraser.Execute(r.Context(), body.CustomerID)
+	switch {
+	case errors.Is(err, domain.ErrInvalidCustomerID):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, domain.ErrCustomerHasLiveSubscriptions):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case err != nil:
+		h.logger.ErrorContext(r.Context(), "failed to erase customer", slog.Any("error", err))
+		http.Error(w, "failed to erase customer", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		h.logger.WarnContext(r.Context(), "failed to write erasure report", slog.Any("error", err))
+	}
+}
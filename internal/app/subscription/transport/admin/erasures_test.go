@@ -0,0 +1,88 @@
+package admin
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/audit"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/logging"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/testkit"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/erase_customer"
+)
+
+type stubEraser struct {
+	erased []string
+}
+
+func (s *stubEraser) Execute(_ context.Context, customerID string) (*erase_customer.Report, error) {
+	switch customerID {
+	case "":
+		return nil, domain.ErrInvalidCustomerID
+	case "cust-live":
+		return nil, domain.ErrCustomerHasLiveSubscriptions
+	}
+	s.erased = append(s.erased, customerID)
+	return &erase_customer.Report{
+		ReportID:  "rep-1",
+		Tombstone: erase_customer.Tombstone(customerID),
+		ErasedAt:  time.Date(2024, 3, 10, 15, 4, 5, 0, time.UTC),
+		Targets:   []erase_customer.TargetResult{{Target: "subscriptions", RowsAffected: 2}},
+		Signature: "sig",
+	}, nil
+}
+
+func TestErasures_ErasesAndAuditsTheRequest(t *testing.T) {
+	eraser := &stubEraser{}
+	log := &testkit.RecordingAuditLog{}
+	h := NewHandler(stubSource{}, nil, nil, nil, nil, nil, eraser, audit.NewRecorder(log, logging.Discard(), nil), staticSecrets{TokenSecret: "s3cret"}, logging.Discard())
+
+	rec := postPath(h, "/admin/erasures", `{"customer_id":"cust-1"}`, "s3cret")
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "no-store", rec.Header().Get("Cache-Control"))
+	assert.JSONEq(t, `{
+		"report_id": "rep-1", "tombstone": "`+erase_customer.Tombstone("cust-1")+`", "erased_at": "2024-03-10T15:04:05Z",
+		"targets": [{"target": "subscriptions", "rows_affected": 2}], "signature": "sig"
+	}`, rec.Body.String())
+	assert.Equal(t, []string{"cust-1"}, eraser.erased)
+
+	hash, err := audit.HashPayload(auditPayload{Method: http.MethodPost, Path: "/admin/erasures", Body: `{"customer_id":"cust-1"}`})
+	require.NoError(t, err)
+	entries := log.Entries()
+	require.Len(t, entries, 1)
+	assert.Equal(t, erase_customer.CommandName, entries[0].Action)
+	assert.Equal(t, "admin-token", entries[0].Principal)
+	assert.Equal(t, "192.0.2.1", entries[0].SourceIP)
+	assert.Equal(t, hash, entries[0].PayloadHash)
+	assert.Equal(t, contracts.AuditSucceeded, entries[0].Outcome)
+}
+
+func TestErasures_RejectedRequestsAreAuditedAsFailed(t *testing.T) {
+	log := &testkit.RecordingAuditLog{}
+	h := NewHandler(stubSource{}, nil, nil, nil, nil, nil, &stubEraser{}, audit.NewRecorder(log, logging.Discard(), nil), staticSecrets{TokenSecret: "s3cret"}, logging.Discard())
+
+	assert.Equal(t, http.StatusBadRequest, postPath(h, "/admin/erasures", `not json`, "s3cret").Code)
+	assert.Equal(t, http.StatusBadRequest, postPath(h, "/admin/erasures", `{}`, "s3cret").Code)
+	assert.Equal(t, http.StatusConflict, postPath(h, "/admin/erasures", `{"customer_id":"cust-live"}`, "s3cret").Code)
+	assert.Equal(t, http.StatusMethodNotAllowed, getPath(h, "/admin/erasures", "s3cret").Code)
+
+	entries := log.Entries()
+	require.Len(t, entries, 4)
+	for _, e := range entries {
+		assert.Equal(t, contracts.AuditFailed, e.Outcome)
+	}
+	assert.Equal(t, "responded 409 Conflict", entries[2].Error)
+}
+
+func TestErasures_NotMountedWithoutEraser(t *testing.T) {
+	h := NewHandler(stubSource{}, nil, nil, nil, nil, nil, nil, nil, staticSecrets{TokenSecret: "s3cret"}, logging.Discard())
+
+	assert.Equal(t, http.StatusNotFound, postPath(h, "/admin/erasures", `{"customer_id":"cust-1"}`, "s3cret").Code)
+}
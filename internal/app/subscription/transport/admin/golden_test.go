@@ -0,0 +1,51 @@
+package admin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/logging"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/testkit/golden"
+)
+
+// Response bodies are compared with snapshots in testdata, so a renamed or dropped
+// field fails here before it breaks a dashboard or script reading the admin API
+func TestResponses_JSONSnapshots(t *testing.T) {
+	h := NewHandler(stubSource{aggregates: &contracts.Aggregates{
+		ActiveByPlan: []contracts.PlanCount{{PlanID: "plan-pro", Active: 12}},
+		Daily:        []contracts.DailyCount{{Day: time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC), New: 3, Cancelled: 1}},
+		Refunds:      []contracts.RefundTotal{{Currency: "USD", Status: "SUCCEEDED", Count: 2, AmountCents: 1800}},
+		RefreshedAt:  time.Date(2024, 3, 10, 15, 4, 5, 0, time.UTC),
+		ReadAt:       time.Date(2024, 3, 10, 15, 3, 50, 0, time.UTC),
+	}}, &stubExporter{}, &stubSurveyExporter{}, &stubReasonExporter{}, &stubIssuer{}, &stubLister{}, nil, nil, staticSecrets{TokenSecret: "s3cret"}, logging.Discard())
+
+	tests := []struct {
+		name  string
+		serve func() *httptest.ResponseRecorder
+	}{
+		{"aggregates", func() *httptest.ResponseRecorder { return getPath(h, "/admin/aggregates", "s3cret") }},
+		{"cohorts", func() *httptest.ResponseRecorder { return getPath(h, "/admin/cohorts", "s3cret") }},
+		{"cancellation_surveys", func() *httptest.ResponseRecorder { return getPath(h, "/admin/cancellation-surveys", "s3cret") }},
+		{"cancellation_reasons", func() *httptest.ResponseRecorder { return getPath(h, "/admin/cancellation-reasons", "s3cret") }},
+		{"subscriptions", func() *httptest.ResponseRecorder {
+			return getPath(h, "/admin/subscriptions?customer_id=cust-1", "s3cret")
+		}},
+		{"portal_session", func() *httptest.ResponseRecorder {
+			return postPath(h, "/admin/portal-sessions", `{"customer_id":"cust-1","scopes":["cancel"]}`, "s3cret")
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := tt.serve()
+
+			require.Contains(t, []int{http.StatusOK, http.StatusCreated}, rec.Code)
+			golden.AssertJSONBytes(t, "responses/"+tt.name, rec.Body.Bytes())
+		})
+	}
+}
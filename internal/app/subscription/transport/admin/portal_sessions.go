@@ -0,0 +1,74 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/issue_portal_session"
+)
+
+// PortalSessionsHandler mints portal tokens for the self-service portal's backend,
+// which hands them to the customer's browser
+type PortalSessionsHandler struct {
+	issuer issue_portal_session.UseCase
+	logger *slog.Logger
+}
+
+// NewPortalSessionsHandler creates the portal sessions handler
+func NewPortalSessionsHandler(issuer issue_portal_session.UseCase, logger *slog.Logger) *PortalSessionsHandler {
+	return &PortalSessionsHandler{issuer: issuer, logger: logger}
+}
+
+// portalSessionRequest is the request body; a zero ttl_seconds means the default lifetime
+type portalSessionRequest struct {
+	CustomerID string               `json:"customer_id"`
+	Scopes     []domain.PortalScope `json:"scopes"`
+	TTLSeconds int64                `json:"ttl_seconds"`
+}
+
+type portalSessionResponse struct {
+	Token     string    `json:"token"`
+	SessionID string    `json:"session_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// ServeHTTP answers POST with a token scoped to the customer
+func (h *PortalSessionsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var body portalSessionRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&body); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.issuer.Execute(r.Context(), issue_portal_session.Request{
+		CustomerID: body.CustomerID,
+		Scopes:     body.Scopes,
+		TTL:        time.Duration(body.TTLSeconds) * time.Second,
+	})
+	switch {
+	case errors.Is(err, domain.ErrInvalidCustomerID), errors.Is(err, domain.ErrInvalidPortalScope), errors.Is(err, domain.ErrInvalidPortalTTL):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case err != nil:
+		h.logger.ErrorContext(r.Context(), "failed to issue portal session", slog.Any("error", err))
+		http.Error(w, "failed to issue portal session", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(portalSessionResponse{Token: result.Token, SessionID: result.SessionID, ExpiresAt: result.ExpiresAt}); err != nil {
+		h.logger.WarnContext(r.Context(), "failed to write portal session", slog.Any("error", err))
+	}
+}
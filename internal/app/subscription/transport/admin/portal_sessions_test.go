@@ -0,0 +1,63 @@
+package admin
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/logging"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/issue_portal_session"
+)
+
+type stubIssuer struct {
+	requests []issue_portal_session.Request
+}
+
+func (s *stubIssuer) Execute(_ context.Context, req issue_portal_session.Request) (*issue_portal_session.Result, error) {
+	s.requests = append(s.requests, req)
+	if len(req.Scopes) == 0 {
+		return nil, domain.ErrInvalidPortalScope
+	}
+	return &issue_portal_session.Result{Token: "tok", SessionID: "ps-1", ExpiresAt: time.Date(2024, 1, 1, 12, 5, 0, 0, time.UTC)}, nil
+}
+
+func postPath(h http.Handler, path, body, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestPortalSessions_IssuesToken(t *testing.T) {
+	issuer := &stubIssuer{}
+	h := NewHandler(stubSource{}, nil, nil, nil, issuer, nil, nil, nil, staticSecrets{TokenSecret: "s3cret"}, logging.Discard())
+
+	rec := postPath(h, "/admin/portal-sessions", `{"customer_id":"cust-1","scopes":["cancel"],"ttl_seconds":300}`, "s3cret")
+
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, "no-store", rec.Header().Get("Cache-Control"))
+	assert.JSONEq(t, `{"token":"tok","session_id":"ps-1","expires_at":"2024-01-01T12:05:00Z"}`, rec.Body.String())
+	assert.Equal(t, []issue_portal_session.Request{{CustomerID: "cust-1", Scopes: []domain.PortalScope{domain.PortalScopeCancel}, TTL: 5 * time.Minute}}, issuer.requests)
+}
+
+func TestPortalSessions_RejectsInvalidRequests(t *testing.T) {
+	h := NewHandler(stubSource{}, nil, nil, nil, &stubIssuer{}, nil, nil, nil, staticSecrets{TokenSecret: "s3cret"}, logging.Discard())
+
+	assert.Equal(t, http.StatusBadRequest, postPath(h, "/admin/portal-sessions", `{"customer_id":"cust-1"}`, "s3cret").Code)
+	assert.Equal(t, http.StatusBadRequest, postPath(h, "/admin/portal-sessions", `not json`, "s3cret").Code)
+	assert.Equal(t, http.StatusMethodNotAllowed, getPath(h, "/admin/portal-sessions", "s3cret").Code)
+	assert.Equal(t, http.StatusUnauthorized, postPath(h, "/admin/portal-sessions", `{"customer_id":"cust-1","scopes":["cancel"]}`, "wrong").Code)
+}
+
+func TestPortalSessions_NotMountedWithoutIssuer(t *testing.T) {
+	h := NewHandler(stubSource{}, nil, nil, nil, nil, nil, nil, nil, staticSecrets{TokenSecret: "s3cret"}, logging.Discard())
+
+	assert.Equal(t, http.StatusNotFound, postPath(h, "/admin/portal-sessions", `{}`, "s3cret").Code)
+}
@@ -0,0 +1,92 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/list_subscriptions"
+)
+
+// SubscriptionsHandler lists a customer's subscriptions a page at a time, for support
+// tooling looking up an account
+type SubscriptionsHandler struct {
+	lister list_subscriptions.UseCase
+	logger *slog.Logger
+}
+
+// NewSubscriptionsHandler creates the subscriptions handler
+func NewSubscriptionsHandler(lister list_subscriptions.UseCase, logger *slog.Logger) *SubscriptionsHandler {
+	return &SubscriptionsHandler{lister: lister, logger: logger}
+}
+
+type subscriptionJSON struct {
+	ID         string    `json:"id"`
+	CustomerID string    `json:"customer_id"`
+	PlanID     string    `json:"plan_id"`
+	PriceCents int64     `json:"price_cents"`
+	Status     string    `json:"status"`
+	StartDate  time.Time `json:"start_date"`
+}
+
+type subscriptionsResponse struct {
+	Subscriptions []subscriptionJSON `json:"subscriptions"`
+	NextPageToken string             `json:"next_page_token,omitempty"`
+}
+
+// ServeHTTP answers GET ?customer_id=ID&status=S&page_size=N&page_token=T with a page
+// of the customer's subscriptions, oldest first
+func (h *SubscriptionsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	req := list_subscriptions.Request{
+		CustomerID: query.Get("customer_id"),
+		Status:     domain.SubscriptionStatus(query.Get("status")),
+		PageToken:  query.Get("page_token"),
+	}
+	if size := query.Get("page_size"); size != "" {
+		var err error
+		if req.PageSize, err = strconv.Atoi(size); err != nil {
+			http.Error(w, domain.ErrInvalidPageSize.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	page, err := h.lister.Execute(r.Context(), req)
+	switch {
+	case errors.Is(err, domain.ErrInvalidCustomerID), errors.Is(err, domain.ErrInvalidSubscriptionStatus),
+		errors.Is(err, domain.ErrInvalidPageSize), errors.Is(err, domain.ErrInvalidPageToken):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case err != nil:
+		h.logger.ErrorContext(r.Context(), "failed to list subscriptions", slog.Any("error", err))
+		http.Error(w, "failed to list subscriptions", http.StatusInternalServerError)
+		return
+	}
+
+	resp := subscriptionsResponse{Subscriptions: make([]subscriptionJSON, 0, len(page.Subscriptions)), NextPageToken: page.NextPageToken}
+	for _, s := range page.Subscriptions {
+		resp.Subscriptions = append(resp.Subscriptions, subscriptionJSON{
+			ID:         s.ID,
+			CustomerID: s.CustomerID,
+			PlanID:     s.PlanID,
+			PriceCents: s.Price,
+			Status:     string(s.Status),
+			StartDate:  s.StartDate,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		h.logger.WarnContext(r.Context(), "failed to write subscriptions", slog.Any("error", err))
+	}
+}
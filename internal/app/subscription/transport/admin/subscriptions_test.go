@@ -0,0 +1,67 @@
+package admin
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/logging"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/list_subscriptions"
+)
+
+type stubLister struct {
+	requests []list_subscriptions.Request
+}
+
+func (s *stubLister) Execute(_ context.Context, req list_subscriptions.Request) (*list_subscriptions.Page, error) {
+	s.requests = append(s.requests, req)
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	return &list_subscriptions.Page{
+		Subscriptions: []contracts.SubscriptionSummary{{
+			ID:         "sub-1",
+			CustomerID: "cust-1",
+			PlanID:     "plan-pro",
+			Price:      2900,
+			Status:     domain.StatusActive,
+			StartDate:  time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC),
+		}},
+		NextPageToken: "next",
+	}, nil
+}
+
+func TestSubscriptions_ListsAPage(t *testing.T) {
+	lister := &stubLister{}
+	h := NewHandler(stubSource{}, nil, nil, nil, nil, lister, nil, nil, staticSecrets{TokenSecret: "s3cret"}, logging.Discard())
+
+	rec := getPath(h, "/admin/subscriptions?customer_id=cust-1&status=ACTIVE&page_size=1&page_token=prev", "s3cret")
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{
+		"subscriptions": [{"id": "sub-1", "customer_id": "cust-1", "plan_id": "plan-pro", "price_cents": 2900, "status": "ACTIVE", "start_date": "2024-01-15T00:00:00Z"}],
+		"next_page_token": "next"
+	}`, rec.Body.String())
+	assert.Equal(t, []list_subscriptions.Request{{CustomerID: "cust-1", Status: domain.StatusActive, PageToken: "prev", PageSize: 1}}, lister.requests)
+}
+
+func TestSubscriptions_RejectsBadParameters(t *testing.T) {
+	h := NewHandler(stubSource{}, nil, nil, nil, nil, &stubLister{}, nil, nil, staticSecrets{TokenSecret: "s3cret"}, logging.Discard())
+
+	assert.Equal(t, http.StatusBadRequest, getPath(h, "/admin/subscriptions", "s3cret").Code)
+	assert.Equal(t, http.StatusBadRequest, getPath(h, "/admin/subscriptions?customer_id=cust-1&status=SUSPENDED", "s3cret").Code)
+	assert.Equal(t, http.StatusBadRequest, getPath(h, "/admin/subscriptions?customer_id=cust-1&page_size=all", "s3cret").Code)
+	assert.Equal(t, http.StatusBadRequest, getPath(h, "/admin/subscriptions?customer_id=cust-1&page_size=1000", "s3cret").Code)
+	assert.Equal(t, http.StatusMethodNotAllowed, postPath(h, "/admin/subscriptions", `{}`, "s3cret").Code)
+}
+
+func TestSubscriptions_NotMountedWithoutLister(t *testing.T) {
+	h := NewHandler(stubSource{}, nil, nil, nil, nil, nil, nil, nil, staticSecrets{TokenSecret: "s3cret"}, logging.Discard())
+
+	assert.Equal(t, http.StatusNotFound, getPath(h, "/admin/subscriptions?customer_id=cust-1", "s3cret").Code)
+}
@@ -0,0 +1,94 @@
+package grpc
+
+import (
+	"context"
+	"crypto/subtle"
+	"log/slog"
+	"net"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/audit"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/correlation"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/recovery"
+)
+
+// TokenSecret names the bearer token callers must present in the "authorization"
+// metadata, resolved through the SecretProvider on every call so it can be rotated
+// without a restart. It is the REST API's token, as both serve the same callers.
+const TokenSecret = "api-token"
+
+// correlationMetadata carries the caller's correlation ID, as recovery.CorrelationHeader
+// does over HTTP
+const correlationMetadata = "x-correlation-id"
+
+// idempotencyKeyMetadata carries the key a create is retried with, as
+// http.IdempotencyKeyHeader does over HTTP
+const idempotencyKeyMetadata = "idempotency-key"
+
+// RequireToken lets through only calls carrying the API token as
+// "authorization: Bearer <token>", and records the caller as the audit principal
+func RequireToken(secrets contracts.SecretProvider, logger *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		want, err := secrets.Secret(ctx, TokenSecret)
+		if err != nil || want == "" {
+			logger.ErrorContext(ctx, "API token unavailable", slog.Any("error", err))
+			return nil, status.Error(codes.Unavailable, "API unavailable")
+		}
+		md, _ := metadata.FromIncomingContext(ctx)
+		var got string
+		if values := md.Get("authorization"); len(values) > 0 {
+			got, _ = strings.CutPrefix(values[0], "Bearer ")
+		}
+		if got == "" || subtle.ConstantTimeCompare([]byte(got), []byte(want)) != 1 {
+			logger.WarnContext(ctx, "API call rejected", slog.String("method", info.FullMethod), slog.String("remote_addr", peerAddr(ctx)))
+			return nil, status.Error(codes.Unauthenticated, "unauthenticated")
+		}
+		ctx = audit.WithPrincipal(ctx, audit.Principal{ID: "api-token", SourceIP: sourceIP(ctx)})
+		return handler(ctx, req)
+	}
+}
+
+// Recover turns a panic in a call into an Internal error, logged with its stack and
+// counted under component. The call runs with the caller's correlation ID, or a new
+// one, which the error carries so the failure can be found in the logs.
+func Recover(logger *slog.Logger, metrics contracts.Metrics, component string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		if values := metadata.ValueFromIncomingContext(ctx, correlationMetadata); len(values) > 0 && correlation.ID(ctx) == "" {
+			ctx = correlation.WithID(ctx, values[0])
+		}
+		ctx, id := correlation.Ensure(ctx)
+
+		panicked := recovery.Do(ctx, logger, metrics, component, func() error {
+			resp, err = handler(ctx, req)
+			return nil
+		})
+		if panicked != nil {
+			return nil, status.Errorf(codes.Internal, "internal error (correlation ID %s)", id)
+		}
+		return resp, err
+	}
+}
+
+func peerAddr(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	return p.Addr.String()
+}
+
+func sourceIP(ctx context.Context) string {
+	addr := peerAddr(ctx)
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	return host
+}
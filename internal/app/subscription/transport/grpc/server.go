@@ -0,0 +1,340 @@
+// Package grpc serves SubscriptionService, the gRPC API internal services create,
+// read, list, quote and cancel subscriptions, and change their add-ons, through.
+package grpc
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/transport/grpc/subscriptionv1"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/attach_add_on"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/cancel_subscription"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/create_subscription"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/detach_add_on"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/get_subscription"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/list_subscriptions"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/preview_cancel"
+)
+
+var _ subscriptionv1.SubscriptionServiceServer = (*Server)(nil)
+
+// Server implements SubscriptionService by delegating to the use cases
+type Server struct {
+	subscriptionv1.UnimplementedSubscriptionServiceServer
+
+	creator   create_subscription.UseCase
+	canceller cancel_subscription.UseCase
+	getter    get_subscription.UseCase
+	previewer preview_cancel.UseCase
+	lister    list_subscriptions.UseCase
+	attacher  attach_add_on.UseCase
+	detacher  detach_add_on.UseCase
+	logger    *slog.Logger
+}
+
+// NewServer creates the SubscriptionService implementation
+func NewServer(creator create_subscription.UseCase, canceller cancel_subscription.UseCase, getter get_subscription.UseCase, previewer preview_cancel.UseCase, lister list_subscriptions.UseCase, attacher attach_add_on.UseCase, detacher detach_add_on.UseCase, logger *slog.Logger) *Server {
+	return &Server{
+		creator:   creator,
+		canceller: canceller,
+		getter:    getter,
+		previewer: previewer,
+		lister:    lister,
+		attacher:  attacher,
+		detacher:  detacher,
+		logger:    logger,
+	}
+}
+
+// CreateSubscription runs create_subscription
+func (s *Server) CreateSubscription(ctx context.Context, req *subscriptionv1.CreateSubscriptionRequest) (*subscriptionv1.Subscription, error) {
+	sub, _, err := s.creator.Execute(ctx, create_subscription.Request{
+		CustomerID:     req.GetCustomerId(),
+		PlanID:         req.GetPlanId(),
+		PriceCents:     req.GetPriceCents(),
+		TrialDays:      req.GetTrialDays(),
+		ReferralCode:   req.GetReferralCode(),
+		CouponCode:     req.GetCouponCode(),
+		EnsureCustomer: req.GetEnsureCustomer(),
+		CustomerEmail:  req.GetCustomerEmail(),
+		CustomerName:   req.GetCustomerName(),
+		IdempotencyKey: idempotencyKey(ctx),
+	})
+	if err != nil {
+		return nil, s.fail(ctx, "failed to create subscription", err)
+	}
+	return toSubscription(sub), nil
+}
+
+// idempotencyKey returns the key the caller retries the call with, if any
+func idempotencyKey(ctx context.Context) string {
+	if values := metadata.ValueFromIncomingContext(ctx, idempotencyKeyMetadata); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// CancelSubscription runs cancel_subscription with the reason given, scheduling the
+// cancellation with at_period_end
+func (s *Server) CancelSubscription(ctx context.Context, req *subscriptionv1.CancelSubscriptionRequest) (*subscriptionv1.CancelSubscriptionResponse, error) {
+	reason := domain.CancellationReason{
+		Code:    domain.CancellationReasonCode(req.GetReason()),
+		Details: req.GetReasonDetails(),
+	}
+	if req.GetAtPeriodEnd() {
+		return s.scheduleCancellation(ctx, req.GetId(), reason)
+	}
+
+	event, err := s.canceller.Execute(ctx, req.GetId(), reason)
+	if err != nil {
+		return nil, s.fail(ctx, "failed to cancel subscription", err)
+	}
+	return &subscriptionv1.CancelSubscriptionResponse{
+		SubscriptionId:    event.SubscriptionID,
+		RefundAmountCents: event.RefundAmount,
+		CreditAmountCents: event.CreditAmount,
+		CancelledAt:       timestamppb.New(event.CancelledAt),
+		Status:            subscriptionv1.SubscriptionStatus_SUBSCRIPTION_STATUS_CANCELLED,
+	}, nil
+}
+
+// PreviewCancelSubscription runs preview_cancel. The reason is checked as the
+// cancellation would check it, though a preview doesn't record it.
+func (s *Server) PreviewCancelSubscription(ctx context.Context, req *subscriptionv1.CancelSubscriptionRequest) (*subscriptionv1.CancellationPreview, error) {
+	reason := domain.CancellationReason{
+		Code:    domain.CancellationReasonCode(req.GetReason()),
+		Details: req.GetReasonDetails(),
+	}
+	if err := reason.Validate(); err != nil {
+		return nil, s.fail(ctx, "invalid cancellation reason", err)
+	}
+
+	preview, err := s.previewer.Execute(ctx, preview_cancel.Request{SubscriptionID: req.GetId(), AtPeriodEnd: req.GetAtPeriodEnd()})
+	if err != nil {
+		return nil, s.fail(ctx, "failed to preview cancellation", err)
+	}
+	return &subscriptionv1.CancellationPreview{
+		SubscriptionId:    preview.SubscriptionID,
+		RefundAmountCents: preview.RefundAmount,
+		CreditAmountCents: preview.CreditAmount,
+		EffectiveAt:       timestamppb.New(preview.EffectiveAt),
+	}, nil
+}
+
+// scheduleCancellation schedules the subscription to cancel when its period ends
+func (s *Server) scheduleCancellation(ctx context.Context, id string, reason domain.CancellationReason) (*subscriptionv1.CancelSubscriptionResponse, error) {
+	event, err := s.canceller.CancelAtPeriodEnd(ctx, id, reason)
+	if err != nil {
+		return nil, s.fail(ctx, "failed to schedule cancellation", err)
+	}
+	return &subscriptionv1.CancelSubscriptionResponse{
+		SubscriptionId: event.SubscriptionID,
+		Status:         subscriptionv1.SubscriptionStatus_SUBSCRIPTION_STATUS_PENDING_CANCELLATION,
+		CancelAt:       timestamppb.New(event.CancelAt),
+	}, nil
+}
+
+// GetSubscription runs get_subscription
+func (s *Server) GetSubscription(ctx context.Context, req *subscriptionv1.GetSubscriptionRequest) (*subscriptionv1.Subscription, error) {
+	view, err := s.getter.Execute(ctx, req.GetId())
+	if err != nil {
+		return nil, s.fail(ctx, "failed to load subscription", err)
+	}
+	pb := toSubscription(view.Subscription)
+	pb.CurrentPeriodEnd = timestamppb.New(view.CurrentPeriodEnd)
+	pb.DaysRemaining = view.DaysRemaining
+	pb.NextRenewalAt = optionalTimestamp(view.NextRenewalAt)
+	pb.ProjectedRefundCents = view.ProjectedRefund
+	pb.ProjectedCreditCents = view.ProjectedCredit
+	return pb, nil
+}
+
+// ListSubscriptions runs list_subscriptions
+func (s *Server) ListSubscriptions(ctx context.Context, req *subscriptionv1.ListSubscriptionsRequest) (*subscriptionv1.ListSubscriptionsResponse, error) {
+	filter, ok := fromStatus(req.GetStatus())
+	if !ok {
+		return nil, s.fail(ctx, "failed to list subscriptions", domain.ErrInvalidSubscriptionStatus)
+	}
+	page, err := s.lister.Execute(ctx, list_subscriptions.Request{
+		CustomerID: req.GetCustomerId(),
+		Status:     filter,
+		PageToken:  req.GetPageToken(),
+		PageSize:   int(req.GetPageSize()),
+	})
+	if err != nil {
+		return nil, s.fail(ctx, "failed to list subscriptions", err)
+	}
+
+	resp := &subscriptionv1.ListSubscriptionsResponse{
+		Subscriptions: make([]*subscriptionv1.Subscription, 0, len(page.Subscriptions)),
+		NextPageToken: page.NextPageToken,
+	}
+	for _, sub := range page.Subscriptions {
+		resp.Subscriptions = append(resp.Subscriptions, toSubscriptionSummary(sub))
+	}
+	return resp, nil
+}
+
+// AttachAddOn runs attach_add_on
+func (s *Server) AttachAddOn(ctx context.Context, req *subscriptionv1.AttachAddOnRequest) (*subscriptionv1.AddOnsChangedResponse, error) {
+	addOn := req.GetAddOn()
+	event, err := s.attacher.Execute(ctx, attach_add_on.Request{
+		SubscriptionID: req.GetSubscriptionId(),
+		AddOn: domain.AddOnCharge{
+			ID:        addOn.GetId(),
+			Name:      addOn.GetName(),
+			Quantity:  addOn.GetQuantity(),
+			UnitPrice: addOn.GetUnitPriceCents(),
+		},
+	})
+	if err != nil {
+		return nil, s.fail(ctx, "failed to attach add-on", err)
+	}
+	return toAddOnsChanged(event), nil
+}
+
+// DetachAddOn runs detach_add_on
+func (s *Server) DetachAddOn(ctx context.Context, req *subscriptionv1.DetachAddOnRequest) (*subscriptionv1.AddOnsChangedResponse, error) {
+	event, err := s.detacher.Execute(ctx, detach_add_on.Request{
+		SubscriptionID: req.GetSubscriptionId(),
+		AddOnID:        req.GetAddOnId(),
+	})
+	if err != nil {
+		return nil, s.fail(ctx, "failed to detach add-on", err)
+	}
+	return toAddOnsChanged(event), nil
+}
+
+// fail turns err into a gRPC status. Errors the caller can act on keep their message;
+// anything else is logged and returned as Internal with msg, so internals don't leak.
+func (s *Server) fail(ctx context.Context, msg string, err error) error {
+	code := codeFor(err)
+	if code != codes.Internal {
+		return status.Error(code, err.Error())
+	}
+	s.logger.ErrorContext(ctx, msg, slog.Any("error", err))
+	return status.Error(code, msg)
+}
+
+// codeFor maps the errors a caller can act on to their gRPC code
+func codeFor(err error) codes.Code {
+	switch {
+	case errors.Is(err, domain.ErrInvalidCustomerID), errors.Is(err, domain.ErrInvalidCustomerEmail),
+		errors.Is(err, domain.ErrInvalidPlanID), errors.Is(err, domain.ErrInvalidPrice),
+		errors.Is(err, domain.ErrInvalidTrialDays), errors.Is(err, domain.ErrInvalidReferralCode),
+		errors.Is(err, domain.ErrSelfReferral), errors.Is(err, domain.ErrInvalidSubscriptionBundle),
+		errors.Is(err, domain.ErrInvalidSubscriptionStatus), errors.Is(err, domain.ErrInvalidPageSize),
+		errors.Is(err, domain.ErrInvalidPageToken), errors.Is(err, domain.ErrInvalidIdempotencyKey),
+		errors.Is(err, domain.ErrInvalidCancellationReason), errors.Is(err, domain.ErrInvalidCouponCode),
+		errors.Is(err, domain.ErrCouponNotFound), errors.Is(err, domain.ErrInvalidAddOn):
+		return codes.InvalidArgument
+	case errors.Is(err, domain.ErrSubscriptionNotFound), errors.Is(err, domain.ErrReferralCodeNotFound),
+		errors.Is(err, domain.ErrPlanNotFound), errors.Is(err, domain.ErrAddOnNotFound):
+		return codes.NotFound
+	case errors.Is(err, domain.ErrAlreadyCancelled), errors.Is(err, domain.ErrInvalidCustomer),
+		errors.Is(err, domain.ErrRejectedByHook), errors.Is(err, domain.ErrPlanInactive),
+		errors.Is(err, domain.ErrPlanPriceMismatch), errors.Is(err, domain.ErrCancellationAlreadyScheduled),
+		errors.Is(err, domain.ErrNotActive), errors.Is(err, domain.ErrCouponExpired),
+		errors.Is(err, domain.ErrCouponRedemptionLimitReached), errors.Is(err, domain.ErrCouponAlreadyApplied),
+		errors.Is(err, domain.ErrUnsupportedCurrency):
+		return codes.FailedPrecondition
+	case errors.Is(err, domain.ErrConcurrentModification):
+		return codes.Aborted
+	case errors.Is(err, context.Canceled):
+		return codes.Canceled
+	case errors.Is(err, context.DeadlineExceeded):
+		return codes.DeadlineExceeded
+	default:
+		return codes.Internal
+	}
+}
+
+var statuses = map[subscriptionv1.SubscriptionStatus]domain.SubscriptionStatus{
+	subscriptionv1.SubscriptionStatus_SUBSCRIPTION_STATUS_ACTIVE:               domain.StatusActive,
+	subscriptionv1.SubscriptionStatus_SUBSCRIPTION_STATUS_CANCELLED:            domain.StatusCancelled,
+	subscriptionv1.SubscriptionStatus_SUBSCRIPTION_STATUS_PAST_DUE:             domain.StatusPastDue,
+	subscriptionv1.SubscriptionStatus_SUBSCRIPTION_STATUS_TRIALING:             domain.StatusTrialing,
+	subscriptionv1.SubscriptionStatus_SUBSCRIPTION_STATUS_PAUSED:               domain.StatusPaused,
+	subscriptionv1.SubscriptionStatus_SUBSCRIPTION_STATUS_PENDING_CANCELLATION: domain.StatusPendingCancellation,
+}
+
+// fromStatus is the domain status for s; unspecified is every status, and false
+// means a value this server doesn't know
+func fromStatus(s subscriptionv1.SubscriptionStatus) (domain.SubscriptionStatus, bool) {
+	if s == subscriptionv1.SubscriptionStatus_SUBSCRIPTION_STATUS_UNSPECIFIED {
+		return "", true
+	}
+	ds, ok := statuses[s]
+	return ds, ok
+}
+
+func toStatus(s domain.SubscriptionStatus) subscriptionv1.SubscriptionStatus {
+	for pb, ds := range statuses {
+		if ds == s {
+			return pb
+		}
+	}
+	return subscriptionv1.SubscriptionStatus_SUBSCRIPTION_STATUS_UNSPECIFIED
+}
+
+func toSubscription(sub *domain.Subscription) *subscriptionv1.Subscription {
+	pb := &subscriptionv1.Subscription{
+		Id:                 sub.ID(),
+		CustomerId:         sub.CustomerID(),
+		PlanId:             sub.PlanID(),
+		PriceCents:         sub.Price(),
+		Status:             toStatus(sub.Status()),
+		StartDate:          timestamppb.New(sub.StartDate()),
+		CurrentPeriodStart: timestamppb.New(sub.CurrentPeriodStart()),
+		TrialEndDate:       optionalTimestamp(sub.TrialEndDate()),
+		PausedAt:           optionalTimestamp(sub.PausedAt()),
+		CancelledAt:        optionalTimestamp(sub.CancelledAt()),
+		CancelAt:           optionalTimestamp(sub.CancelAt()),
+	}
+	if coupon := sub.Coupon(); !coupon.IsZero() {
+		pb.Coupon = &subscriptionv1.Coupon{Code: coupon.Code, PercentOff: coupon.PercentOff, AmountOffCents: coupon.AmountOff, PeriodsLeft: coupon.PeriodsLeft}
+	}
+	return pb
+}
+
+func toSubscriptionSummary(sub contracts.SubscriptionSummary) *subscriptionv1.Subscription {
+	return &subscriptionv1.Subscription{
+		Id:         sub.ID,
+		CustomerId: sub.CustomerID,
+		PlanId:     sub.PlanID,
+		PriceCents: sub.Price,
+		Status:     toStatus(sub.Status),
+		StartDate:  timestamppb.New(sub.StartDate),
+	}
+}
+
+func toAddOnsChanged(event *domain.SubscriptionAddOnsChangedEvent) *subscriptionv1.AddOnsChangedResponse {
+	resp := &subscriptionv1.AddOnsChangedResponse{
+		SubscriptionId:      event.SubscriptionID,
+		AddOnId:             event.AddOnID,
+		AddOns:              make([]*subscriptionv1.AddOn, 0, len(event.AddOns)),
+		ProratedAmountCents: event.ProratedAmount,
+		ChangedAt:           timestamppb.New(event.ChangedAt),
+	}
+	for _, a := range event.AddOns {
+		resp.AddOns = append(resp.AddOns, &subscriptionv1.AddOn{Id: a.ID, Name: a.Name, Quantity: a.Quantity, UnitPriceCents: a.UnitPrice})
+	}
+	return resp
+}
+
+// optionalTimestamp is unset for the zero time
+func optionalTimestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
@@ -0,0 +1,389 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/adapters"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/logging"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/testkit"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/testkit/builders"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/transport/grpc/subscriptionv1"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/attach_add_on"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/create_subscription"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/detach_add_on"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/get_subscription"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/list_subscriptions"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/preview_cancel"
+)
+
+type staticSecrets map[string]string
+
+func (s staticSecrets) Secret(_ context.Context, name string) (string, error) {
+	v, ok := s[name]
+	if !ok {
+		return "", errors.New("not found")
+	}
+	return v, nil
+}
+
+type stubCreator struct {
+	requests []create_subscription.Request
+	err      error
+}
+
+func (s *stubCreator) Execute(_ context.Context, req create_subscription.Request) (*domain.Subscription, *domain.SubscriptionCreatedEvent, error) {
+	s.requests = append(s.requests, req)
+	if s.err != nil {
+		return nil, nil, s.err
+	}
+	b := builders.NewSubscriptionBuilder().WithID("sub-new").WithCustomerID(req.CustomerID).WithPlan(req.PlanID).WithPrice(req.PriceCents)
+	if req.CouponCode != "" {
+		b = b.WithCoupon(domain.SubscriptionCoupon{Code: req.CouponCode, PercentOff: 2000, PeriodsLeft: 3})
+	}
+	sub := b.Build()
+	return sub, &domain.SubscriptionCreatedEvent{SubscriptionID: sub.ID()}, nil
+}
+
+type stubCanceller struct {
+	err     error
+	reasons *[]domain.CancellationReason
+}
+
+func (s stubCanceller) Execute(_ context.Context, subscriptionID string, reason domain.CancellationReason) (*domain.SubscriptionCancelledEvent, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	if s.reasons != nil {
+		*s.reasons = append(*s.reasons, reason)
+	}
+	if subscriptionID == "panic" {
+		panic("boom")
+	}
+	return &domain.SubscriptionCancelledEvent{
+		SubscriptionID: subscriptionID,
+		RefundAmount:   1500,
+		CancelledAt:    time.Date(2024, 1, 16, 0, 0, 0, 0, time.UTC),
+	}, nil
+}
+
+type stubLister struct {
+	requests []list_subscriptions.Request
+}
+
+func (s *stubLister) Execute(_ context.Context, req list_subscriptions.Request) (*list_subscriptions.Page, error) {
+	s.requests = append(s.requests, req)
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	return &list_subscriptions.Page{
+		Subscriptions: []contracts.SubscriptionSummary{{
+			ID:         "sub-1",
+			CustomerID: req.CustomerID,
+			PlanID:     "plan-pro",
+			Price:      2900,
+			Status:     domain.StatusPaused,
+			StartDate:  time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC),
+		}},
+		NextPageToken: "next",
+	}, nil
+}
+
+// dial serves srv behind the interceptors cmd/server installs, over an in-memory
+// connection, and returns a client authenticated with token
+func dial(t *testing.T, srv *Server, token string) subscriptionv1.SubscriptionServiceClient {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	server := grpc.NewServer(grpc.ChainUnaryInterceptor(
+		Recover(logging.Discard(), adapters.NoopMetrics{}, "subscriptions_grpc"),
+		RequireToken(staticSecrets{TokenSecret: "s3cret"}, logging.Discard()),
+	))
+	subscriptionv1.RegisterSubscriptionServiceServer(server, srv)
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.DialContext(context.Background(), "bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+			return invoker(metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token), method, req, reply, cc, opts...)
+		}),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return subscriptionv1.NewSubscriptionServiceClient(conn)
+}
+
+func (s stubCanceller) CancelAtPeriodEnd(_ context.Context, subscriptionID string, reason domain.CancellationReason) (*domain.SubscriptionCancellationScheduledEvent, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	if s.reasons != nil {
+		*s.reasons = append(*s.reasons, reason)
+	}
+	return &domain.SubscriptionCancellationScheduledEvent{
+		SubscriptionID: subscriptionID,
+		CancelAt:       time.Date(2024, 2, 15, 0, 0, 0, 0, time.UTC),
+	}, nil
+}
+
+func newTestServer(creator *stubCreator, canceller stubCanceller, lister *stubLister) *Server {
+	subs := testkit.NewFakeSubscriptions().With(
+		builders.NewSubscriptionBuilder().Build(),
+		builders.NewSubscriptionBuilder().WithID("sub-trial").Trialing(14).Build(),
+		builders.NewSubscriptionBuilder().WithID("sub-ending").PendingCancellationAt(time.Date(2024, 2, 15, 0, 0, 0, 0, time.UTC)).Build(),
+	)
+	// Add-ons are changed by the use cases themselves, ten days into a 30 day period
+	clock := domain.FixedClock{FixedTime: builders.DefaultStartDate.AddDate(0, 0, 10)}
+	cycles := adapters.StaticBillingCycle{Cycle: domain.CycleOfDays(30)}
+	bundles := testkit.NewFakeBundles()
+	attacher := attach_add_on.NewInteractor(subs, bundles, testkit.NewFakeRefunds(), testkit.NewFakeRefundOutbox(), adapters.StaticBillingResolver{Client: testkit.NewFakeBillingClient()}, adapters.StaticPricing{}, cycles, clock)
+	detacher := detach_add_on.NewInteractor(subs, bundles, adapters.StaticPricing{}, cycles, clock)
+	getter := get_subscription.NewInteractor(subs, adapters.StaticPricing{}, cycles, adapters.StaticFeatureFlags{}, clock)
+	previewer := preview_cancel.NewInteractor(subs, adapters.StaticPricing{}, cycles, adapters.StaticFeatureFlags{}, clock)
+	return NewServer(creator, canceller, getter, previewer, lister, attacher, detacher, logging.Discard())
+}
+
+func TestServer_CreateSubscription(t *testing.T) {
+	creator := &stubCreator{}
+	client := dial(t, newTestServer(creator, stubCanceller{}, &stubLister{}), "s3cret")
+
+	sub, err := client.CreateSubscription(context.Background(), &subscriptionv1.CreateSubscriptionRequest{CustomerId: "cust-1", PlanId: "plan-pro", PriceCents: 2900, TrialDays: 14})
+	require.NoError(t, err)
+
+	assert.Equal(t, "sub-new", sub.GetId())
+	assert.Equal(t, subscriptionv1.SubscriptionStatus_SUBSCRIPTION_STATUS_ACTIVE, sub.GetStatus())
+	assert.Equal(t, builders.DefaultStartDate, sub.GetStartDate().AsTime())
+	assert.Nil(t, sub.GetCancelledAt())
+	assert.Nil(t, sub.GetCoupon())
+	assert.Equal(t, []create_subscription.Request{{CustomerID: "cust-1", PlanID: "plan-pro", PriceCents: 2900, TrialDays: 14}}, creator.requests)
+}
+
+func TestServer_CreateSubscriptionPassesIdempotencyKey(t *testing.T) {
+	creator := &stubCreator{}
+	client := dial(t, newTestServer(creator, stubCanceller{}, &stubLister{}), "s3cret")
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "idempotency-key", "order-42")
+
+	_, err := client.CreateSubscription(ctx, &subscriptionv1.CreateSubscriptionRequest{CustomerId: "cust-1", PlanId: "plan-pro", PriceCents: 2900})
+	require.NoError(t, err)
+
+	assert.Equal(t, []create_subscription.Request{{CustomerID: "cust-1", PlanID: "plan-pro", PriceCents: 2900, IdempotencyKey: "order-42"}}, creator.requests)
+}
+
+func TestServer_CreateSubscriptionRedeemsCoupon(t *testing.T) {
+	creator := &stubCreator{}
+	client := dial(t, newTestServer(creator, stubCanceller{}, &stubLister{}), "s3cret")
+
+	sub, err := client.CreateSubscription(context.Background(), &subscriptionv1.CreateSubscriptionRequest{CustomerId: "cust-1", PlanId: "plan-pro", CouponCode: "LAUNCH20"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "LAUNCH20", creator.requests[0].CouponCode)
+	assert.Equal(t, "LAUNCH20", sub.GetCoupon().GetCode())
+	assert.Equal(t, int64(2000), sub.GetCoupon().GetPercentOff())
+	assert.Equal(t, int64(3), sub.GetCoupon().GetPeriodsLeft())
+}
+
+func TestServer_GetSubscription(t *testing.T) {
+	client := dial(t, newTestServer(&stubCreator{}, stubCanceller{}, &stubLister{}), "s3cret")
+
+	sub, err := client.GetSubscription(context.Background(), &subscriptionv1.GetSubscriptionRequest{Id: "sub-trial"})
+	require.NoError(t, err)
+	assert.Equal(t, subscriptionv1.SubscriptionStatus_SUBSCRIPTION_STATUS_TRIALING, sub.GetStatus())
+	assert.Equal(t, builders.DefaultStartDate.AddDate(0, 0, 14), sub.GetTrialEndDate().AsTime())
+	assert.Nil(t, sub.GetPausedAt())
+
+	sub, err = client.GetSubscription(context.Background(), &subscriptionv1.GetSubscriptionRequest{Id: "sub-123"})
+	require.NoError(t, err)
+	assert.Equal(t, builders.DefaultStartDate.AddDate(0, 0, 30), sub.GetCurrentPeriodEnd().AsTime())
+	assert.Equal(t, int64(20), sub.GetDaysRemaining())
+	assert.Equal(t, builders.DefaultStartDate.AddDate(0, 0, 30), sub.GetNextRenewalAt().AsTime())
+	assert.Equal(t, int64(2000), sub.GetProjectedRefundCents()) // 3000 * 20 / 30
+	assert.Zero(t, sub.GetProjectedCreditCents())
+
+	_, err = client.GetSubscription(context.Background(), &subscriptionv1.GetSubscriptionRequest{Id: "missing"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestServer_CancelSubscription(t *testing.T) {
+	client := dial(t, newTestServer(&stubCreator{}, stubCanceller{}, &stubLister{}), "s3cret")
+
+	resp, err := client.CancelSubscription(context.Background(), &subscriptionv1.CancelSubscriptionRequest{Id: "sub-123"})
+	require.NoError(t, err)
+	assert.Equal(t, "sub-123", resp.GetSubscriptionId())
+	assert.Equal(t, int64(1500), resp.GetRefundAmountCents())
+	assert.Equal(t, time.Date(2024, 1, 16, 0, 0, 0, 0, time.UTC), resp.GetCancelledAt().AsTime())
+	assert.Equal(t, subscriptionv1.SubscriptionStatus_SUBSCRIPTION_STATUS_CANCELLED, resp.GetStatus())
+}
+
+func TestServer_PreviewCancelSubscription(t *testing.T) {
+	client := dial(t, newTestServer(&stubCreator{}, stubCanceller{err: errors.New("must not cancel")}, &stubLister{}), "s3cret")
+
+	preview, err := client.PreviewCancelSubscription(context.Background(), &subscriptionv1.CancelSubscriptionRequest{Id: "sub-123", Reason: "too_expensive"})
+	require.NoError(t, err)
+	assert.Equal(t, "sub-123", preview.GetSubscriptionId())
+	assert.Equal(t, int64(2000), preview.GetRefundAmountCents()) // 3000 * 20 / 30
+	assert.Equal(t, builders.DefaultStartDate.AddDate(0, 0, 10), preview.GetEffectiveAt().AsTime())
+
+	preview, err = client.PreviewCancelSubscription(context.Background(), &subscriptionv1.CancelSubscriptionRequest{Id: "sub-123", AtPeriodEnd: true})
+	require.NoError(t, err)
+	assert.Zero(t, preview.GetRefundAmountCents())
+	assert.Equal(t, builders.DefaultStartDate.AddDate(0, 0, 30), preview.GetEffectiveAt().AsTime())
+
+	_, err = client.PreviewCancelSubscription(context.Background(), &subscriptionv1.CancelSubscriptionRequest{Id: "sub-123", Reason: "bored"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = client.PreviewCancelSubscription(context.Background(), &subscriptionv1.CancelSubscriptionRequest{Id: "missing"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestServer_CancelSubscriptionAtPeriodEnd(t *testing.T) {
+	client := dial(t, newTestServer(&stubCreator{}, stubCanceller{}, &stubLister{}), "s3cret")
+
+	resp, err := client.CancelSubscription(context.Background(), &subscriptionv1.CancelSubscriptionRequest{Id: "sub-123", AtPeriodEnd: true})
+	require.NoError(t, err)
+	assert.Equal(t, "sub-123", resp.GetSubscriptionId())
+	assert.Equal(t, subscriptionv1.SubscriptionStatus_SUBSCRIPTION_STATUS_PENDING_CANCELLATION, resp.GetStatus())
+	assert.Equal(t, time.Date(2024, 2, 15, 0, 0, 0, 0, time.UTC), resp.GetCancelAt().AsTime())
+	assert.Nil(t, resp.GetCancelledAt())
+	assert.Zero(t, resp.GetRefundAmountCents())
+
+	sub, err := client.GetSubscription(context.Background(), &subscriptionv1.GetSubscriptionRequest{Id: "sub-ending"})
+	require.NoError(t, err)
+	assert.Equal(t, subscriptionv1.SubscriptionStatus_SUBSCRIPTION_STATUS_PENDING_CANCELLATION, sub.GetStatus())
+	assert.Equal(t, time.Date(2024, 2, 15, 0, 0, 0, 0, time.UTC), sub.GetCancelAt().AsTime())
+
+	client = dial(t, newTestServer(&stubCreator{}, stubCanceller{err: domain.ErrCancellationAlreadyScheduled}, &stubLister{}), "s3cret")
+	_, err = client.CancelSubscription(context.Background(), &subscriptionv1.CancelSubscriptionRequest{Id: "sub-123", AtPeriodEnd: true})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+}
+
+func TestServer_CancelSubscriptionRecordsReason(t *testing.T) {
+	var reasons []domain.CancellationReason
+	client := dial(t, newTestServer(&stubCreator{}, stubCanceller{reasons: &reasons}, &stubLister{}), "s3cret")
+
+	_, err := client.CancelSubscription(context.Background(), &subscriptionv1.CancelSubscriptionRequest{Id: "sub-123", Reason: "too_expensive", ReasonDetails: "Found a cheaper plan"})
+	require.NoError(t, err)
+	_, err = client.CancelSubscription(context.Background(), &subscriptionv1.CancelSubscriptionRequest{Id: "sub-123", AtPeriodEnd: true, Reason: "unused"})
+	require.NoError(t, err)
+
+	assert.Equal(t, []domain.CancellationReason{
+		{Code: domain.CancellationReasonTooExpensive, Details: "Found a cheaper plan"},
+		{Code: domain.CancellationReasonUnused},
+	}, reasons)
+}
+
+func TestServer_ListSubscriptions(t *testing.T) {
+	lister := &stubLister{}
+	client := dial(t, newTestServer(&stubCreator{}, stubCanceller{}, lister), "s3cret")
+
+	resp, err := client.ListSubscriptions(context.Background(), &subscriptionv1.ListSubscriptionsRequest{
+		CustomerId: "cust-1",
+		Status:     subscriptionv1.SubscriptionStatus_SUBSCRIPTION_STATUS_PAUSED,
+		PageSize:   1,
+		PageToken:  "prev",
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.GetSubscriptions(), 1)
+	assert.Equal(t, "sub-1", resp.GetSubscriptions()[0].GetId())
+	assert.Equal(t, subscriptionv1.SubscriptionStatus_SUBSCRIPTION_STATUS_PAUSED, resp.GetSubscriptions()[0].GetStatus())
+	assert.Equal(t, "next", resp.GetNextPageToken())
+	assert.Equal(t, []list_subscriptions.Request{{CustomerID: "cust-1", Status: domain.StatusPaused, PageToken: "prev", PageSize: 1}}, lister.requests)
+
+	_, err = client.ListSubscriptions(context.Background(), &subscriptionv1.ListSubscriptionsRequest{CustomerId: "cust-1", Status: 99})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = client.ListSubscriptions(context.Background(), &subscriptionv1.ListSubscriptionsRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestServer_AttachAndDetachAddOn(t *testing.T) {
+	ctx := context.Background()
+	client := dial(t, newTestServer(&stubCreator{}, stubCanceller{}, &stubLister{}), "s3cret")
+	storage := &subscriptionv1.AddOn{Id: "storage", Name: "Extra storage", Quantity: 1, UnitPriceCents: 900}
+
+	attached, err := client.AttachAddOn(ctx, &subscriptionv1.AttachAddOnRequest{SubscriptionId: "sub-123", AddOn: storage})
+	require.NoError(t, err)
+	assert.Equal(t, "storage", attached.GetAddOnId())
+	require.Len(t, attached.GetAddOns(), 1)
+	assert.Equal(t, int64(900), attached.GetAddOns()[0].GetUnitPriceCents())
+	assert.Equal(t, int64(600), attached.GetProratedAmountCents(), "20 of 30 days remain")
+
+	detached, err := client.DetachAddOn(ctx, &subscriptionv1.DetachAddOnRequest{SubscriptionId: "sub-123", AddOnId: "storage"})
+	require.NoError(t, err)
+	assert.Empty(t, detached.GetAddOns())
+	assert.Equal(t, int64(-600), detached.GetProratedAmountCents())
+
+	_, err = client.DetachAddOn(ctx, &subscriptionv1.DetachAddOnRequest{SubscriptionId: "sub-123", AddOnId: "storage"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	_, err = client.AttachAddOn(ctx, &subscriptionv1.AttachAddOnRequest{SubscriptionId: "sub-123", AddOn: &subscriptionv1.AddOn{Id: "storage"}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = client.AttachAddOn(ctx, &subscriptionv1.AttachAddOnRequest{SubscriptionId: "sub-trial", AddOn: storage})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+}
+
+func TestServer_MapsDomainErrors(t *testing.T) {
+	tests := []struct {
+		err  error
+		want codes.Code
+	}{
+		{domain.ErrInvalidPlanID, codes.InvalidArgument},
+		{domain.ErrInvalidIdempotencyKey, codes.InvalidArgument},
+		{domain.ErrInvalidCancellationReason, codes.InvalidArgument},
+		{domain.ErrInvalidCouponCode, codes.InvalidArgument},
+		{domain.ErrCouponNotFound, codes.InvalidArgument},
+		{domain.ErrCouponExpired, codes.FailedPrecondition},
+		{domain.ErrCouponRedemptionLimitReached, codes.FailedPrecondition},
+		{domain.ErrPlanNotFound, codes.NotFound},
+		{domain.ErrPlanPriceMismatch, codes.FailedPrecondition},
+		{domain.ErrSubscriptionNotFound, codes.NotFound},
+		{domain.ErrAlreadyCancelled, codes.FailedPrecondition},
+		{domain.ErrInvalidCustomer, codes.FailedPrecondition},
+		{fmt.Errorf("%w: %w", domain.ErrRejectedByHook, errors.New("crm down")), codes.FailedPrecondition},
+		{domain.ErrConcurrentModification, codes.Aborted},
+		{errors.New("spanner: session expired"), codes.Internal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.err.Error(), func(t *testing.T) {
+			client := dial(t, newTestServer(&stubCreator{err: tt.err}, stubCanceller{err: tt.err}, &stubLister{}), "s3cret")
+
+			_, err := client.CreateSubscription(context.Background(), &subscriptionv1.CreateSubscriptionRequest{})
+			assert.Equal(t, tt.want, status.Code(err))
+			_, err = client.CancelSubscription(context.Background(), &subscriptionv1.CancelSubscriptionRequest{Id: "sub-123"})
+			assert.Equal(t, tt.want, status.Code(err))
+		})
+	}
+
+	client := dial(t, newTestServer(&stubCreator{err: errors.New("spanner: session expired")}, stubCanceller{}, &stubLister{}), "s3cret")
+	_, err := client.CreateSubscription(context.Background(), &subscriptionv1.CreateSubscriptionRequest{})
+	assert.Equal(t, "failed to create subscription", status.Convert(err).Message())
+}
+
+func TestServer_RequiresToken(t *testing.T) {
+	client := dial(t, newTestServer(&stubCreator{}, stubCanceller{}, &stubLister{}), "wrong")
+
+	_, err := client.GetSubscription(context.Background(), &subscriptionv1.GetSubscriptionRequest{Id: "sub-123"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestServer_RecoversPanics(t *testing.T) {
+	client := dial(t, newTestServer(&stubCreator{}, stubCanceller{}, &stubLister{}), "s3cret")
+
+	_, err := client.CancelSubscription(context.Background(), &subscriptionv1.CancelSubscriptionRequest{Id: "panic"})
+	assert.Equal(t, codes.Internal, status.Code(err))
+
+	_, err = client.GetSubscription(context.Background(), &subscriptionv1.GetSubscriptionRequest{Id: "sub-123"})
+	assert.NoError(t, err, "the server keeps serving")
+}
@@ -0,0 +1,3 @@
+// Package subscriptionv1 holds the SubscriptionService protobuf definitions and the
+// code generated from them. Edit subscription.proto, then run make proto.
+package subscriptionv1
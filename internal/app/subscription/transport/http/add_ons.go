@@ -0,0 +1,111 @@
+package http
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/attach_add_on"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/detach_add_on"
+)
+
+// attachAddOnRequest is the JSON body of PUT /subscriptions/{id}/add-ons/{add_on_id}
+type attachAddOnRequest struct {
+	Name           string `json:"name"`
+	Quantity       int64  `json:"quantity"`
+	UnitPriceCents int64  `json:"unit_price_cents"`
+}
+
+type addOnJSON struct {
+	ID             string `json:"id"`
+	Name           string `json:"name"`
+	Quantity       int64  `json:"quantity"`
+	UnitPriceCents int64  `json:"unit_price_cents"`
+}
+
+// addOnsChangedJSON is the subscription's add-ons once the change is made and what it
+// was prorated at; negative for a detach, which gives nothing back
+type addOnsChangedJSON struct {
+	SubscriptionID      string      `json:"subscription_id"`
+	AddOnID             string      `json:"add_on_id"`
+	AddOns              []addOnJSON `json:"add_ons"`
+	ProratedAmountCents int64       `json:"prorated_amount_cents"`
+	ChangedAt           time.Time   `json:"changed_at"`
+}
+
+// serveAddOn dispatches /subscriptions/{id}/add-ons/{add_on_id} on the method
+func (h *SubscriptionsHandler) serveAddOn(w http.ResponseWriter, r *http.Request, id, addOnID string) {
+	if id == "" || strings.Contains(id, "/") || addOnID == "" || strings.Contains(addOnID, "/") {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	switch r.Method {
+	case http.MethodPut:
+		h.attachAddOn(w, r, id, addOnID)
+	case http.MethodDelete:
+		h.detachAddOn(w, r, id, addOnID)
+	default:
+		w.Header().Set("Allow", http.MethodPut+", "+http.MethodDelete)
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// attachAddOn answers PUT /subscriptions/{id}/add-ons/{add_on_id} with the add-ons
+// attached once it's attached, or its quantity and price changed, and the prorated
+// charge for the rest of the period
+func (h *SubscriptionsHandler) attachAddOn(w http.ResponseWriter, r *http.Request, id, addOnID string) {
+	var body attachAddOnRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	event, err := h.attacher.Execute(r.Context(), attach_add_on.Request{
+		SubscriptionID: id,
+		AddOn: domain.AddOnCharge{
+			ID:        addOnID,
+			Name:      body.Name,
+			Quantity:  body.Quantity,
+			UnitPrice: body.UnitPriceCents,
+		},
+	})
+	if err != nil {
+		h.fail(w, r, "failed to attach add-on", err)
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, toAddOnsChangedJSON(event)); err != nil {
+		h.logger.WarnContext(r.Context(), "failed to write add-ons", slog.Any("error", err))
+	}
+}
+
+// detachAddOn answers DELETE /subscriptions/{id}/add-ons/{add_on_id} with the add-ons
+// left attached
+func (h *SubscriptionsHandler) detachAddOn(w http.ResponseWriter, r *http.Request, id, addOnID string) {
+	event, err := h.detacher.Execute(r.Context(), detach_add_on.Request{SubscriptionID: id, AddOnID: addOnID})
+	if err != nil {
+		h.fail(w, r, "failed to detach add-on", err)
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, toAddOnsChangedJSON(event)); err != nil {
+		h.logger.WarnContext(r.Context(), "failed to write add-ons", slog.Any("error", err))
+	}
+}
+
+func toAddOnsChangedJSON(event *domain.SubscriptionAddOnsChangedEvent) addOnsChangedJSON {
+	resp := addOnsChangedJSON{
+		SubscriptionID:      event.SubscriptionID,
+		AddOnID:             event.AddOnID,
+		AddOns:              make([]addOnJSON, 0, len(event.AddOns)),
+		ProratedAmountCents: event.ProratedAmount,
+		ChangedAt:           event.ChangedAt,
+	}
+	for _, a := range event.AddOns {
+		resp.AddOns = append(resp.AddOns, addOnJSON{ID: a.ID, Name: a.Name, Quantity: a.Quantity, UnitPriceCents: a.UnitPrice})
+	}
+	return resp
+}
@@ -0,0 +1,42 @@
+package http
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubscriptions_AttachAndDetachAddOn(t *testing.T) {
+	h := newTestHandler(&stubCreator{}, stubCanceller{})
+
+	rec := do(h, http.MethodPut, "/subscriptions/sub-123/add-ons/storage", `{"name":"Extra storage","quantity":1,"unit_price_cents":900}`, "s3cret")
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{
+		"subscription_id": "sub-123", "add_on_id": "storage",
+		"add_ons": [{"id": "storage", "name": "Extra storage", "quantity": 1, "unit_price_cents": 900}],
+		"prorated_amount_cents": 600, "changed_at": "2024-01-11T00:00:00Z"
+	}`, rec.Body.String())
+
+	rec = do(h, http.MethodDelete, "/subscriptions/sub-123/add-ons/storage", "", "s3cret")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{
+		"subscription_id": "sub-123", "add_on_id": "storage", "add_ons": [],
+		"prorated_amount_cents": -600, "changed_at": "2024-01-11T00:00:00Z"
+	}`, rec.Body.String())
+
+	rec = do(h, http.MethodDelete, "/subscriptions/sub-123/add-ons/storage", "", "s3cret")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestSubscriptions_RejectsBadAddOnRequests(t *testing.T) {
+	h := newTestHandler(&stubCreator{}, stubCanceller{})
+
+	assert.Equal(t, http.StatusBadRequest, do(h, http.MethodPut, "/subscriptions/sub-123/add-ons/storage", `not json`, "s3cret").Code)
+	assert.Equal(t, http.StatusBadRequest, do(h, http.MethodPut, "/subscriptions/sub-123/add-ons/storage", `{"name":"Extra storage"}`, "s3cret").Code)
+	assert.Equal(t, http.StatusConflict, do(h, http.MethodPut, "/subscriptions/sub-trial/add-ons/storage", `{"name":"Extra storage","quantity":1,"unit_price_cents":900}`, "s3cret").Code)
+	assert.Equal(t, http.StatusMethodNotAllowed, do(h, http.MethodGet, "/subscriptions/sub-123/add-ons/storage", "", "s3cret").Code)
+	assert.Equal(t, http.StatusNotFound, do(h, http.MethodPut, "/subscriptions/sub-123/add-ons/", "", "s3cret").Code)
+	assert.Equal(t, http.StatusNotFound, do(h, http.MethodPut, "/subscriptions/sub-123/add-ons/storage/extra", "", "s3cret").Code)
+}
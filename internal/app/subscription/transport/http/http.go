@@ -0,0 +1,108 @@
+// Package http serves the REST API other services use to create, read and cancel
+// subscriptions, and to change their add-ons.
+package http
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/audit"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/attach_add_on"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/cancel_subscription"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/create_subscription"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/detach_add_on"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/get_subscription"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/preview_cancel"
+)
+
+// TokenSecret names the bearer token API callers must present, resolved through the
+// SecretProvider on every request so it can be rotated without a restart
+const TokenSecret = "api-token"
+
+// RequireToken serves next only to requests carrying the API token as
+// "Authorization: Bearer <token>", and records the caller as the audit principal
+func RequireToken(secrets contracts.SecretProvider, logger *slog.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		want, err := secrets.Secret(r.Context(), TokenSecret)
+		if err != nil || want == "" {
+			logger.ErrorContext(r.Context(), "API token unavailable", slog.Any("error", err))
+			writeError(w, http.StatusServiceUnavailable, "API unavailable")
+			return
+		}
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(want)) != 1 {
+			logger.WarnContext(r.Context(), "API request rejected", slog.String("path", r.URL.Path), slog.String("remote_addr", r.RemoteAddr))
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeError(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+		ctx := audit.WithPrincipal(r.Context(), audit.Principal{ID: "api-token", SourceIP: sourceIP(r)})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func sourceIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// NewHandler routes the subscriptions API
+func NewHandler(creator create_subscription.UseCase, canceller cancel_subscription.UseCase, getter get_subscription.UseCase, previewer preview_cancel.UseCase, attacher attach_add_on.UseCase, detacher detach_add_on.UseCase, secrets contracts.SecretProvider, logger *slog.Logger) http.Handler {
+	h := NewSubscriptionsHandler(creator, canceller, getter, previewer, attacher, detacher, logger)
+	mux := http.NewServeMux()
+	mux.Handle("/subscriptions", h)
+	mux.Handle("/subscriptions/", h)
+	return RequireToken(secrets, logger, mux)
+}
+
+// statusFor maps the errors a caller can act on to their HTTP status; any other
+// error is the service's fault and answered with 500
+func statusFor(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrInvalidCustomerID), errors.Is(err, domain.ErrInvalidCustomerEmail),
+		errors.Is(err, domain.ErrInvalidPlanID), errors.Is(err, domain.ErrInvalidPrice),
+		errors.Is(err, domain.ErrInvalidTrialDays), errors.Is(err, domain.ErrInvalidReferralCode),
+		errors.Is(err, domain.ErrSelfReferral), errors.Is(err, domain.ErrInvalidSubscriptionBundle),
+		errors.Is(err, domain.ErrInvalidIdempotencyKey), errors.Is(err, domain.ErrInvalidCouponCode),
+		errors.Is(err, domain.ErrInvalidCancellationReason), errors.Is(err, domain.ErrInvalidAddOn):
+		return http.StatusBadRequest
+	case errors.Is(err, domain.ErrSubscriptionNotFound), errors.Is(err, domain.ErrAddOnNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrAlreadyCancelled), errors.Is(err, domain.ErrConcurrentModification),
+		errors.Is(err, domain.ErrCancellationAlreadyScheduled), errors.Is(err, domain.ErrNotActive):
+		return http.StatusConflict
+	case errors.Is(err, domain.ErrInvalidCustomer), errors.Is(err, domain.ErrReferralCodeNotFound),
+		errors.Is(err, domain.ErrRejectedByHook), errors.Is(err, domain.ErrPlanNotFound),
+		errors.Is(err, domain.ErrPlanInactive), errors.Is(err, domain.ErrPlanPriceMismatch),
+		errors.Is(err, domain.ErrCouponNotFound), errors.Is(err, domain.ErrCouponExpired),
+		errors.Is(err, domain.ErrCouponRedemptionLimitReached), errors.Is(err, domain.ErrUnsupportedCurrency):
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+type errorJSON struct {
+	Error string `json:"error"`
+}
+
+// writeError answers with a JSON error body, so clients parse every response the same way
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, errorJSON{Error: msg})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	return json.NewEncoder(w).Encode(body)
+}
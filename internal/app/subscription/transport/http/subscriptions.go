@@ -0,0 +1,349 @@
+package http
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/attach_add_on"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/cancel_subscription"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/create_subscription"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/detach_add_on"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/get_subscription"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/preview_cancel"
+)
+
+// IdempotencyKeyHeader carries the key a client retries a create with, so that a retry
+// of a create that went through gets the same subscription back
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// SubscriptionsHandler creates subscriptions at /subscriptions, reads and cancels
+// them at /subscriptions/{id}, and attaches and detaches their add-ons at
+// /subscriptions/{id}/add-ons/{add_on_id}
+type SubscriptionsHandler struct {
+	creator   create_subscription.UseCase
+	canceller cancel_subscription.UseCase
+	getter    get_subscription.UseCase
+	previewer preview_cancel.UseCase
+	attacher  attach_add_on.UseCase
+	detacher  detach_add_on.UseCase
+	logger    *slog.Logger
+}
+
+// NewSubscriptionsHandler creates the subscriptions handler
+func NewSubscriptionsHandler(creator create_subscription.UseCase, canceller cancel_subscription.UseCase, getter get_subscription.UseCase, previewer preview_cancel.UseCase, attacher attach_add_on.UseCase, detacher detach_add_on.UseCase, logger *slog.Logger) *SubscriptionsHandler {
+	return &SubscriptionsHandler{
+		creator:   creator,
+		canceller: canceller,
+		getter:    getter,
+		previewer: previewer,
+		attacher:  attacher,
+		detacher:  detacher,
+		logger:    logger,
+	}
+}
+
+// createSubscriptionRequest is the POST body; a zero trial_days starts the
+// subscription ACTIVE
+type createSubscriptionRequest struct {
+	CustomerID     string `json:"customer_id"`
+	PlanID         string `json:"plan_id"`
+	PriceCents     int64  `json:"price_cents"`
+	TrialDays      int64  `json:"trial_days"`
+	ReferralCode   string `json:"referral_code"`
+	CouponCode     string `json:"coupon_code"`
+	EnsureCustomer bool   `json:"ensure_customer"`
+	CustomerEmail  string `json:"customer_email"`
+	CustomerName   string `json:"customer_name"`
+}
+
+// subscriptionJSON is a subscription as the API returns it; times that don't apply
+// are left out
+type subscriptionJSON struct {
+	ID                 string      `json:"id"`
+	CustomerID         string      `json:"customer_id"`
+	PlanID             string      `json:"plan_id"`
+	PriceCents         int64       `json:"price_cents"`
+	Status             string      `json:"status"`
+	StartDate          time.Time   `json:"start_date"`
+	CurrentPeriodStart time.Time   `json:"current_period_start"`
+	TrialEndDate       *time.Time  `json:"trial_end_date,omitempty"`
+	PausedAt           *time.Time  `json:"paused_at,omitempty"`
+	CancelAt           *time.Time  `json:"cancel_at,omitempty"`
+	CancelledAt        *time.Time  `json:"cancelled_at,omitempty"`
+	Coupon             *couponJSON `json:"coupon,omitempty"`
+}
+
+// couponJSON is the coupon a subscription's charges are discounted by; a zero
+// periods_left discounts every period
+type couponJSON struct {
+	Code           string `json:"code"`
+	PercentOff     int64  `json:"percent_off,omitempty"`
+	AmountOffCents int64  `json:"amount_off_cents,omitempty"`
+	PeriodsLeft    int64  `json:"periods_left"`
+}
+
+// subscriptionViewJSON is a subscription as GET returns it, with what callers would
+// otherwise work out from it as of the request; next_renewal_at is left out for a
+// subscription that won't renew as things stand
+type subscriptionViewJSON struct {
+	subscriptionJSON
+	CurrentPeriodEnd     time.Time  `json:"current_period_end"`
+	DaysRemaining        int64      `json:"days_remaining"`
+	NextRenewalAt        *time.Time `json:"next_renewal_at,omitempty"`
+	ProjectedRefundCents int64      `json:"projected_refund_cents"`
+	ProjectedCreditCents int64      `json:"projected_credit_cents"`
+}
+
+type cancellationJSON struct {
+	SubscriptionID    string    `json:"subscription_id"`
+	RefundAmountCents int64     `json:"refund_amount_cents"`
+	CreditAmountCents int64     `json:"credit_amount_cents"`
+	CancelledAt       time.Time `json:"cancelled_at"`
+}
+
+// cancellationPreviewJSON is what a cancellation would give and when it would take
+// effect, answered by a dry run
+type cancellationPreviewJSON struct {
+	SubscriptionID    string    `json:"subscription_id"`
+	RefundAmountCents int64     `json:"refund_amount_cents"`
+	CreditAmountCents int64     `json:"credit_amount_cents"`
+	EffectiveAt       time.Time `json:"effective_at"`
+}
+
+type scheduledCancellationJSON struct {
+	SubscriptionID string    `json:"subscription_id"`
+	Status         string    `json:"status"`
+	CancelAt       time.Time `json:"cancel_at"`
+}
+
+// ServeHTTP dispatches on the path and method
+func (h *SubscriptionsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/subscriptions" {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		h.create(w, r)
+		return
+	}
+
+	id := strings.TrimPrefix(r.URL.Path, "/subscriptions/")
+	if id, addOnID, ok := strings.Cut(id, "/add-ons/"); ok {
+		h.serveAddOn(w, r, id, addOnID)
+		return
+	}
+	if id == "" || strings.Contains(id, "/") {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		h.get(w, r, id)
+	case http.MethodDelete:
+		h.cancel(w, r, id)
+	default:
+		w.Header().Set("Allow", http.MethodGet+", "+http.MethodDelete)
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// create answers POST /subscriptions with the new subscription
+func (h *SubscriptionsHandler) create(w http.ResponseWriter, r *http.Request) {
+	var body createSubscriptionRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	sub, _, err := h.creator.Execute(r.Context(), create_subscription.Request{
+		CustomerID:     body.CustomerID,
+		PlanID:         body.PlanID,
+		PriceCents:     body.PriceCents,
+		TrialDays:      body.TrialDays,
+		ReferralCode:   body.ReferralCode,
+		CouponCode:     body.CouponCode,
+		EnsureCustomer: body.EnsureCustomer,
+		CustomerEmail:  body.CustomerEmail,
+		CustomerName:   body.CustomerName,
+		IdempotencyKey: r.Header.Get(IdempotencyKeyHeader),
+	})
+	if err != nil {
+		h.fail(w, r, "failed to create subscription", err)
+		return
+	}
+
+	w.Header().Set("Location", "/subscriptions/"+sub.ID())
+	if err := writeJSON(w, http.StatusCreated, toSubscriptionJSON(sub)); err != nil {
+		h.logger.WarnContext(r.Context(), "failed to write subscription", slog.Any("error", err))
+	}
+}
+
+// get answers GET /subscriptions/{id} with the subscription, its days remaining, next
+// renewal and what cancelling it now would refund
+func (h *SubscriptionsHandler) get(w http.ResponseWriter, r *http.Request, id string) {
+	view, err := h.getter.Execute(r.Context(), id)
+	if err != nil {
+		h.fail(w, r, "failed to load subscription", err)
+		return
+	}
+
+	resp := subscriptionViewJSON{
+		subscriptionJSON:     toSubscriptionJSON(view.Subscription),
+		CurrentPeriodEnd:     view.CurrentPeriodEnd,
+		DaysRemaining:        view.DaysRemaining,
+		NextRenewalAt:        optionalTime(view.NextRenewalAt),
+		ProjectedRefundCents: view.ProjectedRefund,
+		ProjectedCreditCents: view.ProjectedCredit,
+	}
+	if err := writeJSON(w, http.StatusOK, resp); err != nil {
+		h.logger.WarnContext(r.Context(), "failed to write subscription", slog.Any("error", err))
+	}
+}
+
+// cancel answers DELETE /subscriptions/{id} with the refund or credit the
+// cancellation gave, recording the ?reason and ?reason_details given. With
+// ?at_period_end=true it schedules the cancellation instead, and with ?dry_run=true it
+// only answers with what either would give.
+func (h *SubscriptionsHandler) cancel(w http.ResponseWriter, r *http.Request, id string) {
+	atPeriodEnd, ok := boolParam(w, r, "at_period_end")
+	if !ok {
+		return
+	}
+	dryRun, ok := boolParam(w, r, "dry_run")
+	if !ok {
+		return
+	}
+	reason := domain.CancellationReason{
+		Code:    domain.CancellationReasonCode(r.URL.Query().Get("reason")),
+		Details: r.URL.Query().Get("reason_details"),
+	}
+	// A dry run answers as the cancellation would, so it rejects the same reasons
+	if err := reason.Validate(); err != nil {
+		h.fail(w, r, "invalid cancellation reason", err)
+		return
+	}
+	switch {
+	case dryRun:
+		h.previewCancellation(w, r, id, atPeriodEnd)
+		return
+	case atPeriodEnd:
+		h.scheduleCancellation(w, r, id, reason)
+		return
+	}
+
+	event, err := h.canceller.Execute(r.Context(), id, reason)
+	if err != nil {
+		h.fail(w, r, "failed to cancel subscription", err)
+		return
+	}
+
+	resp := cancellationJSON{
+		SubscriptionID:    event.SubscriptionID,
+		RefundAmountCents: event.RefundAmount,
+		CreditAmountCents: event.CreditAmount,
+		CancelledAt:       event.CancelledAt,
+	}
+	if err := writeJSON(w, http.StatusOK, resp); err != nil {
+		h.logger.WarnContext(r.Context(), "failed to write cancellation", slog.Any("error", err))
+	}
+}
+
+// previewCancellation answers DELETE /subscriptions/{id}?dry_run=true with what
+// cancelling would refund or credit and when it would take effect, changing nothing
+func (h *SubscriptionsHandler) previewCancellation(w http.ResponseWriter, r *http.Request, id string, atPeriodEnd bool) {
+	preview, err := h.previewer.Execute(r.Context(), preview_cancel.Request{SubscriptionID: id, AtPeriodEnd: atPeriodEnd})
+	if err != nil {
+		h.fail(w, r, "failed to preview cancellation", err)
+		return
+	}
+
+	resp := cancellationPreviewJSON{
+		SubscriptionID:    preview.SubscriptionID,
+		RefundAmountCents: preview.RefundAmount,
+		CreditAmountCents: preview.CreditAmount,
+		EffectiveAt:       preview.EffectiveAt,
+	}
+	if err := writeJSON(w, http.StatusOK, resp); err != nil {
+		h.logger.WarnContext(r.Context(), "failed to write cancellation preview", slog.Any("error", err))
+	}
+}
+
+// boolParam parses the query parameter name, false when it is absent. A value that
+// isn't a boolean is answered with 400 and reported as not ok.
+func boolParam(w http.ResponseWriter, r *http.Request, name string) (value, ok bool) {
+	q := r.URL.Query().Get(name)
+	if q == "" {
+		return false, true
+	}
+	value, err := strconv.ParseBool(q)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, name+" must be true or false")
+		return false, false
+	}
+	return value, true
+}
+
+// scheduleCancellation answers DELETE /subscriptions/{id}?at_period_end=true with
+// when the subscription will be cancelled
+func (h *SubscriptionsHandler) scheduleCancellation(w http.ResponseWriter, r *http.Request, id string, reason domain.CancellationReason) {
+	event, err := h.canceller.CancelAtPeriodEnd(r.Context(), id, reason)
+	if err != nil {
+		h.fail(w, r, "failed to schedule cancellation", err)
+		return
+	}
+
+	resp := scheduledCancellationJSON{
+		SubscriptionID: event.SubscriptionID,
+		Status:         string(domain.StatusPendingCancellation),
+		CancelAt:       event.CancelAt,
+	}
+	if err := writeJSON(w, http.StatusOK, resp); err != nil {
+		h.logger.WarnContext(r.Context(), "failed to write scheduled cancellation", slog.Any("error", err))
+	}
+}
+
+// fail answers with err's status. Errors the caller can act on are returned as is;
+// anything else is logged and answered with msg, so internals don't leak.
+func (h *SubscriptionsHandler) fail(w http.ResponseWriter, r *http.Request, msg string, err error) {
+	status := statusFor(err)
+	if status != http.StatusInternalServerError {
+		writeError(w, status, err.Error())
+		return
+	}
+	h.logger.ErrorContext(r.Context(), msg, slog.Any("error", err))
+	writeError(w, status, msg)
+}
+
+func toSubscriptionJSON(sub *domain.Subscription) subscriptionJSON {
+	view := subscriptionJSON{
+		ID:                 sub.ID(),
+		CustomerID:         sub.CustomerID(),
+		PlanID:             sub.PlanID(),
+		PriceCents:         sub.Price(),
+		Status:             string(sub.Status()),
+		StartDate:          sub.StartDate(),
+		CurrentPeriodStart: sub.CurrentPeriodStart(),
+		TrialEndDate:       optionalTime(sub.TrialEndDate()),
+		PausedAt:           optionalTime(sub.PausedAt()),
+		CancelAt:           optionalTime(sub.CancelAt()),
+		CancelledAt:        optionalTime(sub.CancelledAt()),
+	}
+	if coupon := sub.Coupon(); !coupon.IsZero() {
+		view.Coupon = &couponJSON{Code: coupon.Code, PercentOff: coupon.PercentOff, AmountOffCents: coupon.AmountOff, PeriodsLeft: coupon.PeriodsLeft}
+	}
+	return view
+}
+
+// optionalTime is nil for the zero time, so it is left out of the JSON
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
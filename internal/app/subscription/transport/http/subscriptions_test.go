@@ -0,0 +1,278 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/adapters"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/logging"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/testkit"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/testkit/builders"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/attach_add_on"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/create_subscription"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/detach_add_on"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/get_subscription"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/preview_cancel"
+)
+
+type staticSecrets map[string]string
+
+func (s staticSecrets) Secret(_ context.Context, name string) (string, error) {
+	v, ok := s[name]
+	if !ok {
+		return "", errors.New("not found")
+	}
+	return v, nil
+}
+
+type stubCreator struct {
+	requests []create_subscription.Request
+	err      error
+}
+
+func (s *stubCreator) Execute(_ context.Context, req create_subscription.Request) (*domain.Subscription, *domain.SubscriptionCreatedEvent, error) {
+	s.requests = append(s.requests, req)
+	if s.err != nil {
+		return nil, nil, s.err
+	}
+	sub := builders.NewSubscriptionBuilder().WithID("sub-new").WithCustomerID(req.CustomerID).WithPlan(req.PlanID).WithPrice(req.PriceCents).Build()
+	return sub, &domain.SubscriptionCreatedEvent{SubscriptionID: sub.ID()}, nil
+}
+
+type stubCanceller struct {
+	err error
+}
+
+func (s stubCanceller) Execute(_ context.Context, subscriptionID string, reason domain.CancellationReason) (*domain.SubscriptionCancelledEvent, error) {
+	if err := reason.Validate(); err != nil {
+		return nil, err
+	}
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &domain.SubscriptionCancelledEvent{
+		SubscriptionID: subscriptionID,
+		RefundAmount:   1500,
+		Reason:         reason,
+		CancelledAt:    time.Date(2024, 1, 16, 0, 0, 0, 0, time.UTC),
+	}, nil
+}
+
+func (s stubCanceller) CancelAtPeriodEnd(_ context.Context, subscriptionID string, reason domain.CancellationReason) (*domain.SubscriptionCancellationScheduledEvent, error) {
+	if err := reason.Validate(); err != nil {
+		return nil, err
+	}
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &domain.SubscriptionCancellationScheduledEvent{
+		SubscriptionID: subscriptionID,
+		CancelAt:       time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC),
+	}, nil
+}
+
+// newTestHandler serves subscriptions read, and their add-ons changed, on 11 January,
+// ten days into their first period
+func newTestHandler(creator *stubCreator, canceller stubCanceller) http.Handler {
+	subs := testkit.NewFakeSubscriptions().With(
+		builders.NewSubscriptionBuilder().Build(),
+		builders.NewSubscriptionBuilder().WithID("sub-trial").Trialing(14).Build(),
+	)
+	clock := domain.FixedClock{FixedTime: time.Date(2024, 1, 11, 0, 0, 0, 0, time.UTC)}
+	cycles := adapters.StaticBillingCycle{Cycle: domain.CycleOfDays(30)}
+	getter := get_subscription.NewInteractor(subs, adapters.StaticPricing{}, cycles, adapters.StaticFeatureFlags{}, clock)
+	previewer := preview_cancel.NewInteractor(subs, adapters.StaticPricing{}, cycles, adapters.StaticFeatureFlags{}, clock)
+	bundles := testkit.NewFakeBundles()
+	attacher := attach_add_on.NewInteractor(subs, bundles, testkit.NewFakeRefunds(), testkit.NewFakeRefundOutbox(), adapters.StaticBillingResolver{Client: testkit.NewFakeBillingClient()}, adapters.StaticPricing{}, cycles, clock)
+	detacher := detach_add_on.NewInteractor(subs, bundles, adapters.StaticPricing{}, cycles, clock)
+	return NewHandler(creator, canceller, getter, previewer, attacher, detacher, staticSecrets{TokenSecret: "s3cret"}, logging.Discard())
+}
+
+func do(h http.Handler, method, path, body, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestSubscriptions_Create(t *testing.T) {
+	creator := &stubCreator{}
+	h := newTestHandler(creator, stubCanceller{})
+
+	rec := do(h, http.MethodPost, "/subscriptions", `{"customer_id":"cust-1","plan_id":"plan-pro","price_cents":2900,"trial_days":14,"referral_code":"ABCD1234","coupon_code":"LAUNCH20"}`, "s3cret")
+
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, "/subscriptions/sub-new", rec.Header().Get("Location"))
+	assert.JSONEq(t, `{
+		"id": "sub-new", "customer_id": "cust-1", "plan_id": "plan-pro", "price_cents": 2900, "status": "ACTIVE",
+		"start_date": "2024-01-01T00:00:00Z", "current_period_start": "2024-01-01T00:00:00Z"
+	}`, rec.Body.String())
+	assert.Equal(t, []create_subscription.Request{{CustomerID: "cust-1", PlanID: "plan-pro", PriceCents: 2900, TrialDays: 14, ReferralCode: "ABCD1234", CouponCode: "LAUNCH20"}}, creator.requests)
+}
+
+func TestSubscriptions_CreatePassesIdempotencyKey(t *testing.T) {
+	creator := &stubCreator{}
+	h := newTestHandler(creator, stubCanceller{})
+	req := httptest.NewRequest(http.MethodPost, "/subscriptions", strings.NewReader(`{"customer_id":"cust-1","plan_id":"plan-pro","price_cents":2900}`))
+	req.Header.Set("Authorization", "Bearer s3cret")
+	req.Header.Set(IdempotencyKeyHeader, "order-42")
+	rec := httptest.NewRecorder()
+
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, []create_subscription.Request{{CustomerID: "cust-1", PlanID: "plan-pro", PriceCents: 2900, IdempotencyKey: "order-42"}}, creator.requests)
+}
+
+func TestSubscriptions_Get(t *testing.T) {
+	h := newTestHandler(&stubCreator{}, stubCanceller{})
+
+	rec := do(h, http.MethodGet, "/subscriptions/sub-trial", "", "s3cret")
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{
+		"id": "sub-trial", "customer_id": "cust-456", "plan_id": "plan-789", "price_cents": 3000, "status": "TRIALING",
+		"start_date": "2024-01-01T00:00:00Z", "current_period_start": "2024-01-01T00:00:00Z", "trial_end_date": "2024-01-15T00:00:00Z",
+		"current_period_end": "2024-01-31T00:00:00Z", "days_remaining": 4, "next_renewal_at": "2024-01-15T00:00:00Z",
+		"projected_refund_cents": 0, "projected_credit_cents": 0
+	}`, rec.Body.String())
+
+	rec = do(h, http.MethodGet, "/subscriptions/sub-123", "", "s3cret")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{
+		"id": "sub-123", "customer_id": "cust-456", "plan_id": "plan-789", "price_cents": 3000, "status": "ACTIVE",
+		"start_date": "2024-01-01T00:00:00Z", "current_period_start": "2024-01-01T00:00:00Z",
+		"current_period_end": "2024-01-31T00:00:00Z", "days_remaining": 20, "next_renewal_at": "2024-01-31T00:00:00Z",
+		"projected_refund_cents": 2000, "projected_credit_cents": 0
+	}`, rec.Body.String())
+
+	rec = do(h, http.MethodGet, "/subscriptions/missing", "", "s3cret")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.JSONEq(t, `{"error": "subscription not found"}`, rec.Body.String())
+}
+
+func TestSubscriptions_Cancel(t *testing.T) {
+	h := newTestHandler(&stubCreator{}, stubCanceller{})
+
+	rec := do(h, http.MethodDelete, "/subscriptions/sub-123", "", "s3cret")
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"subscription_id": "sub-123", "refund_amount_cents": 1500, "credit_amount_cents": 0, "cancelled_at": "2024-01-16T00:00:00Z"}`, rec.Body.String())
+}
+
+func TestSubscriptions_CancelWithReason(t *testing.T) {
+	h := newTestHandler(&stubCreator{}, stubCanceller{})
+
+	rec := do(h, http.MethodDelete, "/subscriptions/sub-123?reason=too_expensive&reason_details=Found+a+cheaper+plan", "", "s3cret")
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = do(h, http.MethodDelete, "/subscriptions/sub-123?reason=bored", "", "s3cret")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.JSONEq(t, `{"error": "`+domain.ErrInvalidCancellationReason.Error()+`"}`, rec.Body.String())
+
+	rec = do(h, http.MethodDelete, "/subscriptions/sub-123?at_period_end=true&reason_details=no+code", "", "s3cret")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestSubscriptions_CancelAtPeriodEnd(t *testing.T) {
+	h := newTestHandler(&stubCreator{}, stubCanceller{})
+
+	rec := do(h, http.MethodDelete, "/subscriptions/sub-123?at_period_end=true", "", "s3cret")
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"subscription_id": "sub-123", "status": "PENDING_CANCELLATION", "cancel_at": "2024-01-31T00:00:00Z"}`, rec.Body.String())
+
+	rec = do(h, http.MethodDelete, "/subscriptions/sub-123?at_period_end=false", "", "s3cret")
+	assert.JSONEq(t, `{"subscription_id": "sub-123", "refund_amount_cents": 1500, "credit_amount_cents": 0, "cancelled_at": "2024-01-16T00:00:00Z"}`, rec.Body.String())
+
+	rec = do(h, http.MethodDelete, "/subscriptions/sub-123?at_period_end=soon", "", "s3cret")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	h = newTestHandler(&stubCreator{}, stubCanceller{err: domain.ErrCancellationAlreadyScheduled})
+	assert.Equal(t, http.StatusConflict, do(h, http.MethodDelete, "/subscriptions/sub-123?at_period_end=true", "", "s3cret").Code)
+}
+
+func TestSubscriptions_CancelDryRun(t *testing.T) {
+	h := newTestHandler(&stubCreator{}, stubCanceller{err: errors.New("must not cancel")})
+
+	rec := do(h, http.MethodDelete, "/subscriptions/sub-123?dry_run=true", "", "s3cret")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"subscription_id": "sub-123", "refund_amount_cents": 2000, "credit_amount_cents": 0, "effective_at": "2024-01-11T00:00:00Z"}`, rec.Body.String())
+
+	rec = do(h, http.MethodDelete, "/subscriptions/sub-123?dry_run=true&at_period_end=true", "", "s3cret")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"subscription_id": "sub-123", "refund_amount_cents": 0, "credit_amount_cents": 0, "effective_at": "2024-01-31T00:00:00Z"}`, rec.Body.String())
+
+	assert.Equal(t, http.StatusNotFound, do(h, http.MethodDelete, "/subscriptions/missing?dry_run=true", "", "s3cret").Code)
+	assert.Equal(t, http.StatusBadRequest, do(h, http.MethodDelete, "/subscriptions/sub-123?dry_run=maybe", "", "s3cret").Code)
+
+	rec = do(h, http.MethodDelete, "/subscriptions/sub-123?dry_run=true&reason=bored", "", "s3cret")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.JSONEq(t, `{"error": "`+domain.ErrInvalidCancellationReason.Error()+`"}`, rec.Body.String())
+}
+
+func TestSubscriptions_MapsDomainErrors(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{domain.ErrInvalidPlanID, http.StatusBadRequest},
+		{domain.ErrInvalidTrialDays, http.StatusBadRequest},
+		{domain.ErrInvalidIdempotencyKey, http.StatusBadRequest},
+		{domain.ErrPlanNotFound, http.StatusUnprocessableEntity},
+		{domain.ErrPlanPriceMismatch, http.StatusUnprocessableEntity},
+		{domain.ErrInvalidCouponCode, http.StatusBadRequest},
+		{domain.ErrCouponExpired, http.StatusUnprocessableEntity},
+		{domain.ErrCouponRedemptionLimitReached, http.StatusUnprocessableEntity},
+		{domain.ErrSubscriptionNotFound, http.StatusNotFound},
+		{domain.ErrAlreadyCancelled, http.StatusConflict},
+		{domain.ErrConcurrentModification, http.StatusConflict},
+		{domain.ErrInvalidCustomer, http.StatusUnprocessableEntity},
+		{fmt.Errorf("%w: %w", domain.ErrRejectedByHook, errors.New("crm down")), http.StatusUnprocessableEntity},
+		{errors.New("spanner: session expired"), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.err.Error(), func(t *testing.T) {
+			h := newTestHandler(&stubCreator{err: tt.err}, stubCanceller{err: tt.err})
+
+			assert.Equal(t, tt.want, do(h, http.MethodPost, "/subscriptions", `{}`, "s3cret").Code)
+			assert.Equal(t, tt.want, do(h, http.MethodDelete, "/subscriptions/sub-123", "", "s3cret").Code)
+		})
+	}
+
+	h := newTestHandler(&stubCreator{err: errors.New("spanner: session expired")}, stubCanceller{})
+	assert.JSONEq(t, `{"error": "failed to create subscription"}`, do(h, http.MethodPost, "/subscriptions", `{}`, "s3cret").Body.String())
+}
+
+func TestSubscriptions_RejectsBadRequests(t *testing.T) {
+	creator := &stubCreator{}
+	h := newTestHandler(creator, stubCanceller{})
+
+	assert.Equal(t, http.StatusBadRequest, do(h, http.MethodPost, "/subscriptions", `not json`, "s3cret").Code)
+	assert.Equal(t, http.StatusMethodNotAllowed, do(h, http.MethodGet, "/subscriptions", "", "s3cret").Code)
+	assert.Equal(t, http.StatusMethodNotAllowed, do(h, http.MethodPut, "/subscriptions/sub-123", "", "s3cret").Code)
+	assert.Equal(t, http.StatusNotFound, do(h, http.MethodGet, "/subscriptions/", "", "s3cret").Code)
+	assert.Equal(t, http.StatusNotFound, do(h, http.MethodGet, "/subscriptions/sub-123/refunds", "", "s3cret").Code)
+	assert.Empty(t, creator.requests)
+}
+
+func TestSubscriptions_RequireToken(t *testing.T) {
+	h := newTestHandler(&stubCreator{}, stubCanceller{})
+
+	rec := do(h, http.MethodGet, "/subscriptions/sub-123", "", "wrong")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, "Bearer", rec.Header().Get("WWW-Authenticate"))
+
+	unconfigured := NewHandler(&stubCreator{}, stubCanceller{}, get_subscription.NewInteractor(testkit.NewFakeSubscriptions(), adapters.StaticPricing{}, adapters.StaticBillingCycle{}, adapters.StaticFeatureFlags{}, domain.RealClock{}), nil, nil, nil, staticSecrets{}, logging.Discard())
+	assert.Equal(t, http.StatusServiceUnavailable, do(unconfigured, http.MethodGet, "/subscriptions/sub-123", "", "").Code)
+}
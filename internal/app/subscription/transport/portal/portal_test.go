@@ -0,0 +1,112 @@
+package portal
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/audit"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/logging"
+)
+
+// stubTokens decodes the tokens it was given sessions for
+type stubTokens struct {
+	sessions map[string]*domain.PortalSession
+	err      error
+}
+
+func (s stubTokens) Encode(context.Context, *domain.PortalSession) (string, error) {
+	return "", errors.New("not implemented")
+}
+
+func (s stubTokens) Decode(_ context.Context, token string) (*domain.PortalSession, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	session, ok := s.sessions[token]
+	if !ok {
+		return nil, domain.ErrInvalidPortalToken
+	}
+	return session, nil
+}
+
+var issued = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func newTokens(t *testing.T) stubTokens {
+	session, err := domain.NewPortalSession("ps-1", "cust-1", []domain.PortalScope{domain.PortalScopeCancel}, 5*time.Minute, domain.FixedClock{FixedTime: issued})
+	require.NoError(t, err)
+	return stubTokens{sessions: map[string]*domain.PortalSession{"tok": session}}
+}
+
+func serve(h http.Handler, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/portal/subscriptions/sub-1/cancel", nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestRequireSession(t *testing.T) {
+	var seen *domain.PortalSession
+	var principal audit.Principal
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen, _ = SessionFrom(r.Context())
+		principal, _ = audit.PrincipalFrom(r.Context())
+	})
+	clock := &domain.FixedClock{FixedTime: issued.Add(time.Minute)}
+	tokens := newTokens(t)
+	h := RequireSession(tokens, clock, logging.Discard(), next)
+
+	t.Run("passes the session on", func(t *testing.T) {
+		rec := serve(h, "tok")
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		require.NotNil(t, seen)
+		assert.Equal(t, "cust-1", seen.CustomerID())
+		assert.Equal(t, "portal-session:ps-1", principal.ID)
+	})
+
+	t.Run("rejects missing and unknown tokens", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, serve(h, "").Code)
+		rec := serve(h, "forged")
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.Equal(t, "Bearer", rec.Header().Get("WWW-Authenticate"))
+	})
+
+	t.Run("rejects expired sessions", func(t *testing.T) {
+		clock.FixedTime = issued.Add(5 * time.Minute)
+		defer func() { clock.FixedTime = issued.Add(time.Minute) }()
+
+		rec := serve(h, "tok")
+
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.Contains(t, rec.Body.String(), domain.ErrPortalSessionExpired.Error())
+	})
+
+	t.Run("is unavailable when keys can't be resolved", func(t *testing.T) {
+		failing := RequireSession(stubTokens{err: errors.New("secret manager down")}, clock, logging.Discard(), next)
+
+		assert.Equal(t, http.StatusServiceUnavailable, serve(failing, "tok").Code)
+	})
+}
+
+func TestRequireScope(t *testing.T) {
+	clock := domain.FixedClock{FixedTime: issued}
+	ok := http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})
+
+	cancel := RequireSession(newTokens(t), clock, logging.Discard(), RequireScope(domain.PortalScopeCancel, ok))
+	changePlan := RequireSession(newTokens(t), clock, logging.Discard(), RequireScope(domain.PortalScopeChangePlan, ok))
+
+	assert.Equal(t, http.StatusOK, serve(cancel, "tok").Code)
+	assert.Equal(t, http.StatusForbidden, serve(changePlan, "tok").Code)
+	assert.Equal(t, http.StatusForbidden, serve(RequireScope(domain.PortalScopeCancel, ok), "tok").Code)
+}
@@ -0,0 +1,89 @@
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/complete_charge_authentication"
+)
+
+// AuthenticationHandler completes renewal charges once the billing provider reports
+// whether the customer authenticated them
+type AuthenticationHandler struct {
+	completer complete_charge_authentication.UseCase
+	verifier  SignatureVerifier
+	logger    *slog.Logger
+}
+
+// NewAuthenticationHandler creates the charge authentication webhook handler
+func NewAuthenticationHandler(completer complete_charge_authentication.UseCase, verifier SignatureVerifier, logger *slog.Logger) *AuthenticationHandler {
+	return &AuthenticationHandler{
+		completer: completer,
+		verifier:  verifier,
+		logger:    logger,
+	}
+}
+
+// authenticationNotification is the webhook payload
+type authenticationNotification struct {
+	PaymentID     string `json:"payment_id"`
+	Status        string `json:"status"`
+	FailureReason string `json:"failure_reason"`
+}
+
+// ServeHTTP verifies the signature and records the outcome. Redelivered notifications
+// for authentications already resolved are acknowledged so the provider stops retrying.
+func (h *AuthenticationHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxBodyBytes))
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+	if !h.verifier.Verify(body, r.Header.Get(SignatureHeader)) {
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	var n authenticationNotification
+	if err := json.Unmarshal(body, &n); err != nil || n.PaymentID == "" {
+		http.Error(w, "invalid notification", http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.completer.Execute(r.Context(), complete_charge_authentication.Request{
+		ProviderPaymentID: n.PaymentID,
+		Status:            domain.AuthenticationStatus(strings.ToUpper(n.Status)),
+		FailureReason:     n.FailureReason,
+	})
+	switch {
+	case err == nil:
+		if result.Status == domain.AuthenticationSucceeded && result.Recovered == nil {
+			// The customer paid for a period the subscription no longer owes
+			h.logger.WarnContext(r.Context(), "authenticated charge did not recover subscription; it may need a refund",
+				slog.String("provider_payment_id", n.PaymentID),
+				slog.String("subscription_id", result.SubscriptionID),
+			)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case errors.Is(err, domain.ErrAuthenticationResolved):
+		w.WriteHeader(http.StatusNoContent)
+	case errors.Is(err, domain.ErrAuthenticationNotFound):
+		http.Error(w, "unknown payment", http.StatusNotFound)
+	case errors.Is(err, domain.ErrInvalidAuthenticationStatus):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		h.logger.ErrorContext(r.Context(), "failed to complete charge authentication", slog.String("provider_payment_id", n.PaymentID), slog.Any("error", err))
+		http.Error(w, "internal error", http.StatusInternalServerError)
+	}
+}
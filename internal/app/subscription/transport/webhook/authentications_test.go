@@ -0,0 +1,93 @@
+package webhook
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/adapters"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/complete_charge_authentication"
+)
+
+// MockAuthenticationCompleter is a mock implementation of the complete charge authentication use case
+type MockAuthenticationCompleter struct {
+	mock.Mock
+}
+
+func (m *MockAuthenticationCompleter) Execute(ctx context.Context, req complete_charge_authentication.Request) (*complete_charge_authentication.Result, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*complete_charge_authentication.Result), args.Error(1)
+}
+
+func TestAuthenticationHandler(t *testing.T) {
+	signer, err := adapters.NewHMACSigner([]byte("webhook-secret"))
+	require.NoError(t, err)
+	body := `{"payment_id":"pay-1","status":"succeeded"}`
+	want := complete_charge_authentication.Request{ProviderPaymentID: "pay-1", Status: domain.AuthenticationSucceeded}
+
+	t.Run("completes the charge", func(t *testing.T) {
+		completer := new(MockAuthenticationCompleter)
+		completer.On("Execute", mock.Anything, want).Return(&complete_charge_authentication.Result{
+			SubscriptionID: "sub-123",
+			Status:         domain.AuthenticationSucceeded,
+			Recovered:      &domain.SubscriptionRecoveredEvent{SubscriptionID: "sub-123"},
+		}, nil)
+
+		rec := httptest.NewRecorder()
+		NewAuthenticationHandler(completer, signer, slog.Default()).ServeHTTP(rec, newSignedRequest(t, signer, body))
+
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		completer.AssertExpectations(t)
+	})
+
+	t.Run("acknowledges a resolved authentication", func(t *testing.T) {
+		completer := new(MockAuthenticationCompleter)
+		completer.On("Execute", mock.Anything, want).Return(nil, domain.ErrAuthenticationResolved)
+
+		rec := httptest.NewRecorder()
+		NewAuthenticationHandler(completer, signer, slog.Default()).ServeHTTP(rec, newSignedRequest(t, signer, body))
+
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+	})
+
+	t.Run("rejects an unknown payment", func(t *testing.T) {
+		completer := new(MockAuthenticationCompleter)
+		completer.On("Execute", mock.Anything, want).Return(nil, domain.ErrAuthenticationNotFound)
+
+		rec := httptest.NewRecorder()
+		NewAuthenticationHandler(completer, signer, slog.Default()).ServeHTTP(rec, newSignedRequest(t, signer, body))
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("rejects an invalid status", func(t *testing.T) {
+		completer := new(MockAuthenticationCompleter)
+		completer.On("Execute", mock.Anything, mock.Anything).Return(nil, domain.ErrInvalidAuthenticationStatus)
+
+		rec := httptest.NewRecorder()
+		NewAuthenticationHandler(completer, signer, slog.Default()).ServeHTTP(rec, newSignedRequest(t, signer, `{"payment_id":"pay-1","status":"settled"}`))
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("rejects a bad signature", func(t *testing.T) {
+		completer := new(MockAuthenticationCompleter)
+		req := newSignedRequest(t, signer, body)
+		req.Header.Set(SignatureHeader, "forged")
+
+		rec := httptest.NewRecorder()
+		NewAuthenticationHandler(completer, signer, slog.Default()).ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		completer.AssertNotCalled(t, "Execute", mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,84 @@
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/record_payment_failure"
+)
+
+// PaymentFailureHandler starts dunning when the billing provider reports a failed payment
+type PaymentFailureHandler struct {
+	recorder record_payment_failure.UseCase
+	verifier SignatureVerifier
+	logger   *slog.Logger
+}
+
+// NewPaymentFailureHandler creates the payment failure webhook handler
+func NewPaymentFailureHandler(recorder record_payment_failure.UseCase, verifier SignatureVerifier, logger *slog.Logger) *PaymentFailureHandler {
+	return &PaymentFailureHandler{
+		recorder: recorder,
+		verifier: verifier,
+		logger:   logger,
+	}
+}
+
+// paymentFailedNotification is the webhook payload
+type paymentFailedNotification struct {
+	SubscriptionID string `json:"subscription_id"`
+	FailureReason  string `json:"failure_reason"`
+}
+
+// ServeHTTP verifies the signature and marks the subscription past due. Notifications
+// for subscriptions already in dunning, or no longer active, are acknowledged so the
+// provider stops retrying.
+func (h *PaymentFailureHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxBodyBytes))
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+	if !h.verifier.Verify(body, r.Header.Get(SignatureHeader)) {
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	var n paymentFailedNotification
+	if err := json.Unmarshal(body, &n); err != nil || n.SubscriptionID == "" {
+		http.Error(w, "invalid notification", http.StatusBadRequest)
+		return
+	}
+
+	event, err := h.recorder.Execute(r.Context(), record_payment_failure.Request{
+		SubscriptionID: n.SubscriptionID,
+		FailureReason:  n.FailureReason,
+	})
+	switch {
+	case err == nil:
+		h.logger.WarnContext(r.Context(), "subscription past due",
+			slog.String("subscription_id", event.SubscriptionID),
+			slog.String("customer_id", event.CustomerID),
+			slog.Int64("amount_due", event.AmountDue),
+			slog.String("failure_reason", event.FailureReason),
+			slog.Time("next_payment_retry_at", event.NextPaymentRetryAt),
+		)
+		w.WriteHeader(http.StatusNoContent)
+	case errors.Is(err, domain.ErrAlreadyPastDue), errors.Is(err, domain.ErrNotActive):
+		w.WriteHeader(http.StatusNoContent)
+	case errors.Is(err, domain.ErrSubscriptionNotFound):
+		http.Error(w, "unknown subscription", http.StatusNotFound)
+	default:
+		h.logger.ErrorContext(r.Context(), "failed to record payment failure", slog.String("subscription_id", n.SubscriptionID), slog.Any("error", err))
+		http.Error(w, "internal error", http.StatusInternalServerError)
+	}
+}
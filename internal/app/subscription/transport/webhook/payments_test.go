@@ -0,0 +1,81 @@
+package webhook
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/adapters"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/record_payment_failure"
+)
+
+// MockPaymentFailureRecorder is a mock implementation of the record payment failure use case
+type MockPaymentFailureRecorder struct {
+	mock.Mock
+}
+
+func (m *MockPaymentFailureRecorder) Execute(ctx context.Context, req record_payment_failure.Request) (*domain.SubscriptionPastDueEvent, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.SubscriptionPastDueEvent), args.Error(1)
+}
+
+func TestPaymentFailureHandler(t *testing.T) {
+	signer, err := adapters.NewHMACSigner([]byte("webhook-secret"))
+	require.NoError(t, err)
+	body := `{"subscription_id":"sub-123","failure_reason":"card_declined"}`
+	want := record_payment_failure.Request{SubscriptionID: "sub-123", FailureReason: "card_declined"}
+
+	t.Run("marks the subscription past due", func(t *testing.T) {
+		recorder := new(MockPaymentFailureRecorder)
+		recorder.On("Execute", mock.Anything, want).Return(&domain.SubscriptionPastDueEvent{SubscriptionID: "sub-123"}, nil)
+
+		rec := httptest.NewRecorder()
+		NewPaymentFailureHandler(recorder, signer, slog.Default()).ServeHTTP(rec, newSignedRequest(t, signer, body))
+
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		recorder.AssertExpectations(t)
+	})
+
+	for _, acknowledged := range []error{domain.ErrAlreadyPastDue, domain.ErrNotActive} {
+		t.Run("acknowledges "+acknowledged.Error(), func(t *testing.T) {
+			recorder := new(MockPaymentFailureRecorder)
+			recorder.On("Execute", mock.Anything, want).Return(nil, acknowledged)
+
+			rec := httptest.NewRecorder()
+			NewPaymentFailureHandler(recorder, signer, slog.Default()).ServeHTTP(rec, newSignedRequest(t, signer, body))
+
+			assert.Equal(t, http.StatusNoContent, rec.Code)
+		})
+	}
+
+	t.Run("rejects an unknown subscription", func(t *testing.T) {
+		recorder := new(MockPaymentFailureRecorder)
+		recorder.On("Execute", mock.Anything, want).Return(nil, domain.ErrSubscriptionNotFound)
+
+		rec := httptest.NewRecorder()
+		NewPaymentFailureHandler(recorder, signer, slog.Default()).ServeHTTP(rec, newSignedRequest(t, signer, body))
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("rejects a bad signature", func(t *testing.T) {
+		recorder := new(MockPaymentFailureRecorder)
+		req := newSignedRequest(t, signer, body)
+		req.Header.Set(SignatureHeader, "forged")
+
+		rec := httptest.NewRecorder()
+		NewPaymentFailureHandler(recorder, signer, slog.Default()).ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		recorder.AssertNotCalled(t, "Execute", mock.Anything, mock.Anything)
+	})
+}
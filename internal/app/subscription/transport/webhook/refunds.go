@@ -0,0 +1,90 @@
+// Package webhook receives asynchronous notifications from the billing provider.
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/record_refund_outcome"
+)
+
+// SignatureHeader carries the hex HMAC-SHA256 of the request body
+const SignatureHeader = "X-Billing-Signature"
+
+const maxBodyBytes = 1 << 20
+
+// SignatureVerifier checks a webhook body against its signature
+type SignatureVerifier interface {
+	Verify(payload []byte, signature string) bool
+}
+
+// RefundHandler records refund outcomes pushed by the billing provider
+type RefundHandler struct {
+	recorder record_refund_outcome.UseCase
+	verifier SignatureVerifier
+	logger   *slog.Logger
+}
+
+// NewRefundHandler creates the refund webhook handler
+func NewRefundHandler(recorder record_refund_outcome.UseCase, verifier SignatureVerifier, logger *slog.Logger) *RefundHandler {
+	return &RefundHandler{
+		recorder: recorder,
+		verifier: verifier,
+		logger:   logger,
+	}
+}
+
+// refundNotification is the webhook payload
+type refundNotification struct {
+	RefundID      string `json:"refund_id"`
+	Status        string `json:"status"`
+	FailureReason string `json:"failure_reason"`
+}
+
+// ServeHTTP verifies the signature and records the outcome. Redelivered notifications
+// for refunds that already settled are acknowledged so the provider stops retrying.
+func (h *RefundHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxBodyBytes))
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+	if !h.verifier.Verify(body, r.Header.Get(SignatureHeader)) {
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	var n refundNotification
+	if err := json.Unmarshal(body, &n); err != nil || n.RefundID == "" {
+		http.Error(w, "invalid notification", http.StatusBadRequest)
+		return
+	}
+
+	_, err = h.recorder.Execute(r.Context(), record_refund_outcome.Request{
+		ProviderRefundID: n.RefundID,
+		Status:           domain.RefundStatus(strings.ToUpper(n.Status)),
+		FailureReason:    n.FailureReason,
+	})
+	switch {
+	case err == nil, errors.Is(err, domain.ErrRefundAlreadySettled):
+		w.WriteHeader(http.StatusNoContent)
+	case errors.Is(err, domain.ErrRefundNotFound):
+		http.Error(w, "unknown refund", http.StatusNotFound)
+	case errors.Is(err, domain.ErrInvalidRefundStatus):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		h.logger.ErrorContext(r.Context(), "failed to record refund outcome", slog.String("provider_refund_id", n.RefundID), slog.Any("error", err))
+		http.Error(w, "internal error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,80 @@
+package webhook
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/adapters"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/record_refund_outcome"
+)
+
+// MockRecorder is a mock implementation of the record refund outcome use case
+type MockRecorder struct {
+	mock.Mock
+}
+
+func (m *MockRecorder) Execute(ctx context.Context, req record_refund_outcome.Request) (*record_refund_outcome.Result, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*record_refund_outcome.Result), args.Error(1)
+}
+
+func newSignedRequest(t *testing.T, signer *adapters.HMACSigner, body string) *http.Request {
+	t.Helper()
+	signature, err := signer.Sign([]byte(body))
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/refunds", strings.NewReader(body))
+	req.Header.Set(SignatureHeader, signature)
+	return req
+}
+
+func TestRefundHandler(t *testing.T) {
+	signer, err := adapters.NewHMACSigner([]byte("webhook-secret"))
+	require.NoError(t, err)
+	body := `{"refund_id":"prov-1","status":"succeeded"}`
+	want := record_refund_outcome.Request{ProviderRefundID: "prov-1", Status: domain.RefundSucceeded}
+
+	t.Run("records a signed notification", func(t *testing.T) {
+		recorder := new(MockRecorder)
+		recorder.On("Execute", mock.Anything, want).Return(&record_refund_outcome.Result{}, nil)
+
+		rec := httptest.NewRecorder()
+		NewRefundHandler(recorder, signer, slog.Default()).ServeHTTP(rec, newSignedRequest(t, signer, body))
+
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		recorder.AssertExpectations(t)
+	})
+
+	t.Run("acknowledges redelivery of a settled refund", func(t *testing.T) {
+		recorder := new(MockRecorder)
+		recorder.On("Execute", mock.Anything, want).Return(nil, domain.ErrRefundAlreadySettled)
+
+		rec := httptest.NewRecorder()
+		NewRefundHandler(recorder, signer, slog.Default()).ServeHTTP(rec, newSignedRequest(t, signer, body))
+
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+	})
+
+	t.Run("rejects a bad signature", func(t *testing.T) {
+		recorder := new(MockRecorder)
+		req := httptest.NewRequest(http.MethodPost, "/webhooks/refunds", strings.NewReader(body))
+		req.Header.Set(SignatureHeader, "forged")
+
+		rec := httptest.NewRecorder()
+		NewRefundHandler(recorder, signer, slog.Default()).ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		recorder.AssertNotCalled(t, "Execute", mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,154 @@
+package attach_add_on
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/correlation"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+// refundRetryDelay is how long the refund of a charge that failed to send waits in the
+// refund outbox before the refunds worker sends it again
+const refundRetryDelay = time.Minute
+
+// Request contains the input for attaching an add-on to a subscription
+type Request struct {
+	SubscriptionID string
+	AddOn          domain.AddOnCharge
+}
+
+// Interactor handles the attach add-on use case
+type Interactor struct {
+	repo    contracts.SubscriptionRepository
+	bundles contracts.SubscriptionBundleRepository
+	refunds contracts.RefundRepository
+	outbox  contracts.RefundOutboxRepository
+	billing contracts.BillingResolver
+	pricing contracts.PricingSource
+	cycles  contracts.BillingCycleSource
+	clock   domain.Clock
+}
+
+// NewInteractor creates a new attach add-on interactor
+func NewInteractor(repo contracts.SubscriptionRepository, bundles contracts.SubscriptionBundleRepository, refunds contracts.RefundRepository, outbox contracts.RefundOutboxRepository, billing contracts.BillingResolver, pricing contracts.PricingSource, cycles contracts.BillingCycleSource, clock domain.Clock) *Interactor {
+	return &Interactor{
+		repo:    repo,
+		bundles: bundles,
+		refunds: refunds,
+		outbox:  outbox,
+		billing: billing,
+		pricing: pricing,
+		cycles:  cycles,
+		clock:   clock,
+	}
+}
+
+// Execute attaches an add-on to an active subscription, or changes the quantity and
+// price of one already attached, charging the prorated difference for the rest of the
+// period. Renewals charge it from then on, and cancellation refunds its unused part.
+// The charge is refunded if the attach then fails to commit.
+func (i *Interactor) Execute(ctx context.Context, req Request) (*domain.SubscriptionAddOnsChangedEvent, error) {
+	// 1. Load the subscription and the add-ons it is set up with
+	sub, err := i.repo.FindByID(ctx, req.SubscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	bundle, err := i.bundles.FindBySubscriptionID(ctx, sub.ID())
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. Attach via domain method, which prorates the difference between the
+	// discounted prices over the period of the subscription's plan
+	pricing, err := i.pricing.PricingFor(ctx, sub)
+	if err != nil {
+		return nil, err
+	}
+	cycle, err := i.cycles.CycleFor(ctx, sub)
+	if err != nil {
+		return nil, err
+	}
+	bundle, event, err := sub.AttachAddOn(i.clock, cycle, pricing, bundle, req.AddOn)
+	if err != nil {
+		return nil, err
+	}
+
+	// 3. Charge the difference; nothing is saved unless it succeeds. The key is unique
+	// per period, add-on and version read, so a charge the billing client retries is
+	// taken once.
+	var (
+		billingClient contracts.BillingClient
+		charge        *contracts.ChargeRequest
+	)
+	if event.ProratedAmount > 0 {
+		billingClient, err = i.billing.Resolve(ctx, sub.PlanID(), sub.CustomerID())
+		if err != nil {
+			return nil, err
+		}
+		charge = &contracts.ChargeRequest{
+			CustomerID:     sub.CustomerID(),
+			SubscriptionID: sub.ID(),
+			Amount:         event.ProratedAmount,
+			Currency:       domain.DefaultCurrency,
+			IdempotencyKey: fmt.Sprintf("%s:%d:add-on:%s:%d", sub.ID(), sub.CurrentPeriodStart().Unix(), req.AddOn.ID, sub.Version()),
+		}
+		if err := billingClient.ChargeCustomer(ctx, *charge); err != nil {
+			return nil, err
+		}
+	}
+
+	// 4. Save the bundle with the subscription, whose version check fails the commit
+	// if another change got there first
+	var uow contracts.UnitOfWork
+	uow.SaveChecked(i.repo.Save(ctx, sub))
+	uow.SaveAll(i.bundles.Save(ctx, sub.ID(), bundle))
+	if err := uow.Commit(ctx, i.repo); err != nil {
+		// 5. The customer was charged for an add-on that wasn't saved, and a retry reads
+		// the next version and charges under another key, so give the charge back
+		if charge != nil {
+			return nil, i.refundCharge(ctx, billingClient, sub, *charge, err)
+		}
+		return nil, err
+	}
+
+	return event, nil
+}
+
+// refundCharge refunds the charge of an attach whose commit failed with commitErr,
+// tracking the refund until the provider settles it. A refund the provider doesn't
+// take is queued in the refund outbox for the refunds worker to send. It returns
+// commitErr, joined with any error recording the refund.
+func (i *Interactor) refundCharge(ctx context.Context, billingClient contracts.BillingClient, sub *domain.Subscription, charge contracts.ChargeRequest, commitErr error) error {
+	key := charge.IdempotencyKey + ":refund"
+	providerRefundID, err := billingClient.ProcessRefund(ctx, contracts.RefundRequest{
+		SubscriptionID: sub.ID(),
+		CustomerID:     sub.CustomerID(),
+		Amount:         charge.Amount,
+		Currency:       charge.Currency,
+		Reason:         contracts.RefundReasonAddOnReversal,
+		CorrelationID:  correlation.ID(ctx),
+		IdempotencyKey: key,
+	})
+
+	var uow contracts.UnitOfWork
+	if err != nil {
+		queued := domain.NewQueuedRefund(uuid.New().String(), sub, charge.Amount, charge.Currency, contracts.RefundReasonAddOnReversal, key, correlation.ID(ctx), i.clock)
+		queued.Postpone(i.clock, err.Error(), refundRetryDelay)
+		uow.Save(i.outbox.Save(ctx, queued))
+		if err := uow.Commit(ctx, i.outbox); err != nil {
+			return fmt.Errorf("%w (and queueing the refund of the add-on charge: %w)", commitErr, err)
+		}
+		return commitErr
+	}
+
+	refund := domain.NewPendingRefund(uuid.New().String(), sub.ID(), sub.CustomerID(), charge.Amount, charge.Currency, providerRefundID, i.clock)
+	uow.Save(i.refunds.Save(ctx, refund))
+	if err := uow.Commit(ctx, i.refunds); err != nil {
+		return fmt.Errorf("%w (and tracking the refund of the add-on charge: %w)", commitErr, err)
+	}
+	return commitErr
+}
@@ -0,0 +1,217 @@
+package attach_add_on
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/adapters"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/bus"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/testkit"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/testkit/builders"
+)
+
+var storage = domain.AddOnCharge{ID: "extra-storage", Name: "Extra storage", Quantity: 2, UnitPrice: 500}
+
+// attachOn builds an interactor whose clock reads daysIntoPeriod days into the first
+// 30-day period of the built subscriptions
+func attachOn(subs *testkit.FakeSubscriptions, bundles *testkit.FakeBundles, billing contracts.BillingClient, pricing contracts.PricingSource, daysIntoPeriod int) *Interactor {
+	clock := domain.FixedClock{FixedTime: builders.DefaultStartDate.AddDate(0, 0, daysIntoPeriod)}
+	return NewInteractor(subs, bundles, testkit.NewFakeRefunds(), testkit.NewFakeRefundOutbox(), adapters.StaticBillingResolver{Client: billing}, pricing, adapters.StaticBillingCycle{Cycle: domain.CycleOfDays(30)}, clock)
+}
+
+func TestAttachAddOn_ChargesTheRestOfThePeriod(t *testing.T) {
+	ctx := context.Background()
+	subs := testkit.NewFakeSubscriptions().With(builders.NewSubscriptionBuilder().Build())
+	bundles := testkit.NewFakeBundles()
+	billing := testkit.NewFakeBillingClient()
+	interactor := attachOn(subs, bundles, billing, adapters.StaticPricing{}, 10) // 20 of 30 days remain
+
+	event, err := interactor.Execute(ctx, Request{SubscriptionID: "sub-123", AddOn: storage})
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(666), event.ProratedAmount) // 1000 * 20 / 30
+	assert.Equal(t, "extra-storage", event.AddOnID)
+	assert.Equal(t, []domain.AddOnCharge{storage}, event.AddOns)
+	charges := billing.CallsTo(testkit.OpChargeCustomer)
+	require.Len(t, charges, 1)
+	assert.Equal(t, contracts.ChargeRequest{
+		CustomerID:     "cust-456",
+		SubscriptionID: "sub-123",
+		Amount:         666,
+		Currency:       domain.DefaultCurrency,
+		IdempotencyKey: fmt.Sprintf("sub-123:%d:add-on:extra-storage:0", builders.DefaultStartDate.Unix()),
+	}, charges[0].Charge)
+
+	bundle, err := bundles.FindBySubscriptionID(ctx, "sub-123")
+	require.NoError(t, err)
+	assert.Equal(t, []domain.AddOnCharge{storage}, bundle.AddOns)
+	saved, err := subs.FindByID(ctx, "sub-123")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), saved.Version())
+}
+
+func TestAttachAddOn_ReattachingChargesTheDifference(t *testing.T) {
+	ctx := context.Background()
+	subs := testkit.NewFakeSubscriptions().With(builders.NewSubscriptionBuilder().Build())
+	seats := domain.AddOnCharge{ID: "extra-seats", Name: "Extra seats", Quantity: 1, UnitPrice: 300}
+	bundles := testkit.NewFakeBundles().With("sub-123", domain.SubscriptionBundle{
+		AddOns:   []domain.AddOnCharge{seats, {ID: "extra-storage", Name: "Extra storage", Quantity: 1, UnitPrice: 500}},
+		Metadata: map[string]string{"crm_id": "A-1"},
+	})
+	billing := testkit.NewFakeBillingClient()
+	pricing := adapters.BundlePricing{Bundles: bundles, Base: adapters.StaticPricing{Pricing: domain.Pricing{
+		Discounts: []domain.Discount{{Code: "HALF", PercentOff: 5000}},
+	}}}
+	interactor := attachOn(subs, bundles, billing, pricing, 15)
+
+	event, err := interactor.Execute(ctx, Request{SubscriptionID: "sub-123", AddOn: storage})
+
+	require.NoError(t, err)
+	// One more unit of 500, discounted to 250, for 15 of 30 days
+	assert.Equal(t, int64(125), event.ProratedAmount)
+	bundle, err := bundles.FindBySubscriptionID(ctx, "sub-123")
+	require.NoError(t, err)
+	assert.Equal(t, []domain.AddOnCharge{seats, storage}, bundle.AddOns)
+	assert.Equal(t, map[string]string{"crm_id": "A-1"}, bundle.Metadata)
+}
+
+func TestAttachAddOn_FailedChargeAttachesNothing(t *testing.T) {
+	ctx := context.Background()
+	subs := testkit.NewFakeSubscriptions().With(builders.NewSubscriptionBuilder().Build())
+	bundles := testkit.NewFakeBundles()
+	billing := testkit.NewFakeBillingClient().FailAlways(testkit.OpChargeCustomer, domain.ErrPaymentDeclined)
+	interactor := attachOn(subs, bundles, billing, adapters.StaticPricing{}, 10)
+
+	event, err := interactor.Execute(ctx, Request{SubscriptionID: "sub-123", AddOn: storage})
+
+	assert.ErrorIs(t, err, domain.ErrPaymentDeclined)
+	assert.Nil(t, event)
+	bundle, err := bundles.FindBySubscriptionID(ctx, "sub-123")
+	require.NoError(t, err)
+	assert.True(t, bundle.IsEmpty())
+}
+
+// renewingBilling is a billing client that saves a change to the subscription, as a
+// renewal running alongside would, while the attach's charge is in flight
+type renewingBilling struct {
+	*testkit.FakeBillingClient
+	subs *testkit.FakeSubscriptions
+}
+
+func (b renewingBilling) ChargeCustomer(ctx context.Context, req contracts.ChargeRequest) error {
+	sub, err := b.subs.FindByID(ctx, req.SubscriptionID)
+	if err != nil {
+		return err
+	}
+	if _, _, err := b.subs.Save(ctx, sub.Clone()); err != nil {
+		return err
+	}
+	return b.FakeBillingClient.ChargeCustomer(ctx, req)
+}
+
+func TestAttachAddOn_RefundsTheChargeWhenTheCommitFails(t *testing.T) {
+	ctx := context.Background()
+	subs := testkit.NewFakeSubscriptions().With(builders.NewSubscriptionBuilder().Build())
+	fake := testkit.NewFakeBillingClient()
+	refunds := testkit.NewFakeRefunds()
+	clock := domain.FixedClock{FixedTime: builders.DefaultStartDate.AddDate(0, 0, 10)}
+	interactor := NewInteractor(subs, testkit.NewFakeBundles(), refunds, testkit.NewFakeRefundOutbox(), adapters.StaticBillingResolver{Client: renewingBilling{fake, subs}}, adapters.StaticPricing{}, adapters.StaticBillingCycle{Cycle: domain.CycleOfDays(30)}, clock)
+
+	event, err := interactor.Execute(ctx, Request{SubscriptionID: "sub-123", AddOn: storage})
+
+	assert.ErrorIs(t, err, domain.ErrConcurrentModification)
+	assert.Nil(t, event)
+	charges := fake.CallsTo(testkit.OpChargeCustomer)
+	require.Len(t, charges, 1)
+	sent := fake.CallsTo(testkit.OpProcessRefund)
+	require.Len(t, sent, 1)
+	assert.Equal(t, int64(666), sent[0].Refund.Amount)
+	assert.Equal(t, contracts.RefundReasonAddOnReversal, sent[0].Refund.Reason)
+	assert.Equal(t, charges[0].Charge.IdempotencyKey+":refund", sent[0].Refund.IdempotencyKey)
+	require.Len(t, refunds.Saved(), 1)
+	tracked, err := refunds.FindByID(ctx, refunds.Saved()[0])
+	require.NoError(t, err)
+	assert.Equal(t, domain.RefundPending, tracked.Status())
+	assert.Equal(t, int64(666), tracked.Amount())
+}
+
+func TestAttachAddOn_QueuesTheRefundWhenItFailsToSend(t *testing.T) {
+	ctx := context.Background()
+	subs := testkit.NewFakeSubscriptions().With(builders.NewSubscriptionBuilder().Build())
+	fake := testkit.NewFakeBillingClient().FailAlways(testkit.OpProcessRefund, errors.New("provider unavailable"))
+	outbox := testkit.NewFakeRefundOutbox()
+	clock := domain.FixedClock{FixedTime: builders.DefaultStartDate.AddDate(0, 0, 10)}
+	interactor := NewInteractor(subs, testkit.NewFakeBundles(), testkit.NewFakeRefunds(), outbox, adapters.StaticBillingResolver{Client: renewingBilling{fake, subs}}, adapters.StaticPricing{}, adapters.StaticBillingCycle{Cycle: domain.CycleOfDays(30)}, clock)
+
+	_, err := interactor.Execute(ctx, Request{SubscriptionID: "sub-123", AddOn: storage})
+
+	assert.ErrorIs(t, err, domain.ErrConcurrentModification)
+	queued := outbox.All()
+	require.Len(t, queued, 1)
+	assert.Equal(t, int64(666), queued[0].Amount())
+	assert.Equal(t, contracts.RefundReasonAddOnReversal, queued[0].Reason())
+	assert.Equal(t, fmt.Sprintf("sub-123:%d:add-on:extra-storage:0:refund", builders.DefaultStartDate.Unix()), queued[0].IdempotencyKey())
+	assert.Equal(t, int64(1), queued[0].Attempts())
+}
+
+func TestAttachAddOn_Rejections(t *testing.T) {
+	tests := []struct {
+		name    string
+		sub     *domain.Subscription
+		addOn   domain.AddOnCharge
+		wantErr error
+	}{
+		{"paused", builders.NewSubscriptionBuilder().PausedAt(builders.DefaultStartDate.AddDate(0, 0, 5)).Build(), storage, domain.ErrNotActive},
+		{"cancelled", builders.NewSubscriptionBuilder().Cancelled().Build(), storage, domain.ErrNotActive},
+		{"no quantity", builders.NewSubscriptionBuilder().Build(), domain.AddOnCharge{ID: "extra-storage", Name: "Extra storage", UnitPrice: 500}, domain.ErrInvalidAddOn},
+		{"no name", builders.NewSubscriptionBuilder().Build(), domain.AddOnCharge{ID: "extra-storage", Quantity: 1, UnitPrice: 500}, domain.ErrInvalidAddOn},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			subs := testkit.NewFakeSubscriptions().With(tc.sub)
+			bundles := testkit.NewFakeBundles()
+			billing := testkit.NewFakeBillingClient()
+			interactor := attachOn(subs, bundles, billing, adapters.StaticPricing{}, 10)
+
+			_, err := interactor.Execute(ctx, Request{SubscriptionID: "sub-123", AddOn: tc.addOn})
+
+			assert.ErrorIs(t, err, tc.wantErr)
+			assert.Empty(t, billing.Calls())
+			bundle, err := bundles.FindBySubscriptionID(ctx, "sub-123")
+			require.NoError(t, err)
+			assert.True(t, bundle.IsEmpty())
+		})
+	}
+}
+
+func TestDispatched_RunsTheInteractorThroughTheBus(t *testing.T) {
+	ctx := context.Background()
+	subs := testkit.NewFakeSubscriptions().With(builders.NewSubscriptionBuilder().Build())
+	billing := testkit.NewFakeBillingClient()
+	var dispatched []string
+	b := bus.New(func(next bus.HandlerFunc) bus.HandlerFunc {
+		return func(ctx context.Context, cmd bus.Command) (any, error) {
+			dispatched = append(dispatched, cmd.CommandName())
+			return next(ctx, cmd)
+		}
+	}, bus.Validation())
+	require.NoError(t, b.Register(CommandName, attachOn(subs, testkit.NewFakeBundles(), billing, adapters.StaticPricing{}, 10)))
+	uc := NewDispatched(b)
+
+	event, err := uc.Execute(ctx, Request{SubscriptionID: "sub-123", AddOn: storage})
+	require.NoError(t, err)
+	assert.Equal(t, int64(666), event.ProratedAmount)
+
+	_, err = uc.Execute(ctx, Request{SubscriptionID: "sub-123", AddOn: domain.AddOnCharge{ID: "extra-storage"}})
+	assert.ErrorIs(t, err, domain.ErrInvalidAddOn)
+	assert.Equal(t, []string{CommandName, CommandName}, dispatched)
+	assert.Len(t, billing.CallsTo(testkit.OpChargeCustomer), 1, "the invalid request never reaches the interactor")
+}
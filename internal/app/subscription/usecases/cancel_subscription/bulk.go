@@ -0,0 +1,259 @@
+package cancel_subscription
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/correlation"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/workpool"
+)
+
+// MaxBulkBatchSize keeps a batch's commit within Spanner's limit of 80,000 mutated
+// cells. A cancellation writes a subscription row and either a queued refund or a
+// credit entry, about 30 cells counting their indexes.
+const MaxBulkBatchSize = 2000
+
+// BulkConfig controls how a bulk cancellation is split up
+type BulkConfig struct {
+	BatchSize   int // subscriptions read and committed together, at most MaxBulkBatchSize
+	Concurrency int // batches in flight
+	// Progress, if set, is told of every batch finished
+	Progress func(workpool.Progress)
+}
+
+// DefaultBulkConfig suits account closures of tens of thousands of subscriptions
+func DefaultBulkConfig() BulkConfig {
+	return BulkConfig{BatchSize: 500, Concurrency: 4}
+}
+
+// BulkRequest names the subscriptions to cancel: every subscription of the customer
+// or of the plan that isn't cancelled yet, and the listed ones
+type BulkRequest struct {
+	CustomerID      string
+	PlanID          string // such as a legacy plan being retired
+	SubscriptionIDs []string
+	// Reason is recorded on every subscription cancelled, such as
+	// domain.CancellationReasonPlanRetired for a plan sunset
+	Reason domain.CancellationReason
+}
+
+// BulkFailure is a subscription that could not be cancelled
+type BulkFailure struct {
+	SubscriptionID string
+	Err            error
+}
+
+// BulkResult summarizes a bulk cancellation
+type BulkResult struct {
+	Requested        int // distinct subscriptions
+	Cancelled        int
+	AlreadyCancelled int
+	NotFound         int
+	Failed           []BulkFailure // ordered by subscription ID
+	RefundsQueued    int
+	RefundedAmount   int64 // cents, queued for the refunds worker
+	CreditedAmount   int64 // cents, granted to credit balances
+	Batches          int
+	Elapsed          time.Duration
+}
+
+// Throughput is the number of subscriptions cancelled per second
+func (r BulkResult) Throughput() float64 {
+	if r.Elapsed <= 0 {
+		return 0
+	}
+	return float64(r.Cancelled) / r.Elapsed.Seconds()
+}
+
+// BulkInteractor cancels many subscriptions at once, such as when an account closes or
+// a plan is retired.
+// Cancelling them one at a time costs a read, a commit and a provider call each, so
+// instead each batch is read in one query and committed in one Apply, with several
+// batches in flight. Refunds are not sent to the provider: each is queued in the
+// refund outbox within the batch's commit, and the refunds worker sends it.
+//
+// A subscription is in exactly one batch, so batches in flight never write the same
+// rows. Every batch commits or fails as a whole, and subscriptions that were
+// cancelled before are skipped, so a bulk cancellation that failed or was interrupted
+// can simply be run again.
+type BulkInteractor struct {
+	cancel *Interactor
+	subs   contracts.BulkCancellationRepository
+	outbox contracts.RefundOutboxRepository
+	cfg    BulkConfig
+}
+
+// NewBulkInteractor creates a bulk cancellation interactor that cancels each
+// subscription as cancel would, under the same refund policies and hooks, publishing
+// the same events
+func NewBulkInteractor(cancel *Interactor, subs contracts.BulkCancellationRepository, outbox contracts.RefundOutboxRepository, cfg BulkConfig) *BulkInteractor {
+	if cfg.BatchSize < 1 || cfg.BatchSize > MaxBulkBatchSize {
+		cfg.BatchSize = DefaultBulkConfig().BatchSize
+	}
+	if cfg.Concurrency < 1 {
+		cfg.Concurrency = 1
+	}
+	return &BulkInteractor{cancel: cancel, subs: subs, outbox: outbox, cfg: cfg}
+}
+
+// Execute cancels the requested subscriptions. Failures of single subscriptions and
+// batches are reported in the result rather than stopping the others; an error is
+// returned for an invalid reason, and when the subscriptions can't be listed or ctx
+// ends first.
+func (b *BulkInteractor) Execute(ctx context.Context, req BulkRequest) (*BulkResult, error) {
+	start := b.cancel.clock.Now()
+	if err := req.Reason.Validate(); err != nil {
+		return nil, err
+	}
+
+	// 1. Collect the distinct subscription IDs
+	ids := req.SubscriptionIDs
+	if req.CustomerID != "" {
+		owned, err := b.subs.FindIDsByCustomer(ctx, req.CustomerID)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(owned, ids...)
+	}
+	if req.PlanID != "" {
+		onPlan, err := b.subs.FindIDsByPlan(ctx, req.PlanID)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(onPlan, ids...)
+	}
+	ids = distinct(ids)
+
+	// 2. Cancel batch by batch, Concurrency batches at a time
+	var batches [][]string
+	for from := 0; from < len(ids); from += b.cfg.BatchSize {
+		to := from + b.cfg.BatchSize
+		if to > len(ids) {
+			to = len(ids)
+		}
+		batches = append(batches, ids[from:to])
+	}
+	var (
+		mu     sync.Mutex
+		result = &BulkResult{Requested: len(ids)}
+	)
+	_, err := workpool.Run(ctx, batches, workpool.Config{Concurrency: b.cfg.Concurrency, Progress: b.cfg.Progress}, func(ctx context.Context, batch []string) error {
+		outcome := b.cancelBatch(ctx, batch, req.Reason)
+
+		mu.Lock()
+		defer mu.Unlock()
+		result.add(outcome)
+		return nil
+	})
+
+	sort.Slice(result.Failed, func(i, j int) bool { return result.Failed[i].SubscriptionID < result.Failed[j].SubscriptionID })
+	result.Elapsed = b.cancel.clock.Now().Sub(start)
+	return result, err
+}
+
+// cancelBatch reads, cancels and commits one batch of subscriptions
+func (b *BulkInteractor) cancelBatch(ctx context.Context, ids []string, reason domain.CancellationReason) BulkResult {
+	outcome := BulkResult{Batches: 1}
+	fail := func(id string, err error) {
+		outcome.Failed = append(outcome.Failed, BulkFailure{SubscriptionID: id, Err: err})
+	}
+
+	// 1. Load the batch in one read
+	subs, err := b.subs.FindByIDs(ctx, ids)
+	if err != nil {
+		for _, id := range ids {
+			fail(id, err)
+		}
+		return outcome
+	}
+	outcome.NotFound = len(ids) - len(subs)
+
+	// 2. Cancel each subscription in memory, queueing its refund. Each gets its own
+	// unit of work, so one that fails leaves nothing behind in the batch's.
+	var (
+		batch     contracts.UnitOfWork
+		cancelled []*domain.Subscription
+		events    []*domain.SubscriptionCancelledEvent
+	)
+	for _, sub := range subs {
+		var uow contracts.UnitOfWork
+		event, err := b.cancel.cancel(ctx, sub, reason, &uow)
+		if errors.Is(err, domain.ErrAlreadyCancelled) {
+			outcome.AlreadyCancelled++
+			continue
+		}
+		if err != nil {
+			fail(sub.ID(), err)
+			continue
+		}
+
+		if event.RefundAmount > 0 {
+			queued := domain.NewQueuedRefund(uuid.New().String(), sub, event.RefundAmount, domain.DefaultCurrency, contracts.RefundReasonCancellation, refundIdempotencyKey(sub), correlation.ID(ctx), b.cancel.clock)
+			uow.Save(b.outbox.Save(ctx, queued))
+			if err := uow.Err(); err != nil {
+				fail(sub.ID(), err)
+				continue
+			}
+		}
+
+		batch.Merge(&uow)
+		cancelled = append(cancelled, sub)
+		events = append(events, event)
+	}
+	if batch.Len() == 0 {
+		return outcome
+	}
+
+	// 3. Commit the batch's cancellations and queued refunds together
+	if err := batch.Commit(ctx, b.cancel.repo); err != nil {
+		err = fmt.Errorf("commit batch of %d: %w", len(cancelled), err)
+		for _, sub := range cancelled {
+			fail(sub.ID(), err)
+		}
+		return outcome
+	}
+
+	for n, sub := range cancelled {
+		b.cancel.hooks.AfterCancel(ctx, sub, events[n])
+		b.cancel.events.PublishCancelled(ctx, events[n])
+		outcome.Cancelled++
+		if events[n].RefundAmount > 0 {
+			outcome.RefundsQueued++
+			outcome.RefundedAmount += events[n].RefundAmount
+		}
+		outcome.CreditedAmount += events[n].CreditAmount
+	}
+	return outcome
+}
+
+// add folds a batch's outcome into the result
+func (r *BulkResult) add(batch BulkResult) {
+	r.Cancelled += batch.Cancelled
+	r.AlreadyCancelled += batch.AlreadyCancelled
+	r.NotFound += batch.NotFound
+	r.Failed = append(r.Failed, batch.Failed...)
+	r.RefundsQueued += batch.RefundsQueued
+	r.RefundedAmount += batch.RefundedAmount
+	r.CreditedAmount += batch.CreditedAmount
+	r.Batches += batch.Batches
+}
+
+// distinct drops empty and repeated IDs, keeping the first occurrence of each
+func distinct(ids []string) []string {
+	seen := make(map[string]bool, len(ids))
+	unique := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if id != "" && !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
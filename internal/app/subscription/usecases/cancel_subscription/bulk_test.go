@@ -0,0 +1,278 @@
+package cancel_subscription
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/adapters"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/audit"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/bus"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/logging"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/testkit"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/testkit/builders"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/workpool"
+)
+
+// batchRecorder wraps the fake subscriptions to record each Apply's mutation and
+// version check counts and fail the Applies listed in failApply, counted from 1
+type batchRecorder struct {
+	*testkit.FakeSubscriptions
+
+	mu        sync.Mutex
+	applies   []int
+	checks    []int
+	reads     int
+	failApply map[int]error
+}
+
+func (r *batchRecorder) FindByIDs(ctx context.Context, ids []string) ([]*domain.Subscription, error) {
+	r.mu.Lock()
+	r.reads++
+	r.mu.Unlock()
+	return r.FakeSubscriptions.FindByIDs(ctx, ids)
+}
+
+func (r *batchRecorder) FindByID(ctx context.Context, id string) (*domain.Subscription, error) {
+	return nil, errors.New("bulk cancellation must read whole batches")
+}
+
+func (r *batchRecorder) Apply(ctx context.Context, mutations ...*spanner.Mutation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.applies = append(r.applies, len(mutations))
+	return r.failApply[len(r.applies)]
+}
+
+func (r *batchRecorder) ApplyChecked(ctx context.Context, checks []contracts.VersionCheck, mutations ...*spanner.Mutation) error {
+	r.mu.Lock()
+	r.checks = append(r.checks, len(checks))
+	r.mu.Unlock()
+	return r.Apply(ctx, mutations...)
+}
+
+// bulkFixture holds a bulk interactor cancelling on 2024-01-15, halfway through the
+// period of subscriptions built with the defaults, so each is refunded 1600 cents
+type bulkFixture struct {
+	subs    *batchRecorder
+	outbox  *testkit.FakeRefundOutbox
+	credits *testkit.FakeCreditBalances
+	hooks   *testkit.RecordingHooks
+	events  *testkit.RecordingEvents
+	billing *testkit.FakeBillingClient
+	bulk    *BulkInteractor
+}
+
+func newBulkFixture(flags contracts.FeatureFlags, cfg BulkConfig, subs ...*domain.Subscription) *bulkFixture {
+	f := &bulkFixture{
+		subs:    &batchRecorder{FakeSubscriptions: testkit.NewFakeSubscriptions().With(subs...)},
+		outbox:  testkit.NewFakeRefundOutbox(),
+		credits: testkit.NewFakeCreditBalances(),
+		hooks:   &testkit.RecordingHooks{},
+		events:  &testkit.RecordingEvents{},
+		billing: testkit.NewFakeBillingClient(),
+	}
+	clock := domain.FixedClock{FixedTime: time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)}
+	cancel := NewInteractor(f.subs, testkit.NewFakeRefunds(), f.outbox, f.credits, adapters.StaticBillingResolver{Client: f.billing}, adapters.StaticPricing{}, flags, f.hooks, f.events, clock, adapters.StaticBillingCycle{Cycle: domain.CycleOfDays(30)})
+	f.bulk = NewBulkInteractor(cancel, f.subs, f.outbox, cfg)
+	return f
+}
+
+// activeSubscriptions builds n active subscriptions of one customer, sub-000 onwards
+func activeSubscriptions(n int) []*domain.Subscription {
+	subs := make([]*domain.Subscription, n)
+	for i := range subs {
+		subs[i] = builders.NewSubscriptionBuilder().WithID(fmt.Sprintf("sub-%03d", i)).Build()
+	}
+	return subs
+}
+
+func TestBulkCancel_CommitsEachBatchOnceAndQueuesRefunds(t *testing.T) {
+	f := newBulkFixture(adapters.StaticFeatureFlags{}, BulkConfig{BatchSize: 4, Concurrency: 3}, activeSubscriptions(10)...)
+
+	result, err := f.bulk.Execute(context.Background(), BulkRequest{CustomerID: builders.DefaultCustomerID})
+
+	require.NoError(t, err)
+	assert.Equal(t, 10, result.Requested)
+	assert.Equal(t, 10, result.Cancelled)
+	assert.Equal(t, 3, result.Batches)
+	assert.Equal(t, 3, f.subs.reads, "one read per batch")
+	assert.ElementsMatch(t, []int{8, 8, 4}, f.subs.applies, "one commit per batch, with each cancellation's queued refund")
+	assert.ElementsMatch(t, []int{4, 4, 2}, f.subs.checks, "each cancelled subscription's version is checked")
+	assert.Equal(t, 10, result.RefundsQueued)
+	assert.Equal(t, int64(16000), result.RefundedAmount)
+	assert.Empty(t, f.billing.Calls(), "refunds are sent by the refunds worker")
+
+	queued := f.outbox.All()
+	require.Len(t, queued, 10)
+	assert.Equal(t, "sub-000", queued[0].SubscriptionID())
+	assert.Equal(t, int64(1600), queued[0].Amount())
+	assert.Equal(t, builders.DefaultPlanID, queued[0].PlanID())
+	assert.Equal(t, fmt.Sprintf("sub-000:%d:refund", builders.DefaultStartDate.Unix()), queued[0].IdempotencyKey(), "the key a direct cancellation would send")
+	for _, sub := range f.subs.All() {
+		assert.Equal(t, domain.StatusCancelled, sub.Status(), sub.ID())
+	}
+	assert.Len(t, f.hooks.Calls(), 20, "before and after hooks for every subscription")
+	assert.Len(t, f.events.Cancelled(), 10, "a cancellation event for every subscription")
+}
+
+func TestBulkCancel_ReportsSkippedSubscriptions(t *testing.T) {
+	subs := activeSubscriptions(3)
+	subs = append(subs, builders.NewSubscriptionBuilder().WithID("sub-cancelled").Cancelled().Build())
+	f := newBulkFixture(adapters.StaticFeatureFlags{}, DefaultBulkConfig(), subs...)
+
+	result, err := f.bulk.Execute(context.Background(), BulkRequest{
+		SubscriptionIDs: []string{"sub-000", "sub-001", "sub-001", "sub-cancelled", "sub-missing", ""},
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, 4, result.Requested, "repeated and empty IDs are dropped")
+	assert.Equal(t, 2, result.Cancelled)
+	assert.Equal(t, 1, result.AlreadyCancelled)
+	assert.Equal(t, 1, result.NotFound)
+	assert.Empty(t, result.Failed)
+	assert.Equal(t, domain.StatusActive, subs[2].Status(), "sub-002 wasn't asked for")
+}
+
+func TestBulkCancel_RetiresAPlan(t *testing.T) {
+	subs := activeSubscriptions(5)
+	subs = append(subs,
+		builders.NewSubscriptionBuilder().WithID("sub-other-plan").WithPlan("plan-current").Build(),
+		builders.NewSubscriptionBuilder().WithID("sub-cancelled").Cancelled().Build(),
+	)
+	f := newBulkFixture(adapters.StaticFeatureFlags{}, BulkConfig{BatchSize: 2, Concurrency: 2}, subs...)
+
+	retired := domain.CancellationReason{Code: domain.CancellationReasonPlanRetired}
+	result, err := f.bulk.Execute(context.Background(), BulkRequest{PlanID: builders.DefaultPlanID, SubscriptionIDs: []string{"sub-000"}, Reason: retired})
+
+	require.NoError(t, err)
+	assert.Equal(t, 5, result.Requested, "cancelled subscriptions aren't listed, and sub-000 is counted once")
+	assert.Equal(t, 5, result.Cancelled)
+	assert.Equal(t, 3, result.Batches)
+	require.Len(t, f.events.Cancelled(), 5)
+	for _, event := range f.events.Cancelled() {
+		assert.Equal(t, retired, event.Reason, event.SubscriptionID)
+	}
+	other, err := f.subs.FakeSubscriptions.FindByID(context.Background(), "sub-other-plan")
+	require.NoError(t, err)
+	assert.Equal(t, domain.StatusActive, other.Status())
+}
+
+func TestBulkCancel_FailedBatchIsReportedAndTheOthersCommit(t *testing.T) {
+	f := newBulkFixture(adapters.StaticFeatureFlags{}, BulkConfig{BatchSize: 2, Concurrency: 1}, activeSubscriptions(6)...)
+	unavailable := errors.New("spanner unavailable")
+	f.subs.failApply = map[int]error{2: unavailable}
+
+	result, err := f.bulk.Execute(context.Background(), BulkRequest{CustomerID: builders.DefaultCustomerID})
+
+	require.NoError(t, err)
+	assert.Equal(t, 4, result.Cancelled)
+	require.Len(t, result.Failed, 2)
+	assert.Equal(t, "sub-002", result.Failed[0].SubscriptionID)
+	assert.Equal(t, "sub-003", result.Failed[1].SubscriptionID)
+	assert.ErrorIs(t, result.Failed[0].Err, unavailable)
+	assert.Equal(t, 4, result.RefundsQueued, "the failed batch's refunds aren't counted")
+	assert.Len(t, f.events.Cancelled(), 4, "the failed batch's cancellations aren't published")
+}
+
+func TestBulkCancel_HookVetoFailsOnlyThatSubscription(t *testing.T) {
+	f := newBulkFixture(adapters.StaticFeatureFlags{}, DefaultBulkConfig(), activeSubscriptions(3)...)
+	f.hooks.Veto = errors.New("customer under legal hold")
+
+	result, err := f.bulk.Execute(context.Background(), BulkRequest{CustomerID: builders.DefaultCustomerID})
+
+	require.NoError(t, err)
+	assert.Equal(t, 0, result.Cancelled)
+	require.Len(t, result.Failed, 3)
+	assert.ErrorIs(t, result.Failed[0].Err, domain.ErrRejectedByHook)
+	assert.Empty(t, f.subs.applies, "nothing is committed for a batch with no cancellations")
+	assert.Empty(t, f.outbox.All())
+}
+
+func TestBulkCancel_CreditProrationGrantsCreditsInsteadOfQueueingRefunds(t *testing.T) {
+	flags := adapters.StaticFeatureFlags{FlagCreditProration: {Enabled: true}}
+	f := newBulkFixture(flags, DefaultBulkConfig(), activeSubscriptions(2)...)
+
+	result, err := f.bulk.Execute(context.Background(), BulkRequest{CustomerID: builders.DefaultCustomerID})
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Cancelled)
+	assert.Equal(t, 0, result.RefundsQueued)
+	assert.Equal(t, int64(3200), result.CreditedAmount)
+	assert.Len(t, f.credits.Entries(), 2)
+	assert.Empty(t, f.outbox.All())
+	assert.Equal(t, []int{4}, f.subs.applies)
+}
+
+func TestBulkCancel_RerunSkipsWhatWasCancelled(t *testing.T) {
+	f := newBulkFixture(adapters.StaticFeatureFlags{}, BulkConfig{BatchSize: 2, Concurrency: 2}, activeSubscriptions(5)...)
+	ids := []string{"sub-000", "sub-001", "sub-002", "sub-003", "sub-004"}
+
+	_, err := f.bulk.Execute(context.Background(), BulkRequest{SubscriptionIDs: ids})
+	require.NoError(t, err)
+	again, err := f.bulk.Execute(context.Background(), BulkRequest{SubscriptionIDs: ids})
+
+	require.NoError(t, err)
+	assert.Equal(t, 0, again.Cancelled)
+	assert.Equal(t, 5, again.AlreadyCancelled)
+	assert.Len(t, f.outbox.All(), 5, "no refund is queued twice")
+}
+
+func TestBulkCancel_StopsStartingBatchesWhenCancelled(t *testing.T) {
+	f := newBulkFixture(adapters.StaticFeatureFlags{}, DefaultBulkConfig(), activeSubscriptions(3)...)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	result, err := f.bulk.Execute(ctx, BulkRequest{SubscriptionIDs: []string{"sub-000", "sub-001"}})
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 0, result.Batches)
+}
+
+func TestBulkCancel_ReportsProgressPerBatch(t *testing.T) {
+	var seen []workpool.Progress
+	cfg := BulkConfig{BatchSize: 2, Concurrency: 2, Progress: func(p workpool.Progress) { seen = append(seen, p) }}
+	f := newBulkFixture(adapters.StaticFeatureFlags{}, cfg, activeSubscriptions(5)...)
+
+	_, err := f.bulk.Execute(context.Background(), BulkRequest{CustomerID: builders.DefaultCustomerID})
+
+	require.NoError(t, err)
+	require.Len(t, seen, 3)
+	assert.Equal(t, workpool.Progress{Total: 3, Completed: 3}, seen[2])
+}
+
+func TestBulkCancel_DispatchedThroughTheBusLeavesAnAuditEntry(t *testing.T) {
+	f := newBulkFixture(adapters.StaticFeatureFlags{}, DefaultBulkConfig(), activeSubscriptions(2)...)
+	log := &testkit.RecordingAuditLog{}
+	b := bus.New(bus.Audit(audit.NewRecorder(log, logging.Discard(), nil)))
+	require.NoError(t, b.Register(BulkCommandName, f.bulk))
+	ctx := audit.WithPrincipal(context.Background(), audit.Principal{ID: "ops@example.com", SourceIP: "10.0.0.7"})
+	req := BulkRequest{CustomerID: builders.DefaultCustomerID, Reason: domain.CancellationReason{Code: domain.CancellationReasonPlanRetired}}
+
+	result, err := NewBulkDispatched(b).Execute(ctx, req)
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Cancelled)
+	hash, err := audit.HashPayload(req)
+	require.NoError(t, err)
+	entries := log.Entries()
+	require.Len(t, entries, 1)
+	assert.Equal(t, BulkCommandName, entries[0].Action)
+	assert.Equal(t, "ops@example.com", entries[0].Principal)
+	assert.Equal(t, "10.0.0.7", entries[0].SourceIP)
+	assert.Equal(t, hash, entries[0].PayloadHash)
+	assert.Equal(t, contracts.AuditSucceeded, entries[0].Outcome)
+}
+
+func TestBulkResult_Throughput(t *testing.T) {
+	assert.Equal(t, 250.0, BulkResult{Cancelled: 500, Elapsed: 2 * time.Second}.Throughput())
+	assert.Zero(t, BulkResult{Cancelled: 500}.Throughput())
+}
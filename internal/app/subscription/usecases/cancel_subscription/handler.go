@@ -0,0 +1,136 @@
+package cancel_subscription
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/bus"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+// CommandName identifies the cancel subscription command on the bus
+const CommandName = "subscription.cancel"
+
+var _ bus.Handler = (*Interactor)(nil)
+
+// Request is the bus command for cancelling a subscription. With CancelAtPeriodEnd,
+// the subscription is scheduled to cancel when its period ends instead, and Handle
+// returns the *domain.SubscriptionCancellationScheduledEvent.
+type Request struct {
+	SubscriptionID    string
+	CancelAtPeriodEnd bool
+	Reason            domain.CancellationReason // optional
+}
+
+// CommandName implements bus.Command
+func (r Request) CommandName() string {
+	return CommandName
+}
+
+// Handle executes the use case for a command dispatched through the bus.
+// On a failed refund the event is returned alongside the error, as with Execute.
+func (i *Interactor) Handle(ctx context.Context, cmd bus.Command) (any, error) {
+	req, ok := cmd.(Request)
+	if !ok {
+		return nil, fmt.Errorf("unexpected command type %T", cmd)
+	}
+
+	if req.CancelAtPeriodEnd {
+		scheduled, err := i.CancelAtPeriodEnd(ctx, req.SubscriptionID, req.Reason)
+		if err != nil {
+			return nil, err
+		}
+		return scheduled, nil
+	}
+
+	event, err := i.Execute(ctx, req.SubscriptionID, req.Reason)
+	if event == nil {
+		return nil, err
+	}
+	return event, err
+}
+
+var _ UseCase = (*Dispatched)(nil)
+
+// Dispatched is a UseCase that sends each request through the bus, so the bus
+// middleware sees it
+type Dispatched struct {
+	bus bus.Dispatcher
+}
+
+// NewDispatched creates a use case dispatching through d, on which an *Interactor
+// is registered under CommandName
+func NewDispatched(d bus.Dispatcher) *Dispatched {
+	return &Dispatched{bus: d}
+}
+
+// Execute dispatches a cancellation. On a failed refund the event is returned
+// alongside the error, as with Interactor.Execute.
+func (d *Dispatched) Execute(ctx context.Context, subscriptionID string, reason domain.CancellationReason) (*domain.SubscriptionCancelledEvent, error) {
+	result, err := d.bus.Dispatch(ctx, Request{SubscriptionID: subscriptionID, Reason: reason})
+	event, _ := result.(*domain.SubscriptionCancelledEvent)
+	return event, err
+}
+
+// CancelAtPeriodEnd dispatches a cancellation scheduled for the end of the period
+func (d *Dispatched) CancelAtPeriodEnd(ctx context.Context, subscriptionID string, reason domain.CancellationReason) (*domain.SubscriptionCancellationScheduledEvent, error) {
+	result, err := d.bus.Dispatch(ctx, Request{SubscriptionID: subscriptionID, CancelAtPeriodEnd: true, Reason: reason})
+	if err != nil {
+		return nil, err
+	}
+	scheduled, ok := result.(*domain.SubscriptionCancellationScheduledEvent)
+	if !ok {
+		return nil, fmt.Errorf("unexpected result type %T", result)
+	}
+	return scheduled, nil
+}
+
+// BulkCommandName identifies the bulk cancellation command on the bus
+const BulkCommandName = "subscription.bulk_cancel"
+
+var (
+	_ bus.Handler = (*BulkInteractor)(nil)
+	_ BulkUseCase = (*BulkDispatched)(nil)
+)
+
+// CommandName implements bus.Command
+func (r BulkRequest) CommandName() string {
+	return BulkCommandName
+}
+
+// Privileged implements bus.Privileged: bulk cancellations are audited
+func (r BulkRequest) Privileged() {}
+
+// Handle executes the bulk use case for a command dispatched through the bus.
+// The result of a partly failed run is returned alongside the error, as with Execute.
+func (i *BulkInteractor) Handle(ctx context.Context, cmd bus.Command) (any, error) {
+	req, ok := cmd.(BulkRequest)
+	if !ok {
+		return nil, fmt.Errorf("unexpected command type %T", cmd)
+	}
+
+	result, err := i.Execute(ctx, req)
+	if result == nil {
+		return nil, err
+	}
+	return result, err
+}
+
+// BulkDispatched is a BulkUseCase that sends each request through the bus, so the
+// bus middleware, such as the audit trail, sees it
+type BulkDispatched struct {
+	bus bus.Dispatcher
+}
+
+// NewBulkDispatched creates a bulk use case dispatching through d, on which a
+// *BulkInteractor is registered under BulkCommandName
+func NewBulkDispatched(d bus.Dispatcher) *BulkDispatched {
+	return &BulkDispatched{bus: d}
+}
+
+// Execute dispatches the request
+func (d *BulkDispatched) Execute(ctx context.Context, req BulkRequest) (*BulkResult, error) {
+	result, err := d.bus.Dispatch(ctx, req)
+	bulk, _ := result.(*BulkResult)
+	return bulk, err
+}
@@ -0,0 +1,168 @@
+package cancel_subscription
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/metrics"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/instrument"
+)
+
+// UseCase is the cancel subscription use case as seen by callers
+type UseCase interface {
+	Execute(ctx context.Context, subscriptionID string, reason domain.CancellationReason) (*domain.SubscriptionCancelledEvent, error)
+	CancelAtPeriodEnd(ctx context.Context, subscriptionID string, reason domain.CancellationReason) (*domain.SubscriptionCancellationScheduledEvent, error)
+}
+
+// FinalizeUseCase carries out scheduled cancellations, as seen by the scheduler
+type FinalizeUseCase interface {
+	FinalizeScheduled(ctx context.Context, subscriptionID string) (*domain.SubscriptionCancelledEvent, error)
+}
+
+var (
+	_ UseCase         = (*Interactor)(nil)
+	_ UseCase         = (*Instrumented)(nil)
+	_ FinalizeUseCase = (*Interactor)(nil)
+	_ FinalizeUseCase = (*FinalizeInstrumented)(nil)
+)
+
+// Instrumented decorates a UseCase with logs, metrics and a trace span per execution,
+// counts cancellations and the refunds they issue, and counts each request against
+// the cancel SLO
+type Instrumented struct {
+	next UseCase
+	in   instrument.Instrumentation
+}
+
+// NewInstrumented wraps the given use case with instrumentation
+func NewInstrumented(next UseCase, in instrument.Instrumentation) *Instrumented {
+	return &Instrumented{next: next, in: in}
+}
+
+// Execute runs the wrapped use case
+func (d *Instrumented) Execute(ctx context.Context, subscriptionID string, reason domain.CancellationReason) (*domain.SubscriptionCancelledEvent, error) {
+	attrs := reasonAttrs(subscriptionID, reason)
+
+	event, err := instrument.Run(ctx, d.in, "cancel_subscription", attrs, func(ctx context.Context) (*domain.SubscriptionCancelledEvent, error) {
+		return d.next.Execute(ctx, subscriptionID, reason)
+	})
+	if err == nil {
+		d.in.Metrics.IncCounter(metrics.SubscriptionsCancelled, nil)
+		if event.RefundAmount > 0 {
+			d.in.Metrics.ObserveHistogram(metrics.RefundAmount, float64(event.RefundAmount), map[string]string{"currency": domain.DefaultCurrency})
+		}
+	}
+	instrument.RecordSLI(ctx, d.in, "cancel_subscription", err, domain.ErrSubscriptionNotFound, domain.ErrAlreadyCancelled, domain.ErrRejectedByHook, domain.ErrConcurrentModification, domain.ErrInvalidCancellationReason)
+
+	return event, err
+}
+
+// CancelAtPeriodEnd runs the wrapped use case
+func (d *Instrumented) CancelAtPeriodEnd(ctx context.Context, subscriptionID string, reason domain.CancellationReason) (*domain.SubscriptionCancellationScheduledEvent, error) {
+	attrs := reasonAttrs(subscriptionID, reason)
+
+	event, err := instrument.Run(ctx, d.in, "schedule_cancellation", attrs, func(ctx context.Context) (*domain.SubscriptionCancellationScheduledEvent, error) {
+		return d.next.CancelAtPeriodEnd(ctx, subscriptionID, reason)
+	})
+	instrument.RecordSLI(ctx, d.in, "cancel_subscription", err, domain.ErrSubscriptionNotFound, domain.ErrAlreadyCancelled, domain.ErrCancellationAlreadyScheduled, domain.ErrNotActive, domain.ErrConcurrentModification, domain.ErrInvalidCancellationReason)
+
+	return event, err
+}
+
+// reasonAttrs are the attributes of a cancellation; the reason's free text is left
+// out of logs and traces
+func reasonAttrs(subscriptionID string, reason domain.CancellationReason) map[string]string {
+	attrs := map[string]string{"subscription_id": subscriptionID}
+	if reason.Code != "" {
+		attrs["reason"] = string(reason.Code)
+	}
+	return attrs
+}
+
+// FinalizeInstrumented decorates a FinalizeUseCase with logs, metrics and a trace span
+// per execution, and counts the cancellations carried out
+type FinalizeInstrumented struct {
+	next FinalizeUseCase
+	in   instrument.Instrumentation
+}
+
+// NewFinalizeInstrumented wraps the given use case with instrumentation
+func NewFinalizeInstrumented(next FinalizeUseCase, in instrument.Instrumentation) *FinalizeInstrumented {
+	return &FinalizeInstrumented{next: next, in: in}
+}
+
+// FinalizeScheduled runs the wrapped use case
+func (d *FinalizeInstrumented) FinalizeScheduled(ctx context.Context, subscriptionID string) (*domain.SubscriptionCancelledEvent, error) {
+	attrs := map[string]string{"subscription_id": subscriptionID}
+
+	event, err := instrument.Run(ctx, d.in, "finalize_scheduled_cancellation", attrs, func(ctx context.Context) (*domain.SubscriptionCancelledEvent, error) {
+		return d.next.FinalizeScheduled(ctx, subscriptionID)
+	})
+	if err == nil {
+		d.in.Metrics.IncCounter(metrics.SubscriptionsCancelled, nil)
+	}
+
+	return event, err
+}
+
+// MetricBulkCancellations counts the subscriptions bulk cancellations were asked to
+// cancel, by outcome
+const MetricBulkCancellations = "bulk_cancellations_total"
+
+// BulkUseCase is the bulk cancellation use case as seen by callers
+type BulkUseCase interface {
+	Execute(ctx context.Context, req BulkRequest) (*BulkResult, error)
+}
+
+var (
+	_ BulkUseCase = (*BulkInteractor)(nil)
+	_ BulkUseCase = (*BulkInstrumented)(nil)
+)
+
+// BulkInstrumented decorates a BulkUseCase with logs, metrics and a trace span per
+// execution, and counts each subscription by its outcome
+type BulkInstrumented struct {
+	next BulkUseCase
+	in   instrument.Instrumentation
+}
+
+// NewBulkInstrumented wraps the given use case with instrumentation
+func NewBulkInstrumented(next BulkUseCase, in instrument.Instrumentation) *BulkInstrumented {
+	return &BulkInstrumented{next: next, in: in}
+}
+
+// Execute runs the wrapped use case
+func (d *BulkInstrumented) Execute(ctx context.Context, req BulkRequest) (*BulkResult, error) {
+	attrs := map[string]string{"subscriptions": strconv.Itoa(len(req.SubscriptionIDs))}
+	if req.CustomerID != "" {
+		attrs["customer_id"] = req.CustomerID
+	}
+	if req.PlanID != "" {
+		attrs["plan_id"] = req.PlanID
+	}
+	if req.Reason.Code != "" {
+		attrs["reason"] = string(req.Reason.Code)
+	}
+
+	result, err := instrument.Run(ctx, d.in, "bulk_cancel_subscriptions", attrs, func(ctx context.Context) (*BulkResult, error) {
+		return d.next.Execute(ctx, req)
+	})
+	if result != nil {
+		for outcome, n := range map[string]int{
+			"cancelled":         result.Cancelled,
+			"already_cancelled": result.AlreadyCancelled,
+			"not_found":         result.NotFound,
+			"failed":            len(result.Failed),
+		} {
+			for ; n > 0; n-- {
+				d.in.Metrics.IncCounter(MetricBulkCancellations, map[string]string{"outcome": outcome})
+			}
+		}
+		for n := 0; n < result.Cancelled; n++ {
+			d.in.Metrics.IncCounter(metrics.SubscriptionsCancelled, nil)
+		}
+	}
+
+	return result, err
+}
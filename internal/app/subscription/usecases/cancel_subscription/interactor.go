@@ -2,63 +2,257 @@ package cancel_subscription
 
 import (
 	"context"
+	"fmt"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/correlation"
 	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
 )
 
+const (
+	// FlagHourlyRefunds rolls out refunds measured in unused hours instead of whole days
+	FlagHourlyRefunds = "cancel.hourly_refunds"
+	// FlagCreditProration grants the unused part of the period to the customer's credit
+	// balance, spent by later charges, instead of refunding it
+	FlagCreditProration = "cancel.credit_proration"
+)
+
+// refundRetryDelay is how long a refund the provider didn't take waits in the refund
+// outbox before the refunds worker sends it again, as after its own first failure
+const refundRetryDelay = time.Minute
+
 // Interactor handles the cancel subscription use case
 type Interactor struct {
-	repo             contracts.SubscriptionRepository
-	billingClient    contracts.BillingClient
-	clock            domain.Clock
-	billingCycleDays int64 // Could be from plan, but keeping simple
+	repo    contracts.TransactionalSubscriptionRepository
+	refunds contracts.RefundRepository
+	outbox  contracts.RefundOutboxRepository
+	credits contracts.CreditBalanceRepository
+	billing contracts.BillingResolver
+	pricing contracts.PricingSource
+	flags   contracts.FeatureFlags
+	hooks   contracts.SubscriptionHooks
+	events  contracts.EventPublisher
+	clock   domain.Clock
+	cycles  contracts.BillingCycleSource
 }
 
 // NewInteractor creates a new cancel subscription interactor
-func NewInteractor(repo contracts.SubscriptionRepository, billingClient contracts.BillingClient, clock domain.Clock, billingCycleDays int64) *Interactor {
+func NewInteractor(repo contracts.TransactionalSubscriptionRepository, refunds contracts.RefundRepository, outbox contracts.RefundOutboxRepository, credits contracts.CreditBalanceRepository, billing contracts.BillingResolver, pricing contracts.PricingSource, flags contracts.FeatureFlags, hooks contracts.SubscriptionHooks, events contracts.EventPublisher, clock domain.Clock, cycles contracts.BillingCycleSource) *Interactor {
 	return &Interactor{
-		repo:             repo,
-		billingClient:    billingClient,
-		clock:            clock,
-		billingCycleDays: billingCycleDays,
+		repo:    repo,
+		refunds: refunds,
+		outbox:  outbox,
+		credits: credits,
+		billing: billing,
+		pricing: pricing,
+		flags:   flags,
+		hooks:   hooks,
+		events:  events,
+		clock:   clock,
+		cycles:  cycles,
 	}
 }
 
-// Execute cancels a subscription
-func (i *Interactor) Execute(ctx context.Context, subscriptionID string) (*domain.SubscriptionCancelledEvent, error) {
-	// 1. Load subscription
-	sub, err := i.repo.FindByID(ctx, subscriptionID)
+// Execute cancels a subscription, recording why; a zero reason records none
+func (i *Interactor) Execute(ctx context.Context, subscriptionID string, reason domain.CancellationReason) (*domain.SubscriptionCancelledEvent, error) {
+	return i.execute(ctx, subscriptionID, reason, nil)
+}
+
+// FinalizeScheduled carries out the cancellation a subscription was scheduled for
+// with CancelAtPeriodEnd, failing with domain.ErrCancellationNotDue before its
+// cancel_at. The period it was paid for is over by then, so nothing is refunded, and
+// the reason is the one given when it was scheduled.
+func (i *Interactor) FinalizeScheduled(ctx context.Context, subscriptionID string) (*domain.SubscriptionCancelledEvent, error) {
+	return i.execute(ctx, subscriptionID, domain.CancellationReason{}, func(sub *domain.Subscription) error {
+		return sub.CancellationDue(i.clock)
+	})
+}
+
+// CancelAtPeriodEnd schedules a subscription to cancel when its current period ends,
+// marking it PENDING_CANCELLATION until FinalizeScheduled cancels it. Nothing is
+// refunded or announced yet, and the hooks run when the cancellation is carried out,
+// which keeps the reason given now.
+func (i *Interactor) CancelAtPeriodEnd(ctx context.Context, subscriptionID string, reason domain.CancellationReason) (*domain.SubscriptionCancellationScheduledEvent, error) {
+	var event *domain.SubscriptionCancellationScheduledEvent
+	err := i.repo.RunInTransaction(ctx, func(ctx context.Context, tx contracts.SubscriptionTransaction) error {
+		// 1. Load subscription
+		sub, err := tx.FindByID(ctx, subscriptionID)
+		if err != nil {
+			return err
+		}
+
+		// 2. Schedule via domain method, at the end of the period of the subscription's plan
+		cycle, err := i.cycles.CycleFor(ctx, sub)
+		if err != nil {
+			return err
+		}
+		event, err = sub.ScheduleCancellation(i.clock, cycle, reason)
+		if err != nil {
+			return err
+		}
+
+		// 3. Save it in the same transaction, so a concurrent cancellation can't be undone
+		var uow contracts.UnitOfWork
+		uow.SaveChecked(i.repo.Save(ctx, sub))
+		return uow.Commit(ctx, tx)
+	})
 	if err != nil {
 		return nil, err
 	}
+	return event, nil
+}
 
-	// 2. Cancel via domain method (returns event)
-	event, err := sub.Cancel(i.clock, i.billingCycleDays)
+// execute cancels a subscription. When due is set, it checks the subscription as
+// loaded first, and an error from it cancels nothing.
+func (i *Interactor) execute(ctx context.Context, subscriptionID string, reason domain.CancellationReason, due func(*domain.Subscription) error) (*domain.SubscriptionCancelledEvent, error) {
+	// 1. Load, cancel and commit in one transaction, so a concurrent cancellation
+	// can't also find the subscription active and refund it again. The transaction
+	// runs again if it is aborted, so everything it does is redone from the load.
+	var (
+		sub   *domain.Subscription
+		event *domain.SubscriptionCancelledEvent
+	)
+	err := i.repo.RunInTransaction(ctx, func(ctx context.Context, tx contracts.SubscriptionTransaction) error {
+		var err error
+		sub, err = tx.FindByID(ctx, subscriptionID)
+		if err != nil {
+			return err
+		}
+		if due != nil {
+			if err := due(sub); err != nil {
+				return err
+			}
+		}
+
+		// 2. Cancel under the customer's refund policy, with the writes that go with it
+		var uow contracts.UnitOfWork
+		event, err = i.cancel(ctx, sub, reason, &uow)
+		if err != nil {
+			return err
+		}
+		return uow.Commit(ctx, tx)
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	// 3. Get mutation for saving updated subscription
-	mutation, err := i.repo.Save(ctx, sub)
+	// 3. Announce the committed cancellation
+	i.hooks.AfterCancel(ctx, sub, event)
+	i.events.PublishCancelled(ctx, event)
+
+	// 4. Process refund (after successful save); the key is stable per subscription
+	// period so the billing API deduplicates a refund sent more than once
+	// Note: See ANSWERS.md Q1 for discussion on where this should be
+	if event.RefundAmount > 0 {
+		providerRefundID, err := i.sendRefund(ctx, sub, event)
+		if err != nil {
+			// 5. The subscription is already cancelled, so queue the refund with the
+			// failure for the refunds worker to retry, backing off, with the same key.
+			// See ANSWERS.md Q2 for handling strategy
+			return event, i.queueRefund(ctx, sub, event, err)
+		}
+
+		// 6. Track the accepted refund until the provider settles it
+		refund := domain.NewPendingRefund(uuid.New().String(), sub.ID(), sub.CustomerID(), event.RefundAmount, domain.DefaultCurrency, providerRefundID, i.clock)
+		var uow contracts.UnitOfWork
+		uow.Save(i.refunds.Save(ctx, refund))
+		if err := uow.Commit(ctx, i.refunds); err != nil {
+			return event, err
+		}
+	}
+
+	return event, nil
+}
+
+// sendRefund asks the provider that billed sub for the cancellation's refund and
+// returns its refund ID
+func (i *Interactor) sendRefund(ctx context.Context, sub *domain.Subscription, event *domain.SubscriptionCancelledEvent) (string, error) {
+	billingClient, err := i.billing.Resolve(ctx, sub.PlanID(), sub.CustomerID())
+	if err != nil {
+		return "", err
+	}
+	return billingClient.ProcessRefund(ctx, contracts.RefundRequest{
+		SubscriptionID: sub.ID(),
+		CustomerID:     sub.CustomerID(),
+		Amount:         event.RefundAmount,
+		Currency:       domain.DefaultCurrency,
+		Reason:         contracts.RefundReasonCancellation,
+		CorrelationID:  correlation.ID(ctx),
+		IdempotencyKey: refundIdempotencyKey(sub),
+	})
+}
+
+// queueRefund saves the cancellation's refund in the outbox after sending it failed
+// with sendErr. It returns nil once the refund is queued, and both errors otherwise.
+func (i *Interactor) queueRefund(ctx context.Context, sub *domain.Subscription, event *domain.SubscriptionCancelledEvent, sendErr error) error {
+	queued := domain.NewQueuedRefund(uuid.New().String(), sub, event.RefundAmount, domain.DefaultCurrency, contracts.RefundReasonCancellation, refundIdempotencyKey(sub), correlation.ID(ctx), i.clock)
+	queued.Postpone(i.clock, sendErr.Error(), refundRetryDelay)
+	var uow contracts.UnitOfWork
+	uow.Save(i.outbox.Save(ctx, queued))
+	if err := uow.Commit(ctx, i.outbox); err != nil {
+		return fmt.Errorf("%w (and queueing the refund: %w)", sendErr, err)
+	}
+	return nil
+}
+
+// cancel cancels sub in memory for reason, adds the writes that save it to uow and
+// returns the event. Nothing is written and no refund is sent, so a single
+// cancellation and a bulk batch share it.
+func (i *Interactor) cancel(ctx context.Context, sub *domain.Subscription, reason domain.CancellationReason, uow *contracts.UnitOfWork) (*domain.SubscriptionCancelledEvent, error) {
+	// Cancel via domain method (returns event), under the refund policy rolled out to
+	// this customer; the refund is of the discounted price the period was charged,
+	// prorated over the period of the subscription's plan
+	pricing, err := i.pricing.PricingFor(ctx, sub)
+	if err != nil {
+		return nil, err
+	}
+	cycle, err := i.cycles.CycleFor(ctx, sub)
+	if err != nil {
+		return nil, err
+	}
+	policy, credit := RefundTerms(ctx, i.flags, sub)
+	event, err := sub.CancelWithPolicy(i.clock, cycle, policy, pricing, reason)
 	if err != nil {
 		return nil, err
 	}
 
-	// 4. Apply the mutation
-	if err := i.repo.Apply(ctx, mutation); err != nil {
+	// Save the updated subscription
+	uow.SaveChecked(i.repo.Save(ctx, sub))
+
+	// Credit the unused part instead of refunding it, if rolled out to this customer;
+	// the entry is saved with the cancellation, so no provider call is made at all
+	if event.RefundAmount > 0 && credit {
+		event.CreditAmount, event.RefundAmount = event.RefundAmount, 0
+		entry := domain.NewCreditEntry(uuid.New().String(), sub.CustomerID(), event.CreditAmount, domain.DefaultCurrency, domain.CreditSourceCancellation, sub.ID(), i.clock)
+		uow.Save(i.credits.Save(ctx, entry))
+	}
+	if err := uow.Err(); err != nil {
 		return nil, err
 	}
 
-	// 5. Process refund (after successful save)
-	// Note: See ANSWERS.md Q1 for discussion on where this should be
-	if event.RefundAmount > 0 {
-		if err := i.billingClient.ProcessRefund(ctx, event.RefundAmount); err != nil {
-			// Log error but don't fail - subscription is already cancelled
-			// See ANSWERS.md Q2 for handling strategy
-			return event, err // Return event but also error for caller to handle
-		}
+	// Let the deployment's hooks veto the cancellation before it is saved
+	if err := i.hooks.BeforeCancel(ctx, sub, event); err != nil {
+		return nil, fmt.Errorf("%w: %w", domain.ErrRejectedByHook, err)
 	}
 
 	return event, nil
 }
+
+// RefundTerms is how cancelling sub is settled, as rolled out to its customer: the
+// policy the unused part of the period is measured under, and whether it is granted to
+// the credit balance instead of refunded
+func RefundTerms(ctx context.Context, flags contracts.FeatureFlags, sub *domain.Subscription) (domain.RefundPolicy, bool) {
+	target := contracts.FlagTarget{CustomerID: sub.CustomerID(), SubscriptionID: sub.ID(), PlanID: sub.PlanID()}
+	policy := domain.RefundUnusedDays
+	if flags.Enabled(ctx, FlagHourlyRefunds, target) {
+		policy = domain.RefundUnusedHours
+	}
+	return policy, flags.Enabled(ctx, FlagCreditProration, target)
+}
+
+// refundIdempotencyKey derives the refund key from the subscription ID and billing period
+func refundIdempotencyKey(sub *domain.Subscription) string {
+	return fmt.Sprintf("%s:%d:refund", sub.ID(), sub.CurrentPeriodStart().Unix())
+}
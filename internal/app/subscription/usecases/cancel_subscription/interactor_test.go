@@ -2,40 +2,60 @@ package cancel_subscription
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
 	"testing"
 	"time"
 
 	"cloud.google.com/go/spanner"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/adapters"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
 	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/testkit"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/testkit/builders"
 )
 
-// MockRepository is a mock implementation of SubscriptionRepository
-type MockRepository struct {
+// MockRefundRepository is a mock implementation of RefundRepository
+type MockRefundRepository struct {
 	mock.Mock
 }
 
-func (m *MockRepository) Save(ctx context.Context, sub *domain.Subscription) (*spanner.Mutation, error) {
-	args := m.Called(ctx, sub)
+func (m *MockRefundRepository) Save(ctx context.Context, refund *domain.Refund) (*spanner.Mutation, error) {
+	args := m.Called(ctx, refund)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*spanner.Mutation), args.Error(1)
 }
 
-func (m *MockRepository) Apply(ctx context.Context, mutations ...*spanner.Mutation) error {
-	// Convert variadic to slice for mock
+func (m *MockRefundRepository) Apply(ctx context.Context, mutations ...*spanner.Mutation) error {
 	args := m.Called(ctx, mutations)
 	return args.Error(0)
 }
 
-func (m *MockRepository) FindByID(ctx context.Context, id string) (*domain.Subscription, error) {
+func (m *MockRefundRepository) FindByID(ctx context.Context, id string) (*domain.Refund, error) {
 	args := m.Called(ctx, id)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
-	return args.Get(0).(*domain.Subscription), args.Error(1)
+	return args.Get(0).(*domain.Refund), args.Error(1)
+}
+
+func (m *MockRefundRepository) FindByProviderRefundID(ctx context.Context, providerRefundID string) (*domain.Refund, error) {
+	args := m.Called(ctx, providerRefundID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Refund), args.Error(1)
+}
+
+func (m *MockRefundRepository) FindPending(ctx context.Context, requestedBefore time.Time, limit int) ([]*domain.Refund, error) {
+	args := m.Called(ctx, requestedBefore, limit)
+	return args.Get(0).([]*domain.Refund), args.Error(1)
 }
 
 // MockBillingClient is a mock implementation of BillingClient
@@ -48,32 +68,53 @@ func (m *MockBillingClient) ValidateCustomer(ctx context.Context, customerID str
 	return args.Error(0)
 }
 
-func (m *MockBillingClient) ProcessRefund(ctx context.Context, amount int64) error {
-	args := m.Called(ctx, amount)
+func (m *MockBillingClient) CreateCustomer(ctx context.Context, req contracts.CreateCustomerRequest) error {
+	args := m.Called(ctx, req)
+	return args.Error(0)
+}
+
+func (m *MockBillingClient) ProcessRefund(ctx context.Context, req contracts.RefundRequest) (string, error) {
+	args := m.Called(ctx, req)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockBillingClient) GetRefundStatus(ctx context.Context, providerRefundID string) (contracts.RefundOutcome, error) {
+	args := m.Called(ctx, providerRefundID)
+	return args.Get(0).(contracts.RefundOutcome), args.Error(1)
+}
+
+func (m *MockBillingClient) ChargeCustomer(ctx context.Context, req contracts.ChargeRequest) error {
+	args := m.Called(ctx, req)
 	return args.Error(0)
 }
 
+func (m *MockBillingClient) GetPaymentMethodStatus(ctx context.Context, customerID string) (domain.PaymentMethod, error) {
+	args := m.Called(ctx, customerID)
+	return args.Get(0).(domain.PaymentMethod), args.Error(1)
+}
+
+// refundOf matches a cancellation refund for amount
+func refundOf(amount int64) any {
+	return mock.MatchedBy(func(req contracts.RefundRequest) bool {
+		return req.Amount == amount && req.Reason == contracts.RefundReasonCancellation
+	})
+}
+
 func TestCancelSubscription_Success(t *testing.T) {
 	// Setup
 	ctx := context.Background()
-	startDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	startDate := builders.DefaultStartDate
 	cancelDate := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC) // 14 days later
 
 	clock := domain.FixedClock{FixedTime: cancelDate}
 
-	sub := domain.ReconstructFromPersistence(
-		"sub-123",
-		"cust-456",
-		"plan-789",
-		3000, // $30.00 in cents
-		domain.StatusActive,
-		startDate,
-	)
+	sub := builders.NewSubscriptionBuilder().WithPrice(3000).Build() // $30.00
 
-	mockRepo := new(MockRepository)
+	mockRepo := new(testkit.MockSubscriptions)
+	mockRefunds := new(MockRefundRepository)
 	mockBilling := new(MockBillingClient)
 
-	interactor := NewInteractor(mockRepo, mockBilling, clock, 30)
+	interactor := NewInteractor(mockRepo, mockRefunds, testkit.NewFakeRefundOutbox(), testkit.NewFakeCreditBalances(), adapters.StaticBillingResolver{Client: mockBilling}, adapters.StaticPricing{}, adapters.StaticFeatureFlags{}, adapters.HookChain{}, adapters.NoopEventPublisher{}, clock, adapters.StaticBillingCycle{Cycle: domain.CycleOfDays(30)})
 
 	// Expectations
 	mockRepo.On("FindByID", ctx, "sub-123").Return(sub, nil)
@@ -85,10 +126,23 @@ func TestCancelSubscription_Success(t *testing.T) {
 	mockRepo.On("Apply", ctx, mock.Anything).Return(nil)
 
 	// Expected refund: 3000 * (30 - 14) / 30 = 3000 * 16 / 30 = 1600 cents
-	mockBilling.On("ProcessRefund", ctx, int64(1600)).Return(nil)
+	// Keyed by subscription and period so a retried cancel can't refund twice
+	mockBilling.On("ProcessRefund", ctx, contracts.RefundRequest{
+		SubscriptionID: "sub-123",
+		CustomerID:     "cust-456",
+		Amount:         1600,
+		Currency:       domain.DefaultCurrency,
+		Reason:         contracts.RefundReasonCancellation,
+		IdempotencyKey: fmt.Sprintf("sub-123:%d:refund", startDate.Unix()),
+	}).Return("refund-abc", nil)
+	// The accepted refund is tracked as pending until the provider settles it
+	mockRefunds.On("Save", ctx, mock.MatchedBy(func(r *domain.Refund) bool {
+		return r.ProviderRefundID() == "refund-abc" && r.Status() == domain.RefundPending && r.Amount() == 1600
+	})).Return(&spanner.Mutation{}, nil)
+	mockRefunds.On("Apply", ctx, mock.Anything).Return(nil)
 
 	// Execute
-	event, err := interactor.Execute(ctx, "sub-123")
+	event, err := interactor.Execute(ctx, "sub-123", domain.CancellationReason{})
 
 	// Assert
 	assert.NoError(t, err)
@@ -96,28 +150,21 @@ func TestCancelSubscription_Success(t *testing.T) {
 	assert.Equal(t, "sub-123", event.SubscriptionID)
 	assert.Equal(t, int64(1600), event.RefundAmount)
 	mockRepo.AssertExpectations(t)
+	mockRefunds.AssertExpectations(t)
 	mockBilling.AssertExpectations(t)
 }
 
 func TestCancelSubscription_AlreadyCancelled(t *testing.T) {
 	// Setup
 	ctx := context.Background()
-	startDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
 
-	sub := domain.ReconstructFromPersistence(
-		"sub-123",
-		"cust-456",
-		"plan-789",
-		3000,
-		domain.StatusCancelled, // Already cancelled
-		startDate,
-	)
+	sub := builders.NewSubscriptionBuilder().Cancelled().Build()
 
-	mockRepo := new(MockRepository)
+	mockRepo := new(testkit.MockSubscriptions)
 	mockBilling := new(MockBillingClient)
 	clock := domain.FixedClock{FixedTime: time.Now()}
 
-	interactor := NewInteractor(mockRepo, mockBilling, clock, 30)
+	interactor := NewInteractor(mockRepo, new(MockRefundRepository), testkit.NewFakeRefundOutbox(), testkit.NewFakeCreditBalances(), adapters.StaticBillingResolver{Client: mockBilling}, adapters.StaticPricing{}, adapters.StaticFeatureFlags{}, adapters.HookChain{}, adapters.NoopEventPublisher{}, clock, adapters.StaticBillingCycle{Cycle: domain.CycleOfDays(30)})
 
 	// Expectations
 	mockRepo.On("FindByID", ctx, "sub-123").Return(sub, nil)
@@ -126,7 +173,7 @@ func TestCancelSubscription_AlreadyCancelled(t *testing.T) {
 	// Refund should NOT be called
 
 	// Execute
-	event, err := interactor.Execute(ctx, "sub-123")
+	event, err := interactor.Execute(ctx, "sub-123", domain.CancellationReason{})
 
 	// Assert
 	assert.Error(t, err)
@@ -137,6 +184,29 @@ func TestCancelSubscription_AlreadyCancelled(t *testing.T) {
 	mockBilling.AssertNotCalled(t, "ProcessRefund", ctx, mock.Anything)
 }
 
+func TestCancelSubscription_TrialIsNotRefunded(t *testing.T) {
+	ctx := context.Background()
+	startDate := builders.DefaultStartDate
+	sub, _, err := domain.NewTrialSubscription("sub-123", "cust-456", "plan-789", 3000, 14, domain.CycleOfDays(30), domain.FixedClock{FixedTime: startDate})
+	require.NoError(t, err)
+
+	mockRepo := new(testkit.MockSubscriptions)
+	mockBilling := new(MockBillingClient)
+	clock := domain.FixedClock{FixedTime: startDate.AddDate(0, 0, 3)}
+	interactor := NewInteractor(mockRepo, new(MockRefundRepository), testkit.NewFakeRefundOutbox(), testkit.NewFakeCreditBalances(), adapters.StaticBillingResolver{Client: mockBilling}, adapters.StaticPricing{}, adapters.StaticFeatureFlags{}, adapters.HookChain{}, adapters.NoopEventPublisher{}, clock, adapters.StaticBillingCycle{Cycle: domain.CycleOfDays(30)})
+
+	mockRepo.On("FindByID", ctx, "sub-123").Return(sub, nil)
+	mockRepo.On("Save", ctx, sub).Return(&spanner.Mutation{}, nil)
+	mockRepo.On("Apply", ctx, mock.Anything).Return(nil)
+
+	event, err := interactor.Execute(ctx, "sub-123", domain.CancellationReason{})
+
+	require.NoError(t, err)
+	assert.Zero(t, event.RefundAmount)
+	assert.Equal(t, domain.StatusCancelled, sub.Status())
+	mockBilling.AssertNotCalled(t, "ProcessRefund", mock.Anything, mock.Anything)
+}
+
 func TestCancelSubscription_RefundCalculationCorrectness(t *testing.T) {
 	testCases := []struct {
 		name           string
@@ -171,36 +241,492 @@ func TestCancelSubscription_RefundCalculationCorrectness(t *testing.T) {
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			ctx := context.Background()
-			startDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+			startDate := builders.DefaultStartDate
 			cancelDate := startDate.AddDate(0, 0, tc.daysElapsed)
 
 			clock := domain.FixedClock{FixedTime: cancelDate}
 
-			sub := domain.ReconstructFromPersistence(
-				"sub-123",
-				"cust-456",
-				"plan-789",
-				tc.priceCents,
-				domain.StatusActive,
-				startDate,
-			)
+			sub := builders.NewSubscriptionBuilder().WithPrice(tc.priceCents).Build()
 
-			mockRepo := new(MockRepository)
+			mockRepo := new(testkit.MockSubscriptions)
+			mockRefunds := new(MockRefundRepository)
 			mockBilling := new(MockBillingClient)
 
-			interactor := NewInteractor(mockRepo, mockBilling, clock, tc.billingDays)
+			interactor := NewInteractor(mockRepo, mockRefunds, testkit.NewFakeRefundOutbox(), testkit.NewFakeCreditBalances(), adapters.StaticBillingResolver{Client: mockBilling}, adapters.StaticPricing{}, adapters.StaticFeatureFlags{}, adapters.HookChain{}, adapters.NoopEventPublisher{}, clock, adapters.StaticBillingCycle{Cycle: domain.CycleOfDays(tc.billingDays)})
 
 			mockRepo.On("FindByID", ctx, "sub-123").Return(sub, nil)
 			mockMutation := &spanner.Mutation{}
 			mockRepo.On("Save", ctx, mock.Anything).Return(mockMutation, nil)
 			// Apply accepts variadic mutations (becomes []*spanner.Mutation when called)
 			mockRepo.On("Apply", ctx, mock.Anything).Return(nil)
-			mockBilling.On("ProcessRefund", ctx, tc.expectedRefund).Return(nil)
+			mockBilling.On("ProcessRefund", ctx, refundOf(tc.expectedRefund)).Return("refund-abc", nil)
+			mockRefunds.On("Save", ctx, mock.Anything).Return(&spanner.Mutation{}, nil)
+			mockRefunds.On("Apply", ctx, mock.Anything).Return(nil)
 
-			event, err := interactor.Execute(ctx, "sub-123")
+			event, err := interactor.Execute(ctx, "sub-123", domain.CancellationReason{})
 
 			assert.NoError(t, err)
 			assert.Equal(t, tc.expectedRefund, event.RefundAmount)
 		})
 	}
 }
+
+func TestCancelSubscription_ProratesOverTheMonthOfAMonthlyPlan(t *testing.T) {
+	testCases := []struct {
+		name           string
+		periodStart    time.Time
+		daysElapsed    int
+		expectedRefund int64
+	}{
+		{
+			name:           "February of a leap year",
+			periodStart:    time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+			daysElapsed:    10,
+			expectedRefund: 1965, // 3000 * (29-10) / 29
+		},
+		{
+			name:           "January",
+			periodStart:    time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			daysElapsed:    10,
+			expectedRefund: 2032, // 3000 * (31-10) / 31
+		},
+		{
+			name:           "period from the 31st ends on the last day of February",
+			periodStart:    time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC),
+			daysElapsed:    11,
+			expectedRefund: 1862, // 3000 * (29-11) / 29, to Feb 29
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			clock := domain.FixedClock{FixedTime: tc.periodStart.AddDate(0, 0, tc.daysElapsed)}
+			sub := builders.NewSubscriptionBuilder().WithPrice(3000).StartedAt(tc.periodStart).Build()
+
+			mockRepo := new(testkit.MockSubscriptions)
+			mockRefunds := new(MockRefundRepository)
+			mockBilling := new(MockBillingClient)
+			monthly := adapters.StaticBillingCycle{Cycle: domain.BillingCycle{Interval: domain.IntervalMonth, Days: 30}}
+			interactor := NewInteractor(mockRepo, mockRefunds, testkit.NewFakeRefundOutbox(), testkit.NewFakeCreditBalances(), adapters.StaticBillingResolver{Client: mockBilling}, adapters.StaticPricing{}, adapters.StaticFeatureFlags{}, adapters.HookChain{}, adapters.NoopEventPublisher{}, clock, monthly)
+
+			mockRepo.On("FindByID", ctx, "sub-123").Return(sub, nil)
+			mockRepo.On("Save", ctx, mock.Anything).Return(&spanner.Mutation{}, nil)
+			mockRepo.On("Apply", ctx, mock.Anything).Return(nil)
+			mockBilling.On("ProcessRefund", ctx, refundOf(tc.expectedRefund)).Return("refund-abc", nil)
+			mockRefunds.On("Save", ctx, mock.Anything).Return(&spanner.Mutation{}, nil)
+			mockRefunds.On("Apply", ctx, mock.Anything).Return(nil)
+
+			event, err := interactor.Execute(ctx, "sub-123", domain.CancellationReason{})
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedRefund, event.RefundAmount)
+		})
+	}
+}
+
+func TestCancelSubscription_HourlyRefundsFlag(t *testing.T) {
+	// 14 days 18 hours into a 30 day period: whole days refund 3000 * 16 / 30 = 1600,
+	// hours refund 3000 * 366 / 720 = 1525
+	testCases := []struct {
+		name           string
+		flags          adapters.StaticFeatureFlags
+		expectedRefund int64
+	}{
+		{name: "flag off keeps whole days", flags: adapters.StaticFeatureFlags{}, expectedRefund: 1600},
+		{name: "flag on for the customer", flags: adapters.StaticFeatureFlags{FlagHourlyRefunds: {Customers: []string{"cust-456"}}}, expectedRefund: 1525},
+		{name: "flag on for another customer", flags: adapters.StaticFeatureFlags{FlagHourlyRefunds: {Customers: []string{"cust-999"}}}, expectedRefund: 1600},
+		{name: "flag on for everyone", flags: adapters.StaticFeatureFlags{FlagHourlyRefunds: {Enabled: true}}, expectedRefund: 1525},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			startDate := builders.DefaultStartDate
+			clock := domain.FixedClock{FixedTime: startDate.Add(14*24*time.Hour + 18*time.Hour)}
+
+			sub := builders.NewSubscriptionBuilder().Build()
+
+			mockRepo := new(testkit.MockSubscriptions)
+			mockRefunds := new(MockRefundRepository)
+			mockBilling := new(MockBillingClient)
+
+			interactor := NewInteractor(mockRepo, mockRefunds, testkit.NewFakeRefundOutbox(), testkit.NewFakeCreditBalances(), adapters.StaticBillingResolver{Client: mockBilling}, adapters.StaticPricing{}, tc.flags, adapters.HookChain{}, adapters.NoopEventPublisher{}, clock, adapters.StaticBillingCycle{Cycle: domain.CycleOfDays(30)})
+
+			mockRepo.On("FindByID", ctx, "sub-123").Return(sub, nil)
+			mockRepo.On("Save", ctx, mock.Anything).Return(&spanner.Mutation{}, nil)
+			mockRepo.On("Apply", ctx, mock.Anything).Return(nil)
+			mockBilling.On("ProcessRefund", ctx, refundOf(tc.expectedRefund)).Return("refund-abc", nil)
+			mockRefunds.On("Save", ctx, mock.Anything).Return(&spanner.Mutation{}, nil)
+			mockRefunds.On("Apply", ctx, mock.Anything).Return(nil)
+
+			event, err := interactor.Execute(ctx, "sub-123", domain.CancellationReason{})
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedRefund, event.RefundAmount)
+			mockBilling.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCancelSubscription_CreditProrationFlag(t *testing.T) {
+	ctx := context.Background()
+	clock := domain.FixedClock{FixedTime: time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)}
+
+	sub := builders.NewSubscriptionBuilder().Build()
+
+	mockRepo := new(testkit.MockSubscriptions)
+	mockRefunds := new(MockRefundRepository)
+	mockBilling := new(MockBillingClient)
+	credits := testkit.NewFakeCreditBalances()
+	flags := adapters.StaticFeatureFlags{FlagCreditProration: {Enabled: true}}
+
+	interactor := NewInteractor(mockRepo, mockRefunds, testkit.NewFakeRefundOutbox(), credits, adapters.StaticBillingResolver{Client: mockBilling}, adapters.StaticPricing{}, flags, adapters.HookChain{}, adapters.NoopEventPublisher{}, clock, adapters.StaticBillingCycle{Cycle: domain.CycleOfDays(30)})
+
+	mockRepo.On("FindByID", ctx, "sub-123").Return(sub, nil)
+	mockRepo.On("Save", ctx, mock.Anything).Return(&spanner.Mutation{}, nil)
+	// The credit is saved in the same transaction as the cancellation
+	mockRepo.On("Apply", ctx, mock.MatchedBy(func(m []*spanner.Mutation) bool { return len(m) == 2 })).Return(nil)
+
+	event, err := interactor.Execute(ctx, "sub-123", domain.CancellationReason{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), event.RefundAmount)
+	assert.Equal(t, int64(1600), event.CreditAmount)
+	entries := credits.Entries()
+	assert.Len(t, entries, 1)
+	assert.Equal(t, int64(1600), entries[0].Amount())
+	assert.Equal(t, domain.CreditSourceCancellation, entries[0].Source())
+	mockRepo.AssertExpectations(t)
+	mockBilling.AssertNotCalled(t, "ProcessRefund", mock.Anything, mock.Anything)
+	mockRefunds.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+}
+
+func TestCancelSubscription_RefundsDiscountedPrice(t *testing.T) {
+	ctx := context.Background()
+	startDate := builders.DefaultStartDate
+	clock := domain.FixedClock{FixedTime: startDate.AddDate(0, 0, 10)}
+	sub := builders.NewSubscriptionBuilder().Build()
+	pricing := adapters.StaticPricing{Pricing: domain.Pricing{Discounts: []domain.Discount{{Code: "SPRING20", PercentOff: 2000}}}}
+
+	mockRepo := new(testkit.MockSubscriptions)
+	mockRefunds := new(MockRefundRepository)
+	mockBilling := new(MockBillingClient)
+	interactor := NewInteractor(mockRepo, mockRefunds, testkit.NewFakeRefundOutbox(), testkit.NewFakeCreditBalances(), adapters.StaticBillingResolver{Client: mockBilling}, pricing, adapters.StaticFeatureFlags{}, adapters.HookChain{}, adapters.NoopEventPublisher{}, clock, adapters.StaticBillingCycle{Cycle: domain.CycleOfDays(30)})
+
+	mockRepo.On("FindByID", ctx, "sub-123").Return(sub, nil)
+	mockRepo.On("Save", ctx, mock.Anything).Return(&spanner.Mutation{}, nil)
+	mockRepo.On("Apply", ctx, mock.Anything).Return(nil)
+	mockBilling.On("ProcessRefund", ctx, refundOf(1600)).Return("refund-abc", nil) // 2400 * 20 / 30
+	mockRefunds.On("Save", ctx, mock.Anything).Return(&spanner.Mutation{}, nil)
+	mockRefunds.On("Apply", ctx, mock.Anything).Return(nil)
+
+	event, err := interactor.Execute(ctx, "sub-123", domain.CancellationReason{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1600), event.RefundAmount)
+	assert.Equal(t, []domain.AppliedDiscount{{Code: "SPRING20", Kind: domain.DiscountCoupon, Amount: 600}}, event.Discounts)
+	mockBilling.AssertExpectations(t)
+}
+
+func TestCancelSubscription_RefundsThePriceLessTheCoupon(t *testing.T) {
+	ctx := context.Background()
+	startDate := builders.DefaultStartDate
+	clock := domain.FixedClock{FixedTime: startDate.AddDate(0, 0, 10)}
+	sub := builders.NewSubscriptionBuilder().WithCoupon(domain.SubscriptionCoupon{Code: "LAUNCH", AmountOff: 600, PeriodsLeft: 1}).Build()
+
+	mockRepo := new(testkit.MockSubscriptions)
+	mockRefunds := new(MockRefundRepository)
+	mockBilling := new(MockBillingClient)
+	interactor := NewInteractor(mockRepo, mockRefunds, testkit.NewFakeRefundOutbox(), testkit.NewFakeCreditBalances(), adapters.StaticBillingResolver{Client: mockBilling}, adapters.StaticPricing{}, adapters.StaticFeatureFlags{}, adapters.HookChain{}, adapters.NoopEventPublisher{}, clock, adapters.StaticBillingCycle{Cycle: domain.CycleOfDays(30)})
+
+	mockRepo.On("FindByID", ctx, "sub-123").Return(sub, nil)
+	mockRepo.On("Save", ctx, mock.Anything).Return(&spanner.Mutation{}, nil)
+	mockRepo.On("Apply", ctx, mock.Anything).Return(nil)
+	mockBilling.On("ProcessRefund", ctx, refundOf(1600)).Return("refund-abc", nil) // 2400 * 20 / 30
+	mockRefunds.On("Save", ctx, mock.Anything).Return(&spanner.Mutation{}, nil)
+	mockRefunds.On("Apply", ctx, mock.Anything).Return(nil)
+
+	event, err := interactor.Execute(ctx, "sub-123", domain.CancellationReason{})
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(1600), event.RefundAmount)
+	assert.Equal(t, []domain.AppliedDiscount{{Code: "LAUNCH", Kind: domain.DiscountCoupon, Amount: 600}}, event.Discounts)
+	mockBilling.AssertExpectations(t)
+}
+
+func TestCancelSubscription_RefundsTheAddOnsWithThePlan(t *testing.T) {
+	ctx := context.Background()
+	startDate := builders.DefaultStartDate
+	clock := domain.FixedClock{FixedTime: startDate.AddDate(0, 0, 10)}
+	bundles := testkit.NewFakeBundles().With("sub-123", domain.SubscriptionBundle{AddOns: []domain.AddOnCharge{
+		{ID: "extra-seats", Name: "Extra seats", Quantity: 3, UnitPrice: 200},
+		{ID: "priority-support", Name: "Priority support", Quantity: 1, UnitPrice: 900},
+	}})
+	pricing := adapters.BundlePricing{Bundles: bundles, Base: adapters.StaticPricing{}}
+
+	mockRepo := new(testkit.MockSubscriptions)
+	mockRefunds := new(MockRefundRepository)
+	mockBilling := new(MockBillingClient)
+	interactor := NewInteractor(mockRepo, mockRefunds, testkit.NewFakeRefundOutbox(), testkit.NewFakeCreditBalances(), adapters.StaticBillingResolver{Client: mockBilling}, pricing, adapters.StaticFeatureFlags{}, adapters.HookChain{}, adapters.NoopEventPublisher{}, clock, adapters.StaticBillingCycle{Cycle: domain.CycleOfDays(30)})
+
+	mockRepo.On("FindByID", ctx, "sub-123").Return(builders.NewSubscriptionBuilder().Build(), nil)
+	mockRepo.On("Save", ctx, mock.Anything).Return(&spanner.Mutation{}, nil)
+	mockRepo.On("Apply", ctx, mock.Anything).Return(nil)
+	mockBilling.On("ProcessRefund", ctx, refundOf(3000)).Return("refund-abc", nil) // (3000 + 600 + 900) * 20 / 30
+	mockRefunds.On("Save", ctx, mock.Anything).Return(&spanner.Mutation{}, nil)
+	mockRefunds.On("Apply", ctx, mock.Anything).Return(nil)
+
+	event, err := interactor.Execute(ctx, "sub-123", domain.CancellationReason{})
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(3000), event.RefundAmount)
+	mockBilling.AssertExpectations(t)
+}
+
+func TestCancelSubscription_HookVetoesBeforeSaving(t *testing.T) {
+	ctx := context.Background()
+	startDate := builders.DefaultStartDate
+	clock := domain.FixedClock{FixedTime: startDate.AddDate(0, 0, 14)}
+	mockRepo := new(testkit.MockSubscriptions)
+	mockBilling := new(MockBillingClient)
+	veto := errors.New("customer has an open retention offer")
+	hooks := &testkit.RecordingHooks{Veto: veto}
+	interactor := NewInteractor(mockRepo, new(MockRefundRepository), testkit.NewFakeRefundOutbox(), testkit.NewFakeCreditBalances(), adapters.StaticBillingResolver{Client: mockBilling}, adapters.StaticPricing{}, adapters.StaticFeatureFlags{}, hooks, adapters.NoopEventPublisher{}, clock, adapters.StaticBillingCycle{Cycle: domain.CycleOfDays(30)})
+
+	mockRepo.On("FindByID", ctx, "sub-123").Return(builders.NewSubscriptionBuilder().Build(), nil)
+	mockRepo.On("Save", ctx, mock.Anything).Return(&spanner.Mutation{}, nil)
+
+	_, err := interactor.Execute(ctx, "sub-123", domain.CancellationReason{})
+
+	assert.ErrorIs(t, err, domain.ErrRejectedByHook)
+	assert.ErrorIs(t, err, veto)
+	assert.Equal(t, []string{"BeforeCancel"}, hooks.Calls())
+	mockRepo.AssertNotCalled(t, "Apply", mock.Anything, mock.Anything)
+	mockBilling.AssertNotCalled(t, "ProcessRefund", mock.Anything, mock.Anything)
+}
+
+func TestCancelSubscription_AfterHookRunsBeforeRefund(t *testing.T) {
+	ctx := context.Background()
+	startDate := builders.DefaultStartDate
+	clock := domain.FixedClock{FixedTime: startDate.AddDate(0, 0, 14)}
+	mockRepo := new(testkit.MockSubscriptions)
+	mockBilling := new(MockBillingClient)
+	hooks := &testkit.RecordingHooks{}
+	events := &testkit.RecordingEvents{}
+	interactor := NewInteractor(mockRepo, new(MockRefundRepository), testkit.NewFakeRefundOutbox(), testkit.NewFakeCreditBalances(), adapters.StaticBillingResolver{Client: mockBilling}, adapters.StaticPricing{}, adapters.StaticFeatureFlags{}, hooks, events, clock, adapters.StaticBillingCycle{Cycle: domain.CycleOfDays(30)})
+
+	mockRepo.On("FindByID", ctx, "sub-123").Return(builders.NewSubscriptionBuilder().Build(), nil)
+	mockRepo.On("Save", ctx, mock.Anything).Return(&spanner.Mutation{}, nil)
+	mockRepo.On("Apply", ctx, mock.Anything).Return(nil)
+	refundErr := errors.New("billing unavailable")
+	mockBilling.On("ProcessRefund", ctx, refundOf(1600)).Return("", refundErr)
+
+	// The cancellation is saved before the refund fails, so AfterCancel has already run
+	// and the cancellation has been published
+	event, err := interactor.Execute(ctx, "sub-123", domain.CancellationReason{})
+
+	assert.NoError(t, err, "the refund is queued for the refunds worker")
+	assert.Equal(t, []string{"BeforeCancel", "AfterCancel"}, hooks.Calls())
+	assert.Equal(t, []*domain.SubscriptionCancelledEvent{event}, events.Cancelled())
+}
+
+func TestCancelSubscription_QueuesARefundTheProviderFailed(t *testing.T) {
+	ctx := context.Background()
+	clock := domain.FixedClock{FixedTime: builders.DefaultStartDate.AddDate(0, 0, 14)}
+	mockRepo := new(testkit.MockSubscriptions)
+	mockBilling := new(MockBillingClient)
+	outbox := testkit.NewFakeRefundOutbox()
+	interactor := NewInteractor(mockRepo, new(MockRefundRepository), outbox, testkit.NewFakeCreditBalances(), adapters.StaticBillingResolver{Client: mockBilling}, adapters.StaticPricing{}, adapters.StaticFeatureFlags{}, adapters.HookChain{}, adapters.NoopEventPublisher{}, clock, adapters.StaticBillingCycle{Cycle: domain.CycleOfDays(30)})
+
+	sub := builders.NewSubscriptionBuilder().Build()
+	mockRepo.On("FindByID", ctx, "sub-123").Return(sub, nil)
+	mockRepo.On("Save", ctx, mock.Anything).Return(&spanner.Mutation{}, nil)
+	mockRepo.On("Apply", ctx, mock.Anything).Return(nil)
+	mockBilling.On("ProcessRefund", ctx, refundOf(1600)).Return("", errors.New("billing unavailable"))
+
+	event, err := interactor.Execute(ctx, "sub-123", domain.CancellationReason{})
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(1600), event.RefundAmount)
+	queued, err := outbox.FindByCustomer(ctx, sub.CustomerID())
+	require.NoError(t, err)
+	require.Len(t, queued, 1)
+	assert.Equal(t, "sub-123", queued[0].SubscriptionID())
+	assert.Equal(t, int64(1600), queued[0].Amount())
+	assert.Equal(t, refundIdempotencyKey(sub), queued[0].IdempotencyKey(), "a retry can't refund twice")
+	assert.Equal(t, int64(1), queued[0].Attempts())
+	assert.Equal(t, "billing unavailable", queued[0].LastError())
+	assert.Equal(t, clock.Now().Add(time.Minute), queued[0].NextAttemptAt())
+}
+
+func TestCancelSubscription_LosingAConcurrentCancelSendsNoRefund(t *testing.T) {
+	ctx := context.Background()
+	startDate := builders.DefaultStartDate
+	clock := domain.FixedClock{FixedTime: startDate.AddDate(0, 0, 14)}
+	mockRepo := new(testkit.MockSubscriptions)
+	mockBilling := new(MockBillingClient)
+	hooks := &testkit.RecordingHooks{}
+	events := &testkit.RecordingEvents{}
+	interactor := NewInteractor(mockRepo, new(MockRefundRepository), testkit.NewFakeRefundOutbox(), testkit.NewFakeCreditBalances(), adapters.StaticBillingResolver{Client: mockBilling}, adapters.StaticPricing{}, adapters.StaticFeatureFlags{}, hooks, events, clock, adapters.StaticBillingCycle{Cycle: domain.CycleOfDays(30)})
+
+	// Another request cancelled the subscription after this one read it as active
+	mockRepo.On("FindByID", ctx, "sub-123").Return(builders.NewSubscriptionBuilder().Build(), nil)
+	mockRepo.On("Save", ctx, mock.Anything).Return(&spanner.Mutation{}, nil)
+	mockRepo.On("Apply", ctx, mock.Anything).Return(domain.ErrConcurrentModification)
+
+	event, err := interactor.Execute(ctx, "sub-123", domain.CancellationReason{})
+
+	assert.ErrorIs(t, err, domain.ErrConcurrentModification)
+	assert.Nil(t, event)
+	assert.Equal(t, []string{"BeforeCancel"}, hooks.Calls())
+	assert.Empty(t, events.Cancelled())
+	mockBilling.AssertNotCalled(t, "ProcessRefund", mock.Anything, mock.Anything)
+}
+
+func TestCancelSubscription_AtPeriodEnd(t *testing.T) {
+	ctx := context.Background()
+	clock := domain.FixedClock{FixedTime: time.Date(2024, 2, 10, 0, 0, 0, 0, time.UTC)}
+	sub := builders.NewSubscriptionBuilder().InPeriodFrom(time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)).Build()
+	mockRepo := new(testkit.MockSubscriptions)
+	mockBilling := new(MockBillingClient)
+	hooks := &testkit.RecordingHooks{}
+	events := &testkit.RecordingEvents{}
+	monthly := adapters.StaticBillingCycle{Cycle: domain.BillingCycle{Interval: domain.IntervalMonth, Days: 30}}
+	interactor := NewInteractor(mockRepo, new(MockRefundRepository), testkit.NewFakeRefundOutbox(), testkit.NewFakeCreditBalances(), adapters.StaticBillingResolver{Client: mockBilling}, adapters.StaticPricing{}, adapters.StaticFeatureFlags{}, hooks, events, clock, monthly)
+
+	mockRepo.On("FindByID", ctx, "sub-123").Return(sub, nil)
+	mockRepo.On("Save", ctx, sub).Return(&spanner.Mutation{}, nil)
+	mockRepo.On("Apply", ctx, mock.Anything).Return(nil)
+
+	event, err := interactor.CancelAtPeriodEnd(ctx, "sub-123", domain.CancellationReason{})
+
+	require.NoError(t, err)
+	assert.Equal(t, &domain.SubscriptionCancellationScheduledEvent{
+		SubscriptionID: "sub-123",
+		CustomerID:     "cust-456",
+		PlanID:         "plan-789",
+		CancelAt:       time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		ScheduledAt:    clock.Now(),
+	}, event)
+	assert.Equal(t, domain.StatusPendingCancellation, sub.Status())
+	assert.True(t, sub.CancelledAt().IsZero())
+	// Nothing is cancelled yet, so nothing is refunded or announced
+	assert.Empty(t, hooks.Calls())
+	assert.Empty(t, events.Cancelled())
+	mockBilling.AssertNotCalled(t, "ProcessRefund", mock.Anything, mock.Anything)
+
+	_, err = interactor.CancelAtPeriodEnd(ctx, "sub-123", domain.CancellationReason{})
+	assert.ErrorIs(t, err, domain.ErrCancellationAlreadyScheduled)
+}
+
+func TestCancelSubscription_FinalizeScheduled(t *testing.T) {
+	ctx := context.Background()
+	cancelAt := builders.DefaultStartDate.AddDate(0, 0, 30)
+	mockRepo := new(testkit.MockSubscriptions)
+	mockBilling := new(MockBillingClient)
+	hooks := &testkit.RecordingHooks{}
+	events := &testkit.RecordingEvents{}
+	newInteractor := func(now time.Time) *Interactor {
+		return NewInteractor(mockRepo, new(MockRefundRepository), testkit.NewFakeRefundOutbox(), testkit.NewFakeCreditBalances(), adapters.StaticBillingResolver{Client: mockBilling}, adapters.StaticPricing{}, adapters.StaticFeatureFlags{FlagHourlyRefunds: {Enabled: true}}, hooks, events, domain.FixedClock{FixedTime: now}, adapters.StaticBillingCycle{Cycle: domain.CycleOfDays(30)})
+	}
+
+	sub := builders.NewSubscriptionBuilder().PendingCancellationAt(cancelAt).Build()
+	mockRepo.On("FindByID", ctx, "sub-123").Return(sub, nil)
+	mockRepo.On("Save", ctx, sub).Return(&spanner.Mutation{}, nil)
+	mockRepo.On("Apply", ctx, mock.Anything).Return(nil)
+
+	_, err := newInteractor(cancelAt.Add(-time.Minute)).FinalizeScheduled(ctx, "sub-123")
+	assert.ErrorIs(t, err, domain.ErrCancellationNotDue)
+	assert.Equal(t, domain.StatusPendingCancellation, sub.Status())
+	mockRepo.AssertNotCalled(t, "Apply", mock.Anything, mock.Anything)
+
+	event, err := newInteractor(cancelAt).FinalizeScheduled(ctx, "sub-123")
+
+	require.NoError(t, err)
+	assert.Zero(t, event.RefundAmount, "the period it paid for is over")
+	assert.Equal(t, domain.StatusCancelled, sub.Status())
+	assert.Equal(t, cancelAt, sub.CancelledAt())
+	assert.Equal(t, []string{"BeforeCancel", "AfterCancel"}, hooks.Calls())
+	assert.Equal(t, []*domain.SubscriptionCancelledEvent{event}, events.Cancelled())
+	mockBilling.AssertNotCalled(t, "ProcessRefund", mock.Anything, mock.Anything)
+}
+
+func TestCancelSubscription_RecordsTheReason(t *testing.T) {
+	ctx := context.Background()
+	reason := domain.CancellationReason{Code: domain.CancellationReasonMissingFeatures, Details: "No SSO"}
+	sub := builders.NewSubscriptionBuilder().Build()
+	mockRepo := new(testkit.MockSubscriptions)
+	events := &testkit.RecordingEvents{}
+	// A full period in, so nothing is refunded
+	clock := domain.FixedClock{FixedTime: builders.DefaultStartDate.AddDate(0, 0, 30)}
+	interactor := NewInteractor(mockRepo, new(MockRefundRepository), testkit.NewFakeRefundOutbox(), testkit.NewFakeCreditBalances(), adapters.StaticBillingResolver{Client: new(MockBillingClient)}, adapters.StaticPricing{}, adapters.StaticFeatureFlags{}, adapters.HookChain{}, events, clock, adapters.StaticBillingCycle{Cycle: domain.CycleOfDays(30)})
+
+	mockRepo.On("FindByID", ctx, "sub-123").Return(sub, nil)
+	mockRepo.On("Save", ctx, sub).Return(&spanner.Mutation{}, nil)
+	mockRepo.On("Apply", ctx, mock.Anything).Return(nil)
+
+	event, err := interactor.Execute(ctx, "sub-123", reason)
+
+	require.NoError(t, err)
+	assert.Equal(t, reason, event.Reason)
+	assert.Equal(t, reason, sub.CancellationReason())
+	assert.Equal(t, []*domain.SubscriptionCancelledEvent{event}, events.Cancelled())
+}
+
+func TestCancelSubscription_RejectsAnInvalidReason(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(testkit.MockSubscriptions)
+	interactor := NewInteractor(mockRepo, new(MockRefundRepository), testkit.NewFakeRefundOutbox(), testkit.NewFakeCreditBalances(), adapters.StaticBillingResolver{Client: new(MockBillingClient)}, adapters.StaticPricing{}, adapters.StaticFeatureFlags{}, adapters.HookChain{}, adapters.NoopEventPublisher{}, domain.FixedClock{FixedTime: builders.DefaultStartDate.AddDate(0, 0, 10)}, adapters.StaticBillingCycle{Cycle: domain.CycleOfDays(30)})
+	mockRepo.On("FindByID", ctx, "sub-123").Return(builders.NewSubscriptionBuilder().Build(), nil)
+
+	for _, reason := range []domain.CancellationReason{
+		{Code: "bored"},
+		{Details: "details without a code"},
+		{Code: domain.CancellationReasonOther, Details: strings.Repeat("x", domain.MaxCancellationReasonDetailsLength+1)},
+	} {
+		_, err := interactor.Execute(ctx, "sub-123", reason)
+		assert.ErrorIs(t, err, domain.ErrInvalidCancellationReason, reason)
+
+		_, err = interactor.CancelAtPeriodEnd(ctx, "sub-123", reason)
+		assert.ErrorIs(t, err, domain.ErrInvalidCancellationReason, reason)
+	}
+	mockRepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+}
+
+func TestCancelSubscription_FinalizeScheduledKeepsTheReasonGiven(t *testing.T) {
+	ctx := context.Background()
+	reason := domain.CancellationReason{Code: domain.CancellationReasonUnused}
+	sub := builders.NewSubscriptionBuilder().Build()
+	mockRepo := new(testkit.MockSubscriptions)
+	newInteractor := func(now time.Time) *Interactor {
+		return NewInteractor(mockRepo, new(MockRefundRepository), testkit.NewFakeRefundOutbox(), testkit.NewFakeCreditBalances(), adapters.StaticBillingResolver{Client: new(MockBillingClient)}, adapters.StaticPricing{}, adapters.StaticFeatureFlags{}, adapters.HookChain{}, adapters.NoopEventPublisher{}, domain.FixedClock{FixedTime: now}, adapters.StaticBillingCycle{Cycle: domain.CycleOfDays(30)})
+	}
+	mockRepo.On("FindByID", ctx, "sub-123").Return(sub, nil)
+	mockRepo.On("Save", ctx, sub).Return(&spanner.Mutation{}, nil)
+	mockRepo.On("Apply", ctx, mock.Anything).Return(nil)
+
+	_, err := newInteractor(builders.DefaultStartDate.AddDate(0, 0, 10)).CancelAtPeriodEnd(ctx, "sub-123", reason)
+	require.NoError(t, err)
+	assert.Equal(t, reason, sub.CancellationReason())
+
+	event, err := newInteractor(sub.CancelAt()).FinalizeScheduled(ctx, "sub-123")
+
+	require.NoError(t, err)
+	assert.Equal(t, reason, event.Reason)
+}
+
+func TestCancelSubscription_FinalizeScheduledSkipsUnscheduled(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(testkit.MockSubscriptions)
+	interactor := NewInteractor(mockRepo, new(MockRefundRepository), testkit.NewFakeRefundOutbox(), testkit.NewFakeCreditBalances(), adapters.StaticBillingResolver{Client: new(MockBillingClient)}, adapters.StaticPricing{}, adapters.StaticFeatureFlags{}, adapters.HookChain{}, adapters.NoopEventPublisher{}, domain.FixedClock{FixedTime: builders.DefaultStartDate.AddDate(1, 0, 0)}, adapters.StaticBillingCycle{Cycle: domain.CycleOfDays(30)})
+
+	mockRepo.On("FindByID", ctx, "sub-123").Return(builders.NewSubscriptionBuilder().Build(), nil).Once()
+	_, err := interactor.FinalizeScheduled(ctx, "sub-123")
+	assert.ErrorIs(t, err, domain.ErrCancellationNotDue, "an active subscription was never scheduled")
+
+	mockRepo.On("FindByID", ctx, "sub-123").Return(builders.NewSubscriptionBuilder().Cancelled().Build(), nil).Once()
+	_, err = interactor.FinalizeScheduled(ctx, "sub-123")
+	assert.ErrorIs(t, err, domain.ErrAlreadyCancelled)
+	mockRepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+}
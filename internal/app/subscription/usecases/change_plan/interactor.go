@@ -0,0 +1,118 @@
+package change_plan
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+// FlagDowngradeCredit grants the prorated difference of a downgrade to the customer's
+// credit balance, spent by later charges, instead of dropping it
+const FlagDowngradeCredit = "change_plan.downgrade_credit"
+
+// Request contains the input for moving a subscription to another plan
+type Request struct {
+	SubscriptionID string
+	PlanID         string
+	PriceCents     int64
+}
+
+// Interactor handles the change plan use case
+type Interactor struct {
+	repo    contracts.SubscriptionRepository
+	credits contracts.CreditBalanceRepository
+	billing contracts.BillingResolver
+	pricing contracts.PricingSource
+	flags   contracts.FeatureFlags
+	hooks   contracts.SubscriptionHooks
+	clock   domain.Clock
+	cycles  contracts.BillingCycleSource
+}
+
+// NewInteractor creates a new change plan interactor
+func NewInteractor(repo contracts.SubscriptionRepository, credits contracts.CreditBalanceRepository, billing contracts.BillingResolver, pricing contracts.PricingSource, flags contracts.FeatureFlags, hooks contracts.SubscriptionHooks, clock domain.Clock, cycles contracts.BillingCycleSource) *Interactor {
+	return &Interactor{
+		repo:    repo,
+		credits: credits,
+		billing: billing,
+		pricing: pricing,
+		flags:   flags,
+		hooks:   hooks,
+		clock:   clock,
+		cycles:  cycles,
+	}
+}
+
+// Execute moves a subscription to another plan. An upgrade charges the prorated
+// difference for the rest of the period; a downgrade takes effect without a credit,
+// unless FlagDowngradeCredit grants the difference to the customer's credit balance.
+func (i *Interactor) Execute(ctx context.Context, req Request) (*domain.SubscriptionPlanChangedEvent, error) {
+	// 1. Load subscription
+	sub, err := i.repo.FindByID(ctx, req.SubscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. Resolve the billing provider before the plan changes, since routing is by plan
+	billingClient, err := i.billing.Resolve(ctx, sub.PlanID(), sub.CustomerID())
+	if err != nil {
+		return nil, err
+	}
+
+	// 3. Change plan via domain method, which prorates the difference between the
+	// discounted prices
+	pricing, err := i.pricing.PricingFor(ctx, sub)
+	if err != nil {
+		return nil, err
+	}
+	cycle, err := i.cycles.CycleFor(ctx, sub)
+	if err != nil {
+		return nil, err
+	}
+	event, err := sub.ChangePlan(i.clock, req.PlanID, req.PriceCents, cycle, pricing)
+	if err != nil {
+		return nil, err
+	}
+
+	// 4. Let the deployment's hooks veto the plan change before it is charged
+	if err := i.hooks.BeforePlanChange(ctx, sub, event); err != nil {
+		return nil, fmt.Errorf("%w: %w", domain.ErrRejectedByHook, err)
+	}
+
+	// 5. Charge the upgrade delta; nothing is saved unless it succeeds. The key is
+	// unique per period and target plan so a retried change is charged once.
+	if event.ProratedAmount > 0 {
+		if err := billingClient.ChargeCustomer(ctx, contracts.ChargeRequest{
+			CustomerID:     sub.CustomerID(),
+			SubscriptionID: sub.ID(),
+			Amount:         event.ProratedAmount,
+			Currency:       domain.DefaultCurrency,
+			IdempotencyKey: fmt.Sprintf("%s:%d:plan-change:%s", sub.ID(), sub.CurrentPeriodStart().Unix(), req.PlanID),
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	// 6. Save the updated subscription
+	var uow contracts.UnitOfWork
+	uow.SaveChecked(i.repo.Save(ctx, sub))
+
+	// 7. Credit a downgrade's difference if rolled out to this customer, saved with the plan change
+	target := contracts.FlagTarget{CustomerID: sub.CustomerID(), SubscriptionID: sub.ID(), PlanID: sub.PlanID()}
+	if event.ProratedAmount < 0 && i.flags.Enabled(ctx, FlagDowngradeCredit, target) {
+		event.CreditAmount = -event.ProratedAmount
+		entry := domain.NewCreditEntry(uuid.New().String(), sub.CustomerID(), event.CreditAmount, domain.DefaultCurrency, domain.CreditSourceDowngrade, sub.ID(), i.clock)
+		uow.Save(i.credits.Save(ctx, entry))
+	}
+
+	// 8. Commit the writes
+	if err := uow.Commit(ctx, i.repo); err != nil {
+		return nil, err
+	}
+	i.hooks.AfterPlanChange(ctx, sub, event)
+
+	return event, nil
+}
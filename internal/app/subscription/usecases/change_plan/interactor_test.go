@@ -0,0 +1,217 @@
+package change_plan
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/adapters"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/testkit"
+)
+
+var startDate = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func activeSubscription() *domain.Subscription {
+	return domain.ReconstructFromPersistence("sub-123", "cust-456", "plan-basic", 3000, domain.StatusActive, startDate)
+}
+
+// changeOn builds an interactor whose clock reads daysIntoPeriod days after startDate
+func changeOn(repo contracts.SubscriptionRepository, billing contracts.BillingClient, daysIntoPeriod int) *Interactor {
+	clock := domain.FixedClock{FixedTime: startDate.AddDate(0, 0, daysIntoPeriod)}
+	return NewInteractor(repo, testkit.NewFakeCreditBalances(), adapters.StaticBillingResolver{Client: billing}, adapters.StaticPricing{}, adapters.StaticFeatureFlags{}, adapters.HookChain{}, clock, adapters.StaticBillingCycle{Cycle: domain.CycleOfDays(30)})
+}
+
+func TestChangePlan_UpgradeChargesProratedDifference(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(testkit.MockSubscriptions)
+	billing := testkit.NewFakeBillingClient()
+	interactor := changeOn(mockRepo, billing, 10) // 20 of 30 days remain
+
+	mockRepo.On("FindByID", ctx, "sub-123").Return(activeSubscription(), nil)
+	mockRepo.On("Save", ctx, mock.MatchedBy(func(s *domain.Subscription) bool {
+		return s.PlanID() == "plan-pro" && s.Price() == 6000
+	})).Return(&spanner.Mutation{}, nil)
+	mockRepo.On("Apply", ctx, mock.Anything).Return(nil)
+
+	event, err := interactor.Execute(ctx, Request{SubscriptionID: "sub-123", PlanID: "plan-pro", PriceCents: 6000})
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(2000), event.ProratedAmount) // (6000 - 3000) * 20 / 30
+	assert.Equal(t, "plan-basic", event.OldPlanID)
+	charges := billing.CallsTo(testkit.OpChargeCustomer)
+	require.Len(t, charges, 1)
+	assert.Equal(t, contracts.ChargeRequest{
+		CustomerID:     "cust-456",
+		SubscriptionID: "sub-123",
+		Amount:         2000,
+		Currency:       domain.DefaultCurrency,
+		IdempotencyKey: fmt.Sprintf("sub-123:%d:plan-change:plan-pro", startDate.Unix()),
+	}, charges[0].Charge)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestChangePlan_DowngradeDoesNotCharge(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(testkit.MockSubscriptions)
+	billing := testkit.NewFakeBillingClient()
+	interactor := changeOn(mockRepo, billing, 10)
+
+	mockRepo.On("FindByID", ctx, "sub-123").Return(activeSubscription(), nil)
+	mockRepo.On("Save", ctx, mock.Anything).Return(&spanner.Mutation{}, nil)
+	mockRepo.On("Apply", ctx, mock.Anything).Return(nil)
+
+	event, err := interactor.Execute(ctx, Request{SubscriptionID: "sub-123", PlanID: "plan-lite", PriceCents: 1500})
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(-1000), event.ProratedAmount)
+	assert.Empty(t, billing.CallsTo(testkit.OpChargeCustomer))
+}
+
+func TestChangePlan_FailedChargeKeepsOldPlan(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(testkit.MockSubscriptions)
+	billing := testkit.NewFakeBillingClient().FailAlways(testkit.OpChargeCustomer, domain.ErrPaymentDeclined)
+	interactor := changeOn(mockRepo, billing, 10)
+
+	mockRepo.On("FindByID", ctx, "sub-123").Return(activeSubscription(), nil)
+
+	event, err := interactor.Execute(ctx, Request{SubscriptionID: "sub-123", PlanID: "plan-pro", PriceCents: 6000})
+
+	assert.ErrorIs(t, err, domain.ErrPaymentDeclined)
+	assert.Nil(t, event)
+	mockRepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+}
+
+func TestChangePlan_Rejections(t *testing.T) {
+	tests := []struct {
+		name    string
+		sub     *domain.Subscription
+		planID  string
+		wantErr error
+	}{
+		{"same plan", activeSubscription(), "plan-basic", domain.ErrSamePlan},
+		{"cancelled", domain.ReconstructFromPersistence("sub-123", "cust-456", "plan-basic", 3000, domain.StatusCancelled, startDate), "plan-pro", domain.ErrNotActive},
+		{"past due", domain.ReconstructFromPersistence("sub-123", "cust-456", "plan-basic", 3000, domain.StatusPastDue, startDate), "plan-pro", domain.ErrNotActive},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			mockRepo := new(testkit.MockSubscriptions)
+			billing := testkit.NewFakeBillingClient()
+			interactor := changeOn(mockRepo, billing, 10)
+
+			mockRepo.On("FindByID", ctx, "sub-123").Return(tc.sub, nil)
+
+			_, err := interactor.Execute(ctx, Request{SubscriptionID: "sub-123", PlanID: tc.planID, PriceCents: 6000})
+
+			assert.True(t, errors.Is(err, tc.wantErr))
+			assert.Empty(t, billing.Calls())
+			mockRepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestChangePlan_DowngradeCreditFlag(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(testkit.MockSubscriptions)
+	billing := testkit.NewFakeBillingClient()
+	credits := testkit.NewFakeCreditBalances()
+	flags := adapters.StaticFeatureFlags{FlagDowngradeCredit: {Customers: []string{"cust-456"}}}
+	interactor := NewInteractor(mockRepo, credits, adapters.StaticBillingResolver{Client: billing}, adapters.StaticPricing{}, flags, adapters.HookChain{}, domain.FixedClock{FixedTime: startDate.AddDate(0, 0, 10)}, adapters.StaticBillingCycle{Cycle: domain.CycleOfDays(30)})
+
+	mockRepo.On("FindByID", ctx, "sub-123").Return(activeSubscription(), nil)
+	mockRepo.On("Save", ctx, mock.Anything).Return(&spanner.Mutation{}, nil)
+	mockRepo.On("Apply", ctx, mock.MatchedBy(func(m []*spanner.Mutation) bool { return len(m) == 2 })).Return(nil)
+
+	event, err := interactor.Execute(ctx, Request{SubscriptionID: "sub-123", PlanID: "plan-lite", PriceCents: 1500})
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(1000), event.CreditAmount)
+	entries := credits.Entries()
+	require.Len(t, entries, 1)
+	assert.Equal(t, int64(1000), entries[0].Amount())
+	assert.Equal(t, domain.CreditSourceDowngrade, entries[0].Source())
+	assert.Equal(t, "cust-456", entries[0].CustomerID())
+	assert.Empty(t, billing.Calls())
+	mockRepo.AssertExpectations(t)
+}
+
+func TestChangePlan_ProratesDiscountedPrices(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(testkit.MockSubscriptions)
+	billing := testkit.NewFakeBillingClient()
+	pricing := adapters.StaticPricing{Pricing: domain.Pricing{Discounts: []domain.Discount{
+		{Code: "SAVE5", AmountOff: 500},
+		{Code: "IN-PPP", Kind: domain.DiscountRegional, PercentOff: 5000},
+	}}}
+	clock := domain.FixedClock{FixedTime: startDate.AddDate(0, 0, 10)}
+	interactor := NewInteractor(mockRepo, testkit.NewFakeCreditBalances(), adapters.StaticBillingResolver{Client: billing}, pricing, adapters.StaticFeatureFlags{}, adapters.HookChain{}, clock, adapters.StaticBillingCycle{Cycle: domain.CycleOfDays(30)})
+
+	mockRepo.On("FindByID", ctx, "sub-123").Return(activeSubscription(), nil)
+	mockRepo.On("Save", ctx, mock.Anything).Return(&spanner.Mutation{}, nil)
+	mockRepo.On("Apply", ctx, mock.Anything).Return(nil)
+
+	event, err := interactor.Execute(ctx, Request{SubscriptionID: "sub-123", PlanID: "plan-pro", PriceCents: 6000})
+
+	require.NoError(t, err)
+	// 3000 is discounted to 1000 and 6000 to 2500, so (2500 - 1000) * 20 / 30
+	assert.Equal(t, int64(1000), event.ProratedAmount)
+	assert.Equal(t, []domain.AppliedDiscount{
+		{Code: "IN-PPP", Kind: domain.DiscountRegional, Amount: 3000},
+		{Code: "SAVE5", Kind: domain.DiscountCoupon, Amount: 500},
+	}, event.Discounts)
+	charges := billing.CallsTo(testkit.OpChargeCustomer)
+	require.Len(t, charges, 1)
+	assert.Equal(t, int64(1000), charges[0].Charge.Amount)
+}
+
+func TestChangePlan_HookVetoesBeforeCharging(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(testkit.MockSubscriptions)
+	billing := testkit.NewFakeBillingClient()
+	veto := errors.New("plan change needs sales approval")
+	hooks := &testkit.RecordingHooks{Veto: veto}
+	clock := domain.FixedClock{FixedTime: startDate.AddDate(0, 0, 10)}
+	interactor := NewInteractor(mockRepo, testkit.NewFakeCreditBalances(), adapters.StaticBillingResolver{Client: billing}, adapters.StaticPricing{}, adapters.StaticFeatureFlags{}, hooks, clock, adapters.StaticBillingCycle{Cycle: domain.CycleOfDays(30)})
+
+	mockRepo.On("FindByID", ctx, "sub-123").Return(activeSubscription(), nil)
+
+	_, err := interactor.Execute(ctx, Request{SubscriptionID: "sub-123", PlanID: "plan-pro", PriceCents: 6000})
+
+	assert.ErrorIs(t, err, domain.ErrRejectedByHook)
+	assert.ErrorIs(t, err, veto)
+	assert.Empty(t, billing.Calls())
+	mockRepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+	mockRepo.AssertNotCalled(t, "Apply", mock.Anything, mock.Anything)
+}
+
+func TestChangePlan_AfterHookRunsOnceSaved(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(testkit.MockSubscriptions)
+	hooks := &testkit.RecordingHooks{}
+	clock := domain.FixedClock{FixedTime: startDate.AddDate(0, 0, 10)}
+	interactor := NewInteractor(mockRepo, testkit.NewFakeCreditBalances(), adapters.StaticBillingResolver{Client: testkit.NewFakeBillingClient()}, adapters.StaticPricing{}, adapters.StaticFeatureFlags{}, hooks, clock, adapters.StaticBillingCycle{Cycle: domain.CycleOfDays(30)})
+
+	mockRepo.On("FindByID", ctx, "sub-123").Return(activeSubscription(), nil).Once()
+	mockRepo.On("FindByID", ctx, "sub-123").Return(activeSubscription(), nil)
+	mockRepo.On("Save", ctx, mock.Anything).Return(&spanner.Mutation{}, nil)
+	mockRepo.On("Apply", ctx, mock.Anything).Return(errors.New("spanner unavailable")).Once()
+	mockRepo.On("Apply", ctx, mock.Anything).Return(nil)
+
+	_, err := interactor.Execute(ctx, Request{SubscriptionID: "sub-123", PlanID: "plan-pro", PriceCents: 6000})
+	require.Error(t, err)
+	assert.Equal(t, []string{"BeforePlanChange"}, hooks.Calls())
+
+	_, err = interactor.Execute(ctx, Request{SubscriptionID: "sub-123", PlanID: "plan-pro", PriceCents: 6000})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"BeforePlanChange", "BeforePlanChange", "AfterPlanChange"}, hooks.Calls())
+}
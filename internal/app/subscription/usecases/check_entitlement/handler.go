@@ -0,0 +1,49 @@
+package check_entitlement
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/bus"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+// CommandName identifies the check entitlement command on the bus
+const CommandName = "subscription.check_entitlement"
+
+var _ bus.Handler = (*Interactor)(nil)
+
+// Response is the bus result of a check entitlement command
+type Response struct {
+	Decision *domain.EntitlementDecision
+}
+
+// CommandName implements bus.Command
+func (r Request) CommandName() string {
+	return CommandName
+}
+
+// Validate rejects obviously invalid input before the repository is called
+func (r Request) Validate() error {
+	if r.CustomerID == "" {
+		return domain.ErrInvalidCustomerID
+	}
+	if r.Feature == "" {
+		return domain.ErrInvalidFeature
+	}
+	return nil
+}
+
+// Handle executes the use case for a command dispatched through the bus
+func (i *Interactor) Handle(ctx context.Context, cmd bus.Command) (any, error) {
+	req, ok := cmd.(Request)
+	if !ok {
+		return nil, fmt.Errorf("unexpected command type %T", cmd)
+	}
+
+	decision, err := i.Execute(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return &Response{Decision: decision}, nil
+}
@@ -0,0 +1,41 @@
+package check_entitlement
+
+import (
+	"context"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/instrument"
+)
+
+// UseCase is the check entitlement use case as seen by callers
+type UseCase interface {
+	Execute(ctx context.Context, req Request) (*domain.EntitlementDecision, error)
+}
+
+var (
+	_ UseCase = (*Interactor)(nil)
+	_ UseCase = (*Instrumented)(nil)
+)
+
+// Instrumented decorates a UseCase with logs, metrics and a trace span per execution
+type Instrumented struct {
+	next UseCase
+	in   instrument.Instrumentation
+}
+
+// NewInstrumented wraps the given use case with instrumentation
+func NewInstrumented(next UseCase, in instrument.Instrumentation) *Instrumented {
+	return &Instrumented{next: next, in: in}
+}
+
+// Execute runs the wrapped use case
+func (d *Instrumented) Execute(ctx context.Context, req Request) (*domain.EntitlementDecision, error) {
+	attrs := map[string]string{"customer_id": req.CustomerID, "feature": req.Feature}
+
+	decision, err := instrument.Run(ctx, d.in, "check_entitlement", attrs, func(ctx context.Context) (*domain.EntitlementDecision, error) {
+		return d.next.Execute(ctx, req)
+	})
+	instrument.RecordSLI(ctx, d.in, "check_entitlement", err, domain.ErrInvalidCustomerID, domain.ErrInvalidFeature)
+
+	return decision, err
+}
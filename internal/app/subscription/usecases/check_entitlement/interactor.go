@@ -0,0 +1,57 @@
+package check_entitlement
+
+import (
+	"context"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+// Request asks whether a customer is entitled to a feature
+type Request struct {
+	CustomerID string
+	Feature    string
+}
+
+// Interactor handles the check entitlement use case
+type Interactor struct {
+	entitlements contracts.EntitlementRepository
+	grace        domain.EntitlementGrace
+	clock        domain.Clock
+}
+
+// NewInteractor creates a new check entitlement interactor. Product services call it
+// on every request, so entitlements is expected to be cached, e.g. with
+// adapters.CachedEntitlements over a bounded-staleness repo.EntitlementRepo. grace is
+// what past-due subscriptions keep while dunning retries their charge.
+func NewInteractor(entitlements contracts.EntitlementRepository, grace domain.EntitlementGrace, clock domain.Clock) *Interactor {
+	return &Interactor{entitlements: entitlements, grace: grace, clock: clock}
+}
+
+// Execute decides whether the customer is entitled to the feature right now, and at
+// what limit. A customer without a subscription, or whose plans don't grant the
+// feature, is not entitled; that is a decision, not an error.
+func (i *Interactor) Execute(ctx context.Context, req Request) (*domain.EntitlementDecision, error) {
+	// 1. Load the customer's subscriptions that keep their plan's entitlements
+	subs, err := i.entitlements.FindEntitledSubscriptions(ctx, req.CustomerID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. Load the entitlements of each of their plans once
+	plans := make(map[string][]domain.Entitlement, len(subs))
+	for _, sub := range subs {
+		if _, ok := plans[sub.PlanID()]; ok {
+			continue
+		}
+		entitlements, err := i.entitlements.FindPlanEntitlements(ctx, sub.PlanID())
+		if err != nil {
+			return nil, err
+		}
+		plans[sub.PlanID()] = entitlements
+	}
+
+	// 3. Decide via domain function
+	decision := domain.DecideEntitlement(req.CustomerID, req.Feature, subs, plans, i.grace, i.clock.Now())
+	return &decision, nil
+}
@@ -0,0 +1,185 @@
+package check_entitlement
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/testkit"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/testkit/builders"
+)
+
+var (
+	startDate = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock     = domain.FixedClock{FixedTime: startDate.AddDate(0, 0, 10)}
+)
+
+func subscription(id, planID string, status domain.SubscriptionStatus) *domain.Subscription {
+	return domain.ReconstructFromPersistence(id, "cust-1", planID, 3000, status, startDate)
+}
+
+func TestCheckEntitlement_GrantedByPlan(t *testing.T) {
+	entitlements := testkit.NewFakeEntitlements().
+		WithSubscription(subscription("sub-1", "plan-pro", domain.StatusActive)).
+		WithPlan("plan-pro", domain.Entitlement{Feature: "seats", Limit: 10}, domain.Entitlement{Feature: "sso", Unlimited: true})
+	interactor := NewInteractor(entitlements, domain.GraceFull, clock)
+
+	decision, err := interactor.Execute(context.Background(), Request{CustomerID: "cust-1", Feature: "seats"})
+
+	require.NoError(t, err)
+	assert.Equal(t, &domain.EntitlementDecision{
+		CustomerID:     "cust-1",
+		Feature:        "seats",
+		Entitled:       true,
+		Limit:          10,
+		SubscriptionID: "sub-1",
+		PlanID:         "plan-pro",
+	}, decision)
+}
+
+func TestCheckEntitlement_NotEntitled(t *testing.T) {
+	testCases := []struct {
+		name string
+		subs []*domain.Subscription
+	}{
+		{name: "no subscription"},
+		{name: "plan doesn't grant the feature", subs: []*domain.Subscription{subscription("sub-1", "plan-basic", domain.StatusActive)}},
+		{name: "cancelled subscription", subs: []*domain.Subscription{subscription("sub-1", "plan-pro", domain.StatusCancelled)}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			entitlements := testkit.NewFakeEntitlements().
+				WithPlan("plan-basic", domain.Entitlement{Feature: "seats", Limit: 1}).
+				WithPlan("plan-pro", domain.Entitlement{Feature: "sso", Unlimited: true})
+			for _, sub := range tc.subs {
+				entitlements.WithSubscription(sub)
+			}
+
+			decision, err := NewInteractor(entitlements, domain.GraceFull, clock).Execute(context.Background(), Request{CustomerID: "cust-1", Feature: "sso"})
+
+			require.NoError(t, err)
+			assert.False(t, decision.Entitled)
+			assert.Empty(t, decision.SubscriptionID)
+		})
+	}
+}
+
+func TestCheckEntitlement_TrialEndsAtItsEndDate(t *testing.T) {
+	testCases := []struct {
+		name      string
+		trialDays int
+		entitled  bool
+	}{
+		{name: "trial running", trialDays: 14, entitled: true},
+		{name: "trial ending now", trialDays: 10},
+		{name: "trial past its end date", trialDays: 7},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			trial := builders.NewSubscriptionBuilder().WithID("sub-1").WithCustomerID("cust-1").WithPlan("plan-pro").StartedAt(startDate).Trialing(tc.trialDays).Build()
+			entitlements := testkit.NewFakeEntitlements().
+				WithSubscription(trial).
+				WithPlan("plan-pro", domain.Entitlement{Feature: "sso", Unlimited: true})
+
+			decision, err := NewInteractor(entitlements, domain.GraceFull, clock).Execute(context.Background(), Request{CustomerID: "cust-1", Feature: "sso"})
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.entitled, decision.Entitled)
+		})
+	}
+}
+
+func TestCheckEntitlement_MostGenerousSubscriptionWins(t *testing.T) {
+	entitlements := testkit.NewFakeEntitlements().
+		WithSubscription(subscription("sub-1", "plan-basic", domain.StatusActive)).
+		WithSubscription(subscription("sub-2", "plan-pro", domain.StatusPastDue)).
+		WithSubscription(subscription("sub-3", "plan-team", domain.StatusActive)).
+		WithPlan("plan-basic", domain.Entitlement{Feature: "seats", Limit: 5}).
+		WithPlan("plan-pro", domain.Entitlement{Feature: "seats", Limit: 25}).
+		WithPlan("plan-team", domain.Entitlement{Feature: "seats", Limit: 10})
+
+	decision, err := NewInteractor(entitlements, domain.GraceFull, clock).Execute(context.Background(), Request{CustomerID: "cust-1", Feature: "seats"})
+
+	require.NoError(t, err)
+	// A past-due subscription keeps its entitlements while dunning retries the charge
+	assert.True(t, decision.Entitled)
+	assert.Equal(t, int64(25), decision.Limit)
+	assert.Equal(t, "sub-2", decision.SubscriptionID)
+
+	entitlements.WithSubscription(subscription("sub-4", "plan-unlimited", domain.StatusActive)).
+		WithPlan("plan-unlimited", domain.Entitlement{Feature: "seats", Unlimited: true})
+
+	decision, err = NewInteractor(entitlements, domain.GraceFull, clock).Execute(context.Background(), Request{CustomerID: "cust-1", Feature: "seats"})
+
+	require.NoError(t, err)
+	assert.True(t, decision.Unlimited)
+	assert.Equal(t, "plan-unlimited", decision.PlanID)
+}
+
+func TestCheckEntitlement_PastDueDegradesToGrace(t *testing.T) {
+	testCases := []struct {
+		name     string
+		grace    domain.EntitlementGrace
+		feature  string
+		entitled bool
+		limit    int64
+	}{
+		{name: "full grace keeps the limit", grace: domain.GraceFull, feature: "seats", entitled: true, limit: 20},
+		{name: "partial grace scales the limit", grace: 2500, feature: "seats", entitled: true, limit: 5},
+		{name: "partial grace keeps unlimited", grace: 2500, feature: "sso", entitled: true},
+		{name: "no grace suspends", grace: domain.GraceNone, feature: "seats"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			entitlements := testkit.NewFakeEntitlements().
+				WithSubscription(subscription("sub-1", "plan-pro", domain.StatusPastDue)).
+				WithPlan("plan-pro", domain.Entitlement{Feature: "seats", Limit: 20}, domain.Entitlement{Feature: "sso", Unlimited: true})
+
+			decision, err := NewInteractor(entitlements, tc.grace, clock).Execute(context.Background(), Request{CustomerID: "cust-1", Feature: tc.feature})
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.entitled, decision.Entitled)
+			assert.Equal(t, tc.limit, decision.Limit)
+			assert.Equal(t, tc.entitled && tc.grace < domain.GraceFull, decision.Degraded)
+		})
+	}
+}
+
+func TestCheckEntitlement_ActiveSubscriptionOutranksDegradedOne(t *testing.T) {
+	entitlements := testkit.NewFakeEntitlements().
+		WithSubscription(subscription("sub-1", "plan-pro", domain.StatusPastDue)).
+		WithSubscription(subscription("sub-2", "plan-basic", domain.StatusActive)).
+		WithPlan("plan-pro", domain.Entitlement{Feature: "seats", Limit: 20}).
+		WithPlan("plan-basic", domain.Entitlement{Feature: "seats", Limit: 5})
+
+	decision, err := NewInteractor(entitlements, domain.GraceNone, clock).Execute(context.Background(), Request{CustomerID: "cust-1", Feature: "seats"})
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), decision.Limit)
+	assert.Equal(t, "sub-2", decision.SubscriptionID)
+	assert.False(t, decision.Degraded)
+}
+
+func TestCheckEntitlement_LooksUpEachPlanOnce(t *testing.T) {
+	entitlements := testkit.NewFakeEntitlements().
+		WithSubscription(subscription("sub-1", "plan-pro", domain.StatusActive)).
+		WithSubscription(subscription("sub-2", "plan-pro", domain.StatusActive)).
+		WithPlan("plan-pro", domain.Entitlement{Feature: "sso", Unlimited: true})
+
+	_, err := NewInteractor(entitlements, domain.GraceFull, clock).Execute(context.Background(), Request{CustomerID: "cust-1", Feature: "sso"})
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, entitlements.Lookups()) // the customer's subscriptions, then plan-pro
+}
+
+func TestRequestValidate(t *testing.T) {
+	assert.ErrorIs(t, Request{Feature: "sso"}.Validate(), domain.ErrInvalidCustomerID)
+	assert.ErrorIs(t, Request{CustomerID: "cust-1"}.Validate(), domain.ErrInvalidFeature)
+	assert.NoError(t, Request{CustomerID: "cust-1", Feature: "sso"}.Validate())
+}
@@ -0,0 +1,67 @@
+package check_payment_method
+
+import (
+	"context"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+// Interactor handles the check payment method use case
+type Interactor struct {
+	repo    contracts.SubscriptionRepository
+	billing contracts.BillingResolver
+	clock   domain.Clock
+	cycles  contracts.BillingCycleSource
+}
+
+// NewInteractor creates a new check payment method interactor
+func NewInteractor(repo contracts.SubscriptionRepository, billing contracts.BillingResolver, clock domain.Clock, cycles contracts.BillingCycleSource) *Interactor {
+	return &Interactor{
+		repo:    repo,
+		billing: billing,
+		clock:   clock,
+		cycles:  cycles,
+	}
+}
+
+// Execute flags a subscription whose payment method can't be charged at its next renewal.
+// It fails with domain.ErrPaymentMethodUsable when there is nothing to flag.
+func (i *Interactor) Execute(ctx context.Context, subscriptionID string) (*domain.PaymentMethodExpiringEvent, error) {
+	// 1. Load subscription
+	sub, err := i.repo.FindByID(ctx, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. Look up the customer's payment method with the provider that bills them
+	billingClient, err := i.billing.Resolve(ctx, sub.PlanID(), sub.CustomerID())
+	if err != nil {
+		return nil, err
+	}
+	pm, err := billingClient.GetPaymentMethodStatus(ctx, sub.CustomerID())
+	if err != nil {
+		return nil, err
+	}
+
+	// 3. Flag the subscription via domain method
+	cycle, err := i.cycles.CycleFor(ctx, sub)
+	if err != nil {
+		return nil, err
+	}
+	event, err := sub.FlagExpiringPaymentMethod(i.clock, pm, cycle)
+	if err != nil {
+		return nil, err
+	}
+
+	// 4. Save the updated subscription
+	var uow contracts.UnitOfWork
+	uow.SaveChecked(i.repo.Save(ctx, sub))
+
+	// 5. Commit the write
+	if err := uow.Commit(ctx, i.repo); err != nil {
+		return nil, err
+	}
+
+	return event, nil
+}
@@ -0,0 +1,128 @@
+package check_payment_method
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/adapters"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/testkit"
+)
+
+var (
+	startDate = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	renewsAt  = startDate.AddDate(0, 0, 30) // 2024-01-31
+	checkDate = renewsAt.AddDate(0, 0, -7)
+)
+
+func newTestInteractor(repo contracts.SubscriptionRepository, billing contracts.BillingClient) *Interactor {
+	return NewInteractor(repo, adapters.StaticBillingResolver{Client: billing}, domain.FixedClock{FixedTime: checkDate}, adapters.StaticBillingCycle{Cycle: domain.CycleOfDays(30)})
+}
+
+func activeSubscription(opts ...domain.ReconstructOption) *domain.Subscription {
+	return domain.ReconstructFromPersistence("sub-123", "cust-456", "plan-789", 3000, domain.StatusActive, startDate, opts...)
+}
+
+func TestCheckPaymentMethod_FlagsCardExpiringBeforeRenewal(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(testkit.MockSubscriptions)
+	expiresAt := domain.CardExpiry(12, 2023) // valid through December, renewal is in January
+	billing := testkit.NewFakeBillingClient().SetPaymentMethod("cust-456", domain.PaymentMethod{
+		Valid: true, Brand: "visa", Last4: "4242", ExpiresAt: expiresAt,
+	})
+	interactor := newTestInteractor(mockRepo, billing)
+
+	mockRepo.On("FindByID", ctx, "sub-123").Return(activeSubscription(), nil)
+	mockRepo.On("Save", ctx, mock.MatchedBy(func(s *domain.Subscription) bool {
+		return s.PaymentMethodFlaggedFor().Equal(renewsAt)
+	})).Return(&spanner.Mutation{}, nil)
+	mockRepo.On("Apply", ctx, mock.Anything).Return(nil)
+
+	event, err := interactor.Execute(ctx, "sub-123")
+
+	require.NoError(t, err)
+	assert.Equal(t, &domain.PaymentMethodExpiringEvent{
+		SubscriptionID: "sub-123",
+		CustomerID:     "cust-456",
+		Brand:          "visa",
+		Last4:          "4242",
+		ExpiresAt:      time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		RenewsAt:       renewsAt,
+		DetectedAt:     checkDate,
+	}, event)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCheckPaymentMethod_FlagsMissingPaymentMethod(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(testkit.MockSubscriptions)
+	billing := testkit.NewFakeBillingClient().SetPaymentMethod("cust-456", domain.PaymentMethod{})
+	interactor := newTestInteractor(mockRepo, billing)
+
+	mockRepo.On("FindByID", ctx, "sub-123").Return(activeSubscription(), nil)
+	mockRepo.On("Save", ctx, mock.Anything).Return(&spanner.Mutation{}, nil)
+	mockRepo.On("Apply", ctx, mock.Anything).Return(nil)
+
+	event, err := interactor.Execute(ctx, "sub-123")
+
+	require.NoError(t, err)
+	assert.True(t, event.ExpiresAt.IsZero())
+	assert.Equal(t, renewsAt, event.RenewsAt)
+}
+
+func TestCheckPaymentMethod_CardValidThroughRenewal(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(testkit.MockSubscriptions)
+	billing := testkit.NewFakeBillingClient().SetPaymentMethod("cust-456", domain.PaymentMethod{
+		Valid: true, ExpiresAt: domain.CardExpiry(1, 2024), // valid through January 31st
+	})
+	interactor := newTestInteractor(mockRepo, billing)
+
+	mockRepo.On("FindByID", ctx, "sub-123").Return(activeSubscription(), nil)
+
+	event, err := interactor.Execute(ctx, "sub-123")
+
+	assert.Equal(t, domain.ErrPaymentMethodUsable, err)
+	assert.Nil(t, event)
+	mockRepo.AssertNotCalled(t, "Save", ctx, mock.Anything)
+}
+
+func TestCheckPaymentMethod_FlagsOncePerRenewal(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(testkit.MockSubscriptions)
+	billing := testkit.NewFakeBillingClient().SetPaymentMethod("cust-456", domain.PaymentMethod{
+		Valid: true, ExpiresAt: domain.CardExpiry(12, 2023),
+	})
+	interactor := newTestInteractor(mockRepo, billing)
+
+	mockRepo.On("FindByID", ctx, "sub-123").Return(activeSubscription(domain.WithPaymentMethodFlaggedFor(renewsAt)), nil)
+
+	event, err := interactor.Execute(ctx, "sub-123")
+
+	assert.Equal(t, domain.ErrPaymentMethodAlreadyFlagged, err)
+	assert.Nil(t, event)
+	mockRepo.AssertNotCalled(t, "Save", ctx, mock.Anything)
+}
+
+func TestCheckPaymentMethod_BillingErrorIsReturned(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(testkit.MockSubscriptions)
+	unavailable := errors.New("billing unavailable")
+	billing := testkit.NewFakeBillingClient().FailAlways(testkit.OpGetPaymentMethodStatus, unavailable)
+	interactor := newTestInteractor(mockRepo, billing)
+
+	mockRepo.On("FindByID", ctx, "sub-123").Return(activeSubscription(), nil)
+
+	event, err := interactor.Execute(ctx, "sub-123")
+
+	assert.ErrorIs(t, err, unavailable)
+	assert.Nil(t, event)
+	mockRepo.AssertNotCalled(t, "Save", ctx, mock.Anything)
+}
@@ -0,0 +1,45 @@
+package clone_subscription
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/bus"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/create_subscription"
+)
+
+// CommandName identifies the clone subscription command on the bus
+const CommandName = "subscription.clone"
+
+var _ bus.Handler = (*Interactor)(nil)
+
+// CommandName implements bus.Command
+func (r Request) CommandName() string {
+	return CommandName
+}
+
+// Validate rejects a request without a customer before the original is loaded
+func (r Request) Validate() error {
+	if r.CustomerID == "" {
+		return domain.ErrInvalidCustomerID
+	}
+	if r.EnsureCustomer && r.CustomerEmail == "" {
+		return domain.ErrInvalidCustomerEmail
+	}
+	return nil
+}
+
+// Handle executes the use case for a command dispatched through the bus
+func (i *Interactor) Handle(ctx context.Context, cmd bus.Command) (any, error) {
+	req, ok := cmd.(Request)
+	if !ok {
+		return nil, fmt.Errorf("unexpected command type %T", cmd)
+	}
+
+	sub, event, err := i.Execute(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return &create_subscription.Response{Subscription: sub, Event: event}, nil
+}
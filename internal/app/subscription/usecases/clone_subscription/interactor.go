@@ -0,0 +1,67 @@
+package clone_subscription
+
+import (
+	"context"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/create_subscription"
+)
+
+// Request contains the input for cloning a subscription for a new customer
+type Request struct {
+	SubscriptionID string // the subscription to copy
+	CustomerID     string // the customer the copy is for
+
+	// EnsureCustomer, CustomerEmail and CustomerName are passed on to create_subscription
+	EnsureCustomer bool
+	CustomerEmail  string
+	CustomerName   string
+
+	// Metadata is set over the original's, for what differs per customer; an empty
+	// value drops the original's key
+	Metadata map[string]string
+}
+
+// Interactor handles the clone subscription use case
+type Interactor struct {
+	repo    contracts.SubscriptionRepository
+	bundles contracts.SubscriptionBundleRepository
+	create  create_subscription.UseCase
+}
+
+// NewInteractor creates a new clone subscription interactor. Copies are created through
+// create, so they are validated, billed and hooked like any other subscription.
+func NewInteractor(repo contracts.SubscriptionRepository, bundles contracts.SubscriptionBundleRepository, create create_subscription.UseCase) *Interactor {
+	return &Interactor{
+		repo:    repo,
+		bundles: bundles,
+		create:  create,
+	}
+}
+
+// Execute creates a subscription for the customer with the plan, add-ons and metadata
+// of an existing one, in any status. Only the setup is copied: the copy starts ACTIVE
+// today at the plan's current price, with none of the original's trial, billing or
+// dunning state.
+func (i *Interactor) Execute(ctx context.Context, req Request) (*domain.Subscription, *domain.SubscriptionCreatedEvent, error) {
+	// 1. Load the original and its bundle
+	original, err := i.repo.FindByID(ctx, req.SubscriptionID)
+	if err != nil {
+		return nil, nil, err
+	}
+	bundle, err := i.bundles.FindBySubscriptionID(ctx, original.ID())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// 2. Create the copy
+	return i.create.Execute(ctx, create_subscription.Request{
+		CustomerID:     req.CustomerID,
+		PlanID:         original.PlanID(),
+		EnsureCustomer: req.EnsureCustomer,
+		CustomerEmail:  req.CustomerEmail,
+		CustomerName:   req.CustomerName,
+		Bundle:         bundle.WithMetadata(req.Metadata),
+	})
+}
@@ -0,0 +1,79 @@
+package clone_subscription
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/testkit"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/create_subscription"
+)
+
+// recordingCreate records the create requests it is given and creates nothing
+type recordingCreate struct {
+	requests []create_subscription.Request
+}
+
+func (c *recordingCreate) Execute(ctx context.Context, req create_subscription.Request) (*domain.Subscription, *domain.SubscriptionCreatedEvent, error) {
+	c.requests = append(c.requests, req)
+	return nil, nil, nil
+}
+
+var startDate = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestClone_CopiesPlanAndBundle(t *testing.T) {
+	original := domain.ReconstructFromPersistence("sub-123", "cust-456", "plan-enterprise", 90000, domain.StatusPastDue, startDate,
+		domain.WithDunning(2, startDate.AddDate(0, 1, 3)))
+	repo := new(testkit.MockSubscriptions)
+	repo.On("FindByID", mock.Anything, "sub-123").Return(original, nil)
+	bundles := testkit.NewFakeBundles().With("sub-123", domain.SubscriptionBundle{
+		AddOns:   []domain.AddOnCharge{{ID: "seats-pack", Name: "Seat pack", Quantity: 3, UnitPrice: 2000}},
+		Metadata: map[string]string{"po_number": "PO-1", "segment": "enterprise"},
+	})
+	create := &recordingCreate{}
+
+	_, _, err := NewInteractor(repo, bundles, create).Execute(context.Background(), Request{
+		SubscriptionID: "sub-123",
+		CustomerID:     "cust-789",
+		Metadata:       map[string]string{"po_number": ""},
+	})
+
+	require.NoError(t, err)
+	require.Len(t, create.requests, 1)
+	assert.Equal(t, create_subscription.Request{
+		CustomerID: "cust-789",
+		PlanID:     "plan-enterprise",
+		Bundle: domain.SubscriptionBundle{
+			AddOns:   []domain.AddOnCharge{{ID: "seats-pack", Name: "Seat pack", Quantity: 3, UnitPrice: 2000}},
+			Metadata: map[string]string{"segment": "enterprise"},
+		},
+	}, create.requests[0])
+}
+
+func TestClone_WithoutBundle(t *testing.T) {
+	original := domain.ReconstructFromPersistence("sub-123", "cust-456", "plan-basic", 3000, domain.StatusActive, startDate)
+	repo := new(testkit.MockSubscriptions)
+	repo.On("FindByID", mock.Anything, "sub-123").Return(original, nil)
+	create := &recordingCreate{}
+
+	_, _, err := NewInteractor(repo, testkit.NewFakeBundles(), create).Execute(context.Background(), Request{SubscriptionID: "sub-123", CustomerID: "cust-789"})
+
+	require.NoError(t, err)
+	require.Len(t, create.requests, 1)
+	assert.True(t, create.requests[0].Bundle.IsEmpty())
+}
+
+func TestClone_UnknownSubscription(t *testing.T) {
+	repo := new(testkit.MockSubscriptions)
+	repo.On("FindByID", mock.Anything, "sub-123").Return(nil, domain.ErrSubscriptionNotFound)
+	create := &recordingCreate{}
+
+	_, _, err := NewInteractor(repo, testkit.NewFakeBundles(), create).Execute(context.Background(), Request{SubscriptionID: "sub-123", CustomerID: "cust-789"})
+
+	assert.ErrorIs(t, err, domain.ErrSubscriptionNotFound)
+	assert.Empty(t, create.requests)
+}
@@ -0,0 +1,32 @@
+package complete_charge_authentication
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/bus"
+)
+
+// CommandName identifies the complete charge authentication command on the bus
+const CommandName = "subscription.complete_charge_authentication"
+
+var _ bus.Handler = (*Interactor)(nil)
+
+// CommandName implements bus.Command
+func (r Request) CommandName() string {
+	return CommandName
+}
+
+// Handle executes the use case for a command dispatched through the bus
+func (i *Interactor) Handle(ctx context.Context, cmd bus.Command) (any, error) {
+	req, ok := cmd.(Request)
+	if !ok {
+		return nil, fmt.Errorf("unexpected command type %T", cmd)
+	}
+
+	result, err := i.Execute(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
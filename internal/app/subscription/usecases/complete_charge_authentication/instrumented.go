@@ -0,0 +1,37 @@
+package complete_charge_authentication
+
+import (
+	"context"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/instrument"
+)
+
+// UseCase is the complete charge authentication use case as seen by callers
+type UseCase interface {
+	Execute(ctx context.Context, req Request) (*Result, error)
+}
+
+var (
+	_ UseCase = (*Interactor)(nil)
+	_ UseCase = (*Instrumented)(nil)
+)
+
+// Instrumented decorates a UseCase with logs, metrics and a trace span per execution
+type Instrumented struct {
+	next UseCase
+	in   instrument.Instrumentation
+}
+
+// NewInstrumented wraps the given use case with instrumentation
+func NewInstrumented(next UseCase, in instrument.Instrumentation) *Instrumented {
+	return &Instrumented{next: next, in: in}
+}
+
+// Execute runs the wrapped use case
+func (d *Instrumented) Execute(ctx context.Context, req Request) (*Result, error) {
+	attrs := map[string]string{"provider_payment_id": req.ProviderPaymentID, "status": string(req.Status)}
+
+	return instrument.Run(ctx, d.in, "complete_charge_authentication", attrs, func(ctx context.Context) (*Result, error) {
+		return d.next.Execute(ctx, req)
+	})
+}
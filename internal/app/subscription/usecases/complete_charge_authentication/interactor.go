@@ -0,0 +1,102 @@
+package complete_charge_authentication
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+// Request carries the outcome of a charge held for authentication, as reported by the
+// billing provider, typically via webhook
+type Request struct {
+	ProviderPaymentID string
+	Status            domain.AuthenticationStatus
+	FailureReason     string
+}
+
+// Result describes the outcome; Status is the authentication's status afterwards, and
+// Recovered is set when the authenticated charge paid for the period in dunning
+type Result struct {
+	SubscriptionID string
+	Status         domain.AuthenticationStatus
+	Recovered      *domain.SubscriptionRecoveredEvent
+}
+
+// Interactor handles the complete charge authentication use case
+type Interactor struct {
+	authentications contracts.ChargeAuthenticationRepository
+	repo            contracts.SubscriptionRepository
+	credits         contracts.CreditBalanceRepository
+	pricing         contracts.PricingSource
+	clock           domain.Clock
+}
+
+// NewInteractor creates a new complete charge authentication interactor
+func NewInteractor(authentications contracts.ChargeAuthenticationRepository, repo contracts.SubscriptionRepository, credits contracts.CreditBalanceRepository, pricing contracts.PricingSource, clock domain.Clock) *Interactor {
+	return &Interactor{
+		authentications: authentications,
+		repo:            repo,
+		credits:         credits,
+		pricing:         pricing,
+		clock:           clock,
+	}
+}
+
+// Execute records whether the customer authenticated a held renewal charge. An
+// authenticated charge recovers the subscription if it is still past due for the
+// period the charge paid for; a failed one leaves it to the dunning schedule.
+func (i *Interactor) Execute(ctx context.Context, req Request) (*Result, error) {
+	// 1. Load authentication by the provider's payment ID
+	authentication, err := i.authentications.FindByProviderPaymentID(ctx, req.ProviderPaymentID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. Apply the outcome via domain method
+	changed, err := authentication.ApplyOutcome(i.clock, req.Status, req.FailureReason)
+	if err != nil {
+		return nil, err
+	}
+	result := &Result{SubscriptionID: authentication.SubscriptionID(), Status: authentication.Status()}
+	if !changed {
+		return result, nil
+	}
+
+	var uow contracts.UnitOfWork
+	uow.Save(i.authentications.Save(ctx, authentication))
+
+	// 3. Recover the subscription and spend the credit the renewal set aside for the period
+	if authentication.Status() == domain.AuthenticationSucceeded {
+		sub, err := i.repo.FindByID(ctx, authentication.SubscriptionID())
+		if err != nil {
+			return nil, err
+		}
+		if authentication.CompletesPeriodOf(sub) {
+			pricing, err := i.pricing.PricingFor(ctx, sub)
+			if err != nil {
+				return nil, err
+			}
+			if result.Recovered, err = sub.RecoverPayment(i.clock, pricing); err != nil {
+				return nil, err
+			}
+			uow.SaveChecked(i.repo.Save(ctx, sub))
+
+			if covered := authentication.CreditApplied(); covered > 0 {
+				result.Recovered.CreditApplied = covered
+				sourceID := fmt.Sprintf("%s:%d", sub.ID(), authentication.PeriodStart().Unix())
+				entry := domain.NewCreditEntry(uuid.New().String(), sub.CustomerID(), -covered, authentication.Currency(), domain.CreditSourceRenewal, sourceID, i.clock)
+				uow.Save(i.credits.Save(ctx, entry))
+			}
+		}
+	}
+
+	// 4. Commit the writes
+	if err := uow.Commit(ctx, i.repo); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
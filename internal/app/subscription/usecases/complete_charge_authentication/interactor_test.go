@@ -0,0 +1,125 @@
+package complete_charge_authentication
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/adapters"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/testkit"
+)
+
+var (
+	periodStart   = time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)
+	completedDate = time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+)
+
+type fixture struct {
+	repo            *testkit.MockSubscriptions
+	authentications *testkit.FakeAuthentications
+	credits         *testkit.FakeCreditBalances
+	sub             *domain.Subscription
+	interactor      *Interactor
+}
+
+// newFixture holds a 2000 cent charge of a 3000 cent period for authentication; the
+// other 1000 cents come from the credit balance once it completes
+func newFixture(status domain.SubscriptionStatus) *fixture {
+	f := &fixture{
+		repo:    &testkit.MockSubscriptions{},
+		credits: testkit.NewFakeCreditBalances().Grant("cust-456", 1000),
+		sub:     domain.ReconstructFromPersistence("sub-123", "cust-456", "plan-789", 3000, status, periodStart),
+	}
+	f.authentications = testkit.NewFakeAuthentications().WithAuthentication(domain.ReconstructChargeAuthentication(
+		"auth-1", "sub-123", "cust-456", "pay-1", 2000, 1000, "USD", "https://billing.example/authenticate/pay-1",
+		periodStart, domain.AuthenticationPending, "", periodStart, time.Time{},
+	))
+	f.repo.On("FindByID", mock.Anything, "sub-123").Return(f.sub, nil)
+	f.repo.On("Save", mock.Anything, f.sub).Return(&spanner.Mutation{}, nil)
+	f.repo.On("Apply", mock.Anything, mock.Anything).Return(nil)
+	f.interactor = NewInteractor(f.authentications, f.repo, f.credits, adapters.StaticPricing{}, domain.FixedClock{FixedTime: completedDate})
+	return f
+}
+
+func TestCompleteChargeAuthentication_RecoversPastDueSubscription(t *testing.T) {
+	f := newFixture(domain.StatusPastDue)
+
+	result, err := f.interactor.Execute(context.Background(), Request{ProviderPaymentID: "pay-1", Status: domain.AuthenticationSucceeded})
+
+	require.NoError(t, err)
+	assert.Equal(t, domain.AuthenticationSucceeded, result.Status)
+	assert.Equal(t, domain.StatusActive, f.sub.Status())
+	require.NotNil(t, result.Recovered)
+	assert.Equal(t, int64(1000), result.Recovered.CreditApplied)
+	assert.Equal(t, completedDate, f.authentications.Authentication("pay-1").ResolvedAt())
+	f.repo.AssertNumberOfCalls(t, "Apply", 1)
+
+	entries := f.credits.Entries()
+	require.Len(t, entries, 1)
+	assert.Equal(t, int64(-1000), entries[0].Amount())
+	assert.Equal(t, domain.CreditSourceRenewal, entries[0].Source())
+}
+
+func TestCompleteChargeAuthentication_SubscriptionNoLongerPastDue(t *testing.T) {
+	f := newFixture(domain.StatusActive)
+
+	result, err := f.interactor.Execute(context.Background(), Request{ProviderPaymentID: "pay-1", Status: domain.AuthenticationSucceeded})
+
+	require.NoError(t, err)
+	assert.Equal(t, domain.AuthenticationSucceeded, result.Status)
+	assert.Nil(t, result.Recovered)
+	assert.Empty(t, f.credits.Entries())
+	f.repo.AssertNumberOfCalls(t, "Apply", 1)
+	f.repo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+}
+
+func TestCompleteChargeAuthentication_FailureLeavesDunning(t *testing.T) {
+	f := newFixture(domain.StatusPastDue)
+
+	result, err := f.interactor.Execute(context.Background(), Request{ProviderPaymentID: "pay-1", Status: domain.AuthenticationFailed, FailureReason: "authentication_failed"})
+
+	require.NoError(t, err)
+	assert.Equal(t, domain.AuthenticationFailed, result.Status)
+	assert.Nil(t, result.Recovered)
+	assert.Equal(t, domain.StatusPastDue, f.sub.Status())
+	assert.Equal(t, "authentication_failed", f.authentications.Authentication("pay-1").FailureReason())
+	f.repo.AssertNotCalled(t, "FindByID", mock.Anything, mock.Anything)
+}
+
+func TestCompleteChargeAuthentication_PendingChangesNothing(t *testing.T) {
+	f := newFixture(domain.StatusPastDue)
+
+	result, err := f.interactor.Execute(context.Background(), Request{ProviderPaymentID: "pay-1", Status: domain.AuthenticationPending})
+
+	require.NoError(t, err)
+	assert.Equal(t, domain.AuthenticationPending, result.Status)
+	f.repo.AssertNotCalled(t, "Apply", mock.Anything, mock.Anything)
+}
+
+func TestCompleteChargeAuthentication_Rejections(t *testing.T) {
+	t.Run("already resolved", func(t *testing.T) {
+		f := newFixture(domain.StatusPastDue)
+		_, err := f.interactor.Execute(context.Background(), Request{ProviderPaymentID: "pay-1", Status: domain.AuthenticationFailed})
+		require.NoError(t, err)
+
+		_, err = f.interactor.Execute(context.Background(), Request{ProviderPaymentID: "pay-1", Status: domain.AuthenticationSucceeded})
+
+		assert.ErrorIs(t, err, domain.ErrAuthenticationResolved)
+		assert.Equal(t, domain.StatusPastDue, f.sub.Status())
+	})
+
+	t.Run("invalid status", func(t *testing.T) {
+		_, err := newFixture(domain.StatusPastDue).interactor.Execute(context.Background(), Request{ProviderPaymentID: "pay-1", Status: "SETTLED"})
+		assert.ErrorIs(t, err, domain.ErrInvalidAuthenticationStatus)
+	})
+
+	t.Run("unknown payment", func(t *testing.T) {
+		_, err := newFixture(domain.StatusPastDue).interactor.Execute(context.Background(), Request{ProviderPaymentID: "pay-2", Status: domain.AuthenticationSucceeded})
+		assert.ErrorIs(t, err, domain.ErrAuthenticationNotFound)
+	})
+}
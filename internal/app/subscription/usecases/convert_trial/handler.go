@@ -0,0 +1,37 @@
+package convert_trial
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/bus"
+)
+
+// CommandName identifies the convert trial command on the bus
+const CommandName = "subscription.convert_trial"
+
+var _ bus.Handler = (*Interactor)(nil)
+
+// Request is the bus command for converting a trial
+type Request struct {
+	SubscriptionID string
+}
+
+// CommandName implements bus.Command
+func (r Request) CommandName() string {
+	return CommandName
+}
+
+// Handle executes the use case for a command dispatched through the bus
+func (i *Interactor) Handle(ctx context.Context, cmd bus.Command) (any, error) {
+	req, ok := cmd.(Request)
+	if !ok {
+		return nil, fmt.Errorf("unexpected command type %T", cmd)
+	}
+
+	event, err := i.Execute(ctx, req.SubscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	return event, nil
+}
@@ -0,0 +1,97 @@
+package convert_trial
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+// Interactor handles the convert trial use case
+type Interactor struct {
+	repo    contracts.SubscriptionRepository
+	billing contracts.BillingResolver
+	pricing contracts.PricingSource
+	clock   domain.Clock
+	cycles  contracts.BillingCycleSource
+}
+
+// NewInteractor creates a new convert trial interactor
+func NewInteractor(repo contracts.SubscriptionRepository, billing contracts.BillingResolver, pricing contracts.PricingSource, clock domain.Clock, cycles contracts.BillingCycleSource) *Interactor {
+	return &Interactor{
+		repo:    repo,
+		billing: billing,
+		pricing: pricing,
+		clock:   clock,
+		cycles:  cycles,
+	}
+}
+
+// Execute converts a trialing subscription to a paid one. The customer is validated and
+// must have a payment method that can be charged, since a trial may have started
+// without one; the first period is then charged. Any failure leaves the subscription
+// in its trial, so the conversion can be retried once the customer has fixed it.
+func (i *Interactor) Execute(ctx context.Context, subscriptionID string) (*domain.TrialConvertedEvent, error) {
+	// 1. Load subscription
+	sub, err := i.repo.FindByID(ctx, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. Convert via domain method, at the price less the subscription's discounts
+	// (rejects subscriptions that are not trialing, so a converted trial is charged once)
+	pricing, err := i.pricing.PricingFor(ctx, sub)
+	if err != nil {
+		return nil, err
+	}
+	cycle, err := i.cycles.CycleFor(ctx, sub)
+	if err != nil {
+		return nil, err
+	}
+	event, err := sub.ConvertTrial(i.clock, cycle, pricing)
+	if err != nil {
+		return nil, err
+	}
+
+	// 3. Validate the customer and their payment method, which the trial may have skipped
+	billingClient, err := i.billing.Resolve(ctx, sub.PlanID(), sub.CustomerID())
+	if err != nil {
+		return nil, err
+	}
+	if err := billingClient.ValidateCustomer(ctx, sub.CustomerID()); err != nil {
+		return nil, err
+	}
+	pm, err := billingClient.GetPaymentMethodStatus(ctx, sub.CustomerID())
+	if err != nil {
+		return nil, err
+	}
+	if !pm.UsableAt(event.ConvertedAt) {
+		return nil, domain.ErrNoPaymentMethod
+	}
+
+	// 4. Charge the first period; a subscription converts once, so the key deduplicates
+	// a conversion retried after the charge went through
+	if event.Amount > 0 {
+		if err := billingClient.ChargeCustomer(ctx, contracts.ChargeRequest{
+			CustomerID:     sub.CustomerID(),
+			SubscriptionID: sub.ID(),
+			Amount:         event.Amount,
+			Currency:       domain.DefaultCurrency,
+			IdempotencyKey: fmt.Sprintf("%s:conversion", sub.ID()),
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	// 5. Save the converted subscription
+	var uow contracts.UnitOfWork
+	uow.SaveChecked(i.repo.Save(ctx, sub))
+
+	// 6. Commit the write
+	if err := uow.Commit(ctx, i.repo); err != nil {
+		return nil, err
+	}
+
+	return event, nil
+}
@@ -0,0 +1,101 @@
+package convert_trial
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/adapters"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/testkit"
+)
+
+var (
+	startDate   = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	convertDate = time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+)
+
+func newTrial(t *testing.T) *domain.Subscription {
+	sub, _, err := domain.NewTrialSubscription("sub-123", "cust-456", "plan-789", 3000, 14, domain.CycleOfDays(30), domain.FixedClock{FixedTime: startDate})
+	require.NoError(t, err)
+	return sub
+}
+
+func newTestInteractor(repo *testkit.MockSubscriptions, billing contracts.BillingClient) *Interactor {
+	return NewInteractor(repo, adapters.StaticBillingResolver{Client: billing}, adapters.StaticPricing{}, domain.FixedClock{FixedTime: convertDate}, adapters.StaticBillingCycle{Cycle: domain.CycleOfDays(30)})
+}
+
+func TestConvertTrial_ValidatesAndChargesFirstPeriod(t *testing.T) {
+	ctx := context.Background()
+	sub := newTrial(t)
+	repo := &testkit.MockSubscriptions{}
+	repo.On("FindByID", ctx, "sub-123").Return(sub, nil)
+	repo.On("Save", ctx, sub).Return(&spanner.Mutation{}, nil)
+	repo.On("Apply", ctx, mock.Anything).Return(nil)
+	billing := testkit.NewFakeBillingClient()
+
+	event, err := newTestInteractor(repo, billing).Execute(ctx, "sub-123")
+
+	require.NoError(t, err)
+	assert.Equal(t, domain.StatusActive, sub.Status())
+	assert.Equal(t, convertDate, sub.CurrentPeriodStart())
+	assert.Equal(t, &domain.TrialConvertedEvent{
+		SubscriptionID: "sub-123",
+		CustomerID:     "cust-456",
+		PlanID:         "plan-789",
+		Amount:         3000,
+		TrialEndDate:   startDate.AddDate(0, 0, 14),
+		PeriodStart:    convertDate,
+		PeriodEnd:      convertDate.AddDate(0, 0, 30),
+		ConvertedAt:    convertDate,
+	}, event)
+	assert.Len(t, billing.CallsTo(testkit.OpValidateCustomer), 1)
+	charges := billing.CallsTo(testkit.OpChargeCustomer)
+	require.Len(t, charges, 1)
+	assert.Equal(t, int64(3000), charges[0].Charge.Amount)
+	assert.Equal(t, "sub-123:conversion", charges[0].Charge.IdempotencyKey)
+	repo.AssertExpectations(t)
+}
+
+func TestConvertTrial_FailuresKeepTheTrial(t *testing.T) {
+	tests := []struct {
+		name    string
+		billing *testkit.FakeBillingClient
+		wantErr error
+	}{
+		{"invalid customer", testkit.NewFakeBillingClient().RejectCustomers("cust-456"), domain.ErrInvalidCustomer},
+		{"no payment method", testkit.NewFakeBillingClient().SetPaymentMethod("cust-456", domain.PaymentMethod{}), domain.ErrNoPaymentMethod},
+		{"expired card", testkit.NewFakeBillingClient().SetPaymentMethod("cust-456", domain.PaymentMethod{Valid: true, ExpiresAt: domain.CardExpiry(12, 2023)}), domain.ErrNoPaymentMethod},
+		{"declined charge", testkit.NewFakeBillingClient().FailAlways(testkit.OpChargeCustomer, domain.ErrPaymentDeclined), domain.ErrPaymentDeclined},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := &testkit.MockSubscriptions{}
+			repo.On("FindByID", ctx, "sub-123").Return(newTrial(t), nil)
+
+			_, err := newTestInteractor(repo, tc.billing).Execute(ctx, "sub-123")
+
+			assert.ErrorIs(t, err, tc.wantErr)
+			repo.AssertNotCalled(t, "Apply", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestConvertTrial_RejectsSubscriptionsNotTrialing(t *testing.T) {
+	ctx := context.Background()
+	repo := &testkit.MockSubscriptions{}
+	repo.On("FindByID", ctx, "sub-123").Return(domain.ReconstructFromPersistence("sub-123", "cust-456", "plan-789", 3000, domain.StatusActive, startDate), nil)
+	billing := testkit.NewFakeBillingClient()
+
+	_, err := newTestInteractor(repo, billing).Execute(ctx, "sub-123")
+
+	assert.ErrorIs(t, err, domain.ErrNotTrialing)
+	assert.Empty(t, billing.Calls())
+}
@@ -0,0 +1,45 @@
+package create_coupon
+
+import (
+	"context"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+// Request contains the input for creating a coupon
+type Request struct {
+	Code  string
+	Terms domain.CouponTerms
+}
+
+// Interactor handles the create coupon use case
+type Interactor struct {
+	coupons contracts.CouponRepository
+	clock   domain.Clock
+}
+
+// NewInteractor creates a new create coupon interactor
+func NewInteractor(coupons contracts.CouponRepository, clock domain.Clock) *Interactor {
+	return &Interactor{
+		coupons: coupons,
+		clock:   clock,
+	}
+}
+
+// Execute creates a coupon customers can redeem when they subscribe, from now until it
+// expires or is redeemed as many times as it allows
+func (i *Interactor) Execute(ctx context.Context, req Request) (*domain.Coupon, error) {
+	// 1. Create coupon via domain constructor, which normalizes the code
+	coupon, err := domain.NewCoupon(req.Code, req.Terms, i.clock)
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. Insert it; a taken code fails here
+	if err := i.coupons.Insert(ctx, coupon); err != nil {
+		return nil, err
+	}
+
+	return coupon, nil
+}
@@ -0,0 +1,80 @@
+package create_coupon
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/testkit"
+)
+
+var now = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func launchRequest() Request {
+	return Request{
+		Code:  "launch-20",
+		Terms: domain.CouponTerms{PercentOff: 2000, Duration: domain.CouponRepeating, Periods: 3, MaxRedemptions: 500, ExpiresAt: now.AddDate(0, 1, 0)},
+	}
+}
+
+func TestCreateCoupon(t *testing.T) {
+	coupons := testkit.NewFakeCoupons()
+	interactor := NewInteractor(coupons, domain.FixedClock{FixedTime: now})
+
+	coupon, err := interactor.Execute(context.Background(), launchRequest())
+
+	require.NoError(t, err)
+	assert.Equal(t, "LAUNCH-20", coupon.Code())
+	assert.Equal(t, launchRequest().Terms, coupon.Terms())
+	assert.Zero(t, coupon.TimesRedeemed())
+	assert.Equal(t, now, coupon.CreatedAt())
+
+	stored, err := coupons.FindByCode(context.Background(), "LAUNCH-20")
+	require.NoError(t, err)
+	assert.Equal(t, coupon.Terms(), stored.Terms())
+}
+
+func TestCreateCoupon_CodeTaken(t *testing.T) {
+	interactor := NewInteractor(testkit.NewFakeCoupons(), domain.FixedClock{FixedTime: now})
+	_, err := interactor.Execute(context.Background(), launchRequest())
+	require.NoError(t, err)
+
+	req := launchRequest()
+	req.Code = "Launch-20"
+	_, err = interactor.Execute(context.Background(), req)
+
+	assert.ErrorIs(t, err, domain.ErrCouponAlreadyExists)
+}
+
+func TestCreateCoupon_Rejections(t *testing.T) {
+	for name, tc := range map[string]struct {
+		change  func(*Request)
+		wantErr error
+	}{
+		"empty code":               {func(r *Request) { r.Code = " " }, domain.ErrInvalidCouponCode},
+		"code with spaces":         {func(r *Request) { r.Code = "LAUNCH 20" }, domain.ErrInvalidCouponCode},
+		"nothing off":              {func(r *Request) { r.Terms.PercentOff = 0 }, domain.ErrInvalidCoupon},
+		"percent and amount off":   {func(r *Request) { r.Terms.AmountOff = 500 }, domain.ErrInvalidCoupon},
+		"over 100%":                {func(r *Request) { r.Terms.PercentOff = 10001 }, domain.ErrInvalidCoupon},
+		"repeating for no periods": {func(r *Request) { r.Terms.Periods = 0 }, domain.ErrInvalidCoupon},
+		"periods when once":        {func(r *Request) { r.Terms.Duration = domain.CouponOnce }, domain.ErrInvalidCoupon},
+		"unknown duration":         {func(r *Request) { r.Terms.Duration = "weekly" }, domain.ErrInvalidCoupon},
+		"negative redemptions":     {func(r *Request) { r.Terms.MaxRedemptions = -1 }, domain.ErrInvalidCoupon},
+	} {
+		t.Run(name, func(t *testing.T) {
+			coupons := testkit.NewFakeCoupons()
+			interactor := NewInteractor(coupons, domain.FixedClock{FixedTime: now})
+			req := launchRequest()
+			tc.change(&req)
+
+			_, err := interactor.Execute(context.Background(), req)
+
+			assert.ErrorIs(t, err, tc.wantErr)
+			_, err = coupons.FindByCode(context.Background(), "LAUNCH-20")
+			assert.ErrorIs(t, err, domain.ErrCouponNotFound)
+		})
+	}
+}
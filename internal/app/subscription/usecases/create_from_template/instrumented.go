@@ -0,0 +1,42 @@
+package create_from_template
+
+import (
+	"context"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/create_subscription"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/instrument"
+)
+
+// UseCase is the create from template use case as seen by callers
+type UseCase interface {
+	Execute(ctx context.Context, req Request) (*domain.Subscription, *domain.SubscriptionCreatedEvent, error)
+}
+
+var (
+	_ UseCase = (*Interactor)(nil)
+	_ UseCase = (*Instrumented)(nil)
+)
+
+// Instrumented decorates a UseCase with logs, metrics and a trace span per execution.
+// Subscriptions created are counted by the create use case it wraps.
+type Instrumented struct {
+	next UseCase
+	in   instrument.Instrumentation
+}
+
+// NewInstrumented wraps the given use case with instrumentation
+func NewInstrumented(next UseCase, in instrument.Instrumentation) *Instrumented {
+	return &Instrumented{next: next, in: in}
+}
+
+// Execute runs the wrapped use case
+func (d *Instrumented) Execute(ctx context.Context, req Request) (*domain.Subscription, *domain.SubscriptionCreatedEvent, error) {
+	attrs := map[string]string{"template_id": req.TemplateID, "customer_id": req.CustomerID}
+
+	resp, err := instrument.Run(ctx, d.in, "create_from_template", attrs, func(ctx context.Context) (create_subscription.Response, error) {
+		sub, event, err := d.next.Execute(ctx, req)
+		return create_subscription.Response{Subscription: sub, Event: event}, err
+	})
+	return resp.Subscription, resp.Event, err
+}
@@ -0,0 +1,61 @@
+package create_from_template
+
+import (
+	"context"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/create_subscription"
+)
+
+// Request contains the input for creating a subscription from a template
+type Request struct {
+	TemplateID string
+	CustomerID string
+
+	// EnsureCustomer, CustomerEmail and CustomerName are passed on to create_subscription
+	EnsureCustomer bool
+	CustomerEmail  string
+	CustomerName   string
+
+	// Metadata is set over the template's, for what differs per customer; an empty
+	// value drops the template's key
+	Metadata map[string]string
+}
+
+// Interactor handles the create from template use case
+type Interactor struct {
+	templates contracts.SubscriptionTemplateRepository
+	create    create_subscription.UseCase
+}
+
+// NewInteractor creates a new create from template interactor. Subscriptions are
+// created through create, so they are validated, billed and hooked like any other.
+func NewInteractor(templates contracts.SubscriptionTemplateRepository, create create_subscription.UseCase) *Interactor {
+	return &Interactor{
+		templates: templates,
+		create:    create,
+	}
+}
+
+// Execute creates a subscription for the customer with the template's plan, price,
+// trial, add-ons and metadata
+func (i *Interactor) Execute(ctx context.Context, req Request) (*domain.Subscription, *domain.SubscriptionCreatedEvent, error) {
+	// 1. Load template
+	template, err := i.templates.FindByID(ctx, req.TemplateID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// 2. Create the subscription it describes
+	return i.create.Execute(ctx, create_subscription.Request{
+		CustomerID:     req.CustomerID,
+		PlanID:         template.PlanID(),
+		PriceCents:     template.Price(),
+		EnsureCustomer: req.EnsureCustomer,
+		CustomerEmail:  req.CustomerEmail,
+		CustomerName:   req.CustomerName,
+		TrialDays:      template.TrialDays(),
+		Bundle:         template.Bundle().WithMetadata(req.Metadata),
+	})
+}
@@ -0,0 +1,76 @@
+package create_from_template
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/testkit"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/create_subscription"
+)
+
+// recordingCreate records the create requests it is given and creates nothing
+type recordingCreate struct {
+	requests []create_subscription.Request
+}
+
+func (c *recordingCreate) Execute(ctx context.Context, req create_subscription.Request) (*domain.Subscription, *domain.SubscriptionCreatedEvent, error) {
+	c.requests = append(c.requests, req)
+	return nil, nil, nil
+}
+
+var now = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func enterpriseTemplate() *domain.SubscriptionTemplate {
+	return domain.ReconstructSubscriptionTemplate("tmpl-1", "Enterprise onboarding", "plan-enterprise", 90000, 14, domain.SubscriptionBundle{
+		AddOns:   []domain.AddOnCharge{{ID: "sso", Name: "SSO", Quantity: 1, UnitPrice: 5000}},
+		Metadata: map[string]string{"segment": "enterprise", "region": "emea"},
+	}, now)
+}
+
+func TestCreateFromTemplate(t *testing.T) {
+	create := &recordingCreate{}
+	interactor := NewInteractor(testkit.NewFakeTemplates().With(enterpriseTemplate()), create)
+
+	_, _, err := interactor.Execute(context.Background(), Request{
+		TemplateID:     "tmpl-1",
+		CustomerID:     "cust-1",
+		EnsureCustomer: true,
+		CustomerEmail:  "ops@example.com",
+		Metadata:       map[string]string{"po_number": "PO-7", "region": ""},
+	})
+
+	require.NoError(t, err)
+	require.Len(t, create.requests, 1)
+	assert.Equal(t, create_subscription.Request{
+		CustomerID:     "cust-1",
+		PlanID:         "plan-enterprise",
+		PriceCents:     90000,
+		EnsureCustomer: true,
+		CustomerEmail:  "ops@example.com",
+		TrialDays:      14,
+		Bundle: domain.SubscriptionBundle{
+			AddOns:   []domain.AddOnCharge{{ID: "sso", Name: "SSO", Quantity: 1, UnitPrice: 5000}},
+			Metadata: map[string]string{"segment": "enterprise", "po_number": "PO-7"},
+		},
+	}, create.requests[0])
+	assert.Equal(t, map[string]string{"segment": "enterprise", "region": "emea"}, enterpriseTemplate().Bundle().Metadata)
+}
+
+func TestCreateFromTemplate_UnknownTemplate(t *testing.T) {
+	create := &recordingCreate{}
+	interactor := NewInteractor(testkit.NewFakeTemplates(), create)
+
+	_, _, err := interactor.Execute(context.Background(), Request{TemplateID: "tmpl-1", CustomerID: "cust-1"})
+
+	assert.ErrorIs(t, err, domain.ErrTemplateNotFound)
+	assert.Empty(t, create.requests)
+}
+
+func TestRequestValidate_RequiresCustomer(t *testing.T) {
+	assert.ErrorIs(t, Request{TemplateID: "tmpl-1"}.Validate(), domain.ErrInvalidCustomerID)
+	assert.ErrorIs(t, Request{TemplateID: "tmpl-1", CustomerID: "cust-1", EnsureCustomer: true}.Validate(), domain.ErrInvalidCustomerEmail)
+}
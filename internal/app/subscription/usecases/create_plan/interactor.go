@@ -0,0 +1,51 @@
+package create_plan
+
+import (
+	"context"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+// Request contains the input for creating a plan
+type Request struct {
+	PlanID  string
+	Details domain.PlanDetails
+}
+
+// Response is the plan created and its event
+type Response struct {
+	Plan  *domain.Plan
+	Event *domain.PlanCatalogUpdatedEvent
+}
+
+// Interactor handles the create plan use case
+type Interactor struct {
+	plans contracts.PlanRepository
+	clock domain.Clock
+}
+
+// NewInteractor creates a new create plan interactor
+func NewInteractor(plans contracts.PlanRepository, clock domain.Clock) *Interactor {
+	return &Interactor{
+		plans: plans,
+		clock: clock,
+	}
+}
+
+// Execute creates an active plan maintained by hand, for plans the billing provider's
+// catalog doesn't list. Customers can subscribe to it right away.
+func (i *Interactor) Execute(ctx context.Context, req Request) (*domain.Plan, *domain.PlanCatalogUpdatedEvent, error) {
+	// 1. Create plan via domain constructor
+	plan, event, err := domain.NewPlan(req.PlanID, req.Details, i.clock)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// 2. Insert it; a taken plan ID fails here
+	if err := i.plans.Insert(ctx, plan); err != nil {
+		return nil, nil, err
+	}
+
+	return plan, event, nil
+}
@@ -0,0 +1,83 @@
+package create_plan
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/testkit"
+)
+
+var now = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func annualRequest() Request {
+	return Request{
+		PlanID:  "plan-annual",
+		Details: domain.PlanDetails{Name: "Annual", PriceCents: 30000, Currency: "USD", Interval: domain.IntervalYear, TrialDays: 14},
+	}
+}
+
+func TestCreatePlan(t *testing.T) {
+	plans := testkit.NewFakePlans()
+	interactor := NewInteractor(plans, domain.FixedClock{FixedTime: now})
+
+	plan, event, err := interactor.Execute(context.Background(), annualRequest())
+
+	require.NoError(t, err)
+	assert.Equal(t, annualRequest().Details, plan.Details())
+	assert.True(t, plan.Active())
+	assert.Equal(t, now, plan.CreatedAt())
+	assert.True(t, event.Created)
+	assert.Equal(t, []domain.PlanFieldChange{
+		{Field: "name", Old: "", New: "Annual"},
+		{Field: "price_cents", Old: "0", New: "30000"},
+		{Field: "currency", Old: "", New: "USD"},
+		{Field: "billing_interval", Old: "", New: "year"},
+		{Field: "trial_days", Old: "0", New: "14"},
+		{Field: "active", Old: "false", New: "true"},
+	}, event.Changes)
+
+	stored, err := plans.FindByID(context.Background(), "plan-annual")
+	require.NoError(t, err)
+	assert.Same(t, plan, stored)
+}
+
+func TestCreatePlan_IDTaken(t *testing.T) {
+	interactor := NewInteractor(testkit.NewFakePlans(), domain.FixedClock{FixedTime: now})
+	_, _, err := interactor.Execute(context.Background(), annualRequest())
+	require.NoError(t, err)
+
+	_, _, err = interactor.Execute(context.Background(), annualRequest())
+
+	assert.ErrorIs(t, err, domain.ErrPlanAlreadyExists)
+}
+
+func TestCreatePlan_Rejections(t *testing.T) {
+	for name, tc := range map[string]struct {
+		change  func(*Request)
+		wantErr error
+	}{
+		"no ID":          {func(r *Request) { r.PlanID = "" }, domain.ErrInvalidPlanID},
+		"no name":        {func(r *Request) { r.Details.Name = "" }, domain.ErrInvalidPlanName},
+		"no price":       {func(r *Request) { r.Details.PriceCents = 0 }, domain.ErrInvalidPrice},
+		"bad currency":   {func(r *Request) { r.Details.Currency = "usd" }, domain.ErrInvalidCurrency},
+		"other currency": {func(r *Request) { r.Details.Currency = "EUR" }, domain.ErrUnsupportedCurrency},
+		"bad interval":   {func(r *Request) { r.Details.Interval = "fortnight" }, domain.ErrInvalidBillingInterval},
+		"negative trial": {func(r *Request) { r.Details.TrialDays = -1 }, domain.ErrInvalidTrialDays},
+	} {
+		t.Run(name, func(t *testing.T) {
+			plans := testkit.NewFakePlans()
+			req := annualRequest()
+			tc.change(&req)
+
+			_, _, err := NewInteractor(plans, domain.FixedClock{FixedTime: now}).Execute(context.Background(), req)
+
+			assert.ErrorIs(t, err, tc.wantErr)
+			all, _ := plans.FindAll(context.Background())
+			assert.Empty(t, all)
+		})
+	}
+}
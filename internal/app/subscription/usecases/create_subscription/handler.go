@@ -0,0 +1,103 @@
+package create_subscription
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/bus"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+// CommandName identifies the create subscription command on the bus
+const CommandName = "subscription.create"
+
+var _ bus.Handler = (*Interactor)(nil)
+
+// Response is the bus result of a create subscription command
+type Response struct {
+	Subscription *domain.Subscription
+	Event        *domain.SubscriptionCreatedEvent
+}
+
+// CommandName implements bus.Command
+func (r Request) CommandName() string {
+	return CommandName
+}
+
+// Validate rejects obviously invalid input before the billing API is called
+func (r Request) Validate() error {
+	if r.CustomerID == "" {
+		return domain.ErrInvalidCustomerID
+	}
+	if r.PlanID == "" {
+		return domain.ErrInvalidPlanID
+	}
+	if r.PriceCents < 0 {
+		return domain.ErrInvalidPrice
+	}
+	if r.TrialDays < 0 {
+		return domain.ErrInvalidTrialDays
+	}
+	if r.EnsureCustomer && r.CustomerEmail == "" {
+		return domain.ErrInvalidCustomerEmail
+	}
+	if len(r.IdempotencyKey) > maxIdempotencyKeyLength {
+		return domain.ErrInvalidIdempotencyKey
+	}
+	if err := r.Bundle.Validate(); err != nil {
+		return err
+	}
+	if r.ReferralCode != "" {
+		if _, err := domain.NormalizeReferralCode(r.ReferralCode); err != nil {
+			return err
+		}
+	}
+	if r.CouponCode != "" {
+		if _, err := domain.NormalizeCouponCode(r.CouponCode); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Handle executes the use case for a command dispatched through the bus
+func (i *Interactor) Handle(ctx context.Context, cmd bus.Command) (any, error) {
+	req, ok := cmd.(Request)
+	if !ok {
+		return nil, fmt.Errorf("unexpected command type %T", cmd)
+	}
+
+	sub, event, err := i.Execute(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Response{Subscription: sub, Event: event}, nil
+}
+
+var _ UseCase = (*Dispatched)(nil)
+
+// Dispatched is a UseCase that sends each request through the bus, so the bus
+// middleware sees it
+type Dispatched struct {
+	bus bus.Dispatcher
+}
+
+// NewDispatched creates a use case dispatching through d, on which an *Interactor
+// is registered under CommandName
+func NewDispatched(d bus.Dispatcher) *Dispatched {
+	return &Dispatched{bus: d}
+}
+
+// Execute dispatches the request
+func (d *Dispatched) Execute(ctx context.Context, req Request) (*domain.Subscription, *domain.SubscriptionCreatedEvent, error) {
+	result, err := d.bus.Dispatch(ctx, req)
+	if err != nil {
+		return nil, nil, err
+	}
+	resp, ok := result.(*Response)
+	if !ok {
+		return nil, nil, fmt.Errorf("unexpected result type %T", result)
+	}
+	return resp.Subscription, resp.Event, nil
+}
@@ -0,0 +1,67 @@
+package create_subscription
+
+import (
+	"context"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/metrics"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/instrument"
+)
+
+// UseCase is the create subscription use case as seen by callers
+type UseCase interface {
+	Execute(ctx context.Context, req Request) (*domain.Subscription, *domain.SubscriptionCreatedEvent, error)
+}
+
+var (
+	_ UseCase = (*Interactor)(nil)
+	_ UseCase = (*Instrumented)(nil)
+)
+
+// Instrumented decorates a UseCase with logs, metrics and a trace span per execution,
+// counts subscriptions created per plan, and counts each request against the create SLO
+type Instrumented struct {
+	next UseCase
+	in   instrument.Instrumentation
+}
+
+// NewInstrumented wraps the given use case with instrumentation
+func NewInstrumented(next UseCase, in instrument.Instrumentation) *Instrumented {
+	return &Instrumented{next: next, in: in}
+}
+
+// Execute runs the wrapped use case
+func (d *Instrumented) Execute(ctx context.Context, req Request) (*domain.Subscription, *domain.SubscriptionCreatedEvent, error) {
+	attrs := map[string]string{"customer_id": req.CustomerID, "plan_id": req.PlanID}
+
+	resp, err := instrument.Run(ctx, d.in, "create_subscription", attrs, func(ctx context.Context) (Response, error) {
+		sub, event, err := d.next.Execute(ctx, req)
+		return Response{Subscription: sub, Event: event}, err
+	})
+	// A retry answered with the subscription its key created created nothing
+	if err == nil && resp.Event != nil {
+		d.in.Metrics.IncCounter(metrics.SubscriptionsCreated, map[string]string{"plan_id": req.PlanID})
+	}
+	instrument.RecordSLI(ctx, d.in, "create_subscription", err,
+		domain.ErrInvalidCustomer,
+		domain.ErrInvalidCustomerID,
+		domain.ErrInvalidCustomerEmail,
+		domain.ErrInvalidPlanID,
+		domain.ErrInvalidPrice,
+		domain.ErrPlanNotFound,
+		domain.ErrPlanInactive,
+		domain.ErrPlanPriceMismatch,
+		domain.ErrInvalidSubscriptionBundle,
+		domain.ErrInvalidReferralCode,
+		domain.ErrReferralCodeNotFound,
+		domain.ErrSelfReferral,
+		domain.ErrInvalidCouponCode,
+		domain.ErrCouponNotFound,
+		domain.ErrCouponExpired,
+		domain.ErrCouponRedemptionLimitReached,
+		domain.ErrRejectedByHook,
+		domain.ErrInvalidIdempotencyKey,
+	)
+
+	return resp.Subscription, resp.Event, err
+}
@@ -2,59 +2,295 @@ package create_subscription
 
 import (
 	"context"
+	"errors"
+	"fmt"
 
 	"github.com/google/uuid"
 	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
 	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
 )
 
+// FlagTrialWithoutPaymentMethod lets trials start without validating the customer in
+// billing, so no payment method is needed until the trial converts
+const FlagTrialWithoutPaymentMethod = "create.trial_without_payment_method"
+
+// maxIdempotencyKeyLength is the longest key the idempotency_keys table holds
+const maxIdempotencyKeyLength = 255
+
 // Request contains the input for creating a subscription
 type Request struct {
 	CustomerID string
 	PlanID     string
+	// PriceCents is the price the caller quoted the customer. The subscription is
+	// always created at the plan's price; a quote that differs fails with
+	// ErrPlanPriceMismatch rather than subscribing the customer at another price.
+	// Zero quotes nothing.
 	PriceCents int64
+
+	// EnsureCustomer provisions the customer in the billing provider first, when it
+	// doesn't exist there yet. CustomerEmail is then required.
+	EnsureCustomer bool
+	CustomerEmail  string
+	CustomerName   string
+
+	// ReferralCode is another customer's referral code the customer signed up with.
+	// Both are credited after the subscription's first paid renewal.
+	ReferralCode string
+
+	// CouponCode is a coupon the customer redeems. Its discount applies to the charges
+	// of as many paid periods as the coupon lasts, and to the refunds of them.
+	CouponCode string
+
+	// TrialDays starts the subscription with a free trial of this many days; zero
+	// gives it the plan's trial, starting it ACTIVE when the plan has none. The trial
+	// is charged when convert_trial converts it.
+	TrialDays int64
+
+	// Bundle is the add-ons and metadata the subscription is set up with, if any
+	Bundle domain.SubscriptionBundle
+
+	// IdempotencyKey identifies the request across the client's retries. A retry with
+	// the key of a request that created a subscription gets that subscription back,
+	// whatever the rest of the request, and nothing else happens. Keys are the
+	// customer's; empty creates a subscription every time.
+	IdempotencyKey string
 }
 
 // Interactor handles the create subscription use case
 type Interactor struct {
-	repo          contracts.SubscriptionRepository
-	billingClient contracts.BillingClient
-	clock         domain.Clock
+	repo      contracts.SubscriptionRepository
+	plans     contracts.PlanRepository
+	referrals contracts.ReferralRepository
+	bundles   contracts.SubscriptionBundleRepository
+	keys      contracts.IdempotencyKeyRepository
+	coupons   contracts.CouponRepository
+	billing   contracts.BillingResolver
+	flags     contracts.FeatureFlags
+	hooks     contracts.SubscriptionHooks
+	events    contracts.EventPublisher
+	clock     domain.Clock
+
+	// billingCycleDays is the period length of plans without a billing interval
+	billingCycleDays int64
 }
 
 // NewInteractor creates a new create subscription interactor
-func NewInteractor(repo contracts.SubscriptionRepository, billingClient contracts.BillingClient, clock domain.Clock) *Interactor {
+func NewInteractor(repo contracts.SubscriptionRepository, plans contracts.PlanRepository, referrals contracts.ReferralRepository, bundles contracts.SubscriptionBundleRepository, keys contracts.IdempotencyKeyRepository, coupons contracts.CouponRepository, billing contracts.BillingResolver, flags contracts.FeatureFlags, hooks contracts.SubscriptionHooks, events contracts.EventPublisher, clock domain.Clock, billingCycleDays int64) *Interactor {
 	return &Interactor{
-		repo:          repo,
-		billingClient: billingClient,
-		clock:         clock,
+		repo:      repo,
+		plans:     plans,
+		referrals: referrals,
+		bundles:   bundles,
+		keys:      keys,
+		coupons:   coupons,
+		billing:   billing,
+		flags:     flags,
+		hooks:     hooks,
+		events:    events,
+		clock:     clock,
+
+		billingCycleDays: billingCycleDays,
 	}
 }
 
-// Execute creates a new subscription
+// Execute creates a new subscription. A request whose idempotency key already created
+// one returns that subscription with a nil event, since nothing happened.
 func (i *Interactor) Execute(ctx context.Context, req Request) (*domain.Subscription, *domain.SubscriptionCreatedEvent, error) {
-	// 1. Validate customer with external API
-	if err := i.billingClient.ValidateCustomer(ctx, req.CustomerID); err != nil {
+	// 1. Return the subscription of an earlier attempt with the same key
+	if len(req.IdempotencyKey) > maxIdempotencyKeyLength {
+		return nil, nil, domain.ErrInvalidIdempotencyKey
+	}
+	if req.IdempotencyKey != "" {
+		sub, err := i.created(ctx, req)
+		if !errors.Is(err, domain.ErrIdempotencyKeyNotFound) {
+			return sub, nil, err
+		}
+	}
+
+	// 2. Look up the plan, the referral code's owner and the coupon and check the
+	// bundle, so bad input fails before billing is called
+	if err := req.Bundle.Validate(); err != nil {
+		return nil, nil, err
+	}
+	plan, err := i.plan(ctx, req)
+	if err != nil {
+		return nil, nil, err
+	}
+	trialDays := req.TrialDays
+	if trialDays == 0 {
+		trialDays = plan.TrialDays()
+	}
+	code, referrerID, err := i.resolveReferralCode(ctx, req.ReferralCode)
+	if err != nil {
+		return nil, nil, err
+	}
+	coupon, redeemed, err := i.redeemCoupon(ctx, req.CouponCode)
+	if err != nil {
 		return nil, nil, err
 	}
 
-	// 2. Create domain aggregate
-	id := uuid.New().String()
-	sub, event, err := domain.NewSubscription(id, req.CustomerID, req.PlanID, req.PriceCents, i.clock)
+	// 3. Resolve the billing provider that owns the plan
+	billingClient, err := i.billing.Resolve(ctx, req.PlanID, req.CustomerID)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	// 3. Get mutation for saving subscription
-	mutation, err := i.repo.Save(ctx, sub)
+	// 4. Provision the customer in billing if asked to; an existing customer is left as is
+	if req.EnsureCustomer {
+		if err := billingClient.CreateCustomer(ctx, contracts.CreateCustomerRequest{
+			CustomerID: req.CustomerID,
+			Email:      req.CustomerEmail,
+			Name:       req.CustomerName,
+		}); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// 5. Validate customer, unless a trial may start without a payment method; its
+	// conversion validates the customer instead
+	target := contracts.FlagTarget{CustomerID: req.CustomerID, PlanID: req.PlanID}
+	if trialDays == 0 || !i.flags.Enabled(ctx, FlagTrialWithoutPaymentMethod, target) {
+		if err := billingClient.ValidateCustomer(ctx, req.CustomerID); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// 6. Create domain aggregate at the plan's price
+	id := uuid.New().String()
+	var (
+		sub   *domain.Subscription
+		event *domain.SubscriptionCreatedEvent
+	)
+	cycle := plan.BillingCycle(i.billingCycleDays)
+	if trialDays != 0 {
+		sub, event, err = domain.NewTrialSubscription(id, req.CustomerID, req.PlanID, plan.PriceCents(), trialDays, cycle, i.clock)
+	} else {
+		sub, event, err = domain.NewSubscription(id, req.CustomerID, req.PlanID, plan.PriceCents(), cycle, i.clock)
+	}
 	if err != nil {
 		return nil, nil, err
 	}
+	if coupon != nil {
+		if err := sub.ApplyCoupon(redeemed); err != nil {
+			return nil, nil, err
+		}
+		event.CouponCode = coupon.Code()
+	}
 
-	// 4. Apply the mutation
-	if err := i.repo.Apply(ctx, mutation); err != nil {
+	// 7. Save the subscription, its bundle, the referral and coupon it was created with
+	// and the key that created it
+	var uow contracts.UnitOfWork
+	uow.SaveChecked(i.repo.Save(ctx, sub))
+	if coupon != nil {
+		uow.SaveAll(i.coupons.SaveRedemption(ctx, coupon, sub.ID(), i.clock.Now()))
+	}
+	if req.IdempotencyKey != "" {
+		uow.Save(i.keys.Save(ctx, req.CustomerID, req.IdempotencyKey, sub.ID(), i.clock.Now()))
+	}
+	if !req.Bundle.IsEmpty() {
+		uow.SaveAll(i.bundles.Save(ctx, sub.ID(), req.Bundle))
+	}
+	if code != "" {
+		referral, err := domain.NewReferral(uuid.New().String(), code, referrerID, sub, i.clock)
+		if err != nil {
+			return nil, nil, err
+		}
+		uow.Save(i.referrals.Save(ctx, referral))
+		event.ReferralID = referral.ID()
+		event.ReferrerCustomerID = referrerID
+	}
+	if err := uow.Err(); err != nil {
 		return nil, nil, err
 	}
 
+	// 8. Let the deployment's hooks veto the subscription before it is saved
+	if err := i.hooks.BeforeCreate(ctx, sub, event); err != nil {
+		return nil, nil, fmt.Errorf("%w: %w", domain.ErrRejectedByHook, err)
+	}
+
+	// 9. Commit the writes, then announce the subscription. A concurrent attempt with
+	// the same key that committed first fails the commit with its key, and its
+	// subscription is returned instead. One that redeemed the same coupon first fails
+	// it too, and a retry redeems the coupon again if it still allows.
+	if err := uow.Commit(ctx, i.repo); err != nil {
+		if req.IdempotencyKey != "" {
+			if sub, findErr := i.created(ctx, req); findErr == nil {
+				return sub, nil, nil
+			}
+		}
+		return nil, nil, err
+	}
+	i.hooks.AfterCreate(ctx, sub, event)
+	i.events.PublishCreated(ctx, event)
+
 	return sub, event, nil
 }
+
+// created returns the subscription an earlier request with the idempotency key created,
+// or domain.ErrIdempotencyKeyNotFound
+func (i *Interactor) created(ctx context.Context, req Request) (*domain.Subscription, error) {
+	id, err := i.keys.FindSubscriptionID(ctx, req.CustomerID, req.IdempotencyKey)
+	if err != nil {
+		return nil, err
+	}
+	return i.repo.FindByID(ctx, id)
+}
+
+// plan returns the plan the request subscribes to, as long as customers can subscribe
+// to it at the price the request quotes. A plan saved in another currency before plans
+// were held to DefaultCurrency is refused, since it would be charged in that one.
+func (i *Interactor) plan(ctx context.Context, req Request) (*domain.Plan, error) {
+	plan, err := i.plans.FindByID(ctx, req.PlanID)
+	if err != nil {
+		return nil, err
+	}
+	if !plan.Active() {
+		return nil, domain.ErrPlanInactive
+	}
+	if err := domain.ValidatePlanCurrency(plan.Currency()); err != nil {
+		return nil, err
+	}
+	if req.PriceCents != 0 && req.PriceCents != plan.PriceCents() {
+		return nil, domain.ErrPlanPriceMismatch
+	}
+	return plan, nil
+}
+
+// resolveReferralCode normalizes a referral code and returns it with its owner. An
+// empty code resolves to no referral.
+func (i *Interactor) resolveReferralCode(ctx context.Context, code string) (string, string, error) {
+	if code == "" {
+		return "", "", nil
+	}
+	code, err := domain.NormalizeReferralCode(code)
+	if err != nil {
+		return "", "", err
+	}
+	referrerID, err := i.referrals.FindCodeOwner(ctx, code)
+	if err != nil {
+		return "", "", err
+	}
+	return code, referrerID, nil
+}
+
+// redeemCoupon looks up the coupon a customer redeems and counts the redemption,
+// returning it with its discount as the subscription carries it. An empty code
+// redeems none.
+func (i *Interactor) redeemCoupon(ctx context.Context, code string) (*domain.Coupon, domain.SubscriptionCoupon, error) {
+	if code == "" {
+		return nil, domain.SubscriptionCoupon{}, nil
+	}
+	code, err := domain.NormalizeCouponCode(code)
+	if err != nil {
+		return nil, domain.SubscriptionCoupon{}, err
+	}
+	coupon, err := i.coupons.FindByCode(ctx, code)
+	if err != nil {
+		return nil, domain.SubscriptionCoupon{}, err
+	}
+	redeemed, err := coupon.Redeem(i.clock)
+	if err != nil {
+		return nil, domain.SubscriptionCoupon{}, err
+	}
+	return coupon, redeemed, nil
+}
@@ -0,0 +1,455 @@
+package create_subscription
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/adapters"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/testkit"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/testkit/builders"
+)
+
+var now = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// catalog holds plan-1, the plan the tests subscribe to, at 30.00 USD, and plans
+func catalog(plans ...*domain.Plan) *testkit.FakePlans {
+	return testkit.NewFakePlans(append([]*domain.Plan{domain.ReconstructPlan("plan-1", "Plan 1", 3000, "USD", "", 0, true, "", "", now, now)}, plans...)...)
+}
+
+func newTestInteractor(repo contracts.SubscriptionRepository, billing contracts.BillingClient) *Interactor {
+	return NewInteractor(repo, catalog(), testkit.NewFakeReferrals(), testkit.NewFakeBundles(), testkit.NewFakeIdempotencyKeys(), testkit.NewFakeCoupons(), adapters.StaticBillingResolver{Client: billing}, adapters.StaticFeatureFlags{}, adapters.HookChain{}, adapters.NoopEventPublisher{}, domain.FixedClock{FixedTime: now}, 30)
+}
+
+func TestCreateSubscription_ExistingCustomer(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(testkit.MockSubscriptions)
+	billing := testkit.NewFakeBillingClient()
+	interactor := newTestInteractor(mockRepo, billing)
+
+	mockRepo.On("Save", ctx, mock.Anything).Return(&spanner.Mutation{}, nil)
+	mockRepo.On("Apply", ctx, mock.Anything).Return(nil)
+
+	sub, event, err := interactor.Execute(ctx, Request{CustomerID: "cust-1", PlanID: "plan-1", PriceCents: 3000})
+
+	require.NoError(t, err)
+	assert.Equal(t, "cust-1", sub.CustomerID())
+	assert.Equal(t, sub.ID(), event.SubscriptionID)
+	assert.Empty(t, billing.CallsTo(testkit.OpCreateCustomer))
+	assert.Len(t, billing.CallsTo(testkit.OpValidateCustomer), 1)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCreateSubscription_PricedAndTrialledByThePlan(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(testkit.MockSubscriptions)
+	plans := catalog(domain.ReconstructPlan("plan-trial", "Trial", 4500, "USD", domain.IntervalMonth, 7, true, "", "", now, now))
+	interactor := NewInteractor(mockRepo, plans, testkit.NewFakeReferrals(), testkit.NewFakeBundles(), testkit.NewFakeIdempotencyKeys(), testkit.NewFakeCoupons(), adapters.StaticBillingResolver{Client: testkit.NewFakeBillingClient()}, adapters.StaticFeatureFlags{}, adapters.HookChain{}, adapters.NoopEventPublisher{}, domain.FixedClock{FixedTime: now}, 30)
+	mockRepo.On("Save", ctx, mock.Anything).Return(&spanner.Mutation{}, nil)
+	mockRepo.On("Apply", ctx, mock.Anything).Return(nil)
+
+	sub, event, err := interactor.Execute(ctx, Request{CustomerID: "cust-1", PlanID: "plan-trial"})
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(4500), sub.Price())
+	assert.Equal(t, int64(4500), event.Price)
+	assert.Equal(t, domain.StatusTrialing, sub.Status())
+	assert.Equal(t, now.AddDate(0, 0, 7), sub.TrialEndDate())
+}
+
+func TestCreateSubscription_PlanRejections(t *testing.T) {
+	plans := catalog(
+		domain.ReconstructPlan("plan-retired", "Retired", 1000, "USD", "", 0, false, "", "", now, now),
+		domain.ReconstructPlan("plan-euro", "Euro", 1000, "EUR", "", 0, true, "", "", now, now),
+	)
+	for name, tc := range map[string]struct {
+		req     Request
+		wantErr error
+	}{
+		"unknown plan":   {Request{CustomerID: "cust-1", PlanID: "plan-missing"}, domain.ErrPlanNotFound},
+		"inactive plan":  {Request{CustomerID: "cust-1", PlanID: "plan-retired"}, domain.ErrPlanInactive},
+		"price mismatch": {Request{CustomerID: "cust-1", PlanID: "plan-1", PriceCents: 2500}, domain.ErrPlanPriceMismatch},
+		"other currency": {Request{CustomerID: "cust-1", PlanID: "plan-euro"}, domain.ErrUnsupportedCurrency},
+	} {
+		t.Run(name, func(t *testing.T) {
+			billing := testkit.NewFakeBillingClient()
+			interactor := NewInteractor(new(testkit.MockSubscriptions), plans, testkit.NewFakeReferrals(), testkit.NewFakeBundles(), testkit.NewFakeIdempotencyKeys(), testkit.NewFakeCoupons(), adapters.StaticBillingResolver{Client: billing}, adapters.StaticFeatureFlags{}, adapters.HookChain{}, adapters.NoopEventPublisher{}, domain.FixedClock{FixedTime: now}, 30)
+
+			_, _, err := interactor.Execute(context.Background(), tc.req)
+
+			assert.ErrorIs(t, err, tc.wantErr)
+			assert.Empty(t, billing.CallsTo(testkit.OpValidateCustomer), "bad input fails before billing is called")
+		})
+	}
+}
+
+func TestCreateSubscription_EnsuresCustomerBeforeValidating(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(testkit.MockSubscriptions)
+	billing := testkit.NewFakeBillingClient()
+	interactor := newTestInteractor(mockRepo, billing)
+
+	mockRepo.On("Save", ctx, mock.Anything).Return(&spanner.Mutation{}, nil)
+	mockRepo.On("Apply", ctx, mock.Anything).Return(nil)
+
+	_, _, err := interactor.Execute(ctx, Request{
+		CustomerID:     "cust-new",
+		PlanID:         "plan-1",
+		PriceCents:     3000,
+		EnsureCustomer: true,
+		CustomerEmail:  "new@example.com",
+		CustomerName:   "New Customer",
+	})
+
+	require.NoError(t, err)
+	calls := billing.Calls()
+	require.Len(t, calls, 2)
+	assert.Equal(t, testkit.OpCreateCustomer, calls[0].Op)
+	assert.Equal(t, contracts.CreateCustomerRequest{CustomerID: "cust-new", Email: "new@example.com", Name: "New Customer"}, calls[0].Customer)
+	assert.Equal(t, testkit.OpValidateCustomer, calls[1].Op)
+}
+
+func TestCreateSubscription_CustomerProvisioningFails(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(testkit.MockSubscriptions)
+	provisioningErr := errors.New("billing unavailable")
+	billing := testkit.NewFakeBillingClient().FailAlways(testkit.OpCreateCustomer, provisioningErr)
+	interactor := newTestInteractor(mockRepo, billing)
+
+	_, _, err := interactor.Execute(ctx, Request{CustomerID: "cust-new", PlanID: "plan-1", PriceCents: 3000, EnsureCustomer: true, CustomerEmail: "new@example.com"})
+
+	assert.ErrorIs(t, err, provisioningErr)
+	assert.Empty(t, billing.CallsTo(testkit.OpValidateCustomer))
+	mockRepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+}
+
+func TestCreateSubscription_InvalidCustomer(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(testkit.MockSubscriptions)
+	billing := testkit.NewFakeBillingClient().RejectCustomers("cust-bad")
+	interactor := newTestInteractor(mockRepo, billing)
+
+	_, _, err := interactor.Execute(ctx, Request{CustomerID: "cust-bad", PlanID: "plan-1", PriceCents: 3000})
+
+	assert.ErrorIs(t, err, domain.ErrInvalidCustomer)
+	mockRepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+}
+
+func TestCreateSubscription_Trial(t *testing.T) {
+	tests := []struct {
+		name          string
+		flags         adapters.StaticFeatureFlags
+		wantValidated int
+	}{
+		{name: "flag off validates the customer", flags: adapters.StaticFeatureFlags{}, wantValidated: 1},
+		{name: "flag on skips validation", flags: adapters.StaticFeatureFlags{FlagTrialWithoutPaymentMethod: {Enabled: true}}, wantValidated: 0},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			mockRepo := new(testkit.MockSubscriptions)
+			billing := testkit.NewFakeBillingClient().RejectCustomers("cust-1")
+			if tc.wantValidated > 0 {
+				billing = testkit.NewFakeBillingClient()
+			}
+			interactor := NewInteractor(mockRepo, catalog(), testkit.NewFakeReferrals(), testkit.NewFakeBundles(), testkit.NewFakeIdempotencyKeys(), testkit.NewFakeCoupons(), adapters.StaticBillingResolver{Client: billing}, tc.flags, adapters.HookChain{}, adapters.NoopEventPublisher{}, domain.FixedClock{FixedTime: now}, 30)
+			mockRepo.On("Save", ctx, mock.Anything).Return(&spanner.Mutation{}, nil)
+			mockRepo.On("Apply", ctx, mock.Anything).Return(nil)
+
+			sub, event, err := interactor.Execute(ctx, Request{CustomerID: "cust-1", PlanID: "plan-1", PriceCents: 3000, TrialDays: 14})
+
+			require.NoError(t, err)
+			assert.Equal(t, domain.StatusTrialing, sub.Status())
+			assert.Equal(t, now.AddDate(0, 0, 14), sub.TrialEndDate())
+			assert.Equal(t, sub.TrialEndDate(), event.TrialEndDate)
+			assert.Len(t, billing.CallsTo(testkit.OpValidateCustomer), tc.wantValidated)
+		})
+	}
+}
+
+func TestCreateSubscription_FlagDoesNotSkipValidationWithoutTrial(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(testkit.MockSubscriptions)
+	billing := testkit.NewFakeBillingClient().RejectCustomers("cust-1")
+	flags := adapters.StaticFeatureFlags{FlagTrialWithoutPaymentMethod: {Enabled: true}}
+	interactor := NewInteractor(mockRepo, catalog(), testkit.NewFakeReferrals(), testkit.NewFakeBundles(), testkit.NewFakeIdempotencyKeys(), testkit.NewFakeCoupons(), adapters.StaticBillingResolver{Client: billing}, flags, adapters.HookChain{}, adapters.NoopEventPublisher{}, domain.FixedClock{FixedTime: now}, 30)
+
+	_, _, err := interactor.Execute(ctx, Request{CustomerID: "cust-1", PlanID: "plan-1", PriceCents: 3000})
+
+	assert.ErrorIs(t, err, domain.ErrInvalidCustomer)
+	mockRepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+}
+
+func TestRequestValidate_EnsureCustomerRequiresEmail(t *testing.T) {
+	req := Request{CustomerID: "cust-new", PlanID: "plan-1", PriceCents: 3000, EnsureCustomer: true}
+
+	assert.ErrorIs(t, req.Validate(), domain.ErrInvalidCustomerEmail)
+}
+
+func TestRequestValidate_RejectsNegativeTrial(t *testing.T) {
+	req := Request{CustomerID: "cust-1", PlanID: "plan-1", PriceCents: 3000, TrialDays: -1}
+
+	assert.ErrorIs(t, req.Validate(), domain.ErrInvalidTrialDays)
+}
+
+func TestCreateSubscription_SavesBundle(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(testkit.MockSubscriptions)
+	bundles := testkit.NewFakeBundles()
+	interactor := NewInteractor(mockRepo, catalog(), testkit.NewFakeReferrals(), bundles, testkit.NewFakeIdempotencyKeys(), testkit.NewFakeCoupons(), adapters.StaticBillingResolver{Client: testkit.NewFakeBillingClient()}, adapters.StaticFeatureFlags{}, adapters.HookChain{}, adapters.NoopEventPublisher{}, domain.FixedClock{FixedTime: now}, 30)
+	bundle := domain.SubscriptionBundle{
+		AddOns:   []domain.AddOnCharge{{ID: "sso", Name: "SSO", Quantity: 1, UnitPrice: 5000}},
+		Metadata: map[string]string{"account_manager": "emea-2"},
+	}
+
+	mockRepo.On("Save", ctx, mock.Anything).Return(&spanner.Mutation{}, nil)
+	mockRepo.On("Apply", ctx, mock.MatchedBy(func(mutations []*spanner.Mutation) bool { return len(mutations) == 2 })).Return(nil)
+
+	sub, _, err := interactor.Execute(ctx, Request{CustomerID: "cust-1", PlanID: "plan-1", PriceCents: 3000, Bundle: bundle})
+
+	require.NoError(t, err)
+	saved, err := bundles.FindBySubscriptionID(ctx, sub.ID())
+	require.NoError(t, err)
+	assert.Equal(t, bundle, saved)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCreateSubscription_InvalidBundle(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(testkit.MockSubscriptions)
+	billing := testkit.NewFakeBillingClient()
+	interactor := newTestInteractor(mockRepo, billing)
+	bundle := domain.SubscriptionBundle{AddOns: []domain.AddOnCharge{{ID: "sso", Name: "SSO", Quantity: 0, UnitPrice: 5000}}}
+
+	_, _, err := interactor.Execute(ctx, Request{CustomerID: "cust-1", PlanID: "plan-1", PriceCents: 3000, Bundle: bundle})
+
+	assert.ErrorIs(t, err, domain.ErrInvalidSubscriptionBundle)
+	assert.Empty(t, billing.Calls())
+	mockRepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+}
+
+func TestCreateSubscription_WithReferralCode(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(testkit.MockSubscriptions)
+	referrals := testkit.NewFakeReferrals().WithCode("cust-referrer", "ABCD2345")
+	interactor := NewInteractor(mockRepo, catalog(), referrals, testkit.NewFakeBundles(), testkit.NewFakeIdempotencyKeys(), testkit.NewFakeCoupons(), adapters.StaticBillingResolver{Client: testkit.NewFakeBillingClient()}, adapters.StaticFeatureFlags{}, adapters.HookChain{}, adapters.NoopEventPublisher{}, domain.FixedClock{FixedTime: now}, 30)
+
+	mockRepo.On("Save", ctx, mock.Anything).Return(&spanner.Mutation{}, nil)
+	mockRepo.On("Apply", ctx, mock.MatchedBy(func(m []*spanner.Mutation) bool { return len(m) == 2 })).Return(nil)
+
+	sub, event, err := interactor.Execute(ctx, Request{CustomerID: "cust-1", PlanID: "plan-1", PriceCents: 3000, ReferralCode: " abcd2345 "})
+
+	require.NoError(t, err)
+	referral := referrals.Referral(sub.ID())
+	require.NotNil(t, referral)
+	assert.Equal(t, "ABCD2345", referral.Code())
+	assert.Equal(t, "cust-referrer", referral.ReferrerCustomerID())
+	assert.Equal(t, "cust-1", referral.RefereeCustomerID())
+	assert.Equal(t, domain.ReferralPending, referral.Status())
+	assert.Equal(t, referral.ID(), event.ReferralID)
+	assert.Equal(t, "cust-referrer", event.ReferrerCustomerID)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCreateSubscription_ReferralCodeRejections(t *testing.T) {
+	tests := []struct {
+		name    string
+		code    string
+		wantErr error
+	}{
+		{"malformed", "ABC-1", domain.ErrInvalidReferralCode},
+		{"unknown", "ZZZZ9999", domain.ErrReferralCodeNotFound},
+		{"own code", "MYCD2345", domain.ErrSelfReferral},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			mockRepo := new(testkit.MockSubscriptions)
+			referrals := testkit.NewFakeReferrals().WithCode("cust-1", "MYCD2345")
+			interactor := NewInteractor(mockRepo, catalog(), referrals, testkit.NewFakeBundles(), testkit.NewFakeIdempotencyKeys(), testkit.NewFakeCoupons(), adapters.StaticBillingResolver{Client: testkit.NewFakeBillingClient()}, adapters.StaticFeatureFlags{}, adapters.HookChain{}, adapters.NoopEventPublisher{}, domain.FixedClock{FixedTime: now}, 30)
+			mockRepo.On("Save", ctx, mock.Anything).Return(&spanner.Mutation{}, nil)
+
+			_, _, err := interactor.Execute(ctx, Request{CustomerID: "cust-1", PlanID: "plan-1", PriceCents: 3000, ReferralCode: tc.code})
+
+			assert.ErrorIs(t, err, tc.wantErr)
+			mockRepo.AssertNotCalled(t, "Apply", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestCreateSubscription_WithCoupon(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(testkit.MockSubscriptions)
+	coupon, err := domain.NewCoupon("LAUNCH20", domain.CouponTerms{PercentOff: 2000, Duration: domain.CouponRepeating, Periods: 3, MaxRedemptions: 100}, domain.FixedClock{FixedTime: now})
+	require.NoError(t, err)
+	coupons := testkit.NewFakeCoupons(coupon)
+	interactor := NewInteractor(mockRepo, catalog(), testkit.NewFakeReferrals(), testkit.NewFakeBundles(), testkit.NewFakeIdempotencyKeys(), coupons, adapters.StaticBillingResolver{Client: testkit.NewFakeBillingClient()}, adapters.StaticFeatureFlags{}, adapters.HookChain{}, adapters.NoopEventPublisher{}, domain.FixedClock{FixedTime: now}, 30)
+
+	mockRepo.On("Save", ctx, mock.Anything).Return(&spanner.Mutation{}, nil)
+	// The subscription, the coupon's count and its redemption
+	mockRepo.On("Apply", ctx, mock.MatchedBy(func(m []*spanner.Mutation) bool { return len(m) == 3 })).Return(nil)
+
+	sub, event, err := interactor.Execute(ctx, Request{CustomerID: "cust-1", PlanID: "plan-1", CouponCode: " launch20 "})
+
+	require.NoError(t, err)
+	assert.Equal(t, domain.SubscriptionCoupon{Code: "LAUNCH20", PercentOff: 2000, PeriodsLeft: 3}, sub.Coupon())
+	assert.Equal(t, int64(2400), sub.PeriodPrice(domain.Pricing{}).Net)
+	assert.Equal(t, "LAUNCH20", event.CouponCode)
+	assert.Equal(t, []string{sub.ID()}, coupons.Redemptions("LAUNCH20"))
+	stored, err := coupons.FindByCode(ctx, "LAUNCH20")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), stored.TimesRedeemed())
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCreateSubscription_CouponRejections(t *testing.T) {
+	expiresAt := now.Add(-time.Hour)
+	tests := []struct {
+		name    string
+		code    string
+		wantErr error
+	}{
+		{"malformed", "LAUNCH 20", domain.ErrInvalidCouponCode},
+		{"unknown", "NOPE", domain.ErrCouponNotFound},
+		{"expired", "EXPIRED", domain.ErrCouponExpired},
+		{"fully redeemed", "ONCEONLY", domain.ErrCouponRedemptionLimitReached},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			mockRepo := new(testkit.MockSubscriptions)
+			billing := testkit.NewFakeBillingClient()
+			coupons := testkit.NewFakeCoupons(
+				domain.ReconstructCoupon("EXPIRED", domain.CouponTerms{AmountOff: 500, Duration: domain.CouponOnce, ExpiresAt: expiresAt}, 0, expiresAt),
+				domain.ReconstructCoupon("ONCEONLY", domain.CouponTerms{AmountOff: 500, Duration: domain.CouponOnce, MaxRedemptions: 1}, 1, now),
+			)
+			interactor := NewInteractor(mockRepo, catalog(), testkit.NewFakeReferrals(), testkit.NewFakeBundles(), testkit.NewFakeIdempotencyKeys(), coupons, adapters.StaticBillingResolver{Client: billing}, adapters.StaticFeatureFlags{}, adapters.HookChain{}, adapters.NoopEventPublisher{}, domain.FixedClock{FixedTime: now}, 30)
+
+			_, _, err := interactor.Execute(ctx, Request{CustomerID: "cust-1", PlanID: "plan-1", CouponCode: tc.code})
+
+			assert.ErrorIs(t, err, tc.wantErr)
+			assert.Empty(t, billing.Calls())
+			assert.Empty(t, coupons.Redemptions(tc.code))
+			mockRepo.AssertNotCalled(t, "Apply", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestCreateSubscription_RunsHooksAroundSave(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(testkit.MockSubscriptions)
+	hooks := &testkit.RecordingHooks{}
+	interactor := NewInteractor(mockRepo, catalog(), testkit.NewFakeReferrals(), testkit.NewFakeBundles(), testkit.NewFakeIdempotencyKeys(), testkit.NewFakeCoupons(), adapters.StaticBillingResolver{Client: testkit.NewFakeBillingClient()}, adapters.StaticFeatureFlags{}, hooks, adapters.NoopEventPublisher{}, domain.FixedClock{FixedTime: now}, 30)
+
+	mockRepo.On("Save", ctx, mock.Anything).Return(&spanner.Mutation{}, nil)
+	mockRepo.On("Apply", ctx, mock.Anything).Return(nil)
+
+	_, _, err := interactor.Execute(ctx, Request{CustomerID: "cust-1", PlanID: "plan-1", PriceCents: 3000})
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"BeforeCreate", "AfterCreate"}, hooks.Calls())
+}
+
+func TestCreateSubscription_PublishesCreatedOnceSaved(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(testkit.MockSubscriptions)
+	events := &testkit.RecordingEvents{}
+	interactor := NewInteractor(mockRepo, catalog(), testkit.NewFakeReferrals(), testkit.NewFakeBundles(), testkit.NewFakeIdempotencyKeys(), testkit.NewFakeCoupons(), adapters.StaticBillingResolver{Client: testkit.NewFakeBillingClient()}, adapters.StaticFeatureFlags{}, adapters.HookChain{}, events, domain.FixedClock{FixedTime: now}, 30)
+
+	mockRepo.On("Save", ctx, mock.Anything).Return(&spanner.Mutation{}, nil)
+	mockRepo.On("Apply", ctx, mock.Anything).Return(nil).Once()
+	mockRepo.On("Apply", ctx, mock.Anything).Return(errors.New("spanner: aborted"))
+
+	_, event, err := interactor.Execute(ctx, Request{CustomerID: "cust-1", PlanID: "plan-1", PriceCents: 3000})
+
+	require.NoError(t, err)
+	assert.Equal(t, []*domain.SubscriptionCreatedEvent{event}, events.Created())
+
+	_, _, err = interactor.Execute(ctx, Request{CustomerID: "cust-1", PlanID: "plan-1", PriceCents: 3000})
+
+	assert.Error(t, err)
+	assert.Len(t, events.Created(), 1, "nothing is published for a subscription that wasn't saved")
+	assert.Empty(t, events.Cancelled())
+}
+
+func TestCreateSubscription_HookVetoes(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(testkit.MockSubscriptions)
+	veto := errors.New("customer is on the CRM block list")
+	hooks := &testkit.RecordingHooks{Veto: veto}
+	interactor := NewInteractor(mockRepo, catalog(), testkit.NewFakeReferrals(), testkit.NewFakeBundles(), testkit.NewFakeIdempotencyKeys(), testkit.NewFakeCoupons(), adapters.StaticBillingResolver{Client: testkit.NewFakeBillingClient()}, adapters.StaticFeatureFlags{}, hooks, adapters.NoopEventPublisher{}, domain.FixedClock{FixedTime: now}, 30)
+
+	mockRepo.On("Save", ctx, mock.Anything).Return(&spanner.Mutation{}, nil)
+
+	_, _, err := interactor.Execute(ctx, Request{CustomerID: "cust-1", PlanID: "plan-1", PriceCents: 3000})
+
+	assert.ErrorIs(t, err, domain.ErrRejectedByHook)
+	assert.ErrorIs(t, err, veto)
+	assert.Equal(t, []string{"BeforeCreate"}, hooks.Calls())
+	mockRepo.AssertNotCalled(t, "Apply", mock.Anything, mock.Anything)
+}
+
+func TestCreateSubscription_RetryWithIdempotencyKeyReturnsTheSubscription(t *testing.T) {
+	ctx := context.Background()
+	subs := testkit.NewFakeSubscriptions()
+	keys := testkit.NewFakeIdempotencyKeys()
+	billing := testkit.NewFakeBillingClient()
+	events := &testkit.RecordingEvents{}
+	interactor := NewInteractor(subs, catalog(), testkit.NewFakeReferrals(), testkit.NewFakeBundles(), keys, testkit.NewFakeCoupons(), adapters.StaticBillingResolver{Client: billing}, adapters.StaticFeatureFlags{}, adapters.HookChain{}, events, domain.FixedClock{FixedTime: now}, 30)
+	req := Request{CustomerID: "cust-1", PlanID: "plan-1", PriceCents: 3000, IdempotencyKey: "order-42"}
+
+	first, event, err := interactor.Execute(ctx, req)
+	require.NoError(t, err)
+	require.NotNil(t, event)
+
+	retried, event, err := interactor.Execute(ctx, req)
+
+	require.NoError(t, err)
+	assert.Nil(t, event, "a retry creates nothing")
+	assert.Equal(t, first.ID(), retried.ID())
+	assert.Equal(t, 1, subs.Len())
+	assert.Len(t, billing.CallsTo(testkit.OpValidateCustomer), 1)
+	assert.Len(t, events.Created(), 1)
+
+	other, _, err := interactor.Execute(ctx, Request{CustomerID: "cust-2", PlanID: "plan-1", PriceCents: 3000, IdempotencyKey: "order-42"})
+	require.NoError(t, err)
+	assert.NotEqual(t, first.ID(), other.ID(), "keys are the customer's")
+}
+
+func TestCreateSubscription_RejectsLongIdempotencyKey(t *testing.T) {
+	interactor := newTestInteractor(new(testkit.MockSubscriptions), testkit.NewFakeBillingClient())
+
+	_, _, err := interactor.Execute(context.Background(), Request{CustomerID: "cust-1", PlanID: "plan-1", PriceCents: 3000, IdempotencyKey: strings.Repeat("k", 256)})
+
+	assert.ErrorIs(t, err, domain.ErrInvalidIdempotencyKey)
+}
+
+func TestCreateSubscription_ConcurrentRetryReturnsTheFirstSubscription(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(testkit.MockSubscriptions)
+	keys := testkit.NewFakeIdempotencyKeys()
+	interactor := NewInteractor(mockRepo, catalog(), testkit.NewFakeReferrals(), testkit.NewFakeBundles(), keys, testkit.NewFakeCoupons(), adapters.StaticBillingResolver{Client: testkit.NewFakeBillingClient()}, adapters.StaticFeatureFlags{}, adapters.HookChain{}, adapters.NoopEventPublisher{}, domain.FixedClock{FixedTime: now}, 30)
+	first := builders.NewSubscriptionBuilder().WithID("sub-first").WithCustomerID("cust-1").Build()
+
+	// The first attempt records the key while this one is under way
+	mockRepo.On("Save", ctx, mock.Anything).Run(func(mock.Arguments) { keys.With("cust-1", "order-42", "sub-first") }).Return(&spanner.Mutation{}, nil)
+	mockRepo.On("Apply", ctx, mock.Anything).Return(errors.New("spanner: row already exists"))
+	mockRepo.On("FindByID", ctx, "sub-first").Return(first, nil)
+
+	sub, event, err := interactor.Execute(ctx, Request{CustomerID: "cust-1", PlanID: "plan-1", PriceCents: 3000, IdempotencyKey: "order-42"})
+
+	require.NoError(t, err)
+	assert.Nil(t, event)
+	assert.Equal(t, first, sub)
+}
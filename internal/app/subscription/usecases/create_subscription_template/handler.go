@@ -0,0 +1,32 @@
+package create_subscription_template
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/bus"
+)
+
+// CommandName identifies the create subscription template command on the bus
+const CommandName = "subscription.create_template"
+
+var _ bus.Handler = (*Interactor)(nil)
+
+// CommandName implements bus.Command
+func (r Request) CommandName() string {
+	return CommandName
+}
+
+// Handle executes the use case for a command dispatched through the bus
+func (i *Interactor) Handle(ctx context.Context, cmd bus.Command) (any, error) {
+	req, ok := cmd.(Request)
+	if !ok {
+		return nil, fmt.Errorf("unexpected command type %T", cmd)
+	}
+
+	template, err := i.Execute(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return template, nil
+}
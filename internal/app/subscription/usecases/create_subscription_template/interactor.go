@@ -0,0 +1,49 @@
+package create_subscription_template
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+// Request contains the input for creating a subscription template
+type Request struct {
+	Name       string
+	PlanID     string
+	PriceCents int64
+	TrialDays  int64 // zero starts subscriptions created from the template ACTIVE
+	Bundle     domain.SubscriptionBundle
+}
+
+// Interactor handles the create subscription template use case
+type Interactor struct {
+	templates contracts.SubscriptionTemplateRepository
+	clock     domain.Clock
+}
+
+// NewInteractor creates a new create subscription template interactor
+func NewInteractor(templates contracts.SubscriptionTemplateRepository, clock domain.Clock) *Interactor {
+	return &Interactor{
+		templates: templates,
+		clock:     clock,
+	}
+}
+
+// Execute creates a template subscriptions can be created from. Templates are never
+// changed once created; a new setup is a new template.
+func (i *Interactor) Execute(ctx context.Context, req Request) (*domain.SubscriptionTemplate, error) {
+	// 1. Create template via domain constructor
+	template, err := domain.NewSubscriptionTemplate(uuid.New().String(), req.Name, req.PlanID, req.PriceCents, req.TrialDays, req.Bundle, i.clock)
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. Insert it; a taken name fails here
+	if err := i.templates.Insert(ctx, template); err != nil {
+		return nil, err
+	}
+
+	return template, nil
+}
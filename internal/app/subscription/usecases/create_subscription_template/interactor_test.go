@@ -0,0 +1,92 @@
+package create_subscription_template
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/testkit"
+)
+
+var now = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func enterpriseRequest() Request {
+	return Request{
+		Name:       "Enterprise onboarding",
+		PlanID:     "plan-enterprise",
+		PriceCents: 90000,
+		TrialDays:  14,
+		Bundle: domain.SubscriptionBundle{
+			AddOns:   []domain.AddOnCharge{{ID: "sso", Name: "SSO", Quantity: 1, UnitPrice: 5000}},
+			Metadata: map[string]string{"segment": "enterprise"},
+		},
+	}
+}
+
+func TestCreateTemplate(t *testing.T) {
+	templates := testkit.NewFakeTemplates()
+	interactor := NewInteractor(templates, domain.FixedClock{FixedTime: now})
+
+	template, err := interactor.Execute(context.Background(), enterpriseRequest())
+
+	require.NoError(t, err)
+	assert.Equal(t, "Enterprise onboarding", template.Name())
+	assert.Equal(t, "plan-enterprise", template.PlanID())
+	assert.Equal(t, int64(90000), template.Price())
+	assert.Equal(t, int64(14), template.TrialDays())
+	assert.Equal(t, enterpriseRequest().Bundle, template.Bundle())
+	assert.Equal(t, now, template.CreatedAt())
+
+	stored, err := templates.FindByID(context.Background(), template.ID())
+	require.NoError(t, err)
+	assert.Same(t, template, stored)
+}
+
+func TestCreateTemplate_NameTaken(t *testing.T) {
+	interactor := NewInteractor(testkit.NewFakeTemplates(), domain.FixedClock{FixedTime: now})
+	_, err := interactor.Execute(context.Background(), enterpriseRequest())
+	require.NoError(t, err)
+
+	_, err = interactor.Execute(context.Background(), enterpriseRequest())
+
+	assert.ErrorIs(t, err, domain.ErrTemplateNameTaken)
+}
+
+func TestCreateTemplate_Rejections(t *testing.T) {
+	for name, tc := range map[string]struct {
+		change func(*Request)
+		want   error
+	}{
+		"blank name":          {func(r *Request) { r.Name = "  " }, domain.ErrInvalidTemplateName},
+		"no plan":             {func(r *Request) { r.PlanID = "" }, domain.ErrInvalidPlanID},
+		"no price":            {func(r *Request) { r.PriceCents = 0 }, domain.ErrInvalidPrice},
+		"negative trial":      {func(r *Request) { r.TrialDays = -1 }, domain.ErrInvalidTrialDays},
+		"duplicate add-on":    {func(r *Request) { r.Bundle.AddOns = append(r.Bundle.AddOns, r.Bundle.AddOns[0]) }, domain.ErrInvalidSubscriptionBundle},
+		"add-on without name": {func(r *Request) { r.Bundle.AddOns[0].Name = "" }, domain.ErrInvalidSubscriptionBundle},
+		"blank metadata key":  {func(r *Request) { r.Bundle.Metadata[""] = "x" }, domain.ErrInvalidSubscriptionBundle},
+	} {
+		t.Run(name, func(t *testing.T) {
+			templates := testkit.NewFakeTemplates()
+			req := enterpriseRequest()
+			tc.change(&req)
+
+			_, err := NewInteractor(templates, domain.FixedClock{FixedTime: now}).Execute(context.Background(), req)
+
+			assert.ErrorIs(t, err, tc.want)
+		})
+	}
+}
+
+func TestCreateTemplate_CopiesBundle(t *testing.T) {
+	req := enterpriseRequest()
+	template, err := NewInteractor(testkit.NewFakeTemplates(), domain.FixedClock{FixedTime: now}).Execute(context.Background(), req)
+	require.NoError(t, err)
+
+	req.Bundle.Metadata["segment"] = "smb"
+	template.Bundle().Metadata["segment"] = "smb"
+
+	assert.Equal(t, "enterprise", template.Bundle().Metadata["segment"])
+}
@@ -0,0 +1,48 @@
+package deactivate_plan
+
+import (
+	"context"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+// Interactor handles the deactivate plan use case
+type Interactor struct {
+	plans contracts.PlanRepository
+	clock domain.Clock
+}
+
+// NewInteractor creates a new deactivate plan interactor
+func NewInteractor(plans contracts.PlanRepository, clock domain.Clock) *Interactor {
+	return &Interactor{
+		plans: plans,
+		clock: clock,
+	}
+}
+
+// Execute stops new subscriptions to a plan. Subscriptions already on it are left as
+// they are. An imported plan is active again if the catalog still lists it as active
+// on its next sync.
+func (i *Interactor) Execute(ctx context.Context, planID string) (*domain.PlanCatalogUpdatedEvent, error) {
+	// 1. Load plan
+	plan, err := i.plans.FindByID(ctx, planID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. Deactivate via domain method (returns event)
+	event, err := plan.Deactivate(i.clock)
+	if err != nil {
+		return nil, err
+	}
+
+	// 3. Save it
+	var uow contracts.UnitOfWork
+	uow.Save(i.plans.Save(ctx, plan))
+	if err := uow.Commit(ctx, i.plans); err != nil {
+		return nil, err
+	}
+
+	return event, nil
+}
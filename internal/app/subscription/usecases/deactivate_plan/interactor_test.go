@@ -0,0 +1,45 @@
+package deactivate_plan
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/testkit"
+)
+
+var (
+	createdAt = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	now       = time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+)
+
+func TestDeactivatePlan(t *testing.T) {
+	plans := testkit.NewFakePlans(domain.ReconstructPlan("plan-legacy", "Legacy", 900, "USD", "", 0, true, "prod_legacy", "price_legacy", createdAt, createdAt))
+	interactor := NewInteractor(plans, domain.FixedClock{FixedTime: now})
+
+	event, err := interactor.Execute(context.Background(), "plan-legacy")
+
+	require.NoError(t, err)
+	assert.Equal(t, &domain.PlanCatalogUpdatedEvent{
+		PlanID:            "plan-legacy",
+		ExternalProductID: "prod_legacy",
+		ExternalPriceID:   "price_legacy",
+		Changes:           []domain.PlanFieldChange{{Field: "active", Old: "true", New: "false"}},
+		UpdatedAt:         now,
+	}, event)
+	stored, err := plans.FindByID(context.Background(), "plan-legacy")
+	require.NoError(t, err)
+	assert.False(t, stored.Active())
+
+	_, err = interactor.Execute(context.Background(), "plan-legacy")
+	assert.ErrorIs(t, err, domain.ErrPlanInactive)
+}
+
+func TestDeactivatePlan_NotFound(t *testing.T) {
+	_, err := NewInteractor(testkit.NewFakePlans(), domain.FixedClock{FixedTime: now}).Execute(context.Background(), "plan-missing")
+
+	assert.ErrorIs(t, err, domain.ErrPlanNotFound)
+}
@@ -0,0 +1,68 @@
+package detach_add_on
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/bus"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+// CommandName identifies the detach add-on command on the bus
+const CommandName = "subscription.detach_add_on"
+
+var _ bus.Handler = (*Interactor)(nil)
+
+// CommandName implements bus.Command
+func (r Request) CommandName() string {
+	return CommandName
+}
+
+// Validate rejects obviously invalid input before the subscription is loaded
+func (r Request) Validate() error {
+	if r.AddOnID == "" {
+		return domain.ErrAddOnNotFound
+	}
+	return nil
+}
+
+// Handle executes the use case for a command dispatched through the bus
+func (i *Interactor) Handle(ctx context.Context, cmd bus.Command) (any, error) {
+	req, ok := cmd.(Request)
+	if !ok {
+		return nil, fmt.Errorf("unexpected command type %T", cmd)
+	}
+
+	event, err := i.Execute(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+var _ UseCase = (*Dispatched)(nil)
+
+// Dispatched is a UseCase that sends each request through the bus, so the bus
+// middleware sees it
+type Dispatched struct {
+	bus bus.Dispatcher
+}
+
+// NewDispatched creates a use case dispatching through d, on which an *Interactor
+// is registered under CommandName
+func NewDispatched(d bus.Dispatcher) *Dispatched {
+	return &Dispatched{bus: d}
+}
+
+// Execute dispatches the request
+func (d *Dispatched) Execute(ctx context.Context, req Request) (*domain.SubscriptionAddOnsChangedEvent, error) {
+	result, err := d.bus.Dispatch(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	event, ok := result.(*domain.SubscriptionAddOnsChangedEvent)
+	if !ok {
+		return nil, fmt.Errorf("unexpected result type %T", result)
+	}
+	return event, nil
+}
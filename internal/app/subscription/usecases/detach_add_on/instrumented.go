@@ -0,0 +1,38 @@
+package detach_add_on
+
+import (
+	"context"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/instrument"
+)
+
+// UseCase is the detach add-on use case as seen by callers
+type UseCase interface {
+	Execute(ctx context.Context, req Request) (*domain.SubscriptionAddOnsChangedEvent, error)
+}
+
+var (
+	_ UseCase = (*Interactor)(nil)
+	_ UseCase = (*Instrumented)(nil)
+)
+
+// Instrumented decorates a UseCase with logs, metrics and a trace span per execution
+type Instrumented struct {
+	next UseCase
+	in   instrument.Instrumentation
+}
+
+// NewInstrumented wraps the given use case with instrumentation
+func NewInstrumented(next UseCase, in instrument.Instrumentation) *Instrumented {
+	return &Instrumented{next: next, in: in}
+}
+
+// Execute runs the wrapped use case
+func (d *Instrumented) Execute(ctx context.Context, req Request) (*domain.SubscriptionAddOnsChangedEvent, error) {
+	attrs := map[string]string{"subscription_id": req.SubscriptionID, "add_on_id": req.AddOnID}
+
+	return instrument.Run(ctx, d.in, "detach_add_on", attrs, func(ctx context.Context) (*domain.SubscriptionAddOnsChangedEvent, error) {
+		return d.next.Execute(ctx, req)
+	})
+}
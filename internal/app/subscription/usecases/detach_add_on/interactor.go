@@ -0,0 +1,74 @@
+package detach_add_on
+
+import (
+	"context"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+// Request contains the input for detaching an add-on from a subscription
+type Request struct {
+	SubscriptionID string
+	AddOnID        string
+}
+
+// Interactor handles the detach add-on use case
+type Interactor struct {
+	repo    contracts.SubscriptionRepository
+	bundles contracts.SubscriptionBundleRepository
+	pricing contracts.PricingSource
+	cycles  contracts.BillingCycleSource
+	clock   domain.Clock
+}
+
+// NewInteractor creates a new detach add-on interactor
+func NewInteractor(repo contracts.SubscriptionRepository, bundles contracts.SubscriptionBundleRepository, pricing contracts.PricingSource, cycles contracts.BillingCycleSource, clock domain.Clock) *Interactor {
+	return &Interactor{
+		repo:    repo,
+		bundles: bundles,
+		pricing: pricing,
+		cycles:  cycles,
+		clock:   clock,
+	}
+}
+
+// Execute detaches an add-on from an active subscription. The next renewal stops
+// charging it; the unused part of the current period is not refunded, as with a
+// downgrade, so the event's negative ProratedAmount is for the record only.
+func (i *Interactor) Execute(ctx context.Context, req Request) (*domain.SubscriptionAddOnsChangedEvent, error) {
+	// 1. Load the subscription and the add-ons it is set up with
+	sub, err := i.repo.FindByID(ctx, req.SubscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	bundle, err := i.bundles.FindBySubscriptionID(ctx, sub.ID())
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. Detach via domain method
+	pricing, err := i.pricing.PricingFor(ctx, sub)
+	if err != nil {
+		return nil, err
+	}
+	cycle, err := i.cycles.CycleFor(ctx, sub)
+	if err != nil {
+		return nil, err
+	}
+	bundle, event, err := sub.DetachAddOn(i.clock, cycle, pricing, bundle, req.AddOnID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 3. Save the bundle with the subscription, whose version check fails the commit
+	// if another change got there first
+	var uow contracts.UnitOfWork
+	uow.SaveChecked(i.repo.Save(ctx, sub))
+	uow.SaveAll(i.bundles.Save(ctx, sub.ID(), bundle))
+	if err := uow.Commit(ctx, i.repo); err != nil {
+		return nil, err
+	}
+
+	return event, nil
+}
@@ -0,0 +1,74 @@
+package detach_add_on
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/adapters"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/testkit"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/testkit/builders"
+)
+
+var (
+	seats   = domain.AddOnCharge{ID: "extra-seats", Name: "Extra seats", Quantity: 3, UnitPrice: 200}
+	storage = domain.AddOnCharge{ID: "extra-storage", Name: "Extra storage", Quantity: 2, UnitPrice: 500}
+)
+
+// detachOn builds an interactor whose clock reads daysIntoPeriod days into the first
+// 30-day period of the built subscriptions
+func detachOn(subs *testkit.FakeSubscriptions, bundles *testkit.FakeBundles, daysIntoPeriod int) *Interactor {
+	clock := domain.FixedClock{FixedTime: builders.DefaultStartDate.AddDate(0, 0, daysIntoPeriod)}
+	return NewInteractor(subs, bundles, adapters.BundlePricing{Bundles: bundles, Base: adapters.StaticPricing{}}, adapters.StaticBillingCycle{Cycle: domain.CycleOfDays(30)}, clock)
+}
+
+func TestDetachAddOn_KeepsTheOtherAddOns(t *testing.T) {
+	ctx := context.Background()
+	subs := testkit.NewFakeSubscriptions().With(builders.NewSubscriptionBuilder().Build())
+	bundles := testkit.NewFakeBundles().With("sub-123", domain.SubscriptionBundle{AddOns: []domain.AddOnCharge{seats, storage}})
+	interactor := detachOn(subs, bundles, 10)
+
+	event, err := interactor.Execute(ctx, Request{SubscriptionID: "sub-123", AddOnID: "extra-storage"})
+
+	require.NoError(t, err)
+	assert.Equal(t, "extra-storage", event.AddOnID)
+	assert.Equal(t, []domain.AddOnCharge{seats}, event.AddOns)
+	assert.Equal(t, int64(-666), event.ProratedAmount) // 1000 * 20 / 30, not refunded
+	bundle, err := bundles.FindBySubscriptionID(ctx, "sub-123")
+	require.NoError(t, err)
+	assert.Equal(t, []domain.AddOnCharge{seats}, bundle.AddOns)
+	saved, err := subs.FindByID(ctx, "sub-123")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), saved.Version())
+}
+
+func TestDetachAddOn_Rejections(t *testing.T) {
+	tests := []struct {
+		name    string
+		sub     *domain.Subscription
+		addOnID string
+		wantErr error
+	}{
+		{"not attached", builders.NewSubscriptionBuilder().Build(), "priority-support", domain.ErrAddOnNotFound},
+		{"past due", builders.NewSubscriptionBuilder().PastDue().Build(), "extra-storage", domain.ErrNotActive},
+		{"cancelled", builders.NewSubscriptionBuilder().Cancelled().Build(), "extra-storage", domain.ErrNotActive},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			subs := testkit.NewFakeSubscriptions().With(tc.sub)
+			bundles := testkit.NewFakeBundles().With("sub-123", domain.SubscriptionBundle{AddOns: []domain.AddOnCharge{seats, storage}})
+			interactor := detachOn(subs, bundles, 10)
+
+			_, err := interactor.Execute(ctx, Request{SubscriptionID: "sub-123", AddOnID: tc.addOnID})
+
+			assert.ErrorIs(t, err, tc.wantErr)
+			bundle, err := bundles.FindBySubscriptionID(ctx, "sub-123")
+			require.NoError(t, err)
+			assert.Equal(t, []domain.AddOnCharge{seats, storage}, bundle.AddOns)
+		})
+	}
+}
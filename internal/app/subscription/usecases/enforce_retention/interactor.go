@@ -0,0 +1,97 @@
+package enforce_retention
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+// TargetSubscriptions is the purge audit target for cancelled subscription rows
+const TargetSubscriptions = "subscriptions"
+
+// Policy configures how long cancelled subscriptions are kept and what happens afterwards
+type Policy struct {
+	Retention time.Duration
+	Action    contracts.RetentionAction
+}
+
+// Request contains the input for a retention run
+type Request struct {
+	DryRun bool // count affected rows without changing them
+}
+
+// Report is the outcome of a retention run
+type Report struct {
+	Target       string
+	Action       contracts.RetentionAction
+	Cutoff       time.Time
+	RowsAffected int64
+	DryRun       bool
+}
+
+// Interactor handles the enforce retention use case
+type Interactor struct {
+	repo   contracts.RetentionRepository
+	clock  domain.Clock
+	policy Policy
+}
+
+// NewInteractor creates a new enforce retention interactor
+func NewInteractor(repo contracts.RetentionRepository, clock domain.Clock, policy Policy) *Interactor {
+	return &Interactor{
+		repo:   repo,
+		clock:  clock,
+		policy: policy,
+	}
+}
+
+// Execute applies the retention policy and records the run in the purge audit trail.
+// Dry runs are audited too, so the trail shows what would have been purged.
+func (i *Interactor) Execute(ctx context.Context, req Request) (*Report, error) {
+	now := i.clock.Now()
+	cutoff := now.Add(-i.policy.Retention)
+
+	// 1. Count or apply the configured action
+	var (
+		rows int64
+		err  error
+	)
+	switch {
+	case req.DryRun:
+		rows, err = i.repo.CountExpired(ctx, i.policy.Action, cutoff)
+	case i.policy.Action == contracts.RetentionAnonymize:
+		rows, err = i.repo.AnonymizeExpired(ctx, cutoff)
+	case i.policy.Action == contracts.RetentionDelete:
+		rows, err = i.repo.DeleteExpired(ctx, cutoff)
+	default:
+		return nil, fmt.Errorf("unknown retention action %q", i.policy.Action)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. Record the run
+	if err := i.repo.RecordPurge(ctx, contracts.PurgeRecord{
+		ID:           uuid.New().String(),
+		Target:       TargetSubscriptions,
+		Action:       i.policy.Action,
+		Cutoff:       cutoff,
+		RowsAffected: rows,
+		DryRun:       req.DryRun,
+		ExecutedAt:   now,
+	}); err != nil {
+		return nil, err
+	}
+
+	return &Report{
+		Target:       TargetSubscriptions,
+		Action:       i.policy.Action,
+		Cutoff:       cutoff,
+		RowsAffected: rows,
+		DryRun:       req.DryRun,
+	}, nil
+}
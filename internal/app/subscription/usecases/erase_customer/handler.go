@@ -0,0 +1,40 @@
+package erase_customer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/bus"
+)
+
+// CommandName identifies the erase customer command on the bus
+const CommandName = "customer.erase"
+
+var _ bus.Handler = (*Interactor)(nil)
+
+// Request is the bus command for erasing a customer's personal data
+type Request struct {
+	CustomerID string
+}
+
+// Privileged implements bus.Privileged: erasures are audited
+func (r Request) Privileged() {}
+
+// CommandName implements bus.Command
+func (r Request) CommandName() string {
+	return CommandName
+}
+
+// Handle executes the use case for a command dispatched through the bus
+func (i *Interactor) Handle(ctx context.Context, cmd bus.Command) (any, error) {
+	req, ok := cmd.(Request)
+	if !ok {
+		return nil, fmt.Errorf("unexpected command type %T", cmd)
+	}
+
+	report, err := i.Execute(ctx, req.CustomerID)
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
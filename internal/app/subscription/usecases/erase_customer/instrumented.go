@@ -0,0 +1,38 @@
+package erase_customer
+
+import (
+	"context"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/instrument"
+)
+
+// UseCase is the erase customer use case as seen by callers
+type UseCase interface {
+	Execute(ctx context.Context, customerID string) (*Report, error)
+}
+
+var (
+	_ UseCase = (*Interactor)(nil)
+	_ UseCase = (*Instrumented)(nil)
+)
+
+// Instrumented decorates a UseCase with logs, metrics and a trace span per execution.
+// The customer ID is deliberately not logged.
+type Instrumented struct {
+	next UseCase
+	in   instrument.Instrumentation
+}
+
+// NewInstrumented wraps the given use case with instrumentation
+func NewInstrumented(next UseCase, in instrument.Instrumentation) *Instrumented {
+	return &Instrumented{next: next, in: in}
+}
+
+// Execute runs the wrapped use case
+func (d *Instrumented) Execute(ctx context.Context, customerID string) (*Report, error) {
+	attrs := map[string]string{"tombstone": Tombstone(customerID)}
+
+	return instrument.Run(ctx, d.in, "erase_customer", attrs, func(ctx context.Context) (*Report, error) {
+		return d.next.Execute(ctx, customerID)
+	})
+}
@@ -0,0 +1,100 @@
+package erase_customer
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+// TombstonePrefix marks customer IDs replaced by an erasure
+const TombstonePrefix = "erased-"
+
+// TargetResult is the number of rows changed in one store
+type TargetResult struct {
+	Target       string `json:"target"`
+	RowsAffected int64  `json:"rows_affected"`
+}
+
+// Report is the signed record of an erasure. It identifies the customer only by tombstone.
+type Report struct {
+	ReportID  string         `json:"report_id"`
+	Tombstone string         `json:"tombstone"`
+	ErasedAt  time.Time      `json:"erased_at"`
+	Targets   []TargetResult `json:"targets"`
+	Signature string         `json:"signature"`
+}
+
+// Interactor handles the erase customer use case
+type Interactor struct {
+	repo   contracts.ErasureRepository
+	signer contracts.ReportSigner
+	clock  domain.Clock
+}
+
+// NewInteractor creates a new erase customer interactor
+func NewInteractor(repo contracts.ErasureRepository, signer contracts.ReportSigner, clock domain.Clock) *Interactor {
+	return &Interactor{
+		repo:   repo,
+		signer: signer,
+		clock:  clock,
+	}
+}
+
+// Execute replaces the customer ID with a tombstone wherever it is stored.
+// Rows are kept so revenue and churn history stay intact.
+func (i *Interactor) Execute(ctx context.Context, customerID string) (*Report, error) {
+	if customerID == "" {
+		return nil, domain.ErrInvalidCustomerID
+	}
+
+	// 1. Refuse while the customer is still being billed
+	live, err := i.repo.CountLiveByCustomer(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+	if live > 0 {
+		return nil, domain.ErrCustomerHasLiveSubscriptions
+	}
+
+	// 2. Tombstone the customer ID
+	tombstone := Tombstone(customerID)
+	tombstoned, err := i.repo.TombstoneCustomer(ctx, customerID, tombstone)
+	if err != nil {
+		return nil, err
+	}
+	targets := make([]TargetResult, 0, len(tombstoned))
+	for _, t := range tombstoned {
+		targets = append(targets, TargetResult{Target: t.Table, RowsAffected: t.RowsAffected})
+	}
+
+	// 3. Sign the report over its content without the signature
+	report := &Report{
+		ReportID:  uuid.New().String(),
+		Tombstone: tombstone,
+		ErasedAt:  i.clock.Now(),
+		Targets:   targets,
+	}
+
+	payload, err := json.Marshal(report)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal erasure report: %w", err)
+	}
+	if report.Signature, err = i.signer.Sign(payload); err != nil {
+		return nil, fmt.Errorf("failed to sign erasure report: %w", err)
+	}
+
+	return report, nil
+}
+
+// Tombstone derives the stable replacement for a customer ID
+func Tombstone(customerID string) string {
+	sum := sha256.Sum256([]byte(customerID))
+	return TombstonePrefix + hex.EncodeToString(sum[:])
+}
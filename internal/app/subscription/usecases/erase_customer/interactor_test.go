@@ -0,0 +1,93 @@
+package erase_customer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+// MockErasureRepository is a mock implementation of ErasureRepository
+type MockErasureRepository struct {
+	mock.Mock
+}
+
+func (m *MockErasureRepository) CountLiveByCustomer(ctx context.Context, customerID string) (int64, error) {
+	args := m.Called(ctx, customerID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockErasureRepository) TombstoneCustomer(ctx context.Context, customerID, tombstone string) ([]contracts.TombstonedRows, error) {
+	args := m.Called(ctx, customerID, tombstone)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]contracts.TombstonedRows), args.Error(1)
+}
+
+// fakeSigner signs by prefixing the payload length, which is enough to tell payloads apart
+type fakeSigner struct{}
+
+func (fakeSigner) Sign(payload []byte) (string, error) {
+	return fmt.Sprintf("signed-%d", len(payload)), nil
+}
+
+func TestEraseCustomer_TombstonesAndSignsReport(t *testing.T) {
+	ctx := context.Background()
+	erasedAt := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	signer := fakeSigner{}
+
+	mockRepo := new(MockErasureRepository)
+	interactor := NewInteractor(mockRepo, signer, domain.FixedClock{FixedTime: erasedAt})
+
+	tombstone := Tombstone("cust-456")
+	mockRepo.On("CountLiveByCustomer", ctx, "cust-456").Return(int64(0), nil)
+	mockRepo.On("TombstoneCustomer", ctx, "cust-456", tombstone).Return([]contracts.TombstonedRows{
+		{Table: "subscriptions", RowsAffected: 2},
+		{Table: "refunds", RowsAffected: 1},
+	}, nil)
+
+	report, err := interactor.Execute(ctx, "cust-456")
+
+	require.NoError(t, err)
+	assert.Equal(t, tombstone, report.Tombstone)
+	assert.NotContains(t, report.Tombstone, "cust-456")
+	assert.Equal(t, erasedAt, report.ErasedAt)
+	assert.Equal(t, []TargetResult{
+		{Target: "subscriptions", RowsAffected: 2},
+		{Target: "refunds", RowsAffected: 1},
+	}, report.Targets)
+
+	// The signature covers the report without its signature field
+	unsigned := *report
+	unsigned.Signature = ""
+	payload, err := json.Marshal(unsigned)
+	require.NoError(t, err)
+	expected, err := signer.Sign(payload)
+	require.NoError(t, err)
+	assert.Equal(t, expected, report.Signature)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestEraseCustomer_RejectsLiveSubscriptions(t *testing.T) {
+	ctx := context.Background()
+	signer := fakeSigner{}
+
+	mockRepo := new(MockErasureRepository)
+	interactor := NewInteractor(mockRepo, signer, domain.FixedClock{FixedTime: time.Now()})
+
+	mockRepo.On("CountLiveByCustomer", ctx, "cust-456").Return(int64(1), nil)
+
+	report, err := interactor.Execute(ctx, "cust-456")
+
+	assert.Equal(t, domain.ErrCustomerHasLiveSubscriptions, err)
+	assert.Nil(t, report)
+	mockRepo.AssertNotCalled(t, "TombstoneCustomer", ctx, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,108 @@
+package export_cancellation_reasons
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+// Format is an encoding a report can be exported in
+type Format string
+
+const (
+	FormatJSON Format = "json"
+	FormatCSV  Format = "csv"
+)
+
+// monthLayout formats cancellation months
+const monthLayout = "2006-01"
+
+// ParseFormat reads a format name, case-insensitively; empty means JSON
+func ParseFormat(name string) (Format, error) {
+	switch f := Format(strings.ToLower(strings.TrimSpace(name))); f {
+	case "", FormatJSON:
+		return FormatJSON, nil
+	case FormatCSV:
+		return FormatCSV, nil
+	default:
+		return "", domain.ErrUnsupportedExportFormat
+	}
+}
+
+// ContentType is the media type of the format
+func (f Format) ContentType() string {
+	if f == FormatCSV {
+		return "text/csv; charset=utf-8"
+	}
+	return "application/json"
+}
+
+// Write encodes the report to w in the given format
+func Write(w io.Writer, format Format, report *Report) error {
+	switch format {
+	case FormatJSON:
+		return writeJSON(w, report)
+	case FormatCSV:
+		return writeCSV(w, report)
+	default:
+		return domain.ErrUnsupportedExportFormat
+	}
+}
+
+type reportJSON struct {
+	GeneratedAt time.Time   `json:"generated_at"`
+	Months      []monthJSON `json:"months"`
+}
+
+type monthJSON struct {
+	Month         string       `json:"month"` // YYYY-MM, UTC
+	Cancellations int64        `json:"cancellations"`
+	Reasons       []reasonJSON `json:"reasons"`
+}
+
+type reasonJSON struct {
+	Reason        string `json:"reason"` // empty for cancellations without one
+	Cancellations int64  `json:"cancellations"`
+	ShareBP       int64  `json:"share_bp"`
+}
+
+func writeJSON(w io.Writer, report *Report) error {
+	out := reportJSON{GeneratedAt: report.GeneratedAt, Months: make([]monthJSON, 0, len(report.Months))}
+	for _, m := range report.Months {
+		month := monthJSON{Month: m.Month.Format(monthLayout), Cancellations: m.Cancellations, Reasons: make([]reasonJSON, 0, len(m.Reasons))}
+		for _, r := range m.Reasons {
+			month.Reasons = append(month.Reasons, reasonJSON{Reason: string(r.Code), Cancellations: r.Cancellations, ShareBP: r.ShareBP})
+		}
+		out.Months = append(out.Months, month)
+	}
+	return json.NewEncoder(w).Encode(out)
+}
+
+// writeCSV writes a row per month and reason, the long format chart tools pivot on;
+// months without cancellations have no rows
+func writeCSV(w io.Writer, report *Report) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"month", "reason", "cancellations", "share_bp"}); err != nil {
+		return err
+	}
+	for _, m := range report.Months {
+		for _, r := range m.Reasons {
+			err := cw.Write([]string{
+				m.Month.Format(monthLayout),
+				string(r.Code),
+				strconv.FormatInt(r.Cancellations, 10),
+				strconv.FormatInt(r.ShareBP, 10),
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,38 @@
+package export_cancellation_reasons
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/instrument"
+)
+
+// UseCase is the cancellation reason export use case as seen by callers
+type UseCase interface {
+	Execute(ctx context.Context, req Request) (*Report, error)
+}
+
+var (
+	_ UseCase = (*Interactor)(nil)
+	_ UseCase = (*Instrumented)(nil)
+)
+
+// Instrumented decorates a UseCase with logs, metrics and a trace span per execution
+type Instrumented struct {
+	next UseCase
+	in   instrument.Instrumentation
+}
+
+// NewInstrumented wraps the given use case with instrumentation
+func NewInstrumented(next UseCase, in instrument.Instrumentation) *Instrumented {
+	return &Instrumented{next: next, in: in}
+}
+
+// Execute runs the wrapped use case
+func (d *Instrumented) Execute(ctx context.Context, req Request) (*Report, error) {
+	attrs := map[string]string{"months": strconv.Itoa(req.Months)}
+
+	return instrument.Run(ctx, d.in, "export_cancellation_reasons", attrs, func(ctx context.Context) (*Report, error) {
+		return d.next.Execute(ctx, req)
+	})
+}
@@ -0,0 +1,107 @@
+package export_cancellation_reasons
+
+import (
+	"context"
+	"time"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+const (
+	// DefaultMonths is how many cancellation months a report covers when the request doesn't say
+	DefaultMonths = 12
+	// MaxMonths is the most cancellation months one report covers
+	MaxMonths = 60
+)
+
+// basisPoints is 100%
+const basisPoints = 10000
+
+// Request contains the input for a cancellation reason report
+type Request struct {
+	Months int // cancellation months covered, ending with the current one; zero means DefaultMonths
+}
+
+// Validate checks the request
+func (r Request) Validate() error {
+	if r.Months < 0 || r.Months > MaxMonths {
+		return domain.ErrInvalidReasonWindow
+	}
+	return nil
+}
+
+// Reason is how many of a month's cancellations gave one reason code; the empty code
+// counts those cancelled without a reason
+type Reason struct {
+	Code          domain.CancellationReasonCode
+	Cancellations int64
+	ShareBP       int64 // Cancellations as basis points of the month's cancellations
+}
+
+// Month is the reasons given by the subscriptions cancelled in one UTC month, ordered
+// by code
+type Month struct {
+	Month         time.Time // first of the month, UTC
+	Cancellations int64
+	Reasons       []Reason
+}
+
+// Report is cancellation reasons for consecutive months, oldest first. Every month in
+// the window is listed, empty ones included, so charts don't have to fill gaps.
+type Report struct {
+	GeneratedAt time.Time
+	Months      []Month
+}
+
+// Interactor handles the cancellation reason export use case
+type Interactor struct {
+	repo  contracts.ChurnRepository
+	clock domain.Clock
+}
+
+// NewInteractor creates a new cancellation reason export interactor
+func NewInteractor(repo contracts.ChurnRepository, clock domain.Clock) *Interactor {
+	return &Interactor{repo: repo, clock: clock}
+}
+
+// Execute counts the reasons cancelled subscriptions gave, month by month
+func (i *Interactor) Execute(ctx context.Context, req Request) (*Report, error) {
+	// 1. Validate request
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	months := req.Months
+	if months == 0 {
+		months = DefaultMonths
+	}
+
+	now := i.clock.Now().UTC()
+	current := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	since := current.AddDate(0, 1-months, 0)
+
+	// 2. Count cancellations by month and reason; the repository orders them
+	counts, err := i.repo.CountCancellationReasons(ctx, since)
+	if err != nil {
+		return nil, err
+	}
+	byMonth := make(map[time.Time][]contracts.CancellationReasonCount)
+	for _, c := range counts {
+		byMonth[c.Month] = append(byMonth[c.Month], c)
+	}
+
+	// 3. Build a month per month of the window, with each reason's share of cancellations
+	report := &Report{GeneratedAt: now, Months: make([]Month, 0, months)}
+	for month := since; !month.After(current); month = month.AddDate(0, 1, 0) {
+		m := Month{Month: month}
+		for _, c := range byMonth[month] {
+			m.Cancellations += c.Cancellations
+			m.Reasons = append(m.Reasons, Reason{Code: c.Reason, Cancellations: c.Cancellations})
+		}
+		for ri := range m.Reasons {
+			m.Reasons[ri].ShareBP = m.Reasons[ri].Cancellations * basisPoints / m.Cancellations
+		}
+		report.Months = append(report.Months, m)
+	}
+	return report, nil
+}
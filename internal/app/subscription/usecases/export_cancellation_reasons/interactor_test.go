@@ -0,0 +1,119 @@
+package export_cancellation_reasons
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+// MockRepository is a mock implementation of ChurnRepository
+type MockRepository struct {
+	mock.Mock
+}
+
+func (m *MockRepository) CountCancellationReasons(ctx context.Context, since time.Time) ([]contracts.CancellationReasonCount, error) {
+	args := m.Called(ctx, since)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]contracts.CancellationReasonCount), args.Error(1)
+}
+
+func month(m time.Month) time.Time {
+	return time.Date(2024, m, 1, 0, 0, 0, 0, time.UTC)
+}
+
+var now = time.Date(2024, 3, 10, 15, 4, 5, 0, time.UTC)
+
+func TestExportCancellationReasons_SharesOfEachReasonPerMonth(t *testing.T) {
+	ctx := context.Background()
+	repo := &MockRepository{}
+	repo.On("CountCancellationReasons", ctx, month(1)).Return([]contracts.CancellationReasonCount{
+		{Month: month(1), Reason: "", Cancellations: 1},
+		{Month: month(1), Reason: domain.CancellationReasonTooExpensive, Cancellations: 3},
+		{Month: month(3), Reason: domain.CancellationReasonPlanRetired, Cancellations: 2},
+	}, nil)
+
+	report, err := NewInteractor(repo, domain.FixedClock{FixedTime: now}).Execute(ctx, Request{Months: 3})
+
+	require.NoError(t, err)
+	assert.Equal(t, now, report.GeneratedAt)
+	assert.Equal(t, []Month{
+		{Month: month(1), Cancellations: 4, Reasons: []Reason{
+			{Code: "", Cancellations: 1, ShareBP: 2500},
+			{Code: domain.CancellationReasonTooExpensive, Cancellations: 3, ShareBP: 7500},
+		}},
+		{Month: month(2)},
+		{Month: month(3), Cancellations: 2, Reasons: []Reason{
+			{Code: domain.CancellationReasonPlanRetired, Cancellations: 2, ShareBP: 10000},
+		}},
+	}, report.Months)
+}
+
+func TestExportCancellationReasons_DefaultsToTwelveMonths(t *testing.T) {
+	ctx := context.Background()
+	repo := &MockRepository{}
+	repo.On("CountCancellationReasons", ctx, time.Date(2023, 4, 1, 0, 0, 0, 0, time.UTC)).Return([]contracts.CancellationReasonCount{}, nil)
+
+	report, err := NewInteractor(repo, domain.FixedClock{FixedTime: now}).Execute(ctx, Request{})
+
+	require.NoError(t, err)
+	assert.Len(t, report.Months, DefaultMonths)
+}
+
+func TestExportCancellationReasons_RejectsWindow(t *testing.T) {
+	repo := &MockRepository{}
+
+	for _, months := range []int{-1, MaxMonths + 1} {
+		_, err := NewInteractor(repo, domain.FixedClock{FixedTime: now}).Execute(context.Background(), Request{Months: months})
+		assert.ErrorIs(t, err, domain.ErrInvalidReasonWindow)
+	}
+	repo.AssertNotCalled(t, "CountCancellationReasons", mock.Anything, mock.Anything)
+}
+
+func TestExportCancellationReasons_ScanFails(t *testing.T) {
+	repo := &MockRepository{}
+	failure := errors.New("deadline exceeded")
+	repo.On("CountCancellationReasons", mock.Anything, mock.Anything).Return(nil, failure)
+
+	_, err := NewInteractor(repo, domain.FixedClock{FixedTime: now}).Execute(context.Background(), Request{})
+
+	assert.ErrorIs(t, err, failure)
+}
+
+func TestWrite_CSVAndJSON(t *testing.T) {
+	report := &Report{GeneratedAt: now, Months: []Month{
+		{Month: month(2)},
+		{Month: month(3), Cancellations: 4, Reasons: []Reason{
+			{Code: "", Cancellations: 1, ShareBP: 2500},
+			{Code: domain.CancellationReasonTooExpensive, Cancellations: 3, ShareBP: 7500},
+		}},
+	}}
+
+	var csv bytes.Buffer
+	require.NoError(t, Write(&csv, FormatCSV, report))
+	assert.Equal(t, "month,reason,cancellations,share_bp\n"+
+		"2024-03,,1,2500\n"+
+		"2024-03,too_expensive,3,7500\n", csv.String())
+
+	var json bytes.Buffer
+	require.NoError(t, Write(&json, FormatJSON, report))
+	assert.JSONEq(t, `{
+		"generated_at": "2024-03-10T15:04:05Z",
+		"months": [
+			{"month": "2024-02", "cancellations": 0, "reasons": []},
+			{"month": "2024-03", "cancellations": 4, "reasons": [
+				{"reason": "", "cancellations": 1, "share_bp": 2500},
+				{"reason": "too_expensive", "cancellations": 3, "share_bp": 7500}
+			]}
+		]
+	}`, json.String())
+}
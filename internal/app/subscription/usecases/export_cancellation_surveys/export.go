@@ -0,0 +1,120 @@
+package export_cancellation_surveys
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+// Format is an encoding a report can be exported in
+type Format string
+
+const (
+	FormatJSON Format = "json"
+	FormatCSV  Format = "csv"
+)
+
+// monthLayout formats submission months
+const monthLayout = "2006-01"
+
+// ParseFormat reads a format name, case-insensitively; empty means JSON
+func ParseFormat(name string) (Format, error) {
+	switch f := Format(strings.ToLower(strings.TrimSpace(name))); f {
+	case "", FormatJSON:
+		return FormatJSON, nil
+	case FormatCSV:
+		return FormatCSV, nil
+	default:
+		return "", domain.ErrUnsupportedExportFormat
+	}
+}
+
+// ContentType is the media type of the format
+func (f Format) ContentType() string {
+	if f == FormatCSV {
+		return "text/csv; charset=utf-8"
+	}
+	return "application/json"
+}
+
+// Write encodes the report to w in the given format
+func Write(w io.Writer, format Format, report *Report) error {
+	switch format {
+	case FormatJSON:
+		return writeJSON(w, report)
+	case FormatCSV:
+		return writeCSV(w, report)
+	default:
+		return domain.ErrUnsupportedExportFormat
+	}
+}
+
+type reportJSON struct {
+	GeneratedAt time.Time   `json:"generated_at"`
+	Months      []monthJSON `json:"months"`
+}
+
+type monthJSON struct {
+	Month     string         `json:"month"` // YYYY-MM, UTC
+	Questions []questionJSON `json:"questions"`
+}
+
+type questionJSON struct {
+	ID       string       `json:"id"`
+	Answered int64        `json:"answered"`
+	Answers  []answerJSON `json:"answers"`
+}
+
+type answerJSON struct {
+	Value     string `json:"value"`
+	Responses int64  `json:"responses"`
+	ShareBP   int64  `json:"share_bp"`
+}
+
+func writeJSON(w io.Writer, report *Report) error {
+	out := reportJSON{GeneratedAt: report.GeneratedAt, Months: make([]monthJSON, 0, len(report.Months))}
+	for _, m := range report.Months {
+		month := monthJSON{Month: m.Month.Format(monthLayout), Questions: make([]questionJSON, 0, len(m.Questions))}
+		for _, q := range m.Questions {
+			question := questionJSON{ID: q.ID, Answered: q.Answered, Answers: make([]answerJSON, 0, len(q.Answers))}
+			for _, a := range q.Answers {
+				question.Answers = append(question.Answers, answerJSON{Value: a.Value, Responses: a.Responses, ShareBP: a.ShareBP})
+			}
+			month.Questions = append(month.Questions, question)
+		}
+		out.Months = append(out.Months, month)
+	}
+	return json.NewEncoder(w).Encode(out)
+}
+
+// writeCSV writes a row per month, question and answer, the long format chart tools
+// pivot on; months without responses have no rows
+func writeCSV(w io.Writer, report *Report) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"month", "question_id", "answer", "responses", "share_bp"}); err != nil {
+		return err
+	}
+	for _, m := range report.Months {
+		for _, q := range m.Questions {
+			for _, a := range q.Answers {
+				err := cw.Write([]string{
+					m.Month.Format(monthLayout),
+					q.ID,
+					a.Value,
+					strconv.FormatInt(a.Responses, 10),
+					strconv.FormatInt(a.ShareBP, 10),
+				})
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,120 @@
+package export_cancellation_surveys
+
+import (
+	"context"
+	"time"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+const (
+	// DefaultMonths is how many submission months a report covers when the request doesn't say
+	DefaultMonths = 12
+	// MaxMonths is the most submission months one report covers
+	MaxMonths = 60
+)
+
+// basisPoints is 100%
+const basisPoints = 10000
+
+// Request contains the input for a cancellation survey report
+type Request struct {
+	Months int // submission months covered, ending with the current one; zero means DefaultMonths
+}
+
+// Validate checks the request
+func (r Request) Validate() error {
+	if r.Months < 0 || r.Months > MaxMonths {
+		return domain.ErrInvalidSurveyWindow
+	}
+	return nil
+}
+
+// Answer is how many of a month's responses gave one answer to a question
+type Answer struct {
+	Value     string
+	Responses int64
+	ShareBP   int64 // Responses as basis points of the question's answers that month
+}
+
+// Question is the answers a question got in one month, ordered by value
+type Question struct {
+	ID       string
+	Answered int64 // responses that answered the question; skipped questions don't count
+	Answers  []Answer
+}
+
+// Month is the choice and rating answers of the responses submitted in one UTC month,
+// ordered by question ID
+type Month struct {
+	Month     time.Time // first of the month, UTC
+	Questions []Question
+}
+
+// Report is cancellation survey answers for consecutive months, oldest first. Every
+// month in the window is listed, empty ones included, so charts don't have to fill gaps.
+type Report struct {
+	GeneratedAt time.Time
+	Months      []Month
+}
+
+// Interactor handles the cancellation survey export use case
+type Interactor struct {
+	repo  contracts.CancellationSurveyRepository
+	clock domain.Clock
+}
+
+// NewInteractor creates a new cancellation survey export interactor
+func NewInteractor(repo contracts.CancellationSurveyRepository, clock domain.Clock) *Interactor {
+	return &Interactor{repo: repo, clock: clock}
+}
+
+// Execute counts the answers given to each choice and rating question, month by month.
+// Questions are reported by ID, which stays the same across survey versions.
+func (i *Interactor) Execute(ctx context.Context, req Request) (*Report, error) {
+	// 1. Validate request
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	months := req.Months
+	if months == 0 {
+		months = DefaultMonths
+	}
+
+	now := i.clock.Now().UTC()
+	current := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	since := current.AddDate(0, 1-months, 0)
+
+	// 2. Count answers by month, question and value; the repository orders them
+	counts, err := i.repo.CountAnswers(ctx, since)
+	if err != nil {
+		return nil, err
+	}
+	byMonth := make(map[time.Time][]contracts.SurveyAnswerCount)
+	for _, c := range counts {
+		byMonth[c.Month] = append(byMonth[c.Month], c)
+	}
+
+	// 3. Build a month per month of the window, with each question's share of answers
+	report := &Report{GeneratedAt: now, Months: make([]Month, 0, months)}
+	for month := since; !month.After(current); month = month.AddDate(0, 1, 0) {
+		m := Month{Month: month}
+		for _, c := range byMonth[month] {
+			if n := len(m.Questions); n == 0 || m.Questions[n-1].ID != c.QuestionID {
+				m.Questions = append(m.Questions, Question{ID: c.QuestionID})
+			}
+			q := &m.Questions[len(m.Questions)-1]
+			q.Answered += c.Responses
+			q.Answers = append(q.Answers, Answer{Value: c.Value, Responses: c.Responses})
+		}
+		for qi := range m.Questions {
+			q := &m.Questions[qi]
+			for ai := range q.Answers {
+				q.Answers[ai].ShareBP = q.Answers[ai].Responses * basisPoints / q.Answered
+			}
+		}
+		report.Months = append(report.Months, m)
+	}
+	return report, nil
+}
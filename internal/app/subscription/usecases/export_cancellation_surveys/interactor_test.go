@@ -0,0 +1,130 @@
+package export_cancellation_surveys
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+// MockRepository is a mock implementation of CancellationSurveyRepository
+type MockRepository struct {
+	mock.Mock
+}
+
+func (m *MockRepository) Insert(ctx context.Context, response *domain.SurveyResponse) error {
+	args := m.Called(ctx, response)
+	return args.Error(0)
+}
+
+func (m *MockRepository) CountAnswers(ctx context.Context, since time.Time) ([]contracts.SurveyAnswerCount, error) {
+	args := m.Called(ctx, since)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]contracts.SurveyAnswerCount), args.Error(1)
+}
+
+func month(m time.Month) time.Time {
+	return time.Date(2024, m, 1, 0, 0, 0, 0, time.UTC)
+}
+
+var now = time.Date(2024, 3, 10, 15, 4, 5, 0, time.UTC)
+
+func TestExportCancellationSurveys_SharesOfEachQuestionPerMonth(t *testing.T) {
+	ctx := context.Background()
+	repo := &MockRepository{}
+	repo.On("CountAnswers", ctx, month(1)).Return([]contracts.SurveyAnswerCount{
+		{Month: month(1), QuestionID: "reason", Value: "missing_features", Responses: 1},
+		{Month: month(1), QuestionID: "reason", Value: "too_expensive", Responses: 3},
+		{Month: month(1), QuestionID: "satisfaction", Value: "2", Responses: 2},
+		{Month: month(3), QuestionID: "reason", Value: "too_expensive", Responses: 2},
+	}, nil)
+
+	report, err := NewInteractor(repo, domain.FixedClock{FixedTime: now}).Execute(ctx, Request{Months: 3})
+
+	require.NoError(t, err)
+	assert.Equal(t, now, report.GeneratedAt)
+	assert.Equal(t, []Month{
+		{Month: month(1), Questions: []Question{
+			{ID: "reason", Answered: 4, Answers: []Answer{
+				{Value: "missing_features", Responses: 1, ShareBP: 2500},
+				{Value: "too_expensive", Responses: 3, ShareBP: 7500},
+			}},
+			{ID: "satisfaction", Answered: 2, Answers: []Answer{{Value: "2", Responses: 2, ShareBP: 10000}}},
+		}},
+		{Month: month(2)},
+		{Month: month(3), Questions: []Question{
+			{ID: "reason", Answered: 2, Answers: []Answer{{Value: "too_expensive", Responses: 2, ShareBP: 10000}}},
+		}},
+	}, report.Months)
+}
+
+func TestExportCancellationSurveys_DefaultsToTwelveMonths(t *testing.T) {
+	ctx := context.Background()
+	repo := &MockRepository{}
+	repo.On("CountAnswers", ctx, time.Date(2023, 4, 1, 0, 0, 0, 0, time.UTC)).Return([]contracts.SurveyAnswerCount{}, nil)
+
+	report, err := NewInteractor(repo, domain.FixedClock{FixedTime: now}).Execute(ctx, Request{})
+
+	require.NoError(t, err)
+	assert.Len(t, report.Months, DefaultMonths)
+}
+
+func TestExportCancellationSurveys_RejectsWindow(t *testing.T) {
+	repo := &MockRepository{}
+
+	for _, months := range []int{-1, MaxMonths + 1} {
+		_, err := NewInteractor(repo, domain.FixedClock{FixedTime: now}).Execute(context.Background(), Request{Months: months})
+		assert.ErrorIs(t, err, domain.ErrInvalidSurveyWindow)
+	}
+	repo.AssertNotCalled(t, "CountAnswers", mock.Anything, mock.Anything)
+}
+
+func TestExportCancellationSurveys_ScanFails(t *testing.T) {
+	repo := &MockRepository{}
+	failure := errors.New("deadline exceeded")
+	repo.On("CountAnswers", mock.Anything, mock.Anything).Return(nil, failure)
+
+	_, err := NewInteractor(repo, domain.FixedClock{FixedTime: now}).Execute(context.Background(), Request{})
+
+	assert.ErrorIs(t, err, failure)
+}
+
+func TestWrite_CSVAndJSON(t *testing.T) {
+	report := &Report{GeneratedAt: now, Months: []Month{
+		{Month: month(2)},
+		{Month: month(3), Questions: []Question{
+			{ID: "reason", Answered: 4, Answers: []Answer{
+				{Value: "other", Responses: 1, ShareBP: 2500},
+				{Value: "too_expensive", Responses: 3, ShareBP: 7500},
+			}},
+		}},
+	}}
+
+	var csv bytes.Buffer
+	require.NoError(t, Write(&csv, FormatCSV, report))
+	assert.Equal(t, "month,question_id,answer,responses,share_bp\n"+
+		"2024-03,reason,other,1,2500\n"+
+		"2024-03,reason,too_expensive,3,7500\n", csv.String())
+
+	var json bytes.Buffer
+	require.NoError(t, Write(&json, FormatJSON, report))
+	assert.JSONEq(t, `{
+		"generated_at": "2024-03-10T15:04:05Z",
+		"months": [
+			{"month": "2024-02", "questions": []},
+			{"month": "2024-03", "questions": [{"id": "reason", "answered": 4, "answers": [
+				{"value": "other", "responses": 1, "share_bp": 2500},
+				{"value": "too_expensive", "responses": 3, "share_bp": 7500}
+			]}]}
+		]
+	}`, json.String())
+}
@@ -0,0 +1,119 @@
+package export_cohort_retention
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+// Format is an encoding a report can be exported in
+type Format string
+
+const (
+	FormatJSON Format = "json"
+	FormatCSV  Format = "csv"
+)
+
+// monthLayout formats cohort and period months
+const monthLayout = "2006-01"
+
+// ParseFormat reads a format name, case-insensitively; empty means JSON
+func ParseFormat(name string) (Format, error) {
+	switch f := Format(strings.ToLower(strings.TrimSpace(name))); f {
+	case "", FormatJSON:
+		return FormatJSON, nil
+	case FormatCSV:
+		return FormatCSV, nil
+	default:
+		return "", domain.ErrUnsupportedExportFormat
+	}
+}
+
+// ContentType is the media type of the format
+func (f Format) ContentType() string {
+	if f == FormatCSV {
+		return "text/csv; charset=utf-8"
+	}
+	return "application/json"
+}
+
+// Write encodes the report to w in the given format
+func Write(w io.Writer, format Format, report *Report) error {
+	switch format {
+	case FormatJSON:
+		return writeJSON(w, report)
+	case FormatCSV:
+		return writeCSV(w, report)
+	default:
+		return domain.ErrUnsupportedExportFormat
+	}
+}
+
+type reportJSON struct {
+	GeneratedAt time.Time    `json:"generated_at"`
+	ReadAt      time.Time    `json:"read_at"`
+	Cohorts     []cohortJSON `json:"cohorts"`
+}
+
+type cohortJSON struct {
+	Month   string       `json:"month"` // YYYY-MM, UTC
+	Size    int64        `json:"size"`
+	Periods []periodJSON `json:"periods"`
+}
+
+type periodJSON struct {
+	Offset      int    `json:"offset"`
+	Month       string `json:"month"` // YYYY-MM, UTC
+	Active      int64  `json:"active"`
+	Cancelled   int64  `json:"cancelled"`
+	RetentionBP int64  `json:"retention_bp"`
+}
+
+func writeJSON(w io.Writer, report *Report) error {
+	out := reportJSON{GeneratedAt: report.GeneratedAt, ReadAt: report.ReadAt, Cohorts: make([]cohortJSON, 0, len(report.Cohorts))}
+	for _, c := range report.Cohorts {
+		cohort := cohortJSON{Month: c.Month.Format(monthLayout), Size: c.Size, Periods: make([]periodJSON, 0, len(c.Periods))}
+		for _, p := range c.Periods {
+			cohort.Periods = append(cohort.Periods, periodJSON{
+				Offset:      p.Offset,
+				Month:       p.Month.Format(monthLayout),
+				Active:      p.Active,
+				Cancelled:   p.Cancelled,
+				RetentionBP: p.RetentionBP,
+			})
+		}
+		out.Cohorts = append(out.Cohorts, cohort)
+	}
+	return json.NewEncoder(w).Encode(out)
+}
+
+// writeCSV writes a row per cohort and period, the long format chart tools pivot on
+func writeCSV(w io.Writer, report *Report) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"cohort", "size", "offset", "month", "active", "cancelled", "retention_bp"}); err != nil {
+		return err
+	}
+	for _, c := range report.Cohorts {
+		for _, p := range c.Periods {
+			err := cw.Write([]string{
+				c.Month.Format(monthLayout),
+				strconv.FormatInt(c.Size, 10),
+				strconv.Itoa(p.Offset),
+				p.Month.Format(monthLayout),
+				strconv.FormatInt(p.Active, 10),
+				strconv.FormatInt(p.Cancelled, 10),
+				strconv.FormatInt(p.RetentionBP, 10),
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
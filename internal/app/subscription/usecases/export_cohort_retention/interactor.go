@@ -0,0 +1,127 @@
+package export_cohort_retention
+
+import (
+	"context"
+	"time"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+const (
+	// DefaultMonths is how many signup months a report covers when the request doesn't say
+	DefaultMonths = 12
+	// MaxMonths is the most signup months one report covers
+	MaxMonths = 60
+)
+
+// basisPoints is 100%
+const basisPoints = 10000
+
+// Request contains the input for a cohort retention report
+type Request struct {
+	Months int // signup months covered, ending with the current one; zero means DefaultMonths
+	// AsOf reads subscriptions as of this commit timestamp, such as the aggregates'
+	// ReadAt, so the report agrees with figures read then; zero reads the latest
+	AsOf time.Time
+}
+
+// Validate checks the request
+func (r Request) Validate() error {
+	if r.Months < 0 || r.Months > MaxMonths {
+		return domain.ErrInvalidCohortWindow
+	}
+	return nil
+}
+
+// Period is a cohort's standing at the end of one month after signup, or as of now for
+// the current month
+type Period struct {
+	Offset      int       // months since signup; 0 is the signup month
+	Month       time.Time // first of the month, UTC
+	Active      int64     // subscriptions not cancelled by the end of the month
+	Cancelled   int64     // subscriptions cancelled by the end of the month
+	RetentionBP int64     // Active as basis points of the cohort; zero for an empty cohort
+}
+
+// Cohort is the subscriptions that started in one UTC month
+type Cohort struct {
+	Month   time.Time // first of the month, UTC
+	Size    int64
+	Periods []Period // one per month from signup to the current month
+}
+
+// Report is cohort retention for consecutive signup months, oldest first. Every month
+// in the window has a cohort, empty ones included, so charts don't have to fill gaps.
+type Report struct {
+	GeneratedAt time.Time
+	ReadAt      time.Time // the commit timestamp subscriptions were read at
+	Cohorts     []Cohort
+}
+
+// Interactor handles the cohort retention export use case
+type Interactor struct {
+	repo  contracts.CohortRepository
+	clock domain.Clock
+}
+
+// NewInteractor creates a new cohort retention export interactor
+func NewInteractor(repo contracts.CohortRepository, clock domain.Clock) *Interactor {
+	return &Interactor{repo: repo, clock: clock}
+}
+
+// Execute counts the subscriptions of each signup month that were still active, and
+// cancelled, at the end of every month since
+func (i *Interactor) Execute(ctx context.Context, req Request) (*Report, error) {
+	// 1. Validate request
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	months := req.Months
+	if months == 0 {
+		months = DefaultMonths
+	}
+
+	now := i.clock.Now().UTC()
+	if req.AsOf.After(now) {
+		return nil, domain.ErrInvalidReadTimestamp
+	}
+	current := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	since := current.AddDate(0, 1-months, 0)
+
+	// 2. Count subscriptions by signup and cancellation month
+	counts, readAt, err := i.repo.CountCohorts(ctx, since, contracts.Snapshot{At: req.AsOf})
+	if err != nil {
+		return nil, err
+	}
+
+	sizes := make(map[time.Time]int64)
+	cancelled := make(map[time.Time]map[time.Time]int64) // signup month to cancel month
+	for _, c := range counts {
+		sizes[c.SignupMonth] += c.Subscriptions
+		if c.CancelMonth.IsZero() {
+			continue
+		}
+		if cancelled[c.SignupMonth] == nil {
+			cancelled[c.SignupMonth] = make(map[time.Time]int64)
+		}
+		cancelled[c.SignupMonth][c.CancelMonth] += c.Subscriptions
+	}
+
+	// 3. Build a cohort per month of the window, with a period per month since signup
+	report := &Report{GeneratedAt: now, ReadAt: readAt, Cohorts: make([]Cohort, 0, months)}
+	for month := since; !month.After(current); month = month.AddDate(0, 1, 0) {
+		cohort := Cohort{Month: month, Size: sizes[month]}
+		var gone int64
+		for offset, period := 0, month; !period.After(current); offset, period = offset+1, period.AddDate(0, 1, 0) {
+			gone += cancelled[month][period]
+			p := Period{Offset: offset, Month: period, Active: cohort.Size - gone, Cancelled: gone}
+			if cohort.Size > 0 {
+				p.RetentionBP = p.Active * basisPoints / cohort.Size
+			}
+			cohort.Periods = append(cohort.Periods, p)
+		}
+		report.Cohorts = append(report.Cohorts, cohort)
+	}
+	return report, nil
+}
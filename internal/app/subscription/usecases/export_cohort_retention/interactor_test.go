@@ -0,0 +1,149 @@
+package export_cohort_retention
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+// MockRepository is a mock implementation of CohortRepository
+type MockRepository struct {
+	mock.Mock
+}
+
+func (m *MockRepository) CountCohorts(ctx context.Context, since time.Time, snapshot contracts.Snapshot) ([]contracts.CohortCount, time.Time, error) {
+	args := m.Called(ctx, since, snapshot)
+	if args.Get(0) == nil {
+		return nil, time.Time{}, args.Error(2)
+	}
+	return args.Get(0).([]contracts.CohortCount), args.Get(1).(time.Time), args.Error(2)
+}
+
+func month(m time.Month) time.Time {
+	return time.Date(2024, m, 1, 0, 0, 0, 0, time.UTC)
+}
+
+var now = time.Date(2024, 3, 10, 15, 4, 5, 0, time.UTC)
+
+func TestExportCohortRetention_ComputesRetentionPerMonth(t *testing.T) {
+	ctx := context.Background()
+	repo := &MockRepository{}
+	repo.On("CountCohorts", ctx, month(1), contracts.Snapshot{}).Return([]contracts.CohortCount{
+		{SignupMonth: month(1), Subscriptions: 6},
+		{SignupMonth: month(1), CancelMonth: month(1), Subscriptions: 1},
+		{SignupMonth: month(1), CancelMonth: month(3), Subscriptions: 3},
+		{SignupMonth: month(3), Subscriptions: 2},
+	}, now, nil)
+
+	report, err := NewInteractor(repo, domain.FixedClock{FixedTime: now}).Execute(ctx, Request{Months: 3})
+
+	require.NoError(t, err)
+	assert.Equal(t, now, report.GeneratedAt)
+	assert.Equal(t, now, report.ReadAt)
+	assert.Equal(t, []Cohort{
+		{Month: month(1), Size: 10, Periods: []Period{
+			{Offset: 0, Month: month(1), Active: 9, Cancelled: 1, RetentionBP: 9000},
+			{Offset: 1, Month: month(2), Active: 9, Cancelled: 1, RetentionBP: 9000},
+			{Offset: 2, Month: month(3), Active: 6, Cancelled: 4, RetentionBP: 6000},
+		}},
+		{Month: month(2), Size: 0, Periods: []Period{
+			{Offset: 0, Month: month(2)},
+			{Offset: 1, Month: month(3)},
+		}},
+		{Month: month(3), Size: 2, Periods: []Period{
+			{Offset: 0, Month: month(3), Active: 2, RetentionBP: 10000},
+		}},
+	}, report.Cohorts)
+}
+
+func TestExportCohortRetention_DefaultsToTwelveMonths(t *testing.T) {
+	ctx := context.Background()
+	repo := &MockRepository{}
+	repo.On("CountCohorts", ctx, time.Date(2023, 4, 1, 0, 0, 0, 0, time.UTC), contracts.Snapshot{}).Return([]contracts.CohortCount{}, now, nil)
+
+	report, err := NewInteractor(repo, domain.FixedClock{FixedTime: now}).Execute(ctx, Request{})
+
+	require.NoError(t, err)
+	assert.Len(t, report.Cohorts, DefaultMonths)
+	assert.Len(t, report.Cohorts[0].Periods, DefaultMonths)
+}
+
+func TestExportCohortRetention_RejectsWindow(t *testing.T) {
+	repo := &MockRepository{}
+
+	for _, months := range []int{-1, MaxMonths + 1} {
+		_, err := NewInteractor(repo, domain.FixedClock{FixedTime: now}).Execute(context.Background(), Request{Months: months})
+		assert.ErrorIs(t, err, domain.ErrInvalidCohortWindow)
+	}
+	repo.AssertNotCalled(t, "CountCohorts", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestExportCohortRetention_ReadsAsOfTheChosenTimestamp(t *testing.T) {
+	ctx := context.Background()
+	asOf := now.Add(-10 * time.Minute)
+	repo := &MockRepository{}
+	repo.On("CountCohorts", ctx, month(3), contracts.Snapshot{At: asOf}).Return([]contracts.CohortCount{}, asOf, nil)
+
+	report, err := NewInteractor(repo, domain.FixedClock{FixedTime: now}).Execute(ctx, Request{Months: 1, AsOf: asOf})
+
+	require.NoError(t, err)
+	assert.Equal(t, asOf, report.ReadAt)
+
+	_, err = NewInteractor(repo, domain.FixedClock{FixedTime: now}).Execute(ctx, Request{AsOf: now.Add(time.Minute)})
+	assert.ErrorIs(t, err, domain.ErrInvalidReadTimestamp)
+	repo.AssertNumberOfCalls(t, "CountCohorts", 1)
+}
+
+func TestExportCohortRetention_ScanFails(t *testing.T) {
+	repo := &MockRepository{}
+	failure := errors.New("deadline exceeded")
+	repo.On("CountCohorts", mock.Anything, mock.Anything, mock.Anything).Return(nil, nil, failure)
+
+	_, err := NewInteractor(repo, domain.FixedClock{FixedTime: now}).Execute(context.Background(), Request{})
+
+	assert.ErrorIs(t, err, failure)
+}
+
+func TestWrite_CSVAndJSON(t *testing.T) {
+	report := &Report{GeneratedAt: now, ReadAt: now.Add(-time.Minute), Cohorts: []Cohort{
+		{Month: month(2), Size: 4, Periods: []Period{
+			{Offset: 0, Month: month(2), Active: 4, RetentionBP: 10000},
+			{Offset: 1, Month: month(3), Active: 3, Cancelled: 1, RetentionBP: 7500},
+		}},
+	}}
+
+	var csv bytes.Buffer
+	require.NoError(t, Write(&csv, FormatCSV, report))
+	assert.Equal(t, "cohort,size,offset,month,active,cancelled,retention_bp\n"+
+		"2024-02,4,0,2024-02,4,0,10000\n"+
+		"2024-02,4,1,2024-03,3,1,7500\n", csv.String())
+
+	var json bytes.Buffer
+	require.NoError(t, Write(&json, FormatJSON, report))
+	assert.JSONEq(t, `{
+		"generated_at": "2024-03-10T15:04:05Z",
+		"read_at": "2024-03-10T15:03:05Z",
+		"cohorts": [{"month": "2024-02", "size": 4, "periods": [
+			{"offset": 0, "month": "2024-02", "active": 4, "cancelled": 0, "retention_bp": 10000},
+			{"offset": 1, "month": "2024-03", "active": 3, "cancelled": 1, "retention_bp": 7500}
+		]}]
+	}`, json.String())
+}
+
+func TestParseFormat(t *testing.T) {
+	for name, want := range map[string]Format{"": FormatJSON, "json": FormatJSON, "CSV": FormatCSV} {
+		got, err := ParseFormat(name)
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+	_, err := ParseFormat("xlsx")
+	assert.ErrorIs(t, err, domain.ErrUnsupportedExportFormat)
+}
@@ -0,0 +1,37 @@
+package get_subscription
+
+import (
+	"context"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/instrument"
+)
+
+// UseCase is the get subscription use case as seen by callers
+type UseCase interface {
+	Execute(ctx context.Context, subscriptionID string) (*View, error)
+}
+
+var (
+	_ UseCase = (*Interactor)(nil)
+	_ UseCase = (*Instrumented)(nil)
+)
+
+// Instrumented decorates a UseCase with logs, metrics and a trace span per execution
+type Instrumented struct {
+	next UseCase
+	in   instrument.Instrumentation
+}
+
+// NewInstrumented wraps the given use case with instrumentation
+func NewInstrumented(next UseCase, in instrument.Instrumentation) *Instrumented {
+	return &Instrumented{next: next, in: in}
+}
+
+// Execute runs the wrapped use case
+func (d *Instrumented) Execute(ctx context.Context, subscriptionID string) (*View, error) {
+	attrs := map[string]string{"subscription_id": subscriptionID}
+
+	return instrument.Run(ctx, d.in, "get_subscription", attrs, func(ctx context.Context) (*View, error) {
+		return d.next.Execute(ctx, subscriptionID)
+	})
+}
@@ -0,0 +1,87 @@
+package get_subscription
+
+import (
+	"context"
+	"time"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/cancel_subscription"
+)
+
+// View is a subscription with what callers would otherwise work out from it, as of
+// the moment it was read
+type View struct {
+	Subscription     *domain.Subscription
+	CurrentPeriodEnd time.Time
+	DaysRemaining    int64     // whole days of the current period left, as refunds count them
+	NextRenewalAt    time.Time // zero if the subscription won't renew as things stand
+	// What cancelling now would give back, worked out as cancel_subscription would;
+	// at most one of them is set, and both are zero for a cancelled subscription
+	ProjectedRefund int64 // cents
+	ProjectedCredit int64 // cents
+}
+
+// Interactor handles the get subscription use case
+type Interactor struct {
+	repo    contracts.SubscriptionRepository
+	pricing contracts.PricingSource
+	cycles  contracts.BillingCycleSource
+	flags   contracts.FeatureFlags
+	clock   domain.Clock
+}
+
+// NewInteractor creates a new get subscription interactor. pricing, cycles and flags
+// must be those cancellations run with, so the projected refund is the one a
+// cancellation would give.
+func NewInteractor(repo contracts.SubscriptionRepository, pricing contracts.PricingSource, cycles contracts.BillingCycleSource, flags contracts.FeatureFlags, clock domain.Clock) *Interactor {
+	return &Interactor{
+		repo:    repo,
+		pricing: pricing,
+		cycles:  cycles,
+		flags:   flags,
+		clock:   clock,
+	}
+}
+
+// Execute loads a subscription and works out its view. Nothing is charged or saved.
+func (i *Interactor) Execute(ctx context.Context, subscriptionID string) (*View, error) {
+	// 1. Load subscription
+	sub, err := i.repo.FindByID(ctx, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. Work out the period of the subscription's plan
+	cycle, err := i.cycles.CycleFor(ctx, sub)
+	if err != nil {
+		return nil, err
+	}
+	view := &View{
+		Subscription:     sub,
+		CurrentPeriodEnd: sub.CurrentPeriodEnd(cycle),
+		DaysRemaining:    sub.DaysRemaining(i.clock, cycle),
+		NextRenewalAt:    sub.NextRenewalAt(cycle),
+	}
+	if sub.Status() == domain.StatusCancelled {
+		return view, nil
+	}
+
+	// 3. Quote a cancellation under the terms rolled out to the customer
+	pricing, err := i.pricing.PricingFor(ctx, sub)
+	if err != nil {
+		return nil, err
+	}
+	policy, credit := cancel_subscription.RefundTerms(ctx, i.flags, sub)
+	quote, err := sub.QuoteCancellation(i.clock, cycle, policy, pricing)
+	if err != nil {
+		return nil, err
+	}
+	if credit {
+		view.ProjectedCredit = quote.RefundAmount
+	} else {
+		view.ProjectedRefund = quote.RefundAmount
+	}
+
+	return view, nil
+}
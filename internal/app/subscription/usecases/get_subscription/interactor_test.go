@@ -0,0 +1,111 @@
+package get_subscription
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/adapters"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/testkit"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/testkit/builders"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/cancel_subscription"
+)
+
+var startDate = builders.DefaultStartDate
+
+// getOn builds an interactor whose clock reads daysIn days after startDate
+func getOn(subs *testkit.FakeSubscriptions, flags adapters.StaticFeatureFlags, daysIn int) *Interactor {
+	clock := domain.FixedClock{FixedTime: startDate.AddDate(0, 0, daysIn)}
+	return NewInteractor(subs, adapters.StaticPricing{}, adapters.StaticBillingCycle{Cycle: domain.CycleOfDays(30)}, flags, clock)
+}
+
+func TestGetSubscription_ActiveProjectsTheRefund(t *testing.T) {
+	ctx := context.Background()
+	subs := testkit.NewFakeSubscriptions().With(builders.NewSubscriptionBuilder().Build())
+
+	view, err := getOn(subs, adapters.StaticFeatureFlags{}, 10).Execute(ctx, "sub-123")
+
+	require.NoError(t, err)
+	assert.Equal(t, "sub-123", view.Subscription.ID())
+	assert.Equal(t, startDate.AddDate(0, 0, 30), view.CurrentPeriodEnd)
+	assert.Equal(t, int64(20), view.DaysRemaining)
+	assert.Equal(t, startDate.AddDate(0, 0, 30), view.NextRenewalAt)
+	assert.Equal(t, int64(2000), view.ProjectedRefund) // 3000 * 20 / 30
+	assert.Zero(t, view.ProjectedCredit)
+
+	stored, err := subs.FindByID(ctx, "sub-123")
+	require.NoError(t, err)
+	assert.Equal(t, domain.StatusActive, stored.Status())
+	assert.Zero(t, stored.Version())
+}
+
+func TestGetSubscription_ResumedPeriodEndsLaterByTheTimePaused(t *testing.T) {
+	ctx := context.Background()
+	sub := builders.NewSubscriptionBuilder().PausedAt(startDate.AddDate(0, 0, 5)).Build()
+	_, err := sub.Resume(domain.FixedClock{FixedTime: startDate.AddDate(0, 0, 10)}, domain.CycleOfDays(30))
+	require.NoError(t, err)
+	subs := testkit.NewFakeSubscriptions().With(sub)
+
+	view, err := getOn(subs, adapters.StaticFeatureFlags{}, 15).Execute(ctx, "sub-123")
+
+	require.NoError(t, err)
+	assert.Equal(t, startDate.AddDate(0, 0, 35), view.CurrentPeriodEnd)
+	assert.Equal(t, view.CurrentPeriodEnd, view.NextRenewalAt)
+	assert.Equal(t, int64(20), view.DaysRemaining)
+}
+
+func TestGetSubscription_FollowsTheCancellationFlags(t *testing.T) {
+	ctx := context.Background()
+	subs := testkit.NewFakeSubscriptions().With(builders.NewSubscriptionBuilder().Build())
+	flags := adapters.StaticFeatureFlags{
+		cancel_subscription.FlagHourlyRefunds:   {Customers: []string{"cust-456"}},
+		cancel_subscription.FlagCreditProration: {Customers: []string{"cust-456"}},
+	}
+	clock := domain.FixedClock{FixedTime: startDate.Add(10*24*time.Hour + 12*time.Hour)}
+	interactor := NewInteractor(subs, adapters.StaticPricing{}, adapters.StaticBillingCycle{Cycle: domain.CycleOfDays(30)}, flags, clock)
+
+	view, err := interactor.Execute(ctx, "sub-123")
+
+	require.NoError(t, err)
+	assert.Zero(t, view.ProjectedRefund)
+	assert.Equal(t, int64(1950), view.ProjectedCredit) // 3000 * 468 / 720 hours
+	assert.Equal(t, int64(20), view.DaysRemaining)
+}
+
+func TestGetSubscription_OtherStatuses(t *testing.T) {
+	tests := []struct {
+		name            string
+		sub             *domain.Subscription
+		daysRemaining   int64
+		nextRenewalAt   time.Time
+		projectedRefund int64
+	}{
+		{"trialing", builders.NewSubscriptionBuilder().Trialing(14).Build(), 4, startDate.AddDate(0, 0, 14), 0},
+		// Paused on day 5, so the 25 days after it are refunded
+		{"paused", builders.NewSubscriptionBuilder().PausedAt(startDate.AddDate(0, 0, 5)).Build(), 25, time.Time{}, 2500},
+		{"past due", builders.NewSubscriptionBuilder().PastDue().Build(), 20, time.Time{}, 0},
+		{"cancelled", builders.NewSubscriptionBuilder().CancelledAt(startDate.AddDate(0, 0, 3)).Build(), 0, time.Time{}, 0},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			subs := testkit.NewFakeSubscriptions().With(tc.sub)
+
+			view, err := getOn(subs, adapters.StaticFeatureFlags{}, 10).Execute(context.Background(), "sub-123")
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.daysRemaining, view.DaysRemaining)
+			assert.Equal(t, tc.nextRenewalAt, view.NextRenewalAt)
+			assert.Equal(t, tc.projectedRefund, view.ProjectedRefund)
+		})
+	}
+}
+
+func TestGetSubscription_NotFound(t *testing.T) {
+	_, err := getOn(testkit.NewFakeSubscriptions(), adapters.StaticFeatureFlags{}, 10).Execute(context.Background(), "missing")
+
+	assert.ErrorIs(t, err, domain.ErrSubscriptionNotFound)
+}
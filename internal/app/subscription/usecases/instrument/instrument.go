@@ -0,0 +1,78 @@
+package instrument
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/correlation"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/logging"
+)
+
+const (
+	MetricExecutions = "usecase_executions_total"
+	MetricDuration   = "usecase_duration_seconds"
+)
+
+// Instrumentation bundles the telemetry sinks shared by use case decorators
+type Instrumentation struct {
+	Logger  *slog.Logger
+	Metrics contracts.Metrics
+	Tracer  contracts.Tracer
+}
+
+// Run executes fn inside a span named after the use case, then logs the outcome
+// and records execution count and duration metrics. A correlation ID is attached
+// to ctx if the caller didn't provide one, and attrs become log fields of ctx, so
+// everything fn logs with its context carries them.
+func Run[T any](ctx context.Context, in Instrumentation, useCase string, attrs map[string]string, fn func(ctx context.Context) (T, error)) (T, error) {
+	ctx, correlationID := correlation.Ensure(ctx)
+	ctx = logging.With(ctx, logFields(attrs)...)
+	ctx, span := in.Tracer.Start(ctx, "usecase."+useCase)
+	defer span.End()
+	span.SetAttribute("correlation_id", correlationID)
+	for k, v := range attrs {
+		span.SetAttribute(k, v)
+	}
+
+	start := time.Now()
+	result, err := fn(ctx)
+	elapsed := time.Since(start)
+
+	outcome := "success"
+	if err != nil {
+		outcome = "error"
+		span.RecordError(err)
+	}
+
+	labels := map[string]string{"usecase": useCase, "outcome": outcome}
+	in.Metrics.IncCounter(MetricExecutions, labels)
+	in.Metrics.ObserveHistogram(MetricDuration, elapsed.Seconds(), map[string]string{"usecase": useCase})
+
+	// correlation_id and attrs come from ctx; see logging.ContextHandler
+	logAttrs := []any{slog.String("usecase", useCase), slog.Duration("duration", elapsed)}
+	if err != nil {
+		in.Logger.ErrorContext(ctx, "use case failed", append(logAttrs, slog.Any("error", err))...)
+	} else {
+		in.Logger.InfoContext(ctx, "use case executed", logAttrs...)
+	}
+
+	return result, err
+}
+
+// logFields converts attrs to log fields in key order
+func logFields(attrs map[string]string) []slog.Attr {
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	fields := make([]slog.Attr, 0, len(keys))
+	for _, k := range keys {
+		fields = append(fields, slog.String(k, attrs[k]))
+	}
+	return fields
+}
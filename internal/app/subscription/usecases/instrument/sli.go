@@ -0,0 +1,62 @@
+package instrument
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+// Service level indicators, labelled for the SLO dashboards
+const (
+	MetricSLIRequests         = "sli_requests_total"
+	MetricSLIRefundProcessing = "sli_refund_processing_seconds"
+	MetricSLIRefundFailures   = "sli_refund_failures_total"
+)
+
+// SLI outcomes. Rejected requests failed because of the caller, such as invalid input
+// or a caller that gave up, and are left out of the success ratio.
+const (
+	OutcomeGood     = "good"
+	OutcomeBad      = "bad"
+	OutcomeRejected = "rejected"
+)
+
+// RecordSLI counts one request against slo. err is classified as rejected when it
+// matches one of rejections or the caller's cancellation; any other error is bad.
+func RecordSLI(ctx context.Context, in Instrumentation, slo string, err error, rejections ...error) {
+	outcome := OutcomeGood
+	if err != nil {
+		outcome = OutcomeBad
+		if errors.Is(err, context.Canceled) && ctx.Err() != nil {
+			outcome = OutcomeRejected
+		}
+		for _, r := range rejections {
+			if errors.Is(err, r) {
+				outcome = OutcomeRejected
+				break
+			}
+		}
+	}
+	in.Metrics.IncCounter(MetricSLIRequests, map[string]string{"slo": slo, "outcome": outcome})
+}
+
+// RecordRefundOutcome observes how long a refund took to settle or fail, and counts
+// failures. source names the path that learned the outcome, such as poll or webhook.
+func RecordRefundOutcome(in Instrumentation, source string, settled *domain.RefundSettledEvent, failed *domain.RefundFailedEvent) {
+	var (
+		outcome string
+		elapsed time.Duration
+	)
+	switch {
+	case settled != nil:
+		outcome, elapsed = "settled", settled.SettledAt.Sub(settled.RequestedAt)
+	case failed != nil:
+		outcome, elapsed = "failed", failed.FailedAt.Sub(failed.RequestedAt)
+		in.Metrics.IncCounter(MetricSLIRefundFailures, map[string]string{"source": source})
+	default:
+		return
+	}
+	in.Metrics.ObserveHistogram(MetricSLIRefundProcessing, elapsed.Seconds(), map[string]string{"outcome": outcome, "source": source})
+}
@@ -0,0 +1,67 @@
+package instrument
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+type recordedMetrics struct {
+	counters   map[string]int
+	histograms map[string][]float64
+}
+
+func newRecordedMetrics() *recordedMetrics {
+	return &recordedMetrics{counters: make(map[string]int), histograms: make(map[string][]float64)}
+}
+
+func (m *recordedMetrics) IncCounter(name string, labels map[string]string) {
+	m.counters[name+" "+fmt.Sprint(labels)]++
+}
+
+func (m *recordedMetrics) ObserveHistogram(name string, value float64, labels map[string]string) {
+	key := name + " " + fmt.Sprint(labels)
+	m.histograms[key] = append(m.histograms[key], value)
+}
+
+func TestRecordSLI_ClassifiesOutcomes(t *testing.T) {
+	m := newRecordedMetrics()
+	in := Instrumentation{Metrics: m}
+	ctx := context.Background()
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+
+	RecordSLI(ctx, in, "cancel_subscription", nil)
+	RecordSLI(ctx, in, "cancel_subscription", fmt.Errorf("load: %w", domain.ErrAlreadyCancelled), domain.ErrAlreadyCancelled)
+	RecordSLI(cancelled, in, "cancel_subscription", context.Canceled)
+	RecordSLI(ctx, in, "cancel_subscription", context.Canceled)
+	RecordSLI(ctx, in, "cancel_subscription", errors.New("spanner unavailable"))
+
+	assert.Equal(t, map[string]int{
+		"sli_requests_total map[outcome:good slo:cancel_subscription]":     1,
+		"sli_requests_total map[outcome:rejected slo:cancel_subscription]": 2,
+		"sli_requests_total map[outcome:bad slo:cancel_subscription]":      2,
+	}, m.counters)
+}
+
+func TestRecordRefundOutcome_ObservesProcessingTime(t *testing.T) {
+	m := newRecordedMetrics()
+	in := Instrumentation{Metrics: m}
+	requested := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	RecordRefundOutcome(in, "poll", &domain.RefundSettledEvent{RequestedAt: requested, SettledAt: requested.Add(2 * time.Hour)}, nil)
+	RecordRefundOutcome(in, "webhook", nil, &domain.RefundFailedEvent{RequestedAt: requested, FailedAt: requested.Add(time.Minute)})
+	RecordRefundOutcome(in, "poll", nil, nil)
+
+	assert.Equal(t, map[string][]float64{
+		"sli_refund_processing_seconds map[outcome:settled source:poll]":   {7200},
+		"sli_refund_processing_seconds map[outcome:failed source:webhook]": {60},
+	}, m.histograms)
+	assert.Equal(t, map[string]int{"sli_refund_failures_total map[source:webhook]": 1}, m.counters)
+}
@@ -0,0 +1,47 @@
+package issue_credit_note
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/bus"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+// CommandName identifies the issue credit note command on the bus
+const CommandName = "subscription.issue_credit_note"
+
+var _ bus.Handler = (*Interactor)(nil)
+
+// Privileged implements bus.Privileged: credits move money, so they are audited
+func (r Request) Privileged() {}
+
+// CommandName implements bus.Command
+func (r Request) CommandName() string {
+	return CommandName
+}
+
+// Validate rejects obviously invalid input before the subscription is loaded
+func (r Request) Validate() error {
+	if r.InvoiceID == "" {
+		return domain.ErrInvalidInvoiceID
+	}
+	if r.AmountCents <= 0 {
+		return domain.ErrInvalidCreditAmount
+	}
+	return nil
+}
+
+// Handle executes the use case for a command dispatched through the bus
+func (i *Interactor) Handle(ctx context.Context, cmd bus.Command) (any, error) {
+	req, ok := cmd.(Request)
+	if !ok {
+		return nil, fmt.Errorf("unexpected command type %T", cmd)
+	}
+
+	event, err := i.Execute(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return event, nil
+}
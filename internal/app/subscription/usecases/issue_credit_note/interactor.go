@@ -0,0 +1,119 @@
+package issue_credit_note
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/correlation"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+// Request contains the input for crediting part of a past invoice
+type Request struct {
+	SubscriptionID string
+	InvoiceID      string
+	InvoiceAmount  int64 // cents the invoice charged; credits against it can't exceed it
+	AmountCents    int64
+	Reason         domain.CreditReason
+	Memo           string
+	Settlement     domain.CreditSettlement
+}
+
+// Interactor handles the issue credit note use case
+type Interactor struct {
+	repo     contracts.SubscriptionRepository
+	notes    contracts.CreditNoteRepository
+	balances contracts.CreditBalanceRepository
+	refunds  contracts.RefundRepository
+	billing  contracts.BillingResolver
+	clock    domain.Clock
+}
+
+// NewInteractor creates a new issue credit note interactor
+func NewInteractor(repo contracts.SubscriptionRepository, notes contracts.CreditNoteRepository, balances contracts.CreditBalanceRepository, refunds contracts.RefundRepository, billing contracts.BillingResolver, clock domain.Clock) *Interactor {
+	return &Interactor{
+		repo:     repo,
+		notes:    notes,
+		balances: balances,
+		refunds:  refunds,
+		billing:  billing,
+		clock:    clock,
+	}
+}
+
+// Execute issues a credit note and settles it to the customer's credit balance or as
+// a refund. The note is saved in the same transaction as the balance entry or the
+// pending refund, so finance never sees one without the other.
+func (i *Interactor) Execute(ctx context.Context, req Request) (*domain.CreditNoteIssuedEvent, error) {
+	// 1. Load subscription
+	sub, err := i.repo.FindByID(ctx, req.SubscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. Issue via domain method, which caps the credits against the invoice
+	credited, err := i.notes.CreditedForInvoice(ctx, req.InvoiceID)
+	if err != nil {
+		return nil, err
+	}
+	invoice := domain.InvoiceRef{ID: req.InvoiceID, Amount: req.InvoiceAmount, Credited: credited}
+	note, err := domain.IssueCreditNote(uuid.New().String(), sub, invoice, req.AmountCents, req.Reason, req.Memo, req.Settlement, i.clock)
+	if err != nil {
+		return nil, err
+	}
+
+	// 3. Settle: a balance entry, or a refund the provider has accepted
+	var (
+		uow     contracts.UnitOfWork
+		balance int64
+	)
+	switch note.Settlement() {
+	case domain.SettleToBalance:
+		if balance, err = i.balances.Balance(ctx, note.CustomerID()); err != nil {
+			return nil, err
+		}
+		balance += note.Amount()
+		uow.Save(i.balances.Save(ctx, note.BalanceEntry()))
+	case domain.SettleAsRefund:
+		uow.Save(i.refund(ctx, sub, note, invoice))
+	}
+	if err := uow.Err(); err != nil {
+		return nil, err
+	}
+
+	// 4. Save the note with its settlement in one transaction
+	uow.Save(i.notes.Save(ctx, note))
+	if err := uow.Commit(ctx, i.notes); err != nil {
+		return nil, err
+	}
+
+	return note.IssuedEvent(balance), nil
+}
+
+// refund submits the note's refund and returns the mutation tracking it until the
+// provider settles it. The key is derived from the invoice's credit history, so a
+// request retried after the note failed to save is refunded once.
+func (i *Interactor) refund(ctx context.Context, sub *domain.Subscription, note *domain.CreditNote, invoice domain.InvoiceRef) (*contracts.Mutation, error) {
+	billingClient, err := i.billing.Resolve(ctx, sub.PlanID(), sub.CustomerID())
+	if err != nil {
+		return nil, err
+	}
+	providerRefundID, err := billingClient.ProcessRefund(ctx, contracts.RefundRequest{
+		SubscriptionID: sub.ID(),
+		CustomerID:     sub.CustomerID(),
+		Amount:         note.Amount(),
+		Currency:       note.Currency(),
+		Reason:         contracts.RefundReasonCreditNote,
+		CorrelationID:  correlation.ID(ctx),
+		IdempotencyKey: fmt.Sprintf("%s:%d:credit:%d", invoice.ID, invoice.Credited, note.Amount()),
+	})
+	if err != nil {
+		return nil, err
+	}
+	note.RecordRefund(providerRefundID)
+
+	refund := domain.NewPendingRefund(uuid.New().String(), sub.ID(), sub.CustomerID(), note.Amount(), note.Currency(), providerRefundID, i.clock)
+	return i.refunds.Save(ctx, refund)
+}
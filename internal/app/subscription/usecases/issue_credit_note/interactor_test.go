@@ -0,0 +1,251 @@
+package issue_credit_note
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/adapters"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/testkit"
+)
+
+// MockCreditNoteRepository is a mock implementation of CreditNoteRepository
+type MockCreditNoteRepository struct {
+	mock.Mock
+}
+
+func (m *MockCreditNoteRepository) Save(ctx context.Context, note *domain.CreditNote) (*spanner.Mutation, error) {
+	args := m.Called(ctx, note)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*spanner.Mutation), args.Error(1)
+}
+
+func (m *MockCreditNoteRepository) FindByID(ctx context.Context, id string) (*domain.CreditNote, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.CreditNote), args.Error(1)
+}
+
+func (m *MockCreditNoteRepository) CreditedForInvoice(ctx context.Context, invoiceID string) (int64, error) {
+	args := m.Called(ctx, invoiceID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockCreditNoteRepository) Apply(ctx context.Context, mutations ...*spanner.Mutation) error {
+	args := m.Called(ctx, mutations)
+	return args.Error(0)
+}
+
+// MockCreditBalanceRepository is a mock implementation of CreditBalanceRepository
+type MockCreditBalanceRepository struct {
+	mock.Mock
+}
+
+func (m *MockCreditBalanceRepository) Save(ctx context.Context, entry *domain.CreditEntry) (*spanner.Mutation, error) {
+	args := m.Called(ctx, entry)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*spanner.Mutation), args.Error(1)
+}
+
+func (m *MockCreditBalanceRepository) Balance(ctx context.Context, customerID string) (int64, error) {
+	args := m.Called(ctx, customerID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// MockRefundRepository is a mock implementation of RefundRepository
+type MockRefundRepository struct {
+	mock.Mock
+}
+
+func (m *MockRefundRepository) Save(ctx context.Context, refund *domain.Refund) (*spanner.Mutation, error) {
+	args := m.Called(ctx, refund)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*spanner.Mutation), args.Error(1)
+}
+
+func (m *MockRefundRepository) Apply(ctx context.Context, mutations ...*spanner.Mutation) error {
+	args := m.Called(ctx, mutations)
+	return args.Error(0)
+}
+
+func (m *MockRefundRepository) FindByID(ctx context.Context, id string) (*domain.Refund, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Refund), args.Error(1)
+}
+
+func (m *MockRefundRepository) FindByProviderRefundID(ctx context.Context, providerRefundID string) (*domain.Refund, error) {
+	args := m.Called(ctx, providerRefundID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Refund), args.Error(1)
+}
+
+func (m *MockRefundRepository) FindPending(ctx context.Context, requestedBefore time.Time, limit int) ([]*domain.Refund, error) {
+	args := m.Called(ctx, requestedBefore, limit)
+	return args.Get(0).([]*domain.Refund), args.Error(1)
+}
+
+var (
+	startDate = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	issueDate = time.Date(2024, 1, 20, 0, 0, 0, 0, time.UTC)
+)
+
+type fixture struct {
+	repo     *testkit.MockSubscriptions
+	notes    *MockCreditNoteRepository
+	balances *MockCreditBalanceRepository
+	refunds  *MockRefundRepository
+	billing  *testkit.FakeBillingClient
+}
+
+func newFixture() *fixture {
+	return &fixture{
+		repo:     new(testkit.MockSubscriptions),
+		notes:    new(MockCreditNoteRepository),
+		balances: new(MockCreditBalanceRepository),
+		refunds:  new(MockRefundRepository),
+		billing:  testkit.NewFakeBillingClient(),
+	}
+}
+
+func (f *fixture) interactor() *Interactor {
+	return NewInteractor(f.repo, f.notes, f.balances, f.refunds, adapters.StaticBillingResolver{Client: f.billing}, domain.FixedClock{FixedTime: issueDate})
+}
+
+func activeSubscription() *domain.Subscription {
+	return domain.ReconstructFromPersistence("sub-123", "cust-456", "plan-basic", 3000, domain.StatusActive, startDate)
+}
+
+func outageCredit(settlement domain.CreditSettlement) Request {
+	return Request{
+		SubscriptionID: "sub-123",
+		InvoiceID:      "inv-1",
+		InvoiceAmount:  3000,
+		AmountCents:    500,
+		Reason:         domain.CreditReasonOutage,
+		Memo:           "EU outage on 2024-01-12",
+		Settlement:     settlement,
+	}
+}
+
+func TestIssueCreditNote_ToBalance(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture()
+	noteMutation, entryMutation := &spanner.Mutation{}, &spanner.Mutation{}
+
+	f.repo.On("FindByID", ctx, "sub-123").Return(activeSubscription(), nil)
+	f.notes.On("CreditedForInvoice", ctx, "inv-1").Return(int64(1000), nil)
+	f.balances.On("Balance", ctx, "cust-456").Return(int64(250), nil)
+	f.balances.On("Save", ctx, mock.MatchedBy(func(e *domain.CreditEntry) bool {
+		return e.CustomerID() == "cust-456" && e.Amount() == 500 && e.Source() == domain.CreditSourceCreditNote
+	})).Return(entryMutation, nil)
+	f.notes.On("Save", ctx, mock.MatchedBy(func(n *domain.CreditNote) bool {
+		return n.Memo() == "EU outage on 2024-01-12" && n.ProviderRefundID() == ""
+	})).Return(noteMutation, nil)
+	f.notes.On("Apply", ctx, []*spanner.Mutation{entryMutation, noteMutation}).Return(nil)
+
+	event, err := f.interactor().Execute(ctx, outageCredit(domain.SettleToBalance))
+
+	require.NoError(t, err)
+	assert.Equal(t, "inv-1", event.InvoiceID)
+	assert.Equal(t, int64(500), event.Amount)
+	assert.Equal(t, domain.CreditReasonOutage, event.Reason)
+	assert.Equal(t, int64(750), event.Balance)
+	assert.Equal(t, issueDate, event.IssuedAt)
+	assert.Empty(t, f.billing.Calls())
+	f.notes.AssertExpectations(t)
+	f.balances.AssertExpectations(t)
+}
+
+func TestIssueCreditNote_AsRefund(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture()
+
+	f.repo.On("FindByID", ctx, "sub-123").Return(activeSubscription(), nil)
+	f.notes.On("CreditedForInvoice", ctx, "inv-1").Return(int64(0), nil)
+	f.refunds.On("Save", ctx, mock.MatchedBy(func(r *domain.Refund) bool {
+		return r.Amount() == 500 && r.ProviderRefundID() == "fake-refund-1" && r.Status() == domain.RefundPending
+	})).Return(&spanner.Mutation{}, nil)
+	f.notes.On("Save", ctx, mock.MatchedBy(func(n *domain.CreditNote) bool {
+		return n.ProviderRefundID() == "fake-refund-1"
+	})).Return(&spanner.Mutation{}, nil)
+	f.notes.On("Apply", ctx, mock.MatchedBy(func(m []*spanner.Mutation) bool { return len(m) == 2 })).Return(nil)
+
+	event, err := f.interactor().Execute(ctx, outageCredit(domain.SettleAsRefund))
+
+	require.NoError(t, err)
+	assert.Equal(t, "fake-refund-1", event.ProviderRefundID)
+	assert.Equal(t, domain.SettleAsRefund, event.Settlement)
+	refunds := f.billing.CallsTo(testkit.OpProcessRefund)
+	require.Len(t, refunds, 1)
+	assert.Equal(t, contracts.RefundReasonCreditNote, refunds[0].Refund.Reason)
+	assert.Equal(t, "inv-1:0:credit:500", refunds[0].Refund.IdempotencyKey)
+	f.balances.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+	f.refunds.AssertExpectations(t)
+}
+
+func TestIssueCreditNote_FailedRefundSavesNothing(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture()
+	f.billing.FailAlways(testkit.OpProcessRefund, errors.New("billing unavailable"))
+
+	f.repo.On("FindByID", ctx, "sub-123").Return(activeSubscription(), nil)
+	f.notes.On("CreditedForInvoice", ctx, "inv-1").Return(int64(0), nil)
+
+	event, err := f.interactor().Execute(ctx, outageCredit(domain.SettleAsRefund))
+
+	assert.Error(t, err)
+	assert.Nil(t, event)
+	f.notes.AssertNotCalled(t, "Apply", mock.Anything, mock.Anything)
+}
+
+func TestIssueCreditNote_Rejections(t *testing.T) {
+	tests := []struct {
+		name     string
+		modify   func(*Request)
+		credited int64
+		wantErr  error
+	}{
+		{"exceeds invoice", func(r *Request) {}, 2600, domain.ErrCreditExceedsInvoice},
+		{"zero amount", func(r *Request) { r.AmountCents = 0 }, 0, domain.ErrInvalidCreditAmount},
+		{"unknown reason", func(r *Request) { r.Reason = "because" }, 0, domain.ErrInvalidCreditReason},
+		{"unknown settlement", func(r *Request) { r.Settlement = "cheque" }, 0, domain.ErrInvalidCreditSettlement},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			f := newFixture()
+			req := outageCredit(domain.SettleAsRefund)
+			tc.modify(&req)
+
+			f.repo.On("FindByID", ctx, "sub-123").Return(activeSubscription(), nil)
+			f.notes.On("CreditedForInvoice", ctx, "inv-1").Return(tc.credited, nil)
+
+			_, err := f.interactor().Execute(ctx, req)
+
+			assert.ErrorIs(t, err, tc.wantErr)
+			assert.Empty(t, f.billing.Calls())
+			f.notes.AssertNotCalled(t, "Apply", mock.Anything, mock.Anything)
+		})
+	}
+}
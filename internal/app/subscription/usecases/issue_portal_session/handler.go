@@ -0,0 +1,45 @@
+package issue_portal_session
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/bus"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+// CommandName identifies the issue portal session command on the bus
+const CommandName = "customer.issue_portal_session"
+
+var _ bus.Handler = (*Interactor)(nil)
+
+// Privileged implements bus.Privileged: a portal session acts for the customer, so
+// minting one is audited
+func (r Request) Privileged() {}
+
+// CommandName implements bus.Command
+func (r Request) CommandName() string {
+	return CommandName
+}
+
+// Validate rejects obviously invalid input before a token is signed
+func (r Request) Validate() error {
+	if r.CustomerID == "" {
+		return domain.ErrInvalidCustomerID
+	}
+	return nil
+}
+
+// Handle executes the use case for a command dispatched through the bus
+func (i *Interactor) Handle(ctx context.Context, cmd bus.Command) (any, error) {
+	req, ok := cmd.(Request)
+	if !ok {
+		return nil, fmt.Errorf("unexpected command type %T", cmd)
+	}
+
+	result, err := i.Execute(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,58 @@
+package issue_portal_session
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+// Request contains the input for starting a customer portal session. A zero TTL
+// means domain.DefaultPortalSessionTTL.
+type Request struct {
+	CustomerID string
+	Scopes     []domain.PortalScope
+	TTL        time.Duration
+}
+
+// Result carries the signed token the portal presents, and when it stops working
+type Result struct {
+	Token     string
+	SessionID string
+	ExpiresAt time.Time
+}
+
+// Interactor handles the issue portal session use case
+type Interactor struct {
+	tokens contracts.PortalTokens
+	clock  domain.Clock
+}
+
+// NewInteractor creates a new issue portal session interactor
+func NewInteractor(tokens contracts.PortalTokens, clock domain.Clock) *Interactor {
+	return &Interactor{
+		tokens: tokens,
+		clock:  clock,
+	}
+}
+
+// Execute mints a short-lived token letting the self-service portal act for the
+// customer within the requested scopes. Nothing is stored: the token carries the
+// session, and it can't be revoked before it expires.
+func (i *Interactor) Execute(ctx context.Context, req Request) (*Result, error) {
+	// 1. Start the session via domain constructor
+	session, err := domain.NewPortalSession(uuid.New().String(), req.CustomerID, req.Scopes, req.TTL, i.clock)
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. Sign it into a token
+	token, err := i.tokens.Encode(ctx, session)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Result{Token: token, SessionID: session.ID(), ExpiresAt: session.ExpiresAt()}, nil
+}
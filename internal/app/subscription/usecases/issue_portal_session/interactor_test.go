@@ -0,0 +1,104 @@
+package issue_portal_session
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+// fakeTokens encodes sessions as their ID and remembers them for Decode
+type fakeTokens struct {
+	sessions map[string]*domain.PortalSession
+	err      error
+}
+
+func (f *fakeTokens) Encode(ctx context.Context, session *domain.PortalSession) (string, error) {
+	if f.err != nil {
+		return "", f.err
+	}
+	f.sessions[session.ID()] = session
+	return session.ID(), nil
+}
+
+func (f *fakeTokens) Decode(ctx context.Context, token string) (*domain.PortalSession, error) {
+	session, ok := f.sessions[token]
+	if !ok {
+		return nil, domain.ErrInvalidPortalToken
+	}
+	return session, nil
+}
+
+var now = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func newTestInteractor() (*Interactor, *fakeTokens) {
+	tokens := &fakeTokens{sessions: make(map[string]*domain.PortalSession)}
+	return NewInteractor(tokens, domain.FixedClock{FixedTime: now}), tokens
+}
+
+func TestIssuePortalSession_MintsScopedToken(t *testing.T) {
+	interactor, tokens := newTestInteractor()
+
+	result, err := interactor.Execute(context.Background(), Request{
+		CustomerID: "cust-1",
+		Scopes:     []domain.PortalScope{domain.PortalScopeCancel},
+		TTL:        5 * time.Minute,
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, now.Add(5*time.Minute), result.ExpiresAt)
+	session, err := tokens.Decode(context.Background(), result.Token)
+	require.NoError(t, err)
+	assert.Equal(t, result.SessionID, session.ID())
+	assert.Equal(t, "cust-1", session.CustomerID())
+	assert.True(t, session.Allows(domain.PortalScopeCancel))
+	assert.False(t, session.Allows(domain.PortalScopeChangePlan))
+}
+
+func TestIssuePortalSession_DefaultsLifetime(t *testing.T) {
+	interactor, _ := newTestInteractor()
+
+	result, err := interactor.Execute(context.Background(), Request{CustomerID: "cust-1", Scopes: []domain.PortalScope{domain.PortalScopeChangePlan}})
+
+	require.NoError(t, err)
+	assert.Equal(t, now.Add(domain.DefaultPortalSessionTTL), result.ExpiresAt)
+}
+
+func TestIssuePortalSession_RejectsInvalidRequests(t *testing.T) {
+	cancel := []domain.PortalScope{domain.PortalScopeCancel}
+	testCases := []struct {
+		name string
+		req  Request
+		want error
+	}{
+		{name: "no customer", req: Request{Scopes: cancel}, want: domain.ErrInvalidCustomerID},
+		{name: "no scopes", req: Request{CustomerID: "cust-1"}, want: domain.ErrInvalidPortalScope},
+		{name: "unknown scope", req: Request{CustomerID: "cust-1", Scopes: []domain.PortalScope{"refund"}}, want: domain.ErrInvalidPortalScope},
+		{name: "negative lifetime", req: Request{CustomerID: "cust-1", Scopes: cancel, TTL: -time.Minute}, want: domain.ErrInvalidPortalTTL},
+		{name: "lifetime too long", req: Request{CustomerID: "cust-1", Scopes: cancel, TTL: 2 * time.Hour}, want: domain.ErrInvalidPortalTTL},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			interactor, tokens := newTestInteractor()
+
+			_, err := interactor.Execute(context.Background(), tc.req)
+
+			assert.ErrorIs(t, err, tc.want)
+			assert.Empty(t, tokens.sessions)
+		})
+	}
+}
+
+func TestIssuePortalSession_SigningFailure(t *testing.T) {
+	interactor, tokens := newTestInteractor()
+	tokens.err = errors.New("secret manager down")
+
+	_, err := interactor.Execute(context.Background(), Request{CustomerID: "cust-1", Scopes: []domain.PortalScope{domain.PortalScopeCancel}})
+
+	assert.ErrorIs(t, err, tokens.err)
+}
@@ -0,0 +1,46 @@
+package issue_referral_code
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/bus"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+// CommandName identifies the issue referral code command on the bus
+const CommandName = "subscription.issue_referral_code"
+
+var _ bus.Handler = (*Interactor)(nil)
+
+// Response is the bus result of an issue referral code command
+type Response struct {
+	Code string
+}
+
+// CommandName implements bus.Command
+func (r Request) CommandName() string {
+	return CommandName
+}
+
+// Validate rejects obviously invalid input before the repository is called
+func (r Request) Validate() error {
+	if r.CustomerID == "" {
+		return domain.ErrInvalidCustomerID
+	}
+	return nil
+}
+
+// Handle executes the use case for a command dispatched through the bus
+func (i *Interactor) Handle(ctx context.Context, cmd bus.Command) (any, error) {
+	req, ok := cmd.(Request)
+	if !ok {
+		return nil, fmt.Errorf("unexpected command type %T", cmd)
+	}
+
+	code, err := i.Execute(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return &Response{Code: code}, nil
+}
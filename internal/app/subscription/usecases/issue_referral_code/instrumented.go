@@ -0,0 +1,37 @@
+package issue_referral_code
+
+import (
+	"context"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/instrument"
+)
+
+// UseCase is the issue referral code use case as seen by callers
+type UseCase interface {
+	Execute(ctx context.Context, req Request) (string, error)
+}
+
+var (
+	_ UseCase = (*Interactor)(nil)
+	_ UseCase = (*Instrumented)(nil)
+)
+
+// Instrumented decorates a UseCase with logs, metrics and a trace span per execution
+type Instrumented struct {
+	next UseCase
+	in   instrument.Instrumentation
+}
+
+// NewInstrumented wraps the given use case with instrumentation
+func NewInstrumented(next UseCase, in instrument.Instrumentation) *Instrumented {
+	return &Instrumented{next: next, in: in}
+}
+
+// Execute runs the wrapped use case
+func (d *Instrumented) Execute(ctx context.Context, req Request) (string, error) {
+	attrs := map[string]string{"customer_id": req.CustomerID}
+
+	return instrument.Run(ctx, d.in, "issue_referral_code", attrs, func(ctx context.Context) (string, error) {
+		return d.next.Execute(ctx, req)
+	})
+}
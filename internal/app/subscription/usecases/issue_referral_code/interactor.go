@@ -0,0 +1,56 @@
+package issue_referral_code
+
+import (
+	"context"
+	"errors"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+// Request contains the input for issuing a customer's referral code
+type Request struct {
+	CustomerID string
+}
+
+// Interactor handles the issue referral code use case
+type Interactor struct {
+	referrals contracts.ReferralRepository
+	clock     domain.Clock
+}
+
+// NewInteractor creates a new issue referral code interactor
+func NewInteractor(referrals contracts.ReferralRepository, clock domain.Clock) *Interactor {
+	return &Interactor{
+		referrals: referrals,
+		clock:     clock,
+	}
+}
+
+// Execute returns the customer's referral code, generating one the first time they
+// ask. A customer keeps the same code, so codes already shared keep working.
+func (i *Interactor) Execute(ctx context.Context, req Request) (string, error) {
+	// 1. Return the code the customer already has
+	code, err := i.referrals.FindCode(ctx, req.CustomerID)
+	if err == nil {
+		return code, nil
+	}
+	if !errors.Is(err, domain.ErrReferralCodeNotFound) {
+		return "", err
+	}
+
+	// 2. Generate a new one
+	if code, err = domain.GenerateReferralCode(); err != nil {
+		return "", err
+	}
+
+	// 3. Save it; codes are inserted, so the rare clash with another customer's code
+	// fails rather than taking it over, and the caller can ask again
+	var uow contracts.UnitOfWork
+	uow.Save(i.referrals.SaveCode(ctx, req.CustomerID, code, i.clock.Now()))
+	if err := uow.Commit(ctx, i.referrals); err != nil {
+		return "", err
+	}
+
+	return code, nil
+}
@@ -0,0 +1,47 @@
+package issue_referral_code
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/testkit"
+)
+
+var now = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestIssueReferralCode_GeneratesCodeOnce(t *testing.T) {
+	ctx := context.Background()
+	referrals := testkit.NewFakeReferrals()
+	interactor := NewInteractor(referrals, domain.FixedClock{FixedTime: now})
+
+	code, err := interactor.Execute(ctx, Request{CustomerID: "cust-1"})
+	require.NoError(t, err)
+	normalized, err := domain.NormalizeReferralCode(code)
+	require.NoError(t, err)
+	assert.Equal(t, code, normalized)
+
+	again, err := interactor.Execute(ctx, Request{CustomerID: "cust-1"})
+	require.NoError(t, err)
+	assert.Equal(t, code, again)
+	assert.Equal(t, 1, referrals.Applied())
+
+	owner, err := referrals.FindCodeOwner(ctx, code)
+	require.NoError(t, err)
+	assert.Equal(t, "cust-1", owner)
+}
+
+func TestIssueReferralCode_ReturnsExistingCode(t *testing.T) {
+	ctx := context.Background()
+	referrals := testkit.NewFakeReferrals().WithCode("cust-1", "ABCD2345")
+	interactor := NewInteractor(referrals, domain.FixedClock{FixedTime: now})
+
+	code, err := interactor.Execute(ctx, Request{CustomerID: "cust-1"})
+
+	require.NoError(t, err)
+	assert.Equal(t, "ABCD2345", code)
+	assert.Zero(t, referrals.Applied())
+}
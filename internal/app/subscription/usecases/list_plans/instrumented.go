@@ -0,0 +1,39 @@
+package list_plans
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/instrument"
+)
+
+// UseCase is the plan listing use case as seen by callers
+type UseCase interface {
+	Execute(ctx context.Context, req Request) ([]*domain.Plan, error)
+}
+
+var (
+	_ UseCase = (*Interactor)(nil)
+	_ UseCase = (*Instrumented)(nil)
+)
+
+// Instrumented decorates a UseCase with logs, metrics and a trace span per execution
+type Instrumented struct {
+	next UseCase
+	in   instrument.Instrumentation
+}
+
+// NewInstrumented wraps the given use case with instrumentation
+func NewInstrumented(next UseCase, in instrument.Instrumentation) *Instrumented {
+	return &Instrumented{next: next, in: in}
+}
+
+// Execute runs the wrapped use case
+func (d *Instrumented) Execute(ctx context.Context, req Request) ([]*domain.Plan, error) {
+	attrs := map[string]string{"active_only": strconv.FormatBool(req.ActiveOnly)}
+
+	return instrument.Run(ctx, d.in, "list_plans", attrs, func(ctx context.Context) ([]*domain.Plan, error) {
+		return d.next.Execute(ctx, req)
+	})
+}
@@ -0,0 +1,43 @@
+package list_plans
+
+import (
+	"context"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+// Request contains the input for listing plans
+type Request struct {
+	ActiveOnly bool // leave out plans customers can no longer subscribe to
+}
+
+// Interactor handles the plan listing use case
+type Interactor struct {
+	plans contracts.PlanRepository
+}
+
+// NewInteractor creates a new plan listing interactor
+func NewInteractor(plans contracts.PlanRepository) *Interactor {
+	return &Interactor{plans: plans}
+}
+
+// Execute lists the plan catalog, ordered by plan ID. The catalog is small enough to
+// list in one go.
+func (i *Interactor) Execute(ctx context.Context, req Request) ([]*domain.Plan, error) {
+	plans, err := i.plans.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !req.ActiveOnly {
+		return plans, nil
+	}
+
+	active := make([]*domain.Plan, 0, len(plans))
+	for _, plan := range plans {
+		if plan.Active() {
+			active = append(active, plan)
+		}
+	}
+	return active, nil
+}
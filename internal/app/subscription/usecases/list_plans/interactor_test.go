@@ -0,0 +1,38 @@
+package list_plans
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/testkit"
+)
+
+func TestListPlans(t *testing.T) {
+	at := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	plans := testkit.NewFakePlans(
+		domain.ReconstructPlan("plan-pro", "Pro", 3000, "USD", domain.IntervalMonth, 0, true, "", "", at, at),
+		domain.ReconstructPlan("plan-legacy", "Legacy", 900, "USD", "", 0, false, "", "", at, at),
+		domain.ReconstructPlan("plan-basic", "Basic", 1000, "USD", domain.IntervalMonth, 14, true, "", "", at, at),
+	)
+	interactor := NewInteractor(plans)
+
+	for _, tc := range []struct {
+		req  Request
+		want []string
+	}{
+		{Request{}, []string{"plan-basic", "plan-legacy", "plan-pro"}},
+		{Request{ActiveOnly: true}, []string{"plan-basic", "plan-pro"}},
+	} {
+		listed, err := interactor.Execute(context.Background(), tc.req)
+		require.NoError(t, err)
+		ids := make([]string, 0, len(listed))
+		for _, plan := range listed {
+			ids = append(ids, plan.ID())
+		}
+		assert.Equal(t, tc.want, ids)
+	}
+}
@@ -0,0 +1,117 @@
+package list_subscriptions
+
+import (
+	"context"
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+const (
+	// DefaultPageSize is how many subscriptions a page holds when the request doesn't say
+	DefaultPageSize = 50
+	// MaxPageSize is the most subscriptions one page holds
+	MaxPageSize = 200
+)
+
+// Request contains the input for listing a page of a customer's subscriptions
+type Request struct {
+	CustomerID string
+	Status     domain.SubscriptionStatus // empty lists every status
+	PageToken  string                    // from the previous page; empty for the first
+	PageSize   int                       // zero means DefaultPageSize
+}
+
+// Validate checks the request
+func (r Request) Validate() error {
+	if r.CustomerID == "" {
+		return domain.ErrInvalidCustomerID
+	}
+	switch r.Status {
+	case "", domain.StatusActive, domain.StatusCancelled, domain.StatusPastDue, domain.StatusTrialing, domain.StatusPaused, domain.StatusPendingCancellation:
+	default:
+		return domain.ErrInvalidSubscriptionStatus
+	}
+	if r.PageSize < 0 || r.PageSize > MaxPageSize {
+		return domain.ErrInvalidPageSize
+	}
+	return nil
+}
+
+// Page is one page of subscriptions, oldest first
+type Page struct {
+	Subscriptions []contracts.SubscriptionSummary
+	NextPageToken string // empty on the last page
+}
+
+// Interactor handles the subscription listing use case
+type Interactor struct {
+	repo contracts.SubscriptionListingRepository
+}
+
+// NewInteractor creates a new subscription listing interactor
+func NewInteractor(repo contracts.SubscriptionListingRepository) *Interactor {
+	return &Interactor{repo: repo}
+}
+
+// Execute returns the page after the request's page token. Pages are cut by start date
+// and ID rather than by offset, so a subscription created or cancelled while a caller
+// pages through doesn't shift the pages after it.
+func (i *Interactor) Execute(ctx context.Context, req Request) (*Page, error) {
+	// 1. Validate request
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	after, err := decodePageToken(req.PageToken)
+	if err != nil {
+		return nil, err
+	}
+	size := req.PageSize
+	if size == 0 {
+		size = DefaultPageSize
+	}
+
+	// 2. Read one more than the page holds, to tell whether another page follows
+	subs, err := i.repo.ListByCustomer(ctx, req.CustomerID, req.Status, after, size+1)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &Page{Subscriptions: subs}
+	if len(subs) > size {
+		page.Subscriptions = subs[:size]
+		last := page.Subscriptions[size-1]
+		page.NextPageToken = encodePageToken(contracts.ListingCursor{StartDate: last.StartDate, ID: last.ID})
+	}
+	return page, nil
+}
+
+// encodePageToken makes the cursor opaque to callers, who only hand it back
+func encodePageToken(cursor contracts.ListingCursor) string {
+	raw := strconv.FormatInt(cursor.StartDate.UnixNano(), 10) + "|" + cursor.ID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodePageToken reverses encodePageToken; an empty token is the start of the listing
+func decodePageToken(token string) (contracts.ListingCursor, error) {
+	if token == "" {
+		return contracts.ListingCursor{}, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return contracts.ListingCursor{}, domain.ErrInvalidPageToken
+	}
+	nanos, id, ok := strings.Cut(string(raw), "|")
+	if !ok || id == "" {
+		return contracts.ListingCursor{}, domain.ErrInvalidPageToken
+	}
+	n, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return contracts.ListingCursor{}, domain.ErrInvalidPageToken
+	}
+	return contracts.ListingCursor{StartDate: time.Unix(0, n).UTC(), ID: id}, nil
+}
@@ -0,0 +1,118 @@
+package list_subscriptions
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/testkit"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/testkit/builders"
+)
+
+var day = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// started builds an active subscription of cust-1 that started days after day
+func started(id string, days int) *domain.Subscription {
+	return builders.NewSubscriptionBuilder().WithID(id).WithCustomerID("cust-1").StartedAt(day.AddDate(0, 0, days)).Build()
+}
+
+func ids(subs []contracts.SubscriptionSummary) []string {
+	var ids []string
+	for _, s := range subs {
+		ids = append(ids, s.ID)
+	}
+	return ids
+}
+
+func TestListSubscriptions_PagesByStartDateThenID(t *testing.T) {
+	repo := testkit.NewFakeSubscriptions().With(
+		started("sub-c", 2), started("sub-b", 1), started("sub-a", 1), started("sub-d", 3),
+		builders.NewSubscriptionBuilder().WithID("other").WithCustomerID("cust-2").Build(),
+	)
+	interactor := NewInteractor(repo)
+
+	first, err := interactor.Execute(context.Background(), Request{CustomerID: "cust-1", PageSize: 3})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"sub-a", "sub-b", "sub-c"}, ids(first.Subscriptions), "subscriptions starting together are ordered by ID")
+	require.NotEmpty(t, first.NextPageToken)
+
+	second, err := interactor.Execute(context.Background(), Request{CustomerID: "cust-1", PageSize: 3, PageToken: first.NextPageToken})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"sub-d"}, ids(second.Subscriptions))
+	assert.Empty(t, second.NextPageToken, "the last page has no token")
+}
+
+func TestListSubscriptions_ChangesMidwayDontSkipOrRepeatRows(t *testing.T) {
+	repo := testkit.NewFakeSubscriptions()
+	for n := 0; n < 6; n++ {
+		repo.With(started(fmt.Sprintf("sub-%d", n), n))
+	}
+	interactor := NewInteractor(repo)
+
+	first, err := interactor.Execute(context.Background(), Request{CustomerID: "cust-1", PageSize: 3})
+	require.NoError(t, err)
+
+	// Between pages a subscription is created and one already listed is cancelled
+	repo.With(started("sub-new", 10))
+	listed, err := repo.FindByID(context.Background(), "sub-1")
+	require.NoError(t, err)
+	_, err = listed.Cancel(domain.FixedClock{FixedTime: day.AddDate(0, 0, 20)}, 30)
+	require.NoError(t, err)
+
+	second, err := interactor.Execute(context.Background(), Request{CustomerID: "cust-1", PageSize: 3, PageToken: first.NextPageToken})
+	require.NoError(t, err)
+	third, err := interactor.Execute(context.Background(), Request{CustomerID: "cust-1", PageSize: 3, PageToken: second.NextPageToken})
+	require.NoError(t, err)
+
+	all := append(append(ids(first.Subscriptions), ids(second.Subscriptions)...), ids(third.Subscriptions)...)
+	assert.Equal(t, []string{"sub-0", "sub-1", "sub-2", "sub-3", "sub-4", "sub-5", "sub-new"}, all)
+}
+
+func TestListSubscriptions_FiltersByStatus(t *testing.T) {
+	repo := testkit.NewFakeSubscriptions().With(
+		started("sub-a", 0),
+		builders.NewSubscriptionBuilder().WithID("sub-b").WithCustomerID("cust-1").Cancelled().Build(),
+	)
+
+	page, err := NewInteractor(repo).Execute(context.Background(), Request{CustomerID: "cust-1", Status: domain.StatusCancelled})
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"sub-b"}, ids(page.Subscriptions))
+	assert.Equal(t, domain.StatusCancelled, page.Subscriptions[0].Status)
+}
+
+func TestListSubscriptions_RejectsInvalidRequests(t *testing.T) {
+	interactor := NewInteractor(testkit.NewFakeSubscriptions())
+
+	tests := []struct {
+		name string
+		req  Request
+		want error
+	}{
+		{"no customer", Request{}, domain.ErrInvalidCustomerID},
+		{"unknown status", Request{CustomerID: "cust-1", Status: "SUSPENDED"}, domain.ErrInvalidSubscriptionStatus},
+		{"page too large", Request{CustomerID: "cust-1", PageSize: MaxPageSize + 1}, domain.ErrInvalidPageSize},
+		{"token not base64", Request{CustomerID: "cust-1", PageToken: "!"}, domain.ErrInvalidPageToken},
+		{"token without cursor", Request{CustomerID: "cust-1", PageToken: "bm9wZQ"}, domain.ErrInvalidPageToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := interactor.Execute(context.Background(), tt.req)
+			assert.ErrorIs(t, err, tt.want)
+		})
+	}
+}
+
+func TestPageToken_RoundTrips(t *testing.T) {
+	cursor := contracts.ListingCursor{StartDate: time.Date(2024, 2, 3, 4, 5, 6, 789, time.UTC), ID: "sub|with|bars"}
+
+	got, err := decodePageToken(encodePageToken(cursor))
+
+	require.NoError(t, err)
+	assert.Equal(t, cursor, got)
+}
@@ -0,0 +1,82 @@
+package notify_renewal
+
+import (
+	"context"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+// Interactor handles the notify renewal use case
+type Interactor struct {
+	repo     contracts.SubscriptionRepository
+	regions  contracts.CustomerRegionSource
+	pricing  contracts.PricingSource
+	notifier contracts.RenewalNoticeNotifier
+	clock    domain.Clock
+	cycles   contracts.BillingCycleSource
+	policy   domain.RenewalNoticePolicy
+}
+
+// NewInteractor creates a new notify renewal interactor
+func NewInteractor(repo contracts.SubscriptionRepository, regions contracts.CustomerRegionSource, pricing contracts.PricingSource, notifier contracts.RenewalNoticeNotifier, clock domain.Clock, cycles contracts.BillingCycleSource, policy domain.RenewalNoticePolicy) *Interactor {
+	return &Interactor{
+		repo:     repo,
+		regions:  regions,
+		pricing:  pricing,
+		notifier: notifier,
+		clock:    clock,
+		cycles:   cycles,
+		policy:   policy,
+	}
+}
+
+// Execute tells the customer about the subscription's next renewal once it is within
+// the notice their plan and region call for. It fails with
+// domain.ErrRenewalNoticeNotDue before then, and with
+// domain.ErrRenewalNoticeNotRequired when neither asks for a notice.
+func (i *Interactor) Execute(ctx context.Context, subscriptionID string) (*domain.RenewalUpcomingEvent, error) {
+	// 1. Load subscription
+	sub, err := i.repo.FindByID(ctx, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. Work out the notice the plan and the customer's region require
+	region, err := i.regions.RegionOf(ctx, sub.CustomerID())
+	if err != nil {
+		return nil, err
+	}
+	noticeDays := i.policy.DaysFor(sub.PlanID(), region)
+
+	// 3. Record the notice via domain method, quoting the discounted price
+	pricing, err := i.pricing.PricingFor(ctx, sub)
+	if err != nil {
+		return nil, err
+	}
+	cycle, err := i.cycles.CycleFor(ctx, sub)
+	if err != nil {
+		return nil, err
+	}
+	event, err := sub.NoticeRenewal(i.clock, cycle, noticeDays, pricing)
+	if err != nil {
+		return nil, err
+	}
+
+	// 4. Notify before saving: a notice the law requires is better sent twice than
+	// marked sent and lost, so a failed notification leaves it for the next pass
+	if err := i.notifier.NotifyRenewalUpcoming(ctx, event); err != nil {
+		return nil, err
+	}
+
+	// 5. Save the updated subscription
+	var uow contracts.UnitOfWork
+	uow.SaveChecked(i.repo.Save(ctx, sub))
+
+	// 6. Commit the write
+	if err := uow.Commit(ctx, i.repo); err != nil {
+		return nil, err
+	}
+
+	return event, nil
+}
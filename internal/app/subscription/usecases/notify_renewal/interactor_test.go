@@ -0,0 +1,140 @@
+package notify_renewal
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/adapters"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/testkit"
+)
+
+var (
+	startDate = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	renewsAt  = startDate.AddDate(0, 0, 30)
+	policy    = domain.RenewalNoticePolicy{
+		DefaultDays:       7,
+		PlanDays:          map[string]int64{"plan-annual": 30, "plan-free": 0},
+		RegionMinimumDays: map[string]int64{"US-CA": 15},
+	}
+)
+
+type fixture struct {
+	repo    *testkit.MockSubscriptions
+	notices *testkit.RecordingRenewalNotices
+	sub     *domain.Subscription
+	now     time.Time
+}
+
+// newFixture sets up an active subscription renewing at renewsAt; customer cust-ca is
+// in US-CA, everyone else in DE
+func newFixture(customerID, planID string, now time.Time) *fixture {
+	f := &fixture{
+		repo:    &testkit.MockSubscriptions{},
+		notices: &testkit.RecordingRenewalNotices{},
+		sub:     domain.ReconstructFromPersistence("sub-123", customerID, planID, 3000, domain.StatusActive, startDate),
+		now:     now,
+	}
+	f.repo.On("FindByID", mock.Anything, "sub-123").Return(f.sub, nil)
+	f.repo.On("Save", mock.Anything, f.sub).Return(&spanner.Mutation{}, nil)
+	f.repo.On("Apply", mock.Anything, mock.Anything).Return(nil)
+	return f
+}
+
+func (f *fixture) execute() (*domain.RenewalUpcomingEvent, error) {
+	regions := adapters.StaticRegions{Default: "DE", Customers: map[string]string{"cust-ca": "US-CA"}}
+	interactor := NewInteractor(f.repo, regions, adapters.StaticPricing{}, f.notices, domain.FixedClock{FixedTime: f.now}, adapters.StaticBillingCycle{Cycle: domain.CycleOfDays(30)}, policy)
+	return interactor.Execute(context.Background(), "sub-123")
+}
+
+func TestNotifyRenewal_SendsNoticeWithinPlanNotice(t *testing.T) {
+	f := newFixture("cust-456", "plan-basic", renewsAt.AddDate(0, 0, -7))
+
+	event, err := f.execute()
+
+	require.NoError(t, err)
+	assert.Equal(t, &domain.RenewalUpcomingEvent{
+		SubscriptionID: "sub-123",
+		CustomerID:     "cust-456",
+		PlanID:         "plan-basic",
+		Amount:         3000,
+		Currency:       domain.DefaultCurrency,
+		RenewsAt:       renewsAt,
+		NoticeDays:     7,
+		NotifiedAt:     f.now,
+	}, event)
+	assert.Equal(t, []*domain.RenewalUpcomingEvent{event}, f.notices.Notices())
+	assert.Equal(t, renewsAt, f.sub.RenewalNoticeSentFor())
+	f.repo.AssertCalled(t, "Apply", mock.Anything, mock.Anything)
+}
+
+func TestNotifyRenewal_NoticeDaysPerPlanAndRegion(t *testing.T) {
+	testCases := []struct {
+		name       string
+		planID     string
+		customerID string
+		now        time.Time
+		want       error
+		wantDays   int64
+	}{
+		{name: "before the plan's notice", planID: "plan-basic", customerID: "cust-456", now: renewsAt.AddDate(0, 0, -8), want: domain.ErrRenewalNoticeNotDue},
+		{name: "plan with longer notice", planID: "plan-annual", customerID: "cust-456", now: renewsAt.AddDate(0, 0, -30), wantDays: 30},
+		{name: "region requires more notice", planID: "plan-basic", customerID: "cust-ca", now: renewsAt.AddDate(0, 0, -15), wantDays: 15},
+		{name: "plan without notice", planID: "plan-free", customerID: "cust-456", now: renewsAt.AddDate(0, 0, -1), want: domain.ErrRenewalNoticeNotRequired},
+		{name: "region requires notice for plan without one", planID: "plan-free", customerID: "cust-ca", now: renewsAt.AddDate(0, 0, -1), wantDays: 15},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			f := newFixture(tc.customerID, tc.planID, tc.now)
+
+			event, err := f.execute()
+
+			if tc.want != nil {
+				assert.ErrorIs(t, err, tc.want)
+				assert.Empty(t, f.notices.Notices())
+				f.repo.AssertNotCalled(t, "Apply", mock.Anything, mock.Anything)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantDays, event.NoticeDays)
+		})
+	}
+}
+
+func TestNotifyRenewal_OncePerRenewal(t *testing.T) {
+	f := newFixture("cust-456", "plan-basic", renewsAt.AddDate(0, 0, -3))
+	_, err := f.execute()
+	require.NoError(t, err)
+
+	_, err = f.execute()
+
+	assert.ErrorIs(t, err, domain.ErrRenewalNoticeAlreadySent)
+	assert.Len(t, f.notices.Notices(), 1)
+}
+
+func TestNotifyRenewal_NotificationFailureLeavesNoticeUnsent(t *testing.T) {
+	f := newFixture("cust-456", "plan-basic", renewsAt.AddDate(0, 0, -3))
+	f.notices.Err = errors.New("mailer down")
+
+	_, err := f.execute()
+
+	assert.ErrorIs(t, err, f.notices.Err)
+	f.repo.AssertNotCalled(t, "Apply", mock.Anything, mock.Anything)
+}
+
+func TestNotifyRenewal_SkipsSubscriptionsNotActive(t *testing.T) {
+	f := newFixture("cust-456", "plan-basic", renewsAt.AddDate(0, 0, -3))
+	_, err := f.sub.Cancel(domain.FixedClock{FixedTime: startDate.AddDate(0, 0, 10)}, 30)
+	require.NoError(t, err)
+
+	_, err = f.execute()
+
+	assert.ErrorIs(t, err, domain.ErrNotActive)
+}
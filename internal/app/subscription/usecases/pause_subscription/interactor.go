@@ -0,0 +1,47 @@
+package pause_subscription
+
+import (
+	"context"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+// Interactor handles the pause subscription use case
+type Interactor struct {
+	repo  contracts.SubscriptionRepository
+	clock domain.Clock
+}
+
+// NewInteractor creates a new pause subscription interactor
+func NewInteractor(repo contracts.SubscriptionRepository, clock domain.Clock) *Interactor {
+	return &Interactor{repo: repo, clock: clock}
+}
+
+// Execute pauses an active subscription. While paused it isn't renewed, notified or
+// entitled to its plan's features, and its current period stops running, so nothing
+// is charged or refunded now; resume_subscription picks the period up where it stopped.
+func (i *Interactor) Execute(ctx context.Context, subscriptionID string) (*domain.SubscriptionPausedEvent, error) {
+	// 1. Load subscription
+	sub, err := i.repo.FindByID(ctx, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. Pause via domain method (rejects subscriptions that are not active)
+	event, err := sub.Pause(i.clock)
+	if err != nil {
+		return nil, err
+	}
+
+	// 3. Save the paused subscription
+	var uow contracts.UnitOfWork
+	uow.SaveChecked(i.repo.Save(ctx, sub))
+
+	// 4. Commit the write
+	if err := uow.Commit(ctx, i.repo); err != nil {
+		return nil, err
+	}
+
+	return event, nil
+}
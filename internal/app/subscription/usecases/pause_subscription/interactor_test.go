@@ -0,0 +1,80 @@
+package pause_subscription
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/testkit"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/testkit/builders"
+)
+
+func TestPauseSubscription_PausesActiveSubscription(t *testing.T) {
+	ctx := context.Background()
+	pauseDate := builders.DefaultStartDate.AddDate(0, 0, 10)
+	repo := testkit.NewFakeSubscriptions().With(builders.NewSubscriptionBuilder().Build())
+
+	event, err := NewInteractor(repo, domain.FixedClock{FixedTime: pauseDate}).Execute(ctx, builders.DefaultSubscriptionID)
+	require.NoError(t, err)
+	assert.Equal(t, builders.DefaultSubscriptionID, event.SubscriptionID)
+	assert.Equal(t, pauseDate, event.PausedAt)
+
+	saved, err := repo.FindByID(ctx, builders.DefaultSubscriptionID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.StatusPaused, saved.Status())
+	assert.Equal(t, pauseDate, saved.PausedAt())
+	assert.Equal(t, builders.DefaultStartDate, saved.CurrentPeriodStart(), "pausing doesn't move the period")
+}
+
+func TestPauseSubscription_CancellingWhilePausedRefundsFromThePause(t *testing.T) {
+	ctx := context.Background()
+	pauseDate := builders.DefaultStartDate.AddDate(0, 0, 10)
+	repo := testkit.NewFakeSubscriptions().With(builders.NewSubscriptionBuilder().Build())
+
+	_, err := NewInteractor(repo, domain.FixedClock{FixedTime: pauseDate}).Execute(ctx, builders.DefaultSubscriptionID)
+	require.NoError(t, err)
+
+	sub, err := repo.FindByID(ctx, builders.DefaultSubscriptionID)
+	require.NoError(t, err)
+	event, err := sub.Cancel(domain.FixedClock{FixedTime: pauseDate.AddDate(0, 0, 15)}, 30)
+	require.NoError(t, err)
+
+	// 10 of 30 days used before the pause; the 15 days paused aren't charged
+	assert.Equal(t, int64(2000), event.RefundAmount)
+	assert.True(t, sub.PausedAt().IsZero())
+}
+
+func TestPauseSubscription_RejectsSubscriptionsThatAreNotActive(t *testing.T) {
+	ctx := context.Background()
+	pauseDate := builders.DefaultStartDate.AddDate(0, 0, 10)
+
+	tests := []struct {
+		name string
+		sub  *domain.Subscription
+	}{
+		{"paused", builders.NewSubscriptionBuilder().PausedAt(builders.DefaultStartDate.AddDate(0, 0, 5)).Build()},
+		{"cancelled", builders.NewSubscriptionBuilder().Cancelled().Build()},
+		{"past due", builders.NewSubscriptionBuilder().PastDue().Build()},
+		{"trialing", builders.NewSubscriptionBuilder().Trialing(14).Build()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := tt.sub.Status()
+			repo := testkit.NewFakeSubscriptions().With(tt.sub)
+
+			_, err := NewInteractor(repo, domain.FixedClock{FixedTime: pauseDate}).Execute(ctx, builders.DefaultSubscriptionID)
+			assert.ErrorIs(t, err, domain.ErrNotActive)
+			assert.Equal(t, status, tt.sub.Status())
+		})
+	}
+}
+
+func TestPauseSubscription_NotFound(t *testing.T) {
+	repo := testkit.NewFakeSubscriptions()
+
+	_, err := NewInteractor(repo, domain.FixedClock{FixedTime: time.Now()}).Execute(context.Background(), "missing")
+	assert.ErrorIs(t, err, domain.ErrSubscriptionNotFound)
+}
@@ -0,0 +1,43 @@
+package poll_refund_status
+
+import (
+	"context"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/instrument"
+)
+
+// UseCase is the poll refund status use case as seen by callers
+type UseCase interface {
+	Execute(ctx context.Context, refundID string) (*Result, error)
+}
+
+var (
+	_ UseCase = (*Interactor)(nil)
+	_ UseCase = (*Instrumented)(nil)
+)
+
+// Instrumented decorates a UseCase with logs, metrics and a trace span per execution,
+// and records how long each refund it resolves took to process
+type Instrumented struct {
+	next UseCase
+	in   instrument.Instrumentation
+}
+
+// NewInstrumented wraps the given use case with instrumentation
+func NewInstrumented(next UseCase, in instrument.Instrumentation) *Instrumented {
+	return &Instrumented{next: next, in: in}
+}
+
+// Execute runs the wrapped use case
+func (d *Instrumented) Execute(ctx context.Context, refundID string) (*Result, error) {
+	attrs := map[string]string{"refund_id": refundID}
+
+	result, err := instrument.Run(ctx, d.in, "poll_refund_status", attrs, func(ctx context.Context) (*Result, error) {
+		return d.next.Execute(ctx, refundID)
+	})
+	if err == nil {
+		instrument.RecordRefundOutcome(d.in, "poll", result.Settled, result.Failed)
+	}
+
+	return result, err
+}
@@ -0,0 +1,78 @@
+package poll_refund_status
+
+import (
+	"context"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+// Result holds the event emitted by the poll; both are nil while the refund is still pending
+type Result struct {
+	Settled *domain.RefundSettledEvent
+	Failed  *domain.RefundFailedEvent
+}
+
+// Interactor handles the poll refund status use case
+type Interactor struct {
+	refunds contracts.RefundRepository
+	subs    contracts.SubscriptionRepository
+	billing contracts.BillingResolver
+	clock   domain.Clock
+}
+
+// NewInteractor creates a new poll refund status interactor
+func NewInteractor(refunds contracts.RefundRepository, subs contracts.SubscriptionRepository, billing contracts.BillingResolver, clock domain.Clock) *Interactor {
+	return &Interactor{
+		refunds: refunds,
+		subs:    subs,
+		billing: billing,
+		clock:   clock,
+	}
+}
+
+// Execute asks the billing provider for a pending refund's status and records any outcome
+func (i *Interactor) Execute(ctx context.Context, refundID string) (*Result, error) {
+	// 1. Load refund
+	refund, err := i.refunds.FindByID(ctx, refundID)
+	if err != nil {
+		return nil, err
+	}
+	if refund.Status() != domain.RefundPending {
+		return nil, domain.ErrRefundAlreadySettled
+	}
+
+	// 2. Ask the provider that billed the subscription
+	sub, err := i.subs.FindByID(ctx, refund.SubscriptionID())
+	if err != nil {
+		return nil, err
+	}
+	billingClient, err := i.billing.Resolve(ctx, sub.PlanID(), sub.CustomerID())
+	if err != nil {
+		return nil, err
+	}
+	outcome, err := billingClient.GetRefundStatus(ctx, refund.ProviderRefundID())
+	if err != nil {
+		return nil, err
+	}
+
+	// 3. Apply the outcome via domain method
+	settled, failed, err := refund.ApplyOutcome(i.clock, outcome.Status, outcome.FailureReason)
+	if err != nil {
+		return nil, err
+	}
+	if settled == nil && failed == nil {
+		return &Result{}, nil
+	}
+
+	// 4. Save the updated refund
+	var uow contracts.UnitOfWork
+	uow.Save(i.refunds.Save(ctx, refund))
+
+	// 5. Commit the write
+	if err := uow.Commit(ctx, i.refunds); err != nil {
+		return nil, err
+	}
+
+	return &Result{Settled: settled, Failed: failed}, nil
+}
@@ -0,0 +1,95 @@
+package preview_cancel
+
+import (
+	"context"
+	"time"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/cancel_subscription"
+)
+
+// Request contains the input for previewing a cancellation
+type Request struct {
+	SubscriptionID string
+	AtPeriodEnd    bool // preview scheduling the cancellation instead of cancelling at once
+}
+
+// Preview is what a cancellation would give the customer and when it would take effect.
+// At most one of RefundAmount and CreditAmount is set.
+type Preview struct {
+	SubscriptionID string
+	RefundAmount   int64                    // cents
+	CreditAmount   int64                    // cents granted to the credit balance instead of refunded
+	Discounts      []domain.AppliedDiscount // on the price the refund is of
+	EffectiveAt    time.Time
+}
+
+// Interactor handles the preview cancel use case
+type Interactor struct {
+	repo    contracts.SubscriptionRepository
+	pricing contracts.PricingSource
+	cycles  contracts.BillingCycleSource
+	flags   contracts.FeatureFlags
+	clock   domain.Clock
+}
+
+// NewInteractor creates a new preview cancel interactor. pricing, cycles and flags must
+// be those cancellations run with, so the preview is what the cancellation would give.
+func NewInteractor(repo contracts.SubscriptionRepository, pricing contracts.PricingSource, cycles contracts.BillingCycleSource, flags contracts.FeatureFlags, clock domain.Clock) *Interactor {
+	return &Interactor{
+		repo:    repo,
+		pricing: pricing,
+		cycles:  cycles,
+		flags:   flags,
+		clock:   clock,
+	}
+}
+
+// Execute works out what cancelling a subscription now would refund or credit, and when
+// it would take effect, failing as the cancellation would. Nothing is saved, announced
+// or sent to billing, so support can quote it to the customer before confirming.
+// Lifecycle hooks don't run, so a cancellation a hook would veto is still previewed.
+func (i *Interactor) Execute(ctx context.Context, req Request) (*Preview, error) {
+	// 1. Load subscription
+	sub, err := i.repo.FindByID(ctx, req.SubscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	cycle, err := i.cycles.CycleFor(ctx, sub)
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. A scheduled cancellation refunds nothing and takes effect at the period's end;
+	// it is scheduled on a copy, so the loaded subscription is left as it was
+	if req.AtPeriodEnd {
+		event, err := sub.Clone().ScheduleCancellation(i.clock, cycle, domain.CancellationReason{})
+		if err != nil {
+			return nil, err
+		}
+		return &Preview{SubscriptionID: sub.ID(), EffectiveAt: event.CancelAt}, nil
+	}
+
+	// 3. Quote an immediate cancellation under the terms rolled out to the customer
+	pricing, err := i.pricing.PricingFor(ctx, sub)
+	if err != nil {
+		return nil, err
+	}
+	policy, credit := cancel_subscription.RefundTerms(ctx, i.flags, sub)
+	quote, err := sub.QuoteCancellation(i.clock, cycle, policy, pricing)
+	if err != nil {
+		return nil, err
+	}
+
+	preview := &Preview{
+		SubscriptionID: sub.ID(),
+		RefundAmount:   quote.RefundAmount,
+		Discounts:      quote.Discounts,
+		EffectiveAt:    quote.EffectiveAt,
+	}
+	if credit {
+		preview.CreditAmount, preview.RefundAmount = preview.RefundAmount, 0
+	}
+	return preview, nil
+}
@@ -0,0 +1,99 @@
+package preview_cancel
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/adapters"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/testkit"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/testkit/builders"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/cancel_subscription"
+)
+
+var startDate = builders.DefaultStartDate
+
+// previewOn builds an interactor whose clock reads daysIn days after startDate
+func previewOn(subs *testkit.FakeSubscriptions, pricing contracts.PricingSource, flags adapters.StaticFeatureFlags, daysIn int) *Interactor {
+	clock := domain.FixedClock{FixedTime: startDate.AddDate(0, 0, daysIn)}
+	return NewInteractor(subs, pricing, adapters.StaticBillingCycle{Cycle: domain.CycleOfDays(30)}, flags, clock)
+}
+
+// assertUnchanged checks the stored subscription is still ACTIVE and was never saved
+func assertUnchanged(t *testing.T, subs *testkit.FakeSubscriptions) {
+	t.Helper()
+	stored, err := subs.FindByID(context.Background(), "sub-123")
+	require.NoError(t, err)
+	assert.Equal(t, domain.StatusActive, stored.Status())
+	assert.Zero(t, stored.Version())
+}
+
+func TestPreviewCancel_QuotesTheRefundWithoutCancelling(t *testing.T) {
+	subs := testkit.NewFakeSubscriptions().With(builders.NewSubscriptionBuilder().Build())
+	pricing := adapters.StaticPricing{Pricing: domain.Pricing{Discounts: []domain.Discount{{Code: "SAVE6", AmountOff: 600}}}}
+
+	preview, err := previewOn(subs, pricing, adapters.StaticFeatureFlags{}, 10).Execute(context.Background(), Request{SubscriptionID: "sub-123"})
+
+	require.NoError(t, err)
+	assert.Equal(t, &Preview{
+		SubscriptionID: "sub-123",
+		RefundAmount:   1600, // (3000 - 600) * 20 / 30
+		Discounts:      []domain.AppliedDiscount{{Code: "SAVE6", Kind: domain.DiscountCoupon, Amount: 600}},
+		EffectiveAt:    startDate.AddDate(0, 0, 10),
+	}, preview)
+	assertUnchanged(t, subs)
+}
+
+func TestPreviewCancel_FollowsTheCreditProrationFlag(t *testing.T) {
+	subs := testkit.NewFakeSubscriptions().With(builders.NewSubscriptionBuilder().Build())
+	flags := adapters.StaticFeatureFlags{cancel_subscription.FlagCreditProration: {Customers: []string{"cust-456"}}}
+
+	preview, err := previewOn(subs, adapters.StaticPricing{}, flags, 10).Execute(context.Background(), Request{SubscriptionID: "sub-123"})
+
+	require.NoError(t, err)
+	assert.Zero(t, preview.RefundAmount)
+	assert.Equal(t, int64(2000), preview.CreditAmount)
+	assertUnchanged(t, subs)
+}
+
+func TestPreviewCancel_AtPeriodEnd(t *testing.T) {
+	subs := testkit.NewFakeSubscriptions().With(builders.NewSubscriptionBuilder().Build())
+
+	preview, err := previewOn(subs, adapters.StaticPricing{}, adapters.StaticFeatureFlags{}, 10).Execute(context.Background(), Request{SubscriptionID: "sub-123", AtPeriodEnd: true})
+
+	require.NoError(t, err)
+	assert.Equal(t, &Preview{SubscriptionID: "sub-123", EffectiveAt: startDate.AddDate(0, 0, 30)}, preview)
+	assertUnchanged(t, subs)
+}
+
+func TestPreviewCancel_FailsAsTheCancellationWould(t *testing.T) {
+	tests := []struct {
+		name        string
+		sub         *domain.Subscription
+		atPeriodEnd bool
+		wantErr     error
+	}{
+		{"already cancelled", builders.NewSubscriptionBuilder().Cancelled().Build(), false, domain.ErrAlreadyCancelled},
+		{"already scheduled", builders.NewSubscriptionBuilder().PendingCancellationAt(startDate.AddDate(0, 0, 30)).Build(), true, domain.ErrCancellationAlreadyScheduled},
+		{"past due at period end", builders.NewSubscriptionBuilder().PastDue().Build(), true, domain.ErrNotActive},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			subs := testkit.NewFakeSubscriptions().With(tc.sub)
+
+			_, err := previewOn(subs, adapters.StaticPricing{}, adapters.StaticFeatureFlags{}, 10).Execute(context.Background(), Request{SubscriptionID: "sub-123", AtPeriodEnd: tc.atPeriodEnd})
+
+			assert.ErrorIs(t, err, tc.wantErr)
+		})
+	}
+}
+
+func TestPreviewCancel_NotFound(t *testing.T) {
+	_, err := previewOn(testkit.NewFakeSubscriptions(), adapters.StaticPricing{}, adapters.StaticFeatureFlags{}, 10).Execute(context.Background(), Request{SubscriptionID: "missing"})
+
+	assert.ErrorIs(t, err, domain.ErrSubscriptionNotFound)
+}
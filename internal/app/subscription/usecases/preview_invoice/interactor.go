@@ -0,0 +1,50 @@
+package preview_invoice
+
+import (
+	"context"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+// Interactor handles the preview invoice use case
+type Interactor struct {
+	repo      contracts.SubscriptionRepository
+	items     contracts.InvoiceItemsSource
+	cycles    contracts.BillingCycleSource
+	discounts domain.DiscountPolicy
+}
+
+// NewInteractor creates a new preview invoice interactor. discounts is the policy the
+// invoice's discounts stack under, the same one charges are resolved with.
+func NewInteractor(repo contracts.SubscriptionRepository, items contracts.InvoiceItemsSource, cycles contracts.BillingCycleSource, discounts domain.DiscountPolicy) *Interactor {
+	return &Interactor{
+		repo:      repo,
+		items:     items,
+		cycles:    cycles,
+		discounts: discounts,
+	}
+}
+
+// Execute computes the invoice the subscription's next renewal will charge. Nothing
+// is charged or saved, so it is safe to call whenever the customer portal renders.
+func (i *Interactor) Execute(ctx context.Context, subscriptionID string) (*domain.InvoicePreview, error) {
+	// 1. Load subscription
+	sub, err := i.repo.FindByID(ctx, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. Gather what the renewal bills besides the base price
+	items, err := i.items.UpcomingItems(ctx, sub)
+	if err != nil {
+		return nil, err
+	}
+
+	// 3. Price the next period via domain method
+	cycle, err := i.cycles.CycleFor(ctx, sub)
+	if err != nil {
+		return nil, err
+	}
+	return sub.PreviewInvoice(items, cycle, i.discounts)
+}
@@ -0,0 +1,170 @@
+package preview_invoice
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/adapters"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/testkit"
+)
+
+// MockItems is a mock implementation of InvoiceItemsSource
+type MockItems struct {
+	mock.Mock
+}
+
+func (m *MockItems) UpcomingItems(ctx context.Context, sub *domain.Subscription) (domain.InvoiceItems, error) {
+	args := m.Called(ctx, sub)
+	return args.Get(0).(domain.InvoiceItems), args.Error(1)
+}
+
+var startDate = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func activeSubscription() *domain.Subscription {
+	return domain.ReconstructFromPersistence("sub-123", "cust-456", "plan-basic", 3000, domain.StatusActive, startDate)
+}
+
+func TestPreviewInvoice_BasePriceOnly(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(testkit.MockSubscriptions)
+	interactor := NewInteractor(mockRepo, adapters.StaticInvoiceItems{}, adapters.StaticBillingCycle{Cycle: domain.CycleOfDays(30)}, domain.DiscountPolicy{})
+
+	mockRepo.On("FindByID", ctx, "sub-123").Return(activeSubscription(), nil)
+
+	preview, err := interactor.Execute(ctx, "sub-123")
+
+	require.NoError(t, err)
+	assert.Equal(t, startDate.AddDate(0, 0, 30), preview.PeriodStart)
+	assert.Equal(t, startDate.AddDate(0, 0, 60), preview.PeriodEnd)
+	assert.Equal(t, domain.DefaultCurrency, preview.Currency)
+	assert.Equal(t, []domain.InvoiceLine{
+		{Kind: domain.LineBase, Description: "plan-basic", Quantity: 1, UnitAmount: 3000, Amount: 3000},
+	}, preview.Lines)
+	assert.Equal(t, int64(3000), preview.Subtotal)
+	assert.Equal(t, int64(3000), preview.Total)
+	mockRepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+	mockRepo.AssertNotCalled(t, "Apply", mock.Anything, mock.Anything)
+}
+
+func TestPreviewInvoice_AllComponents(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(testkit.MockSubscriptions)
+	items := new(MockItems)
+	interactor := NewInteractor(mockRepo, items, adapters.StaticBillingCycle{Cycle: domain.CycleOfDays(30)}, domain.DiscountPolicy{})
+
+	sub := activeSubscription()
+	mockRepo.On("FindByID", ctx, "sub-123").Return(sub, nil)
+	items.On("UpcomingItems", ctx, sub).Return(domain.InvoiceItems{
+		Seats:  domain.SeatCharge{Quantity: 8, Included: 5, UnitPrice: 500},
+		AddOns: []domain.AddOnCharge{{ID: "addon-sso", Name: "SSO", Quantity: 1, UnitPrice: 1000}},
+		Usage: []domain.UsageCharge{
+			{Metric: "api_calls", Quantity: 12500, Included: 10000, UnitPrice: 1},
+			{Metric: "storage_gb", Quantity: 40, Included: 50, UnitPrice: 20}, // under the allowance
+		},
+		Discounts: []domain.Discount{{Code: "LAUNCH10", PercentOff: 1000}, {Code: "LOYAL5", AmountOff: 500}},
+		TaxRate:   825,
+	}, nil)
+
+	preview, err := interactor.Execute(ctx, "sub-123")
+
+	require.NoError(t, err)
+	assert.Equal(t, []domain.InvoiceLine{
+		{Kind: domain.LineBase, Description: "plan-basic", Quantity: 1, UnitAmount: 3000, Amount: 3000},
+		{Kind: domain.LineSeats, Description: "additional seats", Quantity: 3, UnitAmount: 500, Amount: 1500},
+		{Kind: domain.LineAddOn, Description: "SSO", Quantity: 1, UnitAmount: 1000, Amount: 1000},
+		{Kind: domain.LineUsage, Description: "api_calls overage", Quantity: 2500, UnitAmount: 1, Amount: 2500},
+		{Kind: domain.LineDiscount, Description: "LAUNCH10", Quantity: 1, UnitAmount: -800, Amount: -800},
+		{Kind: domain.LineDiscount, Description: "LOYAL5", Quantity: 1, UnitAmount: -500, Amount: -500},
+		{Kind: domain.LineTax, Description: "tax", Quantity: 1, UnitAmount: 553, Amount: 553},
+	}, preview.Lines)
+	assert.Equal(t, int64(8000), preview.Subtotal)
+	assert.Equal(t, int64(1300), preview.Discount)
+	assert.Equal(t, int64(553), preview.Tax) // 8.25% of 6700 is 552.75
+	assert.Equal(t, int64(7253), preview.Total)
+}
+
+func TestPreviewInvoice_DiscountsNeverGoBelowZero(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(testkit.MockSubscriptions)
+	interactor := NewInteractor(mockRepo, adapters.StaticInvoiceItems{Items: domain.InvoiceItems{
+		Discounts: []domain.Discount{{Code: "BIG", AmountOff: 5000}, {Code: "UNUSED", AmountOff: 100}},
+		TaxRate:   2000,
+	}}, adapters.StaticBillingCycle{Cycle: domain.CycleOfDays(30)}, domain.DiscountPolicy{})
+
+	mockRepo.On("FindByID", ctx, "sub-123").Return(activeSubscription(), nil)
+
+	preview, err := interactor.Execute(ctx, "sub-123")
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(3000), preview.Discount)
+	assert.Equal(t, int64(0), preview.Tax)
+	assert.Equal(t, int64(0), preview.Total)
+	assert.Len(t, preview.Lines, 2) // base and the one discount that applied
+}
+
+func TestPreviewInvoice_DiscountPrecedenceAndLimits(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(testkit.MockSubscriptions)
+	interactor := NewInteractor(mockRepo, adapters.StaticInvoiceItems{Items: domain.InvoiceItems{
+		Discounts: []domain.Discount{
+			{Code: "SAVE5", Kind: domain.DiscountCoupon, AmountOff: 500},
+			{Code: "SPRING20", Kind: domain.DiscountCoupon, PercentOff: 2000},
+			{Code: "IN-PPP", Kind: domain.DiscountRegional, PercentOff: 3000},
+		},
+	}}, adapters.StaticBillingCycle{Cycle: domain.CycleOfDays(30)}, domain.DiscountPolicy{MaxStacked: 2, MaxPercentOff: 4000})
+
+	mockRepo.On("FindByID", ctx, "sub-123").Return(activeSubscription(), nil)
+
+	preview, err := interactor.Execute(ctx, "sub-123")
+
+	require.NoError(t, err)
+	// The regional discount first, then the percentage coupon capped at 40% in total;
+	// SAVE5 would be a third discount
+	assert.Equal(t, []domain.InvoiceLine{
+		{Kind: domain.LineBase, Description: "plan-basic", Quantity: 1, UnitAmount: 3000, Amount: 3000},
+		{Kind: domain.LineDiscount, Description: "IN-PPP", Quantity: 1, UnitAmount: -900, Amount: -900},
+		{Kind: domain.LineDiscount, Description: "SPRING20", Quantity: 1, UnitAmount: -300, Amount: -300},
+	}, preview.Lines)
+	assert.Equal(t, int64(1200), preview.Discount)
+	assert.Equal(t, int64(1800), preview.Total)
+}
+
+func TestPreviewInvoice_Rejections(t *testing.T) {
+	lookupErr := errors.New("spanner unavailable")
+	tests := []struct {
+		name    string
+		sub     *domain.Subscription
+		findErr error
+		items   domain.InvoiceItems
+		wantErr error
+	}{
+		{"not found", nil, domain.ErrSubscriptionNotFound, domain.InvoiceItems{}, domain.ErrSubscriptionNotFound},
+		{"lookup fails", nil, lookupErr, domain.InvoiceItems{}, lookupErr},
+		{"cancelled", domain.ReconstructFromPersistence("sub-123", "cust-456", "plan-basic", 3000, domain.StatusCancelled, startDate), nil, domain.InvoiceItems{}, domain.ErrNotRenewable},
+		{"past due", domain.ReconstructFromPersistence("sub-123", "cust-456", "plan-basic", 3000, domain.StatusPastDue, startDate), nil, domain.InvoiceItems{}, domain.ErrNotRenewable},
+		{"negative seats", activeSubscription(), nil, domain.InvoiceItems{Seats: domain.SeatCharge{Quantity: -1}}, domain.ErrInvalidInvoiceItem},
+		{"discount over 100%", activeSubscription(), nil, domain.InvoiceItems{Discounts: []domain.Discount{{Code: "X", PercentOff: 10001}}}, domain.ErrInvalidInvoiceItem},
+		{"unknown discount kind", activeSubscription(), nil, domain.InvoiceItems{Discounts: []domain.Discount{{Code: "X", Kind: "loyalty", PercentOff: 1000}}}, domain.ErrInvalidInvoiceItem},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			mockRepo := new(testkit.MockSubscriptions)
+			interactor := NewInteractor(mockRepo, adapters.StaticInvoiceItems{Items: tc.items}, adapters.StaticBillingCycle{Cycle: domain.CycleOfDays(30)}, domain.DiscountPolicy{})
+
+			mockRepo.On("FindByID", ctx, "sub-123").Return(tc.sub, tc.findErr)
+
+			preview, err := interactor.Execute(ctx, "sub-123")
+
+			assert.True(t, errors.Is(err, tc.wantErr), "got %v", err)
+			assert.Nil(t, preview)
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package reconcile_billing
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+// DiscrepancyKind classifies a mismatch between our records and the billing provider's
+type DiscrepancyKind string
+
+const (
+	// KindOrphanedProviderSubscription is active at the provider but unknown to us
+	KindOrphanedProviderSubscription DiscrepancyKind = "ORPHANED_PROVIDER_SUBSCRIPTION"
+	// KindCancelledButActiveAtProvider is cancelled here but still active at the provider.
+	// This is the only kind repaired automatically: cancelling at the provider matches our state.
+	KindCancelledButActiveAtProvider DiscrepancyKind = "CANCELLED_BUT_ACTIVE_AT_PROVIDER"
+	// KindInactiveAtProvider is active here but not at the provider
+	KindInactiveAtProvider DiscrepancyKind = "INACTIVE_AT_PROVIDER"
+	// KindCustomerMismatch has a different customer at the provider
+	KindCustomerMismatch DiscrepancyKind = "CUSTOMER_MISMATCH"
+)
+
+// Request contains the input for a reconciliation run
+type Request struct {
+	Repair bool // apply safe repairs instead of only reporting them
+}
+
+// Discrepancy is a single mismatch found during reconciliation
+type Discrepancy struct {
+	Kind           DiscrepancyKind `json:"kind"`
+	SubscriptionID string          `json:"subscription_id"`
+	CustomerID     string          `json:"customer_id"`
+	Detail         string          `json:"detail,omitempty"`
+	Repaired       bool            `json:"repaired"`
+	RepairError    string          `json:"repair_error,omitempty"`
+}
+
+// Report is the outcome of a reconciliation run
+type Report struct {
+	StartedAt     time.Time     `json:"started_at"`
+	Checked       int           `json:"checked"`
+	Discrepancies []Discrepancy `json:"discrepancies"`
+	Repaired      int           `json:"repaired"`
+}
+
+// Interactor handles the billing reconciliation use case.
+// Refunds are not reconciled yet because we don't persist the refunds we issue.
+type Interactor struct {
+	repo    contracts.SubscriptionRepository
+	records contracts.BillingRecords
+	clock   domain.Clock
+}
+
+// NewInteractor creates a new reconcile billing interactor
+func NewInteractor(repo contracts.SubscriptionRepository, records contracts.BillingRecords, clock domain.Clock) *Interactor {
+	return &Interactor{
+		repo:    repo,
+		records: records,
+		clock:   clock,
+	}
+}
+
+// Execute walks the provider's subscriptions and compares each with ours
+func (i *Interactor) Execute(ctx context.Context, req Request) (*Report, error) {
+	report := &Report{StartedAt: i.clock.Now(), Discrepancies: []Discrepancy{}}
+
+	pageToken := ""
+	for {
+		page, next, err := i.records.ListSubscriptions(ctx, pageToken)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, ps := range page {
+			d, err := i.check(ctx, ps)
+			if err != nil {
+				return nil, err
+			}
+			report.Checked++
+			if d == nil {
+				continue
+			}
+
+			if req.Repair && d.Kind == KindCancelledButActiveAtProvider {
+				if err := i.records.CancelProviderSubscription(ctx, d.SubscriptionID); err != nil {
+					d.RepairError = err.Error()
+				} else {
+					d.Repaired = true
+					report.Repaired++
+				}
+			}
+			report.Discrepancies = append(report.Discrepancies, *d)
+		}
+
+		if next == "" {
+			return report, nil
+		}
+		pageToken = next
+	}
+}
+
+// check compares one provider subscription with ours, returning nil when they agree
+func (i *Interactor) check(ctx context.Context, ps contracts.ProviderSubscription) (*Discrepancy, error) {
+	sub, err := i.repo.FindByID(ctx, ps.SubscriptionID)
+	if errors.Is(err, domain.ErrSubscriptionNotFound) {
+		if !ps.Active {
+			return nil, nil
+		}
+		return &Discrepancy{
+			Kind:           KindOrphanedProviderSubscription,
+			SubscriptionID: ps.SubscriptionID,
+			CustomerID:     ps.CustomerID,
+		}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if sub.CustomerID() != ps.CustomerID {
+		return &Discrepancy{
+			Kind:           KindCustomerMismatch,
+			SubscriptionID: sub.ID(),
+			CustomerID:     sub.CustomerID(),
+			Detail:         "provider customer " + ps.CustomerID,
+		}, nil
+	}
+
+	switch {
+	case sub.Status() == domain.StatusCancelled && ps.Active:
+		return &Discrepancy{
+			Kind:           KindCancelledButActiveAtProvider,
+			SubscriptionID: sub.ID(),
+			CustomerID:     sub.CustomerID(),
+		}, nil
+	case sub.Status() != domain.StatusCancelled && !ps.Active:
+		return &Discrepancy{
+			Kind:           KindInactiveAtProvider,
+			SubscriptionID: sub.ID(),
+			CustomerID:     sub.CustomerID(),
+			Detail:         "local status " + string(sub.Status()),
+		}, nil
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,90 @@
+package reconcile_billing
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/testkit"
+)
+
+// MockBillingRecords is a mock implementation of BillingRecords
+type MockBillingRecords struct {
+	mock.Mock
+}
+
+func (m *MockBillingRecords) ListSubscriptions(ctx context.Context, pageToken string) ([]contracts.ProviderSubscription, string, error) {
+	args := m.Called(ctx, pageToken)
+	return args.Get(0).([]contracts.ProviderSubscription), args.String(1), args.Error(2)
+}
+
+func (m *MockBillingRecords) CancelProviderSubscription(ctx context.Context, subscriptionID string) error {
+	args := m.Called(ctx, subscriptionID)
+	return args.Error(0)
+}
+
+func TestReconcileBilling_FindsAndRepairsDiscrepancies(t *testing.T) {
+	ctx := context.Background()
+	startDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := domain.FixedClock{FixedTime: startDate.AddDate(0, 1, 0)}
+
+	mockRepo := new(testkit.MockSubscriptions)
+	mockRecords := new(MockBillingRecords)
+	interactor := NewInteractor(mockRepo, mockRecords, clock)
+
+	// Two pages: one matching, one orphan, one cancelled locally but active remotely
+	mockRecords.On("ListSubscriptions", ctx, "").Return([]contracts.ProviderSubscription{
+		{SubscriptionID: "sub-ok", CustomerID: "cust-1", Active: true},
+		{SubscriptionID: "sub-orphan", CustomerID: "cust-2", Active: true},
+	}, "page-2", nil)
+	mockRecords.On("ListSubscriptions", ctx, "page-2").Return([]contracts.ProviderSubscription{
+		{SubscriptionID: "sub-cancelled", CustomerID: "cust-3", Active: true},
+	}, "", nil)
+
+	mockRepo.On("FindByID", ctx, "sub-ok").Return(
+		domain.ReconstructFromPersistence("sub-ok", "cust-1", "plan", 3000, domain.StatusActive, startDate), nil)
+	mockRepo.On("FindByID", ctx, "sub-orphan").Return(nil, domain.ErrSubscriptionNotFound)
+	mockRepo.On("FindByID", ctx, "sub-cancelled").Return(
+		domain.ReconstructFromPersistence("sub-cancelled", "cust-3", "plan", 3000, domain.StatusCancelled, startDate), nil)
+
+	mockRecords.On("CancelProviderSubscription", ctx, "sub-cancelled").Return(nil)
+
+	report, err := interactor.Execute(ctx, Request{Repair: true})
+
+	require.NoError(t, err)
+	assert.Equal(t, 3, report.Checked)
+	assert.Equal(t, 1, report.Repaired)
+	require.Len(t, report.Discrepancies, 2)
+	assert.Equal(t, KindOrphanedProviderSubscription, report.Discrepancies[0].Kind)
+	assert.False(t, report.Discrepancies[0].Repaired)
+	assert.Equal(t, KindCancelledButActiveAtProvider, report.Discrepancies[1].Kind)
+	assert.True(t, report.Discrepancies[1].Repaired)
+	mockRecords.AssertExpectations(t)
+}
+
+func TestReconcileBilling_ReportOnlyDoesNotRepair(t *testing.T) {
+	ctx := context.Background()
+	startDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	mockRepo := new(testkit.MockSubscriptions)
+	mockRecords := new(MockBillingRecords)
+	interactor := NewInteractor(mockRepo, mockRecords, domain.FixedClock{FixedTime: startDate})
+
+	mockRecords.On("ListSubscriptions", ctx, "").Return([]contracts.ProviderSubscription{
+		{SubscriptionID: "sub-cancelled", CustomerID: "cust-3", Active: true},
+	}, "", nil)
+	mockRepo.On("FindByID", ctx, "sub-cancelled").Return(
+		domain.ReconstructFromPersistence("sub-cancelled", "cust-3", "plan", 3000, domain.StatusCancelled, startDate), nil)
+
+	report, err := interactor.Execute(ctx, Request{})
+
+	require.NoError(t, err)
+	require.Len(t, report.Discrepancies, 1)
+	assert.False(t, report.Discrepancies[0].Repaired)
+	mockRecords.AssertNotCalled(t, "CancelProviderSubscription", ctx, mock.Anything)
+}
@@ -0,0 +1,67 @@
+package record_payment_failure
+
+import (
+	"context"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+// Request carries a failed payment reported by the billing provider, typically via webhook
+type Request struct {
+	SubscriptionID string
+	FailureReason  string
+}
+
+// Interactor handles the record payment failure use case
+type Interactor struct {
+	repo     contracts.SubscriptionRepository
+	pricing  contracts.PricingSource
+	clock    domain.Clock
+	schedule domain.DunningSchedule
+}
+
+// NewInteractor creates a new record payment failure interactor
+func NewInteractor(repo contracts.SubscriptionRepository, pricing contracts.PricingSource, clock domain.Clock, schedule domain.DunningSchedule) *Interactor {
+	return &Interactor{
+		repo:     repo,
+		pricing:  pricing,
+		clock:    clock,
+		schedule: schedule,
+	}
+}
+
+// Execute moves an active subscription into dunning after the provider reports that
+// its payment failed. From there the dunning worker retries the charge: recovery
+// returns it to ACTIVE and the final failed retry cancels it. A subscription already
+// past due, e.g. because its renewal charge was declined, returns ErrAlreadyPastDue.
+func (i *Interactor) Execute(ctx context.Context, req Request) (*domain.SubscriptionPastDueEvent, error) {
+	// 1. Load subscription
+	sub, err := i.repo.FindByID(ctx, req.SubscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	if sub.Status() == domain.StatusPastDue {
+		return nil, domain.ErrAlreadyPastDue
+	}
+
+	// 2. Start dunning via domain method
+	pricing, err := i.pricing.PricingFor(ctx, sub)
+	if err != nil {
+		return nil, err
+	}
+	event, err := sub.MarkPastDue(i.clock, i.schedule, pricing)
+	if err != nil {
+		return nil, err
+	}
+	event.FailureReason = req.FailureReason
+
+	// 3. Save the subscription
+	var uow contracts.UnitOfWork
+	uow.SaveChecked(i.repo.Save(ctx, sub))
+	if err := uow.Commit(ctx, i.repo); err != nil {
+		return nil, err
+	}
+
+	return event, nil
+}
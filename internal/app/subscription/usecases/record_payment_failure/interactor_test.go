@@ -0,0 +1,70 @@
+package record_payment_failure
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/adapters"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/testkit"
+)
+
+var (
+	startDate  = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	failedDate = time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+)
+
+func newTestInteractor(repo *testkit.MockSubscriptions) *Interactor {
+	return NewInteractor(repo, adapters.StaticPricing{}, domain.FixedClock{FixedTime: failedDate}, domain.DefaultDunningSchedule)
+}
+
+func TestRecordPaymentFailure_StartsDunning(t *testing.T) {
+	ctx := context.Background()
+	sub := domain.ReconstructFromPersistence("sub-123", "cust-456", "plan-789", 3000, domain.StatusActive, startDate)
+	repo := &testkit.MockSubscriptions{}
+	repo.On("FindByID", ctx, "sub-123").Return(sub, nil)
+	repo.On("Save", ctx, sub).Return(&spanner.Mutation{}, nil)
+	repo.On("Apply", ctx, mock.Anything).Return(nil)
+
+	event, err := newTestInteractor(repo).Execute(ctx, Request{SubscriptionID: "sub-123", FailureReason: "card_declined"})
+
+	require.NoError(t, err)
+	assert.Equal(t, domain.StatusPastDue, sub.Status())
+	assert.Equal(t, &domain.SubscriptionPastDueEvent{
+		SubscriptionID:     "sub-123",
+		CustomerID:         "cust-456",
+		AmountDue:          3000,
+		FailureReason:      "card_declined",
+		NextPaymentRetryAt: failedDate.Add(24 * time.Hour),
+		OccurredAt:         failedDate,
+	}, event)
+	repo.AssertCalled(t, "Apply", ctx, mock.Anything)
+}
+
+func TestRecordPaymentFailure_IgnoresSubscriptionsNotActive(t *testing.T) {
+	testCases := []struct {
+		status domain.SubscriptionStatus
+		want   error
+	}{
+		{status: domain.StatusPastDue, want: domain.ErrAlreadyPastDue},
+		{status: domain.StatusCancelled, want: domain.ErrNotActive},
+	}
+
+	for _, tc := range testCases {
+		t.Run(string(tc.status), func(t *testing.T) {
+			ctx := context.Background()
+			repo := &testkit.MockSubscriptions{}
+			repo.On("FindByID", ctx, "sub-123").Return(domain.ReconstructFromPersistence("sub-123", "cust-456", "plan-789", 3000, tc.status, startDate), nil)
+
+			_, err := newTestInteractor(repo).Execute(ctx, Request{SubscriptionID: "sub-123"})
+
+			assert.ErrorIs(t, err, tc.want)
+			repo.AssertNotCalled(t, "Apply", mock.Anything, mock.Anything)
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package record_refund_outcome
+
+import (
+	"context"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/instrument"
+)
+
+// UseCase is the record refund outcome use case as seen by callers
+type UseCase interface {
+	Execute(ctx context.Context, req Request) (*Result, error)
+}
+
+var (
+	_ UseCase = (*Interactor)(nil)
+	_ UseCase = (*Instrumented)(nil)
+)
+
+// Instrumented decorates a UseCase with logs, metrics and a trace span per execution,
+// and records how long each refund it resolves took to process
+type Instrumented struct {
+	next UseCase
+	in   instrument.Instrumentation
+}
+
+// NewInstrumented wraps the given use case with instrumentation
+func NewInstrumented(next UseCase, in instrument.Instrumentation) *Instrumented {
+	return &Instrumented{next: next, in: in}
+}
+
+// Execute runs the wrapped use case
+func (d *Instrumented) Execute(ctx context.Context, req Request) (*Result, error) {
+	attrs := map[string]string{"provider_refund_id": req.ProviderRefundID, "status": string(req.Status)}
+
+	result, err := instrument.Run(ctx, d.in, "record_refund_outcome", attrs, func(ctx context.Context) (*Result, error) {
+		return d.next.Execute(ctx, req)
+	})
+	if err == nil {
+		instrument.RecordRefundOutcome(d.in, "webhook", result.Settled, result.Failed)
+	}
+
+	return result, err
+}
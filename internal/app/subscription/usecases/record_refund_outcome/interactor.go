@@ -0,0 +1,64 @@
+package record_refund_outcome
+
+import (
+	"context"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+// Request carries a refund outcome reported by the billing provider, typically via webhook
+type Request struct {
+	ProviderRefundID string
+	Status           domain.RefundStatus
+	FailureReason    string
+}
+
+// Result holds the event emitted by the outcome; both are nil while the refund is still pending
+type Result struct {
+	Settled *domain.RefundSettledEvent
+	Failed  *domain.RefundFailedEvent
+}
+
+// Interactor handles the record refund outcome use case
+type Interactor struct {
+	refunds contracts.RefundRepository
+	clock   domain.Clock
+}
+
+// NewInteractor creates a new record refund outcome interactor
+func NewInteractor(refunds contracts.RefundRepository, clock domain.Clock) *Interactor {
+	return &Interactor{
+		refunds: refunds,
+		clock:   clock,
+	}
+}
+
+// Execute applies a provider-reported outcome to the tracked refund
+func (i *Interactor) Execute(ctx context.Context, req Request) (*Result, error) {
+	// 1. Load refund by the provider's ID
+	refund, err := i.refunds.FindByProviderRefundID(ctx, req.ProviderRefundID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. Apply the outcome via domain method
+	settled, failed, err := refund.ApplyOutcome(i.clock, req.Status, req.FailureReason)
+	if err != nil {
+		return nil, err
+	}
+	if settled == nil && failed == nil {
+		return &Result{}, nil
+	}
+
+	// 3. Save the updated refund
+	var uow contracts.UnitOfWork
+	uow.Save(i.refunds.Save(ctx, refund))
+
+	// 4. Commit the write
+	if err := uow.Commit(ctx, i.refunds); err != nil {
+		return nil, err
+	}
+
+	return &Result{Settled: settled, Failed: failed}, nil
+}
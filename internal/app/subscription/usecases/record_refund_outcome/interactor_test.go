@@ -0,0 +1,129 @@
+package record_refund_outcome
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+// MockRefundRepository is a mock implementation of RefundRepository
+type MockRefundRepository struct {
+	mock.Mock
+}
+
+func (m *MockRefundRepository) Save(ctx context.Context, refund *domain.Refund) (*spanner.Mutation, error) {
+	args := m.Called(ctx, refund)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*spanner.Mutation), args.Error(1)
+}
+
+func (m *MockRefundRepository) Apply(ctx context.Context, mutations ...*spanner.Mutation) error {
+	args := m.Called(ctx, mutations)
+	return args.Error(0)
+}
+
+func (m *MockRefundRepository) FindByID(ctx context.Context, id string) (*domain.Refund, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Refund), args.Error(1)
+}
+
+func (m *MockRefundRepository) FindByProviderRefundID(ctx context.Context, providerRefundID string) (*domain.Refund, error) {
+	args := m.Called(ctx, providerRefundID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Refund), args.Error(1)
+}
+
+func (m *MockRefundRepository) FindPending(ctx context.Context, requestedBefore time.Time, limit int) ([]*domain.Refund, error) {
+	args := m.Called(ctx, requestedBefore, limit)
+	return args.Get(0).([]*domain.Refund), args.Error(1)
+}
+
+var (
+	requestedAt = time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	settledAt   = time.Date(2024, 1, 17, 0, 0, 0, 0, time.UTC)
+)
+
+func pendingRefund() *domain.Refund {
+	return domain.NewPendingRefund("refund-1", "sub-123", "cust-456", 1600, "USD", "prov-1", domain.FixedClock{FixedTime: requestedAt})
+}
+
+func TestRecordRefundOutcome_Settles(t *testing.T) {
+	ctx := context.Background()
+	mockRefunds := new(MockRefundRepository)
+	interactor := NewInteractor(mockRefunds, domain.FixedClock{FixedTime: settledAt})
+
+	mockRefunds.On("FindByProviderRefundID", ctx, "prov-1").Return(pendingRefund(), nil)
+	mockRefunds.On("Save", ctx, mock.MatchedBy(func(r *domain.Refund) bool {
+		return r.Status() == domain.RefundSucceeded && r.SettledAt().Equal(settledAt)
+	})).Return(&spanner.Mutation{}, nil)
+	mockRefunds.On("Apply", ctx, mock.Anything).Return(nil)
+
+	result, err := interactor.Execute(ctx, Request{ProviderRefundID: "prov-1", Status: domain.RefundSucceeded})
+
+	require.NoError(t, err)
+	require.NotNil(t, result.Settled)
+	assert.Equal(t, "refund-1", result.Settled.RefundID)
+	assert.Equal(t, int64(1600), result.Settled.Amount)
+	assert.Nil(t, result.Failed)
+	mockRefunds.AssertExpectations(t)
+}
+
+func TestRecordRefundOutcome_Fails(t *testing.T) {
+	ctx := context.Background()
+	mockRefunds := new(MockRefundRepository)
+	interactor := NewInteractor(mockRefunds, domain.FixedClock{FixedTime: settledAt})
+
+	mockRefunds.On("FindByProviderRefundID", ctx, "prov-1").Return(pendingRefund(), nil)
+	mockRefunds.On("Save", ctx, mock.Anything).Return(&spanner.Mutation{}, nil)
+	mockRefunds.On("Apply", ctx, mock.Anything).Return(nil)
+
+	result, err := interactor.Execute(ctx, Request{ProviderRefundID: "prov-1", Status: domain.RefundFailed, FailureReason: "card closed"})
+
+	require.NoError(t, err)
+	require.NotNil(t, result.Failed)
+	assert.Equal(t, "card closed", result.Failed.Reason)
+}
+
+func TestRecordRefundOutcome_PendingIsNoop(t *testing.T) {
+	ctx := context.Background()
+	mockRefunds := new(MockRefundRepository)
+	interactor := NewInteractor(mockRefunds, domain.FixedClock{FixedTime: settledAt})
+
+	mockRefunds.On("FindByProviderRefundID", ctx, "prov-1").Return(pendingRefund(), nil)
+
+	result, err := interactor.Execute(ctx, Request{ProviderRefundID: "prov-1", Status: domain.RefundPending})
+
+	require.NoError(t, err)
+	assert.Nil(t, result.Settled)
+	assert.Nil(t, result.Failed)
+	mockRefunds.AssertNotCalled(t, "Save", ctx, mock.Anything)
+}
+
+func TestRecordRefundOutcome_AlreadySettled(t *testing.T) {
+	ctx := context.Background()
+	mockRefunds := new(MockRefundRepository)
+	interactor := NewInteractor(mockRefunds, domain.FixedClock{FixedTime: settledAt})
+
+	settled := pendingRefund()
+	_, err := settled.Settle(domain.FixedClock{FixedTime: settledAt})
+	require.NoError(t, err)
+	mockRefunds.On("FindByProviderRefundID", ctx, "prov-1").Return(settled, nil)
+
+	result, err := interactor.Execute(ctx, Request{ProviderRefundID: "prov-1", Status: domain.RefundFailed})
+
+	assert.Equal(t, domain.ErrRefundAlreadySettled, err)
+	assert.Nil(t, result)
+}
@@ -0,0 +1,44 @@
+package record_usage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/bus"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+// CommandName identifies the record usage command on the bus
+const CommandName = "subscription.record_usage"
+
+var _ bus.Handler = (*Interactor)(nil)
+
+// CommandName implements bus.Command
+func (r Request) CommandName() string {
+	return CommandName
+}
+
+// Validate rejects obviously invalid input before the subscription is loaded
+func (r Request) Validate() error {
+	if r.Metric == "" {
+		return domain.ErrInvalidMetric
+	}
+	if r.Quantity <= 0 {
+		return domain.ErrInvalidUsageQuantity
+	}
+	return nil
+}
+
+// Handle executes the use case for a command dispatched through the bus
+func (i *Interactor) Handle(ctx context.Context, cmd bus.Command) (any, error) {
+	req, ok := cmd.(Request)
+	if !ok {
+		return nil, fmt.Errorf("unexpected command type %T", cmd)
+	}
+
+	result, err := i.Execute(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
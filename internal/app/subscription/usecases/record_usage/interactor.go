@@ -0,0 +1,118 @@
+package record_usage
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+// Request contains the input for recording metered usage
+type Request struct {
+	SubscriptionID string
+	Metric         string
+	Quantity       int64
+}
+
+// Result is the period's usage once the record is saved, and the alerts it raised
+type Result struct {
+	Quantity int64 // used so far this period, this record included
+	Included int64 // zero when the plan includes the metric without limit
+	Alerts   []*domain.UsageThresholdReachedEvent
+}
+
+// Interactor handles the record usage use case
+type Interactor struct {
+	repo         contracts.SubscriptionRepository
+	usage        contracts.UsageRepository
+	entitlements contracts.EntitlementRepository
+	notifier     contracts.UsageAlertNotifier
+	policy       domain.UsageAlertPolicy
+	clock        domain.Clock
+}
+
+// NewInteractor creates a new record usage interactor. A metered metric is a feature
+// the plan grants, and its entitlement limit is the quantity included per billing
+// period. A policy without thresholds alerts at domain.DefaultUsageAlertThresholds.
+func NewInteractor(repo contracts.SubscriptionRepository, usage contracts.UsageRepository, entitlements contracts.EntitlementRepository, notifier contracts.UsageAlertNotifier, policy domain.UsageAlertPolicy, clock domain.Clock) *Interactor {
+	if len(policy.Thresholds) == 0 {
+		policy.Thresholds = domain.DefaultUsageAlertThresholds
+	}
+	return &Interactor{
+		repo:         repo,
+		usage:        usage,
+		entitlements: entitlements,
+		notifier:     notifier,
+		policy:       policy,
+		clock:        clock,
+	}
+}
+
+// Execute records usage against the subscription's current billing period and alerts
+// the customer at each threshold the period's usage reaches for the first time
+func (i *Interactor) Execute(ctx context.Context, req Request) (*Result, error) {
+	// 1. Load subscription
+	sub, err := i.repo.FindByID(ctx, req.SubscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. Look up what the plan includes
+	entitlements, err := i.entitlements.FindPlanEntitlements(ctx, sub.PlanID())
+	if err != nil {
+		return nil, err
+	}
+	included, metered := int64(0), false
+	for _, e := range entitlements {
+		if e.Feature == req.Metric {
+			metered = true
+			if !e.Unlimited {
+				included = e.Limit
+			}
+		}
+	}
+	if !metered {
+		return nil, domain.ErrMetricNotMetered
+	}
+
+	// 3. Record via domain constructor
+	record, err := domain.NewUsageRecord(uuid.New().String(), sub, req.Metric, req.Quantity, i.clock)
+	if err != nil {
+		return nil, err
+	}
+	var uow contracts.UnitOfWork
+	uow.Save(i.usage.Save(ctx, record))
+	if err := uow.Commit(ctx, i.usage); err != nil {
+		return nil, err
+	}
+
+	// 4. Sum the period, this record included; concurrent records are summed too, so
+	// whichever recording sees a threshold reached alerts for it
+	usage, err := i.usage.FindPeriodUsage(ctx, sub.ID(), req.Metric, record.PeriodStart())
+	if err != nil {
+		return nil, err
+	}
+	usage.Included = included
+	result := &Result{Quantity: usage.Quantity, Included: included}
+
+	// 5. Claim and send each alert. The usage is already recorded, so a failure from
+	// here on returns the result as well as the error, and the caller must not record
+	// the usage again.
+	for _, event := range record.ThresholdsReached(usage, i.policy, i.clock) {
+		err := i.usage.ClaimAlert(ctx, event)
+		if errors.Is(err, domain.ErrUsageAlertAlreadySent) {
+			continue
+		}
+		if err != nil {
+			return result, err
+		}
+		if err := i.notifier.NotifyUsageThreshold(ctx, event); err != nil {
+			return result, err
+		}
+		result.Alerts = append(result.Alerts, event)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,152 @@
+package record_usage
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/testkit"
+)
+
+var now = time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+
+type fixture struct {
+	usage      *testkit.FakeUsage
+	alerts     *testkit.RecordingUsageAlerts
+	sub        *domain.Subscription
+	interactor *Interactor
+}
+
+func newFixture(t *testing.T, entitlements ...domain.Entitlement) *fixture {
+	sub, _, err := domain.NewSubscription("sub-1", "cust-1", "plan-pro", 4900, domain.CycleOfDays(30), domain.FixedClock{FixedTime: now})
+	require.NoError(t, err)
+
+	repo := &testkit.MockSubscriptions{}
+	repo.On("FindByID", mock.Anything, "sub-1").Return(sub, nil)
+
+	f := &fixture{usage: testkit.NewFakeUsage(), alerts: &testkit.RecordingUsageAlerts{}, sub: sub}
+	plans := testkit.NewFakeEntitlements().WithPlan("plan-pro", entitlements...)
+	f.interactor = NewInteractor(repo, f.usage, plans, f.alerts, domain.UsageAlertPolicy{}, domain.FixedClock{FixedTime: now})
+	return f
+}
+
+func (f *fixture) record(t *testing.T, quantity int64) *Result {
+	result, err := f.interactor.Execute(context.Background(), Request{SubscriptionID: "sub-1", Metric: "api_calls", Quantity: quantity})
+	require.NoError(t, err)
+	return result
+}
+
+func thresholds(events []*domain.UsageThresholdReachedEvent) []int64 {
+	var out []int64
+	for _, e := range events {
+		out = append(out, e.ThresholdBP)
+	}
+	return out
+}
+
+func TestRecordUsage_AlertsOnceAtEachThreshold(t *testing.T) {
+	f := newFixture(t, domain.Entitlement{Feature: "api_calls", Limit: 1000})
+
+	assert.Empty(t, f.record(t, 700).Alerts)
+
+	result := f.record(t, 150)
+	assert.Equal(t, int64(850), result.Quantity)
+	assert.Equal(t, int64(1000), result.Included)
+	assert.Equal(t, []int64{8000}, thresholds(result.Alerts))
+
+	assert.Empty(t, f.record(t, 100).Alerts)
+	assert.Equal(t, []int64{10000}, thresholds(f.record(t, 50).Alerts))
+	assert.Empty(t, f.record(t, 500).Alerts)
+
+	alerts := f.alerts.Alerts()
+	require.Len(t, alerts, 2)
+	assert.Equal(t, domain.UsageThresholdReachedEvent{
+		SubscriptionID: "sub-1",
+		CustomerID:     "cust-1",
+		Metric:         "api_calls",
+		ThresholdBP:    10000,
+		Quantity:       1000,
+		Included:       1000,
+		PeriodStart:    f.sub.CurrentPeriodStart(),
+		ReachedAt:      now,
+	}, *alerts[1])
+	assert.Len(t, f.usage.Records(), 5)
+}
+
+func TestRecordUsage_JumpPastSeveralThresholds(t *testing.T) {
+	f := newFixture(t, domain.Entitlement{Feature: "api_calls", Limit: 1000})
+
+	assert.Equal(t, []int64{8000, 10000}, thresholds(f.record(t, 1200).Alerts))
+}
+
+func TestRecordUsage_ConcurrentRecordsAlertOnce(t *testing.T) {
+	f := newFixture(t, domain.Entitlement{Feature: "api_calls", Limit: 1000})
+
+	var wg sync.WaitGroup
+	for n := 0; n < 20; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := f.interactor.Execute(context.Background(), Request{SubscriptionID: "sub-1", Metric: "api_calls", Quantity: 100})
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	assert.ElementsMatch(t, []int64{8000, 10000}, thresholds(f.alerts.Alerts()))
+}
+
+func TestRecordUsage_UnlimitedMetricNeverAlerts(t *testing.T) {
+	f := newFixture(t, domain.Entitlement{Feature: "api_calls", Unlimited: true})
+
+	result := f.record(t, 1_000_000)
+
+	assert.Empty(t, result.Alerts)
+	assert.Zero(t, result.Included)
+	assert.Len(t, f.usage.Records(), 1)
+}
+
+func TestRecordUsage_RejectsMetricThePlanDoesNotMeter(t *testing.T) {
+	f := newFixture(t, domain.Entitlement{Feature: "seats", Limit: 5})
+
+	_, err := f.interactor.Execute(context.Background(), Request{SubscriptionID: "sub-1", Metric: "api_calls", Quantity: 1})
+
+	assert.ErrorIs(t, err, domain.ErrMetricNotMetered)
+	assert.Empty(t, f.usage.Records())
+}
+
+func TestRecordUsage_RejectsCancelledSubscription(t *testing.T) {
+	f := newFixture(t, domain.Entitlement{Feature: "api_calls", Limit: 1000})
+	_, err := f.sub.Cancel(domain.FixedClock{FixedTime: now}, 30)
+	require.NoError(t, err)
+
+	_, err = f.interactor.Execute(context.Background(), Request{SubscriptionID: "sub-1", Metric: "api_calls", Quantity: 1})
+
+	assert.ErrorIs(t, err, domain.ErrNotActive)
+	assert.Empty(t, f.usage.Records())
+}
+
+func TestRecordUsage_NotificationFailureKeepsUsage(t *testing.T) {
+	f := newFixture(t, domain.Entitlement{Feature: "api_calls", Limit: 1000})
+	failure := errors.New("mailer down")
+	f.alerts.Err = failure
+
+	result, err := f.interactor.Execute(context.Background(), Request{SubscriptionID: "sub-1", Metric: "api_calls", Quantity: 900})
+
+	assert.ErrorIs(t, err, failure)
+	require.NotNil(t, result)
+	assert.Equal(t, int64(900), result.Quantity)
+	assert.Len(t, f.usage.Records(), 1)
+}
+
+func TestRequest_Validate(t *testing.T) {
+	assert.ErrorIs(t, Request{SubscriptionID: "sub-1", Quantity: 1}.Validate(), domain.ErrInvalidMetric)
+	assert.ErrorIs(t, Request{SubscriptionID: "sub-1", Metric: "api_calls"}.Validate(), domain.ErrInvalidUsageQuantity)
+	assert.NoError(t, Request{SubscriptionID: "sub-1", Metric: "api_calls", Quantity: 1}.Validate())
+}
@@ -0,0 +1,36 @@
+package refresh_reporting
+
+import (
+	"context"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/instrument"
+)
+
+// UseCase is the refresh reporting use case as seen by callers
+type UseCase interface {
+	Execute(ctx context.Context, req Request) (*contracts.Aggregates, error)
+}
+
+var (
+	_ UseCase = (*Interactor)(nil)
+	_ UseCase = (*Instrumented)(nil)
+)
+
+// Instrumented decorates a UseCase with logs, metrics and a trace span per execution
+type Instrumented struct {
+	next UseCase
+	in   instrument.Instrumentation
+}
+
+// NewInstrumented wraps the given use case with instrumentation
+func NewInstrumented(next UseCase, in instrument.Instrumentation) *Instrumented {
+	return &Instrumented{next: next, in: in}
+}
+
+// Execute runs the wrapped use case
+func (d *Instrumented) Execute(ctx context.Context, req Request) (*contracts.Aggregates, error) {
+	return instrument.Run(ctx, d.in, "refresh_reporting", nil, func(ctx context.Context) (*contracts.Aggregates, error) {
+		return d.next.Execute(ctx, req)
+	})
+}
@@ -0,0 +1,75 @@
+package refresh_reporting
+
+import (
+	"context"
+	"time"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+// DefaultWindowDays is how many days of new and cancelled counts the projection keeps
+const DefaultWindowDays = 90
+
+// Request contains the input for a refresh
+type Request struct {
+	// Staleness reads the operational tables this far in the past, at an exact
+	// timestamp any replica can serve without waiting on writes; zero reads the latest
+	Staleness time.Duration
+}
+
+// Interactor handles the refresh reporting use case
+type Interactor struct {
+	repo       contracts.ReportingRepository
+	clock      domain.Clock
+	windowDays int
+}
+
+// NewInteractor creates a new refresh reporting interactor
+func NewInteractor(repo contracts.ReportingRepository, clock domain.Clock, windowDays int) *Interactor {
+	if windowDays < 1 {
+		windowDays = DefaultWindowDays
+	}
+	return &Interactor{
+		repo:       repo,
+		clock:      clock,
+		windowDays: windowDays,
+	}
+}
+
+// Execute recomputes the aggregates and replaces the projection with them. The daily
+// counts cover the window ending today (UTC), with a zero row for every quiet day so
+// dashboards don't have to fill gaps.
+func (i *Interactor) Execute(ctx context.Context, req Request) (*contracts.Aggregates, error) {
+	now := i.clock.Now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	since := today.AddDate(0, 0, 1-i.windowDays)
+
+	// 1. Scan the operational tables, every one at the same timestamp
+	aggregates, err := i.repo.ComputeAggregates(ctx, since, contracts.Snapshot{Staleness: req.Staleness})
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. Fill the window
+	counted := make(map[time.Time]contracts.DailyCount, len(aggregates.Daily))
+	for _, d := range aggregates.Daily {
+		counted[d.Day] = d
+	}
+	daily := make([]contracts.DailyCount, 0, i.windowDays)
+	for day := since; !day.After(today); day = day.AddDate(0, 0, 1) {
+		d, ok := counted[day]
+		if !ok {
+			d = contracts.DailyCount{Day: day}
+		}
+		daily = append(daily, d)
+	}
+	aggregates.Daily = daily
+	aggregates.RefreshedAt = now
+
+	// 3. Replace the projection
+	if err := i.repo.SaveAggregates(ctx, aggregates); err != nil {
+		return nil, err
+	}
+	return aggregates, nil
+}
@@ -0,0 +1,90 @@
+package refresh_reporting
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+// MockRepository is a mock implementation of ReportingRepository
+type MockRepository struct {
+	mock.Mock
+}
+
+func (m *MockRepository) ComputeAggregates(ctx context.Context, since time.Time, snapshot contracts.Snapshot) (*contracts.Aggregates, error) {
+	args := m.Called(ctx, since, snapshot)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*contracts.Aggregates), args.Error(1)
+}
+
+func (m *MockRepository) SaveAggregates(ctx context.Context, aggregates *contracts.Aggregates) error {
+	args := m.Called(ctx, aggregates)
+	return args.Error(0)
+}
+
+func (m *MockRepository) LoadAggregates(ctx context.Context) (*contracts.Aggregates, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*contracts.Aggregates), args.Error(1)
+}
+
+func day(d int) time.Time {
+	return time.Date(2024, 3, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestRefreshReporting_FillsWindowAndSaves(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 3, 10, 15, 4, 5, 0, time.UTC)
+	repo := &MockRepository{}
+
+	repo.On("ComputeAggregates", ctx, day(4), contracts.Snapshot{Staleness: 15 * time.Second}).Return(&contracts.Aggregates{
+		ActiveByPlan: []contracts.PlanCount{{PlanID: "plan-pro", Active: 12}},
+		Daily: []contracts.DailyCount{
+			{Day: day(5), New: 3},
+			{Day: day(10), New: 1, Cancelled: 2},
+		},
+		Refunds: []contracts.RefundTotal{{Currency: "USD", Status: "SUCCEEDED", Count: 2, AmountCents: 1800}},
+		ReadAt:  now.Add(-15 * time.Second),
+	}, nil)
+	repo.On("SaveAggregates", ctx, mock.Anything).Return(nil)
+
+	aggregates, err := NewInteractor(repo, domain.FixedClock{FixedTime: now}, 7).Execute(ctx, Request{Staleness: 15 * time.Second})
+
+	require.NoError(t, err)
+	assert.Equal(t, now, aggregates.RefreshedAt)
+	assert.Equal(t, now.Add(-15*time.Second), aggregates.ReadAt, "saved with the figures, so exports can read at it")
+	assert.Equal(t, []contracts.DailyCount{
+		{Day: day(4)},
+		{Day: day(5), New: 3},
+		{Day: day(6)},
+		{Day: day(7)},
+		{Day: day(8)},
+		{Day: day(9)},
+		{Day: day(10), New: 1, Cancelled: 2},
+	}, aggregates.Daily)
+	assert.Equal(t, []contracts.PlanCount{{PlanID: "plan-pro", Active: 12}}, aggregates.ActiveByPlan)
+	repo.AssertCalled(t, "SaveAggregates", ctx, aggregates)
+}
+
+func TestRefreshReporting_KeepsProjectionWhenScanFails(t *testing.T) {
+	ctx := context.Background()
+	repo := &MockRepository{}
+	failure := errors.New("deadline exceeded")
+	repo.On("ComputeAggregates", ctx, mock.Anything, mock.Anything).Return(nil, failure)
+
+	_, err := NewInteractor(repo, domain.FixedClock{FixedTime: day(10)}, 0).Execute(ctx, Request{})
+
+	assert.ErrorIs(t, err, failure)
+	repo.AssertNotCalled(t, "SaveAggregates", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,37 @@
+package renew_subscription
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/bus"
+)
+
+// CommandName identifies the renew subscription command on the bus
+const CommandName = "subscription.renew"
+
+var _ bus.Handler = (*Interactor)(nil)
+
+// Request is the bus command for renewing a subscription
+type Request struct {
+	SubscriptionID string
+}
+
+// CommandName implements bus.Command
+func (r Request) CommandName() string {
+	return CommandName
+}
+
+// Handle executes the use case for a command dispatched through the bus
+func (i *Interactor) Handle(ctx context.Context, cmd bus.Command) (any, error) {
+	req, ok := cmd.(Request)
+	if !ok {
+		return nil, fmt.Errorf("unexpected command type %T", cmd)
+	}
+
+	result, err := i.Execute(ctx, req.SubscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,205 @@
+package renew_subscription
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+// Result describes the outcome of a renewal; Renewed is always set, PastDue is set
+// when the renewal charge was declined and the subscription entered dunning,
+// AuthenticationRequired is set as well when the decline was a charge held for strong
+// customer authentication, and ReferralRewarded is set when the renewal was the first
+// one paid for by a referee
+type Result struct {
+	Renewed                *domain.SubscriptionRenewedEvent
+	PastDue                *domain.SubscriptionPastDueEvent
+	AuthenticationRequired *domain.AuthenticationRequiredEvent
+	ReferralRewarded       *domain.ReferralRewardedEvent
+	ChargeError            error
+}
+
+// Interactor handles the renew subscription use case
+type Interactor struct {
+	repo            contracts.SubscriptionRepository
+	credits         contracts.CreditBalanceRepository
+	referrals       contracts.ReferralRepository
+	authentications contracts.ChargeAuthenticationRepository
+	billing         contracts.BillingResolver
+	pricing         contracts.PricingSource
+	hooks           contracts.SubscriptionHooks
+	notifier        contracts.AuthenticationNotifier
+	clock           domain.Clock
+	cycles          contracts.BillingCycleSource
+	renewalWindow   time.Duration
+	schedule        domain.DunningSchedule
+	referralReward  domain.ReferralReward
+}
+
+// NewInteractor creates a new renew subscription interactor.
+// renewalWindow is how long before the period end a subscription may be renewed;
+// schedule is the dunning schedule started when the renewal charge is declined;
+// referralReward is what both parties of a referral are credited on its first paid renewal;
+// notifier asks customers to authenticate renewal charges held for authentication.
+func NewInteractor(repo contracts.SubscriptionRepository, credits contracts.CreditBalanceRepository, referrals contracts.ReferralRepository, authentications contracts.ChargeAuthenticationRepository, billing contracts.BillingResolver, pricing contracts.PricingSource, hooks contracts.SubscriptionHooks, notifier contracts.AuthenticationNotifier, clock domain.Clock, cycles contracts.BillingCycleSource, renewalWindow time.Duration, schedule domain.DunningSchedule, referralReward domain.ReferralReward) *Interactor {
+	return &Interactor{
+		repo:            repo,
+		credits:         credits,
+		referrals:       referrals,
+		authentications: authentications,
+		billing:         billing,
+		pricing:         pricing,
+		hooks:           hooks,
+		notifier:        notifier,
+		clock:           clock,
+		cycles:          cycles,
+		renewalWindow:   renewalWindow,
+		schedule:        schedule,
+		referralReward:  referralReward,
+	}
+}
+
+// Execute renews a subscription into its next billing period and charges for it
+func (i *Interactor) Execute(ctx context.Context, subscriptionID string) (*Result, error) {
+	// 1. Load subscription
+	sub, err := i.repo.FindByID(ctx, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. Renew via domain method, at the price less the subscription's discounts (rejects
+	// subscriptions that are not due, which makes repeated executions for the same period safe)
+	pricing, err := i.pricing.PricingFor(ctx, sub)
+	if err != nil {
+		return nil, err
+	}
+	cycle, err := i.cycles.CycleFor(ctx, sub)
+	if err != nil {
+		return nil, err
+	}
+	event, err := sub.Renew(i.clock, cycle, i.renewalWindow, pricing)
+	if err != nil {
+		return nil, err
+	}
+	result := &Result{Renewed: event}
+
+	// 3. Let the deployment's hooks veto the renewal before it is charged
+	if err := i.hooks.BeforeRenew(ctx, sub, event); err != nil {
+		return nil, fmt.Errorf("%w: %w", domain.ErrRejectedByHook, err)
+	}
+
+	// 4. Spend the customer's credit balance first; only the rest is charged
+	balance, err := i.credits.Balance(ctx, sub.CustomerID())
+	if err != nil {
+		return nil, err
+	}
+	covered, due := domain.CoverWithCredit(balance, event.Amount)
+
+	// 5. Charge for the new period; the key is unique per period so a retried
+	// renewal is deduplicated by the billing API
+	var chargeErr error
+	if due > 0 {
+		billingClient, err := i.billing.Resolve(ctx, sub.PlanID(), sub.CustomerID())
+		if err != nil {
+			return nil, err
+		}
+		chargeErr = billingClient.ChargeCustomer(ctx, contracts.ChargeRequest{
+			CustomerID:     sub.CustomerID(),
+			SubscriptionID: sub.ID(),
+			Amount:         due,
+			Currency:       domain.DefaultCurrency,
+			IdempotencyKey: fmt.Sprintf("%s:%d:renewal", sub.ID(), sub.CurrentPeriodStart().Unix()),
+		})
+	}
+
+	// 6. A declined charge starts dunning without spending the credit; any other
+	// failure leaves the subscription unchanged so the next pass retries the same charge.
+	// A charge held for authentication is a decline the customer can still complete, so
+	// it is tracked until the provider reports the outcome.
+	var authentication *domain.ChargeAuthentication
+	if chargeErr != nil {
+		if !errors.Is(chargeErr, domain.ErrPaymentDeclined) {
+			return nil, chargeErr
+		}
+		result.ChargeError = chargeErr
+		if result.PastDue, err = sub.MarkPastDue(i.clock, i.schedule, pricing); err != nil {
+			return nil, err
+		}
+		var challenge *domain.AuthenticationRequiredError
+		if errors.As(chargeErr, &challenge) {
+			result.PastDue.FailureReason = domain.AuthenticationFailureReason
+			authentication, result.AuthenticationRequired = domain.NewChargeAuthentication(uuid.New().String(), sub, due, covered, domain.DefaultCurrency, challenge, i.clock)
+		}
+	}
+
+	// 7. Save the updated subscription, the credit it spent and the charge awaiting
+	// authentication
+	var uow contracts.UnitOfWork
+	uow.SaveChecked(i.repo.Save(ctx, sub))
+	if authentication != nil {
+		uow.Save(i.authentications.Save(ctx, authentication))
+	}
+	if chargeErr == nil && covered > 0 {
+		event.CreditApplied = covered
+		sourceID := fmt.Sprintf("%s:%d", sub.ID(), sub.CurrentPeriodStart().Unix())
+		entry := domain.NewCreditEntry(uuid.New().String(), sub.CustomerID(), -covered, domain.DefaultCurrency, domain.CreditSourceRenewal, sourceID, i.clock)
+		uow.Save(i.credits.Save(ctx, entry))
+	}
+
+	// 8. The first renewal charged to the payment method pays off the referral the
+	// subscription was created with, if any
+	if chargeErr == nil && due > 0 {
+		if err := i.rewardReferral(ctx, sub, result, &uow); err != nil {
+			return nil, err
+		}
+	}
+
+	// 9. Commit the writes
+	if err := uow.Commit(ctx, i.repo); err != nil {
+		return nil, err
+	}
+	i.hooks.AfterRenew(ctx, sub, event)
+
+	// 10. Ask the customer to authenticate the held charge; the renewal stands if this
+	// fails, and the result is returned with the error
+	if result.AuthenticationRequired != nil {
+		if err := i.notifier.NotifyAuthenticationRequired(ctx, result.AuthenticationRequired); err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+// rewardReferral credits both parties of the subscription's pending referral and
+// adds the writes saving it to uow. A subscription created without a referral, or
+// whose referral was already rewarded, adds none.
+func (i *Interactor) rewardReferral(ctx context.Context, sub *domain.Subscription, result *Result, uow *contracts.UnitOfWork) error {
+	referral, err := i.referrals.FindBySubscription(ctx, sub.ID())
+	if errors.Is(err, domain.ErrReferralNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if referral.Status() != domain.ReferralPending {
+		return nil
+	}
+
+	entries, event, err := referral.Reward(i.clock, i.referralReward, uuid.New().String(), uuid.New().String())
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		uow.Save(i.credits.Save(ctx, entry))
+	}
+	uow.Save(i.referrals.Save(ctx, referral))
+	result.ReferralRewarded = event
+	return nil
+}
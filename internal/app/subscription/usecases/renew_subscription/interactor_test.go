@@ -0,0 +1,585 @@
+package renew_subscription
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/adapters"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/testkit"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/testkit/builders"
+)
+
+func newTestInteractor(repo contracts.SubscriptionRepository, billing contracts.BillingClient, clock domain.Clock, renewalWindow time.Duration) *Interactor {
+	return NewInteractor(repo, testkit.NewFakeCreditBalances(), testkit.NewFakeReferrals(), testkit.NewFakeAuthentications(), adapters.StaticBillingResolver{Client: billing}, adapters.StaticPricing{}, adapters.HookChain{}, &testkit.RecordingAuthenticationRequests{}, clock, adapters.StaticBillingCycle{Cycle: domain.CycleOfDays(30)}, renewalWindow, domain.DefaultDunningSchedule, domain.ReferralReward{})
+}
+
+func TestRenewSubscription_Success(t *testing.T) {
+	ctx := context.Background()
+	renewDate := time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC) // period end
+
+	sub := builders.NewSubscriptionBuilder().Build()
+
+	mockRepo := new(testkit.MockSubscriptions)
+	interactor := newTestInteractor(mockRepo, testkit.NewFakeBillingClient(), domain.FixedClock{FixedTime: renewDate}, 0)
+
+	mockRepo.On("FindByID", ctx, "sub-123").Return(sub, nil)
+	mockRepo.On("Save", ctx, mock.MatchedBy(func(s *domain.Subscription) bool {
+		return s.CurrentPeriodStart().Equal(renewDate)
+	})).Return(&spanner.Mutation{}, nil)
+	mockRepo.On("Apply", ctx, mock.Anything).Return(nil)
+
+	result, err := interactor.Execute(ctx, "sub-123")
+
+	require.NoError(t, err)
+	event := result.Renewed
+	assert.Nil(t, result.PastDue)
+	assert.Equal(t, "sub-123", event.SubscriptionID)
+	assert.Equal(t, int64(3000), event.Amount)
+	assert.Equal(t, renewDate, event.PeriodStart)
+	assert.Equal(t, renewDate.AddDate(0, 0, 30), event.PeriodEnd)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestRenewSubscription_WithinWindow(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 30, 23, 0, 0, 0, time.UTC) // one hour before period end
+
+	sub := builders.NewSubscriptionBuilder().Build()
+
+	mockRepo := new(testkit.MockSubscriptions)
+	interactor := newTestInteractor(mockRepo, testkit.NewFakeBillingClient(), domain.FixedClock{FixedTime: now}, 2*time.Hour)
+
+	mockRepo.On("FindByID", ctx, "sub-123").Return(sub, nil)
+	mockRepo.On("Save", ctx, mock.Anything).Return(&spanner.Mutation{}, nil)
+	mockRepo.On("Apply", ctx, mock.Anything).Return(nil)
+
+	result, err := interactor.Execute(ctx, "sub-123")
+
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC), result.Renewed.PeriodStart)
+}
+
+func TestRenewSubscription_NotDue(t *testing.T) {
+	ctx := context.Background()
+	startDate := builders.DefaultStartDate
+
+	sub := builders.NewSubscriptionBuilder().Build()
+
+	mockRepo := new(testkit.MockSubscriptions)
+	interactor := newTestInteractor(mockRepo, testkit.NewFakeBillingClient(), domain.FixedClock{FixedTime: startDate.AddDate(0, 0, 10)}, time.Hour)
+
+	mockRepo.On("FindByID", ctx, "sub-123").Return(sub, nil)
+
+	result, err := interactor.Execute(ctx, "sub-123")
+
+	assert.Equal(t, domain.ErrRenewalNotDue, err)
+	assert.Nil(t, result)
+	mockRepo.AssertNotCalled(t, "Save", ctx, mock.Anything)
+	mockRepo.AssertNotCalled(t, "Apply", ctx, mock.Anything)
+}
+
+func TestRenewSubscription_RenewsEachPeriodAsTimePasses(t *testing.T) {
+	ctx := context.Background()
+	startDate := builders.DefaultStartDate
+
+	subs := testkit.NewFakeSubscriptions().With(builders.NewSubscriptionBuilder().Build())
+	clock := testkit.NewStepClock(startDate.AddDate(0, 0, 30))
+	interactor := newTestInteractor(subs, testkit.NewFakeBillingClient(), clock, 0)
+
+	result, err := interactor.Execute(ctx, "sub-123")
+	require.NoError(t, err)
+	assert.Equal(t, startDate.AddDate(0, 0, 30), result.Renewed.PeriodStart)
+
+	clock.Advance(29 * 24 * time.Hour)
+	_, err = interactor.Execute(ctx, "sub-123")
+	assert.Equal(t, domain.ErrRenewalNotDue, err)
+
+	clock.Advance(24 * time.Hour)
+	result, err = interactor.Execute(ctx, "sub-123")
+	require.NoError(t, err)
+	assert.Equal(t, startDate.AddDate(0, 0, 60), result.Renewed.PeriodStart)
+}
+
+func TestRenewSubscription_Cancelled(t *testing.T) {
+	ctx := context.Background()
+	startDate := builders.DefaultStartDate
+
+	sub := builders.NewSubscriptionBuilder().Cancelled().Build()
+
+	mockRepo := new(testkit.MockSubscriptions)
+	interactor := newTestInteractor(mockRepo, testkit.NewFakeBillingClient(), domain.FixedClock{FixedTime: startDate.AddDate(0, 0, 30)}, 0)
+
+	mockRepo.On("FindByID", ctx, "sub-123").Return(sub, nil)
+
+	result, err := interactor.Execute(ctx, "sub-123")
+
+	assert.Equal(t, domain.ErrNotRenewable, err)
+	assert.Nil(t, result)
+}
+
+func TestRenewSubscription_ChargesForNewPeriod(t *testing.T) {
+	ctx := context.Background()
+	startDate := builders.DefaultStartDate
+	renewDate := startDate.AddDate(0, 0, 30)
+
+	sub := builders.NewSubscriptionBuilder().Build()
+
+	mockRepo := new(testkit.MockSubscriptions)
+	billing := testkit.NewFakeBillingClient()
+	interactor := newTestInteractor(mockRepo, billing, domain.FixedClock{FixedTime: renewDate}, 0)
+
+	mockRepo.On("FindByID", ctx, "sub-123").Return(sub, nil)
+	mockRepo.On("Save", ctx, mock.Anything).Return(&spanner.Mutation{}, nil)
+	mockRepo.On("Apply", ctx, mock.Anything).Return(nil)
+
+	_, err := interactor.Execute(ctx, "sub-123")
+
+	require.NoError(t, err)
+	charges := billing.CallsTo(testkit.OpChargeCustomer)
+	require.Len(t, charges, 1)
+	assert.Equal(t, contracts.ChargeRequest{
+		CustomerID:     "cust-456",
+		SubscriptionID: "sub-123",
+		Amount:         3000,
+		Currency:       domain.DefaultCurrency,
+		IdempotencyKey: fmt.Sprintf("sub-123:%d:renewal", renewDate.Unix()),
+	}, charges[0].Charge)
+}
+
+func TestRenewSubscription_DeclinedChargeStartsDunning(t *testing.T) {
+	ctx := context.Background()
+	startDate := builders.DefaultStartDate
+	renewDate := startDate.AddDate(0, 0, 30)
+
+	sub := builders.NewSubscriptionBuilder().Build()
+
+	mockRepo := new(testkit.MockSubscriptions)
+	billing := testkit.NewFakeBillingClient().FailAlways(testkit.OpChargeCustomer, domain.ErrPaymentDeclined)
+	interactor := newTestInteractor(mockRepo, billing, domain.FixedClock{FixedTime: renewDate}, 0)
+
+	mockRepo.On("FindByID", ctx, "sub-123").Return(sub, nil)
+	mockRepo.On("Save", ctx, mock.MatchedBy(func(s *domain.Subscription) bool {
+		return s.Status() == domain.StatusPastDue && s.CurrentPeriodStart().Equal(renewDate)
+	})).Return(&spanner.Mutation{}, nil)
+	mockRepo.On("Apply", ctx, mock.Anything).Return(nil)
+
+	result, err := interactor.Execute(ctx, "sub-123")
+
+	require.NoError(t, err)
+	require.NotNil(t, result.PastDue)
+	assert.Equal(t, int64(3000), result.PastDue.AmountDue)
+	assert.Equal(t, renewDate.Add(domain.DefaultDunningSchedule[0]), result.PastDue.NextPaymentRetryAt)
+	assert.ErrorIs(t, result.ChargeError, domain.ErrPaymentDeclined)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestRenewSubscription_ChargeHeldForAuthentication(t *testing.T) {
+	ctx := context.Background()
+	startDate := builders.DefaultStartDate
+	renewDate := startDate.AddDate(0, 0, 30)
+
+	sub := builders.NewSubscriptionBuilder().Build()
+
+	mockRepo := new(testkit.MockSubscriptions)
+	challenge := &domain.AuthenticationRequiredError{PaymentID: "pay-1", ActionURL: "https://billing.example/authenticate/pay-1"}
+	billing := testkit.NewFakeBillingClient().FailAlways(testkit.OpChargeCustomer, challenge)
+	credits := testkit.NewFakeCreditBalances().Grant("cust-456", 1000)
+	authentications := testkit.NewFakeAuthentications()
+	notifier := &testkit.RecordingAuthenticationRequests{}
+	interactor := NewInteractor(mockRepo, credits, testkit.NewFakeReferrals(), authentications, adapters.StaticBillingResolver{Client: billing}, adapters.StaticPricing{}, adapters.HookChain{}, notifier, domain.FixedClock{FixedTime: renewDate}, adapters.StaticBillingCycle{Cycle: domain.CycleOfDays(30)}, 0, domain.DefaultDunningSchedule, domain.ReferralReward{})
+
+	mockRepo.On("FindByID", ctx, "sub-123").Return(sub, nil)
+	mockRepo.On("Save", ctx, sub).Return(&spanner.Mutation{}, nil)
+	mockRepo.On("Apply", ctx, mock.MatchedBy(func(m []*spanner.Mutation) bool { return len(m) == 2 })).Return(nil)
+
+	result, err := interactor.Execute(ctx, "sub-123")
+
+	require.NoError(t, err)
+	assert.Equal(t, domain.StatusPastDue, sub.Status())
+	assert.Equal(t, domain.AuthenticationFailureReason, result.PastDue.FailureReason)
+	assert.ErrorIs(t, result.ChargeError, domain.ErrAuthenticationRequired)
+
+	authentication := authentications.Authentication("pay-1")
+	require.NotNil(t, authentication)
+	assert.Equal(t, domain.AuthenticationPending, authentication.Status())
+	assert.Equal(t, int64(2000), authentication.Amount())
+	assert.Equal(t, int64(1000), authentication.CreditApplied())
+	assert.Equal(t, renewDate, authentication.PeriodStart())
+	assert.Empty(t, credits.Entries(), "credit is spent once the charge completes")
+
+	require.NotNil(t, result.AuthenticationRequired)
+	assert.Equal(t, []*domain.AuthenticationRequiredEvent{result.AuthenticationRequired}, notifier.Requests())
+	assert.Equal(t, challenge.ActionURL, result.AuthenticationRequired.ActionURL)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestRenewSubscription_ChargeFailureLeavesSubscriptionUnchanged(t *testing.T) {
+	ctx := context.Background()
+	startDate := builders.DefaultStartDate
+	unavailable := errors.New("billing unavailable")
+
+	sub := builders.NewSubscriptionBuilder().Build()
+
+	mockRepo := new(testkit.MockSubscriptions)
+	billing := testkit.NewFakeBillingClient().FailAlways(testkit.OpChargeCustomer, unavailable)
+	interactor := newTestInteractor(mockRepo, billing, domain.FixedClock{FixedTime: startDate.AddDate(0, 0, 30)}, 0)
+
+	mockRepo.On("FindByID", ctx, "sub-123").Return(sub, nil)
+
+	result, err := interactor.Execute(ctx, "sub-123")
+
+	assert.ErrorIs(t, err, unavailable)
+	assert.Nil(t, result)
+	mockRepo.AssertNotCalled(t, "Save", ctx, mock.Anything)
+}
+
+func TestRenewSubscription_SpendsCreditBalance(t *testing.T) {
+	renewDate := time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		balance     int64
+		wantCharged []int64
+		wantApplied int64
+	}{
+		{"partly covered", 1200, []int64{1800}, 1200},
+		{"fully covered", 5000, nil, 3000},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			mockRepo := new(testkit.MockSubscriptions)
+			billing := testkit.NewFakeBillingClient()
+			credits := testkit.NewFakeCreditBalances().Grant("cust-456", tc.balance)
+			interactor := NewInteractor(mockRepo, credits, testkit.NewFakeReferrals(), testkit.NewFakeAuthentications(), adapters.StaticBillingResolver{Client: billing}, adapters.StaticPricing{}, adapters.HookChain{}, &testkit.RecordingAuthenticationRequests{}, domain.FixedClock{FixedTime: renewDate}, adapters.StaticBillingCycle{Cycle: domain.CycleOfDays(30)}, 0, domain.DefaultDunningSchedule, domain.ReferralReward{})
+
+			mockRepo.On("FindByID", ctx, "sub-123").Return(builders.NewSubscriptionBuilder().Build(), nil)
+			mockRepo.On("Save", ctx, mock.Anything).Return(&spanner.Mutation{}, nil)
+			mockRepo.On("Apply", ctx, mock.MatchedBy(func(m []*spanner.Mutation) bool { return len(m) == 2 })).Return(nil)
+
+			result, err := interactor.Execute(ctx, "sub-123")
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantApplied, result.Renewed.CreditApplied)
+			var charged []int64
+			for _, call := range billing.CallsTo(testkit.OpChargeCustomer) {
+				charged = append(charged, call.Charge.Amount)
+			}
+			assert.Equal(t, tc.wantCharged, charged)
+			entries := credits.Entries()
+			require.Len(t, entries, 1)
+			assert.Equal(t, -tc.wantApplied, entries[0].Amount())
+			assert.Equal(t, domain.CreditSourceRenewal, entries[0].Source())
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRenewSubscription_DeclinedChargeKeepsCredit(t *testing.T) {
+	ctx := context.Background()
+	startDate := builders.DefaultStartDate
+	mockRepo := new(testkit.MockSubscriptions)
+	billing := testkit.NewFakeBillingClient().FailAlways(testkit.OpChargeCustomer, domain.ErrPaymentDeclined)
+	credits := testkit.NewFakeCreditBalances().Grant("cust-456", 1000)
+	interactor := NewInteractor(mockRepo, credits, testkit.NewFakeReferrals(), testkit.NewFakeAuthentications(), adapters.StaticBillingResolver{Client: billing}, adapters.StaticPricing{}, adapters.HookChain{}, &testkit.RecordingAuthenticationRequests{}, domain.FixedClock{FixedTime: startDate.AddDate(0, 0, 30)}, adapters.StaticBillingCycle{Cycle: domain.CycleOfDays(30)}, 0, domain.DefaultDunningSchedule, domain.ReferralReward{})
+
+	mockRepo.On("FindByID", ctx, "sub-123").Return(builders.NewSubscriptionBuilder().Build(), nil)
+	mockRepo.On("Save", ctx, mock.Anything).Return(&spanner.Mutation{}, nil)
+	mockRepo.On("Apply", ctx, mock.Anything).Return(nil)
+
+	result, err := interactor.Execute(ctx, "sub-123")
+
+	require.NoError(t, err)
+	require.NotNil(t, result.PastDue)
+	assert.Zero(t, result.Renewed.CreditApplied)
+	assert.Empty(t, credits.Entries())
+}
+
+func TestRenewSubscription_RewardsReferralOnFirstPaidRenewal(t *testing.T) {
+	startDate := builders.DefaultStartDate
+	renewDate := time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)
+	pending := func() *domain.Referral {
+		return domain.ReconstructReferral("ref-1", "ABCD2345", "cust-referrer", "cust-456", "sub-123", domain.ReferralPending, 0, 0, startDate, time.Time{})
+	}
+	rewarded := func() *domain.Referral {
+		return domain.ReconstructReferral("ref-1", "ABCD2345", "cust-referrer", "cust-456", "sub-123", domain.ReferralRewarded, 1000, 500, startDate, startDate)
+	}
+
+	tests := []struct {
+		name         string
+		referral     func() *domain.Referral
+		balance      int64
+		declined     bool
+		wantRewarded bool
+	}{
+		{"pending referral", pending, 0, false, true},
+		{"no referral", nil, 0, false, false},
+		{"already rewarded", rewarded, 0, false, false},
+		{"declined charge", pending, 0, true, false},
+		{"paid with credit only", pending, 3000, false, false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			mockRepo := new(testkit.MockSubscriptions)
+			billing := testkit.NewFakeBillingClient()
+			if tc.declined {
+				billing.FailAlways(testkit.OpChargeCustomer, domain.ErrPaymentDeclined)
+			}
+			credits := testkit.NewFakeCreditBalances().Grant("cust-456", tc.balance)
+			referrals := testkit.NewFakeReferrals()
+			if tc.referral != nil {
+				referrals.WithReferral(tc.referral())
+			}
+			reward := domain.ReferralReward{ReferrerCredit: 1000, RefereeCredit: 500}
+			interactor := NewInteractor(mockRepo, credits, referrals, testkit.NewFakeAuthentications(), adapters.StaticBillingResolver{Client: billing}, adapters.StaticPricing{}, adapters.HookChain{}, &testkit.RecordingAuthenticationRequests{}, domain.FixedClock{FixedTime: renewDate}, adapters.StaticBillingCycle{Cycle: domain.CycleOfDays(30)}, 0, domain.DefaultDunningSchedule, reward)
+
+			mockRepo.On("FindByID", ctx, "sub-123").Return(builders.NewSubscriptionBuilder().Build(), nil)
+			mockRepo.On("Save", ctx, mock.Anything).Return(&spanner.Mutation{}, nil)
+			mockRepo.On("Apply", ctx, mock.Anything).Return(nil)
+
+			result, err := interactor.Execute(ctx, "sub-123")
+
+			require.NoError(t, err)
+			if !tc.wantRewarded {
+				assert.Nil(t, result.ReferralRewarded)
+				for _, entry := range credits.Entries() {
+					assert.NotEqual(t, domain.CreditSourceReferral, entry.Source())
+				}
+				return
+			}
+			require.NotNil(t, result.ReferralRewarded)
+			assert.Equal(t, "ref-1", result.ReferralRewarded.ReferralID)
+			assert.Equal(t, int64(1000), result.ReferralRewarded.ReferrerCredit)
+			assert.Equal(t, int64(500), result.ReferralRewarded.RefereeCredit)
+			assert.Equal(t, domain.ReferralRewarded, referrals.Referral("sub-123").Status())
+			entries := credits.Entries()
+			require.Len(t, entries, 2)
+			assert.Equal(t, "cust-referrer", entries[0].CustomerID())
+			assert.Equal(t, int64(1000), entries[0].Amount())
+			assert.Equal(t, "cust-456", entries[1].CustomerID())
+			assert.Equal(t, int64(500), entries[1].Amount())
+			assert.Equal(t, "ref-1", entries[1].SourceID())
+			applied := mockRepo.Calls[len(mockRepo.Calls)-1].Arguments.Get(1).([]*spanner.Mutation)
+			assert.Len(t, applied, 4)
+		})
+	}
+}
+
+func TestRenewSubscription_ChargesDiscountedPrice(t *testing.T) {
+	ctx := context.Background()
+	startDate := builders.DefaultStartDate
+	mockRepo := new(testkit.MockSubscriptions)
+	billing := testkit.NewFakeBillingClient()
+	pricing := adapters.StaticPricing{Pricing: domain.Pricing{
+		Discounts: []domain.Discount{{Code: "SAVE5", AmountOff: 500}, {Code: "SPRING20", PercentOff: 2000}, {Code: "LOYAL10", PercentOff: 1000}},
+		Policy:    domain.DiscountPolicy{MaxStacked: 2},
+	}}
+	interactor := NewInteractor(mockRepo, testkit.NewFakeCreditBalances(), testkit.NewFakeReferrals(), testkit.NewFakeAuthentications(), adapters.StaticBillingResolver{Client: billing}, pricing, adapters.HookChain{}, &testkit.RecordingAuthenticationRequests{}, domain.FixedClock{FixedTime: startDate.AddDate(0, 0, 30)}, adapters.StaticBillingCycle{Cycle: domain.CycleOfDays(30)}, 0, domain.DefaultDunningSchedule, domain.ReferralReward{})
+
+	mockRepo.On("FindByID", ctx, "sub-123").Return(builders.NewSubscriptionBuilder().Build(), nil)
+	mockRepo.On("Save", ctx, mock.Anything).Return(&spanner.Mutation{}, nil)
+	mockRepo.On("Apply", ctx, mock.Anything).Return(nil)
+
+	result, err := interactor.Execute(ctx, "sub-123")
+
+	require.NoError(t, err)
+	// Percentages before fixed amounts, and only two of them stack: 20% of 3000, then 10% of 2400
+	assert.Equal(t, int64(2160), result.Renewed.Amount)
+	assert.Equal(t, int64(840), result.Renewed.Discount)
+	assert.Equal(t, []domain.AppliedDiscount{
+		{Code: "SPRING20", Kind: domain.DiscountCoupon, Amount: 600},
+		{Code: "LOYAL10", Kind: domain.DiscountCoupon, Amount: 240},
+	}, result.Renewed.Discounts)
+	charges := billing.CallsTo(testkit.OpChargeCustomer)
+	require.Len(t, charges, 1)
+	assert.Equal(t, int64(2160), charges[0].Charge.Amount)
+}
+
+func TestRenewSubscription_CouponRunsOut(t *testing.T) {
+	ctx := context.Background()
+	startDate := builders.DefaultStartDate
+	coupon := domain.SubscriptionCoupon{Code: "LAUNCH50", PercentOff: 5000, PeriodsLeft: 2}
+
+	subs := testkit.NewFakeSubscriptions().With(builders.NewSubscriptionBuilder().WithCoupon(coupon).Build())
+	clock := testkit.NewStepClock(startDate.AddDate(0, 0, 30))
+	billing := testkit.NewFakeBillingClient()
+	interactor := newTestInteractor(subs, billing, clock, 0)
+
+	// The second period is the coupon's last
+	result, err := interactor.Execute(ctx, "sub-123")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1500), result.Renewed.Amount)
+	assert.Equal(t, []domain.AppliedDiscount{{Code: "LAUNCH50", Kind: domain.DiscountCoupon, Amount: 1500}}, result.Renewed.Discounts)
+
+	clock.Advance(30 * 24 * time.Hour)
+	result, err = interactor.Execute(ctx, "sub-123")
+	require.NoError(t, err)
+	assert.Equal(t, int64(3000), result.Renewed.Amount)
+	assert.Empty(t, result.Renewed.Discounts)
+
+	sub, err := subs.FindByID(ctx, "sub-123")
+	require.NoError(t, err)
+	assert.True(t, sub.Coupon().IsZero())
+	charges := billing.CallsTo(testkit.OpChargeCustomer)
+	require.Len(t, charges, 2)
+	assert.Equal(t, []int64{1500, 3000}, []int64{charges[0].Charge.Amount, charges[1].Charge.Amount})
+}
+
+func TestRenewSubscription_CouponCountsAgainstTheDiscountPolicy(t *testing.T) {
+	ctx := context.Background()
+	startDate := builders.DefaultStartDate
+	mockRepo := new(testkit.MockSubscriptions)
+	billing := testkit.NewFakeBillingClient()
+	pricing := adapters.StaticPricing{Pricing: domain.Pricing{
+		Discounts: []domain.Discount{{Code: "EU", Kind: domain.DiscountRegional, PercentOff: 1000}},
+		Policy:    domain.DiscountPolicy{MaxStacked: 1},
+	}}
+	interactor := NewInteractor(mockRepo, testkit.NewFakeCreditBalances(), testkit.NewFakeReferrals(), testkit.NewFakeAuthentications(), adapters.StaticBillingResolver{Client: billing}, pricing, adapters.HookChain{}, &testkit.RecordingAuthenticationRequests{}, domain.FixedClock{FixedTime: startDate.AddDate(0, 0, 30)}, adapters.StaticBillingCycle{Cycle: domain.CycleOfDays(30)}, 0, domain.DefaultDunningSchedule, domain.ReferralReward{})
+	sub := builders.NewSubscriptionBuilder().WithCoupon(domain.SubscriptionCoupon{Code: "FOREVER5", AmountOff: 500}).Build()
+
+	mockRepo.On("FindByID", ctx, "sub-123").Return(sub, nil)
+	mockRepo.On("Save", ctx, mock.Anything).Return(&spanner.Mutation{}, nil)
+	mockRepo.On("Apply", ctx, mock.Anything).Return(nil)
+
+	result, err := interactor.Execute(ctx, "sub-123")
+
+	require.NoError(t, err)
+	// The coupon is a discount like any other, so the policy's limit leaves it out
+	assert.Equal(t, int64(2700), result.Renewed.Amount)
+	assert.Equal(t, domain.SubscriptionCoupon{Code: "FOREVER5", AmountOff: 500}, sub.Coupon())
+}
+
+func TestRenewSubscription_ChargesTheAddOnsWithThePlan(t *testing.T) {
+	ctx := context.Background()
+	startDate := builders.DefaultStartDate
+	mockRepo := new(testkit.MockSubscriptions)
+	billing := testkit.NewFakeBillingClient()
+	bundles := testkit.NewFakeBundles().With("sub-123", domain.SubscriptionBundle{AddOns: []domain.AddOnCharge{
+		{ID: "extra-seats", Name: "Extra seats", Quantity: 3, UnitPrice: 200},
+	}})
+	pricing := adapters.BundlePricing{Bundles: bundles, Base: adapters.StaticPricing{Pricing: domain.Pricing{
+		Discounts: []domain.Discount{{Code: "TENOFF", PercentOff: 1000}},
+	}}}
+	interactor := NewInteractor(mockRepo, testkit.NewFakeCreditBalances(), testkit.NewFakeReferrals(), testkit.NewFakeAuthentications(), adapters.StaticBillingResolver{Client: billing}, pricing, adapters.HookChain{}, &testkit.RecordingAuthenticationRequests{}, domain.FixedClock{FixedTime: startDate.AddDate(0, 0, 30)}, adapters.StaticBillingCycle{Cycle: domain.CycleOfDays(30)}, 0, domain.DefaultDunningSchedule, domain.ReferralReward{})
+
+	mockRepo.On("FindByID", ctx, "sub-123").Return(builders.NewSubscriptionBuilder().Build(), nil)
+	mockRepo.On("Save", ctx, mock.Anything).Return(&spanner.Mutation{}, nil)
+	mockRepo.On("Apply", ctx, mock.Anything).Return(nil)
+
+	result, err := interactor.Execute(ctx, "sub-123")
+
+	require.NoError(t, err)
+	// The discount is of the plan and add-ons together: (3000 + 600) less 10%
+	assert.Equal(t, int64(3240), result.Renewed.Amount)
+	charges := billing.CallsTo(testkit.OpChargeCustomer)
+	require.Len(t, charges, 1)
+	assert.Equal(t, int64(3240), charges[0].Charge.Amount)
+}
+
+func TestRenewSubscription_HookVetoesBeforeCharging(t *testing.T) {
+	ctx := context.Background()
+	startDate := builders.DefaultStartDate
+	mockRepo := new(testkit.MockSubscriptions)
+	billing := testkit.NewFakeBillingClient()
+	veto := errors.New("contract is up for renegotiation")
+	hooks := &testkit.RecordingHooks{Veto: veto}
+	interactor := NewInteractor(mockRepo, testkit.NewFakeCreditBalances(), testkit.NewFakeReferrals(), testkit.NewFakeAuthentications(), adapters.StaticBillingResolver{Client: billing}, adapters.StaticPricing{}, hooks, &testkit.RecordingAuthenticationRequests{}, domain.FixedClock{FixedTime: startDate.AddDate(0, 0, 30)}, adapters.StaticBillingCycle{Cycle: domain.CycleOfDays(30)}, 0, domain.DefaultDunningSchedule, domain.ReferralReward{})
+
+	mockRepo.On("FindByID", ctx, "sub-123").Return(builders.NewSubscriptionBuilder().Build(), nil)
+
+	_, err := interactor.Execute(ctx, "sub-123")
+
+	assert.ErrorIs(t, err, domain.ErrRejectedByHook)
+	assert.ErrorIs(t, err, veto)
+	assert.Empty(t, billing.Calls())
+	mockRepo.AssertNotCalled(t, "Apply", mock.Anything, mock.Anything)
+}
+
+func TestRenewSubscription_RunsHooksAroundCharge(t *testing.T) {
+	ctx := context.Background()
+	startDate := builders.DefaultStartDate
+	mockRepo := new(testkit.MockSubscriptions)
+	hooks := &testkit.RecordingHooks{}
+	interactor := NewInteractor(mockRepo, testkit.NewFakeCreditBalances(), testkit.NewFakeReferrals(), testkit.NewFakeAuthentications(), adapters.StaticBillingResolver{Client: testkit.NewFakeBillingClient()}, adapters.StaticPricing{}, hooks, &testkit.RecordingAuthenticationRequests{}, domain.FixedClock{FixedTime: startDate.AddDate(0, 0, 30)}, adapters.StaticBillingCycle{Cycle: domain.CycleOfDays(30)}, 0, domain.DefaultDunningSchedule, domain.ReferralReward{})
+
+	mockRepo.On("FindByID", ctx, "sub-123").Return(builders.NewSubscriptionBuilder().Build(), nil)
+	mockRepo.On("Save", ctx, mock.Anything).Return(&spanner.Mutation{}, nil)
+	mockRepo.On("Apply", ctx, mock.Anything).Return(nil)
+
+	_, err := interactor.Execute(ctx, "sub-123")
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"BeforeRenew", "AfterRenew"}, hooks.Calls())
+}
+
+func TestRenewSubscription_RenewsInThePlansBillingCycle(t *testing.T) {
+	ctx := context.Background()
+	at := builders.DefaultStartDate
+	plans := testkit.NewFakePlans(
+		domain.ReconstructPlan("plan-yearly", "Yearly", 30000, "USD", domain.IntervalYear, 0, true, "", "", at, at),
+		domain.ReconstructPlan("plan-monthly", "Monthly", 3000, "USD", domain.IntervalMonth, 0, true, "", "", at, at),
+	)
+	cycles := adapters.PlanBillingCycles{Plans: plans, DefaultDays: 30}
+
+	tests := []struct {
+		name       string
+		plan       string
+		start      time.Time
+		now        time.Time
+		wantErr    error
+		wantPeriod [2]time.Time
+	}{
+		{
+			name:    "yearly plan isn't due after the default cycle",
+			plan:    "plan-yearly",
+			start:   time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			now:     time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC),
+			wantErr: domain.ErrRenewalNotDue,
+		},
+		{
+			name:       "yearly plan renews after a year",
+			plan:       "plan-yearly",
+			start:      time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			now:        time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+			wantPeriod: [2]time.Time{time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)},
+		},
+		{
+			name:       "monthly plan started 31 January renews on the last day of February",
+			plan:       "plan-monthly",
+			start:      time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC),
+			now:        time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC),
+			wantPeriod: [2]time.Time{time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 29, 0, 0, 0, 0, time.UTC)},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := testkit.NewFakeSubscriptions().With(builders.NewSubscriptionBuilder().WithPlan(tc.plan).StartedAt(tc.start).Build())
+			billing := testkit.NewFakeBillingClient()
+			interactor := NewInteractor(repo, testkit.NewFakeCreditBalances(), testkit.NewFakeReferrals(), testkit.NewFakeAuthentications(), adapters.StaticBillingResolver{Client: billing}, adapters.StaticPricing{}, adapters.HookChain{}, &testkit.RecordingAuthenticationRequests{}, domain.FixedClock{FixedTime: tc.now}, cycles, 0, domain.DefaultDunningSchedule, domain.ReferralReward{})
+
+			result, err := interactor.Execute(ctx, builders.DefaultSubscriptionID)
+
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				assert.Empty(t, billing.Calls())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantPeriod[0], result.Renewed.PeriodStart)
+			assert.Equal(t, tc.wantPeriod[1], result.Renewed.PeriodEnd)
+			assert.Len(t, billing.CallsTo(testkit.OpChargeCustomer), 1)
+		})
+	}
+}
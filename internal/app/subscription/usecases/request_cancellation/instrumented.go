@@ -0,0 +1,43 @@
+package request_cancellation
+
+import (
+	"context"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/metrics"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/instrument"
+)
+
+// UseCase is the request cancellation use case as seen by callers
+type UseCase interface {
+	Execute(ctx context.Context, subscriptionID string) (*Result, error)
+}
+
+var (
+	_ UseCase = (*Interactor)(nil)
+	_ UseCase = (*Instrumented)(nil)
+)
+
+// Instrumented decorates a UseCase with logs, metrics and a trace span per execution,
+// and counts the retention offers presented
+type Instrumented struct {
+	next UseCase
+	in   instrument.Instrumentation
+}
+
+// NewInstrumented wraps the given use case with instrumentation
+func NewInstrumented(next UseCase, in instrument.Instrumentation) *Instrumented {
+	return &Instrumented{next: next, in: in}
+}
+
+// Execute runs the wrapped use case
+func (d *Instrumented) Execute(ctx context.Context, subscriptionID string) (*Result, error) {
+	attrs := map[string]string{"subscription_id": subscriptionID}
+
+	result, err := instrument.Run(ctx, d.in, "request_cancellation", attrs, func(ctx context.Context) (*Result, error) {
+		return d.next.Execute(ctx, subscriptionID)
+	})
+	if err == nil && result.Offer != nil {
+		d.in.Metrics.IncCounter(metrics.RetentionOffers, map[string]string{"kind": string(result.Offer.Terms().Kind), "outcome": "presented"})
+	}
+	return result, err
+}
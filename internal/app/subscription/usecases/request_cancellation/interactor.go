@@ -0,0 +1,79 @@
+package request_cancellation
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+// Result is the retention offer to show the customer before they cancel. A nil Offer
+// means there is nothing to offer, and the cancellation can go ahead.
+type Result struct {
+	Offer *domain.RetentionOffer
+}
+
+// Interactor handles the request cancellation use case
+type Interactor struct {
+	repo   contracts.SubscriptionRepository
+	offers contracts.RetentionOfferRepository
+	engine contracts.RetentionOfferEngine
+	policy domain.RetentionOfferPolicy
+	clock  domain.Clock
+}
+
+// NewInteractor creates a new request cancellation interactor. Unset fields of the
+// policy take their defaults.
+func NewInteractor(repo contracts.SubscriptionRepository, offers contracts.RetentionOfferRepository, engine contracts.RetentionOfferEngine, policy domain.RetentionOfferPolicy, clock domain.Clock) *Interactor {
+	return &Interactor{
+		repo:   repo,
+		offers: offers,
+		engine: engine,
+		policy: policy.WithDefaults(),
+		clock:  clock,
+	}
+}
+
+// Execute asks the offer engine for an alternative to cancelling the subscription and
+// records the offer made. Nothing is cancelled here: the customer either accepts the
+// offer or declines it and cancels.
+func (i *Interactor) Execute(ctx context.Context, subscriptionID string) (*Result, error) {
+	// 1. Load subscription; only active subscriptions are made offers
+	sub, err := i.repo.FindByID(ctx, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	if sub.Status() != domain.StatusActive {
+		return &Result{}, nil
+	}
+
+	// 2. Skip subscriptions that took an offer within the cooldown, so an offer can't
+	// be had every period by asking to cancel
+	lastAccepted, err := i.offers.LastAcceptedAt(ctx, sub.ID())
+	if err != nil {
+		return nil, err
+	}
+	if !lastAccepted.IsZero() && i.clock.Now().Sub(lastAccepted) < i.policy.Cooldown {
+		return &Result{}, nil
+	}
+
+	// 3. Ask the engine's rules for an offer
+	terms, err := i.engine.OfferFor(ctx, sub)
+	if err != nil || terms == nil {
+		return &Result{}, err
+	}
+
+	// 4. Present it via domain constructor and record it
+	offer, err := domain.NewRetentionOffer(uuid.New().String(), sub, *terms, i.policy.TTL, i.clock)
+	if err != nil {
+		return nil, err
+	}
+	var uow contracts.UnitOfWork
+	uow.Save(i.offers.Save(ctx, offer))
+	if err := uow.Commit(ctx, i.offers); err != nil {
+		return nil, err
+	}
+
+	return &Result{Offer: offer}, nil
+}
@@ -0,0 +1,131 @@
+package request_cancellation
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/adapters"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/testkit"
+)
+
+// engineFunc adapts a function to RetentionOfferEngine
+type engineFunc func(sub *domain.Subscription) (*domain.RetentionOfferTerms, error)
+
+func (f engineFunc) OfferFor(ctx context.Context, sub *domain.Subscription) (*domain.RetentionOfferTerms, error) {
+	return f(sub)
+}
+
+var (
+	startDate = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	now       = time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)
+	halfOff   = adapters.DiscountOfferRule{Name: "half-off", PercentOff: 5000, Clock: domain.FixedClock{FixedTime: now}}
+)
+
+func newFixture(sub *domain.Subscription, engine contracts.RetentionOfferEngine) (*Interactor, *testkit.FakeRetentionOffers) {
+	repo := &testkit.MockSubscriptions{}
+	repo.On("FindByID", mock.Anything, "sub-123").Return(sub, nil)
+	offers := testkit.NewFakeRetentionOffers()
+	return NewInteractor(repo, offers, engine, domain.RetentionOfferPolicy{}, domain.FixedClock{FixedTime: now}), offers
+}
+
+func activeSubscription() *domain.Subscription {
+	return domain.ReconstructFromPersistence("sub-123", "cust-456", "plan-pro", 3000, domain.StatusActive, startDate)
+}
+
+func TestRequestCancellation_PresentsOffer(t *testing.T) {
+	interactor, offers := newFixture(activeSubscription(), halfOff)
+
+	result, err := interactor.Execute(context.Background(), "sub-123")
+
+	require.NoError(t, err)
+	require.NotNil(t, result.Offer)
+	assert.Equal(t, domain.RetentionOfferTerms{Rule: "half-off", Kind: domain.RetentionOfferDiscount, PercentOff: 5000}, result.Offer.Terms())
+	assert.Equal(t, domain.RetentionOfferPresented, result.Offer.Status())
+	assert.Equal(t, "plan-pro", result.Offer.PlanID())
+	assert.Equal(t, now.Add(domain.DefaultRetentionOfferTTL), result.Offer.ExpiresAt())
+	assert.Equal(t, []string{result.Offer.ID()}, offers.Saved())
+}
+
+func TestRequestCancellation_NothingToOffer(t *testing.T) {
+	interactor, offers := newFixture(activeSubscription(), adapters.RetentionOfferChain{})
+
+	result, err := interactor.Execute(context.Background(), "sub-123")
+
+	require.NoError(t, err)
+	assert.Nil(t, result.Offer)
+	assert.Empty(t, offers.Saved())
+}
+
+// acceptedOffer is an offer the subscription accepted at acceptedAt
+func acceptedOffer(acceptedAt time.Time) *domain.RetentionOffer {
+	terms := domain.RetentionOfferTerms{Rule: "half-off", Kind: domain.RetentionOfferDiscount, PercentOff: 5000}
+	presentedAt := acceptedAt.Add(-time.Hour)
+	return domain.ReconstructRetentionOffer("offer-1", "sub-123", "cust-456", "plan-pro", terms, domain.RetentionOfferAccepted, 1500,
+		presentedAt, presentedAt.Add(domain.DefaultRetentionOfferTTL), acceptedAt)
+}
+
+func TestRequestCancellation_NoOfferWithinCooldown(t *testing.T) {
+	interactor, offers := newFixture(activeSubscription(), halfOff)
+	offers.With(acceptedOffer(now.AddDate(0, -2, 0)))
+
+	result, err := interactor.Execute(context.Background(), "sub-123")
+
+	require.NoError(t, err)
+	assert.Nil(t, result.Offer)
+	assert.Empty(t, offers.Saved())
+}
+
+func TestRequestCancellation_OffersAgainAfterCooldown(t *testing.T) {
+	interactor, offers := newFixture(activeSubscription(), halfOff)
+	offers.With(acceptedOffer(now.AddDate(-1, 0, 0)))
+
+	result, err := interactor.Execute(context.Background(), "sub-123")
+
+	require.NoError(t, err)
+	assert.NotNil(t, result.Offer)
+}
+
+func TestRequestCancellation_NoOfferUnlessActive(t *testing.T) {
+	sub := domain.ReconstructFromPersistence("sub-123", "cust-456", "plan-pro", 3000, domain.StatusPastDue, startDate)
+	interactor, offers := newFixture(sub, halfOff)
+
+	result, err := interactor.Execute(context.Background(), "sub-123")
+
+	require.NoError(t, err)
+	assert.Nil(t, result.Offer)
+	assert.Empty(t, offers.Saved())
+}
+
+func TestRequestCancellation_RejectsInvalidOffers(t *testing.T) {
+	for name, terms := range map[string]domain.RetentionOfferTerms{
+		"discount over 100%":        {Rule: "r", Kind: domain.RetentionOfferDiscount, PercentOff: 12000},
+		"downgrade to same plan":    {Rule: "r", Kind: domain.RetentionOfferDowngrade, PlanID: "plan-pro", PriceCents: 1000},
+		"downgrade that costs more": {Rule: "r", Kind: domain.RetentionOfferDowngrade, PlanID: "plan-max", PriceCents: 9000},
+	} {
+		t.Run(name, func(t *testing.T) {
+			terms := terms
+			interactor, offers := newFixture(activeSubscription(), engineFunc(func(*domain.Subscription) (*domain.RetentionOfferTerms, error) { return &terms, nil }))
+
+			_, err := interactor.Execute(context.Background(), "sub-123")
+
+			assert.Error(t, err)
+			assert.Empty(t, offers.Saved())
+		})
+	}
+}
+
+func TestRequestCancellation_EngineFails(t *testing.T) {
+	failure := errors.New("rules unavailable")
+	interactor, _ := newFixture(activeSubscription(), engineFunc(func(*domain.Subscription) (*domain.RetentionOfferTerms, error) { return nil, failure }))
+
+	_, err := interactor.Execute(context.Background(), "sub-123")
+
+	assert.ErrorIs(t, err, failure)
+}
@@ -0,0 +1,48 @@
+package respond_to_retention_offer
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/metrics"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/instrument"
+)
+
+// UseCase is the respond to retention offer use case as seen by callers
+type UseCase interface {
+	Execute(ctx context.Context, req Request) (*Result, error)
+}
+
+var (
+	_ UseCase = (*Interactor)(nil)
+	_ UseCase = (*Instrumented)(nil)
+)
+
+// Instrumented decorates a UseCase with logs, metrics and a trace span per execution,
+// and counts the retention offers accepted and declined
+type Instrumented struct {
+	next UseCase
+	in   instrument.Instrumentation
+}
+
+// NewInstrumented wraps the given use case with instrumentation
+func NewInstrumented(next UseCase, in instrument.Instrumentation) *Instrumented {
+	return &Instrumented{next: next, in: in}
+}
+
+// Execute runs the wrapped use case
+func (d *Instrumented) Execute(ctx context.Context, req Request) (*Result, error) {
+	attrs := map[string]string{"offer_id": req.OfferID, "accept": strconv.FormatBool(req.Accept)}
+
+	result, err := instrument.Run(ctx, d.in, "respond_to_retention_offer", attrs, func(ctx context.Context) (*Result, error) {
+		return d.next.Execute(ctx, req)
+	})
+	if err == nil {
+		outcome := "declined"
+		if result.Accepted != nil {
+			outcome = "accepted"
+		}
+		d.in.Metrics.IncCounter(metrics.RetentionOffers, map[string]string{"kind": string(result.Offer.Terms().Kind), "outcome": outcome})
+	}
+	return result, err
+}
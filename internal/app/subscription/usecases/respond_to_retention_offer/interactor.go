@@ -0,0 +1,117 @@
+package respond_to_retention_offer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+// Request contains the customer's answer to a retention offer
+type Request struct {
+	OfferID string
+	Accept  bool
+}
+
+// Result is the answered offer, and what accepting it did
+type Result struct {
+	Offer    *domain.RetentionOffer
+	Accepted *domain.RetentionOfferAcceptedEvent // nil when declined
+}
+
+// Interactor handles the respond to retention offer use case
+type Interactor struct {
+	repo    contracts.SubscriptionRepository
+	offers  contracts.RetentionOfferRepository
+	credits contracts.CreditBalanceRepository
+	pricing contracts.PricingSource
+	hooks   contracts.SubscriptionHooks
+	clock   domain.Clock
+	cycles  contracts.BillingCycleSource
+}
+
+// NewInteractor creates a new respond to retention offer interactor
+func NewInteractor(repo contracts.SubscriptionRepository, offers contracts.RetentionOfferRepository, credits contracts.CreditBalanceRepository, pricing contracts.PricingSource, hooks contracts.SubscriptionHooks, clock domain.Clock, cycles contracts.BillingCycleSource) *Interactor {
+	return &Interactor{
+		repo:    repo,
+		offers:  offers,
+		credits: credits,
+		pricing: pricing,
+		hooks:   hooks,
+		clock:   clock,
+		cycles:  cycles,
+	}
+}
+
+// Execute records the customer's answer. Accepting applies the offer instead of
+// cancelling: a discount is granted to the credit balance, which the next renewal
+// spends, and a downgrade changes the plan, without crediting the rest of the period.
+// Declining only records the outcome; the caller goes on to cancel.
+func (i *Interactor) Execute(ctx context.Context, req Request) (*Result, error) {
+	// 1. Load offer
+	offer, err := i.offers.FindByID(ctx, req.OfferID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. A declined offer is saved alone
+	if !req.Accept {
+		if err := offer.Decline(i.clock); err != nil {
+			return nil, err
+		}
+		var uow contracts.UnitOfWork
+		uow.Save(i.offers.Save(ctx, offer))
+		if err := uow.Commit(ctx, i.offers); err != nil {
+			return nil, err
+		}
+		return &Result{Offer: offer}, nil
+	}
+
+	// 3. Load subscription and accept via domain method, on the discounted prices
+	sub, err := i.repo.FindByID(ctx, offer.SubscriptionID())
+	if err != nil {
+		return nil, err
+	}
+	pricing, err := i.pricing.PricingFor(ctx, sub)
+	if err != nil {
+		return nil, err
+	}
+	cycle, err := i.cycles.CycleFor(ctx, sub)
+	if err != nil {
+		return nil, err
+	}
+	event, err := offer.Accept(i.clock, sub, cycle, pricing)
+	if err != nil {
+		return nil, err
+	}
+
+	// 4. Let the deployment's hooks veto a downgrade, as with any plan change
+	if event.PlanChange != nil {
+		if err := i.hooks.BeforePlanChange(ctx, sub, event.PlanChange); err != nil {
+			return nil, fmt.Errorf("%w: %w", domain.ErrRejectedByHook, err)
+		}
+	}
+
+	// 5. Save the offer, the plan change or the credit granted
+	var uow contracts.UnitOfWork
+	uow.Save(i.offers.Save(ctx, offer))
+	if event.PlanChange != nil {
+		uow.SaveChecked(i.repo.Save(ctx, sub))
+	}
+	if event.CreditAmount > 0 {
+		entry := domain.NewCreditEntry(uuid.New().String(), sub.CustomerID(), event.CreditAmount, domain.DefaultCurrency, domain.CreditSourceRetention, offer.ID(), i.clock)
+		uow.Save(i.credits.Save(ctx, entry))
+	}
+
+	// 6. Commit the writes in one transaction
+	if err := uow.Commit(ctx, i.repo); err != nil {
+		return nil, err
+	}
+	if event.PlanChange != nil {
+		i.hooks.AfterPlanChange(ctx, sub, event.PlanChange)
+	}
+
+	return &Result{Offer: offer, Accepted: event}, nil
+}
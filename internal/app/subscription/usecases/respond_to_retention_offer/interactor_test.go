@@ -0,0 +1,159 @@
+package respond_to_retention_offer
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/adapters"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/testkit"
+)
+
+var (
+	startDate   = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	presentedAt = startDate.AddDate(0, 0, 10)
+)
+
+func activeSubscription() *domain.Subscription {
+	return domain.ReconstructFromPersistence("sub-123", "cust-456", "plan-pro", 3000, domain.StatusActive, startDate)
+}
+
+// presentedOffer is an offer of terms made at presentedAt, open for a day
+func presentedOffer(terms domain.RetentionOfferTerms) *domain.RetentionOffer {
+	return domain.ReconstructRetentionOffer("offer-1", "sub-123", "cust-456", "plan-pro", terms, domain.RetentionOfferPresented, 0,
+		presentedAt, presentedAt.Add(24*time.Hour), time.Time{})
+}
+
+var (
+	halfOff   = domain.RetentionOfferTerms{Rule: "half-off", Kind: domain.RetentionOfferDiscount, PercentOff: 5000}
+	downgrade = domain.RetentionOfferTerms{Rule: "lite", Kind: domain.RetentionOfferDowngrade, PlanID: "plan-lite", PriceCents: 1000}
+)
+
+type fixture struct {
+	repo    *testkit.MockSubscriptions
+	offers  *testkit.FakeRetentionOffers
+	credits *testkit.FakeCreditBalances
+	hooks   *testkit.RecordingHooks
+}
+
+// respondAt builds an interactor whose clock reads hoursAfter hours after the offer was made
+func (f *fixture) respondAt(hoursAfter int) *Interactor {
+	clock := domain.FixedClock{FixedTime: presentedAt.Add(time.Duration(hoursAfter) * time.Hour)}
+	return NewInteractor(f.repo, f.offers, f.credits, adapters.StaticPricing{}, f.hooks, clock, adapters.StaticBillingCycle{Cycle: domain.CycleOfDays(30)})
+}
+
+func newFixture(offer *domain.RetentionOffer) *fixture {
+	return &fixture{
+		repo:    new(testkit.MockSubscriptions),
+		offers:  testkit.NewFakeRetentionOffers().With(offer),
+		credits: testkit.NewFakeCreditBalances(),
+		hooks:   &testkit.RecordingHooks{},
+	}
+}
+
+func TestRespond_AcceptDiscountGrantsCredit(t *testing.T) {
+	f := newFixture(presentedOffer(halfOff))
+	f.repo.On("FindByID", mock.Anything, "sub-123").Return(activeSubscription(), nil)
+	f.repo.On("Apply", mock.Anything, mock.Anything).Return(nil)
+
+	result, err := f.respondAt(2).Execute(context.Background(), Request{OfferID: "offer-1", Accept: true})
+
+	require.NoError(t, err)
+	assert.Equal(t, domain.RetentionOfferAccepted, result.Offer.Status())
+	assert.Equal(t, int64(1500), result.Offer.CreditAmount())
+	assert.Equal(t, int64(1500), result.Accepted.CreditAmount)
+	assert.Nil(t, result.Accepted.PlanChange)
+
+	entries := f.credits.Entries()
+	require.Len(t, entries, 1)
+	assert.Equal(t, int64(1500), entries[0].Amount())
+	assert.Equal(t, domain.CreditSourceRetention, entries[0].Source())
+	assert.Equal(t, "offer-1", entries[0].SourceID())
+	f.repo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+	assert.Empty(t, f.hooks.Calls())
+}
+
+func TestRespond_AcceptDowngradeChangesPlan(t *testing.T) {
+	f := newFixture(presentedOffer(downgrade))
+	f.repo.On("FindByID", mock.Anything, "sub-123").Return(activeSubscription(), nil)
+	f.repo.On("Save", mock.Anything, mock.Anything).Return(&spanner.Mutation{}, nil)
+	f.repo.On("Apply", mock.Anything, mock.Anything).Return(nil)
+
+	result, err := f.respondAt(2).Execute(context.Background(), Request{OfferID: "offer-1", Accept: true})
+
+	require.NoError(t, err)
+	require.NotNil(t, result.Accepted.PlanChange)
+	assert.Equal(t, "plan-pro", result.Accepted.PlanChange.OldPlanID)
+	assert.Equal(t, "plan-lite", result.Accepted.PlanChange.NewPlanID)
+	f.repo.AssertCalled(t, "Save", mock.Anything, mock.MatchedBy(func(sub *domain.Subscription) bool {
+		return sub.PlanID() == "plan-lite" && sub.Price() == 1000
+	}))
+	assert.Empty(t, f.credits.Entries())
+	assert.Equal(t, []string{"BeforePlanChange", "AfterPlanChange"}, f.hooks.Calls())
+}
+
+func TestRespond_DeclineRecordsOutcome(t *testing.T) {
+	f := newFixture(presentedOffer(halfOff))
+
+	result, err := f.respondAt(2).Execute(context.Background(), Request{OfferID: "offer-1"})
+
+	require.NoError(t, err)
+	assert.Equal(t, domain.RetentionOfferDeclined, result.Offer.Status())
+	assert.Equal(t, presentedAt.Add(2*time.Hour), result.Offer.RespondedAt())
+	assert.Nil(t, result.Accepted)
+	assert.Equal(t, []string{"offer-1"}, f.offers.Saved())
+	f.repo.AssertNotCalled(t, "FindByID", mock.Anything, mock.Anything)
+}
+
+func TestRespond_ExpiredOffer(t *testing.T) {
+	for _, accept := range []bool{true, false} {
+		f := newFixture(presentedOffer(halfOff))
+		f.repo.On("FindByID", mock.Anything, "sub-123").Return(activeSubscription(), nil)
+
+		_, err := f.respondAt(24).Execute(context.Background(), Request{OfferID: "offer-1", Accept: accept})
+
+		assert.ErrorIs(t, err, domain.ErrRetentionOfferExpired)
+		assert.Empty(t, f.offers.Saved())
+		assert.Empty(t, f.credits.Entries())
+	}
+}
+
+func TestRespond_OfferAlreadyAnswered(t *testing.T) {
+	declined := domain.ReconstructRetentionOffer("offer-1", "sub-123", "cust-456", "plan-pro", halfOff, domain.RetentionOfferDeclined, 0,
+		presentedAt, presentedAt.Add(24*time.Hour), presentedAt.Add(time.Hour))
+	f := newFixture(declined)
+	f.repo.On("FindByID", mock.Anything, "sub-123").Return(activeSubscription(), nil)
+
+	_, err := f.respondAt(2).Execute(context.Background(), Request{OfferID: "offer-1", Accept: true})
+
+	assert.ErrorIs(t, err, domain.ErrRetentionOfferResolved)
+	assert.Empty(t, f.credits.Entries())
+}
+
+func TestRespond_UnknownOffer(t *testing.T) {
+	f := newFixture(presentedOffer(halfOff))
+
+	_, err := f.respondAt(2).Execute(context.Background(), Request{OfferID: "offer-2", Accept: true})
+
+	assert.ErrorIs(t, err, domain.ErrRetentionOfferNotFound)
+}
+
+func TestRespond_HookVetoesDowngrade(t *testing.T) {
+	veto := errors.New("plan-lite is being retired")
+	f := newFixture(presentedOffer(downgrade))
+	f.hooks.Veto = veto
+	f.repo.On("FindByID", mock.Anything, "sub-123").Return(activeSubscription(), nil)
+
+	_, err := f.respondAt(2).Execute(context.Background(), Request{OfferID: "offer-1", Accept: true})
+
+	assert.ErrorIs(t, err, domain.ErrRejectedByHook)
+	assert.ErrorIs(t, err, veto)
+	assert.Empty(t, f.offers.Saved())
+	f.repo.AssertNotCalled(t, "Apply", mock.Anything, mock.Anything)
+}
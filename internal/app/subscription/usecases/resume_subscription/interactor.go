@@ -0,0 +1,56 @@
+package resume_subscription
+
+import (
+	"context"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+// Interactor handles the resume subscription use case
+type Interactor struct {
+	repo   contracts.SubscriptionRepository
+	clock  domain.Clock
+	cycles contracts.BillingCycleSource
+}
+
+// NewInteractor creates a new resume subscription interactor
+func NewInteractor(repo contracts.SubscriptionRepository, clock domain.Clock, cycles contracts.BillingCycleSource) *Interactor {
+	return &Interactor{
+		repo:   repo,
+		clock:  clock,
+		cycles: cycles,
+	}
+}
+
+// Execute resumes a paused subscription. The period it was paused in is extended by
+// the time paused, so the customer gets the rest of what they paid for and the next
+// renewal, and any refund on cancelling, are prorated from there. Nothing is charged.
+func (i *Interactor) Execute(ctx context.Context, subscriptionID string) (*domain.SubscriptionResumedEvent, error) {
+	// 1. Load subscription
+	sub, err := i.repo.FindByID(ctx, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. Resume via domain method (rejects subscriptions that are not paused)
+	cycle, err := i.cycles.CycleFor(ctx, sub)
+	if err != nil {
+		return nil, err
+	}
+	event, err := sub.Resume(i.clock, cycle)
+	if err != nil {
+		return nil, err
+	}
+
+	// 3. Save the resumed subscription
+	var uow contracts.UnitOfWork
+	uow.SaveChecked(i.repo.Save(ctx, sub))
+
+	// 4. Commit the write
+	if err := uow.Commit(ctx, i.repo); err != nil {
+		return nil, err
+	}
+
+	return event, nil
+}
@@ -0,0 +1,96 @@
+package resume_subscription
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/adapters"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/testkit"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/testkit/builders"
+)
+
+func TestResumeSubscription_ExtendsPeriodByTimePaused(t *testing.T) {
+	ctx := context.Background()
+	pauseDate := builders.DefaultStartDate.AddDate(0, 0, 10)
+	resumeDate := pauseDate.AddDate(0, 0, 15)
+	repo := testkit.NewFakeSubscriptions().With(builders.NewSubscriptionBuilder().PausedAt(pauseDate).Build())
+
+	event, err := NewInteractor(repo, domain.FixedClock{FixedTime: resumeDate}, adapters.StaticBillingCycle{Cycle: domain.CycleOfDays(30)}).Execute(ctx, builders.DefaultSubscriptionID)
+	require.NoError(t, err)
+	assert.Equal(t, pauseDate, event.PausedAt)
+	assert.Equal(t, resumeDate, event.ResumedAt)
+	assert.Equal(t, builders.DefaultStartDate, event.PeriodStart)
+	assert.Equal(t, builders.DefaultStartDate.AddDate(0, 0, 45), event.PeriodEnd)
+
+	saved, err := repo.FindByID(ctx, builders.DefaultSubscriptionID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.StatusActive, saved.Status())
+	assert.True(t, saved.PausedAt().IsZero())
+	// The period keeps its start, which keys its charges, and ends later instead
+	assert.Equal(t, builders.DefaultStartDate, saved.CurrentPeriodStart())
+	assert.Equal(t, event.PeriodEnd, saved.RecordedPeriodEnd())
+}
+
+func TestResumeSubscription_KeepsDaysLeftAcrossMonths(t *testing.T) {
+	ctx := context.Background()
+	monthly := domain.BillingCycle{Interval: domain.IntervalMonth}
+	periodStart := time.Date(2025, 1, 20, 0, 0, 0, 0, time.UTC)
+	pauseDate := time.Date(2025, 1, 25, 0, 0, 0, 0, time.UTC)
+	resumeDate := pauseDate.AddDate(0, 0, 10)
+	repo := testkit.NewFakeSubscriptions().With(builders.NewSubscriptionBuilder().InPeriodFrom(periodStart).PausedAt(pauseDate).Build())
+
+	sub, err := repo.FindByID(ctx, builders.DefaultSubscriptionID)
+	require.NoError(t, err)
+	require.Equal(t, int64(26), sub.DaysRemaining(domain.FixedClock{FixedTime: pauseDate}, monthly))
+
+	event, err := NewInteractor(repo, domain.FixedClock{FixedTime: resumeDate}, adapters.StaticBillingCycle{Cycle: monthly}).Execute(ctx, builders.DefaultSubscriptionID)
+	require.NoError(t, err)
+	// 20 Feb, when the period would have ended, pushed out by the 10 days paused
+	assert.Equal(t, time.Date(2025, 3, 2, 0, 0, 0, 0, time.UTC), event.PeriodEnd)
+
+	saved, err := repo.FindByID(ctx, builders.DefaultSubscriptionID)
+	require.NoError(t, err)
+	assert.Equal(t, periodStart, saved.CurrentPeriodStart())
+	assert.Equal(t, int64(26), saved.DaysRemaining(domain.FixedClock{FixedTime: resumeDate}, monthly))
+}
+
+func TestResumeSubscription_CancellingAfterResumeIgnoresTimePaused(t *testing.T) {
+	ctx := context.Background()
+	pauseDate := builders.DefaultStartDate.AddDate(0, 0, 10)
+	resumeDate := pauseDate.AddDate(0, 0, 15)
+	repo := testkit.NewFakeSubscriptions().With(builders.NewSubscriptionBuilder().PausedAt(pauseDate).Build())
+
+	_, err := NewInteractor(repo, domain.FixedClock{FixedTime: resumeDate}, adapters.StaticBillingCycle{Cycle: domain.CycleOfDays(30)}).Execute(ctx, builders.DefaultSubscriptionID)
+	require.NoError(t, err)
+
+	sub, err := repo.FindByID(ctx, builders.DefaultSubscriptionID)
+	require.NoError(t, err)
+	event, err := sub.Cancel(domain.FixedClock{FixedTime: resumeDate.AddDate(0, 0, 5)}, 30)
+	require.NoError(t, err)
+
+	// 10 days before the pause and 5 after the resume: 15 of 30 used
+	assert.Equal(t, int64(1500), event.RefundAmount)
+}
+
+func TestResumeSubscription_ClockBehindPauseLeavesPeriod(t *testing.T) {
+	ctx := context.Background()
+	pauseDate := builders.DefaultStartDate.AddDate(0, 0, 10)
+	repo := testkit.NewFakeSubscriptions().With(builders.NewSubscriptionBuilder().PausedAt(pauseDate).Build())
+
+	event, err := NewInteractor(repo, domain.FixedClock{FixedTime: pauseDate.Add(-time.Hour)}, adapters.StaticBillingCycle{Cycle: domain.CycleOfDays(30)}).Execute(ctx, builders.DefaultSubscriptionID)
+	require.NoError(t, err)
+	assert.Equal(t, builders.DefaultStartDate, event.PeriodStart)
+	assert.Equal(t, builders.DefaultStartDate.AddDate(0, 0, 30), event.PeriodEnd)
+}
+
+func TestResumeSubscription_RejectsSubscriptionsThatAreNotPaused(t *testing.T) {
+	ctx := context.Background()
+	repo := testkit.NewFakeSubscriptions().With(builders.NewSubscriptionBuilder().Build())
+
+	_, err := NewInteractor(repo, domain.FixedClock{FixedTime: builders.DefaultStartDate}, adapters.StaticBillingCycle{Cycle: domain.CycleOfDays(30)}).Execute(ctx, builders.DefaultSubscriptionID)
+	assert.ErrorIs(t, err, domain.ErrNotPaused)
+}
@@ -0,0 +1,122 @@
+package retry_payment
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+// Result describes the outcome of a payment retry; exactly one of Recovered or
+// RetryFailed is set, and Expired is set when the failed retry was the last one
+type Result struct {
+	Recovered   *domain.SubscriptionRecoveredEvent
+	RetryFailed *domain.PaymentRetryFailedEvent
+	Expired     *domain.SubscriptionExpiredEvent
+	ChargeError error
+}
+
+// Interactor handles the retry payment use case for past-due subscriptions
+type Interactor struct {
+	repo     contracts.SubscriptionRepository
+	credits  contracts.CreditBalanceRepository
+	billing  contracts.BillingResolver
+	pricing  contracts.PricingSource
+	clock    domain.Clock
+	schedule domain.DunningSchedule
+}
+
+// NewInteractor creates a new retry payment interactor
+func NewInteractor(repo contracts.SubscriptionRepository, credits contracts.CreditBalanceRepository, billing contracts.BillingResolver, pricing contracts.PricingSource, clock domain.Clock, schedule domain.DunningSchedule) *Interactor {
+	return &Interactor{
+		repo:     repo,
+		credits:  credits,
+		billing:  billing,
+		pricing:  pricing,
+		clock:    clock,
+		schedule: schedule,
+	}
+}
+
+// Execute re-attempts the outstanding charge of a past-due subscription
+func (i *Interactor) Execute(ctx context.Context, subscriptionID string) (*Result, error) {
+	// 1. Load subscription
+	sub, err := i.repo.FindByID(ctx, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if sub.Status() != domain.StatusPastDue {
+		return nil, domain.ErrNotPastDue
+	}
+	if !sub.PaymentRetryDue(i.clock) {
+		return nil, domain.ErrPaymentRetryNotDue
+	}
+
+	// 2. Spend the customer's credit balance first on the discounted price; only the rest is charged
+	pricing, err := i.pricing.PricingFor(ctx, sub)
+	if err != nil {
+		return nil, err
+	}
+	balance, err := i.credits.Balance(ctx, sub.CustomerID())
+	if err != nil {
+		return nil, err
+	}
+	covered, due := domain.CoverWithCredit(balance, sub.PeriodPrice(pricing).Net)
+
+	// 3. Re-attempt the charge; the key is unique per attempt so the billing API
+	// deduplicates a retried attempt but not the next scheduled one
+	var chargeErr error
+	if due > 0 {
+		billingClient, err := i.billing.Resolve(ctx, sub.PlanID(), sub.CustomerID())
+		if err != nil {
+			return nil, err
+		}
+		chargeErr = billingClient.ChargeCustomer(ctx, contracts.ChargeRequest{
+			CustomerID:     sub.CustomerID(),
+			SubscriptionID: sub.ID(),
+			Amount:         due,
+			Currency:       domain.DefaultCurrency,
+			IdempotencyKey: fmt.Sprintf("%s:%d:retry-%d", sub.ID(), sub.CurrentPeriodStart().Unix(), sub.DunningAttempts()+1),
+		})
+	}
+
+	// 4. Update dunning state via domain methods; the credit is only spent on recovery
+	result := &Result{ChargeError: chargeErr}
+	var creditEntry *domain.CreditEntry
+	if chargeErr == nil {
+		if result.Recovered, err = sub.RecoverPayment(i.clock, pricing); err != nil {
+			return nil, err
+		}
+		if covered > 0 {
+			result.Recovered.CreditApplied = covered
+			sourceID := fmt.Sprintf("%s:%d", sub.ID(), sub.CurrentPeriodStart().Unix())
+			creditEntry = domain.NewCreditEntry(uuid.New().String(), sub.CustomerID(), -covered, domain.DefaultCurrency, domain.CreditSourcePaymentRetry, sourceID, i.clock)
+		}
+	} else {
+		if result.RetryFailed, err = sub.RecordFailedPaymentRetry(i.clock, i.schedule); err != nil {
+			return nil, err
+		}
+		if sub.DunningExhausted(i.schedule) {
+			if result.Expired, err = sub.Expire(i.clock); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// 5. Save the updated subscription and the credit it spent
+	var uow contracts.UnitOfWork
+	uow.SaveChecked(i.repo.Save(ctx, sub))
+	if creditEntry != nil {
+		uow.Save(i.credits.Save(ctx, creditEntry))
+	}
+
+	// 6. Commit the writes
+	if err := uow.Commit(ctx, i.repo); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
@@ -0,0 +1,205 @@
+package retry_payment
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/adapters"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/testkit"
+)
+
+// MockBillingClient is a mock implementation of BillingClient
+type MockBillingClient struct {
+	mock.Mock
+}
+
+func (m *MockBillingClient) ValidateCustomer(ctx context.Context, customerID string) error {
+	args := m.Called(ctx, customerID)
+	return args.Error(0)
+}
+
+func (m *MockBillingClient) CreateCustomer(ctx context.Context, req contracts.CreateCustomerRequest) error {
+	args := m.Called(ctx, req)
+	return args.Error(0)
+}
+
+func (m *MockBillingClient) ProcessRefund(ctx context.Context, req contracts.RefundRequest) (string, error) {
+	args := m.Called(ctx, req)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockBillingClient) GetRefundStatus(ctx context.Context, providerRefundID string) (contracts.RefundOutcome, error) {
+	args := m.Called(ctx, providerRefundID)
+	return args.Get(0).(contracts.RefundOutcome), args.Error(1)
+}
+
+func (m *MockBillingClient) ChargeCustomer(ctx context.Context, req contracts.ChargeRequest) error {
+	args := m.Called(ctx, req)
+	return args.Error(0)
+}
+
+func (m *MockBillingClient) GetPaymentMethodStatus(ctx context.Context, customerID string) (domain.PaymentMethod, error) {
+	args := m.Called(ctx, customerID)
+	return args.Get(0).(domain.PaymentMethod), args.Error(1)
+}
+
+var (
+	startDate = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	retryDate = time.Date(2024, 2, 2, 0, 0, 0, 0, time.UTC)
+	schedule  = domain.DunningSchedule{24 * time.Hour, 72 * time.Hour, 72 * time.Hour}
+)
+
+func pastDueSubscription(attempts int64) *domain.Subscription {
+	return domain.ReconstructFromPersistence(
+		"sub-123",
+		"cust-456",
+		"plan-789",
+		3000,
+		domain.StatusPastDue,
+		startDate,
+		domain.WithDunning(attempts, retryDate),
+	)
+}
+
+func TestRetryPayment_RecoversOnSuccessfulCharge(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(testkit.MockSubscriptions)
+	mockBilling := new(MockBillingClient)
+	interactor := NewInteractor(mockRepo, testkit.NewFakeCreditBalances(), adapters.StaticBillingResolver{Client: mockBilling}, adapters.StaticPricing{}, domain.FixedClock{FixedTime: retryDate}, schedule)
+
+	mockRepo.On("FindByID", ctx, "sub-123").Return(pastDueSubscription(0), nil)
+	mockBilling.On("ChargeCustomer", ctx, mock.MatchedBy(func(req contracts.ChargeRequest) bool {
+		return req.SubscriptionID == "sub-123" && req.Amount == 3000 && req.IdempotencyKey != ""
+	})).Return(nil)
+	mockRepo.On("Save", ctx, mock.MatchedBy(func(s *domain.Subscription) bool {
+		return s.Status() == domain.StatusActive && s.DunningAttempts() == 0
+	})).Return(&spanner.Mutation{}, nil)
+	mockRepo.On("Apply", ctx, mock.Anything).Return(nil)
+
+	result, err := interactor.Execute(ctx, "sub-123")
+
+	require.NoError(t, err)
+	require.NotNil(t, result.Recovered)
+	assert.Equal(t, int64(1), result.Recovered.Attempts)
+	assert.Nil(t, result.RetryFailed)
+	assert.Nil(t, result.Expired)
+	mockRepo.AssertExpectations(t)
+	mockBilling.AssertExpectations(t)
+}
+
+func TestRetryPayment_SchedulesNextRetryOnFailure(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(testkit.MockSubscriptions)
+	mockBilling := new(MockBillingClient)
+	interactor := NewInteractor(mockRepo, testkit.NewFakeCreditBalances(), adapters.StaticBillingResolver{Client: mockBilling}, adapters.StaticPricing{}, domain.FixedClock{FixedTime: retryDate}, schedule)
+
+	chargeErr := errors.New("card declined")
+	mockRepo.On("FindByID", ctx, "sub-123").Return(pastDueSubscription(0), nil)
+	mockBilling.On("ChargeCustomer", ctx, mock.Anything).Return(chargeErr)
+	mockRepo.On("Save", ctx, mock.MatchedBy(func(s *domain.Subscription) bool {
+		return s.Status() == domain.StatusPastDue && s.DunningAttempts() == 1
+	})).Return(&spanner.Mutation{}, nil)
+	mockRepo.On("Apply", ctx, mock.Anything).Return(nil)
+
+	result, err := interactor.Execute(ctx, "sub-123")
+
+	require.NoError(t, err)
+	require.NotNil(t, result.RetryFailed)
+	assert.Equal(t, retryDate.Add(72*time.Hour), result.RetryFailed.NextPaymentRetryAt)
+	assert.Nil(t, result.Expired)
+	assert.Equal(t, chargeErr, result.ChargeError)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestRetryPayment_ExpiresAfterFinalFailure(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(testkit.MockSubscriptions)
+	mockBilling := new(MockBillingClient)
+	interactor := NewInteractor(mockRepo, testkit.NewFakeCreditBalances(), adapters.StaticBillingResolver{Client: mockBilling}, adapters.StaticPricing{}, domain.FixedClock{FixedTime: retryDate}, schedule)
+
+	mockRepo.On("FindByID", ctx, "sub-123").Return(pastDueSubscription(2), nil)
+	mockBilling.On("ChargeCustomer", ctx, mock.Anything).Return(errors.New("card declined"))
+	mockRepo.On("Save", ctx, mock.MatchedBy(func(s *domain.Subscription) bool {
+		return s.Status() == domain.StatusCancelled
+	})).Return(&spanner.Mutation{}, nil)
+	mockRepo.On("Apply", ctx, mock.Anything).Return(nil)
+
+	result, err := interactor.Execute(ctx, "sub-123")
+
+	require.NoError(t, err)
+	require.NotNil(t, result.Expired)
+	assert.Equal(t, int64(3), result.Expired.Attempts)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestRetryPayment_NotDue(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(testkit.MockSubscriptions)
+	mockBilling := new(MockBillingClient)
+	interactor := NewInteractor(mockRepo, testkit.NewFakeCreditBalances(), adapters.StaticBillingResolver{Client: mockBilling}, adapters.StaticPricing{}, domain.FixedClock{FixedTime: retryDate.Add(-time.Hour)}, schedule)
+
+	mockRepo.On("FindByID", ctx, "sub-123").Return(pastDueSubscription(0), nil)
+
+	result, err := interactor.Execute(ctx, "sub-123")
+
+	assert.Equal(t, domain.ErrPaymentRetryNotDue, err)
+	assert.Nil(t, result)
+	mockBilling.AssertNotCalled(t, "ChargeCustomer", ctx, mock.Anything)
+}
+
+func TestRetryPayment_SpendsCreditBalanceOnRecovery(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(testkit.MockSubscriptions)
+	mockBilling := new(MockBillingClient)
+	credits := testkit.NewFakeCreditBalances().Grant("cust-456", 500)
+	interactor := NewInteractor(mockRepo, credits, adapters.StaticBillingResolver{Client: mockBilling}, adapters.StaticPricing{}, domain.FixedClock{FixedTime: retryDate}, schedule)
+
+	mockRepo.On("FindByID", ctx, "sub-123").Return(pastDueSubscription(0), nil)
+	mockBilling.On("ChargeCustomer", ctx, mock.MatchedBy(func(req contracts.ChargeRequest) bool {
+		return req.Amount == 2500
+	})).Return(nil)
+	mockRepo.On("Save", ctx, mock.Anything).Return(&spanner.Mutation{}, nil)
+	mockRepo.On("Apply", ctx, mock.MatchedBy(func(m []*spanner.Mutation) bool { return len(m) == 2 })).Return(nil)
+
+	result, err := interactor.Execute(ctx, "sub-123")
+
+	require.NoError(t, err)
+	require.NotNil(t, result.Recovered)
+	assert.Equal(t, int64(500), result.Recovered.CreditApplied)
+	entries := credits.Entries()
+	require.Len(t, entries, 1)
+	assert.Equal(t, int64(-500), entries[0].Amount())
+	assert.Equal(t, domain.CreditSourcePaymentRetry, entries[0].Source())
+	mockRepo.AssertExpectations(t)
+	mockBilling.AssertExpectations(t)
+}
+
+func TestRetryPayment_ChargesDiscountedPrice(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(testkit.MockSubscriptions)
+	mockBilling := new(MockBillingClient)
+	pricing := adapters.StaticPricing{Pricing: domain.Pricing{Discounts: []domain.Discount{{Code: "SAVE5", AmountOff: 500}}}}
+	interactor := NewInteractor(mockRepo, testkit.NewFakeCreditBalances(), adapters.StaticBillingResolver{Client: mockBilling}, pricing, domain.FixedClock{FixedTime: retryDate}, schedule)
+
+	mockRepo.On("FindByID", ctx, "sub-123").Return(pastDueSubscription(0), nil)
+	mockBilling.On("ChargeCustomer", ctx, mock.MatchedBy(func(req contracts.ChargeRequest) bool {
+		return req.Amount == 2500
+	})).Return(nil)
+	mockRepo.On("Save", ctx, mock.Anything).Return(&spanner.Mutation{}, nil)
+	mockRepo.On("Apply", ctx, mock.Anything).Return(nil)
+
+	result, err := interactor.Execute(ctx, "sub-123")
+
+	require.NoError(t, err)
+	require.NotNil(t, result.Recovered)
+	assert.Equal(t, int64(2500), result.Recovered.AmountPaid)
+	mockBilling.AssertExpectations(t)
+}
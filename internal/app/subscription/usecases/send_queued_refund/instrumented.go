@@ -0,0 +1,38 @@
+package send_queued_refund
+
+import (
+	"context"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/instrument"
+)
+
+// UseCase is the send queued refund use case as seen by callers
+type UseCase interface {
+	Execute(ctx context.Context, queuedRefundID string) (*domain.Refund, error)
+}
+
+var (
+	_ UseCase = (*Interactor)(nil)
+	_ UseCase = (*Instrumented)(nil)
+)
+
+// Instrumented decorates a UseCase with logs, metrics and a trace span per execution
+type Instrumented struct {
+	next UseCase
+	in   instrument.Instrumentation
+}
+
+// NewInstrumented wraps the given use case with instrumentation
+func NewInstrumented(next UseCase, in instrument.Instrumentation) *Instrumented {
+	return &Instrumented{next: next, in: in}
+}
+
+// Execute runs the wrapped use case
+func (d *Instrumented) Execute(ctx context.Context, queuedRefundID string) (*domain.Refund, error) {
+	attrs := map[string]string{"queued_refund_id": queuedRefundID}
+
+	return instrument.Run(ctx, d.in, "send_queued_refund", attrs, func(ctx context.Context) (*domain.Refund, error) {
+		return d.next.Execute(ctx, queuedRefundID)
+	})
+}
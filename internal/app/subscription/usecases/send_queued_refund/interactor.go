@@ -0,0 +1,121 @@
+package send_queued_refund
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+// Delays before sending a refund again after the provider rejected or missed an
+// attempt, doubling with each failure
+const (
+	minRetryDelay = time.Minute
+	maxRetryDelay = time.Hour
+)
+
+// maxAttempts is how many times a refund is sent before it is failed for good and left
+// to be refunded by hand. With the delays above, the last attempt is about 18 hours
+// after the first, long enough to outlast a provider outage.
+const maxAttempts = 24
+
+// Interactor handles the send queued refund use case
+type Interactor struct {
+	outbox  contracts.RefundOutboxRepository
+	refunds contracts.RefundRepository
+	billing contracts.BillingResolver
+	clock   domain.Clock
+}
+
+// NewInteractor creates a new send queued refund interactor
+func NewInteractor(outbox contracts.RefundOutboxRepository, refunds contracts.RefundRepository, billing contracts.BillingResolver, clock domain.Clock) *Interactor {
+	return &Interactor{
+		outbox:  outbox,
+		refunds: refunds,
+		billing: billing,
+		clock:   clock,
+	}
+}
+
+// Execute sends a queued refund to the billing provider and, once the provider has
+// accepted it, tracks it as a pending refund in place of the queued one. A failed
+// attempt is recorded and the refund is tried again later, until maxAttempts have
+// failed: the refund is then marked FAILED, is no longer sent, and the error wraps
+// domain.ErrQueuedRefundFailed. The idempotency key is the
+// one the cancellation would have used, so an attempt whose outcome was lost is not
+// refunded twice.
+func (i *Interactor) Execute(ctx context.Context, queuedRefundID string) (*domain.Refund, error) {
+	// 1. Load the queued refund
+	queued, err := i.outbox.FindByID(ctx, queuedRefundID)
+	if err != nil {
+		return nil, err
+	}
+	if queued.Status() == domain.QueuedRefundFailed {
+		return nil, domain.ErrQueuedRefundFailed
+	}
+
+	// 2. Send it to the provider that billed the subscription
+	providerRefundID, err := i.send(ctx, queued)
+	if err != nil {
+		if queued.Attempts()+1 >= maxAttempts {
+			queued.Fail(err.Error())
+			err = fmt.Errorf("%w: %w", domain.ErrQueuedRefundFailed, err)
+		} else {
+			queued.Postpone(i.clock, err.Error(), retryDelay(queued.Attempts()))
+		}
+		var uow contracts.UnitOfWork
+		uow.Save(i.outbox.Save(ctx, queued))
+		if saveErr := uow.Commit(ctx, i.outbox); saveErr != nil {
+			return nil, fmt.Errorf("%w (and recording the attempt: %w)", err, saveErr)
+		}
+		return nil, err
+	}
+
+	// 3. Track the accepted refund and drop it from the outbox in one commit
+	refund := queued.Sent(uuid.New().String(), providerRefundID, i.clock)
+	var uow contracts.UnitOfWork
+	uow.Save(i.refunds.Save(ctx, refund))
+	uow.Save(i.outbox.Delete(ctx, queued.ID()))
+	if err := uow.Commit(ctx, i.refunds); err != nil {
+		return nil, err
+	}
+
+	return refund, nil
+}
+
+// send asks the provider to refund the queued amount and returns its refund ID
+func (i *Interactor) send(ctx context.Context, queued *domain.QueuedRefund) (string, error) {
+	billingClient, err := i.billing.Resolve(ctx, queued.PlanID(), queued.CustomerID())
+	if err != nil {
+		return "", err
+	}
+	reason := queued.Reason()
+	if reason == "" {
+		// Queued before reasons were recorded, when only cancellations queued refunds
+		reason = contracts.RefundReasonCancellation
+	}
+	return billingClient.ProcessRefund(ctx, contracts.RefundRequest{
+		SubscriptionID: queued.SubscriptionID(),
+		CustomerID:     queued.CustomerID(),
+		Amount:         queued.Amount(),
+		Currency:       queued.Currency(),
+		Reason:         reason,
+		CorrelationID:  queued.CorrelationID(),
+		IdempotencyKey: queued.IdempotencyKey(),
+	})
+}
+
+// retryDelay is how long to wait after the given number of earlier failures
+func retryDelay(failures int64) time.Duration {
+	delay := minRetryDelay
+	for n := int64(0); n < failures && delay < maxRetryDelay; n++ {
+		delay *= 2
+	}
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	return delay
+}
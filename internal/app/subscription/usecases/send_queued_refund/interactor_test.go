@@ -0,0 +1,120 @@
+package send_queued_refund
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/adapters"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/testkit"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/testkit/builders"
+)
+
+var queuedAt = time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+
+func queue(outbox *testkit.FakeRefundOutbox) *domain.QueuedRefund {
+	sub := builders.NewSubscriptionBuilder().Build()
+	queued := domain.NewQueuedRefund("queued-1", sub, 1600, domain.DefaultCurrency, contracts.RefundReasonCancellation, "sub-123:1704067200:refund", "corr-1", domain.FixedClock{FixedTime: queuedAt})
+	outbox.Save(context.Background(), queued)
+	return queued
+}
+
+func TestSendQueuedRefund_TracksTheAcceptedRefund(t *testing.T) {
+	outbox := testkit.NewFakeRefundOutbox()
+	refunds := testkit.NewFakeRefunds()
+	billing := testkit.NewFakeBillingClient()
+	queue(outbox)
+	interactor := NewInteractor(outbox, refunds, adapters.StaticBillingResolver{Client: billing}, domain.FixedClock{FixedTime: queuedAt.Add(time.Minute)})
+
+	refund, err := interactor.Execute(context.Background(), "queued-1")
+
+	require.NoError(t, err)
+	assert.Equal(t, domain.RefundPending, refund.Status())
+	assert.Equal(t, "fake-refund-1", refund.ProviderRefundID())
+	assert.Equal(t, int64(1600), refund.Amount())
+	assert.Equal(t, []string{refund.ID()}, refunds.Saved())
+	assert.Empty(t, outbox.All(), "a sent refund leaves the outbox")
+
+	calls := billing.CallsTo(testkit.OpProcessRefund)
+	require.Len(t, calls, 1)
+	assert.Equal(t, contracts.RefundRequest{
+		SubscriptionID: builders.DefaultSubscriptionID,
+		CustomerID:     builders.DefaultCustomerID,
+		Amount:         1600,
+		Currency:       domain.DefaultCurrency,
+		Reason:         contracts.RefundReasonCancellation,
+		CorrelationID:  "corr-1",
+		IdempotencyKey: "sub-123:1704067200:refund",
+	}, calls[0].Refund)
+}
+
+func TestSendQueuedRefund_FailedAttemptIsPostponedWithBackoff(t *testing.T) {
+	outbox := testkit.NewFakeRefundOutbox()
+	unavailable := errors.New("billing unavailable")
+	billing := testkit.NewFakeBillingClient().FailAlways(testkit.OpProcessRefund, unavailable)
+	queue(outbox)
+	now := queuedAt.Add(time.Minute)
+	interactor := NewInteractor(outbox, testkit.NewFakeRefunds(), adapters.StaticBillingResolver{Client: billing}, domain.FixedClock{FixedTime: now})
+
+	_, err := interactor.Execute(context.Background(), "queued-1")
+	assert.ErrorIs(t, err, unavailable)
+	_, err = interactor.Execute(context.Background(), "queued-1")
+	assert.ErrorIs(t, err, unavailable)
+
+	queued, err := outbox.FindByID(context.Background(), "queued-1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), queued.Attempts())
+	assert.Equal(t, "billing unavailable", queued.LastError())
+	assert.Equal(t, now.Add(2*time.Minute), queued.NextAttemptAt())
+}
+
+func TestSendQueuedRefund_FailsForGoodAfterMaxAttempts(t *testing.T) {
+	outbox := testkit.NewFakeRefundOutbox()
+	closed := errors.New("card account closed")
+	billing := testkit.NewFakeBillingClient().FailAlways(testkit.OpProcessRefund, closed)
+	queue(outbox)
+	now := queuedAt.Add(time.Minute)
+	interactor := NewInteractor(outbox, testkit.NewFakeRefunds(), adapters.StaticBillingResolver{Client: billing}, domain.FixedClock{FixedTime: now})
+
+	for n := 1; n < maxAttempts; n++ {
+		_, err := interactor.Execute(context.Background(), "queued-1")
+		require.ErrorIs(t, err, closed)
+		require.NotErrorIs(t, err, domain.ErrQueuedRefundFailed, "attempt %d is retried", n)
+	}
+	_, err := interactor.Execute(context.Background(), "queued-1")
+	assert.ErrorIs(t, err, closed)
+	assert.ErrorIs(t, err, domain.ErrQueuedRefundFailed)
+
+	queued, err := outbox.FindByID(context.Background(), "queued-1")
+	require.NoError(t, err)
+	assert.Equal(t, domain.QueuedRefundFailed, queued.Status())
+	assert.Equal(t, int64(maxAttempts), queued.Attempts())
+	assert.Equal(t, "card account closed", queued.LastError())
+	due, err := outbox.FindDue(context.Background(), now.Add(24*time.Hour), 10)
+	require.NoError(t, err)
+	assert.Empty(t, due, "a failed refund is no longer sent")
+
+	_, err = interactor.Execute(context.Background(), "queued-1")
+	assert.ErrorIs(t, err, domain.ErrQueuedRefundFailed)
+	assert.Len(t, billing.CallsTo(testkit.OpProcessRefund), maxAttempts, "nor sent when run by hand")
+}
+
+func TestSendQueuedRefund_Unknown(t *testing.T) {
+	interactor := NewInteractor(testkit.NewFakeRefundOutbox(), testkit.NewFakeRefunds(), adapters.StaticBillingResolver{Client: testkit.NewFakeBillingClient()}, domain.RealClock{})
+
+	_, err := interactor.Execute(context.Background(), "missing")
+
+	assert.ErrorIs(t, err, domain.ErrQueuedRefundNotFound)
+}
+
+func TestRetryDelay_DoublesUpToAnHour(t *testing.T) {
+	assert.Equal(t, time.Minute, retryDelay(0))
+	assert.Equal(t, 4*time.Minute, retryDelay(2))
+	assert.Equal(t, time.Hour, retryDelay(6))
+	assert.Equal(t, time.Hour, retryDelay(100))
+}
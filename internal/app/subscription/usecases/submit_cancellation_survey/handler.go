@@ -0,0 +1,41 @@
+package submit_cancellation_survey
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/bus"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+// CommandName identifies the submit cancellation survey command on the bus
+const CommandName = "subscription.submit_cancellation_survey"
+
+var _ bus.Handler = (*Interactor)(nil)
+
+// CommandName implements bus.Command
+func (r Request) CommandName() string {
+	return CommandName
+}
+
+// Validate rejects a response without answers before the subscription is loaded
+func (r Request) Validate() error {
+	if len(r.Answers) == 0 {
+		return domain.ErrInvalidSurveyAnswer
+	}
+	return nil
+}
+
+// Handle executes the use case for a command dispatched through the bus
+func (i *Interactor) Handle(ctx context.Context, cmd bus.Command) (any, error) {
+	req, ok := cmd.(Request)
+	if !ok {
+		return nil, fmt.Errorf("unexpected command type %T", cmd)
+	}
+
+	response, err := i.Execute(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return response, nil
+}
@@ -0,0 +1,38 @@
+package submit_cancellation_survey
+
+import (
+	"context"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/instrument"
+)
+
+// UseCase is the submit cancellation survey use case as seen by callers
+type UseCase interface {
+	Execute(ctx context.Context, req Request) (*domain.SurveyResponse, error)
+}
+
+var (
+	_ UseCase = (*Interactor)(nil)
+	_ UseCase = (*Instrumented)(nil)
+)
+
+// Instrumented decorates a UseCase with logs, metrics and a trace span per execution
+type Instrumented struct {
+	next UseCase
+	in   instrument.Instrumentation
+}
+
+// NewInstrumented wraps the given use case with instrumentation
+func NewInstrumented(next UseCase, in instrument.Instrumentation) *Instrumented {
+	return &Instrumented{next: next, in: in}
+}
+
+// Execute runs the wrapped use case
+func (d *Instrumented) Execute(ctx context.Context, req Request) (*domain.SurveyResponse, error) {
+	attrs := map[string]string{"subscription_id": req.SubscriptionID}
+
+	return instrument.Run(ctx, d.in, "submit_cancellation_survey", attrs, func(ctx context.Context) (*domain.SurveyResponse, error) {
+		return d.next.Execute(ctx, req)
+	})
+}
@@ -0,0 +1,57 @@
+package submit_cancellation_survey
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+// Request contains a cancelling customer's answers to the cancellation survey
+type Request struct {
+	SubscriptionID string
+	Answers        []domain.SurveyAnswer // Kind is ignored; it comes from the survey
+}
+
+// Interactor handles the submit cancellation survey use case
+type Interactor struct {
+	repo    contracts.SubscriptionRepository
+	surveys contracts.CancellationSurveyRepository
+	survey  domain.CancellationSurvey
+	clock   domain.Clock
+}
+
+// NewInteractor creates a new submit cancellation survey interactor asking survey,
+// which the caller has validated
+func NewInteractor(repo contracts.SubscriptionRepository, surveys contracts.CancellationSurveyRepository, survey domain.CancellationSurvey, clock domain.Clock) *Interactor {
+	return &Interactor{
+		repo:    repo,
+		surveys: surveys,
+		survey:  survey,
+		clock:   clock,
+	}
+}
+
+// Execute records the answers against the subscription's cancellation. The survey is
+// separate from the cancellation, so a customer who skips it is cancelled all the
+// same; each cancellation takes one response.
+func (i *Interactor) Execute(ctx context.Context, req Request) (*domain.SurveyResponse, error) {
+	// 1. Load subscription
+	sub, err := i.repo.FindByID(ctx, req.SubscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. Check the answers against the survey via domain constructor
+	response, err := domain.NewSurveyResponse(uuid.New().String(), sub, i.survey, req.Answers, i.clock)
+	if err != nil {
+		return nil, err
+	}
+
+	// 3. Insert the response; a second one for the cancellation is rejected
+	if err := i.surveys.Insert(ctx, response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
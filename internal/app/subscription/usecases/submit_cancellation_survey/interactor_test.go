@@ -0,0 +1,156 @@
+package submit_cancellation_survey
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/testkit"
+)
+
+// MockSurveyRepository is a mock implementation of CancellationSurveyRepository
+type MockSurveyRepository struct {
+	mock.Mock
+}
+
+func (m *MockSurveyRepository) Insert(ctx context.Context, response *domain.SurveyResponse) error {
+	args := m.Called(ctx, response)
+	return args.Error(0)
+}
+
+func (m *MockSurveyRepository) CountAnswers(ctx context.Context, since time.Time) ([]contracts.SurveyAnswerCount, error) {
+	args := m.Called(ctx, since)
+	return args.Get(0).([]contracts.SurveyAnswerCount), args.Error(1)
+}
+
+var (
+	startDate  = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cancelDate = time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+	submitDate = time.Date(2024, 1, 10, 0, 5, 0, 0, time.UTC)
+)
+
+var survey = domain.CancellationSurvey{
+	Version: "2024-01",
+	Questions: []domain.SurveyQuestion{
+		{ID: "reason", Prompt: "Why are you leaving?", Kind: domain.SurveyChoice, Options: []string{"too_expensive", "missing_features", "other"}, Required: true},
+		{ID: "satisfaction", Prompt: "How happy were you with the service?", Kind: domain.SurveyRating},
+		{ID: "comments", Prompt: "Anything else?", Kind: domain.SurveyText},
+	},
+}
+
+func cancelledSubscription(t *testing.T) *domain.Subscription {
+	sub, _, err := domain.NewSubscription("sub-123", "cust-456", "plan-pro", 3000, domain.CycleOfDays(30), domain.FixedClock{FixedTime: startDate})
+	require.NoError(t, err)
+	_, err = sub.Cancel(domain.FixedClock{FixedTime: cancelDate}, 30)
+	require.NoError(t, err)
+	return sub
+}
+
+func newTestInteractor(repo *testkit.MockSubscriptions, surveys *MockSurveyRepository) *Interactor {
+	return NewInteractor(repo, surveys, survey, domain.FixedClock{FixedTime: submitDate})
+}
+
+func TestSubmitCancellationSurvey_RecordsAnswersInQuestionOrder(t *testing.T) {
+	ctx := context.Background()
+	repo := &testkit.MockSubscriptions{}
+	repo.On("FindByID", ctx, "sub-123").Return(cancelledSubscription(t), nil)
+	surveys := &MockSurveyRepository{}
+	surveys.On("Insert", ctx, mock.Anything).Return(nil)
+
+	response, err := newTestInteractor(repo, surveys).Execute(ctx, Request{
+		SubscriptionID: "sub-123",
+		Answers: []domain.SurveyAnswer{
+			{QuestionID: "comments", Value: "  Needed SSO  "},
+			{QuestionID: "satisfaction", Value: "04"},
+			{QuestionID: "reason", Value: "missing_features"},
+		},
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "sub-123", response.SubscriptionID())
+	assert.Equal(t, "cust-456", response.CustomerID())
+	assert.Equal(t, "plan-pro", response.PlanID())
+	assert.Equal(t, "2024-01", response.SurveyVersion())
+	assert.Equal(t, cancelDate, response.CancelledAt())
+	assert.Equal(t, submitDate, response.SubmittedAt())
+	assert.Equal(t, []domain.SurveyAnswer{
+		{QuestionID: "reason", Kind: domain.SurveyChoice, Value: "missing_features"},
+		{QuestionID: "satisfaction", Kind: domain.SurveyRating, Value: "4"},
+		{QuestionID: "comments", Kind: domain.SurveyText, Value: "Needed SSO"},
+	}, response.Answers())
+	surveys.AssertCalled(t, "Insert", ctx, response)
+}
+
+func TestSubmitCancellationSurvey_RejectsInvalidAnswers(t *testing.T) {
+	testCases := []struct {
+		name    string
+		answers []domain.SurveyAnswer
+		want    error
+	}{
+		{name: "unknown question", answers: []domain.SurveyAnswer{{QuestionID: "reason", Value: "other"}, {QuestionID: "nps", Value: "9"}}, want: domain.ErrInvalidSurveyAnswer},
+		{name: "option not offered", answers: []domain.SurveyAnswer{{QuestionID: "reason", Value: "bored"}}, want: domain.ErrInvalidSurveyAnswer},
+		{name: "rating out of range", answers: []domain.SurveyAnswer{{QuestionID: "reason", Value: "other"}, {QuestionID: "satisfaction", Value: "6"}}, want: domain.ErrInvalidSurveyAnswer},
+		{name: "blank text", answers: []domain.SurveyAnswer{{QuestionID: "reason", Value: "other"}, {QuestionID: "comments", Value: " "}}, want: domain.ErrInvalidSurveyAnswer},
+		{name: "answered twice", answers: []domain.SurveyAnswer{{QuestionID: "reason", Value: "other"}, {QuestionID: "reason", Value: "too_expensive"}}, want: domain.ErrInvalidSurveyAnswer},
+		{name: "required question skipped", answers: []domain.SurveyAnswer{{QuestionID: "satisfaction", Value: "2"}}, want: domain.ErrSurveyAnswerRequired},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := &testkit.MockSubscriptions{}
+			repo.On("FindByID", ctx, "sub-123").Return(cancelledSubscription(t), nil)
+			surveys := &MockSurveyRepository{}
+
+			_, err := newTestInteractor(repo, surveys).Execute(ctx, Request{SubscriptionID: "sub-123", Answers: tc.answers})
+
+			assert.ErrorIs(t, err, tc.want)
+			surveys.AssertNotCalled(t, "Insert", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestSubmitCancellationSurvey_RejectsSubscriptionNotCancelled(t *testing.T) {
+	ctx := context.Background()
+	repo := &testkit.MockSubscriptions{}
+	repo.On("FindByID", ctx, "sub-123").Return(domain.ReconstructFromPersistence("sub-123", "cust-456", "plan-pro", 3000, domain.StatusActive, startDate), nil)
+	surveys := &MockSurveyRepository{}
+
+	_, err := newTestInteractor(repo, surveys).Execute(ctx, Request{SubscriptionID: "sub-123", Answers: []domain.SurveyAnswer{{QuestionID: "reason", Value: "other"}}})
+
+	assert.ErrorIs(t, err, domain.ErrNotCancelled)
+	surveys.AssertNotCalled(t, "Insert", mock.Anything, mock.Anything)
+}
+
+func TestSubmitCancellationSurvey_SecondResponseRejected(t *testing.T) {
+	ctx := context.Background()
+	repo := &testkit.MockSubscriptions{}
+	repo.On("FindByID", ctx, "sub-123").Return(cancelledSubscription(t), nil)
+	surveys := &MockSurveyRepository{}
+	surveys.On("Insert", ctx, mock.Anything).Return(domain.ErrSurveyAlreadySubmitted)
+
+	_, err := newTestInteractor(repo, surveys).Execute(ctx, Request{SubscriptionID: "sub-123", Answers: []domain.SurveyAnswer{{QuestionID: "reason", Value: "other"}}})
+
+	assert.ErrorIs(t, err, domain.ErrSurveyAlreadySubmitted)
+}
+
+func TestCancellationSurvey_Validate(t *testing.T) {
+	assert.NoError(t, survey.Validate())
+
+	invalid := []domain.CancellationSurvey{
+		{Questions: survey.Questions},
+		{Version: "v1"},
+		{Version: "v1", Questions: []domain.SurveyQuestion{{ID: "reason", Prompt: "Why?", Kind: domain.SurveyChoice}}},
+		{Version: "v1", Questions: []domain.SurveyQuestion{{ID: "score", Prompt: "Score?", Kind: domain.SurveyRating, Options: []string{"1"}}}},
+		{Version: "v1", Questions: []domain.SurveyQuestion{{ID: "a", Prompt: "A?", Kind: domain.SurveyText}, {ID: "a", Prompt: "B?", Kind: domain.SurveyText}}},
+		{Version: "v1", Questions: []domain.SurveyQuestion{{ID: "a", Prompt: "A?", Kind: "yes_no"}}},
+	}
+	for _, s := range invalid {
+		assert.ErrorIs(t, s.Validate(), domain.ErrInvalidSurvey)
+	}
+}
@@ -0,0 +1,195 @@
+package sync_plan_catalog
+
+import (
+	"context"
+	"time"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+// DriftKind classifies a difference between our plans and the billing provider's catalog
+type DriftKind string
+
+const (
+	// KindNewInCatalog is a catalog plan we don't have yet; the sync imports it
+	KindNewInCatalog DriftKind = "NEW_IN_CATALOG"
+	// KindChanged is a plan whose fields differ from the catalog; the sync updates it
+	KindChanged DriftKind = "CHANGED"
+	// KindMissingFromCatalog is a plan imported from a product the catalog no longer
+	// lists. It is left as is: subscriptions may still be on it.
+	KindMissingFromCatalog DriftKind = "MISSING_FROM_CATALOG"
+	// KindInvalid is a catalog entry that can't become a plan, whose plan ID is already
+	// mapped to another product, or whose product was already listed; it is skipped
+	KindInvalid DriftKind = "INVALID"
+)
+
+// Request contains the input for a catalog sync run
+type Request struct {
+	DryRun bool // report drift without changing any plan
+}
+
+// Drift is a single difference found during the sync
+type Drift struct {
+	Kind              DriftKind                `json:"kind"`
+	PlanID            string                   `json:"plan_id"`
+	ExternalProductID string                   `json:"external_product_id"`
+	Changes           []domain.PlanFieldChange `json:"changes,omitempty"`
+	Detail            string                   `json:"detail,omitempty"`
+}
+
+// Report is the outcome of a catalog sync run. On a dry run, Created and Updated count
+// the plans the sync would change. Events are the PlanCatalogUpdated events of the
+// plans saved, none on a dry run.
+type Report struct {
+	StartedAt time.Time                         `json:"started_at"`
+	DryRun    bool                              `json:"dry_run"`
+	Checked   int                               `json:"checked"`
+	Created   int                               `json:"created"`
+	Updated   int                               `json:"updated"`
+	Drift     []Drift                           `json:"drift"`
+	Events    []*domain.PlanCatalogUpdatedEvent `json:"-"`
+}
+
+// Interactor handles the plan catalog sync use case
+type Interactor struct {
+	plans   contracts.PlanRepository
+	catalog contracts.BillingCatalog
+	clock   domain.Clock
+}
+
+// NewInteractor creates a new sync plan catalog interactor
+func NewInteractor(plans contracts.PlanRepository, catalog contracts.BillingCatalog, clock domain.Clock) *Interactor {
+	return &Interactor{
+		plans:   plans,
+		catalog: catalog,
+		clock:   clock,
+	}
+}
+
+// Execute imports the billing provider's catalog into our plans: new catalog plans are
+// created and drifted ones updated, all in one transaction
+func (i *Interactor) Execute(ctx context.Context, req Request) (*Report, error) {
+	report := &Report{StartedAt: i.clock.Now(), DryRun: req.DryRun, Drift: []Drift{}}
+
+	// 1. Load our plans, indexed by the product they were imported from and by ID
+	plans, err := i.plans.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byProduct := make(map[string]*domain.Plan, len(plans))
+	byID := make(map[string]*domain.Plan, len(plans))
+	for _, plan := range plans {
+		if plan.ExternalProductID() != "" {
+			byProduct[plan.ExternalProductID()] = plan
+		}
+		byID[plan.ID()] = plan
+	}
+
+	// 2. Walk the catalog, comparing each entry with the plan it maps to
+	var changed []*domain.Plan
+	seen := make(map[string]bool)
+	pageToken := ""
+	for {
+		page, next, err := i.catalog.ListPlans(ctx, pageToken)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, entry := range page {
+			report.Checked++
+			if entry.ExternalProductID != "" && seen[entry.ExternalProductID] {
+				report.Drift = append(report.Drift, Drift{
+					Kind:              KindInvalid,
+					PlanID:            entry.PlanID,
+					ExternalProductID: entry.ExternalProductID,
+					Detail:            "product listed more than once; only its first price is imported",
+				})
+				continue
+			}
+			seen[entry.ExternalProductID] = true
+			plan, drift := i.sync(entry, byProduct, byID, report)
+			if drift != nil {
+				report.Drift = append(report.Drift, *drift)
+			}
+			if plan != nil {
+				byProduct[plan.ExternalProductID()] = plan
+				byID[plan.ID()] = plan
+				changed = append(changed, plan)
+			}
+		}
+
+		if next == "" {
+			break
+		}
+		pageToken = next
+	}
+
+	// 3. Report imported plans whose product is gone from the catalog
+	for _, plan := range plans {
+		if plan.ExternalProductID() != "" && !seen[plan.ExternalProductID()] {
+			report.Drift = append(report.Drift, Drift{
+				Kind:              KindMissingFromCatalog,
+				PlanID:            plan.ID(),
+				ExternalProductID: plan.ExternalProductID(),
+			})
+		}
+	}
+
+	if req.DryRun || len(changed) == 0 {
+		report.Events = nil
+		return report, nil
+	}
+
+	// 4. Save the new and updated plans together
+	var uow contracts.UnitOfWork
+	for _, plan := range changed {
+		uow.Save(i.plans.Save(ctx, plan))
+	}
+	if err := uow.Commit(ctx, i.plans); err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// sync brings the plan a catalog entry maps to in line with it, returning the plan when
+// it was created or changed and the drift found, if any. Plans are matched by the
+// product they were imported from, then by ID, which links a plan maintained by hand
+// to its product on the first sync.
+func (i *Interactor) sync(entry domain.CatalogPlan, byProduct, byID map[string]*domain.Plan, report *Report) (*domain.Plan, *Drift) {
+	drift := &Drift{PlanID: entry.PlanID, ExternalProductID: entry.ExternalProductID}
+
+	plan, ok := byProduct[entry.ExternalProductID]
+	if !ok {
+		plan, ok = byID[entry.PlanID]
+	}
+	if !ok {
+		plan, event, err := domain.NewPlanFromCatalog(entry, i.clock)
+		if err != nil {
+			drift.Kind, drift.Detail = KindInvalid, err.Error()
+			return nil, drift
+		}
+		drift.Kind, drift.Changes = KindNewInCatalog, event.Changes
+		report.Created++
+		report.Events = append(report.Events, event)
+		return plan, drift
+	}
+
+	// Matching by product wins over the catalog's plan ID, so a product remapped to
+	// another ID keeps updating the plan it was imported as
+	entry.PlanID = plan.ID()
+	drift.PlanID = plan.ID()
+	event, err := plan.SyncFromCatalog(entry, i.clock)
+	if err != nil {
+		drift.Kind, drift.Detail = KindInvalid, err.Error()
+		return nil, drift
+	}
+	if event == nil {
+		return nil, nil
+	}
+	drift.Kind, drift.Changes = KindChanged, event.Changes
+	report.Updated++
+	report.Events = append(report.Events, event)
+	return plan, drift
+}
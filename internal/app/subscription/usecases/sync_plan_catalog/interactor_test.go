@@ -0,0 +1,181 @@
+package sync_plan_catalog
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+// MockPlanRepository is a mock implementation of PlanRepository
+type MockPlanRepository struct {
+	mock.Mock
+}
+
+func (m *MockPlanRepository) Save(ctx context.Context, plan *domain.Plan) (*spanner.Mutation, error) {
+	args := m.Called(ctx, plan)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*spanner.Mutation), args.Error(1)
+}
+
+func (m *MockPlanRepository) Insert(ctx context.Context, plan *domain.Plan) error {
+	args := m.Called(ctx, plan)
+	return args.Error(0)
+}
+
+func (m *MockPlanRepository) FindAll(ctx context.Context) ([]*domain.Plan, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]*domain.Plan), args.Error(1)
+}
+
+func (m *MockPlanRepository) FindByID(ctx context.Context, id string) (*domain.Plan, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Plan), args.Error(1)
+}
+
+func (m *MockPlanRepository) Apply(ctx context.Context, mutations ...*spanner.Mutation) error {
+	args := m.Called(ctx, mutations)
+	return args.Error(0)
+}
+
+// MockBillingCatalog is a mock implementation of BillingCatalog
+type MockBillingCatalog struct {
+	mock.Mock
+}
+
+func (m *MockBillingCatalog) ListPlans(ctx context.Context, pageToken string) ([]domain.CatalogPlan, string, error) {
+	args := m.Called(ctx, pageToken)
+	return args.Get(0).([]domain.CatalogPlan), args.String(1), args.Error(2)
+}
+
+var (
+	createdAt = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	now       = time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+)
+
+func TestSyncPlanCatalog_ImportsAndUpdatesPlans(t *testing.T) {
+	ctx := context.Background()
+	mockPlans := new(MockPlanRepository)
+	mockCatalog := new(MockBillingCatalog)
+	interactor := NewInteractor(mockPlans, mockCatalog, domain.FixedClock{FixedTime: now})
+
+	mockPlans.On("FindAll", ctx).Return([]*domain.Plan{
+		domain.ReconstructPlan("plan-basic", "Basic", 1000, "USD", "", 0, true, "prod_basic", "price_basic_1", createdAt, createdAt),
+		domain.ReconstructPlan("plan-pro", "Pro", 3000, "USD", "", 0, true, "prod_pro", "price_pro_1", createdAt, createdAt),
+		// Maintained by hand until now; the catalog's plan_id links it to its product
+		domain.ReconstructPlan("plan-team", "Team", 5000, "USD", "", 0, true, "", "", createdAt, createdAt),
+	}, nil)
+	mockCatalog.On("ListPlans", ctx, "").Return([]domain.CatalogPlan{
+		{PlanID: "plan-basic", ExternalProductID: "prod_basic", ExternalPriceID: "price_basic_1", Name: "Basic", PriceCents: 1000, Currency: "USD", Active: true},
+		{PlanID: "plan-pro", ExternalProductID: "prod_pro", ExternalPriceID: "price_pro_2", Name: "Pro", PriceCents: 3500, Currency: "USD", Interval: domain.IntervalMonth, Active: true},
+	}, "page-2", nil)
+	mockCatalog.On("ListPlans", ctx, "page-2").Return([]domain.CatalogPlan{
+		{PlanID: "plan-team", ExternalProductID: "prod_team", ExternalPriceID: "price_team_1", Name: "Team", PriceCents: 5000, Currency: "USD", Active: true},
+		{PlanID: "prod_enterprise", ExternalProductID: "prod_enterprise", ExternalPriceID: "price_ent_1", Name: "Enterprise", PriceCents: 20000, Currency: "USD", Active: true},
+	}, "", nil)
+	var saved []*domain.Plan
+	mockPlans.On("Save", ctx, mock.Anything).Run(func(args mock.Arguments) {
+		saved = append(saved, args.Get(1).(*domain.Plan))
+	}).Return(&spanner.Mutation{}, nil)
+	mockPlans.On("Apply", ctx, mock.Anything).Return(nil).Once()
+
+	report, err := interactor.Execute(ctx, Request{})
+
+	require.NoError(t, err)
+	assert.Equal(t, 4, report.Checked)
+	assert.Equal(t, 1, report.Created)
+	assert.Equal(t, 2, report.Updated)
+	require.Len(t, report.Drift, 3)
+	assert.Equal(t, Drift{Kind: KindChanged, PlanID: "plan-pro", ExternalProductID: "prod_pro", Changes: []domain.PlanFieldChange{
+		{Field: "price_cents", Old: "3000", New: "3500"},
+		{Field: "billing_interval", Old: "", New: "month"},
+		{Field: "external_price_id", Old: "price_pro_1", New: "price_pro_2"},
+	}}, report.Drift[0])
+	assert.Equal(t, KindChanged, report.Drift[1].Kind)
+	assert.Equal(t, "plan-team", report.Drift[1].PlanID)
+	assert.Equal(t, KindNewInCatalog, report.Drift[2].Kind)
+	assert.Equal(t, "prod_enterprise", report.Drift[2].PlanID)
+
+	require.Len(t, saved, 3)
+	assert.Equal(t, int64(3500), saved[0].PriceCents())
+	assert.Equal(t, now, saved[0].UpdatedAt())
+	assert.Equal(t, domain.IntervalMonth, saved[0].BillingInterval())
+	assert.Equal(t, "prod_team", saved[1].ExternalProductID())
+	assert.Equal(t, "Enterprise", saved[2].Name())
+
+	require.Len(t, report.Events, 3)
+	assert.False(t, report.Events[0].Created)
+	assert.True(t, report.Events[2].Created)
+	assert.Equal(t, "prod_enterprise", report.Events[2].ExternalProductID)
+	mockPlans.AssertExpectations(t)
+}
+
+func TestSyncPlanCatalog_DryRunOnlyReports(t *testing.T) {
+	ctx := context.Background()
+	mockPlans := new(MockPlanRepository)
+	mockCatalog := new(MockBillingCatalog)
+	interactor := NewInteractor(mockPlans, mockCatalog, domain.FixedClock{FixedTime: now})
+
+	mockPlans.On("FindAll", ctx).Return([]*domain.Plan{
+		domain.ReconstructPlan("plan-basic", "Basic", 1000, "USD", "", 0, true, "prod_basic", "price_basic_1", createdAt, createdAt),
+	}, nil)
+	mockCatalog.On("ListPlans", ctx, "").Return([]domain.CatalogPlan{
+		{PlanID: "plan-basic", ExternalProductID: "prod_basic", ExternalPriceID: "price_basic_1", Name: "Basic", PriceCents: 1000, Currency: "USD", Active: false},
+	}, "", nil)
+
+	report, err := interactor.Execute(ctx, Request{DryRun: true})
+
+	require.NoError(t, err)
+	assert.True(t, report.DryRun)
+	assert.Equal(t, 1, report.Updated)
+	require.Len(t, report.Drift, 1)
+	assert.Equal(t, []domain.PlanFieldChange{{Field: "active", Old: "true", New: "false"}}, report.Drift[0].Changes)
+	assert.Empty(t, report.Events)
+	mockPlans.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+	mockPlans.AssertNotCalled(t, "Apply", mock.Anything, mock.Anything)
+}
+
+func TestSyncPlanCatalog_ReportsWithoutSaving(t *testing.T) {
+	ctx := context.Background()
+	mockPlans := new(MockPlanRepository)
+	mockCatalog := new(MockBillingCatalog)
+	interactor := NewInteractor(mockPlans, mockCatalog, domain.FixedClock{FixedTime: now})
+
+	mockPlans.On("FindAll", ctx).Return([]*domain.Plan{
+		domain.ReconstructPlan("plan-basic", "Basic", 1000, "USD", "", 0, true, "prod_basic", "price_basic_1", createdAt, createdAt),
+		domain.ReconstructPlan("plan-legacy", "Legacy", 900, "USD", "", 0, true, "prod_legacy", "price_legacy_1", createdAt, createdAt),
+	}, nil)
+	mockCatalog.On("ListPlans", ctx, "").Return([]domain.CatalogPlan{
+		{PlanID: "plan-basic", ExternalProductID: "prod_basic", ExternalPriceID: "price_basic_1", Name: "Basic", PriceCents: 1000, Currency: "USD", Active: true},
+		{PlanID: "plan-basic", ExternalProductID: "prod_basic", ExternalPriceID: "price_basic_yearly", Name: "Basic", PriceCents: 10000, Currency: "USD", Active: true},
+		{PlanID: "plan-free", ExternalProductID: "prod_free", ExternalPriceID: "price_free", Name: "Free", PriceCents: 0, Currency: "USD", Active: true},
+		{PlanID: "plan-euro", ExternalProductID: "prod_euro", ExternalPriceID: "price_euro", Name: "Euro", PriceCents: 1000, Currency: "EUR", Active: true},
+		{PlanID: "plan-legacy", ExternalProductID: "prod_other", ExternalPriceID: "price_other", Name: "Other", PriceCents: 900, Currency: "USD", Active: true},
+	}, "", nil)
+
+	report, err := interactor.Execute(ctx, Request{})
+
+	require.NoError(t, err)
+	kinds := make([]DriftKind, 0, len(report.Drift))
+	for _, d := range report.Drift {
+		kinds = append(kinds, d.Kind)
+	}
+	// A second price of a product, a free price, a price in another currency, a plan ID
+	// mapped to another product, and an imported plan whose product is gone
+	assert.Equal(t, []DriftKind{KindInvalid, KindInvalid, KindInvalid, KindInvalid, KindMissingFromCatalog}, kinds)
+	assert.Contains(t, report.Drift[2].Detail, domain.ErrUnsupportedCurrency.Error())
+	assert.Contains(t, report.Drift[3].Detail, domain.ErrPlanMappedElsewhere.Error())
+	assert.Equal(t, "plan-legacy", report.Drift[4].PlanID)
+	assert.Zero(t, report.Created+report.Updated)
+	mockPlans.AssertNotCalled(t, "Apply", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,79 @@
+package update_plan
+
+import (
+	"context"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+// Request contains the input for updating a plan. Each field left nil keeps the
+// plan's current value.
+type Request struct {
+	PlanID     string
+	Name       *string
+	PriceCents *int64
+	Currency   *string
+	Interval   *domain.BillingInterval
+	TrialDays  *int64
+}
+
+// apply returns the plan's details with the request's changes
+func (r Request) apply(details domain.PlanDetails) domain.PlanDetails {
+	if r.Name != nil {
+		details.Name = *r.Name
+	}
+	if r.PriceCents != nil {
+		details.PriceCents = *r.PriceCents
+	}
+	if r.Currency != nil {
+		details.Currency = *r.Currency
+	}
+	if r.Interval != nil {
+		details.Interval = *r.Interval
+	}
+	if r.TrialDays != nil {
+		details.TrialDays = *r.TrialDays
+	}
+	return details
+}
+
+// Interactor handles the update plan use case
+type Interactor struct {
+	plans contracts.PlanRepository
+	clock domain.Clock
+}
+
+// NewInteractor creates a new update plan interactor
+func NewInteractor(plans contracts.PlanRepository, clock domain.Clock) *Interactor {
+	return &Interactor{
+		plans: plans,
+		clock: clock,
+	}
+}
+
+// Execute changes a plan and returns the event listing what changed, nil when the plan
+// already had the details asked for. New subscriptions get the new price and trial;
+// existing ones keep the price they were created at.
+func (i *Interactor) Execute(ctx context.Context, req Request) (*domain.PlanCatalogUpdatedEvent, error) {
+	// 1. Load plan
+	plan, err := i.plans.FindByID(ctx, req.PlanID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. Update it via domain method
+	event, err := plan.Update(req.apply(plan.Details()), i.clock)
+	if err != nil || event == nil {
+		return nil, err
+	}
+
+	// 3. Save it
+	var uow contracts.UnitOfWork
+	uow.Save(i.plans.Save(ctx, plan))
+	if err := uow.Commit(ctx, i.plans); err != nil {
+		return nil, err
+	}
+
+	return event, nil
+}
@@ -0,0 +1,75 @@
+package update_plan
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/testkit"
+)
+
+var (
+	createdAt = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	now       = time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+)
+
+func proPlan() *domain.Plan {
+	return domain.ReconstructPlan("plan-pro", "Pro", 3000, "USD", domain.IntervalMonth, 0, true, "", "", createdAt, createdAt)
+}
+
+func TestUpdatePlan_ChangesOnlyTheFieldsGiven(t *testing.T) {
+	plans := testkit.NewFakePlans(proPlan())
+	price, trial := int64(3500), int64(14)
+
+	event, err := NewInteractor(plans, domain.FixedClock{FixedTime: now}).Execute(context.Background(), Request{PlanID: "plan-pro", PriceCents: &price, TrialDays: &trial})
+
+	require.NoError(t, err)
+	assert.Equal(t, []domain.PlanFieldChange{
+		{Field: "price_cents", Old: "3000", New: "3500"},
+		{Field: "trial_days", Old: "0", New: "14"},
+	}, event.Changes)
+	assert.Equal(t, now, event.UpdatedAt)
+
+	stored, err := plans.FindByID(context.Background(), "plan-pro")
+	require.NoError(t, err)
+	assert.Equal(t, domain.PlanDetails{Name: "Pro", PriceCents: 3500, Currency: "USD", Interval: domain.IntervalMonth, TrialDays: 14}, stored.Details())
+	assert.Equal(t, now, stored.UpdatedAt())
+}
+
+func TestUpdatePlan_NothingToChange(t *testing.T) {
+	plans := testkit.NewFakePlans(proPlan())
+	name := "Pro"
+
+	event, err := NewInteractor(plans, domain.FixedClock{FixedTime: now}).Execute(context.Background(), Request{PlanID: "plan-pro", Name: &name})
+
+	require.NoError(t, err)
+	assert.Nil(t, event)
+	stored, _ := plans.FindByID(context.Background(), "plan-pro")
+	assert.Equal(t, createdAt, stored.UpdatedAt())
+}
+
+func TestUpdatePlan_Rejections(t *testing.T) {
+	price := int64(0)
+	interval := domain.BillingInterval("fortnight")
+	for name, tc := range map[string]struct {
+		req     Request
+		wantErr error
+	}{
+		"unknown plan": {Request{PlanID: "plan-missing"}, domain.ErrPlanNotFound},
+		"no price":     {Request{PlanID: "plan-pro", PriceCents: &price}, domain.ErrInvalidPrice},
+		"bad interval": {Request{PlanID: "plan-pro", Interval: &interval}, domain.ErrInvalidBillingInterval},
+	} {
+		t.Run(name, func(t *testing.T) {
+			plans := testkit.NewFakePlans(proPlan())
+
+			_, err := NewInteractor(plans, domain.FixedClock{FixedTime: now}).Execute(context.Background(), tc.req)
+
+			assert.ErrorIs(t, err, tc.wantErr)
+			stored, _ := plans.FindByID(context.Background(), "plan-pro")
+			assert.Equal(t, proPlan().Details(), stored.Details())
+		})
+	}
+}
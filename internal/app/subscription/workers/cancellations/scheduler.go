@@ -0,0 +1,143 @@
+package cancellations
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/recovery"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/cancel_subscription"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/workpool"
+)
+
+const MetricScheduledCancellations = "scheduled_cancellations_total"
+
+// Config controls how the scheduler selects and processes due cancellations
+type Config struct {
+	BatchSize   int // maximum subscriptions fetched per pass
+	Concurrency int // maximum cancellations in flight
+}
+
+// Result summarizes one scheduler pass
+type Result struct {
+	Cancelled int
+	Skipped   int
+	Failed    int
+}
+
+// Scheduler finds subscriptions pending cancellation whose cancel_at has passed and
+// cancels them
+type Scheduler struct {
+	finder    contracts.ScheduledCancellationRepository
+	finalizer cancel_subscription.FinalizeUseCase
+	clock     domain.Clock
+	metrics   contracts.Metrics
+	logger    *slog.Logger
+	cfg       Config
+}
+
+// NewScheduler creates a cancellation scheduler
+func NewScheduler(finder contracts.ScheduledCancellationRepository, finalizer cancel_subscription.FinalizeUseCase, clock domain.Clock, metrics contracts.Metrics, logger *slog.Logger, cfg Config) *Scheduler {
+	if cfg.Concurrency < 1 {
+		cfg.Concurrency = 1
+	}
+	return &Scheduler{
+		finder:    finder,
+		finalizer: finalizer,
+		clock:     clock,
+		metrics:   metrics,
+		logger:    logger,
+		cfg:       cfg,
+	}
+}
+
+// Run executes a pass every interval until ctx is cancelled
+func (s *Scheduler) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.RunOnce(ctx); err != nil && ctx.Err() == nil {
+			s.logger.ErrorContext(ctx, "cancellation pass failed", slog.Any("error", err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce cancels every subscription whose scheduled cancellation is due, up to BatchSize
+func (s *Scheduler) RunOnce(ctx context.Context) (Result, error) {
+	subs, err := s.finder.FindDueForCancellation(ctx, s.clock.Now(), s.cfg.BatchSize)
+	if err != nil {
+		return Result{}, err
+	}
+	ids := make([]string, len(subs))
+	for n, sub := range subs {
+		ids[n] = sub.ID()
+	}
+
+	var (
+		mu     sync.Mutex
+		result Result
+	)
+	// Cancellations already started finish at shutdown; ctx only stops new ones
+	_, err = workpool.Run(ctx, ids, workpool.Config{Concurrency: s.cfg.Concurrency, Detach: true}, func(ctx context.Context, id string) error {
+		outcome := s.finalize(ctx, id)
+
+		mu.Lock()
+		defer mu.Unlock()
+		switch outcome {
+		case "cancelled":
+			result.Cancelled++
+		case "skipped":
+			result.Skipped++
+		default:
+			result.Failed++
+		}
+		return nil
+	})
+	if err != nil {
+		return result, err
+	}
+
+	s.logger.InfoContext(ctx, "cancellation pass complete",
+		slog.Int("cancelled", result.Cancelled),
+		slog.Int("skipped", result.Skipped),
+		slog.Int("failed", result.Failed),
+	)
+
+	return result, nil
+}
+
+// finalize cancels a single subscription and reports the outcome
+func (s *Scheduler) finalize(ctx context.Context, subscriptionID string) string {
+	outcome := "cancelled"
+
+	err := recovery.Do(ctx, s.logger, s.metrics, "cancellations", func() error {
+		_, err := s.finalizer.FinalizeScheduled(ctx, subscriptionID)
+		return err
+	})
+	switch {
+	case err == nil:
+	case errors.Is(err, domain.ErrCancellationNotDue), errors.Is(err, domain.ErrAlreadyCancelled):
+		// Cancelled since the query ran
+		outcome = "skipped"
+	default:
+		outcome = "failed"
+		s.logger.ErrorContext(ctx, "scheduled cancellation failed",
+			slog.String("subscription_id", subscriptionID),
+			slog.Any("error", err),
+		)
+	}
+
+	s.metrics.IncCounter(MetricScheduledCancellations, map[string]string{"outcome": outcome})
+	return outcome
+}
@@ -0,0 +1,185 @@
+package dunning
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/recovery"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/retry_payment"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/workpool"
+)
+
+const MetricPaymentRetries = "payment_retries_total"
+
+// Config controls how the worker selects and processes due retries
+type Config struct {
+	BatchSize   int // maximum subscriptions retried per pass
+	Concurrency int // maximum retries in flight
+}
+
+// Result summarizes one worker pass
+type Result struct {
+	Recovered int
+	Failed    int // charge failed, another retry is scheduled
+	Expired   int // charge failed and the subscription was cancelled
+	Skipped   int
+	Errors    int
+}
+
+// Worker re-attempts charges for past-due subscriptions whose retry is due
+type Worker struct {
+	finder  contracts.SubscriptionScanRepository
+	retrier retry_payment.UseCase
+	clock   domain.Clock
+	metrics contracts.Metrics
+	logger  *slog.Logger
+	cfg     Config
+}
+
+// NewWorker creates a dunning retry worker
+func NewWorker(finder contracts.SubscriptionScanRepository, retrier retry_payment.UseCase, clock domain.Clock, metrics contracts.Metrics, logger *slog.Logger, cfg Config) *Worker {
+	if cfg.Concurrency < 1 {
+		cfg.Concurrency = 1
+	}
+	return &Worker{
+		finder:  finder,
+		retrier: retrier,
+		clock:   clock,
+		metrics: metrics,
+		logger:  logger,
+		cfg:     cfg,
+	}
+}
+
+// Run executes a pass every interval until ctx is cancelled
+func (w *Worker) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := w.RunOnce(ctx); err != nil && ctx.Err() == nil {
+			w.logger.ErrorContext(ctx, "dunning pass failed", slog.Any("error", err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce retries every past-due subscription whose retry is due, up to BatchSize
+func (w *Worker) RunOnce(ctx context.Context) (Result, error) {
+	ids, err := w.due(ctx, w.clock.Now())
+	if err != nil {
+		return Result{}, err
+	}
+
+	var (
+		mu     sync.Mutex
+		result Result
+	)
+	// A retry in flight at shutdown completes rather than being cut off mid-charge;
+	// ctx only stops new ones
+	_, err = workpool.Run(ctx, ids, workpool.Config{Concurrency: w.cfg.Concurrency, Detach: true}, func(ctx context.Context, id string) error {
+		outcome := w.retry(ctx, id)
+
+		mu.Lock()
+		defer mu.Unlock()
+		switch outcome {
+		case "recovered":
+			result.Recovered++
+		case "failed":
+			result.Failed++
+		case "expired":
+			result.Expired++
+		case "skipped":
+			result.Skipped++
+		default:
+			result.Errors++
+		}
+		return nil
+	})
+	if err != nil {
+		return result, err
+	}
+
+	w.logger.InfoContext(ctx, "dunning pass complete",
+		slog.Int("recovered", result.Recovered),
+		slog.Int("failed", result.Failed),
+		slog.Int("expired", result.Expired),
+		slog.Int("skipped", result.Skipped),
+		slog.Int("errors", result.Errors),
+	)
+
+	return result, nil
+}
+
+// due streams the past-due subscriptions and returns the IDs of up to BatchSize whose
+// next payment retry is at or before now. Only the IDs are kept, so a pass holds one
+// batch however many subscriptions are past due.
+func (w *Worker) due(ctx context.Context, now time.Time) ([]string, error) {
+	ids := make([]string, 0, w.cfg.BatchSize)
+	err := w.finder.ForEachByStatus(ctx, domain.StatusPastDue, func(sub *domain.Subscription) error {
+		if retryAt := sub.NextPaymentRetryAt(); retryAt.IsZero() || retryAt.After(now) {
+			return nil
+		}
+		ids = append(ids, sub.ID())
+		if len(ids) >= w.cfg.BatchSize {
+			return errBatchFull
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errBatchFull) {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// errBatchFull stops a scan once a pass has a full batch
+var errBatchFull = errors.New("dunning batch full")
+
+// retry re-attempts a single payment and reports the outcome
+func (w *Worker) retry(ctx context.Context, subscriptionID string) string {
+	var outcome string
+	log := w.logger.With(slog.String("subscription_id", subscriptionID))
+
+	var res *retry_payment.Result
+	err := recovery.Do(ctx, w.logger, w.metrics, "dunning", func() (err error) {
+		res, err = w.retrier.Execute(ctx, subscriptionID)
+		return err
+	})
+	switch {
+	case errors.Is(err, domain.ErrNotPastDue), errors.Is(err, domain.ErrPaymentRetryNotDue):
+		// Recovered, cancelled or already retried since the query ran
+		outcome = "skipped"
+	case err != nil:
+		outcome = "error"
+		log.ErrorContext(ctx, "payment retry errored", slog.Any("error", err))
+	case res.Recovered != nil:
+		outcome = "recovered"
+		log.InfoContext(ctx, "subscription recovered", slog.Int64("attempts", res.Recovered.Attempts))
+	case res.Expired != nil:
+		outcome = "expired"
+		log.WarnContext(ctx, "subscription expired after final payment retry",
+			slog.Int64("attempts", res.Expired.Attempts),
+			slog.Any("charge_error", res.ChargeError),
+		)
+	default:
+		outcome = "failed"
+		log.WarnContext(ctx, "payment retry failed",
+			slog.Int64("attempt", res.RetryFailed.Attempt),
+			slog.Time("next_payment_retry_at", res.RetryFailed.NextPaymentRetryAt),
+			slog.Any("charge_error", res.ChargeError),
+		)
+	}
+
+	w.metrics.IncCounter(MetricPaymentRetries, map[string]string{"outcome": outcome})
+	return outcome
+}
@@ -0,0 +1,72 @@
+package dunning
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/adapters"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/logging"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/testkit"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/testkit/builders"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/retry_payment"
+)
+
+type stubRetrier struct {
+	mu  sync.Mutex
+	ids []string
+}
+
+func (s *stubRetrier) Execute(_ context.Context, subscriptionID string) (*retry_payment.Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ids = append(s.ids, subscriptionID)
+	return &retry_payment.Result{Recovered: &domain.SubscriptionRecoveredEvent{SubscriptionID: subscriptionID}}, nil
+}
+
+func (s *stubRetrier) retried() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sort.Strings(s.ids)
+	return s.ids
+}
+
+func TestWorker_RetriesThePastDueSubscriptionsDue(t *testing.T) {
+	now := time.Date(2024, 2, 5, 0, 0, 0, 0, time.UTC)
+	subs := testkit.NewFakeSubscriptions().With(
+		builders.NewSubscriptionBuilder().WithID("sub-due").InDunning(1, now.Add(-time.Hour)).Build(),
+		builders.NewSubscriptionBuilder().WithID("sub-later").InDunning(1, now.Add(time.Hour)).Build(),
+		builders.NewSubscriptionBuilder().WithID("sub-active").Build(),
+	)
+	retrier := &stubRetrier{}
+	w := NewWorker(subs, retrier, domain.FixedClock{FixedTime: now}, adapters.NoopMetrics{}, logging.Discard(), Config{BatchSize: 10})
+
+	result, err := w.RunOnce(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, Result{Recovered: 1}, result)
+	assert.Equal(t, []string{"sub-due"}, retrier.retried())
+}
+
+func TestWorker_StopsTheScanAtBatchSize(t *testing.T) {
+	now := time.Date(2024, 2, 5, 0, 0, 0, 0, time.UTC)
+	subs := testkit.NewFakeSubscriptions().With(
+		builders.NewSubscriptionBuilder().WithID("sub-1").InDunning(1, now).Build(),
+		builders.NewSubscriptionBuilder().WithID("sub-2").InDunning(1, now).Build(),
+		builders.NewSubscriptionBuilder().WithID("sub-3").InDunning(1, now).Build(),
+	)
+	retrier := &stubRetrier{}
+	w := NewWorker(subs, retrier, domain.FixedClock{FixedTime: now}, adapters.NoopMetrics{}, logging.Discard(), Config{BatchSize: 2})
+
+	result, err := w.RunOnce(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Recovered)
+	assert.Len(t, retrier.retried(), 2)
+}
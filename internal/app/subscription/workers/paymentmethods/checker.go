@@ -0,0 +1,156 @@
+package paymentmethods
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/recovery"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/check_payment_method"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/workpool"
+)
+
+const MetricPaymentMethodChecks = "payment_method_checks_total"
+
+// Config controls which subscriptions the checker looks at and how
+type Config struct {
+	Lookahead        time.Duration // check subscriptions that renew within this window
+	BillingCycleDays int64         // period length of plans without a billing interval
+	BatchSize        int           // maximum subscriptions fetched per pass
+	Concurrency      int           // maximum checks in flight
+}
+
+// Result summarizes one checker pass
+type Result struct {
+	Flagged int
+	OK      int // payment method can be charged at renewal
+	Skipped int
+	Failed  int
+}
+
+// Checker flags subscriptions whose payment method won't be chargeable at their next renewal
+type Checker struct {
+	finder  contracts.PaymentMethodCheckRepository
+	checker check_payment_method.UseCase
+	clock   domain.Clock
+	metrics contracts.Metrics
+	logger  *slog.Logger
+	cfg     Config
+}
+
+// NewChecker creates a payment method checker
+func NewChecker(finder contracts.PaymentMethodCheckRepository, checker check_payment_method.UseCase, clock domain.Clock, metrics contracts.Metrics, logger *slog.Logger, cfg Config) *Checker {
+	if cfg.Concurrency < 1 {
+		cfg.Concurrency = 1
+	}
+	return &Checker{
+		finder:  finder,
+		checker: checker,
+		clock:   clock,
+		metrics: metrics,
+		logger:  logger,
+		cfg:     cfg,
+	}
+}
+
+// Run executes a pass every interval until ctx is cancelled
+func (c *Checker) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := c.RunOnce(ctx); err != nil && ctx.Err() == nil {
+			c.logger.ErrorContext(ctx, "payment method check pass failed", slog.Any("error", err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce checks every unflagged subscription renewing within Lookahead, up to BatchSize
+func (c *Checker) RunOnce(ctx context.Context) (Result, error) {
+	renewsBefore := c.clock.Now().Add(c.cfg.Lookahead)
+
+	subs, err := c.finder.FindRenewingUnflagged(ctx, renewsBefore, c.cfg.BillingCycleDays, c.cfg.BatchSize)
+	if err != nil {
+		return Result{}, err
+	}
+
+	var (
+		mu     sync.Mutex
+		result Result
+	)
+	// Checks in flight finish during shutdown; ctx only stops new ones
+	_, err = workpool.Run(ctx, subs, workpool.Config{Concurrency: c.cfg.Concurrency, Detach: true}, func(ctx context.Context, sub *domain.Subscription) error {
+		outcome := c.check(ctx, sub.ID())
+
+		mu.Lock()
+		defer mu.Unlock()
+		switch outcome {
+		case "flagged":
+			result.Flagged++
+		case "ok":
+			result.OK++
+		case "skipped":
+			result.Skipped++
+		default:
+			result.Failed++
+		}
+		return nil
+	})
+	if err != nil {
+		return result, err
+	}
+
+	c.logger.InfoContext(ctx, "payment method check pass complete",
+		slog.Int("flagged", result.Flagged),
+		slog.Int("ok", result.OK),
+		slog.Int("skipped", result.Skipped),
+		slog.Int("failed", result.Failed),
+	)
+
+	return result, nil
+}
+
+// check checks a single subscription and reports the outcome
+func (c *Checker) check(ctx context.Context, subscriptionID string) string {
+	var outcome string
+	log := c.logger.With(slog.String("subscription_id", subscriptionID))
+
+	var event *domain.PaymentMethodExpiringEvent
+	err := recovery.Do(ctx, c.logger, c.metrics, "payment_method_checker", func() (err error) {
+		event, err = c.checker.Execute(ctx, subscriptionID)
+		return err
+	})
+	switch {
+	case errors.Is(err, domain.ErrPaymentMethodUsable):
+		outcome = "ok"
+	case errors.Is(err, domain.ErrPaymentMethodAlreadyFlagged), errors.Is(err, domain.ErrNotActive):
+		// Flagged, renewed or cancelled since the query ran
+		outcome = "skipped"
+	case err != nil:
+		outcome = "failed"
+		log.ErrorContext(ctx, "payment method check failed", slog.Any("error", err))
+	default:
+		outcome = "flagged"
+		// The event names the card so a notification can tell the customer which one to replace
+		log.WarnContext(ctx, "payment method expiring",
+			slog.String("customer_id", event.CustomerID),
+			slog.String("brand", event.Brand),
+			slog.String("last4", event.Last4),
+			slog.Time("expires_at", event.ExpiresAt),
+			slog.Time("renews_at", event.RenewsAt),
+		)
+	}
+
+	c.metrics.IncCounter(MetricPaymentMethodChecks, map[string]string{"outcome": outcome})
+	return outcome
+}
@@ -0,0 +1,154 @@
+package refunds
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/recovery"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/poll_refund_status"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/workpool"
+)
+
+const MetricRefundPolls = "refund_polls_total"
+
+// Config controls how the poller selects and processes pending refunds
+type Config struct {
+	BatchSize   int           // maximum refunds fetched per pass
+	Concurrency int           // maximum status lookups in flight
+	MinAge      time.Duration // refunds younger than this are left for the webhook
+}
+
+// Result summarizes one poller pass
+type Result struct {
+	Settled int
+	Failed  int
+	Pending int
+	Errors  int
+}
+
+// Poller asks the billing provider for the status of refunds that are still pending.
+// It backstops the webhook, which may be delayed or lost.
+type Poller struct {
+	finder  contracts.RefundRepository
+	poller  poll_refund_status.UseCase
+	clock   domain.Clock
+	metrics contracts.Metrics
+	logger  *slog.Logger
+	cfg     Config
+}
+
+// NewPoller creates a refund status poller
+func NewPoller(finder contracts.RefundRepository, poller poll_refund_status.UseCase, clock domain.Clock, metrics contracts.Metrics, logger *slog.Logger, cfg Config) *Poller {
+	if cfg.Concurrency < 1 {
+		cfg.Concurrency = 1
+	}
+	return &Poller{
+		finder:  finder,
+		poller:  poller,
+		clock:   clock,
+		metrics: metrics,
+		logger:  logger,
+		cfg:     cfg,
+	}
+}
+
+// Run executes a pass every interval until ctx is cancelled
+func (p *Poller) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := p.RunOnce(ctx); err != nil && ctx.Err() == nil {
+			p.logger.ErrorContext(ctx, "refund poll pass failed", slog.Any("error", err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce polls every pending refund older than MinAge, up to BatchSize
+func (p *Poller) RunOnce(ctx context.Context) (Result, error) {
+	refunds, err := p.finder.FindPending(ctx, p.clock.Now().Add(-p.cfg.MinAge), p.cfg.BatchSize)
+	if err != nil {
+		return Result{}, err
+	}
+
+	var (
+		mu     sync.Mutex
+		result Result
+	)
+	// Status lookups in flight finish during shutdown; ctx only stops new ones
+	_, err = workpool.Run(ctx, refunds, workpool.Config{Concurrency: p.cfg.Concurrency, Detach: true}, func(ctx context.Context, refund *domain.Refund) error {
+		outcome := p.poll(ctx, refund.ID())
+
+		mu.Lock()
+		defer mu.Unlock()
+		switch outcome {
+		case "settled":
+			result.Settled++
+		case "failed":
+			result.Failed++
+		case "pending":
+			result.Pending++
+		default:
+			result.Errors++
+		}
+		return nil
+	})
+	if err != nil {
+		return result, err
+	}
+
+	p.logger.InfoContext(ctx, "refund poll pass complete",
+		slog.Int("settled", result.Settled),
+		slog.Int("failed", result.Failed),
+		slog.Int("pending", result.Pending),
+		slog.Int("errors", result.Errors),
+	)
+
+	return result, nil
+}
+
+// poll checks a single refund and reports the outcome
+func (p *Poller) poll(ctx context.Context, refundID string) string {
+	var outcome string
+	log := p.logger.With(slog.String("refund_id", refundID))
+
+	var res *poll_refund_status.Result
+	err := recovery.Do(ctx, p.logger, p.metrics, "refund_poller", func() (err error) {
+		res, err = p.poller.Execute(ctx, refundID)
+		return err
+	})
+	switch {
+	case errors.Is(err, domain.ErrRefundAlreadySettled):
+		// The webhook recorded the outcome since the query ran
+		outcome = "settled"
+	case err != nil:
+		outcome = "error"
+		log.ErrorContext(ctx, "refund status poll errored", slog.Any("error", err))
+	case res.Settled != nil:
+		outcome = "settled"
+		log.InfoContext(ctx, "refund settled", slog.String("subscription_id", res.Settled.SubscriptionID), slog.Int64("amount", res.Settled.Amount))
+	case res.Failed != nil:
+		outcome = "failed"
+		log.WarnContext(ctx, "refund failed",
+			slog.String("subscription_id", res.Failed.SubscriptionID),
+			slog.Int64("amount", res.Failed.Amount),
+			slog.String("reason", res.Failed.Reason),
+		)
+	default:
+		outcome = "pending"
+	}
+
+	p.metrics.IncCounter(MetricRefundPolls, map[string]string{"outcome": outcome})
+	return outcome
+}
@@ -0,0 +1,150 @@
+package refunds
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/recovery"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/send_queued_refund"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/workpool"
+)
+
+const MetricRefundDispatches = "refund_dispatches_total"
+
+// SenderConfig controls how the sender drains the refund outbox
+type SenderConfig struct {
+	BatchSize   int // maximum queued refunds fetched per pass
+	Concurrency int // maximum provider calls in flight
+}
+
+// SendResult summarizes one sender pass
+type SendResult struct {
+	Sent   int
+	Errors int // attempts that failed and were postponed
+	Failed int // refunds whose last allowed attempt failed, left to be refunded by hand
+}
+
+// Sender sends the refunds queued in the outbox, by bulk cancellations and single ones
+// the provider failed, to the billing provider. Each one it sends is then tracked by the Poller like any other.
+type Sender struct {
+	outbox  contracts.RefundOutboxRepository
+	sender  send_queued_refund.UseCase
+	clock   domain.Clock
+	metrics contracts.Metrics
+	logger  *slog.Logger
+	cfg     SenderConfig
+}
+
+// NewSender creates a refund outbox sender
+func NewSender(outbox contracts.RefundOutboxRepository, sender send_queued_refund.UseCase, clock domain.Clock, metrics contracts.Metrics, logger *slog.Logger, cfg SenderConfig) *Sender {
+	if cfg.Concurrency < 1 {
+		cfg.Concurrency = 1
+	}
+	return &Sender{
+		outbox:  outbox,
+		sender:  sender,
+		clock:   clock,
+		metrics: metrics,
+		logger:  logger,
+		cfg:     cfg,
+	}
+}
+
+// Run executes a pass every interval until ctx is cancelled
+func (s *Sender) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.RunOnce(ctx); err != nil && ctx.Err() == nil {
+			s.logger.ErrorContext(ctx, "refund send pass failed", slog.Any("error", err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce sends every queued refund that is due, up to BatchSize
+func (s *Sender) RunOnce(ctx context.Context) (SendResult, error) {
+	queued, err := s.outbox.FindDue(ctx, s.clock.Now(), s.cfg.BatchSize)
+	if err != nil {
+		return SendResult{}, err
+	}
+
+	var (
+		mu     sync.Mutex
+		result SendResult
+	)
+	// Provider calls in flight finish during shutdown, so their outcome is recorded;
+	// ctx only stops new ones
+	_, err = workpool.Run(ctx, queued, workpool.Config{Concurrency: s.cfg.Concurrency, Detach: true}, func(ctx context.Context, refund *domain.QueuedRefund) error {
+		outcome := s.send(ctx, refund.ID())
+
+		mu.Lock()
+		defer mu.Unlock()
+		switch outcome {
+		case outcomeSent:
+			result.Sent++
+		case outcomeFailed:
+			result.Failed++
+		default:
+			result.Errors++
+		}
+		return nil
+	})
+	if err != nil {
+		return result, err
+	}
+
+	if len(queued) > 0 {
+		s.logger.InfoContext(ctx, "refund send pass complete",
+			slog.Int("sent", result.Sent),
+			slog.Int("errors", result.Errors),
+			slog.Int("failed", result.Failed),
+		)
+	}
+
+	return result, nil
+}
+
+// Outcomes of sending a queued refund, as refund_dispatches_total counts them
+const (
+	outcomeSent   = "sent"
+	outcomeError  = "error"
+	outcomeFailed = "failed"
+)
+
+// send sends a single queued refund and returns its outcome
+func (s *Sender) send(ctx context.Context, queuedRefundID string) string {
+	log := s.logger.With(slog.String("queued_refund_id", queuedRefundID))
+
+	var refund *domain.Refund
+	err := recovery.Do(ctx, s.logger, s.metrics, "refund_sender", func() (err error) {
+		refund, err = s.sender.Execute(ctx, queuedRefundID)
+		return err
+	})
+
+	outcome := outcomeSent
+	switch {
+	case errors.Is(err, domain.ErrQueuedRefundFailed):
+		outcome = outcomeFailed
+		log.ErrorContext(ctx, "queued refund failed every attempt; refund it by hand", slog.Any("error", err))
+	case err != nil:
+		outcome = outcomeError
+		log.ErrorContext(ctx, "sending queued refund failed", slog.Any("error", err))
+	default:
+		log.InfoContext(ctx, "queued refund sent", slog.String("subscription_id", refund.SubscriptionID()), slog.Int64("amount", refund.Amount()))
+	}
+
+	s.metrics.IncCounter(MetricRefundDispatches, map[string]string{"outcome": outcome})
+	return outcome
+}
@@ -0,0 +1,190 @@
+package renewal
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/recovery"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/renew_subscription"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/workpool"
+)
+
+const MetricRenewals = "renewals_total"
+
+// Config controls how the scheduler selects and processes renewals
+type Config struct {
+	Window      time.Duration // renew subscriptions whose period ends within this window
+	BatchSize   int           // maximum subscriptions renewed per pass
+	Concurrency int           // maximum renewals in flight
+}
+
+// Result summarizes one scheduler pass
+type Result struct {
+	Renewed int
+	PastDue int // renewed, but the charge was declined and dunning started
+	Skipped int
+	Failed  int
+}
+
+// Scheduler finds subscriptions due for renewal and renews them
+type Scheduler struct {
+	finder  contracts.SubscriptionScanRepository
+	cycles  contracts.BillingCycleSource
+	renewer renew_subscription.UseCase
+	clock   domain.Clock
+	metrics contracts.Metrics
+	logger  *slog.Logger
+	cfg     Config
+}
+
+// NewScheduler creates a renewal scheduler
+func NewScheduler(finder contracts.SubscriptionScanRepository, cycles contracts.BillingCycleSource, renewer renew_subscription.UseCase, clock domain.Clock, metrics contracts.Metrics, logger *slog.Logger, cfg Config) *Scheduler {
+	if cfg.Concurrency < 1 {
+		cfg.Concurrency = 1
+	}
+	return &Scheduler{
+		finder:  finder,
+		cycles:  cycles,
+		renewer: renewer,
+		clock:   clock,
+		metrics: metrics,
+		logger:  logger,
+		cfg:     cfg,
+	}
+}
+
+// Run executes a pass every interval until ctx is cancelled
+func (s *Scheduler) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.RunOnce(ctx); err != nil && ctx.Err() == nil {
+			s.logger.ErrorContext(ctx, "renewal pass failed", slog.Any("error", err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce renews every subscription currently due, up to BatchSize
+func (s *Scheduler) RunOnce(ctx context.Context) (Result, error) {
+	ids, err := s.due(ctx, s.clock.Now().Add(s.cfg.Window))
+	if err != nil {
+		return Result{}, err
+	}
+
+	var (
+		mu     sync.Mutex
+		result Result
+	)
+	// Renewals already started finish at shutdown; ctx only stops new ones
+	_, err = workpool.Run(ctx, ids, workpool.Config{Concurrency: s.cfg.Concurrency, Detach: true}, func(ctx context.Context, id string) error {
+		outcome := s.renew(ctx, id)
+
+		mu.Lock()
+		defer mu.Unlock()
+		switch outcome {
+		case "renewed":
+			result.Renewed++
+		case "past_due":
+			result.PastDue++
+		case "skipped":
+			result.Skipped++
+		default:
+			result.Failed++
+		}
+		return nil
+	})
+	if err != nil {
+		return result, err
+	}
+
+	s.logger.InfoContext(ctx, "renewal pass complete",
+		slog.Int("renewed", result.Renewed),
+		slog.Int("past_due", result.PastDue),
+		slog.Int("skipped", result.Skipped),
+		slog.Int("failed", result.Failed),
+	)
+
+	return result, nil
+}
+
+// due streams the active subscriptions and returns the IDs of up to BatchSize whose
+// period, in their plan's billing cycle, ends by dueBefore. Only the IDs are kept, so a
+// pass holds one batch however many subscriptions are active.
+func (s *Scheduler) due(ctx context.Context, dueBefore time.Time) ([]string, error) {
+	// Cycles are the plan's, so each plan is looked up once per pass
+	cycles := make(map[string]domain.BillingCycle)
+	// A subscription is renewed at most once per pass; the domain rejects a second
+	// renewal of the same period across passes
+	seen := make(map[string]struct{})
+	ids := make([]string, 0, s.cfg.BatchSize)
+
+	err := s.finder.ForEachByStatus(ctx, domain.StatusActive, func(sub *domain.Subscription) error {
+		cycle, ok := cycles[sub.PlanID()]
+		if !ok {
+			var err error
+			if cycle, err = s.cycles.CycleFor(ctx, sub); err != nil {
+				return err
+			}
+			cycles[sub.PlanID()] = cycle
+		}
+		if sub.CurrentPeriodEnd(cycle).After(dueBefore) {
+			return nil
+		}
+		if _, dup := seen[sub.ID()]; dup {
+			return nil
+		}
+		seen[sub.ID()] = struct{}{}
+		ids = append(ids, sub.ID())
+		if len(ids) >= s.cfg.BatchSize {
+			return errBatchFull
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errBatchFull) {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// errBatchFull stops a scan once a pass has a full batch
+var errBatchFull = errors.New("renewal batch full")
+
+// renew renews a single subscription and reports the outcome
+func (s *Scheduler) renew(ctx context.Context, subscriptionID string) string {
+	outcome := "renewed"
+
+	var result *renew_subscription.Result
+	err := recovery.Do(ctx, s.logger, s.metrics, "renewer", func() (err error) {
+		result, err = s.renewer.Execute(ctx, subscriptionID)
+		return err
+	})
+	switch {
+	case err == nil && result.PastDue != nil:
+		outcome = "past_due"
+	case err == nil:
+	case errors.Is(err, domain.ErrRenewalNotDue), errors.Is(err, domain.ErrNotRenewable):
+		// Renewed or cancelled since the query ran
+		outcome = "skipped"
+	default:
+		outcome = "failed"
+		s.logger.ErrorContext(ctx, "renewal failed",
+			slog.String("subscription_id", subscriptionID),
+			slog.Any("error", err),
+		)
+	}
+
+	s.metrics.IncCounter(MetricRenewals, map[string]string{"outcome": outcome})
+	return outcome
+}
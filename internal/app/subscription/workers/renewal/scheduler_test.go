@@ -0,0 +1,73 @@
+package renewal
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/adapters"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/logging"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/testkit"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/testkit/builders"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/renew_subscription"
+)
+
+type stubRenewer struct {
+	mu  sync.Mutex
+	ids []string
+}
+
+func (s *stubRenewer) Execute(_ context.Context, subscriptionID string) (*renew_subscription.Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ids = append(s.ids, subscriptionID)
+	return &renew_subscription.Result{}, nil
+}
+
+func (s *stubRenewer) renewed() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sort.Strings(s.ids)
+	return s.ids
+}
+
+// Periods of 30 days from 1 January end on 31 January
+func TestScheduler_RenewsTheActiveSubscriptionsDue(t *testing.T) {
+	subs := testkit.NewFakeSubscriptions().With(
+		builders.NewSubscriptionBuilder().WithID("sub-due").Build(),
+		builders.NewSubscriptionBuilder().WithID("sub-later").InPeriodFrom(builders.DefaultStartDate.AddDate(0, 0, 10)).Build(),
+		builders.NewSubscriptionBuilder().WithID("sub-past-due").PastDue().Build(),
+	)
+	renewer := &stubRenewer{}
+	clock := domain.FixedClock{FixedTime: time.Date(2024, 1, 30, 0, 0, 0, 0, time.UTC)}
+	s := NewScheduler(subs, adapters.StaticBillingCycle{Cycle: domain.CycleOfDays(30)}, renewer, clock, adapters.NoopMetrics{}, logging.Discard(), Config{Window: 48 * time.Hour, BatchSize: 10})
+
+	result, err := s.RunOnce(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, Result{Renewed: 1}, result)
+	assert.Equal(t, []string{"sub-due"}, renewer.renewed())
+}
+
+func TestScheduler_StopsTheScanAtBatchSize(t *testing.T) {
+	subs := testkit.NewFakeSubscriptions().With(
+		builders.NewSubscriptionBuilder().WithID("sub-1").Build(),
+		builders.NewSubscriptionBuilder().WithID("sub-2").Build(),
+		builders.NewSubscriptionBuilder().WithID("sub-3").Build(),
+	)
+	renewer := &stubRenewer{}
+	clock := domain.FixedClock{FixedTime: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)}
+	s := NewScheduler(subs, adapters.StaticBillingCycle{Cycle: domain.CycleOfDays(30)}, renewer, clock, adapters.NoopMetrics{}, logging.Discard(), Config{BatchSize: 2})
+
+	result, err := s.RunOnce(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Renewed)
+	assert.Len(t, renewer.renewed(), 2)
+}
@@ -0,0 +1,148 @@
+package renewalnotices
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/recovery"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/usecases/notify_renewal"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/workpool"
+)
+
+const MetricRenewalNotices = "renewal_notices_total"
+
+// Config controls which subscriptions the scheduler looks at and how
+type Config struct {
+	MaxNoticeDays    int64 // look at subscriptions that renew within this many days
+	BillingCycleDays int64 // period length of plans without a billing interval
+	BatchSize        int   // maximum subscriptions fetched per pass
+	Concurrency      int   // maximum notices in flight
+}
+
+// Result summarizes one scheduler pass
+type Result struct {
+	Sent    int
+	NotDue  int // within the longest notice, but not their own
+	Skipped int
+	Failed  int
+}
+
+// Scheduler sends each subscription's renewal notice once it is within its notice period
+type Scheduler struct {
+	finder   contracts.RenewalNoticeRepository
+	notifier notify_renewal.UseCase
+	clock    domain.Clock
+	metrics  contracts.Metrics
+	logger   *slog.Logger
+	cfg      Config
+}
+
+// NewScheduler creates a renewal notice scheduler
+func NewScheduler(finder contracts.RenewalNoticeRepository, notifier notify_renewal.UseCase, clock domain.Clock, metrics contracts.Metrics, logger *slog.Logger, cfg Config) *Scheduler {
+	if cfg.Concurrency < 1 {
+		cfg.Concurrency = 1
+	}
+	return &Scheduler{
+		finder:   finder,
+		notifier: notifier,
+		clock:    clock,
+		metrics:  metrics,
+		logger:   logger,
+		cfg:      cfg,
+	}
+}
+
+// Run executes a pass every interval until ctx is cancelled
+func (s *Scheduler) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.RunOnce(ctx); err != nil && ctx.Err() == nil {
+			s.logger.ErrorContext(ctx, "renewal notice pass failed", slog.Any("error", err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce notices every unnoticed subscription renewing within MaxNoticeDays, up to
+// BatchSize. Each subscription's own notice period decides whether it is due yet.
+func (s *Scheduler) RunOnce(ctx context.Context) (Result, error) {
+	renewsBefore := s.clock.Now().AddDate(0, 0, int(s.cfg.MaxNoticeDays))
+
+	subs, err := s.finder.FindRenewingUnnoticed(ctx, renewsBefore, s.cfg.BillingCycleDays, s.cfg.BatchSize)
+	if err != nil {
+		return Result{}, err
+	}
+
+	var (
+		mu     sync.Mutex
+		result Result
+	)
+	// Notices in flight finish during shutdown; ctx only stops new ones
+	_, err = workpool.Run(ctx, subs, workpool.Config{Concurrency: s.cfg.Concurrency, Detach: true}, func(ctx context.Context, sub *domain.Subscription) error {
+		outcome := s.notice(ctx, sub.ID())
+
+		mu.Lock()
+		defer mu.Unlock()
+		switch outcome {
+		case "sent":
+			result.Sent++
+		case "not_due":
+			result.NotDue++
+		case "skipped":
+			result.Skipped++
+		default:
+			result.Failed++
+		}
+		return nil
+	})
+	if err != nil {
+		return result, err
+	}
+
+	s.logger.InfoContext(ctx, "renewal notice pass complete",
+		slog.Int("sent", result.Sent),
+		slog.Int("not_due", result.NotDue),
+		slog.Int("skipped", result.Skipped),
+		slog.Int("failed", result.Failed),
+	)
+
+	return result, nil
+}
+
+// notice sends a single subscription's notice and reports the outcome
+func (s *Scheduler) notice(ctx context.Context, subscriptionID string) string {
+	var outcome string
+	log := s.logger.With(slog.String("subscription_id", subscriptionID))
+
+	err := recovery.Do(ctx, s.logger, s.metrics, "renewal_notice_scheduler", func() error {
+		_, err := s.notifier.Execute(ctx, subscriptionID)
+		return err
+	})
+	switch {
+	case err == nil:
+		outcome = "sent"
+	case errors.Is(err, domain.ErrRenewalNoticeNotDue), errors.Is(err, domain.ErrRenewalNoticeNotRequired):
+		outcome = "not_due"
+	case errors.Is(err, domain.ErrRenewalNoticeAlreadySent), errors.Is(err, domain.ErrNotActive):
+		// Noticed, renewed or cancelled since the query ran
+		outcome = "skipped"
+	default:
+		outcome = "failed"
+		log.ErrorContext(ctx, "renewal notice failed", slog.Any("error", err))
+	}
+
+	s.metrics.IncCounter(MetricRenewalNotices, map[string]string{"outcome": outcome})
+	return outcome
+}
@@ -0,0 +1,158 @@
+// Package workpool runs a function over many items with bounded concurrency, for bulk
+// operations such as bulk cancellation, renewal passes and partitioned exports. It
+// bounds each item's time, collects the failures in the items' order, reports progress
+// and stops starting items once the context ends. Items that may panic recover
+// themselves, with recovery.Do.
+package workpool
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/wuyiadepoju/subscription-management/internal/lifecycle"
+)
+
+// Config shapes a run
+type Config struct {
+	Concurrency int           // items in flight, at least 1
+	ItemTimeout time.Duration // bounds each item; zero leaves only the run's context
+	// StopOnError stops starting items after the first failure and cancels those in
+	// flight, for runs that are only useful whole, such as an export
+	StopOnError bool
+	// Detach lets items already started finish once ctx ends, within the shutdown's
+	// drain deadline (see lifecycle.Detach); ctx then only stops new items
+	Detach bool
+	// Progress is called after each item finishes, one call at a time
+	Progress func(Progress)
+}
+
+// Progress counts the items finished so far
+type Progress struct {
+	Total     int
+	Completed int
+	Failed    int
+}
+
+// Done is the number of items finished, whether they succeeded or not
+func (p Progress) Done() int {
+	return p.Completed + p.Failed
+}
+
+// Failure is an item whose function returned an error
+type Failure[T any] struct {
+	Index int // the item's position in the items run
+	Item  T
+	Err   error
+}
+
+// Result summarizes a run
+type Result[T any] struct {
+	Completed int
+	Failed    []Failure[T] // in the items' order
+	Skipped   int          // items never started, because ctx ended or an item failed
+
+	first error
+}
+
+// Err joins the failures' errors, or is nil when every item started succeeded
+func (r Result[T]) Err() error {
+	errs := make([]error, len(r.Failed))
+	for i, f := range r.Failed {
+		errs[i] = f.Err
+	}
+	return errors.Join(errs...)
+}
+
+// FirstErr is the error of the item that failed first, or nil. With StopOnError it is
+// the cause; the items cancelled because of it fail after it.
+func (r Result[T]) FirstErr() error {
+	return r.first
+}
+
+// Run calls fn for every item, Concurrency at a time, and waits for the items it
+// started. It returns ctx's error when ctx ended before every item was started;
+// failures of single items are only in the result.
+func Run[T any](ctx context.Context, items []T, cfg Config, fn func(ctx context.Context, item T) error) (Result[T], error) {
+	if cfg.Concurrency < 1 {
+		cfg.Concurrency = 1
+	}
+
+	work, cancel := context.WithCancel(ctx)
+	if cfg.Detach {
+		work, cancel = lifecycle.Detach(ctx)
+	}
+	defer cancel()
+	// stop ends when ctx does, or at the first failure with StopOnError
+	stop, stopAll := context.WithCancel(ctx)
+	defer stopAll()
+
+	var (
+		mu       sync.Mutex
+		result   Result[T]
+		progress = Progress{Total: len(items)}
+		wg       sync.WaitGroup
+		sem      = make(chan struct{}, cfg.Concurrency)
+		started  int
+	)
+	for i, item := range items {
+		if stop.Err() == nil {
+			select {
+			case <-stop.Done():
+			case sem <- struct{}{}:
+			}
+		}
+		if stop.Err() != nil {
+			break
+		}
+		started++
+
+		wg.Add(1)
+		go func(i int, item T) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			err := runItem(work, cfg.ItemTimeout, item, fn)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				result.Failed = append(result.Failed, Failure[T]{Index: i, Item: item, Err: err})
+				progress.Failed++
+				if result.first == nil {
+					result.first = err
+				}
+				if cfg.StopOnError {
+					stopAll()
+					cancel()
+				}
+			} else {
+				result.Completed++
+				progress.Completed++
+			}
+			if cfg.Progress != nil {
+				cfg.Progress(progress)
+			}
+		}(i, item)
+	}
+	wg.Wait()
+
+	sort.Slice(result.Failed, func(i, j int) bool { return result.Failed[i].Index < result.Failed[j].Index })
+	result.Skipped = len(items) - started
+	if result.Skipped > 0 && ctx.Err() != nil {
+		return result, ctx.Err()
+	}
+	return result, nil
+}
+
+// runItem calls fn under the item timeout
+func runItem[T any](ctx context.Context, timeout time.Duration, item T, fn func(context.Context, T) error) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return fn(ctx, item)
+}
@@ -0,0 +1,139 @@
+package workpool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func numbers(n int) []int {
+	items := make([]int, n)
+	for i := range items {
+		items[i] = i
+	}
+	return items
+}
+
+func TestRun_BoundsConcurrency(t *testing.T) {
+	var inFlight, peak atomic.Int32
+
+	result, err := Run(context.Background(), numbers(20), Config{Concurrency: 3}, func(ctx context.Context, n int) error {
+		now := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			old := peak.Load()
+			if now <= old || peak.CompareAndSwap(old, now) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		return nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, 20, result.Completed)
+	assert.LessOrEqual(t, peak.Load(), int32(3))
+	assert.NoError(t, result.Err())
+}
+
+func TestRun_CollectsFailuresInTheItemsOrder(t *testing.T) {
+	result, err := Run(context.Background(), numbers(10), Config{Concurrency: 4}, func(ctx context.Context, n int) error {
+		if n%3 == 0 {
+			return fmt.Errorf("item %d failed", n)
+		}
+		return nil
+	})
+
+	require.NoError(t, err, "item failures are only in the result")
+	assert.Equal(t, 6, result.Completed)
+	require.Len(t, result.Failed, 4)
+	for i, want := range []int{0, 3, 6, 9} {
+		assert.Equal(t, want, result.Failed[i].Item)
+		assert.Equal(t, want, result.Failed[i].Index)
+	}
+	assert.EqualError(t, result.Err(), "item 0 failed\nitem 3 failed\nitem 6 failed\nitem 9 failed")
+}
+
+func TestRun_ItemTimeoutBoundsEachItem(t *testing.T) {
+	result, err := Run(context.Background(), numbers(2), Config{Concurrency: 2, ItemTimeout: 10 * time.Millisecond}, func(ctx context.Context, n int) error {
+		if n == 1 {
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		return nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Completed)
+	require.Len(t, result.Failed, 1)
+	assert.ErrorIs(t, result.Failed[0].Err, context.DeadlineExceeded)
+}
+
+func TestRun_StopOnErrorCancelsTheRest(t *testing.T) {
+	broken := errors.New("partition unreadable")
+	var started atomic.Int32
+
+	result, err := Run(context.Background(), numbers(50), Config{Concurrency: 2, StopOnError: true}, func(ctx context.Context, n int) error {
+		started.Add(1)
+		if n == 1 {
+			return broken
+		}
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	require.NoError(t, err)
+	assert.ErrorIs(t, result.FirstErr(), broken, "the cause, not the items cancelled after it")
+	assert.Equal(t, int(started.Load()), len(result.Failed))
+	assert.Equal(t, 50, len(result.Failed)+result.Skipped)
+	assert.Positive(t, result.Skipped)
+}
+
+func TestRun_StopsStartingItemsWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	result, err := Run(ctx, numbers(10), Config{Concurrency: 1}, func(ctx context.Context, n int) error {
+		if n == 2 {
+			cancel()
+		}
+		return nil
+	})
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 3, result.Completed)
+	assert.Equal(t, 7, result.Skipped)
+}
+
+func TestRun_ReportsProgressOneCallAtATime(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		calls []Progress
+	)
+	_, err := Run(context.Background(), numbers(5), Config{Concurrency: 5, Progress: func(p Progress) {
+		if !mu.TryLock() {
+			t.Error("progress reported concurrently")
+			return
+		}
+		defer mu.Unlock()
+		calls = append(calls, p)
+	}}, func(ctx context.Context, n int) error {
+		if n == 4 {
+			return errors.New("failed")
+		}
+		return nil
+	})
+
+	require.NoError(t, err)
+	require.Len(t, calls, 5)
+	for i, p := range calls {
+		assert.Equal(t, i+1, p.Done())
+	}
+	assert.Equal(t, Progress{Total: 5, Completed: 4, Failed: 1}, calls[4])
+}
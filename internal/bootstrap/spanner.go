@@ -0,0 +1,91 @@
+// Package bootstrap builds the resources a binary's components share. Every binary
+// opens its one Spanner client here, so repositories, workers and health checks use
+// the same session pool and the client closes in the same place at shutdown.
+package bootstrap
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"cloud.google.com/go/spanner"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/repo"
+	"github.com/wuyiadepoju/subscription-management/internal/config"
+	"github.com/wuyiadepoju/subscription-management/internal/lifecycle"
+)
+
+// Delays between warm-up pings while the database doesn't answer
+const (
+	warmUpBackoff    = 250 * time.Millisecond
+	warmUpMaxBackoff = 4 * time.Second
+)
+
+// Spanner opens the client the binary's components share. The pool opens
+// cfg.Spanner.MinSessions sessions up front, and the client is returned once the
+// database answers a ping or, if cfg.Spanner.WarmUp passes first, not at all. The
+// client is registered with app, so it closes after the components have drained and
+// before anything registered earlier, such as the tracer, flushes.
+func Spanner(app *lifecycle.App, cfg config.Config, logger *slog.Logger) (*spanner.Client, error) {
+	ctx := app.Context()
+	client, err := spanner.NewClientWithConfig(ctx, cfg.Spanner.DatabasePath(), clientConfig(cfg.Spanner))
+	if err != nil {
+		return nil, fmt.Errorf("create Spanner client: %w", err)
+	}
+
+	start := time.Now()
+	if err := warmUp(ctx, cfg.Spanner.WarmUp, func(ctx context.Context) error { return repo.Ping(ctx, client) }, logger); err != nil {
+		client.Close()
+		return nil, err
+	}
+	if cfg.Spanner.WarmUp > 0 {
+		logger.Info("spanner ready", slog.String("database", cfg.Spanner.DatabasePath()), slog.Duration("elapsed", time.Since(start)))
+	}
+
+	app.OnClose("spanner", func(context.Context) error {
+		client.Close()
+		return nil
+	})
+	return client, nil
+}
+
+// clientConfig sizes the session pool from s, keeping the library's defaults otherwise
+func clientConfig(s config.Spanner) spanner.ClientConfig {
+	pool := spanner.DefaultSessionPoolConfig
+	pool.MinOpened = uint64(s.MinSessions)
+	if pool.MaxOpened < pool.MinOpened {
+		pool.MaxOpened = pool.MinOpened
+	}
+	return spanner.ClientConfig{SessionPoolConfig: pool}
+}
+
+// warmUp pings until one succeeds, backing off between attempts, and gives up with
+// the last error once timeout passes. A zero timeout skips it.
+func warmUp(ctx context.Context, timeout time.Duration, ping func(ctx context.Context) error, logger *slog.Logger) error {
+	if timeout <= 0 {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	backoff := warmUpBackoff
+	for attempt := 1; ; attempt++ {
+		err := ping(ctx)
+		if err == nil {
+			return nil
+		}
+		logger.Warn("spanner not ready", slog.Int("attempt", attempt), slog.Any("error", err))
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("spanner not ready after %s: %w", timeout, err)
+		case <-timer.C:
+		}
+		if backoff *= 2; backoff > warmUpMaxBackoff {
+			backoff = warmUpMaxBackoff
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package bootstrap
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wuyiadepoju/subscription-management/internal/config"
+)
+
+var discard = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func TestWarmUp_RetriesUntilTheDatabaseAnswers(t *testing.T) {
+	pings := 0
+	err := warmUp(context.Background(), time.Minute, func(context.Context) error {
+		if pings++; pings < 3 {
+			return errors.New("connection refused")
+		}
+		return nil
+	}, discard)
+
+	require.NoError(t, err)
+	assert.Equal(t, 3, pings)
+}
+
+func TestWarmUp_GivesUpWithTheLastError(t *testing.T) {
+	refused := errors.New("connection refused")
+	err := warmUp(context.Background(), 100*time.Millisecond, func(context.Context) error { return refused }, discard)
+
+	assert.ErrorIs(t, err, refused)
+	assert.ErrorContains(t, err, "spanner not ready after 100ms")
+}
+
+func TestWarmUp_ZeroTimeoutSkipsIt(t *testing.T) {
+	err := warmUp(context.Background(), 0, func(context.Context) error {
+		t.Fatal("pinged with warm-up disabled")
+		return nil
+	}, discard)
+
+	assert.NoError(t, err)
+}
+
+func TestClientConfig_SizesThePool(t *testing.T) {
+	cfg := clientConfig(config.Spanner{MinSessions: 25})
+	assert.Equal(t, uint64(25), cfg.SessionPoolConfig.MinOpened)
+	assert.Equal(t, spanner.DefaultSessionPoolConfig.MaxOpened, cfg.SessionPoolConfig.MaxOpened)
+
+	cfg = clientConfig(config.Spanner{MinSessions: 1000})
+	assert.Equal(t, uint64(1000), cfg.SessionPoolConfig.MaxOpened, "the pool can hold the sessions it opens")
+}
@@ -0,0 +1,322 @@
+// Package config loads the settings shared by every binary: Spanner, the billing API,
+// timeouts, logging, metrics and feature toggles. Values come from, in increasing
+// precedence, built-in defaults, an optional YAML file, environment variables and
+// command-line flags, and are validated once at startup.
+//
+// Secrets are not configuration: credentials such as BILLING_TOKEN or PADDLE_API_KEY
+// are read through contracts.SecretProvider so they can be rotated without a restart.
+// Configuration only says where they are read from.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Config is the typed configuration of one binary
+type Config struct {
+	Spanner          Spanner         `yaml:"spanner"`
+	Billing          Billing         `yaml:"billing"`
+	BillingCycleDays int64           `yaml:"billing_cycle_days"`
+	Referrals        Referrals       `yaml:"referrals"`
+	Discounts        Discounts       `yaml:"discounts"`
+	Log              Log             `yaml:"log"`
+	Metrics          Metrics         `yaml:"metrics"`
+	Telemetry        Telemetry       `yaml:"telemetry"`
+	Debug            Debug           `yaml:"debug"`
+	Health           Health          `yaml:"health"`
+	Faults           Faults          `yaml:"faults"`
+	Secrets          Secrets         `yaml:"secrets"`
+	Events           Events          `yaml:"events"`
+	Environment      string          `yaml:"environment"` // production refuses fault injection
+	Features         map[string]bool `yaml:"features"`
+	ShutdownTimeout  time.Duration   `yaml:"shutdown_timeout"` // how long work in flight may drain
+}
+
+// Spanner locates the database
+type Spanner struct {
+	Project  string        `yaml:"project"`
+	Instance string        `yaml:"instance"`
+	Database string        `yaml:"database"`
+	Timeout  time.Duration `yaml:"timeout"` // per operation; zero disables it
+
+	MinSessions int64         `yaml:"min_sessions"` // opened when the client is created
+	WarmUp      time.Duration `yaml:"warm_up"`      // how long startup waits for the database; zero skips it
+
+	QueryHints []string `yaml:"query_hints"` // e.g. "subscriptions.FindDueForCancellation index=idx_x optimizer_version=6"
+	Priority   string   `yaml:"priority"`    // high, medium or low; empty uses the binary's operation class
+}
+
+// DatabasePath is the database's fully qualified resource name
+func (s Spanner) DatabasePath() string {
+	return fmt.Sprintf("projects/%s/instances/%s/databases/%s", s.Project, s.Instance, s.Database)
+}
+
+// Billing configures the billing provider clients
+type Billing struct {
+	Provider string        `yaml:"provider"` // http or paddle
+	URL      string        `yaml:"url"`      // internal billing API, http provider only
+	Timeout  time.Duration `yaml:"timeout"`  // per call attempt; zero disables it
+
+	Auth         string   `yaml:"auth"` // none, bearer, api_key or oauth2
+	APIKeyHeader string   `yaml:"api_key_header"`
+	TokenURL     string   `yaml:"token_url"` // oauth2 only
+	ClientID     string   `yaml:"client_id"` // oauth2 only
+	Scopes       []string `yaml:"scopes"`    // oauth2 only
+
+	PaddleSandbox        bool     `yaml:"paddle_sandbox"`
+	PaddlePlans          []string `yaml:"paddle_plans"`           // plans routed to Paddle
+	PaddleCustomerPrefix string   `yaml:"paddle_customer_prefix"` // customers routed to Paddle
+}
+
+// Referrals configures the credit granted when a referred subscription's first renewal
+// is paid; zero grants none
+type Referrals struct {
+	ReferrerCredit int64 `yaml:"referrer_credit"` // cents, to the customer whose code was used
+	RefereeCredit  int64 `yaml:"referee_credit"`  // cents, to the customer who signed up with it
+}
+
+// Discounts limits how far discounts stack on one charge; zero leaves a limit off
+type Discounts struct {
+	MaxStacked    int64 `yaml:"max_stacked"`     // most discounts applied to one charge
+	MaxPercentOff int64 `yaml:"max_percent_off"` // basis points of a charge they may take off together
+}
+
+// Log configures the structured logger
+type Log struct {
+	Level  string `yaml:"level"`  // debug, info, warn or error
+	Format string `yaml:"format"` // json or text
+}
+
+// Metrics configures the Prometheus endpoint and pushing metrics to a backend that
+// doesn't scrape
+type Metrics struct {
+	Addr           string        `yaml:"addr"`            // empty disables /metrics
+	Exporter       string        `yaml:"exporter"`        // none, otlp, datadog or stdout
+	ExportInterval time.Duration `yaml:"export_interval"` // time between pushes
+}
+
+// Telemetry selects where traces are exported and locates the backends that traces and
+// pushed metrics are sent to
+type Telemetry struct {
+	ServiceName  string   `yaml:"service_name"`  // defaults to the binary's name
+	Traces       string   `yaml:"traces"`        // none, otlp, datadog or stdout; see TracesExporter
+	SampleRatio  float64  `yaml:"sample_ratio"`  // fraction of new traces recorded
+	OTLPEndpoint string   `yaml:"otlp_endpoint"` // collector base URL, e.g. http://localhost:4318
+	OTLPHeaders  []string `yaml:"otlp_headers"`  // key=value, for collectors that require authentication
+	DatadogAgent string   `yaml:"datadog_agent"` // agent host; traces use port 8126 and DogStatsD 8125
+}
+
+// TracesExporter is the traces exporter to use. Unset, it is otlp when an OTLP endpoint
+// is configured and none otherwise, as before exporters were selectable.
+func (t Telemetry) TracesExporter() string {
+	switch {
+	case t.Traces != "":
+		return t.Traces
+	case t.OTLPEndpoint != "":
+		return "otlp"
+	default:
+		return "none"
+	}
+}
+
+// Debug configures the pprof and expvar endpoints
+type Debug struct {
+	Addr string `yaml:"addr"` // ops port; empty disables the endpoints
+}
+
+// Health configures the liveness and readiness probes
+type Health struct {
+	Addr    string        `yaml:"addr"`    // ops port; empty disables /healthz and /readyz
+	Timeout time.Duration `yaml:"timeout"` // per readiness check
+}
+
+// Faults configures fault injection for resilience rehearsals
+type Faults struct {
+	Rules  []string `yaml:"rules"`  // e.g. "billing.* latency=2s error=50%"
+	Header bool     `yaml:"header"` // accept rules in the X-Fault-Inject request header
+}
+
+// Secrets selects the secret provider
+type Secrets struct {
+	Backend  string        `yaml:"backend"`   // env or secret-manager
+	Project  string        `yaml:"project"`   // secret-manager only
+	CacheTTL time.Duration `yaml:"cache_ttl"` // secret-manager only
+}
+
+// Events configures publishing subscription events
+type Events struct {
+	Topic   string        `yaml:"topic"`   // projects/<project>/topics/<topic>; empty publishes none
+	Timeout time.Duration `yaml:"timeout"` // per event
+}
+
+// Default returns the configuration used when nothing overrides it, suited to the
+// local emulator and mock billing API
+func Default() Config {
+	return Config{
+		Spanner: Spanner{
+			Project:  "test-project",
+			Instance: "test-instance",
+			Database: "subscription-db",
+			Timeout:  5 * time.Second,
+
+			MinSessions: 100,
+			WarmUp:      30 * time.Second,
+		},
+		Billing: Billing{
+			Provider:     "http",
+			URL:          "http://localhost:8081",
+			Timeout:      10 * time.Second,
+			Auth:         "none",
+			APIKeyHeader: "X-API-Key",
+		},
+		BillingCycleDays: 30,
+		Secrets:          Secrets{Backend: "env", CacheTTL: 5 * time.Minute},
+		Events:           Events{Timeout: 10 * time.Second},
+		Metrics:          Metrics{Exporter: "none", ExportInterval: 30 * time.Second},
+		Telemetry:        Telemetry{SampleRatio: 1, DatadogAgent: "localhost"},
+		Health:           Health{Timeout: 2 * time.Second},
+		Log:              Log{Level: "info", Format: "json"},
+		ShutdownTimeout:  30 * time.Second,
+		Environment:      "development",
+	}
+}
+
+// Enabled reports whether the named feature toggle is on; unknown toggles are off
+func (c Config) Enabled(feature string) bool {
+	return c.Features[feature]
+}
+
+// Validate checks the sections a binary uses and reports every problem at once
+func (c Config) Validate(sections Section) error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	if sections.has(SectionSpanner) {
+		check(c.Spanner.Project != "", "spanner project is required")
+		check(c.Spanner.Instance != "", "spanner instance is required")
+		check(c.Spanner.Database != "", "spanner database is required")
+		check(c.Spanner.Timeout >= 0, "spanner timeout must not be negative")
+		check(c.Spanner.MinSessions >= 0, "spanner min sessions must not be negative")
+		check(c.Spanner.WarmUp >= 0, "spanner warm-up must not be negative")
+	}
+
+	if sections.has(SectionBilling) {
+		b := c.Billing
+		check(b.Timeout >= 0, "billing timeout must not be negative")
+		if u, err := url.Parse(b.URL); err != nil || u.Scheme == "" || u.Host == "" {
+			check(b.Provider != "http", "billing url %q must be an absolute URL", b.URL)
+		}
+		switch b.Auth {
+		case "none", "bearer":
+		case "api_key":
+			check(b.APIKeyHeader != "", "billing api_key auth requires an API key header")
+		case "oauth2":
+			check(b.TokenURL != "", "billing oauth2 auth requires a token URL")
+			check(b.ClientID != "", "billing oauth2 auth requires a client ID")
+		default:
+			check(false, "billing auth %q must be none, bearer, api_key or oauth2", b.Auth)
+		}
+	}
+
+	if sections.has(SectionBillingProviders) {
+		check(c.Billing.Provider == "http" || c.Billing.Provider == "paddle", "billing provider %q must be http or paddle", c.Billing.Provider)
+	}
+
+	if sections.has(SectionRenewal) {
+		check(c.BillingCycleDays > 0, "billing cycle days must be positive")
+		check(c.Referrals.ReferrerCredit >= 0, "referral referrer credit must not be negative")
+		check(c.Referrals.RefereeCredit >= 0, "referral referee credit must not be negative")
+	}
+
+	if sections.has(SectionDiscounts) {
+		check(c.Discounts.MaxStacked >= 0, "discount max stacked must not be negative")
+		check(c.Discounts.MaxPercentOff >= 0 && c.Discounts.MaxPercentOff <= 10000, "discount max percent off must be between 0 and 10000 basis points")
+	}
+
+	if sections.has(SectionFaults) && c.Environment == "production" {
+		check(len(c.Faults.Rules) == 0 && !c.Faults.Header, "fault injection is not allowed in production")
+	}
+
+	if sections.has(SectionSecrets) {
+		switch c.Secrets.Backend {
+		case "env":
+		case "secret-manager":
+			check(c.Secrets.Project != "", "secret-manager backend requires a secrets project")
+			check(c.Secrets.CacheTTL > 0, "secrets cache TTL must be positive")
+		default:
+			check(false, "secrets backend %q must be env or secret-manager", c.Secrets.Backend)
+		}
+	}
+
+	if sections.has(SectionEvents) {
+		if t := c.Events.Topic; t != "" {
+			parts := strings.Split(t, "/")
+			check(len(parts) == 4 && parts[0] == "projects" && parts[1] != "" && parts[2] == "topics" && parts[3] != "", "events topic %q must be projects/<project>/topics/<topic>", t)
+		}
+		check(c.Events.Timeout > 0, "events timeout must be positive")
+	}
+
+	if sections.has(SectionMetrics) {
+		m := c.Metrics
+		switch m.Exporter {
+		case "none", "stdout":
+		case "otlp":
+			check(c.Telemetry.OTLPEndpoint != "", "otlp metrics exporter requires an OTLP endpoint")
+		case "datadog":
+			check(c.Telemetry.DatadogAgent != "", "datadog metrics exporter requires a Datadog agent host")
+		default:
+			check(false, "metrics exporter %q must be none, otlp, datadog or stdout", m.Exporter)
+		}
+		check(m.Exporter == "none" || m.ExportInterval > 0, "metrics export interval must be positive")
+	}
+
+	if sections.has(SectionHealth) {
+		check(c.Health.Timeout > 0, "health check timeout must be positive")
+	}
+
+	if sections.has(SectionTelemetry) {
+		t := c.Telemetry
+		switch exporter := t.TracesExporter(); exporter {
+		case "none", "stdout":
+		case "otlp":
+			check(t.OTLPEndpoint != "", "otlp traces exporter requires an OTLP endpoint")
+		case "datadog":
+			check(t.DatadogAgent != "", "datadog traces exporter requires a Datadog agent host")
+		default:
+			check(false, "traces exporter %q must be none, otlp, datadog or stdout", exporter)
+		}
+		check(t.SampleRatio >= 0 && t.SampleRatio <= 1, "trace sample ratio must be between 0 and 1")
+		if t.OTLPEndpoint != "" {
+			if u, err := url.Parse(t.OTLPEndpoint); err != nil || u.Scheme == "" || u.Host == "" {
+				check(false, "otlp endpoint %q must be an absolute URL", t.OTLPEndpoint)
+			}
+		}
+		for _, h := range t.OTLPHeaders {
+			key, _, ok := strings.Cut(h, "=")
+			check(ok && strings.TrimSpace(key) != "", "otlp header %q must be key=value", h)
+		}
+	}
+
+	check(c.ShutdownTimeout > 0, "shutdown timeout must be positive")
+
+	switch strings.ToLower(c.Log.Level) {
+	case "debug", "info", "warn", "error":
+	default:
+		check(false, "log level %q must be debug, info, warn or error", c.Log.Level)
+	}
+	switch strings.ToLower(c.Log.Format) {
+	case "json", "text":
+	default:
+		check(false, "log format %q must be json or text", c.Log.Format)
+	}
+
+	return errors.Join(errs...)
+}
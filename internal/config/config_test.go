@@ -0,0 +1,218 @@
+package config
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLoader(t *testing.T, sections Section, env map[string]string, args ...string) *Loader {
+	t.Helper()
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	l := NewLoader(fs, sections, Default())
+	l.lookupEnv = func(key string) (string, bool) {
+		v, ok := env[key]
+		return v, ok
+	}
+	require.NoError(t, fs.Parse(args))
+	return l
+}
+
+func writeFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+const all = SectionSpanner | SectionBilling | SectionBillingProviders | SectionRenewal | SectionMetrics | SectionDebug | SectionFaults | SectionSecrets | SectionTelemetry | SectionHealth | SectionDiscounts | SectionEvents
+
+func TestLoad_Defaults(t *testing.T) {
+	cfg, err := newTestLoader(t, all, nil).Load()
+	require.NoError(t, err)
+
+	assert.Equal(t, Default(), Config{
+		Spanner:          cfg.Spanner,
+		Billing:          cfg.Billing,
+		BillingCycleDays: cfg.BillingCycleDays,
+		Referrals:        cfg.Referrals,
+		Discounts:        cfg.Discounts,
+		Log:              cfg.Log,
+		Metrics:          cfg.Metrics,
+		Debug:            cfg.Debug,
+		Secrets:          cfg.Secrets,
+		Events:           cfg.Events,
+		Telemetry:        cfg.Telemetry,
+		Health:           cfg.Health,
+		ShutdownTimeout:  cfg.ShutdownTimeout,
+		Environment:      cfg.Environment,
+	})
+	assert.Equal(t, "projects/test-project/instances/test-instance/databases/subscription-db", cfg.Spanner.DatabasePath())
+}
+
+func TestLoad_Precedence(t *testing.T) {
+	path := writeFile(t, `
+spanner:
+  project: file-project
+  instance: file-instance
+  timeout: 2s
+billing:
+  url: https://billing.file
+  paddle_plans: [pro, team]
+billing_cycle_days: 7
+referrals:
+  referrer_credit: 1000
+discounts:
+  max_stacked: 2
+`)
+	env := map[string]string{
+		FileEnv:                   path,
+		"SPANNER_INSTANCE":        "env-instance",
+		"BILLING_URL":             "https://billing.env",
+		"REFERRAL_REFEREE_CREDIT": "500",
+	}
+
+	cfg, err := newTestLoader(t, all, env, "-billing-url", "https://billing.flag").Load()
+	require.NoError(t, err)
+
+	assert.Equal(t, "file-project", cfg.Spanner.Project)
+	assert.Equal(t, "env-instance", cfg.Spanner.Instance)
+	assert.Equal(t, "subscription-db", cfg.Spanner.Database)
+	assert.Equal(t, 2*time.Second, cfg.Spanner.Timeout)
+	assert.Equal(t, "https://billing.flag", cfg.Billing.URL)
+	assert.Equal(t, []string{"pro", "team"}, cfg.Billing.PaddlePlans)
+	assert.Equal(t, int64(7), cfg.BillingCycleDays)
+	assert.Equal(t, Referrals{ReferrerCredit: 1000, RefereeCredit: 500}, cfg.Referrals)
+	assert.Equal(t, Discounts{MaxStacked: 2}, cfg.Discounts)
+}
+
+func TestLoad_ConfigFlagOverridesEnvFile(t *testing.T) {
+	envFile := writeFile(t, "log:\n  level: debug\n")
+	flagFile := writeFile(t, "log:\n  level: warn\n")
+
+	cfg, err := newTestLoader(t, 0, map[string]string{FileEnv: envFile}, "-config", flagFile).Load()
+	require.NoError(t, err)
+	assert.Equal(t, "warn", cfg.Log.Level)
+}
+
+func TestLoad_RejectsUnknownFileKeys(t *testing.T) {
+	path := writeFile(t, "spanner:\n  projet: typo\n")
+
+	_, err := newTestLoader(t, SectionSpanner, nil, "-config", path).Load()
+	assert.ErrorContains(t, err, "projet")
+}
+
+func TestLoad_ReportsEveryValidationError(t *testing.T) {
+	env := map[string]string{"BILLING_CYCLE_DAYS": "0", "REFERRAL_REFEREE_CREDIT": "-1", "DISCOUNT_MAX_PERCENT_OFF": "12000", "BILLING_AUTH": "oauth2", "LOG_FORMAT": "xml"}
+
+	_, err := newTestLoader(t, all, env).Load()
+	require.Error(t, err)
+	assert.ErrorContains(t, err, "billing cycle days must be positive")
+	assert.ErrorContains(t, err, "referral referee credit must not be negative")
+	assert.ErrorContains(t, err, "discount max percent off must be between 0 and 10000")
+	assert.ErrorContains(t, err, "requires a token URL")
+	assert.ErrorContains(t, err, "requires a client ID")
+	assert.ErrorContains(t, err, `log format "xml"`)
+}
+
+func TestLoad_IgnoresSectionsTheBinaryDoesNotUse(t *testing.T) {
+	env := map[string]string{"BILLING_URL": "not a url", "BILLING_CYCLE_DAYS": "0"}
+
+	_, err := newTestLoader(t, SectionSpanner, env).Load()
+	assert.NoError(t, err)
+}
+
+func TestLoad_InvalidEnvValue(t *testing.T) {
+	_, err := newTestLoader(t, SectionSpanner, map[string]string{"SPANNER_TIMEOUT": "soon"}).Load()
+	assert.ErrorContains(t, err, "invalid SPANNER_TIMEOUT")
+}
+
+func TestLoader_RejectsMalformedFlagWhileParsing(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	NewLoader(fs, SectionRenewal, Default())
+
+	assert.Error(t, fs.Parse([]string{"-billing-cycle-days", "monthly"}))
+}
+
+func TestLoad_BoolFlagWithoutValue(t *testing.T) {
+	cfg, err := newTestLoader(t, all, nil, "-paddle-sandbox").Load()
+	require.NoError(t, err)
+	assert.True(t, cfg.Billing.PaddleSandbox)
+}
+
+func TestLoad_FeatureToggles(t *testing.T) {
+	path := writeFile(t, "features:\n  dunning_emails: true\n  smart_retries: true\n")
+	env := map[string]string{FileEnv: path, "FEATURES": "-smart_retries,new_invoices"}
+
+	cfg, err := newTestLoader(t, 0, env, "-features", "-new_invoices").Load()
+	require.NoError(t, err)
+
+	assert.True(t, cfg.Enabled("dunning_emails"))
+	assert.False(t, cfg.Enabled("smart_retries"))
+	assert.False(t, cfg.Enabled("new_invoices"))
+	assert.False(t, cfg.Enabled("unknown"))
+}
+
+func TestLoad_RefusesFaultInjectionInProduction(t *testing.T) {
+	env := map[string]string{"ENVIRONMENT": "production", "FAULT_RULES": "billing.* error=100%"}
+	_, err := newTestLoader(t, SectionFaults, env).Load()
+	assert.ErrorContains(t, err, "fault injection is not allowed in production")
+
+	_, err = newTestLoader(t, SectionFaults, map[string]string{"ENVIRONMENT": "production"}, "-fault-header").Load()
+	assert.ErrorContains(t, err, "fault injection is not allowed in production")
+
+	env["ENVIRONMENT"] = "staging"
+	cfg, err := newTestLoader(t, SectionFaults, env).Load()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"billing.* error=100%"}, cfg.Faults.Rules)
+}
+
+func TestLoad_SecretManagerRequiresProject(t *testing.T) {
+	_, err := newTestLoader(t, SectionSecrets, nil, "-secrets-backend", "secret-manager").Load()
+	assert.ErrorContains(t, err, "requires a secrets project")
+
+	_, err = newTestLoader(t, SectionSecrets, map[string]string{"SECRETS_BACKEND": "vault"}).Load()
+	assert.ErrorContains(t, err, "must be env or secret-manager")
+
+	cfg, err := newTestLoader(t, SectionSecrets, map[string]string{"SECRETS_BACKEND": "secret-manager", "SECRETS_PROJECT": "prod-secrets"}).Load()
+	require.NoError(t, err)
+	assert.Equal(t, Secrets{Backend: "secret-manager", Project: "prod-secrets", CacheTTL: 5 * time.Minute}, cfg.Secrets)
+}
+
+func TestLoad_EventsTopic(t *testing.T) {
+	cfg, err := newTestLoader(t, SectionEvents, map[string]string{"EVENTS_TOPIC": "projects/prod/topics/subscription-events"}, "-events-timeout", "3s").Load()
+	require.NoError(t, err)
+	assert.Equal(t, Events{Topic: "projects/prod/topics/subscription-events", Timeout: 3 * time.Second}, cfg.Events)
+
+	_, err = newTestLoader(t, SectionEvents, nil, "-events-topic", "subscription-events", "-events-timeout", "0s").Load()
+	assert.ErrorContains(t, err, "must be projects/<project>/topics/<topic>")
+	assert.ErrorContains(t, err, "events timeout must be positive")
+}
+
+func TestLoad_TelemetryExporters(t *testing.T) {
+	cfg, err := newTestLoader(t, SectionTelemetry, map[string]string{"OTEL_EXPORTER_OTLP_ENDPOINT": "http://collector:4318", "OTEL_TRACES_SAMPLER_ARG": "0.25"}).Load()
+	require.NoError(t, err)
+	assert.Equal(t, "otlp", cfg.Telemetry.TracesExporter(), "an OTLP endpoint alone keeps exporting traces over OTLP")
+	assert.Equal(t, 0.25, cfg.Telemetry.SampleRatio)
+
+	cfg, err = newTestLoader(t, SectionTelemetry|SectionMetrics, map[string]string{"TRACES_EXPORTER": "datadog", "DD_AGENT_HOST": "10.0.0.7"}, "-metrics-exporter", "datadog").Load()
+	require.NoError(t, err)
+	assert.Equal(t, "datadog", cfg.Telemetry.TracesExporter())
+	assert.Equal(t, "10.0.0.7", cfg.Telemetry.DatadogAgent)
+	assert.Equal(t, "datadog", cfg.Metrics.Exporter)
+
+	_, err = newTestLoader(t, SectionTelemetry|SectionMetrics, nil, "-traces-exporter", "otlp", "-metrics-exporter", "otlp").Load()
+	assert.ErrorContains(t, err, "otlp traces exporter requires an OTLP endpoint")
+	assert.ErrorContains(t, err, "otlp metrics exporter requires an OTLP endpoint")
+
+	_, err = newTestLoader(t, SectionTelemetry, nil, "-traces-exporter", "jaeger", "-trace-sample-ratio", "2").Load()
+	assert.ErrorContains(t, err, `traces exporter "jaeger" must be none, otlp, datadog or stdout`)
+	assert.ErrorContains(t, err, "trace sample ratio must be between 0 and 1")
+}
@@ -0,0 +1,311 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Section groups settings so a binary only exposes and validates what it uses.
+// Logging and feature toggles belong to every binary.
+type Section uint
+
+const (
+	SectionSpanner          Section = 1 << iota
+	SectionBilling                  // the internal billing API
+	SectionBillingProviders         // choosing and routing to Paddle
+	SectionRenewal                  // billing cycle length and referral credits
+	SectionMetrics
+	SectionDebug     // pprof and expvar on the ops port
+	SectionFaults    // fault injection into repository and billing calls
+	SectionSecrets   // where credentials and signing keys are read from
+	SectionTelemetry // trace exporters and the backends metrics are pushed to
+	SectionHealth    // liveness and readiness probes on the ops port
+	SectionDiscounts // how far discounts stack on a charge
+	SectionEvents    // where subscription events are published
+)
+
+func (s Section) has(other Section) bool { return s&other != 0 }
+
+// FileEnv names the YAML file to load when -config is not given
+const FileEnv = "CONFIG_FILE"
+
+// setting binds one field to its flag and environment variable
+type setting struct {
+	section Section // zero for settings every binary has
+	flag    string
+	env     string
+	usage   string
+	field   func(c *Config) any // pointer to the field
+}
+
+var settings = []setting{
+	{SectionSpanner, "project", "SPANNER_PROJECT", "Spanner project ID", func(c *Config) any { return &c.Spanner.Project }},
+	{SectionSpanner, "instance", "SPANNER_INSTANCE", "Spanner instance ID", func(c *Config) any { return &c.Spanner.Instance }},
+	{SectionSpanner, "database", "SPANNER_DATABASE", "Spanner database ID", func(c *Config) any { return &c.Spanner.Database }},
+	{SectionSpanner, "spanner-timeout", "SPANNER_TIMEOUT", "Timeout for each Spanner operation", func(c *Config) any { return &c.Spanner.Timeout }},
+	{SectionSpanner, "spanner-min-sessions", "SPANNER_MIN_SESSIONS", "Spanner sessions opened at startup", func(c *Config) any { return &c.Spanner.MinSessions }},
+	{SectionSpanner, "spanner-warm-up", "SPANNER_WARM_UP", "How long startup waits for Spanner to answer (0 skips the check)", func(c *Config) any { return &c.Spanner.WarmUp }},
+	{SectionSpanner, "spanner-query-hints", "SPANNER_QUERY_HINTS", "Comma-separated hints for repository list queries, e.g. \"refunds.FindPending index=idx_refunds_status_requested_at optimizer_version=6\"", func(c *Config) any { return &c.Spanner.QueryHints }},
+	{SectionSpanner, "spanner-priority", "SPANNER_PRIORITY", "Request priority: high, medium or low; empty uses the binary's class (high interactive, medium workers, low exports and reports)", func(c *Config) any { return &c.Spanner.Priority }},
+
+	{SectionBillingProviders, "billing-provider", "BILLING_PROVIDER", "Default billing provider: http or paddle", func(c *Config) any { return &c.Billing.Provider }},
+	{SectionBilling, "billing-url", "BILLING_URL", "Billing API base URL (http provider)", func(c *Config) any { return &c.Billing.URL }},
+	{SectionBilling, "billing-timeout", "BILLING_TIMEOUT", "Timeout for each billing call attempt, separate from the pass deadline", func(c *Config) any { return &c.Billing.Timeout }},
+	{SectionBilling, "billing-auth", "BILLING_AUTH", "Billing API auth: none, bearer, api_key or oauth2 (credentials from BILLING_TOKEN, BILLING_API_KEY or BILLING_CLIENT_SECRET)", func(c *Config) any { return &c.Billing.Auth }},
+	{SectionBilling, "billing-api-key-header", "BILLING_API_KEY_HEADER", "Header carrying the billing API key", func(c *Config) any { return &c.Billing.APIKeyHeader }},
+	{SectionBilling, "billing-token-url", "BILLING_TOKEN_URL", "OAuth2 token endpoint for client credentials", func(c *Config) any { return &c.Billing.TokenURL }},
+	{SectionBilling, "billing-client-id", "BILLING_CLIENT_ID", "OAuth2 client ID", func(c *Config) any { return &c.Billing.ClientID }},
+	{SectionBilling, "billing-scopes", "BILLING_SCOPES", "Comma-separated OAuth2 scopes", func(c *Config) any { return &c.Billing.Scopes }},
+	{SectionBillingProviders, "paddle-sandbox", "PADDLE_SANDBOX", "Use the Paddle sandbox environment", func(c *Config) any { return &c.Billing.PaddleSandbox }},
+	{SectionBillingProviders, "paddle-plans", "PADDLE_PLANS", "Comma-separated plan IDs billed through Paddle", func(c *Config) any { return &c.Billing.PaddlePlans }},
+	{SectionBillingProviders, "paddle-customer-prefix", "PADDLE_CUSTOMER_PREFIX", "Customer ID prefix billed through Paddle (e.g. ctm_)", func(c *Config) any { return &c.Billing.PaddleCustomerPrefix }},
+
+	{SectionRenewal, "billing-cycle-days", "BILLING_CYCLE_DAYS", "Billing cycle length in days", func(c *Config) any { return &c.BillingCycleDays }},
+	{SectionRenewal, "referral-referrer-credit", "REFERRAL_REFERRER_CREDIT", "Credit in cents granted to the referrer when a referred subscription's first renewal is paid", func(c *Config) any { return &c.Referrals.ReferrerCredit }},
+	{SectionRenewal, "referral-referee-credit", "REFERRAL_REFEREE_CREDIT", "Credit in cents granted to the referred customer when their first renewal is paid", func(c *Config) any { return &c.Referrals.RefereeCredit }},
+
+	{SectionDiscounts, "discount-max-stacked", "DISCOUNT_MAX_STACKED", "Most discounts applied to one charge; 0 means no limit", func(c *Config) any { return &c.Discounts.MaxStacked }},
+	{SectionDiscounts, "discount-max-percent-off", "DISCOUNT_MAX_PERCENT_OFF", "Basis points of a charge discounts may take off together; 0 means no cap", func(c *Config) any { return &c.Discounts.MaxPercentOff }},
+
+	{SectionMetrics, "metrics-addr", "METRICS_ADDR", "Listen address for the Prometheus /metrics endpoint (e.g. :9090); empty disables it", func(c *Config) any { return &c.Metrics.Addr }},
+	{SectionMetrics, "metrics-exporter", "METRICS_EXPORTER", "Where metrics are pushed: none, otlp, datadog or stdout; independent of -metrics-addr", func(c *Config) any { return &c.Metrics.Exporter }},
+	{SectionMetrics, "metrics-export-interval", "METRICS_EXPORT_INTERVAL", "Time between metric pushes", func(c *Config) any { return &c.Metrics.ExportInterval }},
+
+	{SectionTelemetry, "service-name", "OTEL_SERVICE_NAME", "Service name on exported traces and metrics; defaults to the binary's name", func(c *Config) any { return &c.Telemetry.ServiceName }},
+	{SectionTelemetry, "traces-exporter", "TRACES_EXPORTER", "Where traces are sent: none, otlp, datadog or stdout; empty means otlp when an OTLP endpoint is set, otherwise none", func(c *Config) any { return &c.Telemetry.Traces }},
+	{SectionTelemetry, "trace-sample-ratio", "OTEL_TRACES_SAMPLER_ARG", "Fraction of new traces recorded; a span with a parent follows its parent", func(c *Config) any { return &c.Telemetry.SampleRatio }},
+	{SectionTelemetry, "otlp-endpoint", "OTEL_EXPORTER_OTLP_ENDPOINT", "OpenTelemetry collector base URL (e.g. http://localhost:4318)", func(c *Config) any { return &c.Telemetry.OTLPEndpoint }},
+	{SectionTelemetry, "otlp-headers", "OTEL_EXPORTER_OTLP_HEADERS", "Comma-separated key=value headers sent with each OTLP export", func(c *Config) any { return &c.Telemetry.OTLPHeaders }},
+	{SectionTelemetry, "datadog-agent", "DD_AGENT_HOST", "Datadog agent host; traces go to port 8126 and metrics to DogStatsD on 8125", func(c *Config) any { return &c.Telemetry.DatadogAgent }},
+
+	{SectionDebug, "debug-addr", "DEBUG_ADDR", "Listen address for the pprof and expvar endpoints (e.g. 127.0.0.1:6060); empty disables them. Requires DEBUG_TOKEN", func(c *Config) any { return &c.Debug.Addr }},
+
+	{SectionHealth, "health-addr", "HEALTH_ADDR", "Listen address for the /healthz and /readyz probes (e.g. :8086); empty disables them", func(c *Config) any { return &c.Health.Addr }},
+	{SectionHealth, "health-timeout", "HEALTH_TIMEOUT", "Timeout for each readiness check", func(c *Config) any { return &c.Health.Timeout }},
+
+	{SectionFaults, "fault-rules", "FAULT_RULES", "Comma-separated fault injection rules, e.g. \"billing.* latency=2s error=50%\"; refused in production", func(c *Config) any { return &c.Faults.Rules }},
+	{SectionFaults, "fault-header", "FAULT_HEADER", "Accept fault injection rules in the X-Fault-Inject request header; refused in production", func(c *Config) any { return &c.Faults.Header }},
+
+	{SectionSecrets, "secrets-backend", "SECRETS_BACKEND", "Where secrets are read from: env or secret-manager", func(c *Config) any { return &c.Secrets.Backend }},
+	{SectionSecrets, "secrets-project", "SECRETS_PROJECT", "Google Cloud project holding the secrets (secret-manager backend)", func(c *Config) any { return &c.Secrets.Project }},
+	{SectionSecrets, "secrets-cache-ttl", "SECRETS_CACHE_TTL", "How long secrets from Secret Manager are cached; rotations take effect within it", func(c *Config) any { return &c.Secrets.CacheTTL }},
+
+	{SectionEvents, "events-topic", "EVENTS_TOPIC", "Pub/Sub topic subscription events are published to, as projects/<project>/topics/<topic>; empty publishes none", func(c *Config) any { return &c.Events.Topic }},
+	{SectionEvents, "events-timeout", "EVENTS_TIMEOUT", "Timeout for publishing each event", func(c *Config) any { return &c.Events.Timeout }},
+
+	{0, "environment", "ENVIRONMENT", "Deployment environment, e.g. development, staging or production", func(c *Config) any { return &c.Environment }},
+	{0, "log-level", "LOG_LEVEL", "Log level: debug, info, warn or error", func(c *Config) any { return &c.Log.Level }},
+	{0, "log-format", "LOG_FORMAT", "Log format: json or text", func(c *Config) any { return &c.Log.Format }},
+	{0, "shutdown-timeout", "SHUTDOWN_TIMEOUT", "How long work in flight may finish after SIGTERM before it is cancelled", func(c *Config) any { return &c.ShutdownTimeout }},
+	{0, "features", "FEATURES", "Comma-separated feature toggles: name to enable, -name to disable", func(c *Config) any { return &c.Features }},
+}
+
+// Loader registers the shared flags on a flag set and, once it is parsed, merges
+// every source into a validated Config
+type Loader struct {
+	fs       *flag.FlagSet
+	sections Section
+	defaults Config
+	file     *string
+	flags    map[string]string // raw values of flags set on the command line
+
+	lookupEnv func(string) (string, bool)
+}
+
+// NewLoader registers -config and the flags of the given sections on fs. defaults
+// start from Default; a binary overrides what differs for it before calling NewLoader.
+func NewLoader(fs *flag.FlagSet, sections Section, defaults Config) *Loader {
+	l := &Loader{
+		fs:        fs,
+		sections:  sections,
+		defaults:  defaults,
+		flags:     make(map[string]string),
+		lookupEnv: os.LookupEnv,
+	}
+	l.file = fs.String("config", "", "YAML configuration file (default $"+FileEnv+")")
+	for _, s := range settings {
+		if s.section == 0 || sections.has(s.section) {
+			fs.Var(&flagValue{loader: l, setting: s, def: format(s.field(&l.defaults))}, s.flag, s.usage)
+		}
+	}
+	return l
+}
+
+// Load merges defaults, the YAML file, environment variables and the flags set on
+// the command line, in that order, and validates the result. Call it after fs is parsed.
+func (l *Loader) Load() (Config, error) {
+	cfg := l.defaults
+	cfg.Features = copyFeatures(l.defaults.Features)
+
+	path := *l.file
+	if path == "" {
+		path, _ = l.lookupEnv(FileEnv)
+	}
+	if path != "" {
+		if err := loadFile(path, &cfg); err != nil {
+			return Config{}, err
+		}
+	}
+
+	for _, s := range settings {
+		if s.section != 0 && !l.sections.has(s.section) {
+			continue
+		}
+		if v, ok := l.lookupEnv(s.env); ok {
+			if err := set(s.field(&cfg), v); err != nil {
+				return Config{}, fmt.Errorf("invalid %s: %w", s.env, err)
+			}
+		}
+	}
+
+	var flagErr error
+	l.fs.Visit(func(f *flag.Flag) {
+		if v, ok := f.Value.(*flagValue); ok && v.loader == l && flagErr == nil {
+			flagErr = set(v.setting.field(&cfg), l.flags[f.Name])
+		}
+	})
+	if flagErr != nil {
+		return Config{}, flagErr
+	}
+
+	if err := cfg.Validate(l.sections); err != nil {
+		return Config{}, fmt.Errorf("invalid configuration: %w", err)
+	}
+	return cfg, nil
+}
+
+// loadFile decodes a YAML file over cfg; unknown keys are rejected so typos surface
+func loadFile(path string, cfg *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// flagValue records a shared flag's raw value; Load applies it after the other sources
+type flagValue struct {
+	loader  *Loader
+	setting setting
+	def     string
+}
+
+func (v *flagValue) String() string {
+	if v == nil {
+		return ""
+	}
+	return v.def
+}
+
+func (v *flagValue) Set(s string) error {
+	// Parse into a scratch config so a malformed value fails during flag parsing
+	scratch := Default()
+	if err := set(v.setting.field(&scratch), s); err != nil {
+		return err
+	}
+	v.loader.flags[v.setting.flag] = s
+	return nil
+}
+
+func (v *flagValue) IsBoolFlag() bool {
+	_, ok := v.setting.field(&Config{}).(*bool)
+	return ok
+}
+
+// set parses s into the field ptr points to
+func set(ptr any, s string) error {
+	switch p := ptr.(type) {
+	case *string:
+		*p = s
+	case *bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		*p = b
+	case *int64:
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return err
+		}
+		*p = n
+	case *float64:
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return err
+		}
+		*p = f
+	case *time.Duration:
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		*p = d
+	case *[]string:
+		*p = splitList(s)
+	case *map[string]bool:
+		// Toggles listed here are applied over those from earlier sources
+		features := copyFeatures(*p)
+		for _, name := range splitList(s) {
+			on := !strings.HasPrefix(name, "-")
+			features[strings.TrimPrefix(name, "-")] = on
+		}
+		*p = features
+	default:
+		return fmt.Errorf("unsupported setting type %T", ptr)
+	}
+	return nil
+}
+
+// format renders a field's value as its flag default
+func format(ptr any) string {
+	switch p := ptr.(type) {
+	case *string:
+		return *p
+	case *bool:
+		return strconv.FormatBool(*p)
+	case *int64:
+		return strconv.FormatInt(*p, 10)
+	case *float64:
+		return strconv.FormatFloat(*p, 'g', -1, 64)
+	case *time.Duration:
+		return p.String()
+	case *[]string:
+		return strings.Join(*p, ",")
+	default:
+		return ""
+	}
+}
+
+// splitList splits a comma-separated value, dropping empty entries
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func copyFeatures(features map[string]bool) map[string]bool {
+	out := make(map[string]bool, len(features))
+	for k, v := range features {
+		out[k] = v
+	}
+	return out
+}
@@ -0,0 +1,407 @@
+// Package datagen generates subscription populations shaped like a real customer base:
+// a weighted plan mix, start dates that grow month over month, cancellations at a
+// steady monthly churn, trials, subscriptions in dunning, and metered usage with a
+// heavy tail. It writes through the repository interfaces, so the same population can
+// go to Spanner for a load test or demo, or to the testkit fakes. cmd/datagen is its
+// command line.
+package datagen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"github.com/google/uuid"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+)
+
+// month is the length of a month for rates given per month
+const month = 30 * 24 * time.Hour
+
+// Plan is one plan of the mix. A plan with Included usage is metered, and its
+// subscriptions record usage of the profile's metric.
+type Plan struct {
+	ID         string
+	PriceCents int64
+	Included   int64 // units of usage included per period; zero for an unmetered plan
+	Weight     int
+}
+
+// ParsePlans parses "plan-basic:999=60,plan-pro:2999:10000=30" into plans: an ID, a
+// price in cents, optionally the usage included per period, and a relative weight
+func ParsePlans(s string) ([]Plan, error) {
+	var plans []Plan
+	for _, term := range strings.Split(s, ",") {
+		spec, weight, ok := strings.Cut(strings.TrimSpace(term), "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid plan %q: want id:price[:included]=weight", term)
+		}
+		fields := strings.Split(spec, ":")
+		if len(fields) < 2 || len(fields) > 3 || fields[0] == "" {
+			return nil, fmt.Errorf("invalid plan %q: want id:price[:included]=weight", term)
+		}
+		p := Plan{ID: fields[0]}
+		var err error
+		if p.PriceCents, err = strconv.ParseInt(fields[1], 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid price in plan %q", term)
+		}
+		if len(fields) == 3 {
+			if p.Included, err = strconv.ParseInt(fields[2], 10, 64); err != nil {
+				return nil, fmt.Errorf("invalid included usage in plan %q", term)
+			}
+		}
+		if p.Weight, err = strconv.Atoi(weight); err != nil {
+			return nil, fmt.Errorf("invalid weight in plan %q", term)
+		}
+		plans = append(plans, p)
+	}
+	return plans, nil
+}
+
+// Profile shapes a population. Rates are per 30-day month.
+type Profile struct {
+	Subscriptions    int // how many to generate, cancelled ones included
+	Plans            []Plan
+	Span             time.Duration // how far back the first subscriptions started
+	MonthlyGrowth    float64       // month-over-month growth in new subscriptions
+	MonthlyChurn     float64       // share of subscriptions cancelling each month
+	TrialShare       float64       // share of subscriptions that start with a trial
+	TrialDays        int64
+	PastDueShare     float64 // share of renewed active subscriptions whose last charge failed
+	Metric           string  // the usage metric of metered plans
+	BillingCycleDays int64
+	Seed             int64     // the same seed and profile generate the same population
+	Now              time.Time // the population is as of Now
+}
+
+// DefaultProfile is a small SaaS business two years in: mostly the cheap plan, a few
+// percent churn a month, and usage metered on the upper plans
+func DefaultProfile() Profile {
+	return Profile{
+		Subscriptions: 10000,
+		Plans: []Plan{
+			{ID: "plan-basic", PriceCents: 999, Weight: 60},
+			{ID: "plan-pro", PriceCents: 2999, Included: 10000, Weight: 30},
+			{ID: "plan-team", PriceCents: 9999, Included: 100000, Weight: 10},
+		},
+		Span:             24 * month,
+		MonthlyGrowth:    0.04,
+		MonthlyChurn:     0.035,
+		TrialShare:       0.3,
+		TrialDays:        14,
+		PastDueShare:     0.02,
+		Metric:           "api_calls",
+		BillingCycleDays: 30,
+		Seed:             1,
+	}
+}
+
+// Validate rejects a profile that can't generate a population
+func (p Profile) Validate() error {
+	switch {
+	case p.Subscriptions <= 0:
+		return errors.New("datagen: subscriptions must be positive")
+	case p.Span <= 0:
+		return errors.New("datagen: span must be positive")
+	case p.BillingCycleDays <= 0:
+		return errors.New("datagen: billing cycle must be positive")
+	case p.MonthlyGrowth < 0:
+		return errors.New("datagen: growth can't be negative")
+	case p.MonthlyChurn < 0 || p.MonthlyChurn >= 1:
+		return errors.New("datagen: churn must be at least 0 and below 1")
+	case !isShare(p.TrialShare) || !isShare(p.PastDueShare):
+		return errors.New("datagen: trial and past due shares must be between 0 and 1")
+	case p.TrialShare > 0 && p.TrialDays <= 0:
+		return errors.New("datagen: trials need trial days")
+	}
+	total := 0
+	for _, plan := range p.Plans {
+		if plan.ID == "" || plan.PriceCents <= 0 || plan.Included < 0 || plan.Weight < 0 {
+			return fmt.Errorf("datagen: invalid plan %+v", plan)
+		}
+		if plan.Included > 0 && p.Metric == "" {
+			return fmt.Errorf("datagen: plan %s is metered but there is no metric", plan.ID)
+		}
+		total += plan.Weight
+	}
+	if total == 0 {
+		return errors.New("datagen: the plan mix has no positive weight")
+	}
+	return nil
+}
+
+func isShare(v float64) bool {
+	return v >= 0 && v <= 1
+}
+
+// Target is where a population is written. Usage is recorded when Usage is set; its
+// mutations are applied with the subscriptions', so both must be the same database.
+type Target struct {
+	Subscriptions contracts.SubscriptionRepository
+	Usage         contracts.UsageRepository
+	BatchSize     int // subscriptions per Apply, with their usage
+}
+
+// DefaultBatchSize keeps a batch with a full period of daily usage under Spanner's
+// mutation limit
+const DefaultBatchSize = 200
+
+// Summary counts what was generated
+type Summary struct {
+	Subscriptions int
+	ByStatus      map[domain.SubscriptionStatus]int
+	ByPlan        map[string]int
+	UsageRecords  int
+	// MRRCents is the monthly recurring revenue of the subscriptions still paying,
+	// past due ones included
+	MRRCents int64
+}
+
+// Generate writes the population p describes to target. It stops at the first failed
+// write, having written the batches before it.
+func Generate(ctx context.Context, p Profile, target Target) (*Summary, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	if p.Now.IsZero() {
+		p.Now = time.Now().UTC()
+	}
+	batchSize := target.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+
+	g := &generator{profile: p, rng: rand.New(rand.NewSource(p.Seed))}
+	for _, plan := range p.Plans {
+		g.totalWeight += plan.Weight
+	}
+	summary := &Summary{ByStatus: make(map[domain.SubscriptionStatus]int), ByPlan: make(map[string]int)}
+
+	var (
+		mutations []*spanner.Mutation
+		checks    []contracts.VersionCheck
+		pending   int
+	)
+	flush := func() error {
+		if len(mutations) == 0 {
+			return nil
+		}
+		if err := target.Subscriptions.ApplyChecked(ctx, checks, mutations...); err != nil {
+			return fmt.Errorf("datagen: writing a batch: %w", err)
+		}
+		mutations, checks, pending = mutations[:0], checks[:0], 0
+		return nil
+	}
+
+	for i := 0; i < p.Subscriptions; i++ {
+		if err := ctx.Err(); err != nil {
+			return summary, err
+		}
+		sub, plan := g.subscription()
+		mutation, check, err := target.Subscriptions.Save(ctx, sub)
+		if err != nil {
+			return summary, err
+		}
+		mutations, checks = append(mutations, mutation), append(checks, check)
+
+		// Usage is drawn whether or not it is written, so a seed generates the same
+		// subscriptions either way
+		records := g.usage(sub, plan)
+		if target.Usage != nil {
+			for _, record := range records {
+				mutation, err := target.Usage.Save(ctx, record)
+				if err != nil {
+					return summary, err
+				}
+				mutations = append(mutations, mutation)
+				summary.UsageRecords++
+			}
+		}
+
+		summary.Subscriptions++
+		summary.ByStatus[sub.Status()]++
+		summary.ByPlan[plan.ID]++
+		if sub.Status() == domain.StatusActive || sub.Status() == domain.StatusPastDue {
+			summary.MRRCents += sub.Price() * 30 / p.BillingCycleDays
+		}
+
+		if pending++; pending == batchSize {
+			if err := flush(); err != nil {
+				return summary, err
+			}
+		}
+	}
+	return summary, flush()
+}
+
+// generator draws subscriptions from a profile's distributions
+type generator struct {
+	profile     Profile
+	rng         *rand.Rand
+	totalWeight int
+}
+
+// subscription draws one subscription and the plan it is on
+func (g *generator) subscription() (*domain.Subscription, Plan) {
+	p := g.profile
+	plan := g.plan()
+	id := g.id()
+	customerID := "cust-" + g.id()
+	start := g.startDate()
+	cycle := p.BillingCycleDays
+
+	var opts []domain.ReconstructOption
+	billingStart := start
+	var trialEnd time.Time
+	if g.rng.Float64() < p.TrialShare {
+		trialEnd = start.AddDate(0, 0, int(p.TrialDays))
+		billingStart = trialEnd
+		opts = append(opts, domain.WithTrialEndDate(trialEnd))
+	}
+
+	// Lifetimes are exponential, so every month the same share of those left cancel
+	if cancelledAt := start.Add(g.lifetime()); cancelledAt.Before(p.Now) {
+		opts = append(opts,
+			domain.WithCurrentPeriodStart(periodStartAt(start, billingStart, cancelledAt, cycle)),
+			domain.WithCancelledAt(cancelledAt),
+		)
+		return domain.ReconstructFromPersistence(id, customerID, plan.ID, plan.PriceCents, domain.StatusCancelled, start, opts...), plan
+	}
+
+	if !trialEnd.IsZero() && p.Now.Before(trialEnd) {
+		opts = append(opts, domain.WithCurrentPeriodStart(start))
+		return domain.ReconstructFromPersistence(id, customerID, plan.ID, plan.PriceCents, domain.StatusTrialing, start, opts...), plan
+	}
+
+	periodStart := periodStartAt(start, billingStart, p.Now, cycle)
+	opts = append(opts, domain.WithCurrentPeriodStart(periodStart))
+	// Only a renewal can fail, so a subscription still in its first paid period is active
+	if periodStart.After(billingStart) && g.rng.Float64() < p.PastDueShare {
+		attempts := 1 + g.rng.Int63n(3)
+		nextRetry := p.Now.Add(time.Duration(1+g.rng.Int63n(72)) * time.Hour)
+		opts = append(opts, domain.WithDunning(attempts, nextRetry))
+		return domain.ReconstructFromPersistence(id, customerID, plan.ID, plan.PriceCents, domain.StatusPastDue, start, opts...), plan
+	}
+	return domain.ReconstructFromPersistence(id, customerID, plan.ID, plan.PriceCents, domain.StatusActive, start, opts...), plan
+}
+
+// usage draws a record of daily usage for each day of the current period so far, for
+// an active subscription on a metered plan. Each subscriber's appetite is log-normal,
+// so most use part of their allowance and a few use several times it, and weekends are
+// quieter than weekdays.
+func (g *generator) usage(sub *domain.Subscription, plan Plan) []*domain.UsageRecord {
+	if plan.Included == 0 || sub.Status() != domain.StatusActive {
+		return nil
+	}
+	p := g.profile
+	appetite := math.Exp(math.Log(0.6) + 0.8*g.rng.NormFloat64()) // of Included per period
+	daily := appetite * float64(plan.Included) / float64(p.BillingCycleDays)
+
+	var records []*domain.UsageRecord
+	for day := sub.CurrentPeriodStart(); day.Before(p.Now); day = day.AddDate(0, 0, 1) {
+		quantity := daily * math.Exp(0.3*g.rng.NormFloat64())
+		if wd := day.Weekday(); wd == time.Saturday || wd == time.Sunday {
+			quantity *= 0.4
+		}
+		recordedAt := day.Add(12 * time.Hour)
+		if recordedAt.After(p.Now) {
+			recordedAt = p.Now
+		}
+		if n := int64(math.Round(quantity)); n > 0 {
+			records = append(records, domain.ReconstructUsageRecord(g.id(), sub.ID(), sub.CustomerID(), p.Metric, n, sub.CurrentPeriodStart(), recordedAt))
+		}
+	}
+	return records
+}
+
+// plan draws a plan by weight
+func (g *generator) plan() Plan {
+	n := g.rng.Intn(g.totalWeight)
+	for _, plan := range g.profile.Plans {
+		if n < plan.Weight {
+			return plan
+		}
+		n -= plan.Weight
+	}
+	panic("unreachable")
+}
+
+// startDate draws a start date in the span, with the density of new subscriptions
+// growing by MonthlyGrowth each month, by inverting its cumulative distribution
+func (g *generator) startDate() time.Time {
+	p := g.profile
+	spanMonths := float64(p.Span) / float64(month)
+	u := g.rng.Float64()
+	t := u * spanMonths // months after the span began
+	if rate := math.Log1p(p.MonthlyGrowth); rate > 0 {
+		t = math.Log1p(u*math.Expm1(rate*spanMonths)) / rate
+	}
+	return p.Now.Add(-p.Span).Add(time.Duration(t * float64(month))).Truncate(time.Second)
+}
+
+// lifetime draws how long a subscription lasts before cancelling
+func (g *generator) lifetime() time.Duration {
+	churn := g.profile.MonthlyChurn
+	if churn == 0 {
+		return math.MaxInt64
+	}
+	months := g.rng.ExpFloat64() / -math.Log1p(-churn)
+	if months*float64(month) >= math.MaxInt64 {
+		return math.MaxInt64
+	}
+	return time.Duration(months * float64(month))
+}
+
+// id draws an ID from the seeded source, so a seed regenerates the same IDs
+func (g *generator) id() string {
+	id, err := uuid.NewRandomFromReader(g.rng)
+	if err != nil {
+		panic(err) // reading from a math/rand source can't fail
+	}
+	return id.String()
+}
+
+// periodStartAt returns the start of the billing period that at falls in. Periods
+// follow billingStart; before it, the subscription is in the trial that began at start.
+func periodStartAt(start, billingStart, at time.Time, cycleDays int64) time.Time {
+	if at.Before(billingStart) {
+		return start
+	}
+	periods := int64(at.Sub(billingStart) / (time.Duration(cycleDays) * 24 * time.Hour))
+	return billingStart.AddDate(0, 0, int(periods*cycleDays))
+}
+
+// WriteText writes the summary as aligned text
+func (s *Summary) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "subscriptions\t%d\n", s.Subscriptions)
+	for _, status := range sortedKeys(s.ByStatus) {
+		fmt.Fprintf(tw, "  %s\t%d\n", status, s.ByStatus[status])
+	}
+	for _, plan := range sortedKeys(s.ByPlan) {
+		fmt.Fprintf(tw, "  %s\t%d\n", plan, s.ByPlan[plan])
+	}
+	fmt.Fprintf(tw, "usage records\t%d\n", s.UsageRecords)
+	fmt.Fprintf(tw, "MRR (cents)\t%d\n", s.MRRCents)
+	return tw.Flush()
+}
+
+func sortedKeys[K ~string, V any](m map[K]V) []K {
+	keys := make([]K, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
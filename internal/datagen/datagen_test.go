@@ -0,0 +1,195 @@
+package datagen
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/contracts"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/domain"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/testkit"
+)
+
+var now = time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+
+func profile(n int) Profile {
+	p := DefaultProfile()
+	p.Subscriptions = n
+	p.Now = now
+	return p
+}
+
+// countingSubscriptions counts the batches applied to a fake
+type countingSubscriptions struct {
+	*testkit.FakeSubscriptions
+	applies int
+}
+
+func (c *countingSubscriptions) ApplyChecked(ctx context.Context, checks []contracts.VersionCheck, mutations ...*spanner.Mutation) error {
+	c.applies++
+	return nil
+}
+
+func generate(t *testing.T, p Profile) (*Summary, *testkit.FakeSubscriptions, *testkit.FakeUsage) {
+	t.Helper()
+	subs, usage := testkit.NewFakeSubscriptions(), testkit.NewFakeUsage()
+	summary, err := Generate(context.Background(), p, Target{Subscriptions: subs, Usage: usage})
+	require.NoError(t, err)
+	return summary, subs, usage
+}
+
+func TestParsePlans(t *testing.T) {
+	plans, err := ParsePlans("plan-basic:999=60, plan-pro:2999:10000=40")
+	require.NoError(t, err)
+	assert.Equal(t, []Plan{
+		{ID: "plan-basic", PriceCents: 999, Weight: 60},
+		{ID: "plan-pro", PriceCents: 2999, Included: 10000, Weight: 40},
+	}, plans)
+
+	for _, bad := range []string{"", "plan-basic", "plan-basic=60", ":999=60", "plan-basic:cheap=60", "plan-basic:999:lots=60", "plan-basic:999:1:2=60", "plan-basic:999=most"} {
+		_, err := ParsePlans(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestGenerate_RejectsInvalidProfiles(t *testing.T) {
+	for name, change := range map[string]func(*Profile){
+		"no subscriptions": func(p *Profile) { p.Subscriptions = 0 },
+		"churn of 1":       func(p *Profile) { p.MonthlyChurn = 1 },
+		"trial share":      func(p *Profile) { p.TrialShare = 1.5 },
+		"no plan weight":   func(p *Profile) { p.Plans = []Plan{{ID: "plan-basic", PriceCents: 999}} },
+		"unmetered metric": func(p *Profile) { p.Metric = "" },
+	} {
+		p := profile(10)
+		change(&p)
+		_, err := Generate(context.Background(), p, Target{Subscriptions: testkit.NewFakeSubscriptions()})
+		assert.Error(t, err, name)
+	}
+}
+
+func TestGenerate_SameSeedSamePopulation(t *testing.T) {
+	first, firstSubs, _ := generate(t, profile(500))
+	second, secondSubs, _ := generate(t, profile(500))
+
+	assert.Equal(t, first, second)
+	require.Len(t, secondSubs.All(), 500)
+	for i, sub := range firstSubs.All() {
+		assert.Equal(t, sub.ID(), secondSubs.All()[i].ID())
+		assert.Equal(t, sub.StartDate(), secondSubs.All()[i].StartDate())
+	}
+
+	p := profile(500)
+	p.Seed = 2
+	other, _, _ := generate(t, p)
+	assert.NotEqual(t, first, other)
+}
+
+func TestGenerate_FollowsThePlanMix(t *testing.T) {
+	summary, _, _ := generate(t, profile(5000))
+
+	assert.Equal(t, 5000, summary.Subscriptions)
+	assert.InDelta(t, 0.6, float64(summary.ByPlan["plan-basic"])/5000, 0.03)
+	assert.InDelta(t, 0.3, float64(summary.ByPlan["plan-pro"])/5000, 0.03)
+	assert.InDelta(t, 0.1, float64(summary.ByPlan["plan-team"])/5000, 0.03)
+}
+
+func TestGenerate_GrowthAndChurn(t *testing.T) {
+	p := profile(5000)
+	p.MonthlyGrowth = 0.1
+	_, subs, _ := generate(t, p)
+
+	firstHalf, secondHalf := 0, 0
+	for _, sub := range subs.All() {
+		if sub.StartDate().Before(now.Add(-p.Span / 2)) {
+			firstHalf++
+		} else {
+			secondHalf++
+		}
+	}
+	assert.Greater(t, secondHalf, 2*firstHalf, "a growing business started more subscriptions recently")
+
+	p = profile(2000)
+	p.MonthlyChurn = 0
+	loyal, _, _ := generate(t, p)
+	assert.Zero(t, loyal.ByStatus[domain.StatusCancelled])
+
+	p.MonthlyChurn = 0.2
+	fickle, _, _ := generate(t, p)
+	assert.Greater(t, fickle.ByStatus[domain.StatusCancelled], 1000)
+}
+
+func TestGenerate_EverySubscriptionIsConsistent(t *testing.T) {
+	p := profile(3000)
+	p.PastDueShare = 0.2
+	summary, subs, usage := generate(t, p)
+
+	cycle := time.Duration(p.BillingCycleDays) * 24 * time.Hour
+	for _, sub := range subs.All() {
+		assert.False(t, sub.StartDate().After(now), sub.ID())
+		assert.False(t, sub.CurrentPeriodStart().Before(sub.StartDate()), sub.ID())
+		switch sub.Status() {
+		case domain.StatusCancelled:
+			assert.True(t, sub.CancelledAt().After(sub.StartDate()) && sub.CancelledAt().Before(now), sub.ID())
+			assert.Less(t, sub.CancelledAt().Sub(sub.CurrentPeriodStart()), cycle, sub.ID())
+		case domain.StatusTrialing:
+			assert.True(t, sub.TrialEndDate().After(now), sub.ID())
+		case domain.StatusPastDue:
+			assert.Positive(t, sub.DunningAttempts(), sub.ID())
+			assert.True(t, sub.NextPaymentRetryAt().After(now), sub.ID())
+		case domain.StatusActive:
+			assert.Less(t, now.Sub(sub.CurrentPeriodStart()), cycle, sub.ID())
+		}
+	}
+	for _, status := range []domain.SubscriptionStatus{domain.StatusActive, domain.StatusCancelled, domain.StatusTrialing, domain.StatusPastDue} {
+		assert.Positive(t, summary.ByStatus[status], status)
+	}
+
+	records := usage.Records()
+	assert.Equal(t, summary.UsageRecords, len(records))
+	assert.NotEmpty(t, records)
+	for _, r := range records {
+		sub, err := subs.FindByID(context.Background(), r.SubscriptionID())
+		require.NoError(t, err)
+		assert.Equal(t, domain.StatusActive, sub.Status())
+		assert.NotEqual(t, "plan-basic", sub.PlanID(), "the basic plan isn't metered")
+		assert.Equal(t, "api_calls", r.Metric())
+		assert.Equal(t, sub.CurrentPeriodStart(), r.PeriodStart())
+		assert.False(t, r.RecordedAt().Before(r.PeriodStart()) || r.RecordedAt().After(now))
+	}
+}
+
+func TestGenerate_AppliesInBatches(t *testing.T) {
+	subs := &countingSubscriptions{FakeSubscriptions: testkit.NewFakeSubscriptions()}
+
+	summary, err := Generate(context.Background(), profile(450), Target{Subscriptions: subs, BatchSize: 100})
+
+	require.NoError(t, err)
+	assert.Equal(t, 5, subs.applies)
+	assert.Zero(t, summary.UsageRecords, "usage isn't written without a usage repository")
+	assert.Equal(t, 450, subs.Len())
+}
+
+func TestSummary_WriteText(t *testing.T) {
+	var buf bytes.Buffer
+	summary := &Summary{
+		Subscriptions: 3,
+		ByStatus:      map[domain.SubscriptionStatus]int{domain.StatusActive: 2, domain.StatusCancelled: 1},
+		ByPlan:        map[string]int{"plan-pro": 3},
+		UsageRecords:  12,
+		MRRCents:      5998,
+	}
+
+	require.NoError(t, summary.WriteText(&buf))
+	assert.Equal(t, `subscriptions  3
+  ACTIVE       2
+  CANCELLED    1
+  plan-pro     3
+usage records  12
+MRR (cents)    5998
+`, buf.String())
+}
@@ -0,0 +1,214 @@
+// Package lifecycle runs a binary's servers and workers and shuts them down in order.
+// Shutdown begins on SIGINT or SIGTERM, or when a component stops. The App then stops
+// accepting new work, lets the work in flight finish within a drain deadline, and
+// finally flushes and closes its resources (trace exporters, publishers, Spanner
+// clients) in reverse order of registration.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"runtime/debug"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// DefaultDrainTimeout bounds how long work in flight may run once shutdown begins
+const DefaultDrainTimeout = 30 * time.Second
+
+// closeTimeout bounds each closer, separately from the drain deadline, so a slow
+// drain can't leave buffered spans or messages unflushed
+const closeTimeout = 10 * time.Second
+
+type drainKey struct{}
+
+type closer struct {
+	name  string
+	close func(ctx context.Context) error
+}
+
+// App owns a binary's components and resources from startup to exit
+type App struct {
+	logger       *slog.Logger
+	drainTimeout time.Duration
+
+	ctx     context.Context // cancelled when shutdown begins
+	stop    context.CancelFunc
+	drained context.Context // cancelled when the drain deadline passes
+	abandon context.CancelFunc
+
+	wg      sync.WaitGroup
+	mu      sync.Mutex
+	err     error
+	closers []closer
+}
+
+// New creates an App whose shutdown begins on SIGINT or SIGTERM. A second signal
+// during shutdown terminates the process immediately.
+func New(logger *slog.Logger, drainTimeout time.Duration) *App {
+	if drainTimeout <= 0 {
+		drainTimeout = DefaultDrainTimeout
+	}
+	drained, abandon := context.WithCancel(context.Background())
+	ctx, stop := signal.NotifyContext(context.WithValue(context.Background(), drainKey{}, drained), syscall.SIGINT, syscall.SIGTERM)
+
+	a := &App{
+		logger:       logger,
+		drainTimeout: drainTimeout,
+		ctx:          ctx,
+		stop:         stop,
+		drained:      drained,
+		abandon:      abandon,
+	}
+	context.AfterFunc(ctx, func() {
+		// Restore the default handlers so a second signal kills the process
+		stop()
+		time.AfterFunc(drainTimeout, abandon)
+	})
+	return a
+}
+
+// Context is cancelled when shutdown begins. Use it for setup and pass it to
+// everything that should stop taking new work at that point.
+func (a *App) Context() context.Context {
+	return a.ctx
+}
+
+// Stop begins shutdown
+func (a *App) Stop() {
+	a.stop()
+}
+
+// Go runs a component, such as a worker loop or a one-off pass. Its context is
+// cancelled when shutdown begins, and the component should then return once its
+// work in flight is done. A component that returns, with or without an error,
+// begins shutdown, so a binary exits once its main work is finished. A component
+// that panics fails like one returning an error, and the rest still shut down in order.
+func (a *App) Go(name string, run func(ctx context.Context) error) {
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+		defer a.stop()
+
+		err := a.run(name, run)
+		if err == nil || (a.ctx.Err() != nil && errors.Is(err, context.Canceled)) {
+			return
+		}
+		a.logger.Error("component failed", slog.String("component", name), slog.Any("error", err))
+		a.mu.Lock()
+		if a.err == nil {
+			a.err = fmt.Errorf("%s: %w", name, err)
+		}
+		a.mu.Unlock()
+	}()
+}
+
+func (a *App) run(name string, run func(ctx context.Context) error) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			a.logger.Error("component panicked", slog.String("component", name), slog.Any("panic", v), slog.String("stack", string(debug.Stack())))
+			err = fmt.Errorf("panic: %v", v)
+		}
+	}()
+	return run(a.ctx)
+}
+
+// Serve runs an HTTP server as a component. When shutdown begins the server stops
+// accepting connections and waits for requests in flight until the drain deadline.
+func (a *App) Serve(name string, server *http.Server) {
+	a.Go(name, func(ctx context.Context) error {
+		errc := make(chan error, 1)
+		go func() {
+			a.logger.Info("listening", slog.String("component", name), slog.String("addr", server.Addr))
+			errc <- server.ListenAndServe()
+		}()
+
+		select {
+		case err := <-errc:
+			return err
+		case <-ctx.Done():
+		}
+		if err := server.Shutdown(a.drained); err != nil {
+			server.Close()
+			return err
+		}
+		return nil
+	})
+}
+
+// OnClose registers a resource to flush or release once every component has stopped.
+// Closers run in reverse order of registration, like deferred calls.
+func (a *App) OnClose(name string, close func(ctx context.Context) error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.closers = append(a.closers, closer{name: name, close: close})
+}
+
+// Wait blocks until shutdown begins, waits for the components to drain and runs the
+// closers. It returns the first component failure.
+func (a *App) Wait() error {
+	<-a.ctx.Done()
+	a.logger.Info("shutting down", slog.Duration("drain_timeout", a.drainTimeout))
+
+	done := make(chan struct{})
+	go func() {
+		a.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-a.drained.Done():
+		a.logger.Warn("drain deadline passed, cancelling work in flight")
+		<-done
+	}
+	a.abandon()
+
+	a.mu.Lock()
+	closers := a.closers
+	a.closers = nil
+	a.mu.Unlock()
+	for i := len(closers) - 1; i >= 0; i-- {
+		c := closers[i]
+		ctx, cancel := context.WithTimeout(context.Background(), closeTimeout)
+		if err := c.close(ctx); err != nil {
+			a.logger.Error("failed to close resource", slog.String("resource", c.name), slog.Any("error", err))
+		}
+		cancel()
+	}
+
+	a.logger.Info("shutdown complete")
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.err
+}
+
+// Fatal logs a setup failure, shuts down what has started so far and exits with status 1
+func (a *App) Fatal(msg string, err error) {
+	a.logger.Error(msg, slog.Any("error", err))
+	a.Stop()
+	a.Wait()
+	os.Exit(1)
+}
+
+// Detach returns a context for one unit of work started under ctx, such as a single
+// renewal in a worker pass. It keeps ctx's values but not its cancellation: once
+// shutdown begins the work runs on until it completes or the drain deadline passes.
+// Outside an App, it is cancelled with ctx. Call cancel when the work is done.
+func Detach(ctx context.Context) (context.Context, context.CancelFunc) {
+	drained, ok := ctx.Value(drainKey{}).(context.Context)
+	if !ok {
+		return context.WithCancel(ctx)
+	}
+	work, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	stopAfter := context.AfterFunc(drained, cancel)
+	return work, func() {
+		stopAfter()
+		cancel()
+	}
+}
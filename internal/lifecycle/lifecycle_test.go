@@ -0,0 +1,170 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/logging"
+)
+
+func TestApp_ComponentFailureStopsTheOthers(t *testing.T) {
+	app := New(logging.Discard(), time.Second)
+	failure := errors.New("boom")
+
+	stopped := make(chan struct{})
+	app.Go("worker", func(ctx context.Context) error {
+		<-ctx.Done()
+		close(stopped)
+		return ctx.Err()
+	})
+	app.Go("broken", func(context.Context) error { return failure })
+
+	err := app.Wait()
+	assert.ErrorIs(t, err, failure)
+	assert.ErrorContains(t, err, "broken")
+	<-stopped
+}
+
+func TestApp_FinishedComponentEndsCleanly(t *testing.T) {
+	app := New(logging.Discard(), time.Second)
+	app.Go("pass", func(context.Context) error { return nil })
+
+	assert.NoError(t, app.Wait())
+}
+
+func TestApp_DrainsDetachedWork(t *testing.T) {
+	app := New(logging.Discard(), time.Second)
+
+	started := make(chan struct{})
+	var workErr error
+	app.Go("worker", func(ctx context.Context) error {
+		work, cancel := Detach(ctx)
+		defer cancel()
+		close(started)
+
+		<-ctx.Done()
+		select {
+		case <-work.Done():
+			workErr = work.Err()
+		case <-time.After(20 * time.Millisecond):
+		}
+		return ctx.Err()
+	})
+
+	<-started
+	app.Stop()
+	require.NoError(t, app.Wait())
+	assert.NoError(t, workErr, "work in flight must survive the start of shutdown")
+}
+
+func TestApp_CancelsDetachedWorkAtDrainDeadline(t *testing.T) {
+	app := New(logging.Discard(), 20*time.Millisecond)
+
+	cancelled := make(chan error, 1)
+	app.Go("worker", func(ctx context.Context) error {
+		work, cancel := Detach(ctx)
+		defer cancel()
+		<-work.Done()
+		cancelled <- work.Err()
+		return work.Err()
+	})
+
+	app.Stop()
+	require.NoError(t, app.Wait())
+	assert.ErrorIs(t, <-cancelled, context.Canceled)
+}
+
+func TestApp_ClosesInReverseOrderAfterComponents(t *testing.T) {
+	app := New(logging.Discard(), time.Second)
+
+	var order []string
+	app.OnClose("tracer", func(context.Context) error {
+		order = append(order, "tracer")
+		return nil
+	})
+	app.OnClose("spanner", func(context.Context) error {
+		order = append(order, "spanner")
+		return errors.New("ignored")
+	})
+	app.Go("worker", func(ctx context.Context) error {
+		<-ctx.Done()
+		order = append(order, "worker")
+		return nil
+	})
+
+	app.Stop()
+	require.NoError(t, app.Wait())
+	assert.Equal(t, []string{"worker", "spanner", "tracer"}, order)
+}
+
+func TestApp_ServeFinishesRequestsInFlight(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := listener.Addr().String()
+	listener.Close()
+
+	app := New(logging.Discard(), time.Second)
+	inFlight := make(chan struct{})
+	app.Serve("api", &http.Server{Addr: addr, Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(inFlight)
+		time.Sleep(20 * time.Millisecond)
+		io.WriteString(w, "done")
+	})})
+
+	var resp *http.Response
+	require.Eventually(t, func() bool {
+		conn, err := net.Dial("tcp", addr)
+		if err == nil {
+			conn.Close()
+		}
+		return err == nil
+	}, time.Second, 5*time.Millisecond)
+
+	result := make(chan error, 1)
+	go func() {
+		var err error
+		resp, err = http.Get("http://" + addr)
+		result <- err
+	}()
+
+	<-inFlight
+	app.Stop()
+	require.NoError(t, app.Wait())
+
+	require.NoError(t, <-result)
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, "done", string(body))
+}
+
+func TestDetach_OutsideAppFollowsParent(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	work, done := Detach(ctx)
+	defer done()
+
+	cancel()
+	<-work.Done()
+	assert.ErrorIs(t, work.Err(), context.Canceled)
+}
+
+func TestApp_PanickingComponentShutsDownInOrder(t *testing.T) {
+	app := New(logging.Discard(), time.Second)
+
+	closed := false
+	app.OnClose("spanner", func(context.Context) error {
+		closed = true
+		return nil
+	})
+	app.Go("worker", func(context.Context) error { panic("bad state") })
+
+	assert.ErrorContains(t, app.Wait(), "worker: panic: bad state")
+	assert.True(t, closed)
+}
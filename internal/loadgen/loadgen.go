@@ -0,0 +1,315 @@
+// Package loadgen drives a weighted mix of operations at a fixed concurrency, and
+// optionally a fixed rate, and reports throughput and latency percentiles per
+// operation. cmd/loadgen supplies the operations.
+package loadgen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// ErrSkipped is returned by an operation that had nothing to act on, such as a
+// cancellation with no subscription left to cancel. Skips are counted, not timed.
+var ErrSkipped = errors.New("operation skipped")
+
+// Op is one operation of the mix
+type Op func(ctx context.Context) error
+
+// Weight is one operation's share of the mix
+type Weight struct {
+	Name   string
+	Weight int
+}
+
+// ParseMix parses "create=60,cancel=20,get=20" into weights. Weights are relative,
+// so they need not add up to 100.
+func ParseMix(s string) ([]Weight, error) {
+	var mix []Weight
+	total := 0
+	for _, term := range strings.Split(s, ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(term), "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid mix term %q: want name=weight", term)
+		}
+		w, err := strconv.Atoi(value)
+		if err != nil || w < 0 {
+			return nil, fmt.Errorf("invalid weight in mix term %q", term)
+		}
+		mix = append(mix, Weight{Name: name, Weight: w})
+		total += w
+	}
+	if total == 0 {
+		return nil, fmt.Errorf("mix %q has no positive weight", s)
+	}
+	return mix, nil
+}
+
+// Config shapes a run
+type Config struct {
+	Mix         []Weight
+	Concurrency int           // operations in flight
+	Duration    time.Duration // how long to run
+	Rate        float64       // operations per second across all workers; zero runs flat out
+}
+
+// Stats summarises one operation's results
+type Stats struct {
+	Count      int           `json:"count"`
+	Errors     int           `json:"errors"`
+	Skipped    int           `json:"skipped"`
+	Throughput float64       `json:"throughput_per_sec"`
+	P50        time.Duration `json:"p50"`
+	P90        time.Duration `json:"p90"`
+	P99        time.Duration `json:"p99"`
+	Max        time.Duration `json:"max"`
+}
+
+// ErrorRate is the fraction of timed operations that failed
+func (s Stats) ErrorRate() float64 {
+	if s.Count == 0 {
+		return 0
+	}
+	return float64(s.Errors) / float64(s.Count)
+}
+
+// Report is the outcome of a run. Total covers every operation together.
+type Report struct {
+	Elapsed time.Duration    `json:"elapsed"`
+	Ops     map[string]Stats `json:"ops"`
+	Total   Stats            `json:"total"`
+	Errors  map[string]int   `json:"errors,omitempty"` // first line of each distinct error, with its count
+}
+
+// Run executes the mix until the duration passes or ctx ends. Operations are picked
+// at random by weight, so the mix holds over a run rather than per worker. A
+// failing operation is counted and the run goes on.
+func Run(ctx context.Context, cfg Config, ops map[string]Op) (*Report, error) {
+	var total int
+	for _, w := range cfg.Mix {
+		if _, ok := ops[w.Name]; !ok {
+			return nil, fmt.Errorf("unknown operation %q in mix", w.Name)
+		}
+		total += w.Weight
+	}
+	if cfg.Concurrency < 1 {
+		return nil, fmt.Errorf("concurrency must be at least 1")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, cfg.Duration)
+	defer cancel()
+
+	var tokens <-chan time.Time
+	if cfg.Rate > 0 {
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / cfg.Rate))
+		defer ticker.Stop()
+		tokens = ticker.C
+	}
+
+	results := make([]*recorder, cfg.Concurrency)
+	var wg sync.WaitGroup
+	start := time.Now()
+	for w := range results {
+		rec := newRecorder()
+		results[w] = rec
+		rng := rand.New(rand.NewSource(time.Now().UnixNano() + int64(w)))
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				if tokens != nil {
+					select {
+					case <-ctx.Done():
+						return
+					case <-tokens:
+					}
+				}
+				if ctx.Err() != nil {
+					return
+				}
+
+				name := pick(cfg.Mix, total, rng)
+				began := time.Now()
+				err := ops[name](ctx)
+				// An operation cut short by the end of the run says nothing about latency
+				if ctx.Err() != nil {
+					return
+				}
+				rec.add(name, time.Since(began), err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	return summarise(results, time.Since(start)), nil
+}
+
+func pick(mix []Weight, total int, rng *rand.Rand) string {
+	n := rng.Intn(total)
+	for _, w := range mix {
+		if n < w.Weight {
+			return w.Name
+		}
+		n -= w.Weight
+	}
+	return mix[len(mix)-1].Name
+}
+
+// recorder holds one worker's results, so workers never contend while recording
+type recorder struct {
+	latencies map[string][]time.Duration
+	errors    map[string]int
+	skipped   map[string]int
+	messages  map[string]int
+}
+
+func newRecorder() *recorder {
+	return &recorder{
+		latencies: make(map[string][]time.Duration),
+		errors:    make(map[string]int),
+		skipped:   make(map[string]int),
+		messages:  make(map[string]int),
+	}
+}
+
+func (r *recorder) add(name string, latency time.Duration, err error) {
+	if errors.Is(err, ErrSkipped) {
+		r.skipped[name]++
+		return
+	}
+	r.latencies[name] = append(r.latencies[name], latency)
+	if err != nil {
+		r.errors[name]++
+		msg, _, _ := strings.Cut(err.Error(), "\n")
+		r.messages[name+": "+msg]++
+	}
+}
+
+func summarise(results []*recorder, elapsed time.Duration) *Report {
+	report := &Report{Elapsed: elapsed, Ops: make(map[string]Stats), Errors: make(map[string]int)}
+
+	all := newRecorder()
+	for _, rec := range results {
+		for name, ls := range rec.latencies {
+			all.latencies[name] = append(all.latencies[name], ls...)
+		}
+		for name, n := range rec.errors {
+			all.errors[name] += n
+		}
+		for name, n := range rec.skipped {
+			all.skipped[name] += n
+		}
+		for msg, n := range rec.messages {
+			report.Errors[msg] += n
+		}
+	}
+
+	var (
+		everything      []time.Duration
+		failed, skipped int
+	)
+	names := make(map[string]bool)
+	for name := range all.latencies {
+		names[name] = true
+	}
+	for name := range all.skipped {
+		names[name] = true
+	}
+	for name := range names {
+		ls := all.latencies[name]
+		everything = append(everything, ls...)
+		stats := newStats(ls, elapsed)
+		stats.Errors = all.errors[name]
+		stats.Skipped = all.skipped[name]
+		report.Ops[name] = stats
+		failed += stats.Errors
+		skipped += stats.Skipped
+	}
+	report.Total = newStats(everything, elapsed)
+	report.Total.Errors = failed
+	report.Total.Skipped = skipped
+	return report
+}
+
+// newStats computes nearest-rank percentiles over latencies, which it sorts
+func newStats(latencies []time.Duration, elapsed time.Duration) Stats {
+	stats := Stats{Count: len(latencies)}
+	if len(latencies) == 0 {
+		return stats
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	percentile := func(p float64) time.Duration {
+		rank := int(math.Ceil(p*float64(len(latencies)))) - 1
+		if rank < 0 {
+			rank = 0
+		}
+		return latencies[rank]
+	}
+	stats.P50 = percentile(0.50)
+	stats.P90 = percentile(0.90)
+	stats.P99 = percentile(0.99)
+	stats.Max = latencies[len(latencies)-1]
+	if elapsed > 0 {
+		stats.Throughput = float64(len(latencies)) / elapsed.Seconds()
+	}
+	return stats
+}
+
+// WriteText writes the report as a table, one row per operation and a total
+func (r *Report) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "op\tcount\terrors\tskipped\tops/s\tp50\tp90\tp99\tmax\t")
+	row := func(name string, s Stats) {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%.1f\t%s\t%s\t%s\t%s\t\n", name, s.Count, s.Errors, s.Skipped, s.Throughput,
+			s.P50.Round(time.Microsecond), s.P90.Round(time.Microsecond), s.P99.Round(time.Microsecond), s.Max.Round(time.Microsecond))
+	}
+
+	for _, name := range r.opNames() {
+		row(name, r.Ops[name])
+	}
+	row("total", r.Total)
+	return tw.Flush()
+}
+
+func (r *Report) opNames() []string {
+	names := make([]string, 0, len(r.Ops))
+	for name := range r.Ops {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Thresholds fail a run whose latency or error rate regressed. Zero values are not checked.
+type Thresholds struct {
+	MaxP99       time.Duration
+	MaxErrorRate float64
+}
+
+// Check reports every operation, and the total, that breaches the thresholds
+func (t Thresholds) Check(r *Report) error {
+	var errs []error
+	check := func(name string, s Stats) {
+		if t.MaxP99 > 0 && s.P99 > t.MaxP99 {
+			errs = append(errs, fmt.Errorf("%s p99 %s exceeds %s", name, s.P99, t.MaxP99))
+		}
+		if t.MaxErrorRate > 0 && s.ErrorRate() > t.MaxErrorRate {
+			errs = append(errs, fmt.Errorf("%s error rate %.2f%% exceeds %.2f%%", name, 100*s.ErrorRate(), 100*t.MaxErrorRate))
+		}
+	}
+	for _, name := range r.opNames() {
+		check(name, r.Ops[name])
+	}
+	check("total", r.Total)
+	return errors.Join(errs...)
+}
@@ -0,0 +1,121 @@
+package loadgen
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMix(t *testing.T) {
+	mix, err := ParseMix("create=60, cancel=20,get=20")
+	require.NoError(t, err)
+	assert.Equal(t, []Weight{{"create", 60}, {"cancel", 20}, {"get", 20}}, mix)
+
+	for _, bad := range []string{"", "create", "create=-1", "create=lots", "create=0,get=0"} {
+		_, err := ParseMix(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestRun_FollowsTheMix(t *testing.T) {
+	var creates, gets, cancels atomic.Int64
+	failure := errors.New("spanner unavailable")
+	ops := map[string]Op{
+		"create": func(context.Context) error { creates.Add(1); return nil },
+		"get":    func(context.Context) error { gets.Add(1); return failure },
+		"cancel": func(context.Context) error { cancels.Add(1); return ErrSkipped },
+	}
+	mix, err := ParseMix("create=3,get=1,cancel=0")
+	require.NoError(t, err)
+
+	report, err := Run(context.Background(), Config{Mix: mix, Concurrency: 4, Duration: 50 * time.Millisecond}, ops)
+	require.NoError(t, err)
+
+	assert.Zero(t, cancels.Load())
+	create, get := report.Ops["create"], report.Ops["get"]
+	assert.InDelta(t, 3, float64(create.Count)/float64(get.Count), 0.5)
+	assert.Zero(t, create.Errors)
+	assert.Equal(t, get.Count, get.Errors)
+	assert.Equal(t, create.Count+get.Count, report.Total.Count)
+	assert.Equal(t, get.Errors, report.Total.Errors)
+	assert.Equal(t, get.Errors, report.Errors["get: spanner unavailable"])
+	assert.Positive(t, report.Total.Throughput)
+}
+
+func TestRun_CountsSkips(t *testing.T) {
+	ops := map[string]Op{"cancel": func(context.Context) error { return ErrSkipped }}
+
+	report, err := Run(context.Background(), Config{Mix: []Weight{{"cancel", 1}}, Concurrency: 1, Duration: 10 * time.Millisecond}, ops)
+	require.NoError(t, err)
+
+	assert.Zero(t, report.Ops["cancel"].Count)
+	assert.Positive(t, report.Ops["cancel"].Skipped)
+}
+
+func TestRun_RateLimits(t *testing.T) {
+	var calls atomic.Int64
+	ops := map[string]Op{"get": func(context.Context) error { calls.Add(1); return nil }}
+
+	_, err := Run(context.Background(), Config{Mix: []Weight{{"get", 1}}, Concurrency: 8, Duration: 100 * time.Millisecond, Rate: 100}, ops)
+	require.NoError(t, err)
+
+	assert.LessOrEqual(t, calls.Load(), int64(11))
+}
+
+func TestRun_RejectsUnknownOperation(t *testing.T) {
+	_, err := Run(context.Background(), Config{Mix: []Weight{{"delete", 1}}, Concurrency: 1, Duration: time.Millisecond}, map[string]Op{})
+	assert.ErrorContains(t, err, `unknown operation "delete"`)
+}
+
+func TestNewStats_Percentiles(t *testing.T) {
+	var latencies []time.Duration
+	for i := 100; i >= 1; i-- {
+		latencies = append(latencies, time.Duration(i)*time.Millisecond)
+	}
+
+	stats := newStats(latencies, 2*time.Second)
+
+	assert.Equal(t, 50*time.Millisecond, stats.P50)
+	assert.Equal(t, 90*time.Millisecond, stats.P90)
+	assert.Equal(t, 99*time.Millisecond, stats.P99)
+	assert.Equal(t, 100*time.Millisecond, stats.Max)
+	assert.Equal(t, 50.0, stats.Throughput)
+}
+
+func TestThresholds_Check(t *testing.T) {
+	report := &Report{
+		Ops: map[string]Stats{
+			"create": {Count: 100, Errors: 5, P99: 80 * time.Millisecond},
+			"get":    {Count: 100, P99: 300 * time.Millisecond},
+		},
+		Total: Stats{Count: 200, Errors: 5, P99: 250 * time.Millisecond},
+	}
+
+	err := Thresholds{MaxP99: 200 * time.Millisecond, MaxErrorRate: 0.01}.Check(report)
+	assert.ErrorContains(t, err, "create error rate 5.00% exceeds 1.00%")
+	assert.ErrorContains(t, err, "get p99 300ms exceeds 200ms")
+	assert.ErrorContains(t, err, "total p99")
+
+	assert.NoError(t, Thresholds{}.Check(report))
+}
+
+func TestReport_WriteText(t *testing.T) {
+	report := &Report{
+		Ops:   map[string]Stats{"get": {Count: 10, P50: 2 * time.Millisecond}, "create": {Count: 5}},
+		Total: Stats{Count: 15},
+	}
+
+	var out bytes.Buffer
+	require.NoError(t, report.WriteText(&out))
+
+	text := out.String()
+	assert.Contains(t, text, "p99")
+	assert.Less(t, bytes.Index(out.Bytes(), []byte("create")), bytes.Index(out.Bytes(), []byte("get")))
+	assert.Contains(t, text, "total")
+}
@@ -0,0 +1,112 @@
+// Package telemetry builds the tracer and metrics exporters a binary's configuration
+// selects, so each environment ships telemetry to its own backend (an OpenTelemetry
+// collector, a Datadog agent or stdout) without code changes.
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"strings"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/metrics"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/tracing"
+	"github.com/wuyiadepoju/subscription-management/internal/config"
+	"github.com/wuyiadepoju/subscription-management/internal/lifecycle"
+)
+
+// The Datadog agent's standard ports
+const (
+	datadogTracePort = "8126"
+	dogStatsDPort    = "8125"
+)
+
+// NewTracer builds the tracer cfg.Telemetry selects and registers its flush with app.
+// serviceName is used unless the configuration overrides it.
+func NewTracer(app *lifecycle.App, cfg config.Config, serviceName string, logger *slog.Logger) (*tracing.Tracer, error) {
+	exporter, err := traceExporter(cfg.Telemetry, service(cfg.Telemetry, serviceName))
+	if err != nil {
+		return nil, err
+	}
+	tracer := tracing.NewTracer(tracing.Config{
+		Exporter:    exporter,
+		SampleRatio: cfg.Telemetry.SampleRatio,
+		Logger:      logger,
+	})
+	app.OnClose("tracer", tracer.Shutdown)
+	return tracer, nil
+}
+
+// StartMetrics serves reg at /metrics when cfg.Metrics.Addr is set and pushes it to the
+// exporter cfg.Metrics selects, flushing once more after the components have drained
+func StartMetrics(app *lifecycle.App, cfg config.Config, reg *metrics.Registry, serviceName string, logger *slog.Logger) error {
+	if cfg.Metrics.Addr != "" {
+		app.Go("metrics", func(ctx context.Context) error {
+			return metrics.Serve(ctx, cfg.Metrics.Addr, reg, logger)
+		})
+	}
+
+	exporter, err := metricsExporter(cfg, service(cfg.Telemetry, serviceName))
+	if err != nil || exporter == nil {
+		return err
+	}
+	pusher := metrics.NewPusher(reg, exporter, cfg.Metrics.ExportInterval, logger)
+	app.Go("metrics exporter", pusher.Run)
+	app.OnClose("metrics exporter", pusher.Flush)
+	logger.Info("pushing metrics", slog.String("exporter", cfg.Metrics.Exporter), slog.Duration("interval", cfg.Metrics.ExportInterval))
+	return nil
+}
+
+// traceExporter returns the exporter t selects, or nil to propagate trace context
+// without exporting
+func traceExporter(t config.Telemetry, serviceName string) (tracing.Exporter, error) {
+	switch exporter := t.TracesExporter(); exporter {
+	case "none":
+		return nil, nil
+	case "otlp":
+		return tracing.NewOTLPExporter(nil, t.OTLPEndpoint, serviceName, headers(t.OTLPHeaders)), nil
+	case "datadog":
+		return tracing.NewDatadogExporter(nil, "http://"+net.JoinHostPort(t.DatadogAgent, datadogTracePort), serviceName), nil
+	case "stdout":
+		return tracing.NewStdoutExporter(os.Stdout, serviceName), nil
+	default:
+		return nil, fmt.Errorf("unknown traces exporter %q", exporter)
+	}
+}
+
+// metricsExporter returns the exporter cfg.Metrics selects, or nil when metrics are
+// only scraped
+func metricsExporter(cfg config.Config, serviceName string) (metrics.Exporter, error) {
+	t := cfg.Telemetry
+	switch cfg.Metrics.Exporter {
+	case "", "none":
+		return nil, nil
+	case "otlp":
+		return metrics.NewOTLPExporter(nil, t.OTLPEndpoint, serviceName, headers(t.OTLPHeaders)), nil
+	case "datadog":
+		return metrics.NewDogStatsDExporter(net.JoinHostPort(t.DatadogAgent, dogStatsDPort), serviceName), nil
+	case "stdout":
+		return metrics.NewStdoutExporter(os.Stdout), nil
+	default:
+		return nil, fmt.Errorf("unknown metrics exporter %q", cfg.Metrics.Exporter)
+	}
+}
+
+func service(t config.Telemetry, serviceName string) string {
+	if t.ServiceName != "" {
+		return t.ServiceName
+	}
+	return serviceName
+}
+
+// headers parses key=value pairs; the configuration has already validated them
+func headers(pairs []string) map[string]string {
+	out := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		key, value, _ := strings.Cut(pair, "=")
+		out[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	return out
+}
@@ -0,0 +1,62 @@
+package telemetry
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/metrics"
+	"github.com/wuyiadepoju/subscription-management/internal/app/subscription/tracing"
+	"github.com/wuyiadepoju/subscription-management/internal/config"
+)
+
+func TestTraceExporter_FollowsConfiguration(t *testing.T) {
+	cases := map[string]any{
+		"":        nil,
+		"none":    nil,
+		"otlp":    &tracing.OTLPExporter{},
+		"datadog": &tracing.DatadogExporter{},
+		"stdout":  &tracing.StdoutExporter{},
+	}
+	for name, want := range cases {
+		exporter, err := traceExporter(config.Telemetry{Traces: name, OTLPEndpoint: "http://collector:4318", DatadogAgent: "localhost"}, "renewer")
+		require.NoError(t, err, name)
+		if name == "" {
+			assert.IsType(t, &tracing.OTLPExporter{}, exporter, "an OTLP endpoint alone selects otlp")
+			continue
+		}
+		if want == nil {
+			assert.Nil(t, exporter, name)
+			continue
+		}
+		assert.IsType(t, want, exporter, name)
+	}
+
+	_, err := traceExporter(config.Telemetry{Traces: "jaeger"}, "renewer")
+	assert.ErrorContains(t, err, `unknown traces exporter "jaeger"`)
+}
+
+func TestMetricsExporter_FollowsConfiguration(t *testing.T) {
+	cfg := config.Default()
+	cfg.Telemetry.OTLPEndpoint = "http://collector:4318"
+
+	exporter, err := metricsExporter(cfg, "renewer")
+	require.NoError(t, err)
+	assert.Nil(t, exporter, "metrics are only scraped by default")
+
+	for name, want := range map[string]metrics.Exporter{
+		"otlp":    &metrics.OTLPExporter{},
+		"datadog": &metrics.DogStatsDExporter{},
+		"stdout":  &metrics.StdoutExporter{},
+	} {
+		cfg.Metrics.Exporter = name
+		exporter, err := metricsExporter(cfg, "renewer")
+		require.NoError(t, err, name)
+		assert.IsType(t, want, exporter, name)
+	}
+}
+
+func TestHeaders(t *testing.T) {
+	assert.Equal(t, map[string]string{"Authorization": "Bearer t=1", "X-Team": "billing"}, headers([]string{"Authorization = Bearer t=1", "X-Team=billing"}))
+}
@@ -0,0 +1,4 @@
+-- Track the current billing period so subscriptions can renew
+-- Migration: 002_add_current_period
+
+ALTER TABLE subscriptions ADD COLUMN current_period_start TIMESTAMP;
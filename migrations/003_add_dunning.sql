@@ -0,0 +1,8 @@
+-- Track dunning progress for past-due subscriptions
+-- Migration: 003_add_dunning
+
+ALTER TABLE subscriptions ADD COLUMN dunning_attempts INT64;
+
+ALTER TABLE subscriptions ADD COLUMN next_payment_retry_at TIMESTAMP;
+
+CREATE INDEX idx_status_next_payment_retry_at ON subscriptions(status, next_payment_retry_at);
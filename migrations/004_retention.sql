@@ -0,0 +1,16 @@
+-- Support the data retention policy
+-- Migration: 004_retention
+
+ALTER TABLE subscriptions ADD COLUMN cancelled_at TIMESTAMP;
+
+CREATE INDEX idx_status_cancelled_at ON subscriptions(status, cancelled_at);
+
+CREATE TABLE purge_audit (
+    id STRING(36) NOT NULL,
+    target STRING(100) NOT NULL,
+    action STRING(20) NOT NULL,
+    cutoff TIMESTAMP NOT NULL,
+    rows_affected INT64 NOT NULL,
+    dry_run BOOL NOT NULL,
+    executed_at TIMESTAMP NOT NULL
+) PRIMARY KEY (id);
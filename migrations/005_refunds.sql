@@ -0,0 +1,21 @@
+-- Track refunds through the billing provider's asynchronous settlement
+-- Migration: 005_refunds
+
+CREATE TABLE refunds (
+    id STRING(36) NOT NULL,
+    subscription_id STRING(255) NOT NULL,
+    customer_id STRING(255) NOT NULL,
+    amount_cents INT64 NOT NULL,
+    currency STRING(3) NOT NULL,
+    provider_refund_id STRING(255) NOT NULL,
+    status STRING(50) NOT NULL,
+    failure_reason STRING(MAX),
+    requested_at TIMESTAMP NOT NULL,
+    settled_at TIMESTAMP
+) PRIMARY KEY (id);
+
+CREATE UNIQUE INDEX idx_refunds_provider_refund_id ON refunds(provider_refund_id);
+
+CREATE INDEX idx_refunds_status_requested_at ON refunds(status, requested_at);
+
+CREATE INDEX idx_refunds_customer_id ON refunds(customer_id);
@@ -0,0 +1,4 @@
+-- Record the renewal a subscription's expiring payment method was flagged for
+-- Migration: 006_payment_method_flag
+
+ALTER TABLE subscriptions ADD COLUMN payment_method_flagged_for TIMESTAMP;
@@ -0,0 +1,16 @@
+-- Security audit trail of privileged operations, kept apart from the domain's purge audit
+-- Migration: 007_admin_audit
+
+CREATE TABLE admin_audit (
+    id STRING(36) NOT NULL,
+    action STRING(100) NOT NULL,
+    principal STRING(255) NOT NULL,
+    source_ip STRING(45),
+    payload_hash STRING(64) NOT NULL,
+    outcome STRING(20) NOT NULL,
+    error STRING(MAX),
+    correlation_id STRING(36),
+    occurred_at TIMESTAMP NOT NULL
+) PRIMARY KEY (id);
+
+CREATE INDEX idx_admin_audit_occurred_at ON admin_audit(occurred_at, id);
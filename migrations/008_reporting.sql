@@ -0,0 +1,23 @@
+-- Reporting projection read by the admin aggregates API, refreshed by cmd/reporting
+-- Migration: 008_reporting
+
+CREATE TABLE report_active_by_plan (
+    plan_id STRING(255) NOT NULL,
+    active_count INT64 NOT NULL,
+    refreshed_at TIMESTAMP NOT NULL
+) PRIMARY KEY (plan_id);
+
+CREATE TABLE report_daily_subscriptions (
+    day DATE NOT NULL,
+    new_count INT64 NOT NULL,
+    cancelled_count INT64 NOT NULL,
+    refreshed_at TIMESTAMP NOT NULL
+) PRIMARY KEY (day);
+
+CREATE TABLE report_refund_totals (
+    currency STRING(3) NOT NULL,
+    status STRING(50) NOT NULL,
+    refund_count INT64 NOT NULL,
+    amount_cents INT64 NOT NULL,
+    refreshed_at TIMESTAMP NOT NULL
+) PRIMARY KEY (currency, status);
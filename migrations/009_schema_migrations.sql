@@ -0,0 +1,9 @@
+-- Migrations applied to this database, recorded by cmd/migrate so binaries can check
+-- the schema is as new as their code expects
+-- Migration: 009_schema_migrations
+
+CREATE TABLE schema_migrations (
+    version INT64 NOT NULL,
+    name STRING(255) NOT NULL,
+    applied_at TIMESTAMP NOT NULL OPTIONS (allow_commit_timestamp = true)
+) PRIMARY KEY (version);
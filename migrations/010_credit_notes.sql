@@ -0,0 +1,32 @@
+-- Credit notes against past invoices and the customer credit balances they feed
+-- Migration: 010_credit_notes
+
+CREATE TABLE credit_notes (
+    id STRING(36) NOT NULL,
+    customer_id STRING(255) NOT NULL,
+    subscription_id STRING(255) NOT NULL,
+    invoice_id STRING(255) NOT NULL,
+    amount_cents INT64 NOT NULL,
+    currency STRING(3) NOT NULL,
+    reason STRING(50) NOT NULL,
+    memo STRING(MAX),
+    settlement STRING(50) NOT NULL,
+    provider_refund_id STRING(255),
+    issued_at TIMESTAMP NOT NULL
+) PRIMARY KEY (id);
+
+CREATE INDEX idx_credit_notes_invoice_id ON credit_notes(invoice_id);
+
+CREATE INDEX idx_credit_notes_customer_id ON credit_notes(customer_id);
+
+CREATE TABLE customer_credits (
+    id STRING(36) NOT NULL,
+    customer_id STRING(255) NOT NULL,
+    amount_cents INT64 NOT NULL,
+    currency STRING(3) NOT NULL,
+    source STRING(50) NOT NULL,
+    source_id STRING(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL
+) PRIMARY KEY (id);
+
+CREATE INDEX idx_customer_credits_customer_id ON customer_credits(customer_id);
@@ -0,0 +1,29 @@
+-- Referral codes and the referrals made with them
+-- Migration: 011_referrals
+
+CREATE TABLE referral_codes (
+    code STRING(16) NOT NULL,
+    customer_id STRING(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL
+) PRIMARY KEY (code);
+
+CREATE UNIQUE INDEX idx_referral_codes_customer_id ON referral_codes(customer_id);
+
+-- One row per subscription created with a referral code. customer_id and
+-- subscription_id are the referee's; the credits are recorded when it is rewarded.
+CREATE TABLE referral_credits (
+    id STRING(36) NOT NULL,
+    code STRING(16) NOT NULL,
+    referrer_customer_id STRING(255) NOT NULL,
+    customer_id STRING(255) NOT NULL,
+    subscription_id STRING(255) NOT NULL,
+    status STRING(50) NOT NULL,
+    referrer_credit_cents INT64 NOT NULL,
+    referee_credit_cents INT64 NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    rewarded_at TIMESTAMP
+) PRIMARY KEY (id);
+
+CREATE UNIQUE INDEX idx_referral_credits_subscription_id ON referral_credits(subscription_id);
+
+CREATE INDEX idx_referral_credits_referrer_customer_id ON referral_credits(referrer_customer_id);
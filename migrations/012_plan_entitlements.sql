@@ -0,0 +1,9 @@
+-- Features each plan grants, for entitlement checks
+-- Migration: 012_plan_entitlements
+
+-- limit_value is how many units of the feature the plan grants; NULL is unlimited
+CREATE TABLE plan_entitlements (
+    plan_id STRING(255) NOT NULL,
+    feature STRING(255) NOT NULL,
+    limit_value INT64
+) PRIMARY KEY (plan_id, feature);
@@ -0,0 +1,17 @@
+-- Plans, imported from the billing provider's product catalog by cmd/catalog-sync
+-- Migration: 013_plans
+
+-- external_product_id and external_price_id are NULL for plans maintained by hand
+CREATE TABLE plans (
+    id STRING(255) NOT NULL,
+    name STRING(255) NOT NULL,
+    price_cents INT64 NOT NULL,
+    currency STRING(3) NOT NULL,
+    active BOOL NOT NULL,
+    external_product_id STRING(255),
+    external_price_id STRING(255),
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+) PRIMARY KEY (id);
+
+CREATE UNIQUE NULL_FILTERED INDEX idx_plans_external_product_id ON plans(external_product_id);
@@ -0,0 +1,26 @@
+-- Metered usage and the threshold alerts it has raised
+-- Migration: 014_usage
+
+-- Usage is the sum of the records of a billing period, keyed by the period's start
+CREATE TABLE usage_records (
+    id STRING(36) NOT NULL,
+    subscription_id STRING(255) NOT NULL,
+    customer_id STRING(255) NOT NULL,
+    metric STRING(255) NOT NULL,
+    quantity INT64 NOT NULL,
+    period_start TIMESTAMP NOT NULL,
+    recorded_at TIMESTAMP NOT NULL
+) PRIMARY KEY (id);
+
+CREATE INDEX idx_usage_records_period ON usage_records(subscription_id, metric, period_start) STORING (quantity);
+
+-- One row per alert sent; inserted, never upserted, so each threshold alerts once a period
+CREATE TABLE usage_alerts (
+    subscription_id STRING(255) NOT NULL,
+    metric STRING(255) NOT NULL,
+    period_start TIMESTAMP NOT NULL,
+    threshold_bp INT64 NOT NULL,
+    quantity INT64 NOT NULL,
+    included INT64 NOT NULL,
+    reached_at TIMESTAMP NOT NULL
+) PRIMARY KEY (subscription_id, metric, period_start, threshold_bp);
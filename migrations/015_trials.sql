@@ -0,0 +1,4 @@
+-- Record when a subscription created as a trial stops being free
+-- Migration: 015_trials
+
+ALTER TABLE subscriptions ADD COLUMN trial_end_date TIMESTAMP;
@@ -0,0 +1,22 @@
+-- Track renewal charges held for strong customer authentication (3-D Secure)
+-- Migration: 016_charge_authentications
+
+CREATE TABLE charge_authentications (
+    id STRING(36) NOT NULL,
+    subscription_id STRING(255) NOT NULL,
+    customer_id STRING(255) NOT NULL,
+    provider_payment_id STRING(255) NOT NULL,
+    amount_cents INT64 NOT NULL,
+    credit_applied_cents INT64 NOT NULL,
+    currency STRING(3) NOT NULL,
+    action_url STRING(MAX) NOT NULL,
+    period_start TIMESTAMP NOT NULL,
+    status STRING(50) NOT NULL,
+    failure_reason STRING(MAX),
+    requested_at TIMESTAMP NOT NULL,
+    resolved_at TIMESTAMP
+) PRIMARY KEY (id);
+
+CREATE UNIQUE INDEX idx_charge_authentications_provider_payment_id ON charge_authentications(provider_payment_id);
+
+CREATE INDEX idx_charge_authentications_customer_id ON charge_authentications(customer_id);
@@ -0,0 +1,4 @@
+-- Record the renewal a customer was last told about, so each is noticed once
+-- Migration: 017_renewal_notices
+
+ALTER TABLE subscriptions ADD COLUMN renewal_notice_sent_for TIMESTAMP;
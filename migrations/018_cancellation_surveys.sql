@@ -0,0 +1,25 @@
+-- Store cancellation survey responses, one per cancellation, and their answers
+-- Migration: 018_cancellation_surveys
+
+CREATE TABLE cancellation_survey_responses (
+    id STRING(36) NOT NULL,
+    subscription_id STRING(255) NOT NULL,
+    customer_id STRING(255) NOT NULL,
+    plan_id STRING(255) NOT NULL,
+    survey_version STRING(255) NOT NULL,
+    cancelled_at TIMESTAMP NOT NULL,
+    submitted_at TIMESTAMP NOT NULL
+) PRIMARY KEY (id);
+
+CREATE UNIQUE INDEX idx_cancellation_survey_responses_cancellation ON cancellation_survey_responses(subscription_id, cancelled_at);
+
+CREATE INDEX idx_cancellation_survey_responses_customer_id ON cancellation_survey_responses(customer_id);
+
+CREATE INDEX idx_cancellation_survey_responses_submitted_at ON cancellation_survey_responses(submitted_at);
+
+CREATE TABLE cancellation_survey_answers (
+    response_id STRING(36) NOT NULL,
+    question_id STRING(255) NOT NULL,
+    kind STRING(50) NOT NULL,
+    value STRING(MAX) NOT NULL
+) PRIMARY KEY (response_id, question_id);
@@ -0,0 +1,23 @@
+-- Record retention offers made to customers asking to cancel, and their outcomes
+-- Migration: 019_retention_offers
+
+CREATE TABLE retention_offers (
+    id STRING(36) NOT NULL,
+    subscription_id STRING(255) NOT NULL,
+    customer_id STRING(255) NOT NULL,
+    plan_id STRING(255) NOT NULL,
+    rule STRING(255) NOT NULL,
+    kind STRING(50) NOT NULL,
+    percent_off_bp INT64 NOT NULL,
+    offer_plan_id STRING(255),
+    offer_price_cents INT64 NOT NULL,
+    status STRING(50) NOT NULL,
+    credit_amount_cents INT64 NOT NULL,
+    presented_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    responded_at TIMESTAMP
+) PRIMARY KEY (id);
+
+CREATE INDEX idx_retention_offers_subscription_status ON retention_offers(subscription_id, status, responded_at);
+
+CREATE INDEX idx_retention_offers_customer_id ON retention_offers(customer_id);
@@ -0,0 +1,41 @@
+-- Store subscription templates, and the add-ons and metadata subscriptions are set up with
+-- Migration: 020_subscription_templates
+
+CREATE TABLE subscription_templates (
+    id STRING(36) NOT NULL,
+    name STRING(100) NOT NULL,
+    plan_id STRING(255) NOT NULL,
+    price_cents INT64 NOT NULL,
+    trial_days INT64 NOT NULL,
+    created_at TIMESTAMP NOT NULL
+) PRIMARY KEY (id);
+
+CREATE UNIQUE INDEX idx_subscription_templates_name ON subscription_templates(name);
+
+CREATE TABLE subscription_template_add_ons (
+    template_id STRING(36) NOT NULL,
+    add_on_id STRING(255) NOT NULL,
+    name STRING(255) NOT NULL,
+    quantity INT64 NOT NULL,
+    unit_price_cents INT64 NOT NULL
+) PRIMARY KEY (template_id, add_on_id);
+
+CREATE TABLE subscription_template_metadata (
+    template_id STRING(36) NOT NULL,
+    key STRING(40) NOT NULL,
+    value STRING(MAX) NOT NULL
+) PRIMARY KEY (template_id, key);
+
+CREATE TABLE subscription_add_ons (
+    subscription_id STRING(255) NOT NULL,
+    add_on_id STRING(255) NOT NULL,
+    name STRING(255) NOT NULL,
+    quantity INT64 NOT NULL,
+    unit_price_cents INT64 NOT NULL
+) PRIMARY KEY (subscription_id, add_on_id);
+
+CREATE TABLE subscription_metadata (
+    subscription_id STRING(255) NOT NULL,
+    key STRING(40) NOT NULL,
+    value STRING(MAX) NOT NULL
+) PRIMARY KEY (subscription_id, key);
@@ -0,0 +1,21 @@
+-- Queue refunds owed by bulk cancellations, sent to the billing provider by the refunds worker
+-- Migration: 021_refund_outbox
+
+CREATE TABLE refund_outbox (
+    id STRING(36) NOT NULL,
+    subscription_id STRING(255) NOT NULL,
+    customer_id STRING(255) NOT NULL,
+    plan_id STRING(255) NOT NULL,
+    amount_cents INT64 NOT NULL,
+    currency STRING(3) NOT NULL,
+    idempotency_key STRING(255) NOT NULL,
+    correlation_id STRING(255),
+    attempts INT64 NOT NULL,
+    last_error STRING(MAX),
+    queued_at TIMESTAMP NOT NULL,
+    next_attempt_at TIMESTAMP NOT NULL
+) PRIMARY KEY (id);
+
+CREATE INDEX idx_refund_outbox_next_attempt_at ON refund_outbox(next_attempt_at);
+
+CREATE INDEX idx_refund_outbox_customer_id ON refund_outbox(customer_id);
@@ -0,0 +1,8 @@
+-- Covering index for paginated listings of a customer's subscriptions
+-- Migration: 022_subscription_listing_index
+
+-- Pages are read in (start_date, id) order, which never changes for a row, so a page
+-- token stays valid while subscriptions are created or change status. The index keeps
+-- each customer's rows of one status in that order and stores the listed columns, so a
+-- page is read from the index alone.
+CREATE INDEX idx_subscriptions_customer_status_start ON subscriptions(customer_id, status, start_date) STORING (plan_id, price_cents);
@@ -0,0 +1,6 @@
+-- Record the commit timestamp each reporting refresh read at
+-- Migration: 023_report_read_timestamp
+
+-- Exports pinned to this timestamp agree with the dashboard figures. Rows written
+-- before this migration leave it NULL.
+ALTER TABLE report_daily_subscriptions ADD COLUMN read_at TIMESTAMP;
@@ -0,0 +1,6 @@
+-- Record when a paused subscription was paused
+-- Migration: 024_subscription_pauses
+
+-- Resuming extends the current period by the time paused, and a cancellation while
+-- paused is prorated as of the pause. NULL unless the subscription is PAUSED.
+ALTER TABLE subscriptions ADD COLUMN paused_at TIMESTAMP;
@@ -0,0 +1,6 @@
+-- Version subscriptions for optimistic concurrency control
+-- Migration: 025_subscription_version
+
+-- Every save writes the version it read plus one, and fails if another save got there
+-- first. NULL for rows not saved since, which read as version 0.
+ALTER TABLE subscriptions ADD COLUMN version INT64;
@@ -0,0 +1,12 @@
+-- Remember the subscription each idempotency key created
+-- Migration: 026_idempotency_keys
+
+-- A client retrying create_subscription sends the key of its first attempt and gets
+-- that attempt's subscription back. Keys are the customer's, so two customers can use
+-- the same one.
+CREATE TABLE idempotency_keys (
+    customer_id STRING(255) NOT NULL,
+    idempotency_key STRING(255) NOT NULL,
+    subscription_id STRING(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL
+) PRIMARY KEY (customer_id, idempotency_key);
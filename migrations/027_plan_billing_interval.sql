@@ -0,0 +1,5 @@
+-- Bill plans monthly, yearly, weekly or daily
+-- Migration: 027_plan_billing_interval
+
+-- day, week, month or year. NULL for plans billed every -billing-cycle-days days.
+ALTER TABLE plans ADD COLUMN billing_interval STRING(8);
@@ -0,0 +1,5 @@
+-- Start subscriptions to a plan with its free trial
+-- Migration: 028_plan_trial_days
+
+-- NULL for plans created before trials were set per plan, read as no trial
+ALTER TABLE plans ADD COLUMN trial_days INT64;
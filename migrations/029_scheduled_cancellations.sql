@@ -0,0 +1,7 @@
+-- Record when a subscription set to cancel at the end of its period is cancelled
+-- Migration: 029_scheduled_cancellations
+
+-- NULL unless the subscription is PENDING_CANCELLATION, or was cancelled from it
+ALTER TABLE subscriptions ADD COLUMN cancel_at TIMESTAMP;
+
+CREATE INDEX idx_status_cancel_at ON subscriptions(status, cancel_at);
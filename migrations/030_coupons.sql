@@ -0,0 +1,36 @@
+-- Coupons customers redeem when they subscribe
+-- Migration: 030_coupons
+
+-- percent_off is in basis points; a coupon has it or amount_off_cents, not both.
+-- duration_periods is set for repeating coupons only, max_redemptions is NULL for
+-- unlimited ones and expires_at NULL for those that never expire.
+CREATE TABLE coupons (
+    code STRING(64) NOT NULL,
+    percent_off INT64,
+    amount_off_cents INT64,
+    duration STRING(16) NOT NULL,
+    duration_periods INT64,
+    max_redemptions INT64,
+    expires_at TIMESTAMP,
+    times_redeemed INT64 NOT NULL,
+    created_at TIMESTAMP NOT NULL
+) PRIMARY KEY (code);
+
+-- Each redemption takes the next number of its coupon, so of two subscriptions
+-- redeeming a coupon at once only the first commits
+CREATE TABLE coupon_redemptions (
+    coupon_code STRING(64) NOT NULL,
+    redemption INT64 NOT NULL,
+    subscription_id STRING(255) NOT NULL,
+    redeemed_at TIMESTAMP NOT NULL
+) PRIMARY KEY (coupon_code, redemption);
+
+-- The coupon is copied onto the subscription when it is redeemed. NULL columns are a
+-- subscription without one; coupon_periods_left is 0 for a coupon that never runs out.
+ALTER TABLE subscriptions ADD COLUMN coupon_code STRING(64);
+
+ALTER TABLE subscriptions ADD COLUMN coupon_percent_off INT64;
+
+ALTER TABLE subscriptions ADD COLUMN coupon_amount_off_cents INT64;
+
+ALTER TABLE subscriptions ADD COLUMN coupon_periods_left INT64;
@@ -0,0 +1,4 @@
+-- Find a plan's subscriptions without a full scan, for bulk cancellations retiring it
+-- Migration: 031_subscriptions_plan_index
+
+CREATE INDEX idx_plan_id_status ON subscriptions(plan_id, status);
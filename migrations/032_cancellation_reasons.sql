@@ -0,0 +1,9 @@
+-- Record why subscriptions are cancelled, for churn analysis
+-- Migration: 032_cancellation_reasons
+
+-- NULL for a cancellation without a reason. cancellation_reason is the reason code,
+-- set when the subscription is cancelled or scheduled to be; the details are the
+-- customer's own words, which erasure clears.
+ALTER TABLE subscriptions ADD COLUMN cancellation_reason STRING(64);
+
+ALTER TABLE subscriptions ADD COLUMN cancellation_reason_details STRING(MAX);
@@ -0,0 +1,6 @@
+-- Record why each queued refund is owed, so the refunds worker sends it with its reason
+-- Migration: 033_refund_outbox_reason
+
+-- cancellation or add_on_reversal. NULL for refunds queued before reasons were
+-- recorded, all of them owed by cancellations.
+ALTER TABLE refund_outbox ADD COLUMN reason STRING(32);
@@ -0,0 +1,7 @@
+-- Stop sending queued refunds that have failed every attempt they are allowed
+-- Migration: 034_refund_outbox_status
+
+-- QUEUED, or FAILED once the refunds worker has given up on it and it waits to be
+-- refunded by hand. NULL for refunds queued before statuses were recorded, all of
+-- them QUEUED.
+ALTER TABLE refund_outbox ADD COLUMN status STRING(16);
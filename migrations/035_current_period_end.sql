@@ -0,0 +1,7 @@
+-- Record when a subscription's current period ends, so resuming a paused one can push
+-- the end out without moving the period's start
+-- Migration: 035_current_period_end
+
+-- NULL for rows written before the column existed; their period ends a cycle after
+-- current_period_start
+ALTER TABLE subscriptions ADD COLUMN current_period_end TIMESTAMP;
@@ -0,0 +1,5 @@
+-- Find the subscriptions whose period ends by a time without a full scan, for the
+-- renewer, the payment method check and renewal notices
+-- Migration: 036_subscriptions_period_end_index
+
+CREATE INDEX idx_status_current_period_end ON subscriptions(status, current_period_end);